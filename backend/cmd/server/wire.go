@@ -81,6 +81,7 @@ func provideCleanup(
 	tokenRefresh *service.TokenRefreshService,
 	accountExpiry *service.AccountExpiryService,
 	subscriptionExpiry *service.SubscriptionExpiryService,
+	referralSettlement *service.ReferralSettlementService,
 	usageCleanup *service.UsageCleanupService,
 	idempotencyCleanup *service.IdempotencyCleanupService,
 	pricing *service.PricingService,
@@ -94,7 +95,15 @@ func provideCleanup(
 	antigravityOAuth *service.AntigravityOAuthService,
 	openAIGateway *service.OpenAIGatewayService,
 	scheduledTestRunner *service.ScheduledTestRunnerService,
+	messageBatch *service.MessageBatchService,
+	openAIBatch *service.OpenAIBatchService,
+	payloadCapture *service.PayloadCaptureService,
+	guardrail *service.GuardrailService,
+	proxyPool *service.ProxyPoolService,
+	credentialEncryption *service.CredentialEncryptionService,
 	backupSvc *service.BackupService,
+	notificationSvc *service.NotificationService,
+	metricsServer *server.MetricsServer,
 ) func() {
 	return func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -179,6 +188,10 @@ func provideCleanup(
 				subscriptionExpiry.Stop()
 				return nil
 			}},
+			{"ReferralSettlementService", func() error {
+				referralSettlement.Stop()
+				return nil
+			}},
 			{"SubscriptionService", func() error {
 				if subscriptionService != nil {
 					subscriptionService.Stop()
@@ -231,12 +244,59 @@ func provideCleanup(
 				}
 				return nil
 			}},
+			{"MessageBatchService", func() error {
+				if messageBatch != nil {
+					messageBatch.Stop()
+				}
+				return nil
+			}},
+			{"OpenAIBatchService", func() error {
+				if openAIBatch != nil {
+					openAIBatch.Stop()
+				}
+				return nil
+			}},
+			{"PayloadCaptureService", func() error {
+				if payloadCapture != nil {
+					payloadCapture.Stop()
+				}
+				return nil
+			}},
+			{"GuardrailService", func() error {
+				if guardrail != nil {
+					guardrail.Stop()
+				}
+				return nil
+			}},
+			{"ProxyPoolService", func() error {
+				if proxyPool != nil {
+					proxyPool.Stop()
+				}
+				return nil
+			}},
+			{"CredentialEncryptionService", func() error {
+				if credentialEncryption != nil {
+					credentialEncryption.Stop()
+				}
+				return nil
+			}},
 			{"BackupService", func() error {
 				if backupSvc != nil {
 					backupSvc.Stop()
 				}
 				return nil
 			}},
+			{"NotificationService", func() error {
+				if notificationSvc != nil {
+					notificationSvc.Stop()
+				}
+				return nil
+			}},
+			{"MetricsServer", func() error {
+				shutdownCtx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+				defer cancel()
+				return metricsServer.Shutdown(shutdownCtx)
+			}},
 		}
 
 		infraSteps := []cleanupStep{
@@ -76,6 +76,14 @@ type Group struct {
 	RequirePrivacySet bool `json:"require_privacy_set,omitempty"`
 	// 默认映射模型 ID，当账号级映射找不到时使用此值
 	DefaultMappedModel string `json:"default_mapped_model,omitempty"`
+	// count_tokens / countTokens 是否使用本地分词器估算，不请求上游
+	LocalTokenCounting bool `json:"local_token_counting,omitempty"`
+	// Batch API 请求在费率倍数之上额外叠加的折扣倍率，NULL 表示使用默认值
+	BatchDiscountMultiplier *float64 `json:"batch_discount_multiplier,omitempty"`
+	// 是否对确定性请求（temperature=0）启用精确匹配响应缓存
+	ResponseCacheEnabled bool `json:"response_cache_enabled,omitempty"`
+	// 用户使用余额购买/续订该订阅分组的价格（USD），NULL 表示不开放购买
+	SubscriptionPrice *float64 `json:"subscription_price,omitempty"`
 	// Edges holds the relations/edges for other nodes in the graph.
 	// The values are being populated by the GroupQuery when eager-loading is set.
 	Edges        GroupEdges `json:"edges"`
@@ -184,9 +192,9 @@ func (*Group) scanValues(columns []string) ([]any, error) {
 		switch columns[i] {
 		case group.FieldModelRouting, group.FieldSupportedModelScopes:
 			values[i] = new([]byte)
-		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled, group.FieldMcpXMLInject, group.FieldAllowMessagesDispatch, group.FieldRequireOauthOnly, group.FieldRequirePrivacySet:
+		case group.FieldIsExclusive, group.FieldClaudeCodeOnly, group.FieldModelRoutingEnabled, group.FieldMcpXMLInject, group.FieldAllowMessagesDispatch, group.FieldRequireOauthOnly, group.FieldRequirePrivacySet, group.FieldLocalTokenCounting, group.FieldResponseCacheEnabled:
 			values[i] = new(sql.NullBool)
-		case group.FieldRateMultiplier, group.FieldDailyLimitUsd, group.FieldWeeklyLimitUsd, group.FieldMonthlyLimitUsd, group.FieldImagePrice1k, group.FieldImagePrice2k, group.FieldImagePrice4k:
+		case group.FieldRateMultiplier, group.FieldDailyLimitUsd, group.FieldWeeklyLimitUsd, group.FieldMonthlyLimitUsd, group.FieldImagePrice1k, group.FieldImagePrice2k, group.FieldImagePrice4k, group.FieldBatchDiscountMultiplier, group.FieldSubscriptionPrice:
 			values[i] = new(sql.NullFloat64)
 		case group.FieldID, group.FieldDefaultValidityDays, group.FieldFallbackGroupID, group.FieldFallbackGroupIDOnInvalidRequest, group.FieldSortOrder:
 			values[i] = new(sql.NullInt64)
@@ -403,6 +411,32 @@ func (_m *Group) assignValues(columns []string, values []any) error {
 			} else if value.Valid {
 				_m.DefaultMappedModel = value.String
 			}
+		case group.FieldLocalTokenCounting:
+			if value, ok := values[i].(*sql.NullBool); !ok {
+				return fmt.Errorf("unexpected type %T for field local_token_counting", values[i])
+			} else if value.Valid {
+				_m.LocalTokenCounting = value.Bool
+			}
+		case group.FieldBatchDiscountMultiplier:
+			if value, ok := values[i].(*sql.NullFloat64); !ok {
+				return fmt.Errorf("unexpected type %T for field batch_discount_multiplier", values[i])
+			} else if value.Valid {
+				_m.BatchDiscountMultiplier = new(float64)
+				*_m.BatchDiscountMultiplier = value.Float64
+			}
+		case group.FieldResponseCacheEnabled:
+			if value, ok := values[i].(*sql.NullBool); !ok {
+				return fmt.Errorf("unexpected type %T for field response_cache_enabled", values[i])
+			} else if value.Valid {
+				_m.ResponseCacheEnabled = value.Bool
+			}
+		case group.FieldSubscriptionPrice:
+			if value, ok := values[i].(*sql.NullFloat64); !ok {
+				return fmt.Errorf("unexpected type %T for field subscription_price", values[i])
+			} else if value.Valid {
+				_m.SubscriptionPrice = new(float64)
+				*_m.SubscriptionPrice = value.Float64
+			}
 		default:
 			_m.selectValues.Set(columns[i], values[i])
 		}
@@ -585,6 +619,22 @@ func (_m *Group) String() string {
 	builder.WriteString(", ")
 	builder.WriteString("default_mapped_model=")
 	builder.WriteString(_m.DefaultMappedModel)
+	builder.WriteString(", ")
+	builder.WriteString("local_token_counting=")
+	builder.WriteString(fmt.Sprintf("%v", _m.LocalTokenCounting))
+	builder.WriteString(", ")
+	if v := _m.BatchDiscountMultiplier; v != nil {
+		builder.WriteString("batch_discount_multiplier=")
+		builder.WriteString(fmt.Sprintf("%v", *v))
+	}
+	builder.WriteString(", ")
+	builder.WriteString("response_cache_enabled=")
+	builder.WriteString(fmt.Sprintf("%v", _m.ResponseCacheEnabled))
+	builder.WriteString(", ")
+	if v := _m.SubscriptionPrice; v != nil {
+		builder.WriteString("subscription_price=")
+		builder.WriteString(fmt.Sprintf("%v", *v))
+	}
 	builder.WriteByte(')')
 	return builder.String()
 }
@@ -73,6 +73,14 @@ const (
 	FieldRequirePrivacySet = "require_privacy_set"
 	// FieldDefaultMappedModel holds the string denoting the default_mapped_model field in the database.
 	FieldDefaultMappedModel = "default_mapped_model"
+	// FieldLocalTokenCounting holds the string denoting the local_token_counting field in the database.
+	FieldLocalTokenCounting = "local_token_counting"
+	// FieldBatchDiscountMultiplier holds the string denoting the batch_discount_multiplier field in the database.
+	FieldBatchDiscountMultiplier = "batch_discount_multiplier"
+	// FieldResponseCacheEnabled holds the string denoting the response_cache_enabled field in the database.
+	FieldResponseCacheEnabled = "response_cache_enabled"
+	// FieldSubscriptionPrice holds the string denoting the subscription_price field in the database.
+	FieldSubscriptionPrice = "subscription_price"
 	// EdgeAPIKeys holds the string denoting the api_keys edge name in mutations.
 	EdgeAPIKeys = "api_keys"
 	// EdgeRedeemCodes holds the string denoting the redeem_codes edge name in mutations.
@@ -177,6 +185,10 @@ var Columns = []string{
 	FieldRequireOauthOnly,
 	FieldRequirePrivacySet,
 	FieldDefaultMappedModel,
+	FieldLocalTokenCounting,
+	FieldBatchDiscountMultiplier,
+	FieldResponseCacheEnabled,
+	FieldSubscriptionPrice,
 }
 
 var (
@@ -252,6 +264,10 @@ var (
 	DefaultDefaultMappedModel string
 	// DefaultMappedModelValidator is a validator for the "default_mapped_model" field. It is called by the builders before save.
 	DefaultMappedModelValidator func(string) error
+	// DefaultLocalTokenCounting holds the default value on creation for the "local_token_counting" field.
+	DefaultLocalTokenCounting bool
+	// DefaultResponseCacheEnabled holds the default value on creation for the "response_cache_enabled" field.
+	DefaultResponseCacheEnabled bool
 )
 
 // OrderOption defines the ordering options for the Group queries.
@@ -397,6 +413,26 @@ func ByDefaultMappedModel(opts ...sql.OrderTermOption) OrderOption {
 	return sql.OrderByField(FieldDefaultMappedModel, opts...).ToFunc()
 }
 
+// ByLocalTokenCounting orders the results by the local_token_counting field.
+func ByLocalTokenCounting(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldLocalTokenCounting, opts...).ToFunc()
+}
+
+// ByBatchDiscountMultiplier orders the results by the batch_discount_multiplier field.
+func ByBatchDiscountMultiplier(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldBatchDiscountMultiplier, opts...).ToFunc()
+}
+
+// ByResponseCacheEnabled orders the results by the response_cache_enabled field.
+func ByResponseCacheEnabled(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldResponseCacheEnabled, opts...).ToFunc()
+}
+
+// BySubscriptionPrice orders the results by the subscription_price field.
+func BySubscriptionPrice(opts ...sql.OrderTermOption) OrderOption {
+	return sql.OrderByField(FieldSubscriptionPrice, opts...).ToFunc()
+}
+
 // ByAPIKeysCount orders the results by api_keys count.
 func ByAPIKeysCount(opts ...sql.OrderTermOption) OrderOption {
 	return func(s *sql.Selector) {
@@ -190,6 +190,26 @@ func DefaultMappedModel(v string) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldDefaultMappedModel, v))
 }
 
+// LocalTokenCounting applies equality check predicate on the "local_token_counting" field. It's identical to LocalTokenCountingEQ.
+func LocalTokenCounting(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldLocalTokenCounting, v))
+}
+
+// BatchDiscountMultiplier applies equality check predicate on the "batch_discount_multiplier" field. It's identical to BatchDiscountMultiplierEQ.
+func BatchDiscountMultiplier(v float64) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldBatchDiscountMultiplier, v))
+}
+
+// ResponseCacheEnabled applies equality check predicate on the "response_cache_enabled" field. It's identical to ResponseCacheEnabledEQ.
+func ResponseCacheEnabled(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldResponseCacheEnabled, v))
+}
+
+// SubscriptionPrice applies equality check predicate on the "subscription_price" field. It's identical to SubscriptionPriceEQ.
+func SubscriptionPrice(v float64) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldSubscriptionPrice, v))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.Group {
 	return predicate.Group(sql.FieldEQ(FieldCreatedAt, v))
@@ -1320,6 +1340,126 @@ func DefaultMappedModelContainsFold(v string) predicate.Group {
 	return predicate.Group(sql.FieldContainsFold(FieldDefaultMappedModel, v))
 }
 
+// LocalTokenCountingEQ applies the EQ predicate on the "local_token_counting" field.
+func LocalTokenCountingEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldLocalTokenCounting, v))
+}
+
+// LocalTokenCountingNEQ applies the NEQ predicate on the "local_token_counting" field.
+func LocalTokenCountingNEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldLocalTokenCounting, v))
+}
+
+// BatchDiscountMultiplierEQ applies the EQ predicate on the "batch_discount_multiplier" field.
+func BatchDiscountMultiplierEQ(v float64) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldBatchDiscountMultiplier, v))
+}
+
+// BatchDiscountMultiplierNEQ applies the NEQ predicate on the "batch_discount_multiplier" field.
+func BatchDiscountMultiplierNEQ(v float64) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldBatchDiscountMultiplier, v))
+}
+
+// BatchDiscountMultiplierIn applies the In predicate on the "batch_discount_multiplier" field.
+func BatchDiscountMultiplierIn(vs ...float64) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldBatchDiscountMultiplier, vs...))
+}
+
+// BatchDiscountMultiplierNotIn applies the NotIn predicate on the "batch_discount_multiplier" field.
+func BatchDiscountMultiplierNotIn(vs ...float64) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldBatchDiscountMultiplier, vs...))
+}
+
+// BatchDiscountMultiplierGT applies the GT predicate on the "batch_discount_multiplier" field.
+func BatchDiscountMultiplierGT(v float64) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldBatchDiscountMultiplier, v))
+}
+
+// BatchDiscountMultiplierGTE applies the GTE predicate on the "batch_discount_multiplier" field.
+func BatchDiscountMultiplierGTE(v float64) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldBatchDiscountMultiplier, v))
+}
+
+// BatchDiscountMultiplierLT applies the LT predicate on the "batch_discount_multiplier" field.
+func BatchDiscountMultiplierLT(v float64) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldBatchDiscountMultiplier, v))
+}
+
+// BatchDiscountMultiplierLTE applies the LTE predicate on the "batch_discount_multiplier" field.
+func BatchDiscountMultiplierLTE(v float64) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldBatchDiscountMultiplier, v))
+}
+
+// BatchDiscountMultiplierIsNil applies the IsNil predicate on the "batch_discount_multiplier" field.
+func BatchDiscountMultiplierIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldBatchDiscountMultiplier))
+}
+
+// BatchDiscountMultiplierNotNil applies the NotNil predicate on the "batch_discount_multiplier" field.
+func BatchDiscountMultiplierNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldBatchDiscountMultiplier))
+}
+
+// ResponseCacheEnabledEQ applies the EQ predicate on the "response_cache_enabled" field.
+func ResponseCacheEnabledEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldResponseCacheEnabled, v))
+}
+
+// ResponseCacheEnabledNEQ applies the NEQ predicate on the "response_cache_enabled" field.
+func ResponseCacheEnabledNEQ(v bool) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldResponseCacheEnabled, v))
+}
+
+// SubscriptionPriceEQ applies the EQ predicate on the "subscription_price" field.
+func SubscriptionPriceEQ(v float64) predicate.Group {
+	return predicate.Group(sql.FieldEQ(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceNEQ applies the NEQ predicate on the "subscription_price" field.
+func SubscriptionPriceNEQ(v float64) predicate.Group {
+	return predicate.Group(sql.FieldNEQ(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceIn applies the In predicate on the "subscription_price" field.
+func SubscriptionPriceIn(vs ...float64) predicate.Group {
+	return predicate.Group(sql.FieldIn(FieldSubscriptionPrice, vs...))
+}
+
+// SubscriptionPriceNotIn applies the NotIn predicate on the "subscription_price" field.
+func SubscriptionPriceNotIn(vs ...float64) predicate.Group {
+	return predicate.Group(sql.FieldNotIn(FieldSubscriptionPrice, vs...))
+}
+
+// SubscriptionPriceGT applies the GT predicate on the "subscription_price" field.
+func SubscriptionPriceGT(v float64) predicate.Group {
+	return predicate.Group(sql.FieldGT(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceGTE applies the GTE predicate on the "subscription_price" field.
+func SubscriptionPriceGTE(v float64) predicate.Group {
+	return predicate.Group(sql.FieldGTE(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceLT applies the LT predicate on the "subscription_price" field.
+func SubscriptionPriceLT(v float64) predicate.Group {
+	return predicate.Group(sql.FieldLT(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceLTE applies the LTE predicate on the "subscription_price" field.
+func SubscriptionPriceLTE(v float64) predicate.Group {
+	return predicate.Group(sql.FieldLTE(FieldSubscriptionPrice, v))
+}
+
+// SubscriptionPriceIsNil applies the IsNil predicate on the "subscription_price" field.
+func SubscriptionPriceIsNil() predicate.Group {
+	return predicate.Group(sql.FieldIsNull(FieldSubscriptionPrice))
+}
+
+// SubscriptionPriceNotNil applies the NotNil predicate on the "subscription_price" field.
+func SubscriptionPriceNotNil() predicate.Group {
+	return predicate.Group(sql.FieldNotNull(FieldSubscriptionPrice))
+}
+
 // HasAPIKeys applies the HasEdge predicate on the "api_keys" edge.
 func HasAPIKeys() predicate.Group {
 	return predicate.Group(func(s *sql.Selector) {
@@ -410,6 +410,62 @@ func (_c *GroupCreate) SetNillableDefaultMappedModel(v *string) *GroupCreate {
 	return _c
 }
 
+// SetLocalTokenCounting sets the "local_token_counting" field.
+func (_c *GroupCreate) SetLocalTokenCounting(v bool) *GroupCreate {
+	_c.mutation.SetLocalTokenCounting(v)
+	return _c
+}
+
+// SetNillableLocalTokenCounting sets the "local_token_counting" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableLocalTokenCounting(v *bool) *GroupCreate {
+	if v != nil {
+		_c.SetLocalTokenCounting(*v)
+	}
+	return _c
+}
+
+// SetBatchDiscountMultiplier sets the "batch_discount_multiplier" field.
+func (_c *GroupCreate) SetBatchDiscountMultiplier(v float64) *GroupCreate {
+	_c.mutation.SetBatchDiscountMultiplier(v)
+	return _c
+}
+
+// SetNillableBatchDiscountMultiplier sets the "batch_discount_multiplier" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableBatchDiscountMultiplier(v *float64) *GroupCreate {
+	if v != nil {
+		_c.SetBatchDiscountMultiplier(*v)
+	}
+	return _c
+}
+
+// SetResponseCacheEnabled sets the "response_cache_enabled" field.
+func (_c *GroupCreate) SetResponseCacheEnabled(v bool) *GroupCreate {
+	_c.mutation.SetResponseCacheEnabled(v)
+	return _c
+}
+
+// SetNillableResponseCacheEnabled sets the "response_cache_enabled" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableResponseCacheEnabled(v *bool) *GroupCreate {
+	if v != nil {
+		_c.SetResponseCacheEnabled(*v)
+	}
+	return _c
+}
+
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (_c *GroupCreate) SetSubscriptionPrice(v float64) *GroupCreate {
+	_c.mutation.SetSubscriptionPrice(v)
+	return _c
+}
+
+// SetNillableSubscriptionPrice sets the "subscription_price" field if the given value is not nil.
+func (_c *GroupCreate) SetNillableSubscriptionPrice(v *float64) *GroupCreate {
+	if v != nil {
+		_c.SetSubscriptionPrice(*v)
+	}
+	return _c
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_c *GroupCreate) AddAPIKeyIDs(ids ...int64) *GroupCreate {
 	_c.mutation.AddAPIKeyIDs(ids...)
@@ -611,6 +667,14 @@ func (_c *GroupCreate) defaults() error {
 		v := group.DefaultDefaultMappedModel
 		_c.mutation.SetDefaultMappedModel(v)
 	}
+	if _, ok := _c.mutation.LocalTokenCounting(); !ok {
+		v := group.DefaultLocalTokenCounting
+		_c.mutation.SetLocalTokenCounting(v)
+	}
+	if _, ok := _c.mutation.ResponseCacheEnabled(); !ok {
+		v := group.DefaultResponseCacheEnabled
+		_c.mutation.SetResponseCacheEnabled(v)
+	}
 	return nil
 }
 
@@ -695,6 +759,12 @@ func (_c *GroupCreate) check() error {
 			return &ValidationError{Name: "default_mapped_model", err: fmt.Errorf(`ent: validator failed for field "Group.default_mapped_model": %w`, err)}
 		}
 	}
+	if _, ok := _c.mutation.LocalTokenCounting(); !ok {
+		return &ValidationError{Name: "local_token_counting", err: errors.New(`ent: missing required field "Group.local_token_counting"`)}
+	}
+	if _, ok := _c.mutation.ResponseCacheEnabled(); !ok {
+		return &ValidationError{Name: "response_cache_enabled", err: errors.New(`ent: missing required field "Group.response_cache_enabled"`)}
+	}
 	return nil
 }
 
@@ -838,6 +908,22 @@ func (_c *GroupCreate) createSpec() (*Group, *sqlgraph.CreateSpec) {
 		_spec.SetField(group.FieldDefaultMappedModel, field.TypeString, value)
 		_node.DefaultMappedModel = value
 	}
+	if value, ok := _c.mutation.LocalTokenCounting(); ok {
+		_spec.SetField(group.FieldLocalTokenCounting, field.TypeBool, value)
+		_node.LocalTokenCounting = value
+	}
+	if value, ok := _c.mutation.BatchDiscountMultiplier(); ok {
+		_spec.SetField(group.FieldBatchDiscountMultiplier, field.TypeFloat64, value)
+		_node.BatchDiscountMultiplier = &value
+	}
+	if value, ok := _c.mutation.ResponseCacheEnabled(); ok {
+		_spec.SetField(group.FieldResponseCacheEnabled, field.TypeBool, value)
+		_node.ResponseCacheEnabled = value
+	}
+	if value, ok := _c.mutation.SubscriptionPrice(); ok {
+		_spec.SetField(group.FieldSubscriptionPrice, field.TypeFloat64, value)
+		_node.SubscriptionPrice = &value
+	}
 	if nodes := _c.mutation.APIKeysIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1462,6 +1548,78 @@ func (u *GroupUpsert) UpdateDefaultMappedModel() *GroupUpsert {
 	return u
 }
 
+// SetLocalTokenCounting sets the "local_token_counting" field.
+func (u *GroupUpsert) SetLocalTokenCounting(v bool) *GroupUpsert {
+	u.Set(group.FieldLocalTokenCounting, v)
+	return u
+}
+
+// UpdateLocalTokenCounting sets the "local_token_counting" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateLocalTokenCounting() *GroupUpsert {
+	u.SetExcluded(group.FieldLocalTokenCounting)
+	return u
+}
+
+// SetBatchDiscountMultiplier sets the "batch_discount_multiplier" field.
+func (u *GroupUpsert) SetBatchDiscountMultiplier(v float64) *GroupUpsert {
+	u.Set(group.FieldBatchDiscountMultiplier, v)
+	return u
+}
+
+// UpdateBatchDiscountMultiplier sets the "batch_discount_multiplier" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateBatchDiscountMultiplier() *GroupUpsert {
+	u.SetExcluded(group.FieldBatchDiscountMultiplier)
+	return u
+}
+
+// AddBatchDiscountMultiplier adds v to the "batch_discount_multiplier" field.
+func (u *GroupUpsert) AddBatchDiscountMultiplier(v float64) *GroupUpsert {
+	u.Add(group.FieldBatchDiscountMultiplier, v)
+	return u
+}
+
+// ClearBatchDiscountMultiplier clears the value of the "batch_discount_multiplier" field.
+func (u *GroupUpsert) ClearBatchDiscountMultiplier() *GroupUpsert {
+	u.SetNull(group.FieldBatchDiscountMultiplier)
+	return u
+}
+
+// SetResponseCacheEnabled sets the "response_cache_enabled" field.
+func (u *GroupUpsert) SetResponseCacheEnabled(v bool) *GroupUpsert {
+	u.Set(group.FieldResponseCacheEnabled, v)
+	return u
+}
+
+// UpdateResponseCacheEnabled sets the "response_cache_enabled" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateResponseCacheEnabled() *GroupUpsert {
+	u.SetExcluded(group.FieldResponseCacheEnabled)
+	return u
+}
+
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (u *GroupUpsert) SetSubscriptionPrice(v float64) *GroupUpsert {
+	u.Set(group.FieldSubscriptionPrice, v)
+	return u
+}
+
+// UpdateSubscriptionPrice sets the "subscription_price" field to the value that was provided on create.
+func (u *GroupUpsert) UpdateSubscriptionPrice() *GroupUpsert {
+	u.SetExcluded(group.FieldSubscriptionPrice)
+	return u
+}
+
+// AddSubscriptionPrice adds v to the "subscription_price" field.
+func (u *GroupUpsert) AddSubscriptionPrice(v float64) *GroupUpsert {
+	u.Add(group.FieldSubscriptionPrice, v)
+	return u
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (u *GroupUpsert) ClearSubscriptionPrice() *GroupUpsert {
+	u.SetNull(group.FieldSubscriptionPrice)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -2053,6 +2211,90 @@ func (u *GroupUpsertOne) UpdateDefaultMappedModel() *GroupUpsertOne {
 	})
 }
 
+// SetLocalTokenCounting sets the "local_token_counting" field.
+func (u *GroupUpsertOne) SetLocalTokenCounting(v bool) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetLocalTokenCounting(v)
+	})
+}
+
+// UpdateLocalTokenCounting sets the "local_token_counting" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateLocalTokenCounting() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateLocalTokenCounting()
+	})
+}
+
+// SetBatchDiscountMultiplier sets the "batch_discount_multiplier" field.
+func (u *GroupUpsertOne) SetBatchDiscountMultiplier(v float64) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetBatchDiscountMultiplier(v)
+	})
+}
+
+// AddBatchDiscountMultiplier adds v to the "batch_discount_multiplier" field.
+func (u *GroupUpsertOne) AddBatchDiscountMultiplier(v float64) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddBatchDiscountMultiplier(v)
+	})
+}
+
+// UpdateBatchDiscountMultiplier sets the "batch_discount_multiplier" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateBatchDiscountMultiplier() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateBatchDiscountMultiplier()
+	})
+}
+
+// ClearBatchDiscountMultiplier clears the value of the "batch_discount_multiplier" field.
+func (u *GroupUpsertOne) ClearBatchDiscountMultiplier() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearBatchDiscountMultiplier()
+	})
+}
+
+// SetResponseCacheEnabled sets the "response_cache_enabled" field.
+func (u *GroupUpsertOne) SetResponseCacheEnabled(v bool) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetResponseCacheEnabled(v)
+	})
+}
+
+// UpdateResponseCacheEnabled sets the "response_cache_enabled" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateResponseCacheEnabled() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateResponseCacheEnabled()
+	})
+}
+
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (u *GroupUpsertOne) SetSubscriptionPrice(v float64) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetSubscriptionPrice(v)
+	})
+}
+
+// AddSubscriptionPrice adds v to the "subscription_price" field.
+func (u *GroupUpsertOne) AddSubscriptionPrice(v float64) *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddSubscriptionPrice(v)
+	})
+}
+
+// UpdateSubscriptionPrice sets the "subscription_price" field to the value that was provided on create.
+func (u *GroupUpsertOne) UpdateSubscriptionPrice() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateSubscriptionPrice()
+	})
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (u *GroupUpsertOne) ClearSubscriptionPrice() *GroupUpsertOne {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearSubscriptionPrice()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -2810,6 +3052,90 @@ func (u *GroupUpsertBulk) UpdateDefaultMappedModel() *GroupUpsertBulk {
 	})
 }
 
+// SetLocalTokenCounting sets the "local_token_counting" field.
+func (u *GroupUpsertBulk) SetLocalTokenCounting(v bool) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetLocalTokenCounting(v)
+	})
+}
+
+// UpdateLocalTokenCounting sets the "local_token_counting" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateLocalTokenCounting() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateLocalTokenCounting()
+	})
+}
+
+// SetBatchDiscountMultiplier sets the "batch_discount_multiplier" field.
+func (u *GroupUpsertBulk) SetBatchDiscountMultiplier(v float64) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetBatchDiscountMultiplier(v)
+	})
+}
+
+// AddBatchDiscountMultiplier adds v to the "batch_discount_multiplier" field.
+func (u *GroupUpsertBulk) AddBatchDiscountMultiplier(v float64) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddBatchDiscountMultiplier(v)
+	})
+}
+
+// UpdateBatchDiscountMultiplier sets the "batch_discount_multiplier" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateBatchDiscountMultiplier() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateBatchDiscountMultiplier()
+	})
+}
+
+// ClearBatchDiscountMultiplier clears the value of the "batch_discount_multiplier" field.
+func (u *GroupUpsertBulk) ClearBatchDiscountMultiplier() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearBatchDiscountMultiplier()
+	})
+}
+
+// SetResponseCacheEnabled sets the "response_cache_enabled" field.
+func (u *GroupUpsertBulk) SetResponseCacheEnabled(v bool) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetResponseCacheEnabled(v)
+	})
+}
+
+// UpdateResponseCacheEnabled sets the "response_cache_enabled" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateResponseCacheEnabled() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateResponseCacheEnabled()
+	})
+}
+
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (u *GroupUpsertBulk) SetSubscriptionPrice(v float64) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.SetSubscriptionPrice(v)
+	})
+}
+
+// AddSubscriptionPrice adds v to the "subscription_price" field.
+func (u *GroupUpsertBulk) AddSubscriptionPrice(v float64) *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.AddSubscriptionPrice(v)
+	})
+}
+
+// UpdateSubscriptionPrice sets the "subscription_price" field to the value that was provided on create.
+func (u *GroupUpsertBulk) UpdateSubscriptionPrice() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.UpdateSubscriptionPrice()
+	})
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (u *GroupUpsertBulk) ClearSubscriptionPrice() *GroupUpsertBulk {
+	return u.Update(func(s *GroupUpsert) {
+		s.ClearSubscriptionPrice()
+	})
+}
+
 // Exec executes the query.
 func (u *GroupUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -552,6 +552,88 @@ func (_u *GroupUpdate) SetNillableDefaultMappedModel(v *string) *GroupUpdate {
 	return _u
 }
 
+// SetLocalTokenCounting sets the "local_token_counting" field.
+func (_u *GroupUpdate) SetLocalTokenCounting(v bool) *GroupUpdate {
+	_u.mutation.SetLocalTokenCounting(v)
+	return _u
+}
+
+// SetNillableLocalTokenCounting sets the "local_token_counting" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableLocalTokenCounting(v *bool) *GroupUpdate {
+	if v != nil {
+		_u.SetLocalTokenCounting(*v)
+	}
+	return _u
+}
+
+// SetBatchDiscountMultiplier sets the "batch_discount_multiplier" field.
+func (_u *GroupUpdate) SetBatchDiscountMultiplier(v float64) *GroupUpdate {
+	_u.mutation.ResetBatchDiscountMultiplier()
+	_u.mutation.SetBatchDiscountMultiplier(v)
+	return _u
+}
+
+// SetNillableBatchDiscountMultiplier sets the "batch_discount_multiplier" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableBatchDiscountMultiplier(v *float64) *GroupUpdate {
+	if v != nil {
+		_u.SetBatchDiscountMultiplier(*v)
+	}
+	return _u
+}
+
+// AddBatchDiscountMultiplier adds value to the "batch_discount_multiplier" field.
+func (_u *GroupUpdate) AddBatchDiscountMultiplier(v float64) *GroupUpdate {
+	_u.mutation.AddBatchDiscountMultiplier(v)
+	return _u
+}
+
+// ClearBatchDiscountMultiplier clears the value of the "batch_discount_multiplier" field.
+func (_u *GroupUpdate) ClearBatchDiscountMultiplier() *GroupUpdate {
+	_u.mutation.ClearBatchDiscountMultiplier()
+	return _u
+}
+
+// SetResponseCacheEnabled sets the "response_cache_enabled" field.
+func (_u *GroupUpdate) SetResponseCacheEnabled(v bool) *GroupUpdate {
+	_u.mutation.SetResponseCacheEnabled(v)
+	return _u
+}
+
+// SetNillableResponseCacheEnabled sets the "response_cache_enabled" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableResponseCacheEnabled(v *bool) *GroupUpdate {
+	if v != nil {
+		_u.SetResponseCacheEnabled(*v)
+	}
+	return _u
+}
+
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (_u *GroupUpdate) SetSubscriptionPrice(v float64) *GroupUpdate {
+	_u.mutation.ResetSubscriptionPrice()
+	_u.mutation.SetSubscriptionPrice(v)
+	return _u
+}
+
+// SetNillableSubscriptionPrice sets the "subscription_price" field if the given value is not nil.
+func (_u *GroupUpdate) SetNillableSubscriptionPrice(v *float64) *GroupUpdate {
+	if v != nil {
+		_u.SetSubscriptionPrice(*v)
+	}
+	return _u
+}
+
+// AddSubscriptionPrice adds value to the "subscription_price" field.
+func (_u *GroupUpdate) AddSubscriptionPrice(v float64) *GroupUpdate {
+	_u.mutation.AddSubscriptionPrice(v)
+	return _u
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (_u *GroupUpdate) ClearSubscriptionPrice() *GroupUpdate {
+	_u.mutation.ClearSubscriptionPrice()
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdate) AddAPIKeyIDs(ids ...int64) *GroupUpdate {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -1012,6 +1094,30 @@ func (_u *GroupUpdate) sqlSave(ctx context.Context) (_node int, err error) {
 	if value, ok := _u.mutation.DefaultMappedModel(); ok {
 		_spec.SetField(group.FieldDefaultMappedModel, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.LocalTokenCounting(); ok {
+		_spec.SetField(group.FieldLocalTokenCounting, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.BatchDiscountMultiplier(); ok {
+		_spec.SetField(group.FieldBatchDiscountMultiplier, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedBatchDiscountMultiplier(); ok {
+		_spec.AddField(group.FieldBatchDiscountMultiplier, field.TypeFloat64, value)
+	}
+	if _u.mutation.BatchDiscountMultiplierCleared() {
+		_spec.ClearField(group.FieldBatchDiscountMultiplier, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.ResponseCacheEnabled(); ok {
+		_spec.SetField(group.FieldResponseCacheEnabled, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.SubscriptionPrice(); ok {
+		_spec.SetField(group.FieldSubscriptionPrice, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedSubscriptionPrice(); ok {
+		_spec.AddField(group.FieldSubscriptionPrice, field.TypeFloat64, value)
+	}
+	if _u.mutation.SubscriptionPriceCleared() {
+		_spec.ClearField(group.FieldSubscriptionPrice, field.TypeFloat64)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -1843,6 +1949,88 @@ func (_u *GroupUpdateOne) SetNillableDefaultMappedModel(v *string) *GroupUpdateO
 	return _u
 }
 
+// SetLocalTokenCounting sets the "local_token_counting" field.
+func (_u *GroupUpdateOne) SetLocalTokenCounting(v bool) *GroupUpdateOne {
+	_u.mutation.SetLocalTokenCounting(v)
+	return _u
+}
+
+// SetNillableLocalTokenCounting sets the "local_token_counting" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableLocalTokenCounting(v *bool) *GroupUpdateOne {
+	if v != nil {
+		_u.SetLocalTokenCounting(*v)
+	}
+	return _u
+}
+
+// SetBatchDiscountMultiplier sets the "batch_discount_multiplier" field.
+func (_u *GroupUpdateOne) SetBatchDiscountMultiplier(v float64) *GroupUpdateOne {
+	_u.mutation.ResetBatchDiscountMultiplier()
+	_u.mutation.SetBatchDiscountMultiplier(v)
+	return _u
+}
+
+// SetNillableBatchDiscountMultiplier sets the "batch_discount_multiplier" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableBatchDiscountMultiplier(v *float64) *GroupUpdateOne {
+	if v != nil {
+		_u.SetBatchDiscountMultiplier(*v)
+	}
+	return _u
+}
+
+// AddBatchDiscountMultiplier adds value to the "batch_discount_multiplier" field.
+func (_u *GroupUpdateOne) AddBatchDiscountMultiplier(v float64) *GroupUpdateOne {
+	_u.mutation.AddBatchDiscountMultiplier(v)
+	return _u
+}
+
+// ClearBatchDiscountMultiplier clears the value of the "batch_discount_multiplier" field.
+func (_u *GroupUpdateOne) ClearBatchDiscountMultiplier() *GroupUpdateOne {
+	_u.mutation.ClearBatchDiscountMultiplier()
+	return _u
+}
+
+// SetResponseCacheEnabled sets the "response_cache_enabled" field.
+func (_u *GroupUpdateOne) SetResponseCacheEnabled(v bool) *GroupUpdateOne {
+	_u.mutation.SetResponseCacheEnabled(v)
+	return _u
+}
+
+// SetNillableResponseCacheEnabled sets the "response_cache_enabled" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableResponseCacheEnabled(v *bool) *GroupUpdateOne {
+	if v != nil {
+		_u.SetResponseCacheEnabled(*v)
+	}
+	return _u
+}
+
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (_u *GroupUpdateOne) SetSubscriptionPrice(v float64) *GroupUpdateOne {
+	_u.mutation.ResetSubscriptionPrice()
+	_u.mutation.SetSubscriptionPrice(v)
+	return _u
+}
+
+// SetNillableSubscriptionPrice sets the "subscription_price" field if the given value is not nil.
+func (_u *GroupUpdateOne) SetNillableSubscriptionPrice(v *float64) *GroupUpdateOne {
+	if v != nil {
+		_u.SetSubscriptionPrice(*v)
+	}
+	return _u
+}
+
+// AddSubscriptionPrice adds value to the "subscription_price" field.
+func (_u *GroupUpdateOne) AddSubscriptionPrice(v float64) *GroupUpdateOne {
+	_u.mutation.AddSubscriptionPrice(v)
+	return _u
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (_u *GroupUpdateOne) ClearSubscriptionPrice() *GroupUpdateOne {
+	_u.mutation.ClearSubscriptionPrice()
+	return _u
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by IDs.
 func (_u *GroupUpdateOne) AddAPIKeyIDs(ids ...int64) *GroupUpdateOne {
 	_u.mutation.AddAPIKeyIDs(ids...)
@@ -2333,6 +2521,30 @@ func (_u *GroupUpdateOne) sqlSave(ctx context.Context) (_node *Group, err error)
 	if value, ok := _u.mutation.DefaultMappedModel(); ok {
 		_spec.SetField(group.FieldDefaultMappedModel, field.TypeString, value)
 	}
+	if value, ok := _u.mutation.LocalTokenCounting(); ok {
+		_spec.SetField(group.FieldLocalTokenCounting, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.BatchDiscountMultiplier(); ok {
+		_spec.SetField(group.FieldBatchDiscountMultiplier, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedBatchDiscountMultiplier(); ok {
+		_spec.AddField(group.FieldBatchDiscountMultiplier, field.TypeFloat64, value)
+	}
+	if _u.mutation.BatchDiscountMultiplierCleared() {
+		_spec.ClearField(group.FieldBatchDiscountMultiplier, field.TypeFloat64)
+	}
+	if value, ok := _u.mutation.ResponseCacheEnabled(); ok {
+		_spec.SetField(group.FieldResponseCacheEnabled, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.SubscriptionPrice(); ok {
+		_spec.SetField(group.FieldSubscriptionPrice, field.TypeFloat64, value)
+	}
+	if value, ok := _u.mutation.AddedSubscriptionPrice(); ok {
+		_spec.AddField(group.FieldSubscriptionPrice, field.TypeFloat64, value)
+	}
+	if _u.mutation.SubscriptionPriceCleared() {
+		_spec.ClearField(group.FieldSubscriptionPrice, field.TypeFloat64)
+	}
 	if _u.mutation.APIKeysCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.O2M,
@@ -74,51 +74,61 @@ const (
 // APIKeyMutation represents an operation that mutates the APIKey nodes in the graph.
 type APIKeyMutation struct {
 	config
-	op                 Op
-	typ                string
-	id                 *int64
-	created_at         *time.Time
-	updated_at         *time.Time
-	deleted_at         *time.Time
-	key                *string
-	name               *string
-	status             *string
-	last_used_at       *time.Time
-	ip_whitelist       *[]string
-	appendip_whitelist []string
-	ip_blacklist       *[]string
-	appendip_blacklist []string
-	quota              *float64
-	addquota           *float64
-	quota_used         *float64
-	addquota_used      *float64
-	expires_at         *time.Time
-	rate_limit_5h      *float64
-	addrate_limit_5h   *float64
-	rate_limit_1d      *float64
-	addrate_limit_1d   *float64
-	rate_limit_7d      *float64
-	addrate_limit_7d   *float64
-	usage_5h           *float64
-	addusage_5h        *float64
-	usage_1d           *float64
-	addusage_1d        *float64
-	usage_7d           *float64
-	addusage_7d        *float64
-	window_5h_start    *time.Time
-	window_1d_start    *time.Time
-	window_7d_start    *time.Time
-	clearedFields      map[string]struct{}
-	user               *int64
-	cleareduser        bool
-	group              *int64
-	clearedgroup       bool
-	usage_logs         map[int64]struct{}
-	removedusage_logs  map[int64]struct{}
-	clearedusage_logs  bool
-	done               bool
-	oldValue           func(context.Context) (*APIKey, error)
-	predicates         []predicate.APIKey
+	op                   Op
+	typ                  string
+	id                   *int64
+	created_at           *time.Time
+	updated_at           *time.Time
+	deleted_at           *time.Time
+	key                  *string
+	key_hash             *string
+	key_prefix           *string
+	name                 *string
+	status               *string
+	last_used_at         *time.Time
+	ip_whitelist         *[]string
+	appendip_whitelist   []string
+	ip_blacklist         *[]string
+	appendip_blacklist   []string
+	allowed_models       *[]string
+	appendallowed_models []string
+	denied_models        *[]string
+	appenddenied_models  []string
+	quota                *float64
+	addquota             *float64
+	quota_used           *float64
+	addquota_used        *float64
+	expires_at           *time.Time
+	rate_limit_5h        *float64
+	addrate_limit_5h     *float64
+	rate_limit_1d        *float64
+	addrate_limit_1d     *float64
+	rate_limit_7d        *float64
+	addrate_limit_7d     *float64
+	rpm_limit            *int
+	addrpm_limit         *int
+	tpm_limit            *int
+	addtpm_limit         *int
+	usage_5h             *float64
+	addusage_5h          *float64
+	usage_1d             *float64
+	addusage_1d          *float64
+	usage_7d             *float64
+	addusage_7d          *float64
+	window_5h_start      *time.Time
+	window_1d_start      *time.Time
+	window_7d_start      *time.Time
+	clearedFields        map[string]struct{}
+	user                 *int64
+	cleareduser          bool
+	group                *int64
+	clearedgroup         bool
+	usage_logs           map[int64]struct{}
+	removedusage_logs    map[int64]struct{}
+	clearedusage_logs    bool
+	done                 bool
+	oldValue             func(context.Context) (*APIKey, error)
+	predicates           []predicate.APIKey
 }
 
 var _ ent.Mutation = (*APIKeyMutation)(nil)
@@ -393,7 +403,7 @@ func (m *APIKeyMutation) Key() (r string, exists bool) {
 // OldKey returns the old "key" field's value of the APIKey entity.
 // If the APIKey object wasn't provided to the builder, the object is fetched from the database.
 // An error is returned if the mutation operation is not UpdateOne, or the database query fails.
-func (m *APIKeyMutation) OldKey(ctx context.Context) (v string, err error) {
+func (m *APIKeyMutation) OldKey(ctx context.Context) (v *string, err error) {
 	if !m.op.Is(OpUpdateOne) {
 		return v, errors.New("OldKey is only allowed on UpdateOne operations")
 	}
@@ -407,9 +417,107 @@ func (m *APIKeyMutation) OldKey(ctx context.Context) (v string, err error) {
 	return oldValue.Key, nil
 }
 
+// ClearKey clears the value of the "key" field.
+func (m *APIKeyMutation) ClearKey() {
+	m.key = nil
+	m.clearedFields[apikey.FieldKey] = struct{}{}
+}
+
+// KeyCleared returns if the "key" field was cleared in this mutation.
+func (m *APIKeyMutation) KeyCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldKey]
+	return ok
+}
+
 // ResetKey resets all changes to the "key" field.
 func (m *APIKeyMutation) ResetKey() {
 	m.key = nil
+	delete(m.clearedFields, apikey.FieldKey)
+}
+
+// SetKeyHash sets the "key_hash" field.
+func (m *APIKeyMutation) SetKeyHash(s string) {
+	m.key_hash = &s
+}
+
+// KeyHash returns the value of the "key_hash" field in the mutation.
+func (m *APIKeyMutation) KeyHash() (r string, exists bool) {
+	v := m.key_hash
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldKeyHash returns the old "key_hash" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldKeyHash(ctx context.Context) (v *string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldKeyHash is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldKeyHash requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldKeyHash: %w", err)
+	}
+	return oldValue.KeyHash, nil
+}
+
+// ClearKeyHash clears the value of the "key_hash" field.
+func (m *APIKeyMutation) ClearKeyHash() {
+	m.key_hash = nil
+	m.clearedFields[apikey.FieldKeyHash] = struct{}{}
+}
+
+// KeyHashCleared returns if the "key_hash" field was cleared in this mutation.
+func (m *APIKeyMutation) KeyHashCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldKeyHash]
+	return ok
+}
+
+// ResetKeyHash resets all changes to the "key_hash" field.
+func (m *APIKeyMutation) ResetKeyHash() {
+	m.key_hash = nil
+	delete(m.clearedFields, apikey.FieldKeyHash)
+}
+
+// SetKeyPrefix sets the "key_prefix" field.
+func (m *APIKeyMutation) SetKeyPrefix(s string) {
+	m.key_prefix = &s
+}
+
+// KeyPrefix returns the value of the "key_prefix" field in the mutation.
+func (m *APIKeyMutation) KeyPrefix() (r string, exists bool) {
+	v := m.key_prefix
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldKeyPrefix returns the old "key_prefix" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldKeyPrefix(ctx context.Context) (v string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldKeyPrefix is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldKeyPrefix requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldKeyPrefix: %w", err)
+	}
+	return oldValue.KeyPrefix, nil
+}
+
+// ResetKeyPrefix resets all changes to the "key_prefix" field.
+func (m *APIKeyMutation) ResetKeyPrefix() {
+	m.key_prefix = nil
 }
 
 // SetName sets the "name" field.
@@ -712,6 +820,136 @@ func (m *APIKeyMutation) ResetIPBlacklist() {
 	delete(m.clearedFields, apikey.FieldIPBlacklist)
 }
 
+// SetAllowedModels sets the "allowed_models" field.
+func (m *APIKeyMutation) SetAllowedModels(s []string) {
+	m.allowed_models = &s
+	m.appendallowed_models = nil
+}
+
+// AllowedModels returns the value of the "allowed_models" field in the mutation.
+func (m *APIKeyMutation) AllowedModels() (r []string, exists bool) {
+	v := m.allowed_models
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAllowedModels returns the old "allowed_models" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldAllowedModels(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAllowedModels is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAllowedModels requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAllowedModels: %w", err)
+	}
+	return oldValue.AllowedModels, nil
+}
+
+// AppendAllowedModels adds s to the "allowed_models" field.
+func (m *APIKeyMutation) AppendAllowedModels(s []string) {
+	m.appendallowed_models = append(m.appendallowed_models, s...)
+}
+
+// AppendedAllowedModels returns the list of values that were appended to the "allowed_models" field in this mutation.
+func (m *APIKeyMutation) AppendedAllowedModels() ([]string, bool) {
+	if len(m.appendallowed_models) == 0 {
+		return nil, false
+	}
+	return m.appendallowed_models, true
+}
+
+// ClearAllowedModels clears the value of the "allowed_models" field.
+func (m *APIKeyMutation) ClearAllowedModels() {
+	m.allowed_models = nil
+	m.appendallowed_models = nil
+	m.clearedFields[apikey.FieldAllowedModels] = struct{}{}
+}
+
+// AllowedModelsCleared returns if the "allowed_models" field was cleared in this mutation.
+func (m *APIKeyMutation) AllowedModelsCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldAllowedModels]
+	return ok
+}
+
+// ResetAllowedModels resets all changes to the "allowed_models" field.
+func (m *APIKeyMutation) ResetAllowedModels() {
+	m.allowed_models = nil
+	m.appendallowed_models = nil
+	delete(m.clearedFields, apikey.FieldAllowedModels)
+}
+
+// SetDeniedModels sets the "denied_models" field.
+func (m *APIKeyMutation) SetDeniedModels(s []string) {
+	m.denied_models = &s
+	m.appenddenied_models = nil
+}
+
+// DeniedModels returns the value of the "denied_models" field in the mutation.
+func (m *APIKeyMutation) DeniedModels() (r []string, exists bool) {
+	v := m.denied_models
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldDeniedModels returns the old "denied_models" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldDeniedModels(ctx context.Context) (v []string, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldDeniedModels is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldDeniedModels requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldDeniedModels: %w", err)
+	}
+	return oldValue.DeniedModels, nil
+}
+
+// AppendDeniedModels adds s to the "denied_models" field.
+func (m *APIKeyMutation) AppendDeniedModels(s []string) {
+	m.appenddenied_models = append(m.appenddenied_models, s...)
+}
+
+// AppendedDeniedModels returns the list of values that were appended to the "denied_models" field in this mutation.
+func (m *APIKeyMutation) AppendedDeniedModels() ([]string, bool) {
+	if len(m.appenddenied_models) == 0 {
+		return nil, false
+	}
+	return m.appenddenied_models, true
+}
+
+// ClearDeniedModels clears the value of the "denied_models" field.
+func (m *APIKeyMutation) ClearDeniedModels() {
+	m.denied_models = nil
+	m.appenddenied_models = nil
+	m.clearedFields[apikey.FieldDeniedModels] = struct{}{}
+}
+
+// DeniedModelsCleared returns if the "denied_models" field was cleared in this mutation.
+func (m *APIKeyMutation) DeniedModelsCleared() bool {
+	_, ok := m.clearedFields[apikey.FieldDeniedModels]
+	return ok
+}
+
+// ResetDeniedModels resets all changes to the "denied_models" field.
+func (m *APIKeyMutation) ResetDeniedModels() {
+	m.denied_models = nil
+	m.appenddenied_models = nil
+	delete(m.clearedFields, apikey.FieldDeniedModels)
+}
+
 // SetQuota sets the "quota" field.
 func (m *APIKeyMutation) SetQuota(f float64) {
 	m.quota = &f
@@ -1041,6 +1279,118 @@ func (m *APIKeyMutation) ResetRateLimit7d() {
 	m.addrate_limit_7d = nil
 }
 
+// SetRpmLimit sets the "rpm_limit" field.
+func (m *APIKeyMutation) SetRpmLimit(i int) {
+	m.rpm_limit = &i
+	m.addrpm_limit = nil
+}
+
+// RpmLimit returns the value of the "rpm_limit" field in the mutation.
+func (m *APIKeyMutation) RpmLimit() (r int, exists bool) {
+	v := m.rpm_limit
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldRpmLimit returns the old "rpm_limit" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldRpmLimit(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldRpmLimit is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldRpmLimit requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldRpmLimit: %w", err)
+	}
+	return oldValue.RpmLimit, nil
+}
+
+// AddRpmLimit adds i to the "rpm_limit" field.
+func (m *APIKeyMutation) AddRpmLimit(i int) {
+	if m.addrpm_limit != nil {
+		*m.addrpm_limit += i
+	} else {
+		m.addrpm_limit = &i
+	}
+}
+
+// AddedRpmLimit returns the value that was added to the "rpm_limit" field in this mutation.
+func (m *APIKeyMutation) AddedRpmLimit() (r int, exists bool) {
+	v := m.addrpm_limit
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetRpmLimit resets all changes to the "rpm_limit" field.
+func (m *APIKeyMutation) ResetRpmLimit() {
+	m.rpm_limit = nil
+	m.addrpm_limit = nil
+}
+
+// SetTpmLimit sets the "tpm_limit" field.
+func (m *APIKeyMutation) SetTpmLimit(i int) {
+	m.tpm_limit = &i
+	m.addtpm_limit = nil
+}
+
+// TpmLimit returns the value of the "tpm_limit" field in the mutation.
+func (m *APIKeyMutation) TpmLimit() (r int, exists bool) {
+	v := m.tpm_limit
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldTpmLimit returns the old "tpm_limit" field's value of the APIKey entity.
+// If the APIKey object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *APIKeyMutation) OldTpmLimit(ctx context.Context) (v int, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldTpmLimit is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldTpmLimit requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldTpmLimit: %w", err)
+	}
+	return oldValue.TpmLimit, nil
+}
+
+// AddTpmLimit adds i to the "tpm_limit" field.
+func (m *APIKeyMutation) AddTpmLimit(i int) {
+	if m.addtpm_limit != nil {
+		*m.addtpm_limit += i
+	} else {
+		m.addtpm_limit = &i
+	}
+}
+
+// AddedTpmLimit returns the value that was added to the "tpm_limit" field in this mutation.
+func (m *APIKeyMutation) AddedTpmLimit() (r int, exists bool) {
+	v := m.addtpm_limit
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ResetTpmLimit resets all changes to the "tpm_limit" field.
+func (m *APIKeyMutation) ResetTpmLimit() {
+	m.tpm_limit = nil
+	m.addtpm_limit = nil
+}
+
 // SetUsage5h sets the "usage_5h" field.
 func (m *APIKeyMutation) SetUsage5h(f float64) {
 	m.usage_5h = &f
@@ -1498,7 +1848,7 @@ func (m *APIKeyMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *APIKeyMutation) Fields() []string {
-	fields := make([]string, 0, 23)
+	fields := make([]string, 0, 29)
 	if m.created_at != nil {
 		fields = append(fields, apikey.FieldCreatedAt)
 	}
@@ -1514,6 +1864,12 @@ func (m *APIKeyMutation) Fields() []string {
 	if m.key != nil {
 		fields = append(fields, apikey.FieldKey)
 	}
+	if m.key_hash != nil {
+		fields = append(fields, apikey.FieldKeyHash)
+	}
+	if m.key_prefix != nil {
+		fields = append(fields, apikey.FieldKeyPrefix)
+	}
 	if m.name != nil {
 		fields = append(fields, apikey.FieldName)
 	}
@@ -1532,6 +1888,12 @@ func (m *APIKeyMutation) Fields() []string {
 	if m.ip_blacklist != nil {
 		fields = append(fields, apikey.FieldIPBlacklist)
 	}
+	if m.allowed_models != nil {
+		fields = append(fields, apikey.FieldAllowedModels)
+	}
+	if m.denied_models != nil {
+		fields = append(fields, apikey.FieldDeniedModels)
+	}
 	if m.quota != nil {
 		fields = append(fields, apikey.FieldQuota)
 	}
@@ -1550,6 +1912,12 @@ func (m *APIKeyMutation) Fields() []string {
 	if m.rate_limit_7d != nil {
 		fields = append(fields, apikey.FieldRateLimit7d)
 	}
+	if m.rpm_limit != nil {
+		fields = append(fields, apikey.FieldRpmLimit)
+	}
+	if m.tpm_limit != nil {
+		fields = append(fields, apikey.FieldTpmLimit)
+	}
 	if m.usage_5h != nil {
 		fields = append(fields, apikey.FieldUsage5h)
 	}
@@ -1586,6 +1954,10 @@ func (m *APIKeyMutation) Field(name string) (ent.Value, bool) {
 		return m.UserID()
 	case apikey.FieldKey:
 		return m.Key()
+	case apikey.FieldKeyHash:
+		return m.KeyHash()
+	case apikey.FieldKeyPrefix:
+		return m.KeyPrefix()
 	case apikey.FieldName:
 		return m.Name()
 	case apikey.FieldGroupID:
@@ -1598,6 +1970,10 @@ func (m *APIKeyMutation) Field(name string) (ent.Value, bool) {
 		return m.IPWhitelist()
 	case apikey.FieldIPBlacklist:
 		return m.IPBlacklist()
+	case apikey.FieldAllowedModels:
+		return m.AllowedModels()
+	case apikey.FieldDeniedModels:
+		return m.DeniedModels()
 	case apikey.FieldQuota:
 		return m.Quota()
 	case apikey.FieldQuotaUsed:
@@ -1610,6 +1986,10 @@ func (m *APIKeyMutation) Field(name string) (ent.Value, bool) {
 		return m.RateLimit1d()
 	case apikey.FieldRateLimit7d:
 		return m.RateLimit7d()
+	case apikey.FieldRpmLimit:
+		return m.RpmLimit()
+	case apikey.FieldTpmLimit:
+		return m.TpmLimit()
 	case apikey.FieldUsage5h:
 		return m.Usage5h()
 	case apikey.FieldUsage1d:
@@ -1641,6 +2021,10 @@ func (m *APIKeyMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldUserID(ctx)
 	case apikey.FieldKey:
 		return m.OldKey(ctx)
+	case apikey.FieldKeyHash:
+		return m.OldKeyHash(ctx)
+	case apikey.FieldKeyPrefix:
+		return m.OldKeyPrefix(ctx)
 	case apikey.FieldName:
 		return m.OldName(ctx)
 	case apikey.FieldGroupID:
@@ -1653,6 +2037,10 @@ func (m *APIKeyMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldIPWhitelist(ctx)
 	case apikey.FieldIPBlacklist:
 		return m.OldIPBlacklist(ctx)
+	case apikey.FieldAllowedModels:
+		return m.OldAllowedModels(ctx)
+	case apikey.FieldDeniedModels:
+		return m.OldDeniedModels(ctx)
 	case apikey.FieldQuota:
 		return m.OldQuota(ctx)
 	case apikey.FieldQuotaUsed:
@@ -1665,6 +2053,10 @@ func (m *APIKeyMutation) OldField(ctx context.Context, name string) (ent.Value,
 		return m.OldRateLimit1d(ctx)
 	case apikey.FieldRateLimit7d:
 		return m.OldRateLimit7d(ctx)
+	case apikey.FieldRpmLimit:
+		return m.OldRpmLimit(ctx)
+	case apikey.FieldTpmLimit:
+		return m.OldTpmLimit(ctx)
 	case apikey.FieldUsage5h:
 		return m.OldUsage5h(ctx)
 	case apikey.FieldUsage1d:
@@ -1721,6 +2113,20 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetKey(v)
 		return nil
+	case apikey.FieldKeyHash:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetKeyHash(v)
+		return nil
+	case apikey.FieldKeyPrefix:
+		v, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetKeyPrefix(v)
+		return nil
 	case apikey.FieldName:
 		v, ok := value.(string)
 		if !ok {
@@ -1763,6 +2169,20 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetIPBlacklist(v)
 		return nil
+	case apikey.FieldAllowedModels:
+		v, ok := value.([]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAllowedModels(v)
+		return nil
+	case apikey.FieldDeniedModels:
+		v, ok := value.([]string)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetDeniedModels(v)
+		return nil
 	case apikey.FieldQuota:
 		v, ok := value.(float64)
 		if !ok {
@@ -1805,6 +2225,20 @@ func (m *APIKeyMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetRateLimit7d(v)
 		return nil
+	case apikey.FieldRpmLimit:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetRpmLimit(v)
+		return nil
+	case apikey.FieldTpmLimit:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetTpmLimit(v)
+		return nil
 	case apikey.FieldUsage5h:
 		v, ok := value.(float64)
 		if !ok {
@@ -1870,6 +2304,12 @@ func (m *APIKeyMutation) AddedFields() []string {
 	if m.addrate_limit_7d != nil {
 		fields = append(fields, apikey.FieldRateLimit7d)
 	}
+	if m.addrpm_limit != nil {
+		fields = append(fields, apikey.FieldRpmLimit)
+	}
+	if m.addtpm_limit != nil {
+		fields = append(fields, apikey.FieldTpmLimit)
+	}
 	if m.addusage_5h != nil {
 		fields = append(fields, apikey.FieldUsage5h)
 	}
@@ -1897,6 +2337,10 @@ func (m *APIKeyMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedRateLimit1d()
 	case apikey.FieldRateLimit7d:
 		return m.AddedRateLimit7d()
+	case apikey.FieldRpmLimit:
+		return m.AddedRpmLimit()
+	case apikey.FieldTpmLimit:
+		return m.AddedTpmLimit()
 	case apikey.FieldUsage5h:
 		return m.AddedUsage5h()
 	case apikey.FieldUsage1d:
@@ -1947,6 +2391,20 @@ func (m *APIKeyMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddRateLimit7d(v)
 		return nil
+	case apikey.FieldRpmLimit:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddRpmLimit(v)
+		return nil
+	case apikey.FieldTpmLimit:
+		v, ok := value.(int)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddTpmLimit(v)
+		return nil
 	case apikey.FieldUsage5h:
 		v, ok := value.(float64)
 		if !ok {
@@ -1979,6 +2437,12 @@ func (m *APIKeyMutation) ClearedFields() []string {
 	if m.FieldCleared(apikey.FieldDeletedAt) {
 		fields = append(fields, apikey.FieldDeletedAt)
 	}
+	if m.FieldCleared(apikey.FieldKey) {
+		fields = append(fields, apikey.FieldKey)
+	}
+	if m.FieldCleared(apikey.FieldKeyHash) {
+		fields = append(fields, apikey.FieldKeyHash)
+	}
 	if m.FieldCleared(apikey.FieldGroupID) {
 		fields = append(fields, apikey.FieldGroupID)
 	}
@@ -1991,6 +2455,12 @@ func (m *APIKeyMutation) ClearedFields() []string {
 	if m.FieldCleared(apikey.FieldIPBlacklist) {
 		fields = append(fields, apikey.FieldIPBlacklist)
 	}
+	if m.FieldCleared(apikey.FieldAllowedModels) {
+		fields = append(fields, apikey.FieldAllowedModels)
+	}
+	if m.FieldCleared(apikey.FieldDeniedModels) {
+		fields = append(fields, apikey.FieldDeniedModels)
+	}
 	if m.FieldCleared(apikey.FieldExpiresAt) {
 		fields = append(fields, apikey.FieldExpiresAt)
 	}
@@ -2020,6 +2490,12 @@ func (m *APIKeyMutation) ClearField(name string) error {
 	case apikey.FieldDeletedAt:
 		m.ClearDeletedAt()
 		return nil
+	case apikey.FieldKey:
+		m.ClearKey()
+		return nil
+	case apikey.FieldKeyHash:
+		m.ClearKeyHash()
+		return nil
 	case apikey.FieldGroupID:
 		m.ClearGroupID()
 		return nil
@@ -2032,6 +2508,12 @@ func (m *APIKeyMutation) ClearField(name string) error {
 	case apikey.FieldIPBlacklist:
 		m.ClearIPBlacklist()
 		return nil
+	case apikey.FieldAllowedModels:
+		m.ClearAllowedModels()
+		return nil
+	case apikey.FieldDeniedModels:
+		m.ClearDeniedModels()
+		return nil
 	case apikey.FieldExpiresAt:
 		m.ClearExpiresAt()
 		return nil
@@ -2067,6 +2549,12 @@ func (m *APIKeyMutation) ResetField(name string) error {
 	case apikey.FieldKey:
 		m.ResetKey()
 		return nil
+	case apikey.FieldKeyHash:
+		m.ResetKeyHash()
+		return nil
+	case apikey.FieldKeyPrefix:
+		m.ResetKeyPrefix()
+		return nil
 	case apikey.FieldName:
 		m.ResetName()
 		return nil
@@ -2085,6 +2573,12 @@ func (m *APIKeyMutation) ResetField(name string) error {
 	case apikey.FieldIPBlacklist:
 		m.ResetIPBlacklist()
 		return nil
+	case apikey.FieldAllowedModels:
+		m.ResetAllowedModels()
+		return nil
+	case apikey.FieldDeniedModels:
+		m.ResetDeniedModels()
+		return nil
 	case apikey.FieldQuota:
 		m.ResetQuota()
 		return nil
@@ -2103,6 +2597,12 @@ func (m *APIKeyMutation) ResetField(name string) error {
 	case apikey.FieldRateLimit7d:
 		m.ResetRateLimit7d()
 		return nil
+	case apikey.FieldRpmLimit:
+		m.ResetRpmLimit()
+		return nil
+	case apikey.FieldTpmLimit:
+		m.ResetTpmLimit()
+		return nil
 	case apikey.FieldUsage5h:
 		m.ResetUsage5h()
 		return nil
@@ -8246,6 +8746,12 @@ type GroupMutation struct {
 	require_oauth_only                      *bool
 	require_privacy_set                     *bool
 	default_mapped_model                    *string
+	local_token_counting                    *bool
+	batch_discount_multiplier               *float64
+	addbatch_discount_multiplier            *float64
+	response_cache_enabled                  *bool
+	subscription_price                      *float64
+	addsubscription_price                   *float64
 	clearedFields                           map[string]struct{}
 	api_keys                                map[int64]struct{}
 	removedapi_keys                         map[int64]struct{}
@@ -9798,6 +10304,218 @@ func (m *GroupMutation) ResetDefaultMappedModel() {
 	m.default_mapped_model = nil
 }
 
+// SetLocalTokenCounting sets the "local_token_counting" field.
+func (m *GroupMutation) SetLocalTokenCounting(b bool) {
+	m.local_token_counting = &b
+}
+
+// LocalTokenCounting returns the value of the "local_token_counting" field in the mutation.
+func (m *GroupMutation) LocalTokenCounting() (r bool, exists bool) {
+	v := m.local_token_counting
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldLocalTokenCounting returns the old "local_token_counting" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldLocalTokenCounting(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldLocalTokenCounting is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldLocalTokenCounting requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldLocalTokenCounting: %w", err)
+	}
+	return oldValue.LocalTokenCounting, nil
+}
+
+// ResetLocalTokenCounting resets all changes to the "local_token_counting" field.
+func (m *GroupMutation) ResetLocalTokenCounting() {
+	m.local_token_counting = nil
+}
+
+// SetBatchDiscountMultiplier sets the "batch_discount_multiplier" field.
+func (m *GroupMutation) SetBatchDiscountMultiplier(f float64) {
+	m.batch_discount_multiplier = &f
+	m.addbatch_discount_multiplier = nil
+}
+
+// BatchDiscountMultiplier returns the value of the "batch_discount_multiplier" field in the mutation.
+func (m *GroupMutation) BatchDiscountMultiplier() (r float64, exists bool) {
+	v := m.batch_discount_multiplier
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldBatchDiscountMultiplier returns the old "batch_discount_multiplier" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldBatchDiscountMultiplier(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldBatchDiscountMultiplier is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldBatchDiscountMultiplier requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldBatchDiscountMultiplier: %w", err)
+	}
+	return oldValue.BatchDiscountMultiplier, nil
+}
+
+// AddBatchDiscountMultiplier adds f to the "batch_discount_multiplier" field.
+func (m *GroupMutation) AddBatchDiscountMultiplier(f float64) {
+	if m.addbatch_discount_multiplier != nil {
+		*m.addbatch_discount_multiplier += f
+	} else {
+		m.addbatch_discount_multiplier = &f
+	}
+}
+
+// AddedBatchDiscountMultiplier returns the value that was added to the "batch_discount_multiplier" field in this mutation.
+func (m *GroupMutation) AddedBatchDiscountMultiplier() (r float64, exists bool) {
+	v := m.addbatch_discount_multiplier
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearBatchDiscountMultiplier clears the value of the "batch_discount_multiplier" field.
+func (m *GroupMutation) ClearBatchDiscountMultiplier() {
+	m.batch_discount_multiplier = nil
+	m.addbatch_discount_multiplier = nil
+	m.clearedFields[group.FieldBatchDiscountMultiplier] = struct{}{}
+}
+
+// BatchDiscountMultiplierCleared returns if the "batch_discount_multiplier" field was cleared in this mutation.
+func (m *GroupMutation) BatchDiscountMultiplierCleared() bool {
+	_, ok := m.clearedFields[group.FieldBatchDiscountMultiplier]
+	return ok
+}
+
+// ResetBatchDiscountMultiplier resets all changes to the "batch_discount_multiplier" field.
+func (m *GroupMutation) ResetBatchDiscountMultiplier() {
+	m.batch_discount_multiplier = nil
+	m.addbatch_discount_multiplier = nil
+	delete(m.clearedFields, group.FieldBatchDiscountMultiplier)
+}
+
+// SetResponseCacheEnabled sets the "response_cache_enabled" field.
+func (m *GroupMutation) SetResponseCacheEnabled(b bool) {
+	m.response_cache_enabled = &b
+}
+
+// ResponseCacheEnabled returns the value of the "response_cache_enabled" field in the mutation.
+func (m *GroupMutation) ResponseCacheEnabled() (r bool, exists bool) {
+	v := m.response_cache_enabled
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldResponseCacheEnabled returns the old "response_cache_enabled" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldResponseCacheEnabled(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldResponseCacheEnabled is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldResponseCacheEnabled requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldResponseCacheEnabled: %w", err)
+	}
+	return oldValue.ResponseCacheEnabled, nil
+}
+
+// ResetResponseCacheEnabled resets all changes to the "response_cache_enabled" field.
+func (m *GroupMutation) ResetResponseCacheEnabled() {
+	m.response_cache_enabled = nil
+}
+
+// SetSubscriptionPrice sets the "subscription_price" field.
+func (m *GroupMutation) SetSubscriptionPrice(f float64) {
+	m.subscription_price = &f
+	m.addsubscription_price = nil
+}
+
+// SubscriptionPrice returns the value of the "subscription_price" field in the mutation.
+func (m *GroupMutation) SubscriptionPrice() (r float64, exists bool) {
+	v := m.subscription_price
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldSubscriptionPrice returns the old "subscription_price" field's value of the Group entity.
+// If the Group object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *GroupMutation) OldSubscriptionPrice(ctx context.Context) (v *float64, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldSubscriptionPrice is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldSubscriptionPrice requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldSubscriptionPrice: %w", err)
+	}
+	return oldValue.SubscriptionPrice, nil
+}
+
+// AddSubscriptionPrice adds f to the "subscription_price" field.
+func (m *GroupMutation) AddSubscriptionPrice(f float64) {
+	if m.addsubscription_price != nil {
+		*m.addsubscription_price += f
+	} else {
+		m.addsubscription_price = &f
+	}
+}
+
+// AddedSubscriptionPrice returns the value that was added to the "subscription_price" field in this mutation.
+func (m *GroupMutation) AddedSubscriptionPrice() (r float64, exists bool) {
+	v := m.addsubscription_price
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// ClearSubscriptionPrice clears the value of the "subscription_price" field.
+func (m *GroupMutation) ClearSubscriptionPrice() {
+	m.subscription_price = nil
+	m.addsubscription_price = nil
+	m.clearedFields[group.FieldSubscriptionPrice] = struct{}{}
+}
+
+// SubscriptionPriceCleared returns if the "subscription_price" field was cleared in this mutation.
+func (m *GroupMutation) SubscriptionPriceCleared() bool {
+	_, ok := m.clearedFields[group.FieldSubscriptionPrice]
+	return ok
+}
+
+// ResetSubscriptionPrice resets all changes to the "subscription_price" field.
+func (m *GroupMutation) ResetSubscriptionPrice() {
+	m.subscription_price = nil
+	m.addsubscription_price = nil
+	delete(m.clearedFields, group.FieldSubscriptionPrice)
+}
+
 // AddAPIKeyIDs adds the "api_keys" edge to the APIKey entity by ids.
 func (m *GroupMutation) AddAPIKeyIDs(ids ...int64) {
 	if m.api_keys == nil {
@@ -10156,7 +10874,7 @@ func (m *GroupMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *GroupMutation) Fields() []string {
-	fields := make([]string, 0, 29)
+	fields := make([]string, 0, 33)
 	if m.created_at != nil {
 		fields = append(fields, group.FieldCreatedAt)
 	}
@@ -10244,6 +10962,18 @@ func (m *GroupMutation) Fields() []string {
 	if m.default_mapped_model != nil {
 		fields = append(fields, group.FieldDefaultMappedModel)
 	}
+	if m.local_token_counting != nil {
+		fields = append(fields, group.FieldLocalTokenCounting)
+	}
+	if m.batch_discount_multiplier != nil {
+		fields = append(fields, group.FieldBatchDiscountMultiplier)
+	}
+	if m.response_cache_enabled != nil {
+		fields = append(fields, group.FieldResponseCacheEnabled)
+	}
+	if m.subscription_price != nil {
+		fields = append(fields, group.FieldSubscriptionPrice)
+	}
 	return fields
 }
 
@@ -10310,6 +11040,14 @@ func (m *GroupMutation) Field(name string) (ent.Value, bool) {
 		return m.RequirePrivacySet()
 	case group.FieldDefaultMappedModel:
 		return m.DefaultMappedModel()
+	case group.FieldLocalTokenCounting:
+		return m.LocalTokenCounting()
+	case group.FieldBatchDiscountMultiplier:
+		return m.BatchDiscountMultiplier()
+	case group.FieldResponseCacheEnabled:
+		return m.ResponseCacheEnabled()
+	case group.FieldSubscriptionPrice:
+		return m.SubscriptionPrice()
 	}
 	return nil, false
 }
@@ -10377,6 +11115,14 @@ func (m *GroupMutation) OldField(ctx context.Context, name string) (ent.Value, e
 		return m.OldRequirePrivacySet(ctx)
 	case group.FieldDefaultMappedModel:
 		return m.OldDefaultMappedModel(ctx)
+	case group.FieldLocalTokenCounting:
+		return m.OldLocalTokenCounting(ctx)
+	case group.FieldBatchDiscountMultiplier:
+		return m.OldBatchDiscountMultiplier(ctx)
+	case group.FieldResponseCacheEnabled:
+		return m.OldResponseCacheEnabled(ctx)
+	case group.FieldSubscriptionPrice:
+		return m.OldSubscriptionPrice(ctx)
 	}
 	return nil, fmt.Errorf("unknown Group field %s", name)
 }
@@ -10589,6 +11335,34 @@ func (m *GroupMutation) SetField(name string, value ent.Value) error {
 		}
 		m.SetDefaultMappedModel(v)
 		return nil
+	case group.FieldLocalTokenCounting:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetLocalTokenCounting(v)
+		return nil
+	case group.FieldBatchDiscountMultiplier:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetBatchDiscountMultiplier(v)
+		return nil
+	case group.FieldResponseCacheEnabled:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetResponseCacheEnabled(v)
+		return nil
+	case group.FieldSubscriptionPrice:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetSubscriptionPrice(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
@@ -10630,6 +11404,12 @@ func (m *GroupMutation) AddedFields() []string {
 	if m.addsort_order != nil {
 		fields = append(fields, group.FieldSortOrder)
 	}
+	if m.addbatch_discount_multiplier != nil {
+		fields = append(fields, group.FieldBatchDiscountMultiplier)
+	}
+	if m.addsubscription_price != nil {
+		fields = append(fields, group.FieldSubscriptionPrice)
+	}
 	return fields
 }
 
@@ -10660,6 +11440,10 @@ func (m *GroupMutation) AddedField(name string) (ent.Value, bool) {
 		return m.AddedFallbackGroupIDOnInvalidRequest()
 	case group.FieldSortOrder:
 		return m.AddedSortOrder()
+	case group.FieldBatchDiscountMultiplier:
+		return m.AddedBatchDiscountMultiplier()
+	case group.FieldSubscriptionPrice:
+		return m.AddedSubscriptionPrice()
 	}
 	return nil, false
 }
@@ -10746,6 +11530,20 @@ func (m *GroupMutation) AddField(name string, value ent.Value) error {
 		}
 		m.AddSortOrder(v)
 		return nil
+	case group.FieldBatchDiscountMultiplier:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddBatchDiscountMultiplier(v)
+		return nil
+	case group.FieldSubscriptionPrice:
+		v, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.AddSubscriptionPrice(v)
+		return nil
 	}
 	return fmt.Errorf("unknown Group numeric field %s", name)
 }
@@ -10787,6 +11585,12 @@ func (m *GroupMutation) ClearedFields() []string {
 	if m.FieldCleared(group.FieldModelRouting) {
 		fields = append(fields, group.FieldModelRouting)
 	}
+	if m.FieldCleared(group.FieldBatchDiscountMultiplier) {
+		fields = append(fields, group.FieldBatchDiscountMultiplier)
+	}
+	if m.FieldCleared(group.FieldSubscriptionPrice) {
+		fields = append(fields, group.FieldSubscriptionPrice)
+	}
 	return fields
 }
 
@@ -10834,6 +11638,12 @@ func (m *GroupMutation) ClearField(name string) error {
 	case group.FieldModelRouting:
 		m.ClearModelRouting()
 		return nil
+	case group.FieldBatchDiscountMultiplier:
+		m.ClearBatchDiscountMultiplier()
+		return nil
+	case group.FieldSubscriptionPrice:
+		m.ClearSubscriptionPrice()
+		return nil
 	}
 	return fmt.Errorf("unknown Group nullable field %s", name)
 }
@@ -10929,6 +11739,18 @@ func (m *GroupMutation) ResetField(name string) error {
 	case group.FieldDefaultMappedModel:
 		m.ResetDefaultMappedModel()
 		return nil
+	case group.FieldLocalTokenCounting:
+		m.ResetLocalTokenCounting()
+		return nil
+	case group.FieldBatchDiscountMultiplier:
+		m.ResetBatchDiscountMultiplier()
+		return nil
+	case group.FieldResponseCacheEnabled:
+		m.ResetResponseCacheEnabled()
+		return nil
+	case group.FieldSubscriptionPrice:
+		m.ResetSubscriptionPrice()
+		return nil
 	}
 	return fmt.Errorf("unknown Group field %s", name)
 }
@@ -26764,6 +27586,8 @@ type UserSubscriptionMutation struct {
 	addmonthly_usage_usd    *float64
 	assigned_at             *time.Time
 	notes                   *string
+	auto_renew              *bool
+	auto_renew_failed_at    *time.Time
 	clearedFields           map[string]struct{}
 	user                    *int64
 	cleareduser             bool
@@ -27627,6 +28451,91 @@ func (m *UserSubscriptionMutation) ResetNotes() {
 	delete(m.clearedFields, usersubscription.FieldNotes)
 }
 
+// SetAutoRenew sets the "auto_renew" field.
+func (m *UserSubscriptionMutation) SetAutoRenew(b bool) {
+	m.auto_renew = &b
+}
+
+// AutoRenew returns the value of the "auto_renew" field in the mutation.
+func (m *UserSubscriptionMutation) AutoRenew() (r bool, exists bool) {
+	v := m.auto_renew
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAutoRenew returns the old "auto_renew" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldAutoRenew(ctx context.Context) (v bool, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAutoRenew is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAutoRenew requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAutoRenew: %w", err)
+	}
+	return oldValue.AutoRenew, nil
+}
+
+// ResetAutoRenew resets all changes to the "auto_renew" field.
+func (m *UserSubscriptionMutation) ResetAutoRenew() {
+	m.auto_renew = nil
+}
+
+// SetAutoRenewFailedAt sets the "auto_renew_failed_at" field.
+func (m *UserSubscriptionMutation) SetAutoRenewFailedAt(t time.Time) {
+	m.auto_renew_failed_at = &t
+}
+
+// AutoRenewFailedAt returns the value of the "auto_renew_failed_at" field in the mutation.
+func (m *UserSubscriptionMutation) AutoRenewFailedAt() (r time.Time, exists bool) {
+	v := m.auto_renew_failed_at
+	if v == nil {
+		return
+	}
+	return *v, true
+}
+
+// OldAutoRenewFailedAt returns the old "auto_renew_failed_at" field's value of the UserSubscription entity.
+// If the UserSubscription object wasn't provided to the builder, the object is fetched from the database.
+// An error is returned if the mutation operation is not UpdateOne, or the database query fails.
+func (m *UserSubscriptionMutation) OldAutoRenewFailedAt(ctx context.Context) (v *time.Time, err error) {
+	if !m.op.Is(OpUpdateOne) {
+		return v, errors.New("OldAutoRenewFailedAt is only allowed on UpdateOne operations")
+	}
+	if m.id == nil || m.oldValue == nil {
+		return v, errors.New("OldAutoRenewFailedAt requires an ID field in the mutation")
+	}
+	oldValue, err := m.oldValue(ctx)
+	if err != nil {
+		return v, fmt.Errorf("querying old value for OldAutoRenewFailedAt: %w", err)
+	}
+	return oldValue.AutoRenewFailedAt, nil
+}
+
+// ClearAutoRenewFailedAt clears the value of the "auto_renew_failed_at" field.
+func (m *UserSubscriptionMutation) ClearAutoRenewFailedAt() {
+	m.auto_renew_failed_at = nil
+	m.clearedFields[usersubscription.FieldAutoRenewFailedAt] = struct{}{}
+}
+
+// AutoRenewFailedAtCleared returns if the "auto_renew_failed_at" field was cleared in this mutation.
+func (m *UserSubscriptionMutation) AutoRenewFailedAtCleared() bool {
+	_, ok := m.clearedFields[usersubscription.FieldAutoRenewFailedAt]
+	return ok
+}
+
+// ResetAutoRenewFailedAt resets all changes to the "auto_renew_failed_at" field.
+func (m *UserSubscriptionMutation) ResetAutoRenewFailedAt() {
+	m.auto_renew_failed_at = nil
+	delete(m.clearedFields, usersubscription.FieldAutoRenewFailedAt)
+}
+
 // ClearUser clears the "user" edge to the User entity.
 func (m *UserSubscriptionMutation) ClearUser() {
 	m.cleareduser = true
@@ -27809,7 +28718,7 @@ func (m *UserSubscriptionMutation) Type() string {
 // order to get all numeric fields that were incremented/decremented, call
 // AddedFields().
 func (m *UserSubscriptionMutation) Fields() []string {
-	fields := make([]string, 0, 17)
+	fields := make([]string, 0, 19)
 	if m.created_at != nil {
 		fields = append(fields, usersubscription.FieldCreatedAt)
 	}
@@ -27861,6 +28770,12 @@ func (m *UserSubscriptionMutation) Fields() []string {
 	if m.notes != nil {
 		fields = append(fields, usersubscription.FieldNotes)
 	}
+	if m.auto_renew != nil {
+		fields = append(fields, usersubscription.FieldAutoRenew)
+	}
+	if m.auto_renew_failed_at != nil {
+		fields = append(fields, usersubscription.FieldAutoRenewFailedAt)
+	}
 	return fields
 }
 
@@ -27903,6 +28818,10 @@ func (m *UserSubscriptionMutation) Field(name string) (ent.Value, bool) {
 		return m.AssignedAt()
 	case usersubscription.FieldNotes:
 		return m.Notes()
+	case usersubscription.FieldAutoRenew:
+		return m.AutoRenew()
+	case usersubscription.FieldAutoRenewFailedAt:
+		return m.AutoRenewFailedAt()
 	}
 	return nil, false
 }
@@ -27946,6 +28865,10 @@ func (m *UserSubscriptionMutation) OldField(ctx context.Context, name string) (e
 		return m.OldAssignedAt(ctx)
 	case usersubscription.FieldNotes:
 		return m.OldNotes(ctx)
+	case usersubscription.FieldAutoRenew:
+		return m.OldAutoRenew(ctx)
+	case usersubscription.FieldAutoRenewFailedAt:
+		return m.OldAutoRenewFailedAt(ctx)
 	}
 	return nil, fmt.Errorf("unknown UserSubscription field %s", name)
 }
@@ -28074,6 +28997,20 @@ func (m *UserSubscriptionMutation) SetField(name string, value ent.Value) error
 		}
 		m.SetNotes(v)
 		return nil
+	case usersubscription.FieldAutoRenew:
+		v, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAutoRenew(v)
+		return nil
+	case usersubscription.FieldAutoRenewFailedAt:
+		v, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for field %s", value, name)
+		}
+		m.SetAutoRenewFailedAt(v)
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription field %s", name)
 }
@@ -28161,6 +29098,9 @@ func (m *UserSubscriptionMutation) ClearedFields() []string {
 	if m.FieldCleared(usersubscription.FieldNotes) {
 		fields = append(fields, usersubscription.FieldNotes)
 	}
+	if m.FieldCleared(usersubscription.FieldAutoRenewFailedAt) {
+		fields = append(fields, usersubscription.FieldAutoRenewFailedAt)
+	}
 	return fields
 }
 
@@ -28193,6 +29133,9 @@ func (m *UserSubscriptionMutation) ClearField(name string) error {
 	case usersubscription.FieldNotes:
 		m.ClearNotes()
 		return nil
+	case usersubscription.FieldAutoRenewFailedAt:
+		m.ClearAutoRenewFailedAt()
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription nullable field %s", name)
 }
@@ -28252,6 +29195,12 @@ func (m *UserSubscriptionMutation) ResetField(name string) error {
 	case usersubscription.FieldNotes:
 		m.ResetNotes()
 		return nil
+	case usersubscription.FieldAutoRenew:
+		m.ResetAutoRenew()
+		return nil
+	case usersubscription.FieldAutoRenewFailedAt:
+		m.ResetAutoRenewFailedAt()
+		return nil
 	}
 	return fmt.Errorf("unknown UserSubscription field %s", name)
 }
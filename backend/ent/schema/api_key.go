@@ -34,10 +34,22 @@ func (APIKey) Mixin() []ent.Mixin {
 func (APIKey) Fields() []ent.Field {
 	return []ent.Field{
 		field.Int64("user_id"),
+		// key 为旧版明文存储列，仅用于启动时迁移：回填 key_hash/key_prefix 后置空
 		field.String("key").
 			MaxLen(128).
-			NotEmpty().
+			Optional().
+			Nillable().
 			Unique(),
+		field.String("key_hash").
+			MaxLen(64).
+			Optional().
+			Nillable().
+			Unique().
+			Comment("HMAC-SHA256 of the full key (hex)"),
+		field.String("key_prefix").
+			MaxLen(32).
+			Default("").
+			Comment("Leading characters of the key shown for identification"),
 		field.String("name").
 			MaxLen(100).
 			NotEmpty(),
@@ -57,6 +69,12 @@ func (APIKey) Fields() []ent.Field {
 		field.JSON("ip_blacklist", []string{}).
 			Optional().
 			Comment("Blocked IPs/CIDRs"),
+		field.JSON("allowed_models", []string{}).
+			Optional().
+			Comment("Allowed model patterns, e.g. [\"claude-sonnet-*\", \"gpt-*-mini\"]"),
+		field.JSON("denied_models", []string{}).
+			Optional().
+			Comment("Denied model patterns (take precedence over allowed_models)"),
 
 		// ========== Quota fields ==========
 		// Quota limit in USD (0 = unlimited)
@@ -89,6 +107,13 @@ func (APIKey) Fields() []ent.Field {
 			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}).
 			Default(0).
 			Comment("Rate limit in USD per 7 days (0 = unlimited)"),
+		// Request rate limits (sliding 60s window, 0 = unlimited)
+		field.Int("rpm_limit").
+			Default(0).
+			Comment("Requests per minute limit (0 = unlimited)"),
+		field.Int("tpm_limit").
+			Default(0).
+			Comment("Tokens per minute limit (0 = unlimited)"),
 		// Rate limit usage tracking
 		field.Float("usage_5h").
 			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}).
@@ -135,7 +160,7 @@ func (APIKey) Edges() []ent.Edge {
 
 func (APIKey) Indexes() []ent.Index {
 	return []ent.Index{
-		// key 字段已在 Fields() 中声明 Unique()，无需重复索引
+		// key/key_hash 字段已在 Fields() 中声明 Unique()，无需重复索引
 		index.Fields("user_id"),
 		index.Fields("group_id"),
 		index.Fields("status"),
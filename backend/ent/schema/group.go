@@ -141,6 +141,30 @@ func (Group) Fields() []ent.Field {
 			MaxLen(100).
 			Default("").
 			Comment("默认映射模型 ID，当账号级映射找不到时使用此值"),
+
+		// 本地 token 计数 (added by migration 098)
+		field.Bool("local_token_counting").
+			Default(false).
+			Comment("count_tokens / countTokens 是否使用本地分词器估算，不请求上游"),
+
+		// 批处理折扣 (added by migration 100)
+		field.Float("batch_discount_multiplier").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "decimal(10,4)"}).
+			Comment("Batch API 请求在费率倍数之上额外叠加的折扣倍率，NULL 表示使用默认值"),
+
+		// 响应缓存 (added by migration 101)
+		field.Bool("response_cache_enabled").
+			Default(false).
+			Comment("是否对确定性请求（temperature=0）启用精确匹配响应缓存"),
+
+		// 余额购买订阅 (added by migration 106)
+		field.Float("subscription_price").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "decimal(20,8)"}).
+			Comment("用户使用余额购买/续订该订阅分组的价格（USD），NULL 表示不开放购买"),
 	}
 }
 
@@ -79,6 +79,16 @@ func (UserSubscription) Fields() []ent.Field {
 			Optional().
 			Nillable().
 			SchemaType(map[string]string{dialect.Postgres: "text"}),
+
+		// 自动续订 (added by migration 106)
+		field.Bool("auto_renew").
+			Default(false).
+			Comment("到期前是否自动从余额扣费续订"),
+		field.Time("auto_renew_failed_at").
+			Optional().
+			Nillable().
+			SchemaType(map[string]string{dialect.Postgres: "timestamptz"}).
+			Comment("最近一次自动续订失败时间，续订成功后清空"),
 	}
 }
 
@@ -140,6 +140,16 @@ func Notes(v string) predicate.UserSubscription {
 	return predicate.UserSubscription(sql.FieldEQ(FieldNotes, v))
 }
 
+// AutoRenew applies equality check predicate on the "auto_renew" field. It's identical to AutoRenewEQ.
+func AutoRenew(v bool) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldEQ(FieldAutoRenew, v))
+}
+
+// AutoRenewFailedAt applies equality check predicate on the "auto_renew_failed_at" field. It's identical to AutoRenewFailedAtEQ.
+func AutoRenewFailedAt(v time.Time) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldEQ(FieldAutoRenewFailedAt, v))
+}
+
 // CreatedAtEQ applies the EQ predicate on the "created_at" field.
 func CreatedAtEQ(v time.Time) predicate.UserSubscription {
 	return predicate.UserSubscription(sql.FieldEQ(FieldCreatedAt, v))
@@ -870,6 +880,66 @@ func NotesContainsFold(v string) predicate.UserSubscription {
 	return predicate.UserSubscription(sql.FieldContainsFold(FieldNotes, v))
 }
 
+// AutoRenewEQ applies the EQ predicate on the "auto_renew" field.
+func AutoRenewEQ(v bool) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldEQ(FieldAutoRenew, v))
+}
+
+// AutoRenewNEQ applies the NEQ predicate on the "auto_renew" field.
+func AutoRenewNEQ(v bool) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldNEQ(FieldAutoRenew, v))
+}
+
+// AutoRenewFailedAtEQ applies the EQ predicate on the "auto_renew_failed_at" field.
+func AutoRenewFailedAtEQ(v time.Time) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldEQ(FieldAutoRenewFailedAt, v))
+}
+
+// AutoRenewFailedAtNEQ applies the NEQ predicate on the "auto_renew_failed_at" field.
+func AutoRenewFailedAtNEQ(v time.Time) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldNEQ(FieldAutoRenewFailedAt, v))
+}
+
+// AutoRenewFailedAtIn applies the In predicate on the "auto_renew_failed_at" field.
+func AutoRenewFailedAtIn(vs ...time.Time) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldIn(FieldAutoRenewFailedAt, vs...))
+}
+
+// AutoRenewFailedAtNotIn applies the NotIn predicate on the "auto_renew_failed_at" field.
+func AutoRenewFailedAtNotIn(vs ...time.Time) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldNotIn(FieldAutoRenewFailedAt, vs...))
+}
+
+// AutoRenewFailedAtGT applies the GT predicate on the "auto_renew_failed_at" field.
+func AutoRenewFailedAtGT(v time.Time) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldGT(FieldAutoRenewFailedAt, v))
+}
+
+// AutoRenewFailedAtGTE applies the GTE predicate on the "auto_renew_failed_at" field.
+func AutoRenewFailedAtGTE(v time.Time) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldGTE(FieldAutoRenewFailedAt, v))
+}
+
+// AutoRenewFailedAtLT applies the LT predicate on the "auto_renew_failed_at" field.
+func AutoRenewFailedAtLT(v time.Time) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldLT(FieldAutoRenewFailedAt, v))
+}
+
+// AutoRenewFailedAtLTE applies the LTE predicate on the "auto_renew_failed_at" field.
+func AutoRenewFailedAtLTE(v time.Time) predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldLTE(FieldAutoRenewFailedAt, v))
+}
+
+// AutoRenewFailedAtIsNil applies the IsNil predicate on the "auto_renew_failed_at" field.
+func AutoRenewFailedAtIsNil() predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldIsNull(FieldAutoRenewFailedAt))
+}
+
+// AutoRenewFailedAtNotNil applies the NotNil predicate on the "auto_renew_failed_at" field.
+func AutoRenewFailedAtNotNil() predicate.UserSubscription {
+	return predicate.UserSubscription(sql.FieldNotNull(FieldAutoRenewFailedAt))
+}
+
 // HasUser applies the HasEdge predicate on the "user" edge.
 func HasUser() predicate.UserSubscription {
 	return predicate.UserSubscription(func(s *sql.Selector) {
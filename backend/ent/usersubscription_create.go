@@ -231,6 +231,34 @@ func (_c *UserSubscriptionCreate) SetNillableNotes(v *string) *UserSubscriptionC
 	return _c
 }
 
+// SetAutoRenew sets the "auto_renew" field.
+func (_c *UserSubscriptionCreate) SetAutoRenew(v bool) *UserSubscriptionCreate {
+	_c.mutation.SetAutoRenew(v)
+	return _c
+}
+
+// SetNillableAutoRenew sets the "auto_renew" field if the given value is not nil.
+func (_c *UserSubscriptionCreate) SetNillableAutoRenew(v *bool) *UserSubscriptionCreate {
+	if v != nil {
+		_c.SetAutoRenew(*v)
+	}
+	return _c
+}
+
+// SetAutoRenewFailedAt sets the "auto_renew_failed_at" field.
+func (_c *UserSubscriptionCreate) SetAutoRenewFailedAt(v time.Time) *UserSubscriptionCreate {
+	_c.mutation.SetAutoRenewFailedAt(v)
+	return _c
+}
+
+// SetNillableAutoRenewFailedAt sets the "auto_renew_failed_at" field if the given value is not nil.
+func (_c *UserSubscriptionCreate) SetNillableAutoRenewFailedAt(v *time.Time) *UserSubscriptionCreate {
+	if v != nil {
+		_c.SetAutoRenewFailedAt(*v)
+	}
+	return _c
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_c *UserSubscriptionCreate) SetUser(v *User) *UserSubscriptionCreate {
 	return _c.SetUserID(v.ID)
@@ -349,6 +377,10 @@ func (_c *UserSubscriptionCreate) defaults() error {
 		v := usersubscription.DefaultAssignedAt()
 		_c.mutation.SetAssignedAt(v)
 	}
+	if _, ok := _c.mutation.AutoRenew(); !ok {
+		v := usersubscription.DefaultAutoRenew
+		_c.mutation.SetAutoRenew(v)
+	}
 	return nil
 }
 
@@ -392,6 +424,9 @@ func (_c *UserSubscriptionCreate) check() error {
 	if _, ok := _c.mutation.AssignedAt(); !ok {
 		return &ValidationError{Name: "assigned_at", err: errors.New(`ent: missing required field "UserSubscription.assigned_at"`)}
 	}
+	if _, ok := _c.mutation.AutoRenew(); !ok {
+		return &ValidationError{Name: "auto_renew", err: errors.New(`ent: missing required field "UserSubscription.auto_renew"`)}
+	}
 	if len(_c.mutation.UserIDs()) == 0 {
 		return &ValidationError{Name: "user", err: errors.New(`ent: missing required edge "UserSubscription.user"`)}
 	}
@@ -481,6 +516,14 @@ func (_c *UserSubscriptionCreate) createSpec() (*UserSubscription, *sqlgraph.Cre
 		_spec.SetField(usersubscription.FieldNotes, field.TypeString, value)
 		_node.Notes = &value
 	}
+	if value, ok := _c.mutation.AutoRenew(); ok {
+		_spec.SetField(usersubscription.FieldAutoRenew, field.TypeBool, value)
+		_node.AutoRenew = value
+	}
+	if value, ok := _c.mutation.AutoRenewFailedAt(); ok {
+		_spec.SetField(usersubscription.FieldAutoRenewFailedAt, field.TypeTime, value)
+		_node.AutoRenewFailedAt = &value
+	}
 	if nodes := _c.mutation.UserIDs(); len(nodes) > 0 {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -846,6 +889,36 @@ func (u *UserSubscriptionUpsert) ClearNotes() *UserSubscriptionUpsert {
 	return u
 }
 
+// SetAutoRenew sets the "auto_renew" field.
+func (u *UserSubscriptionUpsert) SetAutoRenew(v bool) *UserSubscriptionUpsert {
+	u.Set(usersubscription.FieldAutoRenew, v)
+	return u
+}
+
+// UpdateAutoRenew sets the "auto_renew" field to the value that was provided on create.
+func (u *UserSubscriptionUpsert) UpdateAutoRenew() *UserSubscriptionUpsert {
+	u.SetExcluded(usersubscription.FieldAutoRenew)
+	return u
+}
+
+// SetAutoRenewFailedAt sets the "auto_renew_failed_at" field.
+func (u *UserSubscriptionUpsert) SetAutoRenewFailedAt(v time.Time) *UserSubscriptionUpsert {
+	u.Set(usersubscription.FieldAutoRenewFailedAt, v)
+	return u
+}
+
+// UpdateAutoRenewFailedAt sets the "auto_renew_failed_at" field to the value that was provided on create.
+func (u *UserSubscriptionUpsert) UpdateAutoRenewFailedAt() *UserSubscriptionUpsert {
+	u.SetExcluded(usersubscription.FieldAutoRenewFailedAt)
+	return u
+}
+
+// ClearAutoRenewFailedAt clears the value of the "auto_renew_failed_at" field.
+func (u *UserSubscriptionUpsert) ClearAutoRenewFailedAt() *UserSubscriptionUpsert {
+	u.SetNull(usersubscription.FieldAutoRenewFailedAt)
+	return u
+}
+
 // UpdateNewValues updates the mutable fields using the new values that were set on create.
 // Using this option is equivalent to using:
 //
@@ -1178,6 +1251,41 @@ func (u *UserSubscriptionUpsertOne) ClearNotes() *UserSubscriptionUpsertOne {
 	})
 }
 
+// SetAutoRenew sets the "auto_renew" field.
+func (u *UserSubscriptionUpsertOne) SetAutoRenew(v bool) *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetAutoRenew(v)
+	})
+}
+
+// UpdateAutoRenew sets the "auto_renew" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertOne) UpdateAutoRenew() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateAutoRenew()
+	})
+}
+
+// SetAutoRenewFailedAt sets the "auto_renew_failed_at" field.
+func (u *UserSubscriptionUpsertOne) SetAutoRenewFailedAt(v time.Time) *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetAutoRenewFailedAt(v)
+	})
+}
+
+// UpdateAutoRenewFailedAt sets the "auto_renew_failed_at" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertOne) UpdateAutoRenewFailedAt() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateAutoRenewFailedAt()
+	})
+}
+
+// ClearAutoRenewFailedAt clears the value of the "auto_renew_failed_at" field.
+func (u *UserSubscriptionUpsertOne) ClearAutoRenewFailedAt() *UserSubscriptionUpsertOne {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.ClearAutoRenewFailedAt()
+	})
+}
+
 // Exec executes the query.
 func (u *UserSubscriptionUpsertOne) Exec(ctx context.Context) error {
 	if len(u.create.conflict) == 0 {
@@ -1676,6 +1784,41 @@ func (u *UserSubscriptionUpsertBulk) ClearNotes() *UserSubscriptionUpsertBulk {
 	})
 }
 
+// SetAutoRenew sets the "auto_renew" field.
+func (u *UserSubscriptionUpsertBulk) SetAutoRenew(v bool) *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetAutoRenew(v)
+	})
+}
+
+// UpdateAutoRenew sets the "auto_renew" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertBulk) UpdateAutoRenew() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateAutoRenew()
+	})
+}
+
+// SetAutoRenewFailedAt sets the "auto_renew_failed_at" field.
+func (u *UserSubscriptionUpsertBulk) SetAutoRenewFailedAt(v time.Time) *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.SetAutoRenewFailedAt(v)
+	})
+}
+
+// UpdateAutoRenewFailedAt sets the "auto_renew_failed_at" field to the value that was provided on create.
+func (u *UserSubscriptionUpsertBulk) UpdateAutoRenewFailedAt() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.UpdateAutoRenewFailedAt()
+	})
+}
+
+// ClearAutoRenewFailedAt clears the value of the "auto_renew_failed_at" field.
+func (u *UserSubscriptionUpsertBulk) ClearAutoRenewFailedAt() *UserSubscriptionUpsertBulk {
+	return u.Update(func(s *UserSubscriptionUpsert) {
+		s.ClearAutoRenewFailedAt()
+	})
+}
+
 // Exec executes the query.
 func (u *UserSubscriptionUpsertBulk) Exec(ctx context.Context) error {
 	if u.create.err != nil {
@@ -304,6 +304,40 @@ func (_u *UserSubscriptionUpdate) ClearNotes() *UserSubscriptionUpdate {
 	return _u
 }
 
+// SetAutoRenew sets the "auto_renew" field.
+func (_u *UserSubscriptionUpdate) SetAutoRenew(v bool) *UserSubscriptionUpdate {
+	_u.mutation.SetAutoRenew(v)
+	return _u
+}
+
+// SetNillableAutoRenew sets the "auto_renew" field if the given value is not nil.
+func (_u *UserSubscriptionUpdate) SetNillableAutoRenew(v *bool) *UserSubscriptionUpdate {
+	if v != nil {
+		_u.SetAutoRenew(*v)
+	}
+	return _u
+}
+
+// SetAutoRenewFailedAt sets the "auto_renew_failed_at" field.
+func (_u *UserSubscriptionUpdate) SetAutoRenewFailedAt(v time.Time) *UserSubscriptionUpdate {
+	_u.mutation.SetAutoRenewFailedAt(v)
+	return _u
+}
+
+// SetNillableAutoRenewFailedAt sets the "auto_renew_failed_at" field if the given value is not nil.
+func (_u *UserSubscriptionUpdate) SetNillableAutoRenewFailedAt(v *time.Time) *UserSubscriptionUpdate {
+	if v != nil {
+		_u.SetAutoRenewFailedAt(*v)
+	}
+	return _u
+}
+
+// ClearAutoRenewFailedAt clears the value of the "auto_renew_failed_at" field.
+func (_u *UserSubscriptionUpdate) ClearAutoRenewFailedAt() *UserSubscriptionUpdate {
+	_u.mutation.ClearAutoRenewFailedAt()
+	return _u
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_u *UserSubscriptionUpdate) SetUser(v *User) *UserSubscriptionUpdate {
 	return _u.SetUserID(v.ID)
@@ -525,6 +559,15 @@ func (_u *UserSubscriptionUpdate) sqlSave(ctx context.Context) (_node int, err e
 	if _u.mutation.NotesCleared() {
 		_spec.ClearField(usersubscription.FieldNotes, field.TypeString)
 	}
+	if value, ok := _u.mutation.AutoRenew(); ok {
+		_spec.SetField(usersubscription.FieldAutoRenew, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.AutoRenewFailedAt(); ok {
+		_spec.SetField(usersubscription.FieldAutoRenewFailedAt, field.TypeTime, value)
+	}
+	if _u.mutation.AutoRenewFailedAtCleared() {
+		_spec.ClearField(usersubscription.FieldAutoRenewFailedAt, field.TypeTime)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -950,6 +993,40 @@ func (_u *UserSubscriptionUpdateOne) ClearNotes() *UserSubscriptionUpdateOne {
 	return _u
 }
 
+// SetAutoRenew sets the "auto_renew" field.
+func (_u *UserSubscriptionUpdateOne) SetAutoRenew(v bool) *UserSubscriptionUpdateOne {
+	_u.mutation.SetAutoRenew(v)
+	return _u
+}
+
+// SetNillableAutoRenew sets the "auto_renew" field if the given value is not nil.
+func (_u *UserSubscriptionUpdateOne) SetNillableAutoRenew(v *bool) *UserSubscriptionUpdateOne {
+	if v != nil {
+		_u.SetAutoRenew(*v)
+	}
+	return _u
+}
+
+// SetAutoRenewFailedAt sets the "auto_renew_failed_at" field.
+func (_u *UserSubscriptionUpdateOne) SetAutoRenewFailedAt(v time.Time) *UserSubscriptionUpdateOne {
+	_u.mutation.SetAutoRenewFailedAt(v)
+	return _u
+}
+
+// SetNillableAutoRenewFailedAt sets the "auto_renew_failed_at" field if the given value is not nil.
+func (_u *UserSubscriptionUpdateOne) SetNillableAutoRenewFailedAt(v *time.Time) *UserSubscriptionUpdateOne {
+	if v != nil {
+		_u.SetAutoRenewFailedAt(*v)
+	}
+	return _u
+}
+
+// ClearAutoRenewFailedAt clears the value of the "auto_renew_failed_at" field.
+func (_u *UserSubscriptionUpdateOne) ClearAutoRenewFailedAt() *UserSubscriptionUpdateOne {
+	_u.mutation.ClearAutoRenewFailedAt()
+	return _u
+}
+
 // SetUser sets the "user" edge to the User entity.
 func (_u *UserSubscriptionUpdateOne) SetUser(v *User) *UserSubscriptionUpdateOne {
 	return _u.SetUserID(v.ID)
@@ -1201,6 +1278,15 @@ func (_u *UserSubscriptionUpdateOne) sqlSave(ctx context.Context) (_node *UserSu
 	if _u.mutation.NotesCleared() {
 		_spec.ClearField(usersubscription.FieldNotes, field.TypeString)
 	}
+	if value, ok := _u.mutation.AutoRenew(); ok {
+		_spec.SetField(usersubscription.FieldAutoRenew, field.TypeBool, value)
+	}
+	if value, ok := _u.mutation.AutoRenewFailedAt(); ok {
+		_spec.SetField(usersubscription.FieldAutoRenewFailedAt, field.TypeTime, value)
+	}
+	if _u.mutation.AutoRenewFailedAtCleared() {
+		_spec.ClearField(usersubscription.FieldAutoRenewFailedAt, field.TypeTime)
+	}
 	if _u.mutation.UserCleared() {
 		edge := &sqlgraph.EdgeSpec{
 			Rel:     sqlgraph.M2O,
@@ -64,6 +64,8 @@ type Config struct {
 	Ops                     OpsConfig                     `mapstructure:"ops"`
 	JWT                     JWTConfig                     `mapstructure:"jwt"`
 	Totp                    TotpConfig                    `mapstructure:"totp"`
+	CredentialEncryption    CredentialEncryptionConfig    `mapstructure:"credential_encryption"`
+	APIKeyHash              APIKeyHashConfig              `mapstructure:"api_key_hash"`
 	LinuxDo                 LinuxDoConnectConfig          `mapstructure:"linuxdo_connect"`
 	Default                 DefaultConfig                 `mapstructure:"default"`
 	RateLimit               RateLimitConfig               `mapstructure:"rate_limit"`
@@ -72,6 +74,8 @@ type Config struct {
 	APIKeyAuth              APIKeyAuthCacheConfig         `mapstructure:"api_key_auth_cache"`
 	SubscriptionCache       SubscriptionCacheConfig       `mapstructure:"subscription_cache"`
 	SubscriptionMaintenance SubscriptionMaintenanceConfig `mapstructure:"subscription_maintenance"`
+	SubscriptionRenewal     SubscriptionRenewalConfig     `mapstructure:"subscription_renewal"`
+	Referral                ReferralConfig                `mapstructure:"referral"`
 	Dashboard               DashboardCacheConfig          `mapstructure:"dashboard_cache"`
 	DashboardAgg            DashboardAggregationConfig    `mapstructure:"dashboard_aggregation"`
 	UsageCleanup            UsageCleanupConfig            `mapstructure:"usage_cleanup"`
@@ -82,6 +86,12 @@ type Config struct {
 	Gemini                  GeminiConfig                  `mapstructure:"gemini"`
 	Update                  UpdateConfig                  `mapstructure:"update"`
 	Idempotency             IdempotencyConfig             `mapstructure:"idempotency"`
+	Metrics                 MetricsConfig                 `mapstructure:"metrics"`
+	Payment                 PaymentConfig                 `mapstructure:"payment"`
+	AdminAudit              AdminAuditConfig              `mapstructure:"admin_audit"`
+	PayloadCapture          PayloadCaptureConfig          `mapstructure:"payload_capture"`
+	Guardrail               GuardrailConfig               `mapstructure:"guardrail"`
+	ProxyPool               ProxyPoolConfig               `mapstructure:"proxy_pool"`
 }
 
 type LogConfig struct {
@@ -164,6 +174,103 @@ type IdempotencyConfig struct {
 	CleanupBatchSize int `mapstructure:"cleanup_batch_size"`
 }
 
+// MetricsConfig Prometheus 指标导出配置
+type MetricsConfig struct {
+	// Enabled 是否启用 Prometheus 文本格式指标端点
+	Enabled bool `mapstructure:"enabled"`
+	// ListenAddr 独立监听地址（如 "127.0.0.1:9464"）；为空时挂载到主服务端口
+	ListenAddr string `mapstructure:"listen_addr"`
+	// Path 指标端点路径
+	Path string `mapstructure:"path"`
+	// BearerToken 抓取鉴权令牌；为空时不鉴权（建议配合独立监听地址仅内网暴露）
+	BearerToken string `mapstructure:"bearer_token"`
+}
+
+// AdminAuditConfig 管理后台写操作审计日志配置
+type AdminAuditConfig struct {
+	// Enabled 是否记录管理后台写操作（POST/PUT/PATCH/DELETE）
+	Enabled bool `mapstructure:"enabled"`
+	// MaxBodyBytes 请求体记录上限（字节），超出时仅记录截断标记
+	MaxBodyBytes int `mapstructure:"max_body_bytes"`
+}
+
+// PayloadCaptureConfig 网关请求/响应载荷抓取配置（按分组或 API Key 规则显式开启）
+type PayloadCaptureConfig struct {
+	// Enabled 总开关；关闭后忽略所有抓取规则
+	Enabled bool `mapstructure:"enabled"`
+	// MaxBodyBytes 单个请求体/响应体的记录上限（字节），规则未设置或设置更大时使用该值
+	MaxBodyBytes int `mapstructure:"max_body_bytes"`
+	// QueueSize 异步写入队列长度，队列满时丢弃抓取记录
+	QueueSize int `mapstructure:"queue_size"`
+}
+
+// GuardrailConfig 网关请求安全策略（PII 脱敏 / 拦截）配置，具体规则按分组在管理后台维护
+type GuardrailConfig struct {
+	// Enabled 总开关；关闭后忽略所有分组的安全策略规则
+	Enabled bool `mapstructure:"enabled"`
+	// WebhookTimeoutMs 外部 webhook 检查器的默认超时（毫秒），规则未设置超时时使用
+	WebhookTimeoutMs int `mapstructure:"webhook_timeout_ms"`
+}
+
+// ProxyPoolConfig 代理池健康探测与故障转移配置，代理池本身在管理后台维护
+type ProxyPoolConfig struct {
+	// HealthCheckIntervalSeconds 后台健康探测周期（秒），0 表示关闭主动探测（仍按请求失败被动标记）
+	HealthCheckIntervalSeconds int `mapstructure:"health_check_interval_seconds"`
+	// FailureThreshold 连续失败多少次后将代理标记为不可用
+	FailureThreshold int `mapstructure:"failure_threshold"`
+	// MaxFailoverAttempts 单次上游请求最多尝试的池内代理数（含首个）
+	MaxFailoverAttempts int `mapstructure:"max_failover_attempts"`
+}
+
+// PaymentConfig 自助充值（在线支付）配置
+type PaymentConfig struct {
+	// Enabled 是否开放用户自助充值
+	Enabled bool `mapstructure:"enabled"`
+	// OrderExpireMinutes 待支付订单有效期（分钟），超时后不再接受创建支付
+	OrderExpireMinutes int `mapstructure:"order_expire_minutes"`
+	// MinAmount / MaxAmount 单笔余额充值金额范围（支付币种）；MaxAmount 为 0 表示不限制
+	MinAmount float64 `mapstructure:"min_amount"`
+	MaxAmount float64 `mapstructure:"max_amount"`
+	// Currency 支付币种（ISO 4217，如 "usd"、"cny"）
+	Currency string `mapstructure:"currency"`
+	// BalanceRate 每 1 单位支付金额到账的余额（美元）
+	BalanceRate float64 `mapstructure:"balance_rate"`
+	// ReturnURL 支付完成后浏览器跳转的前端地址
+	ReturnURL string `mapstructure:"return_url"`
+	// NotifyBaseURL 对外可访问的后端地址，用于拼接异步回调地址 {notify_base_url}/api/v1/payment/notify/{provider}
+	NotifyBaseURL string `mapstructure:"notify_base_url"`
+
+	Stripe PaymentStripeConfig `mapstructure:"stripe"`
+	EPay   PaymentEPayConfig   `mapstructure:"epay"`
+	Mock   PaymentMockConfig   `mapstructure:"mock"`
+}
+
+// PaymentStripeConfig Stripe Checkout 配置
+type PaymentStripeConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	SecretKey     string `mapstructure:"secret_key"`
+	WebhookSecret string `mapstructure:"webhook_secret"`
+	// APIBase Stripe API 地址（默认 https://api.stripe.com，可指向代理）
+	APIBase string `mapstructure:"api_base"`
+}
+
+// PaymentEPayConfig 易支付（EPay 协议兼容）网关配置
+type PaymentEPayConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// GatewayURL 网关地址（如 https://pay.example.com），下单地址为 {gateway_url}/submit.php
+	GatewayURL string `mapstructure:"gateway_url"`
+	PID        string `mapstructure:"pid"`
+	Key        string `mapstructure:"key"`
+	// Types 允许的支付方式（alipay / wxpay / qqpay 等），为空时默认 alipay
+	Types []string `mapstructure:"types"`
+}
+
+// PaymentMockConfig 模拟支付（仅用于测试环境联调，回调使用 HMAC-SHA256 签名）
+type PaymentMockConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Secret  string `mapstructure:"secret"`
+}
+
 type LinuxDoConnectConfig struct {
 	Enabled             bool   `mapstructure:"enabled"`
 	ClientID            string `mapstructure:"client_id"`
@@ -393,6 +500,74 @@ type GatewayConfig struct {
 	// UserMessageQueue: 用户消息串行队列配置
 	// 对 role:"user" 的真实用户消息实施账号级串行化 + RPM 自适应延迟
 	UserMessageQueue UserMessageQueueConfig `mapstructure:"user_message_queue"`
+
+	// MessageBatches: Anthropic Message Batches API（/v1/messages/batches）配置
+	MessageBatches GatewayMessageBatchesConfig `mapstructure:"message_batches"`
+
+	// OpenAIBatches: OpenAI Files / Batch API（/v1/files、/v1/batches）配置
+	OpenAIBatches GatewayOpenAIBatchesConfig `mapstructure:"openai_batches"`
+
+	// ResponseCache: 确定性请求精确匹配响应缓存配置（需分组开启 response_cache_enabled）
+	ResponseCache GatewayResponseCacheConfig `mapstructure:"response_cache"`
+}
+
+// GatewayResponseCacheConfig 响应缓存配置
+// 仅缓存 temperature=0 的请求；命中时直接回放原始响应（SSE 或 JSON），不请求上游。
+type GatewayResponseCacheConfig struct {
+	// Enabled: 全局开关，关闭后所有分组均不读写缓存
+	Enabled bool `mapstructure:"enabled"`
+	// TTLSeconds: 缓存条目存活时间（秒）
+	TTLSeconds int `mapstructure:"ttl_seconds"`
+	// MaxEntryBytes: 单条缓存响应体的最大字节数，超出则不缓存
+	MaxEntryBytes int `mapstructure:"max_entry_bytes"`
+	// MaxEntriesPerGroup: 单个分组最多缓存的条目数，超出时按写入时间淘汰最旧条目（0 表示不限）
+	MaxEntriesPerGroup int `mapstructure:"max_entries_per_group"`
+	// MaxBytesPerGroup: 单个分组缓存条目（序列化后）的总字节数上限，超出时按写入时间淘汰最旧条目（0 表示不限）
+	MaxBytesPerGroup int `mapstructure:"max_bytes_per_group"`
+	// HitPriceMultiplier: 命中缓存时的计费倍率（叠加在分组倍率之上，0 表示免费）
+	HitPriceMultiplier float64 `mapstructure:"hit_price_multiplier"`
+}
+
+// GatewayMessageBatchesConfig Anthropic Message Batches 配置
+// API Key 账号透传到上游批处理接口；其余账号由本地执行器逐条调用 /v1/messages。
+type GatewayMessageBatchesConfig struct {
+	// Enabled: 是否开放 /v1/messages/batches 端点
+	Enabled bool `mapstructure:"enabled"`
+	// StorageDir: 批处理请求与结果 JSONL 文件的存放目录
+	StorageDir string `mapstructure:"storage_dir"`
+	// Concurrency: 本地执行器单个批次的最大并发请求数
+	Concurrency int `mapstructure:"concurrency"`
+	// MaxRequests: 单个批次允许的最大请求数
+	MaxRequests int `mapstructure:"max_requests"`
+	// DiscountMultiplier: 批处理计费折扣倍率（叠加在分组倍率之上，0.5 即五折）
+	DiscountMultiplier float64 `mapstructure:"discount_multiplier"`
+	// ResultsRetentionHours: 结果文件保留时长（小时），过期后删除文件与记录
+	ResultsRetentionHours int `mapstructure:"results_retention_hours"`
+}
+
+// GatewayOpenAIBatchesConfig OpenAI Files / Batch API 配置
+type GatewayOpenAIBatchesConfig struct {
+	// Enabled: 是否为 OpenAI 分组开放 /v1/files 与 /v1/batches 端点
+	Enabled bool `mapstructure:"enabled"`
+	// StorageBackend: 文件内容存储后端，local（本地磁盘）或 s3（复用数据备份的 S3 存储配置）
+	StorageBackend string `mapstructure:"storage_backend"`
+	// StorageDir: 本地目录，存放 local 后端的文件内容以及执行中批次的中间结果
+	StorageDir string `mapstructure:"storage_dir"`
+	// S3Prefix: s3 后端的对象 key 前缀
+	S3Prefix string `mapstructure:"s3_prefix"`
+	// MaxFileSizeMB: 单个上传文件的最大大小（MB）
+	MaxFileSizeMB int `mapstructure:"max_file_size_mb"`
+	// MaxRequests: 单个批次输入文件允许的最大请求行数
+	MaxRequests int `mapstructure:"max_requests"`
+	// Workers: 全局批处理 worker 数（所有批次共享）
+	Workers int `mapstructure:"workers"`
+	// MaxRetries: 单行请求遇到可重试错误（429/5xx/网络错误）时的最大重试次数
+	MaxRetries int `mapstructure:"max_retries"`
+	// DiscountMultiplier: 默认批处理计费折扣倍率（1 表示不打折）；
+	// 分组配置了 batch_discount_multiplier 时以分组为准
+	DiscountMultiplier float64 `mapstructure:"discount_multiplier"`
+	// FileRetentionHours: 文件保留时长（小时），过期后删除内容与记录
+	FileRetentionHours int `mapstructure:"file_retention_hours"`
 }
 
 // UserMessageQueueConfig 用户消息串行队列配置
@@ -760,6 +935,12 @@ type OpsCleanupConfig struct {
 	ErrorLogRetentionDays      int `mapstructure:"error_log_retention_days"`
 	MinuteMetricsRetentionDays int `mapstructure:"minute_metrics_retention_days"`
 	HourlyMetricsRetentionDays int `mapstructure:"hourly_metrics_retention_days"`
+
+	// AuditLogRetentionDays 管理后台审计日志保留天数（0 表示永久保留）
+	AuditLogRetentionDays int `mapstructure:"audit_log_retention_days"`
+
+	// PayloadCaptureRetentionDays 网关载荷抓取记录保留天数（0 表示永久保留）
+	PayloadCaptureRetentionDays int `mapstructure:"payload_capture_retention_days"`
 }
 
 type OpsAggregationConfig struct {
@@ -794,6 +975,66 @@ type TotpConfig struct {
 	EncryptionKeyConfigured bool `mapstructure:"-"`
 }
 
+// APIKeyHashConfig 用户 API Key 哈希存储配置
+type APIKeyHashConfig struct {
+	// Secret HMAC-SHA256 密钥（至少 32 字节）。为空时自动生成并持久化到数据库；
+	// 多实例部署时以数据库中的值为准。更换密钥会使所有已有 API Key 失效。
+	Secret string `mapstructure:"secret"`
+}
+
+// CredentialEncryptionConfig 上游账号凭证静态加密（信封加密）配置
+//
+// 每个敏感凭证字段使用独立的随机数据密钥（DEK）加密，DEK 再由主密钥（KEK）包裹，
+// 密文中记录主密钥 ID。轮换时新增密钥并切换 ActiveKeyID，旧密钥需保留到重新加密完成。
+type CredentialEncryptionConfig struct {
+	// ActiveKeyID 新写入凭证使用的主密钥 ID；为空表示不加密新写入的凭证（已加密的凭证仍可解密）
+	ActiveKeyID string `mapstructure:"active_key_id"`
+	// Keys 主密钥环：key_id -> AES-256 密钥（32 字节 hex 编码）。key_id 仅允许小写字母、数字、-、_
+	Keys map[string]string `mapstructure:"keys"`
+	// MigrateOnStartup 启动后在后台将明文凭证及旧密钥凭证重新加密为当前主密钥
+	MigrateOnStartup bool `mapstructure:"migrate_on_startup"`
+	// ReencryptBatchSize 重新加密任务每批处理的账号数
+	ReencryptBatchSize int `mapstructure:"reencrypt_batch_size"`
+}
+
+// Enabled 是否对新写入的凭证加密
+func (c CredentialEncryptionConfig) Enabled() bool {
+	return c.ActiveKeyID != ""
+}
+
+func (c CredentialEncryptionConfig) validate() error {
+	for id, key := range c.Keys {
+		if !isValidCredentialKeyID(id) {
+			return fmt.Errorf("credential_encryption.keys: invalid key id %q (allowed: a-z, 0-9, '-', '_', max 32 chars)", id)
+		}
+		raw, err := hex.DecodeString(key)
+		if err != nil || len(raw) != 32 {
+			return fmt.Errorf("credential_encryption.keys.%s must be 32 bytes (64 hex chars)", id)
+		}
+	}
+	if c.ActiveKeyID != "" {
+		if _, ok := c.Keys[c.ActiveKeyID]; !ok {
+			return fmt.Errorf("credential_encryption.active_key_id %q not found in credential_encryption.keys", c.ActiveKeyID)
+		}
+	}
+	if c.ReencryptBatchSize <= 0 {
+		return fmt.Errorf("credential_encryption.reencrypt_batch_size must be positive")
+	}
+	return nil
+}
+
+func isValidCredentialKeyID(id string) bool {
+	if id == "" || len(id) > 32 {
+		return false
+	}
+	for _, r := range id {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '-' && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
 type TurnstileConfig struct {
 	Required bool `mapstructure:"required"`
 }
@@ -836,6 +1077,32 @@ type SubscriptionMaintenanceConfig struct {
 	QueueSize   int `mapstructure:"queue_size"`
 }
 
+// SubscriptionRenewalConfig 订阅自动续订配置（由订阅到期任务执行）
+type SubscriptionRenewalConfig struct {
+	// RenewBeforeHours 到期前多少小时开始尝试从余额扣费续订
+	RenewBeforeHours int `mapstructure:"renew_before_hours"`
+	// RetryIntervalMinutes 续订失败（如余额不足）后的重试间隔
+	RetryIntervalMinutes int `mapstructure:"retry_interval_minutes"`
+	// BatchSize 每轮最多处理的订阅数
+	BatchSize int `mapstructure:"batch_size"`
+}
+
+// ReferralConfig 邀请返佣配置
+type ReferralConfig struct {
+	// Enabled 是否开启邀请返佣（关闭时不绑定新的邀请关系，也不结算）
+	Enabled bool `mapstructure:"enabled"`
+	// CommissionRate 返佣比例（0~1），按被邀请人 usage_logs.actual_cost 计算
+	CommissionRate float64 `mapstructure:"commission_rate"`
+	// CommissionDays 被邀请人注册后多少天内的消费计入返佣，0 表示不限
+	CommissionDays int `mapstructure:"commission_days"`
+	// MaxCommissionPerInvitee 单个被邀请人累计返佣上限（USD），0 表示不限
+	MaxCommissionPerInvitee float64 `mapstructure:"max_commission_per_invitee"`
+	// SettleIntervalMinutes 结算任务执行间隔
+	SettleIntervalMinutes int `mapstructure:"settle_interval_minutes"`
+	// BatchSize 每次查询的邀请关系数
+	BatchSize int `mapstructure:"batch_size"`
+}
+
 // DashboardCacheConfig 仪表盘统计缓存配置
 type DashboardCacheConfig struct {
 	// Enabled: 是否启用仪表盘缓存
@@ -956,6 +1223,7 @@ func load(allowMissingJWTSecret bool) (*Config, error) {
 	}
 	cfg.Server.FrontendURL = strings.TrimSpace(cfg.Server.FrontendURL)
 	cfg.JWT.Secret = strings.TrimSpace(cfg.JWT.Secret)
+	cfg.APIKeyHash.Secret = strings.TrimSpace(cfg.APIKeyHash.Secret)
 	cfg.LinuxDo.ClientID = strings.TrimSpace(cfg.LinuxDo.ClientID)
 	cfg.LinuxDo.ClientSecret = strings.TrimSpace(cfg.LinuxDo.ClientSecret)
 	cfg.LinuxDo.AuthorizeURL = strings.TrimSpace(cfg.LinuxDo.AuthorizeURL)
@@ -979,6 +1247,15 @@ func load(allowMissingJWTSecret bool) (*Config, error) {
 	cfg.Log.Environment = strings.TrimSpace(cfg.Log.Environment)
 	cfg.Log.StacktraceLevel = strings.ToLower(strings.TrimSpace(cfg.Log.StacktraceLevel))
 	cfg.Log.Output.FilePath = strings.TrimSpace(cfg.Log.Output.FilePath)
+	cfg.Metrics.ListenAddr = strings.TrimSpace(cfg.Metrics.ListenAddr)
+	cfg.Metrics.Path = strings.TrimSpace(cfg.Metrics.Path)
+	cfg.Metrics.BearerToken = strings.TrimSpace(cfg.Metrics.BearerToken)
+	cfg.Payment.Currency = strings.ToLower(strings.TrimSpace(cfg.Payment.Currency))
+	cfg.Payment.ReturnURL = strings.TrimSpace(cfg.Payment.ReturnURL)
+	cfg.Payment.NotifyBaseURL = strings.TrimRight(strings.TrimSpace(cfg.Payment.NotifyBaseURL), "/")
+	cfg.Payment.Stripe.APIBase = strings.TrimRight(strings.TrimSpace(cfg.Payment.Stripe.APIBase), "/")
+	cfg.Payment.EPay.GatewayURL = strings.TrimRight(strings.TrimSpace(cfg.Payment.EPay.GatewayURL), "/")
+	cfg.Payment.EPay.Types = normalizeStringSlice(cfg.Payment.EPay.Types)
 
 	// 兼容旧键 gateway.openai_ws.sticky_previous_response_ttl_seconds。
 	// 新键未配置（<=0）时回退旧键；新键优先。
@@ -1008,6 +1285,15 @@ func load(allowMissingJWTSecret bool) (*Config, error) {
 		cfg.Totp.EncryptionKeyConfigured = true
 	}
 
+	cfg.CredentialEncryption.ActiveKeyID = strings.ToLower(strings.TrimSpace(cfg.CredentialEncryption.ActiveKeyID))
+	if len(cfg.CredentialEncryption.Keys) > 0 {
+		keys := make(map[string]string, len(cfg.CredentialEncryption.Keys))
+		for id, key := range cfg.CredentialEncryption.Keys {
+			keys[strings.ToLower(strings.TrimSpace(id))] = strings.TrimSpace(key)
+		}
+		cfg.CredentialEncryption.Keys = keys
+	}
+
 	originalJWTSecret := cfg.JWT.Secret
 	if allowMissingJWTSecret && originalJWTSecret == "" {
 		// 启动阶段允许先无 JWT 密钥，后续在数据库初始化后补齐。
@@ -1171,6 +1457,8 @@ func setDefaults() {
 	viper.SetDefault("ops.cleanup.error_log_retention_days", 30)
 	viper.SetDefault("ops.cleanup.minute_metrics_retention_days", 30)
 	viper.SetDefault("ops.cleanup.hourly_metrics_retention_days", 30)
+	viper.SetDefault("ops.cleanup.audit_log_retention_days", 180)
+	viper.SetDefault("ops.cleanup.payload_capture_retention_days", 7)
 	viper.SetDefault("ops.aggregation.enabled", true)
 	viper.SetDefault("ops.metrics_collector_cache.enabled", true)
 	// TTL should be slightly larger than collection interval (1m) to maximize cross-replica cache hits.
@@ -1186,6 +1474,12 @@ func setDefaults() {
 	// TOTP
 	viper.SetDefault("totp.encryption_key", "")
 
+	// Credential encryption
+	viper.SetDefault("api_key_hash.secret", "")
+	viper.SetDefault("credential_encryption.active_key_id", "")
+	viper.SetDefault("credential_encryption.migrate_on_startup", true)
+	viper.SetDefault("credential_encryption.reencrypt_batch_size", 200)
+
 	// Default
 	// Admin credentials are created via the setup flow (web wizard / CLI / AUTO_SETUP).
 	// Do not ship fixed defaults here to avoid insecure "known credentials" in production.
@@ -1260,6 +1554,45 @@ func setDefaults() {
 	viper.SetDefault("idempotency.cleanup_interval_seconds", 60)
 	viper.SetDefault("idempotency.cleanup_batch_size", 500)
 
+	// Metrics (Prometheus)
+	viper.SetDefault("metrics.enabled", false)
+	viper.SetDefault("metrics.listen_addr", "")
+	viper.SetDefault("metrics.path", "/metrics")
+	viper.SetDefault("metrics.bearer_token", "")
+
+	// Payment (self-service top-up)
+	viper.SetDefault("payment.enabled", false)
+	viper.SetDefault("payment.order_expire_minutes", 30)
+	viper.SetDefault("payment.min_amount", 1)
+	viper.SetDefault("payment.max_amount", 0)
+	viper.SetDefault("payment.currency", "usd")
+	viper.SetDefault("payment.balance_rate", 1)
+	viper.SetDefault("payment.return_url", "")
+	viper.SetDefault("payment.notify_base_url", "")
+	viper.SetDefault("payment.stripe.enabled", false)
+	viper.SetDefault("payment.stripe.api_base", "https://api.stripe.com")
+	viper.SetDefault("payment.epay.enabled", false)
+	viper.SetDefault("payment.epay.types", []string{"alipay"})
+	viper.SetDefault("payment.mock.enabled", false)
+
+	// Admin audit log
+	viper.SetDefault("admin_audit.enabled", true)
+	viper.SetDefault("admin_audit.max_body_bytes", 16*1024)
+
+	// Payload capture
+	viper.SetDefault("payload_capture.enabled", true)
+	viper.SetDefault("payload_capture.max_body_bytes", 256*1024)
+	viper.SetDefault("payload_capture.queue_size", 256)
+
+	// Guardrail
+	viper.SetDefault("guardrail.enabled", true)
+	viper.SetDefault("guardrail.webhook_timeout_ms", 3000)
+
+	// Proxy pool
+	viper.SetDefault("proxy_pool.health_check_interval_seconds", 60)
+	viper.SetDefault("proxy_pool.failure_threshold", 3)
+	viper.SetDefault("proxy_pool.max_failover_attempts", 3)
+
 	// Gateway
 	viper.SetDefault("gateway.response_header_timeout", 600) // 600秒(10分钟)等待上游响应头，LLM高负载时可能排队较久
 	viper.SetDefault("gateway.log_upstream_error_body", true)
@@ -1374,6 +1707,28 @@ func setDefaults() {
 	viper.SetDefault("gateway.user_message_queue.min_delay_ms", 200)
 	viper.SetDefault("gateway.user_message_queue.max_delay_ms", 2000)
 	viper.SetDefault("gateway.user_message_queue.cleanup_interval_seconds", 60)
+	viper.SetDefault("gateway.message_batches.enabled", true)
+	viper.SetDefault("gateway.message_batches.storage_dir", "./data/message_batches")
+	viper.SetDefault("gateway.message_batches.concurrency", 4)
+	viper.SetDefault("gateway.message_batches.max_requests", 100000)
+	viper.SetDefault("gateway.message_batches.discount_multiplier", 0.5)
+	viper.SetDefault("gateway.message_batches.results_retention_hours", 29*24)
+	viper.SetDefault("gateway.openai_batches.enabled", true)
+	viper.SetDefault("gateway.openai_batches.storage_backend", "local")
+	viper.SetDefault("gateway.openai_batches.storage_dir", "./data/openai_files")
+	viper.SetDefault("gateway.openai_batches.s3_prefix", "openai-files")
+	viper.SetDefault("gateway.openai_batches.max_file_size_mb", 200)
+	viper.SetDefault("gateway.openai_batches.max_requests", 50000)
+	viper.SetDefault("gateway.openai_batches.workers", 8)
+	viper.SetDefault("gateway.openai_batches.max_retries", 3)
+	viper.SetDefault("gateway.openai_batches.discount_multiplier", 1.0)
+	viper.SetDefault("gateway.openai_batches.file_retention_hours", 30*24)
+	viper.SetDefault("gateway.response_cache.enabled", true)
+	viper.SetDefault("gateway.response_cache.ttl_seconds", 3600)
+	viper.SetDefault("gateway.response_cache.max_entry_bytes", 1<<20)
+	viper.SetDefault("gateway.response_cache.max_entries_per_group", 10000)
+	viper.SetDefault("gateway.response_cache.max_bytes_per_group", 256<<20)
+	viper.SetDefault("gateway.response_cache.hit_price_multiplier", 0.1)
 
 	viper.SetDefault("gateway.tls_fingerprint.enabled", true)
 	viper.SetDefault("concurrency.ping_interval", 10)
@@ -1397,6 +1752,19 @@ func setDefaults() {
 	viper.SetDefault("subscription_maintenance.worker_count", 2)
 	viper.SetDefault("subscription_maintenance.queue_size", 1024)
 
+	// Subscription Renewal
+	viper.SetDefault("subscription_renewal.renew_before_hours", 24)
+	viper.SetDefault("subscription_renewal.retry_interval_minutes", 60)
+	viper.SetDefault("subscription_renewal.batch_size", 100)
+
+	// Referral
+	viper.SetDefault("referral.enabled", false)
+	viper.SetDefault("referral.commission_rate", 0.1)
+	viper.SetDefault("referral.commission_days", 0)
+	viper.SetDefault("referral.max_commission_per_invitee", 0)
+	viper.SetDefault("referral.settle_interval_minutes", 60)
+	viper.SetDefault("referral.batch_size", 500)
+
 }
 
 func (c *Config) Validate() error {
@@ -1464,6 +1832,30 @@ func (c *Config) Validate() error {
 	if c.SubscriptionMaintenance.QueueSize < 0 {
 		return fmt.Errorf("subscription_maintenance.queue_size must be non-negative")
 	}
+	if c.SubscriptionRenewal.RenewBeforeHours < 0 {
+		return fmt.Errorf("subscription_renewal.renew_before_hours must be non-negative")
+	}
+	if c.SubscriptionRenewal.RetryIntervalMinutes <= 0 {
+		return fmt.Errorf("subscription_renewal.retry_interval_minutes must be positive")
+	}
+	if c.SubscriptionRenewal.BatchSize <= 0 {
+		return fmt.Errorf("subscription_renewal.batch_size must be positive")
+	}
+	if c.Referral.CommissionRate < 0 || c.Referral.CommissionRate > 1 {
+		return fmt.Errorf("referral.commission_rate must be between 0 and 1")
+	}
+	if c.Referral.CommissionDays < 0 {
+		return fmt.Errorf("referral.commission_days must be non-negative")
+	}
+	if c.Referral.MaxCommissionPerInvitee < 0 {
+		return fmt.Errorf("referral.max_commission_per_invitee must be non-negative")
+	}
+	if c.Referral.SettleIntervalMinutes <= 0 {
+		return fmt.Errorf("referral.settle_interval_minutes must be positive")
+	}
+	if c.Referral.BatchSize <= 0 {
+		return fmt.Errorf("referral.batch_size must be positive")
+	}
 
 	// Gemini OAuth 配置校验：client_id 与 client_secret 必须同时设置或同时留空。
 	// 留空时表示使用内置的 Gemini CLI OAuth 客户端（其 client_secret 通过环境变量注入）。
@@ -1750,6 +2142,39 @@ func (c *Config) Validate() error {
 	if c.Idempotency.CleanupBatchSize <= 0 {
 		return fmt.Errorf("idempotency.cleanup_batch_size must be positive")
 	}
+	if c.Metrics.Enabled && !strings.HasPrefix(c.Metrics.Path, "/") {
+		return fmt.Errorf("metrics.path must start with /")
+	}
+	if err := c.Payment.validate(); err != nil {
+		return err
+	}
+	if c.AdminAudit.Enabled && c.AdminAudit.MaxBodyBytes <= 0 {
+		return fmt.Errorf("admin_audit.max_body_bytes must be positive")
+	}
+	if c.PayloadCapture.Enabled && c.PayloadCapture.MaxBodyBytes <= 0 {
+		return fmt.Errorf("payload_capture.max_body_bytes must be positive")
+	}
+	if c.PayloadCapture.Enabled && c.PayloadCapture.QueueSize <= 0 {
+		return fmt.Errorf("payload_capture.queue_size must be positive")
+	}
+	if c.Guardrail.Enabled && c.Guardrail.WebhookTimeoutMs <= 0 {
+		return fmt.Errorf("guardrail.webhook_timeout_ms must be positive")
+	}
+	if c.APIKeyHash.Secret != "" && len([]byte(c.APIKeyHash.Secret)) < 32 {
+		return fmt.Errorf("api_key_hash.secret must be at least 32 bytes")
+	}
+	if err := c.CredentialEncryption.validate(); err != nil {
+		return err
+	}
+	if c.ProxyPool.HealthCheckIntervalSeconds < 0 {
+		return fmt.Errorf("proxy_pool.health_check_interval_seconds must be non-negative")
+	}
+	if c.ProxyPool.FailureThreshold <= 0 {
+		return fmt.Errorf("proxy_pool.failure_threshold must be positive")
+	}
+	if c.ProxyPool.MaxFailoverAttempts <= 0 {
+		return fmt.Errorf("proxy_pool.max_failover_attempts must be positive")
+	}
 	if c.Gateway.MaxBodySize <= 0 {
 		return fmt.Errorf("gateway.max_body_size must be positive")
 	}
@@ -1989,6 +2414,71 @@ func (c *Config) Validate() error {
 	if c.Gateway.ModelsListCacheTTLSeconds < 10 || c.Gateway.ModelsListCacheTTLSeconds > 30 {
 		return fmt.Errorf("gateway.models_list_cache_ttl_seconds must be between 10-30")
 	}
+	if c.Gateway.MessageBatches.Enabled {
+		if strings.TrimSpace(c.Gateway.MessageBatches.StorageDir) == "" {
+			return fmt.Errorf("gateway.message_batches.storage_dir is required when message batches are enabled")
+		}
+		if c.Gateway.MessageBatches.Concurrency <= 0 {
+			return fmt.Errorf("gateway.message_batches.concurrency must be positive")
+		}
+		if c.Gateway.MessageBatches.MaxRequests <= 0 {
+			return fmt.Errorf("gateway.message_batches.max_requests must be positive")
+		}
+		if c.Gateway.MessageBatches.DiscountMultiplier <= 0 || c.Gateway.MessageBatches.DiscountMultiplier > 1 {
+			return fmt.Errorf("gateway.message_batches.discount_multiplier must be in (0, 1]")
+		}
+		if c.Gateway.MessageBatches.ResultsRetentionHours <= 0 {
+			return fmt.Errorf("gateway.message_batches.results_retention_hours must be positive")
+		}
+	}
+	if c.Gateway.OpenAIBatches.Enabled {
+		switch c.Gateway.OpenAIBatches.StorageBackend {
+		case "local", "s3":
+		default:
+			return fmt.Errorf("gateway.openai_batches.storage_backend must be one of: local, s3")
+		}
+		if strings.TrimSpace(c.Gateway.OpenAIBatches.StorageDir) == "" {
+			return fmt.Errorf("gateway.openai_batches.storage_dir is required when openai batches are enabled")
+		}
+		if c.Gateway.OpenAIBatches.MaxFileSizeMB <= 0 {
+			return fmt.Errorf("gateway.openai_batches.max_file_size_mb must be positive")
+		}
+		if c.Gateway.OpenAIBatches.MaxRequests <= 0 {
+			return fmt.Errorf("gateway.openai_batches.max_requests must be positive")
+		}
+		if c.Gateway.OpenAIBatches.Workers <= 0 {
+			return fmt.Errorf("gateway.openai_batches.workers must be positive")
+		}
+		if c.Gateway.OpenAIBatches.MaxRetries < 0 {
+			return fmt.Errorf("gateway.openai_batches.max_retries must be non-negative")
+		}
+		if c.Gateway.OpenAIBatches.DiscountMultiplier <= 0 || c.Gateway.OpenAIBatches.DiscountMultiplier > 1 {
+			return fmt.Errorf("gateway.openai_batches.discount_multiplier must be in (0, 1]")
+		}
+		if c.Gateway.OpenAIBatches.FileRetentionHours <= 0 {
+			return fmt.Errorf("gateway.openai_batches.file_retention_hours must be positive")
+		}
+	}
+	if c.Gateway.ResponseCache.Enabled {
+		if c.Gateway.ResponseCache.TTLSeconds <= 0 {
+			return fmt.Errorf("gateway.response_cache.ttl_seconds must be positive")
+		}
+		if c.Gateway.ResponseCache.MaxEntryBytes <= 0 {
+			return fmt.Errorf("gateway.response_cache.max_entry_bytes must be positive")
+		}
+		if c.Gateway.ResponseCache.MaxEntriesPerGroup < 0 {
+			return fmt.Errorf("gateway.response_cache.max_entries_per_group must be non-negative")
+		}
+		if c.Gateway.ResponseCache.MaxBytesPerGroup < 0 {
+			return fmt.Errorf("gateway.response_cache.max_bytes_per_group must be non-negative")
+		}
+		if c.Gateway.ResponseCache.MaxBytesPerGroup > 0 && c.Gateway.ResponseCache.MaxBytesPerGroup < c.Gateway.ResponseCache.MaxEntryBytes {
+			return fmt.Errorf("gateway.response_cache.max_bytes_per_group must be >= max_entry_bytes")
+		}
+		if c.Gateway.ResponseCache.HitPriceMultiplier < 0 || c.Gateway.ResponseCache.HitPriceMultiplier > 1 {
+			return fmt.Errorf("gateway.response_cache.hit_price_multiplier must be in [0, 1]")
+		}
+	}
 	if c.Gateway.Scheduling.StickySessionMaxWaiting <= 0 {
 		return fmt.Errorf("gateway.scheduling.sticky_session_max_waiting must be positive")
 	}
@@ -2045,6 +2535,12 @@ func (c *Config) Validate() error {
 	if c.Ops.Cleanup.HourlyMetricsRetentionDays < 0 {
 		return fmt.Errorf("ops.cleanup.hourly_metrics_retention_days must be non-negative")
 	}
+	if c.Ops.Cleanup.AuditLogRetentionDays < 0 {
+		return fmt.Errorf("ops.cleanup.audit_log_retention_days must be non-negative")
+	}
+	if c.Ops.Cleanup.PayloadCaptureRetentionDays < 0 {
+		return fmt.Errorf("ops.cleanup.payload_capture_retention_days must be non-negative")
+	}
 	if c.Ops.Cleanup.Enabled && strings.TrimSpace(c.Ops.Cleanup.Schedule) == "" {
 		return fmt.Errorf("ops.cleanup.schedule is required when ops.cleanup.enabled=true")
 	}
@@ -2198,3 +2694,66 @@ func warnIfInsecureURL(field, raw string) {
 		slog.Warn("url uses http scheme; use https in production to avoid token leakage", "field", field)
 	}
 }
+
+func (p PaymentConfig) validate() error {
+	if !p.Enabled {
+		return nil
+	}
+	if !p.Stripe.Enabled && !p.EPay.Enabled && !p.Mock.Enabled {
+		return fmt.Errorf("payment requires at least one provider (stripe/epay/mock) when payment.enabled=true")
+	}
+	if p.OrderExpireMinutes <= 0 {
+		return fmt.Errorf("payment.order_expire_minutes must be positive")
+	}
+	if p.MinAmount <= 0 {
+		return fmt.Errorf("payment.min_amount must be positive")
+	}
+	if p.MaxAmount < 0 || (p.MaxAmount > 0 && p.MaxAmount < p.MinAmount) {
+		return fmt.Errorf("payment.max_amount must be 0 (unlimited) or >= payment.min_amount")
+	}
+	if p.BalanceRate <= 0 {
+		return fmt.Errorf("payment.balance_rate must be positive")
+	}
+	if p.Currency == "" {
+		return fmt.Errorf("payment.currency is required when payment.enabled=true")
+	}
+	if p.ReturnURL != "" {
+		if err := ValidateAbsoluteHTTPURL(p.ReturnURL); err != nil {
+			return fmt.Errorf("payment.return_url invalid: %w", err)
+		}
+	}
+	if p.NotifyBaseURL != "" {
+		if err := ValidateAbsoluteHTTPURL(p.NotifyBaseURL); err != nil {
+			return fmt.Errorf("payment.notify_base_url invalid: %w", err)
+		}
+	}
+	if p.Stripe.Enabled {
+		if strings.TrimSpace(p.Stripe.SecretKey) == "" {
+			return fmt.Errorf("payment.stripe.secret_key is required when payment.stripe.enabled=true")
+		}
+		if strings.TrimSpace(p.Stripe.WebhookSecret) == "" {
+			return fmt.Errorf("payment.stripe.webhook_secret is required when payment.stripe.enabled=true")
+		}
+		if p.Stripe.APIBase != "" {
+			if err := ValidateAbsoluteHTTPURL(p.Stripe.APIBase); err != nil {
+				return fmt.Errorf("payment.stripe.api_base invalid: %w", err)
+			}
+		}
+	}
+	if p.EPay.Enabled {
+		if p.EPay.GatewayURL == "" || strings.TrimSpace(p.EPay.PID) == "" || strings.TrimSpace(p.EPay.Key) == "" {
+			return fmt.Errorf("payment.epay.gateway_url, pid and key are required when payment.epay.enabled=true")
+		}
+		if err := ValidateAbsoluteHTTPURL(p.EPay.GatewayURL); err != nil {
+			return fmt.Errorf("payment.epay.gateway_url invalid: %w", err)
+		}
+		// 易支付依赖 notify_url 异步通知，必须能拼出对外回调地址
+		if p.NotifyBaseURL == "" {
+			return fmt.Errorf("payment.notify_base_url is required when payment.epay.enabled=true")
+		}
+	}
+	if p.Mock.Enabled && strings.TrimSpace(p.Mock.Secret) == "" {
+		return fmt.Errorf("payment.mock.secret is required when payment.mock.enabled=true")
+	}
+	return nil
+}
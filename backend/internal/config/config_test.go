@@ -892,6 +892,26 @@ func TestValidateConfigErrors(t *testing.T) {
 			mutate:  func(c *Config) { c.SubscriptionMaintenance.QueueSize = -1 },
 			wantErr: "subscription_maintenance.queue_size",
 		},
+		{
+			name:    "subscription renewal renew_before_hours non-negative",
+			mutate:  func(c *Config) { c.SubscriptionRenewal.RenewBeforeHours = -1 },
+			wantErr: "subscription_renewal.renew_before_hours",
+		},
+		{
+			name:    "subscription renewal batch_size positive",
+			mutate:  func(c *Config) { c.SubscriptionRenewal.BatchSize = 0 },
+			wantErr: "subscription_renewal.batch_size",
+		},
+		{
+			name:    "referral commission_rate range",
+			mutate:  func(c *Config) { c.Referral.CommissionRate = 1.5 },
+			wantErr: "referral.commission_rate",
+		},
+		{
+			name:    "referral settle_interval_minutes positive",
+			mutate:  func(c *Config) { c.Referral.SettleIntervalMinutes = 0 },
+			wantErr: "referral.settle_interval_minutes",
+		},
 		{
 			name:    "jwt expire hour positive",
 			mutate:  func(c *Config) { c.JWT.ExpireHour = 0 },
@@ -1288,6 +1308,71 @@ func TestValidateConfigErrors(t *testing.T) {
 			mutate:  func(c *Config) { c.Ops.Cleanup.MinuteMetricsRetentionDays = -1 },
 			wantErr: "ops.cleanup.minute_metrics_retention_days",
 		},
+		{
+			name:    "ops cleanup audit log retention",
+			mutate:  func(c *Config) { c.Ops.Cleanup.AuditLogRetentionDays = -1 },
+			wantErr: "ops.cleanup.audit_log_retention_days",
+		},
+		{
+			name:    "ops cleanup payload capture retention",
+			mutate:  func(c *Config) { c.Ops.Cleanup.PayloadCaptureRetentionDays = -1 },
+			wantErr: "ops.cleanup.payload_capture_retention_days",
+		},
+		{
+			name:    "payload capture max body bytes",
+			mutate:  func(c *Config) { c.PayloadCapture.MaxBodyBytes = 0 },
+			wantErr: "payload_capture.max_body_bytes",
+		},
+		{
+			name:    "guardrail webhook timeout",
+			mutate:  func(c *Config) { c.Guardrail.WebhookTimeoutMs = 0 },
+			wantErr: "guardrail.webhook_timeout_ms",
+		},
+		{
+			name:    "credential encryption active key missing",
+			mutate:  func(c *Config) { c.CredentialEncryption.ActiveKeyID = "k1" },
+			wantErr: "credential_encryption.active_key_id",
+		},
+		{
+			name:    "api key hash secret too short",
+			mutate:  func(c *Config) { c.APIKeyHash.Secret = "short" },
+			wantErr: "api_key_hash.secret",
+		},
+		{
+			name: "credential encryption invalid key",
+			mutate: func(c *Config) {
+				c.CredentialEncryption.Keys = map[string]string{"k1": "abcd"}
+			},
+			wantErr: "credential_encryption.keys.k1",
+		},
+		{
+			name:    "proxy pool failure threshold",
+			mutate:  func(c *Config) { c.ProxyPool.FailureThreshold = 0 },
+			wantErr: "proxy_pool.failure_threshold",
+		},
+		{
+			name:    "proxy pool max failover attempts",
+			mutate:  func(c *Config) { c.ProxyPool.MaxFailoverAttempts = 0 },
+			wantErr: "proxy_pool.max_failover_attempts",
+		},
+		{
+			name:    "admin audit max body bytes",
+			mutate:  func(c *Config) { c.AdminAudit.MaxBodyBytes = 0 },
+			wantErr: "admin_audit.max_body_bytes",
+		},
+		{
+			name:    "payment without provider",
+			mutate:  func(c *Config) { c.Payment.Enabled = true },
+			wantErr: "payment requires at least one provider",
+		},
+		{
+			name: "payment epay requires notify base url",
+			mutate: func(c *Config) {
+				c.Payment.Enabled = true
+				c.Payment.EPay = PaymentEPayConfig{Enabled: true, GatewayURL: "https://pay.example.com", PID: "1", Key: "k"}
+			},
+			wantErr: "payment.notify_base_url",
+		},
 	}
 
 	for _, tt := range cases {
@@ -31,6 +31,10 @@ const (
 	AccountTypeAPIKey     = "apikey"      // API Key类型账号
 	AccountTypeUpstream   = "upstream"    // 上游透传类型账号（通过 Base URL + API Key 连接上游）
 	AccountTypeBedrock    = "bedrock"     // AWS Bedrock 类型账号（通过 SigV4 签名或 API Key 连接 Bedrock，由 credentials.auth_mode 区分）
+	AccountTypeAzure      = "azure"       // Azure OpenAI 类型账号（资源 endpoint + api-key 头 + api-version，模型按部署名路由）
+	AccountTypeVertex     = "vertex"      // Google Vertex AI 上的 Claude（Service Account JWT 换取 access token，rawPredict/streamRawPredict）
+
+	AccountTypeOpenAICompatible = "openai-compatible" // 通用 OpenAI 兼容上游（Base URL + API Key，Chat Completions 协议，如 vLLM/SGLang/DeepSeek）
 )
 
 // Redeem type constants
@@ -137,3 +141,25 @@ var DefaultBedrockModelMapping = map[string]string{
 	"claude-haiku-4-5":          "us.anthropic.claude-haiku-4-5-20251001-v1:0",
 	"claude-haiku-4-5-20251001": "us.anthropic.claude-haiku-4-5-20251001-v1:0",
 }
+
+// DefaultVertexModelMapping 是 Google Vertex AI 上 Claude 模型的默认映射
+// 将 Anthropic 标准模型名映射到 Vertex 模型 ID（日期版本以 @ 分隔）
+var DefaultVertexModelMapping = map[string]string{
+	// Claude Opus
+	"claude-opus-4-6-thinking": "claude-opus-4-6",
+	"claude-opus-4-6":          "claude-opus-4-6",
+	"claude-opus-4-5-thinking": "claude-opus-4-5@20251101",
+	"claude-opus-4-5-20251101": "claude-opus-4-5@20251101",
+	"claude-opus-4-1":          "claude-opus-4-1@20250805",
+	"claude-opus-4-20250514":   "claude-opus-4@20250514",
+	// Claude Sonnet
+	"claude-sonnet-4-6-thinking": "claude-sonnet-4-6",
+	"claude-sonnet-4-6":          "claude-sonnet-4-6",
+	"claude-sonnet-4-5":          "claude-sonnet-4-5@20250929",
+	"claude-sonnet-4-5-thinking": "claude-sonnet-4-5@20250929",
+	"claude-sonnet-4-5-20250929": "claude-sonnet-4-5@20250929",
+	"claude-sonnet-4-20250514":   "claude-sonnet-4@20250514",
+	// Claude Haiku
+	"claude-haiku-4-5":          "claude-haiku-4-5@20251001",
+	"claude-haiku-4-5-20251001": "claude-haiku-4-5@20251001",
+}
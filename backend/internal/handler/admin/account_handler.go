@@ -98,7 +98,7 @@ type CreateAccountRequest struct {
 	Name                    string         `json:"name" binding:"required"`
 	Notes                   *string        `json:"notes"`
 	Platform                string         `json:"platform" binding:"required"`
-	Type                    string         `json:"type" binding:"required,oneof=oauth setup-token apikey upstream bedrock"`
+	Type                    string         `json:"type" binding:"required,oneof=oauth setup-token apikey upstream bedrock azure vertex openai-compatible"`
 	Credentials             map[string]any `json:"credentials" binding:"required"`
 	Extra                   map[string]any `json:"extra"`
 	ProxyID                 *int64         `json:"proxy_id"`
@@ -117,7 +117,7 @@ type CreateAccountRequest struct {
 type UpdateAccountRequest struct {
 	Name                    string         `json:"name"`
 	Notes                   *string        `json:"notes"`
-	Type                    string         `json:"type" binding:"omitempty,oneof=oauth setup-token apikey upstream bedrock"`
+	Type                    string         `json:"type" binding:"omitempty,oneof=oauth setup-token apikey upstream bedrock azure vertex openai-compatible"`
 	Credentials             map[string]any `json:"credentials"`
 	Extra                   map[string]any `json:"extra"`
 	ProxyID                 *int64         `json:"proxy_id"`
@@ -625,6 +625,13 @@ func (h *AccountHandler) Update(c *gin.Context) {
 		return
 	}
 
+	// Vertex 凭证变更后丢弃按旧 Service Account 签发的缓存 token
+	if account.IsVertex() && len(req.Credentials) > 0 && h.tokenCacheInvalidator != nil {
+		if invalidateErr := h.tokenCacheInvalidator.InvalidateToken(c.Request.Context(), account); invalidateErr != nil {
+			log.Printf("[WARN] Failed to invalidate token cache for account %d: %v", account.ID, invalidateErr)
+		}
+	}
+
 	response.Success(c, h.buildAccountResponseWithRuntime(c.Request.Context(), account))
 }
 
@@ -1801,6 +1808,10 @@ func (h *AccountHandler) GetAvailableModels(c *gin.Context) {
 		}
 
 		mapping := account.GetModelMapping()
+		// Azure 未配置别名映射时，可用模型即已配置部署的模型
+		if len(mapping) == 0 && account.IsOpenAIAzure() {
+			mapping = account.GetAzureOpenAIDeployments()
+		}
 		if len(mapping) == 0 {
 			response.Success(c, openai.DefaultModels)
 			return
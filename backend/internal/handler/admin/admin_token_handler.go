@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminTokenHandler handles named, scoped admin token management
+type AdminTokenHandler struct {
+	adminTokenService *service.AdminTokenService
+}
+
+// NewAdminTokenHandler creates a new admin token handler
+func NewAdminTokenHandler(adminTokenService *service.AdminTokenService) *AdminTokenHandler {
+	return &AdminTokenHandler{adminTokenService: adminTokenService}
+}
+
+// CreateAdminTokenRequest represents the create admin token payload
+type CreateAdminTokenRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type adminTokenResponse struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	CreatedBy   int64      `json:"created_by"`
+	Status      string     `json:"status"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  string     `json:"last_used_ip"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+func adminTokenFromService(t *service.AdminToken) adminTokenResponse {
+	status := "active"
+	if t.IsRevoked() {
+		status = "revoked"
+	} else if t.IsExpired(time.Now()) {
+		status = "expired"
+	}
+	return adminTokenResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		TokenPrefix: t.TokenPrefix,
+		Scopes:      t.Scopes,
+		CreatedBy:   t.CreatedBy,
+		Status:      status,
+		ExpiresAt:   t.ExpiresAt,
+		LastUsedAt:  t.LastUsedAt,
+		LastUsedIP:  t.LastUsedIP,
+		RevokedAt:   t.RevokedAt,
+		CreatedAt:   t.CreatedAt,
+	}
+}
+
+// List handles listing admin tokens
+// GET /api/v1/admin/admin-tokens
+func (h *AdminTokenHandler) List(c *gin.Context) {
+	tokens, err := h.adminTokenService.List(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]adminTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		out = append(out, adminTokenFromService(&tokens[i]))
+	}
+	response.Success(c, out)
+}
+
+// Scopes returns all assignable scopes
+// GET /api/v1/admin/admin-tokens/scopes
+func (h *AdminTokenHandler) Scopes(c *gin.Context) {
+	response.Success(c, service.AdminTokenScopeCatalog())
+}
+
+// Create handles creating an admin token; the plaintext token is only returned once
+// POST /api/v1/admin/admin-tokens
+func (h *AdminTokenHandler) Create(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req CreateAdminTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	token, raw, err := h.adminTokenService.Create(c.Request.Context(), &service.CreateAdminTokenInput{
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedBy: subject.UserID,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{
+		"token":       raw, // 完整令牌只在创建时返回一次
+		"admin_token": adminTokenFromService(token),
+	})
+}
+
+// Revoke handles revoking an admin token
+// DELETE /api/v1/admin/admin-tokens/:id
+func (h *AdminTokenHandler) Revoke(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid admin token ID")
+		return
+	}
+
+	token, err := h.adminTokenService.Revoke(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, adminTokenFromService(token))
+}
@@ -0,0 +1,224 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditLogHandler handles admin audit log queries
+type AuditLogHandler struct {
+	auditService *service.AdminAuditService
+}
+
+// NewAuditLogHandler creates a new admin audit log handler
+func NewAuditLogHandler(auditService *service.AdminAuditService) *AuditLogHandler {
+	return &AuditLogHandler{auditService: auditService}
+}
+
+type adminAuditLogResponse struct {
+	ID           int64                               `json:"id"`
+	ActorUserID  int64                               `json:"actor_user_id"`
+	ActorEmail   string                              `json:"actor_email"`
+	AuthMethod   string                              `json:"auth_method"`
+	ActorTokenID int64                               `json:"actor_token_id,omitempty"`
+	Method       string                              `json:"method"`
+	Route        string                              `json:"route"`
+	Path         string                              `json:"path"`
+	ResourceType string                              `json:"resource_type"`
+	ResourceID   string                              `json:"resource_id"`
+	TargetIDs    map[string]any                      `json:"target_ids,omitempty"`
+	RequestBody  json.RawMessage                     `json:"request_body,omitempty"`
+	Changes      map[string]service.AdminAuditChange `json:"changes,omitempty"`
+	StatusCode   int                                 `json:"status_code"`
+	Success      bool                                `json:"success"`
+	ErrorReason  string                              `json:"error_reason,omitempty"`
+	ErrorMessage string                              `json:"error_message,omitempty"`
+	ClientIP     string                              `json:"client_ip"`
+	UserAgent    string                              `json:"user_agent"`
+	DurationMs   int64                               `json:"duration_ms"`
+	CreatedAt    time.Time                           `json:"created_at"`
+}
+
+func adminAuditLogFromService(l *service.AdminAuditLog) adminAuditLogResponse {
+	return adminAuditLogResponse{
+		ID:           l.ID,
+		ActorUserID:  l.ActorUserID,
+		ActorEmail:   l.ActorEmail,
+		AuthMethod:   l.AuthMethod,
+		ActorTokenID: l.ActorTokenID,
+		Method:       l.Method,
+		Route:        l.Route,
+		Path:         l.Path,
+		ResourceType: l.ResourceType,
+		ResourceID:   l.ResourceID,
+		TargetIDs:    l.TargetIDs,
+		RequestBody:  l.RequestBody,
+		Changes:      l.Changes,
+		StatusCode:   l.StatusCode,
+		Success:      l.Success,
+		ErrorReason:  l.ErrorReason,
+		ErrorMessage: l.ErrorMessage,
+		ClientIP:     l.ClientIP,
+		UserAgent:    l.UserAgent,
+		DurationMs:   l.DurationMs,
+		CreatedAt:    l.CreatedAt,
+	}
+}
+
+// parseAuditLogFilters 解析查询条件（List 与 Export 共用）
+func parseAuditLogFilters(c *gin.Context) (service.AdminAuditLogFilters, error) {
+	filters := service.AdminAuditLogFilters{
+		AuthMethod:   strings.TrimSpace(c.Query("auth_method")),
+		Method:       strings.ToUpper(strings.TrimSpace(c.Query("method"))),
+		ResourceType: strings.TrimSpace(c.Query("resource_type")),
+		ResourceID:   strings.TrimSpace(c.Query("resource_id")),
+		Search:       strings.TrimSpace(c.Query("search")),
+	}
+	if len(filters.Search) > 100 {
+		filters.Search = filters.Search[:100]
+	}
+	if raw := strings.TrimSpace(c.Query("actor_user_id")); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return filters, fmt.Errorf("invalid actor_user_id")
+		}
+		filters.ActorUserID = id
+	}
+	if raw := strings.TrimSpace(c.Query("actor_token_id")); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return filters, fmt.Errorf("invalid actor_token_id")
+		}
+		filters.ActorTokenID = id
+	}
+	if raw := strings.TrimSpace(c.Query("success")); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return filters, fmt.Errorf("invalid success")
+		}
+		filters.Success = &v
+	}
+	if raw := strings.TrimSpace(c.Query("start_time")); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filters, fmt.Errorf("invalid start_time, expected RFC3339")
+		}
+		filters.StartTime = &t
+	}
+	if raw := strings.TrimSpace(c.Query("end_time")); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filters, fmt.Errorf("invalid end_time, expected RFC3339")
+		}
+		filters.EndTime = &t
+	}
+	return filters, nil
+}
+
+// List handles listing admin audit logs
+// GET /api/v1/admin/audit-logs?actor_user_id=&actor_token_id=&auth_method=&method=&resource_type=&resource_id=&success=&start_time=&end_time=&search=
+func (h *AuditLogHandler) List(c *gin.Context) {
+	filters, err := parseAuditLogFilters(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	page, pageSize := response.ParsePagination(c)
+
+	logs, result, err := h.auditService.List(c.Request.Context(), pagination.PaginationParams{Page: page, PageSize: pageSize}, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]adminAuditLogResponse, 0, len(logs))
+	for i := range logs {
+		out = append(out, adminAuditLogFromService(&logs[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// Export handles exporting admin audit logs as CSV
+// GET /api/v1/admin/audit-logs/export (same filters as List)
+func (h *AuditLogHandler) Export(c *gin.Context) {
+	filters, err := parseAuditLogFilters(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+
+	logs, err := h.auditService.Export(c.Request.Context(), filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write([]string{
+		"id", "created_at", "actor_user_id", "actor_email", "auth_method", "actor_token_id", "method", "route", "path",
+		"resource_type", "resource_id", "target_ids", "request_body", "changes",
+		"status_code", "success", "error_reason", "error_message", "client_ip", "user_agent", "duration_ms",
+	})
+	for i := range logs {
+		l := &logs[i]
+		if err := writer.Write([]string{
+			strconv.FormatInt(l.ID, 10),
+			l.CreatedAt.UTC().Format(time.RFC3339),
+			strconv.FormatInt(l.ActorUserID, 10),
+			l.ActorEmail,
+			l.AuthMethod,
+			strconv.FormatInt(l.ActorTokenID, 10),
+			l.Method,
+			l.Route,
+			l.Path,
+			l.ResourceType,
+			l.ResourceID,
+			auditLogJSONCell(l.TargetIDs),
+			string(l.RequestBody),
+			auditLogJSONCell(l.Changes),
+			strconv.Itoa(l.StatusCode),
+			strconv.FormatBool(l.Success),
+			l.ErrorReason,
+			l.ErrorMessage,
+			l.ClientIP,
+			l.UserAgent,
+			strconv.FormatInt(l.DurationMs, 10),
+		}); err != nil {
+			response.InternalError(c, "Failed to export audit logs: "+err.Error())
+			return
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		response.InternalError(c, "Failed to export audit logs: "+err.Error())
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", "attachment; filename=admin_audit_logs.csv")
+	c.Data(200, "text/csv", buf.Bytes())
+}
+
+func auditLogJSONCell[T any](v map[string]T) string {
+	if len(v) == 0 {
+		return ""
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(raw)
+}
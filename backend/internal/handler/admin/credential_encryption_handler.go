@@ -0,0 +1,39 @@
+package admin
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// CredentialEncryptionHandler 账号凭证静态加密状态与密钥轮换
+type CredentialEncryptionHandler struct {
+	service *service.CredentialEncryptionService
+}
+
+// NewCredentialEncryptionHandler 创建凭证加密处理器
+func NewCredentialEncryptionHandler(service *service.CredentialEncryptionService) *CredentialEncryptionHandler {
+	return &CredentialEncryptionHandler{service: service}
+}
+
+// GetStatus 获取密钥配置、数据库中仍被引用的密钥及最近一次重新加密任务
+// GET /api/v1/admin/credential-encryption
+func (h *CredentialEncryptionHandler) GetStatus(c *gin.Context) {
+	status, err := h.service.Status(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, status)
+}
+
+// Reencrypt 异步将明文凭证及旧密钥凭证重新加密为当前主密钥（密钥轮换）
+// POST /api/v1/admin/credential-encryption/reencrypt
+func (h *CredentialEncryptionHandler) Reencrypt(c *gin.Context) {
+	job, err := h.service.StartReencrypt(service.CredentialReencryptTriggerManual)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, job)
+}
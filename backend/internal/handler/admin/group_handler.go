@@ -109,6 +109,14 @@ type CreateGroupRequest struct {
 	RequireOAuthOnly      bool   `json:"require_oauth_only"`
 	RequirePrivacySet     bool   `json:"require_privacy_set"`
 	DefaultMappedModel    string `json:"default_mapped_model"`
+	// 本地 token 计数（所有平台）
+	LocalTokenCounting bool `json:"local_token_counting"`
+	// Batch API 折扣倍率（负数表示使用配置默认值）
+	BatchDiscountMultiplier *float64 `json:"batch_discount_multiplier"`
+	// 确定性请求响应缓存
+	ResponseCacheEnabled bool `json:"response_cache_enabled"`
+	// 余额购买订阅价格（USD，null/负数表示不开放购买）
+	SubscriptionPrice *float64 `json:"subscription_price"`
 	// 从指定分组复制账号（创建后自动绑定）
 	CopyAccountsFromGroupIDs []int64 `json:"copy_accounts_from_group_ids"`
 }
@@ -143,6 +151,14 @@ type UpdateGroupRequest struct {
 	RequireOAuthOnly      *bool   `json:"require_oauth_only"`
 	RequirePrivacySet     *bool   `json:"require_privacy_set"`
 	DefaultMappedModel    *string `json:"default_mapped_model"`
+	// 本地 token 计数（所有平台）
+	LocalTokenCounting *bool `json:"local_token_counting"`
+	// Batch API 折扣倍率（负数表示清除，使用配置默认值）
+	BatchDiscountMultiplier *float64 `json:"batch_discount_multiplier"`
+	// 确定性请求响应缓存
+	ResponseCacheEnabled *bool `json:"response_cache_enabled"`
+	// 余额购买订阅价格（USD，nil 表示不修改，负数表示关闭购买）
+	SubscriptionPrice *float64 `json:"subscription_price"`
 	// 从指定分组复制账号（同步操作：先清空当前分组的账号绑定，再绑定源分组的账号）
 	CopyAccountsFromGroupIDs []int64 `json:"copy_accounts_from_group_ids"`
 }
@@ -257,6 +273,10 @@ func (h *GroupHandler) Create(c *gin.Context) {
 		RequireOAuthOnly:                req.RequireOAuthOnly,
 		RequirePrivacySet:               req.RequirePrivacySet,
 		DefaultMappedModel:              req.DefaultMappedModel,
+		LocalTokenCounting:              req.LocalTokenCounting,
+		BatchDiscountMultiplier:         req.BatchDiscountMultiplier,
+		ResponseCacheEnabled:            req.ResponseCacheEnabled,
+		SubscriptionPrice:               req.SubscriptionPrice,
 		CopyAccountsFromGroupIDs:        req.CopyAccountsFromGroupIDs,
 	})
 	if err != nil {
@@ -307,6 +327,10 @@ func (h *GroupHandler) Update(c *gin.Context) {
 		RequireOAuthOnly:                req.RequireOAuthOnly,
 		RequirePrivacySet:               req.RequirePrivacySet,
 		DefaultMappedModel:              req.DefaultMappedModel,
+		LocalTokenCounting:              req.LocalTokenCounting,
+		BatchDiscountMultiplier:         req.BatchDiscountMultiplier,
+		ResponseCacheEnabled:            req.ResponseCacheEnabled,
+		SubscriptionPrice:               req.SubscriptionPrice,
 		CopyAccountsFromGroupIDs:        req.CopyAccountsFromGroupIDs,
 	})
 	if err != nil {
@@ -0,0 +1,207 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// GuardrailHandler 分组级网关请求安全策略规则管理
+type GuardrailHandler struct {
+	service *service.GuardrailService
+}
+
+// NewGuardrailHandler 创建安全策略规则处理器
+func NewGuardrailHandler(service *service.GuardrailService) *GuardrailHandler {
+	return &GuardrailHandler{service: service}
+}
+
+// GuardrailRuleRequest 创建/更新安全策略规则请求（更新为整体替换）
+type GuardrailRuleRequest struct {
+	GroupID          int64    `json:"group_id" binding:"required,gt=0"`
+	Name             string   `json:"name" binding:"required,max=100"`
+	Detector         string   `json:"detector" binding:"required,oneof=regex keyword preset webhook"`
+	Patterns         []string `json:"patterns"`
+	CaseSensitive    bool     `json:"case_sensitive"`
+	Action           string   `json:"action" binding:"required,oneof=block mask log"`
+	Replacement      string   `json:"replacement"`
+	WebhookURL       string   `json:"webhook_url"`
+	WebhookTimeoutMs int      `json:"webhook_timeout_ms"`
+	FailOpen         *bool    `json:"fail_open"`
+	Priority         int      `json:"priority"`
+	Enabled          *bool    `json:"enabled"`
+}
+
+func (r *GuardrailRuleRequest) toInput() *service.GuardrailRuleInput {
+	input := &service.GuardrailRuleInput{
+		GroupID:          r.GroupID,
+		Name:             r.Name,
+		Detector:         r.Detector,
+		Patterns:         r.Patterns,
+		CaseSensitive:    r.CaseSensitive,
+		Action:           r.Action,
+		Replacement:      r.Replacement,
+		WebhookURL:       r.WebhookURL,
+		WebhookTimeoutMs: r.WebhookTimeoutMs,
+		FailOpen:         true,
+		Priority:         r.Priority,
+		Enabled:          true,
+	}
+	if r.FailOpen != nil {
+		input.FailOpen = *r.FailOpen
+	}
+	if r.Enabled != nil {
+		input.Enabled = *r.Enabled
+	}
+	return input
+}
+
+type guardrailRuleResponse struct {
+	ID               int64     `json:"id"`
+	GroupID          int64     `json:"group_id"`
+	Name             string    `json:"name"`
+	Detector         string    `json:"detector"`
+	Patterns         []string  `json:"patterns"`
+	CaseSensitive    bool      `json:"case_sensitive"`
+	Action           string    `json:"action"`
+	Replacement      string    `json:"replacement"`
+	WebhookURL       string    `json:"webhook_url"`
+	WebhookTimeoutMs int       `json:"webhook_timeout_ms"`
+	FailOpen         bool      `json:"fail_open"`
+	Priority         int       `json:"priority"`
+	Enabled          bool      `json:"enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func guardrailRuleFromService(r *service.GuardrailRule) guardrailRuleResponse {
+	patterns := r.Patterns
+	if patterns == nil {
+		patterns = []string{}
+	}
+	return guardrailRuleResponse{
+		ID:               r.ID,
+		GroupID:          r.GroupID,
+		Name:             r.Name,
+		Detector:         r.Detector,
+		Patterns:         patterns,
+		CaseSensitive:    r.CaseSensitive,
+		Action:           r.Action,
+		Replacement:      r.Replacement,
+		WebhookURL:       r.WebhookURL,
+		WebhookTimeoutMs: r.WebhookTimeoutMs,
+		FailOpen:         r.FailOpen,
+		Priority:         r.Priority,
+		Enabled:          r.Enabled,
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+	}
+}
+
+// List 获取安全策略规则
+// GET /api/v1/admin/guardrails/rules?group_id=
+func (h *GuardrailHandler) List(c *gin.Context) {
+	var groupID int64
+	if raw := strings.TrimSpace(c.Query("group_id")); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			response.BadRequest(c, "Invalid group_id")
+			return
+		}
+		groupID = id
+	}
+	rules, err := h.service.ListRules(c.Request.Context(), groupID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]guardrailRuleResponse, 0, len(rules))
+	for i := range rules {
+		out = append(out, guardrailRuleFromService(&rules[i]))
+	}
+	response.Success(c, out)
+}
+
+// Presets 获取内置 PII 检测器名称
+// GET /api/v1/admin/guardrails/presets
+func (h *GuardrailHandler) Presets(c *gin.Context) {
+	response.Success(c, service.GuardrailPresetNames())
+}
+
+// GetByID 获取单条安全策略规则
+// GET /api/v1/admin/guardrails/rules/:id
+func (h *GuardrailHandler) GetByID(c *gin.Context) {
+	id, ok := parseGuardrailRuleID(c)
+	if !ok {
+		return
+	}
+	rule, err := h.service.GetRule(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, guardrailRuleFromService(rule))
+}
+
+// Create 创建安全策略规则
+// POST /api/v1/admin/guardrails/rules
+func (h *GuardrailHandler) Create(c *gin.Context) {
+	var req GuardrailRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	rule, err := h.service.CreateRule(c.Request.Context(), req.toInput())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, guardrailRuleFromService(rule))
+}
+
+// Update 更新安全策略规则
+// PUT /api/v1/admin/guardrails/rules/:id
+func (h *GuardrailHandler) Update(c *gin.Context) {
+	id, ok := parseGuardrailRuleID(c)
+	if !ok {
+		return
+	}
+	var req GuardrailRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	rule, err := h.service.UpdateRule(c.Request.Context(), id, req.toInput())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, guardrailRuleFromService(rule))
+}
+
+// Delete 删除安全策略规则
+// DELETE /api/v1/admin/guardrails/rules/:id
+func (h *GuardrailHandler) Delete(c *gin.Context) {
+	id, ok := parseGuardrailRuleID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteRule(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Rule deleted successfully"})
+}
+
+func parseGuardrailRuleID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid rule ID")
+		return 0, false
+	}
+	return id, true
+}
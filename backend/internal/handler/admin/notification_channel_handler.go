@@ -0,0 +1,213 @@
+package admin
+
+import (
+	"strconv"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationChannelHandler handles admin notification channel management
+type NotificationChannelHandler struct {
+	notificationService *service.NotificationService
+}
+
+// NewNotificationChannelHandler creates a new admin notification channel handler
+func NewNotificationChannelHandler(notificationService *service.NotificationService) *NotificationChannelHandler {
+	return &NotificationChannelHandler{notificationService: notificationService}
+}
+
+// --- Request / Response types ---
+
+type createNotificationChannelRequest struct {
+	Name       string            `json:"name" binding:"required,max=100"`
+	Type       string            `json:"type" binding:"required,oneof=webhook slack feishu dingtalk"`
+	Enabled    *bool             `json:"enabled"`
+	WebhookURL string            `json:"webhook_url" binding:"required"`
+	Secret     string            `json:"secret"`
+	Headers    map[string]string `json:"headers"`
+	EventTypes []string          `json:"event_types"`
+}
+
+// updateNotificationChannelRequest 渠道类型创建后不可修改；secret 为空字符串表示清除签名。
+type updateNotificationChannelRequest struct {
+	Name       *string           `json:"name" binding:"omitempty,max=100"`
+	Enabled    *bool             `json:"enabled"`
+	WebhookURL *string           `json:"webhook_url"`
+	Secret     *string           `json:"secret"`
+	Headers    map[string]string `json:"headers"`
+	EventTypes *[]string         `json:"event_types"`
+}
+
+type notificationChannelResponse struct {
+	ID               int64             `json:"id"`
+	Name             string            `json:"name"`
+	Type             string            `json:"type"`
+	Enabled          bool              `json:"enabled"`
+	WebhookURL       string            `json:"webhook_url"`
+	SecretConfigured bool              `json:"secret_configured"`
+	Headers          map[string]string `json:"headers"`
+	EventTypes       []string          `json:"event_types"`
+	CreatedAt        string            `json:"created_at"`
+	UpdatedAt        string            `json:"updated_at"`
+}
+
+func notificationChannelToResponse(ch *service.NotificationChannel) *notificationChannelResponse {
+	if ch == nil {
+		return nil
+	}
+	resp := &notificationChannelResponse{
+		ID:               ch.ID,
+		Name:             ch.Name,
+		Type:             ch.Type,
+		Enabled:          ch.Enabled,
+		WebhookURL:       ch.WebhookURL,
+		SecretConfigured: ch.Secret != "",
+		Headers:          ch.Headers,
+		EventTypes:       ch.EventTypes,
+		CreatedAt:        ch.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        ch.UpdatedAt.Format(time.RFC3339),
+	}
+	if resp.Headers == nil {
+		resp.Headers = map[string]string{}
+	}
+	if resp.EventTypes == nil {
+		resp.EventTypes = []string{}
+	}
+	return resp
+}
+
+// --- Handlers ---
+
+// List handles listing notification channels
+// GET /api/v1/admin/notification-channels
+func (h *NotificationChannelHandler) List(c *gin.Context) {
+	channels, err := h.notificationService.List(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]*notificationChannelResponse, 0, len(channels))
+	for i := range channels {
+		out = append(out, notificationChannelToResponse(&channels[i]))
+	}
+	response.Success(c, out)
+}
+
+// GetByID handles getting a notification channel by ID
+// GET /api/v1/admin/notification-channels/:id
+func (h *NotificationChannelHandler) GetByID(c *gin.Context) {
+	id, ok := parseNotificationChannelID(c)
+	if !ok {
+		return
+	}
+
+	ch, err := h.notificationService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, notificationChannelToResponse(ch))
+}
+
+// Create handles creating a notification channel
+// POST /api/v1/admin/notification-channels
+func (h *NotificationChannelHandler) Create(c *gin.Context) {
+	var req createNotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFrom(c, infraerrors.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	ch, err := h.notificationService.Create(c.Request.Context(), &service.CreateNotificationChannelInput{
+		Name:       req.Name,
+		Type:       req.Type,
+		Enabled:    enabled,
+		WebhookURL: req.WebhookURL,
+		Secret:     req.Secret,
+		Headers:    req.Headers,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, notificationChannelToResponse(ch))
+}
+
+// Update handles updating a notification channel
+// PUT /api/v1/admin/notification-channels/:id
+func (h *NotificationChannelHandler) Update(c *gin.Context) {
+	id, ok := parseNotificationChannelID(c)
+	if !ok {
+		return
+	}
+
+	var req updateNotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFrom(c, infraerrors.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	ch, err := h.notificationService.Update(c.Request.Context(), id, &service.UpdateNotificationChannelInput{
+		Name:       req.Name,
+		Enabled:    req.Enabled,
+		WebhookURL: req.WebhookURL,
+		Secret:     req.Secret,
+		Headers:    req.Headers,
+		EventTypes: req.EventTypes,
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, notificationChannelToResponse(ch))
+}
+
+// Delete handles deleting a notification channel
+// DELETE /api/v1/admin/notification-channels/:id
+func (h *NotificationChannelHandler) Delete(c *gin.Context) {
+	id, ok := parseNotificationChannelID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.Delete(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Notification channel deleted successfully"})
+}
+
+// Test sends a test message through a notification channel
+// POST /api/v1/admin/notification-channels/:id/test
+func (h *NotificationChannelHandler) Test(c *gin.Context) {
+	id, ok := parseNotificationChannelID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.notificationService.Test(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Test notification sent"})
+}
+
+func parseNotificationChannelID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.ErrorFrom(c, infraerrors.BadRequest("INVALID_NOTIFICATION_CHANNEL_ID", "Invalid notification channel ID"))
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,391 @@
+package admin
+
+import (
+	"strconv"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OIDCProviderHandler handles admin OIDC / OAuth2 SSO provider management
+type OIDCProviderHandler struct {
+	oidcService *service.OIDCService
+}
+
+// NewOIDCProviderHandler creates a new admin OIDC provider handler
+func NewOIDCProviderHandler(oidcService *service.OIDCService) *OIDCProviderHandler {
+	return &OIDCProviderHandler{oidcService: oidcService}
+}
+
+// --- Request / Response types ---
+
+type oidcGroupMappingRequest struct {
+	EmailDomain   string                         `json:"email_domain"`
+	Claim         string                         `json:"claim"`
+	ClaimValue    string                         `json:"claim_value"`
+	GroupIDs      []int64                        `json:"group_ids"`
+	Subscriptions []oidcSubscriptionGrantRequest `json:"subscriptions"`
+}
+
+type oidcSubscriptionGrantRequest struct {
+	GroupID      int64 `json:"group_id"`
+	ValidityDays int   `json:"validity_days"`
+}
+
+type createOIDCProviderRequest struct {
+	Slug                string                    `json:"slug" binding:"required,max=32"`
+	Name                string                    `json:"name" binding:"required,max=100"`
+	Enabled             *bool                     `json:"enabled"`
+	IssuerURL           string                    `json:"issuer_url"`
+	AuthorizeURL        string                    `json:"authorize_url"`
+	TokenURL            string                    `json:"token_url"`
+	UserInfoURL         string                    `json:"userinfo_url"`
+	JWKSURL             string                    `json:"jwks_url"`
+	ClientID            string                    `json:"client_id" binding:"required"`
+	ClientSecret        string                    `json:"client_secret"`
+	Scopes              string                    `json:"scopes"`
+	TokenAuthMethod     string                    `json:"token_auth_method" binding:"omitempty,oneof=client_secret_post client_secret_basic none"`
+	UsePKCE             *bool                     `json:"use_pkce"`
+	Audiences           []string                  `json:"audiences"`
+	RedirectURL         string                    `json:"redirect_url" binding:"required"`
+	FrontendRedirectURL string                    `json:"frontend_redirect_url"`
+	AllowedEmailDomains []string                  `json:"allowed_email_domains"`
+	TrustEmail          bool                      `json:"trust_email"`
+	UsernameClaim       string                    `json:"username_claim"`
+	GroupsClaim         string                    `json:"groups_claim"`
+	DefaultGroupIDs     []int64                   `json:"default_group_ids"`
+	GroupMappings       []oidcGroupMappingRequest `json:"group_mappings"`
+	SortOrder           int                       `json:"sort_order"`
+}
+
+// updateOIDCProviderRequest slug 创建后不可修改；client_secret 为空表示保留原值。
+type updateOIDCProviderRequest struct {
+	Name                *string                    `json:"name" binding:"omitempty,max=100"`
+	Enabled             *bool                      `json:"enabled"`
+	IssuerURL           *string                    `json:"issuer_url"`
+	AuthorizeURL        *string                    `json:"authorize_url"`
+	TokenURL            *string                    `json:"token_url"`
+	UserInfoURL         *string                    `json:"userinfo_url"`
+	JWKSURL             *string                    `json:"jwks_url"`
+	ClientID            *string                    `json:"client_id"`
+	ClientSecret        *string                    `json:"client_secret"`
+	Scopes              *string                    `json:"scopes"`
+	TokenAuthMethod     *string                    `json:"token_auth_method" binding:"omitempty,oneof=client_secret_post client_secret_basic none"`
+	UsePKCE             *bool                      `json:"use_pkce"`
+	Audiences           *[]string                  `json:"audiences"`
+	RedirectURL         *string                    `json:"redirect_url"`
+	FrontendRedirectURL *string                    `json:"frontend_redirect_url"`
+	AllowedEmailDomains *[]string                  `json:"allowed_email_domains"`
+	TrustEmail          *bool                      `json:"trust_email"`
+	UsernameClaim       *string                    `json:"username_claim"`
+	GroupsClaim         *string                    `json:"groups_claim"`
+	DefaultGroupIDs     *[]int64                   `json:"default_group_ids"`
+	GroupMappings       *[]oidcGroupMappingRequest `json:"group_mappings"`
+	SortOrder           *int                       `json:"sort_order"`
+}
+
+type oidcProviderResponse struct {
+	ID                     int64                     `json:"id"`
+	Slug                   string                    `json:"slug"`
+	Name                   string                    `json:"name"`
+	Enabled                bool                      `json:"enabled"`
+	IssuerURL              string                    `json:"issuer_url"`
+	AuthorizeURL           string                    `json:"authorize_url"`
+	TokenURL               string                    `json:"token_url"`
+	UserInfoURL            string                    `json:"userinfo_url"`
+	JWKSURL                string                    `json:"jwks_url"`
+	ClientID               string                    `json:"client_id"`
+	ClientSecretConfigured bool                      `json:"client_secret_configured"`
+	Scopes                 string                    `json:"scopes"`
+	TokenAuthMethod        string                    `json:"token_auth_method"`
+	UsePKCE                bool                      `json:"use_pkce"`
+	Audiences              []string                  `json:"audiences"`
+	RedirectURL            string                    `json:"redirect_url"`
+	FrontendRedirectURL    string                    `json:"frontend_redirect_url"`
+	AllowedEmailDomains    []string                  `json:"allowed_email_domains"`
+	TrustEmail             bool                      `json:"trust_email"`
+	UsernameClaim          string                    `json:"username_claim"`
+	GroupsClaim            string                    `json:"groups_claim"`
+	DefaultGroupIDs        []int64                   `json:"default_group_ids"`
+	GroupMappings          []oidcGroupMappingRequest `json:"group_mappings"`
+	SortOrder              int                       `json:"sort_order"`
+	CreatedAt              string                    `json:"created_at"`
+	UpdatedAt              string                    `json:"updated_at"`
+}
+
+type oidcDiscoveryResponse struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func oidcGroupMappingsToService(in []oidcGroupMappingRequest) []service.OIDCGroupMapping {
+	out := make([]service.OIDCGroupMapping, 0, len(in))
+	for _, m := range in {
+		subs := make([]service.DefaultSubscriptionSetting, 0, len(m.Subscriptions))
+		for _, s := range m.Subscriptions {
+			subs = append(subs, service.DefaultSubscriptionSetting{GroupID: s.GroupID, ValidityDays: s.ValidityDays})
+		}
+		out = append(out, service.OIDCGroupMapping{
+			EmailDomain:   m.EmailDomain,
+			Claim:         m.Claim,
+			ClaimValue:    m.ClaimValue,
+			GroupIDs:      m.GroupIDs,
+			Subscriptions: subs,
+		})
+	}
+	return out
+}
+
+func oidcGroupMappingsToResponse(in []service.OIDCGroupMapping) []oidcGroupMappingRequest {
+	out := make([]oidcGroupMappingRequest, 0, len(in))
+	for _, m := range in {
+		subs := make([]oidcSubscriptionGrantRequest, 0, len(m.Subscriptions))
+		for _, s := range m.Subscriptions {
+			subs = append(subs, oidcSubscriptionGrantRequest{GroupID: s.GroupID, ValidityDays: s.ValidityDays})
+		}
+		groupIDs := m.GroupIDs
+		if groupIDs == nil {
+			groupIDs = []int64{}
+		}
+		out = append(out, oidcGroupMappingRequest{
+			EmailDomain:   m.EmailDomain,
+			Claim:         m.Claim,
+			ClaimValue:    m.ClaimValue,
+			GroupIDs:      groupIDs,
+			Subscriptions: subs,
+		})
+	}
+	return out
+}
+
+func oidcProviderToResponse(p *service.OIDCProvider) *oidcProviderResponse {
+	if p == nil {
+		return nil
+	}
+	resp := &oidcProviderResponse{
+		ID:                     p.ID,
+		Slug:                   p.Slug,
+		Name:                   p.Name,
+		Enabled:                p.Enabled,
+		IssuerURL:              p.IssuerURL,
+		AuthorizeURL:           p.AuthorizeURL,
+		TokenURL:               p.TokenURL,
+		UserInfoURL:            p.UserInfoURL,
+		JWKSURL:                p.JWKSURL,
+		ClientID:               p.ClientID,
+		ClientSecretConfigured: p.ClientSecret != "",
+		Scopes:                 p.Scopes,
+		TokenAuthMethod:        p.TokenAuthMethod,
+		UsePKCE:                p.UsePKCE,
+		Audiences:              p.Audiences,
+		RedirectURL:            p.RedirectURL,
+		FrontendRedirectURL:    p.FrontendRedirectURL,
+		AllowedEmailDomains:    p.AllowedEmailDomains,
+		TrustEmail:             p.TrustEmail,
+		UsernameClaim:          p.UsernameClaim,
+		GroupsClaim:            p.GroupsClaim,
+		DefaultGroupIDs:        p.DefaultGroupIDs,
+		GroupMappings:          oidcGroupMappingsToResponse(p.GroupMappings),
+		SortOrder:              p.SortOrder,
+		CreatedAt:              p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:              p.UpdatedAt.Format(time.RFC3339),
+	}
+	if resp.Audiences == nil {
+		resp.Audiences = []string{}
+	}
+	if resp.AllowedEmailDomains == nil {
+		resp.AllowedEmailDomains = []string{}
+	}
+	if resp.DefaultGroupIDs == nil {
+		resp.DefaultGroupIDs = []int64{}
+	}
+	return resp
+}
+
+// --- Handlers ---
+
+// List handles listing SSO providers
+// GET /api/v1/admin/oidc-providers
+func (h *OIDCProviderHandler) List(c *gin.Context) {
+	providers, err := h.oidcService.List(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]*oidcProviderResponse, 0, len(providers))
+	for i := range providers {
+		out = append(out, oidcProviderToResponse(&providers[i]))
+	}
+	response.Success(c, out)
+}
+
+// GetByID handles getting an SSO provider by ID
+// GET /api/v1/admin/oidc-providers/:id
+func (h *OIDCProviderHandler) GetByID(c *gin.Context) {
+	id, ok := parseOIDCProviderID(c)
+	if !ok {
+		return
+	}
+
+	p, err := h.oidcService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, oidcProviderToResponse(p))
+}
+
+// Create handles creating an SSO provider
+// POST /api/v1/admin/oidc-providers
+func (h *OIDCProviderHandler) Create(c *gin.Context) {
+	var req createOIDCProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFrom(c, infraerrors.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+	usePKCE := true
+	if req.UsePKCE != nil {
+		usePKCE = *req.UsePKCE
+	}
+	p, err := h.oidcService.Create(c.Request.Context(), &service.CreateOIDCProviderInput{
+		Provider: service.OIDCProvider{
+			Slug:                req.Slug,
+			Name:                req.Name,
+			Enabled:             enabled,
+			IssuerURL:           req.IssuerURL,
+			AuthorizeURL:        req.AuthorizeURL,
+			TokenURL:            req.TokenURL,
+			UserInfoURL:         req.UserInfoURL,
+			JWKSURL:             req.JWKSURL,
+			ClientID:            req.ClientID,
+			ClientSecret:        req.ClientSecret,
+			Scopes:              req.Scopes,
+			TokenAuthMethod:     req.TokenAuthMethod,
+			UsePKCE:             usePKCE,
+			Audiences:           req.Audiences,
+			RedirectURL:         req.RedirectURL,
+			FrontendRedirectURL: req.FrontendRedirectURL,
+			AllowedEmailDomains: req.AllowedEmailDomains,
+			TrustEmail:          req.TrustEmail,
+			UsernameClaim:       req.UsernameClaim,
+			GroupsClaim:         req.GroupsClaim,
+			DefaultGroupIDs:     req.DefaultGroupIDs,
+			GroupMappings:       oidcGroupMappingsToService(req.GroupMappings),
+			SortOrder:           req.SortOrder,
+		},
+	})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, oidcProviderToResponse(p))
+}
+
+// Update handles updating an SSO provider
+// PUT /api/v1/admin/oidc-providers/:id
+func (h *OIDCProviderHandler) Update(c *gin.Context) {
+	id, ok := parseOIDCProviderID(c)
+	if !ok {
+		return
+	}
+
+	var req updateOIDCProviderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ErrorFrom(c, infraerrors.BadRequest("VALIDATION_ERROR", err.Error()))
+		return
+	}
+
+	input := &service.UpdateOIDCProviderInput{
+		Name:                req.Name,
+		Enabled:             req.Enabled,
+		IssuerURL:           req.IssuerURL,
+		AuthorizeURL:        req.AuthorizeURL,
+		TokenURL:            req.TokenURL,
+		UserInfoURL:         req.UserInfoURL,
+		JWKSURL:             req.JWKSURL,
+		ClientID:            req.ClientID,
+		ClientSecret:        req.ClientSecret,
+		Scopes:              req.Scopes,
+		TokenAuthMethod:     req.TokenAuthMethod,
+		UsePKCE:             req.UsePKCE,
+		Audiences:           req.Audiences,
+		RedirectURL:         req.RedirectURL,
+		FrontendRedirectURL: req.FrontendRedirectURL,
+		AllowedEmailDomains: req.AllowedEmailDomains,
+		TrustEmail:          req.TrustEmail,
+		UsernameClaim:       req.UsernameClaim,
+		GroupsClaim:         req.GroupsClaim,
+		DefaultGroupIDs:     req.DefaultGroupIDs,
+		SortOrder:           req.SortOrder,
+	}
+	if req.GroupMappings != nil {
+		mappings := oidcGroupMappingsToService(*req.GroupMappings)
+		input.GroupMappings = &mappings
+	}
+
+	p, err := h.oidcService.Update(c.Request.Context(), id, input)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, oidcProviderToResponse(p))
+}
+
+// Delete handles deleting an SSO provider
+// DELETE /api/v1/admin/oidc-providers/:id
+func (h *OIDCProviderHandler) Delete(c *gin.Context) {
+	id, ok := parseOIDCProviderID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.oidcService.Delete(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "OIDC provider deleted successfully"})
+}
+
+// TestDiscovery resolves the provider endpoints to verify its configuration
+// POST /api/v1/admin/oidc-providers/:id/test
+func (h *OIDCProviderHandler) TestDiscovery(c *gin.Context) {
+	id, ok := parseOIDCProviderID(c)
+	if !ok {
+		return
+	}
+
+	doc, err := h.oidcService.TestDiscovery(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, oidcDiscoveryResponse{
+		Issuer:                doc.Issuer,
+		AuthorizationEndpoint: doc.AuthorizationEndpoint,
+		TokenEndpoint:         doc.TokenEndpoint,
+		UserInfoEndpoint:      doc.UserInfoEndpoint,
+		JWKSURI:               doc.JWKSURI,
+	})
+}
+
+func parseOIDCProviderID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.ErrorFrom(c, infraerrors.BadRequest("INVALID_OIDC_PROVIDER_ID", "Invalid OIDC provider ID"))
+		return 0, false
+	}
+	return id, true
+}
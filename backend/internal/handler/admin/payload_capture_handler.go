@@ -0,0 +1,309 @@
+package admin
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// PayloadCaptureHandler 网关载荷抓取规则管理与抓取记录查看
+type PayloadCaptureHandler struct {
+	service *service.PayloadCaptureService
+}
+
+// NewPayloadCaptureHandler 创建载荷抓取处理器
+func NewPayloadCaptureHandler(service *service.PayloadCaptureService) *PayloadCaptureHandler {
+	return &PayloadCaptureHandler{service: service}
+}
+
+// PayloadCaptureRuleRequest 创建/更新抓取规则请求（更新为整体替换）
+type PayloadCaptureRuleRequest struct {
+	ScopeType    string     `json:"scope_type" binding:"required,oneof=group api_key"`
+	ScopeID      int64      `json:"scope_id" binding:"required,gt=0"`
+	Enabled      *bool      `json:"enabled"`
+	SampleRate   *float64   `json:"sample_rate"`
+	MaxBodyBytes int        `json:"max_body_bytes"`
+	RedactFields []string   `json:"redact_fields"`
+	Note         string     `json:"note"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+}
+
+func (r *PayloadCaptureRuleRequest) toInput() *service.PayloadCaptureRuleInput {
+	input := &service.PayloadCaptureRuleInput{
+		ScopeType:    r.ScopeType,
+		ScopeID:      r.ScopeID,
+		Enabled:      true,
+		SampleRate:   1,
+		MaxBodyBytes: r.MaxBodyBytes,
+		RedactFields: r.RedactFields,
+		Note:         r.Note,
+		ExpiresAt:    r.ExpiresAt,
+	}
+	if r.Enabled != nil {
+		input.Enabled = *r.Enabled
+	}
+	if r.SampleRate != nil {
+		input.SampleRate = *r.SampleRate
+	}
+	return input
+}
+
+type payloadCaptureRuleResponse struct {
+	ID           int64      `json:"id"`
+	ScopeType    string     `json:"scope_type"`
+	ScopeID      int64      `json:"scope_id"`
+	Enabled      bool       `json:"enabled"`
+	SampleRate   float64    `json:"sample_rate"`
+	MaxBodyBytes int        `json:"max_body_bytes"`
+	RedactFields []string   `json:"redact_fields"`
+	Note         string     `json:"note"`
+	ExpiresAt    *time.Time `json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func payloadCaptureRuleFromService(r *service.PayloadCaptureRule) payloadCaptureRuleResponse {
+	fields := r.RedactFields
+	if fields == nil {
+		fields = []string{}
+	}
+	return payloadCaptureRuleResponse{
+		ID:           r.ID,
+		ScopeType:    r.ScopeType,
+		ScopeID:      r.ScopeID,
+		Enabled:      r.Enabled,
+		SampleRate:   r.SampleRate,
+		MaxBodyBytes: r.MaxBodyBytes,
+		RedactFields: fields,
+		Note:         r.Note,
+		ExpiresAt:    r.ExpiresAt,
+		CreatedAt:    r.CreatedAt,
+		UpdatedAt:    r.UpdatedAt,
+	}
+}
+
+type payloadCaptureResponse struct {
+	ID                int64     `json:"id"`
+	RuleID            int64     `json:"rule_id"`
+	RequestID         string    `json:"request_id"`
+	ClientRequestID   string    `json:"client_request_id"`
+	UserID            int64     `json:"user_id"`
+	APIKeyID          int64     `json:"api_key_id"`
+	GroupID           *int64    `json:"group_id"`
+	AccountID         *int64    `json:"account_id"`
+	Platform          string    `json:"platform"`
+	Model             string    `json:"model"`
+	Method            string    `json:"method"`
+	Path              string    `json:"path"`
+	Stream            bool      `json:"stream"`
+	StatusCode        int       `json:"status_code"`
+	DurationMs        int64     `json:"duration_ms"`
+	RequestBody       string    `json:"request_body,omitempty"`
+	ResponseBody      string    `json:"response_body,omitempty"`
+	RequestBytes      int       `json:"request_bytes"`
+	ResponseBytes     int       `json:"response_bytes"`
+	RequestTruncated  bool      `json:"request_truncated"`
+	ResponseTruncated bool      `json:"response_truncated"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+func payloadCaptureFromService(c *service.PayloadCapture) payloadCaptureResponse {
+	return payloadCaptureResponse{
+		ID:                c.ID,
+		RuleID:            c.RuleID,
+		RequestID:         c.RequestID,
+		ClientRequestID:   c.ClientRequestID,
+		UserID:            c.UserID,
+		APIKeyID:          c.APIKeyID,
+		GroupID:           c.GroupID,
+		AccountID:         c.AccountID,
+		Platform:          c.Platform,
+		Model:             c.Model,
+		Method:            c.Method,
+		Path:              c.Path,
+		Stream:            c.Stream,
+		StatusCode:        c.StatusCode,
+		DurationMs:        c.DurationMs,
+		RequestBody:       c.RequestBody,
+		ResponseBody:      c.ResponseBody,
+		RequestBytes:      c.RequestBytes,
+		ResponseBytes:     c.ResponseBytes,
+		RequestTruncated:  c.RequestTruncated,
+		ResponseTruncated: c.ResponseTruncated,
+		CreatedAt:         c.CreatedAt,
+	}
+}
+
+// ListRules 获取全部抓取规则
+// GET /api/v1/admin/ops/payload-capture/rules
+func (h *PayloadCaptureHandler) ListRules(c *gin.Context) {
+	rules, err := h.service.ListRules(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]payloadCaptureRuleResponse, 0, len(rules))
+	for i := range rules {
+		out = append(out, payloadCaptureRuleFromService(&rules[i]))
+	}
+	response.Success(c, out)
+}
+
+// GetRule 获取单条抓取规则
+// GET /api/v1/admin/ops/payload-capture/rules/:id
+func (h *PayloadCaptureHandler) GetRule(c *gin.Context) {
+	id, ok := parsePayloadCaptureID(c, "Invalid rule ID")
+	if !ok {
+		return
+	}
+	rule, err := h.service.GetRule(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, payloadCaptureRuleFromService(rule))
+}
+
+// CreateRule 创建抓取规则
+// POST /api/v1/admin/ops/payload-capture/rules
+func (h *PayloadCaptureHandler) CreateRule(c *gin.Context) {
+	var req PayloadCaptureRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	rule, err := h.service.CreateRule(c.Request.Context(), req.toInput())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, payloadCaptureRuleFromService(rule))
+}
+
+// UpdateRule 更新抓取规则
+// PUT /api/v1/admin/ops/payload-capture/rules/:id
+func (h *PayloadCaptureHandler) UpdateRule(c *gin.Context) {
+	id, ok := parsePayloadCaptureID(c, "Invalid rule ID")
+	if !ok {
+		return
+	}
+	var req PayloadCaptureRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	rule, err := h.service.UpdateRule(c.Request.Context(), id, req.toInput())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, payloadCaptureRuleFromService(rule))
+}
+
+// DeleteRule 删除抓取规则
+// DELETE /api/v1/admin/ops/payload-capture/rules/:id
+func (h *PayloadCaptureHandler) DeleteRule(c *gin.Context) {
+	id, ok := parsePayloadCaptureID(c, "Invalid rule ID")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteRule(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Rule deleted successfully"})
+}
+
+// ListCaptures 分页查询抓取记录（不含载荷正文）
+// GET /api/v1/admin/ops/payload-captures?request_id=&user_id=&api_key_id=&group_id=&model=&start_time=&end_time=
+//
+// request_id 同时匹配上游请求 ID、client_request_id 与 usage_logs 中的 "client:<id>" 形式。
+func (h *PayloadCaptureHandler) ListCaptures(c *gin.Context) {
+	filters, err := parsePayloadCaptureFilters(c)
+	if err != nil {
+		response.BadRequest(c, err.Error())
+		return
+	}
+	page, pageSize := response.ParsePagination(c)
+
+	captures, result, err := h.service.ListCaptures(c.Request.Context(), pagination.PaginationParams{Page: page, PageSize: pageSize}, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]payloadCaptureResponse, 0, len(captures))
+	for i := range captures {
+		out = append(out, payloadCaptureFromService(&captures[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// GetCapture 获取单条抓取记录（含脱敏后的请求体与响应体）
+// GET /api/v1/admin/ops/payload-captures/:id
+func (h *PayloadCaptureHandler) GetCapture(c *gin.Context) {
+	id, ok := parsePayloadCaptureID(c, "Invalid capture ID")
+	if !ok {
+		return
+	}
+	capture, err := h.service.GetCapture(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, payloadCaptureFromService(capture))
+}
+
+func parsePayloadCaptureID(c *gin.Context, message string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, message)
+		return 0, false
+	}
+	return id, true
+}
+
+func parsePayloadCaptureFilters(c *gin.Context) (service.PayloadCaptureFilters, error) {
+	filters := service.PayloadCaptureFilters{
+		RequestID: strings.TrimSpace(c.Query("request_id")),
+		Model:     strings.TrimSpace(c.Query("model")),
+	}
+	for _, f := range []struct {
+		name string
+		dst  *int64
+	}{
+		{"user_id", &filters.UserID},
+		{"api_key_id", &filters.APIKeyID},
+		{"group_id", &filters.GroupID},
+	} {
+		raw := strings.TrimSpace(c.Query(f.name))
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return filters, fmt.Errorf("invalid %s", f.name)
+		}
+		*f.dst = id
+	}
+	if raw := strings.TrimSpace(c.Query("start_time")); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filters, fmt.Errorf("invalid start_time, expected RFC3339")
+		}
+		filters.StartTime = &t
+	}
+	if raw := strings.TrimSpace(c.Query("end_time")); raw != "" {
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return filters, fmt.Errorf("invalid end_time, expected RFC3339")
+		}
+		filters.EndTime = &t
+	}
+	return filters, nil
+}
@@ -0,0 +1,69 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PaymentOrderHandler handles admin payment order queries
+type PaymentOrderHandler struct {
+	paymentService *service.PaymentService
+}
+
+// NewPaymentOrderHandler creates a new admin payment order handler
+func NewPaymentOrderHandler(paymentService *service.PaymentService) *PaymentOrderHandler {
+	return &PaymentOrderHandler{paymentService: paymentService}
+}
+
+// List handles listing payment orders with filters
+// GET /api/v1/admin/payment-orders?status=&provider=&kind=&user_id=&search=
+func (h *PaymentOrderHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	filters := service.PaymentOrderListFilters{
+		Status:   strings.TrimSpace(c.Query("status")),
+		Provider: strings.TrimSpace(c.Query("provider")),
+		Kind:     strings.TrimSpace(c.Query("kind")),
+		Search:   strings.TrimSpace(c.Query("search")),
+	}
+	if len(filters.Search) > 100 {
+		filters.Search = filters.Search[:100]
+	}
+	if raw := strings.TrimSpace(c.Query("user_id")); raw != "" {
+		userID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || userID <= 0 {
+			response.BadRequest(c, "Invalid user_id")
+			return
+		}
+		filters.UserID = userID
+	}
+
+	orders, result, err := h.paymentService.ListOrders(c.Request.Context(), pagination.PaginationParams{Page: page, PageSize: pageSize}, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.AdminPaymentOrder, 0, len(orders))
+	for i := range orders {
+		out = append(out, *dto.PaymentOrderFromServiceAdmin(&orders[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// GetByOrderNo handles getting a payment order
+// GET /api/v1/admin/payment-orders/:order_no
+func (h *PaymentOrderHandler) GetByOrderNo(c *gin.Context) {
+	order, err := h.paymentService.GetOrder(c.Request.Context(), c.Param("order_no"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.PaymentOrderFromServiceAdmin(order))
+}
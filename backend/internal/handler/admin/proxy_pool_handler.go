@@ -0,0 +1,168 @@
+package admin
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// ProxyPoolHandler 代理池管理与健康状态
+type ProxyPoolHandler struct {
+	service *service.ProxyPoolService
+}
+
+// NewProxyPoolHandler 创建代理池处理器
+func NewProxyPoolHandler(service *service.ProxyPoolService) *ProxyPoolHandler {
+	return &ProxyPoolHandler{service: service}
+}
+
+// ProxyPoolRequest 创建/更新代理池请求（更新为整体替换）
+type ProxyPoolRequest struct {
+	Name        string  `json:"name" binding:"required,max=100"`
+	Description string  `json:"description"`
+	Strategy    string  `json:"strategy" binding:"omitempty,oneof=sticky round_robin lowest_latency"`
+	ProxyIDs    []int64 `json:"proxy_ids" binding:"required,min=1"`
+	Status      string  `json:"status" binding:"omitempty,oneof=active disabled"`
+}
+
+func (r *ProxyPoolRequest) toInput() *service.ProxyPoolInput {
+	return &service.ProxyPoolInput{
+		Name:        r.Name,
+		Description: r.Description,
+		Strategy:    r.Strategy,
+		ProxyIDs:    r.ProxyIDs,
+		Status:      r.Status,
+	}
+}
+
+type proxyPoolResponse struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Strategy    string    `json:"strategy"`
+	ProxyIDs    []int64   `json:"proxy_ids"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func proxyPoolFromService(p *service.ProxyPool) proxyPoolResponse {
+	proxyIDs := p.ProxyIDs
+	if proxyIDs == nil {
+		proxyIDs = []int64{}
+	}
+	return proxyPoolResponse{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Strategy:    p.Strategy,
+		ProxyIDs:    proxyIDs,
+		Status:      p.Status,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+// List 获取代理池列表
+// GET /api/v1/admin/proxy-pools
+func (h *ProxyPoolHandler) List(c *gin.Context) {
+	pools, err := h.service.List(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := make([]proxyPoolResponse, 0, len(pools))
+	for i := range pools {
+		out = append(out, proxyPoolFromService(&pools[i]))
+	}
+	response.Success(c, out)
+}
+
+// Health 获取代理池及池内代理的健康状态（本实例视角）
+// GET /api/v1/admin/proxy-pools/health
+// GET /api/v1/admin/ops/proxy-pools/health
+func (h *ProxyPoolHandler) Health(c *gin.Context) {
+	health, err := h.service.Health(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, health)
+}
+
+// GetByID 获取单个代理池
+// GET /api/v1/admin/proxy-pools/:id
+func (h *ProxyPoolHandler) GetByID(c *gin.Context) {
+	id, ok := parseProxyPoolID(c)
+	if !ok {
+		return
+	}
+	pool, err := h.service.GetByID(c.Request.Context(), id)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, proxyPoolFromService(pool))
+}
+
+// Create 创建代理池
+// POST /api/v1/admin/proxy-pools
+func (h *ProxyPoolHandler) Create(c *gin.Context) {
+	var req ProxyPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	pool, err := h.service.Create(c.Request.Context(), req.toInput())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, proxyPoolFromService(pool))
+}
+
+// Update 更新代理池
+// PUT /api/v1/admin/proxy-pools/:id
+func (h *ProxyPoolHandler) Update(c *gin.Context) {
+	id, ok := parseProxyPoolID(c)
+	if !ok {
+		return
+	}
+	var req ProxyPoolRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+	pool, err := h.service.Update(c.Request.Context(), id, req.toInput())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, proxyPoolFromService(pool))
+}
+
+// Delete 删除代理池（仍有账号绑定时拒绝）
+// DELETE /api/v1/admin/proxy-pools/:id
+func (h *ProxyPoolHandler) Delete(c *gin.Context) {
+	id, ok := parseProxyPoolID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, gin.H{"message": "Proxy pool deleted successfully"})
+}
+
+func parseProxyPoolID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		response.BadRequest(c, "Invalid proxy pool ID")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,88 @@
+package admin
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReferralHandler handles admin views of referral relations and commissions
+type ReferralHandler struct {
+	referralService *service.ReferralService
+}
+
+// NewReferralHandler creates a new admin referral handler
+func NewReferralHandler(referralService *service.ReferralService) *ReferralHandler {
+	return &ReferralHandler{referralService: referralService}
+}
+
+// List handles listing inviter→invitee relations
+// GET /api/v1/admin/referrals?inviter_id=&invitee_id=
+func (h *ReferralHandler) List(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	filters, ok := parseReferralListFilters(c)
+	if !ok {
+		return
+	}
+
+	referrals, result, err := h.referralService.List(c.Request.Context(), pagination.PaginationParams{Page: page, PageSize: pageSize}, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.AdminReferral, 0, len(referrals))
+	for i := range referrals {
+		out = append(out, *dto.ReferralFromServiceAdmin(&referrals[i]))
+	}
+	response.PaginatedWithResult(c, out, toResponsePagination(result))
+}
+
+// ListCommissions handles listing settled referral commissions
+// GET /api/v1/admin/referrals/commissions?inviter_id=&invitee_id=
+func (h *ReferralHandler) ListCommissions(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	filters, ok := parseReferralListFilters(c)
+	if !ok {
+		return
+	}
+
+	commissions, result, err := h.referralService.ListCommissions(c.Request.Context(), pagination.PaginationParams{Page: page, PageSize: pageSize}, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.AdminReferralCommission, 0, len(commissions))
+	for i := range commissions {
+		out = append(out, *dto.ReferralCommissionFromServiceAdmin(&commissions[i]))
+	}
+	response.PaginatedWithResult(c, out, toResponsePagination(result))
+}
+
+func parseReferralListFilters(c *gin.Context) (service.ReferralListFilters, bool) {
+	var filters service.ReferralListFilters
+	if raw := strings.TrimSpace(c.Query("inviter_id")); raw != "" {
+		inviterID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || inviterID <= 0 {
+			response.BadRequest(c, "Invalid inviter_id")
+			return filters, false
+		}
+		filters.InviterID = inviterID
+	}
+	if raw := strings.TrimSpace(c.Query("invitee_id")); raw != "" {
+		inviteeID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || inviteeID <= 0 {
+			response.BadRequest(c, "Invalid invitee_id")
+			return filters, false
+		}
+		filters.InviteeID = inviteeID
+	}
+	return filters, true
+}
@@ -3,6 +3,7 @@ package admin
 import (
 	"context"
 	"strconv"
+	"strings"
 
 	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
@@ -29,12 +30,14 @@ func toResponsePagination(p *pagination.PaginationResult) *response.PaginationRe
 // SubscriptionHandler handles admin subscription management
 type SubscriptionHandler struct {
 	subscriptionService *service.SubscriptionService
+	purchaseService     *service.SubscriptionPurchaseService
 }
 
 // NewSubscriptionHandler creates a new admin subscription handler
-func NewSubscriptionHandler(subscriptionService *service.SubscriptionService) *SubscriptionHandler {
+func NewSubscriptionHandler(subscriptionService *service.SubscriptionService, purchaseService *service.SubscriptionPurchaseService) *SubscriptionHandler {
 	return &SubscriptionHandler{
 		subscriptionService: subscriptionService,
+		purchaseService:     purchaseService,
 	}
 }
 
@@ -321,3 +324,41 @@ func getAdminIDFromContext(c *gin.Context) int64 {
 	}
 	return subject.UserID
 }
+
+// ListPurchases handles listing balance-funded subscription purchases and renewals
+// GET /api/v1/admin/subscriptions/purchases?user_id=&group_id=&kind=&status=
+func (h *SubscriptionHandler) ListPurchases(c *gin.Context) {
+	page, pageSize := response.ParsePagination(c)
+	filters := service.SubscriptionPurchaseListFilters{
+		Kind:   strings.TrimSpace(c.Query("kind")),
+		Status: strings.TrimSpace(c.Query("status")),
+	}
+	if raw := strings.TrimSpace(c.Query("user_id")); raw != "" {
+		userID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || userID <= 0 {
+			response.BadRequest(c, "Invalid user_id")
+			return
+		}
+		filters.UserID = userID
+	}
+	if raw := strings.TrimSpace(c.Query("group_id")); raw != "" {
+		groupID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || groupID <= 0 {
+			response.BadRequest(c, "Invalid group_id")
+			return
+		}
+		filters.GroupID = groupID
+	}
+
+	purchases, result, err := h.purchaseService.ListPurchases(c.Request.Context(), pagination.PaginationParams{Page: page, PageSize: pageSize}, filters)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.SubscriptionPurchase, 0, len(purchases))
+	for i := range purchases {
+		out = append(out, *dto.SubscriptionPurchaseFromService(&purchases[i]))
+	}
+	response.PaginatedWithResult(c, out, toResponsePagination(result))
+}
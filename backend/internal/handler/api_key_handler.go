@@ -35,6 +35,8 @@ type CreateAPIKeyRequest struct {
 	CustomKey     *string  `json:"custom_key"`      // 可选的自定义key
 	IPWhitelist   []string `json:"ip_whitelist"`    // IP 白名单
 	IPBlacklist   []string `json:"ip_blacklist"`    // IP 黑名单
+	AllowedModels []string `json:"allowed_models"`  // 模型白名单（支持 * 通配符）
+	DeniedModels  []string `json:"denied_models"`   // 模型黑名单（支持 * 通配符）
 	Quota         *float64 `json:"quota"`           // 配额限制 (USD)
 	ExpiresInDays *int     `json:"expires_in_days"` // 过期天数
 
@@ -42,24 +44,34 @@ type CreateAPIKeyRequest struct {
 	RateLimit5h *float64 `json:"rate_limit_5h"`
 	RateLimit1d *float64 `json:"rate_limit_1d"`
 	RateLimit7d *float64 `json:"rate_limit_7d"`
+
+	// Request rate limit fields (0 = unlimited)
+	RPMLimit *int `json:"rpm_limit"`
+	TPMLimit *int `json:"tpm_limit"`
 }
 
 // UpdateAPIKeyRequest represents the update API key request payload
 type UpdateAPIKeyRequest struct {
-	Name        string   `json:"name"`
-	GroupID     *int64   `json:"group_id"`
-	Status      string   `json:"status" binding:"omitempty,oneof=active inactive"`
-	IPWhitelist []string `json:"ip_whitelist"` // IP 白名单
-	IPBlacklist []string `json:"ip_blacklist"` // IP 黑名单
-	Quota       *float64 `json:"quota"`        // 配额限制 (USD), 0=无限制
-	ExpiresAt   *string  `json:"expires_at"`   // 过期时间 (ISO 8601)
-	ResetQuota  *bool    `json:"reset_quota"`  // 重置已用配额
+	Name          string   `json:"name"`
+	GroupID       *int64   `json:"group_id"`
+	Status        string   `json:"status" binding:"omitempty,oneof=active inactive"`
+	IPWhitelist   []string `json:"ip_whitelist"`   // IP 白名单
+	IPBlacklist   []string `json:"ip_blacklist"`   // IP 黑名单
+	AllowedModels []string `json:"allowed_models"` // 模型白名单（支持 * 通配符）
+	DeniedModels  []string `json:"denied_models"`  // 模型黑名单（支持 * 通配符）
+	Quota         *float64 `json:"quota"`          // 配额限制 (USD), 0=无限制
+	ExpiresAt     *string  `json:"expires_at"`     // 过期时间 (ISO 8601)
+	ResetQuota    *bool    `json:"reset_quota"`    // 重置已用配额
 
 	// Rate limit fields (nil = no change, 0 = unlimited)
 	RateLimit5h         *float64 `json:"rate_limit_5h"`
 	RateLimit1d         *float64 `json:"rate_limit_1d"`
 	RateLimit7d         *float64 `json:"rate_limit_7d"`
 	ResetRateLimitUsage *bool    `json:"reset_rate_limit_usage"` // 重置限速用量
+
+	// Request rate limit fields (nil = no change, 0 = unlimited)
+	RPMLimit *int `json:"rpm_limit"`
+	TPMLimit *int `json:"tpm_limit"`
 }
 
 // List handles listing user's API keys with pagination
@@ -154,6 +166,8 @@ func (h *APIKeyHandler) Create(c *gin.Context) {
 		CustomKey:     req.CustomKey,
 		IPWhitelist:   req.IPWhitelist,
 		IPBlacklist:   req.IPBlacklist,
+		AllowedModels: req.AllowedModels,
+		DeniedModels:  req.DeniedModels,
 		ExpiresInDays: req.ExpiresInDays,
 	}
 	if req.Quota != nil {
@@ -168,14 +182,34 @@ func (h *APIKeyHandler) Create(c *gin.Context) {
 	if req.RateLimit7d != nil {
 		svcReq.RateLimit7d = *req.RateLimit7d
 	}
+	if req.RPMLimit != nil {
+		svcReq.RPMLimit = *req.RPMLimit
+	}
+	if req.TPMLimit != nil {
+		svcReq.TPMLimit = *req.TPMLimit
+	}
 
-	executeUserIdempotentJSON(c, "user.api_keys.create", req, service.DefaultWriteIdempotencyTTL(), func(ctx context.Context) (any, error) {
+	// 幂等记录会持久化响应体，因此只存储不含明文 Key 的结果；
+	// 完整 Key 仅在首次执行的响应中返回一次，重放时只返回前缀。
+	var created *dto.APIKey
+	data, replayed, ok := runUserIdempotent(c, "user.api_keys.create", req, service.DefaultWriteIdempotencyTTL(), func(ctx context.Context) (any, error) {
 		key, err := h.apiKeyService.Create(ctx, subject.UserID, svcReq)
 		if err != nil {
 			return nil, err
 		}
-		return dto.APIKeyFromService(key), nil
+		created = dto.APIKeyFromService(key)
+		stored := *created
+		stored.Key = ""
+		return &stored, nil
 	})
+	if !ok {
+		return
+	}
+	if !replayed && created != nil {
+		response.Success(c, created)
+		return
+	}
+	response.Success(c, data)
 }
 
 // Update handles updating an API key
@@ -202,12 +236,16 @@ func (h *APIKeyHandler) Update(c *gin.Context) {
 	svcReq := service.UpdateAPIKeyRequest{
 		IPWhitelist:         req.IPWhitelist,
 		IPBlacklist:         req.IPBlacklist,
+		AllowedModels:       req.AllowedModels,
+		DeniedModels:        req.DeniedModels,
 		Quota:               req.Quota,
 		ResetQuota:          req.ResetQuota,
 		RateLimit5h:         req.RateLimit5h,
 		RateLimit1d:         req.RateLimit1d,
 		RateLimit7d:         req.RateLimit7d,
 		ResetRateLimitUsage: req.ResetRateLimitUsage,
+		RPMLimit:            req.RPMLimit,
+		TPMLimit:            req.TPMLimit,
 	}
 	if req.Name != "" {
 		svcReq.Name = &req.Name
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/gemini"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// apiKeyModelNotAllowedMessage 模型不在 API Key 允许范围内时返回给客户端的错误信息
+func apiKeyModelNotAllowedMessage(model string) string {
+	return fmt.Sprintf("Model %q is not allowed for this API key", model)
+}
+
+// isAPIKeyModelAllowed 检查 API Key 的模型白名单/黑名单（在账号调度之前调用）
+func isAPIKeyModelAllowed(apiKey *service.APIKey, model string) bool {
+	return apiKey == nil || apiKey.IsModelAllowed(model)
+}
+
+// filterModelsForAPIKey 按 API Key 的模型限制过滤模型列表；无限制时原样返回
+func filterModelsForAPIKey[T any](apiKey *service.APIKey, models []T, modelID func(T) string) []T {
+	if apiKey == nil || !apiKey.HasModelRestrictions() {
+		return models
+	}
+	filtered := make([]T, 0, len(models))
+	for _, m := range models {
+		if apiKey.IsModelAllowed(modelID(m)) {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
+
+// filterGeminiModelsListBody 过滤上游 Gemini ListModels 响应中的 models[].name；
+// 非 2xx 或无法解析的响应原样返回。
+func filterGeminiModelsListBody(apiKey *service.APIKey, res *service.UpstreamHTTPResult) {
+	if res == nil || apiKey == nil || !apiKey.HasModelRestrictions() {
+		return
+	}
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return
+	}
+	models := gjson.GetBytes(res.Body, "models")
+	if !models.IsArray() {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('[')
+	n := 0
+	for _, m := range models.Array() {
+		if !apiKey.IsModelAllowed(m.Get("name").String()) {
+			continue
+		}
+		if n > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(m.Raw)
+		n++
+	}
+	sb.WriteByte(']')
+
+	body, err := sjson.SetRawBytes(res.Body, "models", []byte(sb.String()))
+	if err != nil {
+		return
+	}
+	res.Body = body
+}
+
+// filterGeminiFallbackModelsList 返回按 API Key 模型限制过滤后的 Gemini 静态模型列表
+func filterGeminiFallbackModelsList(apiKey *service.APIKey) gemini.ModelsListResponse {
+	list := gemini.FallbackModelsList()
+	list.Models = filterModelsForAPIKey(apiKey, list.Models, func(m gemini.Model) string { return m.Name })
+	return list
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/claude"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestFilterModelsForAPIKey(t *testing.T) {
+	models := []claude.Model{{ID: "claude-sonnet-4-5"}, {ID: "claude-opus-4-5"}, {ID: "claude-haiku-4-5"}}
+	id := func(m claude.Model) string { return m.ID }
+
+	require.Len(t, filterModelsForAPIKey(nil, models, id), 3)
+	require.Len(t, filterModelsForAPIKey(&service.APIKey{}, models, id), 3)
+
+	apiKey := &service.APIKey{AllowedModels: []string{"claude-*"}, DeniedModels: []string{"claude-opus-*"}}
+	filtered := filterModelsForAPIKey(apiKey, models, id)
+	require.Equal(t, []claude.Model{{ID: "claude-sonnet-4-5"}, {ID: "claude-haiku-4-5"}}, filtered)
+}
+
+func TestFilterGeminiModelsListBody(t *testing.T) {
+	apiKey := &service.APIKey{AllowedModels: []string{"gemini-2.5-*"}}
+
+	res := &service.UpstreamHTTPResult{
+		StatusCode: http.StatusOK,
+		Body:       []byte(`{"models":[{"name":"models/gemini-2.5-pro"},{"name":"models/gemini-3-pro-preview"},{"name":"models/gemini-2.5-flash"}],"nextPageToken":"x"}`),
+	}
+	filterGeminiModelsListBody(apiKey, res)
+
+	names := gjson.GetBytes(res.Body, "models.#.name").Array()
+	require.Len(t, names, 2)
+	require.Equal(t, "models/gemini-2.5-pro", names[0].String())
+	require.Equal(t, "models/gemini-2.5-flash", names[1].String())
+	require.Equal(t, "x", gjson.GetBytes(res.Body, "nextPageToken").String())
+
+	errBody := []byte(`{"error":{"code":403}}`)
+	errRes := &service.UpstreamHTTPResult{StatusCode: http.StatusForbidden, Body: errBody}
+	filterGeminiModelsListBody(apiKey, errRes)
+	require.Equal(t, errBody, errRes.Body)
+}
@@ -29,11 +29,13 @@ const (
 	linuxDoOAuthStateCookieName   = "linuxdo_oauth_state"
 	linuxDoOAuthVerifierCookie    = "linuxdo_oauth_verifier"
 	linuxDoOAuthRedirectCookie    = "linuxdo_oauth_redirect"
+	linuxDoOAuthReferralCookie    = "linuxdo_oauth_referral"
 	linuxDoOAuthCookieMaxAgeSec   = 10 * 60 // 10 minutes
 	linuxDoOAuthDefaultRedirectTo = "/dashboard"
 	linuxDoOAuthDefaultFrontendCB = "/auth/linuxdo/callback"
 
 	linuxDoOAuthMaxRedirectLen      = 2048
+	oauthMaxReferralCodeLen         = 64
 	linuxDoOAuthMaxFragmentValueLen = 512
 	linuxDoOAuthMaxSubjectLen       = 64 - len("linuxdo-")
 )
@@ -68,7 +70,7 @@ func (e *linuxDoTokenExchangeError) Error() string {
 }
 
 // LinuxDoOAuthStart 启动 LinuxDo Connect OAuth 登录流程。
-// GET /api/v1/auth/oauth/linuxdo/start?redirect=/dashboard
+// GET /api/v1/auth/oauth/linuxdo/start?redirect=/dashboard&referral_code=ABCD2345
 func (h *AuthHandler) LinuxDoOAuthStart(c *gin.Context) {
 	cfg, err := h.getLinuxDoOAuthConfig(c.Request.Context())
 	if err != nil {
@@ -90,6 +92,10 @@ func (h *AuthHandler) LinuxDoOAuthStart(c *gin.Context) {
 	secureCookie := isRequestHTTPS(c)
 	setCookie(c, linuxDoOAuthStateCookieName, encodeCookieValue(state), linuxDoOAuthCookieMaxAgeSec, secureCookie)
 	setCookie(c, linuxDoOAuthRedirectCookie, encodeCookieValue(redirectTo), linuxDoOAuthCookieMaxAgeSec, secureCookie)
+	// 推广码随 state 一起保存，回调注册新用户时绑定邀请关系
+	if referralCode := sanitizeOAuthReferralCode(c.Query("referral_code")); referralCode != "" {
+		setCookie(c, linuxDoOAuthReferralCookie, encodeCookieValue(referralCode), linuxDoOAuthCookieMaxAgeSec, secureCookie)
+	}
 
 	codeChallenge := ""
 	if cfg.UsePKCE {
@@ -148,6 +154,7 @@ func (h *AuthHandler) LinuxDoOAuthCallback(c *gin.Context) {
 		clearCookie(c, linuxDoOAuthStateCookieName, secureCookie)
 		clearCookie(c, linuxDoOAuthVerifierCookie, secureCookie)
 		clearCookie(c, linuxDoOAuthRedirectCookie, secureCookie)
+		clearCookie(c, linuxDoOAuthReferralCookie, secureCookie)
 	}()
 
 	expectedState, err := readCookieDecoded(c, linuxDoOAuthStateCookieName)
@@ -161,6 +168,8 @@ func (h *AuthHandler) LinuxDoOAuthCallback(c *gin.Context) {
 	if redirectTo == "" {
 		redirectTo = linuxDoOAuthDefaultRedirectTo
 	}
+	referralCode, _ := readCookieDecoded(c, linuxDoOAuthReferralCookie)
+	referralCode = sanitizeOAuthReferralCode(referralCode)
 
 	codeVerifier := ""
 	if cfg.UsePKCE {
@@ -212,10 +221,10 @@ func (h *AuthHandler) LinuxDoOAuthCallback(c *gin.Context) {
 	}
 
 	// 传入空邀请码；如果需要邀请码，服务层返回 ErrOAuthInvitationRequired
-	tokenPair, _, err := h.authService.LoginOrRegisterOAuthWithTokenPair(c.Request.Context(), email, username, "")
+	tokenPair, _, err := h.authService.LoginOrRegisterOAuthWithGrants(c.Request.Context(), email, username, "", referralCode, nil)
 	if err != nil {
 		if errors.Is(err, service.ErrOAuthInvitationRequired) {
-			pendingToken, tokenErr := h.authService.CreatePendingOAuthToken(email, username)
+			pendingToken, tokenErr := h.authService.CreatePendingOAuthTokenWithGrants(email, username, referralCode, nil)
 			if tokenErr != nil {
 				redirectOAuthError(c, frontendCallback, "login_failed", "service_error", "")
 				return
@@ -244,25 +253,36 @@ func (h *AuthHandler) LinuxDoOAuthCallback(c *gin.Context) {
 type completeLinuxDoOAuthRequest struct {
 	PendingOAuthToken string `json:"pending_oauth_token" binding:"required"`
 	InvitationCode    string `json:"invitation_code"     binding:"required"`
+	// ReferralCode 可选：pending token 未携带推广码时使用
+	ReferralCode string `json:"referral_code"`
 }
 
 // CompleteLinuxDoOAuthRegistration completes a pending OAuth registration by validating
 // the invitation code and creating the user account.
 // POST /api/v1/auth/oauth/linuxdo/complete-registration
 func (h *AuthHandler) CompleteLinuxDoOAuthRegistration(c *gin.Context) {
+	h.completePendingOAuthRegistration(c)
+}
+
+// completePendingOAuthRegistration 校验 pending token 与邀请码并完成注册（各 OAuth 提供方共用）。
+// pending token 中携带的推广码与 SSO 授予项（专属分组/订阅）在注册完成时一并应用。
+func (h *AuthHandler) completePendingOAuthRegistration(c *gin.Context) {
 	var req completeLinuxDoOAuthRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "INVALID_REQUEST", "message": err.Error()})
 		return
 	}
 
-	email, username, err := h.authService.VerifyPendingOAuthToken(req.PendingOAuthToken)
+	email, username, referralCode, grants, err := h.authService.VerifyPendingOAuthTokenWithGrants(req.PendingOAuthToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "INVALID_TOKEN", "message": "invalid or expired registration token"})
 		return
 	}
+	if referralCode == "" {
+		referralCode = sanitizeOAuthReferralCode(req.ReferralCode)
+	}
 
-	tokenPair, _, err := h.authService.LoginOrRegisterOAuthWithTokenPair(c.Request.Context(), email, username, req.InvitationCode)
+	tokenPair, _, err := h.authService.LoginOrRegisterOAuthWithGrants(c.Request.Context(), email, username, req.InvitationCode, referralCode, grants)
 	if err != nil {
 		response.ErrorFrom(c, err)
 		return
@@ -618,6 +638,15 @@ func sanitizeFrontendRedirectPath(path string) string {
 	return path
 }
 
+// sanitizeOAuthReferralCode 推广码经 cookie / pending token 传递，只保留长度合理的单行值
+func sanitizeOAuthReferralCode(code string) string {
+	code = strings.TrimSpace(code)
+	if len(code) > oauthMaxReferralCodeLen || strings.ContainsAny(code, "\r\n;") {
+		return ""
+	}
+	return code
+}
+
 func isRequestHTTPS(c *gin.Context) bool {
 	if c.Request.TLS != nil {
 		return true
@@ -647,10 +676,14 @@ func readCookieDecoded(c *gin.Context, name string) (string, error) {
 }
 
 func setCookie(c *gin.Context, name string, value string, maxAgeSec int, secure bool) {
+	setCookieWithPath(c, linuxDoOAuthCookiePath, name, value, maxAgeSec, secure)
+}
+
+func setCookieWithPath(c *gin.Context, path string, name string, value string, maxAgeSec int, secure bool) {
 	http.SetCookie(c.Writer, &http.Cookie{
 		Name:     name,
 		Value:    value,
-		Path:     linuxDoOAuthCookiePath,
+		Path:     path,
 		MaxAge:   maxAgeSec,
 		HttpOnly: true,
 		Secure:   secure,
@@ -659,10 +692,14 @@ func setCookie(c *gin.Context, name string, value string, maxAgeSec int, secure
 }
 
 func clearCookie(c *gin.Context, name string, secure bool) {
+	clearCookieWithPath(c, linuxDoOAuthCookiePath, name, secure)
+}
+
+func clearCookieWithPath(c *gin.Context, path string, name string, secure bool) {
 	http.SetCookie(c.Writer, &http.Cookie{
 		Name:     name,
 		Value:    "",
-		Path:     linuxDoOAuthCookiePath,
+		Path:     path,
 		MaxAge:   -1,
 		HttpOnly: true,
 		Secure:   secure,
@@ -20,6 +20,13 @@ func TestSanitizeFrontendRedirectPath(t *testing.T) {
 	require.Equal(t, "", sanitizeFrontendRedirectPath(long))
 }
 
+func TestSanitizeOAuthReferralCode(t *testing.T) {
+	require.Equal(t, "ABCD2345", sanitizeOAuthReferralCode(" ABCD2345 "))
+	require.Equal(t, "", sanitizeOAuthReferralCode("AB\nCD"))
+	require.Equal(t, "", sanitizeOAuthReferralCode("AB;CD"))
+	require.Equal(t, "", sanitizeOAuthReferralCode(strings.Repeat("A", oauthMaxReferralCodeLen+1)))
+}
+
 func TestBuildBearerAuthorization(t *testing.T) {
 	auth, err := buildBearerAuthorization("", "token123")
 	require.NoError(t, err)
@@ -0,0 +1,239 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/oauth"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	oidcOAuthCookiePath        = "/api/v1/auth/oauth/oidc"
+	oidcOAuthStateCookieName   = "oidc_oauth_state"
+	oidcOAuthNonceCookieName   = "oidc_oauth_nonce"
+	oidcOAuthVerifierCookie    = "oidc_oauth_verifier"
+	oidcOAuthRedirectCookie    = "oidc_oauth_redirect"
+	oidcOAuthProviderCookie    = "oidc_oauth_provider"
+	oidcOAuthReferralCookie    = "oidc_oauth_referral"
+	oidcOAuthCookieMaxAgeSec   = 10 * 60 // 10 minutes
+	oidcOAuthDefaultRedirectTo = "/dashboard"
+)
+
+type oidcPublicProvider struct {
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	StartURL string `json:"start_url"`
+}
+
+// ListOIDCProviders 返回登录页可用的 SSO 提供方（不含任何密钥与端点信息）。
+// GET /api/v1/auth/oauth/oidc/providers
+func (h *AuthHandler) ListOIDCProviders(c *gin.Context) {
+	out := []oidcPublicProvider{}
+	if h.oidcService == nil {
+		response.Success(c, out)
+		return
+	}
+	providers, err := h.oidcService.ListEnabled(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	for _, p := range providers {
+		out = append(out, oidcPublicProvider{
+			Slug:     p.Slug,
+			Name:     p.Name,
+			StartURL: oidcOAuthCookiePath + "/" + p.Slug + "/start",
+		})
+	}
+	response.Success(c, out)
+}
+
+// OIDCOAuthStart 启动 OIDC / OAuth2 SSO 登录流程。
+// GET /api/v1/auth/oauth/oidc/:provider/start?redirect=/dashboard&referral_code=ABCD2345
+func (h *AuthHandler) OIDCOAuthStart(c *gin.Context) {
+	p, err := h.getOIDCProvider(c)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	state, err := oauth.GenerateState()
+	if err != nil {
+		response.ErrorFrom(c, infraerrors.InternalServer("OAUTH_STATE_GEN_FAILED", "failed to generate oauth state").WithCause(err))
+		return
+	}
+	nonce, err := oauth.GenerateState()
+	if err != nil {
+		response.ErrorFrom(c, infraerrors.InternalServer("OAUTH_STATE_GEN_FAILED", "failed to generate oidc nonce").WithCause(err))
+		return
+	}
+
+	redirectTo := sanitizeFrontendRedirectPath(c.Query("redirect"))
+	if redirectTo == "" {
+		redirectTo = oidcOAuthDefaultRedirectTo
+	}
+
+	authReq := &service.OIDCAuthRequest{State: state, Nonce: nonce}
+	verifier := ""
+	if p.UsePKCE {
+		verifier, err = oauth.GenerateCodeVerifier()
+		if err != nil {
+			response.ErrorFrom(c, infraerrors.InternalServer("OAUTH_PKCE_GEN_FAILED", "failed to generate pkce verifier").WithCause(err))
+			return
+		}
+		authReq.CodeChallenge = oauth.GenerateCodeChallenge(verifier)
+	}
+
+	authURL, err := h.oidcService.BuildAuthorizeURL(c.Request.Context(), p, authReq)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	secureCookie := isRequestHTTPS(c)
+	setCookieWithPath(c, oidcOAuthCookiePath, oidcOAuthStateCookieName, encodeCookieValue(state), oidcOAuthCookieMaxAgeSec, secureCookie)
+	setCookieWithPath(c, oidcOAuthCookiePath, oidcOAuthNonceCookieName, encodeCookieValue(nonce), oidcOAuthCookieMaxAgeSec, secureCookie)
+	setCookieWithPath(c, oidcOAuthCookiePath, oidcOAuthRedirectCookie, encodeCookieValue(redirectTo), oidcOAuthCookieMaxAgeSec, secureCookie)
+	setCookieWithPath(c, oidcOAuthCookiePath, oidcOAuthProviderCookie, encodeCookieValue(p.Slug), oidcOAuthCookieMaxAgeSec, secureCookie)
+	// 推广码随 state 一起保存，回调注册新用户时绑定邀请关系
+	if referralCode := sanitizeOAuthReferralCode(c.Query("referral_code")); referralCode != "" {
+		setCookieWithPath(c, oidcOAuthCookiePath, oidcOAuthReferralCookie, encodeCookieValue(referralCode), oidcOAuthCookieMaxAgeSec, secureCookie)
+	}
+	if verifier != "" {
+		setCookieWithPath(c, oidcOAuthCookiePath, oidcOAuthVerifierCookie, encodeCookieValue(verifier), oidcOAuthCookieMaxAgeSec, secureCookie)
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCOAuthCallback 处理 SSO 回调：校验身份、创建/登录用户并授予映射的分组，然后重定向到前端。
+// GET /api/v1/auth/oauth/oidc/:provider/callback?code=...&state=...
+func (h *AuthHandler) OIDCOAuthCallback(c *gin.Context) {
+	p, err := h.getOIDCProvider(c)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	frontendCallback := p.FrontendRedirectURL
+	if frontendCallback == "" {
+		frontendCallback = service.OIDCDefaultFrontendRedirectURL
+	}
+
+	if providerErr := strings.TrimSpace(c.Query("error")); providerErr != "" {
+		redirectOAuthError(c, frontendCallback, "provider_error", providerErr, c.Query("error_description"))
+		return
+	}
+
+	code := strings.TrimSpace(c.Query("code"))
+	state := strings.TrimSpace(c.Query("state"))
+	if code == "" || state == "" {
+		redirectOAuthError(c, frontendCallback, "missing_params", "missing code/state", "")
+		return
+	}
+
+	secureCookie := isRequestHTTPS(c)
+	defer func() {
+		for _, name := range []string{oidcOAuthStateCookieName, oidcOAuthNonceCookieName, oidcOAuthVerifierCookie, oidcOAuthRedirectCookie, oidcOAuthProviderCookie, oidcOAuthReferralCookie} {
+			clearCookieWithPath(c, oidcOAuthCookiePath, name, secureCookie)
+		}
+	}()
+
+	expectedState, err := readCookieDecoded(c, oidcOAuthStateCookieName)
+	if err != nil || expectedState == "" || state != expectedState {
+		redirectOAuthError(c, frontendCallback, "invalid_state", "invalid oauth state", "")
+		return
+	}
+	// state 绑定发起登录的提供方，防止跨提供方混用授权码
+	if startedWith, _ := readCookieDecoded(c, oidcOAuthProviderCookie); startedWith != p.Slug {
+		redirectOAuthError(c, frontendCallback, "invalid_state", "oauth provider mismatch", "")
+		return
+	}
+
+	redirectTo, _ := readCookieDecoded(c, oidcOAuthRedirectCookie)
+	redirectTo = sanitizeFrontendRedirectPath(redirectTo)
+	if redirectTo == "" {
+		redirectTo = oidcOAuthDefaultRedirectTo
+	}
+	referralCode, _ := readCookieDecoded(c, oidcOAuthReferralCookie)
+	referralCode = sanitizeOAuthReferralCode(referralCode)
+
+	codeVerifier := ""
+	if p.UsePKCE {
+		codeVerifier, _ = readCookieDecoded(c, oidcOAuthVerifierCookie)
+		if codeVerifier == "" {
+			redirectOAuthError(c, frontendCallback, "missing_verifier", "missing pkce verifier", "")
+			return
+		}
+	}
+	nonce, _ := readCookieDecoded(c, oidcOAuthNonceCookieName)
+	if p.IsOpenID() && nonce == "" {
+		redirectOAuthError(c, frontendCallback, "invalid_state", "missing oidc nonce", "")
+		return
+	}
+
+	identity, err := h.oidcService.Authenticate(c.Request.Context(), p, code, codeVerifier, nonce)
+	if err != nil {
+		log.Printf("[OIDC] provider=%s authentication failed: %v", p.Slug, err)
+		if errors.Is(err, service.ErrOIDCEmailDomainNotAllowed) {
+			redirectOAuthError(c, frontendCallback, "login_failed", infraerrors.Reason(err), infraerrors.Message(err))
+			return
+		}
+		redirectOAuthError(c, frontendCallback, "authentication_failed", infraerrors.Reason(err), "")
+		return
+	}
+
+	email := p.LocalEmail(identity)
+	username := p.LocalUsername(identity)
+	grants := p.ResolveGrants(identity)
+
+	// 传入空邀请码；如果需要邀请码，服务层返回 ErrOAuthInvitationRequired
+	tokenPair, _, err := h.authService.LoginOrRegisterOAuthWithGrants(c.Request.Context(), email, username, "", referralCode, grants)
+	if err != nil {
+		if errors.Is(err, service.ErrOAuthInvitationRequired) {
+			pendingToken, tokenErr := h.authService.CreatePendingOAuthTokenWithGrants(email, username, referralCode, grants)
+			if tokenErr != nil {
+				redirectOAuthError(c, frontendCallback, "login_failed", "service_error", "")
+				return
+			}
+			fragment := url.Values{}
+			fragment.Set("error", "invitation_required")
+			fragment.Set("pending_oauth_token", pendingToken)
+			fragment.Set("provider", p.Slug)
+			fragment.Set("redirect", redirectTo)
+			redirectWithFragment(c, frontendCallback, fragment)
+			return
+		}
+		redirectOAuthError(c, frontendCallback, "login_failed", infraerrors.Reason(err), infraerrors.Message(err))
+		return
+	}
+
+	fragment := url.Values{}
+	fragment.Set("access_token", tokenPair.AccessToken)
+	fragment.Set("refresh_token", tokenPair.RefreshToken)
+	fragment.Set("expires_in", fmt.Sprintf("%d", tokenPair.ExpiresIn))
+	fragment.Set("token_type", "Bearer")
+	fragment.Set("redirect", redirectTo)
+	redirectWithFragment(c, frontendCallback, fragment)
+}
+
+// CompleteOIDCOAuthRegistration completes a pending SSO registration with an invitation code.
+// POST /api/v1/auth/oauth/oidc/complete-registration
+func (h *AuthHandler) CompleteOIDCOAuthRegistration(c *gin.Context) {
+	h.completePendingOAuthRegistration(c)
+}
+
+func (h *AuthHandler) getOIDCProvider(c *gin.Context) (*service.OIDCProvider, error) {
+	if h.oidcService == nil {
+		return nil, service.ErrOIDCProviderDisabled
+	}
+	return h.oidcService.GetEnabledBySlug(c.Request.Context(), c.Param("provider"))
+}
@@ -73,11 +73,14 @@ func APIKeyFromService(k *service.APIKey) *APIKey {
 		ID:            k.ID,
 		UserID:        k.UserID,
 		Key:           k.Key,
+		KeyPrefix:     k.KeyPrefix,
 		Name:          k.Name,
 		GroupID:       k.GroupID,
 		Status:        k.Status,
 		IPWhitelist:   k.IPWhitelist,
 		IPBlacklist:   k.IPBlacklist,
+		AllowedModels: k.AllowedModels,
+		DeniedModels:  k.DeniedModels,
 		LastUsedAt:    k.LastUsedAt,
 		Quota:         k.Quota,
 		QuotaUsed:     k.QuotaUsed,
@@ -93,6 +96,8 @@ func APIKeyFromService(k *service.APIKey) *APIKey {
 		Window5hStart: k.Window5hStart,
 		Window1dStart: k.Window1dStart,
 		Window7dStart: k.Window7dStart,
+		RPMLimit:      k.RPMLimit,
+		TPMLimit:      k.TPMLimit,
 		User:          UserFromServiceShallow(k.User),
 		Group:         GroupFromServiceShallow(k.Group),
 	}
@@ -138,6 +143,10 @@ func GroupFromServiceAdmin(g *service.Group) *AdminGroup {
 		ModelRoutingEnabled:     g.ModelRoutingEnabled,
 		MCPXMLInject:            g.MCPXMLInject,
 		DefaultMappedModel:      g.DefaultMappedModel,
+		LocalTokenCounting:      g.LocalTokenCounting,
+		BatchDiscountMultiplier: g.BatchDiscountMultiplier,
+		ResponseCacheEnabled:    g.ResponseCacheEnabled,
+		SubscriptionPrice:       g.SubscriptionPrice,
 		SupportedModelScopes:    g.SupportedModelScopes,
 		AccountCount:            g.AccountCount,
 		ActiveAccountCount:      g.ActiveAccountCount,
@@ -695,6 +704,8 @@ func userSubscriptionFromServiceBase(sub *service.UserSubscription) UserSubscrip
 		DailyUsageUSD:      sub.DailyUsageUSD,
 		WeeklyUsageUSD:     sub.WeeklyUsageUSD,
 		MonthlyUsageUSD:    sub.MonthlyUsageUSD,
+		AutoRenew:          sub.AutoRenew,
+		AutoRenewFailedAt:  sub.AutoRenewFailedAt,
 		CreatedAt:          sub.CreatedAt,
 		UpdatedAt:          sub.UpdatedAt,
 		User:               UserFromServiceShallow(sub.User),
@@ -756,3 +767,171 @@ func PromoCodeUsageFromService(u *service.PromoCodeUsage) *PromoCodeUsage {
 		User:        UserFromServiceShallow(u.User),
 	}
 }
+
+func PaymentOrderFromService(o *service.PaymentOrder) *PaymentOrder {
+	if o == nil {
+		return nil
+	}
+	out := paymentOrderFromServiceBase(o)
+	return &out
+}
+
+// PaymentOrderFromServiceAdmin converts a service PaymentOrder to DTO for admin users.
+func PaymentOrderFromServiceAdmin(o *service.PaymentOrder) *AdminPaymentOrder {
+	if o == nil {
+		return nil
+	}
+	return &AdminPaymentOrder{
+		PaymentOrder:    paymentOrderFromServiceBase(o),
+		ID:              o.ID,
+		UserID:          o.UserID,
+		ProviderTradeNo: o.ProviderTradeNo,
+		ClientIP:        o.ClientIP,
+		UpdatedAt:       o.UpdatedAt,
+	}
+}
+
+func paymentOrderFromServiceBase(o *service.PaymentOrder) PaymentOrder {
+	status := o.EffectiveStatus(time.Now())
+	out := PaymentOrder{
+		OrderNo:      o.OrderNo,
+		Provider:     o.Provider,
+		PayType:      o.PayType,
+		Kind:         o.Kind,
+		Amount:       o.Amount,
+		Currency:     o.Currency,
+		CreditAmount: o.CreditAmount,
+		GroupID:      o.GroupID,
+		ValidityDays: o.ValidityDays,
+		Subject:      o.Subject,
+		Status:       status,
+		PaidAt:       o.PaidAt,
+		ExpiresAt:    o.ExpiresAt,
+		CreatedAt:    o.CreatedAt,
+	}
+	// 支付链接仅在订单可支付时返回
+	if status == service.PaymentOrderStatusPending {
+		out.PayURL = o.PayURL
+	}
+	return out
+}
+
+func SubscriptionPlanFromService(p *service.SubscriptionPlan) *SubscriptionPlan {
+	if p == nil {
+		return nil
+	}
+	return &SubscriptionPlan{
+		GroupID:         p.GroupID,
+		GroupName:       p.GroupName,
+		Description:     p.Description,
+		Platform:        p.Platform,
+		Price:           p.Price,
+		ValidityDays:    p.ValidityDays,
+		DailyLimitUSD:   p.DailyLimitUSD,
+		WeeklyLimitUSD:  p.WeeklyLimitUSD,
+		MonthlyLimitUSD: p.MonthlyLimitUSD,
+	}
+}
+
+func SubscriptionPurchaseFromService(p *service.SubscriptionPurchase) *SubscriptionPurchase {
+	if p == nil {
+		return nil
+	}
+	return &SubscriptionPurchase{
+		ID:             p.ID,
+		UserID:         p.UserID,
+		GroupID:        p.GroupID,
+		GroupName:      p.GroupName,
+		SubscriptionID: p.SubscriptionID,
+		Kind:           p.Kind,
+		Status:         p.Status,
+		Amount:         p.Amount,
+		ValidityDays:   p.ValidityDays,
+		ExpiresAt:      p.ExpiresAt,
+		FailureReason:  p.FailureReason,
+		CreatedAt:      p.CreatedAt,
+	}
+}
+
+func ReferralOverviewFromService(o *service.ReferralOverview) *ReferralOverview {
+	if o == nil {
+		return nil
+	}
+	out := &ReferralOverview{
+		Enabled:                 o.Enabled,
+		Code:                    o.Code,
+		CommissionRate:          o.CommissionRate,
+		CommissionDays:          o.CommissionDays,
+		MaxCommissionPerInvitee: o.MaxCommissionPerInvitee,
+		InviteeCount:            o.Stats.InviteeCount,
+		TotalCost:               o.Stats.TotalCost,
+		TotalCommission:         o.Stats.TotalCommission,
+	}
+	if o.InvitedBy != nil {
+		email := o.InvitedBy.InviterEmail
+		out.InvitedBy = &email
+	}
+	return out
+}
+
+func ReferralInviteeFromService(r *service.Referral) *ReferralInvitee {
+	if r == nil {
+		return nil
+	}
+	return &ReferralInvitee{
+		InviteeEmail:    r.InviteeEmail,
+		TotalCost:       r.TotalCost,
+		TotalCommission: r.TotalCommission,
+		CreatedAt:       r.CreatedAt,
+	}
+}
+
+func ReferralFromServiceAdmin(r *service.Referral) *AdminReferral {
+	if r == nil {
+		return nil
+	}
+	return &AdminReferral{
+		ID:              r.ID,
+		InviterID:       r.InviterID,
+		InviterEmail:    r.InviterEmail,
+		InviteeID:       r.InviteeID,
+		InviteeEmail:    r.InviteeEmail,
+		SettledUntil:    r.SettledUntil,
+		TotalCost:       r.TotalCost,
+		TotalCommission: r.TotalCommission,
+		CreatedAt:       r.CreatedAt,
+	}
+}
+
+func ReferralCommissionFromService(c *service.ReferralCommission) *ReferralCommission {
+	if c == nil {
+		return nil
+	}
+	out := referralCommissionFromServiceBase(c)
+	return &out
+}
+
+func ReferralCommissionFromServiceAdmin(c *service.ReferralCommission) *AdminReferralCommission {
+	if c == nil {
+		return nil
+	}
+	return &AdminReferralCommission{
+		ReferralCommission: referralCommissionFromServiceBase(c),
+		ReferralID:         c.ReferralID,
+		InviterID:          c.InviterID,
+		InviteeID:          c.InviteeID,
+	}
+}
+
+func referralCommissionFromServiceBase(c *service.ReferralCommission) ReferralCommission {
+	return ReferralCommission{
+		ID:           c.ID,
+		InviteeEmail: c.InviteeEmail,
+		UsageCost:    c.UsageCost,
+		Rate:         c.Rate,
+		Amount:       c.Amount,
+		PeriodStart:  c.PeriodStart,
+		PeriodEnd:    c.PeriodEnd,
+		CreatedAt:    c.CreatedAt,
+	}
+}
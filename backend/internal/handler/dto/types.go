@@ -30,20 +30,23 @@ type AdminUser struct {
 }
 
 type APIKey struct {
-	ID          int64      `json:"id"`
-	UserID      int64      `json:"user_id"`
-	Key         string     `json:"key"`
-	Name        string     `json:"name"`
-	GroupID     *int64     `json:"group_id"`
-	Status      string     `json:"status"`
-	IPWhitelist []string   `json:"ip_whitelist"`
-	IPBlacklist []string   `json:"ip_blacklist"`
-	LastUsedAt  *time.Time `json:"last_used_at"`
-	Quota       float64    `json:"quota"`      // Quota limit in USD (0 = unlimited)
-	QuotaUsed   float64    `json:"quota_used"` // Used quota amount in USD
-	ExpiresAt   *time.Time `json:"expires_at"` // Expiration time (nil = never expires)
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+	ID            int64      `json:"id"`
+	UserID        int64      `json:"user_id"`
+	Key           string     `json:"key,omitempty"` // 完整 Key，仅在创建时返回一次
+	KeyPrefix     string     `json:"key_prefix"`
+	Name          string     `json:"name"`
+	GroupID       *int64     `json:"group_id"`
+	Status        string     `json:"status"`
+	IPWhitelist   []string   `json:"ip_whitelist"`
+	IPBlacklist   []string   `json:"ip_blacklist"`
+	AllowedModels []string   `json:"allowed_models"`
+	DeniedModels  []string   `json:"denied_models"`
+	LastUsedAt    *time.Time `json:"last_used_at"`
+	Quota         float64    `json:"quota"`      // Quota limit in USD (0 = unlimited)
+	QuotaUsed     float64    `json:"quota_used"` // Used quota amount in USD
+	ExpiresAt     *time.Time `json:"expires_at"` // Expiration time (nil = never expires)
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 
 	// Rate limit fields
 	RateLimit5h   float64    `json:"rate_limit_5h"`
@@ -59,6 +62,10 @@ type APIKey struct {
 	Reset1dAt     *time.Time `json:"reset_1d_at,omitempty"`
 	Reset7dAt     *time.Time `json:"reset_7d_at,omitempty"`
 
+	// Request rate limit fields (0 = unlimited)
+	RPMLimit int `json:"rpm_limit"`
+	TPMLimit int `json:"tpm_limit"`
+
 	User  *User  `json:"user,omitempty"`
 	Group *Group `json:"group,omitempty"`
 }
@@ -114,6 +121,18 @@ type AdminGroup struct {
 	// OpenAI Messages 调度配置（仅 openai 平台使用）
 	DefaultMappedModel string `json:"default_mapped_model"`
 
+	// 本地 token 计数（所有平台）
+	LocalTokenCounting bool `json:"local_token_counting"`
+
+	// Batch API 折扣倍率，null 表示使用配置默认值
+	BatchDiscountMultiplier *float64 `json:"batch_discount_multiplier"`
+
+	// 确定性请求响应缓存
+	ResponseCacheEnabled bool `json:"response_cache_enabled"`
+
+	// 余额购买订阅价格（USD），null 表示不开放购买
+	SubscriptionPrice *float64 `json:"subscription_price"`
+
 	// 支持的模型系列（仅 antigravity 平台使用）
 	SupportedModelScopes    []string       `json:"supported_model_scopes"`
 	AccountGroups           []AccountGroup `json:"account_groups,omitempty"`
@@ -474,6 +493,9 @@ type UserSubscription struct {
 	WeeklyUsageUSD  float64 `json:"weekly_usage_usd"`
 	MonthlyUsageUSD float64 `json:"monthly_usage_usd"`
 
+	AutoRenew         bool       `json:"auto_renew"`
+	AutoRenewFailedAt *time.Time `json:"auto_renew_failed_at"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 
@@ -527,3 +549,118 @@ type PromoCodeUsage struct {
 
 	User *User `json:"user,omitempty"`
 }
+
+// PaymentOrder 自助充值订单
+type PaymentOrder struct {
+	OrderNo      string     `json:"order_no"`
+	Provider     string     `json:"provider"`
+	PayType      string     `json:"pay_type,omitempty"`
+	Kind         string     `json:"kind"`
+	Amount       float64    `json:"amount"`
+	Currency     string     `json:"currency"`
+	CreditAmount float64    `json:"credit_amount"`
+	GroupID      *int64     `json:"group_id"`
+	ValidityDays int        `json:"validity_days"`
+	Subject      string     `json:"subject"`
+	Status       string     `json:"status"`
+	PayURL       string     `json:"pay_url,omitempty"`
+	PaidAt       *time.Time `json:"paid_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// AdminPaymentOrder 管理员视角的支付订单（包含用户与第三方交易信息）
+type AdminPaymentOrder struct {
+	PaymentOrder
+
+	ID              int64     `json:"id"`
+	UserID          int64     `json:"user_id"`
+	ProviderTradeNo string    `json:"provider_trade_no"`
+	ClientIP        string    `json:"client_ip"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// SubscriptionPlan 可使用余额购买的订阅套餐
+type SubscriptionPlan struct {
+	GroupID         int64    `json:"group_id"`
+	GroupName       string   `json:"group_name"`
+	Description     string   `json:"description"`
+	Platform        string   `json:"platform"`
+	Price           float64  `json:"price"`
+	ValidityDays    int      `json:"validity_days"`
+	DailyLimitUSD   *float64 `json:"daily_limit_usd"`
+	WeeklyLimitUSD  *float64 `json:"weekly_limit_usd"`
+	MonthlyLimitUSD *float64 `json:"monthly_limit_usd"`
+}
+
+// SubscriptionPurchase 订阅购买/自动续订记录
+type SubscriptionPurchase struct {
+	ID             int64      `json:"id"`
+	UserID         int64      `json:"user_id"`
+	GroupID        int64      `json:"group_id"`
+	GroupName      string     `json:"group_name"`
+	SubscriptionID *int64     `json:"subscription_id"`
+	Kind           string     `json:"kind"`
+	Status         string     `json:"status"`
+	Amount         float64    `json:"amount"`
+	ValidityDays   int        `json:"validity_days"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	FailureReason  string     `json:"failure_reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// ReferralOverview 用户侧邀请返佣概览
+type ReferralOverview struct {
+	Enabled                 bool    `json:"enabled"`
+	Code                    string  `json:"code"`
+	CommissionRate          float64 `json:"commission_rate"`
+	CommissionDays          int     `json:"commission_days"`
+	MaxCommissionPerInvitee float64 `json:"max_commission_per_invitee"`
+	InviteeCount            int64   `json:"invitee_count"`
+	TotalCost               float64 `json:"total_cost"`
+	TotalCommission         float64 `json:"total_commission"`
+	// InvitedBy 邀请人邮箱（已脱敏），无邀请人时为 null
+	InvitedBy *string `json:"invited_by"`
+}
+
+// ReferralInvitee 用户侧被邀请人（邮箱已脱敏）
+type ReferralInvitee struct {
+	InviteeEmail    string    `json:"invitee_email"`
+	TotalCost       float64   `json:"total_cost"`
+	TotalCommission float64   `json:"total_commission"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// AdminReferral 管理端邀请关系
+type AdminReferral struct {
+	ID              int64     `json:"id"`
+	InviterID       int64     `json:"inviter_id"`
+	InviterEmail    string    `json:"inviter_email"`
+	InviteeID       int64     `json:"invitee_id"`
+	InviteeEmail    string    `json:"invitee_email"`
+	SettledUntil    time.Time `json:"settled_until"`
+	TotalCost       float64   `json:"total_cost"`
+	TotalCommission float64   `json:"total_commission"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ReferralCommission 返佣入账明细
+type ReferralCommission struct {
+	ID           int64     `json:"id"`
+	InviteeEmail string    `json:"invitee_email"`
+	UsageCost    float64   `json:"usage_cost"`
+	Rate         float64   `json:"rate"`
+	Amount       float64   `json:"amount"`
+	PeriodStart  time.Time `json:"period_start"`
+	PeriodEnd    time.Time `json:"period_end"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// AdminReferralCommission 管理端返佣明细（包含关联 ID）
+type AdminReferralCommission struct {
+	ReferralCommission
+
+	ReferralID int64 `json:"referral_id"`
+	InviterID  int64 `json:"inviter_id"`
+	InviteeID  int64 `json:"invitee_id"`
+}
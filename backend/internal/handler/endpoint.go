@@ -16,9 +16,15 @@ import (
 
 const (
 	EndpointMessages        = "/v1/messages"
+	EndpointMessageBatches  = "/v1/messages/batches"
 	EndpointChatCompletions = "/v1/chat/completions"
 	EndpointResponses       = "/v1/responses"
 	EndpointGeminiModels    = "/v1beta/models"
+	EndpointEmbeddings      = "/v1/embeddings"
+	EndpointImagesGenerate  = "/v1/images/generations"
+	EndpointImagesEdits     = "/v1/images/edits"
+	EndpointFiles           = "/v1/files"
+	EndpointBatches         = "/v1/batches"
 )
 
 // gin.Context keys used by the middleware and helpers below.
@@ -34,20 +40,37 @@ const (
 // prefixes like /antigravity, /openai) to its canonical form.
 //
 //	"/antigravity/v1/messages"   → "/v1/messages"
+//	"/v1/messages/batches/x"     → "/v1/messages/batches"
 //	"/v1/chat/completions"       → "/v1/chat/completions"
 //	"/openai/v1/responses/foo"   → "/v1/responses"
 //	"/v1beta/models/gemini:gen"  → "/v1beta/models"
+//	"/embeddings"                → "/v1/embeddings"
+//	"/images/edits"              → "/v1/images/edits"
+//	"/v1/files/file-1/content"   → "/v1/files"
+//	"/v1/batches/batch_1/cancel" → "/v1/batches"
 func NormalizeInboundEndpoint(path string) string {
 	path = strings.TrimSpace(path)
 	switch {
 	case strings.Contains(path, EndpointChatCompletions):
 		return EndpointChatCompletions
+	case strings.Contains(path, EndpointMessageBatches):
+		return EndpointMessageBatches
 	case strings.Contains(path, EndpointMessages):
 		return EndpointMessages
 	case strings.Contains(path, EndpointResponses):
 		return EndpointResponses
 	case strings.Contains(path, EndpointGeminiModels):
 		return EndpointGeminiModels
+	case strings.HasSuffix(path, "/embeddings"):
+		return EndpointEmbeddings
+	case strings.HasSuffix(path, "/images/generations"):
+		return EndpointImagesGenerate
+	case strings.HasSuffix(path, "/images/edits"):
+		return EndpointImagesEdits
+	case strings.Contains(path, EndpointFiles):
+		return EndpointFiles
+	case strings.Contains(path, EndpointBatches):
+		return EndpointBatches
 	default:
 		return path
 	}
@@ -57,6 +80,8 @@ func NormalizeInboundEndpoint(path string) string {
 // account platform and the normalized inbound endpoint.
 //
 // Platform-specific rules:
+//   - Embeddings and images are forwarded to the same surface on OpenAI;
+//     Gemini/Antigravity convert them to /v1beta/models calls.
 //   - OpenAI always forwards to /v1/responses (with optional subpath
 //     such as /v1/responses/compact preserved from the raw URL).
 //   - Anthropic  → /v1/messages
@@ -67,6 +92,19 @@ func NormalizeInboundEndpoint(path string) string {
 func DeriveUpstreamEndpoint(inbound, rawRequestPath, platform string) string {
 	inbound = strings.TrimSpace(inbound)
 
+	if inbound == EndpointEmbeddings {
+		if platform == service.PlatformGemini {
+			return EndpointGeminiModels
+		}
+		return EndpointEmbeddings
+	}
+	if inbound == EndpointImagesGenerate || inbound == EndpointImagesEdits {
+		if platform == service.PlatformGemini || platform == service.PlatformAntigravity {
+			return EndpointGeminiModels
+		}
+		return inbound
+	}
+
 	switch platform {
 	case service.PlatformOpenAI:
 		// OpenAI forwards everything to the Responses API.
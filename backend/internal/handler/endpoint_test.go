@@ -23,9 +23,19 @@ func TestNormalizeInboundEndpoint(t *testing.T) {
 	}{
 		// Direct canonical paths.
 		{"/v1/messages", EndpointMessages},
+		{"/v1/messages/batches", EndpointMessageBatches},
+		{"/v1/messages/batches/msgbatch_1/results", EndpointMessageBatches},
+		{"/v1/files", EndpointFiles},
+		{"/v1/files/file-abc/content", EndpointFiles},
+		{"/v1/batches", EndpointBatches},
+		{"/v1/batches/batch_abc/cancel", EndpointBatches},
 		{"/v1/chat/completions", EndpointChatCompletions},
 		{"/v1/responses", EndpointResponses},
 		{"/v1beta/models", EndpointGeminiModels},
+		{"/v1/embeddings", EndpointEmbeddings},
+		{"/embeddings", EndpointEmbeddings},
+		{"/v1/images/generations", EndpointImagesGenerate},
+		{"/images/edits", EndpointImagesEdits},
 
 		// Prefixed paths (antigravity, openai).
 		{"/antigravity/v1/messages", EndpointMessages},
@@ -38,7 +48,7 @@ func TestNormalizeInboundEndpoint(t *testing.T) {
 		{"/v1/responses/*subpath", EndpointResponses},
 
 		// Unknown path is returned as-is.
-		{"/v1/embeddings", "/v1/embeddings"},
+		{"/v1/audio/speech", "/v1/audio/speech"},
 		{"", ""},
 		{"  /v1/messages  ", EndpointMessages},
 	}
@@ -78,8 +88,14 @@ func TestDeriveUpstreamEndpoint(t *testing.T) {
 		{"antigravity claude", EndpointMessages, "/antigravity/v1/messages", service.PlatformAntigravity, EndpointMessages},
 		{"antigravity gemini", EndpointGeminiModels, "/antigravity/v1beta/models", service.PlatformAntigravity, EndpointGeminiModels},
 
+		// Embeddings — same surface except Gemini (embedContent).
+		{"openai embeddings", EndpointEmbeddings, "/v1/embeddings", service.PlatformOpenAI, EndpointEmbeddings},
+		{"gemini embeddings", EndpointEmbeddings, "/v1/embeddings", service.PlatformGemini, EndpointGeminiModels},
+		{"openai images", EndpointImagesGenerate, "/v1/images/generations", service.PlatformOpenAI, EndpointImagesGenerate},
+		{"antigravity images edit", EndpointImagesEdits, "/v1/images/edits", service.PlatformAntigravity, EndpointGeminiModels},
+
 		// Unknown platform — passthrough.
-		{"unknown platform", "/v1/embeddings", "/v1/embeddings", "unknown", "/v1/embeddings"},
+		{"unknown platform", "/v1/audio/speech", "/v1/audio/speech", "unknown", "/v1/audio/speech"},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -45,6 +45,8 @@ type GatewayHandler struct {
 	apiKeyService             *service.APIKeyService
 	usageRecordWorkerPool     *service.UsageRecordWorkerPool
 	errorPassthroughService   *service.ErrorPassthroughService
+	responseCacheService      *service.ResponseCacheService
+	guardrailService          *service.GuardrailService
 	concurrencyHelper         *ConcurrencyHelper
 	userMsgQueueHelper        *UserMsgQueueHelper
 	maxAccountSwitches        int
@@ -66,6 +68,8 @@ func NewGatewayHandler(
 	usageRecordWorkerPool *service.UsageRecordWorkerPool,
 	errorPassthroughService *service.ErrorPassthroughService,
 	userMsgQueueService *service.UserMessageQueueService,
+	responseCacheService *service.ResponseCacheService,
+	guardrailService *service.GuardrailService,
 	cfg *config.Config,
 	settingService *service.SettingService,
 ) *GatewayHandler {
@@ -98,6 +102,8 @@ func NewGatewayHandler(
 		apiKeyService:             apiKeyService,
 		usageRecordWorkerPool:     usageRecordWorkerPool,
 		errorPassthroughService:   errorPassthroughService,
+		responseCacheService:      responseCacheService,
+		guardrailService:          guardrailService,
 		concurrencyHelper:         NewConcurrencyHelper(concurrencyService, SSEPingFormatClaude, pingInterval),
 		userMsgQueueHelper:        umqHelper,
 		maxAccountSwitches:        maxAccountSwitches,
@@ -188,6 +194,25 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "model is required")
 		return
 	}
+	if !isAPIKeyModelAllowed(apiKey, reqModel) {
+		h.errorResponse(c, http.StatusForbidden, "permission_error", apiKeyModelNotAllowedMessage(reqModel))
+		return
+	}
+
+	// 分组安全策略（PII 脱敏 / 拦截）：须在缓存 key、会话 hash 与转发使用请求体之前执行
+	guardedBody, guardrailModified, allowed := applyGuardrail(c, h.guardrailService, apiKey,
+		service.GuardrailProtocolAnthropicMessages, reqModel, reqStream, body, reqLog, h.errorResponse)
+	if !allowed {
+		return
+	}
+	if guardrailModified {
+		body = guardedBody
+		parsedReq, err = service.ParseGatewayRequest(body, domain.PlatformAnthropic)
+		if err != nil {
+			h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+			return
+		}
+	}
 
 	// Track if we've started streaming (for error handling)
 	streamStarted := false
@@ -200,6 +225,20 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 	// 获取订阅信息（可能为nil）- 提前获取用于后续检查
 	subscription, _ := middleware2.GetSubscriptionFromContext(c)
 
+	// 响应缓存：确定性请求命中时直接回放，不请求上游；
+	// 在排队与获取用户并发槽位之前查询，命中的请求不占用并发额度
+	responseCacheStart := time.Now()
+	responseCacheKey := h.responseCacheService.BuildKey(apiKey, service.ResponseCacheEndpointMessages, body)
+	if entry := h.responseCacheService.Lookup(c.Request.Context(), responseCacheKey); entry != nil {
+		reqLog.Debug("gateway.response_cache_hit")
+		h.serveResponseCacheHit(c, entry, apiKey, subscription, body, reqModel, reqStream, channelMapping, responseCacheStart)
+		return
+	}
+	var responseCapture *responseCaptureWriter
+	if responseCacheKey != "" {
+		responseCapture = beginResponseCapture(c, h.responseCacheService.MaxEntryBytes())
+	}
+
 	// 0. 检查wait队列是否已满
 	maxWait := service.CalculateMaxWait(subject.Concurrency)
 	canWait, err := h.concurrencyHelper.IncrementWaitCount(c.Request.Context(), subject.UserID, maxWait)
@@ -397,6 +436,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			}
 			// 记录 Forward 前已写入字节数，Forward 后若增加则说明 SSE 内容已发，禁止 failover
 			writerSizeBeforeForward := c.Writer.Size()
+			responseCapture.reset()
 			if account.Platform == service.PlatformAntigravity {
 				result, err = h.antigravityGatewayService.ForwardGemini(requestCtx, c, account, reqModel, "generateContent", reqStream, body, hasBoundSession)
 			} else {
@@ -455,6 +495,13 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				}
 			}
 
+			setOpsTimeToFirstToken(c, result.FirstTokenMs)
+
+			if cachedBody := responseCapture.cacheableBody(c); cachedBody != nil && !result.ClientDisconnect {
+				h.responseCacheService.Store(c.Request.Context(), apiKey.Group.ID, responseCacheKey,
+					service.NewClaudeResponseCacheEntry(result, account, http.StatusOK, responseCapture.contentType(), cachedBody))
+			}
+
 			// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 			userAgent := c.GetHeader("User-Agent")
 			clientIP := ip.GetClientIP(c)
@@ -679,6 +726,7 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 			}
 			// 记录 Forward 前已写入字节数，Forward 后若增加则说明 SSE 内容已发，禁止 failover
 			writerSizeBeforeForward := c.Writer.Size()
+			responseCapture.reset()
 			if account.Platform == service.PlatformAntigravity && account.Type != service.AccountTypeAPIKey {
 				result, err = h.antigravityGatewayService.Forward(requestCtx, c, account, body, hasBoundSession)
 			} else {
@@ -795,6 +843,13 @@ func (h *GatewayHandler) Messages(c *gin.Context) {
 				}
 			}
 
+			setOpsTimeToFirstToken(c, result.FirstTokenMs)
+
+			if cachedBody := responseCapture.cacheableBody(c); cachedBody != nil && !result.ClientDisconnect {
+				h.responseCacheService.Store(c.Request.Context(), apiKey.Group.ID, responseCacheKey,
+					service.NewClaudeResponseCacheEntry(result, account, http.StatusOK, responseCapture.contentType(), cachedBody))
+			}
+
 			// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 			userAgent := c.GetHeader("User-Agent")
 			clientIP := ip.GetClientIP(c)
@@ -861,6 +916,7 @@ func (h *GatewayHandler) Models(c *gin.Context) {
 
 	// Get available models from account configurations (without platform filter)
 	availableModels := h.gatewayService.GetAvailableModels(c.Request.Context(), groupID, "")
+	availableModels = filterModelsForAPIKey(apiKey, availableModels, func(id string) string { return id })
 
 	if len(availableModels) > 0 {
 		// Build model list from whitelist
@@ -884,23 +940,24 @@ func (h *GatewayHandler) Models(c *gin.Context) {
 	if platform == "openai" {
 		c.JSON(http.StatusOK, gin.H{
 			"object": "list",
-			"data":   openai.DefaultModels,
+			"data":   filterModelsForAPIKey(apiKey, openai.DefaultModels, func(m openai.Model) string { return m.ID }),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"object": "list",
-		"data":   claude.DefaultModels,
+		"data":   filterModelsForAPIKey(apiKey, claude.DefaultModels, func(m claude.Model) string { return m.ID }),
 	})
 }
 
 // AntigravityModels 返回 Antigravity 支持的全部模型
 // GET /antigravity/models
 func (h *GatewayHandler) AntigravityModels(c *gin.Context) {
+	apiKey, _ := middleware2.GetAPIKeyFromContext(c)
 	c.JSON(http.StatusOK, gin.H{
 		"object": "list",
-		"data":   antigravity.DefaultModels(),
+		"data":   filterModelsForAPIKey(apiKey, antigravity.DefaultModels(), func(m antigravity.ClaudeModel) string { return m.ID }),
 	})
 }
 
@@ -1016,6 +1073,29 @@ func (h *GatewayHandler) buildUsageData(ctx context.Context, apiKeyID int64) gin
 	}
 }
 
+// buildRequestRateLimits 构建 RPM/TPM 滑动窗口的剩余额度（未配置或读取失败时返回 nil）
+func (h *GatewayHandler) buildRequestRateLimits(ctx context.Context, apiKey *service.APIKey) []gin.H {
+	if h.apiKeyService == nil || !apiKey.HasRequestRateLimits() {
+		return nil
+	}
+	statuses, err := h.apiKeyService.GetRequestRateStatus(ctx, apiKey)
+	if err != nil || len(statuses) == 0 {
+		return nil
+	}
+	out := make([]gin.H, 0, len(statuses))
+	for _, st := range statuses {
+		out = append(out, gin.H{
+			"type":                st.Kind,
+			"window":              "1m",
+			"limit":               st.Limit,
+			"used":                st.Used,
+			"remaining":           st.Remaining,
+			"reset_after_seconds": int64((st.ResetIn + time.Second - 1) / time.Second),
+		})
+	}
+	return out
+}
+
 // usageQuotaLimited 处理 quota_limited 模式的响应
 func (h *GatewayHandler) usageQuotaLimited(c *gin.Context, ctx context.Context, apiKey *service.APIKey, usageData gin.H, modelStats any) {
 	resp := gin.H{
@@ -1096,6 +1176,9 @@ func (h *GatewayHandler) usageQuotaLimited(c *gin.Context, ctx context.Context,
 		resp["days_until_expiry"] = apiKey.GetDaysUntilExpiry()
 	}
 
+	if requestRateLimits := h.buildRequestRateLimits(ctx, apiKey); requestRateLimits != nil {
+		resp["request_rate_limits"] = requestRateLimits
+	}
 	if usageData != nil {
 		resp["usage"] = usageData
 	}
@@ -1133,6 +1216,9 @@ func (h *GatewayHandler) usageUnrestricted(c *gin.Context, ctx context.Context,
 			}
 		}
 
+		if requestRateLimits := h.buildRequestRateLimits(ctx, apiKey); requestRateLimits != nil {
+			resp["request_rate_limits"] = requestRateLimits
+		}
 		if usageData != nil {
 			resp["usage"] = usageData
 		}
@@ -1158,6 +1244,9 @@ func (h *GatewayHandler) usageUnrestricted(c *gin.Context, ctx context.Context,
 		"unit":      "USD",
 		"balance":   latestUser.Balance,
 	}
+	if requestRateLimits := h.buildRequestRateLimits(ctx, apiKey); requestRateLimits != nil {
+		resp["request_rate_limits"] = requestRateLimits
+	}
 	if usageData != nil {
 		resp["usage"] = usageData
 	}
@@ -1425,6 +1514,10 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 		h.errorResponse(c, http.StatusBadRequest, "invalid_request_error", "model is required")
 		return
 	}
+	if !isAPIKeyModelAllowed(apiKey, parsedReq.Model) {
+		h.errorResponse(c, http.StatusForbidden, "permission_error", apiKeyModelNotAllowedMessage(parsedReq.Model))
+		return
+	}
 
 	setOpsRequestContext(c, parsedReq.Model, parsedReq.Stream, body)
 	setOpsEndpointContext(c, "", int16(service.RequestTypeFromLegacy(parsedReq.Stream, false)))
@@ -1440,6 +1533,12 @@ func (h *GatewayHandler) CountTokens(c *gin.Context) {
 		return
 	}
 
+	// 分组开启本地计数时直接本地估算，不占用上游账号
+	if apiKey.Group != nil && apiKey.Group.LocalTokenCounting {
+		c.JSON(http.StatusOK, gin.H{"input_tokens": service.EstimateAnthropicCountTokens(body, parsedReq.Model)})
+		return
+	}
+
 	// 计算粘性会话 hash
 	parsedReq.SessionContext = &service.SessionContext{
 		ClientIP:  ip.GetClientIP(c),
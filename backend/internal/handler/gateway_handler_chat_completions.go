@@ -74,6 +74,10 @@ func (h *GatewayHandler) ChatCompletions(c *gin.Context) {
 		return
 	}
 	reqModel := modelResult.String()
+	if !isAPIKeyModelAllowed(apiKey, reqModel) {
+		h.chatCompletionsErrorResponse(c, http.StatusForbidden, "permission_error", apiKeyModelNotAllowedMessage(reqModel))
+		return
+	}
 	reqStream := gjson.GetBytes(body, "stream").Bool()
 	reqLog = reqLog.With(zap.String("model", reqModel), zap.Bool("stream", reqStream))
 
@@ -90,6 +94,16 @@ func (h *GatewayHandler) ChatCompletions(c *gin.Context) {
 		return
 	}
 
+	// Group guardrails (PII masking / blocking) before the body is used anywhere else
+	guardedBody, guardrailModified, allowed := applyGuardrail(c, h.guardrailService, apiKey,
+		service.GuardrailProtocolOpenAIChatCompletions, reqModel, reqStream, body, reqLog, h.chatCompletionsErrorResponse)
+	if !allowed {
+		return
+	}
+	if guardrailModified {
+		body = guardedBody
+	}
+
 	// Error passthrough binding
 	if h.errorPassthroughService != nil {
 		service.BindErrorPassthroughService(c, h.errorPassthroughService)
@@ -242,6 +256,8 @@ func (h *GatewayHandler) ChatCompletions(c *gin.Context) {
 			return
 		}
 
+		setOpsTimeToFirstToken(c, result.FirstTokenMs)
+
 		// 6. Record usage
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
@@ -0,0 +1,320 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	pkghttputil "github.com/Wei-Shaw/sub2api/internal/pkg/httputil"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+// GeminiV1BetaCompat serves the Gemini native REST API for Anthropic platform groups.
+// POST /v1beta/models/{model}:generateContent
+// POST /v1beta/models/{model}:streamGenerateContent?alt=sse
+// POST /v1beta/models/{model}:countTokens
+// Requests are converted to Anthropic Messages format, forwarded to Anthropic
+// upstream, and responses are converted back to Gemini format.
+func (h *GatewayHandler) GeminiV1BetaCompat(c *gin.Context) {
+	requestStart := time.Now()
+
+	apiKey, ok := middleware.GetAPIKeyFromContext(c)
+	if !ok || apiKey == nil {
+		googleError(c, http.StatusUnauthorized, "Invalid API key")
+		return
+	}
+	subject, ok := middleware.GetAuthSubjectFromContext(c)
+	if !ok {
+		googleError(c, http.StatusInternalServerError, "User context not found")
+		return
+	}
+	reqLog := requestLogger(
+		c,
+		"handler.gateway.gemini_compat",
+		zap.Int64("user_id", subject.UserID),
+		zap.Int64("api_key_id", apiKey.ID),
+		zap.Any("group_id", apiKey.GroupID),
+	)
+
+	modelName, action, body, ok := readGeminiCompatRequest(c, apiKey)
+	if !ok {
+		return
+	}
+	stream := action == "streamGenerateContent"
+	reqLog = reqLog.With(zap.String("model", modelName), zap.String("action", action), zap.Bool("stream", stream))
+
+	// countTokens：Anthropic 分组本地估算，不占用上游账号
+	if action == "countTokens" {
+		c.JSON(http.StatusOK, gin.H{"totalTokens": service.EstimateGeminiCompatCountTokens(body, modelName)})
+		return
+	}
+
+	setOpsRequestContext(c, modelName, stream, body)
+	setOpsEndpointContext(c, "", int16(service.RequestTypeFromLegacy(stream, false)))
+
+	// 分组安全策略（PII 脱敏 / 拦截）：须在会话 hash 与转发使用请求体之前执行
+	guardedBody, guardrailModified, allowed := applyGuardrail(c, h.guardrailService, apiKey,
+		service.GuardrailProtocolGeminiGenerateContent, modelName, stream, body, reqLog, googleGuardrailError)
+	if !allowed {
+		return
+	}
+	if guardrailModified {
+		body = guardedBody
+	}
+
+	// 解析渠道级模型映射
+	channelMapping, _ := h.gatewayService.ResolveChannelMappingAndRestrict(c.Request.Context(), apiKey.GroupID, modelName)
+	reqModel := modelName
+	if channelMapping.Mapped {
+		modelName = channelMapping.MappedModel
+	}
+
+	if apiKey.Group != nil && apiKey.Group.ClaudeCodeOnly {
+		googleError(c, http.StatusForbidden, "This group is restricted to Claude Code clients (/v1/messages only)")
+		return
+	}
+
+	if h.errorPassthroughService != nil {
+		service.BindErrorPassthroughService(c, h.errorPassthroughService)
+	}
+
+	subscription, _ := middleware.GetSubscriptionFromContext(c)
+
+	service.SetOpsLatencyMs(c, service.OpsAuthLatencyMsKey, time.Since(requestStart).Milliseconds())
+
+	// Gemini 客户端不识别 Claude 风格的 ping 帧
+	geminiConcurrency := NewConcurrencyHelper(h.concurrencyHelper.concurrencyService, SSEPingFormatNone, 0)
+
+	// 1. Acquire user concurrency slot
+	maxWait := service.CalculateMaxWait(subject.Concurrency)
+	canWait, err := geminiConcurrency.IncrementWaitCount(c.Request.Context(), subject.UserID, maxWait)
+	waitCounted := false
+	if err != nil {
+		reqLog.Warn("gateway.gemini_compat.user_wait_counter_increment_failed", zap.Error(err))
+	} else if !canWait {
+		googleError(c, http.StatusTooManyRequests, "Too many pending requests, please retry later")
+		return
+	}
+	if err == nil && canWait {
+		waitCounted = true
+	}
+	defer func() {
+		if waitCounted {
+			geminiConcurrency.DecrementWaitCount(c.Request.Context(), subject.UserID)
+		}
+	}()
+
+	streamStarted := false
+	userReleaseFunc, err := geminiConcurrency.AcquireUserSlotWithWait(c, subject.UserID, subject.Concurrency, stream, &streamStarted)
+	if err != nil {
+		reqLog.Warn("gateway.gemini_compat.user_slot_acquire_failed", zap.Error(err))
+		googleError(c, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if waitCounted {
+		geminiConcurrency.DecrementWaitCount(c.Request.Context(), subject.UserID)
+		waitCounted = false
+	}
+	userReleaseFunc = wrapReleaseOnDone(c.Request.Context(), userReleaseFunc)
+	if userReleaseFunc != nil {
+		defer userReleaseFunc()
+	}
+
+	// 2. Re-check billing
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+		reqLog.Info("gateway.gemini_compat.billing_check_failed", zap.Error(err))
+		status, _, message := billingErrorDetails(err)
+		googleError(c, status, message)
+		return
+	}
+
+	// Session hash: Gemini CLI 会话标识优先，其次通用哈希
+	sessionHash := extractGeminiCLISessionHash(c, body)
+	if sessionHash == "" {
+		parsedReq, _ := service.ParseGatewayRequest(body, domain.PlatformGemini)
+		if parsedReq != nil {
+			parsedReq.SessionContext = &service.SessionContext{
+				ClientIP:  ip.GetClientIP(c),
+				UserAgent: c.GetHeader("User-Agent"),
+				APIKeyID:  apiKey.ID,
+			}
+		}
+		sessionHash = h.gatewayService.GenerateSessionHash(parsedReq)
+	}
+
+	// 3. Account selection + failover loop
+	fs := NewFailoverState(h.maxAccountSwitches, false)
+
+	for {
+		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, sessionHash, modelName, fs.FailedAccountIDs, "", int64(0))
+		if err != nil {
+			if len(fs.FailedAccountIDs) == 0 {
+				googleError(c, http.StatusServiceUnavailable, "No available accounts: "+err.Error())
+				return
+			}
+			action := fs.HandleSelectionExhausted(c.Request.Context())
+			switch action {
+			case FailoverContinue:
+				continue
+			case FailoverCanceled:
+				return
+			default:
+				h.handleGeminiCompatFailoverExhausted(c, fs.LastFailoverErr, streamStarted)
+				return
+			}
+		}
+		account := selection.Account
+		setOpsSelectedAccount(c, account.ID, account.Platform)
+
+		// 4. Acquire account concurrency slot
+		accountReleaseFunc := selection.ReleaseFunc
+		if !selection.Acquired {
+			if selection.WaitPlan == nil {
+				googleError(c, http.StatusServiceUnavailable, "No available accounts")
+				return
+			}
+			accountReleaseFunc, err = geminiConcurrency.AcquireAccountSlotWithWaitTimeout(
+				c,
+				account.ID,
+				selection.WaitPlan.MaxConcurrency,
+				selection.WaitPlan.Timeout,
+				stream,
+				&streamStarted,
+			)
+			if err != nil {
+				reqLog.Warn("gateway.gemini_compat.account_slot_acquire_failed", zap.Int64("account_id", account.ID), zap.Error(err))
+				googleError(c, http.StatusTooManyRequests, err.Error())
+				return
+			}
+		}
+		accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
+
+		// 5. Forward request
+		writerSizeBeforeForward := c.Writer.Size()
+		result, err := h.gatewayService.ForwardAsGemini(c.Request.Context(), c, account, body, modelName, stream)
+
+		if accountReleaseFunc != nil {
+			accountReleaseFunc()
+		}
+
+		if err != nil {
+			var failoverErr *service.UpstreamFailoverError
+			if errors.As(err, &failoverErr) {
+				if c.Writer.Size() != writerSizeBeforeForward {
+					h.handleGeminiCompatFailoverExhausted(c, failoverErr, true)
+					return
+				}
+				action := fs.HandleFailoverError(c.Request.Context(), h.gatewayService, account.ID, account.Platform, failoverErr)
+				switch action {
+				case FailoverContinue:
+					continue
+				case FailoverExhausted:
+					h.handleGeminiCompatFailoverExhausted(c, fs.LastFailoverErr, streamStarted)
+					return
+				case FailoverCanceled:
+					return
+				}
+			}
+			if !streamStarted && !c.Writer.Written() {
+				googleError(c, http.StatusBadGateway, "Upstream request failed")
+			}
+			reqLog.Error("gateway.gemini_compat.forward_failed",
+				zap.Int64("account_id", account.ID),
+				zap.Error(err),
+			)
+			return
+		}
+
+		setOpsTimeToFirstToken(c, result.FirstTokenMs)
+
+		// 6. Record usage
+		userAgent := c.GetHeader("User-Agent")
+		clientIP := ip.GetClientIP(c)
+		requestPayloadHash := service.HashUsageRequestPayload(body)
+		inboundEndpoint := GetInboundEndpoint(c)
+		upstreamEndpoint := GetUpstreamEndpoint(c, account.Platform)
+
+		h.submitUsageRecordTask(func(ctx context.Context) {
+			if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
+				Result:             result,
+				APIKey:             apiKey,
+				User:               apiKey.User,
+				Account:            account,
+				Subscription:       subscription,
+				InboundEndpoint:    inboundEndpoint,
+				UpstreamEndpoint:   upstreamEndpoint,
+				UserAgent:          userAgent,
+				IPAddress:          clientIP,
+				RequestPayloadHash: requestPayloadHash,
+				APIKeyService:      h.apiKeyService,
+				ChannelUsageFields: channelMapping.ToUsageFields(reqModel, result.UpstreamModel),
+			}); err != nil {
+				reqLog.Error("gateway.gemini_compat.record_usage_failed",
+					zap.Int64("account_id", account.ID),
+					zap.Error(err),
+				)
+			}
+		})
+		return
+	}
+}
+
+// handleGeminiCompatFailoverExhausted writes a failover-exhausted error in Google format.
+func (h *GatewayHandler) handleGeminiCompatFailoverExhausted(c *gin.Context, lastErr *service.UpstreamFailoverError, streamStarted bool) {
+	if streamStarted {
+		return
+	}
+	statusCode := http.StatusBadGateway
+	if lastErr != nil && lastErr.StatusCode > 0 {
+		statusCode = lastErr.StatusCode
+	}
+	googleError(c, statusCode, "All available accounts exhausted")
+}
+
+// readGeminiCompatRequest parses {model}:{action} from the path, checks model
+// permissions, and reads the JSON body for the cross-protocol v1beta handlers.
+// It writes a Google-format error and returns ok=false on failure.
+func readGeminiCompatRequest(c *gin.Context, apiKey *service.APIKey) (model string, action string, body []byte, ok bool) {
+	model, action, err := parseGeminiModelAction(strings.TrimPrefix(c.Param("modelAction"), "/"))
+	if err != nil {
+		googleError(c, http.StatusNotFound, err.Error())
+		return "", "", nil, false
+	}
+	switch action {
+	case "generateContent", "streamGenerateContent", "countTokens":
+	default:
+		googleError(c, http.StatusNotFound, "Action "+action+" is not supported for this group")
+		return "", "", nil, false
+	}
+	if !isAPIKeyModelAllowed(apiKey, model) {
+		googleError(c, http.StatusForbidden, apiKeyModelNotAllowedMessage(model))
+		return "", "", nil, false
+	}
+
+	body, err = pkghttputil.ReadRequestBodyWithPrealloc(c.Request)
+	if err != nil {
+		if maxErr, isMax := extractMaxBytesError(err); isMax {
+			googleError(c, http.StatusRequestEntityTooLarge, buildBodyTooLargeMessage(maxErr.Limit))
+			return "", "", nil, false
+		}
+		googleError(c, http.StatusBadRequest, "Failed to read request body")
+		return "", "", nil, false
+	}
+	if len(body) == 0 {
+		googleError(c, http.StatusBadRequest, "Request body is empty")
+		return "", "", nil, false
+	}
+	if !gjson.ValidBytes(body) {
+		googleError(c, http.StatusBadRequest, "Failed to parse request body")
+		return "", "", nil, false
+	}
+	return model, action, body, true
+}
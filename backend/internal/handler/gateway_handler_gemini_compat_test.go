@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func newGeminiCompatTestContext(modelAction, body string, apiKey *service.APIKey) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1beta/models/"+modelAction, strings.NewReader(body))
+	c.Params = gin.Params{{Key: "modelAction", Value: "/" + modelAction}}
+	c.Set(string(middleware2.ContextKeyAPIKey), apiKey)
+	c.Set(string(middleware2.ContextKeyUser), middleware2.AuthSubject{UserID: 1, Concurrency: 1})
+	return c, w
+}
+
+func TestGeminiV1BetaCompat_CountTokensAnsweredLocally(t *testing.T) {
+	apiKey := &service.APIKey{ID: 1, Group: &service.Group{Platform: service.PlatformAnthropic}}
+	body := `{"contents":[{"role":"user","parts":[{"text":"hello there, how are you today?"}]}]}`
+
+	c, w := newGeminiCompatTestContext("claude-sonnet-4-5:countTokens", body, apiKey)
+	(&GatewayHandler{}).GeminiV1BetaCompat(c)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Greater(t, gjson.Get(w.Body.String(), "totalTokens").Int(), int64(0))
+
+	c, w = newGeminiCompatTestContext("gpt-5:countTokens", body, apiKey)
+	(&OpenAIGatewayHandler{}).GeminiV1BetaModels(c)
+	// 依赖缺失时直接返回 503，计数请求不应越过依赖检查
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
+
+func TestGeminiV1BetaCompat_RejectsUnsupportedActionAndModel(t *testing.T) {
+	apiKey := &service.APIKey{ID: 1, DeniedModels: []string{"claude-opus-*"}}
+
+	c, w := newGeminiCompatTestContext("claude-sonnet-4-5:embedContent", `{}`, apiKey)
+	(&GatewayHandler{}).GeminiV1BetaCompat(c)
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Equal(t, "NOT_FOUND", gjson.Get(w.Body.String(), "error.status").String())
+
+	c, w = newGeminiCompatTestContext("claude-opus-4-5:generateContent", `{}`, apiKey)
+	(&GatewayHandler{}).GeminiV1BetaCompat(c)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	c, w = newGeminiCompatTestContext("claude-sonnet-4-5:generateContent", `not json`, apiKey)
+	(&GatewayHandler{}).GeminiV1BetaCompat(c)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	pkghttputil "github.com/Wei-Shaw/sub2api/internal/pkg/httputil"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// imagesRequestError 描述 images 请求解析失败时应返回给客户端的错误。
+type imagesRequestError struct {
+	status  int
+	errType string
+	message string
+}
+
+// readImagesRequest 读取并解析 /v1/images 请求体（generations 为 JSON，edits 为 multipart）。
+// 返回原始 body、Content-Type 与解析后的请求；失败时返回应写回客户端的错误。
+func readImagesRequest(c *gin.Context, endpoint string) ([]byte, string, *apicompat.ImagesRequest, *imagesRequestError) {
+	body, err := pkghttputil.ReadRequestBodyWithPrealloc(c.Request)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			return nil, "", nil, &imagesRequestError{http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit)}
+		}
+		return nil, "", nil, &imagesRequestError{http.StatusBadRequest, "invalid_request_error", "Failed to read request body"}
+	}
+	if len(body) == 0 {
+		return nil, "", nil, &imagesRequestError{http.StatusBadRequest, "invalid_request_error", "Request body is empty"}
+	}
+
+	contentType := c.GetHeader("Content-Type")
+	req, err := service.ParseImagesRequest(contentType, body)
+	if err != nil {
+		return nil, "", nil, &imagesRequestError{http.StatusBadRequest, "invalid_request_error", "Failed to parse request body"}
+	}
+	if strings.TrimSpace(req.Model) == "" {
+		return nil, "", nil, &imagesRequestError{http.StatusBadRequest, "invalid_request_error", "model is required"}
+	}
+	if strings.TrimSpace(req.Prompt) == "" {
+		return nil, "", nil, &imagesRequestError{http.StatusBadRequest, "invalid_request_error", "prompt is required"}
+	}
+	if endpoint == service.ImagesEndpointEdits && len(req.Images) == 0 {
+		return nil, "", nil, &imagesRequestError{http.StatusBadRequest, "invalid_request_error", "image is required"}
+	}
+	return body, contentType, req, nil
+}
+
+// opsImagesRequestBody multipart 请求体包含二进制图片，不写入 ops 错误日志。
+func opsImagesRequestBody(contentType string, body []byte) []byte {
+	if strings.HasPrefix(strings.ToLower(contentType), "multipart/") {
+		return nil
+	}
+	return body
+}
+
+// ImagesGenerations handles OpenAI image generation requests for Gemini and
+// Antigravity groups.
+// POST /v1/images/generations
+func (h *GatewayHandler) ImagesGenerations(c *gin.Context) {
+	h.handleImages(c, service.ImagesEndpointGenerations)
+}
+
+// ImagesEdits handles OpenAI image edit requests for Gemini and Antigravity groups.
+// POST /v1/images/edits
+func (h *GatewayHandler) ImagesEdits(c *gin.Context) {
+	h.handleImages(c, service.ImagesEndpointEdits)
+}
+
+// handleImages converts OpenAI images requests to generateContent calls on a
+// Gemini image generation model and returns the result in OpenAI images format.
+func (h *GatewayHandler) handleImages(c *gin.Context, endpoint string) {
+	streamStarted := false
+
+	requestStart := time.Now()
+
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok {
+		h.chatCompletionsErrorResponse(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return
+	}
+
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		h.chatCompletionsErrorResponse(c, http.StatusInternalServerError, "api_error", "User context not found")
+		return
+	}
+	reqLog := requestLogger(
+		c,
+		"handler.gateway.images",
+		zap.Int64("user_id", subject.UserID),
+		zap.Int64("api_key_id", apiKey.ID),
+		zap.Any("group_id", apiKey.GroupID),
+		zap.String("endpoint", endpoint),
+	)
+
+	body, contentType, imagesReq, reqErr := readImagesRequest(c, endpoint)
+	if reqErr != nil {
+		h.chatCompletionsErrorResponse(c, reqErr.status, reqErr.errType, reqErr.message)
+		return
+	}
+	reqModel := imagesReq.Model
+	if !isAPIKeyModelAllowed(apiKey, reqModel) {
+		h.chatCompletionsErrorResponse(c, http.StatusForbidden, "permission_error", apiKeyModelNotAllowedMessage(reqModel))
+		return
+	}
+	reqLog = reqLog.With(zap.String("model", reqModel))
+
+	setOpsRequestContext(c, reqModel, false, opsImagesRequestBody(contentType, body))
+	setOpsEndpointContext(c, "", int16(service.RequestTypeFromLegacy(false, false)))
+
+	// 分组安全策略（PII 脱敏 / 拦截）：检查 prompt，须在转发使用请求体之前执行
+	body, ok = applyImagesGuardrail(c, h.guardrailService, apiKey, contentType, body, imagesReq, reqLog, h.chatCompletionsErrorResponse)
+	if !ok {
+		return
+	}
+
+	// 解析渠道级模型映射
+	channelMapping, _ := h.gatewayService.ResolveChannelMappingAndRestrict(c.Request.Context(), apiKey.GroupID, reqModel)
+	modelName := reqModel
+	if channelMapping.Mapped {
+		modelName = channelMapping.MappedModel
+	}
+	forwardReq := *imagesReq
+	forwardReq.Model = modelName
+
+	if h.errorPassthroughService != nil {
+		service.BindErrorPassthroughService(c, h.errorPassthroughService)
+	}
+
+	subscription, _ := middleware2.GetSubscriptionFromContext(c)
+
+	service.SetOpsLatencyMs(c, service.OpsAuthLatencyMsKey, time.Since(requestStart).Milliseconds())
+
+	// 1. Acquire user concurrency slot
+	maxWait := service.CalculateMaxWait(subject.Concurrency)
+	canWait, err := h.concurrencyHelper.IncrementWaitCount(c.Request.Context(), subject.UserID, maxWait)
+	waitCounted := false
+	if err != nil {
+		reqLog.Warn("gateway.images.user_wait_counter_increment_failed", zap.Error(err))
+	} else if !canWait {
+		h.chatCompletionsErrorResponse(c, http.StatusTooManyRequests, "rate_limit_error", "Too many pending requests, please retry later")
+		return
+	}
+	if err == nil && canWait {
+		waitCounted = true
+	}
+	defer func() {
+		if waitCounted {
+			h.concurrencyHelper.DecrementWaitCount(c.Request.Context(), subject.UserID)
+		}
+	}()
+
+	userReleaseFunc, err := h.concurrencyHelper.AcquireUserSlotWithWait(c, subject.UserID, subject.Concurrency, false, &streamStarted)
+	if err != nil {
+		reqLog.Warn("gateway.images.user_slot_acquire_failed", zap.Error(err))
+		h.handleConcurrencyError(c, err, "user", streamStarted)
+		return
+	}
+	if waitCounted {
+		h.concurrencyHelper.DecrementWaitCount(c.Request.Context(), subject.UserID)
+		waitCounted = false
+	}
+	userReleaseFunc = wrapReleaseOnDone(c.Request.Context(), userReleaseFunc)
+	if userReleaseFunc != nil {
+		defer userReleaseFunc()
+	}
+
+	// 2. Re-check billing
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+		reqLog.Info("gateway.images.billing_check_failed", zap.Error(err))
+		status, code, message := billingErrorDetails(err)
+		h.chatCompletionsErrorResponse(c, status, code, message)
+		return
+	}
+
+	// 3. Account selection + failover loop
+	fs := NewFailoverState(h.maxAccountSwitchesGemini, false)
+
+	for {
+		selection, err := h.gatewayService.SelectAccountWithLoadAwareness(c.Request.Context(), apiKey.GroupID, "", modelName, fs.FailedAccountIDs, "", int64(0))
+		if err != nil {
+			if fs.LastFailoverErr == nil {
+				h.chatCompletionsErrorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts support image generation")
+				return
+			}
+			action := fs.HandleSelectionExhausted(c.Request.Context())
+			switch action {
+			case FailoverContinue:
+				continue
+			case FailoverCanceled:
+				return
+			default:
+				h.handleCCFailoverExhausted(c, fs.LastFailoverErr, streamStarted)
+				return
+			}
+		}
+		account := selection.Account
+		setOpsSelectedAccount(c, account.ID, account.Platform)
+
+		// 4. Acquire account concurrency slot
+		accountReleaseFunc := selection.ReleaseFunc
+		if !selection.Acquired {
+			if selection.WaitPlan == nil {
+				h.chatCompletionsErrorResponse(c, http.StatusServiceUnavailable, "api_error", "No available accounts")
+				return
+			}
+			accountReleaseFunc, err = h.concurrencyHelper.AcquireAccountSlotWithWaitTimeout(
+				c,
+				account.ID,
+				selection.WaitPlan.MaxConcurrency,
+				selection.WaitPlan.Timeout,
+				false,
+				&streamStarted,
+			)
+			if err != nil {
+				reqLog.Warn("gateway.images.account_slot_acquire_failed", zap.Int64("account_id", account.ID), zap.Error(err))
+				h.handleConcurrencyError(c, err, "account", streamStarted)
+				return
+			}
+		}
+		accountReleaseFunc = wrapReleaseOnDone(c.Request.Context(), accountReleaseFunc)
+
+		// 5. Forward request
+		var result *service.ForwardResult
+		if account.Platform == service.PlatformAntigravity && account.Type != service.AccountTypeAPIKey {
+			result, err = h.antigravityGatewayService.ForwardImages(c.Request.Context(), c, account, &forwardReq)
+		} else {
+			result, err = h.geminiCompatService.ForwardImages(c.Request.Context(), c, account, &forwardReq)
+		}
+
+		if accountReleaseFunc != nil {
+			accountReleaseFunc()
+		}
+
+		if err != nil {
+			var failoverErr *service.UpstreamFailoverError
+			if errors.As(err, &failoverErr) {
+				action := fs.HandleFailoverError(c.Request.Context(), h.gatewayService, account.ID, account.Platform, failoverErr)
+				switch action {
+				case FailoverContinue:
+					continue
+				case FailoverExhausted:
+					h.handleCCFailoverExhausted(c, fs.LastFailoverErr, streamStarted)
+					return
+				case FailoverCanceled:
+					return
+				}
+			}
+			h.ensureForwardErrorResponse(c, streamStarted)
+			reqLog.Error("gateway.images.forward_failed",
+				zap.Int64("account_id", account.ID),
+				zap.Error(err),
+			)
+			return
+		}
+
+		// 6. Record usage
+		userAgent := c.GetHeader("User-Agent")
+		clientIP := ip.GetClientIP(c)
+		requestPayloadHash := service.HashUsageRequestPayload(body)
+		inboundEndpoint := GetInboundEndpoint(c)
+		upstreamEndpoint := GetUpstreamEndpoint(c, account.Platform)
+
+		h.submitUsageRecordTask(func(ctx context.Context) {
+			if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
+				Result:             result,
+				APIKey:             apiKey,
+				User:               apiKey.User,
+				Account:            account,
+				Subscription:       subscription,
+				InboundEndpoint:    inboundEndpoint,
+				UpstreamEndpoint:   upstreamEndpoint,
+				UserAgent:          userAgent,
+				IPAddress:          clientIP,
+				RequestPayloadHash: requestPayloadHash,
+				APIKeyService:      h.apiKeyService,
+				ChannelUsageFields: channelMapping.ToUsageFields(reqModel, result.UpstreamModel),
+			}); err != nil {
+				reqLog.Error("gateway.images.record_usage_failed",
+					zap.Int64("account_id", account.ID),
+					zap.Error(err),
+				)
+			}
+		})
+		return
+	}
+}
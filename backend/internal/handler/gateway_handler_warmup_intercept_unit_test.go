@@ -42,6 +42,9 @@ func (f *fakeSchedulerCache) DeleteAccount(_ context.Context, _ int64) error
 func (f *fakeSchedulerCache) UpdateLastUsed(_ context.Context, _ map[int64]time.Time) error {
 	return nil
 }
+func (f *fakeSchedulerCache) ListCredentialKeyIDs(_ context.Context) ([]string, error) {
+	return nil, nil
+}
 func (f *fakeSchedulerCache) TryLockBucket(_ context.Context, _ service.SchedulerBucket, _ time.Duration) (bool, error) {
 	return true, nil
 }
@@ -135,7 +138,7 @@ func newTestGatewayHandler(t *testing.T, group *service.Group, accounts []*servi
 	t.Helper()
 
 	schedulerCache := &fakeSchedulerCache{accounts: accounts}
-	schedulerSnapshot := service.NewSchedulerSnapshotService(schedulerCache, nil, nil, nil, nil)
+	schedulerSnapshot := service.NewSchedulerSnapshotService(schedulerCache, nil, nil, nil, nil, nil)
 
 	gwSvc := service.NewGatewayService(
 		nil, // accountRepo (not used: scheduler snapshot hit)
@@ -156,6 +159,7 @@ func newTestGatewayHandler(t *testing.T, group *service.Group, accounts []*servi
 		nil, // httpUpstream
 		nil, // deferredService
 		nil, // claudeTokenProvider
+		nil, // vertexTokenProvider
 		nil, // sessionLimitCache
 		nil, // rpmCache
 		nil, // digestStore
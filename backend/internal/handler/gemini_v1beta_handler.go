@@ -47,7 +47,9 @@ func (h *GatewayHandler) GeminiV1BetaListModels(c *gin.Context) {
 
 	// 强制 antigravity 模式：返回 antigravity 支持的模型列表
 	if forcePlatform == service.PlatformAntigravity {
-		c.JSON(http.StatusOK, antigravity.FallbackGeminiModelsList())
+		list := antigravity.FallbackGeminiModelsList()
+		list.Models = filterModelsForAPIKey(apiKey, list.Models, func(m antigravity.GeminiModel) string { return m.Name })
+		c.JSON(http.StatusOK, list)
 		return
 	}
 
@@ -57,7 +59,7 @@ func (h *GatewayHandler) GeminiV1BetaListModels(c *gin.Context) {
 		hasAntigravity, _ := h.geminiCompatService.HasAntigravityAccounts(c.Request.Context(), apiKey.GroupID)
 		if hasAntigravity {
 			// antigravity 账户使用静态模型列表
-			c.JSON(http.StatusOK, gemini.FallbackModelsList())
+			c.JSON(http.StatusOK, filterGeminiFallbackModelsList(apiKey))
 			return
 		}
 		googleError(c, http.StatusServiceUnavailable, "No available Gemini accounts: "+err.Error())
@@ -70,9 +72,10 @@ func (h *GatewayHandler) GeminiV1BetaListModels(c *gin.Context) {
 		return
 	}
 	if shouldFallbackGeminiModels(res) {
-		c.JSON(http.StatusOK, gemini.FallbackModelsList())
+		c.JSON(http.StatusOK, filterGeminiFallbackModelsList(apiKey))
 		return
 	}
+	filterGeminiModelsListBody(apiKey, res)
 	writeUpstreamResponse(c, res)
 }
 
@@ -96,6 +99,10 @@ func (h *GatewayHandler) GeminiV1BetaGetModel(c *gin.Context) {
 		googleError(c, http.StatusBadRequest, "Missing model in URL")
 		return
 	}
+	if !isAPIKeyModelAllowed(apiKey, modelName) {
+		googleError(c, http.StatusForbidden, apiKeyModelNotAllowedMessage(modelName))
+		return
+	}
 
 	// 强制 antigravity 模式：返回 antigravity 模型信息
 	if forcePlatform == service.PlatformAntigravity {
@@ -164,6 +171,11 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		return
 	}
 
+	if !isAPIKeyModelAllowed(apiKey, modelName) {
+		googleError(c, http.StatusForbidden, apiKeyModelNotAllowedMessage(modelName))
+		return
+	}
+
 	stream := action == "streamGenerateContent"
 	reqLog = reqLog.With(zap.String("model", modelName), zap.String("action", action), zap.Bool("stream", stream))
 
@@ -181,9 +193,25 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 		return
 	}
 
+	// 分组开启本地计数时 countTokens 直接本地估算，不请求上游
+	if action == "countTokens" && apiKey.Group != nil && apiKey.Group.LocalTokenCounting {
+		c.JSON(http.StatusOK, gin.H{"totalTokens": service.EstimateGeminiCompatCountTokens(body, modelName)})
+		return
+	}
+
 	setOpsRequestContext(c, modelName, stream, body)
 	setOpsEndpointContext(c, "", int16(service.RequestTypeFromLegacy(stream, false)))
 
+	// 分组安全策略（PII 脱敏 / 拦截）：须在会话 hash 与转发使用请求体之前执行
+	guardedBody, guardrailModified, allowed := applyGuardrail(c, h.guardrailService, apiKey,
+		service.GuardrailProtocolGeminiGenerateContent, modelName, stream, body, reqLog, googleGuardrailError)
+	if !allowed {
+		return
+	}
+	if guardrailModified {
+		body = guardedBody
+	}
+
 	// 解析渠道级模型映射
 	channelMapping, _ := h.gatewayService.ResolveChannelMappingAndRestrict(c.Request.Context(), apiKey.GroupID, modelName)
 	reqModel := modelName // 保存映射前的原始模型名
@@ -491,6 +519,8 @@ func (h *GatewayHandler) GeminiV1BetaModels(c *gin.Context) {
 			return
 		}
 
+		setOpsTimeToFirstToken(c, result.FirstTokenMs)
+
 		// 捕获请求信息（用于异步记录，避免在 goroutine 中访问 gin.Context）
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	coderws "github.com/coder/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"go.uber.org/zap"
+)
+
+// applyGuardrail 在转发上游之前按分组安全策略检查请求体。
+//
+// modified=true 时返回的请求体已替换 mask 规则命中的内容，调用方须改用该请求体；ok=false 表示请求已被拦截，
+// 并已通过 writeError 写入与入站协议一致的错误响应。拦截会在 ops 错误日志中记为 guardrail 阶段。
+func applyGuardrail(
+	c *gin.Context,
+	svc *service.GuardrailService,
+	apiKey *service.APIKey,
+	protocol string,
+	model string,
+	stream bool,
+	body []byte,
+	reqLog *zap.Logger,
+	writeError func(c *gin.Context, status int, errType, message string),
+) (guardedBody []byte, modified bool, ok bool) {
+	result := svc.Inspect(c.Request.Context(), &service.GuardrailRequest{
+		APIKey:   apiKey,
+		Protocol: protocol,
+		Model:    model,
+		Body:     body,
+	})
+	for _, f := range result.Findings {
+		reqLog.Info("gateway.guardrail_hit",
+			zap.Int64("rule_id", f.RuleID),
+			zap.String("rule_name", f.RuleName),
+			zap.String("action", f.Action),
+			zap.Int("matches", f.Matches),
+		)
+	}
+	if result.Blocked {
+		// 被拦截的请求不在 ops 错误日志与载荷抓取中保留原文
+		c.Set(opsRequestBodyKey, []byte(nil))
+		c.Set(opsGuardrailBlockedKey, true)
+		writeError(c, http.StatusBadRequest, "invalid_request_error", result.BlockedMessage())
+		return nil, false, false
+	}
+	if result.Modified {
+		setOpsRequestContext(c, model, stream, result.Body)
+	}
+	return result.Body, result.Modified, true
+}
+
+// googleGuardrailError 以 Gemini 原生错误格式写回拦截结果
+func googleGuardrailError(c *gin.Context, status int, _ string, message string) {
+	googleError(c, status, message)
+}
+
+// applyImagesGuardrail 检查 images 请求的 prompt。edits 为 multipart 请求体，无法直接按 JSON 遍历，
+// 因此只把 prompt 交给安全策略；mask 命中时同步改写解析后的请求与原始请求体。
+func applyImagesGuardrail(
+	c *gin.Context,
+	svc *service.GuardrailService,
+	apiKey *service.APIKey,
+	contentType string,
+	body []byte,
+	req *apicompat.ImagesRequest,
+	reqLog *zap.Logger,
+	writeError func(c *gin.Context, status int, errType, message string),
+) ([]byte, bool) {
+	promptDoc, err := sjson.SetBytes([]byte(`{}`), "prompt", req.Prompt)
+	if err != nil {
+		writeError(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return nil, false
+	}
+	guarded, modified, ok := applyGuardrail(c, svc, apiKey, service.GuardrailProtocolOpenAIImages, req.Model, false, promptDoc, reqLog, writeError)
+	if !ok {
+		return nil, false
+	}
+	if !modified {
+		return body, true
+	}
+	prompt := gjson.GetBytes(guarded, "prompt").String()
+	newBody, err := service.ReplaceImagesRequestPrompt(contentType, body, prompt)
+	if err != nil {
+		reqLog.Warn("gateway.guardrail_images_rewrite_failed", zap.Error(err))
+		writeError(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return nil, false
+	}
+	req.Prompt = prompt
+	setOpsRequestContext(c, req.Model, false, opsImagesRequestBody(contentType, newBody))
+	return newBody, true
+}
+
+// applyWSGuardrail 检查 Responses WebSocket 的 response.create 消息；拦截时返回以策略违规关闭连接的错误
+func applyWSGuardrail(
+	c *gin.Context,
+	svc *service.GuardrailService,
+	apiKey *service.APIKey,
+	payload []byte,
+	reqLog *zap.Logger,
+) ([]byte, error) {
+	model := strings.TrimSpace(gjson.GetBytes(payload, "model").String())
+	var blockedMessage string
+	guarded, modified, ok := applyGuardrail(c, svc, apiKey, service.GuardrailProtocolOpenAIResponses, model, true, payload, reqLog,
+		func(_ *gin.Context, _ int, _ string, message string) { blockedMessage = message })
+	if !ok {
+		return nil, service.NewOpenAIWSClientCloseError(coderws.StatusPolicyViolation, blockedMessage, nil)
+	}
+	if modified {
+		return guarded, nil
+	}
+	return payload, nil
+}
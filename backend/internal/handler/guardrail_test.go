@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	coderws "github.com/coder/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+type guardrailRepoStub struct {
+	service.GuardrailRepository
+	rules []service.GuardrailRule
+}
+
+func (r *guardrailRepoStub) ListRules(context.Context) ([]service.GuardrailRule, error) {
+	return r.rules, nil
+}
+
+func TestApplyGuardrail(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{}
+	cfg.Guardrail = config.GuardrailConfig{Enabled: true, WebhookTimeoutMs: 1000}
+	svc := service.NewGuardrailService(&guardrailRepoStub{rules: []service.GuardrailRule{
+		{ID: 1, GroupID: 1, Name: "no secrets", Detector: service.GuardrailDetectorPreset,
+			Patterns: []string{"api_secret"}, Action: service.GuardrailActionBlock, Enabled: true},
+		{ID: 2, GroupID: 1, Name: "emails", Detector: service.GuardrailDetectorPreset,
+			Patterns: []string{"email"}, Action: service.GuardrailActionMask, Enabled: true},
+	}}, nil, cfg)
+	svc.Start()
+	defer svc.Stop()
+
+	groupID := int64(1)
+	apiKey := &service.APIKey{ID: 1, GroupID: &groupID}
+	anthropicError := (&GatewayHandler{}).errorResponse
+
+	t.Run("block writes protocol error and marks ops", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+		body := []byte(`{"model":"claude","messages":[{"role":"user","content":"my key is sk-ant-REDACTED"}]}`)
+		setOpsRequestContext(c, "claude", false, body)
+
+		_, _, ok := applyGuardrail(c, svc, apiKey, service.GuardrailProtocolAnthropicMessages, "claude", false, body, zap.NewNop(), anthropicError)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.Equal(t, "error", gjson.Get(rec.Body.String(), "type").String())
+		require.Equal(t, "invalid_request_error", gjson.Get(rec.Body.String(), "error.type").String())
+		require.Contains(t, gjson.Get(rec.Body.String(), "error.message").String(), "no secrets")
+
+		blocked, _ := c.Get(opsGuardrailBlockedKey)
+		require.Equal(t, true, blocked)
+		raw, _ := c.Get(opsRequestBodyKey)
+		require.Empty(t, raw)
+	})
+
+	t.Run("mask replaces body and ops request body", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+		body := []byte(`{"model":"claude","messages":[{"role":"user","content":"mail me at a@example.com"}]}`)
+
+		guarded, modified, ok := applyGuardrail(c, svc, apiKey, service.GuardrailProtocolAnthropicMessages, "claude", false, body, zap.NewNop(), anthropicError)
+		require.True(t, ok)
+		require.True(t, modified)
+		require.Equal(t, "mail me at [REDACTED]", gjson.GetBytes(guarded, "messages.0.content").String())
+		raw, _ := c.Get(opsRequestBodyKey)
+		require.Equal(t, guarded, raw)
+	})
+
+	t.Run("gemini protocol uses google error format", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1beta/models/gemini:generateContent", nil)
+		body := []byte(`{"contents":[{"role":"user","parts":[{"text":"sk-ant-REDACTED"}]}]}`)
+
+		_, _, ok := applyGuardrail(c, svc, apiKey, service.GuardrailProtocolGeminiGenerateContent, "gemini", false, body, zap.NewNop(), googleGuardrailError)
+		require.False(t, ok)
+		require.Equal(t, http.StatusBadRequest, rec.Code)
+		require.Equal(t, int64(http.StatusBadRequest), gjson.Get(rec.Body.String(), "error.code").Int())
+		require.Contains(t, gjson.Get(rec.Body.String(), "error.message").String(), "no secrets")
+	})
+
+	t.Run("images mask rewrites prompt in multipart body", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/images/edits", nil)
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		require.NoError(t, writer.WriteField("model", "gpt-image-1"))
+		require.NoError(t, writer.WriteField("prompt", "a poster for a@example.com"))
+		require.NoError(t, writer.Close())
+		contentType := writer.FormDataContentType()
+		req := &apicompat.ImagesRequest{Model: "gpt-image-1", Prompt: "a poster for a@example.com"}
+
+		body, ok := applyImagesGuardrail(c, svc, apiKey, contentType, buf.Bytes(), req, zap.NewNop(), (&OpenAIGatewayHandler{}).errorResponse)
+		require.True(t, ok)
+		require.Equal(t, "a poster for [REDACTED]", req.Prompt)
+		parsed, err := service.ParseImagesRequest(contentType, body)
+		require.NoError(t, err)
+		require.Equal(t, "a poster for [REDACTED]", parsed.Prompt)
+		require.Equal(t, "gpt-image-1", parsed.Model)
+	})
+
+	t.Run("websocket block returns policy violation close error", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/v1/responses", nil)
+		payload := []byte(`{"type":"response.create","model":"gpt-5","input":"sk-ant-REDACTED"}`)
+
+		_, err := applyWSGuardrail(c, svc, apiKey, payload, zap.NewNop())
+		var closeErr *service.OpenAIWSClientCloseError
+		require.ErrorAs(t, err, &closeErr)
+		require.Equal(t, coderws.StatusPolicyViolation, closeErr.StatusCode())
+		require.Contains(t, closeErr.Reason(), "no secrets")
+
+		masked, err := applyWSGuardrail(c, svc, apiKey, []byte(`{"type":"response.create","model":"gpt-5","input":"to a@example.com"}`), zap.NewNop())
+		require.NoError(t, err)
+		require.Equal(t, "to [REDACTED]", gjson.GetBytes(masked, "input").String())
+	})
+
+	t.Run("nil service passes through", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+		body := []byte(`{"messages":[{"role":"user","content":"sk-ant-REDACTED"}]}`)
+
+		guarded, modified, ok := applyGuardrail(c, nil, apiKey, service.GuardrailProtocolAnthropicMessages, "", false, body, zap.NewNop(), anthropicError)
+		require.True(t, ok)
+		require.False(t, modified)
+		require.Equal(t, body, guarded)
+	})
+}
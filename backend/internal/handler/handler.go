@@ -31,6 +31,16 @@ type AdminHandlers struct {
 	APIKey                *admin.AdminAPIKeyHandler
 	ScheduledTest         *admin.ScheduledTestHandler
 	Channel               *admin.ChannelHandler
+	NotificationChannel   *admin.NotificationChannelHandler
+	OIDCProvider          *admin.OIDCProviderHandler
+	PaymentOrder          *admin.PaymentOrderHandler
+	AuditLog              *admin.AuditLogHandler
+	AdminToken            *admin.AdminTokenHandler
+	PayloadCapture        *admin.PayloadCaptureHandler
+	Guardrail             *admin.GuardrailHandler
+	ProxyPool             *admin.ProxyPoolHandler
+	CredentialEncryption  *admin.CredentialEncryptionHandler
+	Referral              *admin.ReferralHandler
 }
 
 // Handlers contains all HTTP handlers
@@ -45,8 +55,12 @@ type Handlers struct {
 	Admin         *AdminHandlers
 	Gateway       *GatewayHandler
 	OpenAIGateway *OpenAIGatewayHandler
+	MessageBatch  *MessageBatchHandler
+	OpenAIBatch   *OpenAIBatchHandler
 	Setting       *SettingHandler
 	Totp          *TotpHandler
+	Payment       *PaymentHandler
+	Referral      *ReferralHandler
 }
 
 // BuildInfo contains build-time information
@@ -21,15 +21,30 @@ func executeUserIdempotentJSON(
 	ttl time.Duration,
 	execute func(context.Context) (any, error),
 ) {
+	data, _, ok := runUserIdempotent(c, scope, payload, ttl, execute)
+	if !ok {
+		return
+	}
+	response.Success(c, data)
+}
+
+// runUserIdempotent 执行幂等写操作但不写成功响应，供需要区分首次执行与重放的调用方使用。
+// 失败时已写入错误响应并返回 ok=false。
+func runUserIdempotent(
+	c *gin.Context,
+	scope string,
+	payload any,
+	ttl time.Duration,
+	execute func(context.Context) (any, error),
+) (data any, replayed bool, ok bool) {
 	coordinator := service.DefaultIdempotencyCoordinator()
 	if coordinator == nil {
 		data, err := execute(c.Request.Context())
 		if err != nil {
 			response.ErrorFrom(c, err)
-			return
+			return nil, false, false
 		}
-		response.Success(c, data)
-		return
+		return data, false, true
 	}
 
 	actorScope := "user:0"
@@ -56,10 +71,10 @@ func executeUserIdempotentJSON(
 			c.Header("Retry-After", strconv.Itoa(retryAfter))
 		}
 		response.ErrorFrom(c, err)
-		return
+		return nil, false, false
 	}
 	if result != nil && result.Replayed {
 		c.Header("X-Idempotency-Replayed", "true")
 	}
-	response.Success(c, result.Data)
+	return result.Data, result.Replayed, true
 }
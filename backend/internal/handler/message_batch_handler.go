@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	pkgerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	pkghttputil "github.com/Wei-Shaw/sub2api/internal/pkg/httputil"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+// MessageBatchHandler serves the Anthropic Message Batches API.
+// POST   /v1/messages/batches
+// GET    /v1/messages/batches
+// GET    /v1/messages/batches/:id
+// POST   /v1/messages/batches/:id/cancel
+// GET    /v1/messages/batches/:id/results
+type MessageBatchHandler struct {
+	messageBatchService *service.MessageBatchService
+	billingCacheService *service.BillingCacheService
+}
+
+// NewMessageBatchHandler creates a new MessageBatchHandler
+func NewMessageBatchHandler(messageBatchService *service.MessageBatchService, billingCacheService *service.BillingCacheService) *MessageBatchHandler {
+	return &MessageBatchHandler{
+		messageBatchService: messageBatchService,
+		billingCacheService: billingCacheService,
+	}
+}
+
+// messageBatchResponse Anthropic message_batch 对象
+type messageBatchResponse struct {
+	ID                string                            `json:"id"`
+	Type              string                            `json:"type"`
+	ProcessingStatus  string                            `json:"processing_status"`
+	RequestCounts     service.MessageBatchRequestCounts `json:"request_counts"`
+	EndedAt           *time.Time                        `json:"ended_at"`
+	CreatedAt         time.Time                         `json:"created_at"`
+	ExpiresAt         time.Time                         `json:"expires_at"`
+	ArchivedAt        *time.Time                        `json:"archived_at"`
+	CancelInitiatedAt *time.Time                        `json:"cancel_initiated_at"`
+	ResultsURL        *string                           `json:"results_url"`
+}
+
+// Create 创建批次
+func (h *MessageBatchHandler) Create(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	reqLog := requestLogger(c, "handler.message_batch",
+		zap.Int64("api_key_id", caller.APIKey.ID),
+		zap.Any("group_id", caller.APIKey.GroupID),
+	)
+
+	body, err := pkghttputil.ReadRequestBodyWithPrealloc(c.Request)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			messageBatchError(c, http.StatusRequestEntityTooLarge, "request_too_large", buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		messageBatchError(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+		return
+	}
+	if !gjson.ValidBytes(body) {
+		messageBatchError(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return
+	}
+
+	requests, err := h.messageBatchService.ParseMessageBatchRequests(body)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	for _, req := range requests {
+		model := gjson.GetBytes(req.Params, "model").String()
+		if !isAPIKeyModelAllowed(caller.APIKey, model) {
+			messageBatchError(c, http.StatusForbidden, "permission_error", apiKeyModelNotAllowedMessage(model))
+			return
+		}
+	}
+
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), caller.APIKey.User, caller.APIKey, caller.APIKey.Group, caller.Subscription); err != nil {
+		status, _, message := billingErrorDetails(err)
+		messageBatchError(c, status, messageBatchErrorTypeForStatus(status), message)
+		return
+	}
+
+	batch, err := h.messageBatchService.Create(c.Request.Context(), caller, requests)
+	if err != nil {
+		reqLog.Warn("message_batch.create_failed", zap.Int("request_count", len(requests)), zap.Error(err))
+		h.serviceError(c, err)
+		return
+	}
+	reqLog.Info("message_batch.created",
+		zap.String("batch_id", batch.ID),
+		zap.String("mode", batch.Mode),
+		zap.Int("request_count", batch.RequestCount),
+	)
+	c.JSON(http.StatusOK, buildMessageBatchResponse(c, batch))
+}
+
+// List 列出当前 API Key 的批次
+func (h *MessageBatchHandler) List(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	params := service.MessageBatchListParams{
+		BeforeID: c.Query("before_id"),
+		AfterID:  c.Query("after_id"),
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > service.MessageBatchListMaxLimit {
+			messageBatchError(c, http.StatusBadRequest, "invalid_request_error", "limit must be between 1 and 1000")
+			return
+		}
+		params.Limit = limit
+	}
+
+	batches, hasMore, err := h.messageBatchService.List(c.Request.Context(), caller, params)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	data := make([]messageBatchResponse, 0, len(batches))
+	for i := range batches {
+		data = append(data, buildMessageBatchResponse(c, &batches[i]))
+	}
+	var firstID, lastID *string
+	if len(data) > 0 {
+		firstID = &data[0].ID
+		lastID = &data[len(data)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":     data,
+		"has_more": hasMore,
+		"first_id": firstID,
+		"last_id":  lastID,
+	})
+}
+
+// Get 查询批次
+func (h *MessageBatchHandler) Get(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	batch, err := h.messageBatchService.Get(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, buildMessageBatchResponse(c, batch))
+}
+
+// Cancel 取消批次
+func (h *MessageBatchHandler) Cancel(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	batch, err := h.messageBatchService.Cancel(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, buildMessageBatchResponse(c, batch))
+}
+
+// Results 以 JSONL 流式返回批次结果
+func (h *MessageBatchHandler) Results(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	results, err := h.messageBatchService.OpenResults(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	defer func() { _ = results.Close() }()
+
+	c.Header("Content-Type", "application/x-jsonl")
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, results); err != nil {
+		requestLogger(c, "handler.message_batch").Warn("message_batch.results_write_failed", zap.Error(err))
+	}
+}
+
+// caller 从认证上下文构造批处理调用方信息
+func (h *MessageBatchHandler) caller(c *gin.Context) (*service.MessageBatchCaller, bool) {
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok || apiKey == nil {
+		messageBatchError(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return nil, false
+	}
+	if !h.messageBatchService.Enabled() {
+		messageBatchError(c, http.StatusNotFound, "not_found_error", "Message batches are not enabled")
+		return nil, false
+	}
+	subscription, _ := middleware2.GetSubscriptionFromContext(c)
+	return &service.MessageBatchCaller{
+		APIKey:          apiKey,
+		Subscription:    subscription,
+		Header:          c.Request.Header.Clone(),
+		InboundEndpoint: GetInboundEndpoint(c),
+		UserAgent:       c.GetHeader("User-Agent"),
+		IPAddress:       ip.GetClientIP(c),
+	}, true
+}
+
+// serviceError 将服务层错误转换为 Anthropic 错误响应
+func (h *MessageBatchHandler) serviceError(c *gin.Context, err error) {
+	var upstreamErr *service.MessageBatchUpstreamError
+	if errors.As(err, &upstreamErr) {
+		status := upstreamErr.StatusCode
+		if status < http.StatusBadRequest {
+			status = http.StatusBadGateway
+		}
+		msg := upstreamErr.Message
+		if msg == "" {
+			msg = "Upstream request failed"
+		}
+		messageBatchError(c, status, messageBatchErrorTypeForStatus(status), msg)
+		return
+	}
+	status := pkgerrors.Code(err)
+	if status < http.StatusBadRequest || status == http.StatusInternalServerError {
+		requestLogger(c, "handler.message_batch").Error("message_batch.request_failed", zap.Error(err))
+		messageBatchError(c, http.StatusInternalServerError, "api_error", "Internal server error")
+		return
+	}
+	messageBatchError(c, status, messageBatchErrorTypeForStatus(status), pkgerrors.Message(err))
+}
+
+func messageBatchError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, gin.H{
+		"type": "error",
+		"error": gin.H{
+			"type":    errType,
+			"message": message,
+		},
+	})
+}
+
+func messageBatchErrorTypeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusPaymentRequired, http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusServiceUnavailable, 529:
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
+
+func buildMessageBatchResponse(c *gin.Context, batch *service.MessageBatch) messageBatchResponse {
+	resp := messageBatchResponse{
+		ID:                batch.ID,
+		Type:              "message_batch",
+		ProcessingStatus:  batch.ProcessingStatus,
+		RequestCounts:     batch.Counts,
+		EndedAt:           batch.EndedAt,
+		CreatedAt:         batch.CreatedAt,
+		ExpiresAt:         batch.ExpiresAt,
+		CancelInitiatedAt: batch.CancelInitiatedAt,
+	}
+	if batch.IsEnded() {
+		scheme := "http"
+		if isRequestHTTPS(c) {
+			scheme = "https"
+		}
+		url := scheme + "://" + c.Request.Host + EndpointMessageBatches + "/" + batch.ID + "/results"
+		resp.ResultsURL = &url
+	}
+	return resp
+}
@@ -0,0 +1,423 @@
+package handler
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	pkgerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	pkghttputil "github.com/Wei-Shaw/sub2api/internal/pkg/httputil"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// OpenAIBatchHandler serves the OpenAI Files and Batch APIs.
+// POST   /v1/files
+// GET    /v1/files
+// GET    /v1/files/:id
+// GET    /v1/files/:id/content
+// DELETE /v1/files/:id
+// POST   /v1/batches
+// GET    /v1/batches
+// GET    /v1/batches/:id
+// POST   /v1/batches/:id/cancel
+type OpenAIBatchHandler struct {
+	openAIBatchService  *service.OpenAIBatchService
+	billingCacheService *service.BillingCacheService
+}
+
+// NewOpenAIBatchHandler creates a new OpenAIBatchHandler
+func NewOpenAIBatchHandler(openAIBatchService *service.OpenAIBatchService, billingCacheService *service.BillingCacheService) *OpenAIBatchHandler {
+	return &OpenAIBatchHandler{
+		openAIBatchService:  openAIBatchService,
+		billingCacheService: billingCacheService,
+	}
+}
+
+// openAIFileResponse OpenAI File 对象
+type openAIFileResponse struct {
+	ID            string  `json:"id"`
+	Object        string  `json:"object"`
+	Bytes         int64   `json:"bytes"`
+	CreatedAt     int64   `json:"created_at"`
+	ExpiresAt     int64   `json:"expires_at"`
+	Filename      string  `json:"filename"`
+	Purpose       string  `json:"purpose"`
+	Status        string  `json:"status"`
+	StatusDetails *string `json:"status_details"`
+}
+
+// openAIBatchResponse OpenAI Batch 对象
+type openAIBatchResponse struct {
+	ID               string                           `json:"id"`
+	Object           string                           `json:"object"`
+	Endpoint         string                           `json:"endpoint"`
+	Errors           *openAIBatchErrorsResponse       `json:"errors"`
+	InputFileID      string                           `json:"input_file_id"`
+	CompletionWindow string                           `json:"completion_window"`
+	Status           string                           `json:"status"`
+	OutputFileID     *string                          `json:"output_file_id"`
+	ErrorFileID      *string                          `json:"error_file_id"`
+	CreatedAt        int64                            `json:"created_at"`
+	InProgressAt     *int64                           `json:"in_progress_at"`
+	ExpiresAt        int64                            `json:"expires_at"`
+	FinalizingAt     *int64                           `json:"finalizing_at"`
+	CompletedAt      *int64                           `json:"completed_at"`
+	FailedAt         *int64                           `json:"failed_at"`
+	ExpiredAt        *int64                           `json:"expired_at"`
+	CancellingAt     *int64                           `json:"cancelling_at"`
+	CancelledAt      *int64                           `json:"cancelled_at"`
+	RequestCounts    service.OpenAIBatchRequestCounts `json:"request_counts"`
+	Metadata         map[string]string                `json:"metadata"`
+}
+
+type openAIBatchErrorsResponse struct {
+	Object string                     `json:"object"`
+	Data   []service.OpenAIBatchError `json:"data"`
+}
+
+// openAIBatchCreateRequest POST /v1/batches 请求体
+type openAIBatchCreateRequest struct {
+	InputFileID      string            `json:"input_file_id"`
+	Endpoint         string            `json:"endpoint"`
+	CompletionWindow string            `json:"completion_window"`
+	Metadata         map[string]string `json:"metadata"`
+}
+
+// UploadFile 上传批处理输入文件（multipart/form-data: file, purpose）
+func (h *OpenAIBatchHandler) UploadFile(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			openAIBatchError(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		openAIBatchError(c, http.StatusBadRequest, "invalid_request_error", "Missing required parameter: 'file'.")
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	f, err := h.openAIBatchService.UploadFile(c.Request.Context(), caller, header.Filename, c.Request.FormValue("purpose"), file)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	requestLogger(c, "handler.openai_batch").Info("openai_batch.file_uploaded",
+		zap.Int64("api_key_id", caller.APIKey.ID),
+		zap.String("file_id", f.ID),
+		zap.Int64("bytes", f.Bytes),
+	)
+	c.JSON(http.StatusOK, buildOpenAIFileResponse(f))
+}
+
+// ListFiles 列出当前 API Key 的文件
+func (h *OpenAIBatchHandler) ListFiles(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	params := service.OpenAIFileListParams{
+		Purpose: c.Query("purpose"),
+		After:   c.Query("after"),
+		Asc:     c.Query("order") == "asc",
+	}
+	limit, ok := parseOpenAIListLimit(c, service.OpenAIFileListMaxLimit)
+	if !ok {
+		return
+	}
+	params.Limit = limit
+
+	files, hasMore, err := h.openAIBatchService.ListFiles(c.Request.Context(), caller, params)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	data := make([]openAIFileResponse, 0, len(files))
+	for i := range files {
+		data = append(data, buildOpenAIFileResponse(&files[i]))
+	}
+	var firstID, lastID *string
+	if len(data) > 0 {
+		firstID = &data[0].ID
+		lastID = &data[len(data)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"object":   "list",
+		"data":     data,
+		"first_id": firstID,
+		"last_id":  lastID,
+		"has_more": hasMore,
+	})
+}
+
+// GetFile 查询文件
+func (h *OpenAIBatchHandler) GetFile(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	f, err := h.openAIBatchService.GetFile(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, buildOpenAIFileResponse(f))
+}
+
+// FileContent 返回文件内容
+func (h *OpenAIBatchHandler) FileContent(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	f, content, err := h.openAIBatchService.OpenFileContent(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	defer func() { _ = content.Close() }()
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Length", strconv.FormatInt(f.Bytes, 10))
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, content); err != nil {
+		requestLogger(c, "handler.openai_batch").Warn("openai_batch.file_content_write_failed", zap.String("file_id", f.ID), zap.Error(err))
+	}
+}
+
+// DeleteFile 删除文件
+func (h *OpenAIBatchHandler) DeleteFile(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	id := c.Param("id")
+	if err := h.openAIBatchService.DeleteFile(c.Request.Context(), caller, id); err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "file", "deleted": true})
+}
+
+// CreateBatch 创建批次
+func (h *OpenAIBatchHandler) CreateBatch(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	body, err := pkghttputil.ReadRequestBodyWithPrealloc(c.Request)
+	if err != nil {
+		if maxErr, ok := extractMaxBytesError(err); ok {
+			openAIBatchError(c, http.StatusRequestEntityTooLarge, "invalid_request_error", buildBodyTooLargeMessage(maxErr.Limit))
+			return
+		}
+		openAIBatchError(c, http.StatusBadRequest, "invalid_request_error", "Failed to read request body")
+		return
+	}
+	var req openAIBatchCreateRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		openAIBatchError(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return
+	}
+
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), caller.APIKey.User, caller.APIKey, caller.APIKey.Group, caller.Subscription); err != nil {
+		status, code, message := billingErrorDetails(err)
+		openAIBatchError(c, status, code, message)
+		return
+	}
+
+	batch, err := h.openAIBatchService.CreateBatch(c.Request.Context(), caller, service.OpenAIBatchCreateInput{
+		InputFileID:      req.InputFileID,
+		Endpoint:         req.Endpoint,
+		CompletionWindow: req.CompletionWindow,
+		Metadata:         req.Metadata,
+	})
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	requestLogger(c, "handler.openai_batch").Info("openai_batch.created",
+		zap.Int64("api_key_id", caller.APIKey.ID),
+		zap.Any("group_id", caller.APIKey.GroupID),
+		zap.String("batch_id", batch.ID),
+		zap.String("endpoint", batch.Endpoint),
+	)
+	c.JSON(http.StatusOK, buildOpenAIBatchResponse(batch))
+}
+
+// ListBatches 列出当前 API Key 的批次
+func (h *OpenAIBatchHandler) ListBatches(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	limit, ok := parseOpenAIListLimit(c, service.OpenAIBatchListMaxLimit)
+	if !ok {
+		return
+	}
+	batches, hasMore, err := h.openAIBatchService.ListBatches(c.Request.Context(), caller, c.Query("after"), limit)
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	data := make([]openAIBatchResponse, 0, len(batches))
+	for i := range batches {
+		data = append(data, buildOpenAIBatchResponse(&batches[i]))
+	}
+	var firstID, lastID *string
+	if len(data) > 0 {
+		firstID = &data[0].ID
+		lastID = &data[len(data)-1].ID
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"object":   "list",
+		"data":     data,
+		"first_id": firstID,
+		"last_id":  lastID,
+		"has_more": hasMore,
+	})
+}
+
+// GetBatch 查询批次
+func (h *OpenAIBatchHandler) GetBatch(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	batch, err := h.openAIBatchService.GetBatch(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, buildOpenAIBatchResponse(batch))
+}
+
+// CancelBatch 取消批次
+func (h *OpenAIBatchHandler) CancelBatch(c *gin.Context) {
+	caller, ok := h.caller(c)
+	if !ok {
+		return
+	}
+	batch, err := h.openAIBatchService.CancelBatch(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		h.serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, buildOpenAIBatchResponse(batch))
+}
+
+// caller 从认证上下文构造批处理调用方信息
+func (h *OpenAIBatchHandler) caller(c *gin.Context) (*service.OpenAIBatchCaller, bool) {
+	apiKey, ok := middleware2.GetAPIKeyFromContext(c)
+	if !ok || apiKey == nil {
+		openAIBatchError(c, http.StatusUnauthorized, "authentication_error", "Invalid API key")
+		return nil, false
+	}
+	if !h.openAIBatchService.Enabled() {
+		openAIBatchError(c, http.StatusNotFound, "not_found_error", "Files and batches are not enabled")
+		return nil, false
+	}
+	subscription, _ := middleware2.GetSubscriptionFromContext(c)
+	return &service.OpenAIBatchCaller{
+		APIKey:       apiKey,
+		Subscription: subscription,
+		UserAgent:    c.GetHeader("User-Agent"),
+		IPAddress:    ip.GetClientIP(c),
+	}, true
+}
+
+// serviceError 将服务层错误转换为 OpenAI 错误响应
+func (h *OpenAIBatchHandler) serviceError(c *gin.Context, err error) {
+	status := pkgerrors.Code(err)
+	if status < http.StatusBadRequest || status == http.StatusInternalServerError {
+		requestLogger(c, "handler.openai_batch").Error("openai_batch.request_failed", zap.Error(err))
+		openAIBatchError(c, http.StatusInternalServerError, "api_error", "Internal server error")
+		return
+	}
+	errType := "invalid_request_error"
+	if status == http.StatusNotFound {
+		errType = "not_found_error"
+	}
+	openAIBatchError(c, status, errType, pkgerrors.Message(err))
+}
+
+func openAIBatchError(c *gin.Context, status int, errType, message string) {
+	c.JSON(status, gin.H{
+		"error": gin.H{
+			"type":    errType,
+			"message": message,
+		},
+	})
+}
+
+// parseOpenAIListLimit 解析 limit 查询参数；未传时返回 0 由服务层使用默认值
+func parseOpenAIListLimit(c *gin.Context, maxLimit int) (int, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit < 1 || limit > maxLimit {
+		openAIBatchError(c, http.StatusBadRequest, "invalid_request_error", "limit must be between 1 and "+strconv.Itoa(maxLimit))
+		return 0, false
+	}
+	return limit, true
+}
+
+func buildOpenAIFileResponse(f *service.OpenAIFile) openAIFileResponse {
+	return openAIFileResponse{
+		ID:        f.ID,
+		Object:    "file",
+		Bytes:     f.Bytes,
+		CreatedAt: f.CreatedAt.Unix(),
+		ExpiresAt: f.ExpiresAt.Unix(),
+		Filename:  f.Filename,
+		Purpose:   f.Purpose,
+		Status:    "processed",
+	}
+}
+
+func buildOpenAIBatchResponse(b *service.OpenAIBatch) openAIBatchResponse {
+	resp := openAIBatchResponse{
+		ID:               b.ID,
+		Object:           "batch",
+		Endpoint:         b.Endpoint,
+		InputFileID:      b.InputFileID,
+		CompletionWindow: b.CompletionWindow,
+		Status:           b.Status,
+		OutputFileID:     b.OutputFileID,
+		ErrorFileID:      b.ErrorFileID,
+		CreatedAt:        b.CreatedAt.Unix(),
+		InProgressAt:     unixPtr(b.InProgressAt),
+		ExpiresAt:        b.ExpiresAt.Unix(),
+		FinalizingAt:     unixPtr(b.FinalizingAt),
+		CompletedAt:      unixPtr(b.CompletedAt),
+		FailedAt:         unixPtr(b.FailedAt),
+		ExpiredAt:        unixPtr(b.ExpiredAt),
+		CancellingAt:     unixPtr(b.CancellingAt),
+		CancelledAt:      unixPtr(b.CancelledAt),
+		RequestCounts:    b.Counts,
+		Metadata:         b.Metadata,
+	}
+	if len(b.Errors) > 0 {
+		resp.Errors = &openAIBatchErrorsResponse{Object: "list", Data: b.Errors}
+	}
+	return resp
+}
+
+func unixPtr(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	v := t.Unix()
+	return &v
+}
@@ -72,6 +72,10 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 		return
 	}
 	reqModel := modelResult.String()
+	if !isAPIKeyModelAllowed(apiKey, reqModel) {
+		h.errorResponse(c, http.StatusForbidden, "permission_error", apiKeyModelNotAllowedMessage(reqModel))
+		return
+	}
 	reqStream := gjson.GetBytes(body, "stream").Bool()
 
 	reqLog = reqLog.With(zap.String("model", reqModel), zap.Bool("stream", reqStream))
@@ -79,6 +83,16 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 	setOpsRequestContext(c, reqModel, reqStream, body)
 	setOpsEndpointContext(c, "", int16(service.RequestTypeFromLegacy(reqStream, false)))
 
+	// 分组安全策略（PII 脱敏 / 拦截）：须在缓存 key 与转发使用请求体之前执行
+	guardedBody, guardrailModified, allowed := applyGuardrail(c, h.guardrailService, apiKey,
+		service.GuardrailProtocolOpenAIChatCompletions, reqModel, reqStream, body, reqLog, h.errorResponse)
+	if !allowed {
+		return
+	}
+	if guardrailModified {
+		body = guardedBody
+	}
+
 	// 解析渠道级模型映射
 	channelMapping, _ := h.gatewayService.ResolveChannelMappingAndRestrict(c.Request.Context(), apiKey.GroupID, reqModel)
 
@@ -91,6 +105,19 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 	service.SetOpsLatencyMs(c, service.OpsAuthLatencyMsKey, time.Since(requestStart).Milliseconds())
 	routingStart := time.Now()
 
+	// 响应缓存：确定性请求命中时直接回放，不请求上游；
+	// 在获取用户并发槽位之前查询，命中的请求不占用并发额度
+	responseCacheKey := h.responseCacheService.BuildKey(apiKey, service.ResponseCacheEndpointChatCompletions, body)
+	if entry := h.responseCacheService.Lookup(c.Request.Context(), responseCacheKey); entry != nil {
+		reqLog.Debug("openai_chat_completions.response_cache_hit")
+		h.serveResponseCacheHit(c, entry, apiKey, subscription, body, reqModel, reqStream, channelMapping, requestStart)
+		return
+	}
+	var responseCapture *responseCaptureWriter
+	if responseCacheKey != "" {
+		responseCapture = beginResponseCapture(c, h.responseCacheService.MaxEntryBytes())
+	}
+
 	userReleaseFunc, acquired := h.acquireResponsesUserSlot(c, subject.UserID, subject.Concurrency, reqStream, &streamStarted, reqLog)
 	if !acquired {
 		return
@@ -190,6 +217,7 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 		if channelMapping.Mapped {
 			forwardBody = h.gatewayService.ReplaceModelInBody(body, channelMapping.MappedModel)
 		}
+		responseCapture.reset()
 		result, err := h.gatewayService.ForwardAsChatCompletions(c.Request.Context(), c, account, forwardBody, promptCacheKey, defaultMappedModel)
 
 		forwardDurationMs := time.Since(forwardStart).Milliseconds()
@@ -259,6 +287,11 @@ func (h *OpenAIGatewayHandler) ChatCompletions(c *gin.Context) {
 			h.gatewayService.ReportOpenAIAccountScheduleResult(account.ID, true, nil)
 		}
 
+		if cachedBody := responseCapture.cacheableBody(c); cachedBody != nil && result != nil {
+			h.responseCacheService.Store(c.Request.Context(), apiKey.Group.ID, responseCacheKey,
+				service.NewOpenAIResponseCacheEntry(result, account, http.StatusOK, responseCapture.contentType(), cachedBody))
+		}
+
 		userAgent := c.GetHeader("User-Agent")
 		clientIP := ip.GetClientIP(c)
 
@@ -29,6 +29,8 @@ const (
 
 	opsUpstreamModelKey = "ops_upstream_model"
 	opsRequestTypeKey   = "ops_request_type"
+	// opsGuardrailBlockedKey 请求被分组安全策略拦截（错误日志归入 guardrail 阶段）
+	opsGuardrailBlockedKey = "ops_guardrail_blocked"
 
 	// 错误过滤匹配常量 — shouldSkipOpsErrorLog 和错误分类共用
 	opsErrContextCanceled            = "context canceled"
@@ -376,6 +378,13 @@ func attachOpsRequestBodyToEntry(c *gin.Context, entry *service.OpsInsertErrorLo
 	opsErrorLogSanitized.Add(1)
 }
 
+// setOpsTimeToFirstToken 记录首 token 时间（ops 错误日志与 Prometheus 指标共用）
+func setOpsTimeToFirstToken(c *gin.Context, firstTokenMs *int) {
+	if firstTokenMs != nil {
+		service.SetOpsLatencyMs(c, service.OpsTimeToFirstTokenMsKey, int64(*firstTokenMs))
+	}
+}
+
 func setOpsSelectedAccount(c *gin.Context, accountID int64, platform ...string) {
 	if c == nil || accountID <= 0 {
 		return
@@ -777,6 +786,11 @@ func OpsErrorLoggerMiddleware(ops *service.OpsService) gin.HandlerFunc {
 
 		phase := classifyOpsPhase(normalizedType, parsed.Message, parsed.Code)
 		isBusinessLimited := classifyOpsIsBusinessLimited(normalizedType, phase, parsed.Code, status, parsed.Message)
+		if blocked, _ := c.Get(opsGuardrailBlockedKey); blocked == true {
+			// 安全策略拦截属于策略性拒绝：单独归类，且不计入 SLA 错误率
+			phase = "guardrail"
+			isBusinessLimited = true
+		}
 
 		errorOwner := classifyOpsErrorOwner(phase, parsed.Message)
 		errorSource := classifyOpsErrorSource(phase, parsed.Message)
@@ -1200,7 +1214,7 @@ func classifyOpsErrorOwner(phase string, message string) string {
 	switch phase {
 	case "upstream", "network":
 		return "provider"
-	case "request", "auth":
+	case "request", "auth", "guardrail":
 		return "client"
 	case "routing", "internal":
 		return "platform"
@@ -1219,7 +1233,7 @@ func classifyOpsErrorSource(phase string, message string) string {
 		return "upstream_http"
 	case "network":
 		return "gateway"
-	case "request", "auth":
+	case "request", "auth", "guardrail":
 		return "client_request"
 	case "routing", "internal":
 		return "gateway"
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// payloadCaptureWriter 镜像写给客户端的响应体。
+// 是否抓取在首次写入时决定：此时 API Key 鉴权已完成，且响应头（Content-Type）已确定。
+type payloadCaptureWriter struct {
+	gin.ResponseWriter
+	c   *gin.Context
+	svc *service.PayloadCaptureService
+
+	decided  bool
+	rule     *service.PayloadCaptureRule
+	limit    int
+	buf      bytes.Buffer
+	total    int
+	overflow bool
+}
+
+func (w *payloadCaptureWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	apiKey, ok := middleware2.GetAPIKeyFromContext(w.c)
+	if !ok {
+		return
+	}
+	w.rule = w.svc.Match(apiKey)
+	if w.rule == nil {
+		return
+	}
+	stream := strings.HasPrefix(strings.ToLower(w.Header().Get("Content-Type")), "text/event-stream")
+	w.limit = w.svc.ResponseBufferLimit(w.rule, stream)
+}
+
+func (w *payloadCaptureWriter) capture(p []byte) {
+	w.decide()
+	if w.rule == nil {
+		return
+	}
+	w.total += len(p)
+	if w.overflow {
+		return
+	}
+	if remaining := w.limit - w.buf.Len(); len(p) > remaining {
+		// 保留前缀：非流式响应按文本脱敏，流式响应重组已收到的部分
+		_, _ = w.buf.Write(p[:remaining])
+		w.overflow = true
+		return
+	}
+	_, _ = w.buf.Write(p)
+}
+
+func (w *payloadCaptureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *payloadCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// PayloadCaptureMiddleware 按分组 / API Key 抓取规则抽样记录网关请求与响应载荷（脱敏后异步写入 ops_payload_captures）。
+//
+// 请求体取自 handler 通过 setOpsRequestContext 保存的原始请求；流式响应在写入时重组为最终消息。
+func PayloadCaptureMiddleware(svc *service.PayloadCaptureService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !svc.Active() || c.IsWebsocket() {
+			c.Next()
+			return
+		}
+
+		startedAt := time.Now()
+		originalWriter := c.Writer
+		w := &payloadCaptureWriter{ResponseWriter: originalWriter, c: c, svc: svc}
+		c.Writer = w
+		c.Next()
+		if c.Writer == w {
+			c.Writer = originalWriter
+		}
+
+		if w.rule == nil {
+			return
+		}
+		apiKey, _ := middleware2.GetAPIKeyFromContext(c)
+		capture := &service.PayloadCapture{
+			ClientRequestID: truncateString(clientRequestIDFromContext(c), 64),
+			UserID:          apiKey.UserID,
+			APIKeyID:        apiKey.ID,
+			GroupID:         apiKey.GroupID,
+			Platform:        truncateString(resolveOpsPlatform(apiKey, guessPlatformFromPath(c.Request.URL.Path)), 32),
+			Method:          c.Request.Method,
+			Path:            truncateString(c.Request.URL.Path, 255),
+			StatusCode:      w.Status(),
+			DurationMs:      time.Since(startedAt).Milliseconds(),
+			ResponseBytes:   w.total,
+		}
+		requestID := w.Header().Get("x-request-id")
+		if requestID == "" {
+			requestID = w.Header().Get("request-id")
+		}
+		capture.RequestID = truncateString(strings.TrimSpace(requestID), 128)
+		if v, ok := c.Get(opsModelKey); ok {
+			if s, ok := v.(string); ok {
+				capture.Model = truncateString(s, 128)
+			}
+		}
+		if v, ok := c.Get(opsStreamKey); ok {
+			if b, ok := v.(bool); ok {
+				capture.Stream = b
+			}
+		}
+		if v, ok := c.Get(opsAccountIDKey); ok {
+			if id, ok := v.(int64); ok && id > 0 {
+				capture.AccountID = &id
+			}
+		}
+		var requestBody []byte
+		if v, ok := c.Get(opsRequestBodyKey); ok {
+			requestBody, _ = v.([]byte)
+		}
+
+		svc.Submit(&service.PayloadCaptureJob{
+			Rule:                w.rule,
+			Capture:             capture,
+			RequestBody:         requestBody,
+			ResponseBody:        w.buf.Bytes(),
+			ResponseContentType: w.Header().Get("Content-Type"),
+			ResponseOverflow:    w.overflow,
+		})
+	}
+}
+
+func clientRequestIDFromContext(c *gin.Context) string {
+	id, _ := c.Request.Context().Value(ctxkey.ClientRequestID).(string)
+	return strings.TrimSpace(id)
+}
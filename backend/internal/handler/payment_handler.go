@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// paymentNotifyMaxBodyBytes 支付回调请求体上限
+const paymentNotifyMaxBodyBytes = 64 << 10
+
+// PaymentHandler handles self-service top-up requests
+type PaymentHandler struct {
+	paymentService *service.PaymentService
+}
+
+// NewPaymentHandler creates a new PaymentHandler
+func NewPaymentHandler(paymentService *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
+}
+
+// CreatePaymentOrderRequest represents the create order payload
+type CreatePaymentOrderRequest struct {
+	Provider string  `json:"provider" binding:"required"`
+	PayType  string  `json:"pay_type"`
+	Kind     string  `json:"kind" binding:"required,oneof=balance subscription"`
+	Amount   float64 `json:"amount"`
+	GroupID  int64   `json:"group_id"`
+}
+
+type paymentProviderOptionResponse struct {
+	Name     string   `json:"name"`
+	PayTypes []string `json:"pay_types,omitempty"`
+}
+
+type paymentPlanResponse struct {
+	GroupID      int64   `json:"group_id"`
+	GroupName    string  `json:"group_name"`
+	Price        float64 `json:"price"`
+	ValidityDays int     `json:"validity_days"`
+}
+
+type paymentOptionsResponse struct {
+	Enabled     bool                            `json:"enabled"`
+	Currency    string                          `json:"currency"`
+	MinAmount   float64                         `json:"min_amount"`
+	MaxAmount   float64                         `json:"max_amount"`
+	BalanceRate float64                         `json:"balance_rate"`
+	Providers   []paymentProviderOptionResponse `json:"providers"`
+	Plans       []paymentPlanResponse           `json:"plans"`
+}
+
+// GetOptions returns available payment providers and subscription plans
+// GET /api/v1/payment/options
+func (h *PaymentHandler) GetOptions(c *gin.Context) {
+	opts, err := h.paymentService.GetOptions(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	out := paymentOptionsResponse{
+		Enabled:     opts.Enabled,
+		Currency:    opts.Currency,
+		MinAmount:   opts.MinAmount,
+		MaxAmount:   opts.MaxAmount,
+		BalanceRate: opts.BalanceRate,
+		Providers:   make([]paymentProviderOptionResponse, 0, len(opts.Providers)),
+		Plans:       make([]paymentPlanResponse, 0, len(opts.Plans)),
+	}
+	for _, p := range opts.Providers {
+		out.Providers = append(out.Providers, paymentProviderOptionResponse{Name: p.Name, PayTypes: p.PayTypes})
+	}
+	for _, p := range opts.Plans {
+		out.Plans = append(out.Plans, paymentPlanResponse{
+			GroupID:      p.GroupID,
+			GroupName:    p.GroupName,
+			Price:        p.Price,
+			ValidityDays: p.ValidityDays,
+		})
+	}
+	response.Success(c, out)
+}
+
+// CreateOrder creates a payment order and returns its pay_url
+// POST /api/v1/payment/orders
+func (h *PaymentHandler) CreateOrder(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	var req CreatePaymentOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	clientIP := ip.GetClientIP(c)
+	executeUserIdempotentJSON(c, "user.payment.orders.create", req, service.DefaultWriteIdempotencyTTL(), func(ctx context.Context) (any, error) {
+		order, err := h.paymentService.CreateOrder(ctx, &service.CreatePaymentOrderInput{
+			UserID:   subject.UserID,
+			Provider: req.Provider,
+			PayType:  req.PayType,
+			Kind:     req.Kind,
+			Amount:   req.Amount,
+			GroupID:  req.GroupID,
+			ClientIP: clientIP,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return dto.PaymentOrderFromService(order), nil
+	})
+}
+
+// ListOrders returns the current user's payment orders
+// GET /api/v1/payment/orders
+func (h *PaymentHandler) ListOrders(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	params := pagination.PaginationParams{Page: page, PageSize: pageSize}
+	orders, result, err := h.paymentService.ListUserOrders(c.Request.Context(), subject.UserID, params, c.Query("status"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.PaymentOrder, 0, len(orders))
+	for i := range orders {
+		out = append(out, *dto.PaymentOrderFromService(&orders[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// GetOrder returns a payment order of the current user (used for polling after payment)
+// GET /api/v1/payment/orders/:order_no
+func (h *PaymentHandler) GetOrder(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	order, err := h.paymentService.GetUserOrder(c.Request.Context(), subject.UserID, c.Param("order_no"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.PaymentOrderFromService(order))
+}
+
+// CancelOrder cancels a pending payment order of the current user
+// POST /api/v1/payment/orders/:order_no/cancel
+func (h *PaymentHandler) CancelOrder(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not authenticated")
+		return
+	}
+
+	order, err := h.paymentService.CancelUserOrder(c.Request.Context(), subject.UserID, c.Param("order_no"))
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.PaymentOrderFromService(order))
+}
+
+// Notify handles asynchronous payment notifications from providers (public, signature verified)
+// GET/POST /api/v1/payment/notify/:provider
+func (h *PaymentHandler) Notify(c *gin.Context) {
+	provider := c.Param("provider")
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, paymentNotifyMaxBodyBytes))
+	if err != nil {
+		response.BadRequest(c, "Failed to read request body")
+		return
+	}
+
+	ack, err := h.paymentService.HandleNotification(c.Request.Context(), provider, &service.PaymentNotifyRequest{
+		Method: c.Request.Method,
+		Header: c.Request.Header,
+		Query:  c.Request.URL.Query(),
+		Body:   body,
+	})
+	if err != nil {
+		log.Printf("[Payment] notification from %s failed: %v", provider, err)
+		response.ErrorFrom(c, err)
+		return
+	}
+	c.String(http.StatusOK, ack)
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ReferralHandler handles the current user's referral code and earnings
+type ReferralHandler struct {
+	referralService *service.ReferralService
+}
+
+// NewReferralHandler creates a new ReferralHandler
+func NewReferralHandler(referralService *service.ReferralService) *ReferralHandler {
+	return &ReferralHandler{referralService: referralService}
+}
+
+// GetOverview returns the current user's referral code, commission rules and earnings summary
+// GET /api/v1/referral
+func (h *ReferralHandler) GetOverview(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	overview, err := h.referralService.GetOverview(c.Request.Context(), subject.UserID)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.ReferralOverviewFromService(overview))
+}
+
+// ListInvitees returns users invited by the current user
+// GET /api/v1/referral/invitees
+func (h *ReferralHandler) ListInvitees(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	referrals, result, err := h.referralService.ListInvitees(c.Request.Context(), subject.UserID, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.ReferralInvitee, 0, len(referrals))
+	for i := range referrals {
+		out = append(out, *dto.ReferralInviteeFromService(&referrals[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
+
+// ListCommissions returns commissions credited to the current user
+// GET /api/v1/referral/commissions
+func (h *ReferralHandler) ListCommissions(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	commissions, result, err := h.referralService.ListUserCommissions(c.Request.Context(), subject.UserID, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.ReferralCommission, 0, len(commissions))
+	for i := range commissions {
+		out = append(out, *dto.ReferralCommissionFromService(&commissions[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
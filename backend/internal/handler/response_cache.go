@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// responseCaptureWriter 在转发期间镜像写给客户端的响应体，成功后写入响应缓存。
+// 超出 limit 后停止镜像并标记 overflow，该响应不会被缓存。
+type responseCaptureWriter struct {
+	gin.ResponseWriter
+	limit    int
+	buf      bytes.Buffer
+	overflow bool
+}
+
+// beginResponseCapture 用捕获 writer 替换 c.Writer；limit <= 0 时不捕获并返回 nil
+func beginResponseCapture(c *gin.Context, limit int) *responseCaptureWriter {
+	if limit <= 0 {
+		return nil
+	}
+	w := &responseCaptureWriter{ResponseWriter: c.Writer, limit: limit}
+	c.Writer = w
+	return w
+}
+
+// reset 丢弃上一次转发尝试镜像的内容（failover 重试前调用）
+func (w *responseCaptureWriter) reset() {
+	if w == nil {
+		return
+	}
+	w.buf.Reset()
+	w.overflow = false
+}
+
+func (w *responseCaptureWriter) capture(n int) bool {
+	if w.overflow {
+		return false
+	}
+	if w.buf.Len()+n > w.limit {
+		w.overflow = true
+		w.buf.Reset()
+		return false
+	}
+	return true
+}
+
+func (w *responseCaptureWriter) Write(b []byte) (int, error) {
+	if w.capture(len(b)) {
+		_, _ = w.buf.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseCaptureWriter) WriteString(s string) (int, error) {
+	if w.capture(len(s)) {
+		_, _ = w.buf.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// cacheableBody 返回可缓存的完整响应体；非 200、超限或客户端已断开时返回 nil
+func (w *responseCaptureWriter) cacheableBody(c *gin.Context) []byte {
+	if w == nil || w.overflow || w.buf.Len() == 0 || w.Status() != http.StatusOK {
+		return nil
+	}
+	if c.Request.Context().Err() != nil {
+		return nil
+	}
+	return bytes.Clone(w.buf.Bytes())
+}
+
+// contentType 返回转发时写给客户端的 Content-Type
+func (w *responseCaptureWriter) contentType() string {
+	return w.Header().Get("Content-Type")
+}
+
+// replayResponseCacheEntry 原样回放缓存的响应（SSE 或 JSON）
+func replayResponseCacheEntry(c *gin.Context, entry *service.ResponseCacheEntry) {
+	c.Header("X-Response-Cache", "hit")
+	if strings.HasPrefix(entry.ContentType, "text/event-stream") {
+		c.Header("Cache-Control", "no-cache")
+		c.Header("X-Accel-Buffering", "no")
+	}
+	c.Data(entry.StatusCode, entry.ContentType, entry.Body)
+}
+
+// serveResponseCacheHit 回放 Messages 缓存响应，并以缓存命中计费方式记录用量。
+// 缓存在获取用户并发槽位之前查询，命中时在此校验余额/订阅。
+func (h *GatewayHandler) serveResponseCacheHit(
+	c *gin.Context,
+	entry *service.ResponseCacheEntry,
+	apiKey *service.APIKey,
+	subscription *service.UserSubscription,
+	body []byte,
+	reqModel string,
+	reqStream bool,
+	channelMapping service.ChannelMappingResult,
+	startedAt time.Time,
+) {
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.handleStreamingAwareError(c, status, code, message, false)
+		return
+	}
+	replayResponseCacheEntry(c, entry)
+
+	result := entry.ForwardResult(reqStream, time.Since(startedAt))
+	account := entry.Account()
+	userAgent := c.GetHeader("User-Agent")
+	clientIP := ip.GetClientIP(c)
+	requestPayloadHash := service.HashUsageRequestPayload(body)
+	inboundEndpoint := GetInboundEndpoint(c)
+
+	h.submitUsageRecordTask(func(ctx context.Context) {
+		if err := h.gatewayService.RecordUsage(ctx, &service.RecordUsageInput{
+			Result:             result,
+			APIKey:             apiKey,
+			User:               apiKey.User,
+			Account:            account,
+			Subscription:       subscription,
+			InboundEndpoint:    inboundEndpoint,
+			UserAgent:          userAgent,
+			IPAddress:          clientIP,
+			RequestPayloadHash: requestPayloadHash,
+			APIKeyService:      h.apiKeyService,
+			ResponseCacheHit:   true,
+			ChannelUsageFields: channelMapping.ToUsageFields(reqModel, result.UpstreamModel),
+		}); err != nil {
+			logger.L().With(
+				zap.String("component", "handler.gateway.messages"),
+				zap.Int64("api_key_id", apiKey.ID),
+				zap.Any("group_id", apiKey.GroupID),
+				zap.String("model", reqModel),
+			).Error("gateway.record_response_cache_usage_failed", zap.Error(err))
+		}
+	})
+}
+
+// serveResponseCacheHit 回放 Chat Completions / Responses 缓存响应，并以缓存命中计费方式记录用量。
+// 缓存在获取用户并发槽位之前查询，命中时在此校验余额/订阅。
+func (h *OpenAIGatewayHandler) serveResponseCacheHit(
+	c *gin.Context,
+	entry *service.ResponseCacheEntry,
+	apiKey *service.APIKey,
+	subscription *service.UserSubscription,
+	body []byte,
+	reqModel string,
+	reqStream bool,
+	channelMapping service.ChannelMappingResult,
+	startedAt time.Time,
+) {
+	if err := h.billingCacheService.CheckBillingEligibility(c.Request.Context(), apiKey.User, apiKey, apiKey.Group, subscription); err != nil {
+		status, code, message := billingErrorDetails(err)
+		h.handleStreamingAwareError(c, status, code, message, false)
+		return
+	}
+	replayResponseCacheEntry(c, entry)
+
+	result := entry.OpenAIForwardResult(reqStream, time.Since(startedAt))
+	account := entry.Account()
+	userAgent := c.GetHeader("User-Agent")
+	clientIP := ip.GetClientIP(c)
+	requestPayloadHash := service.HashUsageRequestPayload(body)
+	inboundEndpoint := GetInboundEndpoint(c)
+
+	h.submitUsageRecordTask(func(ctx context.Context) {
+		if err := h.gatewayService.RecordUsage(ctx, &service.OpenAIRecordUsageInput{
+			Result:             result,
+			APIKey:             apiKey,
+			User:               apiKey.User,
+			Account:            account,
+			Subscription:       subscription,
+			InboundEndpoint:    inboundEndpoint,
+			UserAgent:          userAgent,
+			IPAddress:          clientIP,
+			RequestPayloadHash: requestPayloadHash,
+			APIKeyService:      h.apiKeyService,
+			ResponseCacheHit:   true,
+			ChannelUsageFields: channelMapping.ToUsageFields(reqModel, result.UpstreamModel),
+		}); err != nil {
+			logger.L().With(
+				zap.String("component", "handler.openai_gateway"),
+				zap.Int64("api_key_id", apiKey.ID),
+				zap.Any("group_id", apiKey.GroupID),
+				zap.String("model", reqModel),
+			).Error("openai.record_response_cache_usage_failed", zap.Error(err))
+		}
+	})
+}
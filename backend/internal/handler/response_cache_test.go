@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCaptureWriter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("captures successful response", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+
+		w := beginResponseCapture(c, 64)
+		c.Header("Content-Type", "text/event-stream")
+		_, _ = c.Writer.WriteString("event: ping\n\n")
+		_, _ = c.Writer.Write([]byte("data: {}\n\n"))
+
+		require.Equal(t, "event: ping\n\ndata: {}\n\n", string(w.cacheableBody(c)))
+		require.Equal(t, "text/event-stream", w.contentType())
+		require.Equal(t, "event: ping\n\ndata: {}\n\n", rec.Body.String())
+	})
+
+	t.Run("overflow is not cacheable", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+
+		w := beginResponseCapture(c, 4)
+		_, _ = c.Writer.WriteString("toolong")
+		require.Nil(t, w.cacheableBody(c))
+
+		w.reset()
+		_, _ = c.Writer.WriteString("ok")
+		require.Equal(t, "ok", string(w.cacheableBody(c)))
+	})
+
+	t.Run("error status is not cacheable", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+
+		w := beginResponseCapture(c, 64)
+		c.Status(http.StatusBadRequest)
+		_, _ = c.Writer.WriteString(`{"error":{}}`)
+		require.Nil(t, w.cacheableBody(c))
+	})
+
+	t.Run("client disconnect is not cacheable", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx, cancel := context.WithCancel(context.Background())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil).WithContext(ctx)
+
+		w := beginResponseCapture(c, 64)
+		_, _ = c.Writer.WriteString("partial")
+		cancel()
+		require.Nil(t, w.cacheableBody(c))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		w := beginResponseCapture(c, 0)
+		require.Nil(t, w)
+		w.reset()
+		require.Nil(t, w.cacheableBody(c))
+	})
+}
+
+func TestReplayResponseCacheEntry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+
+	replayResponseCacheEntry(c, &service.ResponseCacheEntry{
+		StatusCode:  http.StatusOK,
+		ContentType: "text/event-stream",
+		Body:        []byte("data: [DONE]\n\n"),
+	})
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	require.Equal(t, "hit", rec.Header().Get("X-Response-Cache"))
+	require.Equal(t, "data: [DONE]\n\n", rec.Body.String())
+}
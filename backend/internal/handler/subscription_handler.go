@@ -1,7 +1,11 @@
 package handler
 
 import (
+	"context"
+	"strconv"
+
 	"github.com/Wei-Shaw/sub2api/internal/handler/dto"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
 	middleware2 "github.com/Wei-Shaw/sub2api/internal/server/middleware"
 	"github.com/Wei-Shaw/sub2api/internal/service"
@@ -33,15 +37,28 @@ type SubscriptionProgressInfo struct {
 // SubscriptionHandler handles user subscription operations
 type SubscriptionHandler struct {
 	subscriptionService *service.SubscriptionService
+	purchaseService     *service.SubscriptionPurchaseService
 }
 
 // NewSubscriptionHandler creates a new user subscription handler
-func NewSubscriptionHandler(subscriptionService *service.SubscriptionService) *SubscriptionHandler {
+func NewSubscriptionHandler(subscriptionService *service.SubscriptionService, purchaseService *service.SubscriptionPurchaseService) *SubscriptionHandler {
 	return &SubscriptionHandler{
 		subscriptionService: subscriptionService,
+		purchaseService:     purchaseService,
 	}
 }
 
+// PurchaseSubscriptionRequest represents a balance-funded subscription purchase
+type PurchaseSubscriptionRequest struct {
+	GroupID   int64 `json:"group_id" binding:"required"`
+	AutoRenew bool  `json:"auto_renew"`
+}
+
+// UpdateAutoRenewRequest toggles auto-renewal of a subscription
+type UpdateAutoRenewRequest struct {
+	AutoRenew *bool `json:"auto_renew" binding:"required"`
+}
+
 // List handles listing current user's subscriptions
 // GET /api/v1/subscriptions
 func (h *SubscriptionHandler) List(c *gin.Context) {
@@ -186,3 +203,95 @@ func (h *SubscriptionHandler) GetSummary(c *gin.Context) {
 
 	response.Success(c, summary)
 }
+
+// ListPlans returns subscription plans purchasable with balance
+// GET /api/v1/subscriptions/plans
+func (h *SubscriptionHandler) ListPlans(c *gin.Context) {
+	plans, err := h.purchaseService.ListPlans(c.Request.Context())
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.SubscriptionPlan, 0, len(plans))
+	for i := range plans {
+		out = append(out, *dto.SubscriptionPlanFromService(&plans[i]))
+	}
+	response.Success(c, out)
+}
+
+// Purchase buys or extends a subscription with the current user's balance
+// POST /api/v1/subscriptions/purchase
+func (h *SubscriptionHandler) Purchase(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	var req PurchaseSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	executeUserIdempotentJSON(c, "user.subscriptions.purchase", req, service.DefaultWriteIdempotencyTTL(), func(ctx context.Context) (any, error) {
+		purchase, err := h.purchaseService.Purchase(ctx, subject.UserID, req.GroupID, req.AutoRenew)
+		if err != nil {
+			return nil, err
+		}
+		return dto.SubscriptionPurchaseFromService(purchase), nil
+	})
+}
+
+// UpdateAutoRenew toggles auto-renewal of the current user's subscription
+// PUT /api/v1/subscriptions/:id/auto-renew
+func (h *SubscriptionHandler) UpdateAutoRenew(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	subscriptionID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		response.BadRequest(c, "Invalid subscription ID")
+		return
+	}
+
+	var req UpdateAutoRenewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request: "+err.Error())
+		return
+	}
+
+	sub, err := h.purchaseService.SetAutoRenew(c.Request.Context(), subject.UserID, subscriptionID, *req.AutoRenew)
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+	response.Success(c, dto.UserSubscriptionFromService(sub))
+}
+
+// ListPurchases returns the current user's subscription purchase history
+// GET /api/v1/subscriptions/purchases
+func (h *SubscriptionHandler) ListPurchases(c *gin.Context) {
+	subject, ok := middleware2.GetAuthSubjectFromContext(c)
+	if !ok {
+		response.Unauthorized(c, "User not found in context")
+		return
+	}
+
+	page, pageSize := response.ParsePagination(c)
+	purchases, result, err := h.purchaseService.ListUserPurchases(c.Request.Context(), subject.UserID, pagination.PaginationParams{Page: page, PageSize: pageSize})
+	if err != nil {
+		response.ErrorFrom(c, err)
+		return
+	}
+
+	out := make([]dto.SubscriptionPurchase, 0, len(purchases))
+	for i := range purchases {
+		out = append(out, *dto.SubscriptionPurchaseFromService(&purchases[i]))
+	}
+	response.Paginated(c, out, result.Total, page, pageSize)
+}
@@ -34,6 +34,16 @@ func ProvideAdminHandlers(
 	apiKeyHandler *admin.AdminAPIKeyHandler,
 	scheduledTestHandler *admin.ScheduledTestHandler,
 	channelHandler *admin.ChannelHandler,
+	notificationChannelHandler *admin.NotificationChannelHandler,
+	oidcProviderHandler *admin.OIDCProviderHandler,
+	paymentOrderHandler *admin.PaymentOrderHandler,
+	auditLogHandler *admin.AuditLogHandler,
+	adminTokenHandler *admin.AdminTokenHandler,
+	payloadCaptureHandler *admin.PayloadCaptureHandler,
+	guardrailHandler *admin.GuardrailHandler,
+	proxyPoolHandler *admin.ProxyPoolHandler,
+	credentialEncryptionHandler *admin.CredentialEncryptionHandler,
+	referralHandler *admin.ReferralHandler,
 ) *AdminHandlers {
 	return &AdminHandlers{
 		Dashboard:             dashboardHandler,
@@ -61,6 +71,16 @@ func ProvideAdminHandlers(
 		APIKey:                apiKeyHandler,
 		ScheduledTest:         scheduledTestHandler,
 		Channel:               channelHandler,
+		NotificationChannel:   notificationChannelHandler,
+		OIDCProvider:          oidcProviderHandler,
+		PaymentOrder:          paymentOrderHandler,
+		AuditLog:              auditLogHandler,
+		AdminToken:            adminTokenHandler,
+		PayloadCapture:        payloadCaptureHandler,
+		Guardrail:             guardrailHandler,
+		ProxyPool:             proxyPoolHandler,
+		CredentialEncryption:  credentialEncryptionHandler,
+		Referral:              referralHandler,
 	}
 }
 
@@ -86,8 +106,12 @@ func ProvideHandlers(
 	adminHandlers *AdminHandlers,
 	gatewayHandler *GatewayHandler,
 	openaiGatewayHandler *OpenAIGatewayHandler,
+	messageBatchHandler *MessageBatchHandler,
+	openAIBatchHandler *OpenAIBatchHandler,
 	settingHandler *SettingHandler,
 	totpHandler *TotpHandler,
+	paymentHandler *PaymentHandler,
+	referralHandler *ReferralHandler,
 	_ *service.IdempotencyCoordinator,
 	_ *service.IdempotencyCleanupService,
 ) *Handlers {
@@ -102,8 +126,12 @@ func ProvideHandlers(
 		Admin:         adminHandlers,
 		Gateway:       gatewayHandler,
 		OpenAIGateway: openaiGatewayHandler,
+		MessageBatch:  messageBatchHandler,
+		OpenAIBatch:   openAIBatchHandler,
 		Setting:       settingHandler,
 		Totp:          totpHandler,
+		Payment:       paymentHandler,
+		Referral:      referralHandler,
 	}
 }
 
@@ -119,7 +147,11 @@ var ProviderSet = wire.NewSet(
 	NewAnnouncementHandler,
 	NewGatewayHandler,
 	NewOpenAIGatewayHandler,
+	NewMessageBatchHandler,
+	NewOpenAIBatchHandler,
 	NewTotpHandler,
+	NewPaymentHandler,
+	NewReferralHandler,
 	ProvideSettingHandler,
 
 	// Admin handlers
@@ -148,6 +180,16 @@ var ProviderSet = wire.NewSet(
 	admin.NewAdminAPIKeyHandler,
 	admin.NewScheduledTestHandler,
 	admin.NewChannelHandler,
+	admin.NewNotificationChannelHandler,
+	admin.NewOIDCProviderHandler,
+	admin.NewPaymentOrderHandler,
+	admin.NewAuditLogHandler,
+	admin.NewAdminTokenHandler,
+	admin.NewPayloadCaptureHandler,
+	admin.NewGuardrailHandler,
+	admin.NewProxyPoolHandler,
+	admin.NewCredentialEncryptionHandler,
+	admin.NewReferralHandler,
 
 	// AdminHandlers and Handlers constructors
 	ProvideAdminHandlers,
@@ -0,0 +1,250 @@
+package apicompat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ---------------------------------------------------------------------------
+// AnthropicToChatCompletions tests
+// ---------------------------------------------------------------------------
+
+func TestAnthropicToChatCompletions_BasicText(t *testing.T) {
+	temp := 0.2
+	req := &AnthropicRequest{
+		Model:       "deepseek-chat",
+		MaxTokens:   1024,
+		Stream:      true,
+		Temperature: &temp,
+		StopSeqs:    []string{"END"},
+		System:      json.RawMessage(`[{"type":"text","text":"You are helpful."},{"type":"text","text":"Be brief."}]`),
+		Messages: []AnthropicMessage{
+			{Role: "user", Content: json.RawMessage(`[{"type":"text","text":"Hello"},{"type":"text","text":"there"}]`)},
+		},
+	}
+
+	out, err := AnthropicToChatCompletions(req)
+	require.NoError(t, err)
+	assert.Equal(t, "deepseek-chat", out.Model)
+	assert.True(t, out.Stream)
+	require.NotNil(t, out.StreamOptions)
+	assert.True(t, out.StreamOptions.IncludeUsage)
+	require.NotNil(t, out.MaxTokens)
+	assert.Equal(t, 1024, *out.MaxTokens)
+	assert.Equal(t, &temp, out.Temperature)
+	assert.JSONEq(t, `["END"]`, string(out.Stop))
+
+	require.Len(t, out.Messages, 2)
+	assert.Equal(t, "system", out.Messages[0].Role)
+	assert.JSONEq(t, `"You are helpful.\n\nBe brief."`, string(out.Messages[0].Content))
+	assert.Equal(t, "user", out.Messages[1].Role)
+	// Text-only content is flattened to a plain string.
+	assert.JSONEq(t, `"Hello\n\nthere"`, string(out.Messages[1].Content))
+}
+
+func TestAnthropicToChatCompletions_ToolRoundTrip(t *testing.T) {
+	req := &AnthropicRequest{
+		Model:     "qwen",
+		MaxTokens: 256,
+		Tools: []AnthropicTool{
+			{Name: "get_weather", Description: "Weather", InputSchema: json.RawMessage(`{"type":"object"}`)},
+			{Type: "web_search_20250305", Name: "web_search"},
+		},
+		ToolChoice: json.RawMessage(`{"type":"tool","name":"get_weather"}`),
+		Messages: []AnthropicMessage{
+			{Role: "user", Content: json.RawMessage(`"Weather in Paris?"`)},
+			{Role: "assistant", Content: json.RawMessage(`[
+				{"type":"thinking","thinking":"let me check","signature":"sig"},
+				{"type":"text","text":"Checking."},
+				{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{"city":"Paris"}}
+			]`)},
+			{Role: "user", Content: json.RawMessage(`[
+				{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"text","text":"Sunny"},{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAAA"}}]},
+				{"type":"text","text":"Thanks"}
+			]`)},
+		},
+	}
+
+	out, err := AnthropicToChatCompletions(req)
+	require.NoError(t, err)
+	assert.Nil(t, out.StreamOptions)
+
+	require.Len(t, out.Tools, 1)
+	assert.Equal(t, "function", out.Tools[0].Type)
+	assert.Equal(t, "get_weather", out.Tools[0].Function.Name)
+	assert.JSONEq(t, `{"type":"object","properties":{}}`, string(out.Tools[0].Function.Parameters))
+	assert.JSONEq(t, `{"type":"function","function":{"name":"get_weather"}}`, string(out.ToolChoice))
+
+	require.Len(t, out.Messages, 4)
+	assistant := out.Messages[1]
+	assert.Equal(t, "assistant", assistant.Role)
+	assert.JSONEq(t, `"Checking."`, string(assistant.Content))
+	assert.Empty(t, assistant.ReasoningContent)
+	require.Len(t, assistant.ToolCalls, 1)
+	assert.Equal(t, "toolu_1", assistant.ToolCalls[0].ID)
+	assert.JSONEq(t, `{"city":"Paris"}`, assistant.ToolCalls[0].Function.Arguments)
+
+	tool := out.Messages[2]
+	assert.Equal(t, "tool", tool.Role)
+	assert.Equal(t, "toolu_1", tool.ToolCallID)
+	assert.JSONEq(t, `"Sunny"`, string(tool.Content))
+
+	// Images from tool results ride along with the following user message.
+	var parts []ChatContentPart
+	require.NoError(t, json.Unmarshal(out.Messages[3].Content, &parts))
+	require.Len(t, parts, 2)
+	assert.Equal(t, "Thanks", parts[0].Text)
+	assert.Equal(t, "data:image/png;base64,AAAA", parts[1].ImageURL.URL)
+}
+
+func TestAnthropicToChatCompletions_ToolChoiceDroppedWithoutTools(t *testing.T) {
+	req := &AnthropicRequest{
+		Model:      "m",
+		Tools:      []AnthropicTool{{Type: "bash_20250124", Name: "bash"}},
+		ToolChoice: json.RawMessage(`{"type":"any"}`),
+		Messages:   []AnthropicMessage{{Role: "user", Content: json.RawMessage(`"hi"`)}},
+	}
+	out, err := AnthropicToChatCompletions(req)
+	require.NoError(t, err)
+	assert.Empty(t, out.Tools)
+	assert.Empty(t, out.ToolChoice)
+}
+
+// ---------------------------------------------------------------------------
+// ChatCompletionsToAnthropic tests
+// ---------------------------------------------------------------------------
+
+func TestChatCompletionsToAnthropic_TextAndTools(t *testing.T) {
+	resp := &ChatCompletionsResponse{
+		ID: "chatcmpl-1",
+		Choices: []ChatChoice{{
+			Message: ChatMessage{
+				Role:             "assistant",
+				Content:          json.RawMessage(`"Let me look."`),
+				ReasoningContent: "thinking...",
+				ToolCalls: []ChatToolCall{
+					{ID: "call_a", Type: "function", Function: ChatFunctionCall{Name: "lookup", Arguments: `{"q":"x"}`}},
+					{Type: "function", Function: ChatFunctionCall{Name: "broken", Arguments: `{"q":`}},
+				},
+			},
+			FinishReason: "tool_calls",
+		}},
+		Usage: &ChatUsage{PromptTokens: 100, CompletionTokens: 20, PromptTokensDetails: &ChatTokenDetails{CachedTokens: 60}},
+	}
+
+	out := ChatCompletionsToAnthropic(resp, "claude-sonnet-4-5")
+	assert.Equal(t, "chatcmpl-1", out.ID)
+	assert.Equal(t, "claude-sonnet-4-5", out.Model)
+	assert.Equal(t, "tool_use", out.StopReason)
+	require.Len(t, out.Content, 4)
+	assert.Equal(t, "thinking", out.Content[0].Type)
+	assert.Equal(t, "text", out.Content[1].Type)
+	assert.Equal(t, "call_a", out.Content[2].ID)
+	assert.JSONEq(t, `{"q":"x"}`, string(out.Content[2].Input))
+	assert.Equal(t, "call_chatcmpl-1_1", out.Content[3].ID)
+	assert.JSONEq(t, `{}`, string(out.Content[3].Input))
+	assert.Equal(t, AnthropicUsage{InputTokens: 40, OutputTokens: 20, CacheReadInputTokens: 60}, out.Usage)
+}
+
+func TestChatUsageToAnthropic_DeepSeekCacheHit(t *testing.T) {
+	usage := ChatUsageToAnthropic(&ChatUsage{PromptTokens: 50, CompletionTokens: 5, PromptCacheHitTokens: 30})
+	assert.Equal(t, AnthropicUsage{InputTokens: 20, OutputTokens: 5, CacheReadInputTokens: 30}, usage)
+	assert.Equal(t, AnthropicUsage{}, ChatUsageToAnthropic(nil))
+}
+
+func TestChatFinishReasonToAnthropic(t *testing.T) {
+	assert.Equal(t, "end_turn", chatFinishReasonToAnthropic("stop", false))
+	assert.Equal(t, "max_tokens", chatFinishReasonToAnthropic("length", false))
+	assert.Equal(t, "tool_use", chatFinishReasonToAnthropic("tool_calls", false))
+	assert.Equal(t, "refusal", chatFinishReasonToAnthropic("content_filter", false))
+	assert.Equal(t, "tool_use", chatFinishReasonToAnthropic("", true))
+}
+
+// ---------------------------------------------------------------------------
+// Streaming tests
+// ---------------------------------------------------------------------------
+
+func chatChunk(t *testing.T, raw string) *ChatCompletionsChunk {
+	t.Helper()
+	var chunk ChatCompletionsChunk
+	require.NoError(t, json.Unmarshal([]byte(raw), &chunk))
+	return &chunk
+}
+
+func TestChatCompletionsChunkToAnthropicEvents_Stream(t *testing.T) {
+	state := NewChatCompletionsToAnthropicState()
+	state.Model = "claude-sonnet-4-5"
+
+	var events []AnthropicStreamEvent
+	for _, raw := range []string{
+		`{"id":"c1","model":"deepseek-reasoner","choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"hmm"}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"content":"Hi"}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"content":" there"}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"run","arguments":""}}]}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"a\":"}}]}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"1}"}}]}}]}`,
+		`{"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+		`{"id":"c1","choices":[],"usage":{"prompt_tokens":12,"completion_tokens":7,"total_tokens":19}}`,
+	} {
+		events = append(events, ChatCompletionsChunkToAnthropicEvents(chatChunk(t, raw), state)...)
+	}
+	events = append(events, FinalizeChatCompletionsAnthropicStream(state)...)
+
+	types := make([]string, 0, len(events))
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	assert.Equal(t, []string{
+		"message_start",
+		"content_block_start", "content_block_delta", // thinking
+		"content_block_stop", "content_block_start", "content_block_delta", "content_block_delta", // text
+		"content_block_stop", "content_block_start", "content_block_delta", "content_block_delta", // tool_use
+		"content_block_stop",
+		"message_delta", "message_stop",
+	}, types)
+
+	assert.Equal(t, "claude-sonnet-4-5", events[0].Message.Model)
+	assert.Equal(t, "c1", events[0].Message.ID)
+	assert.Equal(t, "thinking", events[1].ContentBlock.Type)
+	assert.Equal(t, "text", events[4].ContentBlock.Type)
+	assert.Equal(t, 1, *events[4].Index)
+	assert.Equal(t, "tool_use", events[8].ContentBlock.Type)
+	assert.Equal(t, "call_1", events[8].ContentBlock.ID)
+	assert.Equal(t, 2, *events[9].Index)
+	assert.Equal(t, `{"a":`, events[9].Delta.PartialJSON)
+
+	msgDelta := events[len(events)-2]
+	assert.Equal(t, "tool_use", msgDelta.Delta.StopReason)
+	assert.Equal(t, 12, msgDelta.Usage.InputTokens)
+	assert.Equal(t, 7, msgDelta.Usage.OutputTokens)
+
+	assert.Nil(t, FinalizeChatCompletionsAnthropicStream(state))
+}
+
+func TestChatCompletionsChunkToAnthropicEvents_ParallelToolCalls(t *testing.T) {
+	state := NewChatCompletionsToAnthropicState()
+	var events []AnthropicStreamEvent
+	for _, raw := range []string{
+		`{"id":"c2","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_a","function":{"name":"a","arguments":"{}"}}]}}]}`,
+		`{"id":"c2","choices":[{"index":0,"delta":{"tool_calls":[{"index":1,"id":"call_b","function":{"name":"b","arguments":"{}"}}]}}]}`,
+		`{"id":"c2","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"late"}}]}}]}`,
+	} {
+		events = append(events, ChatCompletionsChunkToAnthropicEvents(chatChunk(t, raw), state)...)
+	}
+	events = append(events, FinalizeChatCompletionsAnthropicStream(state)...)
+
+	var starts []string
+	for _, e := range events {
+		if e.Type == "content_block_start" {
+			starts = append(starts, e.ContentBlock.ID)
+		}
+		if e.Delta != nil {
+			assert.NotEqual(t, "late", e.Delta.PartialJSON)
+		}
+	}
+	assert.Equal(t, []string{"call_a", "call_b"}, starts)
+	assert.Equal(t, "tool_use", events[len(events)-2].Delta.StopReason)
+}
@@ -0,0 +1,233 @@
+package apicompat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AnthropicToChatCompletions converts an Anthropic Messages request directly
+// into a Chat Completions request for generic OpenAI-compatible upstreams
+// (vLLM, SGLang, DeepSeek, Moonshot, Qwen, ...).
+//
+// The output deliberately sticks to the widely supported subset of the Chat
+// Completions API: text-only content is sent as a plain string (several
+// vendors reject content-part arrays), thinking blocks are dropped, and
+// Anthropic server tools are skipped. Streaming requests always ask for a
+// trailing usage chunk so that token usage can be billed.
+func AnthropicToChatCompletions(req *AnthropicRequest) (*ChatCompletionsRequest, error) {
+	messages, err := convertAnthropicToChatMessages(req.System, req.Messages)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &ChatCompletionsRequest{
+		Model:       req.Model,
+		Messages:    messages,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Stream:      req.Stream,
+	}
+	if req.Stream {
+		out.StreamOptions = &ChatStreamOptions{IncludeUsage: true}
+	}
+
+	if req.MaxTokens > 0 {
+		v := req.MaxTokens
+		out.MaxTokens = &v
+	}
+
+	if len(req.StopSeqs) > 0 {
+		stop, err := json.Marshal(req.StopSeqs)
+		if err != nil {
+			return nil, err
+		}
+		out.Stop = stop
+	}
+
+	if len(req.Tools) > 0 {
+		out.Tools = convertAnthropicToolsToChat(req.Tools)
+	}
+
+	// tool_choice only makes sense when at least one function tool survived.
+	if len(req.ToolChoice) > 0 && len(out.Tools) > 0 {
+		tc, err := convertAnthropicToolChoiceToResponses(req.ToolChoice)
+		if err != nil {
+			return nil, fmt.Errorf("convert tool_choice: %w", err)
+		}
+		out.ToolChoice = tc
+	}
+
+	return out, nil
+}
+
+// convertAnthropicToChatMessages builds the Chat Completions messages array
+// from the Anthropic system field and message list.
+func convertAnthropicToChatMessages(system json.RawMessage, msgs []AnthropicMessage) ([]ChatMessage, error) {
+	var out []ChatMessage
+
+	if len(system) > 0 {
+		sysText, err := parseAnthropicSystemPrompt(system)
+		if err != nil {
+			return nil, err
+		}
+		if sysText != "" {
+			content, _ := json.Marshal(sysText)
+			out = append(out, ChatMessage{Role: "system", Content: content})
+		}
+	}
+
+	for _, m := range msgs {
+		var items []ChatMessage
+		var err error
+		if m.Role == "assistant" {
+			items, err = anthropicAssistantToChat(m.Content)
+		} else {
+			items, err = anthropicUserToChat(m.Content)
+		}
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, items...)
+	}
+	return out, nil
+}
+
+// anthropicUserToChat handles an Anthropic user message. tool_result blocks
+// become "tool" role messages (emitted first so they directly follow the
+// assistant tool_calls); remaining text and image blocks, plus images found
+// inside tool results, become a single user message.
+func anthropicUserToChat(raw json.RawMessage) ([]ChatMessage, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		content, _ := json.Marshal(s)
+		return []ChatMessage{{Role: "user", Content: content}}, nil
+	}
+
+	var blocks []AnthropicContentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, err
+	}
+
+	var out []ChatMessage
+	var parts []ChatContentPart
+	var imageParts []ChatContentPart
+
+	for _, b := range blocks {
+		if b.Type != "tool_result" {
+			continue
+		}
+		text, images := convertToolResultOutput(b)
+		if b.IsError {
+			text = "[error] " + text
+		}
+		content, _ := json.Marshal(text)
+		out = append(out, ChatMessage{
+			Role:       "tool",
+			ToolCallID: b.ToolUseID,
+			Content:    content,
+		})
+		for _, img := range images {
+			imageParts = append(imageParts, ChatContentPart{Type: "image_url", ImageURL: &ChatImageURL{URL: img.ImageURL}})
+		}
+	}
+
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			if b.Text != "" {
+				parts = append(parts, ChatContentPart{Type: "text", Text: b.Text})
+			}
+		case "image":
+			if uri := anthropicImageToDataURI(b.Source); uri != "" {
+				imageParts = append(imageParts, ChatContentPart{Type: "image_url", ImageURL: &ChatImageURL{URL: uri}})
+			}
+		}
+	}
+
+	if len(parts) == 0 && len(imageParts) == 0 {
+		return out, nil
+	}
+
+	var content json.RawMessage
+	var err error
+	if len(imageParts) == 0 {
+		texts := make([]string, 0, len(parts))
+		for _, p := range parts {
+			texts = append(texts, p.Text)
+		}
+		content, err = json.Marshal(strings.Join(texts, "\n\n"))
+	} else {
+		content, err = json.Marshal(append(parts, imageParts...))
+	}
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, ChatMessage{Role: "user", Content: content})
+	return out, nil
+}
+
+// anthropicAssistantToChat handles an Anthropic assistant message. Text blocks
+// are joined into content, tool_use blocks become tool_calls and thinking
+// blocks are dropped (OpenAI-compatible vendors generally reject prior
+// reasoning as input).
+func anthropicAssistantToChat(raw json.RawMessage) ([]ChatMessage, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		content, _ := json.Marshal(s)
+		return []ChatMessage{{Role: "assistant", Content: content}}, nil
+	}
+
+	var blocks []AnthropicContentBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return nil, err
+	}
+
+	msg := ChatMessage{Role: "assistant"}
+	if text := extractAnthropicTextFromBlocks(blocks); text != "" {
+		msg.Content, _ = json.Marshal(text)
+	}
+	for _, b := range blocks {
+		if b.Type != "tool_use" {
+			continue
+		}
+		args := "{}"
+		if len(b.Input) > 0 {
+			args = string(b.Input)
+		}
+		msg.ToolCalls = append(msg.ToolCalls, ChatToolCall{
+			ID:   b.ID,
+			Type: "function",
+			Function: ChatFunctionCall{
+				Name:      b.Name,
+				Arguments: args,
+			},
+		})
+	}
+
+	if len(msg.Content) == 0 && len(msg.ToolCalls) == 0 {
+		return nil, nil
+	}
+	return []ChatMessage{msg}, nil
+}
+
+// convertAnthropicToolsToChat maps Anthropic custom tools to Chat Completions
+// function tools. Anthropic-defined server/client tools (web_search, bash,
+// text_editor, ...) have no Chat Completions equivalent and are skipped.
+func convertAnthropicToolsToChat(tools []AnthropicTool) []ChatTool {
+	var out []ChatTool
+	for _, t := range tools {
+		if t.Type != "" && t.Type != "custom" {
+			continue
+		}
+		out = append(out, ChatTool{
+			Type: "function",
+			Function: &ChatFunction{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  normalizeToolParameters(t.InputSchema),
+			},
+		})
+	}
+	return out
+}
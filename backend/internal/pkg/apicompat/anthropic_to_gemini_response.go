@@ -0,0 +1,286 @@
+package apicompat
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Non-streaming: AnthropicResponse → GeminiGenerateContentResponse
+// ---------------------------------------------------------------------------
+
+// AnthropicToGeminiResponse converts an Anthropic Messages response into a
+// Gemini generateContent response. Thinking blocks become thought parts and
+// tool_use blocks become functionCall parts carrying the tool_use id.
+func AnthropicToGeminiResponse(resp *AnthropicResponse, model string) *GeminiGenerateContentResponse {
+	var parts []GeminiPart
+	for _, b := range resp.Content {
+		switch b.Type {
+		case "thinking":
+			if b.Thinking != "" {
+				parts = append(parts, GeminiPart{Text: b.Thinking, Thought: true})
+			}
+		case "text":
+			if b.Text != "" {
+				parts = append(parts, GeminiPart{Text: b.Text})
+			}
+		case "tool_use":
+			parts = append(parts, GeminiPart{FunctionCall: &GeminiFunctionCall{
+				ID:   b.ID,
+				Name: b.Name,
+				Args: normalizeFunctionArgs(b.Input),
+			}})
+		}
+	}
+	if parts == nil {
+		parts = []GeminiPart{}
+	}
+
+	return &GeminiGenerateContentResponse{
+		Candidates: []GeminiCandidate{{
+			Content:      GeminiContent{Role: "model", Parts: parts},
+			FinishReason: anthropicStopReasonToGeminiFinishReason(resp.StopReason),
+		}},
+		UsageMetadata: anthropicUsageToGemini(resp.Usage),
+		ModelVersion:  model,
+		ResponseID:    resp.ID,
+	}
+}
+
+// anthropicStopReasonToGeminiFinishReason maps Anthropic stop_reason to a
+// Gemini finishReason.
+func anthropicStopReasonToGeminiFinishReason(stopReason string) string {
+	switch stopReason {
+	case "max_tokens":
+		return "MAX_TOKENS"
+	case "refusal":
+		return "SAFETY"
+	default:
+		return "STOP"
+	}
+}
+
+// anthropicUsageToGemini converts Anthropic usage. Gemini's promptTokenCount
+// includes cached tokens, so cache reads and writes are added back in.
+func anthropicUsageToGemini(u AnthropicUsage) *GeminiUsageMetadata {
+	prompt := u.InputTokens + u.CacheReadInputTokens + u.CacheCreationInputTokens
+	return &GeminiUsageMetadata{
+		PromptTokenCount:        prompt,
+		CandidatesTokenCount:    u.OutputTokens,
+		TotalTokenCount:         prompt + u.OutputTokens,
+		CachedContentTokenCount: u.CacheReadInputTokens,
+	}
+}
+
+// normalizeFunctionArgs returns args as a JSON object, defaulting to {} when
+// the upstream sent nothing or invalid JSON.
+func normalizeFunctionArgs(args json.RawMessage) json.RawMessage {
+	trimmed := strings.TrimSpace(string(args))
+	if trimmed == "" || trimmed == "null" || !json.Valid([]byte(trimmed)) {
+		return json.RawMessage(`{}`)
+	}
+	return json.RawMessage(trimmed)
+}
+
+// ---------------------------------------------------------------------------
+// Streaming: AnthropicStreamEvent → []GeminiGenerateContentResponse
+// ---------------------------------------------------------------------------
+
+// AnthropicEventToGeminiState tracks state for converting a sequence of
+// Anthropic SSE events into Gemini streamGenerateContent chunks.
+//
+// The most recent chunk is held back so the finishReason and usageMetadata
+// can be attached to it when the message ends, matching how Gemini reports
+// the final chunk.
+type AnthropicEventToGeminiState struct {
+	Model      string
+	ResponseID string
+	Usage      AnthropicUsage
+	StopReason string
+
+	// tool_use block being accumulated, keyed by content block index
+	toolBlocks map[int]*geminiPendingToolCall
+
+	pending  *GeminiGenerateContentResponse
+	Finished bool
+}
+
+type geminiPendingToolCall struct {
+	id   string
+	name string
+	args strings.Builder
+}
+
+// NewAnthropicEventToGeminiState returns an initialised stream state.
+func NewAnthropicEventToGeminiState(model string) *AnthropicEventToGeminiState {
+	return &AnthropicEventToGeminiState{
+		Model:      model,
+		toolBlocks: make(map[int]*geminiPendingToolCall),
+	}
+}
+
+// AnthropicEventToGeminiChunks converts a single Anthropic SSE event into zero
+// or more Gemini stream chunks, updating state as it goes.
+func AnthropicEventToGeminiChunks(evt *AnthropicStreamEvent, state *AnthropicEventToGeminiState) []GeminiGenerateContentResponse {
+	if state.Finished {
+		return nil
+	}
+	switch evt.Type {
+	case "message_start":
+		if evt.Message != nil {
+			state.ResponseID = evt.Message.ID
+			mergeAnthropicStreamUsage(&state.Usage, &evt.Message.Usage)
+		}
+	case "content_block_start":
+		if evt.ContentBlock == nil {
+			return nil
+		}
+		switch evt.ContentBlock.Type {
+		case "tool_use":
+			state.toolBlocks[blockIndex(evt)] = &geminiPendingToolCall{
+				id:   evt.ContentBlock.ID,
+				name: evt.ContentBlock.Name,
+			}
+		case "text":
+			if evt.ContentBlock.Text != "" {
+				return state.push(GeminiPart{Text: evt.ContentBlock.Text})
+			}
+		case "thinking":
+			if evt.ContentBlock.Thinking != "" {
+				return state.push(GeminiPart{Text: evt.ContentBlock.Thinking, Thought: true})
+			}
+		}
+	case "content_block_delta":
+		if evt.Delta == nil {
+			return nil
+		}
+		switch evt.Delta.Type {
+		case "text_delta":
+			if evt.Delta.Text != "" {
+				return state.push(GeminiPart{Text: evt.Delta.Text})
+			}
+		case "thinking_delta":
+			if evt.Delta.Thinking != "" {
+				return state.push(GeminiPart{Text: evt.Delta.Thinking, Thought: true})
+			}
+		case "input_json_delta":
+			if tb := state.toolBlocks[blockIndex(evt)]; tb != nil {
+				tb.args.WriteString(evt.Delta.PartialJSON)
+			}
+		}
+	case "content_block_stop":
+		idx := blockIndex(evt)
+		tb := state.toolBlocks[idx]
+		if tb == nil {
+			return nil
+		}
+		delete(state.toolBlocks, idx)
+		return state.push(GeminiPart{FunctionCall: &GeminiFunctionCall{
+			ID:   tb.id,
+			Name: tb.name,
+			Args: normalizeFunctionArgs(json.RawMessage(tb.args.String())),
+		}})
+	case "message_delta":
+		if evt.Delta != nil && evt.Delta.StopReason != "" {
+			state.StopReason = evt.Delta.StopReason
+		}
+		if evt.Usage != nil {
+			mergeAnthropicStreamUsage(&state.Usage, evt.Usage)
+		}
+	case "message_stop":
+		return state.finish()
+	}
+	return nil
+}
+
+// FinalizeAnthropicGeminiStream flushes the held-back chunk with a finish
+// reason if the stream ended without a message_stop event.
+func FinalizeAnthropicGeminiStream(state *AnthropicEventToGeminiState) []GeminiGenerateContentResponse {
+	if state == nil || state.Finished {
+		return nil
+	}
+	return state.finish()
+}
+
+// GeminiChunkToSSE formats a Gemini stream chunk as an SSE data line
+// (streamGenerateContent?alt=sse).
+func GeminiChunkToSSE(chunk GeminiGenerateContentResponse) (string, error) {
+	data, err := json.Marshal(chunk)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("data: %s\n\n", data), nil
+}
+
+// push queues a chunk carrying a single part and releases the previously
+// held chunk, if any.
+func (s *AnthropicEventToGeminiState) push(part GeminiPart) []GeminiGenerateContentResponse {
+	prev := s.pending
+	s.pending = newGeminiPartChunk(s.Model, s.ResponseID, part)
+	if prev == nil {
+		return nil
+	}
+	return []GeminiGenerateContentResponse{*prev}
+}
+
+func (s *AnthropicEventToGeminiState) finish() []GeminiGenerateContentResponse {
+	s.Finished = true
+	last := finalGeminiChunk(s.pending, s.Model, s.ResponseID,
+		anthropicStopReasonToGeminiFinishReason(s.StopReason), anthropicUsageToGemini(s.Usage))
+	s.pending = nil
+	return []GeminiGenerateContentResponse{*last}
+}
+
+// newGeminiPartChunk builds a stream chunk carrying a single model part.
+func newGeminiPartChunk(model, responseID string, part GeminiPart) *GeminiGenerateContentResponse {
+	return &GeminiGenerateContentResponse{
+		Candidates: []GeminiCandidate{{
+			Content: GeminiContent{Role: "model", Parts: []GeminiPart{part}},
+		}},
+		ModelVersion: model,
+		ResponseID:   responseID,
+	}
+}
+
+// finalGeminiChunk attaches the finish reason and usage to the held-back
+// chunk, or builds an empty one when the stream produced no content.
+func finalGeminiChunk(pending *GeminiGenerateContentResponse, model, responseID, finishReason string, usage *GeminiUsageMetadata) *GeminiGenerateContentResponse {
+	last := pending
+	if last == nil {
+		last = &GeminiGenerateContentResponse{
+			Candidates: []GeminiCandidate{{
+				Content: GeminiContent{Role: "model", Parts: []GeminiPart{}},
+			}},
+			ModelVersion: model,
+			ResponseID:   responseID,
+		}
+	}
+	last.Candidates[0].FinishReason = finishReason
+	last.UsageMetadata = usage
+	return last
+}
+
+// mergeAnthropicStreamUsage copies non-zero counters from an Anthropic stream
+// usage snapshot (message_start or message_delta).
+func mergeAnthropicStreamUsage(dst *AnthropicUsage, src *AnthropicUsage) {
+	if src.InputTokens > 0 {
+		dst.InputTokens = src.InputTokens
+	}
+	if src.OutputTokens > 0 {
+		dst.OutputTokens = src.OutputTokens
+	}
+	if src.CacheCreationInputTokens > 0 {
+		dst.CacheCreationInputTokens = src.CacheCreationInputTokens
+	}
+	if src.CacheReadInputTokens > 0 {
+		dst.CacheReadInputTokens = src.CacheReadInputTokens
+	}
+}
+
+func blockIndex(evt *AnthropicStreamEvent) int {
+	if evt.Index == nil {
+		return 0
+	}
+	return *evt.Index
+}
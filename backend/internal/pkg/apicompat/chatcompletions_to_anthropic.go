@@ -0,0 +1,353 @@
+package apicompat
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// ---------------------------------------------------------------------------
+// Non-streaming: ChatCompletionsResponse → AnthropicResponse
+// ---------------------------------------------------------------------------
+
+// ChatCompletionsToAnthropic converts a Chat Completions response into an
+// Anthropic Messages response. reasoning_content is mapped to a thinking
+// block and tool_calls become tool_use blocks.
+func ChatCompletionsToAnthropic(resp *ChatCompletionsResponse, model string) *AnthropicResponse {
+	id := resp.ID
+	if id == "" {
+		id = generateAnthropicMessageID()
+	}
+	out := &AnthropicResponse{
+		ID:    id,
+		Type:  "message",
+		Role:  "assistant",
+		Model: model,
+	}
+
+	var blocks []AnthropicContentBlock
+	finishReason := ""
+	if len(resp.Choices) > 0 {
+		choice := resp.Choices[0]
+		finishReason = choice.FinishReason
+		if choice.Message.ReasoningContent != "" {
+			blocks = append(blocks, AnthropicContentBlock{Type: "thinking", Thinking: choice.Message.ReasoningContent})
+		}
+		if text := chatContentText(choice.Message.Content); text != "" {
+			blocks = append(blocks, AnthropicContentBlock{Type: "text", Text: text})
+		}
+		for i, tc := range choice.Message.ToolCalls {
+			blocks = append(blocks, AnthropicContentBlock{
+				Type:  "tool_use",
+				ID:    chatToolCallID(tc.ID, id, i),
+				Name:  tc.Function.Name,
+				Input: chatToolArgumentsToInput(tc.Function.Arguments),
+			})
+		}
+	}
+
+	if len(blocks) == 0 {
+		blocks = append(blocks, AnthropicContentBlock{Type: "text", Text: ""})
+	}
+	out.Content = blocks
+	out.StopReason = chatFinishReasonToAnthropic(finishReason, blocks[len(blocks)-1].Type == "tool_use")
+	out.Usage = ChatUsageToAnthropic(resp.Usage)
+	return out
+}
+
+// ChatUsageToAnthropic converts Chat Completions usage into Anthropic usage.
+// prompt_tokens includes cached tokens in OpenAI semantics, whereas Anthropic
+// reports cache reads separately from input_tokens, so cached tokens are
+// subtracted from the input count.
+func ChatUsageToAnthropic(usage *ChatUsage) AnthropicUsage {
+	if usage == nil {
+		return AnthropicUsage{}
+	}
+	cached := usage.PromptCacheHitTokens
+	if usage.PromptTokensDetails != nil && usage.PromptTokensDetails.CachedTokens > 0 {
+		cached = usage.PromptTokensDetails.CachedTokens
+	}
+	if cached > usage.PromptTokens {
+		cached = usage.PromptTokens
+	}
+	return AnthropicUsage{
+		InputTokens:          usage.PromptTokens - cached,
+		OutputTokens:         usage.CompletionTokens,
+		CacheReadInputTokens: cached,
+	}
+}
+
+// chatFinishReasonToAnthropic maps a Chat Completions finish_reason to an
+// Anthropic stop_reason.
+func chatFinishReasonToAnthropic(reason string, endsWithToolUse bool) string {
+	switch reason {
+	case "length":
+		return "max_tokens"
+	case "tool_calls", "function_call":
+		return "tool_use"
+	case "content_filter":
+		return "refusal"
+	default:
+		if endsWithToolUse {
+			return "tool_use"
+		}
+		return "end_turn"
+	}
+}
+
+// chatContentText extracts text from a Chat Completions message content,
+// which may be a string, null, or an array of content parts.
+func chatContentText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	var parts []ChatContentPart
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return ""
+	}
+	text := ""
+	for _, p := range parts {
+		if p.Type == "text" {
+			text += p.Text
+		}
+	}
+	return text
+}
+
+// chatToolArgumentsToInput returns tool call arguments as a JSON object,
+// falling back to {} when the upstream produced empty or malformed JSON.
+func chatToolArgumentsToInput(args string) json.RawMessage {
+	if args == "" || !json.Valid([]byte(args)) {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(args)
+}
+
+// chatToolCallID returns the upstream tool call ID, synthesising a stable one
+// when the upstream omitted it (some self-hosted servers do).
+func chatToolCallID(id, messageID string, index int) string {
+	if id != "" {
+		return id
+	}
+	return fmt.Sprintf("call_%s_%d", messageID, index)
+}
+
+func generateAnthropicMessageID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "msg_" + hex.EncodeToString(b)
+}
+
+// ---------------------------------------------------------------------------
+// Streaming: ChatCompletionsChunk → []AnthropicStreamEvent (stateful converter)
+// ---------------------------------------------------------------------------
+
+// ChatCompletionsToAnthropicState tracks state for converting a sequence of
+// Chat Completions chunks into Anthropic SSE events.
+//
+// message_delta/message_stop are only emitted by
+// FinalizeChatCompletionsAnthropicStream, because with
+// stream_options.include_usage the usage chunk arrives after the chunk that
+// carries finish_reason.
+type ChatCompletionsToAnthropicState struct {
+	MessageStartSent bool
+	MessageStopSent  bool
+
+	ContentBlockIndex int
+	ContentBlockOpen  bool
+	CurrentBlockType  string // "text" | "thinking" | "tool_use"
+
+	// ToolCallIndexToBlockIdx maps Chat Completions tool_calls[].index →
+	// Anthropic content block index.
+	ToolCallIndexToBlockIdx map[int]int
+
+	FinishReason string
+	Usage        AnthropicUsage
+
+	MessageID string
+	Model     string
+}
+
+// NewChatCompletionsToAnthropicState returns an initialised stream state.
+func NewChatCompletionsToAnthropicState() *ChatCompletionsToAnthropicState {
+	return &ChatCompletionsToAnthropicState{
+		ToolCallIndexToBlockIdx: make(map[int]int),
+	}
+}
+
+// ChatCompletionsChunkToAnthropicEvents converts a single Chat Completions
+// chunk into zero or more Anthropic SSE events, updating state as it goes.
+func ChatCompletionsChunkToAnthropicEvents(
+	chunk *ChatCompletionsChunk,
+	state *ChatCompletionsToAnthropicState,
+) []AnthropicStreamEvent {
+	if state.MessageStopSent {
+		return nil
+	}
+
+	var events []AnthropicStreamEvent
+	if !state.MessageStartSent {
+		events = append(events, chatToAnthMessageStart(chunk, state))
+	}
+
+	if chunk.Usage != nil {
+		state.Usage = ChatUsageToAnthropic(chunk.Usage)
+	}
+
+	for _, choice := range chunk.Choices {
+		if choice.Index != 0 {
+			continue
+		}
+		delta := choice.Delta
+		if delta.ReasoningContent != nil && *delta.ReasoningContent != "" {
+			events = append(events, chatToAnthEnsureBlock(state, "thinking")...)
+			idx := state.ContentBlockIndex
+			events = append(events, AnthropicStreamEvent{
+				Type:  "content_block_delta",
+				Index: &idx,
+				Delta: &AnthropicDelta{Type: "thinking_delta", Thinking: *delta.ReasoningContent},
+			})
+		}
+		if delta.Content != nil && *delta.Content != "" {
+			events = append(events, chatToAnthEnsureBlock(state, "text")...)
+			idx := state.ContentBlockIndex
+			events = append(events, AnthropicStreamEvent{
+				Type:  "content_block_delta",
+				Index: &idx,
+				Delta: &AnthropicDelta{Type: "text_delta", Text: *delta.Content},
+			})
+		}
+		for _, tc := range delta.ToolCalls {
+			events = append(events, chatToAnthHandleToolCall(tc, state)...)
+		}
+		if choice.FinishReason != nil && *choice.FinishReason != "" {
+			state.FinishReason = *choice.FinishReason
+		}
+	}
+	return events
+}
+
+// FinalizeChatCompletionsAnthropicStream closes any open content block and
+// emits message_delta (stop_reason + usage) and message_stop. It must be
+// called once the upstream stream ends ([DONE] or EOF).
+func FinalizeChatCompletionsAnthropicStream(state *ChatCompletionsToAnthropicState) []AnthropicStreamEvent {
+	if !state.MessageStartSent || state.MessageStopSent {
+		return nil
+	}
+
+	var events []AnthropicStreamEvent
+	endsWithToolUse := state.CurrentBlockType == "tool_use"
+	events = append(events, chatToAnthCloseBlock(state)...)
+
+	usage := state.Usage
+	events = append(events,
+		AnthropicStreamEvent{
+			Type:  "message_delta",
+			Delta: &AnthropicDelta{StopReason: chatFinishReasonToAnthropic(state.FinishReason, endsWithToolUse)},
+			Usage: &usage,
+		},
+		AnthropicStreamEvent{Type: "message_stop"},
+	)
+	state.MessageStopSent = true
+	return events
+}
+
+// --- internal handlers ---
+
+func chatToAnthMessageStart(chunk *ChatCompletionsChunk, state *ChatCompletionsToAnthropicState) AnthropicStreamEvent {
+	state.MessageStartSent = true
+	state.MessageID = chunk.ID
+	if state.MessageID == "" {
+		state.MessageID = generateAnthropicMessageID()
+	}
+	if state.Model == "" {
+		state.Model = chunk.Model
+	}
+	return AnthropicStreamEvent{
+		Type: "message_start",
+		Message: &AnthropicResponse{
+			ID:      state.MessageID,
+			Type:    "message",
+			Role:    "assistant",
+			Content: []AnthropicContentBlock{},
+			Model:   state.Model,
+		},
+	}
+}
+
+// chatToAnthEnsureBlock opens a new text/thinking block unless one of the
+// same type is already open.
+func chatToAnthEnsureBlock(state *ChatCompletionsToAnthropicState, blockType string) []AnthropicStreamEvent {
+	if state.ContentBlockOpen && state.CurrentBlockType == blockType {
+		return nil
+	}
+	events := chatToAnthCloseBlock(state)
+
+	idx := state.ContentBlockIndex
+	state.ContentBlockOpen = true
+	state.CurrentBlockType = blockType
+	block := &AnthropicContentBlock{Type: blockType}
+	return append(events, AnthropicStreamEvent{
+		Type:         "content_block_start",
+		Index:        &idx,
+		ContentBlock: block,
+	})
+}
+
+func chatToAnthHandleToolCall(tc ChatToolCall, state *ChatCompletionsToAnthropicState) []AnthropicStreamEvent {
+	callIndex := 0
+	if tc.Index != nil {
+		callIndex = *tc.Index
+	}
+
+	var events []AnthropicStreamEvent
+	blockIdx, known := state.ToolCallIndexToBlockIdx[callIndex]
+	if !known {
+		events = append(events, chatToAnthCloseBlock(state)...)
+		blockIdx = state.ContentBlockIndex
+		state.ToolCallIndexToBlockIdx[callIndex] = blockIdx
+		state.ContentBlockOpen = true
+		state.CurrentBlockType = "tool_use"
+		events = append(events, AnthropicStreamEvent{
+			Type:  "content_block_start",
+			Index: &blockIdx,
+			ContentBlock: &AnthropicContentBlock{
+				Type:  "tool_use",
+				ID:    chatToolCallID(tc.ID, state.MessageID, callIndex),
+				Name:  tc.Function.Name,
+				Input: json.RawMessage("{}"),
+			},
+		})
+	} else if !state.ContentBlockOpen || state.ContentBlockIndex != blockIdx {
+		// Anthropic blocks cannot be reopened; late argument fragments for an
+		// already closed tool call are dropped.
+		return nil
+	}
+
+	if tc.Function.Arguments != "" {
+		events = append(events, AnthropicStreamEvent{
+			Type:  "content_block_delta",
+			Index: &blockIdx,
+			Delta: &AnthropicDelta{Type: "input_json_delta", PartialJSON: tc.Function.Arguments},
+		})
+	}
+	return events
+}
+
+func chatToAnthCloseBlock(state *ChatCompletionsToAnthropicState) []AnthropicStreamEvent {
+	if !state.ContentBlockOpen {
+		return nil
+	}
+	idx := state.ContentBlockIndex
+	state.ContentBlockOpen = false
+	state.ContentBlockIndex++
+	return []AnthropicStreamEvent{{
+		Type:  "content_block_stop",
+		Index: &idx,
+	}}
+}
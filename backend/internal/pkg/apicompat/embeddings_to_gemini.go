@@ -0,0 +1,149 @@
+package apicompat
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Gemini embedding actions used by EmbeddingsToGemini.
+const (
+	GeminiActionEmbedContent       = "embedContent"
+	GeminiActionBatchEmbedContents = "batchEmbedContents"
+)
+
+// ErrEmbeddingsTokenInput is returned when the client sends pre-tokenized
+// input (arrays of token ids), which Gemini embedding models cannot accept.
+var ErrEmbeddingsTokenInput = errors.New("token array input is not supported for this model")
+
+// ParseEmbeddingsInput extracts the list of input texts from an embeddings
+// request. A single string yields one element. Token-id arrays are rejected
+// with ErrEmbeddingsTokenInput.
+func ParseEmbeddingsInput(raw json.RawMessage) ([]string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return nil, errors.New("input is required")
+	}
+
+	switch raw[0] {
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, fmt.Errorf("parse input: %w", err)
+		}
+		return []string{s}, nil
+	case '[':
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil, fmt.Errorf("parse input: %w", err)
+		}
+		if len(items) == 0 {
+			return nil, errors.New("input must not be empty")
+		}
+		texts := make([]string, 0, len(items))
+		for _, item := range items {
+			item = bytes.TrimSpace(item)
+			if len(item) == 0 || item[0] != '"' {
+				return nil, ErrEmbeddingsTokenInput
+			}
+			var s string
+			if err := json.Unmarshal(item, &s); err != nil {
+				return nil, fmt.Errorf("parse input: %w", err)
+			}
+			texts = append(texts, s)
+		}
+		return texts, nil
+	default:
+		return nil, errors.New("input must be a string or an array of strings")
+	}
+}
+
+// EmbeddingsToGemini converts an OpenAI embeddings request into a Gemini
+// embedding request for the given upstream model. A single string input maps
+// to :embedContent; arrays map to :batchEmbedContents. It returns the Gemini
+// action, the request body and the number of inputs.
+func EmbeddingsToGemini(req *EmbeddingsRequest, model string) (string, any, int, error) {
+	texts, err := ParseEmbeddingsInput(req.Input)
+	if err != nil {
+		return "", nil, 0, err
+	}
+
+	isArray := bytes.HasPrefix(bytes.TrimSpace(req.Input), []byte("["))
+	if !isArray {
+		return GeminiActionEmbedContent, &GeminiEmbedContentRequest{
+			Content:              GeminiEmbedContent{Parts: []GeminiEmbedPart{{Text: texts[0]}}},
+			OutputDimensionality: req.Dimensions,
+		}, 1, nil
+	}
+
+	modelRef := "models/" + strings.TrimPrefix(model, "models/")
+	batch := &GeminiBatchEmbedContentsRequest{Requests: make([]GeminiEmbedContentRequest, 0, len(texts))}
+	for _, text := range texts {
+		batch.Requests = append(batch.Requests, GeminiEmbedContentRequest{
+			Model:                modelRef,
+			Content:              GeminiEmbedContent{Parts: []GeminiEmbedPart{{Text: text}}},
+			OutputDimensionality: req.Dimensions,
+		})
+	}
+	return GeminiActionBatchEmbedContents, batch, len(texts), nil
+}
+
+// GeminiEmbeddingsToOpenAI converts a Gemini embedContent/batchEmbedContents
+// response body into an OpenAI embeddings response. Gemini does not report
+// token usage for embeddings, so promptTokens is supplied by the caller.
+func GeminiEmbeddingsToOpenAI(body []byte, action, model, encodingFormat string, promptTokens int) (*EmbeddingsResponse, error) {
+	var vectors []GeminiContentEmbedding
+	switch action {
+	case GeminiActionEmbedContent:
+		var resp GeminiEmbedContentResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("parse gemini embedding response: %w", err)
+		}
+		if resp.Embedding == nil {
+			return nil, errors.New("gemini embedding response missing embedding")
+		}
+		vectors = []GeminiContentEmbedding{*resp.Embedding}
+	case GeminiActionBatchEmbedContents:
+		var resp GeminiBatchEmbedContentsResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("parse gemini embedding response: %w", err)
+		}
+		vectors = resp.Embeddings
+	default:
+		return nil, fmt.Errorf("unsupported gemini embedding action: %s", action)
+	}
+
+	out := &EmbeddingsResponse{
+		Object: "list",
+		Data:   make([]EmbeddingData, 0, len(vectors)),
+		Model:  model,
+		Usage:  EmbeddingsUsage{PromptTokens: promptTokens, TotalTokens: promptTokens},
+	}
+	for i, v := range vectors {
+		values := v.Values
+		if values == nil {
+			values = []float64{}
+		}
+		var embedding any = values
+		if encodingFormat == "base64" {
+			embedding = EncodeEmbeddingBase64(values)
+		}
+		out.Data = append(out.Data, EmbeddingData{Object: "embedding", Index: i, Embedding: embedding})
+	}
+	return out, nil
+}
+
+// EncodeEmbeddingBase64 encodes a vector the way OpenAI does for
+// encoding_format=base64: little-endian float32 values, standard base64.
+func EncodeEmbeddingBase64(values []float64) string {
+	buf := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(buf[i*4:], math.Float32bits(float32(v)))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
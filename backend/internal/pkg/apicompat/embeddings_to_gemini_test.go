@@ -0,0 +1,95 @@
+package apicompat
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEmbeddingsInput(t *testing.T) {
+	texts, err := ParseEmbeddingsInput(json.RawMessage(`"hello"`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hello"}, texts)
+
+	texts, err = ParseEmbeddingsInput(json.RawMessage(`["a","b"]`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, texts)
+
+	_, err = ParseEmbeddingsInput(json.RawMessage(`[1,2,3]`))
+	assert.ErrorIs(t, err, ErrEmbeddingsTokenInput)
+
+	_, err = ParseEmbeddingsInput(json.RawMessage(`[]`))
+	assert.Error(t, err)
+
+	_, err = ParseEmbeddingsInput(nil)
+	assert.Error(t, err)
+}
+
+func TestEmbeddingsToGemini_SingleInput(t *testing.T) {
+	dims := 256
+	req := &EmbeddingsRequest{Model: "gemini-embedding-001", Input: json.RawMessage(`"hi"`), Dimensions: &dims}
+
+	action, body, count, err := EmbeddingsToGemini(req, "gemini-embedding-001")
+	require.NoError(t, err)
+	assert.Equal(t, GeminiActionEmbedContent, action)
+	assert.Equal(t, 1, count)
+
+	single, ok := body.(*GeminiEmbedContentRequest)
+	require.True(t, ok)
+	assert.Empty(t, single.Model)
+	assert.Equal(t, "hi", single.Content.Parts[0].Text)
+	require.NotNil(t, single.OutputDimensionality)
+	assert.Equal(t, 256, *single.OutputDimensionality)
+}
+
+func TestEmbeddingsToGemini_BatchInput(t *testing.T) {
+	req := &EmbeddingsRequest{Model: "text-embedding-004", Input: json.RawMessage(`["a","b"]`)}
+
+	action, body, count, err := EmbeddingsToGemini(req, "text-embedding-004")
+	require.NoError(t, err)
+	assert.Equal(t, GeminiActionBatchEmbedContents, action)
+	assert.Equal(t, 2, count)
+
+	batch, ok := body.(*GeminiBatchEmbedContentsRequest)
+	require.True(t, ok)
+	require.Len(t, batch.Requests, 2)
+	assert.Equal(t, "models/text-embedding-004", batch.Requests[0].Model)
+	assert.Equal(t, "b", batch.Requests[1].Content.Parts[0].Text)
+	assert.Nil(t, batch.Requests[0].OutputDimensionality)
+}
+
+func TestGeminiEmbeddingsToOpenAI(t *testing.T) {
+	body := []byte(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3]}]}`)
+	resp, err := GeminiEmbeddingsToOpenAI(body, GeminiActionBatchEmbedContents, "text-embedding-004", "", 7)
+	require.NoError(t, err)
+	assert.Equal(t, "list", resp.Object)
+	assert.Equal(t, "text-embedding-004", resp.Model)
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, 1, resp.Data[1].Index)
+	assert.Equal(t, []float64{0.3}, resp.Data[1].Embedding)
+	assert.Equal(t, 7, resp.Usage.PromptTokens)
+	assert.Equal(t, 7, resp.Usage.TotalTokens)
+
+	_, err = GeminiEmbeddingsToOpenAI([]byte(`{}`), GeminiActionEmbedContent, "m", "", 0)
+	assert.Error(t, err)
+}
+
+func TestGeminiEmbeddingsToOpenAI_Base64(t *testing.T) {
+	body := []byte(`{"embedding":{"values":[1.5,-2]}}`)
+	resp, err := GeminiEmbeddingsToOpenAI(body, GeminiActionEmbedContent, "m", "base64", 1)
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 1)
+
+	encoded, ok := resp.Data[0].Embedding.(string)
+	require.True(t, ok)
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	require.NoError(t, err)
+	require.Len(t, raw, 8)
+	assert.Equal(t, float32(1.5), math.Float32frombits(binary.LittleEndian.Uint32(raw[0:4])))
+	assert.Equal(t, float32(-2), math.Float32frombits(binary.LittleEndian.Uint32(raw[4:8])))
+}
@@ -0,0 +1,399 @@
+package apicompat
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(v int) *int { return &v }
+
+func parseGeminiRequest(t *testing.T, body string) *GeminiGenerateContentRequest {
+	t.Helper()
+	var req GeminiGenerateContentRequest
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+	return &req
+}
+
+// ---------------------------------------------------------------------------
+// GeminiToAnthropic tests
+// ---------------------------------------------------------------------------
+
+func TestGeminiToAnthropic_FunctionCallsImagesAndThoughts(t *testing.T) {
+	req := parseGeminiRequest(t, `{
+		"systemInstruction": {"parts": [{"text": "Be brief."}]},
+		"contents": [
+			{"role": "user", "parts": [
+				{"text": "What is in this image?"},
+				{"inlineData": {"mimeType": "image/png", "data": "iVBORw0KGgo="}}
+			]},
+			{"role": "model", "parts": [
+				{"text": "Let me think", "thought": true},
+				{"functionCall": {"name": "read_file", "args": {"path": "a.txt"}}}
+			]},
+			{"role": "user", "parts": [
+				{"functionResponse": {"name": "read_file", "response": {"output": "hello"}}}
+			]}
+		],
+		"tools": [{"functionDeclarations": [{
+			"name": "read_file",
+			"description": "Read a file",
+			"parameters": {"type": "OBJECT", "properties": {"path": {"type": "STRING"}}, "required": ["path"]}
+		}]}],
+		"toolConfig": {"functionCallingConfig": {"mode": "ANY", "allowedFunctionNames": ["read_file"]}},
+		"generationConfig": {"temperature": 0, "topP": 1, "maxOutputTokens": 4096}
+	}`)
+
+	out, err := GeminiToAnthropic(req, "claude-sonnet-4-5")
+	require.NoError(t, err)
+	assert.Equal(t, "claude-sonnet-4-5", out.Model)
+	assert.Equal(t, 4096, out.MaxTokens)
+	assert.JSONEq(t, `"Be brief."`, string(out.System))
+	assert.JSONEq(t, `{"type":"tool","name":"read_file"}`, string(out.ToolChoice))
+	require.NotNil(t, out.Temperature)
+	assert.Nil(t, out.Thinking)
+
+	require.Len(t, out.Tools, 1)
+	assert.JSONEq(t, `{"type":"object","properties":{"path":{"type":"string"}},"required":["path"]}`, string(out.Tools[0].InputSchema))
+
+	require.Len(t, out.Messages, 3)
+	var user []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[0].Content, &user))
+	require.Len(t, user, 2)
+	assert.Equal(t, "image", user[1].Type)
+	assert.Equal(t, "image/png", user[1].Source.MediaType)
+
+	var assistant []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[1].Content, &assistant))
+	require.Len(t, assistant, 1, "thought parts are dropped from history")
+	assert.Equal(t, "tool_use", assistant[0].Type)
+	assert.Equal(t, "toolu_gemini_1", assistant[0].ID)
+	assert.JSONEq(t, `{"path":"a.txt"}`, string(assistant[0].Input))
+
+	var result []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[2].Content, &result))
+	require.Len(t, result, 1)
+	assert.Equal(t, "tool_result", result[0].Type)
+	assert.Equal(t, "toolu_gemini_1", result[0].ToolUseID)
+	assert.JSONEq(t, `"hello"`, string(result[0].Content))
+}
+
+func TestGeminiToAnthropic_ExplicitCallIDs(t *testing.T) {
+	req := parseGeminiRequest(t, `{"contents": [
+		{"role": "user", "parts": [{"text": "go"}]},
+		{"role": "model", "parts": [
+			{"functionCall": {"id": "toolu_a", "name": "ls", "args": {}}},
+			{"functionCall": {"id": "toolu_b", "name": "ls", "args": {"dir": "x"}}}
+		]},
+		{"role": "user", "parts": [
+			{"functionResponse": {"id": "toolu_b", "name": "ls", "response": {"files": ["y"]}}},
+			{"functionResponse": {"name": "ls", "response": {"output": "z"}}}
+		]}
+	]}`)
+
+	out, err := GeminiToAnthropic(req, "claude-sonnet-4-5")
+	require.NoError(t, err)
+	require.Len(t, out.Messages, 3)
+
+	var result []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[2].Content, &result))
+	require.Len(t, result, 2)
+	assert.Equal(t, "toolu_b", result[0].ToolUseID)
+	assert.JSONEq(t, `"{\"files\":[\"y\"]}"`, string(result[0].Content))
+	assert.Equal(t, "toolu_a", result[1].ToolUseID)
+}
+
+func TestGeminiToAnthropic_OrphanFunctionResponseBecomesText(t *testing.T) {
+	req := parseGeminiRequest(t, `{"contents": [
+		{"role": "user", "parts": [{"functionResponse": {"name": "ls", "response": {"output": "z"}}}]}
+	]}`)
+
+	out, err := GeminiToAnthropic(req, "claude-sonnet-4-5")
+	require.NoError(t, err)
+	var blocks []AnthropicContentBlock
+	require.NoError(t, json.Unmarshal(out.Messages[0].Content, &blocks))
+	require.Len(t, blocks, 1)
+	assert.Equal(t, "text", blocks[0].Type)
+	assert.Contains(t, blocks[0].Text, "ls")
+}
+
+func TestGeminiToAnthropic_Thinking(t *testing.T) {
+	t.Run("dynamic budget drops sampling params", func(t *testing.T) {
+		req := parseGeminiRequest(t, `{
+			"contents": [{"role": "user", "parts": [{"text": "hi"}]}],
+			"generationConfig": {"temperature": 0, "topP": 1, "thinkingConfig": {"includeThoughts": true, "thinkingBudget": -1}}
+		}`)
+		out, err := GeminiToAnthropic(req, "claude-sonnet-4-5")
+		require.NoError(t, err)
+		require.NotNil(t, out.Thinking)
+		assert.Equal(t, "enabled", out.Thinking.Type)
+		assert.Equal(t, 10240, out.Thinking.BudgetTokens)
+		assert.Greater(t, out.MaxTokens, out.Thinking.BudgetTokens)
+		assert.Nil(t, out.Temperature)
+		assert.Nil(t, out.TopP)
+	})
+
+	t.Run("budget clamped below explicit max tokens", func(t *testing.T) {
+		req := parseGeminiRequest(t, `{
+			"contents": [{"role": "user", "parts": [{"text": "hi"}]}],
+			"generationConfig": {"maxOutputTokens": 2000, "thinkingConfig": {"thinkingBudget": 5000}}
+		}`)
+		out, err := GeminiToAnthropic(req, "claude-sonnet-4-5")
+		require.NoError(t, err)
+		require.NotNil(t, out.Thinking)
+		assert.Equal(t, 1999, out.Thinking.BudgetTokens)
+		assert.Equal(t, 2000, out.MaxTokens)
+	})
+
+	t.Run("zero budget disables thinking", func(t *testing.T) {
+		req := parseGeminiRequest(t, `{
+			"contents": [{"role": "user", "parts": [{"text": "hi"}]}],
+			"generationConfig": {"thinkingConfig": {"thinkingBudget": 0}}
+		}`)
+		out, err := GeminiToAnthropic(req, "claude-sonnet-4-5")
+		require.NoError(t, err)
+		assert.Nil(t, out.Thinking)
+		assert.Equal(t, geminiDefaultMaxTokens, out.MaxTokens)
+	})
+}
+
+func TestGeminiToAnthropic_JSONResponseHint(t *testing.T) {
+	req := parseGeminiRequest(t, `{
+		"contents": [{"role": "user", "parts": [{"text": "hi"}]}],
+		"generationConfig": {"responseMimeType": "application/json", "responseJsonSchema": {"type": "object"}}
+	}`)
+	out, err := GeminiToAnthropic(req, "claude-sonnet-4-5")
+	require.NoError(t, err)
+	var system string
+	require.NoError(t, json.Unmarshal(out.System, &system))
+	assert.Contains(t, system, "valid JSON")
+	assert.Contains(t, system, `{"type":"object"}`)
+}
+
+func TestGeminiCountTokensToAnthropic(t *testing.T) {
+	var req GeminiCountTokensRequest
+	require.NoError(t, json.Unmarshal([]byte(`{"generateContentRequest": {
+		"model": "models/claude-sonnet-4-5",
+		"contents": [{"role": "user", "parts": [{"text": "hi"}]}],
+		"generationConfig": {"thinkingConfig": {"thinkingBudget": 2048}}
+	}}`), &req))
+
+	out, err := GeminiCountTokensToAnthropic(&req, "claude-sonnet-4-5")
+	require.NoError(t, err)
+	assert.Nil(t, out.Thinking)
+	require.Len(t, out.Messages, 1)
+}
+
+// ---------------------------------------------------------------------------
+// AnthropicToGeminiResponse tests
+// ---------------------------------------------------------------------------
+
+func TestAnthropicToGeminiResponse(t *testing.T) {
+	resp := &AnthropicResponse{
+		ID: "msg_1",
+		Content: []AnthropicContentBlock{
+			{Type: "thinking", Thinking: "hmm"},
+			{Type: "text", Text: "Reading it"},
+			{Type: "tool_use", ID: "toolu_1", Name: "read_file", Input: json.RawMessage(`{"path":"a"}`)},
+		},
+		StopReason: "tool_use",
+		Usage:      AnthropicUsage{InputTokens: 10, OutputTokens: 5, CacheReadInputTokens: 90},
+	}
+
+	out := AnthropicToGeminiResponse(resp, "claude-sonnet-4-5")
+	require.Len(t, out.Candidates, 1)
+	c := out.Candidates[0]
+	assert.Equal(t, "STOP", c.FinishReason)
+	assert.Equal(t, "model", c.Content.Role)
+	require.Len(t, c.Content.Parts, 3)
+	assert.True(t, c.Content.Parts[0].Thought)
+	assert.Equal(t, "Reading it", c.Content.Parts[1].Text)
+	require.NotNil(t, c.Content.Parts[2].FunctionCall)
+	assert.Equal(t, "toolu_1", c.Content.Parts[2].FunctionCall.ID)
+	assert.JSONEq(t, `{"path":"a"}`, string(c.Content.Parts[2].FunctionCall.Args))
+
+	assert.Equal(t, 100, out.UsageMetadata.PromptTokenCount)
+	assert.Equal(t, 90, out.UsageMetadata.CachedContentTokenCount)
+	assert.Equal(t, 105, out.UsageMetadata.TotalTokenCount)
+	assert.Equal(t, "msg_1", out.ResponseID)
+}
+
+func TestAnthropicEventToGeminiChunks_Stream(t *testing.T) {
+	idx0, idx1, idx2 := 0, 1, 2
+	events := []AnthropicStreamEvent{
+		{Type: "message_start", Message: &AnthropicResponse{ID: "msg_1", Usage: AnthropicUsage{InputTokens: 12}}},
+		{Type: "content_block_start", Index: &idx0, ContentBlock: &AnthropicContentBlock{Type: "thinking"}},
+		{Type: "content_block_delta", Index: &idx0, Delta: &AnthropicDelta{Type: "thinking_delta", Thinking: "plan"}},
+		{Type: "content_block_stop", Index: &idx0},
+		{Type: "content_block_start", Index: &idx1, ContentBlock: &AnthropicContentBlock{Type: "text"}},
+		{Type: "content_block_delta", Index: &idx1, Delta: &AnthropicDelta{Type: "text_delta", Text: "Hi"}},
+		{Type: "content_block_stop", Index: &idx1},
+		{Type: "content_block_start", Index: &idx2, ContentBlock: &AnthropicContentBlock{Type: "tool_use", ID: "toolu_9", Name: "ls"}},
+		{Type: "content_block_delta", Index: &idx2, Delta: &AnthropicDelta{Type: "input_json_delta", PartialJSON: `{"dir":`}},
+		{Type: "content_block_delta", Index: &idx2, Delta: &AnthropicDelta{Type: "input_json_delta", PartialJSON: `"."}`}},
+		{Type: "content_block_stop", Index: &idx2},
+		{Type: "message_delta", Delta: &AnthropicDelta{StopReason: "tool_use"}, Usage: &AnthropicUsage{OutputTokens: 7}},
+		{Type: "message_stop"},
+	}
+
+	state := NewAnthropicEventToGeminiState("claude-sonnet-4-5")
+	var chunks []GeminiGenerateContentResponse
+	for i := range events {
+		chunks = append(chunks, AnthropicEventToGeminiChunks(&events[i], state)...)
+	}
+	assert.Empty(t, FinalizeAnthropicGeminiStream(state))
+
+	require.Len(t, chunks, 3)
+	assert.True(t, chunks[0].Candidates[0].Content.Parts[0].Thought)
+	assert.Equal(t, "Hi", chunks[1].Candidates[0].Content.Parts[0].Text)
+	assert.Empty(t, chunks[1].Candidates[0].FinishReason)
+	assert.Nil(t, chunks[1].UsageMetadata)
+
+	last := chunks[2]
+	require.NotNil(t, last.Candidates[0].Content.Parts[0].FunctionCall)
+	assert.Equal(t, "toolu_9", last.Candidates[0].Content.Parts[0].FunctionCall.ID)
+	assert.JSONEq(t, `{"dir":"."}`, string(last.Candidates[0].Content.Parts[0].FunctionCall.Args))
+	assert.Equal(t, "STOP", last.Candidates[0].FinishReason)
+	require.NotNil(t, last.UsageMetadata)
+	assert.Equal(t, 12, last.UsageMetadata.PromptTokenCount)
+	assert.Equal(t, 7, last.UsageMetadata.CandidatesTokenCount)
+	assert.Equal(t, "msg_1", last.ResponseID)
+
+	sse, err := GeminiChunkToSSE(last)
+	require.NoError(t, err)
+	assert.Contains(t, sse, "data: {")
+	assert.Contains(t, sse, "\n\n")
+}
+
+func TestFinalizeAnthropicGeminiStream_WithoutMessageStop(t *testing.T) {
+	state := NewAnthropicEventToGeminiState("claude-sonnet-4-5")
+	idx := 0
+	evt := AnthropicStreamEvent{Type: "content_block_delta", Index: &idx, Delta: &AnthropicDelta{Type: "text_delta", Text: "partial"}}
+	assert.Empty(t, AnthropicEventToGeminiChunks(&evt, state))
+
+	chunks := FinalizeAnthropicGeminiStream(state)
+	require.Len(t, chunks, 1)
+	assert.Equal(t, "partial", chunks[0].Candidates[0].Content.Parts[0].Text)
+	assert.Equal(t, "STOP", chunks[0].Candidates[0].FinishReason)
+}
+
+// ---------------------------------------------------------------------------
+// Gemini ⇄ Responses tests
+// ---------------------------------------------------------------------------
+
+func TestGeminiToResponses(t *testing.T) {
+	req := parseGeminiRequest(t, `{
+		"contents": [
+			{"role": "user", "parts": [{"text": "list"}, {"inlineData": {"mimeType": "image/jpeg", "data": "AAAA"}}]},
+			{"role": "model", "parts": [{"functionCall": {"name": "ls", "args": {}}}]},
+			{"role": "user", "parts": [{"functionResponse": {"name": "ls", "response": {"output": "a b"}}}]}
+		],
+		"tools": [{"functionDeclarations": [{"name": "ls", "parametersJsonSchema": {"type": "object", "properties": {}}}]}],
+		"generationConfig": {"temperature": 0, "topP": 1, "thinkingConfig": {"thinkingBudget": 8192}}
+	}`)
+
+	out, err := GeminiToResponses(req, "gpt-5.1")
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-5.1", out.Model)
+	assert.Nil(t, out.Temperature)
+	assert.Nil(t, out.TopP)
+	assert.Nil(t, out.MaxOutputTokens)
+	require.NotNil(t, out.Reasoning)
+	assert.Equal(t, "medium", out.Reasoning.Effort)
+	require.Len(t, out.Tools, 1)
+	assert.Equal(t, "function", out.Tools[0].Type)
+
+	var items []ResponsesInputItem
+	require.NoError(t, json.Unmarshal(out.Input, &items))
+	require.Len(t, items, 3)
+	assert.Equal(t, "user", items[0].Role)
+	assert.Contains(t, string(items[0].Content), "data:image/jpeg;base64,AAAA")
+	assert.Equal(t, "function_call", items[1].Type)
+	assert.Equal(t, "fc_toolu_gemini_1", items[1].CallID)
+	assert.Equal(t, "function_call_output", items[2].Type)
+	assert.Equal(t, "fc_toolu_gemini_1", items[2].CallID)
+	assert.Equal(t, "a b", items[2].Output)
+}
+
+func TestGeminiThinkingEffort(t *testing.T) {
+	cfg := func(tc *GeminiThinkingConfig) *GeminiGenerationConfig {
+		return &GeminiGenerationConfig{ThinkingConfig: tc}
+	}
+	assert.Equal(t, "", geminiThinkingEffort(nil))
+	assert.Equal(t, "high", geminiThinkingEffort(cfg(&GeminiThinkingConfig{ThinkingBudget: intPtr(-1)})))
+	assert.Equal(t, "low", geminiThinkingEffort(cfg(&GeminiThinkingConfig{ThinkingBudget: intPtr(0)})))
+	assert.Equal(t, "high", geminiThinkingEffort(cfg(&GeminiThinkingConfig{ThinkingBudget: intPtr(32768)})))
+	assert.Equal(t, "low", geminiThinkingEffort(cfg(&GeminiThinkingConfig{ThinkingLevel: "LOW", ThinkingBudget: intPtr(32768)})))
+}
+
+func TestResponsesToGeminiResponse(t *testing.T) {
+	resp := &ResponsesResponse{
+		ID:     "resp_1",
+		Status: "incomplete",
+		Output: []ResponsesOutput{
+			{Type: "reasoning", Summary: []ResponsesSummary{{Type: "summary_text", Text: "think"}}},
+			{Type: "message", Content: []ResponsesContentPart{{Type: "output_text", Text: "done"}}},
+			{Type: "function_call", CallID: "fc_toolu_gemini_2", Name: "ls", Arguments: `{"dir":"."}`},
+		},
+		IncompleteDetails: &ResponsesIncompleteDetails{Reason: "max_output_tokens"},
+		Usage: &ResponsesUsage{
+			InputTokens:         20,
+			OutputTokens:        15,
+			InputTokensDetails:  &ResponsesInputTokensDetails{CachedTokens: 8},
+			OutputTokensDetails: &ResponsesOutputTokensDetails{ReasoningTokens: 5},
+		},
+	}
+
+	out := ResponsesToGeminiResponse(resp, "gpt-5.1")
+	c := out.Candidates[0]
+	assert.Equal(t, "MAX_TOKENS", c.FinishReason)
+	require.Len(t, c.Content.Parts, 3)
+	assert.True(t, c.Content.Parts[0].Thought)
+	assert.Equal(t, "done", c.Content.Parts[1].Text)
+	assert.Equal(t, "toolu_gemini_2", c.Content.Parts[2].FunctionCall.ID)
+
+	assert.Equal(t, 20, out.UsageMetadata.PromptTokenCount)
+	assert.Equal(t, 10, out.UsageMetadata.CandidatesTokenCount)
+	assert.Equal(t, 5, out.UsageMetadata.ThoughtsTokenCount)
+	assert.Equal(t, 8, out.UsageMetadata.CachedContentTokenCount)
+	assert.Equal(t, 35, out.UsageMetadata.TotalTokenCount)
+}
+
+func TestResponsesEventToGeminiChunks_Stream(t *testing.T) {
+	events := []ResponsesStreamEvent{
+		{Type: "response.created", Response: &ResponsesResponse{ID: "resp_1"}},
+		{Type: "response.reasoning_summary_text.delta", Delta: "plan"},
+		{Type: "response.output_text.delta", Delta: "Hi"},
+		{Type: "response.output_item.added", Item: &ResponsesOutput{Type: "function_call", ID: "fc_item", CallID: "call_1", Name: "ls"}},
+		{Type: "response.function_call_arguments.delta", ItemID: "fc_item", Delta: `{"dir"`},
+		{Type: "response.function_call_arguments.delta", ItemID: "fc_item", Delta: `:"."}`},
+		{Type: "response.output_item.done", Item: &ResponsesOutput{Type: "function_call", ID: "fc_item", CallID: "call_1"}},
+		{Type: "response.completed", Response: &ResponsesResponse{
+			Status: "completed",
+			Usage:  &ResponsesUsage{InputTokens: 9, OutputTokens: 4},
+		}},
+	}
+
+	state := NewResponsesEventToGeminiState("gpt-5.1")
+	var chunks []GeminiGenerateContentResponse
+	for i := range events {
+		chunks = append(chunks, ResponsesEventToGeminiChunks(&events[i], state)...)
+	}
+	assert.Empty(t, FinalizeResponsesGeminiStream(state))
+
+	require.Len(t, chunks, 3)
+	assert.True(t, chunks[0].Candidates[0].Content.Parts[0].Thought)
+	assert.Equal(t, "Hi", chunks[1].Candidates[0].Content.Parts[0].Text)
+
+	last := chunks[2]
+	fc := last.Candidates[0].Content.Parts[0].FunctionCall
+	require.NotNil(t, fc)
+	assert.Equal(t, "call_1", fc.ID)
+	assert.Equal(t, "ls", fc.Name)
+	assert.JSONEq(t, `{"dir":"."}`, string(fc.Args))
+	assert.Equal(t, "STOP", last.Candidates[0].FinishReason)
+	assert.Equal(t, 13, last.UsageMetadata.TotalTokenCount)
+	assert.Equal(t, "resp_1", last.ResponseID)
+}
@@ -0,0 +1,416 @@
+package apicompat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// geminiDefaultMaxTokens is used when the Gemini request omits
+// generationConfig.maxOutputTokens (Anthropic requires max_tokens).
+const geminiDefaultMaxTokens = 8192
+
+// geminiMinThinkingBudget is the smallest thinking budget Anthropic accepts.
+const geminiMinThinkingBudget = 1024
+
+// GeminiToAnthropic converts a Gemini generateContent request into an
+// Anthropic Messages request for the given upstream model.
+//
+//   - model-role contents become assistant messages, everything else user
+//   - functionCall / functionResponse parts become tool_use / tool_result
+//     blocks; calls without an id get a synthetic one and responses are
+//     paired with the oldest pending call of the same name
+//   - inlineData images become base64 image blocks
+//   - thought parts from earlier turns are dropped (Anthropic requires a
+//     signature it issued itself to replay thinking)
+//   - thinkingConfig maps to extended thinking
+func GeminiToAnthropic(req *GeminiGenerateContentRequest, model string) (*AnthropicRequest, error) {
+	messages, err := convertGeminiContentsToAnthropic(req.Contents)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &AnthropicRequest{
+		Model:    model,
+		Messages: messages,
+	}
+
+	system := geminiContentText(req.SystemInstruction)
+	if hint := geminiJSONResponseHint(req.GenerationConfig); hint != "" {
+		if system != "" {
+			system += "\n\n"
+		}
+		system += hint
+	}
+	if system != "" {
+		out.System, _ = json.Marshal(system)
+	}
+
+	if len(req.Tools) > 0 {
+		out.Tools = convertGeminiToolsToAnthropic(req.Tools)
+	}
+	if tc := convertGeminiToolConfigToAnthropic(req.ToolConfig); tc != nil && len(out.Tools) > 0 {
+		out.ToolChoice = tc
+	}
+
+	cfg := req.GenerationConfig
+	if cfg == nil {
+		cfg = &GeminiGenerationConfig{}
+	}
+	out.Temperature = cfg.Temperature
+	out.TopP = cfg.TopP
+	out.StopSeqs = cfg.StopSequences
+	out.MaxTokens = cfg.MaxOutputTokens
+
+	budget := geminiThinkingBudget(cfg.ThinkingConfig)
+	if out.MaxTokens <= 0 {
+		out.MaxTokens = geminiDefaultMaxTokens
+		if budget >= out.MaxTokens {
+			out.MaxTokens = budget + geminiDefaultMaxTokens
+		}
+	} else if budget >= out.MaxTokens {
+		// 显式 maxOutputTokens 优先：预算必须小于 max_tokens
+		budget = out.MaxTokens - 1
+	}
+	if budget >= geminiMinThinkingBudget {
+		out.Thinking = &AnthropicThinking{Type: "enabled", BudgetTokens: budget}
+		// Anthropic 开启 thinking 时不允许修改 temperature/top_p，
+		// 而 Gemini CLI 默认发送 temperature=0 / topP=1。
+		out.Temperature = nil
+		out.TopP = nil
+	}
+
+	return out, nil
+}
+
+// GeminiCountTokensToAnthropic converts a Gemini countTokens request into an
+// Anthropic request suitable for /v1/messages/count_tokens.
+func GeminiCountTokensToAnthropic(req *GeminiCountTokensRequest, model string) (*AnthropicRequest, error) {
+	genReq := req.GenerateContentRequest
+	if genReq == nil {
+		genReq = &GeminiGenerateContentRequest{Contents: req.Contents}
+	}
+	out, err := GeminiToAnthropic(genReq, model)
+	if err != nil {
+		return nil, err
+	}
+	out.Thinking = nil
+	return out, nil
+}
+
+// geminiThinkingBudget returns the Anthropic thinking budget requested by a
+// Gemini thinkingConfig, or 0 when thinking is not requested.
+//
+//	thinkingLevel          → defaultThinkingBudget(level)
+//	thinkingBudget = -1    → defaultThinkingBudget("high") (dynamic)
+//	thinkingBudget > 0     → max(budget, 1024)
+//	thinkingBudget = 0     → disabled
+func geminiThinkingBudget(tc *GeminiThinkingConfig) int {
+	if tc == nil {
+		return 0
+	}
+	if level := geminiThinkingLevelToEffort(tc.ThinkingLevel); level != "" {
+		return defaultThinkingBudget(level)
+	}
+	if tc.ThinkingBudget == nil {
+		if tc.IncludeThoughts {
+			return defaultThinkingBudget("high")
+		}
+		return 0
+	}
+	switch budget := *tc.ThinkingBudget; {
+	case budget < 0:
+		return defaultThinkingBudget("high")
+	case budget == 0:
+		return 0
+	case budget < geminiMinThinkingBudget:
+		return geminiMinThinkingBudget
+	default:
+		return budget
+	}
+}
+
+// geminiThinkingLevelToEffort normalises a Gemini thinkingLevel to the shared
+// low/medium/high effort scale. Unknown levels return "".
+func geminiThinkingLevelToEffort(level string) string {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "minimal", "low":
+		return "low"
+	case "medium":
+		return "medium"
+	case "high":
+		return "high"
+	default:
+		return ""
+	}
+}
+
+// geminiJSONResponseHint turns responseMimeType=application/json (and an
+// optional response schema) into a system prompt instruction, since neither
+// upstream supports Gemini's structured output parameters natively here.
+func geminiJSONResponseHint(cfg *GeminiGenerationConfig) string {
+	if cfg == nil || !strings.EqualFold(strings.TrimSpace(cfg.ResponseMimeType), "application/json") {
+		return ""
+	}
+	hint := "Respond with a single valid JSON value only, without markdown code fences or any other text."
+	schema := cfg.ResponseJSONSchema
+	if len(schema) == 0 {
+		schema = cfg.ResponseSchema
+	}
+	if len(schema) > 0 && string(schema) != "null" {
+		hint += " The JSON must conform to this JSON Schema:\n" + string(normalizeGeminiSchema(schema))
+	}
+	return hint
+}
+
+// geminiContentText joins the text parts of a content, skipping thoughts.
+func geminiContentText(c *GeminiContent) string {
+	if c == nil {
+		return ""
+	}
+	var texts []string
+	for _, p := range c.Parts {
+		if p.Text != "" && !p.Thought {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// geminiToolIDState pairs functionResponse parts with earlier functionCall
+// parts when the client did not send ids.
+type geminiToolIDState struct {
+	counter int
+	pending map[string][]string // function name → FIFO of unanswered tool_use ids
+}
+
+func (s *geminiToolIDState) callID(fc *GeminiFunctionCall) string {
+	id := fc.ID
+	if id == "" {
+		s.counter++
+		id = fmt.Sprintf("toolu_gemini_%d", s.counter)
+	}
+	s.pending[fc.Name] = append(s.pending[fc.Name], id)
+	return id
+}
+
+func (s *geminiToolIDState) responseID(fr *GeminiFunctionResponse) string {
+	queue := s.pending[fr.Name]
+	if fr.ID != "" {
+		for i, id := range queue {
+			if id == fr.ID {
+				s.pending[fr.Name] = append(queue[:i:i], queue[i+1:]...)
+				break
+			}
+		}
+		return fr.ID
+	}
+	if len(queue) == 0 {
+		return ""
+	}
+	s.pending[fr.Name] = queue[1:]
+	return queue[0]
+}
+
+// convertGeminiContentsToAnthropic converts Gemini contents into alternating
+// Anthropic messages.
+func convertGeminiContentsToAnthropic(contents []GeminiContent) ([]AnthropicMessage, error) {
+	ids := &geminiToolIDState{pending: make(map[string][]string)}
+	var messages []AnthropicMessage
+	for _, c := range contents {
+		role := "user"
+		if c.Role == "model" {
+			role = "assistant"
+		}
+		var blocks []AnthropicContentBlock
+		for _, p := range c.Parts {
+			block, ok := geminiPartToAnthropicBlock(p, role, ids)
+			if ok {
+				blocks = append(blocks, block)
+			}
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		content, err := json.Marshal(blocks)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, AnthropicMessage{Role: role, Content: content})
+	}
+	return mergeConsecutiveMessages(messages), nil
+}
+
+// geminiPartToAnthropicBlock converts a single Gemini part. ok is false when
+// the part has no Anthropic equivalent and should be dropped.
+func geminiPartToAnthropicBlock(p GeminiPart, role string, ids *geminiToolIDState) (AnthropicContentBlock, bool) {
+	switch {
+	case p.Thought:
+		return AnthropicContentBlock{}, false
+	case p.FunctionCall != nil:
+		input := p.FunctionCall.Args
+		if len(input) == 0 || string(input) == "null" {
+			input = json.RawMessage(`{}`)
+		}
+		return AnthropicContentBlock{
+			Type:  "tool_use",
+			ID:    ids.callID(p.FunctionCall),
+			Name:  p.FunctionCall.Name,
+			Input: input,
+		}, true
+	case p.FunctionResponse != nil:
+		output := geminiFunctionResponseOutput(p.FunctionResponse.Response)
+		id := ids.responseID(p.FunctionResponse)
+		if id == "" {
+			// 找不到对应的 functionCall 时降级为文本，避免上游拒绝孤立的 tool_result
+			return AnthropicContentBlock{
+				Type: "text",
+				Text: fmt.Sprintf("Result of function %s: %s", p.FunctionResponse.Name, output),
+			}, true
+		}
+		content, _ := json.Marshal(output)
+		return AnthropicContentBlock{
+			Type:      "tool_result",
+			ToolUseID: id,
+			Content:   content,
+		}, true
+	case p.InlineData != nil:
+		if role != "user" || !strings.HasPrefix(strings.ToLower(p.InlineData.MimeType), "image/") || p.InlineData.Data == "" {
+			return AnthropicContentBlock{}, false
+		}
+		return AnthropicContentBlock{
+			Type: "image",
+			Source: &AnthropicImageSource{
+				Type:      "base64",
+				MediaType: p.InlineData.MimeType,
+				Data:      p.InlineData.Data,
+			},
+		}, true
+	case p.Text != "":
+		return AnthropicContentBlock{Type: "text", Text: p.Text}, true
+	default:
+		return AnthropicContentBlock{}, false
+	}
+}
+
+// geminiFunctionResponseOutput extracts the tool output text from a Gemini
+// functionResponse.response object. Gemini CLI wraps plain results as
+// {"output": "..."}; other shapes are passed through as JSON.
+func geminiFunctionResponseOutput(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &obj); err == nil && len(obj) == 1 {
+		for _, key := range []string{"output", "content", "result"} {
+			if v, ok := obj[key]; ok {
+				var s string
+				if json.Unmarshal(v, &s) == nil {
+					return s
+				}
+			}
+		}
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, raw); err == nil {
+		return compact.String()
+	}
+	return string(raw)
+}
+
+// convertGeminiToolsToAnthropic maps Gemini function declarations to
+// Anthropic tools. googleSearch maps to Anthropic's web_search server tool.
+func convertGeminiToolsToAnthropic(tools []GeminiTool) []AnthropicTool {
+	var out []AnthropicTool
+	for _, t := range tools {
+		for _, fd := range t.FunctionDeclarations {
+			schema := fd.ParametersJSONSchema
+			if len(schema) == 0 || string(schema) == "null" {
+				schema = normalizeGeminiSchema(fd.Parameters)
+			}
+			out = append(out, AnthropicTool{
+				Name:        fd.Name,
+				Description: fd.Description,
+				InputSchema: normalizeAnthropicInputSchema(schema),
+			})
+		}
+		if len(t.GoogleSearch) > 0 {
+			out = append(out, AnthropicTool{Type: "web_search_20250305", Name: "web_search"})
+		}
+	}
+	return out
+}
+
+// convertGeminiToolConfigToAnthropic maps functionCallingConfig to tool_choice.
+//
+//	AUTO                          → {"type":"auto"}
+//	ANY                           → {"type":"any"}
+//	ANY + one allowedFunctionName → {"type":"tool","name":"X"}
+//	NONE                          → {"type":"none"}
+func convertGeminiToolConfigToAnthropic(tc *GeminiToolConfig) json.RawMessage {
+	if tc == nil || tc.FunctionCallingConfig == nil {
+		return nil
+	}
+	var choice map[string]string
+	switch strings.ToUpper(tc.FunctionCallingConfig.Mode) {
+	case "AUTO":
+		choice = map[string]string{"type": "auto"}
+	case "ANY":
+		if names := tc.FunctionCallingConfig.AllowedFunctionNames; len(names) == 1 {
+			choice = map[string]string{"type": "tool", "name": names[0]}
+		} else {
+			choice = map[string]string{"type": "any"}
+		}
+	case "NONE":
+		choice = map[string]string{"type": "none"}
+	default:
+		return nil
+	}
+	raw, _ := json.Marshal(choice)
+	return raw
+}
+
+// normalizeGeminiSchema converts a Gemini OpenAPI-subset schema into standard
+// JSON Schema by lower-casing enum-style type names ("OBJECT" → "object").
+func normalizeGeminiSchema(schema json.RawMessage) json.RawMessage {
+	if len(schema) == 0 || string(schema) == "null" {
+		return schema
+	}
+	var v any
+	if err := json.Unmarshal(schema, &v); err != nil {
+		return schema
+	}
+	out, err := json.Marshal(lowerGeminiSchemaTypes(v))
+	if err != nil {
+		return schema
+	}
+	return out
+}
+
+func lowerGeminiSchemaTypes(v any) any {
+	switch node := v.(type) {
+	case map[string]any:
+		for k, child := range node {
+			if k == "type" {
+				if s, ok := child.(string); ok {
+					if s == "TYPE_UNSPECIFIED" {
+						delete(node, k)
+						continue
+					}
+					node[k] = strings.ToLower(s)
+					continue
+				}
+			}
+			node[k] = lowerGeminiSchemaTypes(child)
+		}
+		return node
+	case []any:
+		for i, child := range node {
+			node[i] = lowerGeminiSchemaTypes(child)
+		}
+		return node
+	default:
+		return v
+	}
+}
@@ -0,0 +1,285 @@
+package apicompat
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// ---------------------------------------------------------------------------
+// Request: GeminiGenerateContentRequest → ResponsesRequest
+// ---------------------------------------------------------------------------
+
+// GeminiToResponses converts a Gemini generateContent request into a
+// Responses API request for the given upstream model.
+//
+// The conversion goes through the Anthropic representation so tool call id
+// pairing, image handling and tool schema normalisation stay identical to
+// the /v1/messages → Responses path. Reasoning effort comes from the Gemini
+// thinkingConfig instead of the Anthropic defaults.
+func GeminiToResponses(req *GeminiGenerateContentRequest, model string) (*ResponsesRequest, error) {
+	anthReq, err := GeminiToAnthropic(req, model)
+	if err != nil {
+		return nil, err
+	}
+	// 以 Gemini 原始参数为准：GeminiToAnthropic 为满足 Anthropic thinking 约束可能改写了这些字段
+	anthReq.Thinking = nil
+	anthReq.MaxTokens = 0
+	if req.GenerationConfig != nil {
+		anthReq.MaxTokens = req.GenerationConfig.MaxOutputTokens
+	}
+
+	out, err := AnthropicToResponses(anthReq)
+	if err != nil {
+		return nil, err
+	}
+	// Responses 路径始终请求 reasoning，推理模型不接受 temperature/top_p，
+	// 而 Gemini CLI 默认总会发送这两个参数。
+	out.Temperature = nil
+	out.TopP = nil
+	if effort := geminiThinkingEffort(req.GenerationConfig); effort != "" {
+		out.Reasoning.Effort = effort
+	}
+	return out, nil
+}
+
+// geminiThinkingEffort maps a Gemini thinkingConfig to a Responses reasoning
+// effort. Returns "" when the request does not configure thinking.
+//
+//	thinkingLevel          → low / medium / high
+//	thinkingBudget = 0     → low (reasoning cannot be disabled)
+//	thinkingBudget ≤ 4096  → low
+//	thinkingBudget ≤ 16384 → medium
+//	otherwise / dynamic    → high
+func geminiThinkingEffort(cfg *GeminiGenerationConfig) string {
+	if cfg == nil || cfg.ThinkingConfig == nil {
+		return ""
+	}
+	tc := cfg.ThinkingConfig
+	if effort := geminiThinkingLevelToEffort(tc.ThinkingLevel); effort != "" {
+		return effort
+	}
+	if tc.ThinkingBudget == nil {
+		return ""
+	}
+	switch budget := *tc.ThinkingBudget; {
+	case budget < 0:
+		return "high"
+	case budget <= 4096:
+		return "low"
+	case budget <= 16384:
+		return "medium"
+	default:
+		return "high"
+	}
+}
+
+// GeminiCountTokensToResponses converts a Gemini countTokens request into the
+// Responses request that would be sent for the same conversation. It is used
+// to estimate token counts since the Responses API has no counting endpoint.
+func GeminiCountTokensToResponses(req *GeminiCountTokensRequest, model string) (*ResponsesRequest, error) {
+	genReq := req.GenerateContentRequest
+	if genReq == nil {
+		genReq = &GeminiGenerateContentRequest{Contents: req.Contents}
+	}
+	return GeminiToResponses(genReq, model)
+}
+
+// ---------------------------------------------------------------------------
+// Non-streaming: ResponsesResponse → GeminiGenerateContentResponse
+// ---------------------------------------------------------------------------
+
+// ResponsesToGeminiResponse converts a Responses API response into a Gemini
+// generateContent response. Reasoning summaries become thought parts and
+// function_call items become functionCall parts.
+func ResponsesToGeminiResponse(resp *ResponsesResponse, model string) *GeminiGenerateContentResponse {
+	var parts []GeminiPart
+	for _, item := range resp.Output {
+		switch item.Type {
+		case "reasoning":
+			var sb strings.Builder
+			for _, s := range item.Summary {
+				if s.Type == "summary_text" {
+					sb.WriteString(s.Text)
+				}
+			}
+			if sb.Len() > 0 {
+				parts = append(parts, GeminiPart{Text: sb.String(), Thought: true})
+			}
+		case "message":
+			for _, c := range item.Content {
+				if c.Type == "output_text" && c.Text != "" {
+					parts = append(parts, GeminiPart{Text: c.Text})
+				}
+			}
+		case "function_call":
+			parts = append(parts, GeminiPart{FunctionCall: &GeminiFunctionCall{
+				ID:   fromResponsesCallID(item.CallID),
+				Name: item.Name,
+				Args: normalizeFunctionArgs(json.RawMessage(item.Arguments)),
+			}})
+		}
+	}
+	if parts == nil {
+		parts = []GeminiPart{}
+	}
+
+	return &GeminiGenerateContentResponse{
+		Candidates: []GeminiCandidate{{
+			Content:      GeminiContent{Role: "model", Parts: parts},
+			FinishReason: responsesStatusToGeminiFinishReason(resp.Status, resp.IncompleteDetails),
+		}},
+		UsageMetadata: responsesUsageToGemini(resp.Usage),
+		ModelVersion:  model,
+		ResponseID:    resp.ID,
+	}
+}
+
+// responsesStatusToGeminiFinishReason maps a Responses status to a Gemini
+// finishReason.
+func responsesStatusToGeminiFinishReason(status string, details *ResponsesIncompleteDetails) string {
+	if status == "incomplete" && details != nil {
+		switch details.Reason {
+		case "max_output_tokens":
+			return "MAX_TOKENS"
+		case "content_filter":
+			return "SAFETY"
+		}
+	}
+	return "STOP"
+}
+
+// responsesUsageToGemini converts Responses usage. Reasoning tokens are
+// reported separately as thoughtsTokenCount, as Gemini does.
+func responsesUsageToGemini(u *ResponsesUsage) *GeminiUsageMetadata {
+	if u == nil {
+		return &GeminiUsageMetadata{}
+	}
+	out := &GeminiUsageMetadata{
+		PromptTokenCount:     u.InputTokens,
+		CandidatesTokenCount: u.OutputTokens,
+		TotalTokenCount:      u.InputTokens + u.OutputTokens,
+	}
+	if u.InputTokensDetails != nil {
+		out.CachedContentTokenCount = u.InputTokensDetails.CachedTokens
+	}
+	if u.OutputTokensDetails != nil && u.OutputTokensDetails.ReasoningTokens > 0 {
+		out.ThoughtsTokenCount = u.OutputTokensDetails.ReasoningTokens
+		out.CandidatesTokenCount -= u.OutputTokensDetails.ReasoningTokens
+	}
+	return out
+}
+
+// ---------------------------------------------------------------------------
+// Streaming: ResponsesStreamEvent → []GeminiGenerateContentResponse
+// ---------------------------------------------------------------------------
+
+// ResponsesEventToGeminiState tracks state for converting a sequence of
+// Responses SSE events into Gemini streamGenerateContent chunks. Like the
+// Anthropic converter it holds back the latest chunk so the terminal event
+// can attach finishReason and usageMetadata to it.
+type ResponsesEventToGeminiState struct {
+	Model      string
+	ResponseID string
+
+	// function_call arguments accumulated per output item id
+	toolCalls map[string]*geminiPendingToolCall
+
+	pending  *GeminiGenerateContentResponse
+	Finished bool
+}
+
+// NewResponsesEventToGeminiState returns an initialised stream state.
+func NewResponsesEventToGeminiState(model string) *ResponsesEventToGeminiState {
+	return &ResponsesEventToGeminiState{
+		Model:     model,
+		toolCalls: make(map[string]*geminiPendingToolCall),
+	}
+}
+
+// ResponsesEventToGeminiChunks converts a single Responses SSE event into zero
+// or more Gemini stream chunks, updating state as it goes.
+func ResponsesEventToGeminiChunks(evt *ResponsesStreamEvent, state *ResponsesEventToGeminiState) []GeminiGenerateContentResponse {
+	if state.Finished {
+		return nil
+	}
+	switch evt.Type {
+	case "response.created":
+		if evt.Response != nil {
+			state.ResponseID = evt.Response.ID
+		}
+	case "response.output_text.delta":
+		if evt.Delta != "" {
+			return state.push(GeminiPart{Text: evt.Delta})
+		}
+	case "response.reasoning_summary_text.delta":
+		if evt.Delta != "" {
+			return state.push(GeminiPart{Text: evt.Delta, Thought: true})
+		}
+	case "response.output_item.added":
+		if evt.Item != nil && evt.Item.Type == "function_call" {
+			state.toolCalls[evt.Item.ID] = &geminiPendingToolCall{
+				id:   fromResponsesCallID(evt.Item.CallID),
+				name: evt.Item.Name,
+			}
+		}
+	case "response.function_call_arguments.delta":
+		if tc := state.toolCalls[evt.ItemID]; tc != nil {
+			tc.args.WriteString(evt.Delta)
+		}
+	case "response.output_item.done":
+		if evt.Item == nil || evt.Item.Type != "function_call" {
+			return nil
+		}
+		args := evt.Item.Arguments
+		id := fromResponsesCallID(evt.Item.CallID)
+		name := evt.Item.Name
+		if tc := state.toolCalls[evt.Item.ID]; tc != nil {
+			if args == "" {
+				args = tc.args.String()
+			}
+			if name == "" {
+				name = tc.name
+			}
+			delete(state.toolCalls, evt.Item.ID)
+		}
+		return state.push(GeminiPart{FunctionCall: &GeminiFunctionCall{
+			ID:   id,
+			Name: name,
+			Args: normalizeFunctionArgs(json.RawMessage(args)),
+		}})
+	case "response.completed", "response.incomplete", "response.failed":
+		return state.finish(evt.Response)
+	}
+	return nil
+}
+
+// FinalizeResponsesGeminiStream flushes the held-back chunk with a finish
+// reason if the stream ended without a terminal event.
+func FinalizeResponsesGeminiStream(state *ResponsesEventToGeminiState) []GeminiGenerateContentResponse {
+	if state == nil || state.Finished {
+		return nil
+	}
+	return state.finish(nil)
+}
+
+func (s *ResponsesEventToGeminiState) push(part GeminiPart) []GeminiGenerateContentResponse {
+	prev := s.pending
+	s.pending = newGeminiPartChunk(s.Model, s.ResponseID, part)
+	if prev == nil {
+		return nil
+	}
+	return []GeminiGenerateContentResponse{*prev}
+}
+
+func (s *ResponsesEventToGeminiState) finish(resp *ResponsesResponse) []GeminiGenerateContentResponse {
+	s.Finished = true
+	finishReason := "STOP"
+	usage := &GeminiUsageMetadata{}
+	if resp != nil {
+		finishReason = responsesStatusToGeminiFinishReason(resp.Status, resp.IncompleteDetails)
+		usage = responsesUsageToGemini(resp.Usage)
+	}
+	last := finalGeminiChunk(s.pending, s.Model, s.ResponseID, finishReason, usage)
+	s.pending = nil
+	return []GeminiGenerateContentResponse{*last}
+}
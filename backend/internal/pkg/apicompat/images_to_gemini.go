@@ -0,0 +1,200 @@
+package apicompat
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoImageInResponse is returned when a Gemini response carries no inline
+// image data (e.g. the prompt was blocked or the model answered with text only).
+var ErrNoImageInResponse = errors.New("upstream returned no image")
+
+// geminiAspectRatios lists the aspect ratios accepted by Gemini imageConfig.
+var geminiAspectRatios = []string{"1:1", "2:3", "3:2", "3:4", "4:3", "4:5", "5:4", "9:16", "16:9", "21:9"}
+
+// ImagesRequestCount returns the number of images requested (n), defaulting to 1.
+func ImagesRequestCount(req *ImagesRequest) int {
+	if req == nil || req.N == nil || *req.N <= 0 {
+		return 1
+	}
+	return *req.N
+}
+
+// ImageSizeTier maps an OpenAI size ("1024x1024") or a Gemini size label
+// ("1K"/"2K"/"4K") to the billing tier label used by image pricing.
+// The longest edge decides the tier: ≤1024 → 1K, ≤2048 → 2K, otherwise 4K.
+// Empty, "auto" and unparseable sizes return "".
+func ImageSizeTier(size string) string {
+	size = strings.ToUpper(strings.TrimSpace(size))
+	switch size {
+	case "1K", "2K", "4K":
+		return size
+	}
+	w, h, ok := parseImageDimensions(size)
+	if !ok {
+		return ""
+	}
+	longest := max(w, h)
+	switch {
+	case longest <= 1024:
+		return "1K"
+	case longest <= 2048:
+		return "2K"
+	default:
+		return "4K"
+	}
+}
+
+// imageAspectRatio picks the Gemini aspect ratio closest to an OpenAI size.
+func imageAspectRatio(size string) string {
+	w, h, ok := parseImageDimensions(strings.ToUpper(strings.TrimSpace(size)))
+	if !ok {
+		return ""
+	}
+	target := math.Log(float64(w) / float64(h))
+	best, bestDiff := "", math.MaxFloat64
+	for _, ratio := range geminiAspectRatios {
+		rw, rh, _ := strings.Cut(ratio, ":")
+		a, _ := strconv.ParseFloat(rw, 64)
+		b, _ := strconv.ParseFloat(rh, 64)
+		if diff := math.Abs(math.Log(a/b) - target); diff < bestDiff {
+			best, bestDiff = ratio, diff
+		}
+	}
+	return best
+}
+
+func parseImageDimensions(size string) (int, int, bool) {
+	ws, hs, ok := strings.Cut(size, "X")
+	if !ok {
+		return 0, 0, false
+	}
+	w, err1 := strconv.Atoi(strings.TrimSpace(ws))
+	h, err2 := strconv.Atoi(strings.TrimSpace(hs))
+	if err1 != nil || err2 != nil || w <= 0 || h <= 0 {
+		return 0, 0, false
+	}
+	return w, h, true
+}
+
+// ImagesToGemini converts an OpenAI images request (generations or edits)
+// into a Gemini generateContent body for an image generation model.
+// Edit source images are sent as inlineData parts before the prompt; Gemini
+// has no mask input, so Mask is ignored.
+func ImagesToGemini(req *ImagesRequest) ([]byte, error) {
+	if req == nil || strings.TrimSpace(req.Prompt) == "" {
+		return nil, errors.New("prompt is required")
+	}
+
+	parts := make([]map[string]any, 0, len(req.Images)+1)
+	for _, img := range req.Images {
+		mimeType := img.MimeType
+		if mimeType == "" {
+			mimeType = "image/png"
+		}
+		parts = append(parts, map[string]any{
+			"inlineData": map[string]any{
+				"mimeType": mimeType,
+				"data":     base64.StdEncoding.EncodeToString(img.Data),
+			},
+		})
+	}
+	parts = append(parts, map[string]any{"text": req.Prompt})
+
+	generationConfig := map[string]any{
+		"responseModalities": []string{"TEXT", "IMAGE"},
+	}
+	imageConfig := map[string]any{}
+	if ratio := imageAspectRatio(req.Size); ratio != "" {
+		imageConfig["aspectRatio"] = ratio
+	}
+	if tier := ImageSizeTier(req.Size); tier != "" {
+		imageConfig["imageSize"] = tier
+	}
+	if len(imageConfig) > 0 {
+		generationConfig["imageConfig"] = imageConfig
+	}
+
+	return json.Marshal(map[string]any{
+		"contents": []map[string]any{
+			{"role": "user", "parts": parts},
+		},
+		"generationConfig": generationConfig,
+	})
+}
+
+type geminiImageResponse struct {
+	Response   *geminiImageResponse `json:"response,omitempty"` // Code Assist envelope
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text       string `json:"text,omitempty"`
+				Thought    bool   `json:"thought,omitempty"`
+				InlineData *struct {
+					MimeType string `json:"mimeType"`
+					Data     string `json:"data"`
+				} `json:"inlineData,omitempty"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata,omitempty"`
+}
+
+// GeminiToImages converts a Gemini generateContent response into an OpenAI
+// images response. responseFormat "url" yields data URLs because generated
+// images are not hosted anywhere; any other value yields b64_json.
+// Text parts are returned as revised_prompt of the first image.
+func GeminiToImages(body []byte, responseFormat string) (*ImagesResponse, error) {
+	var resp geminiImageResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parse gemini response: %w", err)
+	}
+	if resp.Response != nil {
+		resp = *resp.Response
+	}
+
+	out := &ImagesResponse{Created: time.Now().Unix(), Data: []ImageData{}}
+	var texts []string
+	for _, cand := range resp.Candidates {
+		for _, part := range cand.Content.Parts {
+			if part.InlineData != nil && part.InlineData.Data != "" {
+				if strings.EqualFold(responseFormat, "url") {
+					mimeType := part.InlineData.MimeType
+					if mimeType == "" {
+						mimeType = "image/png"
+					}
+					out.Data = append(out.Data, ImageData{URL: "data:" + mimeType + ";base64," + part.InlineData.Data})
+				} else {
+					out.Data = append(out.Data, ImageData{B64JSON: part.InlineData.Data})
+				}
+				continue
+			}
+			if !part.Thought && strings.TrimSpace(part.Text) != "" {
+				texts = append(texts, strings.TrimSpace(part.Text))
+			}
+		}
+	}
+	if len(out.Data) == 0 {
+		return nil, ErrNoImageInResponse
+	}
+	if len(texts) > 0 {
+		out.Data[0].RevisedPrompt = strings.Join(texts, "\n")
+	}
+	if u := resp.UsageMetadata; u != nil {
+		out.Usage = &ImagesUsage{
+			InputTokens:  u.PromptTokenCount,
+			OutputTokens: u.CandidatesTokenCount,
+			TotalTokens:  u.PromptTokenCount + u.CandidatesTokenCount,
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,96 @@
+package apicompat
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestImageSizeTier(t *testing.T) {
+	tests := []struct {
+		size string
+		want string
+	}{
+		{"", ""},
+		{"auto", ""},
+		{"1024x1024", "1K"},
+		{"512x512", "1K"},
+		{"1536x1024", "2K"},
+		{"1792x1024", "2K"},
+		{"4096x2304", "4K"},
+		{"2k", "2K"},
+		{"4K", "4K"},
+		{"bogus", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ImageSizeTier(tt.size), tt.size)
+	}
+}
+
+func TestImagesRequestCount(t *testing.T) {
+	assert.Equal(t, 1, ImagesRequestCount(&ImagesRequest{}))
+	n := 3
+	assert.Equal(t, 3, ImagesRequestCount(&ImagesRequest{N: &n}))
+	zero := 0
+	assert.Equal(t, 1, ImagesRequestCount(&ImagesRequest{N: &zero}))
+}
+
+func TestImagesToGemini_Generation(t *testing.T) {
+	body, err := ImagesToGemini(&ImagesRequest{Prompt: "a red fox", Size: "1792x1024"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "user", gjson.GetBytes(body, "contents.0.role").String())
+	assert.Equal(t, "a red fox", gjson.GetBytes(body, "contents.0.parts.0.text").String())
+	assert.Equal(t, "16:9", gjson.GetBytes(body, "generationConfig.imageConfig.aspectRatio").String())
+	assert.Equal(t, "2K", gjson.GetBytes(body, "generationConfig.imageConfig.imageSize").String())
+	assert.Equal(t, "IMAGE", gjson.GetBytes(body, "generationConfig.responseModalities.1").String())
+}
+
+func TestImagesToGemini_EditIncludesSourceImages(t *testing.T) {
+	body, err := ImagesToGemini(&ImagesRequest{
+		Prompt: "make it blue",
+		Images: []ImageInput{{MimeType: "image/jpeg", Data: []byte("abc")}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "image/jpeg", gjson.GetBytes(body, "contents.0.parts.0.inlineData.mimeType").String())
+	assert.Equal(t, "YWJj", gjson.GetBytes(body, "contents.0.parts.0.inlineData.data").String())
+	assert.Equal(t, "make it blue", gjson.GetBytes(body, "contents.0.parts.1.text").String())
+	assert.False(t, gjson.GetBytes(body, "generationConfig.imageConfig").Exists())
+}
+
+func TestImagesToGemini_RequiresPrompt(t *testing.T) {
+	_, err := ImagesToGemini(&ImagesRequest{Prompt: "  "})
+	assert.Error(t, err)
+}
+
+func TestGeminiToImages(t *testing.T) {
+	body := []byte(`{"candidates":[{"content":{"parts":[
+		{"text":"thinking","thought":true},
+		{"text":"Here is your fox"},
+		{"inlineData":{"mimeType":"image/png","data":"AAAA"}}
+	]}}],"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":1290}}`)
+
+	resp, err := GeminiToImages(body, "b64_json")
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "AAAA", resp.Data[0].B64JSON)
+	assert.Equal(t, "Here is your fox", resp.Data[0].RevisedPrompt)
+	require.NotNil(t, resp.Usage)
+	assert.Equal(t, 1300, resp.Usage.TotalTokens)
+
+	resp, err = GeminiToImages(body, "url")
+	require.NoError(t, err)
+	assert.Equal(t, "data:image/png;base64,AAAA", resp.Data[0].URL)
+}
+
+func TestGeminiToImages_UnwrapsEnvelopeAndRejectsTextOnly(t *testing.T) {
+	resp, err := GeminiToImages([]byte(`{"response":{"candidates":[{"content":{"parts":[{"inlineData":{"mimeType":"image/png","data":"QQ=="}}]}}]}}`), "")
+	require.NoError(t, err)
+	assert.Equal(t, "QQ==", resp.Data[0].B64JSON)
+
+	_, err = GeminiToImages([]byte(`{"candidates":[{"content":{"parts":[{"text":"I can't draw that"}]}}]}`), "")
+	assert.ErrorIs(t, err, ErrNoImageInResponse)
+}
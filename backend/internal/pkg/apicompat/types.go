@@ -439,6 +439,9 @@ type ChatUsage struct {
 	CompletionTokens    int               `json:"completion_tokens"`
 	TotalTokens         int               `json:"total_tokens"`
 	PromptTokensDetails *ChatTokenDetails `json:"prompt_tokens_details,omitempty"`
+
+	// DeepSeek reports prompt cache hits outside prompt_tokens_details.
+	PromptCacheHitTokens int `json:"prompt_cache_hit_tokens,omitempty"`
 }
 
 // ChatTokenDetails provides a breakdown of token usage.
@@ -473,6 +476,269 @@ type ChatDelta struct {
 	ToolCalls        []ChatToolCall `json:"tool_calls,omitempty"`
 }
 
+// ---------------------------------------------------------------------------
+// OpenAI Embeddings API types
+// ---------------------------------------------------------------------------
+
+// EmbeddingsRequest is the request body for POST /v1/embeddings.
+type EmbeddingsRequest struct {
+	Model          string          `json:"model"`
+	Input          json.RawMessage `json:"input"`                     // string | []string | []int | [][]int
+	EncodingFormat string          `json:"encoding_format,omitempty"` // "float" | "base64"
+	Dimensions     *int            `json:"dimensions,omitempty"`
+	User           string          `json:"user,omitempty"`
+}
+
+// EmbeddingsResponse is the response from POST /v1/embeddings.
+type EmbeddingsResponse struct {
+	Object string          `json:"object"` // "list"
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  EmbeddingsUsage `json:"usage"`
+}
+
+// EmbeddingData is a single embedding vector in the response.
+type EmbeddingData struct {
+	Object    string `json:"object"` // "embedding"
+	Index     int    `json:"index"`
+	Embedding any    `json:"embedding"` // []float64, or base64 string when encoding_format=base64
+}
+
+// EmbeddingsUsage holds token counts for an embeddings request.
+type EmbeddingsUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// ---------------------------------------------------------------------------
+// Gemini embedding API types
+// ---------------------------------------------------------------------------
+
+// GeminiEmbedContentRequest is a single embedContent request.
+// In batchEmbedContents each entry must also carry Model ("models/{model}").
+type GeminiEmbedContentRequest struct {
+	Model                string             `json:"model,omitempty"`
+	Content              GeminiEmbedContent `json:"content"`
+	OutputDimensionality *int               `json:"outputDimensionality,omitempty"`
+}
+
+// GeminiEmbedContent holds the text parts to embed.
+type GeminiEmbedContent struct {
+	Parts []GeminiEmbedPart `json:"parts"`
+}
+
+// GeminiEmbedPart is a single text part.
+type GeminiEmbedPart struct {
+	Text string `json:"text"`
+}
+
+// GeminiBatchEmbedContentsRequest is the request body for :batchEmbedContents.
+type GeminiBatchEmbedContentsRequest struct {
+	Requests []GeminiEmbedContentRequest `json:"requests"`
+}
+
+// GeminiContentEmbedding is a single embedding vector returned by Gemini.
+type GeminiContentEmbedding struct {
+	Values []float64 `json:"values"`
+}
+
+// GeminiEmbedContentResponse is the response from :embedContent.
+type GeminiEmbedContentResponse struct {
+	Embedding *GeminiContentEmbedding `json:"embedding,omitempty"`
+}
+
+// GeminiBatchEmbedContentsResponse is the response from :batchEmbedContents.
+type GeminiBatchEmbedContentsResponse struct {
+	Embeddings []GeminiContentEmbedding `json:"embeddings"`
+}
+
+// ---------------------------------------------------------------------------
+// OpenAI Images API types
+// ---------------------------------------------------------------------------
+
+// ImagesRequest is the request body for POST /v1/images/generations.
+// /v1/images/edits is multipart; its text fields are parsed into the same
+// struct and the uploaded files land in Images / Mask.
+type ImagesRequest struct {
+	Model          string `json:"model"`
+	Prompt         string `json:"prompt"`
+	N              *int   `json:"n,omitempty"`
+	Size           string `json:"size,omitempty"`            // "1024x1024" | "1536x1024" | "auto" | "1K" ...
+	Quality        string `json:"quality,omitempty"`         // "standard" | "hd" | "low" | "medium" | "high" | "auto"
+	ResponseFormat string `json:"response_format,omitempty"` // "url" | "b64_json"
+	Style          string `json:"style,omitempty"`
+	Background     string `json:"background,omitempty"`
+	OutputFormat   string `json:"output_format,omitempty"`
+	User           string `json:"user,omitempty"`
+
+	Images []ImageInput `json:"-"`
+	Mask   *ImageInput  `json:"-"`
+}
+
+// ImageInput is an uploaded image from an images edit request.
+type ImageInput struct {
+	MimeType string
+	Data     []byte
+}
+
+// ImagesResponse is the response from the images endpoints.
+type ImagesResponse struct {
+	Created int64        `json:"created"`
+	Data    []ImageData  `json:"data"`
+	Usage   *ImagesUsage `json:"usage,omitempty"`
+}
+
+// ImageData is a single generated image.
+type ImageData struct {
+	B64JSON       string `json:"b64_json,omitempty"`
+	URL           string `json:"url,omitempty"`
+	RevisedPrompt string `json:"revised_prompt,omitempty"`
+}
+
+// ImagesUsage holds token counts reported for an images request.
+type ImagesUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// ---------------------------------------------------------------------------
+// Gemini generateContent API types
+// ---------------------------------------------------------------------------
+
+// GeminiGenerateContentRequest is the request body for
+// POST /v1beta/models/{model}:generateContent and :streamGenerateContent.
+type GeminiGenerateContentRequest struct {
+	Contents          []GeminiContent         `json:"contents"`
+	SystemInstruction *GeminiContent          `json:"systemInstruction,omitempty"`
+	Tools             []GeminiTool            `json:"tools,omitempty"`
+	ToolConfig        *GeminiToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+// GeminiContent is a single turn in a Gemini conversation.
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"` // "user" | "model"
+	Parts []GeminiPart `json:"parts"`
+}
+
+// GeminiPart is one part inside a Gemini content. Exactly one of the data
+// fields is populated; Thought marks text parts that carry model reasoning.
+type GeminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	ThoughtSignature string                  `json:"thoughtSignature,omitempty"`
+	InlineData       *GeminiInlineData       `json:"inlineData,omitempty"`
+	FunctionCall     *GeminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *GeminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// GeminiInlineData carries base64-encoded media such as images.
+type GeminiInlineData struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// GeminiFunctionCall is a function call requested by the model.
+type GeminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+// GeminiFunctionResponse is the client's result for a function call.
+type GeminiFunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// GeminiTool groups the function declarations (or built-in tools) available
+// to the model.
+type GeminiTool struct {
+	FunctionDeclarations []GeminiFunctionDeclaration `json:"functionDeclarations,omitempty"`
+	GoogleSearch         json.RawMessage             `json:"googleSearch,omitempty"`
+}
+
+// GeminiFunctionDeclaration describes a callable function. Newer clients send
+// a standard JSON Schema in ParametersJSONSchema; older ones use the OpenAPI
+// subset in Parameters.
+type GeminiFunctionDeclaration struct {
+	Name                 string          `json:"name"`
+	Description          string          `json:"description,omitempty"`
+	Parameters           json.RawMessage `json:"parameters,omitempty"`
+	ParametersJSONSchema json.RawMessage `json:"parametersJsonSchema,omitempty"`
+}
+
+// GeminiToolConfig controls how the model uses the declared functions.
+type GeminiToolConfig struct {
+	FunctionCallingConfig *GeminiFunctionCallingConfig `json:"functionCallingConfig,omitempty"`
+}
+
+// GeminiFunctionCallingConfig selects the function calling mode.
+type GeminiFunctionCallingConfig struct {
+	Mode                 string   `json:"mode,omitempty"` // "AUTO" | "ANY" | "NONE"
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+// GeminiGenerationConfig holds sampling and output parameters.
+type GeminiGenerationConfig struct {
+	Temperature        *float64              `json:"temperature,omitempty"`
+	TopP               *float64              `json:"topP,omitempty"`
+	TopK               *float64              `json:"topK,omitempty"`
+	MaxOutputTokens    int                   `json:"maxOutputTokens,omitempty"`
+	StopSequences      []string              `json:"stopSequences,omitempty"`
+	ResponseMimeType   string                `json:"responseMimeType,omitempty"`
+	ResponseSchema     json.RawMessage       `json:"responseSchema,omitempty"`
+	ResponseJSONSchema json.RawMessage       `json:"responseJsonSchema,omitempty"`
+	ThinkingConfig     *GeminiThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+// GeminiThinkingConfig configures model thinking. ThinkingBudget -1 means
+// dynamic and 0 disables thinking.
+type GeminiThinkingConfig struct {
+	IncludeThoughts bool   `json:"includeThoughts,omitempty"`
+	ThinkingBudget  *int   `json:"thinkingBudget,omitempty"`
+	ThinkingLevel   string `json:"thinkingLevel,omitempty"` // "low" | "medium" | "high"
+}
+
+// GeminiGenerateContentResponse is the response (or a single stream chunk)
+// from :generateContent / :streamGenerateContent.
+type GeminiGenerateContentResponse struct {
+	Candidates    []GeminiCandidate    `json:"candidates"`
+	UsageMetadata *GeminiUsageMetadata `json:"usageMetadata,omitempty"`
+	ModelVersion  string               `json:"modelVersion,omitempty"`
+	ResponseID    string               `json:"responseId,omitempty"`
+}
+
+// GeminiCandidate is a single generated candidate.
+type GeminiCandidate struct {
+	Content      GeminiContent `json:"content"`
+	FinishReason string        `json:"finishReason,omitempty"` // "STOP" | "MAX_TOKENS" | "SAFETY" | ...
+	Index        int           `json:"index"`
+}
+
+// GeminiUsageMetadata holds token counts in Gemini format.
+type GeminiUsageMetadata struct {
+	PromptTokenCount        int `json:"promptTokenCount"`
+	CandidatesTokenCount    int `json:"candidatesTokenCount"`
+	TotalTokenCount         int `json:"totalTokenCount"`
+	CachedContentTokenCount int `json:"cachedContentTokenCount,omitempty"`
+	ThoughtsTokenCount      int `json:"thoughtsTokenCount,omitempty"`
+}
+
+// GeminiCountTokensRequest is the request body for :countTokens. Clients send
+// either bare contents or a full generateContentRequest.
+type GeminiCountTokensRequest struct {
+	Contents               []GeminiContent               `json:"contents,omitempty"`
+	GenerateContentRequest *GeminiGenerateContentRequest `json:"generateContentRequest,omitempty"`
+}
+
+// GeminiCountTokensResponse is the response from :countTokens.
+type GeminiCountTokensResponse struct {
+	TotalTokens int `json:"totalTokens"`
+}
+
 // ---------------------------------------------------------------------------
 // Shared constants
 // ---------------------------------------------------------------------------
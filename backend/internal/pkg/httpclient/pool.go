@@ -63,6 +63,13 @@ var validateResolvedIP = urlvalidator.ValidateResolvedIP
 // 性能优化：相同配置复用同一客户端，避免重复创建 Transport
 // 安全说明：代理配置失败时直接返回错误，不会回退到直连，避免 IP 关联风险
 func GetClient(opts Options) (*http.Client, error) {
+	// 代理池占位 URL 需在计算缓存键前解析，确保客户端随池内代理切换
+	resolved, err := proxyurl.Resolve(opts.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	opts.ProxyURL = resolved
+
 	key := buildClientKey(opts)
 	if cached, ok := sharedClients.Load(key); ok {
 		if client, ok := cached.(*http.Client); ok {
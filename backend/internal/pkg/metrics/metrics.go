@@ -0,0 +1,348 @@
+// Package metrics 提供轻量级的 Prometheus 文本格式（0.0.4）指标实现。
+//
+// 只覆盖网关自身需要的 counter / gauge / histogram 三种类型，
+// 快照型指标通过 CollectorFunc 在抓取时计算，避免在热路径上维护额外状态。
+package metrics
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// ContentType Prometheus 文本格式的 Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// DefaultLatencyBuckets 网关请求耗时桶（秒），覆盖从快速失败到长时间流式输出。
+var DefaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Label 指标标签
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Collector 在抓取时向 Writer 输出指标
+type Collector interface {
+	Collect(w *Writer)
+}
+
+// CollectorFunc 将普通函数适配为 Collector
+type CollectorFunc func(w *Writer)
+
+// Collect implements Collector.
+func (f CollectorFunc) Collect(w *Writer) { f(w) }
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+// NewRegistry 创建空注册表
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register 注册一个或多个 Collector
+func (r *Registry) Register(cs ...Collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range cs {
+		if c != nil {
+			r.collectors = append(r.collectors, c)
+		}
+	}
+}
+
+// WriteText 以 Prometheus 文本格式输出所有指标
+func (r *Registry) WriteText(out io.Writer) error {
+	r.mu.RLock()
+	collectors := append([]Collector(nil), r.collectors...)
+	r.mu.RUnlock()
+
+	w := &Writer{described: make(map[string]struct{})}
+	for _, c := range collectors {
+		c.Collect(w)
+	}
+	_, err := out.Write(w.buf.Bytes())
+	return err
+}
+
+// Writer 累积一次抓取的输出
+type Writer struct {
+	buf       bytes.Buffer
+	described map[string]struct{}
+}
+
+// Describe 输出 HELP/TYPE 行；同名指标只输出一次
+func (w *Writer) Describe(name, help, typ string) {
+	if _, ok := w.described[name]; ok {
+		return
+	}
+	w.described[name] = struct{}{}
+	w.buf.WriteString("# HELP ")
+	w.buf.WriteString(name)
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(escapeHelp(help))
+	w.buf.WriteString("\n# TYPE ")
+	w.buf.WriteString(name)
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(typ)
+	w.buf.WriteByte('\n')
+}
+
+// Sample 输出一个样本行
+func (w *Writer) Sample(name string, labels []Label, value float64) {
+	w.buf.WriteString(name)
+	if len(labels) > 0 {
+		w.buf.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.buf.WriteByte(',')
+			}
+			w.buf.WriteString(l.Name)
+			w.buf.WriteString(`="`)
+			w.buf.WriteString(escapeLabelValue(l.Value))
+			w.buf.WriteByte('"')
+		}
+		w.buf.WriteByte('}')
+	}
+	w.buf.WriteByte(' ')
+	w.buf.WriteString(formatFloat(value))
+	w.buf.WriteByte('\n')
+}
+
+// Gauge 便捷方法：描述并输出单个 gauge 样本
+func (w *Writer) Gauge(name, help string, value float64, labels ...Label) {
+	w.Describe(name, help, TypeGauge)
+	w.Sample(name, labels, value)
+}
+
+// Counter 便捷方法：描述并输出单个 counter 样本（用于快照型累计值）
+func (w *Writer) Counter(name, help string, value float64, labels ...Label) {
+	w.Describe(name, help, TypeCounter)
+	w.Sample(name, labels, value)
+}
+
+// CounterVec 带标签的累加计数器
+type CounterVec struct {
+	name       string
+	help       string
+	labelNames []string
+	series     sync.Map // key -> *counterSeries
+}
+
+type counterSeries struct {
+	labels []Label
+	bits   atomic.Uint64
+}
+
+// NewCounterVec 创建计数器
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return &CounterVec{name: name, help: help, labelNames: labelNames}
+}
+
+// Inc 计数 +1
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 计数 +v（v 必须非负）
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if c == nil || v < 0 {
+		return
+	}
+	s := c.get(labelValues)
+	for {
+		old := s.bits.Load()
+		next := math.Float64bits(math.Float64frombits(old) + v)
+		if s.bits.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// Value 返回指定标签组合的当前值（主要用于测试）
+func (c *CounterVec) Value(labelValues ...string) float64 {
+	if v, ok := c.series.Load(seriesKey(labelValues)); ok {
+		return math.Float64frombits(v.(*counterSeries).bits.Load())
+	}
+	return 0
+}
+
+func (c *CounterVec) get(labelValues []string) *counterSeries {
+	key := seriesKey(labelValues)
+	if v, ok := c.series.Load(key); ok {
+		return v.(*counterSeries)
+	}
+	v, _ := c.series.LoadOrStore(key, &counterSeries{labels: zipLabels(c.labelNames, labelValues)})
+	return v.(*counterSeries)
+}
+
+// Collect implements Collector.
+func (c *CounterVec) Collect(w *Writer) {
+	w.Describe(c.name, c.help, TypeCounter)
+	for _, s := range sortedSeries[*counterSeries](&c.series) {
+		w.Sample(c.name, s.labels, math.Float64frombits(s.bits.Load()))
+	}
+}
+
+// HistogramVec 带标签的直方图
+type HistogramVec struct {
+	name       string
+	help       string
+	buckets    []float64
+	labelNames []string
+	series     sync.Map // key -> *histogramSeries
+}
+
+type histogramSeries struct {
+	labels  []Label
+	counts  []atomic.Uint64 // 非累积计数，输出时累加
+	count   atomic.Uint64
+	sumBits atomic.Uint64
+}
+
+// NewHistogramVec 创建直方图；buckets 为升序上界，不含 +Inf
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	b := append([]float64(nil), buckets...)
+	sort.Float64s(b)
+	return &HistogramVec{name: name, help: help, buckets: b, labelNames: labelNames}
+}
+
+// Observe 记录一次观测值
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	if h == nil || math.IsNaN(v) {
+		return
+	}
+	s := h.get(labelValues)
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i].Add(1)
+	}
+	s.count.Add(1)
+	for {
+		old := s.sumBits.Load()
+		next := math.Float64bits(math.Float64frombits(old) + v)
+		if s.sumBits.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// Count 返回指定标签组合的观测次数（主要用于测试）
+func (h *HistogramVec) Count(labelValues ...string) uint64 {
+	if v, ok := h.series.Load(seriesKey(labelValues)); ok {
+		return v.(*histogramSeries).count.Load()
+	}
+	return 0
+}
+
+func (h *HistogramVec) get(labelValues []string) *histogramSeries {
+	key := seriesKey(labelValues)
+	if v, ok := h.series.Load(key); ok {
+		return v.(*histogramSeries)
+	}
+	v, _ := h.series.LoadOrStore(key, &histogramSeries{
+		labels: zipLabels(h.labelNames, labelValues),
+		counts: make([]atomic.Uint64, len(h.buckets)),
+	})
+	return v.(*histogramSeries)
+}
+
+// Collect implements Collector.
+func (h *HistogramVec) Collect(w *Writer) {
+	w.Describe(h.name, h.help, TypeHistogram)
+	bucketName := h.name + "_bucket"
+	for _, s := range sortedSeries[*histogramSeries](&h.series) {
+		labels := make([]Label, len(s.labels)+1)
+		copy(labels, s.labels)
+		le := &labels[len(labels)-1]
+		le.Name = "le"
+
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i].Load()
+			le.Value = formatFloat(upper)
+			w.Sample(bucketName, labels, float64(cumulative))
+		}
+		count := s.count.Load()
+		le.Value = "+Inf"
+		w.Sample(bucketName, labels, float64(count))
+		w.Sample(h.name+"_sum", s.labels, math.Float64frombits(s.sumBits.Load()))
+		w.Sample(h.name+"_count", s.labels, float64(count))
+	}
+}
+
+type labeledSeries interface {
+	*counterSeries | *histogramSeries
+}
+
+func sortedSeries[T labeledSeries](m *sync.Map) []T {
+	type entry struct {
+		key string
+		val T
+	}
+	var entries []entry
+	m.Range(func(k, v any) bool {
+		entries = append(entries, entry{key: k.(string), val: v.(T)})
+		return true
+	})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	out := make([]T, len(entries))
+	for i := range entries {
+		out[i] = entries[i].val
+	}
+	return out
+}
+
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+func zipLabels(names, values []string) []Label {
+	labels := make([]Label, len(names))
+	for i, name := range names {
+		labels[i].Name = name
+		if i < len(values) {
+			labels[i].Value = values[i]
+		}
+	}
+	return labels
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
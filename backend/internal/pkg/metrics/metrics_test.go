@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryWriteText(t *testing.T) {
+	reg := NewRegistry()
+	requests := NewCounterVec("test_requests_total", "Requests.", "platform", "status")
+	latency := NewHistogramVec("test_latency_seconds", "Latency.", []float64{1, 0.5}, "platform")
+	reg.Register(requests, latency, CollectorFunc(func(w *Writer) {
+		w.Gauge("test_queue_depth", "Queue depth.", 3)
+	}))
+
+	requests.Inc("openai", "2xx")
+	requests.Add(2, "anthropic", "5xx")
+	requests.Add(-1, "anthropic", "5xx") // 负值忽略
+	latency.Observe(0.25, "openai")
+	latency.Observe(0.75, "openai")
+	latency.Observe(3, "openai")
+
+	var buf bytes.Buffer
+	require.NoError(t, reg.WriteText(&buf))
+	require.Equal(t, `# HELP test_requests_total Requests.
+# TYPE test_requests_total counter
+test_requests_total{platform="anthropic",status="5xx"} 2
+test_requests_total{platform="openai",status="2xx"} 1
+# HELP test_latency_seconds Latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{platform="openai",le="0.5"} 1
+test_latency_seconds_bucket{platform="openai",le="1"} 2
+test_latency_seconds_bucket{platform="openai",le="+Inf"} 3
+test_latency_seconds_sum{platform="openai"} 4
+test_latency_seconds_count{platform="openai"} 3
+# HELP test_queue_depth Queue depth.
+# TYPE test_queue_depth gauge
+test_queue_depth 3
+`, buf.String())
+	require.Equal(t, float64(2), requests.Value("anthropic", "5xx"))
+	require.Equal(t, uint64(3), latency.Count("openai"))
+}
+
+func TestWriterEscapesLabelValuesAndHelp(t *testing.T) {
+	w := &Writer{described: map[string]struct{}{}}
+	w.Gauge("g", "line1\nline2 \\ end", 1, Label{Name: "name", Value: "a\"b\\c\nd"})
+	w.Gauge("g", "ignored", 2) // 同名指标只描述一次
+
+	require.Equal(t, "# HELP g line1\\nline2 \\\\ end\n# TYPE g gauge\n"+
+		"g{name=\"a\\\"b\\\\c\\nd\"} 1\ng 2\n", w.buf.String())
+}
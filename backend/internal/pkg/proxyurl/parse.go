@@ -32,12 +32,19 @@ var allowedSchemes = map[string]bool{
 //   - url.Parse 失败返回 error（不含原始 URL，防凭据泄露）
 //   - Host 为空返回 error（用 Redacted() 脱敏）
 //   - Scheme 必须为 http/https/socks5/socks5h
+//   - proxypool://<id> 代理池占位 URL 先经 Resolve 解析为具体代理
 //   - socks5:// 自动升级为 socks5h://（确保 DNS 由代理端解析，防止 DNS 泄漏）
 func Parse(raw string) (trimmed string, parsed *url.URL, err error) {
 	trimmed = strings.TrimSpace(raw)
 	if trimmed == "" {
 		return "", nil, nil
 	}
+	if IsPoolURL(trimmed) {
+		// 代理池占位 URL 先解析为池内具体代理，解析失败同样 fail-fast
+		if trimmed, err = Resolve(trimmed); err != nil {
+			return "", nil, err
+		}
+	}
 
 	parsed, err = url.Parse(trimmed)
 	if err != nil {
@@ -0,0 +1,93 @@
+package proxyurl
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// PoolScheme 代理池占位 URL 协议：proxypool://<pool_id>?account_id=<account_id>
+//
+// 账号绑定代理池时，调用方拿到的代理 URL 为该占位 URL：
+//   - http_upstream 识别后在池内按策略选择代理，并在连接失败时切换到下一个池内代理
+//   - 其他经由 Parse / Resolve 的路径（OAuth 刷新、用量查询、WebSocket 等）解析为池内当前首选代理
+//
+// account_id 用于 sticky 策略，保证同一账号的各类出站请求尽量使用同一出口。
+// 占位 URL 无法解析（未注册解析器、池不存在、池内无可用代理）时一律返回错误，不回退直连。
+const PoolScheme = "proxypool"
+
+// PoolResolver 将代理池解析为账号当前首选的具体代理 URL
+type PoolResolver func(poolID, accountID int64) (string, error)
+
+var poolResolver atomic.Pointer[PoolResolver]
+
+// SetPoolResolver 注册代理池解析器（由代理池服务在启动时注册），传 nil 取消注册
+func SetPoolResolver(fn PoolResolver) {
+	if fn == nil {
+		poolResolver.Store(nil)
+		return
+	}
+	poolResolver.Store(&fn)
+}
+
+// PoolURL 构造代理池占位 URL，accountID <= 0 时省略
+func PoolURL(poolID, accountID int64) string {
+	if accountID <= 0 {
+		return fmt.Sprintf("%s://%d", PoolScheme, poolID)
+	}
+	return fmt.Sprintf("%s://%d?account_id=%d", PoolScheme, poolID, accountID)
+}
+
+// IsPoolURL 判断是否为代理池占位 URL（不校验池 ID）
+func IsPoolURL(raw string) bool {
+	return strings.HasPrefix(strings.ToLower(strings.TrimSpace(raw)), PoolScheme+"://")
+}
+
+// ParsePoolURL 解析代理池占位 URL，返回池 ID 与账号 ID（未携带时为 0）；
+// 非占位 URL 或池 ID 非法时 ok 为 false
+func ParsePoolURL(raw string) (poolID, accountID int64, ok bool) {
+	if !IsPoolURL(raw) {
+		return 0, 0, false
+	}
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return 0, 0, false
+	}
+	poolID, err = strconv.ParseInt(u.Host, 10, 64)
+	if err != nil || poolID <= 0 {
+		return 0, 0, false
+	}
+	if v := u.Query().Get("account_id"); v != "" {
+		accountID, _ = strconv.ParseInt(v, 10, 64)
+	}
+	return poolID, accountID, true
+}
+
+// Resolve 将代理池占位 URL 解析为具体代理 URL；普通代理 URL（含空字符串）原样返回。
+//
+// 需要按代理 URL 缓存客户端的调用方应先 Resolve 再计算缓存键，
+// 否则缓存的客户端会固定在首次解析到的代理上，无法随健康状态切换。
+func Resolve(raw string) (string, error) {
+	if !IsPoolURL(raw) {
+		return raw, nil
+	}
+	poolID, accountID, ok := ParsePoolURL(raw)
+	if !ok {
+		return "", fmt.Errorf("invalid proxy pool URL")
+	}
+	fn := poolResolver.Load()
+	if fn == nil {
+		return "", fmt.Errorf("proxy pool %d: resolver not configured", poolID)
+	}
+	resolved, err := (*fn)(poolID, accountID)
+	if err != nil {
+		return "", err
+	}
+	resolved = strings.TrimSpace(resolved)
+	if resolved == "" || IsPoolURL(resolved) {
+		return "", fmt.Errorf("proxy pool %d has no available proxy", poolID)
+	}
+	return resolved, nil
+}
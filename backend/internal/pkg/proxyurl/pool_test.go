@@ -0,0 +1,87 @@
+package proxyurl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPoolURL_往返解析(t *testing.T) {
+	raw := PoolURL(7, 42)
+	if raw != "proxypool://7?account_id=42" {
+		t.Fatalf("unexpected pool URL: %s", raw)
+	}
+	poolID, accountID, ok := ParsePoolURL(raw)
+	if !ok || poolID != 7 || accountID != 42 {
+		t.Fatalf("ParsePoolURL(%q) = %d, %d, %v", raw, poolID, accountID, ok)
+	}
+
+	poolID, accountID, ok = ParsePoolURL(PoolURL(3, 0))
+	if !ok || poolID != 3 || accountID != 0 {
+		t.Fatalf("ParsePoolURL without account = %d, %d, %v", poolID, accountID, ok)
+	}
+}
+
+func TestParsePoolURL_非法输入(t *testing.T) {
+	for _, raw := range []string{"", "http://1.2.3.4:8080", "proxypool://", "proxypool://abc", "proxypool://0", "proxypool://-1"} {
+		if _, _, ok := ParsePoolURL(raw); ok {
+			t.Errorf("ParsePoolURL(%q) should fail", raw)
+		}
+	}
+}
+
+func TestResolve_普通URL原样返回(t *testing.T) {
+	for _, raw := range []string{"", "http://1.2.3.4:8080"} {
+		got, err := Resolve(raw)
+		if err != nil || got != raw {
+			t.Errorf("Resolve(%q) = %q, %v", raw, got, err)
+		}
+	}
+}
+
+func TestResolve_未注册解析器时报错不直连(t *testing.T) {
+	SetPoolResolver(nil)
+	if _, err := Resolve(PoolURL(1, 1)); err == nil {
+		t.Fatal("expected error without resolver")
+	}
+	if _, _, err := Parse(PoolURL(1, 1)); err == nil {
+		t.Fatal("Parse should fail-fast for unresolved pool URL")
+	}
+}
+
+func TestResolve_通过解析器选择代理(t *testing.T) {
+	t.Cleanup(func() { SetPoolResolver(nil) })
+	SetPoolResolver(func(poolID, accountID int64) (string, error) {
+		if poolID != 5 || accountID != 9 {
+			t.Fatalf("unexpected resolver args: %d %d", poolID, accountID)
+		}
+		return "socks5://10.0.0.1:1080", nil
+	})
+
+	got, err := Resolve(PoolURL(5, 9))
+	if err != nil || got != "socks5://10.0.0.1:1080" {
+		t.Fatalf("Resolve = %q, %v", got, err)
+	}
+
+	trimmed, parsed, err := Parse(PoolURL(5, 9))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if trimmed != "socks5h://10.0.0.1:1080" || parsed.Scheme != "socks5h" {
+		t.Fatalf("Parse should resolve and normalize pool URL, got %q", trimmed)
+	}
+}
+
+func TestResolve_解析器失败或返回空(t *testing.T) {
+	t.Cleanup(func() { SetPoolResolver(nil) })
+
+	SetPoolResolver(func(int64, int64) (string, error) { return "", errors.New("pool down") })
+	if _, err := Resolve(PoolURL(1, 0)); err == nil || !strings.Contains(err.Error(), "pool down") {
+		t.Fatalf("expected resolver error, got %v", err)
+	}
+
+	SetPoolResolver(func(int64, int64) (string, error) { return "", nil })
+	if _, err := Resolve(PoolURL(1, 0)); err == nil {
+		t.Fatal("empty resolution must not fall back to direct")
+	}
+}
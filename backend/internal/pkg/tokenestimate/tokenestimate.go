@@ -0,0 +1,239 @@
+// Package tokenestimate estimates token counts offline for the upstream model
+// families served by the gateway.
+//
+// It is a heuristic estimator, not a tokenizer: no BPE ranks or SentencePiece
+// vocabularies are embedded. Text is split following the pre-tokenization
+// rules of the real vocabularies (letter runs with a leading space, digit
+// groups, punctuation runs, per-rune CJK handling); pieces found in a small
+// table of common single-token words count as one token and everything else
+// is estimated with per-family chars-per-token ratios. Results are close to,
+// but not identical with, the upstream count_tokens endpoints.
+package tokenestimate
+
+import (
+	"bufio"
+	_ "embed"
+	"math"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Family identifies a group of models whose token counts share the same
+// estimation ratios.
+type Family string
+
+const (
+	// O200k approximates o200k_base (GPT-4o, GPT-4.1, GPT-5 and the o-series models).
+	O200k Family = "o200k"
+	// Cl100k approximates cl100k_base (GPT-4, GPT-3.5 and text-embedding-3 models).
+	Cl100k Family = "cl100k"
+	// Claude approximates the Anthropic Claude 3+ tokenizer.
+	Claude Family = "claude"
+	// Gemini approximates the Gemini SentencePiece tokenizer.
+	Gemini Family = "gemini"
+)
+
+//go:embed vocab/common_words.txt
+var commonWordsData string
+
+var (
+	commonWordsOnce sync.Once
+	commonWords     map[string]struct{}
+)
+
+func loadCommonWords() map[string]struct{} {
+	commonWordsOnce.Do(func() {
+		commonWords = make(map[string]struct{}, 512)
+		scanner := bufio.NewScanner(strings.NewReader(commonWordsData))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			commonWords[line] = struct{}{}
+		}
+	})
+	return commonWords
+}
+
+// profile holds the per-family estimation ratios.
+type profile struct {
+	wordCharsPerToken     float64 // Latin letters in words outside the common table
+	nonLatinCharsPerToken float64 // Cyrillic, Greek, Arabic, ...
+	cjkTokensPerRune      float64 // Han, Kana, Hangul
+	punctCharsPerToken    float64 // runs of ASCII punctuation / symbols
+	bytesPerToken         float64 // emoji and other non-letter runes (UTF-8 bytes)
+	digitGroup            int     // digits merged per token
+}
+
+var profiles = map[Family]profile{
+	O200k:  {wordCharsPerToken: 4.0, nonLatinCharsPerToken: 2.6, cjkTokensPerRune: 0.75, punctCharsPerToken: 2.0, bytesPerToken: 2.5, digitGroup: 3},
+	Cl100k: {wordCharsPerToken: 3.6, nonLatinCharsPerToken: 1.6, cjkTokensPerRune: 1.1, punctCharsPerToken: 1.8, bytesPerToken: 2.0, digitGroup: 3},
+	Claude: {wordCharsPerToken: 3.2, nonLatinCharsPerToken: 1.6, cjkTokensPerRune: 1.2, punctCharsPerToken: 1.5, bytesPerToken: 2.0, digitGroup: 3},
+	Gemini: {wordCharsPerToken: 4.0, nonLatinCharsPerToken: 2.8, cjkTokensPerRune: 0.7, punctCharsPerToken: 2.0, bytesPerToken: 2.5, digitGroup: 1},
+}
+
+// FamilyForModel returns the estimation family for a model ID.
+// Unknown models fall back to O200k.
+func FamilyForModel(model string) Family {
+	m := strings.ToLower(strings.TrimSpace(model))
+	if i := strings.LastIndex(m, "/"); i >= 0 {
+		m = m[i+1:]
+	}
+	switch {
+	case strings.Contains(m, "claude"):
+		return Claude
+	case strings.HasPrefix(m, "gemini"), strings.HasPrefix(m, "gemma"):
+		return Gemini
+	case strings.HasPrefix(m, "gpt-4o"), strings.HasPrefix(m, "gpt-4.1"), strings.HasPrefix(m, "gpt-4.5"),
+		strings.HasPrefix(m, "gpt-5"), strings.HasPrefix(m, "chatgpt"), strings.HasPrefix(m, "codex"),
+		strings.HasPrefix(m, "o1"), strings.HasPrefix(m, "o3"), strings.HasPrefix(m, "o4"),
+		strings.HasPrefix(m, "gpt-image"), strings.HasPrefix(m, "gpt-oss"):
+		return O200k
+	case strings.HasPrefix(m, "gpt-4"), strings.HasPrefix(m, "gpt-3.5"), strings.HasPrefix(m, "text-embedding"):
+		return Cl100k
+	default:
+		return O200k
+	}
+}
+
+// EstimateForModel estimates the number of tokens in text for model.
+func EstimateForModel(model, text string) int {
+	return Estimate(FamilyForModel(model), text)
+}
+
+// Estimate returns the estimated number of tokens in text for the family.
+// Unknown families are estimated as O200k.
+func Estimate(family Family, text string) int {
+	if text == "" {
+		return 0
+	}
+	p, ok := profiles[family]
+	if !ok {
+		p = profiles[O200k]
+	}
+	words := loadCommonWords()
+
+	total := 0.0
+	i := 0
+	for i < len(text) {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == ' ' && i+size < len(text) && isWordRune(nextRune(text, i+size)):
+			// 前导空格与后续单词合并为同一个 token（" the"）
+			i += size
+		case isCJK(r):
+			j := i
+			n := 0
+			for j < len(text) {
+				rr, sz := utf8.DecodeRuneInString(text[j:])
+				if !isCJK(rr) {
+					break
+				}
+				n++
+				j += sz
+			}
+			total += math.Max(1, math.Ceil(float64(n)*p.cjkTokensPerRune))
+			i = j
+		case isWordRune(r):
+			j := i
+			latin := true
+			for j < len(text) {
+				rr, sz := utf8.DecodeRuneInString(text[j:])
+				if !isWordRune(rr) {
+					break
+				}
+				if rr > unicode.MaxLatin1 {
+					latin = false
+				}
+				j += sz
+			}
+			total += countWord(text[i:j], latin, p, words)
+			i = j
+		case r >= '0' && r <= '9':
+			j := i
+			for j < len(text) && text[j] >= '0' && text[j] <= '9' {
+				j++
+			}
+			total += math.Ceil(float64(j-i) / float64(p.digitGroup))
+			i = j
+		case unicode.IsSpace(r):
+			j := i
+			for j < len(text) {
+				rr, sz := utf8.DecodeRuneInString(text[j:])
+				if !unicode.IsSpace(rr) {
+					break
+				}
+				j += sz
+			}
+			// 换行/缩进在词表中多为整段 token，长缩进按 16 字符一组
+			total += math.Ceil(float64(j-i) / 16)
+			i = j
+		case r == '\'' && contractionLen(text[i+size:]) > 0:
+			total++
+			i += size + contractionLen(text[i+size:])
+		case r < utf8.RuneSelf:
+			j := i
+			for j < len(text) && text[j] < utf8.RuneSelf && isPunct(rune(text[j])) {
+				j++
+			}
+			if j == i {
+				j = i + 1
+			}
+			total += math.Ceil(float64(j-i) / p.punctCharsPerToken)
+			i = j
+		default:
+			total += math.Max(1, math.Ceil(float64(size)/p.bytesPerToken))
+			i += size
+		}
+	}
+	return int(total)
+}
+
+func countWord(word string, latin bool, p profile, words map[string]struct{}) float64 {
+	if !latin {
+		return math.Max(1, math.Ceil(float64(utf8.RuneCountInString(word))/p.nonLatinCharsPerToken))
+	}
+	lower := strings.ToLower(word)
+	if _, ok := words[lower]; ok {
+		// 首字母大写的常用词同样是单个 token，全大写则会被拆分
+		if word == lower || word[1:] == lower[1:] {
+			return 1
+		}
+	}
+	return math.Max(1, math.Ceil(float64(len(word))/p.wordCharsPerToken))
+}
+
+// contractionLen returns the byte length of an English contraction suffix
+// ('s, 't, 're, 've, 'm, 'll, 'd) following an apostrophe, or 0.
+func contractionLen(rest string) int {
+	lower := strings.ToLower(rest)
+	for _, suffix := range []string{"ll", "re", "ve", "s", "t", "m", "d"} {
+		if strings.HasPrefix(lower, suffix) {
+			if len(rest) == len(suffix) || !isWordRune(nextRune(rest, len(suffix))) {
+				return len(suffix)
+			}
+		}
+	}
+	return 0
+}
+
+func nextRune(s string, i int) rune {
+	r, _ := utf8.DecodeRuneInString(s[i:])
+	return r
+}
+
+func isWordRune(r rune) bool {
+	return (unicode.IsLetter(r) || unicode.Is(unicode.Mn, r)) && !isCJK(r)
+}
+
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
+
+func isPunct(r rune) bool {
+	return r > ' ' && r < utf8.RuneSelf && !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+}
@@ -0,0 +1,59 @@
+package tokenestimate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFamilyForModel(t *testing.T) {
+	cases := map[string]Family{
+		"claude-sonnet-4-5":          Claude,
+		"anthropic/claude-opus-4-1":  Claude,
+		"gemini-2.5-pro":             Gemini,
+		"models/gemini-2.5-flash":    Gemini,
+		"gpt-5.4":                    O200k,
+		"gpt-4o-mini":                O200k,
+		"o3-mini":                    O200k,
+		"gpt-4-turbo":                Cl100k,
+		"gpt-3.5-turbo":              Cl100k,
+		"text-embedding-3-small":     Cl100k,
+		"some-unknown-upstream-name": O200k,
+	}
+	for model, want := range cases {
+		require.Equal(t, want, FamilyForModel(model), model)
+	}
+}
+
+func TestEstimate_CommonEnglish(t *testing.T) {
+	// 常用词 + 前导空格合并，每个词一个 token，句号一个 token
+	require.Equal(t, 0, Estimate(O200k, ""))
+	require.Equal(t, 12, Estimate(O200k, "This is a test of the system and you are here."))
+	require.Equal(t, 1, Estimate(Claude, "Hello"))
+	// 全大写与不在词表中的长词按字符比例拆分
+	require.Equal(t, 2, Estimate(O200k, "HELLO"))
+	require.Equal(t, 5, Estimate(O200k, "internationalization"))
+}
+
+func TestEstimate_DigitsAndContractions(t *testing.T) {
+	require.Equal(t, 2, Estimate(O200k, "123456"))
+	require.Equal(t, 6, Estimate(Gemini, "123456"))
+	// don + 't
+	require.Equal(t, 2, Estimate(O200k, "don't"))
+}
+
+func TestEstimate_CJKAndRatios(t *testing.T) {
+	text := "你好世界，这是一个测试"
+	require.Greater(t, Estimate(Claude, text), Estimate(O200k, text))
+	require.Greater(t, Estimate(Cl100k, text), Estimate(Gemini, text))
+}
+
+func TestEstimate_ScalesWithLength(t *testing.T) {
+	para := "The quick brown fox jumps over the lazy dog while debugging a segmentation fault.\n"
+	one := Estimate(O200k, para)
+	ten := Estimate(O200k, strings.Repeat(para, 10))
+	require.InDelta(t, one*10, ten, float64(one))
+	// Claude 的分词更细，同一段英文 token 数不应少于 o200k
+	require.GreaterOrEqual(t, Estimate(Claude, para), one)
+}
@@ -15,6 +15,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -41,6 +42,7 @@ import (
 //   - client: Ent 客户端，用于类型安全的 ORM 操作
 //   - sql: 原生 SQL 执行器，用于复杂查询和批量操作
 //   - schedulerCache: 调度器缓存，用于在账号状态变更时同步快照
+//   - credentialCipher: 凭证加解密器，写库前加密敏感凭证字段、读库后解密
 type accountRepository struct {
 	client *dbent.Client // Ent ORM 客户端
 	sql    sqlExecutor   // 原生 SQL 执行接口
@@ -49,6 +51,8 @@ type accountRepository struct {
 	// Used to proactively sync account snapshot to cache when status changes,
 	// ensuring sticky sessions can promptly detect unavailable accounts.
 	schedulerCache service.SchedulerCache
+	// credentialCipher 为空时凭证按原样读写（测试场景）
+	credentialCipher service.CredentialCipher
 }
 
 var schedulerNeutralExtraKeyPrefixes = []string{
@@ -66,8 +70,10 @@ var schedulerNeutralExtraKeys = map[string]struct{}{
 
 // NewAccountRepository 创建账户仓储实例。
 // 这是对外暴露的构造函数，返回接口类型以便于依赖注入。
-func NewAccountRepository(client *dbent.Client, sqlDB *sql.DB, schedulerCache service.SchedulerCache) service.AccountRepository {
-	return newAccountRepositoryWithSQL(client, sqlDB, schedulerCache)
+func NewAccountRepository(client *dbent.Client, sqlDB *sql.DB, schedulerCache service.SchedulerCache, credentialCipher service.CredentialCipher) service.AccountRepository {
+	repo := newAccountRepositoryWithSQL(client, sqlDB, schedulerCache)
+	repo.credentialCipher = credentialCipher
+	return repo
 }
 
 // newAccountRepositoryWithSQL 是内部构造函数，支持依赖注入 SQL 执行器。
@@ -80,13 +86,36 @@ func (r *accountRepository) Create(ctx context.Context, account *service.Account
 	if account == nil {
 		return service.ErrAccountNilInput
 	}
+	// 凭证密文绑定账号 ID，而 ID 由数据库分配：启用加密时先以空凭证插入，
+	// 再在同一事务内写入加密后的凭证
+	sealCredentials := r.credentialCipher != nil && r.credentialCipher.ActiveKeyID() != ""
+	client := r.client
+	var tx *dbent.Tx
+	if sealCredentials {
+		var err error
+		tx, err = r.client.Tx(ctx)
+		if err != nil && !errors.Is(err, dbent.ErrTxStarted) {
+			return err
+		}
+		if err == nil {
+			defer func() { _ = tx.Rollback() }()
+			client = tx.Client()
+		} else {
+			// 已处于外部事务中（ErrTxStarted），复用当前 client
+			tx = nil
+		}
+	}
+	credentials := normalizeJSONMap(account.Credentials)
+	if sealCredentials {
+		credentials = map[string]any{}
+	}
 
-	builder := r.client.Account.Create().
+	builder := client.Account.Create().
 		SetName(account.Name).
 		SetNillableNotes(account.Notes).
 		SetPlatform(account.Platform).
 		SetType(account.Type).
-		SetCredentials(normalizeJSONMap(account.Credentials)).
+		SetCredentials(credentials).
 		SetExtra(normalizeJSONMap(account.Extra)).
 		SetConcurrency(account.Concurrency).
 		SetPriority(account.Priority).
@@ -134,6 +163,21 @@ func (r *accountRepository) Create(ctx context.Context, account *service.Account
 	if err != nil {
 		return translatePersistenceError(err, service.ErrAccountNotFound, nil)
 	}
+	if sealCredentials {
+		encrypted, err := r.encryptCredentials(created.ID, account.Credentials)
+		if err != nil {
+			return err
+		}
+		created, err = client.Account.UpdateOneID(created.ID).SetCredentials(encrypted).Save(ctx)
+		if err != nil {
+			return translatePersistenceError(err, service.ErrAccountNotFound, nil)
+		}
+	}
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
 
 	account.ID = created.ID
 	account.CreatedAt = created.CreatedAt
@@ -212,6 +256,9 @@ func (r *accountRepository) GetByIDs(ctx context.Context, ids []int64) ([]*servi
 		if out == nil {
 			continue
 		}
+		if err := r.decryptAccountCredentials(out); err != nil {
+			return nil, err
+		}
 
 		// Prefer the preloaded proxy edge when available.
 		if entAcc.Edges.Proxy != nil {
@@ -317,13 +364,17 @@ func (r *accountRepository) Update(ctx context.Context, account *service.Account
 	if account == nil {
 		return nil
 	}
+	credentials, err := r.encryptCredentials(account.ID, account.Credentials)
+	if err != nil {
+		return err
+	}
 
 	builder := r.client.Account.UpdateOneID(account.ID).
 		SetName(account.Name).
 		SetNillableNotes(account.Notes).
 		SetPlatform(account.Platform).
 		SetType(account.Type).
-		SetCredentials(normalizeJSONMap(account.Credentials)).
+		SetCredentials(credentials).
 		SetExtra(normalizeJSONMap(account.Extra)).
 		SetConcurrency(account.Concurrency).
 		SetPriority(account.Priority).
@@ -405,8 +456,12 @@ func (r *accountRepository) Update(ctx context.Context, account *service.Account
 }
 
 func (r *accountRepository) UpdateCredentials(ctx context.Context, id int64, credentials map[string]any) error {
-	_, err := r.client.Account.UpdateOneID(id).
-		SetCredentials(normalizeJSONMap(credentials)).
+	encrypted, err := r.encryptCredentials(id, credentials)
+	if err != nil {
+		return err
+	}
+	_, err = r.client.Account.UpdateOneID(id).
+		SetCredentials(encrypted).
 		Save(ctx)
 	if err != nil {
 		return translatePersistenceError(err, service.ErrAccountNotFound, nil)
@@ -1336,11 +1391,21 @@ func (r *accountRepository) BulkUpdate(ctx context.Context, ids []int64, updates
 	}
 	// JSONB 需要合并而非覆盖，使用 raw SQL 保持旧行为。
 	if len(updates.Credentials) > 0 {
-		payload, err := json.Marshal(updates.Credentials)
+		// 每个加密字段自包含（见 credential_cipher.go），与已有密文合并是安全的；
+		// 密文绑定账号 ID，因此按账号分别加密，以 {"<id>": patch} 传入后按 id 取出合并
+		patches := make(map[string]map[string]any, len(ids))
+		for _, id := range ids {
+			credentials, err := r.encryptCredentials(id, updates.Credentials)
+			if err != nil {
+				return 0, err
+			}
+			patches[strconv.FormatInt(id, 10)] = credentials
+		}
+		payload, err := json.Marshal(patches)
 		if err != nil {
 			return 0, err
 		}
-		setClauses = append(setClauses, "credentials = COALESCE(credentials, '{}'::jsonb) || $"+itoa(idx)+"::jsonb")
+		setClauses = append(setClauses, "credentials = COALESCE(credentials, '{}'::jsonb) || COALESCE($"+itoa(idx)+"::jsonb -> id::text, '{}'::jsonb)")
 		args = append(args, payload)
 		idx++
 	}
@@ -1487,6 +1552,9 @@ func (r *accountRepository) accountsToService(ctx context.Context, accounts []*d
 		if out == nil {
 			continue
 		}
+		if err := r.decryptAccountCredentials(out); err != nil {
+			return nil, err
+		}
 		if acc.ProxyID != nil {
 			if proxy, ok := proxyMap[*acc.ProxyID]; ok {
 				out.Proxy = proxy
@@ -1666,6 +1734,32 @@ func accountEntityToService(m *dbent.Account) *service.Account {
 	}
 }
 
+// encryptCredentials 写库前加密敏感凭证字段
+func (r *accountRepository) encryptCredentials(accountID int64, credentials map[string]any) (map[string]any, error) {
+	credentials = normalizeJSONMap(credentials)
+	if r.credentialCipher == nil {
+		return credentials, nil
+	}
+	encrypted, err := r.credentialCipher.EncryptCredentials(accountID, credentials)
+	if err != nil {
+		return nil, err
+	}
+	return normalizeJSONMap(encrypted), nil
+}
+
+// decryptAccountCredentials 读库后解密凭证；主密钥缺失时返回错误而不是把密文交给上游
+func (r *accountRepository) decryptAccountCredentials(account *service.Account) error {
+	if r.credentialCipher == nil || len(account.Credentials) == 0 {
+		return nil
+	}
+	credentials, err := r.credentialCipher.DecryptCredentials(account.ID, account.Credentials)
+	if err != nil {
+		return fmt.Errorf("account %d: %w", account.ID, err)
+	}
+	account.Credentials = credentials
+	return nil
+}
+
 func normalizeJSONMap(in map[string]any) map[string]any {
 	if in == nil {
 		return map[string]any{}
@@ -4,11 +4,13 @@ package repository
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	dbent "github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/ent/accountgroup"
+	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/stretchr/testify/suite"
@@ -60,6 +62,10 @@ func (s *schedulerCacheRecorder) UpdateLastUsed(ctx context.Context, updates map
 	return nil
 }
 
+func (s *schedulerCacheRecorder) ListCredentialKeyIDs(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
 func (s *schedulerCacheRecorder) TryLockBucket(ctx context.Context, bucket service.SchedulerBucket, ttl time.Duration) (bool, error) {
 	return true, nil
 }
@@ -111,6 +117,66 @@ func (s *AccountRepoSuite) TestCreate() {
 	s.Require().Equal("test-create", got.Name)
 }
 
+func (s *AccountRepoSuite) TestCredentialEncryption_StoredAsCiphertext() {
+	credentialCipher, err := NewCredentialCipher(&config.Config{CredentialEncryption: config.CredentialEncryptionConfig{
+		ActiveKeyID: "k1",
+		Keys:        map[string]string{"k1": "1111111111111111111111111111111111111111111111111111111111111111"},
+	}})
+	s.Require().NoError(err)
+	s.repo.credentialCipher = credentialCipher
+
+	account := &service.Account{
+		Name:        "encrypted",
+		Platform:    service.PlatformAnthropic,
+		Type:        service.AccountTypeAPIKey,
+		Status:      service.StatusActive,
+		Credentials: map[string]any{"api_key": "sk-secret", "base_url": "https://api.example.com"},
+		Extra:       map[string]any{},
+		Concurrency: 1,
+		Priority:    50,
+		Schedulable: true,
+	}
+	s.Require().NoError(s.repo.Create(s.ctx, account))
+
+	raw, err := s.client.Account.Get(s.ctx, account.ID)
+	s.Require().NoError(err)
+	s.Require().True(strings.HasPrefix(raw.Credentials["api_key"].(string), "enc:v1:k1:"), "api_key must be stored encrypted")
+	s.Require().Equal("https://api.example.com", raw.Credentials["base_url"])
+
+	other := &service.Account{
+		Name:        "encrypted-other",
+		Platform:    service.PlatformAnthropic,
+		Type:        service.AccountTypeAPIKey,
+		Status:      service.StatusActive,
+		Credentials: map[string]any{"api_key": "sk-other"},
+		Extra:       map[string]any{},
+		Concurrency: 1,
+		Priority:    50,
+		Schedulable: true,
+	}
+	s.Require().NoError(s.repo.Create(s.ctx, other))
+
+	_, err = s.repo.BulkUpdate(s.ctx, []int64{account.ID, other.ID}, service.AccountBulkUpdate{
+		Credentials: map[string]any{"refresh_token": "rt-secret"},
+	})
+	s.Require().NoError(err)
+
+	for _, id := range []int64{account.ID, other.ID} {
+		got, err := s.repo.GetByID(s.ctx, id)
+		s.Require().NoError(err)
+		s.Require().Equal("rt-secret", got.Credentials["refresh_token"])
+	}
+	got, err := s.repo.GetByID(s.ctx, account.ID)
+	s.Require().NoError(err)
+	s.Require().Equal("sk-secret", got.Credentials["api_key"])
+
+	// 密文绑定账号 ID，复制到其他账号后无法解密
+	_, err = s.client.Account.UpdateOneID(other.ID).SetCredentials(map[string]any{"api_key": raw.Credentials["api_key"]}).Save(s.ctx)
+	s.Require().NoError(err)
+	_, err = s.repo.GetByID(s.ctx, other.ID)
+	s.Require().ErrorIs(err, service.ErrCredentialDecrypt)
+}
+
 func (s *AccountRepoSuite) TestGetByID_NotFound() {
 	_, err := s.repo.GetByID(s.ctx, 999999)
 	s.Require().Error(err, "expected error for non-existent ID")
@@ -0,0 +1,199 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type adminAuditLogRepository struct {
+	db *sql.DB
+}
+
+// NewAdminAuditLogRepository 创建管理后台审计日志数据访问实例
+func NewAdminAuditLogRepository(db *sql.DB) service.AdminAuditLogRepository {
+	return &adminAuditLogRepository{db: db}
+}
+
+const adminAuditLogColumns = `l.id, l.actor_user_id, COALESCE(u.email, ''), l.auth_method, l.actor_token_id, l.method, l.route, l.path,
+	l.resource_type, l.resource_id, l.target_ids, l.request_body, l.changes, l.status_code, l.success,
+	l.error_reason, l.error_message, l.client_ip, l.user_agent, l.duration_ms, l.created_at`
+
+const adminAuditLogFrom = ` FROM admin_audit_logs l LEFT JOIN users u ON u.id = l.actor_user_id`
+
+func (r *adminAuditLogRepository) Create(ctx context.Context, l *service.AdminAuditLog) error {
+	targetIDs, err := marshalNullableJSON(l.TargetIDs)
+	if err != nil {
+		return fmt.Errorf("marshal audit target ids: %w", err)
+	}
+	changes, err := marshalNullableJSON(l.Changes)
+	if err != nil {
+		return fmt.Errorf("marshal audit changes: %w", err)
+	}
+	var requestBody any
+	if len(l.RequestBody) > 0 {
+		requestBody = string(l.RequestBody)
+	}
+
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO admin_audit_logs (actor_user_id, auth_method, actor_token_id, method, route, path, resource_type, resource_id,
+			target_ids, request_body, changes, status_code, success, error_reason, error_message, client_ip, user_agent, duration_ms)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::jsonb, $10::jsonb, $11::jsonb, $12, $13, $14, $15, $16, $17, $18)
+		 RETURNING id, created_at`,
+		l.ActorUserID, l.AuthMethod, l.ActorTokenID, l.Method, l.Route, l.Path, l.ResourceType, l.ResourceID,
+		targetIDs, requestBody, changes, l.StatusCode, l.Success, l.ErrorReason, l.ErrorMessage, l.ClientIP, l.UserAgent, l.DurationMs,
+	).Scan(&l.ID, &l.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert admin audit log: %w", err)
+	}
+	return nil
+}
+
+func (r *adminAuditLogRepository) List(ctx context.Context, params pagination.PaginationParams, filters service.AdminAuditLogFilters) ([]service.AdminAuditLog, *pagination.PaginationResult, error) {
+	whereClause, args := buildAdminAuditLogWhere(filters)
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM admin_audit_logs l WHERE "+whereClause, args...).Scan(&total); err != nil {
+		return nil, nil, fmt.Errorf("count admin audit logs: %w", err)
+	}
+
+	dataQuery := fmt.Sprintf(
+		`SELECT `+adminAuditLogColumns+adminAuditLogFrom+` WHERE %s ORDER BY l.created_at DESC, l.id DESC LIMIT $%d OFFSET $%d`,
+		whereClause, len(args)+1, len(args)+2,
+	)
+	args = append(args, params.Limit(), params.Offset())
+
+	logs, err := r.query(ctx, dataQuery, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return logs, paginationResultFromTotal(total, params), nil
+}
+
+func (r *adminAuditLogRepository) ListForExport(ctx context.Context, filters service.AdminAuditLogFilters, limit int) ([]service.AdminAuditLog, error) {
+	whereClause, args := buildAdminAuditLogWhere(filters)
+	dataQuery := fmt.Sprintf(
+		`SELECT `+adminAuditLogColumns+adminAuditLogFrom+` WHERE %s ORDER BY l.created_at DESC, l.id DESC LIMIT $%d`,
+		whereClause, len(args)+1,
+	)
+	args = append(args, limit)
+	return r.query(ctx, dataQuery, args...)
+}
+
+func (r *adminAuditLogRepository) query(ctx context.Context, query string, args ...any) ([]service.AdminAuditLog, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query admin audit logs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	logs := []service.AdminAuditLog{}
+	for rows.Next() {
+		l, err := scanAdminAuditLog(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan admin audit log: %w", err)
+		}
+		logs = append(logs, *l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate admin audit logs: %w", err)
+	}
+	return logs, nil
+}
+
+func buildAdminAuditLogWhere(filters service.AdminAuditLogFilters) (string, []any) {
+	where := []string{"1=1"}
+	args := []any{}
+	argIdx := 1
+
+	if filters.ActorUserID > 0 {
+		where = append(where, fmt.Sprintf("l.actor_user_id = $%d", argIdx))
+		args = append(args, filters.ActorUserID)
+		argIdx++
+	}
+	if filters.AuthMethod != "" {
+		where = append(where, fmt.Sprintf("l.auth_method = $%d", argIdx))
+		args = append(args, filters.AuthMethod)
+		argIdx++
+	}
+	if filters.ActorTokenID > 0 {
+		where = append(where, fmt.Sprintf("l.actor_token_id = $%d", argIdx))
+		args = append(args, filters.ActorTokenID)
+		argIdx++
+	}
+	if filters.Method != "" {
+		where = append(where, fmt.Sprintf("l.method = $%d", argIdx))
+		args = append(args, strings.ToUpper(filters.Method))
+		argIdx++
+	}
+	if filters.ResourceType != "" {
+		where = append(where, fmt.Sprintf("l.resource_type = $%d", argIdx))
+		args = append(args, filters.ResourceType)
+		argIdx++
+	}
+	if filters.ResourceID != "" {
+		where = append(where, fmt.Sprintf("l.resource_id = $%d", argIdx))
+		args = append(args, filters.ResourceID)
+		argIdx++
+	}
+	if filters.Success != nil {
+		where = append(where, fmt.Sprintf("l.success = $%d", argIdx))
+		args = append(args, *filters.Success)
+		argIdx++
+	}
+	if filters.StartTime != nil {
+		where = append(where, fmt.Sprintf("l.created_at >= $%d", argIdx))
+		args = append(args, *filters.StartTime)
+		argIdx++
+	}
+	if filters.EndTime != nil {
+		where = append(where, fmt.Sprintf("l.created_at < $%d", argIdx))
+		args = append(args, *filters.EndTime)
+		argIdx++
+	}
+	if filters.Search != "" {
+		where = append(where, fmt.Sprintf("(l.path ILIKE $%d OR l.route ILIKE $%d)", argIdx, argIdx))
+		args = append(args, "%"+escapeLike(filters.Search)+"%")
+	}
+	return strings.Join(where, " AND "), args
+}
+
+func scanAdminAuditLog(row scannable) (*service.AdminAuditLog, error) {
+	var l service.AdminAuditLog
+	var targetIDs, requestBody, changes []byte
+	if err := row.Scan(
+		&l.ID, &l.ActorUserID, &l.ActorEmail, &l.AuthMethod, &l.ActorTokenID, &l.Method, &l.Route, &l.Path,
+		&l.ResourceType, &l.ResourceID, &targetIDs, &requestBody, &changes, &l.StatusCode, &l.Success,
+		&l.ErrorReason, &l.ErrorMessage, &l.ClientIP, &l.UserAgent, &l.DurationMs, &l.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(targetIDs) > 0 {
+		_ = json.Unmarshal(targetIDs, &l.TargetIDs)
+	}
+	if len(requestBody) > 0 {
+		l.RequestBody = json.RawMessage(requestBody)
+	}
+	if len(changes) > 0 {
+		_ = json.Unmarshal(changes, &l.Changes)
+	}
+	return &l, nil
+}
+
+// marshalNullableJSON 序列化为 JSON 文本；空值写入 NULL
+func marshalNullableJSON[T any](v map[string]T) (any, error) {
+	if len(v) == 0 {
+		return nil, nil
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(raw), nil
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type adminTokenRepository struct {
+	db *sql.DB
+}
+
+// NewAdminTokenRepository 创建管理员令牌数据访问实例
+func NewAdminTokenRepository(db *sql.DB) service.AdminTokenRepository {
+	return &adminTokenRepository{db: db}
+}
+
+const adminTokenColumns = `id, name, token_hash, token_prefix, scopes, created_by, expires_at, last_used_at, last_used_ip,
+	revoked_at, created_at, updated_at`
+
+func (r *adminTokenRepository) Create(ctx context.Context, t *service.AdminToken) error {
+	scopes, err := json.Marshal(t.Scopes)
+	if err != nil {
+		return fmt.Errorf("marshal admin token scopes: %w", err)
+	}
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO admin_tokens (name, token_hash, token_prefix, scopes, created_by, expires_at)
+		 VALUES ($1, $2, $3, $4::jsonb, $5, $6)
+		 RETURNING id, created_at, updated_at`,
+		t.Name, t.TokenHash, t.TokenPrefix, string(scopes), t.CreatedBy, t.ExpiresAt,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert admin token: %w", err)
+	}
+	return nil
+}
+
+func (r *adminTokenRepository) GetByID(ctx context.Context, id int64) (*service.AdminToken, error) {
+	return r.getOne(ctx, `SELECT `+adminTokenColumns+` FROM admin_tokens WHERE id = $1`, id)
+}
+
+func (r *adminTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*service.AdminToken, error) {
+	return r.getOne(ctx, `SELECT `+adminTokenColumns+` FROM admin_tokens WHERE token_hash = $1`, tokenHash)
+}
+
+func (r *adminTokenRepository) getOne(ctx context.Context, query string, arg any) (*service.AdminToken, error) {
+	t, err := scanAdminToken(r.db.QueryRowContext(ctx, query, arg))
+	if err == sql.ErrNoRows {
+		return nil, service.ErrAdminTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get admin token: %w", err)
+	}
+	return t, nil
+}
+
+func (r *adminTokenRepository) List(ctx context.Context) ([]service.AdminToken, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+adminTokenColumns+` FROM admin_tokens ORDER BY created_at DESC, id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("query admin tokens: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	tokens := []service.AdminToken{}
+	for rows.Next() {
+		t, err := scanAdminToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan admin token: %w", err)
+		}
+		tokens = append(tokens, *t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate admin tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+func (r *adminTokenRepository) Revoke(ctx context.Context, id int64, revokedAt time.Time) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE admin_tokens SET revoked_at = $1, updated_at = NOW() WHERE id = $2 AND revoked_at IS NULL`,
+		revokedAt, id)
+	if err != nil {
+		return fmt.Errorf("revoke admin token: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return service.ErrAdminTokenNotFound
+	}
+	return nil
+}
+
+func (r *adminTokenRepository) TouchLastUsed(ctx context.Context, id int64, usedAt time.Time, ip string) error {
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE admin_tokens SET last_used_at = $1, last_used_ip = $2 WHERE id = $3`,
+		usedAt, ip, id); err != nil {
+		return fmt.Errorf("touch admin token: %w", err)
+	}
+	return nil
+}
+
+func scanAdminToken(row scannable) (*service.AdminToken, error) {
+	var t service.AdminToken
+	var scopes []byte
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+	if err := row.Scan(
+		&t.ID, &t.Name, &t.TokenHash, &t.TokenPrefix, &scopes, &t.CreatedBy, &expiresAt, &lastUsedAt, &t.LastUsedIP,
+		&revokedAt, &t.CreatedAt, &t.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(scopes) > 0 {
+		if err := json.Unmarshal(scopes, &t.Scopes); err != nil {
+			return nil, fmt.Errorf("decode admin token scopes: %w", err)
+		}
+	}
+	if expiresAt.Valid {
+		v := expiresAt.Time
+		t.ExpiresAt = &v
+	}
+	if lastUsedAt.Valid {
+		v := lastUsedAt.Time
+		t.LastUsedAt = &v
+	}
+	if revokedAt.Valid {
+		v := revokedAt.Time
+		t.RevokedAt = &v
+	}
+	return &t, nil
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+const apiKeyHashMigrationBatchSize = 500
+
+// migratePlaintextAPIKeys 将旧版明文存储的 API Key 迁移为哈希存储：
+// 回填 key_hash/key_prefix 并清空 key 列，返回迁移的行数。
+//
+// 计算哈希需要 api_key_hash 密钥，无法在 SQL 迁移中完成，因此在密钥引导后由启动流程执行。
+// 多实例同时启动时，更新以 key 未变为条件，重复执行不会产生冲突。
+func migratePlaintextAPIKeys(ctx context.Context, db sqlExecutor, secret string) (int, error) {
+	type legacyKey struct {
+		id  int64
+		key string
+	}
+
+	migrated := 0
+	var afterID int64
+	for {
+		rows, err := db.QueryContext(ctx,
+			`SELECT id, key FROM api_keys WHERE key IS NOT NULL AND id > $1 ORDER BY id ASC LIMIT $2`,
+			afterID, apiKeyHashMigrationBatchSize)
+		if err != nil {
+			return migrated, fmt.Errorf("query plaintext api keys: %w", err)
+		}
+		batch := make([]legacyKey, 0, apiKeyHashMigrationBatchSize)
+		for rows.Next() {
+			var item legacyKey
+			if err := rows.Scan(&item.id, &item.key); err != nil {
+				_ = rows.Close()
+				return migrated, fmt.Errorf("scan plaintext api key: %w", err)
+			}
+			batch = append(batch, item)
+		}
+		if err := rows.Err(); err != nil {
+			_ = rows.Close()
+			return migrated, fmt.Errorf("iterate plaintext api keys: %w", err)
+		}
+		_ = rows.Close()
+
+		for _, item := range batch {
+			res, err := db.ExecContext(ctx,
+				`UPDATE api_keys SET key_hash = $1, key_prefix = $2, key = NULL WHERE id = $3 AND key = $4`,
+				service.HashAPIKey(secret, item.key), service.APIKeyDisplayPrefix(item.key), item.id, item.key)
+			if err != nil {
+				return migrated, fmt.Errorf("hash api key %d: %w", item.id, err)
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				migrated++
+			}
+		}
+
+		if len(batch) < apiKeyHashMigrationBatchSize {
+			return migrated, nil
+		}
+		afterID = batch[len(batch)-1].id
+	}
+}
@@ -39,10 +39,10 @@ func (s *APIKeyRepoSuite) TestCreate() {
 	user := s.mustCreateUser("create@test.com")
 
 	key := &service.APIKey{
-		UserID: user.ID,
-		Key:    "sk-create-test",
-		Name:   "Test Key",
-		Status: service.StatusActive,
+		UserID:  user.ID,
+		KeyHash: "sk-create-test",
+		Name:    "Test Key",
+		Status:  service.StatusActive,
 	}
 
 	err := s.repo.Create(s.ctx, key)
@@ -51,7 +51,7 @@ func (s *APIKeyRepoSuite) TestCreate() {
 
 	got, err := s.repo.GetByID(s.ctx, key.ID)
 	s.Require().NoError(err, "GetByID")
-	s.Require().Equal("sk-create-test", got.Key)
+	s.Require().Equal("sk-create-test", got.KeyHash)
 }
 
 func (s *APIKeyRepoSuite) TestGetByID_NotFound() {
@@ -65,14 +65,14 @@ func (s *APIKeyRepoSuite) TestGetByKey() {
 
 	key := &service.APIKey{
 		UserID:  user.ID,
-		Key:     "sk-getbykey",
+		KeyHash: "sk-getbykey",
 		Name:    "My Key",
 		GroupID: &group.ID,
 		Status:  service.StatusActive,
 	}
 	s.Require().NoError(s.repo.Create(s.ctx, key))
 
-	got, err := s.repo.GetByKey(s.ctx, key.Key)
+	got, err := s.repo.GetByKeyHash(s.ctx, key.KeyHash)
 	s.Require().NoError(err, "GetByKey")
 	s.Require().Equal(key.ID, got.ID)
 	s.Require().NotNil(got.User, "expected User preload")
@@ -82,7 +82,7 @@ func (s *APIKeyRepoSuite) TestGetByKey() {
 }
 
 func (s *APIKeyRepoSuite) TestGetByKey_NotFound() {
-	_, err := s.repo.GetByKey(s.ctx, "non-existent-key")
+	_, err := s.repo.GetByKeyHash(s.ctx, "non-existent-key")
 	s.Require().Error(err, "expected error for non-existent key")
 }
 
@@ -91,10 +91,10 @@ func (s *APIKeyRepoSuite) TestGetByKey_NotFound() {
 func (s *APIKeyRepoSuite) TestUpdate() {
 	user := s.mustCreateUser("update@test.com")
 	key := &service.APIKey{
-		UserID: user.ID,
-		Key:    "sk-update",
-		Name:   "Original",
-		Status: service.StatusActive,
+		UserID:  user.ID,
+		KeyHash: "sk-update",
+		Name:    "Original",
+		Status:  service.StatusActive,
 	}
 	s.Require().NoError(s.repo.Create(s.ctx, key))
 
@@ -105,7 +105,7 @@ func (s *APIKeyRepoSuite) TestUpdate() {
 
 	got, err := s.repo.GetByID(s.ctx, key.ID)
 	s.Require().NoError(err, "GetByID after update")
-	s.Require().Equal("sk-update", got.Key, "Update should not change key")
+	s.Require().Equal("sk-update", got.KeyHash, "Update should not change key")
 	s.Require().Equal(user.ID, got.UserID, "Update should not change user_id")
 	s.Require().Equal("Renamed", got.Name)
 	s.Require().Equal(service.StatusDisabled, got.Status)
@@ -116,7 +116,7 @@ func (s *APIKeyRepoSuite) TestUpdate_ClearGroupID() {
 	group := s.mustCreateGroup("g-clear")
 	key := &service.APIKey{
 		UserID:  user.ID,
-		Key:     "sk-clear-group",
+		KeyHash: "sk-clear-group",
 		Name:    "Group Key",
 		GroupID: &group.ID,
 		Status:  service.StatusActive,
@@ -137,10 +137,10 @@ func (s *APIKeyRepoSuite) TestUpdate_ClearGroupID() {
 func (s *APIKeyRepoSuite) TestDelete() {
 	user := s.mustCreateUser("delete@test.com")
 	key := &service.APIKey{
-		UserID: user.ID,
-		Key:    "sk-delete",
-		Name:   "Delete Me",
-		Status: service.StatusActive,
+		UserID:  user.ID,
+		KeyHash: "sk-delete",
+		Name:    "Delete Me",
+		Status:  service.StatusActive,
 	}
 	s.Require().NoError(s.repo.Create(s.ctx, key))
 
@@ -156,20 +156,20 @@ func (s *APIKeyRepoSuite) TestCreate_AfterSoftDelete_AllowsSameKey() {
 	const reusedKey = "sk-reuse-after-soft-delete"
 
 	first := &service.APIKey{
-		UserID: user.ID,
-		Key:    reusedKey,
-		Name:   "First Key",
-		Status: service.StatusActive,
+		UserID:  user.ID,
+		KeyHash: reusedKey,
+		Name:    "First Key",
+		Status:  service.StatusActive,
 	}
 	s.Require().NoError(s.repo.Create(s.ctx, first), "create first key")
 
 	s.Require().NoError(s.repo.Delete(s.ctx, first.ID), "soft delete first key")
 
 	second := &service.APIKey{
-		UserID: user.ID,
-		Key:    reusedKey,
-		Name:   "Second Key",
-		Status: service.StatusActive,
+		UserID:  user.ID,
+		KeyHash: reusedKey,
+		Name:    "Second Key",
+		Status:  service.StatusActive,
 	}
 	s.Require().NoError(s.repo.Create(s.ctx, second), "create second key with same key")
 	s.Require().NotZero(second.ID)
@@ -240,17 +240,17 @@ func (s *APIKeyRepoSuite) TestCountByGroupID() {
 	s.Require().Equal(int64(1), count)
 }
 
-// --- ExistsByKey ---
+// --- ExistsByKeyHash ---
 
 func (s *APIKeyRepoSuite) TestExistsByKey() {
 	user := s.mustCreateUser("exists@test.com")
 	s.mustCreateApiKey(user.ID, "sk-exists", "K", nil)
 
-	exists, err := s.repo.ExistsByKey(s.ctx, "sk-exists")
-	s.Require().NoError(err, "ExistsByKey")
+	exists, err := s.repo.ExistsByKeyHash(s.ctx, "sk-exists")
+	s.Require().NoError(err, "ExistsByKeyHash")
 	s.Require().True(exists)
 
-	notExists, err := s.repo.ExistsByKey(s.ctx, "sk-not-exists")
+	notExists, err := s.repo.ExistsByKeyHash(s.ctx, "sk-not-exists")
 	s.Require().NoError(err)
 	s.Require().False(notExists)
 }
@@ -287,6 +287,66 @@ func (s *APIKeyRepoSuite) TestSearchAPIKeys_NoUserID() {
 	s.Require().Len(found, 1)
 }
 
+func (s *APIKeyRepoSuite) TestSearchAPIKeys_ByKeyPrefix() {
+	user := s.mustCreateUser("searchprefix@test.com")
+	k := &service.APIKey{
+		UserID:    user.ID,
+		KeyHash:   service.HashAPIKey("", "sk-abcdef0123456789abcdef"),
+		KeyPrefix: service.APIKeyDisplayPrefix("sk-abcdef0123456789abcdef"),
+		Name:      "K1",
+		Status:    service.StatusActive,
+	}
+	s.Require().NoError(s.repo.Create(s.ctx, k))
+	s.mustCreateApiKey(user.ID, "sk-other-hash", "K2", nil)
+
+	found, err := s.repo.SearchAPIKeys(s.ctx, user.ID, "sk-abc", 10)
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	s.Require().Equal(k.ID, found[0].ID)
+
+	// 粘贴完整 Key 时按其展示前缀匹配
+	found, err = s.repo.SearchAPIKeys(s.ctx, user.ID, "sk-abcdef0123456789abcdef", 10)
+	s.Require().NoError(err)
+	s.Require().Len(found, 1)
+	s.Require().Equal(k.ID, found[0].ID)
+}
+
+// --- migratePlaintextAPIKeys ---
+
+func (s *APIKeyRepoSuite) TestMigratePlaintextAPIKeys() {
+	user := s.mustCreateUser("migrate-plain@test.com")
+	const plain = "sk-legacy-plaintext-0123456789"
+	const secret = "0123456789abcdef0123456789abcdef"
+
+	legacy, err := s.client.APIKey.Create().
+		SetUserID(user.ID).
+		SetKey(plain).
+		SetName("Legacy").
+		SetStatus(service.StatusActive).
+		Save(s.ctx)
+	s.Require().NoError(err, "create legacy api key")
+
+	migrated, err := migratePlaintextAPIKeys(s.ctx, s.repo.sql, secret)
+	s.Require().NoError(err, "migratePlaintextAPIKeys")
+	s.Require().Equal(1, migrated)
+
+	got, err := s.client.APIKey.Get(s.ctx, legacy.ID)
+	s.Require().NoError(err)
+	s.Require().Nil(got.Key, "明文 key 应被清空")
+	s.Require().NotNil(got.KeyHash)
+	s.Require().Equal(service.HashAPIKey(secret, plain), *got.KeyHash)
+	s.Require().Equal(service.APIKeyDisplayPrefix(plain), got.KeyPrefix)
+
+	byHash, err := s.repo.GetByKeyHashForAuth(s.ctx, service.HashAPIKey(secret, plain))
+	s.Require().NoError(err)
+	s.Require().Equal(legacy.ID, byHash.ID)
+
+	// 重复执行为空操作
+	migrated, err = migratePlaintextAPIKeys(s.ctx, s.repo.sql, secret)
+	s.Require().NoError(err)
+	s.Require().Zero(migrated)
+}
+
 // --- ClearGroupIDByGroupID ---
 
 func (s *APIKeyRepoSuite) TestClearGroupIDByGroupID() {
@@ -318,7 +378,7 @@ func (s *APIKeyRepoSuite) TestCRUD_Search_ClearGroupID() {
 	key := s.mustCreateApiKey(user.ID, "sk-test-1", "My Key", &group.ID)
 	key.GroupID = &group.ID
 
-	got, err := s.repo.GetByKey(s.ctx, key.Key)
+	got, err := s.repo.GetByKeyHash(s.ctx, key.KeyHash)
 	s.Require().NoError(err, "GetByKey")
 	s.Require().Equal(key.ID, got.ID)
 	s.Require().NotNil(got.User)
@@ -333,7 +393,7 @@ func (s *APIKeyRepoSuite) TestCRUD_Search_ClearGroupID() {
 
 	got2, err := s.repo.GetByID(s.ctx, key.ID)
 	s.Require().NoError(err, "GetByID")
-	s.Require().Equal("sk-test-1", got2.Key, "Update should not change key")
+	s.Require().Equal("sk-test-1", got2.KeyHash, "Update should not change key")
 	s.Require().Equal(user.ID, got2.UserID, "Update should not change user_id")
 	s.Require().Equal("Renamed", got2.Name)
 	s.Require().Equal(service.StatusDisabled, got2.Status)
@@ -344,8 +404,8 @@ func (s *APIKeyRepoSuite) TestCRUD_Search_ClearGroupID() {
 	s.Require().Equal(int64(1), page.Total)
 	s.Require().Len(keys, 1)
 
-	exists, err := s.repo.ExistsByKey(s.ctx, "sk-test-1")
-	s.Require().NoError(err, "ExistsByKey")
+	exists, err := s.repo.ExistsByKeyHash(s.ctx, "sk-test-1")
+	s.Require().NoError(err, "ExistsByKeyHash")
 	s.Require().True(exists, "expected key to exist")
 
 	found, err := s.repo.SearchAPIKeys(s.ctx, user.ID, "renam", 10)
@@ -398,15 +458,16 @@ func (s *APIKeyRepoSuite) mustCreateGroup(name string) *service.Group {
 	return groupEntityToService(g)
 }
 
-func (s *APIKeyRepoSuite) mustCreateApiKey(userID int64, key, name string, groupID *int64) *service.APIKey {
+func (s *APIKeyRepoSuite) mustCreateApiKey(userID int64, keyHash, name string, groupID *int64) *service.APIKey {
 	s.T().Helper()
 
 	k := &service.APIKey{
-		UserID:  userID,
-		Key:     key,
-		Name:    name,
-		GroupID: groupID,
-		Status:  service.StatusActive,
+		UserID:    userID,
+		KeyHash:   keyHash,
+		KeyPrefix: service.APIKeyDisplayPrefix(keyHash),
+		Name:      name,
+		GroupID:   groupID,
+		Status:    service.StatusActive,
 	}
 	s.Require().NoError(s.repo.Create(s.ctx, k), "create api key")
 	return k
@@ -455,7 +516,7 @@ func (s *APIKeyRepoSuite) TestIncrementQuotaUsedAndGetState() {
 	s.Require().Equal(3.5, state.QuotaUsed)
 	s.Require().Equal(3.0, state.Quota)
 	s.Require().Equal(service.StatusAPIKeyQuotaExhausted, state.Status)
-	s.Require().Equal(key.Key, state.Key)
+	s.Require().Equal(key.KeyHash, state.KeyHash)
 
 	got, err := s.repo.GetByID(s.ctx, key.ID)
 	s.Require().NoError(err, "GetByID")
@@ -480,10 +541,10 @@ func TestIncrementQuotaUsed_Concurrent(t *testing.T) {
 	require.NoError(t, err, "create user")
 
 	k := &service.APIKey{
-		UserID: u.ID,
-		Key:    "sk-concurrent-" + time.Now().Format(time.RFC3339Nano),
-		Name:   "Concurrent",
-		Status: service.StatusActive,
+		UserID:  u.ID,
+		KeyHash: "sk-concurrent-" + time.Now().Format(time.RFC3339Nano),
+		Name:    "Concurrent",
+		Status:  service.StatusActive,
 	}
 	require.NoError(t, repo.Create(ctx, k), "create api key")
 	t.Cleanup(func() {
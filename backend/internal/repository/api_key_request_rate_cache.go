@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+// API Key RPM/TPM 滑动窗口计数器
+//
+// 设计说明：
+// 每个 API Key 使用一个 Hash 保存相邻两个分钟窗口的计数：
+//   - Key: apikey:reqrate:{apiKeyID}
+//   - Fields: win（当前分钟序号）、cur_req/prev_req、cur_tok/prev_tok
+//   - TTL: 120 秒（超过两个窗口未访问即可丢弃）
+//
+// 所有脚本仅访问单个 key（兼容 Redis Cluster），并在脚本内调用 TIME 获取服务端时间，
+// 由 Lua 完成窗口滚动与"检查 + 计数"的原子操作。
+const (
+	apiKeyRequestRateKeyPrefix = "apikey:reqrate:"
+	apiKeyRequestRateKeyTTLMs  = 120000
+)
+
+// apiKeyRequestRateKey generates the Redis key for API key RPM/TPM counters.
+func apiKeyRequestRateKey(apiKeyID int64) string {
+	return fmt.Sprintf("%s%d", apiKeyRequestRateKeyPrefix, apiKeyID)
+}
+
+// apiKeyRequestRateRollLua 读取并滚动窗口（公共前缀）。
+// 结果变量：win, elapsed, cr, pr, ct, pt
+const apiKeyRequestRateRollLua = `
+	local t = redis.call('TIME')
+	local now_ms = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+	local win = math.floor(now_ms / 60000)
+	local elapsed = now_ms - win * 60000
+	local h = redis.call('HMGET', KEYS[1], 'win', 'cur_req', 'prev_req', 'cur_tok', 'prev_tok')
+	local w = tonumber(h[1]) or win
+	local cr = tonumber(h[2]) or 0
+	local pr = tonumber(h[3]) or 0
+	local ct = tonumber(h[4]) or 0
+	local pt = tonumber(h[5]) or 0
+	if w < win then
+		if w == win - 1 then
+			pr = cr
+			pt = ct
+		else
+			pr = 0
+			pt = 0
+		end
+		cr = 0
+		ct = 0
+	end
+`
+
+var (
+	// acquireAPIKeyRequestRateScript 检查 RPM/TPM 并在放行时计入一次请求
+	// ARGV[1] = rpm_limit, ARGV[2] = tpm_limit（0 = 不限制）, ARGV[3] = ttl_ms
+	// 返回 {allowed, cur_req, prev_req, cur_tok, prev_tok, elapsed_ms}
+	acquireAPIKeyRequestRateScript = redis.NewScript(apiKeyRequestRateRollLua + `
+		local rpm = tonumber(ARGV[1])
+		local tpm = tonumber(ARGV[2])
+		local weight = (60000 - elapsed) / 60000
+		local allowed = 1
+		if rpm > 0 and pr * weight + cr + 1 > rpm then
+			allowed = 0
+		end
+		if tpm > 0 and pt * weight + ct >= tpm then
+			allowed = 0
+		end
+		if allowed == 1 then
+			cr = cr + 1
+		end
+		redis.call('HSET', KEYS[1], 'win', win, 'cur_req', cr, 'prev_req', pr, 'cur_tok', ct, 'prev_tok', pt)
+		redis.call('PEXPIRE', KEYS[1], ARGV[3])
+		return {allowed, cr, pr, ct, pt, elapsed}
+	`)
+
+	// addAPIKeyRequestRateTokensScript 将 token 计入当前窗口
+	// ARGV[1] = tokens, ARGV[2] = ttl_ms
+	addAPIKeyRequestRateTokensScript = redis.NewScript(apiKeyRequestRateRollLua + `
+		ct = ct + tonumber(ARGV[1])
+		redis.call('HSET', KEYS[1], 'win', win, 'cur_req', cr, 'prev_req', pr, 'cur_tok', ct, 'prev_tok', pt)
+		redis.call('PEXPIRE', KEYS[1], ARGV[2])
+		return 1
+	`)
+
+	// getAPIKeyRequestRateScript 只读获取当前窗口状态
+	// 返回 {1, cur_req, prev_req, cur_tok, prev_tok, elapsed_ms}
+	getAPIKeyRequestRateScript = redis.NewScript(apiKeyRequestRateRollLua + `
+		return {1, cr, pr, ct, pt, elapsed}
+	`)
+)
+
+type apiKeyRequestRateCache struct {
+	rdb *redis.Client
+}
+
+// NewAPIKeyRequestRateCache 创建 API Key RPM/TPM 滑动窗口计数器
+func NewAPIKeyRequestRateCache(rdb *redis.Client) service.APIKeyRequestRateCache {
+	return &apiKeyRequestRateCache{rdb: rdb}
+}
+
+func (c *apiKeyRequestRateCache) AcquireRequestRate(ctx context.Context, apiKeyID int64, rpmLimit, tpmLimit int) (bool, service.APIKeyRequestRateWindow, error) {
+	res, err := acquireAPIKeyRequestRateScript.Run(ctx, c.rdb, []string{apiKeyRequestRateKey(apiKeyID)}, rpmLimit, tpmLimit, apiKeyRequestRateKeyTTLMs).Int64Slice()
+	if err != nil {
+		return false, service.APIKeyRequestRateWindow{}, fmt.Errorf("acquire request rate: %w", err)
+	}
+	allowed, w, err := parseAPIKeyRequestRateResult(res)
+	if err != nil {
+		return false, service.APIKeyRequestRateWindow{}, err
+	}
+	return allowed, w, nil
+}
+
+func (c *apiKeyRequestRateCache) AddRequestRateTokens(ctx context.Context, apiKeyID int64, tokens int64) error {
+	if err := addAPIKeyRequestRateTokensScript.Run(ctx, c.rdb, []string{apiKeyRequestRateKey(apiKeyID)}, tokens, apiKeyRequestRateKeyTTLMs).Err(); err != nil {
+		return fmt.Errorf("add request rate tokens: %w", err)
+	}
+	return nil
+}
+
+func (c *apiKeyRequestRateCache) GetRequestRate(ctx context.Context, apiKeyID int64) (service.APIKeyRequestRateWindow, error) {
+	res, err := getAPIKeyRequestRateScript.Run(ctx, c.rdb, []string{apiKeyRequestRateKey(apiKeyID)}).Int64Slice()
+	if err != nil {
+		return service.APIKeyRequestRateWindow{}, fmt.Errorf("get request rate: %w", err)
+	}
+	_, w, err := parseAPIKeyRequestRateResult(res)
+	return w, err
+}
+
+func parseAPIKeyRequestRateResult(res []int64) (bool, service.APIKeyRequestRateWindow, error) {
+	if len(res) != 6 {
+		return false, service.APIKeyRequestRateWindow{}, fmt.Errorf("unexpected request rate script result length: %d", len(res))
+	}
+	return res[0] == 1, service.APIKeyRequestRateWindow{
+		CurRequests:  res[1],
+		PrevRequests: res[2],
+		CurTokens:    res[3],
+		PrevTokens:   res[4],
+		Elapsed:      time.Duration(res[5]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,93 @@
+//go:build integration
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type APIKeyRequestRateCacheSuite struct {
+	IntegrationRedisSuite
+	cache *apiKeyRequestRateCache
+}
+
+func (s *APIKeyRequestRateCacheSuite) SetupTest() {
+	s.IntegrationRedisSuite.SetupTest()
+	s.cache = NewAPIKeyRequestRateCache(s.rdb).(*apiKeyRequestRateCache)
+}
+
+func (s *APIKeyRequestRateCacheSuite) TestGetRequestRate_Missing() {
+	w, err := s.cache.GetRequestRate(s.ctx, 99999)
+	require.NoError(s.T(), err)
+	require.Zero(s.T(), w.CurRequests)
+	require.Zero(s.T(), w.PrevRequests)
+	require.Zero(s.T(), w.CurTokens)
+
+	exists, err := s.rdb.Exists(s.ctx, apiKeyRequestRateKey(99999)).Result()
+	require.NoError(s.T(), err)
+	require.Zero(s.T(), exists, "read must not create the key")
+}
+
+func (s *APIKeyRequestRateCacheSuite) TestAcquireRequestRate_RPM() {
+	apiKeyID := int64(1)
+	for i := 1; i <= 3; i++ {
+		allowed, w, err := s.cache.AcquireRequestRate(s.ctx, apiKeyID, 3, 0)
+		require.NoError(s.T(), err)
+		// 跨分钟边界时 cur 会被滚动，只断言放行
+		require.True(s.T(), allowed, "request %d should be allowed", i)
+		require.LessOrEqual(s.T(), w.CurRequests, int64(i))
+	}
+
+	// 同一分钟内第 4 次必然超限（prev 只会让估算更大）
+	allowed, w, err := s.cache.AcquireRequestRate(s.ctx, apiKeyID, 3, 0)
+	require.NoError(s.T(), err)
+	if w.Elapsed > time.Second {
+		require.False(s.T(), allowed, "4th request should be rejected")
+	}
+
+	ttl, err := s.rdb.PTTL(s.ctx, apiKeyRequestRateKey(apiKeyID)).Result()
+	require.NoError(s.T(), err)
+	s.AssertTTLWithin(ttl, time.Second, apiKeyRequestRateKeyTTLMs*time.Millisecond)
+}
+
+func (s *APIKeyRequestRateCacheSuite) TestAcquireRequestRate_TPM() {
+	apiKeyID := int64(2)
+	allowed, _, err := s.cache.AcquireRequestRate(s.ctx, apiKeyID, 0, 100)
+	require.NoError(s.T(), err)
+	require.True(s.T(), allowed)
+
+	require.NoError(s.T(), s.cache.AddRequestRateTokens(s.ctx, apiKeyID, 150))
+
+	allowed, w, err := s.cache.AcquireRequestRate(s.ctx, apiKeyID, 0, 100)
+	require.NoError(s.T(), err)
+	require.False(s.T(), allowed, "token budget exhausted")
+	require.GreaterOrEqual(s.T(), w.EstimatedTokens(), float64(100))
+}
+
+func (s *APIKeyRequestRateCacheSuite) TestAcquireRequestRate_RollsWindow() {
+	apiKeyID := int64(3)
+	key := apiKeyRequestRateKey(apiKeyID)
+
+	serverTime, err := s.rdb.Time(s.ctx).Result()
+	require.NoError(s.T(), err)
+	win := serverTime.Unix() / 60
+
+	// 上一分钟 5 个请求、上上分钟的数据应被丢弃
+	require.NoError(s.T(), s.rdb.HSet(s.ctx, key, "win", win-1, "cur_req", 5, "prev_req", 7, "cur_tok", 50, "prev_tok", 70).Err())
+
+	w, err := s.cache.GetRequestRate(s.ctx, apiKeyID)
+	require.NoError(s.T(), err)
+	if w.Elapsed > 0 && w.Elapsed < 59*time.Second {
+		require.Equal(s.T(), int64(5), w.PrevRequests)
+		require.Equal(s.T(), int64(50), w.PrevTokens)
+		require.Zero(s.T(), w.CurRequests)
+	}
+}
+
+func TestAPIKeyRequestRateCacheSuite(t *testing.T) {
+	suite.Run(t, new(APIKeyRequestRateCacheSuite))
+}
@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+// credentialCiphertextPrefix 密文字段格式：enc:v1:<key_id>:<wrapped_dek>:<sealed_value>
+//   - wrapped_dek: 主密钥（KEK）AES-256-GCM 包裹的随机数据密钥（DEK），AAD 为 key_id
+//   - sealed_value: DEK AES-256-GCM 加密的字段值 JSON，AAD 为 "<account_id>:<field>"
+//     （防止密文在字段间或账号间挪用）
+//
+// 每个字段自包含，JSONB 合并更新（credentials || patch）与按字段轮换都无需额外元数据。
+const credentialCiphertextPrefix = "enc:v1:"
+
+// credentialSensitiveFields 需要加密的凭证字段
+var credentialSensitiveFields = map[string]struct{}{
+	"access_token":          {},
+	"refresh_token":         {},
+	"id_token":              {},
+	"api_key":               {},
+	"session_key":           {},
+	"client_secret":         {},
+	"private_key":           {},
+	"aws_secret_access_key": {},
+	"aws_session_token":     {},
+	"service_account":       {},
+}
+
+// credentialCipher implements service.CredentialCipher using envelope encryption (AES-256-GCM)
+type credentialCipher struct {
+	activeKeyID string
+	keys        map[string]cipher.AEAD
+}
+
+// NewCredentialCipher 根据 credential_encryption 配置创建凭证加解密器。
+// 未配置密钥时返回的加解密器不加密新写入的凭证，读取到密文时报错。
+func NewCredentialCipher(cfg *config.Config) (service.CredentialCipher, error) {
+	c := &credentialCipher{keys: make(map[string]cipher.AEAD)}
+	if cfg == nil {
+		return c, nil
+	}
+	for id, hexKey := range cfg.CredentialEncryption.Keys {
+		// key_id 以明文写入密文前缀，":" 会破坏格式解析
+		if id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid credential encryption key id %q: must be non-empty and must not contain ':'", id)
+		}
+		key, err := hex.DecodeString(hexKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential encryption key %q: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("credential encryption key %q must be 32 bytes (64 hex chars), got %d bytes", id, len(key))
+		}
+		aead, err := newAESGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		c.keys[id] = aead
+	}
+	c.activeKeyID = cfg.CredentialEncryption.ActiveKeyID
+	if c.activeKeyID != "" && c.keys[c.activeKeyID] == nil {
+		return nil, fmt.Errorf("credential encryption active key %q is not configured", c.activeKeyID)
+	}
+	return c, nil
+}
+
+func (c *credentialCipher) ActiveKeyID() string {
+	return c.activeKeyID
+}
+
+func (c *credentialCipher) KeyIDs() []string {
+	ids := make([]string, 0, len(c.keys))
+	for id := range c.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (c *credentialCipher) HasKey(keyID string) bool {
+	_, ok := c.keys[keyID]
+	return ok
+}
+
+func (c *credentialCipher) EncryptCredentials(accountID int64, credentials map[string]any) (map[string]any, error) {
+	out := copyJSONMap(credentials)
+	if c.activeKeyID == "" || len(out) == 0 {
+		return out, nil
+	}
+	for field, value := range out {
+		if s, ok := value.(string); ok && strings.HasPrefix(s, credentialCiphertextPrefix) {
+			rewrapped, err := c.rewrap(s)
+			if err != nil {
+				return nil, fmt.Errorf("%w: field %s: %v", service.ErrCredentialDecrypt, field, err)
+			}
+			out[field] = rewrapped
+			continue
+		}
+		if !isSensitiveCredentialValue(field, value) {
+			continue
+		}
+		sealed, err := c.seal(accountID, field, value)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt credential field %s: %w", field, err)
+		}
+		out[field] = sealed
+	}
+	return out, nil
+}
+
+func (c *credentialCipher) DecryptCredentials(accountID int64, credentials map[string]any) (map[string]any, error) {
+	out := copyJSONMap(credentials)
+	for field, value := range out {
+		s, ok := value.(string)
+		if !ok || !strings.HasPrefix(s, credentialCiphertextPrefix) {
+			continue
+		}
+		plain, err := c.open(accountID, field, s)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %s: %v", service.ErrCredentialDecrypt, field, err)
+		}
+		out[field] = plain
+	}
+	return out, nil
+}
+
+func (c *credentialCipher) NeedsReencrypt(credentials map[string]any) bool {
+	if c.activeKeyID == "" {
+		return false
+	}
+	for field, value := range credentials {
+		if s, ok := value.(string); ok && strings.HasPrefix(s, credentialCiphertextPrefix) {
+			if keyID, _, _, err := parseCredentialCiphertext(s); err != nil || keyID != c.activeKeyID {
+				return true
+			}
+			continue
+		}
+		if isSensitiveCredentialValue(field, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *credentialCipher) CanDecrypt(credentials map[string]any) bool {
+	for _, value := range credentials {
+		s, ok := value.(string)
+		if !ok || !strings.HasPrefix(s, credentialCiphertextPrefix) {
+			continue
+		}
+		keyID, _, _, err := parseCredentialCiphertext(s)
+		if err != nil || !c.HasKey(keyID) {
+			return false
+		}
+	}
+	return true
+}
+
+// isSensitiveCredentialValue 空值不加密，便于前端 / 业务逻辑按空值判断字段是否配置
+func isSensitiveCredentialValue(field string, value any) bool {
+	if _, ok := credentialSensitiveFields[field]; !ok {
+		return false
+	}
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	default:
+		return true
+	}
+}
+
+func (c *credentialCipher) seal(accountID int64, field string, value any) (string, error) {
+	plaintext, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	dek := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dek); err != nil {
+		return "", fmt.Errorf("generate data key: %w", err)
+	}
+	wrapped, err := sealWithAEAD(c.keys[c.activeKeyID], dek, []byte(c.activeKeyID))
+	if err != nil {
+		return "", err
+	}
+	dekAEAD, err := newAESGCM(dek)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := sealWithAEAD(dekAEAD, plaintext, credentialValueAAD(accountID, field))
+	if err != nil {
+		return "", err
+	}
+	return formatCredentialCiphertext(c.activeKeyID, wrapped, sealed), nil
+}
+
+func (c *credentialCipher) open(accountID int64, field, ciphertext string) (any, error) {
+	keyID, wrapped, sealed, err := parseCredentialCiphertext(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	dek, err := c.unwrap(keyID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	dekAEAD, err := newAESGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := openWithAEAD(dekAEAD, sealed, credentialValueAAD(accountID, field))
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err := json.Unmarshal(plaintext, &value); err != nil {
+		return nil, fmt.Errorf("decode value: %w", err)
+	}
+	return value, nil
+}
+
+func credentialValueAAD(accountID int64, field string) []byte {
+	return []byte(fmt.Sprintf("%d:%s", accountID, field))
+}
+
+// rewrap 将旧主密钥包裹的 DEK 改由当前主密钥包裹，字段密文本身不变
+func (c *credentialCipher) rewrap(ciphertext string) (string, error) {
+	keyID, wrapped, sealed, err := parseCredentialCiphertext(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	if keyID == c.activeKeyID {
+		return ciphertext, nil
+	}
+	dek, err := c.unwrap(keyID, wrapped)
+	if err != nil {
+		return "", err
+	}
+	rewrapped, err := sealWithAEAD(c.keys[c.activeKeyID], dek, []byte(c.activeKeyID))
+	if err != nil {
+		return "", err
+	}
+	return formatCredentialCiphertext(c.activeKeyID, rewrapped, sealed), nil
+}
+
+func (c *credentialCipher) unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	kek, ok := c.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("credential encryption key %q is not configured", keyID)
+	}
+	dek, err := openWithAEAD(kek, wrapped, []byte(keyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	return dek, nil
+}
+
+func formatCredentialCiphertext(keyID string, wrapped, sealed []byte) string {
+	return credentialCiphertextPrefix + keyID + ":" +
+		base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(sealed)
+}
+
+func parseCredentialCiphertext(s string) (keyID string, wrapped, sealed []byte, err error) {
+	parts := strings.Split(strings.TrimPrefix(s, credentialCiphertextPrefix), ":")
+	if len(parts) != 3 || parts[0] == "" {
+		return "", nil, nil, fmt.Errorf("malformed ciphertext")
+	}
+	if wrapped, err = base64.RawStdEncoding.DecodeString(parts[1]); err != nil {
+		return "", nil, nil, fmt.Errorf("decode wrapped key: %w", err)
+	}
+	if sealed, err = base64.RawStdEncoding.DecodeString(parts[2]); err != nil {
+		return "", nil, nil, fmt.Errorf("decode ciphertext: %w", err)
+	}
+	return parts[0], wrapped, sealed, nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("create gcm: %w", err)
+	}
+	return gcm, nil
+}
+
+// sealWithAEAD 输出 nonce + ciphertext + tag
+func sealWithAEAD(aead cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func openWithAEAD(aead cipher.AEAD, data, aad []byte) ([]byte, error) {
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt: %w", err)
+	}
+	return plaintext, nil
+}
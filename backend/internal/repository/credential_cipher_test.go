@@ -0,0 +1,135 @@
+//go:build unit
+
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCredentialKey1 = "1111111111111111111111111111111111111111111111111111111111111111"
+	testCredentialKey2 = "2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+func newTestCredentialCipher(t *testing.T, active string, keys map[string]string) service.CredentialCipher {
+	t.Helper()
+	c, err := NewCredentialCipher(&config.Config{CredentialEncryption: config.CredentialEncryptionConfig{
+		ActiveKeyID: active,
+		Keys:        keys,
+	}})
+	require.NoError(t, err)
+	return c
+}
+
+func TestCredentialCipher_RoundTrip(t *testing.T) {
+	c := newTestCredentialCipher(t, "k1", map[string]string{"k1": testCredentialKey1})
+	plain := map[string]any{
+		"api_key":         "sk-secret",
+		"refresh_token":   "",
+		"base_url":        "https://api.example.com",
+		"service_account": map[string]any{"client_email": "svc@example.com", "private_key": "---KEY---"},
+		"expires_at":      float64(1700000000),
+	}
+
+	enc, err := c.EncryptCredentials(7, plain)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(enc["api_key"].(string), "enc:v1:k1:"))
+	require.True(t, strings.HasPrefix(enc["service_account"].(string), "enc:v1:k1:"))
+	require.Equal(t, "", enc["refresh_token"], "空值不加密")
+	require.Equal(t, "https://api.example.com", enc["base_url"], "非敏感字段保持明文")
+	require.Equal(t, float64(1700000000), enc["expires_at"])
+	require.Equal(t, "sk-secret", plain["api_key"], "不修改入参")
+	require.False(t, c.NeedsReencrypt(enc))
+
+	dec, err := c.DecryptCredentials(7, enc)
+	require.NoError(t, err)
+	require.Equal(t, plain, dec)
+}
+
+func TestCredentialCipher_Rotation(t *testing.T) {
+	old := newTestCredentialCipher(t, "k1", map[string]string{"k1": testCredentialKey1})
+	enc, err := old.EncryptCredentials(7, map[string]any{"access_token": "tok"})
+	require.NoError(t, err)
+
+	rotated := newTestCredentialCipher(t, "k2", map[string]string{"k1": testCredentialKey1, "k2": testCredentialKey2})
+	require.True(t, rotated.NeedsReencrypt(enc))
+
+	reenc, err := rotated.EncryptCredentials(7, enc)
+	require.NoError(t, err)
+	oldParts := strings.Split(enc["access_token"].(string), ":")
+	newParts := strings.Split(reenc["access_token"].(string), ":")
+	require.Equal(t, "k2", newParts[2])
+	require.Equal(t, oldParts[4], newParts[4], "轮换只重新包裹数据密钥，字段密文不变")
+	require.False(t, rotated.NeedsReencrypt(reenc))
+
+	onlyNew := newTestCredentialCipher(t, "k2", map[string]string{"k2": testCredentialKey2})
+	dec, err := onlyNew.DecryptCredentials(7, reenc)
+	require.NoError(t, err)
+	require.Equal(t, "tok", dec["access_token"])
+
+	_, err = onlyNew.DecryptCredentials(7, enc)
+	require.ErrorIs(t, err, service.ErrCredentialDecrypt, "旧密钥已移除时无法解密")
+	require.False(t, onlyNew.CanDecrypt(enc))
+	require.True(t, onlyNew.CanDecrypt(reenc))
+	require.False(t, onlyNew.CanDecrypt(map[string]any{"api_key": "enc:v1:k2:broken"}), "密文格式错误")
+}
+
+func TestCredentialCipher_PlaintextMigration(t *testing.T) {
+	c := newTestCredentialCipher(t, "k1", map[string]string{"k1": testCredentialKey1})
+	legacy := map[string]any{"refresh_token": "rt", "email": "a@example.com"}
+	require.True(t, c.NeedsReencrypt(legacy))
+
+	dec, err := c.DecryptCredentials(7, legacy)
+	require.NoError(t, err)
+	require.Equal(t, legacy, dec, "明文旧数据可直接读取")
+}
+
+func TestCredentialCipher_FieldSwapRejected(t *testing.T) {
+	c := newTestCredentialCipher(t, "k1", map[string]string{"k1": testCredentialKey1})
+	enc, err := c.EncryptCredentials(7, map[string]any{"api_key": "sk"})
+	require.NoError(t, err)
+
+	_, err = c.DecryptCredentials(7, map[string]any{"access_token": enc["api_key"]})
+	require.ErrorIs(t, err, service.ErrCredentialDecrypt)
+}
+
+func TestCredentialCipher_AccountSwapRejected(t *testing.T) {
+	c := newTestCredentialCipher(t, "k1", map[string]string{"k1": testCredentialKey1})
+	enc, err := c.EncryptCredentials(7, map[string]any{"api_key": "sk"})
+	require.NoError(t, err)
+
+	_, err = c.DecryptCredentials(8, enc)
+	require.ErrorIs(t, err, service.ErrCredentialDecrypt, "密文不能挪到其他账号")
+}
+
+func TestCredentialCipher_Disabled(t *testing.T) {
+	c := newTestCredentialCipher(t, "", nil)
+	plain := map[string]any{"api_key": "sk"}
+
+	out, err := c.EncryptCredentials(7, plain)
+	require.NoError(t, err)
+	require.Equal(t, plain, out)
+	require.False(t, c.NeedsReencrypt(plain))
+}
+
+func TestNewCredentialCipher_ActiveKeyMissing(t *testing.T) {
+	_, err := NewCredentialCipher(&config.Config{CredentialEncryption: config.CredentialEncryptionConfig{
+		ActiveKeyID: "k2",
+		Keys:        map[string]string{"k1": testCredentialKey1},
+	}})
+	require.Error(t, err)
+}
+
+func TestNewCredentialCipher_InvalidKeyID(t *testing.T) {
+	for _, id := range []string{"", "k:1"} {
+		_, err := NewCredentialCipher(&config.Config{CredentialEncryption: config.CredentialEncryptionConfig{
+			Keys: map[string]string{id: testCredentialKey1},
+		}})
+		require.Error(t, err, "key id %q", id)
+	}
+}
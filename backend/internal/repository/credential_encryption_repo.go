@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type credentialEncryptionRepository struct {
+	db *sql.DB
+}
+
+// NewCredentialEncryptionRepository 创建凭证加密迁移数据访问实例
+func NewCredentialEncryptionRepository(db *sql.DB) service.CredentialEncryptionRepository {
+	return &credentialEncryptionRepository{db: db}
+}
+
+func (r *credentialEncryptionRepository) ListAccountCredentials(ctx context.Context, afterID int64, limit int) ([]service.AccountCredentialRecord, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, credentials FROM accounts WHERE id > $1 ORDER BY id ASC LIMIT $2`, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query account credentials: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	records := []service.AccountCredentialRecord{}
+	for rows.Next() {
+		var rec service.AccountCredentialRecord
+		var raw []byte
+		if err := rows.Scan(&rec.AccountID, &raw); err != nil {
+			return nil, fmt.Errorf("scan account credentials: %w", err)
+		}
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &rec.Credentials); err != nil {
+				return nil, fmt.Errorf("decode account %d credentials: %w", rec.AccountID, err)
+			}
+		}
+		records = append(records, rec)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate account credentials: %w", err)
+	}
+	return records, nil
+}
+
+// ReplaceAccountCredentials 以 JSONB 相等作为乐观锁条件，避免覆盖并发写入（如令牌刷新）的新凭证。
+// 凭证明文不变，因此不更新 updated_at；调度快照由调用方按批通过 NotifyAccountCredentialsChanged 刷新。
+func (r *credentialEncryptionRepository) ReplaceAccountCredentials(ctx context.Context, accountID int64, old, updated map[string]any) (bool, error) {
+	oldPayload, err := json.Marshal(normalizeJSONMap(old))
+	if err != nil {
+		return false, err
+	}
+	newPayload, err := json.Marshal(normalizeJSONMap(updated))
+	if err != nil {
+		return false, err
+	}
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE accounts SET credentials = $1::jsonb
+		 WHERE id = $2 AND COALESCE(credentials, '{}'::jsonb) = $3::jsonb`,
+		string(newPayload), accountID, string(oldPayload),
+	)
+	if err != nil {
+		return false, fmt.Errorf("replace account credentials: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("replace account credentials: %w", err)
+	}
+	return n > 0, nil
+}
+
+// NotifyAccountCredentialsChanged 写入批量账号变更 outbox 事件，由调度快照服务重新写入这些账号的快照
+func (r *credentialEncryptionRepository) NotifyAccountCredentialsChanged(ctx context.Context, accountIDs []int64) error {
+	if len(accountIDs) == 0 {
+		return nil
+	}
+	payload := map[string]any{"account_ids": accountIDs}
+	return enqueueSchedulerOutbox(ctx, r.db, service.SchedulerOutboxEventAccountBulkChanged, nil, nil, payload)
+}
+
+// ListCredentialKeyIDsInUse 统计全部账号（含软删除，备份会包含这些行）密文字段引用的主密钥
+func (r *credentialEncryptionRepository) ListCredentialKeyIDsInUse(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT DISTINCT split_part(e.value, ':', 3)
+		 FROM accounts a,
+		      jsonb_each_text(CASE WHEN jsonb_typeof(a.credentials) = 'object' THEN a.credentials ELSE '{}'::jsonb END) e
+		 WHERE e.value LIKE $1`,
+		credentialCiphertextPrefix+"%",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query credential key ids: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan credential key id: %w", err)
+		}
+		if id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate credential key ids: %w", err)
+	}
+	return ids, nil
+}
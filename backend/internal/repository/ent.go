@@ -6,6 +6,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"log"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/ent"
@@ -79,6 +80,16 @@ func InitEnt(cfg *config.Config) (*ent.Client, *sql.DB, error) {
 		return nil, nil, fmt.Errorf("validate config after secret bootstrap: %w", err)
 	}
 
+	// 将旧版明文 API Key 迁移为哈希存储（依赖上面补齐的 api_key_hash 密钥）。
+	migrated, err := migratePlaintextAPIKeys(migrationCtx, drv.DB(), cfg.APIKeyHash.Secret)
+	if err != nil {
+		_ = client.Close()
+		return nil, nil, fmt.Errorf("migrate plaintext api keys: %w", err)
+	}
+	if migrated > 0 {
+		log.Printf("Migrated %d plaintext API keys to hashed storage.", migrated)
+	}
+
 	// SIMPLE 模式：启动时补齐各平台默认分组。
 	// - anthropic/openai/gemini: 确保存在 <platform>-default
 	// - antigravity: 仅要求存在 >=2 个未软删除分组（用于 claude/gemini 混合调度场景）
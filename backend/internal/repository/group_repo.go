@@ -58,7 +58,11 @@ func (r *groupRepository) Create(ctx context.Context, groupIn *service.Group) er
 		SetAllowMessagesDispatch(groupIn.AllowMessagesDispatch).
 		SetRequireOauthOnly(groupIn.RequireOAuthOnly).
 		SetRequirePrivacySet(groupIn.RequirePrivacySet).
-		SetDefaultMappedModel(groupIn.DefaultMappedModel)
+		SetDefaultMappedModel(groupIn.DefaultMappedModel).
+		SetLocalTokenCounting(groupIn.LocalTokenCounting).
+		SetNillableBatchDiscountMultiplier(groupIn.BatchDiscountMultiplier).
+		SetResponseCacheEnabled(groupIn.ResponseCacheEnabled).
+		SetNillableSubscriptionPrice(groupIn.SubscriptionPrice)
 
 	// 设置模型路由配置
 	if groupIn.ModelRouting != nil {
@@ -124,7 +128,11 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 		SetAllowMessagesDispatch(groupIn.AllowMessagesDispatch).
 		SetRequireOauthOnly(groupIn.RequireOAuthOnly).
 		SetRequirePrivacySet(groupIn.RequirePrivacySet).
-		SetDefaultMappedModel(groupIn.DefaultMappedModel)
+		SetDefaultMappedModel(groupIn.DefaultMappedModel).
+		SetLocalTokenCounting(groupIn.LocalTokenCounting).
+		SetNillableBatchDiscountMultiplier(groupIn.BatchDiscountMultiplier).
+		SetResponseCacheEnabled(groupIn.ResponseCacheEnabled).
+		SetNillableSubscriptionPrice(groupIn.SubscriptionPrice)
 
 	// 显式处理可空字段：nil 需要 clear，非 nil 需要 set。
 	if groupIn.DailyLimitUSD != nil {
@@ -157,6 +165,16 @@ func (r *groupRepository) Update(ctx context.Context, groupIn *service.Group) er
 	} else {
 		builder = builder.ClearImagePrice4k()
 	}
+	if groupIn.BatchDiscountMultiplier != nil {
+		builder = builder.SetBatchDiscountMultiplier(*groupIn.BatchDiscountMultiplier)
+	} else {
+		builder = builder.ClearBatchDiscountMultiplier()
+	}
+	if groupIn.SubscriptionPrice != nil {
+		builder = builder.SetSubscriptionPrice(*groupIn.SubscriptionPrice)
+	} else {
+		builder = builder.ClearSubscriptionPrice()
+	}
 
 	// 处理 FallbackGroupID：nil 时清除，否则设置
 	if groupIn.FallbackGroupID != nil {
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type guardrailRepository struct {
+	db *sql.DB
+}
+
+// NewGuardrailRepository 创建网关安全策略规则数据访问实例
+func NewGuardrailRepository(db *sql.DB) service.GuardrailRepository {
+	return &guardrailRepository{db: db}
+}
+
+const guardrailRuleColumns = `id, group_id, name, detector, patterns, case_sensitive, action, replacement,
+	webhook_url, webhook_timeout_ms, fail_open, priority, enabled, created_at, updated_at`
+
+func (r *guardrailRepository) ListRules(ctx context.Context) ([]service.GuardrailRule, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+guardrailRuleColumns+` FROM guardrail_rules ORDER BY group_id ASC, priority ASC, id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("query guardrail rules: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	rules := []service.GuardrailRule{}
+	for rows.Next() {
+		rule, err := scanGuardrailRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan guardrail rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate guardrail rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *guardrailRepository) GetRule(ctx context.Context, id int64) (*service.GuardrailRule, error) {
+	rule, err := scanGuardrailRule(r.db.QueryRowContext(ctx,
+		`SELECT `+guardrailRuleColumns+` FROM guardrail_rules WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, service.ErrGuardrailRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get guardrail rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (r *guardrailRepository) CreateRule(ctx context.Context, rule *service.GuardrailRule) error {
+	patterns, err := json.Marshal(rule.Patterns)
+	if err != nil {
+		return fmt.Errorf("marshal guardrail patterns: %w", err)
+	}
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO guardrail_rules (group_id, name, detector, patterns, case_sensitive, action, replacement,
+			webhook_url, webhook_timeout_ms, fail_open, priority, enabled)
+		 VALUES ($1, $2, $3, $4::jsonb, $5, $6, $7, $8, $9, $10, $11, $12)
+		 RETURNING id, created_at, updated_at`,
+		rule.GroupID, rule.Name, rule.Detector, string(patterns), rule.CaseSensitive, rule.Action, rule.Replacement,
+		rule.WebhookURL, rule.WebhookTimeoutMs, rule.FailOpen, rule.Priority, rule.Enabled,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert guardrail rule: %w", err)
+	}
+	return nil
+}
+
+func (r *guardrailRepository) UpdateRule(ctx context.Context, rule *service.GuardrailRule) error {
+	patterns, err := json.Marshal(rule.Patterns)
+	if err != nil {
+		return fmt.Errorf("marshal guardrail patterns: %w", err)
+	}
+	err = r.db.QueryRowContext(ctx,
+		`UPDATE guardrail_rules SET group_id = $1, name = $2, detector = $3, patterns = $4::jsonb, case_sensitive = $5,
+			action = $6, replacement = $7, webhook_url = $8, webhook_timeout_ms = $9, fail_open = $10, priority = $11,
+			enabled = $12, updated_at = NOW()
+		 WHERE id = $13
+		 RETURNING created_at, updated_at`,
+		rule.GroupID, rule.Name, rule.Detector, string(patterns), rule.CaseSensitive, rule.Action, rule.Replacement,
+		rule.WebhookURL, rule.WebhookTimeoutMs, rule.FailOpen, rule.Priority, rule.Enabled, rule.ID,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return service.ErrGuardrailRuleNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("update guardrail rule: %w", err)
+	}
+	return nil
+}
+
+func (r *guardrailRepository) DeleteRule(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM guardrail_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete guardrail rule: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete guardrail rule: %w", err)
+	}
+	if n == 0 {
+		return service.ErrGuardrailRuleNotFound
+	}
+	return nil
+}
+
+func scanGuardrailRule(row scannable) (*service.GuardrailRule, error) {
+	var rule service.GuardrailRule
+	var patterns []byte
+	if err := row.Scan(
+		&rule.ID, &rule.GroupID, &rule.Name, &rule.Detector, &patterns, &rule.CaseSensitive, &rule.Action, &rule.Replacement,
+		&rule.WebhookURL, &rule.WebhookTimeoutMs, &rule.FailOpen, &rule.Priority, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(patterns) > 0 {
+		_ = json.Unmarshal(patterns, &rule.Patterns)
+	}
+	return &rule, nil
+}
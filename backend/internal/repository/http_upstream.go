@@ -91,9 +91,10 @@ type upstreamClientEntry struct {
 // 7. 代理变更时清空旧连接池，避免复用错误代理
 // 8. 账号并发数与连接池上限对应（账号隔离策略下）
 type httpUpstreamService struct {
-	cfg     *config.Config                  // 全局配置
-	mu      sync.RWMutex                    // 保护 clients map 的读写锁
-	clients map[string]*upstreamClientEntry // 客户端缓存池，key 由隔离策略决定
+	cfg        *config.Config                  // 全局配置
+	mu         sync.RWMutex                    // 保护 clients map 的读写锁
+	clients    map[string]*upstreamClientEntry // 客户端缓存池，key 由隔离策略决定
+	proxyPools service.ProxyPoolResolver       // 代理池解析（proxypool:// 占位 URL），可为 nil
 }
 
 // NewHTTPUpstream 创建通用 HTTP 上游服务
@@ -101,13 +102,15 @@ type httpUpstreamService struct {
 //
 // 参数:
 //   - cfg: 全局配置，包含连接池参数和隔离策略
+//   - proxyPools: 代理池解析器，为 nil 时代理池账号的请求直接失败（不回退直连）
 //
 // 返回:
 //   - service.HTTPUpstream 接口实现
-func NewHTTPUpstream(cfg *config.Config) service.HTTPUpstream {
+func NewHTTPUpstream(cfg *config.Config, proxyPools service.ProxyPoolResolver) service.HTTPUpstream {
 	return &httpUpstreamService{
-		cfg:     cfg,
-		clients: make(map[string]*upstreamClientEntry),
+		cfg:        cfg,
+		clients:    make(map[string]*upstreamClientEntry),
+		proxyPools: proxyPools,
 	}
 }
 
@@ -116,7 +119,7 @@ func NewHTTPUpstream(cfg *config.Config) service.HTTPUpstream {
 //
 // 参数:
 //   - req: HTTP 请求对象
-//   - proxyURL: 代理地址，空字符串表示直连
+//   - proxyURL: 代理地址，空字符串表示直连；proxypool:// 占位 URL 表示在代理池内选择并故障转移
 //   - accountID: 账户 ID，用于账户级隔离
 //   - accountConcurrency: 账户并发限制，用于动态调整连接池大小
 //
@@ -128,6 +131,11 @@ func NewHTTPUpstream(cfg *config.Config) service.HTTPUpstream {
 //   - 调用方必须关闭 resp.Body，否则会导致 inFlight 计数泄漏
 //   - inFlight > 0 的客户端不会被淘汰，确保活跃请求不被中断
 func (s *httpUpstreamService) Do(req *http.Request, proxyURL string, accountID int64, accountConcurrency int) (*http.Response, error) {
+	if proxyurl.IsPoolURL(proxyURL) {
+		return s.doWithProxyPool(req, proxyURL, accountID, func(attemptReq *http.Request, candidateURL string) (*http.Response, error) {
+			return s.Do(attemptReq, candidateURL, accountID, accountConcurrency)
+		})
+	}
 	if err := s.validateRequestHost(req); err != nil {
 		return nil, err
 	}
@@ -168,6 +176,11 @@ func (s *httpUpstreamService) DoWithTLS(req *http.Request, proxyURL string, acco
 	if profile == nil {
 		return s.Do(req, proxyURL, accountID, accountConcurrency)
 	}
+	if proxyurl.IsPoolURL(proxyURL) {
+		return s.doWithProxyPool(req, proxyURL, accountID, func(attemptReq *http.Request, candidateURL string) (*http.Response, error) {
+			return s.DoWithTLS(attemptReq, candidateURL, accountID, accountConcurrency, profile)
+		})
+	}
 
 	targetHost := ""
 	if req != nil && req.URL != nil {
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/proxyurl"
+)
+
+// doWithProxyPool 在代理池内依次尝试候选代理执行请求
+//
+// 故障转移规则:
+//   - 仅当请求尚未写出（代理握手 / 连接 / TLS 阶段失败）时才切换到下一个候选代理，
+//     已写出的请求即使失败也不重发，避免上游重复执行
+//   - 每次尝试的结果上报给代理池，用于被动健康标记
+//   - 调用方取消（context 结束）不计为代理失败
+//   - 任何情况下都不回退直连
+func (s *httpUpstreamService) doWithProxyPool(
+	req *http.Request,
+	poolURL string,
+	accountID int64,
+	do func(attemptReq *http.Request, candidateURL string) (*http.Response, error),
+) (*http.Response, error) {
+	if err := s.validateRequestHost(req); err != nil {
+		return nil, err
+	}
+	poolID, poolAccountID, ok := proxyurl.ParsePoolURL(poolURL)
+	if !ok {
+		return nil, fmt.Errorf("invalid proxy pool URL")
+	}
+	if s.proxyPools == nil {
+		return nil, fmt.Errorf("proxy pool %d: resolver not configured", poolID)
+	}
+	if accountID <= 0 {
+		accountID = poolAccountID
+	}
+
+	candidates, err := s.proxyPools.ProxyPoolCandidates(poolID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("proxy pool %d has no available proxy", poolID)
+	}
+	maxAttempts := s.proxyPools.MaxFailoverAttempts()
+	if maxAttempts <= 0 || maxAttempts > len(candidates) {
+		maxAttempts = len(candidates)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		candidate := candidates[attempt]
+		attemptReq, err := proxyPoolAttemptRequest(req, attempt)
+		if err != nil {
+			// 请求体不可重放，无法切换代理
+			return nil, lastErr
+		}
+		var wrote atomic.Bool
+		attemptReq = attemptReq.WithContext(httptrace.WithClientTrace(attemptReq.Context(), &httptrace.ClientTrace{
+			WroteRequest: func(httptrace.WroteRequestInfo) { wrote.Store(true) },
+		}))
+
+		resp, err := do(attemptReq, candidate.URL)
+		if err == nil {
+			s.proxyPools.ReportProxyResult(candidate.ProxyID, nil)
+			return resp, nil
+		}
+		if req.Context().Err() != nil {
+			return nil, err
+		}
+		s.proxyPools.ReportProxyResult(candidate.ProxyID, err)
+		lastErr = err
+		if wrote.Load() {
+			return nil, err
+		}
+		slog.Warn("proxy_pool_failover",
+			"pool_id", poolID,
+			"account_id", accountID,
+			"proxy_id", candidate.ProxyID,
+			"attempt", attempt+1,
+			"max_attempts", maxAttempts,
+			"error", err,
+		)
+	}
+	return nil, fmt.Errorf("proxy pool %d: %d proxies failed: %w", poolID, maxAttempts, lastErr)
+}
+
+// proxyPoolAttemptRequest 返回第 attempt 次尝试使用的请求；重试时通过 GetBody 重建请求体
+func proxyPoolAttemptRequest(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 {
+		return req, nil
+	}
+	clone := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return clone, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("request body is not replayable")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	clone.Body = body
+	return clone, nil
+}
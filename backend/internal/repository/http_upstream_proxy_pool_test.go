@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/proxyurl"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProxyPoolResolver struct {
+	candidates []service.ProxyPoolCandidate
+
+	mu      sync.Mutex
+	results map[int64][]error
+}
+
+func (f *fakeProxyPoolResolver) ProxyPoolCandidates(poolID, accountID int64) ([]service.ProxyPoolCandidate, error) {
+	return f.candidates, nil
+}
+
+func (f *fakeProxyPoolResolver) ReportProxyResult(proxyID int64, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.results == nil {
+		f.results = make(map[int64][]error)
+	}
+	f.results[proxyID] = append(f.results[proxyID], err)
+}
+
+func (f *fakeProxyPoolResolver) MaxFailoverAttempts() int { return 3 }
+
+func newProxyPoolTestConfig() *config.Config {
+	return &config.Config{
+		Security: config.SecurityConfig{
+			URLAllowlist: config.URLAllowlistConfig{AllowPrivateHosts: true},
+		},
+		Gateway: config.GatewayConfig{ResponseHeaderTimeout: 1},
+	}
+}
+
+// deadProxyURL 返回一个已关闭监听的代理地址（连接被拒绝）
+func deadProxyURL(t *testing.T) string {
+	srv := newLocalTestServer(t, http.NotFoundHandler())
+	u := srv.URL
+	srv.Close()
+	return u
+}
+
+func TestDoWithProxyPool_连接失败时切换到下一个代理(t *testing.T) {
+	proxySrv := newLocalTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = io.WriteString(w, "proxied:"+string(body))
+	}))
+	t.Cleanup(proxySrv.Close)
+
+	resolver := &fakeProxyPoolResolver{candidates: []service.ProxyPoolCandidate{
+		{ProxyID: 1, URL: deadProxyURL(t)},
+		{ProxyID: 2, URL: proxySrv.URL},
+	}}
+	up := NewHTTPUpstream(newProxyPoolTestConfig(), resolver)
+
+	req, err := http.NewRequest(http.MethodPost, "http://example.com/v1/messages", strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp, err := up.Do(req, proxyurl.PoolURL(1, 7), 7, 1)
+	require.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+	b, _ := io.ReadAll(resp.Body)
+	require.Equal(t, "proxied:payload", string(b), "重试时应重建请求体")
+
+	require.Len(t, resolver.results[1], 1)
+	require.Error(t, resolver.results[1][0])
+	require.Equal(t, []error{nil}, resolver.results[2])
+}
+
+func TestDoWithProxyPool_全部失败时不回退直连(t *testing.T) {
+	var directHits atomic.Int32
+	upstream := newLocalTestServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		directHits.Add(1)
+	}))
+	t.Cleanup(upstream.Close)
+
+	resolver := &fakeProxyPoolResolver{candidates: []service.ProxyPoolCandidate{
+		{ProxyID: 1, URL: deadProxyURL(t)},
+		{ProxyID: 2, URL: deadProxyURL(t)},
+	}}
+	up := NewHTTPUpstream(newProxyPoolTestConfig(), resolver)
+
+	req, err := http.NewRequest(http.MethodGet, upstream.URL+"/x", nil)
+	require.NoError(t, err)
+	_, err = up.Do(req, proxyurl.PoolURL(1, 7), 7, 1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "proxy pool 1")
+	require.Zero(t, directHits.Load(), "代理池全部失败时不得直连")
+	require.Len(t, resolver.results[1], 1)
+	require.Len(t, resolver.results[2], 1)
+}
+
+func TestDoWithProxyPool_未配置解析器时报错(t *testing.T) {
+	up := NewHTTPUpstream(newProxyPoolTestConfig(), nil)
+	req, err := http.NewRequest(http.MethodGet, "http://example.com/x", nil)
+	require.NoError(t, err)
+	_, err = up.Do(req, proxyurl.PoolURL(1, 7), 7, 1)
+	require.Error(t, err)
+}
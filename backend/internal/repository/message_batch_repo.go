@@ -0,0 +1,220 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type messageBatchRepository struct {
+	db *sql.DB
+}
+
+// NewMessageBatchRepository 创建消息批次数据访问实例
+func NewMessageBatchRepository(db *sql.DB) service.MessageBatchRepository {
+	return &messageBatchRepository{db: db}
+}
+
+const messageBatchColumns = `id, user_id, api_key_id, group_id, account_id, mode, upstream_batch_id, processing_status,
+	request_count, processing_count, succeeded_count, errored_count, canceled_count, expired_count,
+	results_ready, results_billed, created_at, updated_at, expires_at, ended_at, cancel_initiated_at`
+
+func (r *messageBatchRepository) Create(ctx context.Context, b *service.MessageBatch) error {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO message_batches (id, user_id, api_key_id, group_id, account_id, mode, upstream_batch_id, processing_status,
+			request_count, processing_count, succeeded_count, errored_count, canceled_count, expired_count,
+			expires_at, ended_at, cancel_initiated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		 RETURNING created_at, updated_at`,
+		b.ID, b.UserID, b.APIKeyID, b.GroupID, b.AccountID, b.Mode, b.UpstreamBatchID, b.ProcessingStatus,
+		b.RequestCount, b.Counts.Processing, b.Counts.Succeeded, b.Counts.Errored, b.Counts.Canceled, b.Counts.Expired,
+		b.ExpiresAt, b.EndedAt, b.CancelInitiatedAt,
+	).Scan(&b.CreatedAt, &b.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert message batch: %w", err)
+	}
+	return nil
+}
+
+func (r *messageBatchRepository) GetByID(ctx context.Context, id string) (*service.MessageBatch, error) {
+	b, err := scanMessageBatch(r.db.QueryRowContext(ctx, `SELECT `+messageBatchColumns+` FROM message_batches WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, service.ErrMessageBatchNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get message batch: %w", err)
+	}
+	return b, nil
+}
+
+func (r *messageBatchRepository) ListByAPIKey(ctx context.Context, apiKeyID int64, params service.MessageBatchListParams) ([]service.MessageBatch, bool, error) {
+	// 按 (created_at, id) 倒序分页：after_id 取游标之后（更早）的一页，before_id 取游标之前（更新）的一页
+	query := `SELECT ` + messageBatchColumns + ` FROM message_batches WHERE api_key_id = $1`
+	args := []any{apiKeyID}
+	order := ` ORDER BY created_at DESC, id DESC`
+	reverse := false
+	switch {
+	case params.AfterID != "":
+		args = append(args, params.AfterID)
+		query += ` AND (created_at, id) < (SELECT created_at, id FROM message_batches WHERE id = $2)`
+	case params.BeforeID != "":
+		args = append(args, params.BeforeID)
+		query += ` AND (created_at, id) > (SELECT created_at, id FROM message_batches WHERE id = $2)`
+		order = ` ORDER BY created_at ASC, id ASC`
+		reverse = true
+	}
+	args = append(args, params.Limit+1)
+	query += order + fmt.Sprintf(` LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, false, fmt.Errorf("query message batches: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	batches := []service.MessageBatch{}
+	for rows.Next() {
+		b, err := scanMessageBatch(rows)
+		if err != nil {
+			return nil, false, fmt.Errorf("scan message batch: %w", err)
+		}
+		batches = append(batches, *b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("iterate message batches: %w", err)
+	}
+
+	hasMore := len(batches) > params.Limit
+	if hasMore {
+		batches = batches[:params.Limit]
+	}
+	if reverse {
+		for i, j := 0, len(batches)-1; i < j; i, j = i+1, j-1 {
+			batches[i], batches[j] = batches[j], batches[i]
+		}
+	}
+	return batches, hasMore, nil
+}
+
+func (r *messageBatchRepository) Update(ctx context.Context, b *service.MessageBatch) error {
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE message_batches SET processing_status = $1, processing_count = $2, succeeded_count = $3, errored_count = $4,
+			canceled_count = $5, expired_count = $6, results_ready = $7, expires_at = $8, ended_at = $9,
+			cancel_initiated_at = $10, updated_at = NOW()
+		 WHERE id = $11`,
+		b.ProcessingStatus, b.Counts.Processing, b.Counts.Succeeded, b.Counts.Errored,
+		b.Counts.Canceled, b.Counts.Expired, b.ResultsReady, b.ExpiresAt, b.EndedAt,
+		b.CancelInitiatedAt, b.ID); err != nil {
+		return fmt.Errorf("update message batch: %w", err)
+	}
+	return nil
+}
+
+func (r *messageBatchRepository) MarkBilled(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE message_batches SET results_billed = TRUE, updated_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("mark message batch billed: %w", err)
+	}
+	return nil
+}
+
+func (r *messageBatchRepository) ListStale(ctx context.Context, mode string, before time.Time) ([]service.MessageBatch, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+messageBatchColumns+` FROM message_batches
+		 WHERE mode = $1 AND processing_status <> 'ended' AND updated_at < $2
+		 ORDER BY updated_at ASC LIMIT 100`,
+		mode, before)
+	if err != nil {
+		return nil, fmt.Errorf("query stale message batches: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	batches := []service.MessageBatch{}
+	for rows.Next() {
+		b, err := scanMessageBatch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan message batch: %w", err)
+		}
+		batches = append(batches, *b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate message batches: %w", err)
+	}
+	return batches, nil
+}
+
+func (r *messageBatchRepository) ListUnbilledPassthrough(ctx context.Context, before time.Time) ([]service.MessageBatch, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+messageBatchColumns+` FROM message_batches
+		 WHERE mode = $1 AND results_billed = FALSE AND updated_at < $2
+		 ORDER BY updated_at ASC LIMIT 100`,
+		service.MessageBatchModePassthrough, before)
+	if err != nil {
+		return nil, fmt.Errorf("query unbilled passthrough message batches: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	batches := []service.MessageBatch{}
+	for rows.Next() {
+		b, err := scanMessageBatch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan message batch: %w", err)
+		}
+		batches = append(batches, *b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate message batches: %w", err)
+	}
+	return batches, nil
+}
+
+func (r *messageBatchRepository) DeleteEndedBefore(ctx context.Context, before time.Time) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`DELETE FROM message_batches WHERE COALESCE(ended_at, expires_at) < $1 RETURNING id`, before)
+	if err != nil {
+		return nil, fmt.Errorf("delete expired message batches: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan message batch id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate message batch ids: %w", err)
+	}
+	return ids, nil
+}
+
+func scanMessageBatch(row scannable) (*service.MessageBatch, error) {
+	var b service.MessageBatch
+	var groupID sql.NullInt64
+	var endedAt, cancelInitiatedAt sql.NullTime
+	if err := row.Scan(
+		&b.ID, &b.UserID, &b.APIKeyID, &groupID, &b.AccountID, &b.Mode, &b.UpstreamBatchID, &b.ProcessingStatus,
+		&b.RequestCount, &b.Counts.Processing, &b.Counts.Succeeded, &b.Counts.Errored, &b.Counts.Canceled, &b.Counts.Expired,
+		&b.ResultsReady, &b.ResultsBilled, &b.CreatedAt, &b.UpdatedAt, &b.ExpiresAt, &endedAt, &cancelInitiatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if groupID.Valid {
+		v := groupID.Int64
+		b.GroupID = &v
+	}
+	if endedAt.Valid {
+		v := endedAt.Time
+		b.EndedAt = &v
+	}
+	if cancelInitiatedAt.Valid {
+		v := cancelInitiatedAt.Time
+		b.CancelInitiatedAt = &v
+	}
+	return &b, nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type notificationChannelRepository struct {
+	db *sql.DB
+}
+
+// NewNotificationChannelRepository 创建通知渠道数据访问实例
+func NewNotificationChannelRepository(db *sql.DB) service.NotificationChannelRepository {
+	return &notificationChannelRepository{db: db}
+}
+
+const notificationChannelColumns = `id, name, type, enabled, webhook_url, secret, headers, event_types, created_at, updated_at`
+
+func (r *notificationChannelRepository) Create(ctx context.Context, ch *service.NotificationChannel) error {
+	headersJSON, eventTypesJSON, err := marshalNotificationChannelJSON(ch)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO notification_channels (name, type, enabled, webhook_url, secret, headers, event_types) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 RETURNING id, created_at, updated_at`,
+		ch.Name, ch.Type, ch.Enabled, ch.WebhookURL, ch.Secret, headersJSON, eventTypesJSON,
+	).Scan(&ch.ID, &ch.CreatedAt, &ch.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return service.ErrNotificationChannelExists
+		}
+		return fmt.Errorf("insert notification channel: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationChannelRepository) GetByID(ctx context.Context, id int64) (*service.NotificationChannel, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+notificationChannelColumns+` FROM notification_channels WHERE id = $1`, id)
+	ch, err := scanNotificationChannel(row)
+	if err == sql.ErrNoRows {
+		return nil, service.ErrNotificationChannelNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get notification channel: %w", err)
+	}
+	return ch, nil
+}
+
+func (r *notificationChannelRepository) Update(ctx context.Context, ch *service.NotificationChannel) error {
+	headersJSON, eventTypesJSON, err := marshalNotificationChannelJSON(ch)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRowContext(ctx,
+		`UPDATE notification_channels SET name = $1, enabled = $2, webhook_url = $3, secret = $4, headers = $5, event_types = $6, updated_at = NOW()
+		 WHERE id = $7 RETURNING updated_at`,
+		ch.Name, ch.Enabled, ch.WebhookURL, ch.Secret, headersJSON, eventTypesJSON, ch.ID,
+	).Scan(&ch.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return service.ErrNotificationChannelNotFound
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			return service.ErrNotificationChannelExists
+		}
+		return fmt.Errorf("update notification channel: %w", err)
+	}
+	return nil
+}
+
+func (r *notificationChannelRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM notification_channels WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete notification channel: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return service.ErrNotificationChannelNotFound
+	}
+	return nil
+}
+
+func (r *notificationChannelRepository) List(ctx context.Context) ([]service.NotificationChannel, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+notificationChannelColumns+` FROM notification_channels ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("list notification channels: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []service.NotificationChannel{}
+	for rows.Next() {
+		ch, err := scanNotificationChannel(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan notification channel: %w", err)
+		}
+		out = append(out, *ch)
+	}
+	return out, rows.Err()
+}
+
+func scanNotificationChannel(row scannable) (*service.NotificationChannel, error) {
+	ch := &service.NotificationChannel{}
+	var headersJSON, eventTypesJSON []byte
+	if err := row.Scan(
+		&ch.ID, &ch.Name, &ch.Type, &ch.Enabled, &ch.WebhookURL, &ch.Secret,
+		&headersJSON, &eventTypesJSON, &ch.CreatedAt, &ch.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(headersJSON) > 0 {
+		_ = json.Unmarshal(headersJSON, &ch.Headers)
+	}
+	if len(eventTypesJSON) > 0 {
+		_ = json.Unmarshal(eventTypesJSON, &ch.EventTypes)
+	}
+	return ch, nil
+}
+
+func marshalNotificationChannelJSON(ch *service.NotificationChannel) ([]byte, []byte, error) {
+	headers := ch.Headers
+	if headers == nil {
+		headers = map[string]string{}
+	}
+	eventTypes := ch.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+	headersJSON, err := json.Marshal(headers)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal headers: %w", err)
+	}
+	eventTypesJSON, err := json.Marshal(eventTypes)
+	if err != nil {
+		return nil, nil, fmt.Errorf("marshal event_types: %w", err)
+	}
+	return headersJSON, eventTypesJSON, nil
+}
@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/imroc/req/v3"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	oidcRequestTimeout  = 30 * time.Second
+	oidcMaxErrorBodyLen = 512
+)
+
+// NewOIDCClient 创建访问 OIDC / OAuth2 提供方的 HTTP 客户端
+func NewOIDCClient() service.OIDCClient {
+	return &oidcClient{}
+}
+
+type oidcClient struct{}
+
+func (c *oidcClient) httpClient() (*req.Client, error) {
+	return getSharedReqClient(reqClientOptions{Timeout: oidcRequestTimeout})
+}
+
+func (c *oidcClient) FetchDiscovery(ctx context.Context, issuer string) (*service.OIDCDiscoveryDocument, error) {
+	client, err := c.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	discoveryURL := strings.TrimRight(issuer, "/") + "/.well-known/openid-configuration"
+
+	var doc service.OIDCDiscoveryDocument
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/json").
+		SetSuccessResult(&doc).
+		Get(discoveryURL)
+	if err != nil {
+		return nil, fmt.Errorf("request discovery: %w", err)
+	}
+	if !resp.IsSuccessState() {
+		return nil, fmt.Errorf("discovery status=%d", resp.StatusCode)
+	}
+	return &doc, nil
+}
+
+func (c *oidcClient) FetchJWKS(ctx context.Context, jwksURL string) ([]byte, error) {
+	client, err := c.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/json").
+		Get(jwksURL)
+	if err != nil {
+		return nil, fmt.Errorf("request jwks: %w", err)
+	}
+	if !resp.IsSuccessState() {
+		return nil, fmt.Errorf("jwks status=%d", resp.StatusCode)
+	}
+	return resp.Bytes(), nil
+}
+
+func (c *oidcClient) ExchangeCode(ctx context.Context, in *service.OIDCTokenRequest) (*service.OIDCTokenResponse, error) {
+	client, err := c.httpClient()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("client_id", in.ClientID)
+	form.Set("code", in.Code)
+	form.Set("redirect_uri", in.RedirectURI)
+	if in.CodeVerifier != "" {
+		form.Set("code_verifier", in.CodeVerifier)
+	}
+
+	r := client.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/json")
+	switch in.AuthMethod {
+	case "", service.OIDCTokenAuthClientSecretPost:
+		form.Set("client_secret", in.ClientSecret)
+	case service.OIDCTokenAuthClientSecretBasic:
+		// RFC 6749 §2.3.1：Basic 认证前需对 client_id / secret 做 form 编码
+		r.SetBasicAuth(url.QueryEscape(in.ClientID), url.QueryEscape(in.ClientSecret))
+	case service.OIDCTokenAuthNone:
+	default:
+		return nil, fmt.Errorf("unsupported token_auth_method: %s", in.AuthMethod)
+	}
+
+	resp, err := r.SetFormDataFromValues(form).Post(in.TokenURL)
+	if err != nil {
+		return nil, infraerrors.Newf(http.StatusBadGateway, "OIDC_TOKEN_REQUEST_FAILED", "request failed: %v", err)
+	}
+	body := strings.TrimSpace(resp.String())
+	if !resp.IsSuccessState() {
+		return nil, infraerrors.Newf(http.StatusBadGateway, "OIDC_TOKEN_EXCHANGE_FAILED",
+			"token exchange failed: status %d, error=%s", resp.StatusCode, oidcProviderErrorSummary(body))
+	}
+
+	var raw struct {
+		AccessToken string          `json:"access_token"`
+		TokenType   string          `json:"token_type"`
+		IDToken     string          `json:"id_token"`
+		ExpiresIn   json.RawMessage `json:"expires_in"`
+	}
+	if err := json.Unmarshal([]byte(body), &raw); err != nil || strings.TrimSpace(raw.AccessToken) == "" {
+		return nil, infraerrors.New(http.StatusBadGateway, "OIDC_TOKEN_EXCHANGE_FAILED", "token response missing access_token")
+	}
+	tokenType := strings.TrimSpace(raw.TokenType)
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+	return &service.OIDCTokenResponse{
+		AccessToken: strings.TrimSpace(raw.AccessToken),
+		TokenType:   tokenType,
+		IDToken:     strings.TrimSpace(raw.IDToken),
+		// 部分提供方以字符串返回 expires_in
+		ExpiresIn: gjson.ParseBytes(raw.ExpiresIn).Int(),
+	}, nil
+}
+
+func (c *oidcClient) FetchUserInfo(ctx context.Context, userInfoURL, accessToken string) ([]byte, error) {
+	client, err := c.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.R().
+		SetContext(ctx).
+		SetHeader("Accept", "application/json").
+		SetHeader("Authorization", "Bearer "+accessToken).
+		Get(userInfoURL)
+	if err != nil {
+		return nil, fmt.Errorf("request userinfo: %w", err)
+	}
+	if !resp.IsSuccessState() {
+		return nil, fmt.Errorf("userinfo status=%d", resp.StatusCode)
+	}
+	// 部分提供方将用户信息包在 data/user 字段中
+	body := resp.Bytes()
+	for _, wrapper := range []string{"data", "user"} {
+		if inner := gjson.GetBytes(body, wrapper); inner.IsObject() && !gjson.GetBytes(body, "sub").Exists() && !gjson.GetBytes(body, "id").Exists() {
+			return []byte(inner.Raw), nil
+		}
+	}
+	return body, nil
+}
+
+// oidcProviderErrorSummary 提取 OAuth 错误码与描述，避免把完整响应体写入错误信息
+func oidcProviderErrorSummary(body string) string {
+	summary := strings.TrimSpace(gjson.Get(body, "error").String())
+	if desc := strings.TrimSpace(gjson.Get(body, "error_description").String()); desc != "" {
+		summary += ": " + desc
+	}
+	if summary == "" {
+		summary = body
+	}
+	if len(summary) > oidcMaxErrorBodyLen {
+		summary = summary[:oidcMaxErrorBodyLen]
+	}
+	return summary
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type oidcProviderRepository struct {
+	db *sql.DB
+}
+
+// NewOIDCProviderRepository 创建 SSO 提供方数据访问实例
+func NewOIDCProviderRepository(db *sql.DB) service.OIDCProviderRepository {
+	return &oidcProviderRepository{db: db}
+}
+
+const oidcProviderColumns = `id, slug, name, enabled, issuer_url, authorize_url, token_url, userinfo_url, jwks_url,
+	client_id, client_secret, scopes, token_auth_method, use_pkce, audiences, redirect_url, frontend_redirect_url,
+	allowed_email_domains, trust_email, username_claim, groups_claim, default_group_ids, group_mappings, sort_order,
+	created_at, updated_at`
+
+type oidcProviderJSON struct {
+	audiences, allowedEmailDomains, defaultGroupIDs, groupMappings []byte
+}
+
+func (r *oidcProviderRepository) Create(ctx context.Context, p *service.OIDCProvider) error {
+	j, err := marshalOIDCProviderJSON(p)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO oidc_providers (slug, name, enabled, issuer_url, authorize_url, token_url, userinfo_url, jwks_url,
+			client_id, client_secret, scopes, token_auth_method, use_pkce, audiences, redirect_url, frontend_redirect_url,
+			allowed_email_domains, trust_email, username_claim, groups_claim, default_group_ids, group_mappings, sort_order)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
+		 RETURNING id, created_at, updated_at`,
+		p.Slug, p.Name, p.Enabled, p.IssuerURL, p.AuthorizeURL, p.TokenURL, p.UserInfoURL, p.JWKSURL,
+		p.ClientID, p.ClientSecret, p.Scopes, p.TokenAuthMethod, p.UsePKCE, j.audiences, p.RedirectURL, p.FrontendRedirectURL,
+		j.allowedEmailDomains, p.TrustEmail, p.UsernameClaim, p.GroupsClaim, j.defaultGroupIDs, j.groupMappings, p.SortOrder,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return service.ErrOIDCProviderExists
+		}
+		return fmt.Errorf("insert oidc provider: %w", err)
+	}
+	return nil
+}
+
+func (r *oidcProviderRepository) GetByID(ctx context.Context, id int64) (*service.OIDCProvider, error) {
+	return r.getOne(ctx, `SELECT `+oidcProviderColumns+` FROM oidc_providers WHERE id = $1`, id)
+}
+
+func (r *oidcProviderRepository) GetBySlug(ctx context.Context, slug string) (*service.OIDCProvider, error) {
+	return r.getOne(ctx, `SELECT `+oidcProviderColumns+` FROM oidc_providers WHERE slug = $1`, slug)
+}
+
+func (r *oidcProviderRepository) getOne(ctx context.Context, query string, arg any) (*service.OIDCProvider, error) {
+	p, err := scanOIDCProvider(r.db.QueryRowContext(ctx, query, arg))
+	if err == sql.ErrNoRows {
+		return nil, service.ErrOIDCProviderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get oidc provider: %w", err)
+	}
+	return p, nil
+}
+
+func (r *oidcProviderRepository) Update(ctx context.Context, p *service.OIDCProvider) error {
+	j, err := marshalOIDCProviderJSON(p)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRowContext(ctx,
+		`UPDATE oidc_providers SET name = $1, enabled = $2, issuer_url = $3, authorize_url = $4, token_url = $5,
+			userinfo_url = $6, jwks_url = $7, client_id = $8, client_secret = $9, scopes = $10, token_auth_method = $11,
+			use_pkce = $12, audiences = $13, redirect_url = $14, frontend_redirect_url = $15, allowed_email_domains = $16,
+			trust_email = $17, username_claim = $18, groups_claim = $19, default_group_ids = $20, group_mappings = $21,
+			sort_order = $22, updated_at = NOW()
+		 WHERE id = $23 RETURNING updated_at`,
+		p.Name, p.Enabled, p.IssuerURL, p.AuthorizeURL, p.TokenURL,
+		p.UserInfoURL, p.JWKSURL, p.ClientID, p.ClientSecret, p.Scopes, p.TokenAuthMethod,
+		p.UsePKCE, j.audiences, p.RedirectURL, p.FrontendRedirectURL, j.allowedEmailDomains,
+		p.TrustEmail, p.UsernameClaim, p.GroupsClaim, j.defaultGroupIDs, j.groupMappings,
+		p.SortOrder, p.ID,
+	).Scan(&p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return service.ErrOIDCProviderNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("update oidc provider: %w", err)
+	}
+	return nil
+}
+
+func (r *oidcProviderRepository) Delete(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM oidc_providers WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete oidc provider: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return service.ErrOIDCProviderNotFound
+	}
+	return nil
+}
+
+func (r *oidcProviderRepository) List(ctx context.Context) ([]service.OIDCProvider, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+oidcProviderColumns+` FROM oidc_providers ORDER BY sort_order ASC, id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("list oidc providers: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []service.OIDCProvider{}
+	for rows.Next() {
+		p, err := scanOIDCProvider(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan oidc provider: %w", err)
+		}
+		out = append(out, *p)
+	}
+	return out, rows.Err()
+}
+
+func scanOIDCProvider(row scannable) (*service.OIDCProvider, error) {
+	p := &service.OIDCProvider{}
+	var j oidcProviderJSON
+	if err := row.Scan(
+		&p.ID, &p.Slug, &p.Name, &p.Enabled, &p.IssuerURL, &p.AuthorizeURL, &p.TokenURL, &p.UserInfoURL, &p.JWKSURL,
+		&p.ClientID, &p.ClientSecret, &p.Scopes, &p.TokenAuthMethod, &p.UsePKCE, &j.audiences, &p.RedirectURL, &p.FrontendRedirectURL,
+		&j.allowedEmailDomains, &p.TrustEmail, &p.UsernameClaim, &p.GroupsClaim, &j.defaultGroupIDs, &j.groupMappings, &p.SortOrder,
+		&p.CreatedAt, &p.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(j.audiences) > 0 {
+		_ = json.Unmarshal(j.audiences, &p.Audiences)
+	}
+	if len(j.allowedEmailDomains) > 0 {
+		_ = json.Unmarshal(j.allowedEmailDomains, &p.AllowedEmailDomains)
+	}
+	if len(j.defaultGroupIDs) > 0 {
+		_ = json.Unmarshal(j.defaultGroupIDs, &p.DefaultGroupIDs)
+	}
+	if len(j.groupMappings) > 0 {
+		_ = json.Unmarshal(j.groupMappings, &p.GroupMappings)
+	}
+	return p, nil
+}
+
+func marshalOIDCProviderJSON(p *service.OIDCProvider) (oidcProviderJSON, error) {
+	var (
+		j   oidcProviderJSON
+		err error
+	)
+	if j.audiences, err = json.Marshal(nonNilSlice(p.Audiences)); err != nil {
+		return j, fmt.Errorf("marshal audiences: %w", err)
+	}
+	if j.allowedEmailDomains, err = json.Marshal(nonNilSlice(p.AllowedEmailDomains)); err != nil {
+		return j, fmt.Errorf("marshal allowed_email_domains: %w", err)
+	}
+	if j.defaultGroupIDs, err = json.Marshal(nonNilSlice(p.DefaultGroupIDs)); err != nil {
+		return j, fmt.Errorf("marshal default_group_ids: %w", err)
+	}
+	if j.groupMappings, err = json.Marshal(nonNilSlice(p.GroupMappings)); err != nil {
+		return j, fmt.Errorf("marshal group_mappings: %w", err)
+	}
+	return j, nil
+}
+
+// nonNilSlice 保证 JSONB 列写入 [] 而非 null
+func nonNilSlice[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
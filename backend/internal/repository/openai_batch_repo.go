@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type openAIBatchRepository struct {
+	db *sql.DB
+}
+
+// NewOpenAIBatchRepository 创建 OpenAI 批次数据访问实例
+func NewOpenAIBatchRepository(db *sql.DB) service.OpenAIBatchRepository {
+	return &openAIBatchRepository{db: db}
+}
+
+const openAIBatchColumns = `id, user_id, api_key_id, group_id, endpoint, input_file_id, completion_window, status,
+	output_file_id, error_file_id, errors, metadata, total_count, completed_count, failed_count,
+	created_at, updated_at, expires_at, in_progress_at, finalizing_at, completed_at, failed_at,
+	expired_at, cancelling_at, cancelled_at`
+
+// openAIBatchActiveStatuses 未结束的批次状态
+const openAIBatchActiveStatuses = `('validating', 'in_progress', 'finalizing', 'cancelling')`
+
+func (r *openAIBatchRepository) Create(ctx context.Context, b *service.OpenAIBatch) error {
+	metadata, err := marshalNullableJSON(b.Metadata)
+	if err != nil {
+		return fmt.Errorf("marshal openai batch metadata: %w", err)
+	}
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO openai_batches (id, user_id, api_key_id, group_id, endpoint, input_file_id, completion_window,
+			status, metadata, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		 RETURNING updated_at`,
+		b.ID, b.UserID, b.APIKeyID, b.GroupID, b.Endpoint, b.InputFileID, b.CompletionWindow,
+		b.Status, metadata, b.CreatedAt, b.ExpiresAt,
+	).Scan(&b.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert openai batch: %w", err)
+	}
+	return nil
+}
+
+func (r *openAIBatchRepository) GetByID(ctx context.Context, id string) (*service.OpenAIBatch, error) {
+	b, err := scanOpenAIBatch(r.db.QueryRowContext(ctx, `SELECT `+openAIBatchColumns+` FROM openai_batches WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, service.ErrOpenAIBatchNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get openai batch: %w", err)
+	}
+	return b, nil
+}
+
+func (r *openAIBatchRepository) ListByAPIKey(ctx context.Context, apiKeyID int64, after string, limit int) ([]service.OpenAIBatch, bool, error) {
+	query := `SELECT ` + openAIBatchColumns + ` FROM openai_batches WHERE api_key_id = $1`
+	args := []any{apiKeyID}
+	if after != "" {
+		args = append(args, after)
+		query += ` AND (created_at, id) < (SELECT created_at, id FROM openai_batches WHERE id = $2)`
+	}
+	args = append(args, limit+1)
+	query += fmt.Sprintf(` ORDER BY created_at DESC, id DESC LIMIT $%d`, len(args))
+
+	batches, err := r.queryBatches(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	hasMore := len(batches) > limit
+	if hasMore {
+		batches = batches[:limit]
+	}
+	return batches, hasMore, nil
+}
+
+func (r *openAIBatchRepository) Update(ctx context.Context, b *service.OpenAIBatch) (string, error) {
+	var errs any
+	if len(b.Errors) > 0 {
+		raw, err := json.Marshal(b.Errors)
+		if err != nil {
+			return "", fmt.Errorf("marshal openai batch errors: %w", err)
+		}
+		errs = string(raw)
+	}
+	// 其他实例已发起取消时，执行中的进度写入不覆盖 cancelling 状态
+	var status string
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE openai_batches SET
+			status = CASE WHEN status = 'cancelling' AND $1::varchar IN ('validating', 'in_progress') THEN status ELSE $1::varchar END,
+			cancelling_at = COALESCE(cancelling_at, $2),
+			output_file_id = $3, error_file_id = $4, errors = $5,
+			total_count = $6, completed_count = $7, failed_count = $8,
+			in_progress_at = $9, finalizing_at = $10, completed_at = $11, failed_at = $12,
+			expired_at = $13, cancelled_at = $14, updated_at = NOW()
+		 WHERE id = $15
+		 RETURNING status`,
+		b.Status, b.CancellingAt, b.OutputFileID, b.ErrorFileID, errs,
+		b.Counts.Total, b.Counts.Completed, b.Counts.Failed,
+		b.InProgressAt, b.FinalizingAt, b.CompletedAt, b.FailedAt,
+		b.ExpiredAt, b.CancelledAt, b.ID,
+	).Scan(&status)
+	if err == sql.ErrNoRows {
+		return "", service.ErrOpenAIBatchNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("update openai batch: %w", err)
+	}
+	return status, nil
+}
+
+func (r *openAIBatchRepository) MarkCancelling(ctx context.Context, id string, at time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE openai_batches SET status = 'cancelling', cancelling_at = $1, updated_at = NOW()
+		 WHERE id = $2 AND status IN ('validating', 'in_progress')`,
+		at, id)
+	if err != nil {
+		return false, fmt.Errorf("mark openai batch cancelling: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("mark openai batch cancelling: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (r *openAIBatchRepository) ListStale(ctx context.Context, before time.Time, limit int) ([]service.OpenAIBatch, error) {
+	return r.queryBatches(ctx,
+		`SELECT `+openAIBatchColumns+` FROM openai_batches
+		 WHERE status IN `+openAIBatchActiveStatuses+` AND updated_at < $1
+		 ORDER BY updated_at ASC LIMIT $2`,
+		before, limit)
+}
+
+func (r *openAIBatchRepository) Claim(ctx context.Context, id string, before time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE openai_batches SET updated_at = NOW()
+		 WHERE id = $1 AND status IN `+openAIBatchActiveStatuses+` AND updated_at < $2`,
+		id, before)
+	if err != nil {
+		return false, fmt.Errorf("claim openai batch: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("claim openai batch: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (r *openAIBatchRepository) HasActiveForInputFile(ctx context.Context, fileID string) (bool, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM openai_batches WHERE input_file_id = $1 AND status IN `+openAIBatchActiveStatuses+`)`,
+		fileID).Scan(&exists); err != nil {
+		return false, fmt.Errorf("check openai batch input file: %w", err)
+	}
+	return exists, nil
+}
+
+func (r *openAIBatchRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		`DELETE FROM openai_batches
+		 WHERE status NOT IN `+openAIBatchActiveStatuses+`
+		   AND COALESCE(completed_at, failed_at, expired_at, cancelled_at, updated_at) < $1`,
+		before)
+	if err != nil {
+		return 0, fmt.Errorf("delete finished openai batches: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("delete finished openai batches: %w", err)
+	}
+	return n, nil
+}
+
+func (r *openAIBatchRepository) queryBatches(ctx context.Context, query string, args ...any) ([]service.OpenAIBatch, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query openai batches: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	batches := []service.OpenAIBatch{}
+	for rows.Next() {
+		b, err := scanOpenAIBatch(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan openai batch: %w", err)
+		}
+		batches = append(batches, *b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate openai batches: %w", err)
+	}
+	return batches, nil
+}
+
+func scanOpenAIBatch(row scannable) (*service.OpenAIBatch, error) {
+	var b service.OpenAIBatch
+	var (
+		groupID                                           sql.NullInt64
+		outputFileID, errorFileID                         sql.NullString
+		errs, metadata                                    []byte
+		inProgressAt, finalizingAt, completedAt, failedAt sql.NullTime
+		expiredAt, cancellingAt, cancelledAt              sql.NullTime
+	)
+	if err := row.Scan(
+		&b.ID, &b.UserID, &b.APIKeyID, &groupID, &b.Endpoint, &b.InputFileID, &b.CompletionWindow, &b.Status,
+		&outputFileID, &errorFileID, &errs, &metadata, &b.Counts.Total, &b.Counts.Completed, &b.Counts.Failed,
+		&b.CreatedAt, &b.UpdatedAt, &b.ExpiresAt, &inProgressAt, &finalizingAt, &completedAt, &failedAt,
+		&expiredAt, &cancellingAt, &cancelledAt,
+	); err != nil {
+		return nil, err
+	}
+	if groupID.Valid {
+		v := groupID.Int64
+		b.GroupID = &v
+	}
+	if outputFileID.Valid {
+		v := outputFileID.String
+		b.OutputFileID = &v
+	}
+	if errorFileID.Valid {
+		v := errorFileID.String
+		b.ErrorFileID = &v
+	}
+	if len(errs) > 0 {
+		if err := json.Unmarshal(errs, &b.Errors); err != nil {
+			return nil, fmt.Errorf("unmarshal openai batch errors: %w", err)
+		}
+	}
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &b.Metadata); err != nil {
+			return nil, fmt.Errorf("unmarshal openai batch metadata: %w", err)
+		}
+	}
+	b.InProgressAt = openAIBatchTimePtr(inProgressAt)
+	b.FinalizingAt = openAIBatchTimePtr(finalizingAt)
+	b.CompletedAt = openAIBatchTimePtr(completedAt)
+	b.FailedAt = openAIBatchTimePtr(failedAt)
+	b.ExpiredAt = openAIBatchTimePtr(expiredAt)
+	b.CancellingAt = openAIBatchTimePtr(cancellingAt)
+	b.CancelledAt = openAIBatchTimePtr(cancelledAt)
+	return &b, nil
+}
+
+func openAIBatchTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
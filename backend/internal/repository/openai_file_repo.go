@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type openAIFileRepository struct {
+	db *sql.DB
+}
+
+// NewOpenAIFileRepository 创建 OpenAI 文件数据访问实例
+func NewOpenAIFileRepository(db *sql.DB) service.OpenAIFileRepository {
+	return &openAIFileRepository{db: db}
+}
+
+const openAIFileColumns = `id, user_id, api_key_id, purpose, filename, bytes, storage_key, created_at, expires_at`
+
+func (r *openAIFileRepository) Create(ctx context.Context, f *service.OpenAIFile) error {
+	if _, err := r.db.ExecContext(ctx,
+		`INSERT INTO openai_files (id, user_id, api_key_id, purpose, filename, bytes, storage_key, created_at, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		f.ID, f.UserID, f.APIKeyID, f.Purpose, f.Filename, f.Bytes, f.StorageKey, f.CreatedAt, f.ExpiresAt,
+	); err != nil {
+		return fmt.Errorf("insert openai file: %w", err)
+	}
+	return nil
+}
+
+func (r *openAIFileRepository) GetByID(ctx context.Context, id string) (*service.OpenAIFile, error) {
+	f, err := scanOpenAIFile(r.db.QueryRowContext(ctx, `SELECT `+openAIFileColumns+` FROM openai_files WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, service.ErrOpenAIFileNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get openai file: %w", err)
+	}
+	return f, nil
+}
+
+func (r *openAIFileRepository) ListByAPIKey(ctx context.Context, apiKeyID int64, params service.OpenAIFileListParams) ([]service.OpenAIFile, bool, error) {
+	query := `SELECT ` + openAIFileColumns + ` FROM openai_files WHERE api_key_id = $1`
+	args := []any{apiKeyID}
+	if params.Purpose != "" {
+		args = append(args, params.Purpose)
+		query += fmt.Sprintf(` AND purpose = $%d`, len(args))
+	}
+	order := ` ORDER BY created_at DESC, id DESC`
+	cmp := "<"
+	if params.Asc {
+		order = ` ORDER BY created_at ASC, id ASC`
+		cmp = ">"
+	}
+	if params.After != "" {
+		args = append(args, params.After)
+		query += fmt.Sprintf(` AND (created_at, id) %s (SELECT created_at, id FROM openai_files WHERE id = $%d)`, cmp, len(args))
+	}
+	args = append(args, params.Limit+1)
+	query += order + fmt.Sprintf(` LIMIT $%d`, len(args))
+
+	files, err := r.queryFiles(ctx, query, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	hasMore := len(files) > params.Limit
+	if hasMore {
+		files = files[:params.Limit]
+	}
+	return files, hasMore, nil
+}
+
+func (r *openAIFileRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM openai_files WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("delete openai file: %w", err)
+	}
+	return nil
+}
+
+func (r *openAIFileRepository) ListExpired(ctx context.Context, before time.Time, limit int) ([]service.OpenAIFile, error) {
+	return r.queryFiles(ctx,
+		`SELECT `+openAIFileColumns+` FROM openai_files WHERE expires_at < $1 ORDER BY expires_at ASC LIMIT $2`,
+		before, limit)
+}
+
+func (r *openAIFileRepository) queryFiles(ctx context.Context, query string, args ...any) ([]service.OpenAIFile, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query openai files: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	files := []service.OpenAIFile{}
+	for rows.Next() {
+		f, err := scanOpenAIFile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan openai file: %w", err)
+		}
+		files = append(files, *f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate openai files: %w", err)
+	}
+	return files, nil
+}
+
+func scanOpenAIFile(row scannable) (*service.OpenAIFile, error) {
+	var f service.OpenAIFile
+	if err := row.Scan(
+		&f.ID, &f.UserID, &f.APIKeyID, &f.Purpose, &f.Filename, &f.Bytes, &f.StorageKey, &f.CreatedAt, &f.ExpiresAt,
+	); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
@@ -0,0 +1,273 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type payloadCaptureRepository struct {
+	db *sql.DB
+}
+
+// NewPayloadCaptureRepository 创建网关载荷抓取数据访问实例
+func NewPayloadCaptureRepository(db *sql.DB) service.PayloadCaptureRepository {
+	return &payloadCaptureRepository{db: db}
+}
+
+const payloadCaptureRuleColumns = `id, scope_type, scope_id, enabled, sample_rate, max_body_bytes, redact_fields, note,
+	expires_at, created_at, updated_at`
+
+// payloadCaptureSummaryColumns 列表查询不读取载荷正文
+const payloadCaptureSummaryColumns = `id, rule_id, request_id, client_request_id, user_id, api_key_id, group_id, account_id,
+	platform, model, method, path, stream, status_code, duration_ms, '' AS request_body, '' AS response_body,
+	request_bytes, response_bytes, request_truncated, response_truncated, created_at`
+
+const payloadCaptureColumns = `id, rule_id, request_id, client_request_id, user_id, api_key_id, group_id, account_id,
+	platform, model, method, path, stream, status_code, duration_ms, request_body, response_body,
+	request_bytes, response_bytes, request_truncated, response_truncated, created_at`
+
+func (r *payloadCaptureRepository) ListRules(ctx context.Context) ([]service.PayloadCaptureRule, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+payloadCaptureRuleColumns+` FROM payload_capture_rules ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("query payload capture rules: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	rules := []service.PayloadCaptureRule{}
+	for rows.Next() {
+		rule, err := scanPayloadCaptureRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan payload capture rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate payload capture rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r *payloadCaptureRepository) GetRule(ctx context.Context, id int64) (*service.PayloadCaptureRule, error) {
+	rule, err := scanPayloadCaptureRule(r.db.QueryRowContext(ctx,
+		`SELECT `+payloadCaptureRuleColumns+` FROM payload_capture_rules WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, service.ErrPayloadCaptureRuleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get payload capture rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (r *payloadCaptureRepository) CreateRule(ctx context.Context, rule *service.PayloadCaptureRule) error {
+	fields, err := json.Marshal(rule.RedactFields)
+	if err != nil {
+		return fmt.Errorf("marshal payload capture redact fields: %w", err)
+	}
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO payload_capture_rules (scope_type, scope_id, enabled, sample_rate, max_body_bytes, redact_fields, note, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7, $8)
+		 RETURNING id, created_at, updated_at`,
+		rule.ScopeType, rule.ScopeID, rule.Enabled, rule.SampleRate, rule.MaxBodyBytes, string(fields), rule.Note, rule.ExpiresAt,
+	).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if isUniqueViolation(err) {
+		return service.ErrPayloadCaptureRuleExists
+	}
+	if err != nil {
+		return fmt.Errorf("insert payload capture rule: %w", err)
+	}
+	return nil
+}
+
+func (r *payloadCaptureRepository) UpdateRule(ctx context.Context, rule *service.PayloadCaptureRule) error {
+	fields, err := json.Marshal(rule.RedactFields)
+	if err != nil {
+		return fmt.Errorf("marshal payload capture redact fields: %w", err)
+	}
+	err = r.db.QueryRowContext(ctx,
+		`UPDATE payload_capture_rules SET scope_type = $1, scope_id = $2, enabled = $3, sample_rate = $4,
+			max_body_bytes = $5, redact_fields = $6::jsonb, note = $7, expires_at = $8, updated_at = NOW()
+		 WHERE id = $9
+		 RETURNING created_at, updated_at`,
+		rule.ScopeType, rule.ScopeID, rule.Enabled, rule.SampleRate, rule.MaxBodyBytes, string(fields), rule.Note, rule.ExpiresAt, rule.ID,
+	).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return service.ErrPayloadCaptureRuleNotFound
+	}
+	if isUniqueViolation(err) {
+		return service.ErrPayloadCaptureRuleExists
+	}
+	if err != nil {
+		return fmt.Errorf("update payload capture rule: %w", err)
+	}
+	return nil
+}
+
+func (r *payloadCaptureRepository) DeleteRule(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM payload_capture_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete payload capture rule: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete payload capture rule: %w", err)
+	}
+	if n == 0 {
+		return service.ErrPayloadCaptureRuleNotFound
+	}
+	return nil
+}
+
+func (r *payloadCaptureRepository) CreateCapture(ctx context.Context, c *service.PayloadCapture) error {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO ops_payload_captures (rule_id, request_id, client_request_id, user_id, api_key_id, group_id, account_id,
+			platform, model, method, path, stream, status_code, duration_ms, request_body, response_body,
+			request_bytes, response_bytes, request_truncated, response_truncated)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20)
+		 RETURNING id, created_at`,
+		c.RuleID, c.RequestID, c.ClientRequestID, c.UserID, c.APIKeyID, c.GroupID, c.AccountID,
+		c.Platform, c.Model, c.Method, c.Path, c.Stream, c.StatusCode, c.DurationMs, c.RequestBody, c.ResponseBody,
+		c.RequestBytes, c.ResponseBytes, c.RequestTruncated, c.ResponseTruncated,
+	).Scan(&c.ID, &c.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert payload capture: %w", err)
+	}
+	return nil
+}
+
+func (r *payloadCaptureRepository) ListCaptures(ctx context.Context, params pagination.PaginationParams, filters service.PayloadCaptureFilters) ([]service.PayloadCapture, *pagination.PaginationResult, error) {
+	whereClause, args := buildPayloadCaptureWhere(filters)
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM ops_payload_captures WHERE "+whereClause, args...).Scan(&total); err != nil {
+		return nil, nil, fmt.Errorf("count payload captures: %w", err)
+	}
+
+	dataQuery := fmt.Sprintf(
+		`SELECT `+payloadCaptureSummaryColumns+` FROM ops_payload_captures WHERE %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`,
+		whereClause, len(args)+1, len(args)+2,
+	)
+	args = append(args, params.Limit(), params.Offset())
+
+	rows, err := r.db.QueryContext(ctx, dataQuery, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query payload captures: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	captures := []service.PayloadCapture{}
+	for rows.Next() {
+		c, err := scanPayloadCapture(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("scan payload capture: %w", err)
+		}
+		captures = append(captures, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("iterate payload captures: %w", err)
+	}
+	return captures, paginationResultFromTotal(total, params), nil
+}
+
+func (r *payloadCaptureRepository) GetCapture(ctx context.Context, id int64) (*service.PayloadCapture, error) {
+	c, err := scanPayloadCapture(r.db.QueryRowContext(ctx,
+		`SELECT `+payloadCaptureColumns+` FROM ops_payload_captures WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, service.ErrPayloadCaptureNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get payload capture: %w", err)
+	}
+	return c, nil
+}
+
+func buildPayloadCaptureWhere(filters service.PayloadCaptureFilters) (string, []any) {
+	where := []string{"1=1"}
+	args := []any{}
+	argIdx := 1
+
+	if requestID := strings.TrimSpace(filters.RequestID); requestID != "" {
+		// usage_logs.request_id 使用 "client:<client_request_id>" 形式，去掉前缀后同样可以关联
+		where = append(where, fmt.Sprintf("(request_id = $%d OR client_request_id = $%d)", argIdx, argIdx+1))
+		args = append(args, requestID, strings.TrimPrefix(requestID, "client:"))
+		argIdx += 2
+	}
+	if filters.UserID > 0 {
+		where = append(where, fmt.Sprintf("user_id = $%d", argIdx))
+		args = append(args, filters.UserID)
+		argIdx++
+	}
+	if filters.APIKeyID > 0 {
+		where = append(where, fmt.Sprintf("api_key_id = $%d", argIdx))
+		args = append(args, filters.APIKeyID)
+		argIdx++
+	}
+	if filters.GroupID > 0 {
+		where = append(where, fmt.Sprintf("group_id = $%d", argIdx))
+		args = append(args, filters.GroupID)
+		argIdx++
+	}
+	if filters.Model != "" {
+		where = append(where, fmt.Sprintf("model = $%d", argIdx))
+		args = append(args, filters.Model)
+		argIdx++
+	}
+	if filters.StartTime != nil {
+		where = append(where, fmt.Sprintf("created_at >= $%d", argIdx))
+		args = append(args, *filters.StartTime)
+		argIdx++
+	}
+	if filters.EndTime != nil {
+		where = append(where, fmt.Sprintf("created_at < $%d", argIdx))
+		args = append(args, *filters.EndTime)
+	}
+	return strings.Join(where, " AND "), args
+}
+
+func scanPayloadCaptureRule(row scannable) (*service.PayloadCaptureRule, error) {
+	var rule service.PayloadCaptureRule
+	var fields []byte
+	var expiresAt sql.NullTime
+	if err := row.Scan(
+		&rule.ID, &rule.ScopeType, &rule.ScopeID, &rule.Enabled, &rule.SampleRate, &rule.MaxBodyBytes, &fields, &rule.Note,
+		&expiresAt, &rule.CreatedAt, &rule.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(fields) > 0 {
+		_ = json.Unmarshal(fields, &rule.RedactFields)
+	}
+	if expiresAt.Valid {
+		v := expiresAt.Time
+		rule.ExpiresAt = &v
+	}
+	return &rule, nil
+}
+
+func scanPayloadCapture(row scannable) (*service.PayloadCapture, error) {
+	var c service.PayloadCapture
+	var groupID, accountID sql.NullInt64
+	if err := row.Scan(
+		&c.ID, &c.RuleID, &c.RequestID, &c.ClientRequestID, &c.UserID, &c.APIKeyID, &groupID, &accountID,
+		&c.Platform, &c.Model, &c.Method, &c.Path, &c.Stream, &c.StatusCode, &c.DurationMs, &c.RequestBody, &c.ResponseBody,
+		&c.RequestBytes, &c.ResponseBytes, &c.RequestTruncated, &c.ResponseTruncated, &c.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if groupID.Valid {
+		v := groupID.Int64
+		c.GroupID = &v
+	}
+	if accountID.Valid {
+		v := accountID.Int64
+		c.AccountID = &v
+	}
+	return &c, nil
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type paymentOrderRepository struct {
+	db *sql.DB
+}
+
+// NewPaymentOrderRepository 创建支付订单数据访问实例
+func NewPaymentOrderRepository(db *sql.DB) service.PaymentOrderRepository {
+	return &paymentOrderRepository{db: db}
+}
+
+const paymentOrderColumns = `id, order_no, user_id, provider, pay_type, kind, amount, currency, credit_amount, group_id, validity_days,
+	subject, status, provider_trade_no, pay_url, client_ip, paid_at, expires_at, created_at, updated_at`
+
+// exec 优先使用 context 中的事务，使订单状态更新与权益发放处于同一事务
+func (r *paymentOrderRepository) exec(ctx context.Context) sqlExecutor {
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		return tx.Client()
+	}
+	return r.db
+}
+
+func (r *paymentOrderRepository) Create(ctx context.Context, o *service.PaymentOrder) error {
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO payment_orders (order_no, user_id, provider, pay_type, kind, amount, currency, credit_amount, group_id, validity_days,
+			subject, status, client_ip, expires_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		 RETURNING id, created_at, updated_at`,
+		o.OrderNo, o.UserID, o.Provider, o.PayType, o.Kind, o.Amount, o.Currency, o.CreditAmount, nullInt64(o.GroupID), o.ValidityDays,
+		o.Subject, o.Status, o.ClientIP, o.ExpiresAt,
+	).Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return service.ErrPaymentOrderExists
+		}
+		return fmt.Errorf("insert payment order: %w", err)
+	}
+	return nil
+}
+
+func (r *paymentOrderRepository) GetByOrderNo(ctx context.Context, orderNo string) (*service.PaymentOrder, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+paymentOrderColumns+` FROM payment_orders WHERE order_no = $1`, orderNo)
+	o, err := scanPaymentOrder(row)
+	if err == sql.ErrNoRows {
+		return nil, service.ErrPaymentOrderNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get payment order: %w", err)
+	}
+	return o, nil
+}
+
+func (r *paymentOrderRepository) SetPayInfo(ctx context.Context, orderNo, payURL, providerTradeNo string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE payment_orders SET pay_url = $1, provider_trade_no = $2, updated_at = NOW() WHERE order_no = $3`,
+		payURL, providerTradeNo, orderNo)
+	if err != nil {
+		return fmt.Errorf("update payment order pay info: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return service.ErrPaymentOrderNotFound
+	}
+	return nil
+}
+
+func (r *paymentOrderRepository) MarkCompleted(ctx context.Context, orderNo, providerTradeNo string, paidAt time.Time) (bool, error) {
+	result, err := r.exec(ctx).ExecContext(ctx,
+		`UPDATE payment_orders
+		 SET status = $1, paid_at = $2, provider_trade_no = COALESCE(NULLIF($3, ''), provider_trade_no), updated_at = NOW()
+		 WHERE order_no = $4 AND status IN ($5, $6)`,
+		service.PaymentOrderStatusCompleted, paidAt, providerTradeNo, orderNo,
+		service.PaymentOrderStatusPending, service.PaymentOrderStatusCancelled)
+	if err != nil {
+		return false, fmt.Errorf("mark payment order completed: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("mark payment order completed: %w", err)
+	}
+	return rows > 0, nil
+}
+
+func (r *paymentOrderRepository) UpdateStatus(ctx context.Context, orderNo, fromStatus, toStatus string) (bool, error) {
+	result, err := r.exec(ctx).ExecContext(ctx,
+		`UPDATE payment_orders SET status = $1, updated_at = NOW() WHERE order_no = $2 AND status = $3`,
+		toStatus, orderNo, fromStatus)
+	if err != nil {
+		return false, fmt.Errorf("update payment order status: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("update payment order status: %w", err)
+	}
+	return rows > 0, nil
+}
+
+func (r *paymentOrderRepository) List(ctx context.Context, params pagination.PaginationParams, filters service.PaymentOrderListFilters) ([]service.PaymentOrder, *pagination.PaginationResult, error) {
+	where := []string{"1=1"}
+	args := []any{}
+	argIdx := 1
+
+	if filters.UserID > 0 {
+		where = append(where, fmt.Sprintf("user_id = $%d", argIdx))
+		args = append(args, filters.UserID)
+		argIdx++
+	}
+	switch filters.Status {
+	case "":
+	case service.PaymentOrderStatusPending:
+		where = append(where, fmt.Sprintf("status = $%d AND expires_at > NOW()", argIdx))
+		args = append(args, service.PaymentOrderStatusPending)
+		argIdx++
+	case service.PaymentOrderStatusExpired:
+		// expired 不落库：超过有效期仍未支付的 pending 订单
+		where = append(where, fmt.Sprintf("status = $%d AND expires_at <= NOW()", argIdx))
+		args = append(args, service.PaymentOrderStatusPending)
+		argIdx++
+	default:
+		where = append(where, fmt.Sprintf("status = $%d", argIdx))
+		args = append(args, filters.Status)
+		argIdx++
+	}
+	if filters.Provider != "" {
+		where = append(where, fmt.Sprintf("provider = $%d", argIdx))
+		args = append(args, filters.Provider)
+		argIdx++
+	}
+	if filters.Kind != "" {
+		where = append(where, fmt.Sprintf("kind = $%d", argIdx))
+		args = append(args, filters.Kind)
+		argIdx++
+	}
+	if filters.Search != "" {
+		where = append(where, fmt.Sprintf("(order_no ILIKE $%d OR provider_trade_no ILIKE $%d)", argIdx, argIdx))
+		args = append(args, "%"+escapeLike(filters.Search)+"%")
+		argIdx++
+	}
+	whereClause := strings.Join(where, " AND ")
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM payment_orders WHERE "+whereClause, args...).Scan(&total); err != nil {
+		return nil, nil, fmt.Errorf("count payment orders: %w", err)
+	}
+
+	dataQuery := fmt.Sprintf(
+		`SELECT `+paymentOrderColumns+` FROM payment_orders WHERE %s ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d`,
+		whereClause, argIdx, argIdx+1,
+	)
+	args = append(args, params.Limit(), params.Offset())
+
+	rows, err := r.db.QueryContext(ctx, dataQuery, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query payment orders: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	orders := []service.PaymentOrder{}
+	for rows.Next() {
+		o, err := scanPaymentOrder(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("scan payment order: %w", err)
+		}
+		orders = append(orders, *o)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("iterate payment orders: %w", err)
+	}
+	return orders, paginationResultFromTotal(total, params), nil
+}
+
+func scanPaymentOrder(row scannable) (*service.PaymentOrder, error) {
+	var o service.PaymentOrder
+	var groupID sql.NullInt64
+	var paidAt sql.NullTime
+	if err := row.Scan(
+		&o.ID, &o.OrderNo, &o.UserID, &o.Provider, &o.PayType, &o.Kind, &o.Amount, &o.Currency, &o.CreditAmount, &groupID, &o.ValidityDays,
+		&o.Subject, &o.Status, &o.ProviderTradeNo, &o.PayURL, &o.ClientIP, &paidAt, &o.ExpiresAt, &o.CreatedAt, &o.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if groupID.Valid {
+		v := groupID.Int64
+		o.GroupID = &v
+	}
+	if paidAt.Valid {
+		v := paidAt.Time
+		o.PaidAt = &v
+	}
+	return &o, nil
+}
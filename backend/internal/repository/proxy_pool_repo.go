@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type proxyPoolRepository struct {
+	db *sql.DB
+}
+
+// NewProxyPoolRepository 创建代理池数据访问实例
+func NewProxyPoolRepository(db *sql.DB) service.ProxyPoolRepository {
+	return &proxyPoolRepository{db: db}
+}
+
+const proxyPoolColumns = `id, name, description, strategy, proxy_ids, status, created_at, updated_at`
+
+func (r *proxyPoolRepository) List(ctx context.Context) ([]service.ProxyPool, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+proxyPoolColumns+` FROM proxy_pools ORDER BY id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("query proxy pools: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	pools := []service.ProxyPool{}
+	for rows.Next() {
+		pool, err := scanProxyPool(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan proxy pool: %w", err)
+		}
+		pools = append(pools, *pool)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate proxy pools: %w", err)
+	}
+	return pools, nil
+}
+
+func (r *proxyPoolRepository) GetByID(ctx context.Context, id int64) (*service.ProxyPool, error) {
+	pool, err := scanProxyPool(r.db.QueryRowContext(ctx,
+		`SELECT `+proxyPoolColumns+` FROM proxy_pools WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, service.ErrProxyPoolNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get proxy pool: %w", err)
+	}
+	return pool, nil
+}
+
+func (r *proxyPoolRepository) Create(ctx context.Context, pool *service.ProxyPool) error {
+	proxyIDs, err := json.Marshal(pool.ProxyIDs)
+	if err != nil {
+		return fmt.Errorf("marshal proxy pool members: %w", err)
+	}
+	err = r.db.QueryRowContext(ctx,
+		`INSERT INTO proxy_pools (name, description, strategy, proxy_ids, status)
+		 VALUES ($1, $2, $3, $4::jsonb, $5)
+		 RETURNING id, created_at, updated_at`,
+		pool.Name, pool.Description, pool.Strategy, string(proxyIDs), pool.Status,
+	).Scan(&pool.ID, &pool.CreatedAt, &pool.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("insert proxy pool: %w", err)
+	}
+	return nil
+}
+
+func (r *proxyPoolRepository) Update(ctx context.Context, pool *service.ProxyPool) error {
+	proxyIDs, err := json.Marshal(pool.ProxyIDs)
+	if err != nil {
+		return fmt.Errorf("marshal proxy pool members: %w", err)
+	}
+	err = r.db.QueryRowContext(ctx,
+		`UPDATE proxy_pools SET name = $1, description = $2, strategy = $3, proxy_ids = $4::jsonb, status = $5,
+			updated_at = NOW()
+		 WHERE id = $6
+		 RETURNING created_at, updated_at`,
+		pool.Name, pool.Description, pool.Strategy, string(proxyIDs), pool.Status, pool.ID,
+	).Scan(&pool.CreatedAt, &pool.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return service.ErrProxyPoolNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("update proxy pool: %w", err)
+	}
+	return nil
+}
+
+func (r *proxyPoolRepository) Delete(ctx context.Context, id int64) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM proxy_pools WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("delete proxy pool: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("delete proxy pool: %w", err)
+	}
+	if n == 0 {
+		return service.ErrProxyPoolNotFound
+	}
+	return nil
+}
+
+// CountAccountsByPoolID 统计通过 extra.proxy_pool_id 绑定该代理池的账号数
+func (r *proxyPoolRepository) CountAccountsByPoolID(ctx context.Context, poolID int64) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM accounts WHERE deleted_at IS NULL AND extra->>'proxy_pool_id' = $1`,
+		strconv.FormatInt(poolID, 10),
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count proxy pool accounts: %w", err)
+	}
+	return count, nil
+}
+
+func scanProxyPool(row scannable) (*service.ProxyPool, error) {
+	var pool service.ProxyPool
+	var proxyIDs []byte
+	if err := row.Scan(
+		&pool.ID, &pool.Name, &pool.Description, &pool.Strategy, &proxyIDs, &pool.Status, &pool.CreatedAt, &pool.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if len(proxyIDs) > 0 {
+		_ = json.Unmarshal(proxyIDs, &pool.ProxyIDs)
+	}
+	return &pool, nil
+}
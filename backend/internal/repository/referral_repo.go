@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type referralRepository struct {
+	db *sql.DB
+}
+
+// NewReferralRepository 创建邀请返佣数据访问实例
+func NewReferralRepository(db *sql.DB) service.ReferralRepository {
+	return &referralRepository{db: db}
+}
+
+// exec 优先使用 context 中的事务，使结算水位、返佣明细与余额入账处于同一事务
+func (r *referralRepository) exec(ctx context.Context) sqlExecutor {
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		return tx.Client()
+	}
+	return r.db
+}
+
+func (r *referralRepository) GetCodeByUserID(ctx context.Context, userID int64) (string, error) {
+	var code string
+	err := r.db.QueryRowContext(ctx, `SELECT code FROM referral_codes WHERE user_id = $1`, userID).Scan(&code)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", service.ErrReferralCodeNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("get referral code: %w", err)
+	}
+	return code, nil
+}
+
+func (r *referralRepository) CreateCode(ctx context.Context, userID int64, code string) error {
+	// 同一用户并发生成时以先写入者为准（ON CONFLICT user_id），code 冲突由调用方换码重试
+	_, err := r.exec(ctx).ExecContext(ctx,
+		`INSERT INTO referral_codes (user_id, code) VALUES ($1, $2) ON CONFLICT (user_id) DO NOTHING`,
+		userID, code)
+	if isUniqueViolation(err) {
+		return service.ErrReferralCodeExists
+	}
+	if err != nil {
+		return fmt.Errorf("insert referral code: %w", err)
+	}
+	return nil
+}
+
+func (r *referralRepository) GetUserIDByCode(ctx context.Context, code string) (int64, error) {
+	var userID int64
+	err := r.db.QueryRowContext(ctx, `SELECT user_id FROM referral_codes WHERE code = $1`, code).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, service.ErrReferralCodeNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("get referral code owner: %w", err)
+	}
+	return userID, nil
+}
+
+func (r *referralRepository) CreateReferral(ctx context.Context, inviterID, inviteeID int64, settledFrom time.Time) (bool, error) {
+	res, err := r.exec(ctx).ExecContext(ctx,
+		`INSERT INTO referrals (inviter_id, invitee_id, settled_until) VALUES ($1, $2, $3)
+		 ON CONFLICT (invitee_id) DO NOTHING`,
+		inviterID, inviteeID, settledFrom)
+	if err != nil {
+		return false, fmt.Errorf("insert referral: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("insert referral rows affected: %w", err)
+	}
+	return n > 0, nil
+}
+
+const referralSelectColumns = `r.id, r.inviter_id, r.invitee_id, r.settled_until, r.total_cost, r.total_commission, r.created_at,
+	COALESCE(inviter.email, ''), COALESCE(invitee.email, '')`
+
+const referralJoins = `FROM referrals r
+	LEFT JOIN users inviter ON inviter.id = r.inviter_id
+	LEFT JOIN users invitee ON invitee.id = r.invitee_id`
+
+func scanReferral(row scannable) (*service.Referral, error) {
+	var ref service.Referral
+	if err := row.Scan(
+		&ref.ID, &ref.InviterID, &ref.InviteeID, &ref.SettledUntil, &ref.TotalCost, &ref.TotalCommission, &ref.CreatedAt,
+		&ref.InviterEmail, &ref.InviteeEmail,
+	); err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+func (r *referralRepository) GetByInviteeID(ctx context.Context, inviteeID int64) (*service.Referral, error) {
+	row := r.db.QueryRowContext(ctx,
+		"SELECT "+referralSelectColumns+" "+referralJoins+" WHERE r.invitee_id = $1", inviteeID)
+	ref, err := scanReferral(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, service.ErrReferralNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get referral: %w", err)
+	}
+	return ref, nil
+}
+
+func (r *referralRepository) GetStats(ctx context.Context, inviterID int64) (*service.ReferralStats, error) {
+	var stats service.ReferralStats
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(SUM(total_cost), 0), COALESCE(SUM(total_commission), 0)
+		 FROM referrals WHERE inviter_id = $1`, inviterID,
+	).Scan(&stats.InviteeCount, &stats.TotalCost, &stats.TotalCommission); err != nil {
+		return nil, fmt.Errorf("get referral stats: %w", err)
+	}
+	return &stats, nil
+}
+
+// referralWhere 构建邀请关系/返佣明细的筛选条件（两张表的字段名一致）
+func referralWhere(alias string, filters service.ReferralListFilters) (string, []any) {
+	where := []string{"1=1"}
+	args := []any{}
+	if filters.InviterID > 0 {
+		args = append(args, filters.InviterID)
+		where = append(where, fmt.Sprintf("%s.inviter_id = $%d", alias, len(args)))
+	}
+	if filters.InviteeID > 0 {
+		args = append(args, filters.InviteeID)
+		where = append(where, fmt.Sprintf("%s.invitee_id = $%d", alias, len(args)))
+	}
+	return strings.Join(where, " AND "), args
+}
+
+func (r *referralRepository) List(ctx context.Context, params pagination.PaginationParams, filters service.ReferralListFilters) ([]service.Referral, *pagination.PaginationResult, error) {
+	whereClause, args := referralWhere("r", filters)
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM referrals r WHERE "+whereClause, args...).Scan(&total); err != nil {
+		return nil, nil, fmt.Errorf("count referrals: %w", err)
+	}
+
+	query := fmt.Sprintf("SELECT %s %s WHERE %s ORDER BY r.created_at DESC, r.id DESC LIMIT $%d OFFSET $%d",
+		referralSelectColumns, referralJoins, whereClause, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query referrals: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []service.Referral{}
+	for rows.Next() {
+		ref, err := scanReferral(rows)
+		if err != nil {
+			return nil, nil, fmt.Errorf("scan referral: %w", err)
+		}
+		out = append(out, *ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("iterate referrals: %w", err)
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *referralRepository) ListCommissions(ctx context.Context, params pagination.PaginationParams, filters service.ReferralListFilters) ([]service.ReferralCommission, *pagination.PaginationResult, error) {
+	whereClause, args := referralWhere("c", filters)
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM referral_commissions c WHERE "+whereClause, args...).Scan(&total); err != nil {
+		return nil, nil, fmt.Errorf("count referral commissions: %w", err)
+	}
+
+	query := fmt.Sprintf(
+		`SELECT c.id, c.referral_id, c.inviter_id, c.invitee_id, c.usage_cost, c.rate, c.amount, c.period_start, c.period_end,
+			c.created_at, COALESCE(u.email, '')
+		 FROM referral_commissions c
+		 LEFT JOIN users u ON u.id = c.invitee_id
+		 WHERE %s
+		 ORDER BY c.created_at DESC, c.id DESC
+		 LIMIT $%d OFFSET $%d`,
+		whereClause, len(args)+1, len(args)+2)
+	rows, err := r.db.QueryContext(ctx, query, append(args, params.Limit(), params.Offset())...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query referral commissions: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []service.ReferralCommission{}
+	for rows.Next() {
+		var c service.ReferralCommission
+		if err := rows.Scan(
+			&c.ID, &c.ReferralID, &c.InviterID, &c.InviteeID, &c.UsageCost, &c.Rate, &c.Amount, &c.PeriodStart, &c.PeriodEnd,
+			&c.CreatedAt, &c.InviteeEmail,
+		); err != nil {
+			return nil, nil, fmt.Errorf("scan referral commission: %w", err)
+		}
+		out = append(out, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("iterate referral commissions: %w", err)
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
+
+func (r *referralRepository) ListSettlementDue(ctx context.Context, before time.Time, afterID int64, commissionDays int, maxCommission float64, limit int) ([]service.Referral, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT r.id, r.inviter_id, r.invitee_id, r.settled_until, r.total_cost, r.total_commission, r.created_at
+		 FROM referrals r
+		 JOIN users inviter ON inviter.id = r.inviter_id AND inviter.deleted_at IS NULL
+		 WHERE r.id > $1
+		   AND r.settled_until < $2
+		   AND ($3::int <= 0 OR r.settled_until < r.created_at + make_interval(days => $3::int))
+		   AND ($4::numeric <= 0 OR r.total_commission < $4::numeric)
+		 ORDER BY r.id ASC
+		 LIMIT $5`,
+		afterID, before, commissionDays, maxCommission, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query referrals due for settlement: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []service.Referral{}
+	for rows.Next() {
+		var ref service.Referral
+		if err := rows.Scan(&ref.ID, &ref.InviterID, &ref.InviteeID, &ref.SettledUntil, &ref.TotalCost, &ref.TotalCommission, &ref.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan referral: %w", err)
+		}
+		out = append(out, ref)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate referrals due for settlement: %w", err)
+	}
+	return out, nil
+}
+
+func (r *referralRepository) SumInviteeCost(ctx context.Context, inviteeID int64, from, to time.Time) (float64, error) {
+	var cost float64
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(actual_cost), 0) FROM usage_logs
+		 WHERE user_id = $1 AND created_at >= $2 AND created_at < $3`,
+		inviteeID, from, to,
+	).Scan(&cost); err != nil {
+		return 0, fmt.Errorf("sum invitee cost: %w", err)
+	}
+	return cost, nil
+}
+
+func (r *referralRepository) AdvanceSettlement(ctx context.Context, referralID int64, prevSettledUntil, settledUntil time.Time, cost, commission float64) (bool, error) {
+	res, err := r.exec(ctx).ExecContext(ctx,
+		`UPDATE referrals
+		 SET settled_until = $3, total_cost = total_cost + $4, total_commission = total_commission + $5
+		 WHERE id = $1 AND settled_until = $2`,
+		referralID, prevSettledUntil, settledUntil, cost, commission)
+	if err != nil {
+		return false, fmt.Errorf("advance referral settlement: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("advance referral settlement rows affected: %w", err)
+	}
+	return n > 0, nil
+}
+
+func (r *referralRepository) CreateCommission(ctx context.Context, c *service.ReferralCommission) error {
+	err := scanSingleRow(ctx, r.exec(ctx),
+		`INSERT INTO referral_commissions (referral_id, inviter_id, invitee_id, usage_cost, rate, amount, period_start, period_end)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		 RETURNING id, created_at`,
+		[]any{c.ReferralID, c.InviterID, c.InviteeID, c.UsageCost, c.Rate, c.Amount, c.PeriodStart, c.PeriodEnd},
+		&c.ID, &c.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert referral commission: %w", err)
+	}
+	return nil
+}
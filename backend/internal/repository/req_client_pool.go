@@ -36,6 +36,13 @@ var sharedReqClients sync.Map
 // getSharedReqClient 获取共享的 req 客户端实例
 // 性能优化：相同配置复用同一客户端，避免重复创建
 func getSharedReqClient(opts reqClientOptions) (*req.Client, error) {
+	// 代理池占位 URL 需在计算缓存键前解析，确保客户端随池内代理切换
+	resolved, err := proxyurl.Resolve(opts.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+	opts.ProxyURL = resolved
+
 	key := buildReqClientKey(opts)
 	if cached, ok := sharedReqClients.Load(key); ok {
 		if c, ok := cached.(*req.Client); ok {
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	responseCacheKeyPrefix = "response_cache:"
+	// responseCacheIndexPrefix 分组内条目按写入时间排序的有序集合，用于淘汰最旧条目
+	responseCacheIndexPrefix = "response_cache_index:"
+	// responseCacheSizesPrefix 分组内各条目字节数的哈希，#total 字段记录总字节数
+	responseCacheSizesPrefix = "response_cache_sizes:"
+)
+
+var (
+	// setResponseCacheScript 写入条目并维护分组预算
+	// 使用 Redis TIME 命令获取服务器时间，避免多实例时钟不同步问题
+	// KEYS[1] = 条目键
+	// KEYS[2] = 分组索引有序集合键
+	// KEYS[3] = 分组字节数哈希键
+	// ARGV[1] = 条目数据
+	// ARGV[2] = TTL（秒）
+	// ARGV[3] = 分组最大条目数（0 表示不限）
+	// ARGV[4] = 分组最大字节数（0 表示不限）
+	// ARGV[5] = 条目在索引中的成员名
+	// ARGV[6] = 条目键前缀（淘汰时拼接条目键）
+	setResponseCacheScript = redis.NewScript(`
+		local ttl = tonumber(ARGV[2])
+		local maxEntries = tonumber(ARGV[3])
+		local maxBytes = tonumber(ARGV[4])
+		local member = ARGV[5]
+		local size = string.len(ARGV[1])
+
+		-- 微秒精度，保证同一秒内写入的条目仍按写入顺序淘汰
+		local timeResult = redis.call('TIME')
+		local now = tonumber(timeResult[1]) * 1000000 + tonumber(timeResult[2])
+		local expireBefore = now - ttl * 1000000
+
+		local function forget(m)
+			local s = tonumber(redis.call('HGET', KEYS[3], m) or '0')
+			redis.call('HDEL', KEYS[3], m)
+			redis.call('HINCRBY', KEYS[3], '#total', -s)
+		end
+
+		-- 清理已过期条目（条目键本身已随 TTL 失效）
+		local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', expireBefore)
+		for _, m in ipairs(expired) do
+			forget(m)
+		end
+		redis.call('ZREMRANGEBYSCORE', KEYS[2], '-inf', expireBefore)
+
+		-- 覆盖写入时先扣除旧条目大小
+		if redis.call('HEXISTS', KEYS[3], member) == 1 then
+			forget(member)
+		end
+
+		redis.call('SET', KEYS[1], ARGV[1], 'EX', ttl)
+		redis.call('ZADD', KEYS[2], now, member)
+		redis.call('HSET', KEYS[3], member, size)
+		redis.call('HINCRBY', KEYS[3], '#total', size)
+
+		-- 超出预算时按写入时间淘汰最旧条目
+		while true do
+			local count = redis.call('ZCARD', KEYS[2])
+			local total = tonumber(redis.call('HGET', KEYS[3], '#total') or '0')
+			if (maxEntries <= 0 or count <= maxEntries) and (maxBytes <= 0 or total <= maxBytes) then
+				break
+			end
+			local oldest = redis.call('ZPOPMIN', KEYS[2])
+			if #oldest == 0 then
+				break
+			end
+			forget(oldest[1])
+			redis.call('DEL', ARGV[6] .. oldest[1])
+		end
+
+		redis.call('EXPIRE', KEYS[2], ttl)
+		redis.call('EXPIRE', KEYS[3], ttl)
+		return 1
+	`)
+)
+
+type responseCacheStore struct {
+	rdb *redis.Client
+}
+
+// NewResponseCacheStore 创建基于 Redis 的响应缓存存储
+func NewResponseCacheStore(rdb *redis.Client) service.ResponseCacheStore {
+	return &responseCacheStore{rdb: rdb}
+}
+
+func (s *responseCacheStore) Get(ctx context.Context, key string) (*service.ResponseCacheEntry, error) {
+	data, err := s.rdb.Get(ctx, responseCacheKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry service.ResponseCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (s *responseCacheStore) Set(ctx context.Context, groupID int64, key string, entry *service.ResponseCacheEntry, ttl time.Duration, budget service.ResponseCacheBudget) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	group := strconv.FormatInt(groupID, 10)
+	keys := []string{
+		responseCacheKeyPrefix + key,
+		responseCacheIndexPrefix + group,
+		responseCacheSizesPrefix + group,
+	}
+	ttlSeconds := int(ttl / time.Second)
+	if ttlSeconds <= 0 {
+		ttlSeconds = 1
+	}
+	return setResponseCacheScript.Run(ctx, s.rdb, keys,
+		data, ttlSeconds, budget.MaxEntries, budget.MaxBytes, key, responseCacheKeyPrefix).Err()
+}
@@ -0,0 +1,99 @@
+//go:build integration
+
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+)
+
+type ResponseCacheSuite struct {
+	IntegrationRedisSuite
+	store service.ResponseCacheStore
+}
+
+func (s *ResponseCacheSuite) SetupTest() {
+	s.IntegrationRedisSuite.SetupTest()
+	s.store = NewResponseCacheStore(s.rdb)
+}
+
+func (s *ResponseCacheSuite) TestSetAndGet() {
+	entry := &service.ResponseCacheEntry{StatusCode: 200, ContentType: "application/json", Body: []byte(`{"ok":true}`)}
+	require.NoError(s.T(), s.store.Set(s.ctx, 1, "1:2:a", entry, time.Minute, service.ResponseCacheBudget{}))
+
+	got, err := s.store.Get(s.ctx, "1:2:a")
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), got)
+	require.Equal(s.T(), entry.Body, got.Body)
+
+	missing, err := s.store.Get(s.ctx, "1:2:missing")
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), missing)
+}
+
+func (s *ResponseCacheSuite) TestSetEvictsOldestBeyondEntryBudget() {
+	budget := service.ResponseCacheBudget{MaxEntries: 2}
+	for _, key := range []string{"1:2:a", "1:2:b", "1:2:c"} {
+		entry := &service.ResponseCacheEntry{StatusCode: 200, Body: []byte(key)}
+		require.NoError(s.T(), s.store.Set(s.ctx, 1, key, entry, time.Minute, budget))
+	}
+
+	evicted, err := s.store.Get(s.ctx, "1:2:a")
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), evicted, "oldest entry must be evicted")
+	for _, key := range []string{"1:2:b", "1:2:c"} {
+		got, err := s.store.Get(s.ctx, key)
+		require.NoError(s.T(), err)
+		require.NotNil(s.T(), got, key)
+	}
+
+	// 其他分组不受影响
+	require.NoError(s.T(), s.store.Set(s.ctx, 2, "2:2:a", &service.ResponseCacheEntry{Body: []byte("x")}, time.Minute, budget))
+	got, err := s.store.Get(s.ctx, "1:2:c")
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), got)
+}
+
+func (s *ResponseCacheSuite) TestSetEvictsOldestBeyondByteBudget() {
+	first := &service.ResponseCacheEntry{StatusCode: 200, Body: make([]byte, 300)}
+	require.NoError(s.T(), s.store.Set(s.ctx, 1, "1:2:a", first, time.Minute, service.ResponseCacheBudget{}))
+	total, err := s.rdb.HGet(s.ctx, responseCacheSizesPrefix+"1", "#total").Int()
+	require.NoError(s.T(), err)
+
+	// 预算只够容纳一条同等大小的条目
+	budget := service.ResponseCacheBudget{MaxBytes: total + total/2}
+	second := &service.ResponseCacheEntry{StatusCode: 200, Body: make([]byte, 300)}
+	require.NoError(s.T(), s.store.Set(s.ctx, 1, "1:2:b", second, time.Minute, budget))
+
+	evicted, err := s.store.Get(s.ctx, "1:2:a")
+	require.NoError(s.T(), err)
+	require.Nil(s.T(), evicted)
+	got, err := s.store.Get(s.ctx, "1:2:b")
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), got)
+
+	total2, err := s.rdb.HGet(s.ctx, responseCacheSizesPrefix+"1", "#total").Int()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), total, total2)
+}
+
+func (s *ResponseCacheSuite) TestOverwriteDoesNotDoubleCount() {
+	entry := &service.ResponseCacheEntry{StatusCode: 200, Body: []byte("same")}
+	require.NoError(s.T(), s.store.Set(s.ctx, 1, "1:2:a", entry, time.Minute, service.ResponseCacheBudget{}))
+	total, err := s.rdb.HGet(s.ctx, responseCacheSizesPrefix+"1", "#total").Int()
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), s.store.Set(s.ctx, 1, "1:2:a", entry, time.Minute, service.ResponseCacheBudget{}))
+	again, err := s.rdb.HGet(s.ctx, responseCacheSizesPrefix+"1", "#total").Int()
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), total, again)
+	require.Equal(s.T(), int64(1), s.rdb.ZCard(s.ctx, responseCacheIndexPrefix+"1").Val())
+}
+
+func TestResponseCacheSuite(t *testing.T) {
+	suite.Run(t, new(ResponseCacheSuite))
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/service"
@@ -24,10 +25,12 @@ const (
 
 type schedulerCache struct {
 	rdb *redis.Client
+	// credentialCipher 账号快照写入 Redis 前加密敏感凭证字段，避免 Redis 中保存明文凭证
+	credentialCipher service.CredentialCipher
 }
 
-func NewSchedulerCache(rdb *redis.Client) service.SchedulerCache {
-	return &schedulerCache{rdb: rdb}
+func NewSchedulerCache(rdb *redis.Client, credentialCipher service.CredentialCipher) service.SchedulerCache {
+	return &schedulerCache{rdb: rdb, credentialCipher: credentialCipher}
 }
 
 func (c *schedulerCache) GetSnapshot(ctx context.Context, bucket service.SchedulerBucket) ([]*service.Account, bool, error) {
@@ -101,8 +104,9 @@ func (c *schedulerCache) SetSnapshot(ctx context.Context, bucket service.Schedul
 	snapshotKey := schedulerSnapshotKey(bucket, versionStr)
 
 	pipe := c.rdb.Pipeline()
-	for _, account := range accounts {
-		payload, err := json.Marshal(account)
+	for i := range accounts {
+		account := &accounts[i]
+		payload, err := c.encodeAccount(account)
 		if err != nil {
 			return err
 		}
@@ -151,7 +155,7 @@ func (c *schedulerCache) SetAccount(ctx context.Context, account *service.Accoun
 	if account == nil || account.ID <= 0 {
 		return nil
 	}
-	payload, err := json.Marshal(account)
+	payload, err := c.encodeAccount(account)
 	if err != nil {
 		return err
 	}
@@ -204,6 +208,50 @@ func (c *schedulerCache) UpdateLastUsed(ctx context.Context, updates map[int64]t
 	return err
 }
 
+func (c *schedulerCache) ListCredentialKeyIDs(ctx context.Context) ([]string, error) {
+	seen := make(map[string]struct{})
+	var cursor uint64
+	for {
+		keys, next, err := c.rdb.Scan(ctx, cursor, schedulerAccountPrefix+"*", 500).Result()
+		if err != nil {
+			return nil, err
+		}
+		if len(keys) > 0 {
+			values, err := c.rdb.MGet(ctx, keys...).Result()
+			if err != nil {
+				return nil, err
+			}
+			for _, val := range values {
+				if val == nil {
+					continue
+				}
+				account, err := decodeCachedAccount(val)
+				if err != nil {
+					return nil, err
+				}
+				for _, value := range account.Credentials {
+					s, ok := value.(string)
+					if !ok || !strings.HasPrefix(s, credentialCiphertextPrefix) {
+						continue
+					}
+					if keyID, _, _, err := parseCredentialCiphertext(s); err == nil {
+						seen[keyID] = struct{}{}
+					}
+				}
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
 func (c *schedulerCache) TryLockBucket(ctx context.Context, bucket service.SchedulerBucket, ttl time.Duration) (bool, error) {
 	key := schedulerBucketKey(schedulerLockPrefix, bucket)
 	return c.rdb.SetNX(ctx, key, time.Now().UnixNano(), ttl).Result()
@@ -260,6 +308,21 @@ func ptrTime(t time.Time) *time.Time {
 	return &t
 }
 
+// encodeAccount 序列化账号快照，敏感凭证字段以密文写入。
+// 读取快照时不解密，由调度服务在账号被选中后按需解密。
+func (c *schedulerCache) encodeAccount(account *service.Account) ([]byte, error) {
+	if c.credentialCipher == nil || len(account.Credentials) == 0 {
+		return json.Marshal(account)
+	}
+	credentials, err := c.credentialCipher.EncryptCredentials(account.ID, account.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	cached := *account
+	cached.Credentials = credentials
+	return json.Marshal(&cached)
+}
+
 func decodeCachedAccount(val any) (*service.Account, error) {
 	var payload []byte
 	switch raw := val.(type) {
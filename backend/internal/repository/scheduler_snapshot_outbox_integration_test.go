@@ -21,7 +21,7 @@ func TestSchedulerSnapshotOutboxReplay(t *testing.T) {
 
 	accountRepo := newAccountRepositoryWithSQL(client, integrationDB, nil)
 	outboxRepo := NewSchedulerOutboxRepository(integrationDB)
-	cache := NewSchedulerCache(rdb)
+	cache := NewSchedulerCache(rdb, nil)
 
 	cfg := &config.Config{
 		RunMode: config.RunModeStandard,
@@ -48,7 +48,7 @@ func TestSchedulerSnapshotOutboxReplay(t *testing.T) {
 	require.NoError(t, accountRepo.Create(ctx, account))
 	require.NoError(t, cache.SetAccount(ctx, account))
 
-	svc := service.NewSchedulerSnapshotService(cache, outboxRepo, accountRepo, nil, cfg)
+	svc := service.NewSchedulerSnapshotService(cache, outboxRepo, accountRepo, nil, nil, cfg)
 	svc.Start()
 	t.Cleanup(svc.Stop)
 
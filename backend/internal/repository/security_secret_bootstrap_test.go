@@ -127,6 +127,20 @@ func TestEnsureBootstrapSecretsConfiguredSecretDuplicateIgnored(t *testing.T) {
 	require.Equal(t, "existing-jwt-secret-32bytes-long!!!!", cfg.JWT.Secret)
 }
 
+func TestEnsureBootstrapSecretsAPIKeyHashSecretStable(t *testing.T) {
+	client := newSecuritySecretTestClient(t)
+
+	first := &config.Config{}
+	require.NoError(t, ensureBootstrapSecrets(context.Background(), client, first))
+	require.GreaterOrEqual(t, len([]byte(first.APIKeyHash.Secret)), 32)
+	require.NotEqual(t, first.JWT.Secret, first.APIKeyHash.Secret)
+
+	// 其他实例即使配置了不同的密钥，也必须沿用已持久化的值，否则已有 Key 全部失效
+	second := &config.Config{APIKeyHash: config.APIKeyHashConfig{Secret: "another-api-key-hash-secret-32bytes!"}}
+	require.NoError(t, ensureBootstrapSecrets(context.Background(), client, second))
+	require.Equal(t, first.APIKeyHash.Secret, second.APIKeyHash.Secret)
+}
+
 func TestGetOrCreateGeneratedSecuritySecretTrimmedExistingValue(t *testing.T) {
 	client := newSecuritySecretTestClient(t)
 	_, err := client.SecuritySecret.Create().
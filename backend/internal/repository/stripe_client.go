@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/tidwall/gjson"
+)
+
+const stripeRequestTimeout = 30 * time.Second
+
+// NewStripeClient 创建访问 Stripe API 的 HTTP 客户端
+func NewStripeClient() service.StripeClient {
+	return &stripeClient{}
+}
+
+type stripeClient struct{}
+
+func (c *stripeClient) CreateCheckoutSession(ctx context.Context, in *service.StripeCheckoutSessionRequest) (*service.StripeCheckoutSession, error) {
+	client, err := getSharedReqClient(reqClientOptions{Timeout: stripeRequestTimeout})
+	if err != nil {
+		return nil, err
+	}
+
+	// Stripe API 使用 form 编码的嵌套参数
+	form := url.Values{}
+	form.Set("mode", "payment")
+	form.Set("client_reference_id", in.OrderNo)
+	form.Set("metadata[order_no]", in.OrderNo)
+	form.Set("payment_intent_data[metadata][order_no]", in.OrderNo)
+	form.Set("line_items[0][quantity]", "1")
+	form.Set("line_items[0][price_data][currency]", in.Currency)
+	form.Set("line_items[0][price_data][unit_amount]", strconv.FormatInt(in.UnitAmount, 10))
+	form.Set("line_items[0][price_data][product_data][name]", in.ProductName)
+	form.Set("success_url", in.SuccessURL)
+	if in.CancelURL != "" {
+		form.Set("cancel_url", in.CancelURL)
+	}
+	if in.ExpiresAt != nil {
+		form.Set("expires_at", strconv.FormatInt(in.ExpiresAt.Unix(), 10))
+	}
+
+	resp, err := client.R().
+		SetContext(ctx).
+		SetBearerAuthToken(in.SecretKey).
+		// 同一订单重试下单时由 Stripe 去重
+		SetHeader("Idempotency-Key", "checkout-"+in.OrderNo).
+		SetFormDataFromValues(form).
+		Post(strings.TrimRight(in.APIBase, "/") + "/v1/checkout/sessions")
+	if err != nil {
+		return nil, infraerrors.Newf(http.StatusBadGateway, "STRIPE_REQUEST_FAILED", "request failed: %v", err)
+	}
+	body := resp.String()
+	if !resp.IsSuccessState() {
+		return nil, infraerrors.Newf(http.StatusBadGateway, "STRIPE_CHECKOUT_FAILED",
+			"create checkout session failed: status %d, error=%s", resp.StatusCode, gjson.Get(body, "error.message").String())
+	}
+	session := &service.StripeCheckoutSession{
+		ID:  gjson.Get(body, "id").String(),
+		URL: gjson.Get(body, "url").String(),
+	}
+	if session.ID == "" || session.URL == "" {
+		return nil, infraerrors.New(http.StatusBadGateway, "STRIPE_CHECKOUT_FAILED", "checkout session response missing id/url")
+	}
+	return session, nil
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+)
+
+type subscriptionPurchaseRepository struct {
+	db *sql.DB
+}
+
+// NewSubscriptionPurchaseRepository 创建订阅购买记录数据访问实例
+func NewSubscriptionPurchaseRepository(db *sql.DB) service.SubscriptionPurchaseRepository {
+	return &subscriptionPurchaseRepository{db: db}
+}
+
+// exec 优先使用 context 中的事务，使购买记录与扣费、订阅发放处于同一事务
+func (r *subscriptionPurchaseRepository) exec(ctx context.Context) sqlExecutor {
+	if tx := dbent.TxFromContext(ctx); tx != nil {
+		return tx.Client()
+	}
+	return r.db
+}
+
+func (r *subscriptionPurchaseRepository) Create(ctx context.Context, p *service.SubscriptionPurchase) error {
+	var expiresAt sql.NullTime
+	if p.ExpiresAt != nil {
+		expiresAt = nullTime(*p.ExpiresAt)
+	}
+	err := scanSingleRow(ctx, r.exec(ctx),
+		`INSERT INTO subscription_purchases (user_id, group_id, subscription_id, kind, status, amount, validity_days, expires_at, failure_reason)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		 RETURNING id, created_at`,
+		[]any{p.UserID, p.GroupID, nullInt64(p.SubscriptionID), p.Kind, p.Status, p.Amount, p.ValidityDays, expiresAt, p.FailureReason},
+		&p.ID, &p.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("insert subscription purchase: %w", err)
+	}
+	return nil
+}
+
+func (r *subscriptionPurchaseRepository) List(ctx context.Context, params pagination.PaginationParams, filters service.SubscriptionPurchaseListFilters) ([]service.SubscriptionPurchase, *pagination.PaginationResult, error) {
+	where := []string{"1=1"}
+	args := []any{}
+	argIdx := 1
+
+	if filters.UserID > 0 {
+		where = append(where, fmt.Sprintf("p.user_id = $%d", argIdx))
+		args = append(args, filters.UserID)
+		argIdx++
+	}
+	if filters.GroupID > 0 {
+		where = append(where, fmt.Sprintf("p.group_id = $%d", argIdx))
+		args = append(args, filters.GroupID)
+		argIdx++
+	}
+	if filters.Kind != "" {
+		where = append(where, fmt.Sprintf("p.kind = $%d", argIdx))
+		args = append(args, filters.Kind)
+		argIdx++
+	}
+	if filters.Status != "" {
+		where = append(where, fmt.Sprintf("p.status = $%d", argIdx))
+		args = append(args, filters.Status)
+		argIdx++
+	}
+	whereClause := strings.Join(where, " AND ")
+
+	var total int64
+	if err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM subscription_purchases p WHERE "+whereClause, args...).Scan(&total); err != nil {
+		return nil, nil, fmt.Errorf("count subscription purchases: %w", err)
+	}
+
+	dataQuery := fmt.Sprintf(
+		`SELECT p.id, p.user_id, p.group_id, p.subscription_id, p.kind, p.status, p.amount, p.validity_days, p.expires_at,
+			p.failure_reason, p.created_at, COALESCE(g.name, '')
+		 FROM subscription_purchases p
+		 LEFT JOIN groups g ON g.id = p.group_id
+		 WHERE %s
+		 ORDER BY p.created_at DESC, p.id DESC
+		 LIMIT $%d OFFSET $%d`,
+		whereClause, argIdx, argIdx+1,
+	)
+	args = append(args, params.Limit(), params.Offset())
+
+	rows, err := r.db.QueryContext(ctx, dataQuery, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query subscription purchases: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	out := []service.SubscriptionPurchase{}
+	for rows.Next() {
+		var p service.SubscriptionPurchase
+		var subscriptionID sql.NullInt64
+		var expiresAt sql.NullTime
+		if err := rows.Scan(
+			&p.ID, &p.UserID, &p.GroupID, &subscriptionID, &p.Kind, &p.Status, &p.Amount, &p.ValidityDays, &expiresAt,
+			&p.FailureReason, &p.CreatedAt, &p.GroupName,
+		); err != nil {
+			return nil, nil, fmt.Errorf("scan subscription purchase: %w", err)
+		}
+		if subscriptionID.Valid {
+			v := subscriptionID.Int64
+			p.SubscriptionID = &v
+		}
+		if expiresAt.Valid {
+			v := expiresAt.Time
+			p.ExpiresAt = &v
+		}
+		out = append(out, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("iterate subscription purchases: %w", err)
+	}
+	return out, paginationResultFromTotal(total, params), nil
+}
@@ -3967,7 +3967,10 @@ func (r *usageLogRepository) loadAccounts(ctx context.Context, ids []int64) (map
 		return nil, err
 	}
 	for _, m := range models {
-		out[m.ID] = accountEntityToService(m)
+		account := accountEntityToService(m)
+		// 仅用于展示使用记录关联的账号信息；凭证可能为密文，不随记录返回
+		account.Credentials = nil
+		out[m.ID] = account
 	}
 	return out, nil
 }
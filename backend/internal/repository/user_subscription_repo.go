@@ -299,6 +299,70 @@ func (r *userSubscriptionRepository) UpdateNotes(ctx context.Context, subscripti
 	return translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
 }
 
+func (r *userSubscriptionRepository) UpdateAutoRenew(ctx context.Context, subscriptionID int64, autoRenew bool) error {
+	client := clientFromContext(ctx, r.client)
+	_, err := client.UserSubscription.UpdateOneID(subscriptionID).
+		SetAutoRenew(autoRenew).
+		ClearAutoRenewFailedAt().
+		Save(ctx)
+	return translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
+}
+
+// ClaimAutoRenew 以 expires_at 未变化为条件锁定待续订的订阅行。
+// 多实例并发续订时，后到的事务会在行锁释放后重新判断条件并返回 false，避免重复扣费。
+func (r *userSubscriptionRepository) ClaimAutoRenew(ctx context.Context, subscriptionID int64, expiresAt time.Time) (bool, error) {
+	client := clientFromContext(ctx, r.client)
+	n, err := client.UserSubscription.Update().
+		Where(
+			usersubscription.IDEQ(subscriptionID),
+			usersubscription.ExpiresAtEQ(expiresAt),
+			usersubscription.AutoRenewEQ(true),
+		).
+		SetUpdatedAt(time.Now()).
+		Save(ctx)
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (r *userSubscriptionRepository) UpdateAutoRenewFailedAt(ctx context.Context, subscriptionID int64, failedAt *time.Time) error {
+	client := clientFromContext(ctx, r.client)
+	builder := client.UserSubscription.UpdateOneID(subscriptionID)
+	if failedAt != nil {
+		builder.SetAutoRenewFailedAt(*failedAt)
+	} else {
+		builder.ClearAutoRenewFailedAt()
+	}
+	_, err := builder.Save(ctx)
+	return translatePersistenceError(err, service.ErrSubscriptionNotFound, nil)
+}
+
+// ListAutoRenewDue 查询即将到期、需要自动续订的活跃订阅（按到期时间升序）。
+// 最近续订失败且失败时间晚于 retryBefore 的订阅暂不返回，避免失败订阅占满批次。
+func (r *userSubscriptionRepository) ListAutoRenewDue(ctx context.Context, dueBefore, retryBefore time.Time, limit int) ([]service.UserSubscription, error) {
+	client := clientFromContext(ctx, r.client)
+	subs, err := client.UserSubscription.Query().
+		Where(
+			usersubscription.AutoRenew(true),
+			usersubscription.StatusEQ(service.SubscriptionStatusActive),
+			usersubscription.ExpiresAtLTE(dueBefore),
+			usersubscription.Or(
+				usersubscription.AutoRenewFailedAtIsNil(),
+				usersubscription.AutoRenewFailedAtLTE(retryBefore),
+			),
+		).
+		WithUser().
+		WithGroup().
+		Order(dbent.Asc(usersubscription.FieldExpiresAt), dbent.Asc(usersubscription.FieldID)).
+		Limit(limit).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return userSubscriptionEntitiesToService(subs), nil
+}
+
 func (r *userSubscriptionRepository) ActivateWindows(ctx context.Context, id int64, start time.Time) error {
 	client := clientFromContext(ctx, r.client)
 	_, err := client.UserSubscription.UpdateOneID(id).
@@ -445,6 +509,8 @@ func userSubscriptionEntityToService(m *dbent.UserSubscription) *service.UserSub
 		AssignedBy:         m.AssignedBy,
 		AssignedAt:         m.AssignedAt,
 		Notes:              derefString(m.Notes),
+		AutoRenew:          m.AutoRenew,
+		AutoRenewFailedAt:  m.AutoRenewFailedAt,
 		CreatedAt:          m.CreatedAt,
 		UpdatedAt:          m.UpdatedAt,
 	}
@@ -74,6 +74,20 @@ var ProviderSet = wire.NewSet(
 	NewErrorPassthroughRepository,
 	NewTLSFingerprintProfileRepository,
 	NewChannelRepository,
+	NewNotificationChannelRepository,
+	NewOIDCProviderRepository,
+	NewPaymentOrderRepository,
+	NewSubscriptionPurchaseRepository,
+	NewReferralRepository,
+	NewAdminAuditLogRepository,
+	NewPayloadCaptureRepository,
+	NewGuardrailRepository,
+	NewProxyPoolRepository,
+	NewCredentialEncryptionRepository,
+	NewAdminTokenRepository,
+	NewMessageBatchRepository,
+	NewOpenAIFileRepository,
+	NewOpenAIBatchRepository,
 
 	// Cache implementations
 	NewGatewayCache,
@@ -99,9 +113,11 @@ var ProviderSet = wire.NewSet(
 	NewRefreshTokenCache,
 	NewErrorPassthroughCache,
 	NewTLSFingerprintProfileCache,
+	NewResponseCacheStore,
 
 	// Encryptors
 	NewAESEncryptor,
+	NewCredentialCipher,
 
 	// Backup infrastructure
 	NewPgDumper,
@@ -116,6 +132,8 @@ var ProviderSet = wire.NewSet(
 	NewClaudeOAuthClient,
 	NewHTTPUpstream,
 	NewOpenAIOAuthClient,
+	NewOIDCClient,
+	NewStripeClient,
 	NewGeminiOAuthClient,
 	NewGeminiCliCodeAssistClient,
 	NewGeminiDriveClient,
@@ -78,11 +78,14 @@ func TestAPIContracts(t *testing.T) {
 					"id": 100,
 					"user_id": 1,
 					"key": "sk_custom_1234567890",
+					"key_prefix": "sk_cu",
 					"name": "Key One",
 					"group_id": null,
 					"status": "active",
 					"ip_whitelist": null,
 					"ip_blacklist": null,
+					"allowed_models": null,
+					"denied_models": null,
 					"last_used_at": null,
 					"quota": 0,
 					"quota_used": 0,
@@ -95,6 +98,8 @@ func TestAPIContracts(t *testing.T) {
 					"window_5h_start": null,
 					"window_1d_start": null,
 					"window_7d_start": null,
+					"rpm_limit": 0,
+					"tpm_limit": 0,
 					"expires_at": null,
 					"created_at": "2025-01-02T03:04:05Z",
 					"updated_at": "2025-01-02T03:04:05Z"
@@ -108,7 +113,8 @@ func TestAPIContracts(t *testing.T) {
 				deps.apiKeyRepo.MustSeed(&service.APIKey{
 					ID:        100,
 					UserID:    1,
-					Key:       "sk_custom_1234567890",
+					KeyHash:   service.HashAPIKey("", "sk_custom_1234567890"),
+					KeyPrefix: "sk_cu",
 					Name:      "Key One",
 					Status:    service.StatusActive,
 					CreatedAt: deps.now,
@@ -126,12 +132,14 @@ func TestAPIContracts(t *testing.T) {
 						{
 							"id": 100,
 							"user_id": 1,
-							"key": "sk_custom_1234567890",
+							"key_prefix": "sk_cu",
 							"name": "Key One",
 							"group_id": null,
 							"status": "active",
 							"ip_whitelist": null,
 							"ip_blacklist": null,
+							"allowed_models": null,
+							"denied_models": null,
 							"last_used_at": null,
 							"quota": 0,
 							"quota_used": 0,
@@ -144,6 +152,8 @@ func TestAPIContracts(t *testing.T) {
 							"window_5h_start": null,
 							"window_1d_start": null,
 							"window_7d_start": null,
+							"rpm_limit": 0,
+							"tpm_limit": 0,
 							"expires_at": null,
 							"created_at": "2025-01-02T03:04:05Z",
 							"updated_at": "2025-01-02T03:04:05Z"
@@ -261,6 +271,8 @@ func TestAPIContracts(t *testing.T) {
 						"daily_usage_usd": 1.23,
 						"weekly_usage_usd": 2.34,
 						"monthly_usage_usd": 3.45,
+						"auto_renew": false,
+						"auto_renew_failed_at": null,
 						"created_at": "2025-01-02T03:04:05Z",
 						"updated_at": "2025-01-02T03:04:05Z"
 					}
@@ -639,7 +651,7 @@ func newContractDeps(t *testing.T) *contractDeps {
 	usageService := service.NewUsageService(usageRepo, userRepo, nil, nil)
 
 	subscriptionService := service.NewSubscriptionService(groupRepo, userSubRepo, nil, nil, cfg)
-	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService, nil)
 
 	redeemService := service.NewRedeemService(redeemRepo, userRepo, subscriptionService, nil, nil, nil, nil)
 	redeemHandler := handler.NewRedeemHandler(redeemService)
@@ -648,7 +660,7 @@ func newContractDeps(t *testing.T) *contractDeps {
 	settingService := service.NewSettingService(settingRepo, cfg)
 
 	adminService := service.NewAdminService(userRepo, groupRepo, &accountRepo, proxyRepo, apiKeyRepo, redeemRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
-	authHandler := handler.NewAuthHandler(cfg, nil, userService, settingService, nil, redeemService, nil)
+	authHandler := handler.NewAuthHandler(cfg, nil, userService, settingService, nil, redeemService, nil, nil)
 	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
 	usageHandler := handler.NewUsageHandler(usageService, apiKeyService)
 	adminSettingHandler := adminhandler.NewSettingHandler(settingService, nil, nil, nil)
@@ -795,6 +807,10 @@ func (r *stubUserRepo) DeductBalance(ctx context.Context, id int64, amount float
 	return errors.New("not implemented")
 }
 
+func (r *stubUserRepo) DeductBalanceIfSufficient(ctx context.Context, id int64, amount float64) (bool, error) {
+	return false, errors.New("not implemented")
+}
+
 func (r *stubUserRepo) UpdateConcurrency(ctx context.Context, id int64, amount int) error {
 	return errors.New("not implemented")
 }
@@ -1308,6 +1324,18 @@ func (stubUserSubscriptionRepo) UpdateStatus(ctx context.Context, subscriptionID
 func (stubUserSubscriptionRepo) UpdateNotes(ctx context.Context, subscriptionID int64, notes string) error {
 	return errors.New("not implemented")
 }
+func (stubUserSubscriptionRepo) UpdateAutoRenew(ctx context.Context, subscriptionID int64, autoRenew bool) error {
+	return errors.New("not implemented")
+}
+func (stubUserSubscriptionRepo) UpdateAutoRenewFailedAt(ctx context.Context, subscriptionID int64, failedAt *time.Time) error {
+	return errors.New("not implemented")
+}
+func (stubUserSubscriptionRepo) ClaimAutoRenew(ctx context.Context, subscriptionID int64, expiresAt time.Time) (bool, error) {
+	return false, errors.New("not implemented")
+}
+func (stubUserSubscriptionRepo) ListAutoRenewDue(ctx context.Context, dueBefore, retryBefore time.Time, limit int) ([]service.UserSubscription, error) {
+	return nil, errors.New("not implemented")
+}
 func (stubUserSubscriptionRepo) ActivateWindows(ctx context.Context, id int64, start time.Time) error {
 	return errors.New("not implemented")
 }
@@ -1382,7 +1410,7 @@ func (r *stubApiKeyRepo) GetByID(ctx context.Context, id int64) (*service.APIKey
 	return &clone, nil
 }
 
-func (r *stubApiKeyRepo) GetKeyAndOwnerID(ctx context.Context, id int64) (string, int64, error) {
+func (r *stubApiKeyRepo) GetKeyHashAndOwnerID(ctx context.Context, id int64) (string, int64, error) {
 	key, ok := r.byID[id]
 	if !ok {
 		return "", 0, service.ErrAPIKeyNotFound
@@ -1390,7 +1418,7 @@ func (r *stubApiKeyRepo) GetKeyAndOwnerID(ctx context.Context, id int64) (string
 	return key.Key, key.UserID, nil
 }
 
-func (r *stubApiKeyRepo) GetByKey(ctx context.Context, key string) (*service.APIKey, error) {
+func (r *stubApiKeyRepo) GetByKeyHash(ctx context.Context, key string) (*service.APIKey, error) {
 	found, ok := r.byKey[key]
 	if !ok {
 		return nil, service.ErrAPIKeyNotFound
@@ -1399,8 +1427,8 @@ func (r *stubApiKeyRepo) GetByKey(ctx context.Context, key string) (*service.API
 	return &clone, nil
 }
 
-func (r *stubApiKeyRepo) GetByKeyForAuth(ctx context.Context, key string) (*service.APIKey, error) {
-	return r.GetByKey(ctx, key)
+func (r *stubApiKeyRepo) GetByKeyHashForAuth(ctx context.Context, key string) (*service.APIKey, error) {
+	return r.GetByKeyHash(ctx, key)
 }
 
 func (r *stubApiKeyRepo) Update(ctx context.Context, key *service.APIKey) error {
@@ -1496,7 +1524,7 @@ func (r *stubApiKeyRepo) CountByUserID(ctx context.Context, userID int64) (int64
 	return count, nil
 }
 
-func (r *stubApiKeyRepo) ExistsByKey(ctx context.Context, key string) (bool, error) {
+func (r *stubApiKeyRepo) ExistsByKeyHash(ctx context.Context, key string) (bool, error) {
 	_, ok := r.byKey[key]
 	return ok, nil
 }
@@ -1533,11 +1561,11 @@ func (r *stubApiKeyRepo) CountByGroupID(ctx context.Context, groupID int64) (int
 	return 0, errors.New("not implemented")
 }
 
-func (r *stubApiKeyRepo) ListKeysByUserID(ctx context.Context, userID int64) ([]string, error) {
+func (r *stubApiKeyRepo) ListKeyHashesByUserID(ctx context.Context, userID int64) ([]string, error) {
 	return nil, errors.New("not implemented")
 }
 
-func (r *stubApiKeyRepo) ListKeysByGroupID(ctx context.Context, groupID int64) ([]string, error) {
+func (r *stubApiKeyRepo) ListKeyHashesByGroupID(ctx context.Context, groupID int64) ([]string, error) {
 	return nil, errors.New("not implemented")
 }
 
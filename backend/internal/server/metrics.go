@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/metrics"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsServer Prometheus 指标的独立监听服务（metrics.listen_addr 非空时启用）
+type MetricsServer struct {
+	srv *http.Server
+}
+
+// ProvideMetricsServer 按配置启动独立指标监听；未启用或挂载到主服务时返回空实例
+func ProvideMetricsServer(cfg *config.Config, metricsService *service.MetricsService) *MetricsServer {
+	if !cfg.Metrics.Enabled || cfg.Metrics.ListenAddr == "" {
+		return &MetricsServer{}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(cfg.Metrics.Path, NewMetricsHandler(cfg.Metrics, metricsService))
+	srv := &http.Server{
+		Addr:              cfg.Metrics.ListenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       60 * time.Second,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[Metrics] listener on %s stopped: %v", cfg.Metrics.ListenAddr, err)
+		}
+	}()
+	log.Printf("[Metrics] Prometheus endpoint listening on %s%s", cfg.Metrics.ListenAddr, cfg.Metrics.Path)
+	return &MetricsServer{srv: srv}
+}
+
+// Shutdown 关闭独立指标监听
+func (s *MetricsServer) Shutdown(ctx context.Context) error {
+	if s == nil || s.srv == nil {
+		return nil
+	}
+	return s.srv.Shutdown(ctx)
+}
+
+// NewMetricsHandler 返回 Prometheus 文本格式的指标处理器；配置了 bearer_token 时校验 Authorization 头
+func NewMetricsHandler(cfg config.MetricsConfig, metricsService *service.MetricsService) http.Handler {
+	token := []byte(cfg.BearerToken)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if len(token) > 0 {
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(provided)), token) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		if metricsService == nil {
+			http.Error(w, "metrics unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := metricsService.WriteText(&buf); err != nil {
+			log.Printf("[Metrics] render failed: %v", err)
+			http.Error(w, "metrics render failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", metrics.ContentType)
+		w.Header().Set("Cache-Control", "no-store")
+		// 显式写状态码：挂载在 gin 上时未匹配路由的默认状态为 404
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodHead {
+			return
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			log.Printf("[Metrics] write response failed: %v", err)
+		}
+	})
+}
+
+// metricsEndpointMiddleware 在主服务上拦截指标路径，避免被前端静态资源中间件吞掉
+func metricsEndpointMiddleware(path string, h http.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.URL.Path != path {
+			c.Next()
+			return
+		}
+		h.ServeHTTP(c.Writer, c.Request)
+		c.Abort()
+	}
+}
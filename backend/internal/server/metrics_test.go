@@ -0,0 +1,63 @@
+//go:build unit
+
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/metrics"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsHandler_BearerToken(t *testing.T) {
+	metricsService := service.NewMetricsService(nil, nil, nil, nil, nil, service.BuildInfo{Version: "test"})
+	h := NewMetricsHandler(config.MetricsConfig{Path: "/metrics", BearerToken: "s3cret"}, metricsService)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, metrics.ContentType, w.Header().Get("Content-Type"))
+	require.Contains(t, w.Body.String(), `sub2api_build_info{version="test",build_type=""} 1`)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestMetricsEndpointMiddleware_InterceptsPathOnly(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	metricsService := service.NewMetricsService(nil, nil, nil, nil, nil, service.BuildInfo{})
+	r.Use(metricsEndpointMiddleware("/metrics", NewMetricsHandler(config.MetricsConfig{Path: "/metrics"}, metricsService)))
+	r.Use(func(c *gin.Context) {
+		// 模拟前端静态资源中间件吞掉所有未知路径
+		c.String(http.StatusOK, "index.html")
+		c.Abort()
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "# TYPE sub2api_build_info gauge")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+	require.Equal(t, "index.html", w.Body.String())
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// adminAuditResponseCaptureLimit 响应体捕获上限，仅用于解析错误信息与新建实体 ID
+	adminAuditResponseCaptureLimit = 16 * 1024
+	adminAuditErrorMessageMaxLen   = 500
+	adminAuditUserAgentMaxLen      = 512
+)
+
+// NewAdminAuditMiddleware 创建管理后台审计中间件
+func NewAdminAuditMiddleware(auditService *service.AdminAuditService) AdminAuditMiddleware {
+	return AdminAuditMiddleware(adminAudit(auditService))
+}
+
+// adminAudit 记录管理后台写操作（POST/PUT/PATCH/DELETE）。
+// 需挂在 adminAuth 之后：认证失败的请求不会进入审计。
+func adminAudit(auditService *service.AdminAuditService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !auditService.Enabled() || !isAdminAuditMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+
+		startedAt := time.Now()
+		route := c.FullPath()
+		resourceType := adminAuditResourceType(route)
+		resourceID := c.Param("id")
+		ctx := c.Request.Context()
+
+		rawBody, truncated := peekAdminAuditBody(c, auditService.MaxBodyBytes())
+		before := auditService.Snapshot(ctx, resourceType, resourceID)
+
+		w := &adminAuditCaptureWriter{ResponseWriter: c.Writer, limit: adminAuditResponseCaptureLimit}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		status := w.Status()
+		respBody := w.buf.String()
+
+		entry := &service.AdminAuditLog{
+			Method:       c.Request.Method,
+			Route:        route,
+			Path:         c.Request.URL.Path,
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			StatusCode:   status,
+			Success:      status < http.StatusBadRequest,
+			ClientIP:     ip.GetClientIP(c),
+			UserAgent:    truncateAdminAuditString(c.Request.UserAgent(), adminAuditUserAgentMaxLen),
+			DurationMs:   time.Since(startedAt).Milliseconds(),
+		}
+		if subject, ok := GetAuthSubjectFromContext(c); ok {
+			entry.ActorUserID = subject.UserID
+		}
+		entry.AuthMethod = c.GetString("auth_method")
+		entry.ActorTokenID = GetAdminTokenIDFromContext(c)
+
+		contentType := c.GetHeader("Content-Type")
+		entry.RequestBody = service.RedactAdminAuditBody(contentType, rawBody, truncated)
+		entry.TargetIDs = collectAdminAuditTargetIDs(c, contentType, rawBody, truncated)
+
+		if entry.Success {
+			// 新建实体时从响应中补全资源 ID
+			if entry.ResourceID == "" && resourceType != "" {
+				if id := gjson.Get(respBody, "data.id"); id.Exists() {
+					entry.ResourceID = id.String()
+				}
+			}
+			after := auditService.Snapshot(ctx, resourceType, entry.ResourceID)
+			entry.Changes = service.DiffAdminAuditSnapshots(before, after)
+		} else {
+			entry.ErrorReason = gjson.Get(respBody, "reason").String()
+			// 中间件错误格式为 {"code": "<REASON>", "message": ...}
+			if code := gjson.Get(respBody, "code"); entry.ErrorReason == "" && code.Type == gjson.String {
+				entry.ErrorReason = code.String()
+			}
+			entry.ErrorMessage = truncateAdminAuditString(gjson.Get(respBody, "message").String(), adminAuditErrorMessageMaxLen)
+			if entry.ErrorMessage == "" && len(c.Errors) > 0 {
+				entry.ErrorMessage = truncateAdminAuditString(c.Errors.Last().Error(), adminAuditErrorMessageMaxLen)
+			}
+		}
+
+		auditService.Record(ctx, entry)
+	}
+}
+
+func isAdminAuditMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// adminAuditResourceType 取路由模板中 /admin/ 之后的第一段，如 /api/v1/admin/users/:id → users
+func adminAuditResourceType(route string) string {
+	idx := strings.Index(route, "/admin/")
+	if idx < 0 {
+		return ""
+	}
+	rest := route[idx+len("/admin/"):]
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		rest = rest[:i]
+	}
+	if strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "*") {
+		return ""
+	}
+	return rest
+}
+
+// peekAdminAuditBody 读取至多 limit 字节请求体，并原样放回供后续 handler 读取
+func peekAdminAuditBody(c *gin.Context, limit int) ([]byte, bool) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, false
+	}
+	buf, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+	body := c.Request.Body
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(buf), body), body}
+	if err != nil {
+		return nil, false
+	}
+	if len(buf) > limit {
+		return buf[:limit], true
+	}
+	return buf, false
+}
+
+func collectAdminAuditTargetIDs(c *gin.Context, contentType string, rawBody []byte, truncated bool) map[string]any {
+	out := map[string]any{}
+	if !truncated && strings.Contains(strings.ToLower(contentType), "json") {
+		for k, v := range service.ExtractAdminAuditTargetIDs(rawBody) {
+			out[k] = v
+		}
+	}
+	// 路径参数优先
+	for _, p := range c.Params {
+		out[p.Key] = p.Value
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func truncateAdminAuditString(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return strings.ToValidUTF8(s[:max], "")
+}
+
+type adminAuditCaptureWriter struct {
+	gin.ResponseWriter
+	limit int
+	buf   bytes.Buffer
+}
+
+func (w *adminAuditCaptureWriter) Write(b []byte) (int, error) {
+	w.capture(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *adminAuditCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *adminAuditCaptureWriter) capture(b []byte) {
+	if w.buf.Len() >= w.limit {
+		return
+	}
+	remaining := w.limit - w.buf.Len()
+	if len(b) > remaining {
+		b = b[:remaining]
+	}
+	_, _ = w.buf.Write(b)
+}
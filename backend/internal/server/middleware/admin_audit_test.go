@@ -0,0 +1,141 @@
+//go:build unit
+
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/response"
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type auditLogRepoStub struct {
+	logs []*service.AdminAuditLog
+}
+
+func (r *auditLogRepoStub) Create(_ context.Context, l *service.AdminAuditLog) error {
+	r.logs = append(r.logs, l)
+	return nil
+}
+
+func (r *auditLogRepoStub) List(context.Context, pagination.PaginationParams, service.AdminAuditLogFilters) ([]service.AdminAuditLog, *pagination.PaginationResult, error) {
+	return nil, nil, nil
+}
+
+func (r *auditLogRepoStub) ListForExport(context.Context, service.AdminAuditLogFilters, int) ([]service.AdminAuditLog, error) {
+	return nil, nil
+}
+
+func newAdminAuditTestRouter(repo *auditLogRepoStub) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{AdminAudit: config.AdminAuditConfig{Enabled: true, MaxBodyBytes: 1024}}
+	auditService := service.NewAdminAuditService(repo, nil, nil, cfg)
+
+	router := gin.New()
+	admin := router.Group("/api/v1/admin")
+	admin.Use(func(c *gin.Context) {
+		c.Set(string(ContextKeyUser), AuthSubject{UserID: 7})
+		c.Set("auth_method", "admin_api_key")
+		c.Next()
+	})
+	admin.Use(gin.HandlerFunc(NewAdminAuditMiddleware(auditService)))
+	admin.POST("/users/:id/balance", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		var req map[string]any
+		if err := json.Unmarshal(body, &req); err != nil {
+			response.BadRequest(c, "bad body")
+			return
+		}
+		response.Success(c, gin.H{"id": 5})
+	})
+	admin.POST("/proxies", func(c *gin.Context) {
+		response.Success(c, gin.H{"id": 42})
+	})
+	admin.DELETE("/accounts/:id", func(c *gin.Context) {
+		response.ErrorFrom(c, service.ErrAccountNotFound)
+	})
+	admin.GET("/users", func(c *gin.Context) {
+		response.Success(c, nil)
+	})
+	return router
+}
+
+func TestAdminAuditRecordsRedactedMutation(t *testing.T) {
+	repo := &auditLogRepoStub{}
+	router := newAdminAuditTestRouter(repo)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/5/balance",
+		strings.NewReader(`{"balance":10,"operation":"add","password":"p@ss","group_ids":[1,2]}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "10.0.0.1:1234"
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, "handler must still read the full body")
+	require.Len(t, repo.logs, 1)
+	entry := repo.logs[0]
+	require.Equal(t, int64(7), entry.ActorUserID)
+	require.Equal(t, "admin_api_key", entry.AuthMethod)
+	require.Equal(t, "/api/v1/admin/users/:id/balance", entry.Route)
+	require.Equal(t, "users", entry.ResourceType)
+	require.Equal(t, "5", entry.ResourceID)
+	require.True(t, entry.Success)
+	require.Equal(t, "5", entry.TargetIDs["id"])
+	require.Equal(t, []any{float64(1), float64(2)}, entry.TargetIDs["group_ids"])
+	require.NotContains(t, string(entry.RequestBody), "p@ss")
+	require.Contains(t, string(entry.RequestBody), `"balance":10`)
+}
+
+func TestAdminAuditCapturesCreatedIDAndErrors(t *testing.T) {
+	repo := &auditLogRepoStub{}
+	router := newAdminAuditTestRouter(repo)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/proxies", strings.NewReader(`{"name":"p1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/admin/accounts/9", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	require.Len(t, repo.logs, 2)
+	require.Equal(t, "proxies", repo.logs[0].ResourceType)
+	require.Equal(t, "42", repo.logs[0].ResourceID)
+
+	failed := repo.logs[1]
+	require.False(t, failed.Success)
+	require.Equal(t, http.StatusNotFound, failed.StatusCode)
+	require.Equal(t, "ACCOUNT_NOT_FOUND", failed.ErrorReason)
+	require.NotEmpty(t, failed.ErrorMessage)
+	require.Nil(t, failed.RequestBody)
+}
+
+func TestAdminAuditSkipsReadsAndOversizedBodies(t *testing.T) {
+	repo := &auditLogRepoStub{}
+	router := newAdminAuditTestRouter(repo)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/users", nil))
+	require.Empty(t, repo.logs)
+
+	big := `{"balance":1,"note":"` + strings.Repeat("x", 2048) + `"}`
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/users/5/balance", strings.NewReader(big))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, "truncation must not affect the handler")
+	require.Len(t, repo.logs, 1)
+	require.JSONEq(t, `{"truncated":true,"content_type":"application/json"}`, string(repo.logs[0].RequestBody))
+}
@@ -6,6 +6,8 @@ import (
 	"errors"
 	"strings"
 
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
 	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -16,18 +18,20 @@ func NewAdminAuthMiddleware(
 	authService *service.AuthService,
 	userService *service.UserService,
 	settingService *service.SettingService,
+	adminTokenService *service.AdminTokenService,
 ) AdminAuthMiddleware {
-	return AdminAuthMiddleware(adminAuth(authService, userService, settingService))
+	return AdminAuthMiddleware(adminAuth(authService, userService, settingService, adminTokenService))
 }
 
 // adminAuth 管理员认证中间件实现
 // 支持两种认证方式（通过不同的 header 区分）：
-// 1. Admin API Key: x-api-key: <admin-api-key>
+// 1. Admin API Key: x-api-key: <admin-api-key>（全局 Key 或具名令牌 admin-tok-*）
 // 2. JWT Token: Authorization: Bearer <jwt-token> (需要管理员角色)
 func adminAuth(
 	authService *service.AuthService,
 	userService *service.UserService,
 	settingService *service.SettingService,
+	adminTokenService *service.AdminTokenService,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// WebSocket upgrade requests cannot set Authorization headers in browsers.
@@ -47,6 +51,13 @@ func adminAuth(
 		// 检查 x-api-key header（Admin API Key 认证）
 		apiKey := c.GetHeader("x-api-key")
 		if apiKey != "" {
+			if strings.HasPrefix(apiKey, service.AdminTokenPrefix) {
+				if !validateAdminToken(c, apiKey, adminTokenService, userService) {
+					return
+				}
+				c.Next()
+				return
+			}
 			if !validateAdminAPIKey(c, apiKey, settingService, userService) {
 				return
 			}
@@ -150,6 +161,41 @@ func validateAdminAPIKey(
 	return true
 }
 
+// validateAdminToken 验证具名管理员令牌：以创建者身份执行，并将作用域写入上下文供路由分组校验
+func validateAdminToken(
+	c *gin.Context,
+	key string,
+	adminTokenService *service.AdminTokenService,
+	userService *service.UserService,
+) bool {
+	token, err := adminTokenService.Authenticate(c.Request.Context(), key, ip.GetClientIP(c))
+	if err != nil {
+		if infraerrors.IsUnauthorized(err) {
+			AbortWithError(c, 401, infraerrors.Reason(err), infraerrors.Message(err))
+			return false
+		}
+		AbortWithError(c, 500, "INTERNAL_ERROR", "Internal server error")
+		return false
+	}
+
+	// 创建者被删除、禁用或降级后，其创建的令牌随之失效
+	owner, err := userService.GetByID(c.Request.Context(), token.CreatedBy)
+	if err != nil || !owner.IsActive() || !owner.IsAdmin() {
+		AbortWithError(c, 401, "ADMIN_TOKEN_OWNER_INVALID", "Admin token owner is no longer an active admin")
+		return false
+	}
+
+	c.Set(string(ContextKeyUser), AuthSubject{
+		UserID:      owner.ID,
+		Concurrency: owner.Concurrency,
+	})
+	c.Set(string(ContextKeyUserRole), owner.Role)
+	c.Set(string(ContextKeyAdminTokenID), token.ID)
+	c.Set(string(ContextKeyAdminScopes), token.Scopes)
+	c.Set("auth_method", "admin_token")
+	return true
+}
+
 // validateJWTForAdmin 验证 JWT 并检查管理员权限
 func validateJWTForAdmin(
 	c *gin.Context,
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireAdminScope 按管理后台路由分组校验具名令牌作用域。
+// GET/HEAD/OPTIONS 需要 "<resource>:read"，其余方法需要 "<resource>:write"；
+// JWT 与全局 Admin API Key 认证不受作用域限制。
+func RequireAdminScope(resource string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scopes, ok := GetAdminScopesFromContext(c)
+		if !ok {
+			c.Next()
+			return
+		}
+		if !service.AdminScopesAllow(scopes, resource, isAdminWriteMethod(c.Request.Method)) {
+			AbortWithError(c, http.StatusForbidden, "ADMIN_TOKEN_SCOPE_DENIED", "Admin token does not have the required scope")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireFullAdmin 拒绝具名令牌访问（令牌与全局 Key 管理等提权敏感操作）
+func RequireFullAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAdminScopesFromContext(c); ok {
+			AbortWithError(c, http.StatusForbidden, "ADMIN_TOKEN_SCOPE_DENIED", "This operation is not available to scoped admin tokens")
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetAdminScopesFromContext 获取具名管理员令牌作用域；非令牌认证时返回 false
+func GetAdminScopesFromContext(c *gin.Context) ([]string, bool) {
+	value, exists := c.Get(string(ContextKeyAdminScopes))
+	if !exists {
+		return nil, false
+	}
+	scopes, ok := value.([]string)
+	return scopes, ok
+}
+
+// GetAdminTokenIDFromContext 获取具名管理员令牌 ID；非令牌认证时返回 0
+func GetAdminTokenIDFromContext(c *gin.Context) int64 {
+	value, exists := c.Get(string(ContextKeyAdminTokenID))
+	if !exists {
+		return 0
+	}
+	id, _ := value.(int64)
+	return id
+}
+
+func isAdminWriteMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
@@ -49,7 +49,7 @@ func TestSimpleModeBypassesQuotaCheck(t *testing.T) {
 
 	apiKeyRepo := &stubApiKeyRepo{
 		getByKey: func(ctx context.Context, key string) (*service.APIKey, error) {
-			if key != apiKey.Key {
+			if key != service.HashAPIKey("", apiKey.Key) {
 				return nil, service.ErrAPIKeyNotFound
 			}
 			clone := *apiKey
@@ -206,7 +206,7 @@ func TestAPIKeyAuthSetsGroupContext(t *testing.T) {
 
 	apiKeyRepo := &stubApiKeyRepo{
 		getByKey: func(ctx context.Context, key string) (*service.APIKey, error) {
-			if key != apiKey.Key {
+			if key != service.HashAPIKey("", apiKey.Key) {
 				return nil, service.ErrAPIKeyNotFound
 			}
 			clone := *apiKey
@@ -264,7 +264,7 @@ func TestAPIKeyAuthOverwritesInvalidContextGroup(t *testing.T) {
 
 	apiKeyRepo := &stubApiKeyRepo{
 		getByKey: func(ctx context.Context, key string) (*service.APIKey, error) {
-			if key != apiKey.Key {
+			if key != service.HashAPIKey("", apiKey.Key) {
 				return nil, service.ErrAPIKeyNotFound
 			}
 			clone := *apiKey
@@ -321,7 +321,7 @@ func TestAPIKeyAuthIPRestrictionDoesNotTrustSpoofedForwardHeaders(t *testing.T)
 
 	apiKeyRepo := &stubApiKeyRepo{
 		getByKey: func(ctx context.Context, key string) (*service.APIKey, error) {
-			if key != apiKey.Key {
+			if key != service.HashAPIKey("", apiKey.Key) {
 				return nil, service.ErrAPIKeyNotFound
 			}
 			clone := *apiKey
@@ -373,7 +373,7 @@ func TestAPIKeyAuthTouchesLastUsedOnSuccess(t *testing.T) {
 	var touchedAt time.Time
 	apiKeyRepo := &stubApiKeyRepo{
 		getByKey: func(ctx context.Context, key string) (*service.APIKey, error) {
-			if key != apiKey.Key {
+			if key != service.HashAPIKey("", apiKey.Key) {
 				return nil, service.ErrAPIKeyNotFound
 			}
 			clone := *apiKey
@@ -421,7 +421,7 @@ func TestAPIKeyAuthTouchLastUsedFailureDoesNotBlock(t *testing.T) {
 	touchCalls := 0
 	apiKeyRepo := &stubApiKeyRepo{
 		getByKey: func(ctx context.Context, key string) (*service.APIKey, error) {
-			if key != apiKey.Key {
+			if key != service.HashAPIKey("", apiKey.Key) {
 				return nil, service.ErrAPIKeyNotFound
 			}
 			clone := *apiKey
@@ -467,7 +467,7 @@ func TestAPIKeyAuthTouchesLastUsedInStandardMode(t *testing.T) {
 	touchCalls := 0
 	apiKeyRepo := &stubApiKeyRepo{
 		getByKey: func(ctx context.Context, key string) (*service.APIKey, error) {
-			if key != apiKey.Key {
+			if key != service.HashAPIKey("", apiKey.Key) {
 				return nil, service.ErrAPIKeyNotFound
 			}
 			clone := *apiKey
@@ -514,19 +514,19 @@ func (r *stubApiKeyRepo) GetByID(ctx context.Context, id int64) (*service.APIKey
 	return nil, errors.New("not implemented")
 }
 
-func (r *stubApiKeyRepo) GetKeyAndOwnerID(ctx context.Context, id int64) (string, int64, error) {
+func (r *stubApiKeyRepo) GetKeyHashAndOwnerID(ctx context.Context, id int64) (string, int64, error) {
 	return "", 0, errors.New("not implemented")
 }
 
-func (r *stubApiKeyRepo) GetByKey(ctx context.Context, key string) (*service.APIKey, error) {
+func (r *stubApiKeyRepo) GetByKeyHash(ctx context.Context, key string) (*service.APIKey, error) {
 	if r.getByKey != nil {
 		return r.getByKey(ctx, key)
 	}
 	return nil, errors.New("not implemented")
 }
 
-func (r *stubApiKeyRepo) GetByKeyForAuth(ctx context.Context, key string) (*service.APIKey, error) {
-	return r.GetByKey(ctx, key)
+func (r *stubApiKeyRepo) GetByKeyHashForAuth(ctx context.Context, key string) (*service.APIKey, error) {
+	return r.GetByKeyHash(ctx, key)
 }
 
 func (r *stubApiKeyRepo) Update(ctx context.Context, key *service.APIKey) error {
@@ -549,7 +549,7 @@ func (r *stubApiKeyRepo) CountByUserID(ctx context.Context, userID int64) (int64
 	return 0, errors.New("not implemented")
 }
 
-func (r *stubApiKeyRepo) ExistsByKey(ctx context.Context, key string) (bool, error) {
+func (r *stubApiKeyRepo) ExistsByKeyHash(ctx context.Context, key string) (bool, error) {
 	return false, errors.New("not implemented")
 }
 
@@ -573,11 +573,11 @@ func (r *stubApiKeyRepo) CountByGroupID(ctx context.Context, groupID int64) (int
 	return 0, errors.New("not implemented")
 }
 
-func (r *stubApiKeyRepo) ListKeysByUserID(ctx context.Context, userID int64) ([]string, error) {
+func (r *stubApiKeyRepo) ListKeyHashesByUserID(ctx context.Context, userID int64) ([]string, error) {
 	return nil, errors.New("not implemented")
 }
 
-func (r *stubApiKeyRepo) ListKeysByGroupID(ctx context.Context, groupID int64) ([]string, error) {
+func (r *stubApiKeyRepo) ListKeyHashesByGroupID(ctx context.Context, groupID int64) ([]string, error) {
 	return nil, errors.New("not implemented")
 }
 
@@ -673,6 +673,22 @@ func (r *stubUserSubscriptionRepo) UpdateNotes(ctx context.Context, subscription
 	return errors.New("not implemented")
 }
 
+func (r *stubUserSubscriptionRepo) UpdateAutoRenew(ctx context.Context, subscriptionID int64, autoRenew bool) error {
+	return errors.New("not implemented")
+}
+
+func (r *stubUserSubscriptionRepo) UpdateAutoRenewFailedAt(ctx context.Context, subscriptionID int64, failedAt *time.Time) error {
+	return errors.New("not implemented")
+}
+
+func (r *stubUserSubscriptionRepo) ClaimAutoRenew(ctx context.Context, subscriptionID int64, expiresAt time.Time) (bool, error) {
+	return false, errors.New("not implemented")
+}
+
+func (r *stubUserSubscriptionRepo) ListAutoRenewDue(ctx context.Context, dueBefore, retryBefore time.Time, limit int) ([]service.UserSubscription, error) {
+	return nil, errors.New("not implemented")
+}
+
 func (r *stubUserSubscriptionRepo) ActivateWindows(ctx context.Context, id int64, start time.Time) error {
 	if r.activateWindow != nil {
 		return r.activateWindow(ctx, id, start)
@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestRateErrorWriter 按协议格式输出 RPM/TPM 超限错误
+type RequestRateErrorWriter func(c *gin.Context, limitErr *service.APIKeyRequestRateLimitError)
+
+// GatewayRequestRateErrorWriter 按请求路径区分 Anthropic（/messages）与 OpenAI 格式
+func GatewayRequestRateErrorWriter(c *gin.Context, limitErr *service.APIKeyRequestRateLimitError) {
+	if strings.HasSuffix(c.Request.URL.Path, "/messages") {
+		c.JSON(http.StatusTooManyRequests, gin.H{
+			"type":  "error",
+			"error": gin.H{"type": "rate_limit_error", "message": limitErr.Error()},
+		})
+		return
+	}
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": gin.H{
+			"type":    limitErr.Kind,
+			"code":    "rate_limit_exceeded",
+			"message": limitErr.Error(),
+		},
+	})
+}
+
+// GoogleRequestRateErrorWriter 按 Google API 规范输出（RESOURCE_EXHAUSTED）
+func GoogleRequestRateErrorWriter(c *gin.Context, limitErr *service.APIKeyRequestRateLimitError) {
+	GoogleErrorWriter(c, http.StatusTooManyRequests, limitErr.Error())
+}
+
+// APIKeyRequestRateLimit API Key RPM/TPM 滑动窗口限流中间件，需挂在 API Key 认证之后。
+//
+// 仅对会转发上游的请求计数：模型列表、用量查询等 GET 请求以及 count_tokens 不占用额度；
+// Responses WebSocket 按建立连接计一次请求。超限时返回 429 并附带 Retry-After 与 x-ratelimit-* 头。
+func APIKeyRequestRateLimit(apiKeyService *service.APIKeyService, writeError RequestRateErrorWriter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		apiKey, ok := GetAPIKeyFromContext(c)
+		if !ok || apiKeyService == nil || !apiKey.HasRequestRateLimits() || !countsTowardRequestRate(c) {
+			c.Next()
+			return
+		}
+
+		err := apiKeyService.AcquireRequestRate(c.Request.Context(), apiKey)
+		var limitErr *service.APIKeyRequestRateLimitError
+		if !errors.As(err, &limitErr) {
+			c.Next()
+			return
+		}
+
+		setRequestRateLimitHeaders(c, limitErr)
+		writeError(c, limitErr)
+		c.Abort()
+	}
+}
+
+func countsTowardRequestRate(c *gin.Context) bool {
+	if c.Request.Method == http.MethodGet {
+		return c.IsWebsocket()
+	}
+	path := c.Request.URL.Path
+	return !strings.HasSuffix(path, "/count_tokens") && !strings.HasSuffix(path, ":countTokens")
+}
+
+func setRequestRateLimitHeaders(c *gin.Context, limitErr *service.APIKeyRequestRateLimitError) {
+	retryAfter := limitErr.RetryAfterSeconds()
+	c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+	c.Header("x-ratelimit-limit-"+limitErr.Kind, strconv.Itoa(limitErr.Limit))
+	c.Header("x-ratelimit-remaining-"+limitErr.Kind, strconv.Itoa(limitErr.Remaining))
+	c.Header("x-ratelimit-reset-"+limitErr.Kind, fmt.Sprintf("%ds", retryAfter))
+}
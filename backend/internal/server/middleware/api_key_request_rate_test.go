@@ -0,0 +1,128 @@
+//go:build unit
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type stubRequestRateCache struct {
+	allowed  bool
+	window   service.APIKeyRequestRateWindow
+	acquired int
+}
+
+func (s *stubRequestRateCache) AcquireRequestRate(_ context.Context, _ int64, _, _ int) (bool, service.APIKeyRequestRateWindow, error) {
+	s.acquired++
+	return s.allowed, s.window, nil
+}
+
+func (s *stubRequestRateCache) AddRequestRateTokens(_ context.Context, _ int64, _ int64) error {
+	return nil
+}
+
+func (s *stubRequestRateCache) GetRequestRate(_ context.Context, _ int64) (service.APIKeyRequestRateWindow, error) {
+	return s.window, nil
+}
+
+func newRequestRateTestRouter(cache service.APIKeyRequestRateCache, apiKey *service.APIKey, writer RequestRateErrorWriter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	apiKeyService := service.NewAPIKeyService(nil, nil, nil, nil, nil, nil, nil)
+	apiKeyService.SetRequestRateCache(cache)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(string(ContextKeyAPIKey), apiKey)
+		c.Next()
+	})
+	r.Use(APIKeyRequestRateLimit(apiKeyService, writer))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	r.POST("/v1/messages", ok)
+	r.POST("/v1/messages/count_tokens", ok)
+	r.POST("/v1/chat/completions", ok)
+	r.GET("/v1/models", ok)
+	r.POST("/v1beta/models/*modelAction", ok)
+	return r
+}
+
+func TestAPIKeyRequestRateLimit_Allowed(t *testing.T) {
+	cache := &stubRequestRateCache{allowed: true}
+	r := newRequestRateTestRouter(cache, &service.APIKey{ID: 1, RPMLimit: 10}, GatewayRequestRateErrorWriter)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/messages", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, 1, cache.acquired)
+	require.Empty(t, w.Header().Get("Retry-After"))
+}
+
+func TestAPIKeyRequestRateLimit_SkipsNonBillableRequests(t *testing.T) {
+	cache := &stubRequestRateCache{}
+	r := newRequestRateTestRouter(cache, &service.APIKey{ID: 1, RPMLimit: 10}, GatewayRequestRateErrorWriter)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/v1/models", nil),
+		httptest.NewRequest(http.MethodPost, "/v1/messages/count_tokens", nil),
+		httptest.NewRequest(http.MethodPost, "/v1beta/models/gemini-2.5-pro:countTokens", nil),
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, req.URL.Path)
+	}
+	require.Zero(t, cache.acquired)
+
+	// 未配置 RPM/TPM 的 Key 不访问 Redis
+	r = newRequestRateTestRouter(cache, &service.APIKey{ID: 2}, GatewayRequestRateErrorWriter)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/messages", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Zero(t, cache.acquired)
+}
+
+func TestAPIKeyRequestRateLimit_RejectsWithProtocolFormat(t *testing.T) {
+	cache := &stubRequestRateCache{window: service.APIKeyRequestRateWindow{CurRequests: 10, Elapsed: 40 * time.Second}}
+	apiKey := &service.APIKey{ID: 1, RPMLimit: 10}
+
+	t.Run("anthropic", func(t *testing.T) {
+		r := newRequestRateTestRouter(cache, apiKey, GatewayRequestRateErrorWriter)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/messages", nil))
+
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.Equal(t, "26", w.Header().Get("Retry-After"))
+		require.Equal(t, "10", w.Header().Get("x-ratelimit-limit-requests"))
+		require.Equal(t, "0", w.Header().Get("x-ratelimit-remaining-requests"))
+		require.Equal(t, "26s", w.Header().Get("x-ratelimit-reset-requests"))
+		require.Equal(t, "error", gjson.GetBytes(w.Body.Bytes(), "type").String())
+		require.Equal(t, "rate_limit_error", gjson.GetBytes(w.Body.Bytes(), "error.type").String())
+	})
+
+	t.Run("openai", func(t *testing.T) {
+		r := newRequestRateTestRouter(cache, apiKey, GatewayRequestRateErrorWriter)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.Equal(t, "rate_limit_exceeded", gjson.GetBytes(w.Body.Bytes(), "error.code").String())
+		require.Equal(t, "requests", gjson.GetBytes(w.Body.Bytes(), "error.type").String())
+	})
+
+	t.Run("google", func(t *testing.T) {
+		r := newRequestRateTestRouter(cache, apiKey, GoogleRequestRateErrorWriter)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1beta/models/gemini-2.5-pro:generateContent", nil))
+
+		require.Equal(t, http.StatusTooManyRequests, w.Code)
+		require.Equal(t, "RESOURCE_EXHAUSTED", gjson.GetBytes(w.Body.Bytes(), "error.status").String())
+		require.Equal(t, "26", w.Header().Get("Retry-After"))
+	})
+}
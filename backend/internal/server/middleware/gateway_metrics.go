@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// GatewayMetrics 记录网关请求的计数、耗时与首 token 时间（Prometheus 导出）。
+// metricsService 为 nil 时退化为透传。
+func GatewayMetrics(metricsService *service.MetricsService) gin.HandlerFunc {
+	if metricsService == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		obs := service.GatewayRequestObservation{
+			Status:   c.Writer.Status(),
+			Duration: time.Since(start),
+		}
+		if apiKey, ok := GetAPIKeyFromContext(c); ok && apiKey != nil && apiKey.Group != nil {
+			obs.Platform = apiKey.Group.Platform
+			obs.GroupID = apiKey.Group.ID
+			obs.GroupName = apiKey.Group.Name
+		}
+		if v, ok := c.Get(service.OpsTimeToFirstTokenMsKey); ok {
+			if ms, ok := v.(int64); ok && ms >= 0 {
+				ttft := time.Duration(ms) * time.Millisecond
+				obs.TTFT = &ttft
+			}
+		}
+		metricsService.ObserveGatewayRequest(obs)
+	}
+}
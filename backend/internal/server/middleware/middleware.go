@@ -24,6 +24,10 @@ const (
 	ContextKeySubscription ContextKey = "subscription"
 	// ContextKeyForcePlatform 强制平台（用于 /antigravity 路由）
 	ContextKeyForcePlatform ContextKey = "force_platform"
+	// ContextKeyAdminTokenID 具名管理员令牌 ID（int64，仅令牌认证时存在）
+	ContextKeyAdminTokenID ContextKey = "admin_token_id"
+	// ContextKeyAdminScopes 具名管理员令牌作用域（[]string，仅令牌认证时存在）
+	ContextKeyAdminScopes ContextKey = "admin_scopes"
 )
 
 // ForcePlatform 返回设置强制平台的中间件
@@ -25,10 +25,13 @@ func SetupRouter(
 	handlers *handler.Handlers,
 	jwtAuth middleware2.JWTAuthMiddleware,
 	adminAuth middleware2.AdminAuthMiddleware,
+	adminAudit middleware2.AdminAuditMiddleware,
 	apiKeyAuth middleware2.APIKeyAuthMiddleware,
 	apiKeyService *service.APIKeyService,
 	subscriptionService *service.SubscriptionService,
 	opsService *service.OpsService,
+	payloadCaptureService *service.PayloadCaptureService,
+	metricsService *service.MetricsService,
 	settingService *service.SettingService,
 	cfg *config.Config,
 	redisClient *redis.Client,
@@ -61,6 +64,11 @@ func SetupRouter(
 		return nil
 	}))
 
+	// Prometheus 指标：未配置独立监听地址时挂载到主服务（需在前端中间件之前拦截）
+	if cfg.Metrics.Enabled && cfg.Metrics.ListenAddr == "" {
+		r.Use(metricsEndpointMiddleware(cfg.Metrics.Path, NewMetricsHandler(cfg.Metrics, metricsService)))
+	}
+
 	// Serve embedded frontend with settings injection if available
 	if web.HasEmbeddedFrontend() {
 		frontendServer, err := web.NewFrontendServer(settingService)
@@ -81,7 +89,7 @@ func SetupRouter(
 	}
 
 	// 注册路由
-	registerRoutes(r, handlers, jwtAuth, adminAuth, apiKeyAuth, apiKeyService, subscriptionService, opsService, settingService, cfg, redisClient)
+	registerRoutes(r, handlers, jwtAuth, adminAuth, adminAudit, apiKeyAuth, apiKeyService, subscriptionService, opsService, payloadCaptureService, metricsService, settingService, cfg, redisClient)
 
 	return r
 }
@@ -92,10 +100,13 @@ func registerRoutes(
 	h *handler.Handlers,
 	jwtAuth middleware2.JWTAuthMiddleware,
 	adminAuth middleware2.AdminAuthMiddleware,
+	adminAudit middleware2.AdminAuditMiddleware,
 	apiKeyAuth middleware2.APIKeyAuthMiddleware,
 	apiKeyService *service.APIKeyService,
 	subscriptionService *service.SubscriptionService,
 	opsService *service.OpsService,
+	payloadCaptureService *service.PayloadCaptureService,
+	metricsService *service.MetricsService,
 	settingService *service.SettingService,
 	cfg *config.Config,
 	redisClient *redis.Client,
@@ -109,6 +120,7 @@ func registerRoutes(
 	// 注册各模块路由
 	routes.RegisterAuthRoutes(v1, h, jwtAuth, redisClient, settingService)
 	routes.RegisterUserRoutes(v1, h, jwtAuth, settingService)
-	routes.RegisterAdminRoutes(v1, h, adminAuth)
-	routes.RegisterGatewayRoutes(r, h, apiKeyAuth, apiKeyService, subscriptionService, opsService, settingService, cfg)
+	routes.RegisterPaymentRoutes(v1, h)
+	routes.RegisterAdminRoutes(v1, h, adminAuth, adminAudit)
+	routes.RegisterGatewayRoutes(r, h, apiKeyAuth, apiKeyService, subscriptionService, opsService, payloadCaptureService, metricsService, settingService, cfg)
 }
@@ -4,6 +4,7 @@ package routes
 import (
 	"github.com/Wei-Shaw/sub2api/internal/handler"
 	"github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,9 +14,12 @@ func RegisterAdminRoutes(
 	v1 *gin.RouterGroup,
 	h *handler.Handlers,
 	adminAuth middleware.AdminAuthMiddleware,
+	adminAudit middleware.AdminAuditMiddleware,
 ) {
 	admin := v1.Group("/admin")
 	admin.Use(gin.HandlerFunc(adminAuth))
+	// 审计需在认证之后，才能记录操作人
+	admin.Use(gin.HandlerFunc(adminAudit))
 	{
 		// 仪表盘
 		registerDashboardRoutes(admin, h)
@@ -43,6 +47,7 @@ func RegisterAdminRoutes(
 
 		// 代理管理
 		registerProxyRoutes(admin, h)
+		registerProxyPoolRoutes(admin, h)
 
 		// 卡密管理
 		registerRedeemCodeRoutes(admin, h)
@@ -59,6 +64,9 @@ func RegisterAdminRoutes(
 		// 数据库备份恢复
 		registerBackupRoutes(admin, h)
 
+		// 账号凭证静态加密
+		registerCredentialEncryptionRoutes(admin, h)
+
 		// 运维监控（Ops）
 		registerOpsRoutes(admin, h)
 
@@ -68,6 +76,9 @@ func RegisterAdminRoutes(
 		// 订阅管理
 		registerSubscriptionRoutes(admin, h)
 
+		// 邀请返佣
+		registerReferralRoutes(admin, h)
+
 		// 使用记录管理
 		registerUsageRoutes(admin, h)
 
@@ -88,21 +99,40 @@ func RegisterAdminRoutes(
 
 		// 渠道管理
 		registerChannelRoutes(admin, h)
+
+		// 通知渠道管理
+		registerNotificationChannelRoutes(admin, h)
+
+		// SSO 登录提供方管理
+		registerOIDCProviderRoutes(admin, h)
+
+		// 支付订单
+		registerPaymentOrderRoutes(admin, h)
+
+		// 审计日志
+		registerAuditLogRoutes(admin, h)
+
+		// 具名管理员令牌
+		registerAdminTokenRoutes(admin, h)
+
+		// 网关请求安全策略
+		registerGuardrailRoutes(admin, h)
 	}
 }
 
 func registerAdminAPIKeyRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	apiKeys := admin.Group("/api-keys")
+	apiKeys := admin.Group("/api-keys", middleware.RequireAdminScope(service.AdminScopeAPIKeys))
 	{
 		apiKeys.PUT("/:id", h.Admin.APIKey.UpdateGroup)
 	}
 }
 
 func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	ops := admin.Group("/ops")
+	ops := admin.Group("/ops", middleware.RequireAdminScope(service.AdminScopeOps))
 	{
 		// Realtime ops signals
 		ops.GET("/concurrency", h.Admin.Ops.GetConcurrencyStats)
+		ops.GET("/proxy-pools/health", h.Admin.ProxyPool.Health)
 		ops.GET("/user-concurrency", h.Admin.Ops.GetUserConcurrencyStats)
 		ops.GET("/account-availability", h.Admin.Ops.GetAccountAvailability)
 		ops.GET("/realtime-traffic", h.Admin.Ops.GetRealtimeTrafficSummary)
@@ -172,6 +202,15 @@ func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		// Request drilldown (success + error)
 		ops.GET("/requests", h.Admin.Ops.ListRequestDetails)
 
+		// Payload capture (opt-in per group / API key)
+		ops.GET("/payload-capture/rules", h.Admin.PayloadCapture.ListRules)
+		ops.GET("/payload-capture/rules/:id", h.Admin.PayloadCapture.GetRule)
+		ops.POST("/payload-capture/rules", h.Admin.PayloadCapture.CreateRule)
+		ops.PUT("/payload-capture/rules/:id", h.Admin.PayloadCapture.UpdateRule)
+		ops.DELETE("/payload-capture/rules/:id", h.Admin.PayloadCapture.DeleteRule)
+		ops.GET("/payload-captures", h.Admin.PayloadCapture.ListCaptures)
+		ops.GET("/payload-captures/:id", h.Admin.PayloadCapture.GetCapture)
+
 		// Indexed system logs
 		ops.GET("/system-logs", h.Admin.Ops.ListSystemLogs)
 		ops.POST("/system-logs/cleanup", h.Admin.Ops.CleanupSystemLogs)
@@ -189,7 +228,7 @@ func registerOpsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerDashboardRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	dashboard := admin.Group("/dashboard")
+	dashboard := admin.Group("/dashboard", middleware.RequireAdminScope(service.AdminScopeDashboard))
 	{
 		dashboard.GET("/snapshot-v2", h.Admin.Dashboard.GetSnapshotV2)
 		dashboard.GET("/stats", h.Admin.Dashboard.GetStats)
@@ -208,7 +247,7 @@ func registerDashboardRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerUserManagementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	users := admin.Group("/users")
+	users := admin.Group("/users", middleware.RequireAdminScope(service.AdminScopeUsers))
 	{
 		users.GET("", h.Admin.User.List)
 		users.GET("/:id", h.Admin.User.GetByID)
@@ -228,7 +267,7 @@ func registerUserManagementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerGroupRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	groups := admin.Group("/groups")
+	groups := admin.Group("/groups", middleware.RequireAdminScope(service.AdminScopeGroups))
 	{
 		groups.GET("", h.Admin.Group.List)
 		groups.GET("/all", h.Admin.Group.GetAll)
@@ -248,7 +287,7 @@ func registerGroupRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerAccountRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	accounts := admin.Group("/accounts")
+	accounts := admin.Group("/accounts", middleware.RequireAdminScope(service.AdminScopeAccounts))
 	{
 		accounts.GET("", h.Admin.Account.List)
 		accounts.GET("/:id", h.Admin.Account.GetByID)
@@ -297,7 +336,7 @@ func registerAccountRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerAnnouncementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	announcements := admin.Group("/announcements")
+	announcements := admin.Group("/announcements", middleware.RequireAdminScope(service.AdminScopeAnnouncements))
 	{
 		announcements.GET("", h.Admin.Announcement.List)
 		announcements.POST("", h.Admin.Announcement.Create)
@@ -309,7 +348,7 @@ func registerAnnouncementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerOpenAIOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	openai := admin.Group("/openai")
+	openai := admin.Group("/openai", middleware.RequireAdminScope(service.AdminScopeAccounts))
 	{
 		openai.POST("/generate-auth-url", h.Admin.OpenAIOAuth.GenerateAuthURL)
 		openai.POST("/exchange-code", h.Admin.OpenAIOAuth.ExchangeCode)
@@ -320,7 +359,7 @@ func registerOpenAIOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerGeminiOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	gemini := admin.Group("/gemini")
+	gemini := admin.Group("/gemini", middleware.RequireAdminScope(service.AdminScopeAccounts))
 	{
 		gemini.POST("/oauth/auth-url", h.Admin.GeminiOAuth.GenerateAuthURL)
 		gemini.POST("/oauth/exchange-code", h.Admin.GeminiOAuth.ExchangeCode)
@@ -329,7 +368,7 @@ func registerGeminiOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerAntigravityOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	antigravity := admin.Group("/antigravity")
+	antigravity := admin.Group("/antigravity", middleware.RequireAdminScope(service.AdminScopeAccounts))
 	{
 		antigravity.POST("/oauth/auth-url", h.Admin.AntigravityOAuth.GenerateAuthURL)
 		antigravity.POST("/oauth/exchange-code", h.Admin.AntigravityOAuth.ExchangeCode)
@@ -338,7 +377,7 @@ func registerAntigravityOAuthRoutes(admin *gin.RouterGroup, h *handler.Handlers)
 }
 
 func registerProxyRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	proxies := admin.Group("/proxies")
+	proxies := admin.Group("/proxies", middleware.RequireAdminScope(service.AdminScopeProxies))
 	{
 		proxies.GET("", h.Admin.Proxy.List)
 		proxies.GET("/all", h.Admin.Proxy.GetAll)
@@ -357,8 +396,20 @@ func registerProxyRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 	}
 }
 
+func registerProxyPoolRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	pools := admin.Group("/proxy-pools", middleware.RequireAdminScope(service.AdminScopeProxies))
+	{
+		pools.GET("", h.Admin.ProxyPool.List)
+		pools.GET("/health", h.Admin.ProxyPool.Health)
+		pools.GET("/:id", h.Admin.ProxyPool.GetByID)
+		pools.POST("", h.Admin.ProxyPool.Create)
+		pools.PUT("/:id", h.Admin.ProxyPool.Update)
+		pools.DELETE("/:id", h.Admin.ProxyPool.Delete)
+	}
+}
+
 func registerRedeemCodeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	codes := admin.Group("/redeem-codes")
+	codes := admin.Group("/redeem-codes", middleware.RequireAdminScope(service.AdminScopeRedeem))
 	{
 		codes.GET("", h.Admin.Redeem.List)
 		codes.GET("/stats", h.Admin.Redeem.GetStats)
@@ -373,7 +424,7 @@ func registerRedeemCodeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerPromoCodeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	promoCodes := admin.Group("/promo-codes")
+	promoCodes := admin.Group("/promo-codes", middleware.RequireAdminScope(service.AdminScopeRedeem))
 	{
 		promoCodes.GET("", h.Admin.Promo.List)
 		promoCodes.GET("/:id", h.Admin.Promo.GetByID)
@@ -385,16 +436,16 @@ func registerPromoCodeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerSettingsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	adminSettings := admin.Group("/settings")
+	adminSettings := admin.Group("/settings", middleware.RequireAdminScope(service.AdminScopeSettings))
 	{
 		adminSettings.GET("", h.Admin.Setting.GetSettings)
 		adminSettings.PUT("", h.Admin.Setting.UpdateSettings)
 		adminSettings.POST("/test-smtp", h.Admin.Setting.TestSMTPConnection)
 		adminSettings.POST("/send-test-email", h.Admin.Setting.SendTestEmail)
-		// Admin API Key 管理
-		adminSettings.GET("/admin-api-key", h.Admin.Setting.GetAdminAPIKey)
-		adminSettings.POST("/admin-api-key/regenerate", h.Admin.Setting.RegenerateAdminAPIKey)
-		adminSettings.DELETE("/admin-api-key", h.Admin.Setting.DeleteAdminAPIKey)
+		// Admin API Key 管理（具名令牌不可操作全局 Key）
+		adminSettings.GET("/admin-api-key", middleware.RequireFullAdmin(), h.Admin.Setting.GetAdminAPIKey)
+		adminSettings.POST("/admin-api-key/regenerate", middleware.RequireFullAdmin(), h.Admin.Setting.RegenerateAdminAPIKey)
+		adminSettings.DELETE("/admin-api-key", middleware.RequireFullAdmin(), h.Admin.Setting.DeleteAdminAPIKey)
 		// 529过载冷却配置
 		adminSettings.GET("/overload-cooldown", h.Admin.Setting.GetOverloadCooldownSettings)
 		adminSettings.PUT("/overload-cooldown", h.Admin.Setting.UpdateOverloadCooldownSettings)
@@ -411,7 +462,7 @@ func registerSettingsRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerDataManagementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	dataManagement := admin.Group("/data-management")
+	dataManagement := admin.Group("/data-management", middleware.RequireAdminScope(service.AdminScopeSystem))
 	{
 		dataManagement.GET("/agent/health", h.Admin.DataManagement.GetAgentHealth)
 		dataManagement.GET("/config", h.Admin.DataManagement.GetConfig)
@@ -433,8 +484,16 @@ func registerDataManagementRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 	}
 }
 
+func registerCredentialEncryptionRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	enc := admin.Group("/credential-encryption", middleware.RequireAdminScope(service.AdminScopeSystem))
+	{
+		enc.GET("", h.Admin.CredentialEncryption.GetStatus)
+		enc.POST("/reencrypt", h.Admin.CredentialEncryption.Reencrypt)
+	}
+}
+
 func registerBackupRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	backup := admin.Group("/backups")
+	backup := admin.Group("/backups", middleware.RequireAdminScope(service.AdminScopeSystem))
 	{
 		// S3 存储配置
 		backup.GET("/s3-config", h.Admin.Backup.GetS3Config)
@@ -458,7 +517,7 @@ func registerBackupRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerSystemRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	system := admin.Group("/system")
+	system := admin.Group("/system", middleware.RequireAdminScope(service.AdminScopeSystem))
 	{
 		system.GET("/version", h.Admin.System.GetVersion)
 		system.GET("/check-updates", h.Admin.System.CheckUpdates)
@@ -469,9 +528,10 @@ func registerSystemRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerSubscriptionRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	subscriptions := admin.Group("/subscriptions")
+	subscriptions := admin.Group("/subscriptions", middleware.RequireAdminScope(service.AdminScopeSubscriptions))
 	{
 		subscriptions.GET("", h.Admin.Subscription.List)
+		subscriptions.GET("/purchases", h.Admin.Subscription.ListPurchases)
 		subscriptions.GET("/:id", h.Admin.Subscription.GetByID)
 		subscriptions.GET("/:id/progress", h.Admin.Subscription.GetProgress)
 		subscriptions.POST("/assign", h.Admin.Subscription.Assign)
@@ -482,14 +542,22 @@ func registerSubscriptionRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 	}
 
 	// 分组下的订阅列表
-	admin.GET("/groups/:id/subscriptions", h.Admin.Subscription.ListByGroup)
+	admin.GET("/groups/:id/subscriptions", middleware.RequireAdminScope(service.AdminScopeSubscriptions), h.Admin.Subscription.ListByGroup)
 
 	// 用户下的订阅列表
-	admin.GET("/users/:id/subscriptions", h.Admin.Subscription.ListByUser)
+	admin.GET("/users/:id/subscriptions", middleware.RequireAdminScope(service.AdminScopeSubscriptions), h.Admin.Subscription.ListByUser)
+}
+
+func registerReferralRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	referrals := admin.Group("/referrals", middleware.RequireAdminScope(service.AdminScopeUsers))
+	{
+		referrals.GET("", h.Admin.Referral.List)
+		referrals.GET("/commissions", h.Admin.Referral.ListCommissions)
+	}
 }
 
 func registerUsageRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	usage := admin.Group("/usage")
+	usage := admin.Group("/usage", middleware.RequireAdminScope(service.AdminScopeUsage))
 	{
 		usage.GET("", h.Admin.Usage.List)
 		usage.GET("/stats", h.Admin.Usage.Stats)
@@ -502,7 +570,7 @@ func registerUsageRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerUserAttributeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	attrs := admin.Group("/user-attributes")
+	attrs := admin.Group("/user-attributes", middleware.RequireAdminScope(service.AdminScopeUsers))
 	{
 		attrs.GET("", h.Admin.UserAttribute.ListDefinitions)
 		attrs.POST("", h.Admin.UserAttribute.CreateDefinition)
@@ -514,7 +582,7 @@ func registerUserAttributeRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 }
 
 func registerScheduledTestRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	plans := admin.Group("/scheduled-test-plans")
+	plans := admin.Group("/scheduled-test-plans", middleware.RequireAdminScope(service.AdminScopeAccounts))
 	{
 		plans.POST("", h.Admin.ScheduledTest.Create)
 		plans.PUT("/:id", h.Admin.ScheduledTest.Update)
@@ -522,11 +590,11 @@ func registerScheduledTestRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		plans.GET("/:id/results", h.Admin.ScheduledTest.ListResults)
 	}
 	// Nested under accounts
-	admin.GET("/accounts/:id/scheduled-test-plans", h.Admin.ScheduledTest.ListByAccount)
+	admin.GET("/accounts/:id/scheduled-test-plans", middleware.RequireAdminScope(service.AdminScopeAccounts), h.Admin.ScheduledTest.ListByAccount)
 }
 
 func registerErrorPassthroughRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	rules := admin.Group("/error-passthrough-rules")
+	rules := admin.Group("/error-passthrough-rules", middleware.RequireAdminScope(service.AdminScopeAccounts))
 	{
 		rules.GET("", h.Admin.ErrorPassthrough.List)
 		rules.GET("/:id", h.Admin.ErrorPassthrough.GetByID)
@@ -537,7 +605,7 @@ func registerErrorPassthroughRoutes(admin *gin.RouterGroup, h *handler.Handlers)
 }
 
 func registerTLSFingerprintProfileRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	profiles := admin.Group("/tls-fingerprint-profiles")
+	profiles := admin.Group("/tls-fingerprint-profiles", middleware.RequireAdminScope(service.AdminScopeAccounts))
 	{
 		profiles.GET("", h.Admin.TLSFingerprintProfile.List)
 		profiles.GET("/:id", h.Admin.TLSFingerprintProfile.GetByID)
@@ -548,7 +616,7 @@ func registerTLSFingerprintProfileRoutes(admin *gin.RouterGroup, h *handler.Hand
 }
 
 func registerChannelRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
-	channels := admin.Group("/channels")
+	channels := admin.Group("/channels", middleware.RequireAdminScope(service.AdminScopeChannels))
 	{
 		channels.GET("", h.Admin.Channel.List)
 		channels.GET("/model-pricing", h.Admin.Channel.GetModelDefaultPricing)
@@ -558,3 +626,66 @@ func registerChannelRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
 		channels.DELETE("/:id", h.Admin.Channel.Delete)
 	}
 }
+
+func registerNotificationChannelRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	channels := admin.Group("/notification-channels", middleware.RequireAdminScope(service.AdminScopeSettings))
+	{
+		channels.GET("", h.Admin.NotificationChannel.List)
+		channels.GET("/:id", h.Admin.NotificationChannel.GetByID)
+		channels.POST("", h.Admin.NotificationChannel.Create)
+		channels.PUT("/:id", h.Admin.NotificationChannel.Update)
+		channels.DELETE("/:id", h.Admin.NotificationChannel.Delete)
+		channels.POST("/:id/test", h.Admin.NotificationChannel.Test)
+	}
+}
+
+func registerOIDCProviderRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	providers := admin.Group("/oidc-providers", middleware.RequireAdminScope(service.AdminScopeSettings))
+	{
+		providers.GET("", h.Admin.OIDCProvider.List)
+		providers.GET("/:id", h.Admin.OIDCProvider.GetByID)
+		providers.POST("", h.Admin.OIDCProvider.Create)
+		providers.PUT("/:id", h.Admin.OIDCProvider.Update)
+		providers.DELETE("/:id", h.Admin.OIDCProvider.Delete)
+		providers.POST("/:id/test", h.Admin.OIDCProvider.TestDiscovery)
+	}
+}
+
+func registerPaymentOrderRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	orders := admin.Group("/payment-orders", middleware.RequireAdminScope(service.AdminScopePayments))
+	{
+		orders.GET("", h.Admin.PaymentOrder.List)
+		orders.GET("/:order_no", h.Admin.PaymentOrder.GetByOrderNo)
+	}
+}
+
+func registerAuditLogRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	logs := admin.Group("/audit-logs", middleware.RequireAdminScope(service.AdminScopeAudit))
+	{
+		logs.GET("", h.Admin.AuditLog.List)
+		logs.GET("/export", h.Admin.AuditLog.Export)
+	}
+}
+
+// registerAdminTokenRoutes 具名管理员令牌管理：仅限 JWT 或全局 Admin API Key，防止令牌自我提权
+func registerAdminTokenRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	tokens := admin.Group("/admin-tokens", middleware.RequireFullAdmin())
+	{
+		tokens.GET("", h.Admin.AdminToken.List)
+		tokens.GET("/scopes", h.Admin.AdminToken.Scopes)
+		tokens.POST("", h.Admin.AdminToken.Create)
+		tokens.DELETE("/:id", h.Admin.AdminToken.Revoke)
+	}
+}
+
+func registerGuardrailRoutes(admin *gin.RouterGroup, h *handler.Handlers) {
+	guardrails := admin.Group("/guardrails", middleware.RequireAdminScope(service.AdminScopeGroups))
+	{
+		guardrails.GET("/presets", h.Admin.Guardrail.Presets)
+		guardrails.GET("/rules", h.Admin.Guardrail.List)
+		guardrails.GET("/rules/:id", h.Admin.Guardrail.GetByID)
+		guardrails.POST("/rules", h.Admin.Guardrail.Create)
+		guardrails.PUT("/rules/:id", h.Admin.Guardrail.Update)
+		guardrails.DELETE("/rules/:id", h.Admin.Guardrail.Delete)
+	}
+}
@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/handler"
+	servermiddleware "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// newAdminRoutesTestRouter 模拟具名令牌认证；handler 均为空实现，作用域校验失败时不会被调用
+func newAdminRoutesTestRouter(scopes []string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	RegisterAdminRoutes(
+		router.Group("/api/v1"),
+		&handler.Handlers{Admin: &handler.AdminHandlers{}},
+		servermiddleware.AdminAuthMiddleware(func(c *gin.Context) {
+			c.Set(string(servermiddleware.ContextKeyAdminTokenID), int64(1))
+			c.Set(string(servermiddleware.ContextKeyAdminScopes), scopes)
+			c.Next()
+		}),
+		servermiddleware.AdminAuditMiddleware(func(c *gin.Context) {
+			c.Next()
+		}),
+	)
+	return router
+}
+
+func adminRouteSamplePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if strings.HasPrefix(p, ":") || strings.HasPrefix(p, "*") {
+			parts[i] = "1"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+func TestAdminRoutesEnforceTokenScopesOnEveryRoute(t *testing.T) {
+	router := newAdminRoutesTestRouter(nil)
+
+	routes := router.Routes()
+	require.NotEmpty(t, routes)
+	for _, r := range routes {
+		req := httptest.NewRequest(r.Method, adminRouteSamplePath(r.Path), nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusForbidden, w.Code, "%s %s must be scope-guarded", r.Method, r.Path)
+		require.Contains(t, w.Body.String(), "ADMIN_TOKEN_SCOPE_DENIED", "%s %s", r.Method, r.Path)
+	}
+}
+
+func TestAdminRoutesReadScopeDoesNotGrantWrite(t *testing.T) {
+	router := newAdminRoutesTestRouter([]string{"usage:read", "accounts:write"})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/usage/cleanup-tasks", nil))
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/admin/users", nil))
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	// 令牌管理与全局 Key 管理始终拒绝具名令牌
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/admin/admin-tokens", nil))
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -19,26 +19,37 @@ func RegisterGatewayRoutes(
 	apiKeyService *service.APIKeyService,
 	subscriptionService *service.SubscriptionService,
 	opsService *service.OpsService,
+	payloadCaptureService *service.PayloadCaptureService,
+	metricsService *service.MetricsService,
 	settingService *service.SettingService,
 	cfg *config.Config,
 ) {
 	bodyLimit := middleware.RequestBodyLimit(cfg.Gateway.MaxBodySize)
 	clientRequestID := middleware.ClientRequestID()
+	gatewayMetrics := middleware.GatewayMetrics(metricsService)
 	opsErrorLogger := handler.OpsErrorLoggerMiddleware(opsService)
+	payloadCapture := handler.PayloadCaptureMiddleware(payloadCaptureService)
 	endpointNorm := handler.InboundEndpointMiddleware()
 
 	// 未分组 Key 拦截中间件（按协议格式区分错误响应）
 	requireGroupAnthropic := middleware.RequireGroupAssignment(settingService, middleware.AnthropicErrorWriter)
 	requireGroupGoogle := middleware.RequireGroupAssignment(settingService, middleware.GoogleErrorWriter)
 
+	// API Key RPM/TPM 限流中间件（按协议格式区分错误响应）
+	requestRate := middleware.APIKeyRequestRateLimit(apiKeyService, middleware.GatewayRequestRateErrorWriter)
+	requestRateGoogle := middleware.APIKeyRequestRateLimit(apiKeyService, middleware.GoogleRequestRateErrorWriter)
+
 	// API网关（Claude API兼容）
 	gateway := r.Group("/v1")
 	gateway.Use(bodyLimit)
 	gateway.Use(clientRequestID)
+	gateway.Use(gatewayMetrics)
 	gateway.Use(opsErrorLogger)
+	gateway.Use(payloadCapture)
 	gateway.Use(endpointNorm)
 	gateway.Use(gin.HandlerFunc(apiKeyAuth))
 	gateway.Use(requireGroupAnthropic)
+	gateway.Use(requestRate)
 	{
 		// /v1/messages: auto-route based on group platform
 		gateway.POST("/messages", func(c *gin.Context) {
@@ -48,9 +59,9 @@ func RegisterGatewayRoutes(
 			}
 			h.Gateway.Messages(c)
 		})
-		// /v1/messages/count_tokens: OpenAI groups get 404
+		// /v1/messages/count_tokens: OpenAI groups get 404 unless local token counting is enabled
 		gateway.POST("/messages/count_tokens", func(c *gin.Context) {
-			if getGroupPlatform(c) == service.PlatformOpenAI {
+			if getGroupPlatform(c) == service.PlatformOpenAI && !groupLocalTokenCounting(c) {
 				c.JSON(http.StatusNotFound, gin.H{
 					"type": "error",
 					"error": gin.H{
@@ -62,6 +73,12 @@ func RegisterGatewayRoutes(
 			}
 			h.Gateway.CountTokens(c)
 		})
+		// Anthropic Message Batches API: Anthropic groups only
+		gateway.POST("/messages/batches", messageBatchesHandler(h.MessageBatch.Create))
+		gateway.GET("/messages/batches", messageBatchesHandler(h.MessageBatch.List))
+		gateway.GET("/messages/batches/:id", messageBatchesHandler(h.MessageBatch.Get))
+		gateway.POST("/messages/batches/:id/cancel", messageBatchesHandler(h.MessageBatch.Cancel))
+		gateway.GET("/messages/batches/:id/results", messageBatchesHandler(h.MessageBatch.Results))
 		gateway.GET("/models", h.Gateway.Models)
 		gateway.GET("/usage", h.Gateway.Usage)
 		// OpenAI Responses API: auto-route based on group platform
@@ -88,21 +105,52 @@ func RegisterGatewayRoutes(
 			}
 			h.Gateway.ChatCompletions(c)
 		})
+		// OpenAI Embeddings API: OpenAI and Gemini groups only
+		gateway.POST("/embeddings", embeddingsHandler(h))
+		// OpenAI Images API: OpenAI image accounts and Gemini/Antigravity image models
+		gateway.POST("/images/generations", imagesHandler(h.OpenAIGateway.ImagesGenerations, h.Gateway.ImagesGenerations))
+		gateway.POST("/images/edits", imagesHandler(h.OpenAIGateway.ImagesEdits, h.Gateway.ImagesEdits))
+	}
+
+	// OpenAI Files / Batch API：OpenAI 分组专用；文件上传使用独立的请求体上限
+	openAIFiles := r.Group("/v1")
+	openAIFiles.Use(middleware.RequestBodyLimit(int64(cfg.Gateway.OpenAIBatches.MaxFileSizeMB) << 20))
+	openAIFiles.Use(clientRequestID)
+	openAIFiles.Use(gatewayMetrics)
+	openAIFiles.Use(opsErrorLogger)
+	openAIFiles.Use(endpointNorm)
+	openAIFiles.Use(gin.HandlerFunc(apiKeyAuth))
+	openAIFiles.Use(requireGroupAnthropic)
+	openAIFiles.Use(requestRate)
+	openAIFiles.Use(openAIBatchesOnly)
+	{
+		openAIFiles.POST("/files", h.OpenAIBatch.UploadFile)
+		openAIFiles.GET("/files", h.OpenAIBatch.ListFiles)
+		openAIFiles.GET("/files/:id", h.OpenAIBatch.GetFile)
+		openAIFiles.GET("/files/:id/content", h.OpenAIBatch.FileContent)
+		openAIFiles.DELETE("/files/:id", h.OpenAIBatch.DeleteFile)
 	}
+	gateway.POST("/batches", openAIBatchesOnly, h.OpenAIBatch.CreateBatch)
+	gateway.GET("/batches", openAIBatchesOnly, h.OpenAIBatch.ListBatches)
+	gateway.GET("/batches/:id", openAIBatchesOnly, h.OpenAIBatch.GetBatch)
+	gateway.POST("/batches/:id/cancel", openAIBatchesOnly, h.OpenAIBatch.CancelBatch)
 
 	// Gemini 原生 API 兼容层（Gemini SDK/CLI 直连）
 	gemini := r.Group("/v1beta")
 	gemini.Use(bodyLimit)
 	gemini.Use(clientRequestID)
+	gemini.Use(gatewayMetrics)
 	gemini.Use(opsErrorLogger)
+	gemini.Use(payloadCapture)
 	gemini.Use(endpointNorm)
 	gemini.Use(middleware.APIKeyAuthWithSubscriptionGoogle(apiKeyService, subscriptionService, cfg))
 	gemini.Use(requireGroupGoogle)
+	gemini.Use(requestRateGoogle)
 	{
 		gemini.GET("/models", h.Gateway.GeminiV1BetaListModels)
 		gemini.GET("/models/:model", h.Gateway.GeminiV1BetaGetModel)
 		// Gin treats ":" as a param marker, but Gemini uses "{model}:{action}" in the same segment.
-		gemini.POST("/models/*modelAction", h.Gateway.GeminiV1BetaModels)
+		gemini.POST("/models/*modelAction", geminiV1BetaModelsHandler(h))
 	}
 
 	// OpenAI Responses API（不带v1前缀的别名）— auto-route based on group platform
@@ -113,11 +161,11 @@ func RegisterGatewayRoutes(
 		}
 		h.Gateway.Responses(c)
 	}
-	r.POST("/responses", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, responsesHandler)
-	r.POST("/responses/*subpath", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, responsesHandler)
-	r.GET("/responses", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, h.OpenAIGateway.ResponsesWebSocket)
+	r.POST("/responses", bodyLimit, clientRequestID, gatewayMetrics, opsErrorLogger, payloadCapture, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, requestRate, responsesHandler)
+	r.POST("/responses/*subpath", bodyLimit, clientRequestID, gatewayMetrics, opsErrorLogger, payloadCapture, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, requestRate, responsesHandler)
+	r.GET("/responses", bodyLimit, clientRequestID, gatewayMetrics, opsErrorLogger, payloadCapture, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, requestRate, h.OpenAIGateway.ResponsesWebSocket)
 	// OpenAI Chat Completions API（不带v1前缀的别名）— auto-route based on group platform
-	r.POST("/chat/completions", bodyLimit, clientRequestID, opsErrorLogger, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, func(c *gin.Context) {
+	r.POST("/chat/completions", bodyLimit, clientRequestID, gatewayMetrics, opsErrorLogger, payloadCapture, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, requestRate, func(c *gin.Context) {
 		if getGroupPlatform(c) == service.PlatformOpenAI {
 			h.OpenAIGateway.ChatCompletions(c)
 			return
@@ -125,6 +173,12 @@ func RegisterGatewayRoutes(
 		h.Gateway.ChatCompletions(c)
 	})
 
+	// OpenAI Embeddings API（不带v1前缀的别名）
+	r.POST("/embeddings", bodyLimit, clientRequestID, gatewayMetrics, opsErrorLogger, payloadCapture, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, requestRate, embeddingsHandler(h))
+	// OpenAI Images API（不带v1前缀的别名）
+	r.POST("/images/generations", bodyLimit, clientRequestID, gatewayMetrics, opsErrorLogger, payloadCapture, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, requestRate, imagesHandler(h.OpenAIGateway.ImagesGenerations, h.Gateway.ImagesGenerations))
+	r.POST("/images/edits", bodyLimit, clientRequestID, gatewayMetrics, opsErrorLogger, payloadCapture, endpointNorm, gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, requestRate, imagesHandler(h.OpenAIGateway.ImagesEdits, h.Gateway.ImagesEdits))
+
 	// Antigravity 模型列表
 	r.GET("/antigravity/models", gin.HandlerFunc(apiKeyAuth), requireGroupAnthropic, h.Gateway.AntigravityModels)
 
@@ -132,11 +186,14 @@ func RegisterGatewayRoutes(
 	antigravityV1 := r.Group("/antigravity/v1")
 	antigravityV1.Use(bodyLimit)
 	antigravityV1.Use(clientRequestID)
+	antigravityV1.Use(gatewayMetrics)
 	antigravityV1.Use(opsErrorLogger)
+	antigravityV1.Use(payloadCapture)
 	antigravityV1.Use(endpointNorm)
 	antigravityV1.Use(middleware.ForcePlatform(service.PlatformAntigravity))
 	antigravityV1.Use(gin.HandlerFunc(apiKeyAuth))
 	antigravityV1.Use(requireGroupAnthropic)
+	antigravityV1.Use(requestRate)
 	{
 		antigravityV1.POST("/messages", h.Gateway.Messages)
 		antigravityV1.POST("/messages/count_tokens", h.Gateway.CountTokens)
@@ -147,11 +204,14 @@ func RegisterGatewayRoutes(
 	antigravityV1Beta := r.Group("/antigravity/v1beta")
 	antigravityV1Beta.Use(bodyLimit)
 	antigravityV1Beta.Use(clientRequestID)
+	antigravityV1Beta.Use(gatewayMetrics)
 	antigravityV1Beta.Use(opsErrorLogger)
+	antigravityV1Beta.Use(payloadCapture)
 	antigravityV1Beta.Use(endpointNorm)
 	antigravityV1Beta.Use(middleware.ForcePlatform(service.PlatformAntigravity))
 	antigravityV1Beta.Use(middleware.APIKeyAuthWithSubscriptionGoogle(apiKeyService, subscriptionService, cfg))
 	antigravityV1Beta.Use(requireGroupGoogle)
+	antigravityV1Beta.Use(requestRateGoogle)
 	{
 		antigravityV1Beta.GET("/models", h.Gateway.GeminiV1BetaListModels)
 		antigravityV1Beta.GET("/models/:model", h.Gateway.GeminiV1BetaGetModel)
@@ -160,6 +220,103 @@ func RegisterGatewayRoutes(
 
 }
 
+// geminiV1BetaModelsHandler routes /v1beta/models/*modelAction by group platform.
+// Anthropic and OpenAI groups convert Gemini requests through apicompat; other
+// groups keep the native Gemini forwarding path.
+func geminiV1BetaModelsHandler(h *handler.Handlers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch getGroupPlatform(c) {
+		case service.PlatformOpenAI:
+			h.OpenAIGateway.GeminiV1BetaModels(c)
+		case service.PlatformAnthropic:
+			h.Gateway.GeminiV1BetaCompat(c)
+		default:
+			h.Gateway.GeminiV1BetaModels(c)
+		}
+	}
+}
+
+// embeddingsHandler routes /v1/embeddings by group platform. Only OpenAI and
+// Gemini groups have an embeddings upstream; other groups get an OpenAI-style 404.
+func embeddingsHandler(h *handler.Handlers) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch getGroupPlatform(c) {
+		case service.PlatformOpenAI:
+			h.OpenAIGateway.Embeddings(c)
+		case service.PlatformGemini:
+			h.Gateway.Embeddings(c)
+		default:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"type":    "not_found_error",
+					"message": "Embeddings are not supported for this platform",
+				},
+			})
+		}
+	}
+}
+
+// imagesHandler routes /v1/images/* by group platform. OpenAI groups forward to
+// image-capable API Key accounts; Gemini and Antigravity groups translate to
+// Gemini image generation models.
+func imagesHandler(openaiHandler, geminiHandler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch getGroupPlatform(c) {
+		case service.PlatformOpenAI:
+			openaiHandler(c)
+		case service.PlatformGemini, service.PlatformAntigravity:
+			geminiHandler(c)
+		default:
+			c.JSON(http.StatusNotFound, gin.H{
+				"error": gin.H{
+					"type":    "not_found_error",
+					"message": "Images are not supported for this platform",
+				},
+			})
+		}
+	}
+}
+
+// messageBatchesHandler restricts the Message Batches API to Anthropic groups
+// (and ungrouped keys, which are scheduled as Anthropic).
+func messageBatchesHandler(next gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch getGroupPlatform(c) {
+		case "", service.PlatformAnthropic:
+			next(c)
+		default:
+			c.JSON(http.StatusNotFound, gin.H{
+				"type": "error",
+				"error": gin.H{
+					"type":    "not_found_error",
+					"message": "Message batches are not supported for this platform",
+				},
+			})
+		}
+	}
+}
+
+// openAIBatchesOnly restricts the OpenAI Files and Batch APIs to OpenAI groups.
+func openAIBatchesOnly(c *gin.Context) {
+	if getGroupPlatform(c) == service.PlatformOpenAI {
+		c.Next()
+		return
+	}
+	c.AbortWithStatusJSON(http.StatusNotFound, gin.H{
+		"error": gin.H{
+			"type":    "not_found_error",
+			"message": "Files and batches are not supported for this platform",
+		},
+	})
+}
+
+// groupLocalTokenCounting reports whether the API Key's group answers
+// count_tokens locally instead of calling upstream.
+func groupLocalTokenCounting(c *gin.Context) bool {
+	apiKey, ok := middleware.GetAPIKeyFromContext(c)
+	return ok && apiKey.Group != nil && apiKey.Group.LocalTokenCounting
+}
+
 // getGroupPlatform extracts the group platform from the API Key stored in context.
 func getGroupPlatform(c *gin.Context) string {
 	apiKey, ok := middleware.GetAPIKeyFromContext(c)
@@ -1,14 +1,17 @@
 package routes
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/handler"
 	servermiddleware "github.com/Wei-Shaw/sub2api/internal/server/middleware"
+	"github.com/Wei-Shaw/sub2api/internal/service"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 )
@@ -30,6 +33,8 @@ func newGatewayRoutesTestRouter() *gin.Engine {
 		nil,
 		nil,
 		nil,
+		nil,
+		nil,
 		&config.Config{},
 	)
 
@@ -48,3 +53,148 @@ func TestGatewayRoutesOpenAIResponsesCompactPathIsRegistered(t *testing.T) {
 		require.NotEqual(t, http.StatusNotFound, w.Code, "path=%s should hit OpenAI responses handler", path)
 	}
 }
+
+func TestGatewayRoutesEmbeddingsRejectsUnsupportedPlatform(t *testing.T) {
+	router := newGatewayRoutesTestRouter()
+
+	for _, path := range []string{"/v1/embeddings", "/embeddings"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"model":"text-embedding-3-small","input":"hi"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNotFound, w.Code, "path=%s", path)
+		require.Contains(t, w.Body.String(), "not_found_error", "path=%s should hit embeddings platform router", path)
+	}
+}
+
+func TestGatewayRoutesImagesRejectsUnsupportedPlatform(t *testing.T) {
+	router := newGatewayRoutesTestRouter()
+
+	for _, path := range []string{"/v1/images/generations", "/v1/images/edits", "/images/generations", "/images/edits"} {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"model":"gpt-image-1","prompt":"a fox"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNotFound, w.Code, "path=%s", path)
+		require.Contains(t, w.Body.String(), "Images are not supported", "path=%s should hit images platform router", path)
+	}
+}
+
+func TestGatewayRoutesOpenAIBatchesRejectUnsupportedPlatform(t *testing.T) {
+	router := newGatewayRoutesTestRouter()
+
+	for _, tc := range []struct{ method, path string }{
+		{http.MethodPost, "/v1/files"},
+		{http.MethodGet, "/v1/files/file-abc/content"},
+		{http.MethodPost, "/v1/batches"},
+		{http.MethodPost, "/v1/batches/batch_abc/cancel"},
+	} {
+		req := httptest.NewRequest(tc.method, tc.path, nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusNotFound, w.Code, "path=%s", tc.path)
+		require.Contains(t, w.Body.String(), "Files and batches are not supported", "path=%s should hit batches platform router", tc.path)
+	}
+}
+
+type gatewayRoutesAPIKeyRepo struct {
+	service.APIKeyRepository
+	keys map[string]*service.APIKey
+}
+
+func (r *gatewayRoutesAPIKeyRepo) GetByKeyHashForAuth(_ context.Context, keyHash string) (*service.APIKey, error) {
+	for key, apiKey := range r.keys {
+		if service.HashAPIKey("", key) == keyHash {
+			clone := *apiKey
+			return &clone, nil
+		}
+	}
+	return nil, service.ErrAPIKeyNotFound
+}
+
+func (r *gatewayRoutesAPIKeyRepo) GetByKeyHash(ctx context.Context, keyHash string) (*service.APIKey, error) {
+	return r.GetByKeyHashForAuth(ctx, keyHash)
+}
+
+func (r *gatewayRoutesAPIKeyRepo) UpdateLastUsed(context.Context, int64, time.Time) error {
+	return nil
+}
+
+func newGatewayRoutesTestAPIKey(id int64, key, platform string) *service.APIKey {
+	user := &service.User{ID: 1, Status: service.StatusActive, Role: service.RoleUser, Balance: 10, Concurrency: 1}
+	groupID := id
+	return &service.APIKey{
+		ID:           id,
+		UserID:       user.ID,
+		Key:          key,
+		Status:       service.StatusActive,
+		GroupID:      &groupID,
+		DeniedModels: []string{"x"},
+		User:         user,
+		Group: &service.Group{
+			ID:               groupID,
+			Platform:         platform,
+			Status:           service.StatusActive,
+			SubscriptionType: service.SubscriptionTypeStandard,
+			RateMultiplier:   1,
+		},
+	}
+}
+
+func TestGatewayRoutesGeminiV1BetaModelsDispatchByGroupPlatform(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	cfg := &config.Config{RunMode: config.RunModeSimple}
+	repo := &gatewayRoutesAPIKeyRepo{keys: map[string]*service.APIKey{
+		"sk-anthropic": newGatewayRoutesTestAPIKey(1, "sk-anthropic", service.PlatformAnthropic),
+		"sk-openai":    newGatewayRoutesTestAPIKey(2, "sk-openai", service.PlatformOpenAI),
+	}}
+	apiKeyService := service.NewAPIKeyService(repo, nil, nil, nil, nil, nil, cfg)
+
+	RegisterGatewayRoutes(
+		router,
+		&handler.Handlers{
+			Gateway:       &handler.GatewayHandler{},
+			OpenAIGateway: &handler.OpenAIGatewayHandler{},
+		},
+		servermiddleware.APIKeyAuthMiddleware(func(c *gin.Context) {
+			c.Next()
+		}),
+		apiKeyService,
+		nil,
+		nil,
+		nil,
+		nil,
+		nil,
+		cfg,
+	)
+
+	cases := []struct {
+		name       string
+		key        string
+		wantStatus int
+		wantBody   string
+	}{
+		// Anthropic 分组走 GatewayHandler.GeminiV1BetaCompat：模型限制由兼容层判定
+		{name: "anthropic", key: "sk-anthropic", wantStatus: http.StatusForbidden, wantBody: `is not allowed for this API key`},
+		// OpenAI 分组走 OpenAIGatewayHandler.GeminiV1BetaModels：先校验 responses 依赖
+		{name: "openai", key: "sk-openai", wantStatus: http.StatusServiceUnavailable, wantBody: `Service temporarily unavailable`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1beta/models/x:generateContent", strings.NewReader(`{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("x-goog-api-key", tc.key)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+			require.Equal(t, tc.wantStatus, w.Code, "body=%s", w.Body.String())
+			require.Contains(t, w.Body.String(), tc.wantBody)
+			require.NotContains(t, w.Body.String(), "platform is not gemini")
+		})
+	}
+}
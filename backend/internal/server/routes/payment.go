@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/handler"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterPaymentRoutes 注册支付回调路由（公开访问，由各提供方签名校验保证可信）
+func RegisterPaymentRoutes(v1 *gin.RouterGroup, h *handler.Handlers) {
+	notify := v1.Group("/payment/notify")
+	{
+		// 易支付类网关可能以 GET 或 POST 表单回调
+		notify.GET("/:provider", h.Payment.Notify)
+		notify.POST("/:provider", h.Payment.Notify)
+	}
+}
@@ -82,6 +82,16 @@ func RegisterUserRoutes(
 			redeem.GET("/history", h.Redeem.GetHistory)
 		}
 
+		// 自助充值
+		payment := authenticated.Group("/payment")
+		{
+			payment.GET("/options", h.Payment.GetOptions)
+			payment.POST("/orders", h.Payment.CreateOrder)
+			payment.GET("/orders", h.Payment.ListOrders)
+			payment.GET("/orders/:order_no", h.Payment.GetOrder)
+			payment.POST("/orders/:order_no/cancel", h.Payment.CancelOrder)
+		}
+
 		// 用户订阅
 		subscriptions := authenticated.Group("/subscriptions")
 		{
@@ -89,6 +99,18 @@ func RegisterUserRoutes(
 			subscriptions.GET("/active", h.Subscription.GetActive)
 			subscriptions.GET("/progress", h.Subscription.GetProgress)
 			subscriptions.GET("/summary", h.Subscription.GetSummary)
+			subscriptions.GET("/plans", h.Subscription.ListPlans)
+			subscriptions.POST("/purchase", h.Subscription.Purchase)
+			subscriptions.GET("/purchases", h.Subscription.ListPurchases)
+			subscriptions.PUT("/:id/auto-renew", h.Subscription.UpdateAutoRenew)
+		}
+
+		// 邀请返佣
+		referral := authenticated.Group("/referral")
+		{
+			referral.GET("", h.Referral.GetOverview)
+			referral.GET("/invitees", h.Referral.ListInvitees)
+			referral.GET("/commissions", h.Referral.ListCommissions)
 		}
 	}
 }
@@ -5,14 +5,17 @@ import (
 	"encoding/json"
 	"errors"
 	"hash/fnv"
+	"log/slog"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/domain"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/proxyurl"
 )
 
 type Account struct {
@@ -63,6 +66,16 @@ type Account struct {
 	modelMappingCacheRawPtr         uintptr
 	modelMappingCacheRawLen         int
 	modelMappingCacheRawSig         uint64
+
+	// sealedCredentials 非 nil 时 Credentials 中的敏感字段仍为密文（来自调度快照），
+	// 首次读取凭证时才解密，未被选中的账号不会解密（非持久化字段）
+	sealedCredentials *sealedCredentials
+}
+
+// sealedCredentials 延迟解密状态；账号按值复制时共享同一份凭证 map 与解密状态
+type sealedCredentials struct {
+	once sync.Once
+	open func(int64, map[string]any) (map[string]any, error)
 }
 
 type TempUnschedulableRule struct {
@@ -191,7 +204,37 @@ func (a *Account) CanGetUsage() bool {
 	return a.Type == AccountTypeOAuth
 }
 
+// sealCredentials 标记 Credentials 中的敏感字段为密文，首次读取时使用 open 解密
+func (a *Account) sealCredentials(open func(int64, map[string]any) (map[string]any, error)) {
+	if a == nil || open == nil || len(a.Credentials) == 0 {
+		return
+	}
+	a.sealedCredentials = &sealedCredentials{open: open}
+}
+
+// openCredentials 就地解密调度快照中的密文凭证；直接读取敏感字段（不经过 GetCredential）前需调用
+func (a *Account) openCredentials() {
+	if a == nil || a.sealedCredentials == nil {
+		return
+	}
+	a.sealedCredentials.once.Do(func() {
+		opened, err := a.sealedCredentials.open(a.ID, a.Credentials)
+		if err != nil {
+			// 清空凭证，避免把密文当作令牌发往上游；后续按缺少凭证处理
+			slog.Warn("account_credentials_decrypt_failed", "account_id", a.ID, "error", err)
+			for key := range a.Credentials {
+				delete(a.Credentials, key)
+			}
+			return
+		}
+		for key, value := range opened {
+			a.Credentials[key] = value
+		}
+	})
+}
+
 func (a *Account) GetCredential(key string) string {
+	a.openCredentials()
 	if a.Credentials == nil {
 		return ""
 	}
@@ -557,6 +600,12 @@ func resolveRequestedModelInMapping(mapping map[string]string, requestedModel st
 // IsModelSupported 检查模型是否在 model_mapping 中（支持通配符）
 // 如果未配置 mapping，返回 true（允许所有模型）
 func (a *Account) IsModelSupported(requestedModel string) bool {
+	// Azure 配置了部署映射时，只有能解析到部署的模型才可调度
+	if a.IsOpenAIAzure() && len(a.GetAzureOpenAIDeployments()) > 0 {
+		if _, matched := a.ResolveAzureOpenAIDeployment(a.GetMappedModel(requestedModel)); !matched {
+			return false
+		}
+	}
 	mapping := a.GetModelMapping()
 	if len(mapping) == 0 {
 		return true // 无映射 = 允许所有
@@ -827,13 +876,23 @@ func (a *Account) IsBedrock() bool {
 	return a.Platform == PlatformAnthropic && a.Type == AccountTypeBedrock
 }
 
+// IsVertex 返回是否为 Google Vertex AI 上的 Claude 账号
+func (a *Account) IsVertex() bool {
+	return a.Platform == PlatformAnthropic && a.Type == AccountTypeVertex
+}
+
+// IsOpenAICompatible 返回是否为通用 OpenAI 兼容（Chat Completions）上游账号
+func (a *Account) IsOpenAICompatible() bool {
+	return a.Platform == PlatformAnthropic && a.Type == AccountTypeOpenAICompatible
+}
+
 func (a *Account) IsBedrockAPIKey() bool {
 	return a.IsBedrock() && a.GetCredential("auth_mode") == "apikey"
 }
 
-// IsAPIKeyOrBedrock 返回账号类型是否支持配额和池模式等特性
+// IsAPIKeyOrBedrock 返回账号类型是否支持配额和池模式等特性（含 OpenAI 兼容上游）
 func (a *Account) IsAPIKeyOrBedrock() bool {
-	return a.Type == AccountTypeAPIKey || a.Type == AccountTypeBedrock
+	return a.Type == AccountTypeAPIKey || a.Type == AccountTypeBedrock || a.Type == AccountTypeOpenAICompatible
 }
 
 func (a *Account) IsOpenAI() bool {
@@ -852,10 +911,18 @@ func (a *Account) IsOpenAIApiKey() bool {
 	return a.IsOpenAI() && a.Type == AccountTypeAPIKey
 }
 
+func (a *Account) IsOpenAIAzure() bool {
+	return a.IsOpenAI() && a.Type == AccountTypeAzure
+}
+
 func (a *Account) GetOpenAIBaseURL() string {
 	if !a.IsOpenAI() {
 		return ""
 	}
+	// Azure 资源 endpoint 无默认值（https://{resource}.openai.azure.com）
+	if a.Type == AccountTypeAzure {
+		return a.GetCredential("base_url")
+	}
 	if a.Type == AccountTypeAPIKey {
 		baseURL := a.GetCredential("base_url")
 		if baseURL != "" {
@@ -865,6 +932,48 @@ func (a *Account) GetOpenAIBaseURL() string {
 	return "https://api.openai.com"
 }
 
+// GetAzureOpenAIAPIVersion 返回 Azure OpenAI 的 api-version 查询参数，未配置时使用默认版本
+func (a *Account) GetAzureOpenAIAPIVersion() string {
+	if !a.IsOpenAIAzure() {
+		return ""
+	}
+	if v := strings.TrimSpace(a.GetCredential("api_version")); v != "" {
+		return v
+	}
+	return DefaultAzureOpenAIAPIVersion
+}
+
+// GetAzureOpenAIDeployments 返回 模型 -> 部署名 映射（credentials.deployments，key 支持通配符）
+func (a *Account) GetAzureOpenAIDeployments() map[string]string {
+	if !a.IsOpenAIAzure() || a.Credentials == nil {
+		return nil
+	}
+	raw, _ := a.Credentials["deployments"].(map[string]any)
+	if len(raw) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			result[k] = strings.TrimSpace(s)
+		}
+	}
+	return result
+}
+
+// ResolveAzureOpenAIDeployment 将（已经过 model_mapping 的）模型名解析为部署名。
+// 未配置部署映射或未命中时，按 Azure 惯例直接使用模型名作为部署名。
+func (a *Account) ResolveAzureOpenAIDeployment(model string) (deployment string, matched bool) {
+	deployments := a.GetAzureOpenAIDeployments()
+	if len(deployments) == 0 {
+		return model, false
+	}
+	if deployment, matched := resolveRequestedModelInMapping(deployments, model); matched {
+		return deployment, true
+	}
+	return model, false
+}
+
 func (a *Account) GetOpenAIAccessToken() string {
 	if !a.IsOpenAI() {
 		return ""
@@ -887,7 +996,7 @@ func (a *Account) GetOpenAIIDToken() string {
 }
 
 func (a *Account) GetOpenAIApiKey() string {
-	if !a.IsOpenAIApiKey() {
+	if !a.IsOpenAIApiKey() && !a.IsOpenAIAzure() {
 		return ""
 	}
 	return a.GetCredential("api_key")
@@ -1242,6 +1351,54 @@ func (a *Account) GetTLSFingerprintProfileID() int64 {
 	return 0
 }
 
+// GetProxyPoolID 获取账号绑定的代理池 ID
+// 返回 0 表示未绑定代理池（使用 proxy_id 指定的单个代理）
+func (a *Account) GetProxyPoolID() int64 {
+	if a.Extra == nil {
+		return 0
+	}
+	v, ok := a.Extra["proxy_pool_id"]
+	if !ok {
+		return 0
+	}
+	switch id := v.(type) {
+	case float64:
+		return int64(id)
+	case int64:
+		return id
+	case int:
+		return int64(id)
+	case json.Number:
+		if i, err := id.Int64(); err == nil {
+			return i
+		}
+	}
+	return 0
+}
+
+// ProxyURL 返回账号出站请求使用的代理 URL
+//   - 绑定代理池时返回代理池占位 URL（优先于 proxy_id），由 HTTPUpstream 在池内选择代理并故障转移
+//   - 否则返回 proxy_id 对应代理的 URL
+//   - 均未配置时返回空字符串（直连）
+func (a *Account) ProxyURL() string {
+	if poolURL := a.proxyPoolURL(); poolURL != "" {
+		return poolURL
+	}
+	if a.ProxyID != nil && a.Proxy != nil {
+		return a.Proxy.URL()
+	}
+	return ""
+}
+
+// proxyPoolURL 绑定代理池时返回代理池占位 URL，否则返回空字符串。
+// 仅持有 ProxyID（未加载 Proxy）的调用方应先检查该值，避免代理池账号回退直连。
+func (a *Account) proxyPoolURL() string {
+	if poolID := a.GetProxyPoolID(); poolID > 0 {
+		return proxyurl.PoolURL(poolID, a.ID)
+	}
+	return ""
+}
+
 // GetUserMsgQueueMode 获取用户消息队列模式
 // "serialize" = 串行队列, "throttle" = 软性限速, "" = 未设置（使用全局配置）
 func (a *Account) GetUserMsgQueueMode() string {
@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/claude"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/geminicli"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/openai"
@@ -58,6 +59,7 @@ const (
 type AccountTestService struct {
 	accountRepo               AccountRepository
 	geminiTokenProvider       *GeminiTokenProvider
+	vertexTokenProvider       *VertexTokenProvider
 	antigravityGatewayService *AntigravityGatewayService
 	httpUpstream              HTTPUpstream
 	cfg                       *config.Config
@@ -68,6 +70,7 @@ type AccountTestService struct {
 func NewAccountTestService(
 	accountRepo AccountRepository,
 	geminiTokenProvider *GeminiTokenProvider,
+	vertexTokenProvider *VertexTokenProvider,
 	antigravityGatewayService *AntigravityGatewayService,
 	httpUpstream HTTPUpstream,
 	cfg *config.Config,
@@ -76,6 +79,7 @@ func NewAccountTestService(
 	return &AccountTestService{
 		accountRepo:               accountRepo,
 		geminiTokenProvider:       geminiTokenProvider,
+		vertexTokenProvider:       vertexTokenProvider,
 		antigravityGatewayService: antigravityGatewayService,
 		httpUpstream:              httpUpstream,
 		cfg:                       cfg,
@@ -203,6 +207,12 @@ func (s *AccountTestService) testClaudeAccountConnection(c *gin.Context, account
 	if account.IsBedrock() {
 		return s.testBedrockAccountConnection(c, ctx, account, testModelID)
 	}
+	if account.IsVertex() {
+		return s.testVertexAccountConnection(c, ctx, account, testModelID)
+	}
+	if account.IsOpenAICompatible() {
+		return s.testOpenAICompatibleAccountConnection(c, ctx, account, modelID)
+	}
 
 	// Determine authentication method and API URL
 	var authToken string
@@ -409,6 +419,183 @@ func (s *AccountTestService) testBedrockAccountConnection(c *gin.Context, ctx co
 	return nil
 }
 
+// testVertexAccountConnection tests a Vertex (service account) account using streamRawPredict
+func (s *AccountTestService) testVertexAccountConnection(c *gin.Context, ctx context.Context, account *Account, testModelID string) error {
+	resolvedModelID, ok := ResolveVertexModelID(account, testModelID)
+	if !ok {
+		return s.sendErrorAndEnd(c, fmt.Sprintf("Unsupported Vertex model: %s", testModelID))
+	}
+	testModelID = resolvedModelID
+
+	projectID := vertexProjectID(account)
+	if projectID == "" {
+		return s.sendErrorAndEnd(c, "No Vertex project_id available")
+	}
+	if s.vertexTokenProvider == nil {
+		return s.sendErrorAndEnd(c, "Vertex token provider not configured")
+	}
+	accessToken, err := s.vertexTokenProvider.GetAccessToken(ctx, account)
+	if err != nil {
+		return s.sendErrorAndEnd(c, fmt.Sprintf("Failed to get access token: %s", err.Error()))
+	}
+
+	// Set SSE headers
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+	c.Writer.Flush()
+
+	vertexBody, _ := json.Marshal(map[string]any{
+		"anthropic_version": vertexAnthropicVersion,
+		"messages": []map[string]any{
+			{
+				"role": "user",
+				"content": []map[string]any{
+					{
+						"type": "text",
+						"text": "hi",
+					},
+				},
+			},
+		},
+		"max_tokens": 256,
+		"stream":     true,
+	})
+
+	s.sendEvent(c, TestEvent{Type: "test_start", Model: testModelID})
+
+	apiURL := BuildVertexURL(vertexRegion(account), projectID, testModelID, true)
+	req, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(vertexBody))
+	if err != nil {
+		return s.sendErrorAndEnd(c, "Failed to create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	proxyURL := ""
+	if account.ProxyID != nil && account.Proxy != nil {
+		proxyURL = account.Proxy.URL()
+	}
+
+	resp, err := s.httpUpstream.DoWithTLS(req, proxyURL, account.ID, account.Concurrency, nil)
+	if err != nil {
+		return s.sendErrorAndEnd(c, fmt.Sprintf("Request failed: %s", err.Error()))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusUnauthorized {
+			s.vertexTokenProvider.InvalidateToken(ctx, account)
+		}
+		return s.sendErrorAndEnd(c, fmt.Sprintf("API returned %d: %s", resp.StatusCode, string(body)))
+	}
+
+	// Vertex streamRawPredict 返回标准 Anthropic SSE
+	return s.processClaudeStream(c, resp.Body)
+}
+
+// testOpenAICompatibleAccountConnection tests an OpenAI-compatible account using streaming Chat Completions
+func (s *AccountTestService) testOpenAICompatibleAccountConnection(c *gin.Context, ctx context.Context, account *Account, modelID string) error {
+	// 未指定模型时优先使用账号模型列表中的第一个，避免默认 Claude 模型名无法在第三方上游解析
+	testModelID := modelID
+	if testModelID == "" {
+		if models := account.GetOpenAICompatibleModels(); len(models) > 0 {
+			testModelID = models[0]
+		} else {
+			testModelID = claude.DefaultTestModel
+		}
+	}
+	resolvedModelID, ok := ResolveOpenAICompatibleModel(account, testModelID)
+	if !ok {
+		return s.sendErrorAndEnd(c, fmt.Sprintf("Unsupported model for this account: %s", testModelID))
+	}
+	testModelID = resolvedModelID
+
+	baseURL, err := s.validateUpstreamBaseURL(account.GetCredential("base_url"))
+	if err != nil {
+		return s.sendErrorAndEnd(c, fmt.Sprintf("Invalid base URL: %s", err.Error()))
+	}
+
+	// Set SSE headers
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+	c.Writer.Flush()
+
+	chatBody, _ := json.Marshal(map[string]any{
+		"model":          testModelID,
+		"messages":       []map[string]any{{"role": "user", "content": "hi"}},
+		"max_tokens":     256,
+		"stream":         true,
+		"stream_options": map[string]any{"include_usage": true},
+	})
+
+	s.sendEvent(c, TestEvent{Type: "test_start", Model: testModelID})
+
+	req, err := buildUpstreamRequestOpenAICompatible(ctx, buildOpenAICompatibleChatURL(baseURL), chatBody, account.GetCredential("api_key"), true)
+	if err != nil {
+		return s.sendErrorAndEnd(c, "Failed to create request")
+	}
+
+	proxyURL := ""
+	if account.ProxyID != nil && account.Proxy != nil {
+		proxyURL = account.Proxy.URL()
+	}
+
+	resp, err := s.httpUpstream.DoWithTLS(req, proxyURL, account.ID, account.Concurrency, nil)
+	if err != nil {
+		return s.sendErrorAndEnd(c, fmt.Sprintf("Request failed: %s", err.Error()))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return s.sendErrorAndEnd(c, fmt.Sprintf("API returned %d: %s", resp.StatusCode, string(body)))
+	}
+
+	return s.processChatCompletionsStream(c, resp.Body)
+}
+
+// processChatCompletionsStream processes the SSE stream from a Chat Completions API
+func (s *AccountTestService) processChatCompletionsStream(c *gin.Context, body io.Reader) error {
+	reader := bufio.NewReader(body)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+				return nil
+			}
+			return s.sendErrorAndEnd(c, fmt.Sprintf("Stream read error: %s", err.Error()))
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" || !sseDataPrefix.MatchString(line) {
+			continue
+		}
+
+		jsonStr := sseDataPrefix.ReplaceAllString(line, "")
+		if jsonStr == "[DONE]" {
+			s.sendEvent(c, TestEvent{Type: "test_complete", Success: true})
+			return nil
+		}
+
+		var chunk apicompat.ChatCompletionsChunk
+		if err := json.Unmarshal([]byte(jsonStr), &chunk); err != nil {
+			continue
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != nil && *choice.Delta.Content != "" {
+				s.sendEvent(c, TestEvent{Type: "content", Text: *choice.Delta.Content})
+			}
+		}
+	}
+}
+
 // testOpenAIAccountConnection tests an OpenAI account's connection
 func (s *AccountTestService) testOpenAIAccountConnection(c *gin.Context, account *Account, modelID string) error {
 	ctx := c.Request.Context()
@@ -419,8 +606,8 @@ func (s *AccountTestService) testOpenAIAccountConnection(c *gin.Context, account
 		testModelID = openai.DefaultTestModel
 	}
 
-	// For API Key accounts with model mapping, map the model
-	if account.Type == "apikey" {
+	// For API Key / Azure accounts with model mapping, map the model
+	if account.Type == AccountTypeAPIKey || account.Type == AccountTypeAzure {
 		mapping := account.GetModelMapping()
 		if len(mapping) > 0 {
 			if mappedModel, exists := mapping[testModelID]; exists {
@@ -433,6 +620,7 @@ func (s *AccountTestService) testOpenAIAccountConnection(c *gin.Context, account
 	var authToken string
 	var apiURL string
 	var isOAuth bool
+	var isAzure bool
 	var chatgptAccountID string
 
 	if account.IsOAuth() {
@@ -462,6 +650,19 @@ func (s *AccountTestService) testOpenAIAccountConnection(c *gin.Context, account
 			return s.sendErrorAndEnd(c, fmt.Sprintf("Invalid base URL: %s", err.Error()))
 		}
 		apiURL = strings.TrimSuffix(normalizedBaseURL, "/") + "/responses"
+	} else if account.IsOpenAIAzure() {
+		// Azure - api-key header, resource endpoint + api-version, model is the deployment name
+		isAzure = true
+		authToken = account.GetOpenAIApiKey()
+		if authToken == "" {
+			return s.sendErrorAndEnd(c, "No API key available")
+		}
+		normalizedBaseURL, err := s.validateUpstreamBaseURL(account.GetOpenAIBaseURL())
+		if err != nil {
+			return s.sendErrorAndEnd(c, fmt.Sprintf("Invalid base URL: %s", err.Error()))
+		}
+		apiURL = buildAzureOpenAIResponsesURL(normalizedBaseURL, "", account.GetAzureOpenAIAPIVersion())
+		testModelID, _ = account.ResolveAzureOpenAIDeployment(testModelID)
 	} else {
 		return s.sendErrorAndEnd(c, fmt.Sprintf("Unsupported account type: %s", account.Type))
 	}
@@ -487,7 +688,11 @@ func (s *AccountTestService) testOpenAIAccountConnection(c *gin.Context, account
 
 	// Set common headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+authToken)
+	if isAzure {
+		req.Header.Set("api-key", authToken)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+authToken)
+	}
 
 	// Set OAuth-specific headers for ChatGPT internal API
 	if isOAuth {
@@ -531,6 +736,12 @@ func (s *AccountTestService) testOpenAIAccountConnection(c *gin.Context, account
 				account.RateLimitResetAt = resetAt
 			}
 		}
+		if isAzure && resp.StatusCode == http.StatusTooManyRequests && s.accountRepo != nil {
+			if resetAt := calculateAzure429ResetTime(resp.Header, time.Now()); resetAt != nil {
+				_ = s.accountRepo.SetRateLimited(ctx, account.ID, *resetAt)
+				account.RateLimitResetAt = resetAt
+			}
+		}
 		// 401 Unauthorized: 标记账号为永久错误
 		if resp.StatusCode == http.StatusUnauthorized && s.accountRepo != nil {
 			errMsg := fmt.Sprintf("Authentication failed (401): %s", string(body))
@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/util/logredact"
+)
+
+const (
+	// adminAuditExportMaxRows 单次导出的最大记录数
+	adminAuditExportMaxRows = 10000
+	// adminAuditFlattenMaxDepth 快照差异展开的最大嵌套层级，更深的值整体比较
+	adminAuditFlattenMaxDepth = 4
+	// adminAuditWriteTimeout 审计记录写入超时，避免拖慢管理请求
+	adminAuditWriteTimeout = 3 * time.Second
+)
+
+// adminAuditSensitiveKeys 在 logredact 默认敏感字段之外额外脱敏的字段。
+// 请求体为 snake_case；实体快照由 Go 结构体直接序列化，字段名按小写匹配。
+var adminAuditSensitiveKeys = []string{
+	"api_key", "key", "secret", "secret_key", "private_key", "session_key", "token",
+	"credentials", "new_password", "old_password", "smtp_password", "turnstile_secret_key",
+	"linuxdo_connect_client_secret", "webhook_secret", "totp_secret", "encryption_key",
+	"passwordhash", "totpsecretencrypted", "smtppassword", "turnstilesecretkey", "linuxdoconnectclientsecret",
+}
+
+// AdminAuditChange 单个字段的变更前后值（已脱敏）
+type AdminAuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AdminAuditLog 管理后台写操作审计记录（只追加，不可修改）
+type AdminAuditLog struct {
+	ID           int64
+	ActorUserID  int64
+	ActorEmail   string // 查询时关联 users 表，不落库
+	AuthMethod   string // jwt / admin_api_key / admin_token
+	ActorTokenID int64  // 具名管理员令牌 ID（令牌认证时）
+	Method       string
+	Route        string // 路由模板，如 /api/v1/admin/users/:id/balance
+	Path         string
+	ResourceType string
+	ResourceID   string
+	TargetIDs    map[string]any
+	RequestBody  json.RawMessage
+	Changes      map[string]AdminAuditChange
+	StatusCode   int
+	Success      bool
+	ErrorReason  string
+	ErrorMessage string
+	ClientIP     string
+	UserAgent    string
+	DurationMs   int64
+	CreatedAt    time.Time
+}
+
+// AdminAuditLogFilters 审计日志查询条件
+type AdminAuditLogFilters struct {
+	ActorUserID  int64
+	AuthMethod   string
+	ActorTokenID int64
+	Method       string
+	ResourceType string
+	ResourceID   string
+	Success      *bool
+	StartTime    *time.Time
+	EndTime      *time.Time
+	Search       string // 匹配 path / route
+}
+
+// AdminAuditLogRepository 审计日志数据访问接口（不提供更新与删除）
+type AdminAuditLogRepository interface {
+	Create(ctx context.Context, log *AdminAuditLog) error
+	List(ctx context.Context, params pagination.PaginationParams, filters AdminAuditLogFilters) ([]AdminAuditLog, *pagination.PaginationResult, error)
+	ListForExport(ctx context.Context, filters AdminAuditLogFilters, limit int) ([]AdminAuditLog, error)
+}
+
+// adminAuditSnapshotFunc 读取实体当前状态，用于生成变更前后差异
+type adminAuditSnapshotFunc func(ctx context.Context, resourceID string) (any, error)
+
+// AdminAuditService 管理后台审计日志服务
+type AdminAuditService struct {
+	repo         AdminAuditLogRepository
+	cfg          *config.Config
+	snapshotters map[string]adminAuditSnapshotFunc
+}
+
+// NewAdminAuditService 创建审计日志服务
+func NewAdminAuditService(repo AdminAuditLogRepository, adminService AdminService, settingService *SettingService, cfg *config.Config) *AdminAuditService {
+	s := &AdminAuditService{
+		repo:         repo,
+		cfg:          cfg,
+		snapshotters: map[string]adminAuditSnapshotFunc{},
+	}
+	// 余额、凭证、代理、定价、系统设置等关键实体记录变更前后差异
+	if adminService != nil {
+		s.snapshotters["users"] = func(ctx context.Context, id string) (any, error) {
+			return snapshotByInt64ID(ctx, id, adminService.GetUser)
+		}
+		s.snapshotters["groups"] = func(ctx context.Context, id string) (any, error) {
+			return snapshotByInt64ID(ctx, id, adminService.GetGroup)
+		}
+		s.snapshotters["accounts"] = func(ctx context.Context, id string) (any, error) {
+			return snapshotByInt64ID(ctx, id, adminService.GetAccount)
+		}
+		s.snapshotters["proxies"] = func(ctx context.Context, id string) (any, error) {
+			return snapshotByInt64ID(ctx, id, adminService.GetProxy)
+		}
+	}
+	if settingService != nil {
+		s.snapshotters["settings"] = func(ctx context.Context, _ string) (any, error) {
+			return settingService.GetAllSettings(ctx)
+		}
+	}
+	return s
+}
+
+func snapshotByInt64ID[T any](ctx context.Context, id string, get func(context.Context, int64) (T, error)) (any, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+	return get(ctx, n)
+}
+
+// Enabled 是否启用审计记录
+func (s *AdminAuditService) Enabled() bool {
+	return s != nil && s.repo != nil && (s.cfg == nil || s.cfg.AdminAudit.Enabled)
+}
+
+// MaxBodyBytes 请求体记录上限
+func (s *AdminAuditService) MaxBodyBytes() int {
+	if s == nil || s.cfg == nil || s.cfg.AdminAudit.MaxBodyBytes <= 0 {
+		return 16 * 1024
+	}
+	return s.cfg.AdminAudit.MaxBodyBytes
+}
+
+// Snapshot 读取实体当前状态（转换为通用 JSON 结构）；不支持的实体或读取失败时返回 nil
+func (s *AdminAuditService) Snapshot(ctx context.Context, resourceType, resourceID string) map[string]any {
+	if s == nil {
+		return nil
+	}
+	fn, ok := s.snapshotters[resourceType]
+	if !ok {
+		return nil
+	}
+	entity, err := fn(ctx, resourceID)
+	if err != nil || entity == nil {
+		return nil
+	}
+	if v := reflect.ValueOf(entity); v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil
+	}
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return nil
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// Record 写入一条审计记录（尽力而为：失败仅记录日志，不影响管理请求）
+func (s *AdminAuditService) Record(ctx context.Context, entry *AdminAuditLog) {
+	if !s.Enabled() || entry == nil {
+		return
+	}
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), adminAuditWriteTimeout)
+	defer cancel()
+	if err := s.repo.Create(writeCtx, entry); err != nil {
+		logger.LegacyPrintf("service.admin_audit", "[AdminAudit] write failed: method=%s path=%s actor=%d err=%v",
+			entry.Method, entry.Path, entry.ActorUserID, err)
+	}
+}
+
+// List 分页查询审计日志
+func (s *AdminAuditService) List(ctx context.Context, params pagination.PaginationParams, filters AdminAuditLogFilters) ([]AdminAuditLog, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, params, filters)
+}
+
+// Export 按条件导出审计日志（最多 adminAuditExportMaxRows 条，按时间倒序）
+func (s *AdminAuditService) Export(ctx context.Context, filters AdminAuditLogFilters) ([]AdminAuditLog, error) {
+	return s.repo.ListForExport(ctx, filters, adminAuditExportMaxRows)
+}
+
+// RedactAdminAuditBody 脱敏请求体；非 JSON 或超出上限时仅记录描述信息
+func RedactAdminAuditBody(contentType string, raw []byte, truncated bool) json.RawMessage {
+	if len(raw) == 0 {
+		return nil
+	}
+	if truncated {
+		return mustMarshalAuditJSON(map[string]any{"truncated": true, "content_type": contentType})
+	}
+	if !strings.Contains(strings.ToLower(contentType), "json") || !json.Valid(raw) {
+		return mustMarshalAuditJSON(map[string]any{"redacted": true, "content_type": contentType, "size": len(raw)})
+	}
+	return json.RawMessage(logredact.RedactJSON(raw, adminAuditSensitiveKeys...))
+}
+
+// ExtractAdminAuditTargetIDs 从 JSON 请求体顶层提取目标实体 ID（id / ids / *_id / *_ids）
+func ExtractAdminAuditTargetIDs(raw []byte) map[string]any {
+	var body map[string]any
+	if len(raw) == 0 || json.Unmarshal(raw, &body) != nil {
+		return nil
+	}
+	out := map[string]any{}
+	for k, v := range body {
+		key := strings.ToLower(k)
+		if key != "id" && key != "ids" && !strings.HasSuffix(key, "_id") && !strings.HasSuffix(key, "_ids") {
+			continue
+		}
+		if isAuditIDValue(v) {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func isAuditIDValue(v any) bool {
+	switch t := v.(type) {
+	case float64, string:
+		return true
+	case []any:
+		for _, item := range t {
+			switch item.(type) {
+			case float64, string:
+			default:
+				return false
+			}
+		}
+		return len(t) > 0
+	default:
+		return false
+	}
+}
+
+// DiffAdminAuditSnapshots 对比实体快照，返回已脱敏的字段级差异（key 为点分路径）。
+// 敏感字段发生变化时前后值均记为 "***"，仅保留"已变更"这一事实。
+func DiffAdminAuditSnapshots(before, after map[string]any) map[string]AdminAuditChange {
+	if before == nil && after == nil {
+		return nil
+	}
+	rawBefore := flattenAuditSnapshot(before)
+	rawAfter := flattenAuditSnapshot(after)
+	redBefore := flattenAuditSnapshot(redactAuditSnapshot(before))
+	redAfter := flattenAuditSnapshot(redactAuditSnapshot(after))
+
+	changes := map[string]AdminAuditChange{}
+	collect := func(key string) {
+		if _, done := changes[key]; done {
+			return
+		}
+		b, hasB := rawBefore[key]
+		a, hasA := rawAfter[key]
+		if hasA == hasB && reflect.DeepEqual(a, b) {
+			return
+		}
+		changes[key] = AdminAuditChange{
+			Before: redactedAuditValue(redBefore, key, hasB),
+			After:  redactedAuditValue(redAfter, key, hasA),
+		}
+	}
+	for k := range rawBefore {
+		collect(k)
+	}
+	for k := range rawAfter {
+		collect(k)
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes
+}
+
+func redactAuditSnapshot(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	return logredact.RedactMap(m, adminAuditSensitiveKeys...)
+}
+
+// redactedAuditValue 取脱敏后的值；原始存在但脱敏结果中缺失说明其祖先字段已被整体脱敏
+func redactedAuditValue(redacted map[string]any, key string, present bool) any {
+	if !present {
+		return nil
+	}
+	if v, ok := redacted[key]; ok {
+		return v
+	}
+	return "***"
+}
+
+func flattenAuditSnapshot(m map[string]any) map[string]any {
+	out := map[string]any{}
+	flattenAuditValue("", m, 0, out)
+	return out
+}
+
+func flattenAuditValue(prefix string, value any, depth int, out map[string]any) {
+	nested, ok := value.(map[string]any)
+	if !ok || depth >= adminAuditFlattenMaxDepth || (len(nested) == 0 && prefix != "") {
+		if prefix != "" {
+			out[prefix] = value
+		}
+		return
+	}
+	for k, v := range nested {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		flattenAuditValue(key, v, depth+1, out)
+	}
+}
+
+func mustMarshalAuditJSON(v any) json.RawMessage {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffAdminAuditSnapshots(t *testing.T) {
+	before := map[string]any{
+		"Balance":     float64(10),
+		"Status":      "active",
+		"Credentials": map[string]any{"api_key": "sk-old", "base_url": "https://a"},
+		"Extra":       map[string]any{"note": "x"},
+	}
+	after := map[string]any{
+		"Balance":     float64(25),
+		"Status":      "active",
+		"Credentials": map[string]any{"api_key": "sk-new", "base_url": "https://a"},
+		"Extra":       map[string]any{"note": "x", "tier": "pro"},
+	}
+
+	changes := DiffAdminAuditSnapshots(before, after)
+	require.Equal(t, AdminAuditChange{Before: float64(10), After: float64(25)}, changes["Balance"])
+	require.Equal(t, AdminAuditChange{Before: "***", After: "***"}, changes["Credentials.api_key"])
+	require.Equal(t, AdminAuditChange{Before: nil, After: "pro"}, changes["Extra.tier"])
+	require.NotContains(t, changes, "Status")
+	require.NotContains(t, changes, "Credentials.base_url")
+	require.Len(t, changes, 3)
+
+	require.Nil(t, DiffAdminAuditSnapshots(before, before))
+}
+
+func TestAdminAuditSnapshotAndDeleteDiff(t *testing.T) {
+	user := &User{ID: 3, Email: "u@example.com", Balance: 1.5, PasswordHash: "hash"}
+	svc := &AdminAuditService{snapshotters: map[string]adminAuditSnapshotFunc{
+		"users": func(ctx context.Context, id string) (any, error) {
+			return snapshotByInt64ID(ctx, id, func(context.Context, int64) (*User, error) { return user, nil })
+		},
+	}}
+
+	require.Nil(t, svc.Snapshot(context.Background(), "users", "not-a-number"))
+	require.Nil(t, svc.Snapshot(context.Background(), "groups", "3"))
+
+	snap := svc.Snapshot(context.Background(), "users", "3")
+	require.Equal(t, 1.5, snap["Balance"])
+
+	// 删除后无 after 快照：记录被删除实体的全部字段，敏感字段仍需脱敏
+	changes := DiffAdminAuditSnapshots(snap, nil)
+	require.Equal(t, AdminAuditChange{Before: "u@example.com", After: nil}, changes["Email"])
+	require.Equal(t, AdminAuditChange{Before: "***", After: nil}, changes["PasswordHash"])
+}
+
+func TestRedactAdminAuditBody(t *testing.T) {
+	body := RedactAdminAuditBody("application/json", []byte(`{"email":"a@b.c","password":"x","credentials":{"api_key":"k"}}`), false)
+	require.JSONEq(t, `{"email":"a@b.c","password":"***","credentials":"***"}`, string(body))
+
+	body = RedactAdminAuditBody("multipart/form-data; boundary=x", []byte("--x"), false)
+	require.JSONEq(t, `{"redacted":true,"content_type":"multipart/form-data; boundary=x","size":3}`, string(body))
+
+	require.Nil(t, RedactAdminAuditBody("application/json", nil, false))
+}
@@ -156,6 +156,12 @@ type CreateGroupInput struct {
 	DefaultMappedModel    string
 	RequireOAuthOnly      bool
 	RequirePrivacySet     bool
+	LocalTokenCounting    bool
+	// Batch API 折扣倍率：nil/负数 表示使用配置默认值
+	BatchDiscountMultiplier *float64
+	ResponseCacheEnabled    bool
+	// 余额购买订阅价格：nil/负数 表示不开放购买
+	SubscriptionPrice *float64
 	// 从指定分组复制账号（创建分组后在同一事务内绑定）
 	CopyAccountsFromGroupIDs []int64
 }
@@ -190,6 +196,12 @@ type UpdateGroupInput struct {
 	DefaultMappedModel    *string
 	RequireOAuthOnly      *bool
 	RequirePrivacySet     *bool
+	LocalTokenCounting    *bool
+	// Batch API 折扣倍率：nil 表示不修改，负数表示清除（使用配置默认值）
+	BatchDiscountMultiplier *float64
+	ResponseCacheEnabled    *bool
+	// 余额购买订阅价格：nil 表示不修改，负数表示关闭购买
+	SubscriptionPrice *float64
 	// 从指定分组复制账号（同步操作：先清空当前分组的账号绑定，再绑定源分组的账号）
 	CopyAccountsFromGroupIDs []int64
 }
@@ -829,6 +841,11 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 	imagePrice2K := normalizePrice(input.ImagePrice2K)
 	imagePrice4K := normalizePrice(input.ImagePrice4K)
 
+	batchDiscount, err := normalizeBatchDiscountMultiplier(input.BatchDiscountMultiplier)
+	if err != nil {
+		return nil, err
+	}
+
 	// 校验降级分组
 	if input.FallbackGroupID != nil {
 		if err := s.validateFallbackGroup(ctx, 0, *input.FallbackGroupID); err != nil {
@@ -908,6 +925,10 @@ func (s *adminServiceImpl) CreateGroup(ctx context.Context, input *CreateGroupIn
 		RequireOAuthOnly:                input.RequireOAuthOnly,
 		RequirePrivacySet:               input.RequirePrivacySet,
 		DefaultMappedModel:              input.DefaultMappedModel,
+		LocalTokenCounting:              input.LocalTokenCounting,
+		BatchDiscountMultiplier:         batchDiscount,
+		ResponseCacheEnabled:            input.ResponseCacheEnabled,
+		SubscriptionPrice:               normalizePrice(input.SubscriptionPrice),
 	}
 	if err := s.groupRepo.Create(ctx, group); err != nil {
 		return nil, err
@@ -961,6 +982,17 @@ func normalizePrice(price *float64) *float64 {
 	return price
 }
 
+// normalizeBatchDiscountMultiplier 负数转换为 nil（使用配置默认值），有效范围 (0, 1]
+func normalizeBatchDiscountMultiplier(v *float64) (*float64, error) {
+	if v == nil || *v < 0 {
+		return nil, nil
+	}
+	if *v == 0 || *v > 1 {
+		return nil, infraerrors.BadRequest("INVALID_BATCH_DISCOUNT", "batch_discount_multiplier must be within (0, 1]")
+	}
+	return v, nil
+}
+
 // validateFallbackGroup 校验降级分组的有效性
 // currentGroupID: 当前分组 ID（新建时为 0）
 // fallbackGroupID: 降级分组 ID
@@ -1135,6 +1167,22 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 	if input.DefaultMappedModel != nil {
 		group.DefaultMappedModel = *input.DefaultMappedModel
 	}
+	if input.LocalTokenCounting != nil {
+		group.LocalTokenCounting = *input.LocalTokenCounting
+	}
+	if input.BatchDiscountMultiplier != nil {
+		batchDiscount, err := normalizeBatchDiscountMultiplier(input.BatchDiscountMultiplier)
+		if err != nil {
+			return nil, err
+		}
+		group.BatchDiscountMultiplier = batchDiscount
+	}
+	if input.ResponseCacheEnabled != nil {
+		group.ResponseCacheEnabled = *input.ResponseCacheEnabled
+	}
+	if input.SubscriptionPrice != nil {
+		group.SubscriptionPrice = normalizePrice(input.SubscriptionPrice)
+	}
 
 	if err := s.groupRepo.Update(ctx, group); err != nil {
 		return nil, err
@@ -1217,7 +1265,7 @@ func (s *adminServiceImpl) UpdateGroup(ctx context.Context, id int64, input *Upd
 func (s *adminServiceImpl) DeleteGroup(ctx context.Context, id int64) error {
 	var groupKeys []string
 	if s.authCacheInvalidator != nil {
-		keys, err := s.apiKeyRepo.ListKeysByGroupID(ctx, id)
+		keys, err := s.apiKeyRepo.ListKeyHashesByGroupID(ctx, id)
 		if err == nil {
 			groupKeys = keys
 		}
@@ -1244,7 +1292,7 @@ func (s *adminServiceImpl) DeleteGroup(ctx context.Context, id int64) error {
 	}
 	if s.authCacheInvalidator != nil {
 		for _, key := range groupKeys {
-			s.authCacheInvalidator.InvalidateAuthCacheByKey(ctx, key)
+			s.authCacheInvalidator.InvalidateAuthCacheByKeyHash(ctx, key)
 		}
 	}
 
@@ -1368,7 +1416,7 @@ func (s *adminServiceImpl) AdminUpdateAPIKeyGroupID(ctx context.Context, keyID i
 
 			// 失效认证缓存（在事务提交后执行）
 			if s.authCacheInvalidator != nil {
-				s.authCacheInvalidator.InvalidateAuthCacheByKey(ctx, apiKey.Key)
+				s.authCacheInvalidator.InvalidateAuthCacheByKeyHash(ctx, apiKey.KeyHash)
 			}
 
 			result.APIKey = apiKey
@@ -1383,7 +1431,7 @@ func (s *adminServiceImpl) AdminUpdateAPIKeyGroupID(ctx context.Context, keyID i
 
 	// 失效认证缓存
 	if s.authCacheInvalidator != nil {
-		s.authCacheInvalidator.InvalidateAuthCacheByKey(ctx, apiKey.Key)
+		s.authCacheInvalidator.InvalidateAuthCacheByKeyHash(ctx, apiKey.KeyHash)
 	}
 
 	result.APIKey = apiKey
@@ -1444,10 +1492,10 @@ func (s *adminServiceImpl) ReplaceUserGroup(ctx context.Context, userID, oldGrou
 
 	// 失效该用户所有 Key 的认证缓存
 	if s.authCacheInvalidator != nil {
-		keys, keyErr := s.apiKeyRepo.ListKeysByUserID(ctx, userID)
+		keys, keyErr := s.apiKeyRepo.ListKeyHashesByUserID(ctx, userID)
 		if keyErr == nil {
 			for _, k := range keys {
-				s.authCacheInvalidator.InvalidateAuthCacheByKey(ctx, k)
+				s.authCacheInvalidator.InvalidateAuthCacheByKeyHash(ctx, k)
 			}
 		}
 	}
@@ -1510,6 +1558,22 @@ func (s *adminServiceImpl) CreateAccount(ctx context.Context, input *CreateAccou
 		}
 	}
 
+	if input.Type == AccountTypeAzure {
+		if err := ValidateAzureOpenAICredentials(input.Platform, input.Credentials); err != nil {
+			return nil, err
+		}
+	}
+	if input.Type == AccountTypeVertex {
+		if err := ValidateVertexCredentials(input.Platform, input.Credentials); err != nil {
+			return nil, err
+		}
+	}
+	if input.Type == AccountTypeOpenAICompatible {
+		if err := ValidateOpenAICompatibleCredentials(input.Platform, input.Credentials); err != nil {
+			return nil, err
+		}
+	}
+
 	account := &Account{
 		Name:        input.Name,
 		Notes:       normalizeAccountNotes(input.Notes),
@@ -1609,6 +1673,21 @@ func (s *adminServiceImpl) UpdateAccount(ctx context.Context, id int64, input *U
 	if len(input.Credentials) > 0 {
 		account.Credentials = input.Credentials
 	}
+	if account.Type == AccountTypeAzure && (input.Type != "" || len(input.Credentials) > 0) {
+		if err := ValidateAzureOpenAICredentials(account.Platform, account.Credentials); err != nil {
+			return nil, err
+		}
+	}
+	if account.Type == AccountTypeVertex && (input.Type != "" || len(input.Credentials) > 0) {
+		if err := ValidateVertexCredentials(account.Platform, account.Credentials); err != nil {
+			return nil, err
+		}
+	}
+	if account.Type == AccountTypeOpenAICompatible && (input.Type != "" || len(input.Credentials) > 0) {
+		if err := ValidateOpenAICompatibleCredentials(account.Platform, account.Credentials); err != nil {
+			return nil, err
+		}
+	}
 	// Extra 使用 map：需要区分“未提供(nil)”与“显式清空({})”。
 	// 关闭配额限制时前端会删除 quota_* 键并提交 extra:{}，此时也必须落库。
 	if input.Extra != nil {
@@ -2576,32 +2655,7 @@ func (s *adminServiceImpl) attachProxyLatency(ctx context.Context, proxies []Pro
 }
 
 func (s *adminServiceImpl) saveProxyLatency(ctx context.Context, proxyID int64, info *ProxyLatencyInfo) {
-	if s.proxyLatencyCache == nil || info == nil {
-		return
-	}
-
-	merged := *info
-	if latencies, err := s.proxyLatencyCache.GetProxyLatencies(ctx, []int64{proxyID}); err == nil {
-		if existing := latencies[proxyID]; existing != nil {
-			if merged.QualityCheckedAt == nil &&
-				merged.QualityScore == nil &&
-				merged.QualityGrade == "" &&
-				merged.QualityStatus == "" &&
-				merged.QualitySummary == "" &&
-				merged.QualityCFRay == "" {
-				merged.QualityStatus = existing.QualityStatus
-				merged.QualityScore = existing.QualityScore
-				merged.QualityGrade = existing.QualityGrade
-				merged.QualitySummary = existing.QualitySummary
-				merged.QualityCheckedAt = existing.QualityCheckedAt
-				merged.QualityCFRay = existing.QualityCFRay
-			}
-		}
-	}
-
-	if err := s.proxyLatencyCache.SetProxyLatency(ctx, proxyID, &merged); err != nil {
-		logger.LegacyPrintf("service.admin", "Warning: store proxy latency cache failed: %v", err)
-	}
+	saveProxyLatencyToCache(ctx, s.proxyLatencyCache, proxyID, info)
 }
 
 // getAccountPlatform 根据账号 platform 判断混合渠道检查用的平台标识
@@ -2651,8 +2705,8 @@ func (s *adminServiceImpl) EnsureOpenAIPrivacy(ctx context.Context, account *Acc
 		return ""
 	}
 
-	var proxyURL string
-	if account.ProxyID != nil {
+	proxyURL := account.proxyPoolURL()
+	if proxyURL == "" && account.ProxyID != nil {
 		if p, err := s.proxyRepo.GetByID(ctx, *account.ProxyID); err == nil && p != nil {
 			proxyURL = p.URL()
 		}
@@ -2681,8 +2735,8 @@ func (s *adminServiceImpl) ForceOpenAIPrivacy(ctx context.Context, account *Acco
 		return ""
 	}
 
-	var proxyURL string
-	if account.ProxyID != nil {
+	proxyURL := account.proxyPoolURL()
+	if proxyURL == "" && account.ProxyID != nil {
 		if p, err := s.proxyRepo.GetByID(ctx, *account.ProxyID); err == nil && p != nil {
 			proxyURL = p.URL()
 		}
@@ -2724,8 +2778,8 @@ func (s *adminServiceImpl) EnsureAntigravityPrivacy(ctx context.Context, account
 
 	projectID, _ := account.Credentials["project_id"].(string)
 
-	var proxyURL string
-	if account.ProxyID != nil {
+	proxyURL := account.proxyPoolURL()
+	if proxyURL == "" && account.ProxyID != nil {
 		if p, err := s.proxyRepo.GetByID(ctx, *account.ProxyID); err == nil && p != nil {
 			proxyURL = p.URL()
 		}
@@ -2757,8 +2811,8 @@ func (s *adminServiceImpl) ForceAntigravityPrivacy(ctx context.Context, account
 
 	projectID, _ := account.Credentials["project_id"].(string)
 
-	var proxyURL string
-	if account.ProxyID != nil {
+	proxyURL := account.proxyPoolURL()
+	if proxyURL == "" && account.ProxyID != nil {
 		if p, err := s.proxyRepo.GetByID(ctx, *account.ProxyID); err == nil && p != nil {
 			proxyURL = p.URL()
 		}
@@ -78,6 +78,10 @@ func (s *userRepoStub) DeductBalance(ctx context.Context, id int64, amount float
 	panic("unexpected DeductBalance call")
 }
 
+func (s *userRepoStub) DeductBalanceIfSufficient(ctx context.Context, id int64, amount float64) (bool, error) {
+	panic("unexpected DeductBalanceIfSufficient call")
+}
+
 func (s *userRepoStub) UpdateConcurrency(ctx context.Context, id int64, amount int) error {
 	panic("unexpected UpdateConcurrency call")
 }
@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+)
+
+// 管理员令牌作用域资源，对应管理后台路由分组。
+// 作用域格式为 "<resource>:read" / "<resource>:write"，write 隐含 read。
+const (
+	AdminScopeDashboard     = "dashboard"
+	AdminScopeUsers         = "users"
+	AdminScopeGroups        = "groups"
+	AdminScopeAccounts      = "accounts"
+	AdminScopeProxies       = "proxies"
+	AdminScopeRedeem        = "redeem"
+	AdminScopeAnnouncements = "announcements"
+	AdminScopeSettings      = "settings"
+	AdminScopeChannels      = "channels"
+	AdminScopeSubscriptions = "subscriptions"
+	AdminScopeUsage         = "usage"
+	AdminScopeAPIKeys       = "api_keys"
+	AdminScopePayments      = "payments"
+	AdminScopeOps           = "ops"
+	AdminScopeAudit         = "audit"
+	AdminScopeSystem        = "system"
+)
+
+const (
+	adminScopeRead  = "read"
+	adminScopeWrite = "write"
+
+	// adminTokenTouchInterval last_used_at 更新节流间隔
+	adminTokenTouchInterval = time.Minute
+	adminTokenMaxNameLen    = 100
+)
+
+var adminScopeResources = []string{
+	AdminScopeDashboard, AdminScopeUsers, AdminScopeGroups, AdminScopeAccounts, AdminScopeProxies,
+	AdminScopeRedeem, AdminScopeAnnouncements, AdminScopeSettings, AdminScopeChannels, AdminScopeSubscriptions,
+	AdminScopeUsage, AdminScopeAPIKeys, AdminScopePayments, AdminScopeOps, AdminScopeAudit, AdminScopeSystem,
+}
+
+var (
+	ErrAdminTokenNotFound     = infraerrors.NotFound("ADMIN_TOKEN_NOT_FOUND", "admin token not found")
+	ErrAdminTokenInvalid      = infraerrors.Unauthorized("INVALID_ADMIN_TOKEN", "invalid admin token")
+	ErrAdminTokenExpired      = infraerrors.Unauthorized("ADMIN_TOKEN_EXPIRED", "admin token has expired")
+	ErrAdminTokenRevoked      = infraerrors.Unauthorized("ADMIN_TOKEN_REVOKED", "admin token has been revoked")
+	ErrAdminTokenScopeDenied  = infraerrors.Forbidden("ADMIN_TOKEN_SCOPE_DENIED", "admin token does not have the required scope")
+	ErrAdminTokenNameRequired = infraerrors.BadRequest("ADMIN_TOKEN_NAME_REQUIRED", "admin token name is required")
+	ErrAdminTokenScopeInvalid = infraerrors.BadRequest("ADMIN_TOKEN_SCOPE_INVALID", "invalid admin token scope")
+	ErrAdminTokenExpiryPassed = infraerrors.BadRequest("ADMIN_TOKEN_EXPIRY_INVALID", "expires_at must be in the future")
+)
+
+// AdminToken 具名管理员令牌：仅保存哈希，明文只在创建时返回一次
+type AdminToken struct {
+	ID          int64
+	Name        string
+	TokenHash   string
+	TokenPrefix string // 明文前若干位，用于列表中识别
+	Scopes      []string
+	CreatedBy   int64 // 创建者（令牌以其身份执行操作）
+	ExpiresAt   *time.Time
+	LastUsedAt  *time.Time
+	LastUsedIP  string
+	RevokedAt   *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// IsRevoked 是否已吊销
+func (t *AdminToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+// IsExpired 是否已过期
+func (t *AdminToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// HasScope 判断令牌是否允许访问资源；write 作用域隐含 read
+func (t *AdminToken) HasScope(resource string, write bool) bool {
+	return AdminScopesAllow(t.Scopes, resource, write)
+}
+
+// AdminScopesAllow 判断作用域列表是否允许访问资源
+func AdminScopesAllow(scopes []string, resource string, write bool) bool {
+	if slices.Contains(scopes, resource+":"+adminScopeWrite) {
+		return true
+	}
+	return !write && slices.Contains(scopes, resource+":"+adminScopeRead)
+}
+
+// AdminTokenScopeCatalog 返回全部可用作用域
+func AdminTokenScopeCatalog() []string {
+	out := make([]string, 0, len(adminScopeResources)*2)
+	for _, r := range adminScopeResources {
+		out = append(out, r+":"+adminScopeRead, r+":"+adminScopeWrite)
+	}
+	return out
+}
+
+// normalizeAdminTokenScopes 校验并去重作用域
+func normalizeAdminTokenScopes(scopes []string) ([]string, error) {
+	valid := AdminTokenScopeCatalog()
+	out := make([]string, 0, len(scopes))
+	for _, s := range scopes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" {
+			continue
+		}
+		if !slices.Contains(valid, s) {
+			return nil, infraerrors.BadRequest("ADMIN_TOKEN_SCOPE_INVALID", fmt.Sprintf("invalid admin token scope: %s", s))
+		}
+		if !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrAdminTokenScopeInvalid
+	}
+	slices.Sort(out)
+	return out, nil
+}
+
+func hashAdminToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// AdminTokenRepository 管理员令牌数据访问接口
+type AdminTokenRepository interface {
+	Create(ctx context.Context, token *AdminToken) error
+	GetByID(ctx context.Context, id int64) (*AdminToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*AdminToken, error)
+	List(ctx context.Context) ([]AdminToken, error)
+	Revoke(ctx context.Context, id int64, revokedAt time.Time) error
+	TouchLastUsed(ctx context.Context, id int64, usedAt time.Time, ip string) error
+}
+
+// CreateAdminTokenInput 创建管理员令牌参数
+type CreateAdminTokenInput struct {
+	Name      string
+	Scopes    []string
+	ExpiresAt *time.Time
+	CreatedBy int64
+}
+
+// AdminTokenService 具名管理员令牌服务
+type AdminTokenService struct {
+	repo AdminTokenRepository
+}
+
+// NewAdminTokenService 创建管理员令牌服务
+func NewAdminTokenService(repo AdminTokenRepository) *AdminTokenService {
+	return &AdminTokenService{repo: repo}
+}
+
+// Create 创建令牌，返回令牌记录与明文（明文仅此一次可见）
+func (s *AdminTokenService) Create(ctx context.Context, in *CreateAdminTokenInput) (*AdminToken, string, error) {
+	name := strings.TrimSpace(in.Name)
+	if name == "" {
+		return nil, "", ErrAdminTokenNameRequired
+	}
+	if len(name) > adminTokenMaxNameLen {
+		return nil, "", infraerrors.BadRequest("ADMIN_TOKEN_NAME_TOO_LONG", "admin token name is too long")
+	}
+	scopes, err := normalizeAdminTokenScopes(in.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if in.ExpiresAt != nil && !in.ExpiresAt.After(time.Now()) {
+		return nil, "", ErrAdminTokenExpiryPassed
+	}
+
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, "", fmt.Errorf("generate random bytes: %w", err)
+	}
+	raw := AdminTokenPrefix + hex.EncodeToString(buf)
+
+	token := &AdminToken{
+		Name:        name,
+		TokenHash:   hashAdminToken(raw),
+		TokenPrefix: raw[:len(AdminTokenPrefix)+6],
+		Scopes:      scopes,
+		CreatedBy:   in.CreatedBy,
+		ExpiresAt:   in.ExpiresAt,
+	}
+	if err := s.repo.Create(ctx, token); err != nil {
+		return nil, "", err
+	}
+	return token, raw, nil
+}
+
+// List 列出全部令牌（含已吊销/已过期）
+func (s *AdminTokenService) List(ctx context.Context) ([]AdminToken, error) {
+	return s.repo.List(ctx)
+}
+
+// Revoke 吊销令牌（软删除，保留记录供审计追溯）
+func (s *AdminTokenService) Revoke(ctx context.Context, id int64) (*AdminToken, error) {
+	token, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if token.IsRevoked() {
+		return token, nil
+	}
+	now := time.Now()
+	if err := s.repo.Revoke(ctx, id, now); err != nil {
+		return nil, err
+	}
+	token.RevokedAt = &now
+	return token, nil
+}
+
+// Authenticate 校验令牌明文，返回有效的令牌记录，并节流更新最近使用信息
+func (s *AdminTokenService) Authenticate(ctx context.Context, raw, clientIP string) (*AdminToken, error) {
+	if s == nil || !strings.HasPrefix(raw, AdminTokenPrefix) {
+		return nil, ErrAdminTokenInvalid
+	}
+	token, err := s.repo.GetByHash(ctx, hashAdminToken(raw))
+	if err != nil {
+		if infraerrors.IsNotFound(err) {
+			return nil, ErrAdminTokenInvalid
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if token.IsRevoked() {
+		return nil, ErrAdminTokenRevoked
+	}
+	if token.IsExpired(now) {
+		return nil, ErrAdminTokenExpired
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= adminTokenTouchInterval || token.LastUsedIP != clientIP {
+		if err := s.repo.TouchLastUsed(ctx, token.ID, now, clientIP); err != nil {
+			logger.LegacyPrintf("service.admin_token", "[AdminToken] update last used failed: token_id=%d err=%v", token.ID, err)
+		} else {
+			token.LastUsedAt = &now
+			token.LastUsedIP = clientIP
+		}
+	}
+	return token, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type adminTokenRepoStub struct {
+	tokens  map[int64]*AdminToken
+	nextID  int64
+	touches int
+}
+
+func newAdminTokenRepoStub() *adminTokenRepoStub {
+	return &adminTokenRepoStub{tokens: map[int64]*AdminToken{}}
+}
+
+func (r *adminTokenRepoStub) Create(_ context.Context, t *AdminToken) error {
+	r.nextID++
+	t.ID = r.nextID
+	t.CreatedAt = time.Now()
+	clone := *t
+	r.tokens[t.ID] = &clone
+	return nil
+}
+
+func (r *adminTokenRepoStub) GetByID(_ context.Context, id int64) (*AdminToken, error) {
+	t, ok := r.tokens[id]
+	if !ok {
+		return nil, ErrAdminTokenNotFound
+	}
+	clone := *t
+	return &clone, nil
+}
+
+func (r *adminTokenRepoStub) GetByHash(_ context.Context, hash string) (*AdminToken, error) {
+	for _, t := range r.tokens {
+		if t.TokenHash == hash {
+			clone := *t
+			return &clone, nil
+		}
+	}
+	return nil, ErrAdminTokenNotFound
+}
+
+func (r *adminTokenRepoStub) List(context.Context) ([]AdminToken, error) {
+	out := make([]AdminToken, 0, len(r.tokens))
+	for _, t := range r.tokens {
+		out = append(out, *t)
+	}
+	return out, nil
+}
+
+func (r *adminTokenRepoStub) Revoke(_ context.Context, id int64, at time.Time) error {
+	t, ok := r.tokens[id]
+	if !ok || t.RevokedAt != nil {
+		return ErrAdminTokenNotFound
+	}
+	t.RevokedAt = &at
+	return nil
+}
+
+func (r *adminTokenRepoStub) TouchLastUsed(_ context.Context, id int64, at time.Time, ip string) error {
+	r.touches++
+	r.tokens[id].LastUsedAt = &at
+	r.tokens[id].LastUsedIP = ip
+	return nil
+}
+
+func TestAdminTokenCreateValidatesScopes(t *testing.T) {
+	svc := NewAdminTokenService(newAdminTokenRepoStub())
+	ctx := context.Background()
+
+	_, _, err := svc.Create(ctx, &CreateAdminTokenInput{Name: "bot", Scopes: []string{"usage:admin"}, CreatedBy: 1})
+	require.True(t, infraerrors.IsBadRequest(err))
+
+	_, _, err = svc.Create(ctx, &CreateAdminTokenInput{Name: "bot", Scopes: nil, CreatedBy: 1})
+	require.True(t, infraerrors.IsBadRequest(err))
+
+	past := time.Now().Add(-time.Hour)
+	_, _, err = svc.Create(ctx, &CreateAdminTokenInput{Name: "bot", Scopes: []string{"usage:read"}, ExpiresAt: &past, CreatedBy: 1})
+	require.ErrorIs(t, err, ErrAdminTokenExpiryPassed)
+
+	token, raw, err := svc.Create(ctx, &CreateAdminTokenInput{
+		Name:      " billing bot ",
+		Scopes:    []string{"Usage:Read", "usage:read", "accounts:write"},
+		CreatedBy: 1,
+	})
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(raw, AdminTokenPrefix))
+	require.Equal(t, "billing bot", token.Name)
+	require.Equal(t, []string{"accounts:write", "usage:read"}, token.Scopes)
+	require.True(t, strings.HasPrefix(raw, token.TokenPrefix))
+	require.NotContains(t, token.TokenHash, raw)
+}
+
+func TestAdminTokenAuthenticate(t *testing.T) {
+	repo := newAdminTokenRepoStub()
+	svc := NewAdminTokenService(repo)
+	ctx := context.Background()
+
+	token, raw, err := svc.Create(ctx, &CreateAdminTokenInput{Name: "bot", Scopes: []string{"ops:read"}, CreatedBy: 1})
+	require.NoError(t, err)
+
+	got, err := svc.Authenticate(ctx, raw, "10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, token.ID, got.ID)
+	require.Equal(t, 1, repo.touches)
+
+	// 节流：同 IP 一分钟内不重复写入
+	_, err = svc.Authenticate(ctx, raw, "10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, 1, repo.touches)
+
+	_, err = svc.Authenticate(ctx, raw+"x", "10.0.0.1")
+	require.ErrorIs(t, err, ErrAdminTokenInvalid)
+	_, err = svc.Authenticate(ctx, "admin-deadbeef", "10.0.0.1")
+	require.ErrorIs(t, err, ErrAdminTokenInvalid)
+
+	expired := time.Now().Add(-time.Minute)
+	repo.tokens[token.ID].ExpiresAt = &expired
+	_, err = svc.Authenticate(ctx, raw, "10.0.0.1")
+	require.ErrorIs(t, err, ErrAdminTokenExpired)
+
+	repo.tokens[token.ID].ExpiresAt = nil
+	_, err = svc.Revoke(ctx, token.ID)
+	require.NoError(t, err)
+	_, err = svc.Authenticate(ctx, raw, "10.0.0.1")
+	require.ErrorIs(t, err, ErrAdminTokenRevoked)
+
+	// 重复吊销幂等
+	revoked, err := svc.Revoke(ctx, token.ID)
+	require.NoError(t, err)
+	require.True(t, revoked.IsRevoked())
+}
+
+func TestAdminScopesAllow(t *testing.T) {
+	scopes := []string{"usage:read", "accounts:write"}
+	require.True(t, AdminScopesAllow(scopes, AdminScopeUsage, false))
+	require.False(t, AdminScopesAllow(scopes, AdminScopeUsage, true))
+	require.True(t, AdminScopesAllow(scopes, AdminScopeAccounts, false))
+	require.True(t, AdminScopesAllow(scopes, AdminScopeAccounts, true))
+	require.False(t, AdminScopesAllow(scopes, AdminScopeUsers, false))
+	require.False(t, AdminScopesAllow(nil, AdminScopeUsage, false))
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"strings"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ip"
@@ -14,6 +15,9 @@ const (
 	StatusAPIKeyExpired        = "expired"
 )
 
+// maxModelPatternLength 单条模型模式的最大长度
+const maxModelPatternLength = 128
+
 // Rate limit window durations
 const (
 	RateLimitWindow5h = 5 * time.Hour
@@ -30,7 +34,9 @@ func IsWindowExpired(windowStart *time.Time, duration time.Duration) bool {
 type APIKey struct {
 	ID          int64
 	UserID      int64
-	Key         string
+	Key         string // 完整明文 Key，仅在创建时及认证路径中可用；从数据库读取时为空
+	KeyHash     string // HMAC-SHA256(api_key_hash.secret, Key)，用于查找与认证缓存
+	KeyPrefix   string // Key 的前几位，用于展示识别
 	Name        string
 	GroupID     *int64
 	Status      string
@@ -45,6 +51,10 @@ type APIKey struct {
 	User                *User
 	Group               *Group
 
+	// Model restriction fields (supports * wildcard; DeniedModels wins over AllowedModels)
+	AllowedModels []string // Allowed model patterns (empty = all models)
+	DeniedModels  []string // Denied model patterns
+
 	// Quota fields
 	Quota     float64    // Quota limit in USD (0 = unlimited)
 	QuotaUsed float64    // Used quota amount
@@ -60,6 +70,10 @@ type APIKey struct {
 	Window5hStart *time.Time // Start of current 5h window
 	Window1dStart *time.Time // Start of current 1d window
 	Window7dStart *time.Time // Start of current 7d window
+
+	// Request rate limit fields (sliding 60s window, counters in Redis)
+	RPMLimit int // Requests per minute (0 = unlimited)
+	TPMLimit int // Tokens per minute (0 = unlimited)
 }
 
 func (k *APIKey) IsActive() bool {
@@ -71,6 +85,11 @@ func (k *APIKey) HasRateLimits() bool {
 	return k.RateLimit5h > 0 || k.RateLimit1d > 0 || k.RateLimit7d > 0
 }
 
+// HasRequestRateLimits returns true if an RPM or TPM limit is configured
+func (k *APIKey) HasRequestRateLimits() bool {
+	return k.RPMLimit > 0 || k.TPMLimit > 0
+}
+
 // IsExpired checks if the API key has expired
 func (k *APIKey) IsExpired() bool {
 	if k.ExpiresAt == nil {
@@ -111,6 +130,95 @@ func (k *APIKey) GetDaysUntilExpiry() int {
 	return int(duration.Hours() / 24)
 }
 
+// HasModelRestrictions returns true if any model allowlist/denylist pattern is configured
+func (k *APIKey) HasModelRestrictions() bool {
+	return len(k.AllowedModels) > 0 || len(k.DeniedModels) > 0
+}
+
+// IsModelAllowed checks the requested model against the key's model patterns.
+// Denylist wins over allowlist; an empty allowlist allows every model not denied.
+func (k *APIKey) IsModelAllowed(model string) bool {
+	if k == nil || !k.HasModelRestrictions() {
+		return true
+	}
+	model = normalizeModelForRestriction(model)
+	for _, pattern := range k.DeniedModels {
+		if matchModelGlob(pattern, model) {
+			return false
+		}
+	}
+	if len(k.AllowedModels) == 0 {
+		return true
+	}
+	for _, pattern := range k.AllowedModels {
+		if matchModelGlob(pattern, model) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeModelForRestriction 去掉 Gemini 风格的 "models/" 前缀并统一小写
+func normalizeModelForRestriction(model string) string {
+	model = strings.TrimSpace(model)
+	model = strings.TrimPrefix(model, "models/")
+	return strings.ToLower(model)
+}
+
+// matchModelGlob 大小写不敏感的通配符匹配，* 可出现在任意位置并匹配任意长度字符
+func matchModelGlob(pattern, model string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return false
+	}
+	if !strings.Contains(pattern, "*") {
+		return pattern == model
+	}
+
+	parts := strings.Split(pattern, "*")
+	// 首段必须是前缀，末段必须是后缀，中间段按顺序出现
+	if !strings.HasPrefix(model, parts[0]) {
+		return false
+	}
+	rest := model[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+		rest = rest[idx+len(part):]
+	}
+	return strings.HasSuffix(rest, last)
+}
+
+// ValidateModelPatterns 规范化模型模式（去空白、去重），返回非法模式列表
+func ValidateModelPatterns(patterns []string) ([]string, []string) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	seen := make(map[string]struct{}, len(patterns))
+	normalized := make([]string, 0, len(patterns))
+	var invalid []string
+	for _, raw := range patterns {
+		p := strings.TrimSpace(raw)
+		if p == "" {
+			continue
+		}
+		if len(p) > maxModelPatternLength || strings.ContainsAny(p, " \t\r\n") {
+			invalid = append(invalid, raw)
+			continue
+		}
+		key := strings.ToLower(p)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		normalized = append(normalized, p)
+	}
+	return normalized, invalid
+}
+
 // EffectiveUsage5h returns the 5h window usage, or 0 if the window has expired.
 func (k *APIKey) EffectiveUsage5h() float64 {
 	if IsWindowExpired(k.Window5hStart, RateLimitWindow5h) {
@@ -4,14 +4,16 @@ import "time"
 
 // APIKeyAuthSnapshot API Key 认证缓存快照（仅包含认证所需字段）
 type APIKeyAuthSnapshot struct {
-	APIKeyID    int64                    `json:"api_key_id"`
-	UserID      int64                    `json:"user_id"`
-	GroupID     *int64                   `json:"group_id,omitempty"`
-	Status      string                   `json:"status"`
-	IPWhitelist []string                 `json:"ip_whitelist,omitempty"`
-	IPBlacklist []string                 `json:"ip_blacklist,omitempty"`
-	User        APIKeyAuthUserSnapshot   `json:"user"`
-	Group       *APIKeyAuthGroupSnapshot `json:"group,omitempty"`
+	APIKeyID      int64                    `json:"api_key_id"`
+	UserID        int64                    `json:"user_id"`
+	GroupID       *int64                   `json:"group_id,omitempty"`
+	Status        string                   `json:"status"`
+	IPWhitelist   []string                 `json:"ip_whitelist,omitempty"`
+	IPBlacklist   []string                 `json:"ip_blacklist,omitempty"`
+	AllowedModels []string                 `json:"allowed_models,omitempty"`
+	DeniedModels  []string                 `json:"denied_models,omitempty"`
+	User          APIKeyAuthUserSnapshot   `json:"user"`
+	Group         *APIKeyAuthGroupSnapshot `json:"group,omitempty"`
 
 	// Quota fields for API Key independent quota feature
 	Quota     float64 `json:"quota"`      // Quota limit in USD (0 = unlimited)
@@ -24,6 +26,10 @@ type APIKeyAuthSnapshot struct {
 	RateLimit5h float64 `json:"rate_limit_5h"`
 	RateLimit1d float64 `json:"rate_limit_1d"`
 	RateLimit7d float64 `json:"rate_limit_7d"`
+
+	// Request rate limits (sliding 60s window, counters in Redis)
+	RPMLimit int `json:"rpm_limit"`
+	TPMLimit int `json:"tpm_limit"`
 }
 
 // APIKeyAuthUserSnapshot 用户快照
@@ -65,6 +71,10 @@ type APIKeyAuthGroupSnapshot struct {
 	// OpenAI Messages 调度配置（仅 openai 平台使用）
 	AllowMessagesDispatch bool   `json:"allow_messages_dispatch"`
 	DefaultMappedModel    string `json:"default_mapped_model,omitempty"`
+	LocalTokenCounting    bool   `json:"local_token_counting,omitempty"`
+
+	BatchDiscountMultiplier *float64 `json:"batch_discount_multiplier,omitempty"`
+	ResponseCacheEnabled    bool     `json:"response_cache_enabled,omitempty"`
 }
 
 // APIKeyAuthCacheEntry 缓存条目，支持负缓存
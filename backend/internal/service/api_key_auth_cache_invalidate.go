@@ -2,13 +2,12 @@ package service
 
 import "context"
 
-// InvalidateAuthCacheByKey 清除指定 API Key 的认证缓存
-func (s *APIKeyService) InvalidateAuthCacheByKey(ctx context.Context, key string) {
-	if key == "" {
+// InvalidateAuthCacheByKeyHash 清除指定 API Key（按 Key 哈希）的认证缓存
+func (s *APIKeyService) InvalidateAuthCacheByKeyHash(ctx context.Context, keyHash string) {
+	if keyHash == "" {
 		return
 	}
-	cacheKey := s.authCacheKey(key)
-	s.deleteAuthCache(ctx, cacheKey)
+	s.deleteAuthCache(ctx, keyHash)
 }
 
 // InvalidateAuthCacheByUserID 清除用户相关的 API Key 认证缓存
@@ -16,11 +15,11 @@ func (s *APIKeyService) InvalidateAuthCacheByUserID(ctx context.Context, userID
 	if userID <= 0 {
 		return
 	}
-	keys, err := s.apiKeyRepo.ListKeysByUserID(ctx, userID)
+	keyHashes, err := s.apiKeyRepo.ListKeyHashesByUserID(ctx, userID)
 	if err != nil {
 		return
 	}
-	s.deleteAuthCacheByKeys(ctx, keys)
+	s.deleteAuthCacheByKeyHashes(ctx, keyHashes)
 }
 
 // InvalidateAuthCacheByGroupID 清除分组相关的 API Key 认证缓存
@@ -28,21 +27,21 @@ func (s *APIKeyService) InvalidateAuthCacheByGroupID(ctx context.Context, groupI
 	if groupID <= 0 {
 		return
 	}
-	keys, err := s.apiKeyRepo.ListKeysByGroupID(ctx, groupID)
+	keyHashes, err := s.apiKeyRepo.ListKeyHashesByGroupID(ctx, groupID)
 	if err != nil {
 		return
 	}
-	s.deleteAuthCacheByKeys(ctx, keys)
+	s.deleteAuthCacheByKeyHashes(ctx, keyHashes)
 }
 
-func (s *APIKeyService) deleteAuthCacheByKeys(ctx context.Context, keys []string) {
-	if len(keys) == 0 {
+func (s *APIKeyService) deleteAuthCacheByKeyHashes(ctx context.Context, keyHashes []string) {
+	if len(keyHashes) == 0 {
 		return
 	}
-	for _, key := range keys {
-		if key == "" {
+	for _, keyHash := range keyHashes {
+		if keyHash == "" {
 			continue
 		}
-		s.deleteAuthCache(ctx, s.authCacheKey(key))
+		s.deleteAuthCache(ctx, keyHash)
 	}
 }
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// apiKeyDisplayPrefixMaxLen 展示前缀的最大长度，默认格式 "sk-" + 64 位 hex 时为 "sk-" + 8 位
+const apiKeyDisplayPrefixMaxLen = 11
+
+// HashAPIKey 计算 API Key 的存储哈希（HMAC-SHA256，十六进制）。
+// 数据库与认证缓存仅保存该值，完整 Key 只在创建时返回一次。
+func HashAPIKey(secret, key string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// APIKeyDisplayPrefix 返回用于列表展示与搜索的 Key 前缀。
+// 长度不超过 apiKeyDisplayPrefixMaxLen，且最多为 Key 长度的四分之一，避免短自定义 Key 暴露过多。
+func APIKeyDisplayPrefix(key string) string {
+	n := len(key) / 4
+	if n > apiKeyDisplayPrefixMaxLen {
+		n = apiKeyDisplayPrefixMaxLen
+	}
+	return key[:n]
+}
+
+// APIKeyPrefixSearchTerm 将 Key 搜索词映射为前缀搜索词。
+// 明文 Key 不再落库，搜索只能匹配展示前缀；搜索词长到足以是完整 Key（≥16 位）时取其展示前缀，
+// 这样粘贴完整 Key 也能找到对应记录。
+func APIKeyPrefixSearchTerm(search string) string {
+	if len(search) >= 16 {
+		return APIKeyDisplayPrefix(search)
+	}
+	return search
+}
+
+// hashKey 使用配置的 api_key_hash.secret 计算 Key 哈希
+func (s *APIKeyService) hashKey(key string) string {
+	secret := ""
+	if s.cfg != nil {
+		secret = s.cfg.APIKeyHash.Secret
+	}
+	return HashAPIKey(secret, key)
+}
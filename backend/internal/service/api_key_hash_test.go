@@ -0,0 +1,28 @@
+//go:build unit
+
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashAPIKey_DependsOnSecret(t *testing.T) {
+	h1 := HashAPIKey("secret-a", "sk-test")
+	require.Len(t, h1, 64)
+	require.Equal(t, h1, HashAPIKey("secret-a", "sk-test"))
+	require.NotEqual(t, h1, HashAPIKey("secret-b", "sk-test"), "不同密钥应产生不同哈希")
+	require.NotEqual(t, h1, HashAPIKey("secret-a", "sk-test2"))
+}
+
+func TestAPIKeyDisplayPrefix(t *testing.T) {
+	require.Equal(t, "sk-0123abcd", APIKeyDisplayPrefix("sk-0123abcd4567ef890123abcd4567ef890123abcd4567ef890123abcd4567ef89"))
+	require.Equal(t, "sk_cu", APIKeyDisplayPrefix("sk_custom_1234567890"), "短 Key 最多展示四分之一")
+	require.Equal(t, "", APIKeyDisplayPrefix("abc"))
+}
+
+func TestAPIKeyPrefixSearchTerm(t *testing.T) {
+	require.Equal(t, "sk-01", APIKeyPrefixSearchTerm("sk-01"), "短搜索词按原样匹配前缀")
+	require.Equal(t, "sk_cu", APIKeyPrefixSearchTerm("sk_custom_1234567890"), "完整 Key 映射为展示前缀")
+}
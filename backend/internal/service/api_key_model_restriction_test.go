@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchModelGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		model   string
+		want    bool
+	}{
+		{"claude-sonnet-4-5", "claude-sonnet-4-5", true},
+		{"claude-sonnet-*", "claude-sonnet-4-5-20250929", true},
+		{"claude-sonnet-*", "claude-opus-4-5", false},
+		{"gpt-*-mini", "gpt-5.4-mini", true},
+		{"gpt-*-mini", "gpt-5.4-mini-high", false},
+		{"gpt-*-mini", "gpt-mini", false},
+		{"*-opus-*", "claude-opus-4-5", true},
+		{"*", "anything", true},
+		{"Claude-Sonnet-*", "claude-sonnet-4", true},
+		{"", "claude-sonnet-4", false},
+		{"a*b*c", "abc", true},
+		{"a*b*c", "aXbYc", true},
+		{"a*b*c", "aXcYb", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.model, func(t *testing.T) {
+			require.Equal(t, tt.want, matchModelGlob(tt.pattern, normalizeModelForRestriction(tt.model)))
+		})
+	}
+}
+
+func TestAPIKeyIsModelAllowed(t *testing.T) {
+	t.Run("no restrictions", func(t *testing.T) {
+		k := &APIKey{}
+		require.True(t, k.IsModelAllowed("claude-opus-4-5"))
+	})
+
+	t.Run("allowlist only", func(t *testing.T) {
+		k := &APIKey{AllowedModels: []string{"claude-sonnet-*", "gpt-*-mini"}}
+		require.True(t, k.IsModelAllowed("claude-sonnet-4-5"))
+		require.True(t, k.IsModelAllowed("gpt-5.4-mini"))
+		require.False(t, k.IsModelAllowed("claude-opus-4-5"))
+		require.False(t, k.IsModelAllowed("gpt-5.4"))
+	})
+
+	t.Run("denylist wins over allowlist", func(t *testing.T) {
+		k := &APIKey{
+			AllowedModels: []string{"claude-*"},
+			DeniedModels:  []string{"claude-opus-*"},
+		}
+		require.True(t, k.IsModelAllowed("claude-sonnet-4-5"))
+		require.False(t, k.IsModelAllowed("claude-opus-4-5"))
+	})
+
+	t.Run("denylist only", func(t *testing.T) {
+		k := &APIKey{DeniedModels: []string{"*-opus-*"}}
+		require.True(t, k.IsModelAllowed("claude-sonnet-4-5"))
+		require.False(t, k.IsModelAllowed("claude-opus-4-5"))
+	})
+
+	t.Run("gemini models/ prefix is ignored", func(t *testing.T) {
+		k := &APIKey{AllowedModels: []string{"gemini-2.5-*"}}
+		require.True(t, k.IsModelAllowed("models/gemini-2.5-pro"))
+		require.False(t, k.IsModelAllowed("models/gemini-3-pro-preview"))
+	})
+}
+
+func TestValidateModelPatterns(t *testing.T) {
+	normalized, invalid := ValidateModelPatterns([]string{" claude-* ", "", "CLAUDE-*", "gpt-*-mini", "bad pattern"})
+	require.Equal(t, []string{"claude-*", "gpt-*-mini"}, normalized)
+	require.Equal(t, []string{"bad pattern"}, invalid)
+
+	normalized, invalid = ValidateModelPatterns(nil)
+	require.Nil(t, normalized)
+	require.Nil(t, invalid)
+}
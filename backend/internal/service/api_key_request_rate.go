@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+)
+
+// APIKeyRequestRateWindow 请求速率滑动窗口
+//
+// 采用"两个固定分钟窗口加权"的滑动窗口近似算法：
+//
+//	estimate = prev * (1 - elapsed/60s) + cur
+//
+// 其中 cur 为当前分钟计数，prev 为上一分钟计数，elapsed 为当前分钟已过去的时长。
+type APIKeyRequestRateWindow struct {
+	CurRequests  int64
+	PrevRequests int64
+	CurTokens    int64
+	PrevTokens   int64
+	Elapsed      time.Duration // 当前固定窗口已过去的时长（Redis 服务端时间）
+}
+
+// APIKeyRequestRateWindowSize 滑动窗口长度
+const APIKeyRequestRateWindowSize = time.Minute
+
+// Request rate limit kinds
+const (
+	RequestRateKindRequests = "requests"
+	RequestRateKindTokens   = "tokens"
+)
+
+// APIKeyRequestRateCache API Key RPM/TPM 滑动窗口计数器（Redis 实现见 repository 层）
+type APIKeyRequestRateCache interface {
+	// AcquireRequestRate 原子地检查 RPM/TPM 限制，未超限时计入一次请求。
+	// limit 为 0 表示不限制；返回的窗口为检查（及计数）后的状态。
+	AcquireRequestRate(ctx context.Context, apiKeyID int64, rpmLimit, tpmLimit int) (allowed bool, window APIKeyRequestRateWindow, err error)
+	// AddRequestRateTokens 将本次请求实际消耗的 token 计入当前窗口
+	AddRequestRateTokens(ctx context.Context, apiKeyID int64, tokens int64) error
+	// GetRequestRate 读取当前窗口状态（不计数）
+	GetRequestRate(ctx context.Context, apiKeyID int64) (APIKeyRequestRateWindow, error)
+}
+
+// APIKeyRequestRateStatus 单个维度（请求数或 token 数）的限流状态
+type APIKeyRequestRateStatus struct {
+	Kind      string        // requests / tokens
+	Limit     int           // 每分钟上限
+	Used      int           // 滑动窗口内估算用量
+	Remaining int           // 剩余额度
+	ResetIn   time.Duration // 额度恢复到可用所需时长（未超限时为 0）
+}
+
+// APIKeyRequestRateLimitError RPM/TPM 超限错误，携带生成 429 响应头所需的信息
+type APIKeyRequestRateLimitError struct {
+	Kind       string
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+func (e *APIKeyRequestRateLimitError) Error() string {
+	unit := "requests"
+	if e.Kind == RequestRateKindTokens {
+		unit = "tokens"
+	}
+	return fmt.Sprintf("Rate limit reached for this API key: %d %s per minute. Please retry after %ds.", e.Limit, unit, int(e.RetryAfterSeconds()))
+}
+
+// RetryAfterSeconds 返回向上取整的重试等待秒数（至少 1 秒）
+func (e *APIKeyRequestRateLimitError) RetryAfterSeconds() int64 {
+	secs := int64(math.Ceil(e.RetryAfter.Seconds()))
+	if secs < 1 {
+		secs = 1
+	}
+	return secs
+}
+
+// EstimatedRequests 滑动窗口内的估算请求数
+func (w APIKeyRequestRateWindow) EstimatedRequests() float64 {
+	return slidingWindowEstimate(w.PrevRequests, w.CurRequests, w.Elapsed)
+}
+
+// EstimatedTokens 滑动窗口内的估算 token 数
+func (w APIKeyRequestRateWindow) EstimatedTokens() float64 {
+	return slidingWindowEstimate(w.PrevTokens, w.CurTokens, w.Elapsed)
+}
+
+func slidingWindowEstimate(prev, cur int64, elapsed time.Duration) float64 {
+	weight := 1 - float64(clampElapsed(elapsed))/float64(APIKeyRequestRateWindowSize)
+	return float64(prev)*weight + float64(cur)
+}
+
+func clampElapsed(elapsed time.Duration) time.Duration {
+	if elapsed < 0 {
+		return 0
+	}
+	if elapsed > APIKeyRequestRateWindowSize {
+		return APIKeyRequestRateWindowSize
+	}
+	return elapsed
+}
+
+// slidingWindowRetryAfter 计算估算值降到 limit-1 以下（即可再放行一次）所需的等待时长。
+// 需要等待跨窗口时，当前窗口计数会滚动为 prev 并继续按权重衰减。
+func slidingWindowRetryAfter(prev, cur int64, elapsed time.Duration, limit int) time.Duration {
+	if limit <= 0 {
+		return 0
+	}
+	window := float64(APIKeyRequestRateWindowSize)
+	e := float64(clampElapsed(elapsed))
+	budget := float64(limit - 1)
+
+	if float64(cur) <= budget {
+		if prev <= 0 || slidingWindowEstimate(prev, cur, elapsed) <= budget {
+			return 0
+		}
+		// prev*(W-e-x)/W + cur <= budget
+		x := window - e - (budget-float64(cur))*window/float64(prev)
+		return time.Duration(math.Max(0, x))
+	}
+
+	// 当前窗口本身已超限：等到窗口结束，再等 cur（变为 prev）衰减
+	x := window - budget*window/float64(cur)
+	return time.Duration(window - e + math.Max(0, x))
+}
+
+// requestRateStatuses 根据窗口状态计算各维度的限流状态
+func requestRateStatuses(apiKey *APIKey, w APIKeyRequestRateWindow) []APIKeyRequestRateStatus {
+	var out []APIKeyRequestRateStatus
+	if apiKey.RPMLimit > 0 {
+		out = append(out, buildRequestRateStatus(RequestRateKindRequests, apiKey.RPMLimit, w.PrevRequests, w.CurRequests, w.Elapsed))
+	}
+	if apiKey.TPMLimit > 0 {
+		out = append(out, buildRequestRateStatus(RequestRateKindTokens, apiKey.TPMLimit, w.PrevTokens, w.CurTokens, w.Elapsed))
+	}
+	return out
+}
+
+func buildRequestRateStatus(kind string, limit int, prev, cur int64, elapsed time.Duration) APIKeyRequestRateStatus {
+	used := int(math.Ceil(slidingWindowEstimate(prev, cur, elapsed)))
+	return APIKeyRequestRateStatus{
+		Kind:      kind,
+		Limit:     limit,
+		Used:      used,
+		Remaining: max(0, limit-used),
+		ResetIn:   slidingWindowRetryAfter(prev, cur, elapsed, limit),
+	}
+}
+
+// SetRequestRateCache sets the optional RPM/TPM counter cache.
+// Called after construction (e.g. in wire); nil disables request rate limiting.
+func (s *APIKeyService) SetRequestRateCache(cache APIKeyRequestRateCache) {
+	s.requestRateCache = cache
+}
+
+// AcquireRequestRate 检查并计入一次请求的 RPM/TPM 用量。
+// 超限时返回 *APIKeyRequestRateLimitError；Redis 异常时放行（fail-open），避免缓存故障阻断网关。
+func (s *APIKeyService) AcquireRequestRate(ctx context.Context, apiKey *APIKey) error {
+	if s.requestRateCache == nil || apiKey == nil || !apiKey.HasRequestRateLimits() {
+		return nil
+	}
+	allowed, w, err := s.requestRateCache.AcquireRequestRate(ctx, apiKey.ID, apiKey.RPMLimit, apiKey.TPMLimit)
+	if err != nil {
+		logger.LegacyPrintf("service.api_key", "Warning: acquire request rate failed for api key %d: %v", apiKey.ID, err)
+		return nil
+	}
+	if allowed {
+		return nil
+	}
+
+	// 优先报告请求数超限，其次 token 超限
+	if apiKey.RPMLimit > 0 && w.EstimatedRequests()+1 > float64(apiKey.RPMLimit) {
+		return newRequestRateLimitError(RequestRateKindRequests, apiKey.RPMLimit, w.PrevRequests, w.CurRequests, w.Elapsed)
+	}
+	if apiKey.TPMLimit > 0 && w.EstimatedTokens() >= float64(apiKey.TPMLimit) {
+		return newRequestRateLimitError(RequestRateKindTokens, apiKey.TPMLimit, w.PrevTokens, w.CurTokens, w.Elapsed)
+	}
+	// Redis 与本地浮点计算存在边界误差时，仍以 Redis 判定为准
+	if apiKey.RPMLimit > 0 {
+		return newRequestRateLimitError(RequestRateKindRequests, apiKey.RPMLimit, w.PrevRequests, w.CurRequests, w.Elapsed)
+	}
+	return newRequestRateLimitError(RequestRateKindTokens, apiKey.TPMLimit, w.PrevTokens, w.CurTokens, w.Elapsed)
+}
+
+func newRequestRateLimitError(kind string, limit int, prev, cur int64, elapsed time.Duration) *APIKeyRequestRateLimitError {
+	st := buildRequestRateStatus(kind, limit, prev, cur, elapsed)
+	return &APIKeyRequestRateLimitError{
+		Kind:       kind,
+		Limit:      limit,
+		Remaining:  st.Remaining,
+		RetryAfter: st.ResetIn,
+	}
+}
+
+// RecordRequestRateTokens 将请求实际消耗的 token 计入 TPM 窗口（仅配置了 TPM 的 Key）
+func (s *APIKeyService) RecordRequestRateTokens(ctx context.Context, apiKey *APIKey, tokens int) {
+	if s.requestRateCache == nil || apiKey == nil || apiKey.TPMLimit <= 0 || tokens <= 0 {
+		return
+	}
+	if err := s.requestRateCache.AddRequestRateTokens(ctx, apiKey.ID, int64(tokens)); err != nil {
+		logger.LegacyPrintf("service.api_key", "Warning: record request rate tokens failed for api key %d: %v", apiKey.ID, err)
+	}
+}
+
+// GetRequestRateStatus 返回 API Key 当前的 RPM/TPM 状态（用于 /v1/usage）
+func (s *APIKeyService) GetRequestRateStatus(ctx context.Context, apiKey *APIKey) ([]APIKeyRequestRateStatus, error) {
+	if apiKey == nil || !apiKey.HasRequestRateLimits() {
+		return nil, nil
+	}
+	var w APIKeyRequestRateWindow
+	if s.requestRateCache != nil {
+		var err error
+		w, err = s.requestRateCache.GetRequestRate(ctx, apiKey.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get request rate: %w", err)
+		}
+	}
+	return requestRateStatuses(apiKey, w), nil
+}
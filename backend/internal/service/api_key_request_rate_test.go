@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type requestRateCacheStub struct {
+	allowed  bool
+	window   APIKeyRequestRateWindow
+	err      error
+	acquired int
+	tokens   int64
+}
+
+func (s *requestRateCacheStub) AcquireRequestRate(_ context.Context, _ int64, _, _ int) (bool, APIKeyRequestRateWindow, error) {
+	s.acquired++
+	return s.allowed, s.window, s.err
+}
+
+func (s *requestRateCacheStub) AddRequestRateTokens(_ context.Context, _ int64, tokens int64) error {
+	s.tokens += tokens
+	return s.err
+}
+
+func (s *requestRateCacheStub) GetRequestRate(_ context.Context, _ int64) (APIKeyRequestRateWindow, error) {
+	return s.window, s.err
+}
+
+func TestSlidingWindowEstimate(t *testing.T) {
+	require.InDelta(t, 10, slidingWindowEstimate(0, 10, 30*time.Second), 1e-9)
+	require.InDelta(t, 15, slidingWindowEstimate(10, 10, 30*time.Second), 1e-9)
+	require.InDelta(t, 20, slidingWindowEstimate(10, 10, 0), 1e-9)
+	require.InDelta(t, 10, slidingWindowEstimate(10, 10, 2*time.Minute), 1e-9)
+}
+
+func TestSlidingWindowRetryAfter(t *testing.T) {
+	// 未超限
+	require.Zero(t, slidingWindowRetryAfter(0, 5, 10*time.Second, 10))
+
+	// prev 衰减即可放行：10*(60-e-x)/60 + 5 <= 9 → x = 60-30-24 = 6s
+	require.Equal(t, 6*time.Second, slidingWindowRetryAfter(10, 5, 30*time.Second, 10))
+
+	// 当前窗口已满：等窗口结束（20s）后 cur 作为 prev 衰减到 9 → 60-9*60/10 = 6s
+	require.Equal(t, 26*time.Second, slidingWindowRetryAfter(0, 10, 40*time.Second, 10))
+
+	require.Zero(t, slidingWindowRetryAfter(100, 100, 0, 0))
+}
+
+func TestAPIKeyService_AcquireRequestRate(t *testing.T) {
+	apiKey := &APIKey{ID: 1, RPMLimit: 10}
+
+	t.Run("no limits skips cache", func(t *testing.T) {
+		stub := &requestRateCacheStub{}
+		svc := &APIKeyService{requestRateCache: stub}
+		require.NoError(t, svc.AcquireRequestRate(context.Background(), &APIKey{ID: 1}))
+		require.Zero(t, stub.acquired)
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		svc := &APIKeyService{requestRateCache: &requestRateCacheStub{allowed: true}}
+		require.NoError(t, svc.AcquireRequestRate(context.Background(), apiKey))
+	})
+
+	t.Run("fail open on cache error", func(t *testing.T) {
+		svc := &APIKeyService{requestRateCache: &requestRateCacheStub{err: errors.New("redis down")}}
+		require.NoError(t, svc.AcquireRequestRate(context.Background(), apiKey))
+	})
+
+	t.Run("rpm exceeded", func(t *testing.T) {
+		svc := &APIKeyService{requestRateCache: &requestRateCacheStub{
+			window: APIKeyRequestRateWindow{CurRequests: 10, Elapsed: 40 * time.Second},
+		}}
+		err := svc.AcquireRequestRate(context.Background(), apiKey)
+		var limitErr *APIKeyRequestRateLimitError
+		require.ErrorAs(t, err, &limitErr)
+		require.Equal(t, RequestRateKindRequests, limitErr.Kind)
+		require.Equal(t, 10, limitErr.Limit)
+		require.Zero(t, limitErr.Remaining)
+		require.Equal(t, int64(26), limitErr.RetryAfterSeconds())
+	})
+
+	t.Run("tpm exceeded", func(t *testing.T) {
+		svc := &APIKeyService{requestRateCache: &requestRateCacheStub{
+			window: APIKeyRequestRateWindow{CurRequests: 2, CurTokens: 1500, Elapsed: 30 * time.Second},
+		}}
+		err := svc.AcquireRequestRate(context.Background(), &APIKey{ID: 1, RPMLimit: 10, TPMLimit: 1000})
+		var limitErr *APIKeyRequestRateLimitError
+		require.ErrorAs(t, err, &limitErr)
+		require.Equal(t, RequestRateKindTokens, limitErr.Kind)
+		require.Equal(t, 1000, limitErr.Limit)
+	})
+}
+
+func TestAPIKeyService_RecordRequestRateTokens(t *testing.T) {
+	stub := &requestRateCacheStub{}
+	svc := &APIKeyService{requestRateCache: stub}
+
+	svc.RecordRequestRateTokens(context.Background(), &APIKey{ID: 1, RPMLimit: 10}, 100)
+	require.Zero(t, stub.tokens, "keys without TPM limit are not tracked")
+
+	svc.RecordRequestRateTokens(context.Background(), &APIKey{ID: 1, TPMLimit: 1000}, 100)
+	require.Equal(t, int64(100), stub.tokens)
+}
+
+func TestAPIKeyService_GetRequestRateStatus(t *testing.T) {
+	svc := &APIKeyService{requestRateCache: &requestRateCacheStub{
+		window: APIKeyRequestRateWindow{PrevRequests: 4, CurRequests: 3, CurTokens: 200, Elapsed: 30 * time.Second},
+	}}
+	statuses, err := svc.GetRequestRateStatus(context.Background(), &APIKey{ID: 1, RPMLimit: 10, TPMLimit: 1000})
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	require.Equal(t, APIKeyRequestRateStatus{Kind: RequestRateKindRequests, Limit: 10, Used: 5, Remaining: 5}, statuses[0])
+	require.Equal(t, APIKeyRequestRateStatus{Kind: RequestRateKindTokens, Limit: 1000, Used: 200, Remaining: 800}, statuses[1])
+}
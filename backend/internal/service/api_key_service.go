@@ -20,13 +20,15 @@ import (
 )
 
 var (
-	ErrAPIKeyNotFound     = infraerrors.NotFound("API_KEY_NOT_FOUND", "api key not found")
-	ErrGroupNotAllowed    = infraerrors.Forbidden("GROUP_NOT_ALLOWED", "user is not allowed to bind this group")
-	ErrAPIKeyExists       = infraerrors.Conflict("API_KEY_EXISTS", "api key already exists")
-	ErrAPIKeyTooShort     = infraerrors.BadRequest("API_KEY_TOO_SHORT", "api key must be at least 16 characters")
-	ErrAPIKeyInvalidChars = infraerrors.BadRequest("API_KEY_INVALID_CHARS", "api key can only contain letters, numbers, underscores, and hyphens")
-	ErrAPIKeyRateLimited  = infraerrors.TooManyRequests("API_KEY_RATE_LIMITED", "too many failed attempts, please try again later")
-	ErrInvalidIPPattern   = infraerrors.BadRequest("INVALID_IP_PATTERN", "invalid IP or CIDR pattern")
+	ErrAPIKeyNotFound      = infraerrors.NotFound("API_KEY_NOT_FOUND", "api key not found")
+	ErrGroupNotAllowed     = infraerrors.Forbidden("GROUP_NOT_ALLOWED", "user is not allowed to bind this group")
+	ErrAPIKeyExists        = infraerrors.Conflict("API_KEY_EXISTS", "api key already exists")
+	ErrAPIKeyTooShort      = infraerrors.BadRequest("API_KEY_TOO_SHORT", "api key must be at least 16 characters")
+	ErrAPIKeyInvalidChars  = infraerrors.BadRequest("API_KEY_INVALID_CHARS", "api key can only contain letters, numbers, underscores, and hyphens")
+	ErrAPIKeyRateLimited   = infraerrors.TooManyRequests("API_KEY_RATE_LIMITED", "too many failed attempts, please try again later")
+	ErrInvalidIPPattern    = infraerrors.BadRequest("INVALID_IP_PATTERN", "invalid IP or CIDR pattern")
+	ErrInvalidModelPattern = infraerrors.BadRequest("INVALID_MODEL_PATTERN", "invalid model pattern")
+	ErrInvalidRequestRate  = infraerrors.BadRequest("INVALID_REQUEST_RATE_LIMIT", "rpm_limit and tpm_limit must not be negative")
 	// ErrAPIKeyExpired        = infraerrors.Forbidden("API_KEY_EXPIRED", "api key has expired")
 	ErrAPIKeyExpired = infraerrors.Forbidden("API_KEY_EXPIRED", "api key 已过期")
 	// ErrAPIKeyQuotaExhausted = infraerrors.TooManyRequests("API_KEY_QUOTA_EXHAUSTED", "api key quota exhausted")
@@ -48,26 +50,26 @@ const (
 type APIKeyRepository interface {
 	Create(ctx context.Context, key *APIKey) error
 	GetByID(ctx context.Context, id int64) (*APIKey, error)
-	// GetKeyAndOwnerID 仅获取 API Key 的 key 与所有者 ID，用于删除等轻量场景
-	GetKeyAndOwnerID(ctx context.Context, id int64) (string, int64, error)
-	GetByKey(ctx context.Context, key string) (*APIKey, error)
-	// GetByKeyForAuth 认证专用查询，返回最小字段集
-	GetByKeyForAuth(ctx context.Context, key string) (*APIKey, error)
+	// GetKeyHashAndOwnerID 仅获取 API Key 的哈希与所有者 ID，用于删除等轻量场景
+	GetKeyHashAndOwnerID(ctx context.Context, id int64) (string, int64, error)
+	GetByKeyHash(ctx context.Context, keyHash string) (*APIKey, error)
+	// GetByKeyHashForAuth 认证专用查询，返回最小字段集
+	GetByKeyHashForAuth(ctx context.Context, keyHash string) (*APIKey, error)
 	Update(ctx context.Context, key *APIKey) error
 	Delete(ctx context.Context, id int64) error
 
 	ListByUserID(ctx context.Context, userID int64, params pagination.PaginationParams, filters APIKeyListFilters) ([]APIKey, *pagination.PaginationResult, error)
 	VerifyOwnership(ctx context.Context, userID int64, apiKeyIDs []int64) ([]int64, error)
 	CountByUserID(ctx context.Context, userID int64) (int64, error)
-	ExistsByKey(ctx context.Context, key string) (bool, error)
+	ExistsByKeyHash(ctx context.Context, keyHash string) (bool, error)
 	ListByGroupID(ctx context.Context, groupID int64, params pagination.PaginationParams) ([]APIKey, *pagination.PaginationResult, error)
 	SearchAPIKeys(ctx context.Context, userID int64, keyword string, limit int) ([]APIKey, error)
 	ClearGroupIDByGroupID(ctx context.Context, groupID int64) (int64, error)
 	// UpdateGroupIDByUserAndGroup 将用户下绑定 oldGroupID 的所有 Key 迁移到 newGroupID
 	UpdateGroupIDByUserAndGroup(ctx context.Context, userID, oldGroupID, newGroupID int64) (int64, error)
 	CountByGroupID(ctx context.Context, groupID int64) (int64, error)
-	ListKeysByUserID(ctx context.Context, userID int64) ([]string, error)
-	ListKeysByGroupID(ctx context.Context, groupID int64) ([]string, error)
+	ListKeyHashesByUserID(ctx context.Context, userID int64) ([]string, error)
+	ListKeyHashesByGroupID(ctx context.Context, groupID int64) ([]string, error)
 
 	// Quota methods
 	IncrementQuotaUsed(ctx context.Context, id int64, amount float64) (float64, error)
@@ -118,7 +120,7 @@ func (d *APIKeyRateLimitData) EffectiveUsage7d() float64 {
 type APIKeyQuotaUsageState struct {
 	QuotaUsed float64
 	Quota     float64
-	Key       string
+	KeyHash   string
 	Status    string
 }
 
@@ -142,7 +144,7 @@ type APIKeyCache interface {
 
 // APIKeyAuthCacheInvalidator 提供认证缓存失效能力
 type APIKeyAuthCacheInvalidator interface {
-	InvalidateAuthCacheByKey(ctx context.Context, key string)
+	InvalidateAuthCacheByKeyHash(ctx context.Context, keyHash string)
 	InvalidateAuthCacheByUserID(ctx context.Context, userID int64)
 	InvalidateAuthCacheByGroupID(ctx context.Context, groupID int64)
 }
@@ -155,6 +157,10 @@ type CreateAPIKeyRequest struct {
 	IPWhitelist []string `json:"ip_whitelist"` // IP 白名单
 	IPBlacklist []string `json:"ip_blacklist"` // IP 黑名单
 
+	// Model restriction (supports * wildcard)
+	AllowedModels []string `json:"allowed_models"` // 模型白名单
+	DeniedModels  []string `json:"denied_models"`  // 模型黑名单
+
 	// Quota fields
 	Quota         float64 `json:"quota"`           // Quota limit in USD (0 = unlimited)
 	ExpiresInDays *int    `json:"expires_in_days"` // Days until expiry (nil = never expires)
@@ -163,6 +169,10 @@ type CreateAPIKeyRequest struct {
 	RateLimit5h float64 `json:"rate_limit_5h"`
 	RateLimit1d float64 `json:"rate_limit_1d"`
 	RateLimit7d float64 `json:"rate_limit_7d"`
+
+	// Request rate limit fields (0 = unlimited)
+	RPMLimit int `json:"rpm_limit"`
+	TPMLimit int `json:"tpm_limit"`
 }
 
 // UpdateAPIKeyRequest 更新API Key请求
@@ -173,6 +183,10 @@ type UpdateAPIKeyRequest struct {
 	IPWhitelist []string `json:"ip_whitelist"` // IP 白名单（空数组清空）
 	IPBlacklist []string `json:"ip_blacklist"` // IP 黑名单（空数组清空）
 
+	// Model restriction (supports * wildcard)
+	AllowedModels []string `json:"allowed_models"` // 模型白名单（空数组清空）
+	DeniedModels  []string `json:"denied_models"`  // 模型黑名单（空数组清空）
+
 	// Quota fields
 	Quota           *float64   `json:"quota"`       // Quota limit in USD (nil = no change, 0 = unlimited)
 	ExpiresAt       *time.Time `json:"expires_at"`  // Expiration time (nil = no change)
@@ -184,6 +198,10 @@ type UpdateAPIKeyRequest struct {
 	RateLimit1d         *float64 `json:"rate_limit_1d"`
 	RateLimit7d         *float64 `json:"rate_limit_7d"`
 	ResetRateLimitUsage *bool    `json:"reset_rate_limit_usage"` // Reset all usage counters to 0
+
+	// Request rate limit fields (nil = no change, 0 = unlimited)
+	RPMLimit *int `json:"rpm_limit"`
+	TPMLimit *int `json:"tpm_limit"`
 }
 
 // APIKeyService API Key服务
@@ -200,6 +218,7 @@ type APIKeyService struct {
 	userGroupRateRepo     UserGroupRateRepository
 	cache                 APIKeyCache
 	rateLimitCacheInvalid RateLimitCacheInvalidator // optional: invalidate Redis rate limit cache
+	requestRateCache      APIKeyRequestRateCache    // optional: RPM/TPM sliding window counters
 	cfg                   *config.Config
 	authCacheL1           *ristretto.Cache
 	authCfg               apiKeyAuthCacheConfig
@@ -347,6 +366,20 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		}
 	}
 
+	// 验证模型白名单/黑名单格式
+	allowedModels, invalidModels := ValidateModelPatterns(req.AllowedModels)
+	if len(invalidModels) > 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidModelPattern, invalidModels)
+	}
+	deniedModels, invalidModels := ValidateModelPatterns(req.DeniedModels)
+	if len(invalidModels) > 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidModelPattern, invalidModels)
+	}
+
+	if req.RPMLimit < 0 || req.TPMLimit < 0 {
+		return nil, ErrInvalidRequestRate
+	}
+
 	// 验证分组权限（如果指定了分组）
 	if req.GroupID != nil {
 		group, err := s.groupRepo.GetByID(ctx, *req.GroupID)
@@ -375,7 +408,7 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		}
 
 		// 检查Key是否已存在
-		exists, err := s.apiKeyRepo.ExistsByKey(ctx, *req.CustomKey)
+		exists, err := s.apiKeyRepo.ExistsByKeyHash(ctx, s.hashKey(*req.CustomKey))
 		if err != nil {
 			return nil, fmt.Errorf("check key exists: %w", err)
 		}
@@ -396,19 +429,26 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 	}
 
 	// 创建API Key记录
+	// 数据库仅保存哈希与展示前缀，明文 Key 只随本次创建结果返回
 	apiKey := &APIKey{
-		UserID:      userID,
-		Key:         key,
-		Name:        req.Name,
-		GroupID:     req.GroupID,
-		Status:      StatusActive,
-		IPWhitelist: req.IPWhitelist,
-		IPBlacklist: req.IPBlacklist,
-		Quota:       req.Quota,
-		QuotaUsed:   0,
-		RateLimit5h: req.RateLimit5h,
-		RateLimit1d: req.RateLimit1d,
-		RateLimit7d: req.RateLimit7d,
+		UserID:        userID,
+		Key:           key,
+		KeyHash:       s.hashKey(key),
+		KeyPrefix:     APIKeyDisplayPrefix(key),
+		Name:          req.Name,
+		GroupID:       req.GroupID,
+		Status:        StatusActive,
+		IPWhitelist:   req.IPWhitelist,
+		IPBlacklist:   req.IPBlacklist,
+		AllowedModels: allowedModels,
+		DeniedModels:  deniedModels,
+		Quota:         req.Quota,
+		QuotaUsed:     0,
+		RateLimit5h:   req.RateLimit5h,
+		RateLimit1d:   req.RateLimit1d,
+		RateLimit7d:   req.RateLimit7d,
+		RPMLimit:      req.RPMLimit,
+		TPMLimit:      req.TPMLimit,
 	}
 
 	// Set expiration time if specified
@@ -421,7 +461,7 @@ func (s *APIKeyService) Create(ctx context.Context, userID int64, req CreateAPIK
 		return nil, fmt.Errorf("create api key: %w", err)
 	}
 
-	s.InvalidateAuthCacheByKey(ctx, apiKey.Key)
+	s.InvalidateAuthCacheByKeyHash(ctx, apiKey.KeyHash)
 	s.compileAPIKeyIPRules(apiKey)
 
 	return apiKey, nil
@@ -460,10 +500,10 @@ func (s *APIKeyService) GetByID(ctx context.Context, id int64) (*APIKey, error)
 
 // GetByKey 根据Key字符串获取API Key（用于认证）
 func (s *APIKeyService) GetByKey(ctx context.Context, key string) (*APIKey, error) {
-	cacheKey := s.authCacheKey(key)
+	keyHash := s.hashKey(key)
 
-	if entry, ok := s.getAuthCacheEntry(ctx, cacheKey); ok {
-		if apiKey, used, err := s.applyAuthCacheEntry(key, entry); used {
+	if entry, ok := s.getAuthCacheEntry(ctx, keyHash); ok {
+		if apiKey, used, err := s.applyAuthCacheEntry(key, keyHash, entry); used {
 			if err != nil {
 				return nil, fmt.Errorf("get api key: %w", err)
 			}
@@ -473,14 +513,14 @@ func (s *APIKeyService) GetByKey(ctx context.Context, key string) (*APIKey, erro
 	}
 
 	if s.authCfg.singleflight {
-		value, err, _ := s.authGroup.Do(cacheKey, func() (any, error) {
-			return s.loadAuthCacheEntry(ctx, key, cacheKey)
+		value, err, _ := s.authGroup.Do(keyHash, func() (any, error) {
+			return s.loadAuthCacheEntry(ctx, keyHash)
 		})
 		if err != nil {
 			return nil, err
 		}
 		entry, _ := value.(*APIKeyAuthCacheEntry)
-		if apiKey, used, err := s.applyAuthCacheEntry(key, entry); used {
+		if apiKey, used, err := s.applyAuthCacheEntry(key, keyHash, entry); used {
 			if err != nil {
 				return nil, fmt.Errorf("get api key: %w", err)
 			}
@@ -488,11 +528,11 @@ func (s *APIKeyService) GetByKey(ctx context.Context, key string) (*APIKey, erro
 			return apiKey, nil
 		}
 	} else {
-		entry, err := s.loadAuthCacheEntry(ctx, key, cacheKey)
+		entry, err := s.loadAuthCacheEntry(ctx, keyHash)
 		if err != nil {
 			return nil, err
 		}
-		if apiKey, used, err := s.applyAuthCacheEntry(key, entry); used {
+		if apiKey, used, err := s.applyAuthCacheEntry(key, keyHash, entry); used {
 			if err != nil {
 				return nil, fmt.Errorf("get api key: %w", err)
 			}
@@ -501,11 +541,12 @@ func (s *APIKeyService) GetByKey(ctx context.Context, key string) (*APIKey, erro
 		}
 	}
 
-	apiKey, err := s.apiKeyRepo.GetByKeyForAuth(ctx, key)
+	apiKey, err := s.apiKeyRepo.GetByKeyHashForAuth(ctx, keyHash)
 	if err != nil {
 		return nil, fmt.Errorf("get api key: %w", err)
 	}
 	apiKey.Key = key
+	apiKey.KeyHash = keyHash
 	s.compileAPIKeyIPRules(apiKey)
 	return apiKey, nil
 }
@@ -536,6 +577,20 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 		}
 	}
 
+	// 验证模型白名单/黑名单格式
+	allowedModels, invalidModels := ValidateModelPatterns(req.AllowedModels)
+	if len(invalidModels) > 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidModelPattern, invalidModels)
+	}
+	deniedModels, invalidModels := ValidateModelPatterns(req.DeniedModels)
+	if len(invalidModels) > 0 {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidModelPattern, invalidModels)
+	}
+
+	if (req.RPMLimit != nil && *req.RPMLimit < 0) || (req.TPMLimit != nil && *req.TPMLimit < 0) {
+		return nil, ErrInvalidRequestRate
+	}
+
 	// 更新字段
 	if req.Name != nil {
 		apiKey.Name = *req.Name
@@ -601,6 +656,10 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 	apiKey.IPWhitelist = req.IPWhitelist
 	apiKey.IPBlacklist = req.IPBlacklist
 
+	// 更新模型限制（空数组会清空设置）
+	apiKey.AllowedModels = allowedModels
+	apiKey.DeniedModels = deniedModels
+
 	// Update rate limit configuration
 	if req.RateLimit5h != nil {
 		apiKey.RateLimit5h = *req.RateLimit5h
@@ -611,6 +670,12 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 	if req.RateLimit7d != nil {
 		apiKey.RateLimit7d = *req.RateLimit7d
 	}
+	if req.RPMLimit != nil {
+		apiKey.RPMLimit = *req.RPMLimit
+	}
+	if req.TPMLimit != nil {
+		apiKey.TPMLimit = *req.TPMLimit
+	}
 	resetRateLimit := req.ResetRateLimitUsage != nil && *req.ResetRateLimitUsage
 	if resetRateLimit {
 		apiKey.Usage5h = 0
@@ -625,7 +690,7 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 		return nil, fmt.Errorf("update api key: %w", err)
 	}
 
-	s.InvalidateAuthCacheByKey(ctx, apiKey.Key)
+	s.InvalidateAuthCacheByKeyHash(ctx, apiKey.KeyHash)
 	s.compileAPIKeyIPRules(apiKey)
 
 	// Invalidate Redis rate limit cache so reset takes effect immediately
@@ -638,7 +703,7 @@ func (s *APIKeyService) Update(ctx context.Context, id int64, userID int64, req
 
 // Delete 删除API Key
 func (s *APIKeyService) Delete(ctx context.Context, id int64, userID int64) error {
-	key, ownerID, err := s.apiKeyRepo.GetKeyAndOwnerID(ctx, id)
+	keyHash, ownerID, err := s.apiKeyRepo.GetKeyHashAndOwnerID(ctx, id)
 	if err != nil {
 		return fmt.Errorf("get api key: %w", err)
 	}
@@ -652,7 +717,7 @@ func (s *APIKeyService) Delete(ctx context.Context, id int64, userID int64) erro
 	if s.cache != nil {
 		_ = s.cache.DeleteCreateAttemptCount(ctx, userID)
 	}
-	s.InvalidateAuthCacheByKey(ctx, key)
+	s.InvalidateAuthCacheByKeyHash(ctx, keyHash)
 
 	if err := s.apiKeyRepo.Delete(ctx, id); err != nil {
 		return fmt.Errorf("delete api key: %w", err)
@@ -838,8 +903,8 @@ func (s *APIKeyService) UpdateQuotaUsed(ctx context.Context, apiKeyID int64, cos
 		if err != nil {
 			return fmt.Errorf("increment quota used: %w", err)
 		}
-		if state != nil && state.Status == StatusAPIKeyQuotaExhausted && strings.TrimSpace(state.Key) != "" {
-			s.InvalidateAuthCacheByKey(ctx, state.Key)
+		if state != nil && state.Status == StatusAPIKeyQuotaExhausted && strings.TrimSpace(state.KeyHash) != "" {
+			s.InvalidateAuthCacheByKeyHash(ctx, state.KeyHash)
 		}
 		return nil
 	}
@@ -863,7 +928,7 @@ func (s *APIKeyService) UpdateQuotaUsed(ctx context.Context, apiKeyID int64, cos
 			return nil // Don't fail the request
 		}
 		// Invalidate cache so next request sees the new status
-		s.InvalidateAuthCacheByKey(ctx, apiKey.Key)
+		s.InvalidateAuthCacheByKeyHash(ctx, apiKey.KeyHash)
 	}
 
 	return nil
@@ -30,17 +30,17 @@ func (s *authRepoStub) GetByID(ctx context.Context, id int64) (*APIKey, error) {
 	panic("unexpected GetByID call")
 }
 
-func (s *authRepoStub) GetKeyAndOwnerID(ctx context.Context, id int64) (string, int64, error) {
-	panic("unexpected GetKeyAndOwnerID call")
+func (s *authRepoStub) GetKeyHashAndOwnerID(ctx context.Context, id int64) (string, int64, error) {
+	panic("unexpected GetKeyHashAndOwnerID call")
 }
 
-func (s *authRepoStub) GetByKey(ctx context.Context, key string) (*APIKey, error) {
-	panic("unexpected GetByKey call")
+func (s *authRepoStub) GetByKeyHash(ctx context.Context, key string) (*APIKey, error) {
+	panic("unexpected GetByKeyHash call")
 }
 
-func (s *authRepoStub) GetByKeyForAuth(ctx context.Context, key string) (*APIKey, error) {
+func (s *authRepoStub) GetByKeyHashForAuth(ctx context.Context, key string) (*APIKey, error) {
 	if s.getByKeyForAuth == nil {
-		panic("unexpected GetByKeyForAuth call")
+		panic("unexpected GetByKeyHashForAuth call")
 	}
 	return s.getByKeyForAuth(ctx, key)
 }
@@ -65,8 +65,8 @@ func (s *authRepoStub) CountByUserID(ctx context.Context, userID int64) (int64,
 	panic("unexpected CountByUserID call")
 }
 
-func (s *authRepoStub) ExistsByKey(ctx context.Context, key string) (bool, error) {
-	panic("unexpected ExistsByKey call")
+func (s *authRepoStub) ExistsByKeyHash(ctx context.Context, key string) (bool, error) {
+	panic("unexpected ExistsByKeyHash call")
 }
 
 func (s *authRepoStub) ListByGroupID(ctx context.Context, groupID int64, params pagination.PaginationParams) ([]APIKey, *pagination.PaginationResult, error) {
@@ -88,16 +88,16 @@ func (s *authRepoStub) CountByGroupID(ctx context.Context, groupID int64) (int64
 	panic("unexpected CountByGroupID call")
 }
 
-func (s *authRepoStub) ListKeysByUserID(ctx context.Context, userID int64) ([]string, error) {
+func (s *authRepoStub) ListKeyHashesByUserID(ctx context.Context, userID int64) ([]string, error) {
 	if s.listKeysByUserID == nil {
-		panic("unexpected ListKeysByUserID call")
+		panic("unexpected ListKeyHashesByUserID call")
 	}
 	return s.listKeysByUserID(ctx, userID)
 }
 
-func (s *authRepoStub) ListKeysByGroupID(ctx context.Context, groupID int64) ([]string, error) {
+func (s *authRepoStub) ListKeyHashesByGroupID(ctx context.Context, groupID int64) ([]string, error) {
 	if s.listKeysByGroupID == nil {
-		panic("unexpected ListKeysByGroupID call")
+		panic("unexpected ListKeyHashesByGroupID call")
 	}
 	return s.listKeysByGroupID(ctx, groupID)
 }
@@ -315,7 +315,7 @@ func TestAPIKeyService_GetByKey_UsesL1Cache(t *testing.T) {
 	_, err := svc.GetByKey(context.Background(), "k-l1")
 	require.NoError(t, err)
 	svc.authCacheL1.Wait()
-	cacheKey := svc.authCacheKey("k-l1")
+	cacheKey := svc.hashKey("k-l1")
 	_, ok := svc.authCacheL1.Get(cacheKey)
 	require.True(t, ok)
 	_, err = svc.GetByKey(context.Background(), "k-l1")
@@ -374,7 +374,7 @@ func TestAPIKeyService_InvalidateAuthCacheByKey(t *testing.T) {
 	}
 	svc := NewAPIKeyService(repo, nil, nil, nil, nil, cache, cfg)
 
-	svc.InvalidateAuthCacheByKey(context.Background(), "k1")
+	svc.InvalidateAuthCacheByKeyHash(context.Background(), "k1")
 	require.Len(t, cache.deleteAuthKeys, 1)
 }
 
@@ -20,12 +20,12 @@ import (
 // 用于隔离测试 APIKeyService.Delete 方法，避免依赖真实数据库。
 //
 // 设计说明：
-//   - apiKey/getByIDErr: 模拟 GetKeyAndOwnerID 返回的记录与错误
+//   - apiKey/getByIDErr: 模拟 GetKeyHashAndOwnerID 返回的记录与错误
 //   - deleteErr: 模拟 Delete 返回的错误
 //   - deletedIDs: 记录被调用删除的 API Key ID，用于断言验证
 type apiKeyRepoStub struct {
-	apiKey         *APIKey // GetKeyAndOwnerID 的返回值
-	getByIDErr     error   // GetKeyAndOwnerID 的错误返回值
+	apiKey         *APIKey // GetKeyHashAndOwnerID 的返回值
+	getByIDErr     error   // GetKeyHashAndOwnerID 的错误返回值
 	deleteErr      error   // Delete 的错误返回值
 	deletedIDs     []int64 // 记录已删除的 API Key ID 列表
 	updateLastUsed func(ctx context.Context, id int64, usedAt time.Time) error
@@ -50,22 +50,22 @@ func (s *apiKeyRepoStub) GetByID(ctx context.Context, id int64) (*APIKey, error)
 	panic("unexpected GetByID call")
 }
 
-func (s *apiKeyRepoStub) GetKeyAndOwnerID(ctx context.Context, id int64) (string, int64, error) {
+func (s *apiKeyRepoStub) GetKeyHashAndOwnerID(ctx context.Context, id int64) (string, int64, error) {
 	if s.getByIDErr != nil {
 		return "", 0, s.getByIDErr
 	}
 	if s.apiKey != nil {
-		return s.apiKey.Key, s.apiKey.UserID, nil
+		return s.apiKey.KeyHash, s.apiKey.UserID, nil
 	}
 	return "", 0, ErrAPIKeyNotFound
 }
 
-func (s *apiKeyRepoStub) GetByKey(ctx context.Context, key string) (*APIKey, error) {
-	panic("unexpected GetByKey call")
+func (s *apiKeyRepoStub) GetByKeyHash(ctx context.Context, key string) (*APIKey, error) {
+	panic("unexpected GetByKeyHash call")
 }
 
-func (s *apiKeyRepoStub) GetByKeyForAuth(ctx context.Context, key string) (*APIKey, error) {
-	panic("unexpected GetByKeyForAuth call")
+func (s *apiKeyRepoStub) GetByKeyHashForAuth(ctx context.Context, key string) (*APIKey, error) {
+	panic("unexpected GetByKeyHashForAuth call")
 }
 
 func (s *apiKeyRepoStub) Update(ctx context.Context, key *APIKey) error {
@@ -93,8 +93,8 @@ func (s *apiKeyRepoStub) CountByUserID(ctx context.Context, userID int64) (int64
 	panic("unexpected CountByUserID call")
 }
 
-func (s *apiKeyRepoStub) ExistsByKey(ctx context.Context, key string) (bool, error) {
-	panic("unexpected ExistsByKey call")
+func (s *apiKeyRepoStub) ExistsByKeyHash(ctx context.Context, key string) (bool, error) {
+	panic("unexpected ExistsByKeyHash call")
 }
 
 func (s *apiKeyRepoStub) ListByGroupID(ctx context.Context, groupID int64, params pagination.PaginationParams) ([]APIKey, *pagination.PaginationResult, error) {
@@ -116,12 +116,12 @@ func (s *apiKeyRepoStub) CountByGroupID(ctx context.Context, groupID int64) (int
 	panic("unexpected CountByGroupID call")
 }
 
-func (s *apiKeyRepoStub) ListKeysByUserID(ctx context.Context, userID int64) ([]string, error) {
-	panic("unexpected ListKeysByUserID call")
+func (s *apiKeyRepoStub) ListKeyHashesByUserID(ctx context.Context, userID int64) ([]string, error) {
+	panic("unexpected ListKeyHashesByUserID call")
 }
 
-func (s *apiKeyRepoStub) ListKeysByGroupID(ctx context.Context, groupID int64) ([]string, error) {
-	panic("unexpected ListKeysByGroupID call")
+func (s *apiKeyRepoStub) ListKeyHashesByGroupID(ctx context.Context, groupID int64) ([]string, error) {
+	panic("unexpected ListKeyHashesByGroupID call")
 }
 
 func (s *apiKeyRepoStub) IncrementQuotaUsed(ctx context.Context, id int64, amount float64) (float64, error) {
@@ -209,14 +209,14 @@ func (s *apiKeyCacheStub) SubscribeAuthCacheInvalidation(ctx context.Context, ha
 
 // TestApiKeyService_Delete_OwnerMismatch 测试非所有者尝试删除时返回权限错误。
 // 预期行为：
-//   - GetKeyAndOwnerID 返回所有者 ID 为 1
+//   - GetKeyHashAndOwnerID 返回所有者 ID 为 1
 //   - 调用者 userID 为 2（不匹配）
 //   - 返回 ErrInsufficientPerms 错误
 //   - Delete 方法不被调用
 //   - 缓存不被清除
 func TestApiKeyService_Delete_OwnerMismatch(t *testing.T) {
 	repo := &apiKeyRepoStub{
-		apiKey: &APIKey{ID: 10, UserID: 1, Key: "k"},
+		apiKey: &APIKey{ID: 10, UserID: 1, KeyHash: "k"},
 	}
 	cache := &apiKeyCacheStub{}
 	svc := &APIKeyService{apiKeyRepo: repo, cache: cache}
@@ -230,14 +230,14 @@ func TestApiKeyService_Delete_OwnerMismatch(t *testing.T) {
 
 // TestApiKeyService_Delete_Success 测试所有者成功删除 API Key 的场景。
 // 预期行为：
-//   - GetKeyAndOwnerID 返回所有者 ID 为 7
+//   - GetKeyHashAndOwnerID 返回所有者 ID 为 7
 //   - 调用者 userID 为 7（匹配）
 //   - Delete 成功执行
 //   - 缓存被正确清除（使用 ownerID）
 //   - 返回 nil 错误
 func TestApiKeyService_Delete_Success(t *testing.T) {
 	repo := &apiKeyRepoStub{
-		apiKey: &APIKey{ID: 42, UserID: 7, Key: "k"},
+		apiKey: &APIKey{ID: 42, UserID: 7, KeyHash: "k"},
 	}
 	cache := &apiKeyCacheStub{}
 	svc := &APIKeyService{apiKeyRepo: repo, cache: cache}
@@ -247,14 +247,14 @@ func TestApiKeyService_Delete_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []int64{42}, repo.deletedIDs)  // 验证正确的 API Key 被删除
 	require.Equal(t, []int64{7}, cache.invalidated) // 验证所有者的缓存被清除
-	require.Equal(t, []string{svc.authCacheKey("k")}, cache.deleteAuthKeys)
+	require.Equal(t, []string{"k"}, cache.deleteAuthKeys)
 	_, exists := svc.lastUsedTouchL1.Load(int64(42))
 	require.False(t, exists, "delete should clear touch debounce cache")
 }
 
 // TestApiKeyService_Delete_NotFound 测试删除不存在的 API Key 时返回正确的错误。
 // 预期行为：
-//   - GetKeyAndOwnerID 返回 ErrAPIKeyNotFound 错误
+//   - GetKeyHashAndOwnerID 返回 ErrAPIKeyNotFound 错误
 //   - 返回 ErrAPIKeyNotFound 错误（被 fmt.Errorf 包装）
 //   - Delete 方法不被调用
 //   - 缓存不被清除
@@ -272,14 +272,14 @@ func TestApiKeyService_Delete_NotFound(t *testing.T) {
 
 // TestApiKeyService_Delete_DeleteFails 测试删除操作失败时的错误处理。
 // 预期行为：
-//   - GetKeyAndOwnerID 返回正确的所有者 ID
+//   - GetKeyHashAndOwnerID 返回正确的所有者 ID
 //   - 所有权验证通过
 //   - 缓存被清除（在删除之前）
 //   - Delete 被调用但返回错误
 //   - 返回包含 "delete api key" 的错误信息
 func TestApiKeyService_Delete_DeleteFails(t *testing.T) {
 	repo := &apiKeyRepoStub{
-		apiKey:    &APIKey{ID: 42, UserID: 3, Key: "k"},
+		apiKey:    &APIKey{ID: 42, UserID: 3, KeyHash: "k"},
 		deleteErr: errors.New("delete failed"),
 	}
 	cache := &apiKeyCacheStub{}
@@ -290,5 +290,5 @@ func TestApiKeyService_Delete_DeleteFails(t *testing.T) {
 	require.ErrorContains(t, err, "delete api key")
 	require.Equal(t, []int64{3}, repo.deletedIDs)   // 验证删除操作被调用
 	require.Equal(t, []int64{3}, cache.invalidated) // 验证缓存已被清除（即使删除失败）
-	require.Equal(t, []string{svc.authCacheKey("k")}, cache.deleteAuthKeys)
+	require.Equal(t, []string{"k"}, cache.deleteAuthKeys)
 }
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"net/mail"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -71,6 +72,7 @@ type AuthService struct {
 	emailQueueService  *EmailQueueService
 	promoService       *PromoService
 	defaultSubAssigner DefaultSubscriptionAssigner
+	referralService    *ReferralService
 }
 
 type DefaultSubscriptionAssigner interface {
@@ -106,13 +108,18 @@ func NewAuthService(
 	}
 }
 
+// SetReferralService 注入邀请返佣服务（可选），用于注册时绑定邀请关系
+func (s *AuthService) SetReferralService(referralService *ReferralService) {
+	s.referralService = referralService
+}
+
 // Register 用户注册，返回token和用户
 func (s *AuthService) Register(ctx context.Context, email, password string) (string, *User, error) {
-	return s.RegisterWithVerification(ctx, email, password, "", "", "")
+	return s.RegisterWithVerification(ctx, email, password, "", "", "", "")
 }
 
-// RegisterWithVerification 用户注册（支持邮件验证、优惠码和邀请码），返回token和用户
-func (s *AuthService) RegisterWithVerification(ctx context.Context, email, password, verifyCode, promoCode, invitationCode string) (string, *User, error) {
+// RegisterWithVerification 用户注册（支持邮件验证、优惠码、邀请码和推广码），返回token和用户
+func (s *AuthService) RegisterWithVerification(ctx context.Context, email, password, verifyCode, promoCode, invitationCode, referralCode string) (string, *User, error) {
 	// 检查是否开放注册（默认关闭：settingService 未配置时不允许注册）
 	if s.settingService == nil || !s.settingService.IsRegistrationEnabled(ctx) {
 		return "", nil, ErrRegDisabled
@@ -146,6 +153,17 @@ func (s *AuthService) RegisterWithVerification(ctx context.Context, email, passw
 		invitationRedeemCode = redeemCode
 	}
 
+	// 校验推广码（返佣功能关闭时忽略）
+	var inviterID int64
+	if referralCode != "" && s.referralService.Enabled() {
+		id, err := s.referralService.ResolveCode(ctx, referralCode)
+		if err != nil {
+			logger.LegacyPrintf("service.auth", "[Auth] Invalid referral code: %s, error: %v", referralCode, err)
+			return "", nil, ErrReferralCodeInvalid
+		}
+		inviterID = id
+	}
+
 	// 检查是否需要邮件验证
 	if s.settingService != nil && s.settingService.IsEmailVerifyEnabled(ctx) {
 		// 如果邮件验证已开启但邮件服务未配置，拒绝注册
@@ -214,6 +232,8 @@ func (s *AuthService) RegisterWithVerification(ctx context.Context, email, passw
 			logger.LegacyPrintf("service.auth", "[Auth] Failed to mark invitation code as used for user %d: %v", user.ID, err)
 		}
 	}
+	// 绑定邀请关系（如果使用了推广码）
+	s.bindReferral(ctx, inviterID, user.ID)
 	// 应用优惠码（如果提供且功能已启用）
 	if promoCode != "" && s.promoService != nil && s.settingService != nil && s.settingService.IsPromoCodeEnabled(ctx) {
 		if err := s.promoService.ApplyPromoCode(ctx, user.ID, promoCode); err != nil {
@@ -532,6 +552,13 @@ func (s *AuthService) LoginOrRegisterOAuth(ctx context.Context, email, username
 // 与 LoginOrRegisterOAuth 功能相同，但返回 TokenPair 而非单个 token。
 // invitationCode 仅在邀请码注册模式下新用户注册时使用；已有账号登录时忽略。
 func (s *AuthService) LoginOrRegisterOAuthWithTokenPair(ctx context.Context, email, username, invitationCode string) (*TokenPair, *User, error) {
+	return s.LoginOrRegisterOAuthWithGrants(ctx, email, username, invitationCode, "", nil)
+}
+
+// LoginOrRegisterOAuthWithGrants 与 LoginOrRegisterOAuthWithTokenPair 相同，并额外应用 SSO 提供方授予的权限：
+// grants.AllowedGroupIDs 每次登录增量加入用户专属分组；grants.Subscriptions 仅在首次注册时分配。
+// referralCode 仅在新用户注册时绑定邀请关系。
+func (s *AuthService) LoginOrRegisterOAuthWithGrants(ctx context.Context, email, username, invitationCode, referralCode string, grants *OAuthSignupGrants) (*TokenPair, *User, error) {
 	// 检查 refreshTokenCache 是否可用
 	if s.refreshTokenCache == nil {
 		return nil, nil, errors.New("refresh token cache not configured")
@@ -573,6 +600,7 @@ func (s *AuthService) LoginOrRegisterOAuthWithTokenPair(ctx context.Context, ema
 				}
 				invitationRedeemCode = redeemCode
 			}
+			inviterID := s.resolveOAuthReferral(ctx, referralCode)
 
 			randomPassword, err := randomHexString(32)
 			if err != nil {
@@ -631,6 +659,8 @@ func (s *AuthService) LoginOrRegisterOAuthWithTokenPair(ctx context.Context, ema
 					}
 					user = newUser
 					s.assignDefaultSubscriptions(ctx, user.ID)
+					s.assignOAuthGrantSubscriptions(ctx, user.ID, grants)
+					s.bindReferral(ctx, inviterID, user.ID)
 				}
 			} else {
 				if err := s.userRepo.Create(ctx, newUser); err != nil {
@@ -647,6 +677,8 @@ func (s *AuthService) LoginOrRegisterOAuthWithTokenPair(ctx context.Context, ema
 				} else {
 					user = newUser
 					s.assignDefaultSubscriptions(ctx, user.ID)
+					s.assignOAuthGrantSubscriptions(ctx, user.ID, grants)
+					s.bindReferral(ctx, inviterID, user.ID)
 					if invitationRedeemCode != nil {
 						if err := s.redeemRepo.Use(ctx, invitationRedeemCode.ID, user.ID); err != nil {
 							return nil, nil, ErrInvitationCodeInvalid
@@ -671,6 +703,8 @@ func (s *AuthService) LoginOrRegisterOAuthWithTokenPair(ctx context.Context, ema
 		}
 	}
 
+	s.applyOAuthGrantGroups(ctx, user, grants)
+
 	tokenPair, err := s.GenerateTokenPair(ctx, user, "")
 	if err != nil {
 		return nil, nil, fmt.Errorf("generate token pair: %w", err)
@@ -685,20 +719,33 @@ const pendingOAuthTokenTTL = 10 * time.Minute
 const pendingOAuthPurpose = "pending_oauth_registration"
 
 type pendingOAuthClaims struct {
-	Email    string `json:"email"`
-	Username string `json:"username"`
-	Purpose  string `json:"purpose"`
+	Email        string             `json:"email"`
+	Username     string             `json:"username"`
+	Purpose      string             `json:"purpose"`
+	ReferralCode string             `json:"referral_code,omitempty"`
+	Grants       *OAuthSignupGrants `json:"grants,omitempty"`
 	jwt.RegisteredClaims
 }
 
 // CreatePendingOAuthToken generates a short-lived JWT that carries the OAuth identity
 // while waiting for the user to supply an invitation code.
 func (s *AuthService) CreatePendingOAuthToken(email, username string) (string, error) {
+	return s.CreatePendingOAuthTokenWithGrants(email, username, "", nil)
+}
+
+// CreatePendingOAuthTokenWithGrants is like CreatePendingOAuthToken but also carries the
+// referral code and SSO provider grants, so they are applied once registration is completed.
+func (s *AuthService) CreatePendingOAuthTokenWithGrants(email, username, referralCode string, grants *OAuthSignupGrants) (string, error) {
+	if grants.IsEmpty() {
+		grants = nil
+	}
 	now := time.Now()
 	claims := &pendingOAuthClaims{
-		Email:    email,
-		Username: username,
-		Purpose:  pendingOAuthPurpose,
+		Email:        email,
+		Username:     username,
+		Purpose:      pendingOAuthPurpose,
+		ReferralCode: referralCode,
+		Grants:       grants,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(now.Add(pendingOAuthTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -712,8 +759,15 @@ func (s *AuthService) CreatePendingOAuthToken(email, username string) (string, e
 // VerifyPendingOAuthToken validates a pending OAuth token and returns the embedded identity.
 // Returns ErrInvalidToken when the token is invalid or expired.
 func (s *AuthService) VerifyPendingOAuthToken(tokenStr string) (email, username string, err error) {
+	email, username, _, _, err = s.VerifyPendingOAuthTokenWithGrants(tokenStr)
+	return email, username, err
+}
+
+// VerifyPendingOAuthTokenWithGrants validates a pending OAuth token and returns the embedded
+// identity together with the referral code and SSO provider grants (nil when none were issued).
+func (s *AuthService) VerifyPendingOAuthTokenWithGrants(tokenStr string) (email, username, referralCode string, grants *OAuthSignupGrants, err error) {
 	if len(tokenStr) > maxTokenLength {
-		return "", "", ErrInvalidToken
+		return "", "", "", nil, ErrInvalidToken
 	}
 	parser := jwt.NewParser(jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
 	token, parseErr := parser.ParseWithClaims(tokenStr, &pendingOAuthClaims{}, func(t *jwt.Token) (any, error) {
@@ -723,16 +777,75 @@ func (s *AuthService) VerifyPendingOAuthToken(tokenStr string) (email, username
 		return []byte(s.cfg.JWT.Secret), nil
 	})
 	if parseErr != nil {
-		return "", "", ErrInvalidToken
+		return "", "", "", nil, ErrInvalidToken
 	}
 	claims, ok := token.Claims.(*pendingOAuthClaims)
 	if !ok || !token.Valid {
-		return "", "", ErrInvalidToken
+		return "", "", "", nil, ErrInvalidToken
 	}
 	if claims.Purpose != pendingOAuthPurpose {
-		return "", "", ErrInvalidToken
+		return "", "", "", nil, ErrInvalidToken
+	}
+	return claims.Email, claims.Username, claims.ReferralCode, claims.Grants, nil
+}
+
+// resolveOAuthReferral 解析第三方登录注册携带的推广码。
+// 与密码注册不同，无效推广码只记录日志：用户无法在第三方登录流程中修改推广码。
+func (s *AuthService) resolveOAuthReferral(ctx context.Context, referralCode string) int64 {
+	referralCode = strings.TrimSpace(referralCode)
+	if referralCode == "" || !s.referralService.Enabled() {
+		return 0
+	}
+	inviterID, err := s.referralService.ResolveCode(ctx, referralCode)
+	if err != nil {
+		logger.LegacyPrintf("service.auth", "[Auth] Ignoring invalid referral code for oauth signup: %s, error: %v", referralCode, err)
+		return 0
+	}
+	return inviterID
+}
+
+// bindReferral 绑定邀请关系；绑定失败不影响注册，只记录日志
+func (s *AuthService) bindReferral(ctx context.Context, inviterID, inviteeID int64) {
+	if inviterID <= 0 || inviteeID <= 0 {
+		return
+	}
+	if err := s.referralService.BindInvitee(ctx, inviterID, inviteeID, time.Now()); err != nil {
+		logger.LegacyPrintf("service.auth", "[Auth] Failed to bind referral for user %d: %v", inviteeID, err)
+	}
+}
+
+// assignOAuthGrantSubscriptions 为 SSO 首次注册的用户分配提供方映射的订阅
+func (s *AuthService) assignOAuthGrantSubscriptions(ctx context.Context, userID int64, grants *OAuthSignupGrants) {
+	if grants.IsEmpty() || s.defaultSubAssigner == nil || userID <= 0 {
+		return
+	}
+	for _, item := range grants.Subscriptions {
+		if _, _, err := s.defaultSubAssigner.AssignOrExtendSubscription(ctx, &AssignSubscriptionInput{
+			UserID:       userID,
+			GroupID:      item.GroupID,
+			ValidityDays: item.ValidityDays,
+			Notes:        "auto assigned by sso provider group mapping",
+		}); err != nil {
+			logger.LegacyPrintf("service.auth", "[Auth] Failed to assign sso subscription: user_id=%d group_id=%d err=%v", userID, item.GroupID, err)
+		}
+	}
+}
+
+// applyOAuthGrantGroups 将 SSO 提供方映射的专属分组加入用户可用分组（幂等，不会移除已有分组）
+func (s *AuthService) applyOAuthGrantGroups(ctx context.Context, user *User, grants *OAuthSignupGrants) {
+	if grants.IsEmpty() || user == nil {
+		return
+	}
+	for _, groupID := range grants.AllowedGroupIDs {
+		if slices.Contains(user.AllowedGroups, groupID) {
+			continue
+		}
+		if err := s.userRepo.AddGroupToAllowedGroups(ctx, user.ID, groupID); err != nil {
+			logger.LegacyPrintf("service.auth", "[Auth] Failed to grant sso group: user_id=%d group_id=%d err=%v", user.ID, groupID, err)
+			continue
+		}
+		user.AllowedGroups = append(user.AllowedGroups, groupID)
 	}
-	return claims.Email, claims.Username, nil
 }
 
 func (s *AuthService) assignDefaultSubscriptions(ctx context.Context, userID int64) {
@@ -833,7 +946,8 @@ func randomHexString(byteLength int) (string, error) {
 
 func isReservedEmail(email string) bool {
 	normalized := strings.ToLower(strings.TrimSpace(email))
-	return strings.HasSuffix(normalized, LinuxDoConnectSyntheticEmailDomain)
+	return strings.HasSuffix(normalized, LinuxDoConnectSyntheticEmailDomain) ||
+		strings.HasSuffix(normalized, OIDCSyntheticEmailDomain)
 }
 
 // GenerateToken 生成JWT access token
@@ -35,6 +35,33 @@ func TestVerifyPendingOAuthToken_ValidToken(t *testing.T) {
 	require.Equal(t, "alice", username)
 }
 
+// TestVerifyPendingOAuthTokenWithGrants_RoundTrip 验证 SSO 授予项随 pending token 传递到注册完成阶段。
+func TestVerifyPendingOAuthTokenWithGrants_RoundTrip(t *testing.T) {
+	svc := newAuthServiceForPendingOAuthTest()
+	grants := &OAuthSignupGrants{
+		AllowedGroupIDs: []int64{3, 7},
+		Subscriptions:   []DefaultSubscriptionSetting{{GroupID: 9, ValidityDays: 30}},
+	}
+
+	token, err := svc.CreatePendingOAuthTokenWithGrants("oidc-corp-abc@oidc-sso.invalid", "alice", "ABCD2345", grants)
+	require.NoError(t, err)
+
+	email, username, referralCode, got, err := svc.VerifyPendingOAuthTokenWithGrants(token)
+	require.NoError(t, err)
+	require.Equal(t, "oidc-corp-abc@oidc-sso.invalid", email)
+	require.Equal(t, "alice", username)
+	require.Equal(t, "ABCD2345", referralCode, "推广码随 pending token 传递到注册完成阶段")
+	require.Equal(t, grants, got)
+
+	// 旧接口签发的 token 不带授予项与推广码
+	token, err = svc.CreatePendingOAuthToken("user@example.com", "alice")
+	require.NoError(t, err)
+	_, _, referralCode, got, err = svc.VerifyPendingOAuthTokenWithGrants(token)
+	require.NoError(t, err)
+	require.Empty(t, referralCode)
+	require.Nil(t, got)
+}
+
 // TestVerifyPendingOAuthToken_RegularJWTRejected 用普通 access token 尝试验证，应返回 ErrInvalidToken。
 func TestVerifyPendingOAuthToken_RegularJWTRejected(t *testing.T) {
 	svc := newAuthServiceForPendingOAuthTest()
@@ -101,6 +101,8 @@ type BackupRecord struct {
 	RestoreStatus string `json:"restore_status,omitempty"` // "", "running", "completed", "failed"
 	RestoreError  string `json:"restore_error,omitempty"`
 	RestoredAt    string `json:"restored_at,omitempty"`
+	// CredentialKeyIDs 备份时账号凭证密文引用的主密钥，恢复前校验这些密钥仍已配置
+	CredentialKeyIDs []string `json:"credential_key_ids,omitempty"`
 }
 
 // BackupService 数据库备份恢复服务
@@ -111,6 +113,9 @@ type BackupService struct {
 	storeFactory BackupObjectStoreFactory
 	dumper       DBDumper
 
+	// credentialEncryption 可选：记录备份依赖的凭证主密钥，恢复后迁移明文凭证
+	credentialEncryption *CredentialEncryptionService
+
 	opMu      sync.Mutex // 保护 backingUp/restoring 标志
 	backingUp bool
 	restoring bool
@@ -150,6 +155,11 @@ func NewBackupService(
 	}
 }
 
+// SetCredentialEncryption 注入凭证加密服务（可选）
+func (s *BackupService) SetCredentialEncryption(svc *CredentialEncryptionService) {
+	s.credentialEncryption = svc
+}
+
 // Start 启动定时备份调度器并清理孤立记录
 func (s *BackupService) Start() {
 	s.cronSched = cron.New()
@@ -474,6 +484,7 @@ func (s *BackupService) CreateBackup(ctx context.Context, triggeredBy string, ex
 		StartedAt:   now.Format(time.RFC3339),
 		ExpiresAt:   expiresAt,
 	}
+	s.recordCredentialKeyIDs(ctx, record)
 
 	// 流式执行: pg_dump -> gzip -> S3 upload
 	dumpReader, err := s.dumper.Dump(ctx)
@@ -598,6 +609,7 @@ func (s *BackupService) StartBackup(ctx context.Context, triggeredBy string, exp
 		ExpiresAt:   expiresAt,
 		Progress:    "pending",
 	}
+	s.recordCredentialKeyIDs(ctx, record)
 
 	if err := s.saveRecord(ctx, record); err != nil {
 		return nil, fmt.Errorf("save initial record: %w", err)
@@ -729,6 +741,9 @@ func (s *BackupService) RestoreBackup(ctx context.Context, backupID string) erro
 	if record.Status != "completed" {
 		return infraerrors.BadRequest("BACKUP_NOT_COMPLETED", "can only restore from a completed backup")
 	}
+	if err := s.checkCredentialKeys(record); err != nil {
+		return err
+	}
 
 	s3Cfg, err := s.loadS3Config(ctx)
 	if err != nil {
@@ -757,6 +772,7 @@ func (s *BackupService) RestoreBackup(ctx context.Context, backupID string) erro
 	if err := s.dumper.Restore(ctx, gzReader); err != nil {
 		return fmt.Errorf("pg restore: %w", err)
 	}
+	s.reencryptAfterRestore()
 
 	return nil
 }
@@ -792,6 +808,9 @@ func (s *BackupService) StartRestore(ctx context.Context, backupID string) (*Bac
 	if record.Status != "completed" {
 		return nil, infraerrors.BadRequest("BACKUP_NOT_COMPLETED", "can only restore from a completed backup")
 	}
+	if err := s.checkCredentialKeys(record); err != nil {
+		return nil, err
+	}
 
 	s3Cfg, err := s.loadS3Config(ctx)
 	if err != nil {
@@ -865,6 +884,43 @@ func (s *BackupService) executeRestore(record *BackupRecord, objectStore BackupO
 	if err := s.saveRecord(context.Background(), record); err != nil {
 		logger.LegacyPrintf("service.backup", "[Backup] 保存恢复记录失败: %v", err)
 	}
+	s.reencryptAfterRestore()
+}
+
+// recordCredentialKeyIDs 记录备份中账号凭证密文依赖的主密钥（失败不影响备份）
+func (s *BackupService) recordCredentialKeyIDs(ctx context.Context, record *BackupRecord) {
+	if s.credentialEncryption == nil {
+		return
+	}
+	ids, err := s.credentialEncryption.KeyIDsInUse(ctx)
+	if err != nil {
+		logger.LegacyPrintf("service.backup", "[Backup] 读取凭证密钥 ID 失败: %v", err)
+		return
+	}
+	record.CredentialKeyIDs = ids
+}
+
+// checkCredentialKeys 恢复前确认备份依赖的凭证主密钥均已配置，否则恢复后账号凭证无法解密
+func (s *BackupService) checkCredentialKeys(record *BackupRecord) error {
+	if s.credentialEncryption == nil || len(record.CredentialKeyIDs) == 0 {
+		return nil
+	}
+	if missing := s.credentialEncryption.MissingKeyIDs(record.CredentialKeyIDs); len(missing) > 0 {
+		return infraerrors.BadRequest("BACKUP_CREDENTIAL_KEY_MISSING",
+			fmt.Sprintf("backup requires credential encryption keys that are not configured: %s", strings.Join(missing, ", ")))
+	}
+	return nil
+}
+
+// reencryptAfterRestore 恢复的备份可能早于启用加密或使用旧主密钥，恢复后重新加密
+func (s *BackupService) reencryptAfterRestore() {
+	if s.credentialEncryption == nil {
+		return
+	}
+	if _, err := s.credentialEncryption.StartReencrypt(CredentialReencryptTriggerRestore); err != nil &&
+		!errors.Is(err, ErrCredentialEncryptionDisabled) {
+		logger.LegacyPrintf("service.backup", "[Backup] 恢复后重新加密凭证未启动: %v", err)
+	}
 }
 
 // ─── 备份记录管理 ───
@@ -956,6 +1012,18 @@ func (s *BackupService) GetBackupDownloadURL(ctx context.Context, backupID strin
 	return url, nil
 }
 
+// ObjectStore 返回数据备份使用的 S3 对象存储，供其他需要对象存储的功能复用同一份配置
+func (s *BackupService) ObjectStore(ctx context.Context) (BackupObjectStore, error) {
+	cfg, err := s.loadS3Config(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil || !cfg.IsConfigured() {
+		return nil, ErrBackupS3NotConfigured
+	}
+	return s.getOrCreateStore(ctx, cfg)
+}
+
 // ─── 内部方法 ───
 
 func (s *BackupService) loadS3Config(ctx context.Context) (*BackupS3Config, error) {
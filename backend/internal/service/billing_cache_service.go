@@ -847,6 +847,16 @@ func (b *billingCircuitBreaker) OnSuccess() {
 	}
 }
 
+// CircuitBreakerState 返回计费熔断器状态：closed/open/half-open；未启用熔断时返回 disabled
+func (s *BillingCacheService) CircuitBreakerState() string {
+	if s == nil || s.circuitBreaker == nil {
+		return "disabled"
+	}
+	s.circuitBreaker.mu.Lock()
+	defer s.circuitBreaker.mu.Unlock()
+	return circuitStateString(s.circuitBreaker.state)
+}
+
 func circuitStateString(state billingCircuitBreakerState) string {
 	switch state {
 	case billingCircuitClosed:
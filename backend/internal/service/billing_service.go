@@ -270,6 +270,20 @@ func (s *BillingService) initFallbackPricing() {
 		SupportsCacheBreakdown:         false,
 	}
 	s.fallbackPrices["gpt-5.3-codex"] = s.fallbackPrices["gpt-5.1-codex"]
+
+	// Embedding 模型只有输入 token，输出价格为 0
+	s.fallbackPrices["text-embedding-3-small"] = &ModelPricing{
+		InputPricePerToken: 0.02e-6, // $0.02 per MTok
+	}
+	s.fallbackPrices["text-embedding-3-large"] = &ModelPricing{
+		InputPricePerToken: 0.13e-6, // $0.13 per MTok
+	}
+	s.fallbackPrices["text-embedding-ada-002"] = &ModelPricing{
+		InputPricePerToken: 0.1e-6, // $0.10 per MTok
+	}
+	s.fallbackPrices["gemini-embedding-001"] = &ModelPricing{
+		InputPricePerToken: 0.15e-6, // $0.15 per MTok
+	}
 }
 
 // getFallbackPricing 根据模型系列获取回退价格
@@ -305,6 +319,18 @@ func (s *BillingService) getFallbackPricing(model string) *ModelPricing {
 	if strings.Contains(modelLower, "gemini-3.1-pro") || strings.Contains(modelLower, "gemini-3-1-pro") {
 		return s.fallbackPrices["gemini-3.1-pro"]
 	}
+	if strings.Contains(modelLower, "embedding") {
+		switch {
+		case strings.Contains(modelLower, "3-large"):
+			return s.fallbackPrices["text-embedding-3-large"]
+		case strings.Contains(modelLower, "ada-002"):
+			return s.fallbackPrices["text-embedding-ada-002"]
+		case strings.Contains(modelLower, "gemini") || strings.Contains(modelLower, "text-embedding-00"):
+			return s.fallbackPrices["gemini-embedding-001"]
+		default:
+			return s.fallbackPrices["text-embedding-3-small"]
+		}
+	}
 
 	// OpenAI 仅匹配已知 GPT-5/Codex 族，避免未知 OpenAI 型号误计价。
 	if strings.Contains(modelLower, "gpt-5") || strings.Contains(modelLower, "codex") {
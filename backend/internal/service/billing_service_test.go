@@ -240,6 +240,11 @@ func TestGetFallbackPricing_FamilyMatching(t *testing.T) {
 		{name: "openai gpt5.1 codex max alias", model: "gpt-5.1-codex-max", expectedInput: 1.5e-6},
 		{name: "openai codex mini latest alias", model: "codex-mini-latest", expectedInput: 1.5e-6},
 		{name: "openai unknown no fallback", model: "gpt-unknown-model", expectNilPricing: true},
+		{name: "openai embedding small", model: "text-embedding-3-small", expectedInput: 0.02e-6},
+		{name: "openai embedding large", model: "text-embedding-3-large", expectedInput: 0.13e-6},
+		{name: "openai embedding ada", model: "text-embedding-ada-002", expectedInput: 0.1e-6},
+		{name: "gemini embedding", model: "gemini-embedding-001", expectedInput: 0.15e-6},
+		{name: "gemini legacy embedding", model: "text-embedding-004", expectedInput: 0.15e-6},
 		{name: "non supported family", model: "qwen-max", expectNilPricing: true},
 	}
 
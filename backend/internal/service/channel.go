@@ -14,6 +14,9 @@ const (
 	BillingModeToken      BillingMode = "token"       // 按 token 区间计费
 	BillingModePerRequest BillingMode = "per_request" // 按次计费（支持上下文窗口分层）
 	BillingModeImage      BillingMode = "image"       // 图片计费（当前按次，预留 token 计费）
+
+	// BillingModeResponseCache 仅用于 usage_logs：命中响应缓存的请求，不可用于渠道定价
+	BillingModeResponseCache BillingMode = "response_cache"
 )
 
 // IsValid 检查 BillingMode 是否为合法值
@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"os"
 	"strconv"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -86,6 +87,22 @@ const (
 // ConcurrencyService manages concurrent request limiting for accounts and users
 type ConcurrencyService struct {
 	cache ConcurrencyCache
+
+	// 本实例持有的槽位统计（Redis 为全局视图，这里仅记录当前进程），供指标导出使用
+	accountSlots   sync.Map // accountID -> *localSlotUsage
+	userSlotsInUse atomic.Int64
+}
+
+type localSlotUsage struct {
+	inUse          atomic.Int64
+	maxConcurrency atomic.Int64
+}
+
+// AccountSlotUsage 本实例持有的账号槽位快照
+type AccountSlotUsage struct {
+	AccountID      int64
+	InUse          int64
+	MaxConcurrency int64
 }
 
 // NewConcurrencyService creates a new ConcurrencyService
@@ -144,9 +161,14 @@ func (s *ConcurrencyService) AcquireAccountSlot(ctx context.Context, accountID i
 	}
 
 	if acquired {
+		usage := s.trackAccountSlot(accountID, maxConcurrency)
+		var released atomic.Bool
 		return &AcquireResult{
 			Acquired: true,
 			ReleaseFunc: func() {
+				if !released.Swap(true) {
+					usage.inUse.Add(-1)
+				}
 				bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer cancel()
 				if err := s.cache.ReleaseAccountSlot(bgCtx, accountID, requestID); err != nil {
@@ -183,9 +205,14 @@ func (s *ConcurrencyService) AcquireUserSlot(ctx context.Context, userID int64,
 	}
 
 	if acquired {
+		s.userSlotsInUse.Add(1)
+		var released atomic.Bool
 		return &AcquireResult{
 			Acquired: true,
 			ReleaseFunc: func() {
+				if !released.Swap(true) {
+					s.userSlotsInUse.Add(-1)
+				}
 				bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 				defer cancel()
 				if err := s.cache.ReleaseUserSlot(bgCtx, userID, requestID); err != nil {
@@ -201,6 +228,40 @@ func (s *ConcurrencyService) AcquireUserSlot(ctx context.Context, userID int64,
 	}, nil
 }
 
+func (s *ConcurrencyService) trackAccountSlot(accountID int64, maxConcurrency int) *localSlotUsage {
+	v, _ := s.accountSlots.LoadOrStore(accountID, &localSlotUsage{})
+	usage := v.(*localSlotUsage)
+	usage.maxConcurrency.Store(int64(maxConcurrency))
+	usage.inUse.Add(1)
+	return usage
+}
+
+// LocalAccountSlotUsage 返回本实例持有的账号槽位快照（仅包含曾获取过槽位的账号）
+func (s *ConcurrencyService) LocalAccountSlotUsage() []AccountSlotUsage {
+	if s == nil {
+		return nil
+	}
+	var out []AccountSlotUsage
+	s.accountSlots.Range(func(k, v any) bool {
+		usage := v.(*localSlotUsage)
+		out = append(out, AccountSlotUsage{
+			AccountID:      k.(int64),
+			InUse:          usage.inUse.Load(),
+			MaxConcurrency: usage.maxConcurrency.Load(),
+		})
+		return true
+	})
+	return out
+}
+
+// LocalUserSlotsInUse 返回本实例持有的用户槽位总数
+func (s *ConcurrencyService) LocalUserSlotsInUse() int64 {
+	if s == nil {
+		return 0
+	}
+	return s.userSlotsInUse.Load()
+}
+
 // ============================================
 // Wait Queue Count Methods
 // ============================================
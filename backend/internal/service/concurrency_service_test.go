@@ -335,3 +335,27 @@ func TestIncrementAccountWaitCount_NilCache(t *testing.T) {
 	require.NoError(t, err)
 	require.True(t, allowed)
 }
+
+func TestAcquireSlots_TracksLocalUsage(t *testing.T) {
+	cache := &stubConcurrencyCacheForTest{acquireResult: true}
+	svc := NewConcurrencyService(cache)
+
+	r1, err := svc.AcquireAccountSlot(context.Background(), 7, 3)
+	require.NoError(t, err)
+	r2, err := svc.AcquireAccountSlot(context.Background(), 7, 3)
+	require.NoError(t, err)
+	u1, err := svc.AcquireUserSlot(context.Background(), 1, 2)
+	require.NoError(t, err)
+
+	require.Equal(t, []AccountSlotUsage{{AccountID: 7, InUse: 2, MaxConcurrency: 3}}, svc.LocalAccountSlotUsage())
+	require.Equal(t, int64(1), svc.LocalUserSlotsInUse())
+
+	r1.ReleaseFunc()
+	r1.ReleaseFunc() // 重复释放不应重复扣减
+	u1.ReleaseFunc()
+	require.Equal(t, []AccountSlotUsage{{AccountID: 7, InUse: 1, MaxConcurrency: 3}}, svc.LocalAccountSlotUsage())
+	require.Equal(t, int64(0), svc.LocalUserSlotsInUse())
+
+	r2.ReleaseFunc()
+	require.Equal(t, int64(0), svc.LocalAccountSlotUsage()[0].InUse)
+}
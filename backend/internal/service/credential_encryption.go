@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrCredentialEncryptionDisabled  = infraerrors.BadRequest("CREDENTIAL_ENCRYPTION_DISABLED", "credential encryption is not enabled (credential_encryption.active_key_id is empty)")
+	ErrCredentialReencryptInProgress = infraerrors.Conflict("CREDENTIAL_REENCRYPT_IN_PROGRESS", "a credential re-encryption job is already running")
+	// ErrCredentialDecrypt 凭证解密失败（主密钥缺失或密文损坏）
+	ErrCredentialDecrypt = errors.New("credential decrypt failed")
+)
+
+// 重新加密任务触发来源
+const (
+	CredentialReencryptTriggerStartup = "startup"
+	CredentialReencryptTriggerManual  = "manual"
+	CredentialReencryptTriggerRestore = "restore"
+)
+
+// 重新加密任务状态
+const (
+	CredentialReencryptStatusRunning   = "running"
+	CredentialReencryptStatusCompleted = "completed"
+	CredentialReencryptStatusFailed    = "failed"
+)
+
+// credentialReencryptMaxRetries 行被并发修改时重新读取并重试的次数
+const credentialReencryptMaxRetries = 3
+
+// CredentialCipher 账号凭证敏感字段的信封加解密（实现见 repository.NewCredentialCipher）。
+// 所有方法都返回新的 map，不修改入参。
+type CredentialCipher interface {
+	// ActiveKeyID 当前用于加密的主密钥 ID，为空表示不加密新写入的凭证
+	ActiveKeyID() string
+	// KeyIDs 已配置的主密钥 ID（有序）
+	KeyIDs() []string
+	// HasKey 密钥环中是否存在该主密钥
+	HasKey(keyID string) bool
+	// EncryptCredentials 明文敏感字段用当前主密钥加密；旧主密钥的密文仅重新包裹数据密钥。
+	// 密文绑定 accountID，只能由同一账号解密
+	EncryptCredentials(accountID int64, credentials map[string]any) (map[string]any, error)
+	// DecryptCredentials 解密所有密文字段，明文字段原样保留
+	DecryptCredentials(accountID int64, credentials map[string]any) (map[string]any, error)
+	// NeedsReencrypt 是否存在明文敏感字段或非当前主密钥加密的字段
+	NeedsReencrypt(credentials map[string]any) bool
+	// CanDecrypt 所有密文字段格式正确且主密钥已配置（不执行解密，供调度快照快速剔除无法解密的账号）
+	CanDecrypt(credentials map[string]any) bool
+}
+
+// AccountCredentialRecord 重新加密任务读取的账号凭证原始数据（可能为密文）
+type AccountCredentialRecord struct {
+	AccountID   int64
+	Credentials map[string]any
+}
+
+// CredentialEncryptionRepository 凭证加密迁移 / 轮换所需的数据访问
+type CredentialEncryptionRepository interface {
+	// ListAccountCredentials 按 ID 升序读取 afterID 之后的账号原始凭证（含软删除账号，保证备份中不残留明文）
+	ListAccountCredentials(ctx context.Context, afterID int64, limit int) ([]AccountCredentialRecord, error)
+	// ReplaceAccountCredentials 仅当当前凭证仍等于 old 时替换为 updated，返回是否替换成功
+	ReplaceAccountCredentials(ctx context.Context, accountID int64, old, updated map[string]any) (bool, error)
+	// ListCredentialKeyIDsInUse 返回账号凭证密文中出现过的主密钥 ID
+	ListCredentialKeyIDsInUse(ctx context.Context) ([]string, error)
+	// NotifyAccountCredentialsChanged 通知调度快照刷新这些账号（快照中的密文仍引用旧主密钥）
+	NotifyAccountCredentialsChanged(ctx context.Context, accountIDs []int64) error
+}
+
+// CredentialReencryptJob 重新加密任务进度（实例内存）
+type CredentialReencryptJob struct {
+	Status      string     `json:"status"`
+	TriggeredBy string     `json:"triggered_by"`
+	KeyID       string     `json:"key_id"`
+	Scanned     int        `json:"scanned"`
+	Updated     int        `json:"updated"`
+	Skipped     int        `json:"skipped"`
+	Failed      int        `json:"failed"`
+	LastError   string     `json:"last_error,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// CredentialEncryptionStatus 凭证加密状态
+type CredentialEncryptionStatus struct {
+	Enabled     bool   `json:"enabled"`
+	ActiveKeyID string `json:"active_key_id"`
+	// KeyIDs 已配置的主密钥
+	KeyIDs []string `json:"key_ids"`
+	// KeyIDsInUse 数据库或调度快照中仍被密文引用的主密钥；不在此列表中的旧密钥可以安全移除
+	KeyIDsInUse []string `json:"key_ids_in_use"`
+	// MissingKeyIDs 被密文引用但未配置的主密钥（对应账号无法解密）
+	MissingKeyIDs []string                `json:"missing_key_ids"`
+	Job           *CredentialReencryptJob `json:"job,omitempty"`
+}
+
+// CredentialEncryptionService 凭证加密的明文迁移与密钥轮换
+type CredentialEncryptionService struct {
+	repo   CredentialEncryptionRepository
+	cipher CredentialCipher
+	// schedulerCache 可选：调度快照（Redis）中同样保存账号凭证密文
+	schedulerCache SchedulerCache
+	cfg            *config.Config
+
+	jobMu sync.Mutex
+	job   *CredentialReencryptJob
+
+	wg       sync.WaitGroup
+	bgCtx    context.Context
+	bgCancel context.CancelFunc
+}
+
+// NewCredentialEncryptionService 创建凭证加密服务实例
+func NewCredentialEncryptionService(repo CredentialEncryptionRepository, cipher CredentialCipher, schedulerCache SchedulerCache, cfg *config.Config) *CredentialEncryptionService {
+	bgCtx, bgCancel := context.WithCancel(context.Background())
+	return &CredentialEncryptionService{
+		repo:           repo,
+		cipher:         cipher,
+		schedulerCache: schedulerCache,
+		cfg:            cfg,
+		bgCtx:          bgCtx,
+		bgCancel:       bgCancel,
+	}
+}
+
+// Start 按配置在后台迁移明文 / 旧密钥凭证
+func (s *CredentialEncryptionService) Start() {
+	if s.cfg == nil || !s.cfg.CredentialEncryption.MigrateOnStartup || s.cipher.ActiveKeyID() == "" {
+		return
+	}
+	if _, err := s.StartReencrypt(CredentialReencryptTriggerStartup); err != nil {
+		logger.L().Warn("credential encryption: startup migration not started", zap.Error(err))
+	}
+}
+
+func (s *CredentialEncryptionService) Stop() {
+	if s == nil {
+		return
+	}
+	s.bgCancel()
+	s.wg.Wait()
+}
+
+// StartReencrypt 异步启动重新加密任务：明文敏感字段加密为当前主密钥，旧主密钥密文重新包裹数据密钥
+func (s *CredentialEncryptionService) StartReencrypt(triggeredBy string) (*CredentialReencryptJob, error) {
+	activeKeyID := s.cipher.ActiveKeyID()
+	if activeKeyID == "" {
+		return nil, ErrCredentialEncryptionDisabled
+	}
+
+	s.jobMu.Lock()
+	if s.job != nil && s.job.Status == CredentialReencryptStatusRunning {
+		s.jobMu.Unlock()
+		return nil, ErrCredentialReencryptInProgress
+	}
+	job := &CredentialReencryptJob{
+		Status:      CredentialReencryptStatusRunning,
+		TriggeredBy: triggeredBy,
+		KeyID:       activeKeyID,
+		StartedAt:   time.Now(),
+	}
+	s.job = job
+	snapshot := *job
+	s.jobMu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.runReencrypt(s.bgCtx)
+	}()
+	return &snapshot, nil
+}
+
+func (s *CredentialEncryptionService) runReencrypt(ctx context.Context) {
+	batchSize := 200
+	if s.cfg != nil && s.cfg.CredentialEncryption.ReencryptBatchSize > 0 {
+		batchSize = s.cfg.CredentialEncryption.ReencryptBatchSize
+	}
+
+	var runErr error
+	var afterID int64
+	for {
+		if err := ctx.Err(); err != nil {
+			runErr = err
+			break
+		}
+		records, err := s.repo.ListAccountCredentials(ctx, afterID, batchSize)
+		if err != nil {
+			runErr = err
+			break
+		}
+		updatedIDs := make([]int64, 0, len(records))
+		for _, rec := range records {
+			afterID = rec.AccountID
+			if s.reencryptRecord(ctx, rec) {
+				updatedIDs = append(updatedIDs, rec.AccountID)
+			}
+		}
+		// 快照中的密文仍由旧主密钥包裹，按批刷新，避免移除旧主密钥后快照账号无法解密
+		if len(updatedIDs) > 0 {
+			if err := s.repo.NotifyAccountCredentialsChanged(ctx, updatedIDs); err != nil {
+				logger.L().Warn("credential encryption: refresh scheduler snapshots failed",
+					zap.Int("accounts", len(updatedIDs)),
+					zap.Error(err),
+				)
+			}
+		}
+		if len(records) < batchSize {
+			break
+		}
+	}
+
+	now := time.Now()
+	s.jobMu.Lock()
+	job := s.job
+	job.FinishedAt = &now
+	job.Status = CredentialReencryptStatusCompleted
+	if runErr != nil {
+		job.Status = CredentialReencryptStatusFailed
+		job.LastError = runErr.Error()
+	}
+	result := *job
+	s.jobMu.Unlock()
+
+	logger.L().Info("credential encryption: re-encryption finished",
+		zap.String("status", result.Status),
+		zap.String("triggered_by", result.TriggeredBy),
+		zap.String("key_id", result.KeyID),
+		zap.Int("scanned", result.Scanned),
+		zap.Int("updated", result.Updated),
+		zap.Int("skipped", result.Skipped),
+		zap.Int("failed", result.Failed),
+		zap.String("last_error", result.LastError),
+	)
+}
+
+// reencryptRecord 处理单个账号；行被并发修改（如令牌刷新）时重新读取后重试。返回是否写入了新密文。
+func (s *CredentialEncryptionService) reencryptRecord(ctx context.Context, rec AccountCredentialRecord) bool {
+	outcome := "skipped"
+	var lastErr error
+	for attempt := 0; attempt < credentialReencryptMaxRetries; attempt++ {
+		if !s.cipher.NeedsReencrypt(rec.Credentials) {
+			// 已是当前主密钥（含并发写入方已按当前主密钥加密的情况）
+			outcome = "unchanged"
+			break
+		}
+		updated, err := s.cipher.EncryptCredentials(rec.AccountID, rec.Credentials)
+		if err != nil {
+			outcome, lastErr = "failed", err
+			break
+		}
+		swapped, err := s.repo.ReplaceAccountCredentials(ctx, rec.AccountID, rec.Credentials, updated)
+		if err != nil {
+			outcome, lastErr = "failed", err
+			break
+		}
+		if swapped {
+			outcome = "updated"
+			break
+		}
+		fresh, err := s.repo.ListAccountCredentials(ctx, rec.AccountID-1, 1)
+		if err != nil {
+			outcome, lastErr = "failed", err
+			break
+		}
+		if len(fresh) == 0 || fresh[0].AccountID != rec.AccountID {
+			outcome = "unchanged"
+			break
+		}
+		rec = fresh[0]
+	}
+
+	s.jobMu.Lock()
+	defer s.jobMu.Unlock()
+	s.job.Scanned++
+	switch outcome {
+	case "updated":
+		s.job.Updated++
+	case "skipped":
+		s.job.Skipped++
+	case "failed":
+		s.job.Failed++
+		s.job.LastError = lastErr.Error()
+		logger.L().Warn("credential encryption: re-encrypt account failed",
+			zap.Int64("account_id", rec.AccountID),
+			zap.Error(lastErr),
+		)
+	}
+	return outcome == "updated"
+}
+
+// Status 返回密钥配置、数据库中引用的密钥以及最近一次重新加密任务
+func (s *CredentialEncryptionService) Status(ctx context.Context) (*CredentialEncryptionStatus, error) {
+	inUse, err := s.KeyIDsInUse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	status := &CredentialEncryptionStatus{
+		Enabled:       s.cipher.ActiveKeyID() != "",
+		ActiveKeyID:   s.cipher.ActiveKeyID(),
+		KeyIDs:        s.cipher.KeyIDs(),
+		KeyIDsInUse:   inUse,
+		MissingKeyIDs: s.MissingKeyIDs(inUse),
+	}
+	if status.KeyIDs == nil {
+		status.KeyIDs = []string{}
+	}
+	s.jobMu.Lock()
+	if s.job != nil {
+		job := *s.job
+		status.Job = &job
+	}
+	s.jobMu.Unlock()
+	return status, nil
+}
+
+// KeyIDsInUse 返回数据库与调度快照中账号凭证密文引用的主密钥 ID（有序、去重）
+func (s *CredentialEncryptionService) KeyIDsInUse(ctx context.Context) ([]string, error) {
+	ids, err := s.repo.ListCredentialKeyIDsInUse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.schedulerCache != nil {
+		cached, err := s.schedulerCache.ListCredentialKeyIDs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, cached...)
+	}
+	seen := make(map[string]struct{}, len(ids))
+	out := []string{}
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// MissingKeyIDs 返回 keyIDs 中未在密钥环配置的主密钥
+func (s *CredentialEncryptionService) MissingKeyIDs(keyIDs []string) []string {
+	missing := []string{}
+	for _, id := range keyIDs {
+		if !s.cipher.HasKey(id) {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,269 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeCredentialCipher 以 "enc:<key_id>:<value>" 模拟密文，仅 api_key 为敏感字段
+type fakeCredentialCipher struct {
+	active string
+	keys   map[string]bool
+}
+
+func (c *fakeCredentialCipher) ActiveKeyID() string { return c.active }
+
+func (c *fakeCredentialCipher) KeyIDs() []string {
+	ids := make([]string, 0, len(c.keys))
+	for id := range c.keys {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func (c *fakeCredentialCipher) HasKey(keyID string) bool { return c.keys[keyID] }
+
+func (c *fakeCredentialCipher) EncryptCredentials(_ int64, credentials map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(credentials))
+	for k, v := range credentials {
+		out[k] = v
+	}
+	if v, ok := out["api_key"].(string); ok {
+		if parts := strings.SplitN(v, ":", 3); len(parts) == 3 && parts[0] == "enc" {
+			v = parts[2]
+		}
+		out["api_key"] = "enc:" + c.active + ":" + v
+	}
+	return out, nil
+}
+
+func (c *fakeCredentialCipher) DecryptCredentials(_ int64, credentials map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(credentials))
+	for k, v := range credentials {
+		if s, ok := v.(string); ok {
+			if parts := strings.SplitN(s, ":", 3); len(parts) == 3 && parts[0] == "enc" {
+				v = parts[2]
+			}
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+func (c *fakeCredentialCipher) NeedsReencrypt(credentials map[string]any) bool {
+	v, ok := credentials["api_key"].(string)
+	return ok && !strings.HasPrefix(v, "enc:"+c.active+":")
+}
+
+func (c *fakeCredentialCipher) CanDecrypt(credentials map[string]any) bool {
+	for _, v := range credentials {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if parts := strings.SplitN(s, ":", 3); len(parts) == 3 && parts[0] == "enc" && !c.keys[parts[1]] {
+			return false
+		}
+	}
+	return true
+}
+
+type credentialEncryptionRepoStub struct {
+	mu        sync.Mutex
+	rows      map[int64]map[string]any
+	order     []int64
+	keyIDs    []string
+	conflicts map[int64]int // 模拟并发写入：该账号前 N 次替换失败
+	notified  [][]int64
+}
+
+func (r *credentialEncryptionRepoStub) ListAccountCredentials(ctx context.Context, afterID int64, limit int) ([]AccountCredentialRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := []AccountCredentialRecord{}
+	for _, id := range r.order {
+		if id <= afterID || len(out) >= limit {
+			continue
+		}
+		out = append(out, AccountCredentialRecord{AccountID: id, Credentials: r.rows[id]})
+	}
+	return out, nil
+}
+
+func (r *credentialEncryptionRepoStub) ReplaceAccountCredentials(ctx context.Context, accountID int64, old, updated map[string]any) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conflicts[accountID] > 0 {
+		r.conflicts[accountID]--
+		return false, nil
+	}
+	r.rows[accountID] = updated
+	return true, nil
+}
+
+func (r *credentialEncryptionRepoStub) ListCredentialKeyIDsInUse(ctx context.Context) ([]string, error) {
+	return r.keyIDs, nil
+}
+
+func (r *credentialEncryptionRepoStub) NotifyAccountCredentialsChanged(ctx context.Context, accountIDs []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notified = append(r.notified, append([]int64(nil), accountIDs...))
+	return nil
+}
+
+// credentialSchedulerCacheStub 调度快照缓存：返回固定账号与密文主密钥
+type credentialSchedulerCacheStub struct {
+	SchedulerCache
+	accounts []*Account
+	keyIDs   []string
+}
+
+func (c *credentialSchedulerCacheStub) GetSnapshot(ctx context.Context, bucket SchedulerBucket) ([]*Account, bool, error) {
+	return c.accounts, true, nil
+}
+
+func (c *credentialSchedulerCacheStub) GetAccount(ctx context.Context, accountID int64) (*Account, error) {
+	for _, account := range c.accounts {
+		if account.ID == accountID {
+			return account, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *credentialSchedulerCacheStub) ListCredentialKeyIDs(ctx context.Context) ([]string, error) {
+	return c.keyIDs, nil
+}
+
+func newCredentialEncryptionTestService(repo *credentialEncryptionRepoStub, cipher *fakeCredentialCipher) *CredentialEncryptionService {
+	cfg := &config.Config{CredentialEncryption: config.CredentialEncryptionConfig{ReencryptBatchSize: 2}}
+	return NewCredentialEncryptionService(repo, cipher, nil, cfg)
+}
+
+func TestCredentialEncryptionService_Reencrypt(t *testing.T) {
+	repo := &credentialEncryptionRepoStub{
+		rows: map[int64]map[string]any{
+			1: {"api_key": "plain"},
+			2: {"api_key": "enc:k1:old"},
+			3: {"api_key": "enc:k2:current"},
+			4: {"base_url": "https://example.com"},
+			5: {"api_key": "busy"},
+		},
+		order:     []int64{1, 2, 3, 4, 5},
+		conflicts: map[int64]int{5: 1},
+	}
+	svc := newCredentialEncryptionTestService(repo, &fakeCredentialCipher{active: "k2", keys: map[string]bool{"k1": true, "k2": true}})
+
+	job, err := svc.StartReencrypt(CredentialReencryptTriggerManual)
+	require.NoError(t, err)
+	require.Equal(t, CredentialReencryptStatusRunning, job.Status)
+	svc.wg.Wait()
+
+	status, err := svc.Status(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, status.Job)
+	require.Equal(t, CredentialReencryptStatusCompleted, status.Job.Status)
+	require.Equal(t, 5, status.Job.Scanned)
+	require.Equal(t, 3, status.Job.Updated)
+	require.Zero(t, status.Job.Failed)
+
+	require.Equal(t, "enc:k2:plain", repo.rows[1]["api_key"])
+	require.Equal(t, "enc:k2:old", repo.rows[2]["api_key"])
+	require.Equal(t, "enc:k2:current", repo.rows[3]["api_key"])
+	require.Equal(t, "enc:k2:busy", repo.rows[5]["api_key"], "并发修改后应重新读取并重试")
+	require.Equal(t, [][]int64{{1, 2}, {5}}, repo.notified, "重新加密的账号应按批刷新调度快照")
+}
+
+func TestCredentialEncryptionService_Disabled(t *testing.T) {
+	svc := newCredentialEncryptionTestService(&credentialEncryptionRepoStub{}, &fakeCredentialCipher{})
+	_, err := svc.StartReencrypt(CredentialReencryptTriggerManual)
+	require.ErrorIs(t, err, ErrCredentialEncryptionDisabled)
+}
+
+func TestCredentialEncryptionService_MissingKeyIDs(t *testing.T) {
+	repo := &credentialEncryptionRepoStub{keyIDs: []string{"k2", "k1"}}
+	svc := newCredentialEncryptionTestService(repo, &fakeCredentialCipher{active: "k2", keys: map[string]bool{"k2": true}})
+
+	status, err := svc.Status(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"k1", "k2"}, status.KeyIDsInUse)
+	require.Equal(t, []string{"k1"}, status.MissingKeyIDs)
+}
+
+func TestCredentialEncryptionService_KeyIDsInUseIncludesSchedulerCache(t *testing.T) {
+	repo := &credentialEncryptionRepoStub{keyIDs: []string{"k2"}}
+	cache := &credentialSchedulerCacheStub{keyIDs: []string{"k1", "k2"}}
+	svc := NewCredentialEncryptionService(repo, &fakeCredentialCipher{active: "k2", keys: map[string]bool{"k1": true, "k2": true}}, cache, nil)
+
+	ids, err := svc.KeyIDsInUse(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"k1", "k2"}, ids, "快照中仍引用旧主密钥时不能视为可移除")
+}
+
+func TestSchedulerSnapshotService_DecryptsOnlySelectedAccount(t *testing.T) {
+	decrypted := 0
+	cipher := &fakeCredentialCipher{active: "k2", keys: map[string]bool{"k2": true}}
+	cache := &credentialSchedulerCacheStub{accounts: []*Account{
+		{ID: 1, Credentials: map[string]any{"api_key": "enc:k2:first", "base_url": "https://a.example"}},
+		{ID: 2, Credentials: map[string]any{"api_key": "enc:k1:removed"}},
+		{ID: 3, Credentials: map[string]any{"api_key": "enc:k2:third"}},
+	}}
+	svc := NewSchedulerSnapshotService(cache, nil, nil, nil, &countingCredentialCipher{fakeCredentialCipher: cipher, calls: &decrypted}, nil)
+
+	accounts, _, err := svc.ListSchedulableAccounts(context.Background(), nil, PlatformOpenAI, false)
+	require.NoError(t, err)
+	require.Len(t, accounts, 2, "主密钥缺失的账号应被跳过")
+	require.Equal(t, int64(1), accounts[0].ID)
+	require.Equal(t, int64(3), accounts[1].ID)
+	require.Zero(t, decrypted, "读取快照时不应解密")
+
+	selected := accounts[1]
+	require.Equal(t, "third", selected.GetCredential("api_key"))
+	require.Equal(t, "third", selected.GetCredential("api_key"))
+	require.Equal(t, 1, decrypted, "仅被选中的账号解密一次")
+
+	account, err := svc.GetAccount(context.Background(), 2)
+	require.NoError(t, err)
+	require.Nil(t, account, "无法解密的单账号快照应视为未命中")
+}
+
+type countingCredentialCipher struct {
+	*fakeCredentialCipher
+	calls *int
+}
+
+func (c *countingCredentialCipher) DecryptCredentials(accountID int64, credentials map[string]any) (map[string]any, error) {
+	*c.calls++
+	return c.fakeCredentialCipher.DecryptCredentials(accountID, credentials)
+}
+
+func TestBackupService_RestoreBackup_CredentialKeyMissing(t *testing.T) {
+	repo := newMockSettingRepo()
+	seedS3Config(t, repo)
+	dumper := &mockDumper{dumpData: []byte("data")}
+	svc := newTestBackupService(repo, dumper, newMockObjectStore())
+
+	credRepo := &credentialEncryptionRepoStub{keyIDs: []string{"k1"}}
+	svc.SetCredentialEncryption(newCredentialEncryptionTestService(credRepo,
+		&fakeCredentialCipher{active: "k1", keys: map[string]bool{"k1": true}}))
+
+	record, err := svc.CreateBackup(context.Background(), "manual", 14)
+	require.NoError(t, err)
+	require.Equal(t, []string{"k1"}, record.CredentialKeyIDs)
+
+	// 轮换后移除了 k1：恢复该备份会导致凭证无法解密，应拒绝
+	svc.SetCredentialEncryption(newCredentialEncryptionTestService(credRepo,
+		&fakeCredentialCipher{active: "k2", keys: map[string]bool{"k2": true}}))
+	err = svc.RestoreBackup(context.Background(), record.ID)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "k1")
+	require.Nil(t, dumper.restored)
+}
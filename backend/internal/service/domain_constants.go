@@ -33,6 +33,10 @@ const (
 	AccountTypeAPIKey     = domain.AccountTypeAPIKey     // API Key类型账号
 	AccountTypeUpstream   = domain.AccountTypeUpstream   // 上游透传类型账号（通过 Base URL + API Key 连接上游）
 	AccountTypeBedrock    = domain.AccountTypeBedrock    // AWS Bedrock 类型账号（通过 SigV4 签名或 API Key 连接 Bedrock，由 credentials.auth_mode 区分）
+	AccountTypeAzure      = domain.AccountTypeAzure      // Azure OpenAI 类型账号（资源 endpoint + api-key 头 + api-version，模型按部署名路由）
+	AccountTypeVertex     = domain.AccountTypeVertex     // Google Vertex AI 上的 Claude（Service Account JWT 换取 access token，rawPredict/streamRawPredict）
+
+	AccountTypeOpenAICompatible = domain.AccountTypeOpenAICompatible // 通用 OpenAI 兼容上游（Base URL + API Key，Chat Completions 协议，如 vLLM/SGLang/DeepSeek）
 )
 
 // Redeem type constants
@@ -71,6 +75,9 @@ const (
 // LinuxDoConnectSyntheticEmailDomain 是 LinuxDo Connect 用户的合成邮箱后缀（RFC 保留域名）。
 const LinuxDoConnectSyntheticEmailDomain = "@linuxdo-connect.invalid"
 
+// OIDCSyntheticEmailDomain 是 OIDC/OAuth2 SSO 用户的合成邮箱后缀（RFC 保留域名）。
+const OIDCSyntheticEmailDomain = "@oidc-sso.invalid"
+
 // Setting keys
 const (
 	// 注册设置
@@ -222,3 +229,6 @@ const (
 
 // AdminAPIKeyPrefix is the prefix for admin API keys (distinct from user "sk-" keys).
 const AdminAPIKeyPrefix = "admin-"
+
+// AdminTokenPrefix is the prefix for named, scoped admin tokens (global keys are "admin-" + hex, so no overlap).
+const AdminTokenPrefix = "admin-tok-"
@@ -0,0 +1,59 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newImageCostGatewayService(t *testing.T, pricing []ChannelModelPricing) *GatewayService {
+	t.Helper()
+	resolver := newResolverWithChannel(t, pricing)
+	return &GatewayService{billingService: resolver.billingService, resolver: resolver}
+}
+
+func TestGatewayCalculateImageCost_ChatImageChannelPricingBillsOnePerRequest(t *testing.T) {
+	// 对话生图（generateContent）沿用渠道按次计费：不乘张数、不按尺寸层级取价
+	svc := newImageCostGatewayService(t, []ChannelModelPricing{{
+		Platform:        "anthropic",
+		Models:          []string{"claude-sonnet-4"},
+		BillingMode:     BillingModeImage,
+		PerRequestPrice: testPtrFloat64(0.05),
+	}})
+	apiKey := &APIKey{Group: &Group{ID: 100}}
+
+	cost := svc.calculateImageCost(context.Background(), &ForwardResult{ImageCount: 3, ImageSize: "4K"}, apiKey, "claude-sonnet-4", 2)
+	require.InDelta(t, 0.05, cost.TotalCost, 1e-12)
+	require.InDelta(t, 0.10, cost.ActualCost, 1e-12)
+}
+
+func TestGatewayCalculateImageCost_ImagesEndpointBillsPerImageTier(t *testing.T) {
+	svc := newImageCostGatewayService(t, []ChannelModelPricing{{
+		Platform:        "anthropic",
+		Models:          []string{"claude-sonnet-4"},
+		BillingMode:     BillingModeImage,
+		PerRequestPrice: testPtrFloat64(0.05),
+		Intervals: []PricingInterval{
+			{TierLabel: "1K", PerRequestPrice: testPtrFloat64(0.04)},
+			{TierLabel: "4K", PerRequestPrice: testPtrFloat64(0.16)},
+		},
+	}})
+	apiKey := &APIKey{Group: &Group{ID: 100}}
+
+	cost := svc.calculateImageCost(context.Background(), &ForwardResult{ImageCount: 3, ImageSize: "4k", ImagesEndpoint: true}, apiKey, "claude-sonnet-4", 1)
+	require.InDelta(t, 0.48, cost.TotalCost, 1e-12)
+}
+
+func TestGatewayCalculateImageCost_GroupPriceFallbackMultipliesCount(t *testing.T) {
+	// 无渠道定价时两条路径都使用分组图片单价 × 张数（与改动前一致）
+	svc := &GatewayService{billingService: newTestBillingServiceForResolver()}
+	apiKey := &APIKey{Group: &Group{ID: 7, ImagePrice2K: testPtrFloat64(0.1)}}
+
+	for _, imagesEndpoint := range []bool{false, true} {
+		cost := svc.calculateImageCost(context.Background(), &ForwardResult{ImageCount: 2, ImageSize: "2K", ImagesEndpoint: imagesEndpoint}, apiKey, "gemini-2.5-flash-image", 1)
+		require.InDelta(t, 0.2, cost.TotalCost, 1e-12, "images_endpoint=%v", imagesEndpoint)
+	}
+}
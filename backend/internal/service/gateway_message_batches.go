@@ -0,0 +1,113 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// supportsUpstreamMessageBatches 判断账号能否直接使用上游 Message Batches 接口。
+// 仅 Anthropic API Key 账号支持；Bedrock 批处理需要 S3 输入/输出桶，
+// Vertex 批处理需要 GCS/BigQuery，当前账号凭据均不具备，这些账号走本地执行器。
+func supportsUpstreamMessageBatches(account *Account) bool {
+	return account != nil && account.Platform == PlatformAnthropic && account.Type == AccountTypeAPIKey
+}
+
+// doMessageBatchUpstream 以 API Key 账号身份调用上游 /v1/messages/batches{suffix}。
+// clientHeader 中仅白名单头（anthropic-version / anthropic-beta 等）会被透传。
+func (s *GatewayService) doMessageBatchUpstream(
+	ctx context.Context,
+	account *Account,
+	method string,
+	suffix string,
+	body []byte,
+	clientHeader http.Header,
+) (*http.Response, error) {
+	token, tokenType, err := s.GetAccessToken(ctx, account)
+	if err != nil {
+		return nil, err
+	}
+	if tokenType != "apikey" {
+		return nil, fmt.Errorf("message batches passthrough requires apikey token, got: %s", tokenType)
+	}
+
+	validatedURL, err := s.validateUpstreamBaseURL(account.GetBaseURL())
+	if err != nil {
+		return nil, err
+	}
+	targetURL := strings.TrimRight(validatedURL, "/") + "/v1/messages/batches" + suffix
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, targetURL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range clientHeader {
+		lowerKey := strings.ToLower(strings.TrimSpace(key))
+		if !allowedHeaders[lowerKey] {
+			continue
+		}
+		wireKey := resolveWireCasing(key)
+		for _, v := range values {
+			addHeaderRaw(req.Header, wireKey, v)
+		}
+	}
+
+	req.Header.Del("authorization")
+	req.Header.Del("x-api-key")
+	req.Header.Del("x-goog-api-key")
+	req.Header.Del("cookie")
+	setHeaderRaw(req.Header, "x-api-key", token)
+	if body != nil && getHeaderRaw(req.Header, "content-type") == "" {
+		setHeaderRaw(req.Header, "content-type", "application/json")
+	}
+	if getHeaderRaw(req.Header, "anthropic-version") == "" {
+		setHeaderRaw(req.Header, "anthropic-version", "2023-06-01")
+	}
+
+	proxyURL := account.ProxyURL()
+	return s.httpUpstream.DoWithTLS(req, proxyURL, account.ID, account.Concurrency, s.tlsFPProfileService.ResolveTLSProfile(account))
+}
+
+// callMessageBatchUpstream 调用上游批处理接口并读取完整响应体（用于非结果类的小响应）。
+// 上游 4xx/5xx 以 *MessageBatchUpstreamError 返回。
+func (s *GatewayService) callMessageBatchUpstream(
+	ctx context.Context,
+	account *Account,
+	method string,
+	suffix string,
+	body []byte,
+	clientHeader http.Header,
+) ([]byte, error) {
+	resp, err := s.doMessageBatchUpstream(ctx, account, method, suffix, body, clientHeader)
+	if err != nil {
+		return nil, fmt.Errorf("message batch upstream request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	respBody, err := readUpstreamResponseBodyLimited(resp.Body, resolveUpstreamResponseReadLimit(s.cfg))
+	if err != nil {
+		if errors.Is(err, ErrUpstreamResponseBodyTooLarge) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("read message batch upstream response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		if s.rateLimitService != nil {
+			s.rateLimitService.HandleUpstreamError(ctx, account, resp.StatusCode, resp.Header, respBody)
+		}
+		return nil, &MessageBatchUpstreamError{
+			StatusCode: resp.StatusCode,
+			Message:    sanitizeUpstreamErrorMessage(strings.TrimSpace(extractUpstreamErrorMessage(respBody))),
+		}
+	}
+	return respBody, nil
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// forwardOpenAICompatible 将 Anthropic Messages 请求转换为 Chat Completions 转发到通用 OpenAI 兼容上游
+// （vLLM/SGLang 自建服务或 DeepSeek/Moonshot/Qwen 等厂商），再把响应转换回 Anthropic 格式。
+// 错误处理复用 Bedrock/Vertex 的重试 + failover + 限流标记流程。
+func (s *GatewayService) forwardOpenAICompatible(
+	ctx context.Context,
+	c *gin.Context,
+	account *Account,
+	parsed *ParsedRequest,
+	startTime time.Time,
+) (*ForwardResult, error) {
+	reqModel := parsed.Model
+	reqStream := parsed.Stream
+
+	upstreamModel, ok := ResolveOpenAICompatibleModel(account, reqModel)
+	if !ok {
+		return nil, fmt.Errorf("unsupported openai-compatible model: %s", reqModel)
+	}
+	if upstreamModel != reqModel {
+		logger.LegacyPrintf("service.gateway", "[OpenAICompatible] Model mapping: %s -> %s (account: %s)", reqModel, upstreamModel, account.Name)
+	}
+
+	var anthropicReq apicompat.AnthropicRequest
+	if err := json.Unmarshal(parsed.Body, &anthropicReq); err != nil {
+		return nil, fmt.Errorf("parse anthropic request: %w", err)
+	}
+	chatReq, err := apicompat.AnthropicToChatCompletions(&anthropicReq)
+	if err != nil {
+		return nil, fmt.Errorf("convert anthropic to chat completions: %w", err)
+	}
+	chatReq.Model = upstreamModel
+	chatBody, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("marshal chat completions request: %w", err)
+	}
+
+	targetURL, err := s.validateUpstreamBaseURL(account.GetCredential("base_url"))
+	if err != nil {
+		return nil, err
+	}
+	targetURL = buildOpenAICompatibleChatURL(targetURL)
+	apiKey := account.GetCredential("api_key")
+
+	proxyURL := account.ProxyURL()
+
+	logger.LegacyPrintf("service.gateway", "[OpenAICompatible] 命中 OpenAI 兼容分支: account=%d name=%s model=%s->%s stream=%v",
+		account.ID, account.Name, reqModel, upstreamModel, reqStream)
+
+	// 流式请求与客户端连接解耦，客户端断开后继续读完上游以获取 usage 计费
+	upstreamCtx, releaseUpstreamCtx := detachStreamUpstreamContext(ctx, reqStream)
+	defer releaseUpstreamCtx()
+	resp, err := s.executeCloudUpstream(ctx, c, account, "OpenAICompatible", proxyURL, func() (*http.Request, error) {
+		return buildUpstreamRequestOpenAICompatible(upstreamCtx, targetURL, chatBody, apiKey, reqStream)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		return s.handleCloudUpstreamErrors(ctx, resp, c, account, "OpenAICompatible")
+	}
+
+	result := &ForwardResult{
+		RequestID:     openAICompatibleRequestID(resp.Header),
+		Model:         reqModel,
+		UpstreamModel: upstreamModel,
+		Stream:        reqStream,
+	}
+	if reqStream {
+		err = s.handleOpenAICompatibleStreamingResponse(resp, c, reqModel, startTime, result)
+	} else {
+		err = s.handleOpenAICompatibleNonStreamingResponse(resp, c, reqModel, result)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+// buildUpstreamRequestOpenAICompatible 构建 Chat Completions 上游请求；自建服务可不配置 api_key
+func buildUpstreamRequestOpenAICompatible(ctx context.Context, targetURL string, body []byte, apiKey string, stream bool) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	if stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	return req, nil
+}
+
+// openAICompatibleRequestID 各厂商的请求 ID 响应头并不统一，按常见名称依次尝试
+func openAICompatibleRequestID(h http.Header) string {
+	for _, key := range []string{"x-request-id", "x-ds-trace-id", "x-trace-id", "req-id"} {
+		if v := h.Get(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (s *GatewayService) handleOpenAICompatibleNonStreamingResponse(
+	resp *http.Response,
+	c *gin.Context,
+	originalModel string,
+	result *ForwardResult,
+) error {
+	body, err := readUpstreamResponseBodyLimited(resp.Body, resolveUpstreamResponseReadLimit(s.cfg))
+	if err != nil {
+		if errors.Is(err, ErrUpstreamResponseBodyTooLarge) {
+			setOpsUpstreamError(c, http.StatusBadGateway, "upstream response too large", "")
+			writeAnthropicError(c, http.StatusBadGateway, "upstream_error", "Upstream response too large")
+		}
+		return err
+	}
+
+	var chatResp apicompat.ChatCompletionsResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		writeAnthropicError(c, http.StatusBadGateway, "upstream_error", "Failed to parse upstream response")
+		return fmt.Errorf("parse chat completions response: %w", err)
+	}
+
+	anthropicResp := apicompat.ChatCompletionsToAnthropic(&chatResp, originalModel)
+	result.Usage = ClaudeUsage{
+		InputTokens:          anthropicResp.Usage.InputTokens,
+		OutputTokens:         anthropicResp.Usage.OutputTokens,
+		CacheReadInputTokens: anthropicResp.Usage.CacheReadInputTokens,
+	}
+
+	responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.responseHeaderFilter)
+	c.JSON(http.StatusOK, anthropicResp)
+	return nil
+}
+
+// handleOpenAICompatibleStreamingResponse 逐块把 Chat Completions SSE 转换为 Anthropic SSE。
+// usage 来自 stream_options.include_usage 产生的末尾 usage 块；客户端断开后继续读取上游直至结束，
+// 以保证计费完整。
+func (s *GatewayService) handleOpenAICompatibleStreamingResponse(
+	resp *http.Response,
+	c *gin.Context,
+	originalModel string,
+	startTime time.Time,
+	result *ForwardResult,
+) error {
+	requestID := result.RequestID
+
+	responseheaders.WriteFilteredHeaders(c.Writer.Header(), resp.Header, s.responseHeaderFilter)
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+	c.Writer.WriteHeader(http.StatusOK)
+
+	state := apicompat.NewChatCompletionsToAnthropicState()
+	state.Model = originalModel
+	sawUsage := false
+
+	writeEvents := func(events []apicompat.AnthropicStreamEvent) {
+		if result.ClientDisconnect || len(events) == 0 {
+			return
+		}
+		for _, evt := range events {
+			sse, err := apicompat.ResponsesAnthropicEventToSSE(evt)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprint(c.Writer, sse); err != nil {
+				logger.L().Info("openai compatible stream: client disconnected, draining upstream for usage",
+					zap.String("request_id", requestID),
+				)
+				result.ClientDisconnect = true
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	maxLineSize := defaultMaxLineSize
+	if s.cfg != nil && s.cfg.Gateway.MaxLineSize > 0 {
+		maxLineSize = s.cfg.Gateway.MaxLineSize
+	}
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		payload := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if payload == "[DONE]" {
+			break
+		}
+
+		var chunk apicompat.ChatCompletionsChunk
+		if err := json.Unmarshal([]byte(payload), &chunk); err != nil {
+			logger.L().Warn("openai compatible stream: failed to parse chunk",
+				zap.Error(err),
+				zap.String("request_id", requestID),
+			)
+			continue
+		}
+		if result.FirstTokenMs == nil && len(chunk.Choices) > 0 {
+			ms := int(time.Since(startTime).Milliseconds())
+			result.FirstTokenMs = &ms
+		}
+		if chunk.Usage != nil {
+			sawUsage = true
+		}
+		writeEvents(apicompat.ChatCompletionsChunkToAnthropicEvents(&chunk, state))
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		logger.L().Warn("openai compatible stream: read error",
+			zap.Error(err),
+			zap.String("request_id", requestID),
+		)
+	}
+
+	writeEvents(apicompat.FinalizeChatCompletionsAnthropicStream(state))
+	if !sawUsage {
+		logger.L().Warn("openai compatible stream: upstream sent no usage chunk",
+			zap.String("request_id", requestID),
+			zap.String("model", originalModel),
+		)
+	}
+	result.Usage = ClaudeUsage{
+		InputTokens:          state.Usage.InputTokens,
+		OutputTokens:         state.Usage.OutputTokens,
+		CacheReadInputTokens: state.Usage.CacheReadInputTokens,
+	}
+	return nil
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/Wei-Shaw/sub2api/internal/pkg/claude"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/proxyurl"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/usagestats"
 	"github.com/Wei-Shaw/sub2api/internal/util/responseheaders"
 	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
@@ -503,7 +504,8 @@ type ForwardResult struct {
 	// 图片生成计费字段（图片生成模型使用）
 	ImageCount int    // 生成的图片数量
 	ImageSize  string // 图片尺寸 "1K", "2K", "4K"
-
+	// ImagesEndpoint 为 /v1/images 请求：渠道定价按张数与尺寸层级计费
+	ImagesEndpoint bool
 }
 
 // UpstreamFailoverError indicates an upstream error that should trigger account failover.
@@ -555,6 +557,7 @@ type GatewayService struct {
 	deferredService       *DeferredService
 	concurrencyService    *ConcurrencyService
 	claudeTokenProvider   *ClaudeTokenProvider
+	vertexTokenProvider   *VertexTokenProvider
 	sessionLimitCache     SessionLimitCache // 会话数量限制缓存（仅 Anthropic OAuth/SetupToken）
 	rpmCache              RPMCache          // RPM 计数缓存（仅 Anthropic OAuth/SetupToken）
 	userGroupRateResolver *userGroupRateResolver
@@ -592,6 +595,7 @@ func NewGatewayService(
 	httpUpstream HTTPUpstream,
 	deferredService *DeferredService,
 	claudeTokenProvider *ClaudeTokenProvider,
+	vertexTokenProvider *VertexTokenProvider,
 	sessionLimitCache SessionLimitCache,
 	rpmCache RPMCache,
 	digestStore *DigestSessionStore,
@@ -623,6 +627,7 @@ func NewGatewayService(
 		httpUpstream:         httpUpstream,
 		deferredService:      deferredService,
 		claudeTokenProvider:  claudeTokenProvider,
+		vertexTokenProvider:  vertexTokenProvider,
 		sessionLimitCache:    sessionLimitCache,
 		rpmCache:             rpmCache,
 		userGroupRateCache:   gocache.New(userGroupRateTTL, time.Minute),
@@ -3430,6 +3435,14 @@ func (s *GatewayService) isModelSupportedByAccount(account *Account, requestedMo
 		_, ok := ResolveBedrockModelID(account, requestedModel)
 		return ok
 	}
+	if account.IsVertex() {
+		_, ok := ResolveVertexModelID(account, requestedModel)
+		return ok
+	}
+	if account.IsOpenAICompatible() {
+		_, ok := ResolveOpenAICompatibleModel(account, requestedModel)
+		return ok
+	}
 	// OAuth/SetupToken 账号使用 Anthropic 标准映射（短ID → 长ID）
 	if account.Platform == PlatformAnthropic && account.Type != AccountTypeAPIKey {
 		requestedModel = claude.NormalizeModelID(requestedModel)
@@ -3452,6 +3465,10 @@ func (s *GatewayService) GetAccessToken(ctx context.Context, account *Account) (
 		return apiKey, "apikey", nil
 	case AccountTypeBedrock:
 		return "", "bedrock", nil // Bedrock 使用 SigV4 签名或 API Key，由 forwardBedrock 处理
+	case AccountTypeVertex:
+		return "", "vertex", nil // Vertex 使用 Service Account 换取的 access token，由 forwardVertex 处理
+	case AccountTypeOpenAICompatible:
+		return account.GetCredential("api_key"), "apikey", nil // 自建服务允许不配置 api_key
 	default:
 		return "", "", fmt.Errorf("unsupported account type: %s", account.Type)
 	}
@@ -3952,6 +3969,14 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 		return s.forwardBedrock(ctx, c, account, parsed, startTime)
 	}
 
+	if account != nil && account.IsVertex() {
+		return s.forwardVertex(ctx, c, account, parsed, startTime)
+	}
+
+	if account != nil && account.IsOpenAICompatible() {
+		return s.forwardOpenAICompatible(ctx, c, account, parsed, startTime)
+	}
+
 	// Beta policy: evaluate once; block check + cache filter set for buildUpstreamRequest.
 	// Always overwrite the cache to prevent stale values from a previous retry with a different account.
 	if account.Platform == PlatformAnthropic && c != nil {
@@ -4051,10 +4076,8 @@ func (s *GatewayService) Forward(ctx context.Context, c *gin.Context, account *A
 
 	// 获取代理URL（自定义 base URL 模式下，proxy 通过 buildCustomRelayURL 作为查询参数传递）
 	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		if !account.IsCustomBaseURLEnabled() || account.GetCustomBaseURL() == "" {
-			proxyURL = account.Proxy.URL()
-		}
+	if !account.IsCustomBaseURLEnabled() || account.GetCustomBaseURL() == "" {
+		proxyURL = account.ProxyURL()
 	}
 
 	// 解析 TLS 指纹 profile（同一请求生命周期内不变，避免重试循环中重复解析）
@@ -4545,10 +4568,7 @@ func (s *GatewayService) forwardAnthropicAPIKeyPassthroughWithInput(
 		return nil, fmt.Errorf("anthropic api key passthrough requires apikey token, got: %s", tokenType)
 	}
 
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.ProxyURL()
 
 	logger.LegacyPrintf("service.gateway", "[Anthropic 自动透传] 命中 API Key 透传分支: account=%d name=%s model=%s stream=%v",
 		account.ID, account.Name, input.RequestModel, input.RequestStream)
@@ -5189,10 +5209,7 @@ func (s *GatewayService) forwardBedrock(
 		return nil, fmt.Errorf("prepare bedrock request body: %w", err)
 	}
 
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.ProxyURL()
 
 	logger.LegacyPrintf("service.gateway", "[Bedrock] 命中 Bedrock 分支: account=%d name=%s model=%s->%s stream=%v",
 		account.ID, account.Name, reqModel, mappedModel, reqStream)
@@ -5227,7 +5244,7 @@ func (s *GatewayService) forwardBedrock(
 
 	// 错误/failover 处理
 	if resp.StatusCode >= 400 {
-		return s.handleBedrockUpstreamErrors(ctx, resp, c, account)
+		return s.handleCloudUpstreamErrors(ctx, resp, c, account, "Bedrock")
 	}
 
 	// 响应处理
@@ -5276,17 +5293,29 @@ func (s *GatewayService) executeBedrockUpstream(
 	signer *BedrockSigner,
 	apiKey string,
 	proxyURL string,
+) (*http.Response, error) {
+	return s.executeCloudUpstream(ctx, c, account, "Bedrock", proxyURL, func() (*http.Request, error) {
+		if account.IsBedrockAPIKey() {
+			return s.buildUpstreamRequestBedrockAPIKey(ctx, body, modelID, region, stream, apiKey)
+		}
+		return s.buildUpstreamRequestBedrock(ctx, body, modelID, region, stream, signer)
+	})
+}
+
+// executeCloudUpstream 执行云厂商托管 Claude（Bedrock/Vertex）的上游请求（含重试逻辑）
+// buildReq 每次重试都会重新调用，以便重新签名
+func (s *GatewayService) executeCloudUpstream(
+	ctx context.Context,
+	c *gin.Context,
+	account *Account,
+	logTag string,
+	proxyURL string,
+	buildReq func() (*http.Request, error),
 ) (*http.Response, error) {
 	var resp *http.Response
-	var err error
 	retryStart := time.Now()
 	for attempt := 1; attempt <= maxRetryAttempts; attempt++ {
-		var upstreamReq *http.Request
-		if account.IsBedrockAPIKey() {
-			upstreamReq, err = s.buildUpstreamRequestBedrockAPIKey(ctx, body, modelID, region, stream, apiKey)
-		} else {
-			upstreamReq, err = s.buildUpstreamRequestBedrock(ctx, body, modelID, region, stream, signer)
-		}
+		upstreamReq, err := buildReq()
 		if err != nil {
 			return nil, err
 		}
@@ -5350,8 +5379,8 @@ func (s *GatewayService) executeBedrockUpstream(
 						return ""
 					}(),
 				})
-				logger.LegacyPrintf("service.gateway", "[Bedrock] account %d: upstream error %d, retry %d/%d after %v",
-					account.ID, resp.StatusCode, attempt, maxRetryAttempts, delay)
+				logger.LegacyPrintf("service.gateway", "[%s] account %d: upstream error %d, retry %d/%d after %v",
+					logTag, account.ID, resp.StatusCode, attempt, maxRetryAttempts, delay)
 				if err := sleepWithContext(ctx, delay); err != nil {
 					return nil, err
 				}
@@ -5368,12 +5397,13 @@ func (s *GatewayService) executeBedrockUpstream(
 	return resp, nil
 }
 
-// handleBedrockUpstreamErrors 处理 Bedrock 上游 4xx/5xx 错误（failover + 错误响应）
-func (s *GatewayService) handleBedrockUpstreamErrors(
+// handleCloudUpstreamErrors 处理 Bedrock/Vertex 上游 4xx/5xx 错误（failover + 错误响应）
+func (s *GatewayService) handleCloudUpstreamErrors(
 	ctx context.Context,
 	resp *http.Response,
 	c *gin.Context,
 	account *Account,
+	logTag string,
 ) (*ForwardResult, error) {
 	// retry exhausted + failover
 	if s.shouldRetryUpstreamError(account, resp.StatusCode) {
@@ -5382,8 +5412,8 @@ func (s *GatewayService) handleBedrockUpstreamErrors(
 			_ = resp.Body.Close()
 			resp.Body = io.NopCloser(bytes.NewReader(respBody))
 
-			logger.LegacyPrintf("service.gateway", "[Bedrock] Upstream error (retry exhausted, failover): Account=%d(%s) Status=%d Body=%s",
-				account.ID, account.Name, resp.StatusCode, truncateString(string(respBody), 1000))
+			logger.LegacyPrintf("service.gateway", "[%s] Upstream error (retry exhausted, failover): Account=%d(%s) Status=%d Body=%s",
+				logTag, account.ID, account.Name, resp.StatusCode, truncateString(string(respBody), 1000))
 
 			s.handleRetryExhaustedSideEffects(ctx, resp, account)
 			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
@@ -5871,9 +5901,10 @@ func (s *GatewayService) evaluateBetaPolicy(ctx context.Context, betaHeader stri
 	}
 	isOAuth := account.IsOAuth()
 	isBedrock := account.IsBedrock()
+	isVertex := account.IsVertex()
 	var result betaPolicyResult
 	for _, rule := range settings.Rules {
-		if !betaPolicyScopeMatches(rule.Scope, isOAuth, isBedrock) {
+		if !betaPolicyScopeMatches(rule.Scope, isOAuth, isBedrock, isVertex) {
 			continue
 		}
 		effectiveAction, effectiveErrMsg := resolveRuleAction(rule, model)
@@ -5934,16 +5965,18 @@ func (s *GatewayService) getBetaPolicyFilterSet(ctx context.Context, c *gin.Cont
 }
 
 // betaPolicyScopeMatches checks whether a rule's scope matches the current account type.
-func betaPolicyScopeMatches(scope string, isOAuth bool, isBedrock bool) bool {
+func betaPolicyScopeMatches(scope string, isOAuth bool, isBedrock bool, isVertex bool) bool {
 	switch scope {
 	case BetaPolicyScopeAll:
 		return true
 	case BetaPolicyScopeOAuth:
 		return isOAuth
 	case BetaPolicyScopeAPIKey:
-		return !isOAuth && !isBedrock
+		return !isOAuth && !isBedrock && !isVertex
 	case BetaPolicyScopeBedrock:
 		return isBedrock
+	case BetaPolicyScopeVertex:
+		return isVertex
 	default:
 		return true // unknown scope → match all (fail-open)
 	}
@@ -6053,13 +6086,14 @@ func (s *GatewayService) checkBetaPolicyBlockForTokens(ctx context.Context, toke
 	}
 	isOAuth := account.IsOAuth()
 	isBedrock := account.IsBedrock()
+	isVertex := account.IsVertex()
 	tokenSet := buildBetaTokenSet(tokens)
 	for _, rule := range settings.Rules {
 		effectiveAction, effectiveErrMsg := resolveRuleAction(rule, model)
 		if effectiveAction != BetaPolicyActionBlock {
 			continue
 		}
-		if !betaPolicyScopeMatches(rule.Scope, isOAuth, isBedrock) {
+		if !betaPolicyScopeMatches(rule.Scope, isOAuth, isBedrock, isVertex) {
 			continue
 		}
 		if _, present := tokenSet[rule.BetaToken]; present {
@@ -7276,6 +7310,8 @@ type RecordUsageInput struct {
 	RequestPayloadHash string             // 请求体语义哈希，用于降低 request_id 误复用时的静默误去重风险
 	ForceCacheBilling  bool               // 强制缓存计费：将 input_tokens 转为 cache_read 计费（用于粘性会话切换）
 	APIKeyService      APIKeyQuotaUpdater // 可选：用于更新API Key配额
+	BatchDiscount      float64            // 批处理折扣倍率（叠加在费率倍数之上），0 表示不打折
+	ResponseCacheHit   bool               // 命中响应缓存：按命中倍率计费，不计账号成本
 
 	ChannelUsageFields // 渠道映射信息（由 handler 在 Forward 前解析）
 }
@@ -7287,7 +7323,11 @@ type APIKeyQuotaUpdater interface {
 }
 
 type apiKeyAuthCacheInvalidator interface {
-	InvalidateAuthCacheByKey(ctx context.Context, key string)
+	InvalidateAuthCacheByKeyHash(ctx context.Context, keyHash string)
+}
+
+type apiKeyRequestRateRecorder interface {
+	RecordRequestRateTokens(ctx context.Context, apiKey *APIKey, tokens int)
 }
 
 type usageLogBestEffortWriter interface {
@@ -7463,6 +7503,7 @@ func applyUsageBilling(ctx context.Context, requestID string, usageLog *UsageLog
 	cmd := buildUsageBillingCommand(requestID, usageLog, p)
 	if cmd == nil || cmd.RequestID == "" || repo == nil {
 		postUsageBilling(ctx, p, deps)
+		recordAPIKeyRequestRateTokens(ctx, usageLog, p)
 		return true, nil
 	}
 
@@ -7480,15 +7521,30 @@ func applyUsageBilling(ctx context.Context, requestID string, usageLog *UsageLog
 	}
 
 	if result.APIKeyQuotaExhausted {
-		if invalidator, ok := p.APIKeyService.(apiKeyAuthCacheInvalidator); ok && p.APIKey != nil && p.APIKey.Key != "" {
-			invalidator.InvalidateAuthCacheByKey(billingCtx, p.APIKey.Key)
+		if invalidator, ok := p.APIKeyService.(apiKeyAuthCacheInvalidator); ok && p.APIKey != nil && p.APIKey.KeyHash != "" {
+			invalidator.InvalidateAuthCacheByKeyHash(billingCtx, p.APIKey.KeyHash)
 		}
 	}
 
 	finalizePostUsageBilling(p, deps)
+	recordAPIKeyRequestRateTokens(billingCtx, usageLog, p)
 	return true, nil
 }
 
+// recordAPIKeyRequestRateTokens 将本次请求的 token 用量计入 API Key 的 TPM 滑动窗口
+func recordAPIKeyRequestRateTokens(ctx context.Context, usageLog *UsageLog, p *postUsageBillingParams) {
+	if usageLog == nil || p.APIKey == nil || p.APIKey.TPMLimit <= 0 {
+		return
+	}
+	recorder, ok := p.APIKeyService.(apiKeyRequestRateRecorder)
+	if !ok {
+		return
+	}
+	recordCtx, cancel := detachedBillingContext(ctx)
+	defer cancel()
+	recorder.RecordRequestRateTokens(recordCtx, p.APIKey, usageLog.TotalTokens())
+}
+
 func finalizePostUsageBilling(p *postUsageBillingParams, deps *billingDeps) {
 	if p == nil || p.Cost == nil || deps == nil {
 		return
@@ -7600,6 +7656,8 @@ func (s *GatewayService) RecordUsage(ctx context.Context, input *RecordUsageInpu
 		RequestPayloadHash: input.RequestPayloadHash,
 		ForceCacheBilling:  input.ForceCacheBilling,
 		APIKeyService:      input.APIKeyService,
+		BatchDiscount:      input.BatchDiscount,
+		ResponseCacheHit:   input.ResponseCacheHit,
 		ChannelUsageFields: input.ChannelUsageFields,
 	}, &recordUsageOpts{
 		EnableClaudePath: true,
@@ -7662,6 +7720,8 @@ type recordUsageCoreInput struct {
 	RequestPayloadHash string
 	ForceCacheBilling  bool
 	APIKeyService      APIKeyQuotaUpdater
+	BatchDiscount      float64
+	ResponseCacheHit   bool
 	ChannelUsageFields
 }
 
@@ -7701,6 +7761,13 @@ func (s *GatewayService) recordUsageCore(ctx context.Context, input *recordUsage
 		groupDefault := apiKey.Group.RateMultiplier
 		multiplier = s.getUserGroupRateMultiplier(ctx, user.ID, *apiKey.GroupID, groupDefault)
 	}
+	// 批处理折扣叠加在用户/分组倍率之上
+	if input.BatchDiscount > 0 {
+		multiplier *= input.BatchDiscount
+	}
+	if input.ResponseCacheHit {
+		multiplier *= responseCacheHitMultiplier(s.cfg)
+	}
 
 	// 确定计费模型
 	billingModel := forwardResultBillingModel(result.Model, result.UpstreamModel)
@@ -7729,8 +7796,16 @@ func (s *GatewayService) recordUsageCore(ctx context.Context, input *recordUsage
 
 	// 创建使用日志
 	accountRateMultiplier := account.BillingRateMultiplier()
+	if input.ResponseCacheHit {
+		// 缓存命中未消耗上游账号额度
+		accountRateMultiplier = 0
+	}
 	usageLog := s.buildRecordUsageLog(ctx, input, result, apiKey, user, account, subscription,
 		requestedModel, multiplier, accountRateMultiplier, billingType, cacheTTLOverridden, cost, opts)
+	if input.ResponseCacheHit {
+		mode := string(BillingModeResponseCache)
+		usageLog.BillingMode = &mode
+	}
 
 	if s.cfg != nil && s.cfg.RunMode == config.RunModeSimple {
 		writeUsageLogBestEffort(ctx, s.usageLogRepo, usageLog, "service.gateway")
@@ -7793,6 +7868,8 @@ func (s *GatewayService) resolveChannelPricing(ctx context.Context, billingModel
 }
 
 // calculateImageCost 计算图片生成费用：渠道级别定价优先，否则走按次计费。
+// /v1/images 请求按张数与尺寸层级计费（见 calculateImageUsageCost）；
+// 对话生图（Gemini/Antigravity generateContent）的渠道定价按单次请求计费。
 func (s *GatewayService) calculateImageCost(
 	ctx context.Context,
 	result *ForwardResult,
@@ -7800,12 +7877,16 @@ func (s *GatewayService) calculateImageCost(
 	billingModel string,
 	multiplier float64,
 ) *CostBreakdown {
+	tokens := UsageTokens{
+		InputTokens:       result.Usage.InputTokens,
+		OutputTokens:      result.Usage.OutputTokens,
+		ImageOutputTokens: result.Usage.ImageOutputTokens,
+	}
+	if result.ImagesEndpoint {
+		return calculateImageUsageCost(ctx, s.billingService, s.resolver, apiKey, billingModel, result.ImageSize, result.ImageCount, tokens, multiplier)
+	}
+
 	if resolved := s.resolveChannelPricing(ctx, billingModel, apiKey); resolved != nil {
-		tokens := UsageTokens{
-			InputTokens:       result.Usage.InputTokens,
-			OutputTokens:      result.Usage.OutputTokens,
-			ImageOutputTokens: result.Usage.ImageOutputTokens,
-		}
 		gid := apiKey.Group.ID
 		cost, err := s.billingService.CalculateCostUnified(CostInput{
 			Ctx:            ctx,
@@ -7824,15 +7905,59 @@ func (s *GatewayService) calculateImageCost(
 		return cost
 	}
 
-	var groupConfig *ImagePriceConfig
-	if apiKey.Group != nil {
-		groupConfig = &ImagePriceConfig{
-			Price1K: apiKey.Group.ImagePrice1K,
-			Price2K: apiKey.Group.ImagePrice2K,
-			Price4K: apiKey.Group.ImagePrice4K,
+	return s.billingService.CalculateImageCost(billingModel, result.ImageSize, result.ImageCount, groupImagePriceConfig(apiKey), multiplier)
+}
+
+// calculateImageUsageCost /v1/images 请求的图片计费，Gateway（Gemini 分组）与 OpenAI 两条 RecordUsage 路径共用。
+// 渠道定价为按次/图片模式时，按 imageSize 匹配 Intervals 中的层级标签（1K/2K/4K）取单价并乘以张数；
+// 没有渠道定价时回退到分组的 ImagePrice1K/2K/4K。
+func calculateImageUsageCost(
+	ctx context.Context,
+	billingService *BillingService,
+	resolver *ModelPricingResolver,
+	apiKey *APIKey,
+	billingModel string,
+	imageSize string,
+	imageCount int,
+	tokens UsageTokens,
+	multiplier float64,
+) *CostBreakdown {
+	if resolver != nil && apiKey.Group != nil {
+		gid := apiKey.Group.ID
+		resolved := resolver.Resolve(ctx, PricingInput{Model: billingModel, GroupID: &gid})
+		if resolved.Source == PricingSourceChannel {
+			cost, err := billingService.CalculateCostUnified(CostInput{
+				Ctx:            ctx,
+				Model:          billingModel,
+				GroupID:        &gid,
+				Tokens:         tokens,
+				RequestCount:   imageCount,
+				SizeTier:       imageSize,
+				RateMultiplier: multiplier,
+				Resolver:       resolver,
+				Resolved:       resolved,
+			})
+			if err != nil {
+				logger.LegacyPrintf("service.gateway", "Calculate image token cost failed: %v", err)
+				return &CostBreakdown{ActualCost: 0}
+			}
+			return cost
 		}
 	}
-	return s.billingService.CalculateImageCost(billingModel, result.ImageSize, result.ImageCount, groupConfig, multiplier)
+
+	return billingService.CalculateImageCost(billingModel, imageSize, imageCount, groupImagePriceConfig(apiKey), multiplier)
+}
+
+// groupImagePriceConfig 返回分组的图片单价配置，未绑定分组时为 nil（使用默认价格）
+func groupImagePriceConfig(apiKey *APIKey) *ImagePriceConfig {
+	if apiKey.Group == nil {
+		return nil
+	}
+	return &ImagePriceConfig{
+		Price1K: apiKey.Group.ImagePrice1K,
+		Price2K: apiKey.Group.ImagePrice2K,
+		Price4K: apiKey.Group.ImagePrice4K,
+	}
 }
 
 // calculateTokenCost 计算 Token 计费：根据 opts 决定走普通/长上下文/渠道统一计费。
@@ -8096,6 +8221,15 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 		s.countTokensError(c, http.StatusNotFound, "not_found_error", "count_tokens endpoint is not supported for Bedrock")
 		return nil
 	}
+	if account != nil && account.IsVertex() {
+		s.countTokensError(c, http.StatusNotFound, "not_found_error", "count_tokens endpoint is not supported for Vertex")
+		return nil
+	}
+	// OpenAI 兼容上游没有 count_tokens 端点，且分词器与 Claude 不同，直接返回本地估算
+	if account != nil && account.IsOpenAICompatible() {
+		c.JSON(http.StatusOK, gin.H{"input_tokens": EstimateAnthropicCountTokens(parsed.Body, parsed.Model)})
+		return nil
+	}
 
 	body := parsed.Body
 	reqModel := parsed.Model
@@ -8160,10 +8294,8 @@ func (s *GatewayService) ForwardCountTokens(ctx context.Context, c *gin.Context,
 
 	// 获取代理URL（自定义 base URL 模式下，proxy 通过 buildCustomRelayURL 作为查询参数传递）
 	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		if !account.IsCustomBaseURLEnabled() || account.GetCustomBaseURL() == "" {
-			proxyURL = account.Proxy.URL()
-		}
+	if !account.IsCustomBaseURLEnabled() || account.GetCustomBaseURL() == "" {
+		proxyURL = account.ProxyURL()
 	}
 
 	// 发送请求
@@ -8279,10 +8411,7 @@ func (s *GatewayService) forwardCountTokensAnthropicAPIKeyPassthrough(ctx contex
 		return err
 	}
 
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.ProxyURL()
 
 	resp, err := s.httpUpstream.DoWithTLS(upstreamReq, proxyURL, account.ID, account.Concurrency, s.tlsFPProfileService.ResolveTLSProfile(account))
 	if err != nil {
@@ -8591,11 +8720,13 @@ func (s *GatewayService) countTokensError(c *gin.Context, status int, errType, m
 // 在 path 后附加 beta=true 和可选的 proxy 查询参数
 func (s *GatewayService) buildCustomRelayURL(baseURL, path string, account *Account) string {
 	u := strings.TrimRight(baseURL, "/") + path + "?beta=true"
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL := account.Proxy.URL()
-		if proxyURL != "" {
-			u += "&proxy=" + url.QueryEscape(proxyURL)
+	if proxyURL := account.ProxyURL(); proxyURL != "" {
+		// 中继侧需要具体代理地址：代理池占位 URL 解析为池内当前首选代理，
+		// 解析失败时原样传递占位 URL，由中继拒绝而不是静默直连
+		if resolved, err := proxyurl.Resolve(proxyURL); err == nil {
+			proxyURL = resolved
 		}
+		u += "&proxy=" + url.QueryEscape(proxyURL)
 	}
 	return u
 }
@@ -8669,6 +8800,13 @@ func (s *GatewayService) GetAvailableModels(ctx context.Context, groupID *int64,
 				modelSet[model] = struct{}{}
 			}
 		}
+		// OpenAI 兼容账号的上游模型列表也可被直接请求
+		if acc.IsOpenAICompatible() {
+			for _, model := range acc.GetOpenAICompatibleModels() {
+				hasAnyMapping = true
+				modelSet[model] = struct{}{}
+			}
+		}
 	}
 
 	// If no account has model_mapping, return nil (use default)
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// forwardVertex 转发请求到 Google Vertex AI 上的 Claude（rawPredict/streamRawPredict）
+// Vertex 的请求/响应格式与 Anthropic Messages API 一致，仅 URL、认证和 anthropic_version 不同，
+// 因此响应处理直接复用标准 Anthropic 流式/非流式处理（含 usage 解析）。
+func (s *GatewayService) forwardVertex(
+	ctx context.Context,
+	c *gin.Context,
+	account *Account,
+	parsed *ParsedRequest,
+	startTime time.Time,
+) (*ForwardResult, error) {
+	reqModel := parsed.Model
+	reqStream := parsed.Stream
+
+	mappedModel, ok := ResolveVertexModelID(account, reqModel)
+	if !ok {
+		return nil, fmt.Errorf("unsupported vertex model: %s", reqModel)
+	}
+	if mappedModel != reqModel {
+		logger.LegacyPrintf("service.gateway", "[Vertex] Model mapping: %s -> %s (account: %s)", reqModel, mappedModel, account.Name)
+	}
+
+	projectID := vertexProjectID(account)
+	if projectID == "" {
+		return nil, errors.New("project_id not found in vertex credentials")
+	}
+	region := vertexRegion(account)
+
+	betaHeader := ""
+	if c != nil && c.Request != nil {
+		betaHeader = c.GetHeader("anthropic-beta")
+	}
+	betaTokens, err := s.resolveVertexBetaTokensForRequest(ctx, account, betaHeader, mappedModel)
+	if err != nil {
+		return nil, err
+	}
+
+	vertexBody, err := PrepareVertexRequestBody(parsed.Body)
+	if err != nil {
+		return nil, fmt.Errorf("prepare vertex request body: %w", err)
+	}
+
+	if s.vertexTokenProvider == nil {
+		return nil, errors.New("vertex token provider not configured")
+	}
+	accessToken, err := s.vertexTokenProvider.GetAccessToken(ctx, account)
+	if err != nil {
+		return nil, fmt.Errorf("get vertex access token: %w", err)
+	}
+
+	proxyURL := account.ProxyURL()
+
+	logger.LegacyPrintf("service.gateway", "[Vertex] 命中 Vertex 分支: account=%d name=%s model=%s->%s region=%s stream=%v",
+		account.ID, account.Name, reqModel, mappedModel, region, reqStream)
+
+	targetURL := BuildVertexURL(region, projectID, mappedModel, reqStream)
+	resp, err := s.executeCloudUpstream(ctx, c, account, "Vertex", proxyURL, func() (*http.Request, error) {
+		return buildUpstreamRequestVertex(ctx, targetURL, vertexBody, accessToken, betaTokens)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		// access_token 可能已被吊销或 Service Account 密钥已轮换，清除缓存以便下次重新签发
+		if resp.StatusCode == http.StatusUnauthorized {
+			s.vertexTokenProvider.InvalidateToken(ctx, account)
+		}
+		return s.handleCloudUpstreamErrors(ctx, resp, c, account, "Vertex")
+	}
+
+	var usage *ClaudeUsage
+	var firstTokenMs *int
+	var clientDisconnect bool
+	if reqStream {
+		streamResult, err := s.handleStreamingResponse(ctx, resp, c, account, startTime, reqModel, mappedModel, false)
+		if err != nil {
+			return nil, err
+		}
+		usage = streamResult.usage
+		firstTokenMs = streamResult.firstTokenMs
+		clientDisconnect = streamResult.clientDisconnect
+	} else {
+		usage, err = s.handleNonStreamingResponse(ctx, resp, c, account, reqModel, mappedModel)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if usage == nil {
+		usage = &ClaudeUsage{}
+	}
+
+	return &ForwardResult{
+		RequestID:        resp.Header.Get("x-request-id"),
+		Usage:            *usage,
+		Model:            reqModel,
+		UpstreamModel:    mappedModel,
+		Stream:           reqStream,
+		Duration:         time.Since(startTime),
+		FirstTokenMs:     firstTokenMs,
+		ClientDisconnect: clientDisconnect,
+	}, nil
+}
+
+// buildUpstreamRequestVertex 构建 Vertex 上游请求；beta 通过 anthropic-beta HTTP 头传递
+func buildUpstreamRequestVertex(ctx context.Context, targetURL string, body []byte, accessToken string, betaTokens []string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	if len(betaTokens) > 0 {
+		req.Header.Set("anthropic-beta", strings.Join(betaTokens, ","))
+	}
+	return req, nil
+}
+
+// resolveVertexBetaTokensForRequest 与 resolveBedrockBetaTokensForRequest 使用相同的 beta 策略：
+// 先对原始 header 做 block 检查，再对 Vertex 可接受的 token 应用 filter 规则。
+func (s *GatewayService) resolveVertexBetaTokensForRequest(
+	ctx context.Context,
+	account *Account,
+	betaHeader string,
+	modelID string,
+) ([]string, error) {
+	policy := s.evaluateBetaPolicy(ctx, betaHeader, account, modelID)
+	if policy.blockErr != nil {
+		return nil, policy.blockErr
+	}
+
+	betaTokens := ResolveVertexBetaTokens(betaHeader)
+	if blockErr := s.checkBetaPolicyBlockForTokens(ctx, betaTokens, account, modelID); blockErr != nil {
+		return nil, blockErr
+	}
+
+	return filterBetaTokens(betaTokens, policy.filterSet), nil
+}
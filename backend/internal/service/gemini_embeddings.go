@@ -0,0 +1,216 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/geminicli"
+	"github.com/gin-gonic/gin"
+)
+
+// SupportsGeminiEmbeddings 判断 Gemini 账号是否可以承接 embeddings 请求。
+// Code Assist（带 project_id 的 OAuth）没有 embedContent 接口，只有 AI Studio 模式可用。
+func SupportsGeminiEmbeddings(account *Account) bool {
+	if account == nil || account.Platform != PlatformGemini {
+		return false
+	}
+	switch account.Type {
+	case AccountTypeAPIKey:
+		return true
+	case AccountTypeOAuth:
+		return strings.TrimSpace(account.GetCredential("project_id")) == ""
+	default:
+		return false
+	}
+}
+
+// ForwardEmbeddings accepts an OpenAI embeddings request body, converts it to
+// Gemini embedContent/batchEmbedContents, and writes the result back in OpenAI
+// embeddings format. Gemini does not report usage for embeddings, so input
+// tokens are estimated locally from the input texts.
+func (s *GeminiMessagesCompatService) ForwardEmbeddings(ctx context.Context, c *gin.Context, account *Account, body []byte) (*ForwardResult, error) {
+	startTime := time.Now()
+
+	var req apicompat.EmbeddingsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeChatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return nil, fmt.Errorf("parse embeddings request: %w", err)
+	}
+	originalModel := strings.TrimSpace(req.Model)
+
+	if !SupportsGeminiEmbeddings(account) {
+		writeChatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Embeddings are not supported by this account")
+		return nil, fmt.Errorf("account %d does not support embeddings", account.ID)
+	}
+
+	mappedModel := originalModel
+	if account.Type == AccountTypeAPIKey {
+		mappedModel = account.GetMappedModel(originalModel)
+	}
+	mappedModel = strings.TrimPrefix(mappedModel, "models/")
+
+	texts, err := apicompat.ParseEmbeddingsInput(req.Input)
+	if err != nil {
+		writeChatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return nil, err
+	}
+	action, geminiReq, _, err := apicompat.EmbeddingsToGemini(&req, mappedModel)
+	if err != nil {
+		writeChatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return nil, err
+	}
+	geminiBody, err := json.Marshal(geminiReq)
+	if err != nil {
+		return nil, fmt.Errorf("marshal gemini embedding request: %w", err)
+	}
+
+	baseURL, err := s.validateUpstreamBaseURL(account.GetGeminiBaseURL(geminicli.AIStudioBaseURL))
+	if err != nil {
+		writeChatCompletionsError(c, http.StatusBadGateway, "upstream_error", err.Error())
+		return nil, err
+	}
+	fullURL := fmt.Sprintf("%s/v1beta/models/%s:%s", strings.TrimRight(baseURL, "/"), mappedModel, action)
+
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, fullURL, bytes.NewReader(geminiBody))
+	if err != nil {
+		return nil, err
+	}
+	upstreamReq.Header.Set("Content-Type", "application/json")
+	switch account.Type {
+	case AccountTypeAPIKey:
+		apiKey := strings.TrimSpace(account.GetCredential("api_key"))
+		if apiKey == "" {
+			writeChatCompletionsError(c, http.StatusBadGateway, "upstream_error", "gemini api_key not configured")
+			return nil, errors.New("gemini api_key not configured")
+		}
+		upstreamReq.Header.Set("x-goog-api-key", apiKey)
+	case AccountTypeOAuth:
+		if s.tokenProvider == nil {
+			writeChatCompletionsError(c, http.StatusBadGateway, "upstream_error", "gemini token provider not configured")
+			return nil, errors.New("gemini token provider not configured")
+		}
+		accessToken, err := s.tokenProvider.GetAccessToken(ctx, account)
+		if err != nil {
+			writeChatCompletionsError(c, http.StatusBadGateway, "upstream_error", "Failed to get upstream access token")
+			return nil, err
+		}
+		upstreamReq.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	proxyURL := account.ProxyURL()
+	setOpsUpstreamRequestBody(c, geminiBody)
+
+	upstreamStart := time.Now()
+	resp, err := s.httpUpstream.Do(upstreamReq, proxyURL, account.ID, account.Concurrency)
+	SetOpsLatencyMs(c, OpsUpstreamLatencyMsKey, time.Since(upstreamStart).Milliseconds())
+	if err != nil {
+		safeErr := sanitizeUpstreamErrorMessage(err.Error())
+		setOpsUpstreamError(c, 0, safeErr, "")
+		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+			Platform:           account.Platform,
+			AccountID:          account.ID,
+			AccountName:        account.Name,
+			UpstreamStatusCode: 0,
+			Kind:               "request_error",
+			Message:            safeErr,
+		})
+		return nil, &UpstreamFailoverError{StatusCode: http.StatusBadGateway}
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	requestID := resp.Header.Get("x-request-id")
+	if requestID == "" {
+		requestID = resp.Header.Get("x-goog-request-id")
+	}
+
+	if resp.StatusCode >= 400 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+		s.handleGeminiUpstreamError(ctx, account, resp.StatusCode, resp.Header, respBody)
+
+		upstreamMsg := sanitizeUpstreamErrorMessage(strings.TrimSpace(extractUpstreamErrorMessage(respBody)))
+		upstreamDetail := ""
+		if s.cfg != nil && s.cfg.Gateway.LogUpstreamErrorBody {
+			maxBytes := s.cfg.Gateway.LogUpstreamErrorBodyMaxBytes
+			if maxBytes <= 0 {
+				maxBytes = 2048
+			}
+			upstreamDetail = truncateString(string(respBody), maxBytes)
+		}
+		setOpsUpstreamError(c, resp.StatusCode, upstreamMsg, upstreamDetail)
+
+		if s.shouldFailoverGeminiUpstreamError(resp.StatusCode) {
+			appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+				Platform:           account.Platform,
+				AccountID:          account.ID,
+				AccountName:        account.Name,
+				UpstreamStatusCode: resp.StatusCode,
+				UpstreamRequestID:  requestID,
+				Kind:               "failover",
+				Message:            upstreamMsg,
+				Detail:             upstreamDetail,
+			})
+			return nil, &UpstreamFailoverError{StatusCode: resp.StatusCode, ResponseBody: respBody}
+		}
+
+		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+			Platform:           account.Platform,
+			AccountID:          account.ID,
+			AccountName:        account.Name,
+			UpstreamStatusCode: resp.StatusCode,
+			UpstreamRequestID:  requestID,
+			Kind:               "http_error",
+			Message:            upstreamMsg,
+			Detail:             upstreamDetail,
+		})
+		if upstreamMsg == "" {
+			upstreamMsg = "Upstream request failed"
+		}
+		errType := "upstream_error"
+		if resp.StatusCode == http.StatusBadRequest {
+			errType = "invalid_request_error"
+		}
+		writeChatCompletionsError(c, resp.StatusCode, errType, upstreamMsg)
+		return nil, fmt.Errorf("gemini embeddings upstream error: %d", resp.StatusCode)
+	}
+
+	respBody, err := readUpstreamResponseBodyLimited(resp.Body, resolveUpstreamResponseReadLimit(s.cfg))
+	if err != nil {
+		if errors.Is(err, ErrUpstreamResponseBodyTooLarge) {
+			setOpsUpstreamError(c, http.StatusBadGateway, "upstream response too large", "")
+			writeChatCompletionsError(c, http.StatusBadGateway, "upstream_error", "Upstream response too large")
+		}
+		return nil, err
+	}
+
+	inputTokens := 0
+	for _, text := range texts {
+		inputTokens += estimateTokensForText(text)
+	}
+
+	out, err := apicompat.GeminiEmbeddingsToOpenAI(respBody, action, originalModel, req.EncodingFormat, inputTokens)
+	if err != nil {
+		writeChatCompletionsError(c, http.StatusBadGateway, "upstream_error", "Failed to parse upstream response")
+		return nil, err
+	}
+	if requestID != "" {
+		c.Header("x-request-id", requestID)
+	}
+	c.JSON(http.StatusOK, out)
+
+	return &ForwardResult{
+		RequestID:     requestID,
+		Usage:         ClaudeUsage{InputTokens: inputTokens},
+		Model:         originalModel,
+		UpstreamModel: mappedModel,
+		Stream:        false,
+		Duration:      time.Since(startTime),
+	}, nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// maxImagesPerRequest 单次 /v1/images 请求允许的最大张数。
+// Gemini 每次 generateContent 只产出一张图，n 张图需要 n 次上游调用。
+const maxImagesPerRequest = 4
+
+// imagesCaptureContextKey 仅用于确保外层 gin.Context.Keys 已初始化，以便与内层捕获上下文共享。
+const imagesCaptureContextKey = "images_capture"
+
+// geminiImageForwardFunc 执行一次 Gemini 原生 generateContent 转发，响应写入传入的 gin.Context。
+type geminiImageForwardFunc func(ctx context.Context, c *gin.Context, body []byte) (*ForwardResult, error)
+
+// ForwardImages serves an OpenAI images request (generations or edits) with a
+// Gemini image generation model through the native generateContent path.
+func (s *GeminiMessagesCompatService) ForwardImages(ctx context.Context, c *gin.Context, account *Account, req *apicompat.ImagesRequest) (*ForwardResult, error) {
+	return forwardImagesViaGeminiNative(ctx, c, account, req, func(ctx context.Context, inner *gin.Context, body []byte) (*ForwardResult, error) {
+		return s.ForwardNative(ctx, inner, account, req.Model, "generateContent", false, body)
+	})
+}
+
+// ForwardImages serves an OpenAI images request with an Antigravity Gemini
+// image generation model through ForwardGemini.
+func (s *AntigravityGatewayService) ForwardImages(ctx context.Context, c *gin.Context, account *Account, req *apicompat.ImagesRequest) (*ForwardResult, error) {
+	return forwardImagesViaGeminiNative(ctx, c, account, req, func(ctx context.Context, inner *gin.Context, body []byte) (*ForwardResult, error) {
+		return s.ForwardGemini(ctx, inner, account, req.Model, "generateContent", false, body, false)
+	})
+}
+
+// forwardImagesViaGeminiNative 把 OpenAI images 请求转换为 Gemini generateContent，
+// 复用原生转发链路（鉴权、重试、限流处理、ops 记录），再把响应转换回 OpenAI images 格式。
+// 原生转发直接写 gin.Context，因此每次调用都写入一个共享 Request/Keys 的捕获上下文。
+func forwardImagesViaGeminiNative(
+	ctx context.Context,
+	c *gin.Context,
+	account *Account,
+	req *apicompat.ImagesRequest,
+	forward geminiImageForwardFunc,
+) (*ForwardResult, error) {
+	startTime := time.Now()
+
+	if !isImageGenerationModel(req.Model) && !isImageGenerationModel(account.GetMappedModel(req.Model)) {
+		writeChatCompletionsError(c, http.StatusBadRequest, "invalid_request_error",
+			fmt.Sprintf("Model %s does not support image generation", req.Model))
+		return nil, fmt.Errorf("model %s is not an image generation model", req.Model)
+	}
+
+	n := apicompat.ImagesRequestCount(req)
+	if n > maxImagesPerRequest {
+		writeChatCompletionsError(c, http.StatusBadRequest, "invalid_request_error",
+			fmt.Sprintf("n must be between 1 and %d", maxImagesPerRequest))
+		return nil, fmt.Errorf("images n %d exceeds limit", n)
+	}
+
+	body, err := apicompat.ImagesToGemini(req)
+	if err != nil {
+		writeChatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return nil, err
+	}
+
+	c.Set(imagesCaptureContextKey, true)
+
+	out := &apicompat.ImagesResponse{Created: time.Now().Unix(), Data: []apicompat.ImageData{}}
+	var last *ForwardResult
+	var usage ClaudeUsage
+	requestID := ""
+	for i := 0; i < n; i++ {
+		rec := httptest.NewRecorder()
+		inner, _ := gin.CreateTestContext(rec)
+		inner.Request = c.Request
+		inner.Keys = c.Keys
+
+		result, err := forward(ctx, inner, body)
+		if err != nil {
+			if len(out.Data) > 0 {
+				// 已经拿到部分图片：返回已生成的部分，避免整单失败
+				logger.L().Warn("images: partial result after upstream failure",
+					zap.Int64("account_id", account.ID),
+					zap.Int("generated", len(out.Data)),
+					zap.Int("requested", n),
+					zap.Error(err),
+				)
+				break
+			}
+			var failoverErr *UpstreamFailoverError
+			if errors.As(err, &failoverErr) {
+				return nil, err
+			}
+			writeImagesCapturedError(c, rec)
+			return nil, err
+		}
+
+		converted, convErr := apicompat.GeminiToImages(rec.Body.Bytes(), req.ResponseFormat)
+		if convErr != nil {
+			if len(out.Data) > 0 {
+				break
+			}
+			status := http.StatusBadGateway
+			if errors.Is(convErr, apicompat.ErrNoImageInResponse) {
+				status = http.StatusBadRequest
+			}
+			writeChatCompletionsError(c, status, "upstream_error", convErr.Error())
+			return nil, convErr
+		}
+
+		out.Data = append(out.Data, converted.Data...)
+		usage.InputTokens += result.Usage.InputTokens
+		usage.OutputTokens += result.Usage.OutputTokens
+		usage.ImageOutputTokens += result.Usage.ImageOutputTokens
+		if requestID == "" {
+			requestID = result.RequestID
+		}
+		last = result
+	}
+
+	if usage.InputTokens > 0 || usage.OutputTokens > 0 {
+		out.Usage = &apicompat.ImagesUsage{
+			InputTokens:  usage.InputTokens,
+			OutputTokens: usage.OutputTokens,
+			TotalTokens:  usage.InputTokens + usage.OutputTokens,
+		}
+	}
+	if requestID != "" {
+		c.Header("x-request-id", requestID)
+	}
+	c.JSON(http.StatusOK, out)
+
+	imageSize := apicompat.ImageSizeTier(req.Size)
+	if imageSize == "" {
+		imageSize = last.ImageSize
+	}
+	return &ForwardResult{
+		RequestID:      requestID,
+		Usage:          usage,
+		Model:          req.Model,
+		UpstreamModel:  last.UpstreamModel,
+		Stream:         false,
+		Duration:       time.Since(startTime),
+		ImageCount:     len(out.Data),
+		ImageSize:      imageSize,
+		ImagesEndpoint: true,
+	}, nil
+}
+
+// writeImagesCapturedError 把原生转发写入捕获上下文的 Google 格式错误转换为 OpenAI 错误格式。
+func writeImagesCapturedError(c *gin.Context, rec *httptest.ResponseRecorder) {
+	status := rec.Code
+	if status < http.StatusBadRequest {
+		status = http.StatusBadGateway
+	}
+	msg := sanitizeUpstreamErrorMessage(strings.TrimSpace(extractUpstreamErrorMessage(rec.Body.Bytes())))
+	if msg == "" {
+		msg = "Upstream request failed"
+	}
+	errType := "upstream_error"
+	if status == http.StatusBadRequest {
+		errType = "invalid_request_error"
+	}
+	writeChatCompletionsError(c, status, errType, msg)
+}
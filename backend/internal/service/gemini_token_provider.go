@@ -113,8 +113,8 @@ func (p *GeminiTokenProvider) GetAccessToken(ctx context.Context, account *Accou
 			return accessToken, nil
 		}
 
-		var proxyURL string
-		if account.ProxyID != nil && p.geminiOAuthService.proxyRepo != nil {
+		proxyURL := account.proxyPoolURL()
+		if proxyURL == "" && account.ProxyID != nil && p.geminiOAuthService.proxyRepo != nil {
 			if proxy, err := p.geminiOAuthService.proxyRepo.GetByID(ctx, *account.ProxyID); err == nil && proxy != nil {
 				proxyURL = proxy.URL()
 			}
@@ -54,6 +54,19 @@ type Group struct {
 	RequirePrivacySet     bool // 调度时仅允许 privacy 已成功设置的账号（OpenAI/Antigravity/Anthropic/Gemini）
 	DefaultMappedModel    string
 
+	// 本地 token 计数：count_tokens / countTokens 不请求上游（所有平台）
+	LocalTokenCounting bool
+
+	// OpenAI Batch API 折扣倍率（叠加在费率倍数之上），nil 表示使用配置默认值；
+	// 不影响 Anthropic Message Batches（其折扣只取 gateway.message_batches.discount_multiplier）
+	BatchDiscountMultiplier *float64
+
+	// 响应缓存：temperature=0 的确定性请求按规范化请求哈希精确命中时直接回放
+	ResponseCacheEnabled bool
+
+	// 余额购买订阅价格（USD），nil 表示不开放购买；有效天数取 DefaultValidityDays
+	SubscriptionPrice *float64
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
@@ -87,6 +100,14 @@ func (g *Group) HasMonthlyLimit() bool {
 	return g.MonthlyLimitUSD != nil && *g.MonthlyLimitUSD > 0
 }
 
+// ResolveBatchDiscount 返回 OpenAI Batch API 折扣倍率：分组配置优先，否则使用 defaultMultiplier
+func (g *Group) ResolveBatchDiscount(defaultMultiplier float64) float64 {
+	if g != nil && g.BatchDiscountMultiplier != nil {
+		return *g.BatchDiscountMultiplier
+	}
+	return defaultMultiplier
+}
+
 // GetImagePrice 根据 image_size 返回对应的图片生成价格
 // 如果分组未配置价格，返回 nil（调用方应使用默认值）
 func (g *Group) GetImagePrice(imageSize string) *float64 {
@@ -90,3 +90,35 @@ func TestGroup_GetImagePrice_PartialConfig(t *testing.T) {
 	require.Nil(t, group.GetImagePrice("2K"))
 	require.Nil(t, group.GetImagePrice("4K"))
 }
+
+// TestGroup_ResolveBatchDiscount 分组未设置批处理折扣时使用默认值
+func TestGroup_ResolveBatchDiscount(t *testing.T) {
+	var nilGroup *Group
+	require.InDelta(t, 0.5, nilGroup.ResolveBatchDiscount(0.5), 0.0001)
+	require.InDelta(t, 0.5, (&Group{}).ResolveBatchDiscount(0.5), 0.0001)
+
+	discount := 0.3
+	require.InDelta(t, 0.3, (&Group{BatchDiscountMultiplier: &discount}).ResolveBatchDiscount(0.5), 0.0001)
+}
+
+// TestNormalizeBatchDiscountMultiplier 负数表示清除，0 或大于 1 的值被拒绝
+func TestNormalizeBatchDiscountMultiplier(t *testing.T) {
+	v, err := normalizeBatchDiscountMultiplier(nil)
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	neg := -1.0
+	v, err = normalizeBatchDiscountMultiplier(&neg)
+	require.NoError(t, err)
+	require.Nil(t, v)
+
+	for _, bad := range []float64{0, 1.5} {
+		_, err = normalizeBatchDiscountMultiplier(&bad)
+		require.Error(t, err)
+	}
+
+	ok := 0.5
+	v, err = normalizeBatchDiscountMultiplier(&ok)
+	require.NoError(t, err)
+	require.InDelta(t, 0.5, *v, 0.0001)
+}
@@ -0,0 +1,835 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
+	"go.uber.org/zap"
+)
+
+// 安全策略检测器类型
+const (
+	GuardrailDetectorRegex   = "regex"
+	GuardrailDetectorKeyword = "keyword"
+	GuardrailDetectorPreset  = "preset"
+	GuardrailDetectorWebhook = "webhook"
+)
+
+// 命中后的动作
+const (
+	GuardrailActionBlock = "block"
+	GuardrailActionMask  = "mask"
+	GuardrailActionLog   = "log"
+)
+
+// 被检查请求的协议（webhook 请求体中透传给外部检查器）
+const (
+	GuardrailProtocolAnthropicMessages     = "anthropic_messages"
+	GuardrailProtocolOpenAIResponses       = "openai_responses"
+	GuardrailProtocolOpenAIChatCompletions = "openai_chat_completions"
+	GuardrailProtocolOpenAIEmbeddings      = "openai_embeddings"
+	GuardrailProtocolOpenAIImages          = "openai_images"
+	GuardrailProtocolGeminiGenerateContent = "gemini_generate_content"
+)
+
+const (
+	// guardrailRuleRefreshInterval 规则快照刷新周期（多实例部署时其他实例的规则变更在此周期内生效）
+	guardrailRuleRefreshInterval = 30 * time.Second
+	guardrailRuleLoadTimeout     = 3 * time.Second
+	guardrailDefaultReplacement  = "[REDACTED]"
+	guardrailMaxPatterns         = 200
+	guardrailMaxPatternLength    = 1024
+	guardrailMaxReplacementLen   = 64
+	guardrailMaxWebhookTimeoutMs = 10000
+	// guardrailWebhookResponseReadSize webhook 响应读取上限
+	guardrailWebhookResponseReadSize = 64 * 1024
+)
+
+var (
+	ErrGuardrailRuleNotFound = infraerrors.NotFound("GUARDRAIL_RULE_NOT_FOUND", "guardrail rule not found")
+)
+
+// GuardrailRule 分组级请求安全策略规则：在请求解析之后、转发上游之前检查用户输入文本。
+type GuardrailRule struct {
+	ID       int64
+	GroupID  int64
+	Name     string
+	Detector string   // regex / keyword / preset / webhook
+	Patterns []string // regex: 正则; keyword: 关键词; preset: 内置检测器名称; webhook 不使用
+	// CaseSensitive 仅对 regex / keyword 生效
+	CaseSensitive bool
+	Action        string // block / mask / log
+	Replacement   string // mask 替换文本，空表示 [REDACTED]
+	WebhookURL    string
+	// WebhookTimeoutMs 0 表示使用 guardrail.webhook_timeout_ms
+	WebhookTimeoutMs int
+	// FailOpen webhook 不可用时放行；false 时拒绝请求
+	FailOpen  bool
+	Priority  int // 数值越小越先执行
+	Enabled   bool
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// GuardrailRuleInput 创建/更新安全策略规则的参数
+type GuardrailRuleInput struct {
+	GroupID          int64
+	Name             string
+	Detector         string
+	Patterns         []string
+	CaseSensitive    bool
+	Action           string
+	Replacement      string
+	WebhookURL       string
+	WebhookTimeoutMs int
+	FailOpen         bool
+	Priority         int
+	Enabled          bool
+}
+
+// GuardrailRepository 安全策略规则数据访问接口
+type GuardrailRepository interface {
+	ListRules(ctx context.Context) ([]GuardrailRule, error)
+	GetRule(ctx context.Context, id int64) (*GuardrailRule, error)
+	CreateRule(ctx context.Context, rule *GuardrailRule) error
+	UpdateRule(ctx context.Context, rule *GuardrailRule) error
+	DeleteRule(ctx context.Context, id int64) error
+}
+
+// GuardrailRequest 待检查的网关请求
+type GuardrailRequest struct {
+	APIKey   *APIKey
+	Protocol string
+	Model    string
+	Body     []byte
+}
+
+// GuardrailFinding 单条规则的命中情况（不包含命中内容本身）
+type GuardrailFinding struct {
+	RuleID   int64
+	RuleName string
+	Action   string
+	Matches  int
+}
+
+// GuardrailResult 检查结果
+type GuardrailResult struct {
+	// Body 应转发的请求体；Modified 为 true 时已替换 mask 规则命中的内容
+	Body     []byte
+	Modified bool
+	// Blocked 为 true 时请求必须拒绝，BlockedBy 为触发拦截的规则
+	Blocked   bool
+	BlockedBy *GuardrailRule
+	Reason    string
+	Findings  []GuardrailFinding
+}
+
+// BlockedMessage 返回拦截时写回客户端的说明（规则名与 webhook 给出的原因）
+func (r *GuardrailResult) BlockedMessage() string {
+	msg := "Request blocked by guardrail policy"
+	if r.BlockedBy != nil && r.BlockedBy.Name != "" {
+		msg += " (" + r.BlockedBy.Name + ")"
+	}
+	if reason := strings.TrimSpace(r.Reason); reason != "" {
+		msg += ": " + truncateString(reason, 256)
+	}
+	return msg
+}
+
+// guardrailPreset 内置 PII 检测器；validate 用于校验位等二次过滤
+type guardrailPreset struct {
+	re       *regexp.Regexp
+	validate func(string) bool
+}
+
+var guardrailPresets = map[string]guardrailPreset{
+	"email": {re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)},
+	"phone": {re: regexp.MustCompile(
+		`\+\d{1,3}[ -]?\d[\d -]{6,14}\d\b|\b1[3-9]\d{9}\b|\(?\b\d{3}\)?[ .-]\d{3}[ .-]\d{4}\b`)},
+	"cn_id_card": {
+		re:       regexp.MustCompile(`\b[1-9]\d{5}(?:18|19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]\b`),
+		validate: validCNIDCardChecksum,
+	},
+	"credit_card": {
+		re:       regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+		validate: validLuhn,
+	},
+	"api_secret": {re: regexp.MustCompile(
+		`\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}` +
+			`|\bAKIA[0-9A-Z]{16}\b` +
+			`|\bgh[pousr]_[A-Za-z0-9]{36,}` +
+			`|\bxox[abprs]-[A-Za-z0-9-]{10,}` +
+			`|\bAIza[0-9A-Za-z_-]{35}` +
+			`|-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----` +
+			`|(?i:bearer)\s+[A-Za-z0-9._~+/-]{20,}=*`)},
+}
+
+// GuardrailPresetNames 返回内置检测器名称（按字母序）
+func GuardrailPresetNames() []string {
+	names := make([]string, 0, len(guardrailPresets))
+	for name := range guardrailPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type compiledGuardrailRule struct {
+	rule     *GuardrailRule
+	matchers []guardrailPreset
+}
+
+// find 返回 text 中所有命中区间（已按起点排序并合并重叠区间）
+func (r *compiledGuardrailRule) find(text string) [][2]int {
+	var spans [][2]int
+	for _, m := range r.matchers {
+		for _, loc := range m.re.FindAllStringIndex(text, -1) {
+			if m.validate != nil && !m.validate(text[loc[0]:loc[1]]) {
+				continue
+			}
+			spans = append(spans, [2]int{loc[0], loc[1]})
+		}
+	}
+	if len(spans) < 2 {
+		return spans
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+	merged := spans[:1]
+	for _, s := range spans[1:] {
+		last := &merged[len(merged)-1]
+		if s[0] <= last[1] {
+			last[1] = max(last[1], s[1])
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+func (r *compiledGuardrailRule) replacement() string {
+	if r.rule.Replacement != "" {
+		return r.rule.Replacement
+	}
+	return guardrailDefaultReplacement
+}
+
+func compileGuardrailRule(rule *GuardrailRule) (*compiledGuardrailRule, error) {
+	compiled := &compiledGuardrailRule{rule: rule}
+	flags := "(?i)"
+	if rule.CaseSensitive {
+		flags = ""
+	}
+	switch rule.Detector {
+	case GuardrailDetectorRegex:
+		for _, p := range rule.Patterns {
+			re, err := regexp.Compile(flags + p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex %q: %w", p, err)
+			}
+			compiled.matchers = append(compiled.matchers, guardrailPreset{re: re})
+		}
+	case GuardrailDetectorKeyword:
+		quoted := make([]string, 0, len(rule.Patterns))
+		for _, p := range rule.Patterns {
+			quoted = append(quoted, regexp.QuoteMeta(p))
+		}
+		re, err := regexp.Compile(flags + "(?:" + strings.Join(quoted, "|") + ")")
+		if err != nil {
+			return nil, fmt.Errorf("compile keywords: %w", err)
+		}
+		compiled.matchers = append(compiled.matchers, guardrailPreset{re: re})
+	case GuardrailDetectorPreset:
+		for _, p := range rule.Patterns {
+			preset, ok := guardrailPresets[p]
+			if !ok {
+				return nil, fmt.Errorf("unknown preset %q", p)
+			}
+			compiled.matchers = append(compiled.matchers, preset)
+		}
+	case GuardrailDetectorWebhook:
+	default:
+		return nil, fmt.Errorf("unknown detector %q", rule.Detector)
+	}
+	return compiled, nil
+}
+
+// GuardrailService 网关请求安全策略服务（PII 脱敏 / 关键词拦截 / 外部 webhook 检查）
+type GuardrailService struct {
+	repo      GuardrailRepository
+	groupRepo GroupRepository
+	cfg       *config.Config
+
+	rules      atomic.Pointer[map[int64][]*compiledGuardrailRule]
+	httpClient *http.Client
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewGuardrailService 创建安全策略服务
+func NewGuardrailService(repo GuardrailRepository, groupRepo GroupRepository, cfg *config.Config) *GuardrailService {
+	return &GuardrailService{
+		repo:      repo,
+		groupRepo: groupRepo,
+		cfg:       cfg,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start 加载规则并启动定时刷新
+func (s *GuardrailService) Start() {
+	if !s.configEnabled() {
+		return
+	}
+	s.reloadRules(context.Background())
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(guardrailRuleRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.reloadRules(context.Background())
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止规则刷新协程
+func (s *GuardrailService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	s.wg.Wait()
+}
+
+func (s *GuardrailService) configEnabled() bool {
+	return s != nil && s.repo != nil && s.cfg != nil && s.cfg.Guardrail.Enabled
+}
+
+func (s *GuardrailService) groupRules(apiKey *APIKey) []*compiledGuardrailRule {
+	if apiKey == nil || apiKey.GroupID == nil || !s.configEnabled() {
+		return nil
+	}
+	set := s.rules.Load()
+	if set == nil {
+		return nil
+	}
+	return (*set)[*apiKey.GroupID]
+}
+
+// Inspect 按分组规则检查请求中的用户输入文本。
+//
+// 规则按 priority 依次执行：block 命中立即返回；mask 命中后替换文本，后续规则（含 webhook）看到的是替换后的内容；
+// log 只记录命中次数。未配置规则时直接返回原请求体。
+func (s *GuardrailService) Inspect(ctx context.Context, req *GuardrailRequest) *GuardrailResult {
+	result := &GuardrailResult{Body: req.Body}
+	rules := s.groupRules(req.APIKey)
+	if len(rules) == 0 {
+		return result
+	}
+	texts := collectGuardrailTexts(req.Body)
+	if len(texts) == 0 {
+		return result
+	}
+
+	for _, rule := range rules {
+		var matches int
+		var blocked bool
+		var reason string
+		if rule.rule.Detector == GuardrailDetectorWebhook {
+			matches, blocked, reason = s.inspectWebhook(ctx, rule, req, texts)
+		} else {
+			matches = applyGuardrailRule(rule, texts)
+			blocked = matches > 0 && rule.rule.Action == GuardrailActionBlock
+		}
+		if matches == 0 && !blocked {
+			continue
+		}
+		result.Findings = append(result.Findings, GuardrailFinding{
+			RuleID:   rule.rule.ID,
+			RuleName: rule.rule.Name,
+			Action:   rule.rule.Action,
+			Matches:  matches,
+		})
+		if blocked {
+			result.Blocked = true
+			result.BlockedBy = rule.rule
+			result.Reason = reason
+			return result
+		}
+	}
+
+	body := req.Body
+	for i := range texts {
+		if !texts[i].modified {
+			continue
+		}
+		next, err := setGuardrailText(body, texts[i].path, texts[i].value)
+		if err != nil {
+			// 无法回写时拒绝转发，避免原文泄露到上游
+			logger.L().Warn("guardrail.mask_write_failed", zap.String("path", texts[i].path), zap.Error(err))
+			result.Blocked = true
+			result.Reason = "failed to apply guardrail masking"
+			return result
+		}
+		body = next
+		result.Modified = true
+	}
+	result.Body = body
+	return result
+}
+
+// applyGuardrailRule 对本地检测器规则执行匹配；mask 规则直接替换 texts 中的命中内容
+func applyGuardrailRule(rule *compiledGuardrailRule, texts []guardrailText) int {
+	matches := 0
+	for i := range texts {
+		spans := rule.find(texts[i].value)
+		if len(spans) == 0 {
+			continue
+		}
+		matches += len(spans)
+		if rule.rule.Action != GuardrailActionMask {
+			if rule.rule.Action == GuardrailActionBlock {
+				return matches
+			}
+			continue
+		}
+		texts[i].value = maskGuardrailSpans(texts[i].value, spans, rule.replacement())
+		texts[i].modified = true
+	}
+	return matches
+}
+
+func maskGuardrailSpans(text string, spans [][2]int, replacement string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	prev := 0
+	for _, s := range spans {
+		b.WriteString(text[prev:s[0]])
+		b.WriteString(replacement)
+		prev = s[1]
+	}
+	b.WriteString(text[prev:])
+	return b.String()
+}
+
+type guardrailWebhookRequest struct {
+	RuleID   int64    `json:"rule_id"`
+	GroupID  int64    `json:"group_id"`
+	APIKeyID int64    `json:"api_key_id"`
+	UserID   int64    `json:"user_id"`
+	Model    string   `json:"model"`
+	Protocol string   `json:"protocol"`
+	Texts    []string `json:"texts"`
+}
+
+// guardrailWebhookResponse 外部检查器响应：flagged 表示命中；mask 动作使用 matches 中的原文片段做替换
+type guardrailWebhookResponse struct {
+	Flagged bool     `json:"flagged"`
+	Reason  string   `json:"reason"`
+	Matches []string `json:"matches"`
+}
+
+// inspectWebhook 调用外部检查器，返回 (命中数, 是否拦截, 拦截原因)
+func (s *GuardrailService) inspectWebhook(ctx context.Context, rule *compiledGuardrailRule, req *GuardrailRequest, texts []guardrailText) (int, bool, string) {
+	payload := guardrailWebhookRequest{
+		RuleID:   rule.rule.ID,
+		GroupID:  rule.rule.GroupID,
+		Model:    req.Model,
+		Protocol: req.Protocol,
+		Texts:    make([]string, 0, len(texts)),
+	}
+	if req.APIKey != nil {
+		payload.APIKeyID = req.APIKey.ID
+		payload.UserID = req.APIKey.UserID
+	}
+	for i := range texts {
+		payload.Texts = append(payload.Texts, texts[i].value)
+	}
+
+	resp, err := s.callWebhook(ctx, rule, &payload)
+	if err != nil {
+		logger.L().Warn("guardrail.webhook_failed",
+			zap.Int64("rule_id", rule.rule.ID),
+			zap.Bool("fail_open", rule.rule.FailOpen),
+			zap.Error(err))
+		if rule.rule.FailOpen {
+			return 0, false, ""
+		}
+		return 0, true, "guardrail inspector unavailable"
+	}
+	if !resp.Flagged {
+		return 0, false, ""
+	}
+
+	switch rule.rule.Action {
+	case GuardrailActionBlock:
+		return 1, true, strings.TrimSpace(resp.Reason)
+	case GuardrailActionMask:
+		matches := 0
+		replacement := rule.replacement()
+		for _, m := range resp.Matches {
+			if m == "" {
+				continue
+			}
+			for i := range texts {
+				if n := strings.Count(texts[i].value, m); n > 0 {
+					matches += n
+					texts[i].value = strings.ReplaceAll(texts[i].value, m, replacement)
+					texts[i].modified = true
+				}
+			}
+		}
+		if matches == 0 {
+			// 检查器判定命中但未给出可替换的片段，无法安全脱敏，按拦截处理
+			return 1, true, strings.TrimSpace(resp.Reason)
+		}
+		return matches, false, ""
+	default:
+		return max(len(resp.Matches), 1), false, ""
+	}
+}
+
+func (s *GuardrailService) callWebhook(ctx context.Context, rule *compiledGuardrailRule, payload *guardrailWebhookRequest) (*guardrailWebhookResponse, error) {
+	client, err := s.client()
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal guardrail webhook request: %w", err)
+	}
+	timeoutMs := rule.rule.WebhookTimeoutMs
+	if timeoutMs <= 0 {
+		timeoutMs = s.cfg.Guardrail.WebhookTimeoutMs
+	}
+	reqCtx, cancel := context.WithTimeout(ctx, time.Duration(timeoutMs)*time.Millisecond)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(reqCtx, http.MethodPost, rule.rule.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("build guardrail webhook request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send guardrail webhook request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, guardrailWebhookResponseReadSize))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("guardrail webhook returned status %d", resp.StatusCode)
+	}
+	var out guardrailWebhookResponse
+	if err := json.Unmarshal(respBody, &out); err != nil {
+		return nil, fmt.Errorf("decode guardrail webhook response: %w", err)
+	}
+	return &out, nil
+}
+
+func (s *GuardrailService) client() (*http.Client, error) {
+	if s.httpClient != nil {
+		return s.httpClient, nil
+	}
+	opts := httpclient.Options{Timeout: guardrailMaxWebhookTimeoutMs * time.Millisecond}
+	if s.cfg != nil {
+		opts.ValidateResolvedIP = s.cfg.Security.URLAllowlist.Enabled
+		opts.AllowPrivateHosts = s.cfg.Security.URLAllowlist.AllowPrivateHosts
+	}
+	client, err := httpclient.GetClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("create http client failed: %w", err)
+	}
+	return client, nil
+}
+
+func (s *GuardrailService) reloadRules(ctx context.Context) {
+	if !s.configEnabled() {
+		return
+	}
+	loadCtx, cancel := context.WithTimeout(ctx, guardrailRuleLoadTimeout)
+	defer cancel()
+	rules, err := s.repo.ListRules(loadCtx)
+	if err != nil {
+		logger.L().Warn("guardrail.load_rules_failed", zap.Error(err))
+		return
+	}
+	set := buildGuardrailRuleSet(rules)
+	s.rules.Store(&set)
+}
+
+func buildGuardrailRuleSet(rules []GuardrailRule) map[int64][]*compiledGuardrailRule {
+	set := map[int64][]*compiledGuardrailRule{}
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Enabled {
+			continue
+		}
+		compiled, err := compileGuardrailRule(rule)
+		if err != nil {
+			logger.L().Warn("guardrail.compile_rule_failed", zap.Int64("rule_id", rule.ID), zap.Error(err))
+			continue
+		}
+		set[rule.GroupID] = append(set[rule.GroupID], compiled)
+	}
+	for _, list := range set {
+		sort.SliceStable(list, func(i, j int) bool {
+			if list[i].rule.Priority != list[j].rule.Priority {
+				return list[i].rule.Priority < list[j].rule.Priority
+			}
+			return list[i].rule.ID < list[j].rule.ID
+		})
+	}
+	return set
+}
+
+// ListRules 列出安全策略规则；groupID > 0 时只返回该分组的规则
+func (s *GuardrailService) ListRules(ctx context.Context, groupID int64) ([]GuardrailRule, error) {
+	rules, err := s.repo.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if groupID <= 0 {
+		return rules, nil
+	}
+	out := make([]GuardrailRule, 0, len(rules))
+	for i := range rules {
+		if rules[i].GroupID == groupID {
+			out = append(out, rules[i])
+		}
+	}
+	return out, nil
+}
+
+// GetRule 查询单条安全策略规则
+func (s *GuardrailService) GetRule(ctx context.Context, id int64) (*GuardrailRule, error) {
+	return s.repo.GetRule(ctx, id)
+}
+
+// CreateRule 创建安全策略规则
+func (s *GuardrailService) CreateRule(ctx context.Context, input *GuardrailRuleInput) (*GuardrailRule, error) {
+	rule, err := s.normalizeRuleInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.reloadRules(ctx)
+	return rule, nil
+}
+
+// UpdateRule 更新安全策略规则（整体替换）
+func (s *GuardrailService) UpdateRule(ctx context.Context, id int64, input *GuardrailRuleInput) (*GuardrailRule, error) {
+	rule, err := s.normalizeRuleInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = id
+	if err := s.repo.UpdateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.reloadRules(ctx)
+	return rule, nil
+}
+
+// DeleteRule 删除安全策略规则
+func (s *GuardrailService) DeleteRule(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+	s.reloadRules(ctx)
+	return nil
+}
+
+func (s *GuardrailService) normalizeRuleInput(ctx context.Context, input *GuardrailRuleInput) (*GuardrailRule, error) {
+	rule, err := normalizeGuardrailRuleInput(input)
+	if err != nil {
+		return nil, err
+	}
+	if s.groupRepo != nil {
+		if _, err := s.groupRepo.GetByIDLite(ctx, rule.GroupID); err != nil {
+			return nil, err
+		}
+	}
+	if rule.Detector == GuardrailDetectorWebhook {
+		normalized, err := s.validateWebhookURL(rule.WebhookURL)
+		if err != nil {
+			return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", err.Error())
+		}
+		rule.WebhookURL = normalized
+	}
+	return rule, nil
+}
+
+func (s *GuardrailService) validateWebhookURL(raw string) (string, error) {
+	if s.cfg != nil && !s.cfg.Security.URLAllowlist.Enabled {
+		normalized, err := urlvalidator.ValidateURLFormat(raw, s.cfg.Security.URLAllowlist.AllowInsecureHTTP)
+		if err != nil {
+			return "", fmt.Errorf("invalid webhook_url: %w", err)
+		}
+		return normalized, nil
+	}
+	opts := urlvalidator.ValidationOptions{}
+	allowInsecureHTTP := false
+	if s.cfg != nil {
+		opts.AllowPrivate = s.cfg.Security.URLAllowlist.AllowPrivateHosts
+		allowInsecureHTTP = s.cfg.Security.URLAllowlist.AllowInsecureHTTP
+	}
+	normalized, err := urlvalidator.ValidateHTTPURL(raw, allowInsecureHTTP, opts)
+	if err != nil {
+		return "", fmt.Errorf("invalid webhook_url: %w", err)
+	}
+	return normalized, nil
+}
+
+func normalizeGuardrailRuleInput(input *GuardrailRuleInput) (*GuardrailRule, error) {
+	if input == nil {
+		return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", "rule input cannot be nil")
+	}
+	if input.GroupID <= 0 {
+		return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", "group_id must be positive")
+	}
+	name := strings.TrimSpace(input.Name)
+	if name == "" || len(name) > 100 {
+		return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", "name is required and must be at most 100 characters")
+	}
+	detector := strings.ToLower(strings.TrimSpace(input.Detector))
+	action := strings.ToLower(strings.TrimSpace(input.Action))
+	if !slices.Contains([]string{GuardrailActionBlock, GuardrailActionMask, GuardrailActionLog}, action) {
+		return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", "action must be block, mask or log")
+	}
+	replacement := strings.TrimSpace(input.Replacement)
+	if len(replacement) > guardrailMaxReplacementLen {
+		return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", "replacement is too long")
+	}
+	if input.WebhookTimeoutMs < 0 || input.WebhookTimeoutMs > guardrailMaxWebhookTimeoutMs {
+		return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID",
+			fmt.Sprintf("webhook_timeout_ms must be within [0, %d]", guardrailMaxWebhookTimeoutMs))
+	}
+	if len(input.Patterns) > guardrailMaxPatterns {
+		return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", "too many patterns")
+	}
+
+	patterns := make([]string, 0, len(input.Patterns))
+	seen := make(map[string]struct{}, len(input.Patterns))
+	for _, p := range input.Patterns {
+		if detector == GuardrailDetectorPreset {
+			p = strings.ToLower(strings.TrimSpace(p))
+		}
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		if len(p) > guardrailMaxPatternLength {
+			return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", "pattern is too long")
+		}
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		patterns = append(patterns, p)
+	}
+
+	rule := &GuardrailRule{
+		GroupID:          input.GroupID,
+		Name:             name,
+		Detector:         detector,
+		Patterns:         patterns,
+		CaseSensitive:    input.CaseSensitive,
+		Action:           action,
+		Replacement:      replacement,
+		WebhookTimeoutMs: input.WebhookTimeoutMs,
+		FailOpen:         input.FailOpen,
+		Priority:         input.Priority,
+		Enabled:          input.Enabled,
+	}
+	switch detector {
+	case GuardrailDetectorWebhook:
+		rule.WebhookURL = strings.TrimSpace(input.WebhookURL)
+		if rule.WebhookURL == "" {
+			return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", "webhook_url is required for webhook detector")
+		}
+		rule.Patterns = []string{}
+	case GuardrailDetectorRegex, GuardrailDetectorKeyword, GuardrailDetectorPreset:
+		if len(patterns) == 0 {
+			return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", "patterns cannot be empty")
+		}
+	default:
+		return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", "detector must be regex, keyword, preset or webhook")
+	}
+	if _, err := compileGuardrailRule(rule); err != nil {
+		return nil, infraerrors.BadRequest("GUARDRAIL_RULE_INVALID", err.Error())
+	}
+	return rule, nil
+}
+
+// validLuhn 银行卡号 Luhn 校验（忽略空格与连字符）
+func validLuhn(s string) bool {
+	sum, n := 0, 0
+	double := false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		if c < '0' || c > '9' {
+			return false
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// validCNIDCardChecksum 18 位居民身份证号校验位（GB 11643-1999）
+func validCNIDCardChecksum(s string) bool {
+	if len(s) != 18 {
+		return false
+	}
+	weights := [17]int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i := 0; i < 17; i++ {
+		c := s[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		sum += int(c-'0') * weights[i]
+	}
+	check := "10X98765432"[sum%11]
+	last := s[17]
+	if last == 'x' {
+		last = 'X'
+	}
+	return last == check
+}
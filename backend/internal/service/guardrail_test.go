@@ -0,0 +1,232 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type guardrailRepoStub struct {
+	GuardrailRepository
+	rules []GuardrailRule
+}
+
+func (r *guardrailRepoStub) ListRules(context.Context) ([]GuardrailRule, error) {
+	return r.rules, nil
+}
+
+func newGuardrailServiceForTest(rules ...GuardrailRule) *GuardrailService {
+	cfg := &config.Config{}
+	cfg.Guardrail = config.GuardrailConfig{Enabled: true, WebhookTimeoutMs: 1000}
+	svc := NewGuardrailService(&guardrailRepoStub{rules: rules}, nil, cfg)
+	svc.reloadRules(context.Background())
+	return svc
+}
+
+func guardrailTestAPIKey(groupID int64) *APIKey {
+	return &APIKey{ID: 5, UserID: 9, GroupID: &groupID}
+}
+
+func TestGuardrailMaskAnthropicMessages(t *testing.T) {
+	svc := newGuardrailServiceForTest(GuardrailRule{
+		ID: 1, GroupID: 1, Name: "pii", Detector: GuardrailDetectorPreset,
+		Patterns: []string{"email", "cn_id_card", "api_secret"}, Action: GuardrailActionMask, Enabled: true,
+	})
+	body := []byte(`{"model":"claude-sonnet-4-5","system":"Contact ops@example.com",` +
+		`"messages":[{"role":"user","content":[` +
+		`{"type":"text","text":"我的身份证 11010519491231002X，key sk-ant-REDACTED"},` +
+		`{"type":"thinking","thinking":"alice@example.com","signature":"sig"}]},` +
+		`{"role":"assistant","content":"reply to bob@example.org"}],` +
+		`"metadata":{"user_id":"carol@example.com"}}`)
+
+	result := svc.Inspect(context.Background(), &GuardrailRequest{APIKey: guardrailTestAPIKey(1), Body: body})
+	require.False(t, result.Blocked)
+	require.True(t, result.Modified)
+	require.Len(t, result.Findings, 1)
+	require.Equal(t, 4, result.Findings[0].Matches)
+
+	require.Equal(t, "Contact [REDACTED]", gjson.GetBytes(result.Body, "system").String())
+	require.Equal(t, "我的身份证 [REDACTED]，key [REDACTED]", gjson.GetBytes(result.Body, "messages.0.content.0.text").String())
+	require.Equal(t, "reply to [REDACTED]", gjson.GetBytes(result.Body, "messages.1.content").String())
+	// 带签名的 thinking 块与 metadata 不改动
+	require.Equal(t, "alice@example.com", gjson.GetBytes(result.Body, "messages.0.content.1.thinking").String())
+	require.Equal(t, "carol@example.com", gjson.GetBytes(result.Body, "metadata.user_id").String())
+	require.Equal(t, "claude-sonnet-4-5", gjson.GetBytes(result.Body, "model").String())
+}
+
+func TestGuardrailMaskOpenAIFormats(t *testing.T) {
+	svc := newGuardrailServiceForTest(GuardrailRule{
+		ID: 1, GroupID: 1, Name: "phone", Detector: GuardrailDetectorPreset,
+		Patterns: []string{"phone"}, Action: GuardrailActionMask, Replacement: "<PHONE>", Enabled: true,
+	})
+
+	responses := []byte(`{"model":"gpt-5","instructions":"call 13812345678",` +
+		`"input":[{"role":"user","content":[{"type":"input_text","text":"my number is +1 415-555-0134"}]},` +
+		`{"type":"function_call_output","call_id":"c1","output":"(415) 555-0199"}]}`)
+	result := svc.Inspect(context.Background(), &GuardrailRequest{APIKey: guardrailTestAPIKey(1), Body: responses})
+	require.True(t, result.Modified)
+	require.Equal(t, "call <PHONE>", gjson.GetBytes(result.Body, "instructions").String())
+	require.Equal(t, "my number is <PHONE>", gjson.GetBytes(result.Body, "input.0.content.0.text").String())
+	require.Equal(t, "<PHONE>", gjson.GetBytes(result.Body, "input.1.output").String())
+	require.Equal(t, "c1", gjson.GetBytes(result.Body, "input.1.call_id").String())
+
+	chat := []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"ring 13812345678"}],"tools":[{"type":"function","function":{"name":"f","description":"13812345678"}}]}`)
+	result = svc.Inspect(context.Background(), &GuardrailRequest{APIKey: guardrailTestAPIKey(1), Body: chat})
+	require.Equal(t, "ring <PHONE>", gjson.GetBytes(result.Body, "messages.0.content").String())
+	require.Equal(t, "13812345678", gjson.GetBytes(result.Body, "tools.0.function.description").String())
+}
+
+func TestGuardrailBlockAndPriority(t *testing.T) {
+	svc := newGuardrailServiceForTest(
+		GuardrailRule{ID: 1, GroupID: 1, Name: "secret words", Detector: GuardrailDetectorKeyword,
+			Patterns: []string{"Project Falcon"}, Action: GuardrailActionBlock, Priority: 10, Enabled: true},
+		GuardrailRule{ID: 2, GroupID: 1, Name: "mask falcon", Detector: GuardrailDetectorRegex,
+			Patterns: []string{`falcon`}, Action: GuardrailActionMask, Priority: 1, Enabled: true},
+		GuardrailRule{ID: 3, GroupID: 1, Name: "disabled", Detector: GuardrailDetectorKeyword,
+			Patterns: []string{"hello"}, Action: GuardrailActionBlock, Enabled: false},
+	)
+	body := []byte(`{"messages":[{"role":"user","content":"hello, tell me about PROJECT FALCON"}]}`)
+
+	// mask 规则优先执行，block 规则看到的是替换后的文本
+	result := svc.Inspect(context.Background(), &GuardrailRequest{APIKey: guardrailTestAPIKey(1), Body: body})
+	require.False(t, result.Blocked)
+	require.Equal(t, "hello, tell me about PROJECT [REDACTED]", gjson.GetBytes(result.Body, "messages.0.content").String())
+
+	svc = newGuardrailServiceForTest(GuardrailRule{ID: 1, GroupID: 1, Name: "secret words", Detector: GuardrailDetectorKeyword,
+		Patterns: []string{"Project Falcon"}, Action: GuardrailActionBlock, Enabled: true})
+	result = svc.Inspect(context.Background(), &GuardrailRequest{APIKey: guardrailTestAPIKey(1), Body: body})
+	require.True(t, result.Blocked)
+	require.Equal(t, "secret words", result.BlockedBy.Name)
+
+	// 其他分组与无分组不受影响
+	result = svc.Inspect(context.Background(), &GuardrailRequest{APIKey: guardrailTestAPIKey(2), Body: body})
+	require.False(t, result.Blocked)
+	result = svc.Inspect(context.Background(), &GuardrailRequest{APIKey: &APIKey{ID: 1}, Body: body})
+	require.False(t, result.Blocked)
+	require.False(t, result.Modified)
+}
+
+func TestGuardrailLogOnlyKeepsBody(t *testing.T) {
+	svc := newGuardrailServiceForTest(GuardrailRule{ID: 1, GroupID: 1, Name: "cards", Detector: GuardrailDetectorPreset,
+		Patterns: []string{"credit_card"}, Action: GuardrailActionLog, Enabled: true})
+	body := []byte(`{"messages":[{"role":"user","content":"4111 1111 1111 1111 and 4111 1111 1111 1112"}]}`)
+
+	result := svc.Inspect(context.Background(), &GuardrailRequest{APIKey: guardrailTestAPIKey(1), Body: body})
+	require.False(t, result.Blocked)
+	require.False(t, result.Modified)
+	require.Equal(t, body, result.Body)
+	require.Len(t, result.Findings, 1)
+	require.Equal(t, 1, result.Findings[0].Matches, "only the Luhn-valid number matches")
+}
+
+func TestGuardrailWebhook(t *testing.T) {
+	var received guardrailWebhookRequest
+	var reply guardrailWebhookResponse
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		_ = json.NewEncoder(w).Encode(reply)
+	}))
+	defer srv.Close()
+
+	rule := GuardrailRule{ID: 7, GroupID: 1, Name: "inspector", Detector: GuardrailDetectorWebhook,
+		WebhookURL: srv.URL, Action: GuardrailActionMask, FailOpen: true, Enabled: true}
+	svc := newGuardrailServiceForTest(rule)
+	svc.httpClient = srv.Client()
+	body := []byte(`{"model":"m","messages":[{"role":"user","content":"customer Jane Roe ordered"}]}`)
+	req := &GuardrailRequest{APIKey: guardrailTestAPIKey(1), Protocol: GuardrailProtocolOpenAIChatCompletions, Model: "m", Body: body}
+
+	reply = guardrailWebhookResponse{Flagged: true, Matches: []string{"Jane Roe"}}
+	result := svc.Inspect(context.Background(), req)
+	require.Equal(t, []string{"customer Jane Roe ordered"}, received.Texts)
+	require.Equal(t, int64(7), received.RuleID)
+	require.Equal(t, GuardrailProtocolOpenAIChatCompletions, received.Protocol)
+	require.Equal(t, "customer [REDACTED] ordered", gjson.GetBytes(result.Body, "messages.0.content").String())
+
+	// 判定命中但没有可替换的片段时按拦截处理
+	reply = guardrailWebhookResponse{Flagged: true, Reason: "policy violation"}
+	result = svc.Inspect(context.Background(), req)
+	require.True(t, result.Blocked)
+	require.Equal(t, "policy violation", result.Reason)
+
+	reply = guardrailWebhookResponse{Flagged: false}
+	result = svc.Inspect(context.Background(), req)
+	require.False(t, result.Blocked)
+	require.False(t, result.Modified)
+}
+
+func TestGuardrailWebhookFailureMode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	body := []byte(`{"messages":[{"role":"user","content":"hi"}]}`)
+
+	for _, failOpen := range []bool{true, false} {
+		svc := newGuardrailServiceForTest(GuardrailRule{ID: 1, GroupID: 1, Name: "inspector", Detector: GuardrailDetectorWebhook,
+			WebhookURL: srv.URL, Action: GuardrailActionBlock, FailOpen: failOpen, Enabled: true})
+		svc.httpClient = srv.Client()
+		result := svc.Inspect(context.Background(), &GuardrailRequest{APIKey: guardrailTestAPIKey(1), Body: body})
+		require.Equal(t, !failOpen, result.Blocked)
+	}
+}
+
+func TestCollectGuardrailTextsEscapesPaths(t *testing.T) {
+	body := []byte(`{"messages":[{"content":{"a.b":{"text":"x@example.com"},"0":{"text":"y@example.com"}}}]}`)
+	texts := collectGuardrailTexts(body)
+	require.Len(t, texts, 2)
+	for _, tx := range texts {
+		out, err := setGuardrailText(body, tx.path, "masked")
+		require.NoError(t, err)
+		require.Equal(t, strings.Count(string(body), "@"), strings.Count(string(out), "@")+1, tx.path)
+	}
+}
+
+func TestGuardrailPresetValidators(t *testing.T) {
+	require.True(t, validCNIDCardChecksum("11010519491231002X"))
+	require.False(t, validCNIDCardChecksum("110105194912310021"))
+	require.True(t, validLuhn("4111-1111-1111-1111"))
+	require.False(t, validLuhn("4111-1111-1111-1112"))
+}
+
+func TestNormalizeGuardrailRuleInput(t *testing.T) {
+	base := GuardrailRuleInput{GroupID: 1, Name: "r", Detector: GuardrailDetectorRegex, Patterns: []string{`\d+`}, Action: GuardrailActionMask}
+
+	in := base
+	in.Patterns = []string{"("}
+	_, err := normalizeGuardrailRuleInput(&in)
+	require.Error(t, err)
+
+	in = base
+	in.Action = "drop"
+	_, err = normalizeGuardrailRuleInput(&in)
+	require.Error(t, err)
+
+	in = base
+	in.Detector = GuardrailDetectorPreset
+	in.Patterns = []string{"passport"}
+	_, err = normalizeGuardrailRuleInput(&in)
+	require.Error(t, err)
+
+	in = base
+	in.Detector = GuardrailDetectorWebhook
+	in.Patterns = nil
+	_, err = normalizeGuardrailRuleInput(&in)
+	require.Error(t, err, "webhook_url required")
+
+	in = base
+	in.Detector = " Preset "
+	in.Patterns = []string{"EMAIL", "email", " "}
+	rule, err := normalizeGuardrailRuleInput(&in)
+	require.NoError(t, err)
+	require.Equal(t, GuardrailDetectorPreset, rule.Detector)
+	require.Equal(t, []string{"email"}, rule.Patterns)
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// guardrailTextKeys 承载用户输入文本的字段名，覆盖 Anthropic Messages（system / content / text）、
+// OpenAI Responses（instructions / input / output / text）与 Chat Completions（content / text / arguments）。
+var guardrailTextKeys = map[string]struct{}{
+	"system":       {},
+	"content":      {},
+	"text":         {},
+	"instructions": {},
+	"input":        {},
+	"output":       {},
+	"prompt":       {},
+	"arguments":    {},
+}
+
+// guardrailSkipKeys 不需要检查的子树：工具定义、输出格式、请求元数据，以及带签名、改动后会被上游拒绝的 thinking 块
+var guardrailSkipKeys = map[string]struct{}{
+	"tools":             {},
+	"tool_choice":       {},
+	"response_format":   {},
+	"metadata":          {},
+	"thinking":          {},
+	"redacted_thinking": {},
+	"signature":         {},
+	"encrypted_content": {},
+}
+
+// guardrailText 请求体中的一段待检查文本及其 sjson 路径
+type guardrailText struct {
+	path     string
+	value    string
+	modified bool
+}
+
+// collectGuardrailTexts 遍历请求 JSON，收集承载用户输入的字符串字段
+func collectGuardrailTexts(body []byte) []guardrailText {
+	if !gjson.ValidBytes(body) {
+		return nil
+	}
+	root := gjson.ParseBytes(body)
+	if !root.IsObject() {
+		return nil
+	}
+	var texts []guardrailText
+	walkGuardrailJSON(root, "", "", &texts)
+	return texts
+}
+
+func walkGuardrailJSON(node gjson.Result, path, key string, texts *[]guardrailText) {
+	switch {
+	case node.IsObject():
+		node.ForEach(func(k, v gjson.Result) bool {
+			name := k.String()
+			if _, skip := guardrailSkipKeys[name]; skip {
+				return true
+			}
+			walkGuardrailJSON(v, joinGuardrailPath(path, escapeGuardrailPathKey(name)), name, texts)
+			return true
+		})
+	case node.IsArray():
+		idx := 0
+		node.ForEach(func(_, v gjson.Result) bool {
+			// 数组元素继承父字段名：如 "system": ["..."] 或 "input": ["..."]
+			walkGuardrailJSON(v, joinGuardrailPath(path, strconv.Itoa(idx)), key, texts)
+			idx++
+			return true
+		})
+	case node.Type == gjson.String:
+		if _, ok := guardrailTextKeys[key]; ok && node.Str != "" {
+			*texts = append(*texts, guardrailText{path: path, value: node.Str})
+		}
+	}
+}
+
+func joinGuardrailPath(parent, elem string) string {
+	if parent == "" {
+		return elem
+	}
+	return parent + "." + elem
+}
+
+// escapeGuardrailPathKey 转义 gjson/sjson 路径中的特殊字符
+func escapeGuardrailPathKey(key string) string {
+	if !strings.ContainsAny(key, `.*?|#@\`) {
+		if _, err := strconv.Atoi(key); err != nil {
+			return key
+		}
+	}
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		switch key[i] {
+		case '.', '*', '?', '|', '#', '@', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(key[i])
+	}
+	out := b.String()
+	// 纯数字键在 sjson 中会被当作数组下标，使用 ":" 前缀强制按对象键处理
+	if _, err := strconv.Atoi(key); err == nil {
+		return ":" + out
+	}
+	return out
+}
+
+func setGuardrailText(body []byte, path, value string) ([]byte, error) {
+	return sjson.SetBytes(body, path, value)
+}
@@ -22,3 +22,21 @@ type HTTPUpstream interface {
 	// 支持按账号绑定的数据库 profile 或内置默认 profile。
 	DoWithTLS(req *http.Request, proxyURL string, accountID int64, accountConcurrency int, profile *tlsfingerprint.Profile) (*http.Response, error)
 }
+
+// ProxyPoolCandidate 代理池内的一个候选代理
+type ProxyPoolCandidate struct {
+	ProxyID int64
+	URL     string
+}
+
+// ProxyPoolResolver 代理池解析接口
+// HTTPUpstream 收到代理池占位 URL（proxypool://）时据此在池内选择代理，
+// 连接失败时切换到下一个候选代理，并上报结果用于健康标记；任何情况下都不回退直连。
+type ProxyPoolResolver interface {
+	// ProxyPoolCandidates 返回账号本次请求应依次尝试的池内代理（按策略排序，健康代理优先）
+	ProxyPoolCandidates(poolID, accountID int64) ([]ProxyPoolCandidate, error)
+	// ReportProxyResult 上报一次经由池内代理的连接结果，err 为 nil 表示成功
+	ReportProxyResult(proxyID int64, err error)
+	// MaxFailoverAttempts 单次请求最多尝试的代理数
+	MaxFailoverAttempts() int
+}
@@ -0,0 +1,562 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+const (
+	// messageBatchProgressInterval 本地批次进度落库的最小间隔（同时作为心跳）
+	messageBatchProgressInterval = 30 * time.Second
+	// messageBatchMaxAccountSwitches 单条请求遇到可 failover 错误时的最大换号次数
+	messageBatchMaxAccountSwitches = 3
+	// messageBatchSlotRetryInterval 账号并发槽位已满时的重试间隔（两种批处理执行器共用）
+	messageBatchSlotRetryInterval = 500 * time.Millisecond
+)
+
+// messageBatchHeaderAllowlist 本地执行时从创建请求继承的请求头
+var messageBatchHeaderAllowlist = map[string]bool{
+	"anthropic-version": true,
+	"anthropic-beta":    true,
+	"user-agent":        true,
+}
+
+// localMessageBatchJob 本地执行中的批次。batch 由 mu 保护，取消与进度落库共用同一份状态。
+type localMessageBatchJob struct {
+	mu          sync.Mutex
+	persistMu   sync.Mutex // 串行化落库，保证数据库中的状态按快照顺序写入
+	batch       *MessageBatch
+	caller      *MessageBatchCaller
+	requests    []MessageBatchRequest
+	results     *os.File
+	lastPersist time.Time
+
+	cancelOnce sync.Once
+	cancelCh   chan struct{}
+}
+
+// messageBatchResultLine 结果文件中的一行
+type messageBatchResultLine struct {
+	CustomID string                  `json:"custom_id"`
+	Result   messageBatchResultEntry `json:"result"`
+}
+
+type messageBatchResultEntry struct {
+	Type    string          `json:"type"`
+	Message json.RawMessage `json:"message,omitempty"`
+	Error   json.RawMessage `json:"error,omitempty"`
+}
+
+func newMessageBatchErroredLine(customID string, status int, message string) messageBatchResultLine {
+	errBody, _ := json.Marshal(map[string]any{
+		"type": "error",
+		"error": map[string]string{
+			"type":    messageBatchErrorType(status),
+			"message": message,
+		},
+	})
+	return messageBatchResultLine{
+		CustomID: customID,
+		Result:   messageBatchResultEntry{Type: MessageBatchResultErrored, Error: errBody},
+	}
+}
+
+// messageBatchErrorType 将 HTTP 状态码映射为 Anthropic 错误类型
+func messageBatchErrorType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid_request_error"
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case 529:
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
+
+func (s *MessageBatchService) startLocalBatch(batch *MessageBatch, caller *MessageBatchCaller, requests []MessageBatchRequest) {
+	job := &localMessageBatchJob{
+		batch:       batch,
+		caller:      caller,
+		requests:    requests,
+		lastPersist: time.Now(),
+		cancelCh:    make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.running[batch.ID] = job
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, batch.ID)
+			s.mu.Unlock()
+		}()
+		s.runLocalBatch(job)
+	}()
+}
+
+func (s *MessageBatchService) runningJob(id string) *localMessageBatchJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running[id]
+}
+
+// runLocalBatch 以有界并发逐条执行批次请求，结果追加写入 JSONL 文件
+func (s *MessageBatchService) runLocalBatch(job *localMessageBatchJob) {
+	batchID := job.batch.ID
+	log := logger.L().With(zap.String("component", "service.message_batch"), zap.String("batch_id", batchID))
+
+	f, err := os.OpenFile(s.resultsPath(batchID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		log.Error("message_batch.open_results_failed", zap.Error(err))
+		return
+	}
+	job.results = f
+	defer func() { _ = f.Close() }()
+
+	ctx, cancel := context.WithDeadline(s.rootCtx, job.batch.ExpiresAt)
+	defer cancel()
+
+	concurrency := s.cfg.Gateway.MessageBatches.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+	var inflight sync.WaitGroup
+
+	// 心跳：长耗时请求期间也定期刷新 updated_at，避免被维护任务误判为中断
+	stopHeartbeat := make(chan struct{})
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		ticker := time.NewTicker(messageBatchProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				job.persist(s.repo, true)
+			case <-stopHeartbeat:
+				return
+			}
+		}
+	}()
+
+dispatch:
+	for _, req := range job.requests {
+		acquired := false
+		select {
+		case sem <- struct{}{}:
+			acquired = true
+		case <-job.cancelCh:
+		case <-ctx.Done():
+		}
+		if s.rootCtx.Err() != nil {
+			// 网关关闭：保留已完成结果，剩余请求由维护任务标记为失败
+			if acquired {
+				<-sem
+			}
+			break dispatch
+		}
+		if skip := job.skipResultType(ctx); skip != "" {
+			if acquired {
+				<-sem
+			}
+			job.write(messageBatchResultLine{CustomID: req.CustomID, Result: messageBatchResultEntry{Type: skip}}, s.repo)
+			continue
+		}
+
+		inflight.Add(1)
+		go func(req MessageBatchRequest) {
+			defer inflight.Done()
+			defer func() { <-sem }()
+			job.write(s.executeLocalItem(ctx, job, req), s.repo)
+		}(req)
+	}
+	inflight.Wait()
+	close(stopHeartbeat)
+	<-heartbeatDone
+
+	if s.rootCtx.Err() != nil {
+		job.persist(s.repo, true)
+		return
+	}
+
+	job.mu.Lock()
+	now := time.Now()
+	job.batch.ProcessingStatus = MessageBatchStatusEnded
+	job.batch.EndedAt = &now
+	job.batch.ResultsReady = true
+	job.batch.Counts.Processing = 0
+	job.mu.Unlock()
+	job.persist(s.repo, true)
+
+	final := job.snapshot()
+	log.Info("message_batch.local_ended",
+		zap.Int("succeeded", final.Counts.Succeeded),
+		zap.Int("errored", final.Counts.Errored),
+		zap.Int("canceled", final.Counts.Canceled),
+		zap.Int("expired", final.Counts.Expired),
+	)
+}
+
+// executeLocalItem 选择账号并通过 GatewayService.Forward 执行单条请求。
+// 每条请求执行前重新校验计费资格，余额/订阅额度耗尽后剩余请求直接记为失败。
+func (s *MessageBatchService) executeLocalItem(ctx context.Context, job *localMessageBatchJob, req MessageBatchRequest) messageBatchResultLine {
+	apiKey := job.caller.APIKey
+	parsed, err := ParseGatewayRequest(req.Params, domain.PlatformAnthropic)
+	if err != nil || parsed.Model == "" {
+		return newMessageBatchErroredLine(req.CustomID, http.StatusBadRequest, "Failed to parse request params")
+	}
+	if s.billingCacheService != nil {
+		if err := s.billingCacheService.CheckBillingEligibility(ctx, apiKey.User, apiKey, apiKey.Group, job.caller.Subscription); err != nil {
+			return newMessageBatchErroredLine(req.CustomID, infraerrors.Code(err), infraerrors.Message(err))
+		}
+	}
+	// 分组安全策略：与 /v1/messages 一致，在转发之前检查每条请求
+	guard := s.guardrailService.Inspect(ctx, &GuardrailRequest{
+		APIKey:   apiKey,
+		Protocol: GuardrailProtocolAnthropicMessages,
+		Model:    parsed.Model,
+		Body:     parsed.Body,
+	})
+	if guard.Blocked {
+		return newMessageBatchErroredLine(req.CustomID, http.StatusBadRequest, guard.BlockedMessage())
+	}
+	if guard.Modified {
+		parsed, err = ParseGatewayRequest(guard.Body, domain.PlatformAnthropic)
+		if err != nil {
+			return newMessageBatchErroredLine(req.CustomID, http.StatusBadRequest, "Failed to parse request params")
+		}
+	}
+	reqModel := parsed.Model
+	channelMapping, _ := s.gatewayService.ResolveChannelMappingAndRestrict(ctx, apiKey.GroupID, reqModel)
+	if channelMapping.Mapped {
+		parsed.Model = channelMapping.MappedModel
+		parsed.Body = ReplaceModelInBody(parsed.Body, channelMapping.MappedModel)
+	}
+
+	failedAccountIDs := make(map[int64]struct{})
+	var lastFailover *UpstreamFailoverError
+	for switches := 0; switches <= messageBatchMaxAccountSwitches; switches++ {
+		selection, err := s.gatewayService.SelectAccountWithLoadAwareness(ctx, apiKey.GroupID, "", reqModel, failedAccountIDs, parsed.MetadataUserID, 0)
+		if err != nil || selection == nil || selection.Account == nil {
+			break
+		}
+		account := selection.Account
+		release, err := acquireBatchAccountSlot(ctx, s.concurrencyService, selection)
+		if err != nil {
+			return newMessageBatchErroredLine(req.CustomID, http.StatusServiceUnavailable, "No available account slot before the batch expired")
+		}
+
+		c, recorder := newMessageBatchGinContext(ctx, job.caller.Header)
+		result, err := s.gatewayService.Forward(ctx, c, account, parsed)
+		if release != nil {
+			release()
+		}
+		if err != nil {
+			var failoverErr *UpstreamFailoverError
+			if errors.As(err, &failoverErr) {
+				failedAccountIDs[account.ID] = struct{}{}
+				lastFailover = failoverErr
+				continue
+			}
+			if recorder.status >= http.StatusBadRequest && gjson.ValidBytes(recorder.body.Bytes()) {
+				return messageBatchErroredLineFromBody(req.CustomID, recorder.status, recorder.body.Bytes())
+			}
+			return newMessageBatchErroredLine(req.CustomID, http.StatusBadGateway, "Upstream request failed")
+		}
+		if recorder.status >= http.StatusBadRequest {
+			return messageBatchErroredLineFromBody(req.CustomID, recorder.status, recorder.body.Bytes())
+		}
+		message := recorder.body.Bytes()
+		if !gjson.ValidBytes(message) {
+			return newMessageBatchErroredLine(req.CustomID, http.StatusBadGateway, "Upstream returned an invalid response")
+		}
+
+		s.recordItemUsage(job.batch, req.CustomID, job.caller, account, result, "/v1/messages", req.Params,
+			channelMapping.ToUsageFields(reqModel, result.UpstreamModel))
+		return messageBatchResultLine{
+			CustomID: req.CustomID,
+			Result:   messageBatchResultEntry{Type: MessageBatchResultSucceeded, Message: json.RawMessage(message)},
+		}
+	}
+
+	if lastFailover != nil {
+		return messageBatchErroredLineFromBody(req.CustomID, lastFailover.StatusCode, lastFailover.ResponseBody)
+	}
+	return newMessageBatchErroredLine(req.CustomID, http.StatusServiceUnavailable, "No available accounts")
+}
+
+// acquireBatchAccountSlot 获取账号并发槽位；槽位已满时按等待计划轮询，直到获取成功或 ctx 结束。
+// 批处理执行器（Anthropic Message Batches / OpenAI Batch）共用。
+func acquireBatchAccountSlot(ctx context.Context, concurrencyService *ConcurrencyService, selection *AccountSelectionResult) (func(), error) {
+	if selection.Acquired {
+		return selection.ReleaseFunc, nil
+	}
+	if selection.WaitPlan == nil || concurrencyService == nil {
+		return nil, nil
+	}
+	for {
+		// 批处理不追求低延迟：等待计划超时后继续等待，直到批次过期或被取消
+		result, err := concurrencyService.AcquireAccountSlot(ctx, selection.Account.ID, selection.WaitPlan.MaxConcurrency)
+		if err == nil && result.Acquired {
+			return result.ReleaseFunc, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(messageBatchSlotRetryInterval):
+		}
+	}
+}
+
+func messageBatchErroredLineFromBody(customID string, status int, body []byte) messageBatchResultLine {
+	if gjson.GetBytes(body, "type").String() == "error" && gjson.GetBytes(body, "error").IsObject() {
+		return messageBatchResultLine{
+			CustomID: customID,
+			Result:   messageBatchResultEntry{Type: MessageBatchResultErrored, Error: json.RawMessage(body)},
+		}
+	}
+	msg := sanitizeUpstreamErrorMessage(strings.TrimSpace(extractUpstreamErrorMessage(body)))
+	if msg == "" {
+		msg = fmt.Sprintf("Upstream request failed with status %d", status)
+	}
+	return newMessageBatchErroredLine(customID, status, msg)
+}
+
+// batchResponseWriter 批处理执行器在内存中接收单条请求的完整响应（两种批处理执行器共用）
+type batchResponseWriter struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func newBatchResponseWriter() *batchResponseWriter {
+	return &batchResponseWriter{header: make(http.Header), status: http.StatusOK}
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = statusCode
+}
+
+func (w *batchResponseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.body.Write(p)
+}
+
+func (w *batchResponseWriter) Flush() {}
+
+// newMessageBatchGinContext 构造 Forward 所需的 gin 上下文，响应写入内存 writer
+func newMessageBatchGinContext(ctx context.Context, header http.Header) (*gin.Context, *batchResponseWriter) {
+	recorder := newBatchResponseWriter()
+	c, _ := gin.CreateTestContext(recorder)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost/v1/messages", nil)
+	req.Header.Set("content-type", "application/json")
+	for key, values := range header {
+		if !messageBatchHeaderAllowlist[strings.ToLower(key)] {
+			continue
+		}
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	c.Request = req
+	return c, recorder
+}
+
+// skipResultType 返回未开始请求应记录的结果类型（已取消 / 已过期），空串表示继续执行
+func (j *localMessageBatchJob) skipResultType(ctx context.Context) string {
+	select {
+	case <-j.cancelCh:
+		return MessageBatchResultCanceled
+	default:
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return MessageBatchResultExpired
+	}
+	return ""
+}
+
+// write 追加一行结果并更新计数；达到落库间隔时持久化进度
+func (j *localMessageBatchJob) write(line messageBatchResultLine, repo MessageBatchRepository) {
+	data, err := json.Marshal(line)
+	if err != nil {
+		data, _ = json.Marshal(newMessageBatchErroredLine(line.CustomID, http.StatusInternalServerError, "Failed to encode result"))
+		line.Result.Type = MessageBatchResultErrored
+	}
+
+	j.mu.Lock()
+	if _, err := j.results.Write(append(data, '\n')); err != nil {
+		logger.L().Error("message_batch.write_result_failed", zap.String("batch_id", j.batch.ID), zap.Error(err))
+	}
+	counts := &j.batch.Counts
+	switch line.Result.Type {
+	case MessageBatchResultSucceeded:
+		counts.Succeeded++
+	case MessageBatchResultCanceled:
+		counts.Canceled++
+	case MessageBatchResultExpired:
+		counts.Expired++
+	default:
+		counts.Errored++
+	}
+	if counts.Processing > 0 {
+		counts.Processing--
+	}
+	j.mu.Unlock()
+
+	j.persist(repo, false)
+}
+
+// persist 持久化批次进度；force=false 时按 messageBatchProgressInterval 节流
+func (j *localMessageBatchJob) persist(repo MessageBatchRepository, force bool) {
+	j.persistMu.Lock()
+	defer j.persistMu.Unlock()
+
+	j.mu.Lock()
+	if !force && time.Since(j.lastPersist) < messageBatchProgressInterval {
+		j.mu.Unlock()
+		return
+	}
+	j.lastPersist = time.Now()
+	snapshot := *j.batch
+	j.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := repo.Update(ctx, &snapshot); err != nil {
+		logger.L().Warn("message_batch.persist_failed", zap.String("batch_id", snapshot.ID), zap.Error(err))
+	}
+}
+
+func (j *localMessageBatchJob) snapshot() *MessageBatch {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	b := *j.batch
+	return &b
+}
+
+func (j *localMessageBatchJob) cancel(ctx context.Context, repo MessageBatchRepository) *MessageBatch {
+	j.cancelOnce.Do(func() {
+		j.mu.Lock()
+		if j.batch.IsEnded() {
+			j.mu.Unlock()
+			return
+		}
+		now := time.Now()
+		j.batch.ProcessingStatus = MessageBatchStatusCanceling
+		j.batch.CancelInitiatedAt = &now
+		j.mu.Unlock()
+		close(j.cancelCh)
+		j.persist(repo, true)
+	})
+	return j.snapshot()
+}
+
+// recoverInterruptedBatch 结束因网关重启而中断的本地批次：未产出结果的请求记为 errored
+func (s *MessageBatchService) recoverInterruptedBatch(ctx context.Context, batch *MessageBatch) error {
+	ids, err := os.ReadFile(s.customIDsPath(batch.ID))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("read message batch custom ids: %w", err)
+	}
+
+	counts := MessageBatchRequestCounts{}
+	done := make(map[string]struct{})
+	if f, err := os.Open(s.resultsPath(batch.ID)); err == nil {
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), messageBatchResultLineMaxBytes)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			done[gjson.GetBytes(line, "custom_id").String()] = struct{}{}
+			switch gjson.GetBytes(line, "result.type").String() {
+			case MessageBatchResultSucceeded:
+				counts.Succeeded++
+			case MessageBatchResultCanceled:
+				counts.Canceled++
+			case MessageBatchResultExpired:
+				counts.Expired++
+			default:
+				counts.Errored++
+			}
+		}
+		scanErr := scanner.Err()
+		_ = f.Close()
+		if scanErr != nil {
+			return fmt.Errorf("read message batch results: %w", scanErr)
+		}
+	}
+
+	f, err := os.OpenFile(s.resultsPath(batch.ID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open message batch results: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	for _, customID := range strings.Split(strings.TrimSpace(string(ids)), "\n") {
+		if customID == "" {
+			continue
+		}
+		if _, ok := done[customID]; ok {
+			continue
+		}
+		data, _ := json.Marshal(newMessageBatchErroredLine(customID, http.StatusInternalServerError, "Batch execution was interrupted before this request was processed"))
+		_, _ = w.Write(append(data, '\n'))
+		counts.Errored++
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write message batch results: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write message batch results: %w", err)
+	}
+
+	now := time.Now()
+	batch.Counts = counts
+	batch.ProcessingStatus = MessageBatchStatusEnded
+	batch.EndedAt = &now
+	batch.ResultsReady = true
+	logger.L().Info("message_batch.recovered_interrupted",
+		zap.String("batch_id", batch.ID),
+		zap.Int("errored", counts.Errored),
+	)
+	return s.repo.Update(ctx, batch)
+}
@@ -0,0 +1,832 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+)
+
+// 批次执行模式
+const (
+	MessageBatchModePassthrough = "passthrough" // 透传上游 Message Batches 接口
+	MessageBatchModeLocal       = "local"       // 网关本地执行器逐条转发
+)
+
+// 批次处理状态（与 Anthropic processing_status 一致）
+const (
+	MessageBatchStatusInProgress = "in_progress"
+	MessageBatchStatusCanceling  = "canceling"
+	MessageBatchStatusEnded      = "ended"
+)
+
+// 单条结果类型（与 Anthropic result.type 一致）
+const (
+	MessageBatchResultSucceeded = "succeeded"
+	MessageBatchResultErrored   = "errored"
+	MessageBatchResultCanceled  = "canceled"
+	MessageBatchResultExpired   = "expired"
+)
+
+const (
+	messageBatchIDPrefix = "msgbatch_"
+	// messageBatchTTL 批次最长处理时间，超时未执行的请求记为 expired
+	messageBatchTTL = 24 * time.Hour
+	// messageBatchMaintenanceInterval 中断批次回收与过期结果清理的周期
+	messageBatchMaintenanceInterval = 5 * time.Minute
+	// messageBatchStaleAfter 本地批次超过该时长无进度心跳视为执行中断（实例重启/崩溃）
+	messageBatchStaleAfter = 5 * time.Minute
+	// messageBatchPassthroughPollAfter passthrough 批次距上次同步超过该时长时由维护任务同步上游并结算
+	messageBatchPassthroughPollAfter = 5 * time.Minute
+	// messageBatchMaintenanceEndpoint 维护任务结算 passthrough 批次时记录的入站端点
+	messageBatchMaintenanceEndpoint = "/v1/messages/batches"
+	// messageBatchResultLineMaxBytes 结果文件单行读取上限
+	messageBatchResultLineMaxBytes = 64 << 20
+
+	MessageBatchListDefaultLimit = 20
+	MessageBatchListMaxLimit     = 1000
+)
+
+var messageBatchCustomIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+var (
+	ErrMessageBatchNotFound          = infraerrors.NotFound("MESSAGE_BATCH_NOT_FOUND", "message batch not found")
+	ErrMessageBatchesDisabled        = infraerrors.NotFound("MESSAGE_BATCHES_DISABLED", "message batches are not enabled")
+	ErrMessageBatchEmpty             = infraerrors.BadRequest("MESSAGE_BATCH_EMPTY", "requests must contain at least one request")
+	ErrMessageBatchInvalidBody       = infraerrors.BadRequest("MESSAGE_BATCH_INVALID_BODY", "request body must be an object with a requests array")
+	ErrMessageBatchInvalidCustomID   = infraerrors.BadRequest("MESSAGE_BATCH_INVALID_CUSTOM_ID", "custom_id must be 1-64 characters of letters, digits, '-' or '_'")
+	ErrMessageBatchDuplicateCustomID = infraerrors.BadRequest("MESSAGE_BATCH_DUPLICATE_CUSTOM_ID", "custom_id must be unique within a batch")
+	ErrMessageBatchInvalidParams     = infraerrors.BadRequest("MESSAGE_BATCH_INVALID_PARAMS", "params must be a Messages API request object with a model")
+	ErrMessageBatchStreamUnsupported = infraerrors.BadRequest("MESSAGE_BATCH_STREAM_UNSUPPORTED", "streaming is not supported for batch requests")
+	ErrMessageBatchNotEnded          = infraerrors.BadRequest("MESSAGE_BATCH_NOT_ENDED", "results are not available until the batch has ended")
+	ErrMessageBatchResultsMissing    = infraerrors.NotFound("MESSAGE_BATCH_RESULTS_MISSING", "message batch results are no longer available")
+	ErrMessageBatchNoAccount         = infraerrors.ServiceUnavailable("MESSAGE_BATCH_NO_ACCOUNT", "no available accounts")
+)
+
+// MessageBatchUpstreamError 上游 Message Batches 接口返回的错误
+type MessageBatchUpstreamError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *MessageBatchUpstreamError) Error() string {
+	return fmt.Sprintf("message batch upstream error: %d %s", e.StatusCode, e.Message)
+}
+
+// MessageBatchRequestCounts 批次内各状态的请求数
+type MessageBatchRequestCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// MessageBatch 消息批次元数据；请求与结果内容保存在 storage_dir 下的 JSONL 文件中
+type MessageBatch struct {
+	ID                string
+	UserID            int64
+	APIKeyID          int64
+	GroupID           *int64
+	AccountID         int64  // passthrough 模式下创建上游批次的账号
+	Mode              string // passthrough / local
+	UpstreamBatchID   string
+	ProcessingStatus  string
+	RequestCount      int
+	Counts            MessageBatchRequestCounts
+	ResultsReady      bool // 结果文件已完整落盘
+	ResultsBilled     bool // passthrough 结果已逐条计费
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	ExpiresAt         time.Time
+	EndedAt           *time.Time
+	CancelInitiatedAt *time.Time
+}
+
+// IsEnded 批次是否已结束
+func (b *MessageBatch) IsEnded() bool {
+	return b.ProcessingStatus == MessageBatchStatusEnded
+}
+
+// MessageBatchListParams 列表分页参数（与 Anthropic before_id / after_id 语义一致，按创建时间倒序）
+type MessageBatchListParams struct {
+	Limit    int
+	BeforeID string
+	AfterID  string
+}
+
+// MessageBatchRepository 消息批次数据访问接口
+type MessageBatchRepository interface {
+	Create(ctx context.Context, b *MessageBatch) error
+	GetByID(ctx context.Context, id string) (*MessageBatch, error)
+	// ListByAPIKey 返回一页批次以及是否还有更多
+	ListByAPIKey(ctx context.Context, apiKeyID int64, params MessageBatchListParams) ([]MessageBatch, bool, error)
+	// Update 更新状态、计数与时间戳等可变字段
+	Update(ctx context.Context, b *MessageBatch) error
+	MarkBilled(ctx context.Context, id string) error
+	// ListStale 返回指定模式下 updated_at 早于 before 的未结束批次
+	ListStale(ctx context.Context, mode string, before time.Time) ([]MessageBatch, error)
+	// ListUnbilledPassthrough 返回 updated_at 早于 before 且尚未完成结算的 passthrough 批次
+	ListUnbilledPassthrough(ctx context.Context, before time.Time) ([]MessageBatch, error)
+	// DeleteEndedBefore 删除结束（或过期）早于 before 的批次，返回被删除的 ID
+	DeleteEndedBefore(ctx context.Context, before time.Time) ([]string, error)
+}
+
+// MessageBatchRequest 批次中的单条请求
+type MessageBatchRequest struct {
+	CustomID string          `json:"custom_id"`
+	Params   json.RawMessage `json:"params"`
+}
+
+// MessageBatchCaller 发起批处理操作的调用方信息，用于上游请求头透传与逐条计费
+type MessageBatchCaller struct {
+	APIKey          *APIKey
+	Subscription    *UserSubscription
+	Header          http.Header
+	InboundEndpoint string
+	UserAgent       string
+	IPAddress       string
+}
+
+// MessageBatchService Anthropic Message Batches：
+// Anthropic API Key 账号透传上游批处理接口（享受上游批处理价格），
+// 其余账号（OAuth / Setup Token / Bedrock / Vertex）由本地执行器以有界并发逐条调用 Forward。
+// 两种模式的每条成功结果都按 discount_multiplier 折扣写入 usage_logs。
+type MessageBatchService struct {
+	repo                MessageBatchRepository
+	gatewayService      *GatewayService
+	concurrencyService  *ConcurrencyService
+	billingCacheService *BillingCacheService
+	subscriptionService *SubscriptionService
+	apiKeyService       *APIKeyService
+	guardrailService    *GuardrailService
+	cfg                 *config.Config
+
+	mu       sync.Mutex
+	running  map[string]*localMessageBatchJob
+	finalize singleflight.Group
+
+	rootCtx    context.Context
+	rootCancel context.CancelFunc
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+}
+
+// NewMessageBatchService 创建消息批处理服务
+func NewMessageBatchService(
+	repo MessageBatchRepository,
+	gatewayService *GatewayService,
+	concurrencyService *ConcurrencyService,
+	billingCacheService *BillingCacheService,
+	subscriptionService *SubscriptionService,
+	apiKeyService *APIKeyService,
+	guardrailService *GuardrailService,
+	cfg *config.Config,
+) *MessageBatchService {
+	rootCtx, rootCancel := context.WithCancel(context.Background())
+	return &MessageBatchService{
+		repo:                repo,
+		gatewayService:      gatewayService,
+		concurrencyService:  concurrencyService,
+		billingCacheService: billingCacheService,
+		subscriptionService: subscriptionService,
+		apiKeyService:       apiKeyService,
+		guardrailService:    guardrailService,
+		cfg:                 cfg,
+		running:             make(map[string]*localMessageBatchJob),
+		rootCtx:             rootCtx,
+		rootCancel:          rootCancel,
+		stopCh:              make(chan struct{}),
+	}
+}
+
+// Start 启动后台维护：回收中断的本地批次、同步并结算 passthrough 批次、清理过期结果
+func (s *MessageBatchService) Start() {
+	if s == nil || !s.Enabled() {
+		return
+	}
+	if err := os.MkdirAll(s.cfg.Gateway.MessageBatches.StorageDir, 0o755); err != nil {
+		logger.L().Warn("message_batch.storage_dir_create_failed", zap.Error(err))
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(messageBatchMaintenanceInterval)
+		defer ticker.Stop()
+
+		s.runMaintenance()
+		for {
+			select {
+			case <-ticker.C:
+				s.runMaintenance()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台任务；执行中的本地批次保留已完成结果，剩余请求由下次维护标记为失败
+func (s *MessageBatchService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+		s.rootCancel()
+	})
+	s.wg.Wait()
+}
+
+// Enabled 是否开放批处理端点
+func (s *MessageBatchService) Enabled() bool {
+	return s != nil && s.cfg != nil && s.cfg.Gateway.MessageBatches.Enabled
+}
+
+// ParseMessageBatchRequests 解析并校验创建批次的请求体
+func (s *MessageBatchService) ParseMessageBatchRequests(body []byte) ([]MessageBatchRequest, error) {
+	requests := gjson.GetBytes(body, "requests")
+	if !requests.IsArray() {
+		return nil, ErrMessageBatchInvalidBody
+	}
+	items := requests.Array()
+	if len(items) == 0 {
+		return nil, ErrMessageBatchEmpty
+	}
+	if s.cfg != nil && len(items) > s.cfg.Gateway.MessageBatches.MaxRequests {
+		return nil, infraerrors.Newf(http.StatusBadRequest, "MESSAGE_BATCH_TOO_LARGE",
+			"a batch may contain at most %d requests", s.cfg.Gateway.MessageBatches.MaxRequests)
+	}
+
+	seen := make(map[string]struct{}, len(items))
+	out := make([]MessageBatchRequest, 0, len(items))
+	for _, item := range items {
+		customID := item.Get("custom_id").String()
+		if !messageBatchCustomIDPattern.MatchString(customID) {
+			return nil, ErrMessageBatchInvalidCustomID
+		}
+		if _, dup := seen[customID]; dup {
+			return nil, ErrMessageBatchDuplicateCustomID
+		}
+		seen[customID] = struct{}{}
+
+		params := item.Get("params")
+		if !params.IsObject() || strings.TrimSpace(params.Get("model").String()) == "" {
+			return nil, ErrMessageBatchInvalidParams
+		}
+		if params.Get("stream").Bool() {
+			return nil, ErrMessageBatchStreamUnsupported
+		}
+		out = append(out, MessageBatchRequest{CustomID: customID, Params: json.RawMessage(params.Raw)})
+	}
+	return out, nil
+}
+
+// Create 创建批次：优先透传到支持批处理的上游账号，否则交给本地执行器
+func (s *MessageBatchService) Create(ctx context.Context, caller *MessageBatchCaller, requests []MessageBatchRequest) (*MessageBatch, error) {
+	if !s.Enabled() {
+		return nil, ErrMessageBatchesDisabled
+	}
+	if len(requests) == 0 {
+		return nil, ErrMessageBatchEmpty
+	}
+	apiKey := caller.APIKey
+	now := time.Now()
+	batch := &MessageBatch{
+		ID:               newMessageBatchID(),
+		UserID:           apiKey.UserID,
+		APIKeyID:         apiKey.ID,
+		GroupID:          apiKey.GroupID,
+		Mode:             MessageBatchModeLocal,
+		ProcessingStatus: MessageBatchStatusInProgress,
+		RequestCount:     len(requests),
+		Counts:           MessageBatchRequestCounts{Processing: len(requests)},
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(messageBatchTTL),
+	}
+
+	firstModel := gjson.GetBytes(requests[0].Params, "model").String()
+	account, err := s.gatewayService.SelectAccountForModel(ctx, apiKey.GroupID, "", firstModel)
+	if err != nil || account == nil {
+		return nil, ErrMessageBatchNoAccount.WithCause(err)
+	}
+
+	if supportsUpstreamMessageBatches(account) {
+		upstream, err := s.createUpstreamBatch(ctx, account, caller.Header, requests)
+		var upstreamErr *MessageBatchUpstreamError
+		switch {
+		case err == nil:
+			batch.Mode = MessageBatchModePassthrough
+			batch.AccountID = account.ID
+			batch.UpstreamBatchID = upstream.ID
+			applyUpstreamMessageBatch(batch, upstream)
+		case errors.As(err, &upstreamErr) && (upstreamErr.StatusCode == http.StatusNotFound || upstreamErr.StatusCode == http.StatusMethodNotAllowed):
+			// 中转站未实现批处理接口时回退到本地执行器
+			logger.L().Info("message_batch.upstream_unsupported_fallback_local",
+				zap.Int64("account_id", account.ID),
+				zap.Int("upstream_status", upstreamErr.StatusCode),
+			)
+		default:
+			return nil, err
+		}
+	}
+
+	if batch.Mode == MessageBatchModeLocal {
+		if err := s.writeCustomIDs(batch.ID, requests); err != nil {
+			return nil, fmt.Errorf("write message batch requests: %w", err)
+		}
+	}
+	if err := s.repo.Create(ctx, batch); err != nil {
+		s.removeFiles(batch.ID)
+		return nil, err
+	}
+	if batch.Mode == MessageBatchModeLocal {
+		s.startLocalBatch(batch, caller, requests)
+	}
+	return batch, nil
+}
+
+// Get 查询批次；passthrough 批次会同步上游最新状态，结束后下载结果并逐条计费
+func (s *MessageBatchService) Get(ctx context.Context, caller *MessageBatchCaller, id string) (*MessageBatch, error) {
+	batch, err := s.getOwned(ctx, caller.APIKey.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if job := s.runningJob(id); job != nil {
+		return job.snapshot(), nil
+	}
+	if batch.Mode == MessageBatchModePassthrough {
+		if !batch.IsEnded() {
+			if err := s.refreshPassthrough(ctx, batch, caller.Header); err != nil {
+				logger.L().Warn("message_batch.refresh_failed", zap.String("batch_id", id), zap.Error(err))
+			}
+		}
+		if batch.IsEnded() && (!batch.ResultsReady || !batch.ResultsBilled) {
+			if err := s.finalizePassthrough(ctx, batch, caller); err != nil {
+				logger.L().Warn("message_batch.finalize_failed", zap.String("batch_id", id), zap.Error(err))
+			}
+		}
+	}
+	return batch, nil
+}
+
+// List 列出当前 API Key 创建的批次（按创建时间倒序）
+func (s *MessageBatchService) List(ctx context.Context, caller *MessageBatchCaller, params MessageBatchListParams) ([]MessageBatch, bool, error) {
+	if !s.Enabled() {
+		return nil, false, ErrMessageBatchesDisabled
+	}
+	if params.Limit <= 0 {
+		params.Limit = MessageBatchListDefaultLimit
+	}
+	if params.Limit > MessageBatchListMaxLimit {
+		params.Limit = MessageBatchListMaxLimit
+	}
+	batches, hasMore, err := s.repo.ListByAPIKey(ctx, caller.APIKey.ID, params)
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range batches {
+		if job := s.runningJob(batches[i].ID); job != nil {
+			batches[i] = *job.snapshot()
+		}
+	}
+	return batches, hasMore, nil
+}
+
+// Cancel 取消批次：未开始的请求记为 canceled，已在执行的请求继续完成
+func (s *MessageBatchService) Cancel(ctx context.Context, caller *MessageBatchCaller, id string) (*MessageBatch, error) {
+	batch, err := s.getOwned(ctx, caller.APIKey.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.IsEnded() {
+		return batch, nil
+	}
+	if job := s.runningJob(id); job != nil {
+		return job.cancel(ctx, s.repo), nil
+	}
+	if batch.Mode != MessageBatchModePassthrough {
+		return batch, nil
+	}
+
+	account, err := s.gatewayService.accountRepo.GetByID(ctx, batch.AccountID)
+	if err != nil {
+		return nil, fmt.Errorf("load message batch account: %w", err)
+	}
+	respBody, err := s.gatewayService.callMessageBatchUpstream(ctx, account, http.MethodPost, "/"+batch.UpstreamBatchID+"/cancel", nil, caller.Header)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyUpstreamResponse(ctx, batch, respBody); err != nil {
+		return nil, err
+	}
+	return batch, nil
+}
+
+// OpenResults 打开已结束批次的 JSONL 结果文件
+func (s *MessageBatchService) OpenResults(ctx context.Context, caller *MessageBatchCaller, id string) (io.ReadCloser, error) {
+	batch, err := s.Get(ctx, caller, id)
+	if err != nil {
+		return nil, err
+	}
+	if !batch.IsEnded() {
+		return nil, ErrMessageBatchNotEnded
+	}
+	if !batch.ResultsReady {
+		return nil, ErrMessageBatchResultsMissing
+	}
+	f, err := os.Open(s.resultsPath(batch.ID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrMessageBatchResultsMissing
+		}
+		return nil, fmt.Errorf("open message batch results: %w", err)
+	}
+	return f, nil
+}
+
+func (s *MessageBatchService) getOwned(ctx context.Context, apiKeyID int64, id string) (*MessageBatch, error) {
+	if !s.Enabled() {
+		return nil, ErrMessageBatchesDisabled
+	}
+	if !strings.HasPrefix(id, messageBatchIDPrefix) {
+		return nil, ErrMessageBatchNotFound
+	}
+	batch, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.APIKeyID != apiKeyID {
+		return nil, ErrMessageBatchNotFound
+	}
+	return batch, nil
+}
+
+// ===== passthrough =====
+
+// upstreamMessageBatch 上游批次对象中网关关心的字段
+type upstreamMessageBatch struct {
+	ID                string                    `json:"id"`
+	ProcessingStatus  string                    `json:"processing_status"`
+	RequestCounts     MessageBatchRequestCounts `json:"request_counts"`
+	ExpiresAt         *time.Time                `json:"expires_at"`
+	EndedAt           *time.Time                `json:"ended_at"`
+	CancelInitiatedAt *time.Time                `json:"cancel_initiated_at"`
+}
+
+func (s *MessageBatchService) createUpstreamBatch(ctx context.Context, account *Account, header http.Header, requests []MessageBatchRequest) (*upstreamMessageBatch, error) {
+	body := []byte(`{"requests":[]}`)
+	for i, req := range requests {
+		params := []byte(req.Params)
+		if model := gjson.GetBytes(params, "model").String(); model != "" {
+			if mapped := account.GetMappedModel(model); mapped != model {
+				params = ReplaceModelInBody(params, mapped)
+			}
+		}
+		var err error
+		body, err = sjson.SetBytes(body, fmt.Sprintf("requests.%d.custom_id", i), req.CustomID)
+		if err != nil {
+			return nil, err
+		}
+		body, err = sjson.SetRawBytes(body, fmt.Sprintf("requests.%d.params", i), params)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	respBody, err := s.gatewayService.callMessageBatchUpstream(ctx, account, http.MethodPost, "", body, header)
+	if err != nil {
+		return nil, err
+	}
+	var upstream upstreamMessageBatch
+	if err := json.Unmarshal(respBody, &upstream); err != nil || upstream.ID == "" {
+		return nil, fmt.Errorf("decode upstream message batch: invalid response")
+	}
+	return &upstream, nil
+}
+
+func (s *MessageBatchService) refreshPassthrough(ctx context.Context, batch *MessageBatch, header http.Header) error {
+	account, err := s.gatewayService.accountRepo.GetByID(ctx, batch.AccountID)
+	if err != nil {
+		return fmt.Errorf("load message batch account: %w", err)
+	}
+	respBody, err := s.gatewayService.callMessageBatchUpstream(ctx, account, http.MethodGet, "/"+batch.UpstreamBatchID, nil, header)
+	if err != nil {
+		return err
+	}
+	return s.applyUpstreamResponse(ctx, batch, respBody)
+}
+
+func (s *MessageBatchService) applyUpstreamResponse(ctx context.Context, batch *MessageBatch, respBody []byte) error {
+	var upstream upstreamMessageBatch
+	if err := json.Unmarshal(respBody, &upstream); err != nil {
+		return fmt.Errorf("decode upstream message batch: %w", err)
+	}
+	applyUpstreamMessageBatch(batch, &upstream)
+	return s.repo.Update(ctx, batch)
+}
+
+func applyUpstreamMessageBatch(batch *MessageBatch, upstream *upstreamMessageBatch) {
+	if upstream.ProcessingStatus != "" {
+		batch.ProcessingStatus = upstream.ProcessingStatus
+	}
+	batch.Counts = upstream.RequestCounts
+	if upstream.ExpiresAt != nil {
+		batch.ExpiresAt = *upstream.ExpiresAt
+	}
+	batch.EndedAt = upstream.EndedAt
+	batch.CancelInitiatedAt = upstream.CancelInitiatedAt
+}
+
+// finalizePassthrough 下载上游结果并逐条计费。
+// 客户端查询时与维护任务都会触发结算，客户端不再查询的批次也会被计费。
+func (s *MessageBatchService) finalizePassthrough(ctx context.Context, batch *MessageBatch, caller *MessageBatchCaller) error {
+	_, err, _ := s.finalize.Do(batch.ID, func() (any, error) {
+		account, err := s.gatewayService.accountRepo.GetByID(ctx, batch.AccountID)
+		if err != nil {
+			return nil, fmt.Errorf("load message batch account: %w", err)
+		}
+		if !batch.ResultsReady {
+			if err := s.downloadUpstreamResults(ctx, batch, account, caller.Header); err != nil {
+				return nil, err
+			}
+			batch.ResultsReady = true
+			if err := s.repo.Update(ctx, batch); err != nil {
+				return nil, err
+			}
+		}
+		if !batch.ResultsBilled {
+			if err := s.billResultsFile(ctx, batch, account, caller); err != nil {
+				return nil, err
+			}
+			if err := s.repo.MarkBilled(ctx, batch.ID); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return err
+	}
+	// singleflight 合并的并发调用持有各自的 batch 副本，这里统一回填结算结果
+	batch.ResultsReady = true
+	batch.ResultsBilled = true
+	return nil
+}
+
+func (s *MessageBatchService) downloadUpstreamResults(ctx context.Context, batch *MessageBatch, account *Account, header http.Header) error {
+	resp, err := s.gatewayService.doMessageBatchUpstream(ctx, account, http.MethodGet, "/"+batch.UpstreamBatchID+"/results", nil, header)
+	if err != nil {
+		return fmt.Errorf("message batch results request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 400 {
+		respBody, _ := readUpstreamResponseBodyLimited(resp.Body, resolveUpstreamResponseReadLimit(s.cfg))
+		return &MessageBatchUpstreamError{
+			StatusCode: resp.StatusCode,
+			Message:    sanitizeUpstreamErrorMessage(strings.TrimSpace(extractUpstreamErrorMessage(respBody))),
+		}
+	}
+
+	path := s.resultsPath(batch.ID)
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("create message batch results: %w", err)
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("download message batch results: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write message batch results: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// billResultsFile 对 passthrough 结果中的每条成功响应计费。
+// 每条结果使用 "<batch_id>:<custom_id>" 作为计费幂等键，重复结算不会重复扣费。
+func (s *MessageBatchService) billResultsFile(ctx context.Context, batch *MessageBatch, account *Account, caller *MessageBatchCaller) error {
+	f, err := os.Open(s.resultsPath(batch.ID))
+	if err != nil {
+		return fmt.Errorf("open message batch results: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), messageBatchResultLineMaxBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if gjson.GetBytes(line, "result.type").String() != MessageBatchResultSucceeded {
+			continue
+		}
+		message := gjson.GetBytes(line, "result.message")
+		usage := parseClaudeUsageFromResponseBody([]byte(message.Raw))
+		result := &ForwardResult{
+			RequestID: message.Get("id").String(),
+			Usage:     *usage,
+			Model:     message.Get("model").String(),
+		}
+		s.recordItemUsage(batch, gjson.GetBytes(line, "custom_id").String(), caller, account, result, "/v1/messages/batches", nil, ChannelUsageFields{})
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read message batch results: %w", err)
+	}
+	return nil
+}
+
+// recordItemUsage 按批处理折扣记录单条请求的用量
+func (s *MessageBatchService) recordItemUsage(
+	batch *MessageBatch,
+	customID string,
+	caller *MessageBatchCaller,
+	account *Account,
+	result *ForwardResult,
+	upstreamEndpoint string,
+	params []byte,
+	channelFields ChannelUsageFields,
+) {
+	ctx := context.WithValue(context.Background(), ctxkey.ClientRequestID, batch.ID+":"+customID)
+	input := &RecordUsageInput{
+		Result:             result,
+		APIKey:             caller.APIKey,
+		User:               caller.APIKey.User,
+		Account:            account,
+		Subscription:       caller.Subscription,
+		InboundEndpoint:    caller.InboundEndpoint,
+		UpstreamEndpoint:   upstreamEndpoint,
+		UserAgent:          caller.UserAgent,
+		IPAddress:          caller.IPAddress,
+		BatchDiscount:      s.discountMultiplier(),
+		ChannelUsageFields: channelFields,
+	}
+	if len(params) > 0 {
+		input.RequestPayloadHash = HashUsageRequestPayload(params)
+	}
+	if s.apiKeyService != nil {
+		input.APIKeyService = s.apiKeyService
+	}
+	if err := s.gatewayService.RecordUsage(ctx, input); err != nil {
+		logger.L().Error("message_batch.record_usage_failed",
+			zap.String("batch_id", batch.ID),
+			zap.String("custom_id", customID),
+			zap.Int64("account_id", account.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+// discountMultiplier Message Batches 折扣只取全局配置，分组的 batch_discount_multiplier 仅用于 OpenAI Batch
+func (s *MessageBatchService) discountMultiplier() float64 {
+	if s.cfg == nil {
+		return 0
+	}
+	return s.cfg.Gateway.MessageBatches.DiscountMultiplier
+}
+
+// ===== maintenance & storage =====
+
+func (s *MessageBatchService) runMaintenance() {
+	ctx, cancel := context.WithTimeout(s.rootCtx, time.Minute)
+	defer cancel()
+
+	stale, err := s.repo.ListStale(ctx, MessageBatchModeLocal, time.Now().Add(-messageBatchStaleAfter))
+	if err != nil {
+		logger.L().Warn("message_batch.list_stale_failed", zap.Error(err))
+	}
+	for i := range stale {
+		if s.runningJob(stale[i].ID) != nil {
+			continue
+		}
+		if err := s.recoverInterruptedBatch(ctx, &stale[i]); err != nil {
+			logger.L().Warn("message_batch.recover_failed", zap.String("batch_id", stale[i].ID), zap.Error(err))
+		}
+	}
+
+	s.settlePassthroughBatches(ctx)
+
+	retention := time.Duration(s.cfg.Gateway.MessageBatches.ResultsRetentionHours) * time.Hour
+	ids, err := s.repo.DeleteEndedBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		logger.L().Warn("message_batch.cleanup_failed", zap.Error(err))
+		return
+	}
+	for _, id := range ids {
+		s.removeFiles(id)
+	}
+	if len(ids) > 0 {
+		logger.L().Info("message_batch.cleanup_expired", zap.Int("count", len(ids)))
+	}
+}
+
+// settlePassthroughBatches 同步未结束的 passthrough 批次，并为已结束但未结算的批次下载结果、计费
+func (s *MessageBatchService) settlePassthroughBatches(ctx context.Context) {
+	pending, err := s.repo.ListUnbilledPassthrough(ctx, time.Now().Add(-messageBatchPassthroughPollAfter))
+	if err != nil {
+		logger.L().Warn("message_batch.list_unbilled_passthrough_failed", zap.Error(err))
+		return
+	}
+	for i := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		batch := &pending[i]
+		caller, err := s.maintenanceCaller(ctx, batch)
+		if err != nil {
+			logger.L().Warn("message_batch.load_caller_failed", zap.String("batch_id", batch.ID), zap.Error(err))
+			continue
+		}
+		if !batch.IsEnded() {
+			if err := s.refreshPassthrough(ctx, batch, nil); err != nil {
+				logger.L().Warn("message_batch.refresh_failed", zap.String("batch_id", batch.ID), zap.Error(err))
+				continue
+			}
+		}
+		if batch.IsEnded() && (!batch.ResultsReady || !batch.ResultsBilled) {
+			if err := s.finalizePassthrough(ctx, batch, caller); err != nil {
+				logger.L().Warn("message_batch.finalize_failed", zap.String("batch_id", batch.ID), zap.Error(err))
+			}
+		}
+	}
+}
+
+// maintenanceCaller 为维护任务重建计费所需的调用方（API Key、用户、分组与订阅）
+func (s *MessageBatchService) maintenanceCaller(ctx context.Context, batch *MessageBatch) (*MessageBatchCaller, error) {
+	if s.apiKeyService == nil {
+		return nil, errors.New("api key service unavailable")
+	}
+	apiKey, err := s.apiKeyService.GetByID(ctx, batch.APIKeyID)
+	if err != nil {
+		return nil, err
+	}
+	caller := &MessageBatchCaller{APIKey: apiKey, InboundEndpoint: messageBatchMaintenanceEndpoint}
+	if apiKey.Group != nil && apiKey.Group.IsSubscriptionType() {
+		if s.subscriptionService == nil {
+			return nil, errors.New("subscription service unavailable")
+		}
+		sub, err := s.subscriptionService.GetActiveSubscription(ctx, apiKey.UserID, apiKey.Group.ID)
+		if err != nil {
+			return nil, fmt.Errorf("get active subscription: %w", err)
+		}
+		caller.Subscription = sub
+	}
+	return caller, nil
+}
+
+func (s *MessageBatchService) resultsPath(id string) string {
+	return filepath.Join(s.cfg.Gateway.MessageBatches.StorageDir, id+".results.jsonl")
+}
+
+func (s *MessageBatchService) customIDsPath(id string) string {
+	return filepath.Join(s.cfg.Gateway.MessageBatches.StorageDir, id+".ids")
+}
+
+// writeCustomIDs 保存本地批次的 custom_id 清单，用于中断后补齐缺失结果
+func (s *MessageBatchService) writeCustomIDs(id string, requests []MessageBatchRequest) error {
+	if err := os.MkdirAll(s.cfg.Gateway.MessageBatches.StorageDir, 0o755); err != nil {
+		return err
+	}
+	var sb strings.Builder
+	for _, req := range requests {
+		sb.WriteString(req.CustomID)
+		sb.WriteByte('\n')
+	}
+	return os.WriteFile(s.customIDsPath(id), []byte(sb.String()), 0o600)
+}
+
+func (s *MessageBatchService) removeFiles(id string) {
+	for _, path := range []string{s.resultsPath(id), s.customIDsPath(id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.L().Warn("message_batch.remove_file_failed", zap.String("path", path), zap.Error(err))
+		}
+	}
+}
+
+func newMessageBatchID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return messageBatchIDPrefix + hex.EncodeToString(b)
+}
@@ -0,0 +1,190 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type messageBatchRepoStub struct {
+	MessageBatchRepository
+	updated []*MessageBatch
+}
+
+func (r *messageBatchRepoStub) Update(_ context.Context, b *MessageBatch) error {
+	cp := *b
+	r.updated = append(r.updated, &cp)
+	return nil
+}
+
+// messageBatchBillingCacheStub 余额固定为 0 的计费缓存
+type messageBatchBillingCacheStub struct {
+	BillingCache
+}
+
+func (messageBatchBillingCacheStub) GetUserBalance(context.Context, int64) (float64, error) {
+	return 0, nil
+}
+
+func newMessageBatchServiceForTest(t *testing.T, repo MessageBatchRepository) *MessageBatchService {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Gateway.MessageBatches = config.GatewayMessageBatchesConfig{
+		Enabled:            true,
+		StorageDir:         t.TempDir(),
+		Concurrency:        2,
+		MaxRequests:        3,
+		DiscountMultiplier: 0.5,
+	}
+	return NewMessageBatchService(repo, nil, nil, nil, nil, nil, nil, cfg)
+}
+
+func TestParseMessageBatchRequests(t *testing.T) {
+	svc := newMessageBatchServiceForTest(t, nil)
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr error
+	}{
+		{"missing requests", `{}`, ErrMessageBatchInvalidBody},
+		{"empty requests", `{"requests":[]}`, ErrMessageBatchEmpty},
+		{"invalid custom_id", `{"requests":[{"custom_id":"a b","params":{"model":"claude-sonnet-4-5"}}]}`, ErrMessageBatchInvalidCustomID},
+		{"duplicate custom_id", `{"requests":[{"custom_id":"a","params":{"model":"m"}},{"custom_id":"a","params":{"model":"m"}}]}`, ErrMessageBatchDuplicateCustomID},
+		{"missing model", `{"requests":[{"custom_id":"a","params":{"max_tokens":10}}]}`, ErrMessageBatchInvalidParams},
+		{"stream rejected", `{"requests":[{"custom_id":"a","params":{"model":"m","stream":true}}]}`, ErrMessageBatchStreamUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ParseMessageBatchRequests([]byte(tt.body))
+			require.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("too many requests", func(t *testing.T) {
+		body := `{"requests":[` + strings.Repeat(`{"custom_id":"x","params":{"model":"m"}},`, 3) + `{"custom_id":"y","params":{"model":"m"}}]}`
+		_, err := svc.ParseMessageBatchRequests([]byte(body))
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "at most 3 requests")
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		reqs, err := svc.ParseMessageBatchRequests([]byte(`{"requests":[{"custom_id":"req-1","params":{"model":"claude-sonnet-4-5","max_tokens":16}},{"custom_id":"req_2","params":{"model":"claude-haiku-4-5"}}]}`))
+		require.NoError(t, err)
+		require.Len(t, reqs, 2)
+		require.Equal(t, "req-1", reqs[0].CustomID)
+		require.Equal(t, "claude-sonnet-4-5", gjson.GetBytes(reqs[0].Params, "model").String())
+		require.Equal(t, int64(16), gjson.GetBytes(reqs[0].Params, "max_tokens").Int())
+	})
+}
+
+func TestNewMessageBatchErroredLine(t *testing.T) {
+	line := newMessageBatchErroredLine("req-1", http.StatusTooManyRequests, "slow down")
+	data, err := json.Marshal(line)
+	require.NoError(t, err)
+
+	require.Equal(t, "req-1", gjson.GetBytes(data, "custom_id").String())
+	require.Equal(t, MessageBatchResultErrored, gjson.GetBytes(data, "result.type").String())
+	require.Equal(t, "error", gjson.GetBytes(data, "result.error.type").String())
+	require.Equal(t, "rate_limit_error", gjson.GetBytes(data, "result.error.error.type").String())
+	require.Equal(t, "slow down", gjson.GetBytes(data, "result.error.error.message").String())
+	require.False(t, gjson.GetBytes(data, "result.message").Exists())
+}
+
+func TestMessageBatchErrorType(t *testing.T) {
+	require.Equal(t, "invalid_request_error", messageBatchErrorType(http.StatusBadRequest))
+	require.Equal(t, "overloaded_error", messageBatchErrorType(529))
+	require.Equal(t, "api_error", messageBatchErrorType(http.StatusBadGateway))
+}
+
+func TestRecoverInterruptedBatch(t *testing.T) {
+	repo := &messageBatchRepoStub{}
+	svc := newMessageBatchServiceForTest(t, repo)
+	batch := &MessageBatch{
+		ID:               "msgbatch_test",
+		Mode:             MessageBatchModeLocal,
+		ProcessingStatus: MessageBatchStatusInProgress,
+		RequestCount:     3,
+		Counts:           MessageBatchRequestCounts{Processing: 3},
+		ExpiresAt:        time.Now().Add(time.Hour),
+	}
+	require.NoError(t, svc.writeCustomIDs(batch.ID, []MessageBatchRequest{{CustomID: "a"}, {CustomID: "b"}, {CustomID: "c"}}))
+	require.NoError(t, os.WriteFile(svc.resultsPath(batch.ID),
+		[]byte(`{"custom_id":"a","result":{"type":"succeeded","message":{"id":"msg_1"}}}`+"\n"), 0o600))
+
+	require.NoError(t, svc.recoverInterruptedBatch(context.Background(), batch))
+
+	require.Len(t, repo.updated, 1)
+	require.Equal(t, MessageBatchStatusEnded, batch.ProcessingStatus)
+	require.NotNil(t, batch.EndedAt)
+	require.True(t, batch.ResultsReady)
+	require.Equal(t, MessageBatchRequestCounts{Succeeded: 1, Errored: 2}, batch.Counts)
+
+	data, err := os.ReadFile(svc.resultsPath(batch.ID))
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	require.Equal(t, "b", gjson.Get(lines[1], "custom_id").String())
+	require.Equal(t, MessageBatchResultErrored, gjson.Get(lines[1], "result.type").String())
+	require.Equal(t, "c", gjson.Get(lines[2], "custom_id").String())
+}
+
+func TestExecuteLocalItemRechecksBillingEligibility(t *testing.T) {
+	svc := newMessageBatchServiceForTest(t, nil)
+	billing := NewBillingCacheService(messageBatchBillingCacheStub{}, nil, nil, nil, &config.Config{})
+	t.Cleanup(billing.Stop)
+	svc.billingCacheService = billing
+
+	// 余额已耗尽：请求不应再选择账号转发，直接记为失败
+	job := &localMessageBatchJob{caller: &MessageBatchCaller{APIKey: &APIKey{ID: 1, UserID: 7, User: &User{ID: 7}}}}
+	line := svc.executeLocalItem(context.Background(), job, MessageBatchRequest{
+		CustomID: "a",
+		Params:   json.RawMessage(`{"model":"claude-sonnet-4-5","max_tokens":10,"messages":[{"role":"user","content":"hi"}]}`),
+	})
+
+	require.Equal(t, "a", line.CustomID)
+	require.Equal(t, MessageBatchResultErrored, line.Result.Type)
+	require.Equal(t, "invalid_request_error", gjson.GetBytes(line.Result.Error, "error.type").String())
+	require.Equal(t, "insufficient balance", gjson.GetBytes(line.Result.Error, "error.message").String())
+}
+
+func TestExecuteLocalItemAppliesGuardrail(t *testing.T) {
+	svc := newMessageBatchServiceForTest(t, nil)
+	svc.guardrailService = newGuardrailServiceForTest(GuardrailRule{
+		ID: 1, GroupID: 3, Name: "no codename", Detector: GuardrailDetectorKeyword,
+		Patterns: []string{"project-x"}, Action: GuardrailActionBlock, Enabled: true, Priority: 1,
+	})
+
+	job := &localMessageBatchJob{caller: &MessageBatchCaller{APIKey: guardrailTestAPIKey(3)}}
+	line := svc.executeLocalItem(context.Background(), job, MessageBatchRequest{
+		CustomID: "a",
+		Params:   json.RawMessage(`{"model":"claude-sonnet-4-5","max_tokens":10,"messages":[{"role":"user","content":"tell me about project-x"}]}`),
+	})
+
+	require.Equal(t, MessageBatchResultErrored, line.Result.Type)
+	require.Equal(t, "invalid_request_error", gjson.GetBytes(line.Result.Error, "error.type").String())
+	require.Contains(t, gjson.GetBytes(line.Result.Error, "error.message").String(), "no codename")
+}
+
+func TestBatchResponseWriterCapturesStatusAndBody(t *testing.T) {
+	c, w := newMessageBatchGinContext(context.Background(), http.Header{
+		"Anthropic-Version": []string{"2023-06-01"},
+		"Authorization":     []string{"Bearer secret"},
+	})
+	require.Equal(t, "2023-06-01", c.Request.Header.Get("anthropic-version"))
+	require.Empty(t, c.Request.Header.Get("authorization"), "只继承白名单请求头")
+
+	c.JSON(http.StatusTooManyRequests, map[string]string{"type": "error"})
+	require.Equal(t, http.StatusTooManyRequests, w.status)
+	require.JSONEq(t, `{"type":"error"}`, w.body.String())
+}
@@ -0,0 +1,232 @@
+package service
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/metrics"
+)
+
+const metricsNamespace = "sub2api_"
+
+// MetricsService 汇总网关进程内的运行时指标，并以 Prometheus 文本格式导出。
+//
+// 请求类指标由网关中间件在请求结束时写入；其余指标在抓取时从各组件的
+// 进程内快照读取，不访问数据库。多实例部署时每个实例只报告自身状态。
+type MetricsService struct {
+	registry *metrics.Registry
+
+	requests *metrics.CounterVec
+	duration *metrics.HistogramVec
+	ttft     *metrics.HistogramVec
+
+	concurrencyService       *ConcurrencyService
+	schedulerSnapshotService *SchedulerSnapshotService
+	openAIGatewayService     *OpenAIGatewayService
+	usageRecordWorkerPool    *UsageRecordWorkerPool
+	billingCacheService      *BillingCacheService
+	buildInfo                BuildInfo
+}
+
+// NewMetricsService 创建指标服务；各依赖均可为 nil（对应指标不输出）
+func NewMetricsService(
+	concurrencyService *ConcurrencyService,
+	schedulerSnapshotService *SchedulerSnapshotService,
+	openAIGatewayService *OpenAIGatewayService,
+	usageRecordWorkerPool *UsageRecordWorkerPool,
+	billingCacheService *BillingCacheService,
+	buildInfo BuildInfo,
+) *MetricsService {
+	s := &MetricsService{
+		registry: metrics.NewRegistry(),
+		requests: metrics.NewCounterVec(
+			metricsNamespace+"gateway_requests_total",
+			"Gateway requests by platform, group and response status class.",
+			"platform", "group_id", "group", "status_class",
+		),
+		duration: metrics.NewHistogramVec(
+			metricsNamespace+"gateway_request_duration_seconds",
+			"Gateway request latency from receipt to completion.",
+			metrics.DefaultLatencyBuckets,
+			"platform", "group_id", "group",
+		),
+		ttft: metrics.NewHistogramVec(
+			metricsNamespace+"gateway_time_to_first_token_seconds",
+			"Time to first upstream token for successful requests.",
+			[]float64{0.1, 0.25, 0.5, 1, 2, 3, 5, 10, 20, 30, 60},
+			"platform", "group_id", "group",
+		),
+		concurrencyService:       concurrencyService,
+		schedulerSnapshotService: schedulerSnapshotService,
+		openAIGatewayService:     openAIGatewayService,
+		usageRecordWorkerPool:    usageRecordWorkerPool,
+		billingCacheService:      billingCacheService,
+		buildInfo:                buildInfo,
+	}
+	s.registry.Register(
+		metrics.CollectorFunc(s.collectBuildInfo),
+		s.requests,
+		s.duration,
+		s.ttft,
+		metrics.CollectorFunc(s.collectConcurrency),
+		metrics.CollectorFunc(s.collectSchedulerOutbox),
+		metrics.CollectorFunc(s.collectOpenAIWSPool),
+		metrics.CollectorFunc(s.collectUsageRecordPool),
+		metrics.CollectorFunc(s.collectBillingCircuitBreaker),
+		metrics.CollectorFunc(s.collectIdempotency),
+	)
+	return s
+}
+
+// GatewayRequestObservation 单个网关请求的观测数据
+type GatewayRequestObservation struct {
+	Platform  string
+	GroupID   int64
+	GroupName string
+	Status    int
+	Duration  time.Duration
+	// TTFT 首 token 时间；nil 表示未知（非流式或失败请求）
+	TTFT *time.Duration
+}
+
+// ObserveGatewayRequest 记录一次网关请求（由网关中间件调用）
+func (s *MetricsService) ObserveGatewayRequest(obs GatewayRequestObservation) {
+	if s == nil {
+		return
+	}
+	platform := obs.Platform
+	if platform == "" {
+		platform = "unknown"
+	}
+	groupID := ""
+	if obs.GroupID > 0 {
+		groupID = strconv.FormatInt(obs.GroupID, 10)
+	}
+
+	s.requests.Inc(platform, groupID, obs.GroupName, statusClass(obs.Status))
+	s.duration.Observe(obs.Duration.Seconds(), platform, groupID, obs.GroupName)
+	if obs.TTFT != nil && obs.Status < http.StatusBadRequest {
+		s.ttft.Observe(obs.TTFT.Seconds(), platform, groupID, obs.GroupName)
+	}
+}
+
+// WriteText 以 Prometheus 文本格式输出全部指标
+func (s *MetricsService) WriteText(w io.Writer) error {
+	return s.registry.WriteText(w)
+}
+
+func statusClass(status int) string {
+	if status < 100 || status > 599 {
+		return "unknown"
+	}
+	return strconv.Itoa(status/100) + "xx"
+}
+
+func (s *MetricsService) collectBuildInfo(w *metrics.Writer) {
+	w.Gauge(metricsNamespace+"build_info", "Build information of the running binary.", 1,
+		metrics.Label{Name: "version", Value: s.buildInfo.Version},
+		metrics.Label{Name: "build_type", Value: s.buildInfo.BuildType},
+	)
+}
+
+func (s *MetricsService) collectConcurrency(w *metrics.Writer) {
+	if s.concurrencyService == nil {
+		return
+	}
+	inUseName := metricsNamespace + "account_slots_in_use"
+	maxName := metricsNamespace + "account_slots_max"
+	w.Describe(inUseName, "Account concurrency slots held by this instance.", metrics.TypeGauge)
+	w.Describe(maxName, "Account max concurrency observed when acquiring a slot.", metrics.TypeGauge)
+	for _, usage := range s.concurrencyService.LocalAccountSlotUsage() {
+		labels := []metrics.Label{{Name: "account_id", Value: strconv.FormatInt(usage.AccountID, 10)}}
+		w.Sample(inUseName, labels, float64(usage.InUse))
+		w.Sample(maxName, labels, float64(usage.MaxConcurrency))
+	}
+	w.Gauge(metricsNamespace+"user_slots_in_use", "User concurrency slots held by this instance.",
+		float64(s.concurrencyService.LocalUserSlotsInUse()))
+}
+
+func (s *MetricsService) collectSchedulerOutbox(w *metrics.Writer) {
+	if s.schedulerSnapshotService == nil {
+		return
+	}
+	stats := s.schedulerSnapshotService.OutboxStats()
+	w.Gauge(metricsNamespace+"scheduler_outbox_lag_seconds", "Age of the oldest unprocessed scheduler outbox event at the last poll.", stats.Lag.Seconds())
+	w.Gauge(metricsNamespace+"scheduler_outbox_watermark", "Last scheduler outbox event ID applied to the snapshot cache.", float64(stats.Watermark))
+	lastPoll := 0.0
+	if !stats.LastPollAt.IsZero() {
+		lastPoll = float64(stats.LastPollAt.Unix())
+	}
+	w.Gauge(metricsNamespace+"scheduler_outbox_last_poll_timestamp_seconds", "Unix time of the last successful scheduler outbox poll.", lastPoll)
+}
+
+func (s *MetricsService) collectOpenAIWSPool(w *metrics.Writer) {
+	if s.openAIGatewayService == nil {
+		return
+	}
+	sizes := s.openAIGatewayService.SnapshotOpenAIWSPoolSizes()
+	connsName := metricsNamespace + "openai_ws_pool_conns"
+	w.Describe(connsName, "OpenAI WebSocket pool connections by state.", metrics.TypeGauge)
+	w.Sample(connsName, []metrics.Label{{Name: "state", Value: "leased"}}, float64(sizes.Leased))
+	w.Sample(connsName, []metrics.Label{{Name: "state", Value: "idle"}}, float64(sizes.Idle))
+	w.Sample(connsName, []metrics.Label{{Name: "state", Value: "creating"}}, float64(sizes.Creating))
+	w.Gauge(metricsNamespace+"openai_ws_pool_accounts", "Accounts with an OpenAI WebSocket pool.", float64(sizes.Accounts))
+	w.Gauge(metricsNamespace+"openai_ws_pool_waiters", "Requests waiting for an OpenAI WebSocket connection.", float64(sizes.Waiters))
+
+	pool := s.openAIGatewayService.SnapshotOpenAIWSPoolMetrics()
+	acquireName := metricsNamespace + "openai_ws_pool_acquire_total"
+	w.Describe(acquireName, "OpenAI WebSocket connection acquisitions by outcome.", metrics.TypeCounter)
+	w.Sample(acquireName, []metrics.Label{{Name: "result", Value: "reuse"}}, float64(pool.AcquireReuseTotal))
+	w.Sample(acquireName, []metrics.Label{{Name: "result", Value: "create"}}, float64(pool.AcquireCreateTotal))
+	w.Counter(metricsNamespace+"openai_ws_pool_queue_wait_seconds_total", "Total time spent waiting for a pooled OpenAI WebSocket connection.", float64(pool.AcquireQueueWaitMsTotal)/1000)
+	scaleName := metricsNamespace + "openai_ws_pool_scale_total"
+	w.Describe(scaleName, "OpenAI WebSocket pool scale events.", metrics.TypeCounter)
+	w.Sample(scaleName, []metrics.Label{{Name: "direction", Value: "up"}}, float64(pool.ScaleUpTotal))
+	w.Sample(scaleName, []metrics.Label{{Name: "direction", Value: "down"}}, float64(pool.ScaleDownTotal))
+}
+
+func (s *MetricsService) collectUsageRecordPool(w *metrics.Writer) {
+	if s.usageRecordWorkerPool == nil {
+		return
+	}
+	stats := s.usageRecordWorkerPool.Stats()
+	w.Gauge(metricsNamespace+"usage_record_pool_queue_depth", "Usage-record tasks waiting in the worker pool queue.", float64(stats.WaitingTasks))
+	w.Gauge(metricsNamespace+"usage_record_pool_running_workers", "Usage-record workers currently running.", float64(stats.RunningWorkers))
+	w.Gauge(metricsNamespace+"usage_record_pool_max_workers", "Configured usage-record worker concurrency.", float64(stats.MaxConcurrency))
+	tasksName := metricsNamespace + "usage_record_pool_tasks_total"
+	w.Describe(tasksName, "Usage-record tasks by outcome.", metrics.TypeCounter)
+	w.Sample(tasksName, []metrics.Label{{Name: "result", Value: "success"}}, float64(stats.SuccessfulTasks))
+	w.Sample(tasksName, []metrics.Label{{Name: "result", Value: "failed"}}, float64(stats.FailedTasks))
+	w.Sample(tasksName, []metrics.Label{{Name: "result", Value: "dropped_queue_full"}}, float64(stats.DroppedQueueFull))
+	w.Sample(tasksName, []metrics.Label{{Name: "result", Value: "dropped_pool_stopped"}}, float64(stats.DroppedPoolStopped))
+	w.Sample(tasksName, []metrics.Label{{Name: "result", Value: "sync_fallback"}}, float64(stats.SyncFallbackTasks))
+}
+
+func (s *MetricsService) collectBillingCircuitBreaker(w *metrics.Writer) {
+	if s.billingCacheService == nil {
+		return
+	}
+	current := s.billingCacheService.CircuitBreakerState()
+	name := metricsNamespace + "billing_circuit_breaker_state"
+	w.Describe(name, "Billing circuit breaker state (1 for the current state).", metrics.TypeGauge)
+	for _, state := range []string{"disabled", "closed", "open", "half-open"} {
+		v := 0.0
+		if state == current {
+			v = 1
+		}
+		w.Sample(name, []metrics.Label{{Name: "state", Value: state}}, v)
+	}
+}
+
+func (s *MetricsService) collectIdempotency(w *metrics.Writer) {
+	snap := GetIdempotencyMetricsSnapshot()
+	w.Counter(metricsNamespace+"idempotency_claims_total", "Idempotency keys claimed.", float64(snap.ClaimTotal))
+	w.Counter(metricsNamespace+"idempotency_replays_total", "Requests answered from a stored idempotent response.", float64(snap.ReplayTotal))
+	w.Counter(metricsNamespace+"idempotency_conflicts_total", "Idempotency key conflicts.", float64(snap.ConflictTotal))
+	w.Counter(metricsNamespace+"idempotency_retry_backoff_total", "Requests rejected during the failed-retry backoff window.", float64(snap.RetryBackoffTotal))
+	w.Counter(metricsNamespace+"idempotency_store_unavailable_total", "Idempotency store unavailable events.", float64(snap.StoreUnavailableTotal))
+	w.Counter(metricsNamespace+"idempotency_processing_seconds_total", "Total processing time of idempotent requests.", snap.ProcessingDurationTotalMs/1000)
+	w.Counter(metricsNamespace+"idempotency_processing_requests_total", "Idempotent requests with recorded processing time.", float64(snap.ProcessingDurationCount))
+}
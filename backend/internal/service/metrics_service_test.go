@@ -0,0 +1,49 @@
+//go:build unit
+
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsService_WriteText(t *testing.T) {
+	concurrency := NewConcurrencyService(&stubConcurrencyCacheForTest{acquireResult: true})
+	_, err := concurrency.AcquireAccountSlot(context.Background(), 42, 4)
+	require.NoError(t, err)
+
+	svc := NewMetricsService(concurrency, nil, nil, nil, &BillingCacheService{}, BuildInfo{Version: "1.2.3", BuildType: "release"})
+
+	ttft := 1500 * time.Millisecond
+	svc.ObserveGatewayRequest(GatewayRequestObservation{Platform: PlatformAnthropic, GroupID: 3, GroupName: "claude", Status: 200, Duration: 2 * time.Second, TTFT: &ttft})
+	svc.ObserveGatewayRequest(GatewayRequestObservation{Platform: PlatformAnthropic, GroupID: 3, GroupName: "claude", Status: 529, Duration: 300 * time.Millisecond, TTFT: &ttft})
+	svc.ObserveGatewayRequest(GatewayRequestObservation{Status: 401, Duration: time.Millisecond})
+
+	var buf bytes.Buffer
+	require.NoError(t, svc.WriteText(&buf))
+	out := buf.String()
+
+	require.Contains(t, out, `sub2api_build_info{version="1.2.3",build_type="release"} 1`)
+	require.Contains(t, out, `sub2api_gateway_requests_total{platform="anthropic",group_id="3",group="claude",status_class="2xx"} 1`)
+	require.Contains(t, out, `sub2api_gateway_requests_total{platform="anthropic",group_id="3",group="claude",status_class="5xx"} 1`)
+	require.Contains(t, out, `sub2api_gateway_requests_total{platform="unknown",group_id="",group="",status_class="4xx"} 1`)
+	require.Contains(t, out, `sub2api_gateway_request_duration_seconds_count{platform="anthropic",group_id="3",group="claude"} 2`)
+	// 失败请求不计入 TTFT
+	require.Contains(t, out, `sub2api_gateway_time_to_first_token_seconds_count{platform="anthropic",group_id="3",group="claude"} 1`)
+	require.Contains(t, out, `sub2api_account_slots_in_use{account_id="42"} 1`)
+	require.Contains(t, out, `sub2api_account_slots_max{account_id="42"} 4`)
+	require.Contains(t, out, `sub2api_billing_circuit_breaker_state{state="disabled"} 1`)
+	require.Contains(t, out, "# TYPE sub2api_idempotency_claims_total counter")
+	require.NotContains(t, out, "sub2api_scheduler_outbox_lag_seconds")
+}
+
+func TestStatusClass(t *testing.T) {
+	require.Equal(t, "2xx", statusClass(204))
+	require.Equal(t, "4xx", statusClass(429))
+	require.Equal(t, "5xx", statusClass(503))
+	require.Equal(t, "unknown", statusClass(0))
+}
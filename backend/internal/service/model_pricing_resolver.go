@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"log/slog"
+	"strings"
 )
 
 // PricingSource 定价来源标识
@@ -211,10 +212,11 @@ func intervalToModelPricing(iv *PricingInterval, supportsCacheBreakdown bool) *M
 	return pricing
 }
 
-// GetRequestTierPrice 根据层级标签获取按次价格
+// GetRequestTierPrice 根据层级标签获取按次价格（标签不区分大小写，"2k" 与 "2K" 等价）
 func (r *ModelPricingResolver) GetRequestTierPrice(resolved *ResolvedPricing, tierLabel string) float64 {
+	tierLabel = strings.TrimSpace(tierLabel)
 	for _, tier := range resolved.RequestTiers {
-		if tier.TierLabel == tierLabel && tier.PerRequestPrice != nil {
+		if strings.EqualFold(tier.TierLabel, tierLabel) && tier.PerRequestPrice != nil {
 			return *tier.PerRequestPrice
 		}
 	}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// 通知渠道类型
+const (
+	NotificationChannelTypeWebhook  = "webhook"  // 通用 JSON webhook（支持 HMAC 签名）
+	NotificationChannelTypeSlack    = "slack"    // Slack incoming webhook 及兼容实现
+	NotificationChannelTypeFeishu   = "feishu"   // 飞书自定义机器人
+	NotificationChannelTypeDingTalk = "dingtalk" // 钉钉自定义机器人
+)
+
+// 通知事件类型
+const (
+	NotificationEventOpsAlert                 = "ops_alert"
+	NotificationEventOpsReport                = "ops_report"
+	NotificationEventTest                     = "test"
+	NotificationEventAccountRateLimited       = "account_rate_limited"
+	NotificationEventAccountTempUnschedulable = "account_temp_unschedulable"
+	NotificationEventAccountDisabled          = "account_disabled"
+)
+
+// 通用 webhook 签名请求头
+const (
+	NotificationTimestampHeader = "X-Sub2API-Timestamp"
+	NotificationSignatureHeader = "X-Sub2API-Signature"
+)
+
+// NotificationChannelTypes 支持的通知渠道类型
+var NotificationChannelTypes = []string{
+	NotificationChannelTypeWebhook,
+	NotificationChannelTypeSlack,
+	NotificationChannelTypeFeishu,
+	NotificationChannelTypeDingTalk,
+}
+
+// NotificationSubscribableEvents 渠道可订阅的事件类型。
+// 告警与报表通过规则/报表配置中的渠道 ID 显式投递，不在此列。
+var NotificationSubscribableEvents = []string{
+	NotificationEventAccountRateLimited,
+	NotificationEventAccountTempUnschedulable,
+	NotificationEventAccountDisabled,
+}
+
+// NotificationChannel 通知渠道实体
+type NotificationChannel struct {
+	ID         int64
+	Name       string
+	Type       string
+	Enabled    bool
+	WebhookURL string
+	Secret     string            // 签名密钥（为空则不签名）
+	Headers    map[string]string // 附加请求头（主要用于通用 webhook 鉴权）
+	EventTypes []string          // 订阅的账号事件类型
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// SubscribesTo 判断渠道是否订阅了指定事件
+func (c *NotificationChannel) SubscribesTo(eventType string) bool {
+	if c == nil {
+		return false
+	}
+	return slices.Contains(c.EventTypes, eventType)
+}
+
+// NotificationMessage 一条待投递的通知
+type NotificationMessage struct {
+	EventType  string
+	Title      string
+	Text       string         // 纯文本正文
+	Severity   string         // 可选：P0/P1/... 或 info/warning/critical
+	Data       map[string]any // 结构化字段，仅通用 webhook 原样输出
+	OccurredAt time.Time
+}
+
+// PlainText 机器人类渠道使用的纯文本内容
+func (m *NotificationMessage) PlainText() string {
+	title := strings.TrimSpace(m.Title)
+	text := strings.TrimSpace(m.Text)
+	if text == "" {
+		return title
+	}
+	if title == "" {
+		return text
+	}
+	return title + "\n\n" + text
+}
+
+// notificationRequest 按渠道类型构造好的出站请求
+type notificationRequest struct {
+	URL    string
+	Body   []byte
+	Header http.Header
+}
+
+// buildNotificationRequest 按渠道类型构造请求体并按需签名。
+// webhook/slack 使用请求头签名；飞书将签名写入请求体；钉钉将签名写入 URL 查询参数。
+func buildNotificationRequest(ch *NotificationChannel, msg *NotificationMessage, now time.Time) (*notificationRequest, error) {
+	if ch == nil || msg == nil {
+		return nil, fmt.Errorf("nil channel or message")
+	}
+	occurredAt := msg.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = now
+	}
+	secret := strings.TrimSpace(ch.Secret)
+
+	req := &notificationRequest{URL: strings.TrimSpace(ch.WebhookURL), Header: http.Header{}}
+	var payload any
+
+	switch ch.Type {
+	case NotificationChannelTypeWebhook:
+		data := msg.Data
+		if data == nil {
+			data = map[string]any{}
+		}
+		payload = map[string]any{
+			"event_type":  msg.EventType,
+			"title":       msg.Title,
+			"text":        msg.Text,
+			"severity":    msg.Severity,
+			"occurred_at": occurredAt.UTC().Format(time.RFC3339),
+			"data":        data,
+		}
+	case NotificationChannelTypeSlack:
+		text := msg.Text
+		if title := strings.TrimSpace(msg.Title); title != "" {
+			text = "*" + title + "*\n" + text
+		}
+		payload = map[string]any{"text": strings.TrimSpace(text)}
+	case NotificationChannelTypeFeishu:
+		body := map[string]any{
+			"msg_type": "text",
+			"content":  map[string]any{"text": msg.PlainText()},
+		}
+		if secret != "" {
+			ts := strconv.FormatInt(now.Unix(), 10)
+			body["timestamp"] = ts
+			body["sign"] = signFeishu(secret, ts)
+		}
+		payload = body
+	case NotificationChannelTypeDingTalk:
+		payload = map[string]any{
+			"msgtype": "text",
+			"text":    map[string]any{"content": msg.PlainText()},
+		}
+		if secret != "" {
+			u, err := url.Parse(req.URL)
+			if err != nil {
+				return nil, fmt.Errorf("parse webhook url: %w", err)
+			}
+			ts := strconv.FormatInt(now.UnixMilli(), 10)
+			q := u.Query()
+			q.Set("timestamp", ts)
+			q.Set("sign", signDingTalk(secret, ts))
+			u.RawQuery = q.Encode()
+			req.URL = u.String()
+		}
+	default:
+		return nil, fmt.Errorf("unsupported notification channel type: %s", ch.Type)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal notification payload: %w", err)
+	}
+	req.Body = body
+
+	keys := make([]string, 0, len(ch.Headers))
+	for k := range ch.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		req.Header.Set(k, ch.Headers[k])
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	if secret != "" && (ch.Type == NotificationChannelTypeWebhook || ch.Type == NotificationChannelTypeSlack) {
+		ts := strconv.FormatInt(now.Unix(), 10)
+		req.Header.Set(NotificationTimestampHeader, ts)
+		req.Header.Set(NotificationSignatureHeader, "sha256="+SignNotificationPayload(secret, ts, body))
+	}
+	return req, nil
+}
+
+// SignNotificationPayload 计算通用 webhook 签名：hex(HMAC-SHA256(secret, timestamp + "." + body))。
+// 接收方应使用 X-Sub2API-Timestamp 与原始请求体重新计算并比对 X-Sub2API-Signature。
+func SignNotificationPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signFeishu 飞书签名：以 "timestamp\nsecret" 为密钥对空串做 HMAC-SHA256 后 base64。
+func signFeishu(secret, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signDingTalk 钉钉签名：以 secret 为密钥对 "timestamp\nsecret" 做 HMAC-SHA256 后 base64。
+func signDingTalk(secret, timestamp string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// checkNotificationResponse 判断投递结果；retryable 表示可以退避重试。
+// 飞书/钉钉在 HTTP 200 中通过业务错误码返回失败，需要解析响应体。
+func checkNotificationResponse(channelType string, statusCode int, body []byte) (retryable bool, err error) {
+	if statusCode == http.StatusTooManyRequests || statusCode >= 500 {
+		return true, fmt.Errorf("notification endpoint returned %d: %s", statusCode, truncateForLog(body, 256))
+	}
+	if statusCode >= 400 {
+		return false, fmt.Errorf("notification endpoint returned %d: %s", statusCode, truncateForLog(body, 256))
+	}
+
+	trimmed := bytes.TrimSpace(body)
+	switch channelType {
+	case NotificationChannelTypeFeishu:
+		if code := gjson.GetBytes(trimmed, "code"); code.Exists() && code.Int() != 0 {
+			// 9499: 请求过于频繁
+			return code.Int() == 9499, fmt.Errorf("feishu error %d: %s", code.Int(), gjson.GetBytes(trimmed, "msg").String())
+		}
+	case NotificationChannelTypeDingTalk:
+		if code := gjson.GetBytes(trimmed, "errcode"); code.Exists() && code.Int() != 0 {
+			// 130101: 发送速度太快而限流
+			return code.Int() == 130101, fmt.Errorf("dingtalk error %d: %s", code.Int(), gjson.GetBytes(trimmed, "errmsg").String())
+		}
+	}
+	return false, nil
+}
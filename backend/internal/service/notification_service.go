@@ -0,0 +1,533 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/httpclient"
+	"github.com/Wei-Shaw/sub2api/internal/util/urlvalidator"
+)
+
+var (
+	ErrNotificationChannelNotFound = infraerrors.NotFound("NOTIFICATION_CHANNEL_NOT_FOUND", "notification channel not found")
+	ErrNotificationChannelExists   = infraerrors.Conflict("NOTIFICATION_CHANNEL_EXISTS", "notification channel name already exists")
+)
+
+const (
+	notificationHTTPTimeout      = 10 * time.Second
+	notificationMaxAttempts      = 3
+	notificationRetryBackoff     = 2 * time.Second
+	notificationChannelCacheTTL  = 30 * time.Second
+	notificationQueueSize        = 256
+	notificationAccountThrottle  = 5 * time.Minute // 同一账号同一事件的最小通知间隔
+	notificationResponseReadSize = 4 << 10
+	notificationJobTimeout       = 2 * time.Minute
+)
+
+// NotificationChannelRepository 通知渠道数据访问接口
+type NotificationChannelRepository interface {
+	Create(ctx context.Context, channel *NotificationChannel) error
+	GetByID(ctx context.Context, id int64) (*NotificationChannel, error)
+	Update(ctx context.Context, channel *NotificationChannel) error
+	Delete(ctx context.Context, id int64) error
+	List(ctx context.Context) ([]NotificationChannel, error)
+}
+
+// CreateNotificationChannelInput 创建通知渠道输入
+type CreateNotificationChannelInput struct {
+	Name       string
+	Type       string
+	Enabled    bool
+	WebhookURL string
+	Secret     string
+	Headers    map[string]string
+	EventTypes []string
+}
+
+// UpdateNotificationChannelInput 更新通知渠道输入（nil 表示不修改）
+type UpdateNotificationChannelInput struct {
+	Name       *string
+	Enabled    *bool
+	WebhookURL *string
+	Secret     *string
+	Headers    map[string]string
+	EventTypes *[]string
+}
+
+type notificationJob struct {
+	channels []NotificationChannel
+	msg      *NotificationMessage
+}
+
+// NotificationService 管理通知渠道并负责出站投递（签名、退避重试）。
+// 告警/报表同步投递到指定渠道；账号状态事件经异步队列投递到订阅该事件的渠道。
+type NotificationService struct {
+	repo NotificationChannelRepository
+	cfg  *config.Config
+
+	httpClient   *http.Client
+	maxAttempts  int
+	retryBackoff time.Duration
+	now          func() time.Time
+
+	cacheMu  sync.RWMutex
+	cache    []NotificationChannel
+	cachedAt time.Time
+
+	throttleMu sync.Mutex
+	throttle   map[string]time.Time
+
+	queue     chan notificationJob
+	stopCh    chan struct{}
+	startOnce sync.Once
+	stopOnce  sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewNotificationService 创建通知服务
+func NewNotificationService(repo NotificationChannelRepository, cfg *config.Config) *NotificationService {
+	return &NotificationService{
+		repo:         repo,
+		cfg:          cfg,
+		maxAttempts:  notificationMaxAttempts,
+		retryBackoff: notificationRetryBackoff,
+		now:          time.Now,
+		throttle:     make(map[string]time.Time),
+		queue:        make(chan notificationJob, notificationQueueSize),
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Start 启动异步投递 worker
+func (s *NotificationService) Start() {
+	if s == nil {
+		return
+	}
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go s.runWorker()
+	})
+}
+
+// Stop 停止 worker（丢弃队列中尚未投递的通知）
+func (s *NotificationService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		if s.stopCh != nil {
+			close(s.stopCh)
+		}
+	})
+	s.wg.Wait()
+}
+
+func (s *NotificationService) runWorker() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case job := <-s.queue:
+			ctx, cancel := context.WithTimeout(context.Background(), notificationJobTimeout)
+			s.deliverAll(ctx, job.channels, job.msg)
+			cancel()
+		}
+	}
+}
+
+// --- CRUD ---
+
+// List 列出全部通知渠道
+func (s *NotificationService) List(ctx context.Context) ([]NotificationChannel, error) {
+	return s.repo.List(ctx)
+}
+
+// GetByID 获取通知渠道
+func (s *NotificationService) GetByID(ctx context.Context, id int64) (*NotificationChannel, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Create 创建通知渠道
+func (s *NotificationService) Create(ctx context.Context, input *CreateNotificationChannelInput) (*NotificationChannel, error) {
+	ch := &NotificationChannel{
+		Name:       strings.TrimSpace(input.Name),
+		Type:       strings.ToLower(strings.TrimSpace(input.Type)),
+		Enabled:    input.Enabled,
+		WebhookURL: strings.TrimSpace(input.WebhookURL),
+		Secret:     strings.TrimSpace(input.Secret),
+		Headers:    input.Headers,
+		EventTypes: input.EventTypes,
+	}
+	if err := s.validateChannel(ch); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, ch); err != nil {
+		return nil, err
+	}
+	s.invalidateCache()
+	return ch, nil
+}
+
+// Update 更新通知渠道
+func (s *NotificationService) Update(ctx context.Context, id int64, input *UpdateNotificationChannelInput) (*NotificationChannel, error) {
+	ch, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if input.Name != nil {
+		ch.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Enabled != nil {
+		ch.Enabled = *input.Enabled
+	}
+	if input.WebhookURL != nil {
+		ch.WebhookURL = strings.TrimSpace(*input.WebhookURL)
+	}
+	if input.Secret != nil {
+		ch.Secret = strings.TrimSpace(*input.Secret)
+	}
+	if input.Headers != nil {
+		ch.Headers = input.Headers
+	}
+	if input.EventTypes != nil {
+		ch.EventTypes = *input.EventTypes
+	}
+	if err := s.validateChannel(ch); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, ch); err != nil {
+		return nil, err
+	}
+	s.invalidateCache()
+	return ch, nil
+}
+
+// Delete 删除通知渠道
+func (s *NotificationService) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateCache()
+	return nil
+}
+
+// Test 向指定渠道同步发送一条测试消息（忽略启用状态），返回投递错误。
+func (s *NotificationService) Test(ctx context.Context, id int64) error {
+	ch, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	msg := &NotificationMessage{
+		EventType:  NotificationEventTest,
+		Title:      "Sub2API test notification",
+		Text:       fmt.Sprintf("Notification channel %q is configured correctly.", ch.Name),
+		Severity:   "info",
+		OccurredAt: s.now(),
+	}
+	if err := s.deliver(ctx, ch, msg); err != nil {
+		return infraerrors.BadRequest("NOTIFICATION_TEST_FAILED", err.Error())
+	}
+	return nil
+}
+
+func (s *NotificationService) validateChannel(ch *NotificationChannel) error {
+	if ch.Name == "" {
+		return infraerrors.BadRequest("VALIDATION_ERROR", "name is required")
+	}
+	if !slices.Contains(NotificationChannelTypes, ch.Type) {
+		return infraerrors.BadRequest("VALIDATION_ERROR", "type must be one of: "+strings.Join(NotificationChannelTypes, ", "))
+	}
+	normalized, err := s.validateWebhookURL(ch.WebhookURL)
+	if err != nil {
+		return infraerrors.BadRequest("VALIDATION_ERROR", err.Error())
+	}
+	ch.WebhookURL = normalized
+
+	eventTypes := make([]string, 0, len(ch.EventTypes))
+	seen := make(map[string]struct{}, len(ch.EventTypes))
+	for _, raw := range ch.EventTypes {
+		t := strings.TrimSpace(raw)
+		if t == "" {
+			continue
+		}
+		if !slices.Contains(NotificationSubscribableEvents, t) {
+			return infraerrors.BadRequest("VALIDATION_ERROR", "event_types must be within: "+strings.Join(NotificationSubscribableEvents, ", "))
+		}
+		if _, ok := seen[t]; ok {
+			continue
+		}
+		seen[t] = struct{}{}
+		eventTypes = append(eventTypes, t)
+	}
+	ch.EventTypes = eventTypes
+
+	headers := make(map[string]string, len(ch.Headers))
+	for k, v := range ch.Headers {
+		key := http.CanonicalHeaderKey(strings.TrimSpace(k))
+		if key == "" {
+			continue
+		}
+		headers[key] = strings.TrimSpace(v)
+	}
+	ch.Headers = headers
+	return nil
+}
+
+func (s *NotificationService) validateWebhookURL(raw string) (string, error) {
+	if s.cfg != nil && !s.cfg.Security.URLAllowlist.Enabled {
+		normalized, err := urlvalidator.ValidateURLFormat(raw, s.cfg.Security.URLAllowlist.AllowInsecureHTTP)
+		if err != nil {
+			return "", fmt.Errorf("invalid webhook_url: %w", err)
+		}
+		return normalized, nil
+	}
+	opts := urlvalidator.ValidationOptions{}
+	allowInsecureHTTP := false
+	if s.cfg != nil {
+		opts.AllowPrivate = s.cfg.Security.URLAllowlist.AllowPrivateHosts
+		allowInsecureHTTP = s.cfg.Security.URLAllowlist.AllowInsecureHTTP
+	}
+	normalized, err := urlvalidator.ValidateHTTPURL(raw, allowInsecureHTTP, opts)
+	if err != nil {
+		return "", fmt.Errorf("invalid webhook_url: %w", err)
+	}
+	return normalized, nil
+}
+
+// --- 投递 ---
+
+// Send 同步投递到指定的已启用渠道，返回成功的渠道数。用于告警与报表。
+func (s *NotificationService) Send(ctx context.Context, channelIDs []int64, msg *NotificationMessage) int {
+	if s == nil || len(channelIDs) == 0 || msg == nil {
+		return 0
+	}
+	wanted := make(map[int64]struct{}, len(channelIDs))
+	for _, id := range channelIDs {
+		wanted[id] = struct{}{}
+	}
+	var targets []NotificationChannel
+	for _, ch := range s.enabledChannels(ctx) {
+		if _, ok := wanted[ch.ID]; ok {
+			targets = append(targets, ch)
+		}
+	}
+	return s.deliverAll(ctx, targets, msg)
+}
+
+// Publish 异步投递到订阅了 msg.EventType 的已启用渠道；队列满时丢弃。
+func (s *NotificationService) Publish(ctx context.Context, msg *NotificationMessage) {
+	if s == nil || msg == nil {
+		return
+	}
+	var targets []NotificationChannel
+	for _, ch := range s.enabledChannels(ctx) {
+		if ch.SubscribesTo(msg.EventType) {
+			targets = append(targets, ch)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+	select {
+	case s.queue <- notificationJob{channels: targets, msg: msg}:
+	default:
+		slog.Warn("notification_queue_full", "event_type", msg.EventType)
+	}
+}
+
+// NotifyAccountEvent 实现 AccountEventNotifier：把账号状态变更转换为通知并异步投递。
+// 同一账号同一事件在 notificationAccountThrottle 内只通知一次，避免限流风暴刷屏。
+func (s *NotificationService) NotifyAccountEvent(ctx context.Context, event AccountStateEvent) {
+	if s == nil || event.AccountID <= 0 {
+		return
+	}
+	now := s.now()
+	if !s.allowAccountEvent(event, now) {
+		return
+	}
+	s.Publish(ctx, buildAccountEventMessage(event, now))
+}
+
+func (s *NotificationService) allowAccountEvent(event AccountStateEvent, now time.Time) bool {
+	key := fmt.Sprintf("%d:%s", event.AccountID, event.Type)
+	s.throttleMu.Lock()
+	defer s.throttleMu.Unlock()
+	if last, ok := s.throttle[key]; ok && now.Sub(last) < notificationAccountThrottle {
+		return false
+	}
+	s.throttle[key] = now
+	// 顺带清理过期项，防止 map 无限增长
+	if len(s.throttle) > 1024 {
+		for k, t := range s.throttle {
+			if now.Sub(t) >= notificationAccountThrottle {
+				delete(s.throttle, k)
+			}
+		}
+	}
+	return true
+}
+
+func buildAccountEventMessage(event AccountStateEvent, now time.Time) *NotificationMessage {
+	var title, severity string
+	switch event.Type {
+	case NotificationEventAccountRateLimited:
+		title, severity = "Account rate limited", "warning"
+	case NotificationEventAccountTempUnschedulable:
+		title, severity = "Account temporarily unschedulable", "warning"
+	case NotificationEventAccountDisabled:
+		title, severity = "Account disabled", "critical"
+	default:
+		title, severity = "Account state changed", "info"
+	}
+	title = fmt.Sprintf("%s: %s (#%d)", title, event.AccountName, event.AccountID)
+
+	lines := []string{fmt.Sprintf("Platform: %s", event.Platform)}
+	data := map[string]any{
+		"account_id":   event.AccountID,
+		"account_name": event.AccountName,
+		"platform":     event.Platform,
+	}
+	if event.Until != nil {
+		lines = append(lines, fmt.Sprintf("Until: %s", event.Until.UTC().Format(time.RFC3339)))
+		data["until"] = event.Until.UTC().Format(time.RFC3339)
+	}
+	if reason := strings.TrimSpace(event.Reason); reason != "" {
+		lines = append(lines, fmt.Sprintf("Reason: %s", reason))
+		data["reason"] = reason
+	}
+	return &NotificationMessage{
+		EventType:  event.Type,
+		Title:      title,
+		Text:       strings.Join(lines, "\n"),
+		Severity:   severity,
+		Data:       data,
+		OccurredAt: now,
+	}
+}
+
+func (s *NotificationService) deliverAll(ctx context.Context, channels []NotificationChannel, msg *NotificationMessage) int {
+	sent := 0
+	for i := range channels {
+		ch := &channels[i]
+		if err := s.deliver(ctx, ch, msg); err != nil {
+			slog.Warn("notification_deliver_failed", "channel_id", ch.ID, "channel_type", ch.Type, "event_type", msg.EventType, "error", err)
+			continue
+		}
+		sent++
+	}
+	return sent
+}
+
+// deliver 向单个渠道投递，网络错误、429 与 5xx 按指数退避重试。
+func (s *NotificationService) deliver(ctx context.Context, ch *NotificationChannel, msg *NotificationMessage) error {
+	client, err := s.client()
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	backoff := s.retryBackoff
+	for attempt := 1; attempt <= s.maxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+
+		// 每次重试重新签名，避免接收方拒绝过期时间戳
+		req, err := buildNotificationRequest(ch, msg, s.now())
+		if err != nil {
+			return err
+		}
+		retryable, err := s.post(ctx, client, ch.Type, req)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retryable {
+			return err
+		}
+	}
+	return lastErr
+}
+
+func (s *NotificationService) post(ctx context.Context, client *http.Client, channelType string, r *notificationRequest) (bool, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
+	if err != nil {
+		return false, fmt.Errorf("build notification request: %w", err)
+	}
+	httpReq.Header = r.Header
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return ctx.Err() == nil, fmt.Errorf("send notification: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, notificationResponseReadSize))
+	return checkNotificationResponse(channelType, resp.StatusCode, body)
+}
+
+func (s *NotificationService) client() (*http.Client, error) {
+	if s.httpClient != nil {
+		return s.httpClient, nil
+	}
+	opts := httpclient.Options{Timeout: notificationHTTPTimeout}
+	if s.cfg != nil {
+		opts.ValidateResolvedIP = s.cfg.Security.URLAllowlist.Enabled
+		opts.AllowPrivateHosts = s.cfg.Security.URLAllowlist.AllowPrivateHosts
+	}
+	client, err := httpclient.GetClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("create http client failed: %w", err)
+	}
+	return client, nil
+}
+
+// enabledChannels 返回已启用的渠道（短 TTL 缓存，账号事件属于热路径）
+func (s *NotificationService) enabledChannels(ctx context.Context) []NotificationChannel {
+	now := s.now()
+	s.cacheMu.RLock()
+	if s.cache != nil && now.Sub(s.cachedAt) < notificationChannelCacheTTL {
+		out := s.cache
+		s.cacheMu.RUnlock()
+		return out
+	}
+	s.cacheMu.RUnlock()
+
+	all, err := s.repo.List(ctx)
+	if err != nil {
+		slog.Warn("notification_channels_load_failed", "error", err)
+		return nil
+	}
+	enabled := make([]NotificationChannel, 0, len(all))
+	for _, ch := range all {
+		if ch.Enabled {
+			enabled = append(enabled, ch)
+		}
+	}
+
+	s.cacheMu.Lock()
+	s.cache = enabled
+	s.cachedAt = now
+	s.cacheMu.Unlock()
+	return enabled
+}
+
+func (s *NotificationService) invalidateCache() {
+	s.cacheMu.Lock()
+	s.cache = nil
+	s.cacheMu.Unlock()
+}
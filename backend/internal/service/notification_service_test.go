@@ -0,0 +1,212 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type notificationChannelRepoStub struct {
+	channels []NotificationChannel
+}
+
+func (r *notificationChannelRepoStub) Create(ctx context.Context, ch *NotificationChannel) error {
+	ch.ID = int64(len(r.channels) + 1)
+	r.channels = append(r.channels, *ch)
+	return nil
+}
+
+func (r *notificationChannelRepoStub) GetByID(ctx context.Context, id int64) (*NotificationChannel, error) {
+	for i := range r.channels {
+		if r.channels[i].ID == id {
+			ch := r.channels[i]
+			return &ch, nil
+		}
+	}
+	return nil, ErrNotificationChannelNotFound
+}
+
+func (r *notificationChannelRepoStub) Update(ctx context.Context, ch *NotificationChannel) error {
+	return nil
+}
+
+func (r *notificationChannelRepoStub) Delete(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (r *notificationChannelRepoStub) List(ctx context.Context) ([]NotificationChannel, error) {
+	return r.channels, nil
+}
+
+func newNotificationServiceForTest(repo NotificationChannelRepository, client *http.Client) *NotificationService {
+	svc := NewNotificationService(repo, &config.Config{})
+	svc.httpClient = client
+	svc.retryBackoff = time.Millisecond
+	return svc
+}
+
+func TestBuildNotificationRequest_WebhookSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ch := &NotificationChannel{
+		Type:       NotificationChannelTypeWebhook,
+		WebhookURL: "https://hooks.example.com/ops",
+		Secret:     "s3cret",
+		Headers:    map[string]string{"Authorization": "Bearer abc"},
+	}
+	msg := &NotificationMessage{
+		EventType: NotificationEventAccountDisabled,
+		Title:     "Account disabled",
+		Text:      "Reason: 401",
+		Data:      map[string]any{"account_id": 7},
+	}
+
+	req, err := buildNotificationRequest(ch, msg, now)
+	require.NoError(t, err)
+	require.Equal(t, "https://hooks.example.com/ops", req.URL)
+	require.Equal(t, "Bearer abc", req.Header.Get("Authorization"))
+	require.Equal(t, "1700000000", req.Header.Get(NotificationTimestampHeader))
+	require.Equal(t, "sha256="+SignNotificationPayload("s3cret", "1700000000", req.Body), req.Header.Get(NotificationSignatureHeader))
+	require.Equal(t, NotificationEventAccountDisabled, gjson.GetBytes(req.Body, "event_type").String())
+	require.Equal(t, int64(7), gjson.GetBytes(req.Body, "data.account_id").Int())
+}
+
+func TestBuildNotificationRequest_BotFormats(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	msg := &NotificationMessage{Title: "Ops Alert", Text: "error_rate > 5"}
+
+	slack, err := buildNotificationRequest(&NotificationChannel{Type: NotificationChannelTypeSlack, WebhookURL: "https://hooks.slack.com/x"}, msg, now)
+	require.NoError(t, err)
+	require.Equal(t, "*Ops Alert*\nerror_rate > 5", gjson.GetBytes(slack.Body, "text").String())
+	require.Empty(t, slack.Header.Get(NotificationSignatureHeader))
+
+	feishu, err := buildNotificationRequest(&NotificationChannel{Type: NotificationChannelTypeFeishu, WebhookURL: "https://open.feishu.cn/x", Secret: "fs"}, msg, now)
+	require.NoError(t, err)
+	require.Equal(t, "text", gjson.GetBytes(feishu.Body, "msg_type").String())
+	require.Equal(t, "Ops Alert\n\nerror_rate > 5", gjson.GetBytes(feishu.Body, "content.text").String())
+	require.Equal(t, "1700000000", gjson.GetBytes(feishu.Body, "timestamp").String())
+	require.Equal(t, signFeishu("fs", "1700000000"), gjson.GetBytes(feishu.Body, "sign").String())
+
+	ding, err := buildNotificationRequest(&NotificationChannel{Type: NotificationChannelTypeDingTalk, WebhookURL: "https://oapi.dingtalk.com/robot/send?access_token=t", Secret: "dd"}, msg, now)
+	require.NoError(t, err)
+	u, err := url.Parse(ding.URL)
+	require.NoError(t, err)
+	require.Equal(t, "t", u.Query().Get("access_token"))
+	require.Equal(t, "1700000000000", u.Query().Get("timestamp"))
+	require.Equal(t, signDingTalk("dd", "1700000000000"), u.Query().Get("sign"))
+	require.Equal(t, "Ops Alert\n\nerror_rate > 5", gjson.GetBytes(ding.Body, "text.content").String())
+}
+
+func TestNotificationService_DeliverRetriesOnServerError(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo := &notificationChannelRepoStub{channels: []NotificationChannel{
+		{ID: 1, Type: NotificationChannelTypeWebhook, Enabled: true, WebhookURL: server.URL},
+		{ID: 2, Type: NotificationChannelTypeWebhook, Enabled: false, WebhookURL: server.URL},
+	}}
+	svc := newNotificationServiceForTest(repo, server.Client())
+
+	sent := svc.Send(context.Background(), []int64{1, 2}, &NotificationMessage{EventType: NotificationEventOpsAlert, Title: "t"})
+	require.Equal(t, 1, sent)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestNotificationService_DeliverDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = io.WriteString(w, `{"code":19021,"msg":"sign match fail"}`)
+	}))
+	defer server.Close()
+
+	repo := &notificationChannelRepoStub{channels: []NotificationChannel{
+		{ID: 1, Name: "feishu", Type: NotificationChannelTypeFeishu, Enabled: true, WebhookURL: server.URL},
+	}}
+	svc := newNotificationServiceForTest(repo, server.Client())
+
+	err := svc.Test(context.Background(), 1)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "sign match fail")
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestNotificationService_NotifyAccountEventThrottlesAndFilters(t *testing.T) {
+	bodies := make(chan string, 4)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer server.Close()
+
+	repo := &notificationChannelRepoStub{channels: []NotificationChannel{
+		{ID: 1, Type: NotificationChannelTypeWebhook, Enabled: true, WebhookURL: server.URL, EventTypes: []string{NotificationEventAccountDisabled}},
+	}}
+	svc := newNotificationServiceForTest(repo, server.Client())
+	svc.Start()
+	defer svc.Stop()
+
+	ctx := context.Background()
+	event := AccountStateEvent{Type: NotificationEventAccountDisabled, AccountID: 7, AccountName: "acc", Platform: PlatformOpenAI, Reason: "Access forbidden (403)"}
+	svc.NotifyAccountEvent(ctx, event)
+	svc.NotifyAccountEvent(ctx, event)                                                                      // throttled
+	svc.NotifyAccountEvent(ctx, AccountStateEvent{Type: NotificationEventAccountRateLimited, AccountID: 7}) // not subscribed
+
+	select {
+	case body := <-bodies:
+		require.Equal(t, NotificationEventAccountDisabled, gjson.Get(body, "event_type").String())
+		require.True(t, strings.Contains(gjson.Get(body, "text").String(), "Access forbidden (403)"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification not delivered")
+	}
+	select {
+	case body := <-bodies:
+		t.Fatalf("unexpected extra notification: %s", body)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestNotificationService_CreateValidates(t *testing.T) {
+	svc := NewNotificationService(&notificationChannelRepoStub{}, &config.Config{})
+	ctx := context.Background()
+
+	_, err := svc.Create(ctx, &CreateNotificationChannelInput{Name: "x", Type: "pagerduty", WebhookURL: "https://example.com"})
+	require.Error(t, err)
+
+	_, err = svc.Create(ctx, &CreateNotificationChannelInput{Name: "x", Type: NotificationChannelTypeWebhook, WebhookURL: "http://example.com"})
+	require.Error(t, err, "insecure http is rejected by default")
+
+	_, err = svc.Create(ctx, &CreateNotificationChannelInput{Name: "x", Type: NotificationChannelTypeWebhook, WebhookURL: "https://example.com", EventTypes: []string{NotificationEventOpsAlert}})
+	require.Error(t, err, "ops_alert is targeted by rule, not subscribed")
+
+	ch, err := svc.Create(ctx, &CreateNotificationChannelInput{
+		Name:       " ops ",
+		Type:       "Slack",
+		WebhookURL: "https://hooks.slack.com/services/x",
+		Headers:    map[string]string{"x-token": " v "},
+		EventTypes: []string{NotificationEventAccountRateLimited, NotificationEventAccountRateLimited},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "ops", ch.Name)
+	require.Equal(t, NotificationChannelTypeSlack, ch.Type)
+	require.Equal(t, map[string]string{"X-Token": "v"}, ch.Headers)
+	require.Equal(t, []string{NotificationEventAccountRateLimited}, ch.EventTypes)
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// OIDC 令牌端点认证方式
+const (
+	OIDCTokenAuthClientSecretPost  = "client_secret_post"
+	OIDCTokenAuthClientSecretBasic = "client_secret_basic"
+	OIDCTokenAuthNone              = "none"
+)
+
+// OIDCDefaultScopes 未配置 scopes 时使用的默认值
+const OIDCDefaultScopes = "openid email profile"
+
+// OIDCDefaultFrontendRedirectURL 默认前端回调路径
+const OIDCDefaultFrontendRedirectURL = "/auth/oidc/callback"
+
+var oidcProviderSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// OIDCProvider OIDC / 通用 OAuth2 登录提供方
+//
+// 配置 IssuerURL 时通过 discovery 文档获取端点并校验 id_token；
+// 手动配置的端点优先于 discovery 结果（用于非标准 OAuth2 提供方）。
+type OIDCProvider struct {
+	ID      int64
+	Slug    string // URL 标识：/api/v1/auth/oauth/oidc/{slug}/start
+	Name    string // 登录按钮显示名称
+	Enabled bool
+
+	IssuerURL    string
+	AuthorizeURL string
+	TokenURL     string
+	UserInfoURL  string
+	JWKSURL      string
+
+	ClientID        string
+	ClientSecret    string
+	Scopes          string
+	TokenAuthMethod string
+	UsePKCE         bool
+	Audiences       []string // 额外接受的 aud（client_id 始终接受）
+
+	RedirectURL         string // 后端回调地址（需在 IdP 注册）
+	FrontendRedirectURL string // 前端回调页
+
+	// 账号与权限映射
+	AllowedEmailDomains []string           // 非空时仅允许这些邮箱域（要求 IdP 返回已验证邮箱）
+	TrustEmail          bool               // 使用 IdP 返回的已验证邮箱绑定本地账号（否则使用合成邮箱）
+	UsernameClaim       string             // 用户名声明路径（默认 preferred_username/name）
+	GroupsClaim         string             // 分组声明路径（默认 groups）
+	DefaultGroupIDs     []int64            // 通过该提供方登录的用户均可使用的专属分组
+	GroupMappings       []OIDCGroupMapping // 基于邮箱域/声明的分组映射
+
+	SortOrder int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// OIDCGroupMapping 声明/邮箱域 → 专属分组与默认订阅的映射规则。
+// EmailDomain 与 ClaimValue 至少配置一个；同时配置时需全部匹配。
+type OIDCGroupMapping struct {
+	EmailDomain   string                       `json:"email_domain,omitempty"`
+	Claim         string                       `json:"claim,omitempty"` // 声明路径，为空时使用提供方 GroupsClaim
+	ClaimValue    string                       `json:"claim_value,omitempty"`
+	GroupIDs      []int64                      `json:"group_ids,omitempty"`
+	Subscriptions []DefaultSubscriptionSetting `json:"subscriptions,omitempty"`
+}
+
+// OAuthSignupGrants SSO 登录后授予用户的权限。
+// AllowedGroupIDs 每次登录增量授予；Subscriptions 仅在首次注册时分配。
+type OAuthSignupGrants struct {
+	AllowedGroupIDs []int64                      `json:"allowed_group_ids,omitempty"`
+	Subscriptions   []DefaultSubscriptionSetting `json:"subscriptions,omitempty"`
+}
+
+// IsEmpty 判断是否没有任何授予项
+func (g *OAuthSignupGrants) IsEmpty() bool {
+	return g == nil || (len(g.AllowedGroupIDs) == 0 && len(g.Subscriptions) == 0)
+}
+
+// OIDCIdentity 从 id_token / userinfo 合并得到的身份信息
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	Claims        string // 合并后的原始声明 JSON（用于分组映射）
+}
+
+// EmailDomain 返回小写的邮箱域（不含 @）
+func (i *OIDCIdentity) EmailDomain() string {
+	at := strings.LastIndexByte(i.Email, '@')
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(i.Email[at+1:])
+}
+
+// ClaimValues 读取声明路径的值（数组展开为多个值，字符串按空白分隔兼容 scope 风格）
+func (i *OIDCIdentity) ClaimValues(path string) []string {
+	path = strings.TrimSpace(path)
+	if path == "" || i.Claims == "" {
+		return nil
+	}
+	res := gjson.Get(i.Claims, path)
+	if !res.Exists() {
+		return nil
+	}
+	var values []string
+	if res.IsArray() {
+		for _, v := range res.Array() {
+			if s := strings.TrimSpace(v.String()); s != "" {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return strings.Fields(res.String())
+}
+
+// IsEmailDomainAllowed 检查邮箱域限制（未配置时放行）
+func (p *OIDCProvider) IsEmailDomainAllowed(identity *OIDCIdentity) bool {
+	if len(p.AllowedEmailDomains) == 0 {
+		return true
+	}
+	if identity == nil || identity.Email == "" || !identity.EmailVerified {
+		return false
+	}
+	return slices.Contains(p.AllowedEmailDomains, identity.EmailDomain())
+}
+
+// ResolveGrants 根据身份信息计算应授予的分组与订阅
+func (p *OIDCProvider) ResolveGrants(identity *OIDCIdentity) *OAuthSignupGrants {
+	grants := &OAuthSignupGrants{}
+	grants.AllowedGroupIDs = append(grants.AllowedGroupIDs, p.DefaultGroupIDs...)
+	for _, m := range p.GroupMappings {
+		if !p.mappingMatches(m, identity) {
+			continue
+		}
+		grants.AllowedGroupIDs = append(grants.AllowedGroupIDs, m.GroupIDs...)
+		grants.Subscriptions = append(grants.Subscriptions, m.Subscriptions...)
+	}
+	slices.Sort(grants.AllowedGroupIDs)
+	grants.AllowedGroupIDs = slices.Compact(grants.AllowedGroupIDs)
+	return grants
+}
+
+func (p *OIDCProvider) mappingMatches(m OIDCGroupMapping, identity *OIDCIdentity) bool {
+	if identity == nil || (m.EmailDomain == "" && m.ClaimValue == "") {
+		return false
+	}
+	if m.EmailDomain != "" {
+		// 邮箱域映射要求 IdP 已验证邮箱，避免用户自填邮箱越权
+		if !identity.EmailVerified || !strings.EqualFold(identity.EmailDomain(), m.EmailDomain) {
+			return false
+		}
+	}
+	if m.ClaimValue != "" {
+		claim := m.Claim
+		if claim == "" {
+			claim = p.groupsClaim()
+		}
+		if !slices.Contains(identity.ClaimValues(claim), m.ClaimValue) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *OIDCProvider) groupsClaim() string {
+	if c := strings.TrimSpace(p.GroupsClaim); c != "" {
+		return c
+	}
+	return "groups"
+}
+
+// LocalEmail 返回用于绑定本地账号的邮箱。
+// 默认使用基于 provider + subject 的稳定合成邮箱，避免第三方邮箱与本地账号冲突导致账号被接管；
+// 仅在管理员显式信任且邮箱已验证时使用真实邮箱。
+func (p *OIDCProvider) LocalEmail(identity *OIDCIdentity) string {
+	if p.TrustEmail && identity.EmailVerified && identity.Email != "" {
+		return strings.ToLower(identity.Email)
+	}
+	sum := sha256.Sum256([]byte(identity.Subject))
+	return "oidc-" + p.Slug + "-" + hex.EncodeToString(sum[:12]) + OIDCSyntheticEmailDomain
+}
+
+// LocalUsername 返回新用户的用户名
+func (p *OIDCProvider) LocalUsername(identity *OIDCIdentity) string {
+	if identity.Username != "" {
+		return identity.Username
+	}
+	if at := strings.IndexByte(identity.Email, '@'); at > 0 {
+		return identity.Email[:at]
+	}
+	return p.Slug + "_user"
+}
+
+// EffectiveScopes 返回授权请求的 scope
+func (p *OIDCProvider) EffectiveScopes() string {
+	if s := strings.TrimSpace(p.Scopes); s != "" {
+		return s
+	}
+	return OIDCDefaultScopes
+}
+
+// IsOpenID 是否按 OIDC 处理（请求 openid scope）
+func (p *OIDCProvider) IsOpenID() bool {
+	return slices.Contains(strings.Fields(p.EffectiveScopes()), "openid")
+}
+
+// AcceptsAudience 检查 aud 是否为 client_id 或额外允许的受众
+func (p *OIDCProvider) AcceptsAudience(aud string) bool {
+	return aud == p.ClientID || slices.Contains(p.Audiences, aud)
+}
+
+// IsValidOIDCProviderSlug 校验 slug（小写字母、数字与连字符，最长 32）
+func IsValidOIDCProviderSlug(slug string) bool {
+	return oidcProviderSlugPattern.MatchString(slug)
+}
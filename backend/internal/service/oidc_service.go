@@ -0,0 +1,858 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/tidwall/gjson"
+)
+
+var (
+	ErrOIDCProviderNotFound       = infraerrors.NotFound("OIDC_PROVIDER_NOT_FOUND", "oidc provider not found")
+	ErrOIDCProviderExists         = infraerrors.Conflict("OIDC_PROVIDER_EXISTS", "oidc provider slug already exists")
+	ErrOIDCProviderDisabled       = infraerrors.NotFound("OAUTH_DISABLED", "oauth login is disabled")
+	ErrOIDCDiscoveryFailed        = infraerrors.ServiceUnavailable("OIDC_DISCOVERY_FAILED", "failed to load oidc discovery document")
+	ErrOIDCInvalidIDToken         = infraerrors.Unauthorized("OIDC_INVALID_ID_TOKEN", "invalid id token")
+	ErrOIDCMissingSubject         = infraerrors.Unauthorized("OIDC_MISSING_SUBJECT", "identity provider did not return a subject")
+	ErrOIDCEmailDomainNotAllowed  = infraerrors.Forbidden("OIDC_EMAIL_DOMAIN_NOT_ALLOWED", "email domain is not allowed for this login provider")
+	ErrOIDCUserInfoSubjectInvalid = infraerrors.Unauthorized("OIDC_USERINFO_SUBJECT_MISMATCH", "userinfo subject does not match id token")
+)
+
+const (
+	oidcDiscoveryCacheTTL   = time.Hour
+	oidcJWKSCacheTTL        = time.Hour
+	oidcJWKSMinRefresh      = time.Minute // 未知 kid 触发刷新的最小间隔（防止伪造 kid 打爆 IdP）
+	oidcIDTokenLeeway       = time.Minute
+	oidcMaxSubjectLen       = 255
+	oidcMaxMappingsPerEntry = 100
+)
+
+// oidcSigningMethods 接受的 id_token 签名算法（拒绝 none/HS*）
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// OIDCProviderRepository OIDC 提供方持久化
+type OIDCProviderRepository interface {
+	List(ctx context.Context) ([]OIDCProvider, error)
+	GetByID(ctx context.Context, id int64) (*OIDCProvider, error)
+	GetBySlug(ctx context.Context, slug string) (*OIDCProvider, error)
+	Create(ctx context.Context, p *OIDCProvider) error
+	Update(ctx context.Context, p *OIDCProvider) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// OIDCDiscoveryDocument OpenID Provider Metadata（仅使用到的字段）
+type OIDCDiscoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCTokenRequest 授权码换取令牌请求
+type OIDCTokenRequest struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	AuthMethod   string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+}
+
+// OIDCTokenResponse 令牌端点响应
+type OIDCTokenResponse struct {
+	AccessToken string
+	TokenType   string
+	IDToken     string
+	ExpiresIn   int64
+}
+
+// OIDCClient 访问 IdP 的 HTTP 客户端（repository 层实现）
+type OIDCClient interface {
+	FetchDiscovery(ctx context.Context, issuer string) (*OIDCDiscoveryDocument, error)
+	FetchJWKS(ctx context.Context, jwksURL string) ([]byte, error)
+	ExchangeCode(ctx context.Context, req *OIDCTokenRequest) (*OIDCTokenResponse, error)
+	FetchUserInfo(ctx context.Context, userInfoURL, accessToken string) ([]byte, error)
+}
+
+// CreateOIDCProviderInput 创建提供方参数
+type CreateOIDCProviderInput struct {
+	Provider OIDCProvider
+}
+
+// UpdateOIDCProviderInput 更新提供方参数（nil 表示不修改；ClientSecret 为空字符串表示保留原值）
+type UpdateOIDCProviderInput struct {
+	Name                *string
+	Enabled             *bool
+	IssuerURL           *string
+	AuthorizeURL        *string
+	TokenURL            *string
+	UserInfoURL         *string
+	JWKSURL             *string
+	ClientID            *string
+	ClientSecret        *string
+	Scopes              *string
+	TokenAuthMethod     *string
+	UsePKCE             *bool
+	Audiences           *[]string
+	RedirectURL         *string
+	FrontendRedirectURL *string
+	AllowedEmailDomains *[]string
+	TrustEmail          *bool
+	UsernameClaim       *string
+	GroupsClaim         *string
+	DefaultGroupIDs     *[]int64
+	GroupMappings       *[]OIDCGroupMapping
+	SortOrder           *int
+}
+
+// OIDCAuthRequest 一次授权请求的随机参数
+type OIDCAuthRequest struct {
+	State         string
+	Nonce         string
+	CodeChallenge string
+}
+
+type oidcEndpoints struct {
+	Issuer       string
+	AuthorizeURL string
+	TokenURL     string
+	UserInfoURL  string
+	JWKSURL      string
+}
+
+type oidcDiscoveryEntry struct {
+	doc       *OIDCDiscoveryDocument
+	fetchedAt time.Time
+}
+
+type oidcJWKSEntry struct {
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// OIDCService OIDC / 通用 OAuth2 单点登录服务
+type OIDCService struct {
+	repo   OIDCProviderRepository
+	client OIDCClient
+
+	mu        sync.Mutex
+	discovery map[string]oidcDiscoveryEntry
+	jwks      map[string]oidcJWKSEntry
+
+	now func() time.Time
+}
+
+// NewOIDCService 创建 OIDC 服务
+func NewOIDCService(repo OIDCProviderRepository, client OIDCClient) *OIDCService {
+	return &OIDCService{
+		repo:      repo,
+		client:    client,
+		discovery: make(map[string]oidcDiscoveryEntry),
+		jwks:      make(map[string]oidcJWKSEntry),
+		now:       time.Now,
+	}
+}
+
+// --- Admin CRUD ---
+
+// List 返回全部提供方（按 sort_order, id 排序）
+func (s *OIDCService) List(ctx context.Context) ([]OIDCProvider, error) {
+	return s.repo.List(ctx)
+}
+
+// GetByID 获取提供方
+func (s *OIDCService) GetByID(ctx context.Context, id int64) (*OIDCProvider, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// Create 创建提供方
+func (s *OIDCService) Create(ctx context.Context, input *CreateOIDCProviderInput) (*OIDCProvider, error) {
+	p := input.Provider
+	normalizeOIDCProvider(&p)
+	if err := validateOIDCProvider(&p); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Update 更新提供方（slug 创建后不可修改，避免 IdP 侧回调地址失效）
+func (s *OIDCService) Update(ctx context.Context, id int64, input *UpdateOIDCProviderInput) (*OIDCProvider, error) {
+	p, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	oldIssuer := p.IssuerURL
+	applyOIDCProviderUpdate(p, input)
+	normalizeOIDCProvider(p)
+	if err := validateOIDCProvider(p); err != nil {
+		return nil, err
+	}
+	if err := s.repo.Update(ctx, p); err != nil {
+		return nil, err
+	}
+	s.invalidateDiscovery(oldIssuer)
+	return p, nil
+}
+
+// Delete 删除提供方（已通过该提供方注册的用户保留，可继续使用其他方式登录）
+func (s *OIDCService) Delete(ctx context.Context, id int64) error {
+	p, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidateDiscovery(p.IssuerURL)
+	return nil
+}
+
+// TestDiscovery 加载提供方的 discovery 文档，用于管理端校验配置
+func (s *OIDCService) TestDiscovery(ctx context.Context, id int64) (*OIDCDiscoveryDocument, error) {
+	p, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateDiscovery(p.IssuerURL)
+	ep, err := s.resolveEndpoints(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCDiscoveryDocument{
+		Issuer:                ep.Issuer,
+		AuthorizationEndpoint: ep.AuthorizeURL,
+		TokenEndpoint:         ep.TokenURL,
+		UserInfoEndpoint:      ep.UserInfoURL,
+		JWKSURI:               ep.JWKSURL,
+	}, nil
+}
+
+// --- Login flow ---
+
+// ListEnabled 返回已启用的提供方（用于登录页展示）
+func (s *OIDCService) ListEnabled(ctx context.Context) ([]OIDCProvider, error) {
+	providers, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]OIDCProvider, 0, len(providers))
+	for _, p := range providers {
+		if p.Enabled {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+// GetEnabledBySlug 获取已启用的提供方；未启用与不存在统一返回 OAUTH_DISABLED
+func (s *OIDCService) GetEnabledBySlug(ctx context.Context, slug string) (*OIDCProvider, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !IsValidOIDCProviderSlug(slug) {
+		return nil, ErrOIDCProviderDisabled
+	}
+	p, err := s.repo.GetBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, ErrOIDCProviderNotFound) {
+			return nil, ErrOIDCProviderDisabled
+		}
+		return nil, err
+	}
+	if !p.Enabled {
+		return nil, ErrOIDCProviderDisabled
+	}
+	return p, nil
+}
+
+// BuildAuthorizeURL 构建 IdP 授权地址
+func (s *OIDCService) BuildAuthorizeURL(ctx context.Context, p *OIDCProvider, req *OIDCAuthRequest) (string, error) {
+	ep, err := s.resolveEndpoints(ctx, p)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(ep.AuthorizeURL)
+	if err != nil {
+		return "", fmt.Errorf("parse authorize url: %w", err)
+	}
+	q := u.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", p.ClientID)
+	q.Set("redirect_uri", p.RedirectURL)
+	q.Set("scope", p.EffectiveScopes())
+	q.Set("state", req.State)
+	if p.IsOpenID() && req.Nonce != "" {
+		q.Set("nonce", req.Nonce)
+	}
+	if p.UsePKCE {
+		q.Set("code_challenge", req.CodeChallenge)
+		q.Set("code_challenge_method", "S256")
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// Authenticate 用授权码换取令牌，校验 id_token 并合并 userinfo，返回身份信息。
+// 邮箱域限制在此处执行；账号绑定与分组授予由调用方完成。
+func (s *OIDCService) Authenticate(ctx context.Context, p *OIDCProvider, code, codeVerifier, nonce string) (*OIDCIdentity, error) {
+	ep, err := s.resolveEndpoints(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenResp, err := s.client.ExchangeCode(ctx, &OIDCTokenRequest{
+		TokenURL:     ep.TokenURL,
+		ClientID:     p.ClientID,
+		ClientSecret: p.ClientSecret,
+		AuthMethod:   p.TokenAuthMethod,
+		Code:         code,
+		RedirectURI:  p.RedirectURL,
+		CodeVerifier: codeVerifier,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claims := map[string]any{}
+	if p.IsOpenID() {
+		if tokenResp.IDToken == "" {
+			return nil, ErrOIDCInvalidIDToken.WithCause(errors.New("token response missing id_token"))
+		}
+		claims, err = s.verifyIDToken(ctx, p, ep, tokenResp.IDToken, nonce)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if ep.UserInfoURL != "" && tokenResp.AccessToken != "" {
+		body, err := s.client.FetchUserInfo(ctx, ep.UserInfoURL, tokenResp.AccessToken)
+		if err != nil {
+			// OIDC 下 id_token 已包含身份信息，userinfo 失败仅记录日志；纯 OAuth2 则必须成功
+			if !p.IsOpenID() {
+				return nil, err
+			}
+			logger.LegacyPrintf("service.oidc", "[OIDC] provider=%s userinfo fetch failed: %v", p.Slug, err)
+		} else if err := mergeOIDCUserInfo(claims, body); err != nil {
+			return nil, err
+		}
+	}
+
+	identity, err := buildOIDCIdentity(p, claims)
+	if err != nil {
+		return nil, err
+	}
+	if !p.IsEmailDomainAllowed(identity) {
+		return nil, ErrOIDCEmailDomainNotAllowed
+	}
+	return identity, nil
+}
+
+// resolveEndpoints 合并 discovery 与手动配置的端点
+func (s *OIDCService) resolveEndpoints(ctx context.Context, p *OIDCProvider) (*oidcEndpoints, error) {
+	ep := &oidcEndpoints{
+		Issuer:       p.IssuerURL,
+		AuthorizeURL: p.AuthorizeURL,
+		TokenURL:     p.TokenURL,
+		UserInfoURL:  p.UserInfoURL,
+		JWKSURL:      p.JWKSURL,
+	}
+	if p.IssuerURL != "" {
+		doc, err := s.getDiscovery(ctx, p.IssuerURL)
+		if err != nil {
+			return nil, err
+		}
+		ep.Issuer = doc.Issuer
+		ep.AuthorizeURL = firstNonEmptyString(ep.AuthorizeURL, doc.AuthorizationEndpoint)
+		ep.TokenURL = firstNonEmptyString(ep.TokenURL, doc.TokenEndpoint)
+		ep.UserInfoURL = firstNonEmptyString(ep.UserInfoURL, doc.UserInfoEndpoint)
+		ep.JWKSURL = firstNonEmptyString(ep.JWKSURL, doc.JWKSURI)
+	}
+	if ep.AuthorizeURL == "" || ep.TokenURL == "" {
+		return nil, infraerrors.InternalServer("OAUTH_CONFIG_INVALID", "oauth authorize/token endpoint not configured")
+	}
+	if p.IsOpenID() && (ep.Issuer == "" || ep.JWKSURL == "") {
+		return nil, infraerrors.InternalServer("OAUTH_CONFIG_INVALID", "oidc issuer/jwks not configured")
+	}
+	if !p.IsOpenID() && ep.UserInfoURL == "" {
+		return nil, infraerrors.InternalServer("OAUTH_CONFIG_INVALID", "oauth userinfo url not configured")
+	}
+	return ep, nil
+}
+
+func (s *OIDCService) getDiscovery(ctx context.Context, issuer string) (*OIDCDiscoveryDocument, error) {
+	s.mu.Lock()
+	entry, ok := s.discovery[issuer]
+	s.mu.Unlock()
+	if ok && s.now().Sub(entry.fetchedAt) < oidcDiscoveryCacheTTL {
+		return entry.doc, nil
+	}
+
+	doc, err := s.client.FetchDiscovery(ctx, issuer)
+	if err != nil {
+		logger.LegacyPrintf("service.oidc", "[OIDC] discovery failed: issuer=%s err=%v", issuer, err)
+		return nil, ErrOIDCDiscoveryFailed.WithCause(err)
+	}
+	// OIDC Discovery 1.0 §4.3：文档中的 issuer 必须与请求使用的 issuer 完全一致
+	if strings.TrimRight(doc.Issuer, "/") != strings.TrimRight(issuer, "/") {
+		return nil, ErrOIDCDiscoveryFailed.WithCause(fmt.Errorf("issuer mismatch: got %q want %q", doc.Issuer, issuer))
+	}
+	for _, raw := range []string{doc.AuthorizationEndpoint, doc.TokenEndpoint, doc.JWKSURI} {
+		if err := config.ValidateAbsoluteHTTPURL(raw); err != nil {
+			return nil, ErrOIDCDiscoveryFailed.WithCause(fmt.Errorf("invalid endpoint %q: %w", raw, err))
+		}
+	}
+
+	s.mu.Lock()
+	s.discovery[issuer] = oidcDiscoveryEntry{doc: doc, fetchedAt: s.now()}
+	s.mu.Unlock()
+	return doc, nil
+}
+
+func (s *OIDCService) invalidateDiscovery(issuer string) {
+	if issuer == "" {
+		return
+	}
+	s.mu.Lock()
+	delete(s.discovery, issuer)
+	s.mu.Unlock()
+}
+
+// verifyIDToken 校验 id_token 签名、issuer、audience、过期时间与 nonce
+func (s *OIDCService) verifyIDToken(ctx context.Context, p *OIDCProvider, ep *oidcEndpoints, rawToken, nonce string) (map[string]any, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(oidcSigningMethods),
+		jwt.WithIssuer(ep.Issuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(oidcIDTokenLeeway),
+		jwt.WithTimeFunc(s.now),
+	)
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(rawToken, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return s.lookupJWK(ctx, ep.JWKSURL, kid)
+	})
+	if err != nil {
+		return nil, ErrOIDCInvalidIDToken.WithCause(err)
+	}
+
+	audiences, err := claims.GetAudience()
+	if err != nil || len(audiences) == 0 {
+		return nil, ErrOIDCInvalidIDToken.WithCause(errors.New("missing aud"))
+	}
+	if !slices.ContainsFunc(audiences, p.AcceptsAudience) {
+		return nil, ErrOIDCInvalidIDToken.WithCause(fmt.Errorf("audience %v not accepted", audiences))
+	}
+	// OIDC Core §3.1.3.7：存在 azp 时必须为本客户端
+	if azp, ok := claims["azp"].(string); ok && azp != "" && azp != p.ClientID {
+		return nil, ErrOIDCInvalidIDToken.WithCause(fmt.Errorf("azp %q not accepted", azp))
+	}
+	if nonce != "" {
+		if got, _ := claims["nonce"].(string); got != nonce {
+			return nil, ErrOIDCInvalidIDToken.WithCause(errors.New("nonce mismatch"))
+		}
+	}
+	return claims, nil
+}
+
+func (s *OIDCService) lookupJWK(ctx context.Context, jwksURL, kid string) (crypto.PublicKey, error) {
+	s.mu.Lock()
+	entry, ok := s.jwks[jwksURL]
+	s.mu.Unlock()
+
+	fresh := ok && s.now().Sub(entry.fetchedAt) < oidcJWKSCacheTTL
+	if fresh {
+		if key := selectJWK(entry.keys, kid); key != nil {
+			return key, nil
+		}
+		// 未知 kid：可能是 IdP 轮换了密钥，限频刷新
+		if s.now().Sub(entry.fetchedAt) < oidcJWKSMinRefresh {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+	}
+
+	body, err := s.client.FetchJWKS(ctx, jwksURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetch jwks: %w", err)
+	}
+	keys, err := parseJWKS(body)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.jwks[jwksURL] = oidcJWKSEntry{keys: keys, fetchedAt: s.now()}
+	s.mu.Unlock()
+
+	if key := selectJWK(keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func selectJWK(keys map[string]crypto.PublicKey, kid string) crypto.PublicKey {
+	if key, ok := keys[kid]; ok {
+		return key
+	}
+	// 无 kid 的令牌仅在 JWKS 只有一把密钥时接受
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return nil
+}
+
+type oidcJSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS 解析 JWKS 中的 RSA / EC 签名公钥（忽略加密用途与不支持的类型）
+func parseJWKS(body []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []oidcJSONWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &set); err != nil {
+		return nil, fmt.Errorf("parse jwks: %w", err)
+	}
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var (
+			key crypto.PublicKey
+			err error
+		)
+		switch k.Kty {
+		case "RSA":
+			key, err = parseRSAJWK(k)
+		case "EC":
+			key, err = parseECJWK(k)
+		default:
+			continue
+		}
+		if err != nil {
+			logger.LegacyPrintf("service.oidc", "[OIDC] skip invalid jwk kid=%s: %v", k.Kid, err)
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks contains no usable signing keys")
+	}
+	return keys, nil
+}
+
+func parseRSAJWK(k oidcJSONWebKey) (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("decode n: %w", err)
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("decode e: %w", err)
+	}
+	exp := new(big.Int).SetBytes(e)
+	if len(n) < 256 || !exp.IsInt64() || exp.Int64() < 3 {
+		return nil, errors.New("rsa key too weak")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+}
+
+func parseECJWK(k oidcJSONWebKey) (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch k.Crv {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+	}
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return nil, fmt.Errorf("decode x: %w", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(k.Y)
+	if err != nil {
+		return nil, fmt.Errorf("decode y: %w", err)
+	}
+	key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, errors.New("ec point not on curve")
+	}
+	return key, nil
+}
+
+// mergeOIDCUserInfo 将 userinfo 合并进 id_token 声明（id_token 中已有的声明优先）。
+// 存在 id_token 时 userinfo 的 sub 必须一致（OIDC Core §5.3.2）。
+func mergeOIDCUserInfo(claims map[string]any, body []byte) error {
+	var info map[string]any
+	if err := json.Unmarshal(body, &info); err != nil {
+		return fmt.Errorf("parse userinfo: %w", err)
+	}
+	if sub, ok := claims["sub"]; ok {
+		if fmt.Sprint(info["sub"]) != fmt.Sprint(sub) {
+			return ErrOIDCUserInfoSubjectInvalid
+		}
+	}
+	for k, v := range info {
+		if _, exists := claims[k]; !exists {
+			claims[k] = v
+		}
+	}
+	return nil
+}
+
+func buildOIDCIdentity(p *OIDCProvider, claims map[string]any) (*OIDCIdentity, error) {
+	raw, err := json.Marshal(claims)
+	if err != nil {
+		return nil, fmt.Errorf("marshal claims: %w", err)
+	}
+	body := string(raw)
+	get := func(paths ...string) string {
+		for _, path := range paths {
+			if strings.TrimSpace(path) == "" {
+				continue
+			}
+			if v := strings.TrimSpace(gjson.Get(body, path).String()); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+
+	identity := &OIDCIdentity{
+		// 纯 OAuth2 提供方常用 id 作为用户标识
+		Subject:  get("sub", "id", "user_id", "uid"),
+		Email:    get("email"),
+		Username: get(p.UsernameClaim, "preferred_username", "name", "nickname", "login", "username"),
+		Claims:   body,
+	}
+	if identity.Subject == "" || len(identity.Subject) > oidcMaxSubjectLen {
+		return nil, ErrOIDCMissingSubject
+	}
+	verified := gjson.Get(body, "email_verified")
+	identity.EmailVerified = verified.Type == gjson.True || (verified.Type == gjson.String && strings.EqualFold(verified.Str, "true"))
+	return identity, nil
+}
+
+func firstNonEmptyString(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func applyOIDCProviderUpdate(p *OIDCProvider, in *UpdateOIDCProviderInput) {
+	setString := func(dst *string, src *string) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	setString(&p.Name, in.Name)
+	setString(&p.IssuerURL, in.IssuerURL)
+	setString(&p.AuthorizeURL, in.AuthorizeURL)
+	setString(&p.TokenURL, in.TokenURL)
+	setString(&p.UserInfoURL, in.UserInfoURL)
+	setString(&p.JWKSURL, in.JWKSURL)
+	setString(&p.ClientID, in.ClientID)
+	if in.ClientSecret != nil && strings.TrimSpace(*in.ClientSecret) != "" {
+		p.ClientSecret = *in.ClientSecret
+	}
+	setString(&p.Scopes, in.Scopes)
+	setString(&p.TokenAuthMethod, in.TokenAuthMethod)
+	setString(&p.RedirectURL, in.RedirectURL)
+	setString(&p.FrontendRedirectURL, in.FrontendRedirectURL)
+	setString(&p.UsernameClaim, in.UsernameClaim)
+	setString(&p.GroupsClaim, in.GroupsClaim)
+	if in.Enabled != nil {
+		p.Enabled = *in.Enabled
+	}
+	if in.UsePKCE != nil {
+		p.UsePKCE = *in.UsePKCE
+	}
+	if in.TrustEmail != nil {
+		p.TrustEmail = *in.TrustEmail
+	}
+	if in.Audiences != nil {
+		p.Audiences = *in.Audiences
+	}
+	if in.AllowedEmailDomains != nil {
+		p.AllowedEmailDomains = *in.AllowedEmailDomains
+	}
+	if in.DefaultGroupIDs != nil {
+		p.DefaultGroupIDs = *in.DefaultGroupIDs
+	}
+	if in.GroupMappings != nil {
+		p.GroupMappings = *in.GroupMappings
+	}
+	if in.SortOrder != nil {
+		p.SortOrder = *in.SortOrder
+	}
+}
+
+func normalizeOIDCProvider(p *OIDCProvider) {
+	p.Slug = strings.ToLower(strings.TrimSpace(p.Slug))
+	p.Name = strings.TrimSpace(p.Name)
+	p.IssuerURL = strings.TrimSpace(p.IssuerURL)
+	p.AuthorizeURL = strings.TrimSpace(p.AuthorizeURL)
+	p.TokenURL = strings.TrimSpace(p.TokenURL)
+	p.UserInfoURL = strings.TrimSpace(p.UserInfoURL)
+	p.JWKSURL = strings.TrimSpace(p.JWKSURL)
+	p.ClientID = strings.TrimSpace(p.ClientID)
+	p.ClientSecret = strings.TrimSpace(p.ClientSecret)
+	p.Scopes = strings.Join(strings.Fields(p.Scopes), " ")
+	p.TokenAuthMethod = strings.ToLower(strings.TrimSpace(p.TokenAuthMethod))
+	if p.TokenAuthMethod == "" {
+		p.TokenAuthMethod = OIDCTokenAuthClientSecretPost
+	}
+	p.RedirectURL = strings.TrimSpace(p.RedirectURL)
+	p.FrontendRedirectURL = strings.TrimSpace(p.FrontendRedirectURL)
+	if p.FrontendRedirectURL == "" {
+		p.FrontendRedirectURL = OIDCDefaultFrontendRedirectURL
+	}
+	p.UsernameClaim = strings.TrimSpace(p.UsernameClaim)
+	p.GroupsClaim = strings.TrimSpace(p.GroupsClaim)
+	p.Audiences = normalizeStringList(p.Audiences, nil)
+	p.AllowedEmailDomains = normalizeStringList(p.AllowedEmailDomains, func(s string) string {
+		return strings.ToLower(strings.TrimPrefix(s, "@"))
+	})
+	for i := range p.GroupMappings {
+		m := &p.GroupMappings[i]
+		m.EmailDomain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(m.EmailDomain), "@"))
+		m.Claim = strings.TrimSpace(m.Claim)
+		m.ClaimValue = strings.TrimSpace(m.ClaimValue)
+	}
+}
+
+func normalizeStringList(values []string, transform func(string) string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if transform != nil {
+			v = transform(v)
+		}
+		if v != "" && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func validateOIDCProvider(p *OIDCProvider) error {
+	invalid := func(msg string) error {
+		return infraerrors.BadRequest("OIDC_PROVIDER_INVALID", msg)
+	}
+	if !IsValidOIDCProviderSlug(p.Slug) {
+		return invalid("slug must be 1-32 lowercase letters, digits or hyphens")
+	}
+	if p.Name == "" || len([]rune(p.Name)) > 100 {
+		return invalid("name is required (max 100 characters)")
+	}
+	if p.ClientID == "" {
+		return invalid("client_id is required")
+	}
+	if p.IssuerURL == "" && (p.AuthorizeURL == "" || p.TokenURL == "") {
+		return invalid("issuer_url or authorize_url/token_url is required")
+	}
+	if p.IsOpenID() && p.IssuerURL == "" && p.JWKSURL == "" {
+		return invalid("issuer_url or jwks_url is required for openid providers")
+	}
+	if !p.IsOpenID() && p.IssuerURL == "" && p.UserInfoURL == "" {
+		return invalid("userinfo_url is required for non-openid providers")
+	}
+	for name, raw := range map[string]string{
+		"issuer_url":    p.IssuerURL,
+		"authorize_url": p.AuthorizeURL,
+		"token_url":     p.TokenURL,
+		"userinfo_url":  p.UserInfoURL,
+		"jwks_url":      p.JWKSURL,
+	} {
+		if raw == "" {
+			continue
+		}
+		if err := config.ValidateAbsoluteHTTPURL(raw); err != nil {
+			return invalid(name + " is invalid")
+		}
+	}
+	if err := config.ValidateAbsoluteHTTPURL(p.RedirectURL); err != nil {
+		return invalid("redirect_url is invalid")
+	}
+	if err := config.ValidateFrontendRedirectURL(p.FrontendRedirectURL); err != nil {
+		return invalid("frontend_redirect_url is invalid")
+	}
+
+	switch p.TokenAuthMethod {
+	case OIDCTokenAuthClientSecretPost, OIDCTokenAuthClientSecretBasic:
+		if p.ClientSecret == "" {
+			return invalid("client_secret is required")
+		}
+	case OIDCTokenAuthNone:
+		if !p.UsePKCE {
+			return invalid("use_pkce must be enabled when token_auth_method=none")
+		}
+	default:
+		return invalid("token_auth_method invalid")
+	}
+
+	if len(p.GroupMappings) > oidcMaxMappingsPerEntry {
+		return invalid("too many group mappings")
+	}
+	for _, id := range p.DefaultGroupIDs {
+		if id <= 0 {
+			return invalid("default_group_ids contains invalid group id")
+		}
+	}
+	for i, m := range p.GroupMappings {
+		if m.EmailDomain == "" && m.ClaimValue == "" {
+			return invalid(fmt.Sprintf("group_mappings[%d] requires email_domain or claim_value", i))
+		}
+		if len(m.GroupIDs) == 0 && len(m.Subscriptions) == 0 {
+			return invalid(fmt.Sprintf("group_mappings[%d] grants nothing", i))
+		}
+		for _, id := range m.GroupIDs {
+			if id <= 0 {
+				return invalid(fmt.Sprintf("group_mappings[%d] contains invalid group id", i))
+			}
+		}
+		for _, sub := range m.Subscriptions {
+			if sub.GroupID <= 0 || sub.ValidityDays <= 0 || sub.ValidityDays > MaxValidityDays {
+				return invalid(fmt.Sprintf("group_mappings[%d] contains invalid subscription", i))
+			}
+		}
+	}
+	return nil
+}
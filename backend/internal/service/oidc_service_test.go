@@ -0,0 +1,296 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const testOIDCIssuer = "https://idp.example.com"
+
+type oidcClientStub struct {
+	key           *rsa.PrivateKey
+	kid           string
+	idToken       string
+	userInfo      string
+	discoveryHits int
+	jwksHits      int
+	lastTokenReq  *OIDCTokenRequest
+}
+
+func (s *oidcClientStub) FetchDiscovery(_ context.Context, issuer string) (*OIDCDiscoveryDocument, error) {
+	s.discoveryHits++
+	return &OIDCDiscoveryDocument{
+		Issuer:                issuer,
+		AuthorizationEndpoint: issuer + "/authorize",
+		TokenEndpoint:         issuer + "/token",
+		UserInfoEndpoint:      issuer + "/userinfo",
+		JWKSURI:               issuer + "/jwks",
+	}, nil
+}
+
+func (s *oidcClientStub) FetchJWKS(context.Context, string) ([]byte, error) {
+	s.jwksHits++
+	pub := s.key.PublicKey
+	return json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": s.kid,
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+func (s *oidcClientStub) ExchangeCode(_ context.Context, req *OIDCTokenRequest) (*OIDCTokenResponse, error) {
+	s.lastTokenReq = req
+	return &OIDCTokenResponse{AccessToken: "at", TokenType: "Bearer", IDToken: s.idToken}, nil
+}
+
+func (s *oidcClientStub) FetchUserInfo(context.Context, string, string) ([]byte, error) {
+	if s.userInfo == "" {
+		return []byte(`{"sub":"user-123"}`), nil
+	}
+	return []byte(s.userInfo), nil
+}
+
+func newTestOIDCProvider() *OIDCProvider {
+	return &OIDCProvider{
+		Slug:            "corp",
+		Name:            "Corp SSO",
+		Enabled:         true,
+		IssuerURL:       testOIDCIssuer,
+		ClientID:        "client-1",
+		ClientSecret:    "secret",
+		TokenAuthMethod: OIDCTokenAuthClientSecretPost,
+		UsePKCE:         true,
+		RedirectURL:     "https://app.example.com/api/v1/auth/oauth/oidc/corp/callback",
+	}
+}
+
+func newOIDCServiceForTest(t *testing.T) (*OIDCService, *oidcClientStub) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	client := &oidcClientStub{key: key, kid: "k1"}
+	return NewOIDCService(nil, client), client
+}
+
+func signTestIDToken(t *testing.T, client *oidcClientStub, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = client.kid
+	signed, err := token.SignedString(client.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func baseTestIDTokenClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            testOIDCIssuer,
+		"aud":            "client-1",
+		"sub":            "user-123",
+		"email":          "alice@corp.example",
+		"email_verified": true,
+		"nonce":          "n-1",
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"groups":         []string{"engineering", "admins"},
+	}
+}
+
+func TestOIDCService_Authenticate_ValidIDToken(t *testing.T) {
+	svc, client := newOIDCServiceForTest(t)
+	client.idToken = signTestIDToken(t, client, baseTestIDTokenClaims())
+	client.userInfo = `{"sub":"user-123","preferred_username":"alice"}`
+
+	identity, err := svc.Authenticate(context.Background(), newTestOIDCProvider(), "code", "verifier", "n-1")
+	require.NoError(t, err)
+	require.Equal(t, "user-123", identity.Subject)
+	require.Equal(t, "alice@corp.example", identity.Email)
+	require.True(t, identity.EmailVerified)
+	require.Equal(t, "alice", identity.Username)
+	require.Equal(t, []string{"engineering", "admins"}, identity.ClaimValues("groups"))
+
+	require.Equal(t, testOIDCIssuer+"/token", client.lastTokenReq.TokenURL)
+	require.Equal(t, "verifier", client.lastTokenReq.CodeVerifier)
+}
+
+func TestOIDCService_Authenticate_RejectsInvalidIDTokens(t *testing.T) {
+	cases := map[string]func(jwt.MapClaims){
+		"wrong issuer":   func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" },
+		"wrong audience": func(c jwt.MapClaims) { c["aud"] = "other-client" },
+		"wrong azp":      func(c jwt.MapClaims) { c["azp"] = "other-client" },
+		"nonce mismatch": func(c jwt.MapClaims) { c["nonce"] = "replayed" },
+		"expired":        func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"missing exp":    func(c jwt.MapClaims) { delete(c, "exp") },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			svc, client := newOIDCServiceForTest(t)
+			claims := baseTestIDTokenClaims()
+			mutate(claims)
+			client.idToken = signTestIDToken(t, client, claims)
+
+			_, err := svc.Authenticate(context.Background(), newTestOIDCProvider(), "code", "verifier", "n-1")
+			require.ErrorIs(t, err, ErrOIDCInvalidIDToken)
+		})
+	}
+}
+
+func TestOIDCService_Authenticate_AcceptsExtraAudience(t *testing.T) {
+	svc, client := newOIDCServiceForTest(t)
+	claims := baseTestIDTokenClaims()
+	claims["aud"] = []string{"api://gateway", "client-1"}
+	client.idToken = signTestIDToken(t, client, claims)
+
+	_, err := svc.Authenticate(context.Background(), newTestOIDCProvider(), "code", "verifier", "n-1")
+	require.NoError(t, err)
+
+	p := newTestOIDCProvider()
+	p.ClientID = "client-2"
+	p.Audiences = []string{"api://gateway"}
+	_, err = svc.Authenticate(context.Background(), p, "code", "verifier", "n-1")
+	require.NoError(t, err)
+}
+
+func TestOIDCService_Authenticate_RejectsForeignSignature(t *testing.T) {
+	svc, client := newOIDCServiceForTest(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, baseTestIDTokenClaims())
+	token.Header["kid"] = client.kid
+	client.idToken, err = token.SignedString(otherKey)
+	require.NoError(t, err)
+
+	_, err = svc.Authenticate(context.Background(), newTestOIDCProvider(), "code", "verifier", "n-1")
+	require.ErrorIs(t, err, ErrOIDCInvalidIDToken)
+}
+
+func TestOIDCService_Authenticate_UserInfoSubjectMismatch(t *testing.T) {
+	svc, client := newOIDCServiceForTest(t)
+	client.idToken = signTestIDToken(t, client, baseTestIDTokenClaims())
+	client.userInfo = `{"sub":"someone-else"}`
+
+	_, err := svc.Authenticate(context.Background(), newTestOIDCProvider(), "code", "verifier", "n-1")
+	require.ErrorIs(t, err, ErrOIDCUserInfoSubjectInvalid)
+}
+
+func TestOIDCService_Authenticate_EmailDomainRestriction(t *testing.T) {
+	svc, client := newOIDCServiceForTest(t)
+	claims := baseTestIDTokenClaims()
+	claims["email_verified"] = false
+	client.idToken = signTestIDToken(t, client, claims)
+
+	p := newTestOIDCProvider()
+	p.AllowedEmailDomains = []string{"corp.example"}
+	_, err := svc.Authenticate(context.Background(), p, "code", "verifier", "n-1")
+	require.ErrorIs(t, err, ErrOIDCEmailDomainNotAllowed)
+
+	claims["email_verified"] = true
+	client.idToken = signTestIDToken(t, client, claims)
+	_, err = svc.Authenticate(context.Background(), p, "code", "verifier", "n-1")
+	require.NoError(t, err)
+}
+
+func TestOIDCService_CachesDiscoveryAndJWKS(t *testing.T) {
+	svc, client := newOIDCServiceForTest(t)
+	client.idToken = signTestIDToken(t, client, baseTestIDTokenClaims())
+	p := newTestOIDCProvider()
+
+	for i := 0; i < 3; i++ {
+		_, err := svc.Authenticate(context.Background(), p, "code", "verifier", "n-1")
+		require.NoError(t, err)
+	}
+	require.Equal(t, 1, client.discoveryHits)
+	require.Equal(t, 1, client.jwksHits)
+}
+
+func TestOIDCService_BuildAuthorizeURL(t *testing.T) {
+	svc, _ := newOIDCServiceForTest(t)
+	authURL, err := svc.BuildAuthorizeURL(context.Background(), newTestOIDCProvider(), &OIDCAuthRequest{
+		State:         "s-1",
+		Nonce:         "n-1",
+		CodeChallenge: "challenge",
+	})
+	require.NoError(t, err)
+	require.Contains(t, authURL, testOIDCIssuer+"/authorize?")
+	require.Contains(t, authURL, "nonce=n-1")
+	require.Contains(t, authURL, "code_challenge=challenge")
+	require.Contains(t, authURL, "code_challenge_method=S256")
+	require.Contains(t, authURL, "scope=openid+email+profile")
+}
+
+func TestOIDCProvider_ResolveGrants(t *testing.T) {
+	p := newTestOIDCProvider()
+	p.DefaultGroupIDs = []int64{5}
+	p.GroupMappings = []OIDCGroupMapping{
+		{EmailDomain: "corp.example", GroupIDs: []int64{7, 5}},
+		{ClaimValue: "admins", GroupIDs: []int64{9}, Subscriptions: []DefaultSubscriptionSetting{{GroupID: 11, ValidityDays: 30}}},
+		{ClaimValue: "finance", GroupIDs: []int64{13}},
+		{Claim: "org.tier", ClaimValue: "gold", GroupIDs: []int64{15}},
+	}
+
+	identity := &OIDCIdentity{
+		Subject:       "user-123",
+		Email:         "alice@Corp.Example",
+		EmailVerified: true,
+		Claims:        `{"groups":["engineering","admins"],"org":{"tier":"gold"}}`,
+	}
+	grants := p.ResolveGrants(identity)
+	require.Equal(t, []int64{5, 7, 9, 15}, grants.AllowedGroupIDs)
+	require.Equal(t, []DefaultSubscriptionSetting{{GroupID: 11, ValidityDays: 30}}, grants.Subscriptions)
+
+	// 未验证邮箱不匹配邮箱域规则
+	identity.EmailVerified = false
+	grants = p.ResolveGrants(identity)
+	require.Equal(t, []int64{5, 9, 15}, grants.AllowedGroupIDs)
+}
+
+func TestOIDCProvider_LocalEmail(t *testing.T) {
+	p := newTestOIDCProvider()
+	identity := &OIDCIdentity{Subject: "user-123", Email: "Alice@corp.example", EmailVerified: true}
+
+	synthetic := p.LocalEmail(identity)
+	require.Regexp(t, `^oidc-corp-[0-9a-f]{24}@oidc-sso\.invalid$`, synthetic)
+	require.Equal(t, synthetic, p.LocalEmail(&OIDCIdentity{Subject: "user-123"}))
+	require.True(t, isReservedEmail(synthetic))
+
+	p.TrustEmail = true
+	require.Equal(t, "alice@corp.example", p.LocalEmail(identity))
+
+	identity.EmailVerified = false
+	require.Equal(t, synthetic, p.LocalEmail(identity))
+}
+
+func TestValidateOIDCProvider(t *testing.T) {
+	valid := newTestOIDCProvider()
+	normalizeOIDCProvider(valid)
+	require.NoError(t, validateOIDCProvider(valid))
+
+	cases := map[string]func(*OIDCProvider){
+		"bad slug":            func(p *OIDCProvider) { p.Slug = "Bad Slug" },
+		"no endpoints":        func(p *OIDCProvider) { p.IssuerURL = "" },
+		"missing secret":      func(p *OIDCProvider) { p.ClientSecret = "" },
+		"public without pkce": func(p *OIDCProvider) { p.TokenAuthMethod = OIDCTokenAuthNone; p.UsePKCE = false },
+		"bad redirect":        func(p *OIDCProvider) { p.RedirectURL = "javascript:alert(1)" },
+		"empty mapping":       func(p *OIDCProvider) { p.GroupMappings = []OIDCGroupMapping{{GroupIDs: []int64{1}}} },
+		"mapping grants none": func(p *OIDCProvider) { p.GroupMappings = []OIDCGroupMapping{{EmailDomain: "corp.example"}} },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			p := newTestOIDCProvider()
+			mutate(p)
+			normalizeOIDCProvider(p)
+			require.Error(t, validateOIDCProvider(p))
+		})
+	}
+}
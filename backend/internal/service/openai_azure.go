@@ -0,0 +1,108 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// DefaultAzureOpenAIAPIVersion Azure OpenAI 账号未配置 credentials.api_version 时使用的版本
+const DefaultAzureOpenAIAPIVersion = "2025-04-01-preview"
+
+// azureOpenAIDefault429Cooldown Azure 429 未携带 retry-after 时的默认冷却时间。
+// Azure 按分钟维度（TPM/RPM）限流，默认值远短于其他平台的 5 分钟。
+const azureOpenAIDefault429Cooldown = time.Minute
+
+// normalizeAzureOpenAIEndpoint 规范化 Azure 资源 endpoint，兼容用户粘贴带 /openai 后缀的地址
+func normalizeAzureOpenAIEndpoint(endpoint string) string {
+	normalized := strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	normalized = strings.TrimSuffix(normalized, "/openai")
+	return strings.TrimRight(normalized, "/")
+}
+
+// buildAzureOpenAIResponsesURL 组装 Azure Responses 端点：
+// {endpoint}/openai/responses{suffix}?api-version=...，部署名通过 body.model 传递。
+func buildAzureOpenAIResponsesURL(endpoint, suffix, apiVersion string) string {
+	target := appendOpenAIResponsesRequestPathSuffix(normalizeAzureOpenAIEndpoint(endpoint)+"/openai/responses", suffix)
+	return target + "?api-version=" + url.QueryEscape(apiVersion)
+}
+
+// buildAzureOpenAIDeploymentURL 组装部署级端点：
+// {endpoint}/openai/deployments/{deployment}/{operation}?api-version=...
+func buildAzureOpenAIDeploymentURL(endpoint, deployment, operation, apiVersion string) string {
+	return fmt.Sprintf("%s/openai/deployments/%s/%s?api-version=%s",
+		normalizeAzureOpenAIEndpoint(endpoint),
+		url.PathEscape(deployment),
+		strings.TrimLeft(operation, "/"),
+		url.QueryEscape(apiVersion),
+	)
+}
+
+// setOpenAIUpstreamAuth 注入上游认证：Azure 使用 api-key 头，其余账号使用 Bearer token
+func setOpenAIUpstreamAuth(req *http.Request, account *Account, token string) {
+	if account != nil && account.Type == AccountTypeAzure {
+		req.Header.Del("authorization")
+		req.Header.Set("api-key", token)
+		return
+	}
+	req.Header.Set("authorization", "Bearer "+token)
+}
+
+// ValidateAzureOpenAICredentials 校验 Azure OpenAI 账号凭证（管理后台创建/更新账号时调用）
+func ValidateAzureOpenAICredentials(platform string, credentials map[string]any) error {
+	if platform != PlatformOpenAI {
+		return infraerrors.BadRequest("AZURE_PLATFORM_INVALID", "azure account type is only supported on the openai platform")
+	}
+	baseURL, _ := credentials["base_url"].(string)
+	if strings.TrimSpace(baseURL) == "" {
+		return infraerrors.BadRequest("AZURE_ENDPOINT_REQUIRED", "credentials.base_url (Azure resource endpoint) is required")
+	}
+	apiKey, _ := credentials["api_key"].(string)
+	if strings.TrimSpace(apiKey) == "" {
+		return infraerrors.BadRequest("AZURE_API_KEY_REQUIRED", "credentials.api_key is required")
+	}
+	if raw, ok := credentials["api_version"]; ok && raw != nil {
+		if _, isString := raw.(string); !isString {
+			return infraerrors.BadRequest("AZURE_API_VERSION_INVALID", "credentials.api_version must be a string")
+		}
+	}
+	if raw, ok := credentials["deployments"]; ok && raw != nil {
+		deployments, isMap := raw.(map[string]any)
+		if !isMap {
+			return infraerrors.BadRequest("AZURE_DEPLOYMENTS_INVALID", "credentials.deployments must be an object of model -> deployment name")
+		}
+		for model, v := range deployments {
+			name, isString := v.(string)
+			if strings.TrimSpace(model) == "" || !isString || strings.TrimSpace(name) == "" {
+				return infraerrors.BadRequest("AZURE_DEPLOYMENTS_INVALID", fmt.Sprintf("invalid deployment mapping for model %q", model))
+			}
+		}
+	}
+	return nil
+}
+
+// calculateAzure429ResetTime 解析 Azure 429 的重试时间：
+// 优先 retry-after-ms（毫秒），其次 retry-after（秒），均缺失时返回 nil。
+func calculateAzure429ResetTime(headers http.Header, now time.Time) *time.Time {
+	if raw := strings.TrimSpace(headers.Get("retry-after-ms")); raw != "" {
+		if ms, err := strconv.ParseFloat(raw, 64); err == nil && ms > 0 {
+			resetAt := now.Add(time.Duration(ms * float64(time.Millisecond)))
+			return &resetAt
+		}
+	}
+	if raw := strings.TrimSpace(headers.Get("retry-after")); raw != "" {
+		if secs, err := strconv.ParseFloat(raw, 64); err == nil && secs > 0 {
+			resetAt := now.Add(time.Duration(secs * float64(time.Second)))
+			return &resetAt
+		}
+		if at, err := http.ParseTime(raw); err == nil && at.After(now) {
+			return &at
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func newAzureTestAccount() *Account {
+	return &Account{
+		ID:          11,
+		Platform:    PlatformOpenAI,
+		Type:        AccountTypeAzure,
+		Concurrency: 1,
+		Credentials: map[string]any{
+			"api_key":     "azure-key",
+			"base_url":    "https://contoso.openai.azure.com/openai/",
+			"api_version": "2025-03-01-preview",
+			"deployments": map[string]any{
+				"gpt-4.1":                "contoso-gpt41",
+				"text-embedding-3-small": "contoso-embed",
+				"gpt-5*":                 "contoso-gpt5",
+			},
+		},
+	}
+}
+
+func TestBuildAzureOpenAIURLs(t *testing.T) {
+	require.Equal(t,
+		"https://contoso.openai.azure.com/openai/responses?api-version=2025-04-01-preview",
+		buildAzureOpenAIResponsesURL("https://contoso.openai.azure.com/openai/", "", DefaultAzureOpenAIAPIVersion))
+	require.Equal(t,
+		"https://contoso.openai.azure.com/openai/responses/compact?api-version=v1",
+		buildAzureOpenAIResponsesURL("https://contoso.openai.azure.com", "/compact", "v1"))
+	require.Equal(t,
+		"https://contoso.openai.azure.com/openai/deployments/my%20embed/embeddings?api-version=2024-10-21",
+		buildAzureOpenAIDeploymentURL("https://contoso.openai.azure.com/", "my embed", "embeddings", "2024-10-21"))
+}
+
+func TestAzureAccountDeploymentResolution(t *testing.T) {
+	account := newAzureTestAccount()
+
+	require.Equal(t, "2025-03-01-preview", account.GetAzureOpenAIAPIVersion())
+	require.Equal(t, "azure-key", account.GetOpenAIApiKey())
+	require.Equal(t, "contoso-gpt41", normalizeOpenAIModelForUpstream(account, "gpt-4.1"))
+	require.Equal(t, "contoso-gpt5", normalizeOpenAIModelForUpstream(account, "gpt-5.1"))
+
+	require.True(t, account.IsModelSupported("gpt-4.1"))
+	require.True(t, account.IsModelSupported("gpt-5.1"))
+	require.False(t, account.IsModelSupported("o3"))
+
+	// 未配置部署映射时模型名即部署名，且不限制模型
+	delete(account.Credentials, "deployments")
+	delete(account.Credentials, "api_version")
+	require.Equal(t, DefaultAzureOpenAIAPIVersion, account.GetAzureOpenAIAPIVersion())
+	require.Equal(t, "o3", normalizeOpenAIModelForUpstream(account, "o3"))
+	require.True(t, account.IsModelSupported("o3"))
+}
+
+func TestOpenAIBuildUpstreamRequest_Azure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/responses", nil)
+	c.Request.Header.Set("Authorization", "Bearer client-key")
+
+	svc := &OpenAIGatewayService{cfg: &config.Config{}}
+	req, err := svc.buildUpstreamRequest(context.Background(), c, newAzureTestAccount(), []byte(`{"model":"contoso-gpt41"}`), "azure-key", true, "", false)
+	require.NoError(t, err)
+	require.Equal(t, "https://contoso.openai.azure.com/openai/responses?api-version=2025-03-01-preview", req.URL.String())
+	require.Equal(t, "azure-key", req.Header.Get("api-key"))
+	require.Empty(t, req.Header.Get("authorization"))
+}
+
+func TestOpenAIForwardEmbeddings_AzureUsesDeploymentURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	body := []byte(`{"model":"text-embedding-3-small","input":"hi"}`)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/embeddings", bytes.NewReader(body))
+
+	upstream := &httpUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body: io.NopCloser(strings.NewReader(
+			`{"object":"list","data":[],"model":"text-embedding-3-small","usage":{"prompt_tokens":2,"total_tokens":2}}`,
+		)),
+	}}
+	svc := &OpenAIGatewayService{cfg: &config.Config{}, httpUpstream: upstream}
+
+	result, err := svc.ForwardEmbeddings(context.Background(), c, newAzureTestAccount(), body)
+	require.NoError(t, err)
+	require.Equal(t,
+		"https://contoso.openai.azure.com/openai/deployments/contoso-embed/embeddings?api-version=2025-03-01-preview",
+		upstream.lastReq.URL.String())
+	require.Equal(t, "azure-key", upstream.lastReq.Header.Get("api-key"))
+	require.Empty(t, upstream.lastReq.Header.Get("authorization"))
+	require.Equal(t, "contoso-embed", gjson.GetBytes(upstream.lastBody, "model").String())
+	require.Equal(t, "text-embedding-3-small", result.Model)
+	require.Equal(t, 2, result.Usage.InputTokens)
+}
+
+func TestCalculateAzure429ResetTime(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	headers := http.Header{}
+	headers.Set("retry-after-ms", "1500")
+	headers.Set("retry-after", "30")
+	resetAt := calculateAzure429ResetTime(headers, now)
+	require.NotNil(t, resetAt)
+	require.Equal(t, now.Add(1500*time.Millisecond), *resetAt)
+
+	headers = http.Header{}
+	headers.Set("retry-after", "30")
+	resetAt = calculateAzure429ResetTime(headers, now)
+	require.NotNil(t, resetAt)
+	require.Equal(t, now.Add(30*time.Second), *resetAt)
+
+	require.Nil(t, calculateAzure429ResetTime(http.Header{}, now))
+}
+
+func TestValidateAzureOpenAICredentials(t *testing.T) {
+	valid := map[string]any{
+		"base_url":    "https://contoso.openai.azure.com",
+		"api_key":     "k",
+		"deployments": map[string]any{"gpt-4.1": "d1"},
+	}
+	require.NoError(t, ValidateAzureOpenAICredentials(PlatformOpenAI, valid))
+	require.Error(t, ValidateAzureOpenAICredentials(PlatformAnthropic, valid))
+	require.Error(t, ValidateAzureOpenAICredentials(PlatformOpenAI, map[string]any{"api_key": "k"}))
+	require.Error(t, ValidateAzureOpenAICredentials(PlatformOpenAI, map[string]any{
+		"base_url":    "https://contoso.openai.azure.com",
+		"api_key":     "k",
+		"deployments": map[string]any{"gpt-4.1": ""},
+	}))
+}
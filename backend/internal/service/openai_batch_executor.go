@@ -0,0 +1,839 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/ctxkey"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+const (
+	// openAIBatchProgressInterval 批次进度落库的最小间隔（同时作为心跳与跨实例取消的检查周期）
+	openAIBatchProgressInterval = 30 * time.Second
+	// openAIBatchMaxRetryBackoff 单条请求重试退避上限
+	openAIBatchMaxRetryBackoff = 30 * time.Second
+)
+
+// openAIBatchRequest 输入文件中的一行
+type openAIBatchRequest struct {
+	CustomID string          `json:"custom_id"`
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body"`
+}
+
+// openAIBatchResultLine 输出/错误文件中的一行
+type openAIBatchResultLine struct {
+	ID       string                     `json:"id"`
+	CustomID string                     `json:"custom_id"`
+	Response *openAIBatchResultResponse `json:"response"`
+	Error    *openAIBatchResultError    `json:"error"`
+}
+
+type openAIBatchResultResponse struct {
+	StatusCode int             `json:"status_code"`
+	RequestID  string          `json:"request_id"`
+	Body       json.RawMessage `json:"body"`
+}
+
+type openAIBatchResultError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// succeeded 2xx 响应写入输出文件，其余写入错误文件
+func (l *openAIBatchResultLine) succeeded() bool {
+	return l.Response != nil && l.Response.StatusCode >= 200 && l.Response.StatusCode < 300
+}
+
+func newOpenAIBatchErrorLine(customID, code, message string) openAIBatchResultLine {
+	return openAIBatchResultLine{
+		ID:       newOpenAIObjectID(openAIBatchRequestIDPrefix),
+		CustomID: customID,
+		Error:    &openAIBatchResultError{Code: code, Message: message},
+	}
+}
+
+func newOpenAIBatchResponseLine(customID string, status int, requestID string, body []byte) openAIBatchResultLine {
+	if !gjson.ValidBytes(body) {
+		msg := strings.TrimSpace(string(body))
+		if msg == "" {
+			msg = fmt.Sprintf("Upstream request failed with status %d", status)
+		}
+		body, _ = json.Marshal(map[string]any{
+			"error": map[string]any{"type": "api_error", "message": sanitizeUpstreamErrorMessage(msg)},
+		})
+	}
+	return openAIBatchResultLine{
+		ID:       newOpenAIObjectID(openAIBatchRequestIDPrefix),
+		CustomID: customID,
+		Response: &openAIBatchResultResponse{StatusCode: status, RequestID: requestID, Body: json.RawMessage(body)},
+	}
+}
+
+// parseOpenAIBatchInput 校验输入文件：每行为 JSON 对象，custom_id 唯一，method 为 POST，
+// url 与批次 endpoint 一致，body 为包含 model 的对象且不能开启 stream。
+func parseOpenAIBatchInput(r io.Reader, endpoint string, maxRequests int) ([]openAIBatchRequest, []OpenAIBatchError, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), openAIBatchLineMaxBytes)
+
+	var (
+		requests []openAIBatchRequest
+		errs     []OpenAIBatchError
+	)
+	seen := make(map[string]struct{})
+	addErr := func(line int, code, param, message string) {
+		if len(errs) >= openAIBatchMaxValidationErrors {
+			return
+		}
+		e := OpenAIBatchError{Code: code, Message: message, Line: &line}
+		if param != "" {
+			e.Param = &param
+		}
+		errs = append(errs, e)
+	}
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := strings.TrimSpace(scanner.Text())
+		if raw == "" {
+			continue
+		}
+		var req openAIBatchRequest
+		if !gjson.Valid(raw) || !gjson.Parse(raw).IsObject() || json.Unmarshal([]byte(raw), &req) != nil {
+			addErr(lineNo, "invalid_json_line", "", "This line is not parseable as valid JSON.")
+			continue
+		}
+		switch {
+		case req.CustomID == "":
+			addErr(lineNo, "missing_required_parameter", "custom_id", "Missing required parameter: 'custom_id'.")
+			continue
+		case !strings.EqualFold(req.Method, http.MethodPost):
+			addErr(lineNo, "invalid_value", "method", "The method must be 'POST'.")
+			continue
+		case req.URL != endpoint:
+			addErr(lineNo, "mismatched_endpoint", "url", fmt.Sprintf("The url must match the batch endpoint '%s'.", endpoint))
+			continue
+		case !gjson.ParseBytes(req.Body).IsObject():
+			addErr(lineNo, "invalid_value", "body", "The body must be a JSON object.")
+			continue
+		case gjson.GetBytes(req.Body, "model").String() == "":
+			addErr(lineNo, "missing_required_parameter", "body.model", "Missing required parameter: 'body.model'.")
+			continue
+		case gjson.GetBytes(req.Body, "stream").Bool():
+			addErr(lineNo, "invalid_value", "body.stream", "Streaming is not supported in batch requests.")
+			continue
+		}
+		if _, dup := seen[req.CustomID]; dup {
+			addErr(lineNo, "duplicate_custom_id", "custom_id", fmt.Sprintf("The custom_id '%s' is duplicated.", req.CustomID))
+			continue
+		}
+		seen[req.CustomID] = struct{}{}
+		requests = append(requests, req)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			addErr(lineNo+1, "line_too_long", "", "This line exceeds the maximum supported size.")
+			return nil, errs, nil
+		}
+		return nil, nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errs, nil
+	}
+	if len(requests) == 0 {
+		return nil, []OpenAIBatchError{{Code: "empty_file", Message: "The input file does not contain any requests."}}, nil
+	}
+	if maxRequests > 0 && len(requests) > maxRequests {
+		return nil, []OpenAIBatchError{{Code: "too_many_requests", Message: fmt.Sprintf("The input file can contain at most %d requests.", maxRequests)}}, nil
+	}
+	return requests, nil, nil
+}
+
+// openAIBatchJob 本实例执行中的批次。batch 由 mu 保护，取消与进度落库共用同一份状态。
+type openAIBatchJob struct {
+	mu          sync.Mutex
+	persistMu   sync.Mutex // 串行化落库，保证数据库中的状态按快照顺序写入
+	batch       *OpenAIBatch
+	caller      *OpenAIBatchCaller
+	output      *os.File
+	errors      *os.File
+	lastPersist time.Time
+
+	cancelOnce sync.Once
+	cancelCh   chan struct{}
+}
+
+func (s *OpenAIBatchService) startBatch(batch *OpenAIBatch, caller *OpenAIBatchCaller) {
+	job := &openAIBatchJob{
+		batch:       batch,
+		caller:      caller,
+		lastPersist: time.Now(),
+		cancelCh:    make(chan struct{}),
+	}
+	s.mu.Lock()
+	s.running[batch.ID] = job
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, batch.ID)
+			s.mu.Unlock()
+		}()
+		s.runBatch(job)
+	}()
+}
+
+func (s *OpenAIBatchService) runningJob(id string) *openAIBatchJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running[id]
+}
+
+// runBatch 校验输入文件后以全局 worker 池逐条执行，结果追加写入本地中间文件，结束后上传为输出/错误文件
+func (s *OpenAIBatchService) runBatch(job *openAIBatchJob) {
+	batchID := job.batch.ID
+	log := logger.L().With(zap.String("component", "service.openai_batch"), zap.String("batch_id", batchID))
+
+	requests, ok := s.validateBatch(job, log)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithDeadline(s.rootCtx, job.batch.ExpiresAt)
+	defer cancel()
+
+	if err := os.MkdirAll(s.workDir(), 0o755); err != nil {
+		log.Error("openai_batch.work_dir_create_failed", zap.Error(err))
+		return
+	}
+	output, err := os.OpenFile(s.outputWorkPath(batchID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		log.Error("openai_batch.open_work_file_failed", zap.Error(err))
+		return
+	}
+	defer func() { _ = output.Close() }()
+	errFile, err := os.OpenFile(s.errorWorkPath(batchID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		log.Error("openai_batch.open_work_file_failed", zap.Error(err))
+		return
+	}
+	defer func() { _ = errFile.Close() }()
+	job.output = output
+	job.errors = errFile
+
+	// 心跳：长耗时请求期间也定期刷新 updated_at，并感知其他实例发起的取消
+	stopHeartbeat := make(chan struct{})
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		ticker := time.NewTicker(openAIBatchProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				job.persist(s.batchRepo, true)
+			case <-stopHeartbeat:
+				return
+			}
+		}
+	}()
+
+	var inflight sync.WaitGroup
+	expired := false
+dispatch:
+	for _, req := range requests {
+		acquired := false
+		select {
+		case s.workers <- struct{}{}:
+			acquired = true
+		case <-job.cancelCh:
+		case <-ctx.Done():
+		}
+		if s.rootCtx.Err() != nil {
+			// 网关关闭：保留已完成结果，剩余请求由维护任务标记为失败
+			if acquired {
+				<-s.workers
+			}
+			break dispatch
+		}
+		if job.cancelled() {
+			// 已取消：未开始的请求不写入结果
+			if acquired {
+				<-s.workers
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			if acquired {
+				<-s.workers
+			}
+			expired = true
+			job.write(newOpenAIBatchErrorLine(req.CustomID, "batch_expired",
+				"This request could not be executed before the completion window expired."), s.batchRepo)
+			continue
+		}
+
+		inflight.Add(1)
+		go func(req openAIBatchRequest) {
+			defer inflight.Done()
+			defer func() { <-s.workers }()
+			line, ok := s.executeItem(ctx, job, req)
+			if !ok {
+				return
+			}
+			job.write(line, s.batchRepo)
+		}(req)
+	}
+	inflight.Wait()
+	close(stopHeartbeat)
+	<-heartbeatDone
+
+	if s.rootCtx.Err() != nil {
+		job.persist(s.batchRepo, true)
+		return
+	}
+
+	final := job.snapshot()
+	finalStatus := OpenAIBatchStatusCompleted
+	switch {
+	case job.cancelled():
+		finalStatus = OpenAIBatchStatusCancelled
+	case expired:
+		finalStatus = OpenAIBatchStatusExpired
+	}
+	if err := s.finalizeBatch(s.rootCtx, final, finalStatus, nil); err != nil {
+		log.Error("openai_batch.finalize_failed", zap.Error(err))
+		return
+	}
+	job.mu.Lock()
+	job.batch = final
+	job.mu.Unlock()
+	log.Info("openai_batch.ended",
+		zap.String("status", final.Status),
+		zap.Int("total", final.Counts.Total),
+		zap.Int("completed", final.Counts.Completed),
+		zap.Int("failed", final.Counts.Failed),
+	)
+}
+
+// validateBatch 读取并校验输入文件；校验失败时批次直接结束为 failed
+func (s *OpenAIBatchService) validateBatch(job *openAIBatchJob, log *zap.Logger) ([]openAIBatchRequest, bool) {
+	batch := job.snapshot()
+	ctx, cancel := context.WithTimeout(s.rootCtx, 10*time.Minute)
+	defer cancel()
+
+	var (
+		requests []openAIBatchRequest
+		errs     []OpenAIBatchError
+	)
+	rc, err := s.storage.Open(ctx, batch.InputFileID)
+	if err == nil {
+		requests, errs, err = parseOpenAIBatchInput(rc, batch.Endpoint, s.cfg.Gateway.OpenAIBatches.MaxRequests)
+		_ = rc.Close()
+	}
+	if s.rootCtx.Err() != nil {
+		return nil, false
+	}
+	if err != nil {
+		log.Warn("openai_batch.read_input_failed", zap.Error(err))
+		errs = []OpenAIBatchError{{Code: "input_file_unreadable", Message: "The input file could not be read."}}
+	}
+
+	now := time.Now()
+	job.mu.Lock()
+	if len(errs) > 0 {
+		job.batch.Status = OpenAIBatchStatusFailed
+		job.batch.Errors = errs
+		job.batch.FailedAt = &now
+	} else {
+		job.batch.Counts.Total = len(requests)
+		if job.batch.Status != OpenAIBatchStatusCancelling {
+			job.batch.Status = OpenAIBatchStatusInProgress
+		}
+		job.batch.InProgressAt = &now
+	}
+	job.mu.Unlock()
+	job.persist(s.batchRepo, true)
+
+	if len(errs) > 0 {
+		log.Info("openai_batch.validation_failed", zap.Int("error_count", len(errs)))
+		return nil, false
+	}
+	return requests, true
+}
+
+// executeItem 选择账号并通过 OpenAIGatewayService 执行单条请求。
+// 网关关闭导致中断时返回 ok=false，该请求由维护任务补记为失败。
+func (s *OpenAIBatchService) executeItem(ctx context.Context, job *openAIBatchJob, req openAIBatchRequest) (openAIBatchResultLine, bool) {
+	apiKey := job.caller.APIKey
+	reqModel := gjson.GetBytes(req.Body, "model").String()
+	if !apiKey.IsModelAllowed(reqModel) {
+		return newOpenAIBatchResponseLine(req.CustomID, http.StatusForbidden, "", openAIBatchErrorBody(
+			"permission_error", fmt.Sprintf("Model %q is not allowed for this API key", reqModel))), true
+	}
+	if s.billingCacheService != nil {
+		if err := s.billingCacheService.CheckBillingEligibility(ctx, apiKey.User, apiKey, apiKey.Group, job.caller.Subscription); err != nil {
+			return newOpenAIBatchErrorLine(req.CustomID, "billing_error", infraerrors.Message(err)), true
+		}
+	}
+
+	// 分组安全策略：与在线端点一致，在转发之前检查每条请求
+	body := []byte(req.Body)
+	guardProtocol := GuardrailProtocolOpenAIResponses
+	if req.URL == "/v1/chat/completions" {
+		guardProtocol = GuardrailProtocolOpenAIChatCompletions
+	}
+	guard := s.guardrailService.Inspect(ctx, &GuardrailRequest{
+		APIKey:   apiKey,
+		Protocol: guardProtocol,
+		Model:    reqModel,
+		Body:     body,
+	})
+	if guard.Blocked {
+		return newOpenAIBatchResponseLine(req.CustomID, http.StatusBadRequest, "", openAIBatchErrorBody(
+			"invalid_request_error", guard.BlockedMessage())), true
+	}
+	body = guard.Body
+
+	channelMapping, _ := s.gatewayService.ResolveChannelMappingAndRestrict(ctx, apiKey.GroupID, reqModel)
+	if channelMapping.Mapped {
+		body = s.gatewayService.ReplaceModelInBody(body, channelMapping.MappedModel)
+	}
+	defaultMappedModel := ""
+	if apiKey.Group != nil {
+		defaultMappedModel = strings.TrimSpace(apiKey.Group.DefaultMappedModel)
+	}
+
+	maxRetries := s.cfg.Gateway.OpenAIBatches.MaxRetries
+	failedAccountIDs := make(map[int64]struct{})
+	var last openAIBatchResultLine
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := min(time.Second<<(attempt-1), openAIBatchMaxRetryBackoff)
+			select {
+			case <-ctx.Done():
+				return s.interruptedLine(ctx, req.CustomID, last)
+			case <-time.After(backoff):
+			}
+		}
+
+		selection, _, err := s.gatewayService.SelectAccountWithScheduler(ctx, apiKey.GroupID, "", "", reqModel, failedAccountIDs, OpenAIUpstreamTransportAny)
+		if err != nil || selection == nil || selection.Account == nil {
+			// 排除的账号可能已恢复：清空后退避重试
+			clear(failedAccountIDs)
+			last = newOpenAIBatchErrorLine(req.CustomID, "no_available_accounts", "No available accounts")
+			continue
+		}
+		account := selection.Account
+		release, err := acquireBatchAccountSlot(ctx, s.concurrencyService, selection)
+		if err != nil {
+			return s.interruptedLine(ctx, req.CustomID, last)
+		}
+
+		c, recorder := newOpenAIBatchGinContext(ctx, req.URL, apiKey)
+		var result *OpenAIForwardResult
+		if req.URL == "/v1/chat/completions" {
+			result, err = s.gatewayService.ForwardAsChatCompletions(ctx, c, account, body, "", defaultMappedModel)
+		} else {
+			result, err = s.gatewayService.Forward(ctx, c, account, body)
+		}
+		if release != nil {
+			release()
+		}
+		if s.rootCtx.Err() != nil {
+			return openAIBatchResultLine{}, false
+		}
+
+		if err != nil {
+			s.gatewayService.ReportOpenAIAccountScheduleResult(account.ID, false, nil)
+			var failoverErr *UpstreamFailoverError
+			if errors.As(err, &failoverErr) {
+				if !failoverErr.RetryableOnSameAccount {
+					failedAccountIDs[account.ID] = struct{}{}
+				}
+				last = newOpenAIBatchResponseLine(req.CustomID, failoverErr.StatusCode, "", failoverErr.ResponseBody)
+				continue
+			}
+			status := recorder.status
+			if status < http.StatusBadRequest {
+				status = http.StatusBadGateway
+			}
+			last = newOpenAIBatchResponseLine(req.CustomID, status, "", recorder.body.Bytes())
+			if status == http.StatusTooManyRequests || status >= http.StatusInternalServerError {
+				continue
+			}
+			return last, true
+		}
+		s.gatewayService.ReportOpenAIAccountScheduleResult(account.ID, true, result.FirstTokenMs)
+
+		s.recordItemUsage(job, req.CustomID, account, result, body, channelMapping.ToUsageFields(reqModel, result.UpstreamModel))
+		return newOpenAIBatchResponseLine(req.CustomID, recorder.status, result.RequestID, recorder.body.Bytes()), true
+	}
+	return last, true
+}
+
+// interruptedLine 等待期间 ctx 结束：网关关闭时不写结果，批次过期时记为 batch_expired
+func (s *OpenAIBatchService) interruptedLine(ctx context.Context, customID string, last openAIBatchResultLine) (openAIBatchResultLine, bool) {
+	if s.rootCtx.Err() != nil {
+		return openAIBatchResultLine{}, false
+	}
+	if last.CustomID != "" && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return last, true
+	}
+	return newOpenAIBatchErrorLine(customID, "batch_expired",
+		"This request could not be executed before the completion window expired."), true
+}
+
+// recordItemUsage 按批处理折扣记录单条请求的用量
+func (s *OpenAIBatchService) recordItemUsage(
+	job *openAIBatchJob,
+	customID string,
+	account *Account,
+	result *OpenAIForwardResult,
+	body []byte,
+	channelFields ChannelUsageFields,
+) {
+	caller := job.caller
+	ctx := context.WithValue(context.Background(), ctxkey.ClientRequestID, job.batch.ID+":"+customID)
+	input := &OpenAIRecordUsageInput{
+		Result:             result,
+		APIKey:             caller.APIKey,
+		User:               caller.APIKey.User,
+		Account:            account,
+		Subscription:       caller.Subscription,
+		InboundEndpoint:    "/v1/batches",
+		UpstreamEndpoint:   "/v1/responses",
+		UserAgent:          caller.UserAgent,
+		IPAddress:          caller.IPAddress,
+		RequestPayloadHash: HashUsageRequestPayload(body),
+		BatchDiscount:      s.discountMultiplier(caller.APIKey.Group),
+		ChannelUsageFields: channelFields,
+	}
+	if s.apiKeyService != nil {
+		input.APIKeyService = s.apiKeyService
+	}
+	if err := s.gatewayService.RecordUsage(ctx, input); err != nil {
+		logger.L().Error("openai_batch.record_usage_failed",
+			zap.String("batch_id", job.batch.ID),
+			zap.String("custom_id", customID),
+			zap.Int64("account_id", account.ID),
+			zap.Error(err),
+		)
+	}
+}
+
+func openAIBatchErrorBody(errType, message string) []byte {
+	body, _ := json.Marshal(map[string]any{
+		"error": map[string]any{"type": errType, "message": message},
+	})
+	return body
+}
+
+// newOpenAIBatchGinContext 构造 Forward 所需的 gin 上下文，响应写入内存 writer
+func newOpenAIBatchGinContext(ctx context.Context, path string, apiKey *APIKey) (*gin.Context, *batchResponseWriter) {
+	recorder := newBatchResponseWriter()
+	c, _ := gin.CreateTestContext(recorder)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, "http://localhost"+path, nil)
+	req.Header.Set("content-type", "application/json")
+	c.Request = req
+	c.Set("api_key", apiKey)
+	return c, recorder
+}
+
+// finalizeBatch 上传中间结果为输出/错误文件并写入最终状态。
+// extraErrors 为需要补记到错误文件的请求（中断回收时使用）。
+func (s *OpenAIBatchService) finalizeBatch(ctx context.Context, batch *OpenAIBatch, finalStatus string, extraErrors []openAIBatchResultLine) error {
+	now := time.Now()
+	batch.Status = OpenAIBatchStatusFinalizing
+	batch.FinalizingAt = &now
+	if _, err := s.batchRepo.Update(ctx, batch); err != nil {
+		return err
+	}
+
+	if len(extraErrors) > 0 {
+		f, err := os.OpenFile(s.errorWorkPath(batch.ID), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("open openai batch error file: %w", err)
+		}
+		w := bufio.NewWriter(f)
+		for i := range extraErrors {
+			data, _ := json.Marshal(&extraErrors[i])
+			_, _ = w.Write(append(data, '\n'))
+		}
+		err = w.Flush()
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return fmt.Errorf("write openai batch error file: %w", err)
+		}
+	}
+
+	outputID, err := s.uploadWorkFile(ctx, batch, s.outputWorkPath(batch.ID), "output")
+	if err != nil {
+		return err
+	}
+	errorID, err := s.uploadWorkFile(ctx, batch, s.errorWorkPath(batch.ID), "error")
+	if err != nil {
+		return err
+	}
+	batch.OutputFileID = outputID
+	batch.ErrorFileID = errorID
+
+	now = time.Now()
+	batch.Status = finalStatus
+	switch finalStatus {
+	case OpenAIBatchStatusCancelled:
+		batch.CancelledAt = &now
+	case OpenAIBatchStatusExpired:
+		batch.ExpiredAt = &now
+	default:
+		batch.CompletedAt = &now
+	}
+	if _, err := s.batchRepo.Update(ctx, batch); err != nil {
+		return err
+	}
+	s.removeWorkFiles(batch.ID)
+	return nil
+}
+
+// uploadWorkFile 将非空的中间结果文件保存为 batch_output 文件，返回文件 ID
+func (s *OpenAIBatchService) uploadWorkFile(ctx context.Context, batch *OpenAIBatch, path, kind string) (*string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("open openai batch %s file: %w", kind, err)
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat openai batch %s file: %w", kind, err)
+	}
+	if info.Size() == 0 {
+		return nil, nil
+	}
+
+	id := newOpenAIObjectID(openAIFileIDPrefix)
+	n, err := s.storage.Put(ctx, id, f)
+	if err != nil {
+		return nil, fmt.Errorf("store openai batch %s file: %w", kind, err)
+	}
+	file, err := s.createFileRecord(ctx, batch.UserID, batch.APIKeyID, id, OpenAIFilePurposeBatchOutput,
+		fmt.Sprintf("%s_%s.jsonl", batch.ID, kind), n)
+	if err != nil {
+		return nil, err
+	}
+	return &file.ID, nil
+}
+
+// recoverInterruptedBatch 结束因网关重启而中断的批次：
+// 校验阶段中断的批次记为 failed；执行阶段未产出结果的请求以 batch_interrupted 写入错误文件后正常结束。
+func (s *OpenAIBatchService) recoverInterruptedBatch(batch *OpenAIBatch) {
+	ctx, cancel := context.WithTimeout(s.rootCtx, 10*time.Minute)
+	defer cancel()
+	log := logger.L().With(zap.String("component", "service.openai_batch"), zap.String("batch_id", batch.ID))
+
+	if batch.Status == OpenAIBatchStatusValidating {
+		now := time.Now()
+		batch.Status = OpenAIBatchStatusFailed
+		batch.FailedAt = &now
+		batch.Errors = []OpenAIBatchError{{Code: "batch_interrupted", Message: "Batch validation was interrupted. Please create the batch again."}}
+		if _, err := s.batchRepo.Update(ctx, batch); err != nil {
+			log.Warn("openai_batch.recover_failed", zap.Error(err))
+		}
+		return
+	}
+	// 上传已完成但最终状态未写入
+	if batch.Status == OpenAIBatchStatusFinalizing && (batch.OutputFileID != nil || batch.ErrorFileID != nil) {
+		now := time.Now()
+		batch.Status = OpenAIBatchStatusCompleted
+		batch.CompletedAt = &now
+		if _, err := s.batchRepo.Update(ctx, batch); err != nil {
+			log.Warn("openai_batch.recover_failed", zap.Error(err))
+		}
+		return
+	}
+
+	extra, err := s.collectInterruptedLines(ctx, batch)
+	if err != nil {
+		log.Warn("openai_batch.recover_failed", zap.Error(err))
+		return
+	}
+	finalStatus := OpenAIBatchStatusCompleted
+	if batch.Status == OpenAIBatchStatusCancelling {
+		finalStatus = OpenAIBatchStatusCancelled
+		extra = nil
+	}
+	if err := s.finalizeBatch(ctx, batch, finalStatus, extra); err != nil {
+		log.Warn("openai_batch.recover_failed", zap.Error(err))
+		return
+	}
+	log.Info("openai_batch.recovered_interrupted",
+		zap.String("status", batch.Status),
+		zap.Int("completed", batch.Counts.Completed),
+		zap.Int("failed", batch.Counts.Failed),
+	)
+}
+
+// collectInterruptedLines 根据中间结果重新统计计数，并为输入文件中尚无结果的请求生成 batch_interrupted 错误行
+func (s *OpenAIBatchService) collectInterruptedLines(ctx context.Context, batch *OpenAIBatch) ([]openAIBatchResultLine, error) {
+	done := make(map[string]struct{})
+	counts := OpenAIBatchRequestCounts{}
+	for _, path := range []string{s.outputWorkPath(batch.ID), s.errorWorkPath(batch.ID)} {
+		isOutput := path == s.outputWorkPath(batch.ID)
+		f, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("open openai batch work file: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), openAIBatchLineMaxBytes)
+		for scanner.Scan() {
+			done[gjson.GetBytes(scanner.Bytes(), "custom_id").String()] = struct{}{}
+			if isOutput {
+				counts.Completed++
+			} else {
+				counts.Failed++
+			}
+		}
+		scanErr := scanner.Err()
+		_ = f.Close()
+		if scanErr != nil {
+			return nil, fmt.Errorf("read openai batch work file: %w", scanErr)
+		}
+	}
+
+	rc, err := s.storage.Open(ctx, batch.InputFileID)
+	if err != nil {
+		return nil, fmt.Errorf("open openai batch input file: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+	var extra []openAIBatchResultLine
+	scanner := bufio.NewScanner(rc)
+	scanner.Buffer(make([]byte, 0, 64*1024), openAIBatchLineMaxBytes)
+	for scanner.Scan() {
+		customID := gjson.GetBytes(scanner.Bytes(), "custom_id").String()
+		if customID == "" {
+			continue
+		}
+		if _, ok := done[customID]; ok {
+			continue
+		}
+		done[customID] = struct{}{}
+		extra = append(extra, newOpenAIBatchErrorLine(customID, "batch_interrupted",
+			"Batch execution was interrupted before this request was processed."))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read openai batch input file: %w", err)
+	}
+	if batch.Status != OpenAIBatchStatusCancelling {
+		counts.Failed += len(extra)
+	}
+	counts.Total = max(batch.Counts.Total, counts.Completed+counts.Failed)
+	batch.Counts = counts
+	return extra, nil
+}
+
+// write 将结果写入输出或错误文件并更新计数；达到落库间隔时持久化进度
+func (j *openAIBatchJob) write(line openAIBatchResultLine, repo OpenAIBatchRepository) {
+	data, err := json.Marshal(&line)
+	if err != nil {
+		line = newOpenAIBatchErrorLine(line.CustomID, "internal_error", "Failed to encode result")
+		data, _ = json.Marshal(&line)
+	}
+
+	j.mu.Lock()
+	target := j.errors
+	if line.succeeded() {
+		target = j.output
+		j.batch.Counts.Completed++
+	} else {
+		j.batch.Counts.Failed++
+	}
+	if _, err := target.Write(append(data, '\n')); err != nil {
+		logger.L().Error("openai_batch.write_result_failed", zap.String("batch_id", j.batch.ID), zap.Error(err))
+	}
+	j.mu.Unlock()
+
+	j.persist(repo, false)
+}
+
+// persist 持久化批次进度；force=false 时按 openAIBatchProgressInterval 节流。
+// 数据库中的状态已被其他实例标记为 cancelling 时，在本地响应取消。
+func (j *openAIBatchJob) persist(repo OpenAIBatchRepository, force bool) {
+	j.persistMu.Lock()
+	defer j.persistMu.Unlock()
+
+	j.mu.Lock()
+	if !force && time.Since(j.lastPersist) < openAIBatchProgressInterval {
+		j.mu.Unlock()
+		return
+	}
+	j.lastPersist = time.Now()
+	snapshot := *j.batch
+	j.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	status, err := repo.Update(ctx, &snapshot)
+	if err != nil {
+		logger.L().Warn("openai_batch.persist_failed", zap.String("batch_id", snapshot.ID), zap.Error(err))
+		return
+	}
+	if status == OpenAIBatchStatusCancelling && snapshot.Status != OpenAIBatchStatusCancelling {
+		j.requestCancel()
+	}
+}
+
+func (j *openAIBatchJob) snapshot() *OpenAIBatch {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	b := *j.batch
+	return &b
+}
+
+// requestCancel 标记取消：未开始的请求不再执行，执行中的请求完成后批次结束为 cancelled
+func (j *openAIBatchJob) requestCancel() {
+	j.cancelOnce.Do(func() {
+		j.mu.Lock()
+		now := time.Now()
+		if !j.batch.IsTerminal() && j.batch.Status != OpenAIBatchStatusFinalizing {
+			j.batch.Status = OpenAIBatchStatusCancelling
+			j.batch.CancellingAt = &now
+		}
+		j.mu.Unlock()
+		close(j.cancelCh)
+	})
+}
+
+func (j *openAIBatchJob) cancelled() bool {
+	select {
+	case <-j.cancelCh:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,656 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 文件用途
+const (
+	OpenAIFilePurposeBatch       = "batch"        // 批处理输入文件（用户上传）
+	OpenAIFilePurposeBatchOutput = "batch_output" // 批处理输出/错误文件（网关生成）
+)
+
+// 批次状态（与 OpenAI Batch.status 一致）
+const (
+	OpenAIBatchStatusValidating = "validating"
+	OpenAIBatchStatusFailed     = "failed"
+	OpenAIBatchStatusInProgress = "in_progress"
+	OpenAIBatchStatusFinalizing = "finalizing"
+	OpenAIBatchStatusCompleted  = "completed"
+	OpenAIBatchStatusExpired    = "expired"
+	OpenAIBatchStatusCancelling = "cancelling"
+	OpenAIBatchStatusCancelled  = "cancelled"
+)
+
+const (
+	openAIFileIDPrefix         = "file-"
+	openAIBatchIDPrefix        = "batch_"
+	openAIBatchRequestIDPrefix = "batch_req_"
+
+	// OpenAIBatchCompletionWindow 目前 OpenAI 仅支持 24h 完成窗口
+	OpenAIBatchCompletionWindow = "24h"
+	openAIBatchTTL              = 24 * time.Hour
+
+	// openAIBatchMaintenanceInterval 中断批次回收与过期文件清理的周期
+	openAIBatchMaintenanceInterval = 5 * time.Minute
+	// openAIBatchStaleAfter 未结束批次超过该时长无进度心跳视为执行中断（实例重启/崩溃）
+	openAIBatchStaleAfter = 5 * time.Minute
+	// openAIBatchLineMaxBytes 输入/结果文件单行读取上限
+	openAIBatchLineMaxBytes = 64 << 20
+	// openAIBatchMaxValidationErrors 输入文件校验最多返回的错误数
+	openAIBatchMaxValidationErrors = 100
+
+	openAIBatchMetadataMaxPairs    = 16
+	openAIBatchMetadataMaxKeyLen   = 64
+	openAIBatchMetadataMaxValueLen = 512
+
+	OpenAIFileListDefaultLimit  = 10000
+	OpenAIFileListMaxLimit      = 10000
+	OpenAIBatchListDefaultLimit = 20
+	OpenAIBatchListMaxLimit     = 100
+)
+
+// openAIBatchEndpoints 批处理支持的目标端点
+var openAIBatchEndpoints = map[string]bool{
+	"/v1/chat/completions": true,
+	"/v1/responses":        true,
+}
+
+var (
+	ErrOpenAIBatchesDisabled       = infraerrors.NotFound("OPENAI_BATCHES_DISABLED", "files and batches are not enabled")
+	ErrOpenAIFileNotFound          = infraerrors.NotFound("OPENAI_FILE_NOT_FOUND", "No such File object")
+	ErrOpenAIFileInvalidPurpose    = infraerrors.BadRequest("OPENAI_FILE_INVALID_PURPOSE", "purpose must be 'batch'")
+	ErrOpenAIFileEmpty             = infraerrors.BadRequest("OPENAI_FILE_EMPTY", "file must not be empty")
+	ErrOpenAIFileNotDeletable      = infraerrors.BadRequest("OPENAI_FILE_NOT_DELETABLE", "file is used by a batch that is still running")
+	ErrOpenAIBatchNotFound         = infraerrors.NotFound("OPENAI_BATCH_NOT_FOUND", "No such Batch object")
+	ErrOpenAIBatchInvalidEndpoint  = infraerrors.BadRequest("OPENAI_BATCH_INVALID_ENDPOINT", "endpoint must be one of: /v1/chat/completions, /v1/responses")
+	ErrOpenAIBatchInvalidWindow    = infraerrors.BadRequest("OPENAI_BATCH_INVALID_COMPLETION_WINDOW", "completion_window must be '24h'")
+	ErrOpenAIBatchInvalidInputFile = infraerrors.BadRequest("OPENAI_BATCH_INVALID_INPUT_FILE", "input_file_id must reference a file with purpose 'batch'")
+	ErrOpenAIBatchInvalidMetadata  = infraerrors.BadRequest("OPENAI_BATCH_INVALID_METADATA", "metadata supports up to 16 pairs with keys up to 64 and values up to 512 characters")
+	ErrOpenAIBatchNotCancellable   = infraerrors.Conflict("OPENAI_BATCH_NOT_CANCELLABLE", "batch has already finished and cannot be cancelled")
+)
+
+// OpenAIFile 上传或生成的文件
+type OpenAIFile struct {
+	ID         string
+	UserID     int64
+	APIKeyID   int64
+	Purpose    string
+	Filename   string
+	Bytes      int64
+	StorageKey string
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+}
+
+// OpenAIFileListParams 文件列表参数（after 游标分页）
+type OpenAIFileListParams struct {
+	Purpose string
+	After   string
+	Limit   int
+	Asc     bool
+}
+
+// OpenAIBatchRequestCounts 批次请求计数（与 OpenAI request_counts 一致）
+type OpenAIBatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// OpenAIBatchError 输入文件校验错误
+type OpenAIBatchError struct {
+	Code    string  `json:"code"`
+	Message string  `json:"message"`
+	Param   *string `json:"param"`
+	Line    *int    `json:"line"`
+}
+
+// OpenAIBatch 批次
+type OpenAIBatch struct {
+	ID               string
+	UserID           int64
+	APIKeyID         int64
+	GroupID          *int64
+	Endpoint         string
+	InputFileID      string
+	CompletionWindow string
+	Status           string
+	OutputFileID     *string
+	ErrorFileID      *string
+	Errors           []OpenAIBatchError
+	Metadata         map[string]string
+	Counts           OpenAIBatchRequestCounts
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+	ExpiresAt        time.Time
+	InProgressAt     *time.Time
+	FinalizingAt     *time.Time
+	CompletedAt      *time.Time
+	FailedAt         *time.Time
+	ExpiredAt        *time.Time
+	CancellingAt     *time.Time
+	CancelledAt      *time.Time
+}
+
+// IsTerminal 批次是否已结束（completed / failed / expired / cancelled）
+func (b *OpenAIBatch) IsTerminal() bool {
+	switch b.Status {
+	case OpenAIBatchStatusCompleted, OpenAIBatchStatusFailed, OpenAIBatchStatusExpired, OpenAIBatchStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// OpenAIFileRepository 文件元数据存储
+type OpenAIFileRepository interface {
+	Create(ctx context.Context, f *OpenAIFile) error
+	GetByID(ctx context.Context, id string) (*OpenAIFile, error)
+	ListByAPIKey(ctx context.Context, apiKeyID int64, params OpenAIFileListParams) ([]OpenAIFile, bool, error)
+	Delete(ctx context.Context, id string) error
+	ListExpired(ctx context.Context, before time.Time, limit int) ([]OpenAIFile, error)
+}
+
+// OpenAIBatchRepository 批次元数据存储
+type OpenAIBatchRepository interface {
+	Create(ctx context.Context, b *OpenAIBatch) error
+	GetByID(ctx context.Context, id string) (*OpenAIBatch, error)
+	ListByAPIKey(ctx context.Context, apiKeyID int64, after string, limit int) ([]OpenAIBatch, bool, error)
+	// Update 写入批次状态与进度并刷新 updated_at。
+	// 写入 validating/in_progress 时若库中已被标记为 cancelling 则保留 cancelling，返回写入后的状态。
+	Update(ctx context.Context, b *OpenAIBatch) (string, error)
+	// MarkCancelling 将未结束的批次标记为 cancelling，由执行该批次的实例在下次心跳时响应
+	MarkCancelling(ctx context.Context, id string, at time.Time) (bool, error)
+	ListStale(ctx context.Context, before time.Time, limit int) ([]OpenAIBatch, error)
+	// Claim 以 updated_at 为乐观锁认领中断批次，避免多实例重复回收
+	Claim(ctx context.Context, id string, before time.Time) (bool, error)
+	HasActiveForInputFile(ctx context.Context, fileID string) (bool, error)
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// OpenAIBatchCaller 创建批次的调用方信息（执行与计费使用）
+type OpenAIBatchCaller struct {
+	APIKey       *APIKey
+	Subscription *UserSubscription
+	UserAgent    string
+	IPAddress    string
+}
+
+// OpenAIBatchCreateInput 创建批次参数
+type OpenAIBatchCreateInput struct {
+	InputFileID      string
+	Endpoint         string
+	CompletionWindow string
+	Metadata         map[string]string
+}
+
+// OpenAIBatchService OpenAI Files / Batch API：文件存储、批次校验与本地执行
+type OpenAIBatchService struct {
+	fileRepo            OpenAIFileRepository
+	batchRepo           OpenAIBatchRepository
+	storage             OpenAIFileStorage
+	gatewayService      *OpenAIGatewayService
+	concurrencyService  *ConcurrencyService
+	billingCacheService *BillingCacheService
+	apiKeyService       *APIKeyService
+	guardrailService    *GuardrailService
+	cfg                 *config.Config
+
+	// workers 全局 worker 槽位，所有批次共享
+	workers chan struct{}
+
+	mu      sync.Mutex
+	running map[string]*openAIBatchJob
+
+	rootCtx    context.Context
+	rootCancel context.CancelFunc
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+}
+
+// NewOpenAIBatchService 创建 OpenAI 批处理服务
+func NewOpenAIBatchService(
+	fileRepo OpenAIFileRepository,
+	batchRepo OpenAIBatchRepository,
+	storage OpenAIFileStorage,
+	gatewayService *OpenAIGatewayService,
+	concurrencyService *ConcurrencyService,
+	billingCacheService *BillingCacheService,
+	apiKeyService *APIKeyService,
+	guardrailService *GuardrailService,
+	cfg *config.Config,
+) *OpenAIBatchService {
+	workers := 1
+	if cfg != nil && cfg.Gateway.OpenAIBatches.Workers > 0 {
+		workers = cfg.Gateway.OpenAIBatches.Workers
+	}
+	rootCtx, rootCancel := context.WithCancel(context.Background())
+	return &OpenAIBatchService{
+		fileRepo:            fileRepo,
+		batchRepo:           batchRepo,
+		storage:             storage,
+		gatewayService:      gatewayService,
+		concurrencyService:  concurrencyService,
+		billingCacheService: billingCacheService,
+		apiKeyService:       apiKeyService,
+		guardrailService:    guardrailService,
+		cfg:                 cfg,
+		workers:             make(chan struct{}, workers),
+		running:             make(map[string]*openAIBatchJob),
+		rootCtx:             rootCtx,
+		rootCancel:          rootCancel,
+		stopCh:              make(chan struct{}),
+	}
+}
+
+// Start 启动后台维护：回收中断的批次、清理过期文件
+func (s *OpenAIBatchService) Start() {
+	if s == nil || !s.Enabled() {
+		return
+	}
+	if err := os.MkdirAll(s.workDir(), 0o755); err != nil {
+		logger.L().Warn("openai_batch.work_dir_create_failed", zap.Error(err))
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(openAIBatchMaintenanceInterval)
+		defer ticker.Stop()
+
+		s.runMaintenance()
+		for {
+			select {
+			case <-ticker.C:
+				s.runMaintenance()
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台任务；执行中的批次保留中间结果，由下次维护结束并生成输出文件
+func (s *OpenAIBatchService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+		s.rootCancel()
+	})
+	s.wg.Wait()
+}
+
+// Enabled 是否开放文件与批处理端点
+func (s *OpenAIBatchService) Enabled() bool {
+	return s != nil && s.cfg != nil && s.cfg.Gateway.OpenAIBatches.Enabled
+}
+
+// MaxFileBytes 单个上传文件的最大字节数
+func (s *OpenAIBatchService) MaxFileBytes() int64 {
+	return int64(s.cfg.Gateway.OpenAIBatches.MaxFileSizeMB) << 20
+}
+
+// ===== files =====
+
+// UploadFile 保存上传的批处理输入文件
+func (s *OpenAIBatchService) UploadFile(ctx context.Context, caller *OpenAIBatchCaller, filename, purpose string, body io.Reader) (*OpenAIFile, error) {
+	if !s.Enabled() {
+		return nil, ErrOpenAIBatchesDisabled
+	}
+	if purpose != OpenAIFilePurposeBatch {
+		return nil, ErrOpenAIFileInvalidPurpose
+	}
+	maxBytes := s.MaxFileBytes()
+	id := newOpenAIObjectID(openAIFileIDPrefix)
+	n, err := s.storage.Put(ctx, id, io.LimitReader(body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("store file: %w", err)
+	}
+	if n == 0 || n > maxBytes {
+		s.deleteStoredContent(id)
+		if n == 0 {
+			return nil, ErrOpenAIFileEmpty
+		}
+		return nil, infraerrors.Newf(http.StatusRequestEntityTooLarge, "OPENAI_FILE_TOO_LARGE",
+			"file exceeds the maximum size of %d MB", s.cfg.Gateway.OpenAIBatches.MaxFileSizeMB)
+	}
+	return s.createFileRecord(ctx, caller.APIKey.UserID, caller.APIKey.ID, id, purpose, filename, n)
+}
+
+func (s *OpenAIBatchService) createFileRecord(ctx context.Context, userID, apiKeyID int64, key, purpose, filename string, size int64) (*OpenAIFile, error) {
+	now := time.Now()
+	f := &OpenAIFile{
+		ID:         key,
+		UserID:     userID,
+		APIKeyID:   apiKeyID,
+		Purpose:    purpose,
+		Filename:   filename,
+		Bytes:      size,
+		StorageKey: key,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(time.Duration(s.cfg.Gateway.OpenAIBatches.FileRetentionHours) * time.Hour),
+	}
+	if err := s.fileRepo.Create(ctx, f); err != nil {
+		s.deleteStoredContent(key)
+		return nil, err
+	}
+	return f, nil
+}
+
+// ListFiles 列出当前 API Key 的文件
+func (s *OpenAIBatchService) ListFiles(ctx context.Context, caller *OpenAIBatchCaller, params OpenAIFileListParams) ([]OpenAIFile, bool, error) {
+	if !s.Enabled() {
+		return nil, false, ErrOpenAIBatchesDisabled
+	}
+	if params.Limit <= 0 {
+		params.Limit = OpenAIFileListDefaultLimit
+	}
+	if params.Limit > OpenAIFileListMaxLimit {
+		params.Limit = OpenAIFileListMaxLimit
+	}
+	return s.fileRepo.ListByAPIKey(ctx, caller.APIKey.ID, params)
+}
+
+// GetFile 查询文件
+func (s *OpenAIBatchService) GetFile(ctx context.Context, caller *OpenAIBatchCaller, id string) (*OpenAIFile, error) {
+	if !s.Enabled() {
+		return nil, ErrOpenAIBatchesDisabled
+	}
+	if !strings.HasPrefix(id, openAIFileIDPrefix) {
+		return nil, ErrOpenAIFileNotFound
+	}
+	f, err := s.fileRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if f.APIKeyID != caller.APIKey.ID {
+		return nil, ErrOpenAIFileNotFound
+	}
+	return f, nil
+}
+
+// OpenFileContent 打开文件内容
+func (s *OpenAIBatchService) OpenFileContent(ctx context.Context, caller *OpenAIBatchCaller, id string) (*OpenAIFile, io.ReadCloser, error) {
+	f, err := s.GetFile(ctx, caller, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, err := s.storage.Open(ctx, f.StorageKey)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrOpenAIFileNotFound
+		}
+		return nil, nil, fmt.Errorf("open file content: %w", err)
+	}
+	return f, rc, nil
+}
+
+// DeleteFile 删除文件；正在被批次读取的输入文件不允许删除
+func (s *OpenAIBatchService) DeleteFile(ctx context.Context, caller *OpenAIBatchCaller, id string) error {
+	f, err := s.GetFile(ctx, caller, id)
+	if err != nil {
+		return err
+	}
+	if f.Purpose == OpenAIFilePurposeBatch {
+		active, err := s.batchRepo.HasActiveForInputFile(ctx, f.ID)
+		if err != nil {
+			return err
+		}
+		if active {
+			return ErrOpenAIFileNotDeletable
+		}
+	}
+	if err := s.storage.Delete(ctx, f.StorageKey); err != nil {
+		return fmt.Errorf("delete file content: %w", err)
+	}
+	return s.fileRepo.Delete(ctx, f.ID)
+}
+
+func (s *OpenAIBatchService) deleteStoredContent(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.storage.Delete(ctx, key); err != nil {
+		logger.L().Warn("openai_batch.delete_file_content_failed", zap.String("storage_key", key), zap.Error(err))
+	}
+}
+
+// ===== batches =====
+
+// CreateBatch 创建批次：输入文件在后台校验后逐行执行
+func (s *OpenAIBatchService) CreateBatch(ctx context.Context, caller *OpenAIBatchCaller, input OpenAIBatchCreateInput) (*OpenAIBatch, error) {
+	if !s.Enabled() {
+		return nil, ErrOpenAIBatchesDisabled
+	}
+	if !openAIBatchEndpoints[input.Endpoint] {
+		return nil, ErrOpenAIBatchInvalidEndpoint
+	}
+	if input.CompletionWindow != OpenAIBatchCompletionWindow {
+		return nil, ErrOpenAIBatchInvalidWindow
+	}
+	if err := validateOpenAIBatchMetadata(input.Metadata); err != nil {
+		return nil, err
+	}
+	f, err := s.GetFile(ctx, caller, input.InputFileID)
+	if err != nil {
+		if infraerrors.IsNotFound(err) {
+			return nil, ErrOpenAIBatchInvalidInputFile
+		}
+		return nil, err
+	}
+	if f.Purpose != OpenAIFilePurposeBatch {
+		return nil, ErrOpenAIBatchInvalidInputFile
+	}
+
+	now := time.Now()
+	batch := &OpenAIBatch{
+		ID:               newOpenAIObjectID(openAIBatchIDPrefix),
+		UserID:           caller.APIKey.UserID,
+		APIKeyID:         caller.APIKey.ID,
+		GroupID:          caller.APIKey.GroupID,
+		Endpoint:         input.Endpoint,
+		InputFileID:      f.ID,
+		CompletionWindow: input.CompletionWindow,
+		Status:           OpenAIBatchStatusValidating,
+		Metadata:         input.Metadata,
+		CreatedAt:        now,
+		ExpiresAt:        now.Add(openAIBatchTTL),
+	}
+	if err := s.batchRepo.Create(ctx, batch); err != nil {
+		return nil, err
+	}
+	s.startBatch(batch, caller)
+	return batch, nil
+}
+
+// GetBatch 查询批次
+func (s *OpenAIBatchService) GetBatch(ctx context.Context, caller *OpenAIBatchCaller, id string) (*OpenAIBatch, error) {
+	batch, err := s.getOwnedBatch(ctx, caller.APIKey.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if job := s.runningJob(id); job != nil {
+		return job.snapshot(), nil
+	}
+	return batch, nil
+}
+
+// ListBatches 列出当前 API Key 的批次（按创建时间倒序）
+func (s *OpenAIBatchService) ListBatches(ctx context.Context, caller *OpenAIBatchCaller, after string, limit int) ([]OpenAIBatch, bool, error) {
+	if !s.Enabled() {
+		return nil, false, ErrOpenAIBatchesDisabled
+	}
+	if limit <= 0 {
+		limit = OpenAIBatchListDefaultLimit
+	}
+	if limit > OpenAIBatchListMaxLimit {
+		limit = OpenAIBatchListMaxLimit
+	}
+	batches, hasMore, err := s.batchRepo.ListByAPIKey(ctx, caller.APIKey.ID, after, limit)
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range batches {
+		if job := s.runningJob(batches[i].ID); job != nil {
+			batches[i] = *job.snapshot()
+		}
+	}
+	return batches, hasMore, nil
+}
+
+// CancelBatch 取消批次：未开始的请求不再执行，已完成部分仍生成输出文件
+func (s *OpenAIBatchService) CancelBatch(ctx context.Context, caller *OpenAIBatchCaller, id string) (*OpenAIBatch, error) {
+	batch, err := s.getOwnedBatch(ctx, caller.APIKey.ID, id)
+	if err != nil {
+		return nil, err
+	}
+	if job := s.runningJob(id); job != nil {
+		snapshot := job.snapshot()
+		if snapshot.IsTerminal() || snapshot.Status == OpenAIBatchStatusFinalizing {
+			return nil, ErrOpenAIBatchNotCancellable
+		}
+		job.requestCancel()
+		job.persist(s.batchRepo, true)
+		return job.snapshot(), nil
+	}
+	switch batch.Status {
+	case OpenAIBatchStatusCancelling:
+		return batch, nil
+	case OpenAIBatchStatusValidating, OpenAIBatchStatusInProgress:
+	default:
+		return nil, ErrOpenAIBatchNotCancellable
+	}
+	// 批次由其他实例执行：标记 cancelling，执行实例在下次心跳时响应
+	if _, err := s.batchRepo.MarkCancelling(ctx, id, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.batchRepo.GetByID(ctx, id)
+}
+
+func (s *OpenAIBatchService) getOwnedBatch(ctx context.Context, apiKeyID int64, id string) (*OpenAIBatch, error) {
+	if !s.Enabled() {
+		return nil, ErrOpenAIBatchesDisabled
+	}
+	if !strings.HasPrefix(id, openAIBatchIDPrefix) {
+		return nil, ErrOpenAIBatchNotFound
+	}
+	batch, err := s.batchRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if batch.APIKeyID != apiKeyID {
+		return nil, ErrOpenAIBatchNotFound
+	}
+	return batch, nil
+}
+
+func (s *OpenAIBatchService) discountMultiplier(group *Group) float64 {
+	defaultMultiplier := 0.0
+	if s.cfg != nil {
+		defaultMultiplier = s.cfg.Gateway.OpenAIBatches.DiscountMultiplier
+	}
+	return group.ResolveBatchDiscount(defaultMultiplier)
+}
+
+func validateOpenAIBatchMetadata(metadata map[string]string) error {
+	if len(metadata) > openAIBatchMetadataMaxPairs {
+		return ErrOpenAIBatchInvalidMetadata
+	}
+	for k, v := range metadata {
+		if len(k) > openAIBatchMetadataMaxKeyLen || len(v) > openAIBatchMetadataMaxValueLen {
+			return ErrOpenAIBatchInvalidMetadata
+		}
+	}
+	return nil
+}
+
+// ===== maintenance & storage =====
+
+func (s *OpenAIBatchService) runMaintenance() {
+	ctx, cancel := context.WithTimeout(s.rootCtx, time.Minute)
+	defer cancel()
+
+	before := time.Now().Add(-openAIBatchStaleAfter)
+	stale, err := s.batchRepo.ListStale(ctx, before, 100)
+	if err != nil {
+		logger.L().Warn("openai_batch.list_stale_failed", zap.Error(err))
+	}
+	for i := range stale {
+		if s.runningJob(stale[i].ID) != nil {
+			continue
+		}
+		claimed, err := s.batchRepo.Claim(ctx, stale[i].ID, before)
+		if err != nil || !claimed {
+			continue
+		}
+		s.recoverInterruptedBatch(&stale[i])
+	}
+
+	expired, err := s.fileRepo.ListExpired(ctx, time.Now(), 500)
+	if err != nil {
+		logger.L().Warn("openai_batch.list_expired_files_failed", zap.Error(err))
+	}
+	for i := range expired {
+		if err := s.storage.Delete(ctx, expired[i].StorageKey); err != nil {
+			logger.L().Warn("openai_batch.delete_expired_file_failed", zap.String("file_id", expired[i].ID), zap.Error(err))
+			continue
+		}
+		if err := s.fileRepo.Delete(ctx, expired[i].ID); err != nil {
+			logger.L().Warn("openai_batch.delete_expired_file_failed", zap.String("file_id", expired[i].ID), zap.Error(err))
+		}
+	}
+	if len(expired) > 0 {
+		logger.L().Info("openai_batch.cleanup_expired_files", zap.Int("count", len(expired)))
+	}
+
+	retention := time.Duration(s.cfg.Gateway.OpenAIBatches.FileRetentionHours) * time.Hour
+	if n, err := s.batchRepo.DeleteFinishedBefore(ctx, time.Now().Add(-retention)); err != nil {
+		logger.L().Warn("openai_batch.cleanup_batches_failed", zap.Error(err))
+	} else if n > 0 {
+		logger.L().Info("openai_batch.cleanup_batches", zap.Int64("count", n))
+	}
+}
+
+// workDir 执行中批次的中间结果目录
+func (s *OpenAIBatchService) workDir() string {
+	return filepath.Join(s.cfg.Gateway.OpenAIBatches.StorageDir, "batches")
+}
+
+func (s *OpenAIBatchService) outputWorkPath(id string) string {
+	return filepath.Join(s.workDir(), id+".output.jsonl")
+}
+
+func (s *OpenAIBatchService) errorWorkPath(id string) string {
+	return filepath.Join(s.workDir(), id+".error.jsonl")
+}
+
+func (s *OpenAIBatchService) removeWorkFiles(id string) {
+	for _, path := range []string{s.outputWorkPath(id), s.errorWorkPath(id)} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.L().Warn("openai_batch.remove_work_file_failed", zap.String("path", path), zap.Error(err))
+		}
+	}
+}
+
+func newOpenAIObjectID(prefix string) string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return prefix + hex.EncodeToString(b)
+}
@@ -0,0 +1,250 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type openAIBatchRepoStub struct {
+	OpenAIBatchRepository
+	updated []OpenAIBatch
+}
+
+func (r *openAIBatchRepoStub) Update(_ context.Context, b *OpenAIBatch) (string, error) {
+	r.updated = append(r.updated, *b)
+	return b.Status, nil
+}
+
+type openAIFileRepoStub struct {
+	OpenAIFileRepository
+	created []OpenAIFile
+}
+
+func (r *openAIFileRepoStub) Create(_ context.Context, f *OpenAIFile) error {
+	r.created = append(r.created, *f)
+	return nil
+}
+
+func newOpenAIBatchServiceForTest(t *testing.T, fileRepo OpenAIFileRepository, batchRepo OpenAIBatchRepository) *OpenAIBatchService {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Gateway.OpenAIBatches = config.GatewayOpenAIBatchesConfig{
+		Enabled:            true,
+		StorageBackend:     "local",
+		StorageDir:         t.TempDir(),
+		MaxFileSizeMB:      1,
+		MaxRequests:        3,
+		Workers:            2,
+		DiscountMultiplier: 0.5,
+		FileRetentionHours: 24,
+	}
+	storage := NewOpenAIFileStorage("local", cfg.Gateway.OpenAIBatches.StorageDir, "", nil)
+	return NewOpenAIBatchService(fileRepo, batchRepo, storage, nil, nil, nil, nil, nil, cfg)
+}
+
+func TestParseOpenAIBatchInput(t *testing.T) {
+	const endpoint = "/v1/chat/completions"
+	line := func(customID, body string) string {
+		return `{"custom_id":"` + customID + `","method":"POST","url":"/v1/chat/completions","body":` + body + `}`
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		wantCode string
+	}{
+		{"invalid json", "not json", "invalid_json_line"},
+		{"missing custom_id", `{"method":"POST","url":"/v1/chat/completions","body":{"model":"gpt-5"}}`, "missing_required_parameter"},
+		{"wrong method", `{"custom_id":"a","method":"GET","url":"/v1/chat/completions","body":{"model":"gpt-5"}}`, "invalid_value"},
+		{"mismatched url", `{"custom_id":"a","method":"POST","url":"/v1/responses","body":{"model":"gpt-5"}}`, "mismatched_endpoint"},
+		{"missing model", line("a", `{"messages":[]}`), "missing_required_parameter"},
+		{"stream rejected", line("a", `{"model":"gpt-5","stream":true}`), "invalid_value"},
+		{"duplicate custom_id", line("a", `{"model":"gpt-5"}`) + "\n" + line("a", `{"model":"gpt-5"}`), "duplicate_custom_id"},
+		{"empty", "\n\n", "empty_file"},
+		{"too many requests", strings.Join([]string{
+			line("a", `{"model":"gpt-5"}`), line("b", `{"model":"gpt-5"}`),
+			line("c", `{"model":"gpt-5"}`), line("d", `{"model":"gpt-5"}`),
+		}, "\n"), "too_many_requests"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs, errs, err := parseOpenAIBatchInput(strings.NewReader(tt.input), endpoint, 3)
+			require.NoError(t, err)
+			require.Nil(t, reqs)
+			require.NotEmpty(t, errs)
+			require.Equal(t, tt.wantCode, errs[0].Code)
+		})
+	}
+
+	t.Run("line numbers", func(t *testing.T) {
+		_, errs, err := parseOpenAIBatchInput(strings.NewReader(line("a", `{"model":"gpt-5"}`)+"\nbad"), endpoint, 3)
+		require.NoError(t, err)
+		require.Len(t, errs, 1)
+		require.NotNil(t, errs[0].Line)
+		require.Equal(t, 2, *errs[0].Line)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		reqs, errs, err := parseOpenAIBatchInput(strings.NewReader(
+			line("req-1", `{"model":"gpt-5","messages":[{"role":"user","content":"hi"}]}`)+"\n\n"+line("req-2", `{"model":"gpt-5-mini"}`)+"\n"),
+			endpoint, 3)
+		require.NoError(t, err)
+		require.Empty(t, errs)
+		require.Len(t, reqs, 2)
+		require.Equal(t, "req-1", reqs[0].CustomID)
+		require.Equal(t, "gpt-5-mini", gjson.GetBytes(reqs[1].Body, "model").String())
+	})
+}
+
+func TestOpenAIBatchResultLineRouting(t *testing.T) {
+	ok := newOpenAIBatchResponseLine("a", 200, "req_1", []byte(`{"id":"chatcmpl-1"}`))
+	require.True(t, ok.succeeded())
+
+	failed := newOpenAIBatchResponseLine("b", 400, "", []byte("bad request"))
+	require.False(t, failed.succeeded())
+	require.Equal(t, "bad request", gjson.GetBytes(failed.Response.Body, "error.message").String())
+
+	errLine := newOpenAIBatchErrorLine("c", "batch_expired", "expired")
+	require.False(t, errLine.succeeded())
+	require.True(t, strings.HasPrefix(errLine.ID, openAIBatchRequestIDPrefix))
+}
+
+func TestLocalOpenAIFileStorage(t *testing.T) {
+	storage := NewOpenAIFileStorage("local", t.TempDir(), "", nil)
+	ctx := context.Background()
+
+	n, err := storage.Put(ctx, "file-abc", strings.NewReader("hello\n"))
+	require.NoError(t, err)
+	require.Equal(t, int64(6), n)
+
+	rc, err := storage.Open(ctx, "file-abc")
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	require.Equal(t, "hello\n", string(data))
+
+	require.NoError(t, storage.Delete(ctx, "file-abc"))
+	require.NoError(t, storage.Delete(ctx, "file-abc"))
+	_, err = storage.Open(ctx, "file-abc")
+	require.True(t, os.IsNotExist(err))
+
+	_, err = storage.Put(ctx, "../escape", strings.NewReader("x"))
+	require.Error(t, err)
+}
+
+func TestRecoverInterruptedOpenAIBatch(t *testing.T) {
+	fileRepo := &openAIFileRepoStub{}
+	batchRepo := &openAIBatchRepoStub{}
+	svc := newOpenAIBatchServiceForTest(t, fileRepo, batchRepo)
+	ctx := context.Background()
+
+	input := strings.Join([]string{
+		`{"custom_id":"a","method":"POST","url":"/v1/responses","body":{"model":"gpt-5"}}`,
+		`{"custom_id":"b","method":"POST","url":"/v1/responses","body":{"model":"gpt-5"}}`,
+		`{"custom_id":"c","method":"POST","url":"/v1/responses","body":{"model":"gpt-5"}}`,
+	}, "\n")
+	_, err := svc.storage.Put(ctx, "file-input", strings.NewReader(input))
+	require.NoError(t, err)
+
+	batch := &OpenAIBatch{
+		ID:          "batch_test",
+		UserID:      1,
+		APIKeyID:    2,
+		Endpoint:    "/v1/responses",
+		InputFileID: "file-input",
+		Status:      OpenAIBatchStatusInProgress,
+		Counts:      OpenAIBatchRequestCounts{Total: 3},
+	}
+	require.NoError(t, os.MkdirAll(svc.workDir(), 0o755))
+	require.NoError(t, os.WriteFile(svc.outputWorkPath(batch.ID),
+		[]byte(`{"id":"batch_req_1","custom_id":"a","response":{"status_code":200,"request_id":"","body":{}},"error":null}`+"\n"), 0o600))
+
+	svc.recoverInterruptedBatch(batch)
+
+	require.Equal(t, OpenAIBatchStatusCompleted, batch.Status)
+	require.NotNil(t, batch.CompletedAt)
+	require.Equal(t, OpenAIBatchRequestCounts{Total: 3, Completed: 1, Failed: 2}, batch.Counts)
+	require.NotNil(t, batch.OutputFileID)
+	require.NotNil(t, batch.ErrorFileID)
+	require.Len(t, fileRepo.created, 2)
+	require.Equal(t, OpenAIFilePurposeBatchOutput, fileRepo.created[1].Purpose)
+
+	rc, err := svc.storage.Open(ctx, *batch.ErrorFileID)
+	require.NoError(t, err)
+	data, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	require.NoError(t, rc.Close())
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, "b", gjson.Get(lines[0], "custom_id").String())
+	require.Equal(t, "batch_interrupted", gjson.Get(lines[0], "error.code").String())
+
+	_, err = os.Stat(svc.outputWorkPath(batch.ID))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestRecoverInterruptedOpenAIBatchDuringValidation(t *testing.T) {
+	batchRepo := &openAIBatchRepoStub{}
+	svc := newOpenAIBatchServiceForTest(t, &openAIFileRepoStub{}, batchRepo)
+	batch := &OpenAIBatch{ID: "batch_validating", Status: OpenAIBatchStatusValidating}
+
+	svc.recoverInterruptedBatch(batch)
+
+	require.Len(t, batchRepo.updated, 1)
+	require.Equal(t, OpenAIBatchStatusFailed, batch.Status)
+	require.NotNil(t, batch.FailedAt)
+	require.Equal(t, "batch_interrupted", batch.Errors[0].Code)
+}
+
+func TestValidateOpenAIBatchMetadata(t *testing.T) {
+	require.NoError(t, validateOpenAIBatchMetadata(nil))
+	require.NoError(t, validateOpenAIBatchMetadata(map[string]string{"project": "demo"}))
+	require.ErrorIs(t, validateOpenAIBatchMetadata(map[string]string{strings.Repeat("k", 65): "v"}), ErrOpenAIBatchInvalidMetadata)
+
+	tooMany := make(map[string]string)
+	for i := 0; i < 17; i++ {
+		tooMany[string(rune('a'+i))] = "v"
+	}
+	require.ErrorIs(t, validateOpenAIBatchMetadata(tooMany), ErrOpenAIBatchInvalidMetadata)
+}
+
+func TestOpenAIBatchDiscountMultiplier(t *testing.T) {
+	svc := newOpenAIBatchServiceForTest(t, nil, nil)
+	require.Equal(t, 0.5, svc.discountMultiplier(nil))
+	require.Equal(t, 0.5, svc.discountMultiplier(&Group{}))
+
+	groupDiscount := 0.8
+	require.Equal(t, 0.8, svc.discountMultiplier(&Group{BatchDiscountMultiplier: &groupDiscount}))
+}
+
+func TestOpenAIBatchExecuteItemAppliesGuardrail(t *testing.T) {
+	svc := newOpenAIBatchServiceForTest(t, nil, nil)
+	svc.guardrailService = newGuardrailServiceForTest(GuardrailRule{
+		ID: 1, GroupID: 3, Name: "no codename", Detector: GuardrailDetectorKeyword,
+		Patterns: []string{"project-x"}, Action: GuardrailActionBlock, Enabled: true, Priority: 1,
+	})
+
+	job := &openAIBatchJob{caller: &OpenAIBatchCaller{APIKey: guardrailTestAPIKey(3)}}
+	line, ok := svc.executeItem(context.Background(), job, openAIBatchRequest{
+		CustomID: "a",
+		Method:   "POST",
+		URL:      "/v1/chat/completions",
+		Body:     []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"tell me about project-x"}]}`),
+	})
+
+	require.True(t, ok)
+	require.False(t, line.succeeded())
+	require.Equal(t, 400, line.Response.StatusCode)
+	require.Equal(t, "invalid_request_error", gjson.GetBytes(line.Response.Body, "error.type").String())
+	require.Contains(t, gjson.GetBytes(line.Response.Body, "error.message").String(), "no codename")
+}
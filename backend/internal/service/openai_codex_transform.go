@@ -279,6 +279,11 @@ func normalizeOpenAIModelForUpstream(account *Account, model string) string {
 	if account == nil || account.Type == AccountTypeOAuth {
 		return normalizeCodexModel(model)
 	}
+	if account.Type == AccountTypeAzure {
+		// Azure 按部署名路由，body.model 需为部署名
+		deployment, _ := account.ResolveAzureOpenAIDeployment(strings.TrimSpace(model))
+		return deployment
+	}
 	return strings.TrimSpace(model)
 }
 
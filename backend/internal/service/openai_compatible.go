@@ -0,0 +1,125 @@
+package service
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+)
+
+// openAICompatibleDefault429Cooldown OpenAI 兼容上游 429 未携带任何重置时间时的默认冷却时间。
+// 自建 vLLM/SGLang 与 DeepSeek/Moonshot/Qwen 等厂商基本按分钟维度限流。
+const openAICompatibleDefault429Cooldown = time.Minute
+
+// GetOpenAICompatibleModels 返回账号配置的上游模型列表（credentials.models）。
+// 列表中的模型可被直接请求并原样转发；未配置时返回 nil。
+func (a *Account) GetOpenAICompatibleModels() []string {
+	raw, ok := a.Credentials["models"].([]any)
+	if !ok {
+		return nil
+	}
+	models := make([]string, 0, len(raw))
+	for _, v := range raw {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			models = append(models, strings.TrimSpace(s))
+		}
+	}
+	return models
+}
+
+// ResolveOpenAICompatibleModel 解析 OpenAI 兼容账号的上游模型名。
+//
+// 优先使用 model_mapping（支持通配符，例如 "claude-*" -> "deepseek-chat"）；未命中映射时，
+// 请求的模型必须出现在 credentials.models 中。两者均未配置时允许任意模型原样透传。
+func ResolveOpenAICompatibleModel(account *Account, requestedModel string) (string, bool) {
+	if account == nil {
+		return "", false
+	}
+	mapped, matched := account.ResolveMappedModel(requestedModel)
+	if matched {
+		mapped = strings.TrimSpace(mapped)
+		return mapped, mapped != ""
+	}
+	models := account.GetOpenAICompatibleModels()
+	if len(models) == 0 {
+		return requestedModel, len(account.GetModelMapping()) == 0
+	}
+	for _, m := range models {
+		if m == requestedModel {
+			return requestedModel, true
+		}
+	}
+	return "", false
+}
+
+// buildOpenAICompatibleChatURL 组装 Chat Completions 端点。
+// base_url 通常已带版本前缀（如 https://api.deepseek.com/v1），仅有域名时补全 /v1；
+// 已是完整 /chat/completions 地址时原样使用。
+func buildOpenAICompatibleChatURL(baseURL string) string {
+	base := strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if strings.HasSuffix(base, "/chat/completions") {
+		return base
+	}
+	if u, err := url.Parse(base); err == nil && (u.Path == "" || u.Path == "/") {
+		return base + "/v1/chat/completions"
+	}
+	return base + "/chat/completions"
+}
+
+// ValidateOpenAICompatibleCredentials 校验 OpenAI 兼容账号凭证（管理后台创建/更新账号时调用）
+func ValidateOpenAICompatibleCredentials(platform string, credentials map[string]any) error {
+	if platform != PlatformAnthropic {
+		return infraerrors.BadRequest("OPENAI_COMPATIBLE_PLATFORM_INVALID", "openai-compatible account type is only supported on the anthropic platform")
+	}
+	baseURL, _ := credentials["base_url"].(string)
+	if strings.TrimSpace(baseURL) == "" {
+		return infraerrors.BadRequest("OPENAI_COMPATIBLE_BASE_URL_REQUIRED", "credentials.base_url is required")
+	}
+	if u, err := url.Parse(strings.TrimSpace(baseURL)); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return infraerrors.BadRequest("OPENAI_COMPATIBLE_BASE_URL_INVALID", "credentials.base_url must be an http(s) URL")
+	}
+	if raw, ok := credentials["api_key"]; ok && raw != nil {
+		if _, isString := raw.(string); !isString {
+			return infraerrors.BadRequest("OPENAI_COMPATIBLE_API_KEY_INVALID", "credentials.api_key must be a string")
+		}
+	}
+	if raw, ok := credentials["models"]; ok && raw != nil {
+		models, isList := raw.([]any)
+		if !isList {
+			return infraerrors.BadRequest("OPENAI_COMPATIBLE_MODELS_INVALID", "credentials.models must be an array of model names")
+		}
+		for _, v := range models {
+			if name, isString := v.(string); !isString || strings.TrimSpace(name) == "" {
+				return infraerrors.BadRequest("OPENAI_COMPATIBLE_MODELS_INVALID", "credentials.models must only contain non-empty strings")
+			}
+		}
+	}
+	return nil
+}
+
+// calculateOpenAICompatible429ResetTime 解析 OpenAI 兼容上游 429 的重置时间：
+// 先按 retry-after-ms / retry-after，再按 OpenAI 风格的 x-ratelimit-reset-requests/tokens（如 "1s"、"6m0s"），
+// 取两个窗口中较晚的一个；均缺失时返回 nil。
+func calculateOpenAICompatible429ResetTime(headers http.Header, now time.Time) *time.Time {
+	if resetAt := calculateAzure429ResetTime(headers, now); resetAt != nil {
+		return resetAt
+	}
+	var latest *time.Time
+	for _, key := range []string{"x-ratelimit-reset-requests", "x-ratelimit-reset-tokens"} {
+		raw := strings.TrimSpace(headers.Get(key))
+		if raw == "" {
+			continue
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			continue
+		}
+		resetAt := now.Add(d)
+		if latest == nil || resetAt.After(*latest) {
+			latest = &resetAt
+		}
+	}
+	return latest
+}
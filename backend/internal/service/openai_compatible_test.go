@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type openAICompatibleAccountRepoStub struct {
+	AccountRepository
+	rateLimitedUntil *time.Time
+}
+
+func (r *openAICompatibleAccountRepoStub) SetRateLimited(_ context.Context, _ int64, resetAt time.Time) error {
+	r.rateLimitedUntil = &resetAt
+	return nil
+}
+
+func newOpenAICompatibleTestAccount() *Account {
+	return &Account{
+		ID:          301,
+		Name:        "deepseek",
+		Platform:    PlatformAnthropic,
+		Type:        AccountTypeOpenAICompatible,
+		Concurrency: 1,
+		Credentials: map[string]any{
+			"base_url":      "https://api.deepseek.com/v1",
+			"api_key":       "sk-deepseek",
+			"models":        []any{"deepseek-chat", "deepseek-reasoner"},
+			"model_mapping": map[string]any{"claude-*": "deepseek-chat"},
+		},
+		Status:      StatusActive,
+		Schedulable: true,
+	}
+}
+
+func newOpenAICompatibleTestService(upstream HTTPUpstream, repo AccountRepository) *GatewayService {
+	cfg := &config.Config{
+		Gateway: config.GatewayConfig{MaxLineSize: defaultMaxLineSize},
+	}
+	return &GatewayService{
+		cfg:                  cfg,
+		responseHeaderFilter: compileResponseHeaderFilter(cfg),
+		httpUpstream:         upstream,
+		rateLimitService:     &RateLimitService{accountRepo: repo},
+	}
+}
+
+func TestResolveOpenAICompatibleModel(t *testing.T) {
+	account := newOpenAICompatibleTestAccount()
+
+	model, ok := ResolveOpenAICompatibleModel(account, "claude-sonnet-4-5")
+	require.True(t, ok)
+	require.Equal(t, "deepseek-chat", model)
+
+	model, ok = ResolveOpenAICompatibleModel(account, "deepseek-reasoner")
+	require.True(t, ok)
+	require.Equal(t, "deepseek-reasoner", model)
+
+	_, ok = ResolveOpenAICompatibleModel(account, "gpt-4o")
+	require.False(t, ok)
+
+	// 未配置映射与模型列表时任意模型原样透传
+	bare := &Account{Platform: PlatformAnthropic, Type: AccountTypeOpenAICompatible, Credentials: map[string]any{"base_url": "http://vllm:8000"}}
+	model, ok = ResolveOpenAICompatibleModel(bare, "Qwen/Qwen3-32B")
+	require.True(t, ok)
+	require.Equal(t, "Qwen/Qwen3-32B", model)
+}
+
+func TestBuildOpenAICompatibleChatURL(t *testing.T) {
+	require.Equal(t, "https://api.deepseek.com/v1/chat/completions", buildOpenAICompatibleChatURL("https://api.deepseek.com/v1/"))
+	require.Equal(t, "http://vllm:8000/v1/chat/completions", buildOpenAICompatibleChatURL("http://vllm:8000"))
+	require.Equal(t, "https://dashscope.aliyuncs.com/compatible-mode/v1/chat/completions",
+		buildOpenAICompatibleChatURL("https://dashscope.aliyuncs.com/compatible-mode/v1"))
+	require.Equal(t, "https://x.example/v1/chat/completions", buildOpenAICompatibleChatURL("https://x.example/v1/chat/completions"))
+}
+
+func TestValidateOpenAICompatibleCredentials(t *testing.T) {
+	valid := map[string]any{"base_url": "https://api.moonshot.cn/v1", "api_key": "k", "models": []any{"kimi-k2"}}
+	require.NoError(t, ValidateOpenAICompatibleCredentials(PlatformAnthropic, valid))
+	require.NoError(t, ValidateOpenAICompatibleCredentials(PlatformAnthropic, map[string]any{"base_url": "http://10.0.0.2:8000"}))
+	require.Error(t, ValidateOpenAICompatibleCredentials(PlatformOpenAI, valid))
+	require.Error(t, ValidateOpenAICompatibleCredentials(PlatformAnthropic, map[string]any{"api_key": "k"}))
+	require.Error(t, ValidateOpenAICompatibleCredentials(PlatformAnthropic, map[string]any{"base_url": "api.moonshot.cn"}))
+	require.Error(t, ValidateOpenAICompatibleCredentials(PlatformAnthropic, map[string]any{"base_url": "https://a.b", "models": "kimi"}))
+	require.Error(t, ValidateOpenAICompatibleCredentials(PlatformAnthropic, map[string]any{"base_url": "https://a.b", "models": []any{""}}))
+}
+
+func TestCalculateOpenAICompatible429ResetTime(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	headers := http.Header{}
+	headers.Set("retry-after", "20")
+	headers.Set("x-ratelimit-reset-requests", "1m")
+	resetAt := calculateOpenAICompatible429ResetTime(headers, now)
+	require.NotNil(t, resetAt)
+	require.Equal(t, now.Add(20*time.Second), *resetAt)
+
+	headers = http.Header{}
+	headers.Set("x-ratelimit-reset-requests", "2s")
+	headers.Set("x-ratelimit-reset-tokens", "6m0s")
+	resetAt = calculateOpenAICompatible429ResetTime(headers, now)
+	require.NotNil(t, resetAt)
+	require.Equal(t, now.Add(6*time.Minute), *resetAt)
+
+	require.Nil(t, calculateOpenAICompatible429ResetTime(http.Header{}, now))
+}
+
+func TestGatewayService_ForwardOpenAICompatible_Stream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+
+	body := []byte(`{"model":"claude-sonnet-4-5","max_tokens":64,"stream":true,"system":"be brief","messages":[{"role":"user","content":"hi"}]}`)
+	upstreamSSE := strings.Join([]string{
+		`data: {"id":"c1","model":"deepseek-chat","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`data: {"id":"c1","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}`,
+		`data: {"id":"c1","choices":[],"usage":{"prompt_tokens":30,"completion_tokens":2,"prompt_cache_hit_tokens":10}}`,
+		"data: [DONE]",
+		"",
+	}, "\n\n")
+	upstream := &anthropicHTTPUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}, "X-Request-Id": []string{"rid-1"}},
+		Body:       io.NopCloser(strings.NewReader(upstreamSSE)),
+	}}
+	svc := newOpenAICompatibleTestService(upstream, nil)
+
+	result, err := svc.Forward(context.Background(), c, newOpenAICompatibleTestAccount(), &ParsedRequest{Body: body, Model: "claude-sonnet-4-5", Stream: true})
+	require.NoError(t, err)
+
+	require.Equal(t, "https://api.deepseek.com/v1/chat/completions", upstream.lastReq.URL.String())
+	require.Equal(t, "Bearer sk-deepseek", upstream.lastReq.Header.Get("Authorization"))
+	require.Equal(t, "deepseek-chat", gjson.GetBytes(upstream.lastBody, "model").String())
+	require.True(t, gjson.GetBytes(upstream.lastBody, "stream_options.include_usage").Bool())
+	require.Equal(t, "system", gjson.GetBytes(upstream.lastBody, "messages.0.role").String())
+
+	out := rec.Body.String()
+	require.Contains(t, out, "event: message_start")
+	require.Contains(t, out, `"text":"Hel"`)
+	require.Contains(t, out, `"stop_reason":"end_turn"`)
+	require.Contains(t, out, "event: message_stop")
+	require.Contains(t, out, `"model":"claude-sonnet-4-5"`)
+
+	require.Equal(t, "rid-1", result.RequestID)
+	require.Equal(t, "claude-sonnet-4-5", result.Model)
+	require.Equal(t, "deepseek-chat", result.UpstreamModel)
+	require.Equal(t, 20, result.Usage.InputTokens)
+	require.Equal(t, 2, result.Usage.OutputTokens)
+	require.Equal(t, 10, result.Usage.CacheReadInputTokens)
+	require.NotNil(t, result.FirstTokenMs)
+}
+
+func TestGatewayService_ForwardOpenAICompatible_StreamClientDisconnectStillBills(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	c.Writer = &failWriteResponseWriter{ResponseWriter: c.Writer}
+
+	upstreamSSE := strings.Join([]string{
+		`data: {"id":"c1","choices":[{"index":0,"delta":{"content":"x"}}]}`,
+		`data: {"id":"c1","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":1}}`,
+		"data: [DONE]",
+		"",
+	}, "\n\n")
+	upstream := &anthropicHTTPUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(upstreamSSE)),
+	}}
+	svc := newOpenAICompatibleTestService(upstream, nil)
+
+	body := []byte(`{"model":"deepseek-chat","max_tokens":8,"stream":true,"messages":[{"role":"user","content":"hi"}]}`)
+	result, err := svc.Forward(context.Background(), c, newOpenAICompatibleTestAccount(), &ParsedRequest{Body: body, Model: "deepseek-chat", Stream: true})
+	require.NoError(t, err)
+	require.True(t, result.ClientDisconnect)
+	require.Equal(t, 5, result.Usage.InputTokens)
+	require.Equal(t, 1, result.Usage.OutputTokens)
+}
+
+func TestGatewayService_ForwardOpenAICompatible_NonStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+
+	upstreamJSON := `{"id":"c2","object":"chat.completion","model":"deepseek-chat","choices":[{"index":0,"message":{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"ls","arguments":"{\"path\":\".\"}"}}]},"finish_reason":"tool_calls"}],"usage":{"prompt_tokens":12,"completion_tokens":4,"total_tokens":16}}`
+	upstream := &anthropicHTTPUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(upstreamJSON)),
+	}}
+	svc := newOpenAICompatibleTestService(upstream, nil)
+
+	body := []byte(`{"model":"claude-haiku-4-5","max_tokens":64,"tools":[{"name":"ls","input_schema":{"type":"object","properties":{"path":{"type":"string"}}}}],"messages":[{"role":"user","content":"list"}]}`)
+	result, err := svc.Forward(context.Background(), c, newOpenAICompatibleTestAccount(), &ParsedRequest{Body: body, Model: "claude-haiku-4-5"})
+	require.NoError(t, err)
+	require.False(t, gjson.GetBytes(upstream.lastBody, "stream").Bool())
+	require.Equal(t, "ls", gjson.GetBytes(upstream.lastBody, "tools.0.function.name").String())
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "tool_use", gjson.Get(rec.Body.String(), "stop_reason").String())
+	require.Equal(t, "call_1", gjson.Get(rec.Body.String(), "content.0.id").String())
+	require.Equal(t, ".", gjson.Get(rec.Body.String(), "content.0.input.path").String())
+	require.Equal(t, "claude-haiku-4-5", gjson.Get(rec.Body.String(), "model").String())
+	require.Equal(t, 12, result.Usage.InputTokens)
+	require.Equal(t, 4, result.Usage.OutputTokens)
+}
+
+func TestGatewayService_ForwardOpenAICompatible_429FailoverMarksRateLimited(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+
+	upstream := &anthropicHTTPUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": []string{"30"}},
+		Body:       io.NopCloser(strings.NewReader(`{"error":{"message":"Rate limit reached","type":"rate_limit_error"}}`)),
+	}}
+	repo := &openAICompatibleAccountRepoStub{}
+	svc := newOpenAICompatibleTestService(upstream, repo)
+
+	body := []byte(`{"model":"deepseek-chat","max_tokens":8,"messages":[{"role":"user","content":"hi"}]}`)
+	before := time.Now()
+	_, err := svc.Forward(context.Background(), c, newOpenAICompatibleTestAccount(), &ParsedRequest{Body: body, Model: "deepseek-chat"})
+
+	var failoverErr *UpstreamFailoverError
+	require.True(t, errors.As(err, &failoverErr))
+	require.Equal(t, http.StatusTooManyRequests, failoverErr.StatusCode)
+	require.NotNil(t, repo.rateLimitedUntil)
+	require.WithinDuration(t, before.Add(30*time.Second), *repo.rateLimitedUntil, 2*time.Second)
+}
+
+func TestGatewayService_ForwardOpenAICompatible_UnsupportedModel(t *testing.T) {
+	svc := newOpenAICompatibleTestService(&anthropicHTTPUpstreamRecorder{}, nil)
+	require.False(t, svc.isModelSupportedByAccount(newOpenAICompatibleTestAccount(), "gpt-4o"))
+	require.True(t, svc.isModelSupportedByAccount(newOpenAICompatibleTestAccount(), "claude-opus-4-6"))
+}
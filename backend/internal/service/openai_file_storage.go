@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// OpenAIFileStorage OpenAI Files 的内容存储后端
+type OpenAIFileStorage interface {
+	Put(ctx context.Context, key string, body io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewOpenAIFileStorage 按 gateway.openai_batches.storage_backend 创建存储后端。
+// s3 后端复用数据备份的 S3 配置，每次操作时读取，管理员修改备份配置后立即生效。
+func NewOpenAIFileStorage(backend, dir, s3Prefix string, backupService *BackupService) OpenAIFileStorage {
+	if backend == "s3" {
+		return &s3OpenAIFileStorage{backupService: backupService, prefix: strings.Trim(s3Prefix, "/")}
+	}
+	return &localOpenAIFileStorage{dir: filepath.Join(dir, "files")}
+}
+
+type localOpenAIFileStorage struct {
+	dir string
+}
+
+func (s *localOpenAIFileStorage) path(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || key == "." || key == ".." {
+		return "", fmt.Errorf("invalid file storage key: %q", key)
+	}
+	return filepath.Join(s.dir, key), nil
+}
+
+func (s *localOpenAIFileStorage) Put(_ context.Context, key string, body io.Reader) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp(s.dir, key+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(tmp, body)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *localOpenAIFileStorage) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (s *localOpenAIFileStorage) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+type s3OpenAIFileStorage struct {
+	backupService *BackupService
+	prefix        string
+}
+
+func (s *s3OpenAIFileStorage) objectKey(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+	return s.prefix + "/" + key
+}
+
+func (s *s3OpenAIFileStorage) Put(ctx context.Context, key string, body io.Reader) (int64, error) {
+	store, err := s.backupService.ObjectStore(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return store.Upload(ctx, s.objectKey(key), body, "application/jsonl")
+}
+
+func (s *s3OpenAIFileStorage) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	store, err := s.backupService.ObjectStore(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return store.Download(ctx, s.objectKey(key))
+}
+
+func (s *s3OpenAIFileStorage) Delete(ctx context.Context, key string) error {
+	store, err := s.backupService.ObjectStore(ctx)
+	if err != nil {
+		return err
+	}
+	return store.Delete(ctx, s.objectKey(key))
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"go.uber.org/zap"
+)
+
+// SupportsOpenAIEmbeddings 判断账号是否可以承接 /v1/embeddings 请求。
+// OAuth 账号走 ChatGPT internal API，没有 embeddings 端点，只有 API Key / 上游透传 / Azure 账号可用。
+func SupportsOpenAIEmbeddings(account *Account) bool {
+	if account == nil || account.Platform != PlatformOpenAI {
+		return false
+	}
+	switch account.Type {
+	case AccountTypeAPIKey, AccountTypeUpstream, AccountTypeAzure:
+		return true
+	default:
+		return false
+	}
+}
+
+// ForwardEmbeddings forwards an OpenAI embeddings request to the upstream
+// /v1/embeddings endpoint of an API Key, upstream or Azure account. The body
+// is forwarded as-is except for model mapping; usage.prompt_tokens is billed
+// as input tokens.
+func (s *OpenAIGatewayService) ForwardEmbeddings(
+	ctx context.Context,
+	c *gin.Context,
+	account *Account,
+	body []byte,
+) (*OpenAIForwardResult, error) {
+	startTime := time.Now()
+
+	if !SupportsOpenAIEmbeddings(account) {
+		writeChatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Embeddings are not supported by this account")
+		return nil, fmt.Errorf("account %d does not support embeddings", account.ID)
+	}
+
+	originalModel := gjson.GetBytes(body, "model").String()
+	billingModel := resolveOpenAIForwardModel(account, originalModel, "")
+	upstreamModel := normalizeOpenAIModelForUpstream(account, billingModel)
+	if upstreamModel != originalModel {
+		body = s.ReplaceModelInBody(body, upstreamModel)
+	}
+
+	logger.L().Debug("openai embeddings: model mapping applied",
+		zap.Int64("account_id", account.ID),
+		zap.String("original_model", originalModel),
+		zap.String("upstream_model", upstreamModel),
+	)
+
+	resp, respBody, err := s.sendOpenAIUnaryRequest(ctx, c, account, openAIUnaryRequest{
+		Path:          "embeddings",
+		UpstreamModel: upstreamModel,
+		ContentType:   "application/json",
+		Body:          body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	usage := extractOpenAIEmbeddingsUsage(respBody)
+	if upstreamModel != originalModel {
+		respBody = s.replaceModelInResponseBody(respBody, upstreamModel, originalModel)
+	}
+
+	writeOpenAIPassthroughResponseHeaders(c.Writer.Header(), resp.Header, s.responseHeaderFilter)
+	c.Data(resp.StatusCode, "application/json", respBody)
+
+	return &OpenAIForwardResult{
+		RequestID:     resp.Header.Get("x-request-id"),
+		Usage:         usage,
+		Model:         originalModel,
+		BillingModel:  billingModel,
+		UpstreamModel: upstreamModel,
+		Duration:      time.Since(startTime),
+	}, nil
+}
+
+// extractOpenAIEmbeddingsUsage 解析 embeddings 响应中的 usage。
+// embeddings 只有输入 token，字段名沿用 Chat Completions 的 prompt_tokens。
+func extractOpenAIEmbeddingsUsage(body []byte) OpenAIUsage {
+	promptTokens := gjson.GetBytes(body, "usage.prompt_tokens").Int()
+	if promptTokens <= 0 {
+		promptTokens = gjson.GetBytes(body, "usage.total_tokens").Int()
+	}
+	return OpenAIUsage{InputTokens: int(promptTokens)}
+}
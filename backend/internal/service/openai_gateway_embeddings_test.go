@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestBuildOpenAIEndpointURL_Embeddings(t *testing.T) {
+	require.Equal(t, "https://api.openai.com/v1/embeddings", buildOpenAIEndpointURL("https://api.openai.com", "embeddings"))
+	require.Equal(t, "https://example.com/v1/embeddings", buildOpenAIEndpointURL("https://example.com/v1/", "embeddings"))
+	require.Equal(t, "https://example.com/custom/embeddings", buildOpenAIEndpointURL("https://example.com/custom/embeddings", "embeddings"))
+}
+
+func TestSupportsEmbeddingsByAccountType(t *testing.T) {
+	require.True(t, SupportsOpenAIEmbeddings(&Account{Platform: PlatformOpenAI, Type: AccountTypeAPIKey}))
+	require.True(t, SupportsOpenAIEmbeddings(&Account{Platform: PlatformOpenAI, Type: AccountTypeUpstream}))
+	require.True(t, SupportsOpenAIEmbeddings(&Account{Platform: PlatformOpenAI, Type: AccountTypeAzure}))
+	require.False(t, SupportsOpenAIEmbeddings(&Account{Platform: PlatformAntigravity, Type: AccountTypeUpstream}))
+	require.False(t, SupportsOpenAIEmbeddings(&Account{Platform: PlatformOpenAI, Type: AccountTypeOAuth}))
+
+	require.True(t, SupportsGeminiEmbeddings(&Account{Platform: PlatformGemini, Type: AccountTypeAPIKey}))
+	require.True(t, SupportsGeminiEmbeddings(&Account{Platform: PlatformGemini, Type: AccountTypeOAuth}))
+	require.False(t, SupportsGeminiEmbeddings(&Account{
+		Platform:    PlatformGemini,
+		Type:        AccountTypeOAuth,
+		Credentials: map[string]any{"project_id": "proj-1"},
+	}))
+}
+
+func TestOpenAIForwardEmbeddings_MapsModelAndBillsPromptTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	body := []byte(`{"model":"embed-alias","input":["a","b"]}`)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/embeddings", bytes.NewReader(body))
+
+	upstream := &httpUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}, "X-Request-Id": []string{"rid_embed"}},
+		Body: io.NopCloser(strings.NewReader(
+			`{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.1]}],"model":"text-embedding-3-small","usage":{"prompt_tokens":9,"total_tokens":9}}`,
+		)),
+	}}
+	svc := &OpenAIGatewayService{cfg: &config.Config{}, httpUpstream: upstream}
+	account := &Account{
+		ID:          1,
+		Platform:    PlatformOpenAI,
+		Type:        AccountTypeAPIKey,
+		Concurrency: 1,
+		Credentials: map[string]any{
+			"api_key":       "sk-test",
+			"base_url":      "https://upstream.example.com/v1",
+			"model_mapping": map[string]any{"embed-alias": "text-embedding-3-small"},
+		},
+	}
+
+	result, err := svc.ForwardEmbeddings(context.Background(), c, account, body)
+	require.NoError(t, err)
+	require.Equal(t, "https://upstream.example.com/v1/embeddings", upstream.lastReq.URL.String())
+	require.Equal(t, "Bearer sk-test", upstream.lastReq.Header.Get("authorization"))
+	require.Equal(t, "text-embedding-3-small", gjson.GetBytes(upstream.lastBody, "model").String())
+
+	require.Equal(t, 9, result.Usage.InputTokens)
+	require.Zero(t, result.Usage.OutputTokens)
+	require.Equal(t, "embed-alias", result.Model)
+	require.Equal(t, "text-embedding-3-small", result.UpstreamModel)
+	require.Equal(t, "rid_embed", result.RequestID)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "embed-alias", gjson.Get(rec.Body.String(), "model").String())
+}
+
+func TestOpenAIForwardEmbeddings_UpstreamAccountUsesBaseURLAndAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	body := []byte(`{"model":"text-embedding-3-small","input":"hi"}`)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/embeddings", bytes.NewReader(body))
+
+	upstream := &httpUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"object":"list","data":[],"usage":{"prompt_tokens":1,"total_tokens":1}}`)),
+	}}
+	svc := &OpenAIGatewayService{cfg: &config.Config{}, httpUpstream: upstream}
+	account := &Account{
+		ID:          4,
+		Platform:    PlatformOpenAI,
+		Type:        AccountTypeUpstream,
+		Concurrency: 1,
+		Credentials: map[string]any{"api_key": "sk-relay", "base_url": "https://relay.example.com"},
+	}
+
+	result, err := svc.ForwardEmbeddings(context.Background(), c, account, body)
+	require.NoError(t, err)
+	require.Equal(t, "https://relay.example.com/v1/embeddings", upstream.lastReq.URL.String())
+	require.Equal(t, "Bearer sk-relay", upstream.lastReq.Header.Get("authorization"))
+	require.Equal(t, 1, result.Usage.InputTokens)
+
+	// 上游透传账号没有默认端点，缺少 base_url 时不能回落到 api.openai.com
+	delete(account.Credentials, "base_url")
+	_, err = svc.ForwardEmbeddings(context.Background(), c, account, body)
+	require.ErrorContains(t, err, "base_url")
+}
+
+func TestOpenAIForwardEmbeddings_FailoverOnRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	body := []byte(`{"model":"text-embedding-3-small","input":"hi"}`)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/embeddings", bytes.NewReader(body))
+
+	upstream := &httpUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(`{"error":{"message":"slow down"}}`)),
+	}}
+	svc := &OpenAIGatewayService{cfg: &config.Config{}, httpUpstream: upstream}
+	account := &Account{
+		ID:          2,
+		Platform:    PlatformOpenAI,
+		Type:        AccountTypeAPIKey,
+		Concurrency: 1,
+		Credentials: map[string]any{"api_key": "sk-test"},
+	}
+
+	_, err := svc.ForwardEmbeddings(context.Background(), c, account, body)
+	var failoverErr *UpstreamFailoverError
+	require.ErrorAs(t, err, &failoverErr)
+	require.Equal(t, http.StatusTooManyRequests, failoverErr.StatusCode)
+}
+
+func TestGeminiForwardEmbeddings_ConvertsBatchResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	body := []byte(`{"model":"gemini-embedding-001","input":["hello world","hi"]}`)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/embeddings", bytes.NewReader(body))
+
+	upstream := &httpUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"embeddings":[{"values":[0.1,0.2]},{"values":[0.3,0.4]}]}`)),
+	}}
+	svc := &GeminiMessagesCompatService{cfg: &config.Config{}, httpUpstream: upstream}
+	account := &Account{
+		ID:          3,
+		Platform:    PlatformGemini,
+		Type:        AccountTypeAPIKey,
+		Concurrency: 1,
+		Credentials: map[string]any{"api_key": "g-key"},
+	}
+
+	result, err := svc.ForwardEmbeddings(context.Background(), c, account, body)
+	require.NoError(t, err)
+	require.True(t, strings.HasSuffix(upstream.lastReq.URL.Path, "/v1beta/models/gemini-embedding-001:batchEmbedContents"))
+	require.Equal(t, "g-key", upstream.lastReq.Header.Get("x-goog-api-key"))
+	require.Equal(t, "models/gemini-embedding-001", gjson.GetBytes(upstream.lastBody, "requests.0.model").String())
+
+	require.Equal(t, estimateTokensForText("hello world")+estimateTokensForText("hi"), result.Usage.InputTokens)
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "list", gjson.Get(rec.Body.String(), "object").String())
+	require.Equal(t, int64(2), gjson.Get(rec.Body.String(), "data.#").Int())
+	require.Equal(t, int64(result.Usage.InputTokens), gjson.Get(rec.Body.String(), "usage.prompt_tokens").Int())
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func newOpenAIGeminiCompatTestService(t *testing.T, upstreamBody string) (*OpenAIGatewayService, *httpUpstreamRecorder, *Account) {
+	t.Helper()
+	upstream := &httpUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}, "x-request-id": []string{"rid_gemini"}},
+		Body:       io.NopCloser(strings.NewReader(upstreamBody)),
+	}}
+	account := &Account{
+		ID:          1,
+		Name:        "openai-oauth",
+		Platform:    PlatformOpenAI,
+		Type:        AccountTypeOAuth,
+		Concurrency: 1,
+		Credentials: map[string]any{
+			"access_token":       "oauth-token",
+			"chatgpt_account_id": "chatgpt-acc",
+		},
+	}
+	return &OpenAIGatewayService{httpUpstream: upstream}, upstream, account
+}
+
+func TestOpenAIForwardAsGemini_Buffered(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	body := []byte(`{"contents":[{"role":"user","parts":[{"text":"hello"}]}],"generationConfig":{"temperature":0.7,"thinkingConfig":{"thinkingBudget":20000}}}`)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1beta/models/gpt-5.4:generateContent", bytes.NewReader(body))
+
+	upstreamBody := strings.Join([]string{
+		`data: {"type":"response.completed","response":{"id":"resp_1","object":"response","model":"gpt-5.4","status":"completed","output":[{"type":"message","id":"msg_1","role":"assistant","status":"completed","content":[{"type":"output_text","text":"hi"}]},{"type":"function_call","id":"fc_1","call_id":"call_1","name":"get_weather","arguments":"{\"city\":\"Paris\"}"}],"usage":{"input_tokens":5,"output_tokens":3,"total_tokens":8}}}`,
+		"",
+	}, "\n")
+	svc, upstream, account := newOpenAIGeminiCompatTestService(t, upstreamBody)
+
+	result, err := svc.ForwardAsGemini(context.Background(), c, account, body, "gpt-5.4", false, "")
+	require.NoError(t, err)
+	require.Equal(t, "gpt-5.4", result.Model)
+	require.False(t, result.Stream)
+	require.Equal(t, 5, result.Usage.InputTokens)
+	require.Equal(t, 3, result.Usage.OutputTokens)
+	require.NotNil(t, result.ReasoningEffort)
+	require.Equal(t, "high", *result.ReasoningEffort)
+
+	require.Equal(t, "high", gjson.GetBytes(upstream.lastBody, "reasoning.effort").String())
+	require.False(t, gjson.GetBytes(upstream.lastBody, "temperature").Exists())
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	out := rec.Body.Bytes()
+	require.Equal(t, "hi", gjson.GetBytes(out, "candidates.0.content.parts.0.text").String())
+	require.Equal(t, "get_weather", gjson.GetBytes(out, "candidates.0.content.parts.1.functionCall.name").String())
+	require.Equal(t, "Paris", gjson.GetBytes(out, "candidates.0.content.parts.1.functionCall.args.city").String())
+	require.Equal(t, "STOP", gjson.GetBytes(out, "candidates.0.finishReason").String())
+	require.Equal(t, int64(8), gjson.GetBytes(out, "usageMetadata.totalTokenCount").Int())
+}
+
+func TestOpenAIForwardAsGemini_Streaming(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	body := []byte(`{"contents":[{"role":"user","parts":[{"text":"hello"}]}]}`)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1beta/models/gpt-5.4:streamGenerateContent?alt=sse", bytes.NewReader(body))
+
+	upstreamBody := strings.Join([]string{
+		`data: {"type":"response.created","response":{"id":"resp_2","status":"in_progress"}}`,
+		"",
+		`data: {"type":"response.output_text.delta","delta":"Hel"}`,
+		"",
+		`data: {"type":"response.output_text.delta","delta":"lo"}`,
+		"",
+		`data: {"type":"response.completed","response":{"id":"resp_2","status":"completed","usage":{"input_tokens":4,"output_tokens":2,"total_tokens":6}}}`,
+		"",
+	}, "\n")
+	svc, _, account := newOpenAIGeminiCompatTestService(t, upstreamBody)
+
+	result, err := svc.ForwardAsGemini(context.Background(), c, account, body, "gpt-5.4", true, "")
+	require.NoError(t, err)
+	require.True(t, result.Stream)
+	require.NotNil(t, result.FirstTokenMs)
+	require.Equal(t, 4, result.Usage.InputTokens)
+
+	var chunks []string
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if strings.HasPrefix(line, "data: ") {
+			chunks = append(chunks, strings.TrimPrefix(line, "data: "))
+		}
+	}
+	require.Len(t, chunks, 2)
+	require.Equal(t, "Hel", gjson.Get(chunks[0], "candidates.0.content.parts.0.text").String())
+	require.Equal(t, "lo", gjson.Get(chunks[1], "candidates.0.content.parts.0.text").String())
+	require.Equal(t, "STOP", gjson.Get(chunks[1], "candidates.0.finishReason").String())
+	require.Equal(t, int64(6), gjson.Get(chunks[1], "usageMetadata.totalTokenCount").Int())
+	require.NotContains(t, rec.Body.String(), "[DONE]")
+}
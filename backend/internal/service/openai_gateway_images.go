@@ -0,0 +1,254 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"go.uber.org/zap"
+)
+
+// OpenAI images 端点类型
+const (
+	ImagesEndpointGenerations = "generations"
+	ImagesEndpointEdits       = "edits"
+)
+
+// defaultOpenAIImageSizeTier OpenAI 未指定 size 时默认输出 1024x1024，按 1K 计费。
+const defaultOpenAIImageSizeTier = "1K"
+
+// maxImagesMultipartMemory 解析 images edits multipart 时保留在内存中的上限，超出部分落临时文件。
+const maxImagesMultipartMemory = 32 << 20
+
+// SupportsOpenAIImages 判断账号是否可以承接 /v1/images 请求。
+// OAuth 账号走 ChatGPT internal API，没有 images 端点，只有 API Key / Azure 账号可用。
+func SupportsOpenAIImages(account *Account) bool {
+	return account != nil && account.Platform == PlatformOpenAI && (account.Type == AccountTypeAPIKey || account.Type == AccountTypeAzure)
+}
+
+// ParseImagesRequest parses an images request body. generations uses JSON;
+// edits uses multipart/form-data whose image/image[]/mask files are loaded
+// into the returned request.
+func ParseImagesRequest(contentType string, body []byte) (*apicompat.ImagesRequest, error) {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType != "multipart/form-data" {
+		var req apicompat.ImagesRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			return nil, fmt.Errorf("parse images request: %w", err)
+		}
+		return &req, nil
+	}
+
+	form, err := multipart.NewReader(bytes.NewReader(body), params["boundary"]).ReadForm(maxImagesMultipartMemory)
+	if err != nil {
+		return nil, fmt.Errorf("parse multipart form: %w", err)
+	}
+	defer func() { _ = form.RemoveAll() }()
+
+	value := func(key string) string {
+		if vs := form.Value[key]; len(vs) > 0 {
+			return strings.TrimSpace(vs[0])
+		}
+		return ""
+	}
+	req := &apicompat.ImagesRequest{
+		Model:          value("model"),
+		Prompt:         value("prompt"),
+		Size:           value("size"),
+		Quality:        value("quality"),
+		ResponseFormat: value("response_format"),
+		Background:     value("background"),
+		OutputFormat:   value("output_format"),
+		User:           value("user"),
+	}
+	if raw := value("n"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid n: %q", raw)
+		}
+		req.N = &n
+	}
+
+	for _, key := range []string{"image", "image[]"} {
+		for _, fh := range form.File[key] {
+			img, err := readImagesFormFile(fh)
+			if err != nil {
+				return nil, err
+			}
+			req.Images = append(req.Images, img)
+		}
+	}
+	if fhs := form.File["mask"]; len(fhs) > 0 {
+		mask, err := readImagesFormFile(fhs[0])
+		if err != nil {
+			return nil, err
+		}
+		req.Mask = &mask
+	}
+	return req, nil
+}
+
+func readImagesFormFile(fh *multipart.FileHeader) (apicompat.ImageInput, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return apicompat.ImageInput{}, fmt.Errorf("open %s: %w", fh.Filename, err)
+	}
+	defer func() { _ = f.Close() }()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return apicompat.ImageInput{}, fmt.Errorf("read %s: %w", fh.Filename, err)
+	}
+	mimeType := fh.Header.Get("Content-Type")
+	if mimeType == "" || mimeType == "application/octet-stream" {
+		mimeType = http.DetectContentType(data)
+	}
+	return apicompat.ImageInput{MimeType: mimeType, Data: data}, nil
+}
+
+// ForwardImages forwards an OpenAI images request to the upstream
+// /v1/images/{endpoint} of an API Key account. JSON and multipart bodies are
+// forwarded as-is except for model mapping. The number of returned images is
+// billed against the image size tier derived from the request size.
+func (s *OpenAIGatewayService) ForwardImages(
+	ctx context.Context,
+	c *gin.Context,
+	account *Account,
+	endpoint string,
+	contentType string,
+	body []byte,
+	req *apicompat.ImagesRequest,
+) (*OpenAIForwardResult, error) {
+	startTime := time.Now()
+
+	if !SupportsOpenAIImages(account) {
+		writeChatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Images are not supported by this account")
+		return nil, fmt.Errorf("account %d does not support images", account.ID)
+	}
+
+	originalModel := req.Model
+	billingModel := resolveOpenAIForwardModel(account, originalModel, "")
+	upstreamModel := normalizeOpenAIModelForUpstream(account, billingModel)
+	// 原始 body 中仍是客户端请求的模型（渠道映射只改了 req.Model），统一改写为上游模型
+	body, contentType, err := replaceImagesRequestModel(contentType, body, upstreamModel)
+	if err != nil {
+		writeChatCompletionsError(c, http.StatusBadRequest, "invalid_request_error", "Failed to parse request body")
+		return nil, err
+	}
+
+	logger.L().Debug("openai images: model mapping applied",
+		zap.Int64("account_id", account.ID),
+		zap.String("endpoint", endpoint),
+		zap.String("original_model", originalModel),
+		zap.String("upstream_model", upstreamModel),
+	)
+
+	resp, respBody, err := s.sendOpenAIUnaryRequest(ctx, c, account, openAIUnaryRequest{
+		Path:          "images/" + endpoint,
+		UpstreamModel: upstreamModel,
+		ContentType:   contentType,
+		Body:          body,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	writeOpenAIPassthroughResponseHeaders(c.Writer.Header(), resp.Header, s.responseHeaderFilter)
+	c.Data(resp.StatusCode, "application/json", respBody)
+
+	imageSize := apicompat.ImageSizeTier(req.Size)
+	if imageSize == "" {
+		imageSize = defaultOpenAIImageSizeTier
+	}
+	return &OpenAIForwardResult{
+		RequestID:     resp.Header.Get("x-request-id"),
+		Usage:         extractOpenAIImagesUsage(respBody),
+		Model:         originalModel,
+		BillingModel:  billingModel,
+		UpstreamModel: upstreamModel,
+		Duration:      time.Since(startTime),
+		ImageCount:    int(gjson.GetBytes(respBody, "data.#").Int()),
+		ImageSize:     imageSize,
+	}, nil
+}
+
+// extractOpenAIImagesUsage 解析 gpt-image 系列返回的 usage（dall-e 不返回 usage）。
+func extractOpenAIImagesUsage(body []byte) OpenAIUsage {
+	return OpenAIUsage{
+		InputTokens:  int(gjson.GetBytes(body, "usage.input_tokens").Int()),
+		OutputTokens: int(gjson.GetBytes(body, "usage.output_tokens").Int()),
+	}
+}
+
+// replaceImagesRequestModel 替换 images 请求中的 model 字段。
+func replaceImagesRequestModel(contentType string, body []byte, model string) ([]byte, string, error) {
+	return replaceImagesRequestField(contentType, body, "model", model)
+}
+
+// ReplaceImagesRequestPrompt 替换 images 请求中的 prompt 字段（安全策略 mask 后回写）。
+func ReplaceImagesRequestPrompt(contentType string, body []byte, prompt string) ([]byte, error) {
+	newBody, _, err := replaceImagesRequestField(contentType, body, "prompt", prompt)
+	return newBody, err
+}
+
+// replaceImagesRequestField 替换 images 请求中的文本字段。
+// multipart 请求逐个复制 part，只改写该字段，其余字段与文件保持原样。
+func replaceImagesRequestField(contentType string, body []byte, name, value string) ([]byte, string, error) {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType != "multipart/form-data" {
+		newBody, err := sjson.SetBytes(body, name, value)
+		return newBody, contentType, err
+	}
+
+	reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	if err := writer.SetBoundary(params["boundary"]); err != nil {
+		return nil, "", err
+	}
+	replaced := false
+	for {
+		part, err := reader.NextRawPart()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("read multipart part: %w", err)
+		}
+		dst, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return nil, "", err
+		}
+		if part.FormName() == name && part.FileName() == "" {
+			_, err = io.WriteString(dst, value)
+			replaced = true
+		} else {
+			_, err = io.Copy(dst, part)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if !replaced {
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), contentType, nil
+}
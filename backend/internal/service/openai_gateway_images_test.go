@@ -0,0 +1,190 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/apicompat"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestBuildOpenAIEndpointURL_Images(t *testing.T) {
+	require.Equal(t, "https://api.openai.com/v1/images/generations", buildOpenAIEndpointURL("https://api.openai.com", "images/"+ImagesEndpointGenerations))
+	require.Equal(t, "https://example.com/v1/images/edits", buildOpenAIEndpointURL("https://example.com/v1/", "images/"+ImagesEndpointEdits))
+	require.Equal(t, "https://example.com/x/images/edits", buildOpenAIEndpointURL("https://example.com/x/images/edits", "images/"+ImagesEndpointEdits))
+}
+
+func buildImagesEditMultipart(t *testing.T) (string, []byte) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	require.NoError(t, w.WriteField("model", "image-alias"))
+	require.NoError(t, w.WriteField("prompt", "make it blue"))
+	require.NoError(t, w.WriteField("n", "2"))
+	require.NoError(t, w.WriteField("size", "1024x1024"))
+	part, err := w.CreateFormFile("image[]", "fox.png")
+	require.NoError(t, err)
+	_, err = part.Write([]byte("\x89PNG\r\n\x1a\nfake"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return w.FormDataContentType(), buf.Bytes()
+}
+
+func TestParseImagesRequest(t *testing.T) {
+	req, err := ParseImagesRequest("application/json", []byte(`{"model":"gpt-image-1","prompt":"a fox","n":3,"size":"1536x1024"}`))
+	require.NoError(t, err)
+	require.Equal(t, "gpt-image-1", req.Model)
+	require.Equal(t, 3, apicompat.ImagesRequestCount(req))
+
+	contentType, body := buildImagesEditMultipart(t)
+	req, err = ParseImagesRequest(contentType, body)
+	require.NoError(t, err)
+	require.Equal(t, "image-alias", req.Model)
+	require.Equal(t, "make it blue", req.Prompt)
+	require.Equal(t, 2, apicompat.ImagesRequestCount(req))
+	require.Len(t, req.Images, 1)
+	require.Equal(t, "image/png", req.Images[0].MimeType)
+}
+
+func TestReplaceImagesRequestModel_Multipart(t *testing.T) {
+	contentType, body := buildImagesEditMultipart(t)
+
+	newBody, newContentType, err := replaceImagesRequestModel(contentType, body, "gpt-image-1")
+	require.NoError(t, err)
+	require.Equal(t, contentType, newContentType)
+
+	req, err := ParseImagesRequest(newContentType, newBody)
+	require.NoError(t, err)
+	require.Equal(t, "gpt-image-1", req.Model)
+	require.Equal(t, "make it blue", req.Prompt)
+	require.Len(t, req.Images, 1)
+}
+
+func TestOpenAIForwardImages_CountsImagesAndSizeTier(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	body := []byte(`{"model":"image-alias","prompt":"a fox","n":2,"size":"1536x1024"}`)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/images/generations", bytes.NewReader(body))
+
+	upstream := &httpUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}, "X-Request-Id": []string{"rid_img"}},
+		Body: io.NopCloser(strings.NewReader(
+			`{"created":1,"data":[{"b64_json":"AA=="},{"b64_json":"BB=="}],"usage":{"input_tokens":12,"output_tokens":4000,"total_tokens":4012}}`,
+		)),
+	}}
+	svc := &OpenAIGatewayService{cfg: &config.Config{}, httpUpstream: upstream}
+	account := &Account{
+		ID:          1,
+		Platform:    PlatformOpenAI,
+		Type:        AccountTypeAPIKey,
+		Concurrency: 1,
+		Credentials: map[string]any{
+			"api_key":       "sk-test",
+			"model_mapping": map[string]any{"image-alias": "gpt-image-1"},
+		},
+	}
+	req, err := ParseImagesRequest("application/json", body)
+	require.NoError(t, err)
+
+	result, err := svc.ForwardImages(context.Background(), c, account, ImagesEndpointGenerations, "application/json", body, req)
+	require.NoError(t, err)
+	require.Equal(t, "https://api.openai.com/v1/images/generations", upstream.lastReq.URL.String())
+	require.Equal(t, "gpt-image-1", gjson.GetBytes(upstream.lastBody, "model").String())
+
+	require.Equal(t, 2, result.ImageCount)
+	require.Equal(t, "2K", result.ImageSize)
+	require.Equal(t, 12, result.Usage.InputTokens)
+	require.Equal(t, 4000, result.Usage.OutputTokens)
+	require.Equal(t, "gpt-image-1", result.UpstreamModel)
+	require.Equal(t, "rid_img", result.RequestID)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestForwardImagesViaGeminiNative_LoopsAndConverts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/images/generations", nil)
+
+	n := 2
+	req := &apicompat.ImagesRequest{Model: "gemini-3-pro-image-preview", Prompt: "a fox", N: &n, Size: "4096x4096"}
+	calls := 0
+	forward := func(ctx context.Context, inner *gin.Context, body []byte) (*ForwardResult, error) {
+		calls++
+		require.Equal(t, "4K", gjson.GetBytes(body, "generationConfig.imageConfig.imageSize").String())
+		inner.Set("probe", calls)
+		inner.Data(http.StatusOK, "application/json", []byte(`{"candidates":[{"content":{"parts":[{"inlineData":{"mimeType":"image/png","data":"AAAA"}}]}}]}`))
+		return &ForwardResult{
+			RequestID:     "rid_gem",
+			Usage:         ClaudeUsage{InputTokens: 5, OutputTokens: 1120},
+			UpstreamModel: "gemini-3-pro-image-preview",
+			ImageSize:     "4K",
+		}, nil
+	}
+
+	result, err := forwardImagesViaGeminiNative(context.Background(), c, &Account{ID: 9, Platform: PlatformGemini}, req, forward)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+	require.Equal(t, 2, result.ImageCount)
+	require.Equal(t, "4K", result.ImageSize)
+	require.Equal(t, 10, result.Usage.InputTokens)
+	require.Equal(t, 2240, result.Usage.OutputTokens)
+
+	// 内层捕获上下文与外层共享 Keys，ops 记录等副作用对外层可见
+	probe, ok := c.Get("probe")
+	require.True(t, ok)
+	require.Equal(t, 2, probe)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, int64(2), gjson.Get(rec.Body.String(), "data.#").Int())
+	require.Equal(t, "AAAA", gjson.Get(rec.Body.String(), "data.0.b64_json").String())
+}
+
+func TestForwardImagesViaGeminiNative_RejectsNonImageModel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/images/generations", nil)
+
+	req := &apicompat.ImagesRequest{Model: "gemini-2.5-pro", Prompt: "a fox"}
+	_, err := forwardImagesViaGeminiNative(context.Background(), c, &Account{ID: 9, Platform: PlatformGemini}, req,
+		func(context.Context, *gin.Context, []byte) (*ForwardResult, error) {
+			t.Fatal("forward should not be called")
+			return nil, nil
+		})
+	require.Error(t, err)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "invalid_request_error", gjson.Get(rec.Body.String(), "error.type").String())
+}
+
+func TestForwardImagesViaGeminiNative_ConvertsUpstreamError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/images/generations", nil)
+
+	req := &apicompat.ImagesRequest{Model: "gemini-2.5-flash-image", Prompt: "a fox"}
+	_, err := forwardImagesViaGeminiNative(context.Background(), c, &Account{ID: 9, Platform: PlatformGemini}, req,
+		func(_ context.Context, inner *gin.Context, _ []byte) (*ForwardResult, error) {
+			inner.Data(http.StatusBadRequest, "application/json", []byte(`{"error":{"code":400,"message":"prompt blocked","status":"INVALID_ARGUMENT"}}`))
+			return nil, io.ErrUnexpectedEOF
+		})
+	require.Error(t, err)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.Equal(t, "prompt blocked", gjson.Get(rec.Body.String(), "error.message").String())
+}
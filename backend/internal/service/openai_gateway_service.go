@@ -233,6 +233,9 @@ type OpenAIForwardResult struct {
 	ResponseHeaders http.Header
 	Duration        time.Duration
 	FirstTokenMs    *int
+	// ImageCount/ImageSize 仅 /v1/images 请求填写，非零时按图片层级计费
+	ImageCount int
+	ImageSize  string
 }
 
 type OpenAIWSRetryMetricsSnapshot struct {
@@ -1766,6 +1769,12 @@ func (s *OpenAIGatewayService) GetAccessToken(ctx context.Context, account *Acco
 			return "", "", errors.New("api_key not found in credentials")
 		}
 		return apiKey, "apikey", nil
+	case AccountTypeAzure:
+		apiKey := account.GetOpenAIApiKey()
+		if apiKey == "" {
+			return "", "", errors.New("api_key not found in credentials")
+		}
+		return apiKey, "azure", nil
 	default:
 		return "", "", fmt.Errorf("unsupported account type: %s", account.Type)
 	}
@@ -2020,7 +2029,7 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 
 		// Also handle max_completion_tokens (similar logic)
 		if _, hasMaxCompletionTokens := reqBody["max_completion_tokens"]; hasMaxCompletionTokens {
-			if account.Type == AccountTypeAPIKey || account.Platform != PlatformOpenAI {
+			if account.Type == AccountTypeAPIKey || account.Type == AccountTypeAzure || account.Platform != PlatformOpenAI {
 				delete(reqBody, "max_completion_tokens")
 				bodyModified = true
 				markPatchDelete("max_completion_tokens")
@@ -2302,10 +2311,7 @@ func (s *OpenAIGatewayService) Forward(ctx context.Context, c *gin.Context, acco
 		}
 
 		// Get proxy URL
-		proxyURL := ""
-		if account.ProxyID != nil && account.Proxy != nil {
-			proxyURL = account.Proxy.URL()
-		}
+		proxyURL := account.ProxyURL()
 
 		// Send request
 		upstreamStart := time.Now()
@@ -2475,6 +2481,13 @@ func (s *OpenAIGatewayService) forwardOpenAIPassthrough(
 		reqStream = gjson.GetBytes(body, "stream").Bool()
 	}
 
+	// Azure 透传：上游按部署名路由，仅将 body.model 替换为部署名
+	if account != nil && account.Type == AccountTypeAzure && reqModel != "" {
+		if deployment := normalizeOpenAIModelForUpstream(account, account.GetMappedModel(reqModel)); deployment != reqModel {
+			body = s.ReplaceModelInBody(body, deployment)
+		}
+	}
+
 	logger.LegacyPrintf("service.openai_gateway",
 		"[OpenAI 自动透传] 命中自动透传分支: account=%d name=%s type=%s model=%s stream=%v",
 		account.ID,
@@ -2511,10 +2524,7 @@ func (s *OpenAIGatewayService) forwardOpenAIPassthrough(
 		return nil, err
 	}
 
-	proxyURL := ""
-	if account.ProxyID != nil && account.Proxy != nil {
-		proxyURL = account.Proxy.URL()
-	}
+	proxyURL := account.ProxyURL()
 
 	setOpsUpstreamRequestBody(c, body)
 	if c != nil {
@@ -2643,8 +2653,16 @@ func (s *OpenAIGatewayService) buildUpstreamRequestOpenAIPassthrough(
 			}
 			targetURL = buildOpenAIResponsesURL(validatedURL)
 		}
+	case AccountTypeAzure:
+		validatedURL, err := s.validateUpstreamBaseURL(account.GetOpenAIBaseURL())
+		if err != nil {
+			return nil, err
+		}
+		targetURL = buildAzureOpenAIResponsesURL(validatedURL, openAIResponsesRequestPathSuffix(c), account.GetAzureOpenAIAPIVersion())
+	}
+	if account.Type != AccountTypeAzure {
+		targetURL = appendOpenAIResponsesRequestPathSuffix(targetURL, openAIResponsesRequestPathSuffix(c))
 	}
-	targetURL = appendOpenAIResponsesRequestPathSuffix(targetURL, openAIResponsesRequestPathSuffix(c))
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(body))
 	if err != nil {
@@ -2669,7 +2687,8 @@ func (s *OpenAIGatewayService) buildUpstreamRequestOpenAIPassthrough(
 	req.Header.Del("authorization")
 	req.Header.Del("x-api-key")
 	req.Header.Del("x-goog-api-key")
-	req.Header.Set("authorization", "Bearer "+token)
+	req.Header.Del("api-key")
+	setOpenAIUpstreamAuth(req, account, token)
 
 	// OAuth 透传到 ChatGPT internal API 时补齐必要头。
 	if account.Type == AccountTypeOAuth {
@@ -3097,10 +3116,19 @@ func (s *OpenAIGatewayService) buildUpstreamRequest(ctx context.Context, c *gin.
 			}
 			targetURL = buildOpenAIResponsesURL(validatedURL)
 		}
+	case AccountTypeAzure:
+		// Azure accounts use the resource endpoint with api-version query
+		validatedURL, err := s.validateUpstreamBaseURL(account.GetOpenAIBaseURL())
+		if err != nil {
+			return nil, err
+		}
+		targetURL = buildAzureOpenAIResponsesURL(validatedURL, openAIResponsesRequestPathSuffix(c), account.GetAzureOpenAIAPIVersion())
 	default:
 		targetURL = openaiPlatformAPIURL
 	}
-	targetURL = appendOpenAIResponsesRequestPathSuffix(targetURL, openAIResponsesRequestPathSuffix(c))
+	if account.Type != AccountTypeAzure {
+		targetURL = appendOpenAIResponsesRequestPathSuffix(targetURL, openAIResponsesRequestPathSuffix(c))
+	}
 
 	req, err := http.NewRequestWithContext(ctx, "POST", targetURL, bytes.NewReader(body))
 	if err != nil {
@@ -3108,7 +3136,7 @@ func (s *OpenAIGatewayService) buildUpstreamRequest(ctx context.Context, c *gin.
 	}
 
 	// Set authentication header
-	req.Header.Set("authorization", "Bearer "+token)
+	setOpenAIUpstreamAuth(req, account, token)
 
 	// Set headers specific to OAuth accounts (ChatGPT internal API)
 	if account.Type == AccountTypeOAuth {
@@ -4321,6 +4349,8 @@ type OpenAIRecordUsageInput struct {
 	IPAddress          string // 请求的客户端 IP 地址
 	RequestPayloadHash string
 	APIKeyService      APIKeyQuotaUpdater
+	BatchDiscount      float64 // 批处理折扣倍率（叠加在费率倍数之上），0 表示不打折
+	ResponseCacheHit   bool    // 命中响应缓存：按命中倍率计费，不计账号成本
 	ChannelUsageFields
 }
 
@@ -4329,8 +4359,10 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	result := input.Result
 
 	// 跳过所有 token 均为零的用量记录——上游未返回 usage 时不应写入数据库
+	// 图片请求按张计费，即使上游没有返回 token usage 也需要记录
 	if result.Usage.InputTokens == 0 && result.Usage.OutputTokens == 0 &&
-		result.Usage.CacheCreationInputTokens == 0 && result.Usage.CacheReadInputTokens == 0 {
+		result.Usage.CacheCreationInputTokens == 0 && result.Usage.CacheReadInputTokens == 0 &&
+		result.ImageCount == 0 {
 		return nil
 	}
 
@@ -4367,6 +4399,12 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		}
 		multiplier = resolver.Resolve(ctx, user.ID, *apiKey.GroupID, apiKey.Group.RateMultiplier)
 	}
+	if input.BatchDiscount > 0 {
+		multiplier *= input.BatchDiscount
+	}
+	if input.ResponseCacheHit {
+		multiplier *= responseCacheHitMultiplier(s.cfg)
+	}
 
 	var cost *CostBreakdown
 	var err error
@@ -4384,7 +4422,9 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	if result.ServiceTier != nil {
 		serviceTier = strings.TrimSpace(*result.ServiceTier)
 	}
-	if s.resolver != nil && apiKey.Group != nil {
+	if result.ImageCount > 0 {
+		cost = calculateImageUsageCost(ctx, s.billingService, s.resolver, apiKey, billingModel, result.ImageSize, result.ImageCount, tokens, multiplier)
+	} else if s.resolver != nil && apiKey.Group != nil {
 		gid := apiKey.Group.ID
 		cost, err = s.billingService.CalculateCostUnified(CostInput{
 			Ctx:            ctx,
@@ -4413,6 +4453,10 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	// Create usage log
 	durationMs := int(result.Duration.Milliseconds())
 	accountRateMultiplier := account.BillingRateMultiplier()
+	if input.ResponseCacheHit {
+		// 缓存命中未消耗上游账号额度
+		accountRateMultiplier = 0
+	}
 	requestID := resolveUsageBillingRequestID(ctx, result.RequestID)
 
 	// 确定 RequestedModel（渠道映射前的原始模型）
@@ -4438,6 +4482,8 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 		CacheCreationTokens: result.Usage.CacheCreationInputTokens,
 		CacheReadTokens:     result.Usage.CacheReadInputTokens,
 		ImageOutputTokens:   result.Usage.ImageOutputTokens,
+		ImageCount:          result.ImageCount,
+		ImageSize:           optionalTrimmedStringPtr(result.ImageSize),
 	}
 	if cost != nil {
 		usageLog.InputCost = cost.InputCost
@@ -4460,9 +4506,15 @@ func (s *OpenAIGatewayService) RecordUsage(ctx context.Context, input *OpenAIRec
 	usageLog.ChannelID = optionalInt64Ptr(input.ChannelID)
 	usageLog.ModelMappingChain = optionalTrimmedStringPtr(input.ModelMappingChain)
 	// 设置计费模式
-	if cost != nil && cost.BillingMode != "" {
+	if input.ResponseCacheHit {
+		billingMode := string(BillingModeResponseCache)
+		usageLog.BillingMode = &billingMode
+	} else if cost != nil && cost.BillingMode != "" {
 		billingMode := cost.BillingMode
 		usageLog.BillingMode = &billingMode
+	} else if result.ImageCount > 0 {
+		billingMode := string(BillingModeImage)
+		usageLog.BillingMode = &billingMode
 	} else {
 		billingMode := string(BillingModeToken)
 		usageLog.BillingMode = &billingMode
@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAIUnaryRequest 一次非流式 OpenAI 端点请求（embeddings、images 等），响应原样返回给客户端。
+type openAIUnaryRequest struct {
+	// Path 相对 /v1 的端点路径，如 "embeddings"、"images/generations"
+	Path string
+	// UpstreamModel 映射后的上游模型，Azure 账号按部署名路由
+	UpstreamModel string
+	ContentType   string
+	Body          []byte
+}
+
+// sendOpenAIUnaryRequest 发送请求并读取成功响应体。
+// 网络错误、超大响应已写入客户端响应；上游 4xx/5xx 按账号策略透传或返回 UpstreamFailoverError。
+func (s *OpenAIGatewayService) sendOpenAIUnaryRequest(
+	ctx context.Context,
+	c *gin.Context,
+	account *Account,
+	req openAIUnaryRequest,
+) (*http.Response, []byte, error) {
+	baseURL, token, err := s.openAIUnaryEndpoint(ctx, account)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get access token: %w", err)
+	}
+
+	validatedURL, err := s.validateUpstreamBaseURL(baseURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	targetURL := buildOpenAIEndpointURL(validatedURL, req.Path)
+	if account.Type == AccountTypeAzure {
+		targetURL = buildAzureOpenAIDeploymentURL(validatedURL, req.UpstreamModel, req.Path, account.GetAzureOpenAIAPIVersion())
+	}
+
+	upstreamReq, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(req.Body))
+	if err != nil {
+		return nil, nil, fmt.Errorf("build upstream request: %w", err)
+	}
+	setOpenAIUpstreamAuth(upstreamReq, account, token)
+	upstreamReq.Header.Set("content-type", req.ContentType)
+	upstreamReq.Header.Set("accept", "application/json")
+	if customUA := account.GetOpenAIUserAgent(); customUA != "" {
+		upstreamReq.Header.Set("user-agent", customUA)
+	}
+
+	// multipart 请求体可能包含大文件，不写入 ops 上下文
+	if !strings.HasPrefix(req.ContentType, "multipart/") {
+		setOpsUpstreamRequestBody(c, req.Body)
+	}
+
+	upstreamStart := time.Now()
+	resp, err := s.httpUpstream.Do(upstreamReq, account.ProxyURL(), account.ID, account.Concurrency)
+	SetOpsLatencyMs(c, OpsUpstreamLatencyMsKey, time.Since(upstreamStart).Milliseconds())
+	if err != nil {
+		safeErr := sanitizeUpstreamErrorMessage(err.Error())
+		setOpsUpstreamError(c, 0, safeErr, "")
+		appendOpsUpstreamError(c, OpsUpstreamErrorEvent{
+			Platform:           account.Platform,
+			AccountID:          account.ID,
+			AccountName:        account.Name,
+			UpstreamStatusCode: 0,
+			Kind:               "request_error",
+			Message:            safeErr,
+		})
+		writeChatCompletionsError(c, http.StatusBadGateway, "upstream_error", "Upstream request failed")
+		return nil, nil, fmt.Errorf("upstream request failed: %s", safeErr)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 400 {
+		if s.shouldFailoverUpstreamError(resp.StatusCode) {
+			return nil, nil, s.handleFailoverErrorResponsePassthrough(ctx, resp, c, account, req.Body)
+		}
+		return nil, nil, s.handleErrorResponsePassthrough(ctx, resp, c, account, req.Body)
+	}
+
+	respBody, err := readUpstreamResponseBodyLimited(resp.Body, resolveUpstreamResponseReadLimit(s.cfg))
+	if err != nil {
+		if errors.Is(err, ErrUpstreamResponseBodyTooLarge) {
+			setOpsUpstreamError(c, http.StatusBadGateway, "upstream response too large", "")
+			writeChatCompletionsError(c, http.StatusBadGateway, "upstream_error", "Upstream response too large")
+		}
+		return nil, nil, err
+	}
+	return resp, respBody, nil
+}
+
+// openAIUnaryEndpoint 返回上游 base URL 与鉴权 token。
+// 上游透传账号使用凭证中的 base_url + api_key（必填，无默认端点）。
+func (s *OpenAIGatewayService) openAIUnaryEndpoint(ctx context.Context, account *Account) (string, string, error) {
+	if account.Type == AccountTypeUpstream {
+		baseURL := strings.TrimSpace(account.GetCredential("base_url"))
+		if baseURL == "" {
+			return "", "", errors.New("upstream account missing base_url in credentials")
+		}
+		apiKey := strings.TrimSpace(account.GetCredential("api_key"))
+		if apiKey == "" {
+			return "", "", errors.New("upstream account missing api_key in credentials")
+		}
+		return baseURL, apiKey, nil
+	}
+	token, _, err := s.GetAccessToken(ctx, account)
+	if err != nil {
+		return "", "", err
+	}
+	return account.GetOpenAIBaseURL(), token, nil
+}
+
+// buildOpenAIEndpointURL 组装 OpenAI /v1 下的端点，规则同 buildOpenAIResponsesURL：
+// base 已以端点路径结尾时原样使用，以 /v1 结尾时只拼接路径，否则补全 /v1。
+func buildOpenAIEndpointURL(base, path string) string {
+	normalized := strings.TrimRight(strings.TrimSpace(base), "/")
+	suffix := "/" + strings.Trim(path, "/")
+	if strings.HasSuffix(normalized, suffix) {
+		return normalized
+	}
+	if strings.HasSuffix(normalized, "/v1") {
+		return normalized + suffix
+	}
+	return normalized + "/v1" + suffix
+}
@@ -11,6 +11,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/Wei-Shaw/sub2api/internal/pkg/proxyurl"
 	openaiwsv2 "github.com/Wei-Shaw/sub2api/internal/service/openai_ws_v2"
 	coderws "github.com/coder/websocket"
 	"github.com/coder/websocket/wsjson"
@@ -82,6 +83,11 @@ func (d *coderOpenAIWSClientDialer) Dial(
 		CompressionMode: coderws.CompressionContextTakeover,
 	}
 	if proxy := strings.TrimSpace(proxyURL); proxy != "" {
+		// 代理池占位 URL 解析为池内当前首选代理（解析失败直接报错，不回退直连）
+		proxy, err := proxyurl.Resolve(proxy)
+		if err != nil {
+			return nil, 0, nil, err
+		}
 		proxyClient, err := d.proxyHTTPClient(proxy)
 		if err != nil {
 			return nil, 0, nil, err
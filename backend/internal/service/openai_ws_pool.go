@@ -1277,6 +1277,44 @@ func (p *openAIWSConnPool) AccountPoolLoad(accountID int64) (inflight int, waite
 	return inflight, waiters, len(ap.conns)
 }
 
+// OpenAIWSPoolSizeSnapshot 连接池规模快照（所有账号汇总）。
+type OpenAIWSPoolSizeSnapshot struct {
+	Accounts int
+	Conns    int
+	Leased   int
+	Idle     int
+	Waiters  int
+	Creating int
+}
+
+// SnapshotSizes 汇总所有账号连接池的连接数、占用与排队情况。
+func (p *openAIWSConnPool) SnapshotSizes() OpenAIWSPoolSizeSnapshot {
+	var out OpenAIWSPoolSizeSnapshot
+	if p == nil {
+		return out
+	}
+	p.accounts.Range(func(_, value any) bool {
+		ap, ok := value.(*openAIWSAccountPool)
+		if !ok || ap == nil {
+			return true
+		}
+		ap.mu.Lock()
+		inflight, waiters := accountPoolLoadLocked(ap)
+		conns := len(ap.conns)
+		creating := ap.creating
+		ap.mu.Unlock()
+
+		out.Accounts++
+		out.Conns += conns
+		out.Leased += inflight
+		out.Idle += conns - inflight
+		out.Waiters += waiters
+		out.Creating += creating
+		return true
+	})
+	return out
+}
+
 func (p *openAIWSConnPool) ensureTargetIdleAsync(accountID int64) {
 	if p == nil || accountID <= 0 {
 		return
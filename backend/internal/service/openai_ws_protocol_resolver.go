@@ -42,6 +42,10 @@ func (r *defaultOpenAIWSProtocolResolver) Resolve(account *Account) OpenAIWSProt
 	if account.IsOpenAIWSForceHTTPEnabled() {
 		return openAIWSHTTPDecision("account_force_http")
 	}
+	if account.IsOpenAIAzure() {
+		// Azure OpenAI 不提供 Responses WebSocket 端点
+		return openAIWSHTTPDecision("azure_http_only")
+	}
 	if r == nil || r.cfg == nil {
 		return openAIWSHTTPDecision("config_missing")
 	}
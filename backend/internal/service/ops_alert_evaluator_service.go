@@ -36,6 +36,8 @@ type OpsAlertEvaluatorService struct {
 	opsRepo      OpsRepository
 	emailService *EmailService
 
+	notificationService *NotificationService
+
 	redisClient *redis.Client
 	cfg         *config.Config
 	instanceID  string
@@ -80,6 +82,11 @@ func NewOpsAlertEvaluatorService(
 	}
 }
 
+// SetNotificationService 设置通知渠道服务（可选依赖）
+func (s *OpsAlertEvaluatorService) SetNotificationService(notificationService *NotificationService) {
+	s.notificationService = notificationService
+}
+
 func (s *OpsAlertEvaluatorService) Start() {
 	if s == nil {
 		return
@@ -196,6 +203,7 @@ func (s *OpsAlertEvaluatorService) evaluateOnce(interval time.Duration) {
 	eventsCreated := 0
 	eventsResolved := 0
 	emailsSent := 0
+	notificationsSent := 0
 
 	now := time.Now().UTC()
 	safeEnd := now.Truncate(time.Minute)
@@ -292,6 +300,7 @@ func (s *OpsAlertEvaluatorService) evaluateOnce(interval time.Duration) {
 				if s.maybeSendAlertEmail(ctx, runtimeCfg, rule, created) {
 					emailsSent++
 				}
+				notificationsSent += s.maybeSendAlertNotifications(ctx, runtimeCfg, rule, created)
 			}
 			continue
 		}
@@ -307,7 +316,7 @@ func (s *OpsAlertEvaluatorService) evaluateOnce(interval time.Duration) {
 		}
 	}
 
-	result := truncateString(fmt.Sprintf("rules=%d enabled=%d evaluated=%d created=%d resolved=%d emails_sent=%d", rulesTotal, rulesEnabled, rulesEvaluated, eventsCreated, eventsResolved, emailsSent), 2048)
+	result := truncateString(fmt.Sprintf("rules=%d enabled=%d evaluated=%d created=%d resolved=%d emails_sent=%d notifications_sent=%d", rulesTotal, rulesEnabled, rulesEvaluated, eventsCreated, eventsResolved, emailsSent, notificationsSent), 2048)
 	s.recordHeartbeatSuccess(runAt, time.Since(startedAt), result)
 }
 
@@ -699,6 +708,61 @@ func (s *OpsAlertEvaluatorService) maybeSendAlertEmail(ctx context.Context, runt
 	return anySent
 }
 
+// maybeSendAlertNotifications 将告警投递到规则配置的通知渠道，返回成功投递的渠道数。
+// 静默规则同样生效；邮件的最低级别与频率限制不适用于通知渠道。
+func (s *OpsAlertEvaluatorService) maybeSendAlertNotifications(ctx context.Context, runtimeCfg *OpsAlertRuntimeSettings, rule *OpsAlertRule, event *OpsAlertEvent) int {
+	if s == nil || s.notificationService == nil || event == nil || rule == nil || len(rule.NotifyChannelIDs) == 0 {
+		return 0
+	}
+	if runtimeCfg != nil && runtimeCfg.Silencing.Enabled {
+		if isOpsAlertSilenced(time.Now().UTC(), rule, event, runtimeCfg.Silencing) {
+			return 0
+		}
+	}
+	return s.notificationService.Send(ctx, rule.NotifyChannelIDs, buildOpsAlertNotification(rule, event))
+}
+
+func buildOpsAlertNotification(rule *OpsAlertRule, event *OpsAlertEvent) *NotificationMessage {
+	value := "-"
+	threshold := fmt.Sprintf("%.2f", rule.Threshold)
+	if event.MetricValue != nil {
+		value = fmt.Sprintf("%.2f", *event.MetricValue)
+	}
+	if event.ThresholdValue != nil {
+		threshold = fmt.Sprintf("%.2f", *event.ThresholdValue)
+	}
+	lines := []string{
+		fmt.Sprintf("Rule: %s", strings.TrimSpace(rule.Name)),
+		fmt.Sprintf("Severity: %s", strings.TrimSpace(rule.Severity)),
+		fmt.Sprintf("Metric: %s %s %s (threshold %s)", strings.TrimSpace(rule.MetricType), strings.TrimSpace(rule.Operator), value, threshold),
+		fmt.Sprintf("Fired at: %s", event.FiredAt.UTC().Format(time.RFC3339)),
+	}
+	if desc := strings.TrimSpace(event.Description); desc != "" {
+		lines = append(lines, fmt.Sprintf("Description: %s", desc))
+	}
+	data := map[string]any{
+		"rule_id":     rule.ID,
+		"event_id":    event.ID,
+		"rule_name":   rule.Name,
+		"metric_type": rule.MetricType,
+		"operator":    rule.Operator,
+		"threshold":   rule.Threshold,
+		"status":      event.Status,
+		"dimensions":  event.Dimensions,
+	}
+	if event.MetricValue != nil {
+		data["metric_value"] = *event.MetricValue
+	}
+	return &NotificationMessage{
+		EventType:  NotificationEventOpsAlert,
+		Title:      fmt.Sprintf("[Ops Alert][%s] %s", strings.TrimSpace(rule.Severity), strings.TrimSpace(rule.Name)),
+		Text:       strings.Join(lines, "\n"),
+		Severity:   strings.TrimSpace(rule.Severity),
+		Data:       data,
+		OccurredAt: event.FiredAt,
+	}
+}
+
 func buildOpsAlertEmailBody(rule *OpsAlertRule, event *OpsAlertEvent) string {
 	if rule == nil || event == nil {
 		return ""
@@ -29,7 +29,8 @@ type OpsAlertRule struct {
 	SustainedMinutes int `json:"sustained_minutes"`
 	CooldownMinutes  int `json:"cooldown_minutes"`
 
-	NotifyEmail bool `json:"notify_email"`
+	NotifyEmail      bool    `json:"notify_email"`
+	NotifyChannelIDs []int64 `json:"notify_channel_ids"` // 触发时投递的通知渠道
 
 	Filters map[string]any `json:"filters,omitempty"`
 
@@ -154,19 +154,21 @@ func (s *OpsCleanupService) runScheduled() {
 }
 
 type opsCleanupDeletedCounts struct {
-	errorLogs     int64
-	retryAttempts int64
-	alertEvents   int64
-	systemLogs    int64
-	logAudits     int64
-	systemMetrics int64
-	hourlyPreagg  int64
-	dailyPreagg   int64
+	errorLogs       int64
+	retryAttempts   int64
+	alertEvents     int64
+	systemLogs      int64
+	logAudits       int64
+	systemMetrics   int64
+	hourlyPreagg    int64
+	dailyPreagg     int64
+	auditLogs       int64
+	payloadCaptures int64
 }
 
 func (c opsCleanupDeletedCounts) String() string {
 	return fmt.Sprintf(
-		"error_logs=%d retry_attempts=%d alert_events=%d system_logs=%d log_audits=%d system_metrics=%d hourly_preagg=%d daily_preagg=%d",
+		"error_logs=%d retry_attempts=%d alert_events=%d system_logs=%d log_audits=%d system_metrics=%d hourly_preagg=%d daily_preagg=%d audit_logs=%d payload_captures=%d",
 		c.errorLogs,
 		c.retryAttempts,
 		c.alertEvents,
@@ -175,6 +177,8 @@ func (c opsCleanupDeletedCounts) String() string {
 		c.systemMetrics,
 		c.hourlyPreagg,
 		c.dailyPreagg,
+		c.auditLogs,
+		c.payloadCaptures,
 	)
 }
 
@@ -248,6 +252,26 @@ func (s *OpsCleanupService) runCleanupOnce(ctx context.Context) (opsCleanupDelet
 		out.dailyPreagg = n
 	}
 
+	// Admin audit logs (append-only; only removed by retention).
+	if days := s.cfg.Ops.Cleanup.AuditLogRetentionDays; days > 0 {
+		cutoff := now.AddDate(0, 0, -days)
+		n, err := deleteOldRowsByID(ctx, s.db, "admin_audit_logs", "created_at", cutoff, batchSize, false)
+		if err != nil {
+			return out, err
+		}
+		out.auditLogs = n
+	}
+
+	// Gateway payload captures (debugging snapshots of prompts/completions).
+	if days := s.cfg.Ops.Cleanup.PayloadCaptureRetentionDays; days > 0 {
+		cutoff := now.AddDate(0, 0, -days)
+		n, err := deleteOldRowsByID(ctx, s.db, "ops_payload_captures", "created_at", cutoff, batchSize, false)
+		if err != nil {
+			return out, err
+		}
+		out.payloadCaptures = n
+	}
+
 	return out, nil
 }
 
@@ -3,7 +3,9 @@ package service
 import (
 	"context"
 	"fmt"
+	"html"
 	"log"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -42,6 +44,8 @@ type OpsScheduledReportService struct {
 	redisClient  *redis.Client
 	cfg          *config.Config
 
+	notificationService *NotificationService
+
 	instanceID string
 	loc        *time.Location
 
@@ -89,6 +93,11 @@ func NewOpsScheduledReportService(
 	}
 }
 
+// SetNotificationService 设置通知渠道服务（可选依赖）
+func (s *OpsScheduledReportService) SetNotificationService(notificationService *NotificationService) {
+	s.notificationService = notificationService
+}
+
 func (s *OpsScheduledReportService) Start() {
 	s.StartWithContext(context.Background())
 }
@@ -103,7 +112,7 @@ func (s *OpsScheduledReportService) StartWithContext(ctx context.Context) {
 	if s.cfg != nil && !s.cfg.Ops.Enabled {
 		return
 	}
-	if s.opsService == nil || s.emailService == nil {
+	if s.opsService == nil || (s.emailService == nil && s.notificationService == nil) {
 		return
 	}
 
@@ -144,7 +153,7 @@ func (s *OpsScheduledReportService) run() {
 }
 
 func (s *OpsScheduledReportService) runOnce() {
-	if s == nil || s.opsService == nil || s.emailService == nil {
+	if s == nil || s.opsService == nil || (s.emailService == nil && s.notificationService == nil) {
 		return
 	}
 
@@ -211,6 +220,7 @@ type opsScheduledReport struct {
 	TimeRange time.Duration
 
 	Recipients []string
+	ChannelIDs []int64
 
 	ErrorDigestMinCount             int
 	AccountHealthErrorRateThreshold float64
@@ -293,6 +303,7 @@ func (s *OpsScheduledReportService) listScheduledReports(ctx context.Context, no
 			TimeRange: d.timeRange,
 
 			Recipients: recipients,
+			ChannelIDs: emailCfg.Report.ChannelIDs,
 
 			ErrorDigestMinCount:             emailCfg.Report.ErrorDigestMinCount,
 			AccountHealthErrorRateThreshold: emailCfg.Report.AccountHealthErrorRateThreshold,
@@ -306,7 +317,7 @@ func (s *OpsScheduledReportService) listScheduledReports(ctx context.Context, no
 }
 
 func (s *OpsScheduledReportService) runReport(ctx context.Context, report *opsScheduledReport, now time.Time) (int, error) {
-	if s == nil || s.opsService == nil || s.emailService == nil || report == nil {
+	if s == nil || s.opsService == nil || report == nil {
 		return 0, nil
 	}
 	if ctx == nil {
@@ -325,6 +336,24 @@ func (s *OpsScheduledReportService) runReport(ctx context.Context, report *opsSc
 		return 0, nil
 	}
 
+	subject := fmt.Sprintf("[Ops Report] %s", strings.TrimSpace(report.Name))
+
+	attempts := 0
+	if s.notificationService != nil && len(report.ChannelIDs) > 0 {
+		attempts += len(report.ChannelIDs)
+		s.notificationService.Send(ctx, report.ChannelIDs, &NotificationMessage{
+			EventType:  NotificationEventOpsReport,
+			Title:      subject,
+			Text:       opsReportHTMLToText(content),
+			Severity:   "info",
+			Data:       map[string]any{"report_type": report.ReportType},
+			OccurredAt: now,
+		})
+	}
+	if s.emailService == nil {
+		return attempts, nil
+	}
+
 	recipients := report.Recipients
 	if len(recipients) == 0 && s.userService != nil {
 		admin, err := s.userService.GetFirstAdmin(ctx)
@@ -333,12 +362,9 @@ func (s *OpsScheduledReportService) runReport(ctx context.Context, report *opsSc
 		}
 	}
 	if len(recipients) == 0 {
-		return 0, nil
+		return attempts, nil
 	}
 
-	subject := fmt.Sprintf("[Ops Report] %s", strings.TrimSpace(report.Name))
-
-	attempts := 0
 	for _, to := range recipients {
 		addr := strings.TrimSpace(to)
 		if addr == "" {
@@ -420,6 +446,30 @@ func (s *OpsScheduledReportService) generateReportHTML(ctx context.Context, repo
 	}
 }
 
+var (
+	opsReportHTMLBreakRe = regexp.MustCompile(`(?i)<br\s*/?>|</(p|h[1-6]|li|tr|ul|table)>`)
+	opsReportHTMLCellRe  = regexp.MustCompile(`(?i)</t[dh]>`)
+	opsReportHTMLTagRe   = regexp.MustCompile(`<[^>]+>`)
+)
+
+// opsReportHTMLToText 把报表邮件 HTML 转为机器人渠道可读的纯文本
+func opsReportHTMLToText(content string) string {
+	text := opsReportHTMLBreakRe.ReplaceAllString(content, "\n")
+	text = strings.ReplaceAll(text, "<li>", "- ")
+	text = opsReportHTMLCellRe.ReplaceAllString(text, " | ")
+	text = html.UnescapeString(opsReportHTMLTagRe.ReplaceAllString(text, ""))
+
+	lines := strings.Split(text, "\n")
+	out := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSuffix(strings.TrimSpace(line), " |")
+		if line != "" {
+			out = append(out, line)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
 func buildOpsSummaryEmailHTML(title string, start, end time.Time, overview *OpsDashboardOverview) string {
 	if overview == nil {
 		return fmt.Sprintf("<h2>%s</h2><p>No data.</p>", htmlEscape(title))
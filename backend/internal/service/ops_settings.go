@@ -89,6 +89,9 @@ func (s *OpsService) UpdateEmailNotificationConfig(ctx context.Context, req *Ops
 		cfg.Report.AccountHealthEnabled = req.Report.AccountHealthEnabled
 		cfg.Report.AccountHealthSchedule = strings.TrimSpace(req.Report.AccountHealthSchedule)
 		cfg.Report.AccountHealthErrorRateThreshold = req.Report.AccountHealthErrorRateThreshold
+		if req.Report.ChannelIDs != nil {
+			cfg.Report.ChannelIDs = req.Report.ChannelIDs
+		}
 	}
 
 	if err := validateOpsEmailNotificationConfig(cfg); err != nil {
@@ -129,6 +132,7 @@ func defaultOpsEmailNotificationConfig() *OpsEmailNotificationConfig {
 			AccountHealthEnabled:            false,
 			AccountHealthSchedule:           "0 9 * * *",
 			AccountHealthErrorRateThreshold: 10.0,
+			ChannelIDs:                      []int64{},
 		},
 	}
 }
@@ -143,6 +147,9 @@ func normalizeOpsEmailNotificationConfig(cfg *OpsEmailNotificationConfig) {
 	if cfg.Report.Recipients == nil {
 		cfg.Report.Recipients = []string{}
 	}
+	if cfg.Report.ChannelIDs == nil {
+		cfg.Report.ChannelIDs = []int64{}
+	}
 
 	cfg.Alert.MinSeverity = strings.TrimSpace(cfg.Alert.MinSeverity)
 	cfg.Report.DailySummarySchedule = strings.TrimSpace(cfg.Report.DailySummarySchedule)
@@ -188,6 +195,11 @@ func validateOpsEmailNotificationConfig(cfg *OpsEmailNotificationConfig) error {
 	if cfg.Report.AccountHealthErrorRateThreshold < 0 || cfg.Report.AccountHealthErrorRateThreshold > 100 {
 		return errors.New("report.account_health_error_rate_threshold must be between 0 and 100")
 	}
+	for _, id := range cfg.Report.ChannelIDs {
+		if id <= 0 {
+			return errors.New("report.channel_ids must contain positive integers")
+		}
+	}
 	return nil
 }
 
@@ -29,6 +29,7 @@ type OpsEmailReportConfig struct {
 	AccountHealthEnabled            bool     `json:"account_health_enabled"`
 	AccountHealthSchedule           string   `json:"account_health_schedule"`
 	AccountHealthErrorRateThreshold float64  `json:"account_health_error_rate_threshold"`
+	ChannelIDs                      []int64  `json:"channel_ids"` // 同时投递到的通知渠道
 }
 
 // OpsEmailNotificationConfigUpdateRequest allows partial updates, while the
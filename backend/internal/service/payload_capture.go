@@ -0,0 +1,487 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/Wei-Shaw/sub2api/internal/util/logredact"
+	"go.uber.org/zap"
+)
+
+// 载荷抓取规则作用范围
+const (
+	PayloadCaptureScopeGroup  = "group"
+	PayloadCaptureScopeAPIKey = "api_key"
+)
+
+const (
+	// payloadCaptureRuleRefreshInterval 规则快照刷新周期（多实例部署时其他实例的规则变更在此周期内生效）
+	payloadCaptureRuleRefreshInterval = 30 * time.Second
+	// payloadCaptureWriteTimeout 单条抓取记录写入超时
+	payloadCaptureWriteTimeout = 3 * time.Second
+	// payloadCaptureStreamBufferFactor 流式响应原始 SSE 的缓冲上限相对 max_body_bytes 的倍数（重组后再截断）
+	payloadCaptureStreamBufferFactor = 8
+	// payloadCaptureMaxRedactFields 单条规则允许配置的额外脱敏字段数
+	payloadCaptureMaxRedactFields = 64
+)
+
+// payloadCaptureSensitiveKeys 在 logredact 默认敏感字段之外，载荷中始终脱敏的字段
+var payloadCaptureSensitiveKeys = []string{
+	"api_key", "x-api-key", "authorization", "secret", "secret_key", "private_key",
+}
+
+var (
+	ErrPayloadCaptureRuleNotFound = infraerrors.NotFound("PAYLOAD_CAPTURE_RULE_NOT_FOUND", "payload capture rule not found")
+	ErrPayloadCaptureRuleExists   = infraerrors.Conflict("PAYLOAD_CAPTURE_RULE_EXISTS", "a payload capture rule already exists for this scope")
+	ErrPayloadCaptureNotFound     = infraerrors.NotFound("PAYLOAD_CAPTURE_NOT_FOUND", "payload capture not found")
+)
+
+// PayloadCaptureRule 载荷抓取规则：对指定分组或 API Key 的网关请求按比例抽样记录请求/响应体。
+// 同一 API Key 同时命中 Key 规则与分组规则时以 Key 规则为准（Key 规则停用即表示该 Key 不抓取）。
+type PayloadCaptureRule struct {
+	ID           int64
+	ScopeType    string // group / api_key
+	ScopeID      int64
+	Enabled      bool
+	SampleRate   float64  // (0, 1]
+	MaxBodyBytes int      // 0 表示使用 payload_capture.max_body_bytes
+	RedactFields []string // 额外脱敏的 JSON 字段名（大小写不敏感）
+	Note         string
+	ExpiresAt    *time.Time // 到期后自动停止抓取
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// active 规则当前是否生效
+func (r *PayloadCaptureRule) active(now time.Time) bool {
+	return r.Enabled && (r.ExpiresAt == nil || now.Before(*r.ExpiresAt))
+}
+
+// PayloadCaptureRuleInput 创建/更新抓取规则的参数
+type PayloadCaptureRuleInput struct {
+	ScopeType    string
+	ScopeID      int64
+	Enabled      bool
+	SampleRate   float64
+	MaxBodyBytes int
+	RedactFields []string
+	Note         string
+	ExpiresAt    *time.Time
+}
+
+// PayloadCapture 一条抓取记录。请求体/响应体已脱敏并按上限截断；
+// 流式响应保存重组后的最终消息而非原始 SSE。
+type PayloadCapture struct {
+	ID                int64
+	RuleID            int64
+	RequestID         string // 上游 x-request-id
+	ClientRequestID   string // 网关生成的请求 ID（usage_logs.request_id 为 "client:" + 该值）
+	UserID            int64
+	APIKeyID          int64
+	GroupID           *int64
+	AccountID         *int64
+	Platform          string
+	Model             string
+	Method            string
+	Path              string
+	Stream            bool
+	StatusCode        int
+	DurationMs        int64
+	RequestBody       string
+	ResponseBody      string
+	RequestBytes      int
+	ResponseBytes     int
+	RequestTruncated  bool
+	ResponseTruncated bool
+	CreatedAt         time.Time
+}
+
+// PayloadCaptureFilters 抓取记录查询条件
+type PayloadCaptureFilters struct {
+	RequestID string // 匹配上游 request_id、client_request_id 或 usage_logs 中的 "client:" 前缀形式
+	UserID    int64
+	APIKeyID  int64
+	GroupID   int64
+	Model     string
+	StartTime *time.Time
+	EndTime   *time.Time
+}
+
+// PayloadCaptureRepository 载荷抓取规则与记录的数据访问接口
+type PayloadCaptureRepository interface {
+	ListRules(ctx context.Context) ([]PayloadCaptureRule, error)
+	GetRule(ctx context.Context, id int64) (*PayloadCaptureRule, error)
+	CreateRule(ctx context.Context, rule *PayloadCaptureRule) error
+	UpdateRule(ctx context.Context, rule *PayloadCaptureRule) error
+	DeleteRule(ctx context.Context, id int64) error
+
+	CreateCapture(ctx context.Context, capture *PayloadCapture) error
+	// ListCaptures 列表不返回请求体/响应体
+	ListCaptures(ctx context.Context, params pagination.PaginationParams, filters PayloadCaptureFilters) ([]PayloadCapture, *pagination.PaginationResult, error)
+	GetCapture(ctx context.Context, id int64) (*PayloadCapture, error)
+}
+
+// PayloadCaptureJob 网关请求结束时提交的原始载荷；脱敏、流式重组与截断在后台写入协程中完成
+type PayloadCaptureJob struct {
+	Rule                *PayloadCaptureRule
+	Capture             *PayloadCapture
+	RequestBody         []byte
+	ResponseBody        []byte
+	ResponseContentType string
+	ResponseOverflow    bool // 响应超出缓冲上限，ResponseBody 只是前缀
+}
+
+type payloadCaptureRuleSet struct {
+	byGroup  map[int64]*PayloadCaptureRule
+	byAPIKey map[int64]*PayloadCaptureRule
+}
+
+// PayloadCaptureService 网关载荷抓取服务（调试用，按规则显式开启）
+type PayloadCaptureService struct {
+	repo PayloadCaptureRepository
+	cfg  *config.Config
+
+	rules   atomic.Pointer[payloadCaptureRuleSet]
+	queue   chan *PayloadCaptureJob
+	dropped atomic.Int64
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewPayloadCaptureService 创建载荷抓取服务
+func NewPayloadCaptureService(repo PayloadCaptureRepository, cfg *config.Config) *PayloadCaptureService {
+	queueSize := 1
+	if cfg != nil && cfg.PayloadCapture.QueueSize > 0 {
+		queueSize = cfg.PayloadCapture.QueueSize
+	}
+	return &PayloadCaptureService{
+		repo:   repo,
+		cfg:    cfg,
+		queue:  make(chan *PayloadCaptureJob, queueSize),
+		stopCh: make(chan struct{}),
+	}
+}
+
+// Start 加载规则并启动规则刷新与异步写入协程
+func (s *PayloadCaptureService) Start() {
+	if !s.configEnabled() {
+		return
+	}
+	s.reloadRules(context.Background())
+
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(payloadCaptureRuleRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.reloadRules(context.Background())
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+	go func() {
+		defer s.wg.Done()
+		for {
+			select {
+			case job := <-s.queue:
+				s.write(job)
+			case <-s.stopCh:
+				// 退出前写完已入队的记录
+				for {
+					select {
+					case job := <-s.queue:
+						s.write(job)
+					default:
+						return
+					}
+				}
+			}
+		}
+	}()
+}
+
+// Stop 停止后台协程
+func (s *PayloadCaptureService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	s.wg.Wait()
+}
+
+func (s *PayloadCaptureService) configEnabled() bool {
+	return s != nil && s.repo != nil && s.cfg != nil && s.cfg.PayloadCapture.Enabled
+}
+
+// Active 是否存在任何规则（供中间件快速跳过）
+func (s *PayloadCaptureService) Active() bool {
+	if !s.configEnabled() {
+		return false
+	}
+	set := s.rules.Load()
+	return set != nil && (len(set.byGroup) > 0 || len(set.byAPIKey) > 0)
+}
+
+// Match 返回本次请求应使用的抓取规则；未命中规则或未被抽中时返回 nil
+func (s *PayloadCaptureService) Match(apiKey *APIKey) *PayloadCaptureRule {
+	if apiKey == nil || !s.configEnabled() {
+		return nil
+	}
+	set := s.rules.Load()
+	if set == nil {
+		return nil
+	}
+	rule, ok := set.byAPIKey[apiKey.ID]
+	if !ok && apiKey.GroupID != nil {
+		rule = set.byGroup[*apiKey.GroupID]
+	}
+	if rule == nil || !rule.active(time.Now()) {
+		return nil
+	}
+	if rule.SampleRate < 1 && rand.Float64() >= rule.SampleRate {
+		return nil
+	}
+	return rule
+}
+
+// BodyLimit 规则生效的单个载荷记录上限（不超过全局上限）
+func (s *PayloadCaptureService) BodyLimit(rule *PayloadCaptureRule) int {
+	if !s.configEnabled() {
+		return 0
+	}
+	limit := s.cfg.PayloadCapture.MaxBodyBytes
+	if rule != nil && rule.MaxBodyBytes > 0 && rule.MaxBodyBytes < limit {
+		limit = rule.MaxBodyBytes
+	}
+	return limit
+}
+
+// ResponseBufferLimit 响应镜像缓冲上限；流式响应需要保留更多原始 SSE 以便重组
+func (s *PayloadCaptureService) ResponseBufferLimit(rule *PayloadCaptureRule, stream bool) int {
+	limit := s.BodyLimit(rule)
+	if stream {
+		return limit * payloadCaptureStreamBufferFactor
+	}
+	return limit
+}
+
+// Submit 提交抓取任务（非阻塞，队列满时丢弃）
+func (s *PayloadCaptureService) Submit(job *PayloadCaptureJob) {
+	if job == nil || job.Rule == nil || job.Capture == nil || !s.configEnabled() {
+		return
+	}
+	select {
+	case s.queue <- job:
+	default:
+		if n := s.dropped.Add(1); n == 1 || n%100 == 0 {
+			logger.L().Warn("payload_capture.queue_full", zap.Int64("dropped_total", n))
+		}
+	}
+}
+
+func (s *PayloadCaptureService) write(job *PayloadCaptureJob) {
+	capture := BuildPayloadCapture(job, s.BodyLimit(job.Rule))
+	ctx, cancel := context.WithTimeout(context.Background(), payloadCaptureWriteTimeout)
+	defer cancel()
+	if err := s.repo.CreateCapture(ctx, capture); err != nil {
+		logger.L().Warn("payload_capture.write_failed",
+			zap.Int64("rule_id", capture.RuleID),
+			zap.String("client_request_id", capture.ClientRequestID),
+			zap.Error(err))
+	}
+}
+
+// BuildPayloadCapture 对原始载荷脱敏、重组流式响应并按上限截断
+func BuildPayloadCapture(job *PayloadCaptureJob, limit int) *PayloadCapture {
+	capture := job.Capture
+	extraKeys := append(append([]string{}, payloadCaptureSensitiveKeys...), job.Rule.RedactFields...)
+
+	capture.RuleID = job.Rule.ID
+	capture.RequestBytes = len(job.RequestBody)
+	capture.RequestBody, capture.RequestTruncated = redactPayloadCaptureBody(job.RequestBody, limit, extraKeys)
+
+	response := job.ResponseBody
+	if strings.HasPrefix(strings.ToLower(job.ResponseContentType), "text/event-stream") {
+		capture.Stream = true
+		if assembled := AssemblePayloadCaptureStream(response); assembled != nil {
+			response = assembled
+		}
+	}
+	if capture.ResponseBytes == 0 {
+		capture.ResponseBytes = len(job.ResponseBody)
+	}
+	capture.ResponseBody, capture.ResponseTruncated = redactPayloadCaptureBody(response, limit, extraKeys)
+	capture.ResponseTruncated = capture.ResponseTruncated || job.ResponseOverflow
+	return capture
+}
+
+// redactPayloadCaptureBody 先脱敏（JSON 按字段，其他按文本模式）再按字节上限截断（保持 UTF-8 完整）
+func redactPayloadCaptureBody(raw []byte, limit int, extraKeys []string) (string, bool) {
+	if len(raw) == 0 {
+		return "", false
+	}
+	var out string
+	if json.Valid(raw) {
+		out = logredact.RedactJSON(raw, extraKeys...)
+	} else {
+		out = logredact.RedactText(string(raw), extraKeys...)
+	}
+	// TEXT 列不接受 NUL 与非法 UTF-8
+	out = strings.ToValidUTF8(strings.ReplaceAll(out, "\x00", ""), "")
+	if limit <= 0 || len(out) <= limit {
+		return out, false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(out[cut]) {
+		cut--
+	}
+	return out[:cut], true
+}
+
+func (s *PayloadCaptureService) reloadRules(ctx context.Context) {
+	if !s.configEnabled() {
+		return
+	}
+	loadCtx, cancel := context.WithTimeout(ctx, payloadCaptureWriteTimeout)
+	defer cancel()
+	rules, err := s.repo.ListRules(loadCtx)
+	if err != nil {
+		logger.L().Warn("payload_capture.load_rules_failed", zap.Error(err))
+		return
+	}
+	s.rules.Store(buildPayloadCaptureRuleSet(rules))
+}
+
+func buildPayloadCaptureRuleSet(rules []PayloadCaptureRule) *payloadCaptureRuleSet {
+	set := &payloadCaptureRuleSet{
+		byGroup:  map[int64]*PayloadCaptureRule{},
+		byAPIKey: map[int64]*PayloadCaptureRule{},
+	}
+	for i := range rules {
+		rule := &rules[i]
+		switch rule.ScopeType {
+		case PayloadCaptureScopeGroup:
+			set.byGroup[rule.ScopeID] = rule
+		case PayloadCaptureScopeAPIKey:
+			set.byAPIKey[rule.ScopeID] = rule
+		}
+	}
+	return set
+}
+
+// ListRules 列出全部抓取规则
+func (s *PayloadCaptureService) ListRules(ctx context.Context) ([]PayloadCaptureRule, error) {
+	return s.repo.ListRules(ctx)
+}
+
+// GetRule 查询单条抓取规则
+func (s *PayloadCaptureService) GetRule(ctx context.Context, id int64) (*PayloadCaptureRule, error) {
+	return s.repo.GetRule(ctx, id)
+}
+
+// CreateRule 创建抓取规则（同一作用范围只允许一条）
+func (s *PayloadCaptureService) CreateRule(ctx context.Context, input *PayloadCaptureRuleInput) (*PayloadCaptureRule, error) {
+	rule, err := normalizePayloadCaptureRuleInput(input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.reloadRules(ctx)
+	return rule, nil
+}
+
+// UpdateRule 更新抓取规则
+func (s *PayloadCaptureService) UpdateRule(ctx context.Context, id int64, input *PayloadCaptureRuleInput) (*PayloadCaptureRule, error) {
+	rule, err := normalizePayloadCaptureRuleInput(input)
+	if err != nil {
+		return nil, err
+	}
+	rule.ID = id
+	if err := s.repo.UpdateRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	s.reloadRules(ctx)
+	return rule, nil
+}
+
+// DeleteRule 删除抓取规则（已抓取的记录保留至过期清理）
+func (s *PayloadCaptureService) DeleteRule(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+	s.reloadRules(ctx)
+	return nil
+}
+
+// ListCaptures 分页查询抓取记录（不含载荷）
+func (s *PayloadCaptureService) ListCaptures(ctx context.Context, params pagination.PaginationParams, filters PayloadCaptureFilters) ([]PayloadCapture, *pagination.PaginationResult, error) {
+	return s.repo.ListCaptures(ctx, params, filters)
+}
+
+// GetCapture 查询单条抓取记录（含载荷）
+func (s *PayloadCaptureService) GetCapture(ctx context.Context, id int64) (*PayloadCapture, error) {
+	return s.repo.GetCapture(ctx, id)
+}
+
+func normalizePayloadCaptureRuleInput(input *PayloadCaptureRuleInput) (*PayloadCaptureRule, error) {
+	if input == nil {
+		return nil, infraerrors.BadRequest("PAYLOAD_CAPTURE_RULE_INVALID", "rule input cannot be nil")
+	}
+	scopeType := strings.TrimSpace(input.ScopeType)
+	if scopeType != PayloadCaptureScopeGroup && scopeType != PayloadCaptureScopeAPIKey {
+		return nil, infraerrors.BadRequest("PAYLOAD_CAPTURE_RULE_INVALID", "scope_type must be group or api_key")
+	}
+	if input.ScopeID <= 0 {
+		return nil, infraerrors.BadRequest("PAYLOAD_CAPTURE_RULE_INVALID", "scope_id must be positive")
+	}
+	if input.SampleRate <= 0 || input.SampleRate > 1 {
+		return nil, infraerrors.BadRequest("PAYLOAD_CAPTURE_RULE_INVALID", "sample_rate must be within (0, 1]")
+	}
+	if input.MaxBodyBytes < 0 {
+		return nil, infraerrors.BadRequest("PAYLOAD_CAPTURE_RULE_INVALID", "max_body_bytes must be non-negative")
+	}
+	if len(input.RedactFields) > payloadCaptureMaxRedactFields {
+		return nil, infraerrors.BadRequest("PAYLOAD_CAPTURE_RULE_INVALID", "too many redact_fields")
+	}
+	fields := make([]string, 0, len(input.RedactFields))
+	seen := make(map[string]struct{}, len(input.RedactFields))
+	for _, f := range input.RedactFields {
+		f = strings.ToLower(strings.TrimSpace(f))
+		if f == "" {
+			continue
+		}
+		if _, ok := seen[f]; ok {
+			continue
+		}
+		seen[f] = struct{}{}
+		fields = append(fields, f)
+	}
+	return &PayloadCaptureRule{
+		ScopeType:    scopeType,
+		ScopeID:      input.ScopeID,
+		Enabled:      input.Enabled,
+		SampleRate:   input.SampleRate,
+		MaxBodyBytes: input.MaxBodyBytes,
+		RedactFields: fields,
+		Note:         strings.TrimSpace(input.Note),
+		ExpiresAt:    input.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// AssemblePayloadCaptureStream 将 SSE 响应重组为等价的非流式响应 JSON，便于排查时直接阅读最终消息。
+// 支持 Anthropic Messages、OpenAI Chat Completions、OpenAI Responses 与 Gemini 流格式；
+// 无法识别时返回 nil（调用方保留原始 SSE）。
+func AssemblePayloadCaptureStream(raw []byte) []byte {
+	events := parsePayloadCaptureSSE(raw)
+	if len(events) == 0 {
+		return nil
+	}
+	first := events[0]
+	switch {
+	case strings.HasPrefix(first.Get("type").String(), "message_"),
+		strings.HasPrefix(first.Get("type").String(), "content_block_"):
+		return assembleAnthropicStream(events)
+	case strings.HasPrefix(first.Get("type").String(), "response."):
+		return assembleResponsesStream(events)
+	case first.Get("choices").Exists():
+		return assembleChatCompletionsStream(events)
+	case first.Get("candidates").Exists(), first.Get("response.candidates").Exists():
+		return assembleGeminiStream(events)
+	}
+	return nil
+}
+
+// parsePayloadCaptureSSE 提取 data: 行中的 JSON 事件（忽略 [DONE]、注释与不完整的尾部事件）
+func parsePayloadCaptureSSE(raw []byte) []gjson.Result {
+	var events []gjson.Result
+	for _, line := range bytes.Split(raw, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		data := bytes.TrimSpace(line[len("data:"):])
+		if len(data) == 0 || bytes.Equal(data, []byte("[DONE]")) || !gjson.ValidBytes(data) {
+			continue
+		}
+		events = append(events, gjson.ParseBytes(data))
+	}
+	return events
+}
+
+// assembleAnthropicStream 按 content block 索引拼接 text / thinking / tool_use 增量，合并 message_delta 中的停止原因与用量
+func assembleAnthropicStream(events []gjson.Result) []byte {
+	message := map[string]any{"type": "message", "role": "assistant"}
+	blocks := map[int64]map[string]any{}
+	partialJSON := map[int64]*strings.Builder{}
+
+	for _, ev := range events {
+		switch ev.Get("type").String() {
+		case "message_start":
+			if m, ok := ev.Get("message").Value().(map[string]any); ok {
+				for k, v := range m {
+					message[k] = v
+				}
+			}
+		case "content_block_start":
+			block, _ := ev.Get("content_block").Value().(map[string]any)
+			if block == nil {
+				block = map[string]any{}
+			}
+			blocks[ev.Get("index").Int()] = block
+		case "content_block_delta":
+			idx := ev.Get("index").Int()
+			block := blocks[idx]
+			if block == nil {
+				block = map[string]any{}
+				blocks[idx] = block
+			}
+			delta := ev.Get("delta")
+			switch delta.Get("type").String() {
+			case "text_delta":
+				block["text"] = assembledStringField(block, "text") + delta.Get("text").String()
+			case "thinking_delta":
+				block["thinking"] = assembledStringField(block, "thinking") + delta.Get("thinking").String()
+			case "signature_delta":
+				block["signature"] = delta.Get("signature").String()
+			case "input_json_delta":
+				b := partialJSON[idx]
+				if b == nil {
+					b = &strings.Builder{}
+					partialJSON[idx] = b
+				}
+				b.WriteString(delta.Get("partial_json").String())
+			}
+		case "message_delta":
+			ev.Get("delta").ForEach(func(k, v gjson.Result) bool {
+				message[k.String()] = v.Value()
+				return true
+			})
+			if usage := ev.Get("usage"); usage.Exists() {
+				merged, _ := message["usage"].(map[string]any)
+				if merged == nil {
+					merged = map[string]any{}
+				}
+				usage.ForEach(func(k, v gjson.Result) bool {
+					merged[k.String()] = v.Value()
+					return true
+				})
+				message["usage"] = merged
+			}
+		case "error":
+			message["error"] = ev.Get("error").Value()
+		}
+	}
+
+	for idx, b := range partialJSON {
+		block := blocks[idx]
+		if block == nil {
+			continue
+		}
+		if input := b.String(); json.Valid([]byte(input)) {
+			block["input"] = json.RawMessage(input)
+		} else {
+			block["input"] = input
+		}
+	}
+	message["content"] = orderedAnthropicBlocks(blocks)
+	return marshalPayloadCapture(message)
+}
+
+type assembledChatToolCall struct {
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type assembledChatChoice struct {
+	Index   int64 `json:"index"`
+	Message struct {
+		Role             string                  `json:"role"`
+		Content          string                  `json:"content"`
+		ReasoningContent string                  `json:"reasoning_content,omitempty"`
+		ToolCalls        []assembledChatToolCall `json:"tool_calls,omitempty"`
+	} `json:"message"`
+	FinishReason any `json:"finish_reason"`
+
+	toolCalls map[int64]*assembledChatToolCall
+}
+
+// assembleChatCompletionsStream 将 chat.completion.chunk 合并为 chat.completion
+func assembleChatCompletionsStream(events []gjson.Result) []byte {
+	out := map[string]any{"object": "chat.completion"}
+	choices := map[int64]*assembledChatChoice{}
+
+	for _, ev := range events {
+		for _, key := range []string{"id", "model", "created", "system_fingerprint", "service_tier"} {
+			if v := ev.Get(key); v.Exists() && v.Type != gjson.Null {
+				out[key] = v.Value()
+			}
+		}
+		if usage := ev.Get("usage"); usage.IsObject() {
+			out["usage"] = usage.Value()
+		}
+		for _, c := range ev.Get("choices").Array() {
+			idx := c.Get("index").Int()
+			choice := choices[idx]
+			if choice == nil {
+				choice = &assembledChatChoice{Index: idx, toolCalls: map[int64]*assembledChatToolCall{}}
+				choice.Message.Role = "assistant"
+				choices[idx] = choice
+			}
+			delta := c.Get("delta")
+			if role := delta.Get("role").String(); role != "" {
+				choice.Message.Role = role
+			}
+			choice.Message.Content += delta.Get("content").String()
+			choice.Message.ReasoningContent += delta.Get("reasoning_content").String()
+			for _, tc := range delta.Get("tool_calls").Array() {
+				tcIdx := tc.Get("index").Int()
+				call := choice.toolCalls[tcIdx]
+				if call == nil {
+					call = &assembledChatToolCall{}
+					choice.toolCalls[tcIdx] = call
+				}
+				if id := tc.Get("id").String(); id != "" {
+					call.ID = id
+				}
+				if typ := tc.Get("type").String(); typ != "" {
+					call.Type = typ
+				}
+				if name := tc.Get("function.name").String(); name != "" {
+					call.Function.Name = name
+				}
+				call.Function.Arguments += tc.Get("function.arguments").String()
+			}
+			if fr := c.Get("finish_reason"); fr.Exists() && fr.Type != gjson.Null {
+				choice.FinishReason = fr.String()
+			}
+		}
+	}
+
+	indexes := make([]int64, 0, len(choices))
+	for idx := range choices {
+		indexes = append(indexes, idx)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	list := make([]*assembledChatChoice, 0, len(indexes))
+	for _, idx := range indexes {
+		choice := choices[idx]
+		tcIndexes := make([]int64, 0, len(choice.toolCalls))
+		for tcIdx := range choice.toolCalls {
+			tcIndexes = append(tcIndexes, tcIdx)
+		}
+		sort.Slice(tcIndexes, func(i, j int) bool { return tcIndexes[i] < tcIndexes[j] })
+		for _, tcIdx := range tcIndexes {
+			choice.Message.ToolCalls = append(choice.Message.ToolCalls, *choice.toolCalls[tcIdx])
+		}
+		list = append(list, choice)
+	}
+	out["choices"] = list
+	return marshalPayloadCapture(out)
+}
+
+// assembleResponsesStream 优先使用终止事件携带的完整 response；流被截断时退化为拼接 output_text 增量
+func assembleResponsesStream(events []gjson.Result) []byte {
+	for i := len(events) - 1; i >= 0; i-- {
+		switch events[i].Get("type").String() {
+		case "response.completed", "response.incomplete", "response.failed", "response.done":
+			if resp := events[i].Get("response"); resp.IsObject() {
+				return []byte(resp.Raw)
+			}
+		}
+	}
+
+	out := map[string]any{"object": "response", "status": "incomplete"}
+	var text strings.Builder
+	for _, ev := range events {
+		switch ev.Get("type").String() {
+		case "response.created", "response.in_progress":
+			for _, key := range []string{"id", "model", "created_at"} {
+				if v := ev.Get("response." + key); v.Exists() {
+					out[key] = v.Value()
+				}
+			}
+		case "response.output_text.delta":
+			text.WriteString(ev.Get("delta").String())
+		}
+	}
+	out["output_text"] = text.String()
+	return marshalPayloadCapture(out)
+}
+
+// assembleGeminiStream 按候选索引拼接文本片段（思考片段单独合并），保留最后一次出现的用量与结束原因
+func assembleGeminiStream(events []gjson.Result) []byte {
+	type candidate struct {
+		parts        []any
+		text         strings.Builder
+		thought      strings.Builder
+		finishReason string
+	}
+	out := map[string]any{}
+	candidates := map[int64]*candidate{}
+
+	for _, ev := range events {
+		if inner := ev.Get("response"); inner.IsObject() {
+			ev = inner
+		}
+		for _, key := range []string{"modelVersion", "responseId", "usageMetadata"} {
+			if v := ev.Get(key); v.Exists() {
+				out[key] = v.Value()
+			}
+		}
+		for i, c := range ev.Get("candidates").Array() {
+			idx := int64(i)
+			if v := c.Get("index"); v.Exists() {
+				idx = v.Int()
+			}
+			cand := candidates[idx]
+			if cand == nil {
+				cand = &candidate{}
+				candidates[idx] = cand
+			}
+			for _, part := range c.Get("content.parts").Array() {
+				text := part.Get("text")
+				switch {
+				case text.Exists() && part.Get("thought").Bool():
+					cand.thought.WriteString(text.String())
+				case text.Exists():
+					cand.text.WriteString(text.String())
+				default:
+					cand.parts = append(cand.parts, part.Value())
+				}
+			}
+			if fr := c.Get("finishReason").String(); fr != "" {
+				cand.finishReason = fr
+			}
+		}
+	}
+
+	indexes := make([]int64, 0, len(candidates))
+	for idx := range candidates {
+		indexes = append(indexes, idx)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	list := make([]map[string]any, 0, len(indexes))
+	for _, idx := range indexes {
+		cand := candidates[idx]
+		parts := make([]any, 0, len(cand.parts)+2)
+		if cand.thought.Len() > 0 {
+			parts = append(parts, map[string]any{"text": cand.thought.String(), "thought": true})
+		}
+		if cand.text.Len() > 0 {
+			parts = append(parts, map[string]any{"text": cand.text.String()})
+		}
+		parts = append(parts, cand.parts...)
+		entry := map[string]any{
+			"index":   idx,
+			"content": map[string]any{"role": "model", "parts": parts},
+		}
+		if cand.finishReason != "" {
+			entry["finishReason"] = cand.finishReason
+		}
+		list = append(list, entry)
+	}
+	out["candidates"] = list
+	return marshalPayloadCapture(out)
+}
+
+func assembledStringField(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+func orderedAnthropicBlocks(blocks map[int64]map[string]any) []map[string]any {
+	indexes := make([]int64, 0, len(blocks))
+	for idx := range blocks {
+		indexes = append(indexes, idx)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+	out := make([]map[string]any, 0, len(indexes))
+	for _, idx := range indexes {
+		out = append(out, blocks[idx])
+	}
+	return out
+}
+
+func marshalPayloadCapture(v any) []byte {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return raw
+}
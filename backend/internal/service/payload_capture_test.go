@@ -0,0 +1,201 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+type payloadCaptureRepoStub struct {
+	PayloadCaptureRepository
+	rules []PayloadCaptureRule
+}
+
+func (r *payloadCaptureRepoStub) ListRules(context.Context) ([]PayloadCaptureRule, error) {
+	return r.rules, nil
+}
+
+func newPayloadCaptureServiceForTest(rules ...PayloadCaptureRule) *PayloadCaptureService {
+	cfg := &config.Config{}
+	cfg.PayloadCapture = config.PayloadCaptureConfig{Enabled: true, MaxBodyBytes: 1024, QueueSize: 4}
+	svc := NewPayloadCaptureService(&payloadCaptureRepoStub{rules: rules}, cfg)
+	svc.reloadRules(context.Background())
+	return svc
+}
+
+func TestPayloadCaptureMatch(t *testing.T) {
+	groupID := int64(10)
+	past := time.Now().Add(-time.Minute)
+	svc := newPayloadCaptureServiceForTest(
+		PayloadCaptureRule{ID: 1, ScopeType: PayloadCaptureScopeGroup, ScopeID: groupID, Enabled: true, SampleRate: 1},
+		PayloadCaptureRule{ID: 2, ScopeType: PayloadCaptureScopeAPIKey, ScopeID: 100, Enabled: false, SampleRate: 1},
+		PayloadCaptureRule{ID: 3, ScopeType: PayloadCaptureScopeAPIKey, ScopeID: 101, Enabled: true, SampleRate: 1, MaxBodyBytes: 64},
+		PayloadCaptureRule{ID: 4, ScopeType: PayloadCaptureScopeAPIKey, ScopeID: 102, Enabled: true, SampleRate: 1, ExpiresAt: &past},
+	)
+	require.True(t, svc.Active())
+
+	rule := svc.Match(&APIKey{ID: 1, GroupID: &groupID})
+	require.NotNil(t, rule)
+	require.Equal(t, int64(1), rule.ID)
+	require.Equal(t, 1024, svc.BodyLimit(rule))
+
+	// Key 规则优先：停用的 Key 规则表示该 Key 不抓取
+	require.Nil(t, svc.Match(&APIKey{ID: 100, GroupID: &groupID}))
+
+	rule = svc.Match(&APIKey{ID: 101})
+	require.NotNil(t, rule)
+	require.Equal(t, 64, svc.BodyLimit(rule))
+	require.Equal(t, 64*payloadCaptureStreamBufferFactor, svc.ResponseBufferLimit(rule, true))
+
+	require.Nil(t, svc.Match(&APIKey{ID: 102}), "expired rule")
+	require.Nil(t, svc.Match(&APIKey{ID: 999}))
+}
+
+func TestBuildPayloadCaptureRedactsAndTruncates(t *testing.T) {
+	rule := &PayloadCaptureRule{ID: 7, RedactFields: []string{"ssn"}}
+	job := &PayloadCaptureJob{
+		Rule:                rule,
+		Capture:             &PayloadCapture{},
+		RequestBody:         []byte(`{"model":"claude","metadata":{"ssn":"123-45-6789"},"api_key":"sk-secret","messages":[{"role":"user","content":"hi"}]}`),
+		ResponseBody:        []byte(`{"content":[{"type":"text","text":"` + strings.Repeat("a", 400) + `"}]}`),
+		ResponseContentType: "application/json",
+	}
+
+	capture := BuildPayloadCapture(job, 200)
+	require.Equal(t, int64(7), capture.RuleID)
+	require.False(t, capture.RequestTruncated)
+	require.NotContains(t, capture.RequestBody, "123-45-6789")
+	require.NotContains(t, capture.RequestBody, "sk-secret")
+	require.Equal(t, "hi", gjson.Get(capture.RequestBody, "messages.0.content").String())
+
+	require.True(t, capture.ResponseTruncated)
+	require.Len(t, capture.ResponseBody, 200)
+	require.Equal(t, len(job.ResponseBody), capture.ResponseBytes)
+}
+
+func TestAssemblePayloadCaptureStreamAnthropic(t *testing.T) {
+	sse := strings.Join([]string{
+		`event: message_start`,
+		`data: {"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[],"usage":{"input_tokens":12,"output_tokens":1}}}`,
+		``,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me"}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":" think."}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Hello"}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":", world"}}`,
+		`data: {"type":"content_block_start","index":2,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather","input":{}}}`,
+		`data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+		`data: {"type":"content_block_delta","index":2,"delta":{"type":"input_json_delta","partial_json":"\"Paris\"}"}}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"tool_use","stop_sequence":null},"usage":{"output_tokens":42}}`,
+		`data: {"type":"message_stop"}`,
+		``,
+	}, "\n")
+
+	out := AssemblePayloadCaptureStream([]byte(sse))
+	require.NotNil(t, out)
+	require.Equal(t, "msg_1", gjson.GetBytes(out, "id").String())
+	require.Equal(t, "Let me think.", gjson.GetBytes(out, "content.0.thinking").String())
+	require.Equal(t, "sig", gjson.GetBytes(out, "content.0.signature").String())
+	require.Equal(t, "Hello, world", gjson.GetBytes(out, "content.1.text").String())
+	require.Equal(t, "Paris", gjson.GetBytes(out, "content.2.input.city").String())
+	require.Equal(t, "tool_use", gjson.GetBytes(out, "stop_reason").String())
+	require.Equal(t, int64(12), gjson.GetBytes(out, "usage.input_tokens").Int())
+	require.Equal(t, int64(42), gjson.GetBytes(out, "usage.output_tokens").Int())
+}
+
+func TestAssemblePayloadCaptureStreamChatCompletions(t *testing.T) {
+	sse := strings.Join([]string{
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"gpt-5","choices":[{"index":0,"delta":{"role":"assistant","content":""},"finish_reason":null}]}`,
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"gpt-5","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":null}]}`,
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"gpt-5","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{\"q\""}}]},"finish_reason":null}]}`,
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"gpt-5","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":":1}"}}]},"finish_reason":"tool_calls"}]}`,
+		`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","model":"gpt-5","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":7}}`,
+		`data: [DONE]`,
+	}, "\n\n")
+
+	out := AssemblePayloadCaptureStream([]byte(sse))
+	require.NotNil(t, out)
+	require.Equal(t, "chat.completion", gjson.GetBytes(out, "object").String())
+	require.Equal(t, "Hi", gjson.GetBytes(out, "choices.0.message.content").String())
+	require.Equal(t, "lookup", gjson.GetBytes(out, "choices.0.message.tool_calls.0.function.name").String())
+	require.Equal(t, `{"q":1}`, gjson.GetBytes(out, "choices.0.message.tool_calls.0.function.arguments").String())
+	require.Equal(t, "tool_calls", gjson.GetBytes(out, "choices.0.finish_reason").String())
+	require.Equal(t, int64(7), gjson.GetBytes(out, "usage.completion_tokens").Int())
+}
+
+func TestAssemblePayloadCaptureStreamResponses(t *testing.T) {
+	completed := strings.Join([]string{
+		`event: response.created`,
+		`data: {"type":"response.created","response":{"id":"resp_1","model":"gpt-5","status":"in_progress"}}`,
+		``,
+		`event: response.output_text.delta`,
+		`data: {"type":"response.output_text.delta","delta":"Hel"}`,
+		``,
+		`event: response.completed`,
+		`data: {"type":"response.completed","response":{"id":"resp_1","status":"completed","output":[{"type":"message","content":[{"type":"output_text","text":"Hello"}]}]}}`,
+	}, "\n")
+	out := AssemblePayloadCaptureStream([]byte(completed))
+	require.Equal(t, "completed", gjson.GetBytes(out, "status").String())
+	require.Equal(t, "Hello", gjson.GetBytes(out, "output.0.content.0.text").String())
+
+	// 流被截断时退化为拼接增量文本
+	truncated := strings.Join([]string{
+		`data: {"type":"response.created","response":{"id":"resp_2","model":"gpt-5"}}`,
+		`data: {"type":"response.output_text.delta","delta":"Hel"}`,
+		`data: {"type":"response.output_text.delta","delta":"lo"}`,
+		`data: {"type":"response.output_text.del`,
+	}, "\n")
+	out = AssemblePayloadCaptureStream([]byte(truncated))
+	require.Equal(t, "resp_2", gjson.GetBytes(out, "id").String())
+	require.Equal(t, "Hello", gjson.GetBytes(out, "output_text").String())
+	require.Equal(t, "incomplete", gjson.GetBytes(out, "status").String())
+}
+
+func TestAssemblePayloadCaptureStreamGemini(t *testing.T) {
+	sse := strings.Join([]string{
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Plan","thought":true}]}}]}`,
+		`data: {"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello"}]}}]}}`,
+		`data: {"candidates":[{"content":{"role":"model","parts":[{"text":" there"}]},"finishReason":"STOP"}],"usageMetadata":{"totalTokenCount":9},"modelVersion":"gemini-2.5-pro"}`,
+	}, "\n\n")
+
+	out := AssemblePayloadCaptureStream([]byte(sse))
+	require.NotNil(t, out)
+	require.Equal(t, "Plan", gjson.GetBytes(out, "candidates.0.content.parts.0.text").String())
+	require.True(t, gjson.GetBytes(out, "candidates.0.content.parts.0.thought").Bool())
+	require.Equal(t, "Hello there", gjson.GetBytes(out, "candidates.0.content.parts.1.text").String())
+	require.Equal(t, "STOP", gjson.GetBytes(out, "candidates.0.finishReason").String())
+	require.Equal(t, int64(9), gjson.GetBytes(out, "usageMetadata.totalTokenCount").Int())
+}
+
+func TestAssemblePayloadCaptureStreamUnknown(t *testing.T) {
+	require.Nil(t, AssemblePayloadCaptureStream([]byte("data: not json\n\n")))
+	require.Nil(t, AssemblePayloadCaptureStream([]byte(`data: {"foo":"bar"}`)))
+}
+
+func TestNormalizePayloadCaptureRuleInput(t *testing.T) {
+	_, err := normalizePayloadCaptureRuleInput(&PayloadCaptureRuleInput{ScopeType: "user", ScopeID: 1, SampleRate: 1})
+	require.Error(t, err)
+	_, err = normalizePayloadCaptureRuleInput(&PayloadCaptureRuleInput{ScopeType: PayloadCaptureScopeGroup, ScopeID: 1, SampleRate: 0})
+	require.Error(t, err)
+	_, err = normalizePayloadCaptureRuleInput(&PayloadCaptureRuleInput{ScopeType: PayloadCaptureScopeGroup, ScopeID: 1, SampleRate: 1.5})
+	require.Error(t, err)
+
+	rule, err := normalizePayloadCaptureRuleInput(&PayloadCaptureRuleInput{
+		ScopeType:    PayloadCaptureScopeAPIKey,
+		ScopeID:      3,
+		Enabled:      true,
+		SampleRate:   0.25,
+		RedactFields: []string{" SSN ", "ssn", "", "email"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"ssn", "email"}, rule.RedactFields)
+}
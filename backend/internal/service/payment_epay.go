@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+)
+
+const (
+	epayTradeStatusSuccess = "TRADE_SUCCESS"
+	epayDefaultPayType     = "alipay"
+	epayAck                = "success"
+)
+
+// epayPaymentProvider 易支付协议兼容网关（submit.php 跳转下单 + MD5 签名异步通知）
+type epayPaymentProvider struct {
+	gatewayURL string
+	pid        string
+	key        string
+	types      []string
+}
+
+func newEPayPaymentProvider(cfg config.PaymentEPayConfig) *epayPaymentProvider {
+	types := cfg.Types
+	if len(types) == 0 {
+		types = []string{epayDefaultPayType}
+	}
+	return &epayPaymentProvider{
+		gatewayURL: strings.TrimRight(cfg.GatewayURL, "/"),
+		pid:        strings.TrimSpace(cfg.PID),
+		key:        cfg.Key,
+		types:      types,
+	}
+}
+
+func (p *epayPaymentProvider) Name() string { return PaymentProviderEPay }
+
+func (p *epayPaymentProvider) PayTypes() []string { return p.types }
+
+func (p *epayPaymentProvider) CreatePayment(_ context.Context, order *PaymentOrder, urls PaymentURLs) (*PaymentCreateResult, error) {
+	payType := order.PayType
+	if payType == "" {
+		payType = p.types[0]
+	}
+	if !slices.Contains(p.types, payType) {
+		return nil, ErrPaymentPayTypeInvalid
+	}
+	params := url.Values{}
+	params.Set("pid", p.pid)
+	params.Set("type", payType)
+	params.Set("out_trade_no", order.OrderNo)
+	params.Set("notify_url", urls.NotifyURL)
+	params.Set("return_url", urls.ReturnURL)
+	params.Set("name", order.Subject)
+	params.Set("money", formatPaymentAmount(order.Amount))
+	params.Set("sign", epaySign(params, p.key))
+	params.Set("sign_type", "MD5")
+	return &PaymentCreateResult{PayURL: p.gatewayURL + "/submit.php?" + params.Encode()}, nil
+}
+
+func (p *epayPaymentProvider) VerifyNotification(_ context.Context, req *PaymentNotifyRequest) (*PaymentNotification, error) {
+	params := req.Params()
+	sign := strings.ToLower(strings.TrimSpace(params.Get("sign")))
+	if sign == "" || subtle.ConstantTimeCompare([]byte(sign), []byte(epaySign(params, p.key))) != 1 {
+		return nil, ErrPaymentSignatureInvalid
+	}
+	if params.Get("pid") != p.pid {
+		return nil, ErrPaymentSignatureInvalid
+	}
+	orderNo := strings.TrimSpace(params.Get("out_trade_no"))
+	if orderNo == "" {
+		return nil, ErrPaymentNotificationMalformed
+	}
+	amount, err := strconv.ParseFloat(strings.TrimSpace(params.Get("money")), 64)
+	if err != nil {
+		return nil, ErrPaymentNotificationMalformed
+	}
+	return &PaymentNotification{
+		OrderNo:         orderNo,
+		ProviderTradeNo: strings.TrimSpace(params.Get("trade_no")),
+		Amount:          amount,
+		Paid:            params.Get("trade_status") == epayTradeStatusSuccess,
+		Ack:             epayAck,
+	}, nil
+}
+
+// epaySign 易支付签名：非空参数（排除 sign/sign_type）按键名 ASCII 升序拼接为 k=v&k=v，末尾直接拼接商户密钥后取 MD5
+func epaySign(params url.Values, key string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		if k == "sign" || k == "sign_type" || params.Get(k) == "" {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var sb strings.Builder
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte('&')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(params.Get(k))
+	}
+	sb.WriteString(key)
+	sum := md5.Sum([]byte(sb.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// formatPaymentAmount 金额保留两位小数
+func formatPaymentAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
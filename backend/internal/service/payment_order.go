@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 支付订单购买类型
+const (
+	PaymentOrderKindBalance      = "balance"      // 充值余额
+	PaymentOrderKindSubscription = "subscription" // 购买分组订阅
+)
+
+// 支付订单状态
+const (
+	PaymentOrderStatusPending   = "pending"   // 待支付
+	PaymentOrderStatusCompleted = "completed" // 已支付并已发放权益
+	PaymentOrderStatusFailed    = "failed"    // 支付失败 / 金额不符
+	PaymentOrderStatusExpired   = "expired"   // 超时未支付（不落库，由 pending + expires_at 推导）
+	PaymentOrderStatusCancelled = "cancelled" // 用户取消
+)
+
+var (
+	ErrPaymentDisabled              = infraerrors.Forbidden("PAYMENT_DISABLED", "online payment is disabled")
+	ErrPaymentProviderNotFound      = infraerrors.NotFound("PAYMENT_PROVIDER_NOT_FOUND", "payment provider not found or disabled")
+	ErrPaymentOrderNotFound         = infraerrors.NotFound("PAYMENT_ORDER_NOT_FOUND", "payment order not found")
+	ErrPaymentOrderExists           = infraerrors.Conflict("PAYMENT_ORDER_EXISTS", "payment order already exists")
+	ErrPaymentOrderNotPending       = infraerrors.Conflict("PAYMENT_ORDER_NOT_PENDING", "payment order is not pending")
+	ErrPaymentOrderKindInvalid      = infraerrors.BadRequest("PAYMENT_ORDER_KIND_INVALID", "order kind must be balance or subscription")
+	ErrPaymentAmountInvalid         = infraerrors.BadRequest("PAYMENT_AMOUNT_INVALID", "payment amount is out of range")
+	ErrPaymentPlanNotFound          = infraerrors.NotFound("PAYMENT_PLAN_NOT_FOUND", "subscription plan not found")
+	ErrPaymentPayTypeInvalid        = infraerrors.BadRequest("PAYMENT_PAY_TYPE_INVALID", "unsupported pay type")
+	ErrPaymentSignatureInvalid      = infraerrors.Unauthorized("PAYMENT_SIGNATURE_INVALID", "invalid payment notification signature")
+	ErrPaymentNotificationMalformed = infraerrors.BadRequest("PAYMENT_NOTIFICATION_MALFORMED", "malformed payment notification")
+	ErrPaymentAmountMismatch        = infraerrors.BadRequest("PAYMENT_AMOUNT_MISMATCH", "paid amount does not match order amount")
+)
+
+// PaymentOrder 支付订单实体
+type PaymentOrder struct {
+	ID       int64
+	OrderNo  string
+	UserID   int64
+	Provider string
+	PayType  string // 易支付等网关的支付方式（alipay/wxpay...），其他提供方为空
+	Kind     string
+	Amount   float64 // 支付金额（Currency 币种）
+	Currency string
+	// CreditAmount 到账余额（美元），仅 Kind=balance 有效
+	CreditAmount float64
+	// GroupID / ValidityDays 购买的订阅分组与天数，仅 Kind=subscription 有效
+	GroupID         *int64
+	ValidityDays    int
+	Subject         string
+	Status          string
+	ProviderTradeNo string
+	PayURL          string
+	ClientIP        string
+	PaidAt          *time.Time
+	ExpiresAt       time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// EffectiveStatus 展示用状态：超过有效期的待支付订单视为 expired
+func (o *PaymentOrder) EffectiveStatus(now time.Time) string {
+	if o.Status == PaymentOrderStatusPending && now.After(o.ExpiresAt) {
+		return PaymentOrderStatusExpired
+	}
+	return o.Status
+}
+
+// PaymentOrderListFilters 订单列表筛选条件
+type PaymentOrderListFilters struct {
+	UserID   int64
+	Status   string
+	Provider string
+	Kind     string
+	Search   string // 按订单号 / 第三方交易号模糊匹配
+}
+
+// PaymentOrderRepository 支付订单持久化。
+// MarkCompleted 需支持通过 context 中的事务执行；List 的 Status=expired/pending 按 expires_at 区分。
+type PaymentOrderRepository interface {
+	Create(ctx context.Context, order *PaymentOrder) error
+	GetByOrderNo(ctx context.Context, orderNo string) (*PaymentOrder, error)
+	SetPayInfo(ctx context.Context, orderNo, payURL, providerTradeNo string) error
+	// MarkCompleted 将 pending/cancelled 订单原子地置为 completed；返回 false 表示订单已被处理过
+	MarkCompleted(ctx context.Context, orderNo, providerTradeNo string, paidAt time.Time) (bool, error)
+	// UpdateStatus 仅当当前状态为 fromStatus 时更新，返回是否更新成功
+	UpdateStatus(ctx context.Context, orderNo, fromStatus, toStatus string) (bool, error)
+	List(ctx context.Context, params pagination.PaginationParams, filters PaymentOrderListFilters) ([]PaymentOrder, *pagination.PaginationResult, error)
+}
+
+// generatePaymentOrderNo 生成订单号：P + 时间戳 + 随机串（≤32 字符，兼容各网关 out_trade_no 长度限制）
+func generatePaymentOrderNo(now time.Time) (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "P" + now.UTC().Format("20060102150405") + hex.EncodeToString(b), nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+
+	"github.com/tidwall/gjson"
+)
+
+// 支付提供方名称（同时作为回调路径 /api/v1/payment/notify/{provider}）
+const (
+	PaymentProviderStripe = "stripe"
+	PaymentProviderEPay   = "epay"
+	PaymentProviderMock   = "mock"
+)
+
+// MockPaymentSignatureHeader 模拟支付回调签名请求头：hex(HMAC-SHA256(secret, body))
+const MockPaymentSignatureHeader = "X-Mock-Signature"
+
+// PaymentProvider 支付提供方：负责创建支付与校验异步通知，不涉及订单状态与权益发放
+type PaymentProvider interface {
+	Name() string
+	// PayTypes 支持的支付方式（仅聚合网关需要用户选择，其他提供方返回 nil）
+	PayTypes() []string
+	// CreatePayment 为订单创建支付，返回用户跳转地址
+	CreatePayment(ctx context.Context, order *PaymentOrder, urls PaymentURLs) (*PaymentCreateResult, error)
+	// VerifyNotification 校验异步通知签名并解析结果；签名无效时返回 ErrPaymentSignatureInvalid
+	VerifyNotification(ctx context.Context, req *PaymentNotifyRequest) (*PaymentNotification, error)
+}
+
+// PaymentURLs 创建支付时使用的回调与返回地址
+type PaymentURLs struct {
+	NotifyURL string
+	ReturnURL string
+	CancelURL string
+}
+
+// PaymentCreateResult 创建支付结果
+type PaymentCreateResult struct {
+	PayURL          string
+	ProviderTradeNo string
+}
+
+// PaymentNotifyRequest 原始异步通知
+type PaymentNotifyRequest struct {
+	Method string
+	Header http.Header
+	Query  url.Values
+	Body   []byte
+}
+
+// Params 合并 query 与表单 body 参数（易支付等网关可能使用 GET 或 POST 表单回调）
+func (r *PaymentNotifyRequest) Params() url.Values {
+	out := url.Values{}
+	for k, v := range r.Query {
+		out[k] = append([]string(nil), v...)
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "application/x-www-form-urlencoded" && len(r.Body) > 0 {
+		if form, err := url.ParseQuery(string(r.Body)); err == nil {
+			for k, v := range form {
+				out[k] = v
+			}
+		}
+	}
+	return out
+}
+
+// PaymentNotification 校验通过的通知内容
+type PaymentNotification struct {
+	OrderNo         string
+	ProviderTradeNo string
+	Amount          float64
+	Currency        string // 为空表示提供方未回传币种
+	// Paid 为 false 表示无需处理的事件（如未完成支付），仅需应答
+	Paid bool
+	// Ack 通知处理成功后返回给提供方的响应体
+	Ack string
+}
+
+// buildPaymentProviders 根据配置构建已启用的支付提供方
+func buildPaymentProviders(cfg config.PaymentConfig, stripeClient StripeClient) map[string]PaymentProvider {
+	providers := make(map[string]PaymentProvider)
+	if !cfg.Enabled {
+		return providers
+	}
+	if cfg.Stripe.Enabled && stripeClient != nil {
+		providers[PaymentProviderStripe] = newStripePaymentProvider(cfg.Stripe, stripeClient)
+	}
+	if cfg.EPay.Enabled {
+		providers[PaymentProviderEPay] = newEPayPaymentProvider(cfg.EPay)
+	}
+	if cfg.Mock.Enabled {
+		providers[PaymentProviderMock] = newMockPaymentProvider(cfg.Mock.Secret)
+	}
+	return providers
+}
+
+// mockPaymentProvider 模拟支付：支付页直接跳转到返回地址，回调 body 为 JSON 并以 HMAC-SHA256 签名
+type mockPaymentProvider struct {
+	secret string
+}
+
+func newMockPaymentProvider(secret string) *mockPaymentProvider {
+	return &mockPaymentProvider{secret: secret}
+}
+
+func (p *mockPaymentProvider) Name() string { return PaymentProviderMock }
+
+func (p *mockPaymentProvider) PayTypes() []string { return nil }
+
+func (p *mockPaymentProvider) CreatePayment(_ context.Context, order *PaymentOrder, urls PaymentURLs) (*PaymentCreateResult, error) {
+	payURL := urls.ReturnURL
+	if payURL == "" {
+		payURL = "/"
+	}
+	return &PaymentCreateResult{
+		PayURL:          appendURLQuery(payURL, url.Values{"order_no": {order.OrderNo}, "mock": {"1"}}),
+		ProviderTradeNo: "MOCK-" + order.OrderNo,
+	}, nil
+}
+
+// VerifyNotification body: {"order_no":"...","trade_no":"...","amount":10,"currency":"usd","status":"paid"}
+func (p *mockPaymentProvider) VerifyNotification(_ context.Context, req *PaymentNotifyRequest) (*PaymentNotification, error) {
+	sig := strings.TrimSpace(req.Header.Get(MockPaymentSignatureHeader))
+	if sig == "" || !hmac.Equal([]byte(sig), []byte(SignMockPaymentNotification(p.secret, req.Body))) {
+		return nil, ErrPaymentSignatureInvalid
+	}
+	if !gjson.ValidBytes(req.Body) {
+		return nil, ErrPaymentNotificationMalformed
+	}
+	body := gjson.ParseBytes(req.Body)
+	orderNo := strings.TrimSpace(body.Get("order_no").String())
+	if orderNo == "" {
+		return nil, ErrPaymentNotificationMalformed
+	}
+	return &PaymentNotification{
+		OrderNo:         orderNo,
+		ProviderTradeNo: strings.TrimSpace(body.Get("trade_no").String()),
+		Amount:          body.Get("amount").Float(),
+		Currency:        strings.ToLower(strings.TrimSpace(body.Get("currency").String())),
+		Paid:            body.Get("status").String() == "paid",
+		Ack:             "ok",
+	}, nil
+}
+
+// SignMockPaymentNotification 计算模拟支付回调签名（供测试与联调脚本使用）
+func SignMockPaymentNotification(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// appendURLQuery 在已有 URL 上追加 query 参数
+func appendURLQuery(rawURL string, values url.Values) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	q := u.Query()
+	for k, v := range values {
+		q[k] = v
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,507 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	paymentNotifyPathPrefix  = "/api/v1/payment/notify/"
+	paymentFulfillScope      = "payment.order.fulfill"
+	paymentMaxCreditAmount   = 1_000_000
+	paymentCacheInvalidateTO = 5 * time.Second
+)
+
+// CreatePaymentOrderInput 创建支付订单输入
+type CreatePaymentOrderInput struct {
+	UserID   int64
+	Provider string
+	PayType  string
+	Kind     string
+	Amount   float64 // Kind=balance 时的支付金额
+	GroupID  int64   // Kind=subscription 时购买的套餐分组
+	ClientIP string
+}
+
+// PaymentOptions 用户端可见的支付选项
+type PaymentOptions struct {
+	Enabled     bool
+	Currency    string
+	MinAmount   float64
+	MaxAmount   float64
+	BalanceRate float64
+	Providers   []PaymentProviderOption
+	Plans       []PaymentPlan
+}
+
+// PaymentProviderOption 可用支付方式
+type PaymentProviderOption struct {
+	Name     string
+	PayTypes []string
+}
+
+// PaymentPlan 可购买的订阅套餐
+type PaymentPlan struct {
+	GroupID      int64
+	GroupName    string
+	Price        float64
+	ValidityDays int
+}
+
+// PaymentService 自助充值：创建支付订单、处理提供方回调并发放余额或订阅
+type PaymentService struct {
+	cfg                  config.PaymentConfig
+	orderRepo            PaymentOrderRepository
+	groupRepo            GroupRepository
+	userRepo             UserRepository
+	redeemRepo           RedeemCodeRepository
+	subscriptionService  *SubscriptionService
+	billingCacheService  *BillingCacheService
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	entClient            *dbent.Client
+	idempotency          *IdempotencyCoordinator
+	providers            map[string]PaymentProvider
+}
+
+// NewPaymentService 创建支付服务
+func NewPaymentService(
+	cfg *config.Config,
+	orderRepo PaymentOrderRepository,
+	groupRepo GroupRepository,
+	userRepo UserRepository,
+	redeemRepo RedeemCodeRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	entClient *dbent.Client,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	idempotency *IdempotencyCoordinator,
+	stripeClient StripeClient,
+) *PaymentService {
+	var paymentCfg config.PaymentConfig
+	if cfg != nil {
+		paymentCfg = cfg.Payment
+	}
+	return &PaymentService{
+		cfg:                  paymentCfg,
+		orderRepo:            orderRepo,
+		groupRepo:            groupRepo,
+		userRepo:             userRepo,
+		redeemRepo:           redeemRepo,
+		subscriptionService:  subscriptionService,
+		billingCacheService:  billingCacheService,
+		authCacheInvalidator: authCacheInvalidator,
+		entClient:            entClient,
+		idempotency:          idempotency,
+		providers:            buildPaymentProviders(paymentCfg, stripeClient),
+	}
+}
+
+// Enabled 是否开放自助充值
+func (s *PaymentService) Enabled() bool {
+	return s != nil && s.cfg.Enabled && len(s.providers) > 0
+}
+
+// GetOptions 返回充值页所需的支付方式与套餐
+func (s *PaymentService) GetOptions(ctx context.Context) (*PaymentOptions, error) {
+	out := &PaymentOptions{
+		Enabled:     s.Enabled(),
+		Currency:    s.cfg.Currency,
+		MinAmount:   s.cfg.MinAmount,
+		MaxAmount:   s.cfg.MaxAmount,
+		BalanceRate: s.cfg.BalanceRate,
+		Providers:   []PaymentProviderOption{},
+		Plans:       []PaymentPlan{},
+	}
+	if !out.Enabled {
+		return out, nil
+	}
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out.Providers = append(out.Providers, PaymentProviderOption{Name: name, PayTypes: s.providers[name].PayTypes()})
+	}
+	groups, err := s.groupRepo.ListActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list active groups: %w", err)
+	}
+	for i := range groups {
+		plan, ok := planFromGroup(&groups[i])
+		if !ok {
+			continue
+		}
+		out.Plans = append(out.Plans, PaymentPlan{
+			GroupID:      plan.GroupID,
+			GroupName:    plan.GroupName,
+			Price:        plan.Price,
+			ValidityDays: plan.ValidityDays,
+		})
+	}
+	return out, nil
+}
+
+// CreateOrder 创建支付订单并向提供方下单，返回含支付跳转地址的订单
+func (s *PaymentService) CreateOrder(ctx context.Context, in *CreatePaymentOrderInput) (*PaymentOrder, error) {
+	if !s.Enabled() {
+		return nil, ErrPaymentDisabled
+	}
+	provider, ok := s.providers[strings.ToLower(strings.TrimSpace(in.Provider))]
+	if !ok {
+		return nil, ErrPaymentProviderNotFound
+	}
+	payType := strings.TrimSpace(in.PayType)
+	if payTypes := provider.PayTypes(); len(payTypes) > 0 {
+		if payType == "" {
+			payType = payTypes[0]
+		}
+		if !slices.Contains(payTypes, payType) {
+			return nil, ErrPaymentPayTypeInvalid
+		}
+	} else {
+		payType = ""
+	}
+
+	now := time.Now()
+	orderNo, err := generatePaymentOrderNo(now)
+	if err != nil {
+		return nil, fmt.Errorf("generate order no: %w", err)
+	}
+	order := &PaymentOrder{
+		OrderNo:   orderNo,
+		UserID:    in.UserID,
+		Provider:  provider.Name(),
+		PayType:   payType,
+		Kind:      in.Kind,
+		Currency:  s.cfg.Currency,
+		Status:    PaymentOrderStatusPending,
+		ClientIP:  in.ClientIP,
+		ExpiresAt: now.Add(time.Duration(s.cfg.OrderExpireMinutes) * time.Minute),
+	}
+
+	switch in.Kind {
+	case PaymentOrderKindBalance:
+		amount := roundPaymentAmount(in.Amount)
+		if amount <= 0 || amount < s.cfg.MinAmount || (s.cfg.MaxAmount > 0 && amount > s.cfg.MaxAmount) {
+			return nil, ErrPaymentAmountInvalid
+		}
+		order.Amount = amount
+		order.CreditAmount = amount * s.cfg.BalanceRate
+		if order.CreditAmount <= 0 || order.CreditAmount > paymentMaxCreditAmount {
+			return nil, ErrPaymentAmountInvalid
+		}
+		order.Subject = fmt.Sprintf("Balance top-up $%s", strconv.FormatFloat(order.CreditAmount, 'f', -1, 64))
+	case PaymentOrderKindSubscription:
+		plan, err := s.resolvePlan(ctx, in.GroupID)
+		if err != nil {
+			return nil, err
+		}
+		groupID := plan.GroupID
+		order.Amount = roundPaymentAmount(plan.Price)
+		order.GroupID = &groupID
+		order.ValidityDays = plan.ValidityDays
+		order.Subject = fmt.Sprintf("Subscription %s (%d days)", plan.GroupName, plan.ValidityDays)
+	default:
+		return nil, ErrPaymentOrderKindInvalid
+	}
+
+	if err := s.orderRepo.Create(ctx, order); err != nil {
+		return nil, err
+	}
+
+	result, err := provider.CreatePayment(ctx, order, s.paymentURLs(order))
+	if err != nil {
+		if _, markErr := s.orderRepo.UpdateStatus(ctx, order.OrderNo, PaymentOrderStatusPending, PaymentOrderStatusFailed); markErr != nil {
+			logger.LegacyPrintf("service.payment", "mark order failed error: order_no=%s err=%v", order.OrderNo, markErr)
+		}
+		logger.LegacyPrintf("service.payment", "create payment failed: provider=%s order_no=%s err=%v", order.Provider, order.OrderNo, err)
+		return nil, err
+	}
+	if err := s.orderRepo.SetPayInfo(ctx, order.OrderNo, result.PayURL, result.ProviderTradeNo); err != nil {
+		return nil, err
+	}
+	order.PayURL = result.PayURL
+	order.ProviderTradeNo = result.ProviderTradeNo
+	return order, nil
+}
+
+// GetUserOrder 获取当前用户的订单
+func (s *PaymentService) GetUserOrder(ctx context.Context, userID int64, orderNo string) (*PaymentOrder, error) {
+	order, err := s.orderRepo.GetByOrderNo(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, ErrPaymentOrderNotFound
+	}
+	return order, nil
+}
+
+// CancelUserOrder 用户取消待支付订单（已取消的订单仍可能收到迟到的支付回调，此时照常发放）
+func (s *PaymentService) CancelUserOrder(ctx context.Context, userID int64, orderNo string) (*PaymentOrder, error) {
+	order, err := s.GetUserOrder(ctx, userID, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.orderRepo.UpdateStatus(ctx, orderNo, PaymentOrderStatusPending, PaymentOrderStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrPaymentOrderNotPending
+	}
+	order.Status = PaymentOrderStatusCancelled
+	return order, nil
+}
+
+// ListUserOrders 当前用户的订单列表
+func (s *PaymentService) ListUserOrders(ctx context.Context, userID int64, params pagination.PaginationParams, status string) ([]PaymentOrder, *pagination.PaginationResult, error) {
+	return s.orderRepo.List(ctx, params, PaymentOrderListFilters{UserID: userID, Status: status})
+}
+
+// ListOrders 订单列表（管理员）
+func (s *PaymentService) ListOrders(ctx context.Context, params pagination.PaginationParams, filters PaymentOrderListFilters) ([]PaymentOrder, *pagination.PaginationResult, error) {
+	return s.orderRepo.List(ctx, params, filters)
+}
+
+// GetOrder 获取订单（管理员）
+func (s *PaymentService) GetOrder(ctx context.Context, orderNo string) (*PaymentOrder, error) {
+	return s.orderRepo.GetByOrderNo(ctx, orderNo)
+}
+
+// HandleNotification 校验并处理提供方异步通知，返回应答提供方的响应体。
+// 返回错误时提供方会按其策略重试；重复通知不会重复发放。
+func (s *PaymentService) HandleNotification(ctx context.Context, providerName string, req *PaymentNotifyRequest) (string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", ErrPaymentProviderNotFound
+	}
+	notification, err := provider.VerifyNotification(ctx, req)
+	if err != nil {
+		logger.LegacyPrintf("service.payment", "reject notification: provider=%s err=%v", providerName, err)
+		return "", err
+	}
+	if !notification.Paid {
+		return notification.Ack, nil
+	}
+
+	order, err := s.orderRepo.GetByOrderNo(ctx, notification.OrderNo)
+	if err != nil {
+		return "", err
+	}
+	if order.Provider != provider.Name() {
+		logger.LegacyPrintf("service.payment", "notification provider mismatch: order_no=%s order_provider=%s notify_provider=%s", order.OrderNo, order.Provider, providerName)
+		return "", ErrPaymentOrderNotFound
+	}
+	if order.Status == PaymentOrderStatusCompleted {
+		return notification.Ack, nil
+	}
+	if !paymentAmountEqual(notification.Amount, order.Amount) ||
+		(notification.Currency != "" && !strings.EqualFold(notification.Currency, order.Currency)) {
+		logger.LegacyPrintf("service.payment", "amount mismatch: order_no=%s expected=%s %s got=%s %s",
+			order.OrderNo, formatPaymentAmount(order.Amount), order.Currency, formatPaymentAmount(notification.Amount), notification.Currency)
+		if _, markErr := s.orderRepo.UpdateStatus(ctx, order.OrderNo, order.Status, PaymentOrderStatusFailed); markErr != nil {
+			logger.LegacyPrintf("service.payment", "mark order failed error: order_no=%s err=%v", order.OrderNo, markErr)
+		}
+		return "", ErrPaymentAmountMismatch
+	}
+	if order.Status == PaymentOrderStatusFailed {
+		return "", ErrPaymentOrderNotPending
+	}
+
+	if err := s.fulfillOrder(ctx, order, notification.ProviderTradeNo); err != nil {
+		return "", err
+	}
+	return notification.Ack, nil
+}
+
+// fulfillOrder 幂等地完成订单并发放权益。
+// 幂等记录（按订单号）避免并发回调重复执行；事务内 pending -> completed 的条件更新是最终的防重保证。
+func (s *PaymentService) fulfillOrder(ctx context.Context, order *PaymentOrder, providerTradeNo string) error {
+	execute := func(ctx context.Context) (any, error) {
+		credited, err := s.creditOrder(ctx, order, providerTradeNo)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"order_no": order.OrderNo, "credited": credited}, nil
+	}
+	if s.idempotency == nil {
+		_, err := execute(ctx)
+		return err
+	}
+	_, err := s.idempotency.Execute(ctx, IdempotencyExecuteOptions{
+		Scope:          paymentFulfillScope,
+		ActorScope:     "user:" + strconv.FormatInt(order.UserID, 10),
+		Method:         "POST",
+		Route:          paymentNotifyPathPrefix + order.Provider,
+		IdempotencyKey: order.OrderNo,
+		Payload:        map[string]any{"order_no": order.OrderNo, "provider": order.Provider},
+		RequireKey:     true,
+		TTL:            DefaultWriteIdempotencyTTL(),
+	}, execute)
+	return err
+}
+
+// creditOrder 在事务中标记订单完成并发放余额/订阅；订单已完成时返回 false
+func (s *PaymentService) creditOrder(ctx context.Context, order *PaymentOrder, providerTradeNo string) (bool, error) {
+	opCtx := ctx
+	var tx *dbent.Tx
+	if s.entClient != nil {
+		var err error
+		tx, err = s.entClient.Tx(ctx)
+		if err != nil {
+			return false, fmt.Errorf("begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+		opCtx = dbent.NewTxContext(ctx, tx)
+	}
+
+	// 【关键】先通过条件更新抢占订单，保证同一订单只发放一次
+	marked, err := s.orderRepo.MarkCompleted(opCtx, order.OrderNo, providerTradeNo, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("mark order completed: %w", err)
+	}
+	if !marked {
+		return false, nil
+	}
+
+	switch order.Kind {
+	case PaymentOrderKindBalance:
+		if err := s.userRepo.UpdateBalance(opCtx, order.UserID, order.CreditAmount); err != nil {
+			return false, fmt.Errorf("update user balance: %w", err)
+		}
+	case PaymentOrderKindSubscription:
+		if order.GroupID == nil {
+			return false, fmt.Errorf("subscription order %s missing group_id", order.OrderNo)
+		}
+		if s.subscriptionService == nil {
+			return false, errors.New("subscription service unavailable")
+		}
+		_, _, err := s.subscriptionService.AssignOrExtendSubscription(opCtx, &AssignSubscriptionInput{
+			UserID:       order.UserID,
+			GroupID:      *order.GroupID,
+			ValidityDays: order.ValidityDays,
+			AssignedBy:   0, // 系统分配
+			Notes:        fmt.Sprintf("在线支付订单 %s", order.OrderNo),
+		})
+		if err != nil {
+			return false, fmt.Errorf("assign or extend subscription: %w", err)
+		}
+	default:
+		return false, fmt.Errorf("unsupported order kind: %s", order.Kind)
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return false, fmt.Errorf("commit transaction: %w", err)
+		}
+	}
+	logger.LegacyPrintf("service.payment", "order completed: order_no=%s user_id=%d kind=%s amount=%s %s",
+		order.OrderNo, order.UserID, order.Kind, formatPaymentAmount(order.Amount), order.Currency)
+
+	s.invalidateOrderCaches(ctx, order)
+	if order.Kind == PaymentOrderKindBalance {
+		s.recordBalanceHistory(ctx, order)
+	}
+	return true, nil
+}
+
+// recordBalanceHistory 写入余额充值记录，使其出现在用户充值历史与累计充值统计中
+func (s *PaymentService) recordBalanceHistory(ctx context.Context, order *PaymentOrder) {
+	if s.redeemRepo == nil {
+		return
+	}
+	code, err := GenerateRedeemCode()
+	if err != nil {
+		logger.LegacyPrintf("service.payment", "failed to generate balance history code: %v", err)
+		return
+	}
+	now := time.Now()
+	userID := order.UserID
+	record := &RedeemCode{
+		Code:   code,
+		Type:   RedeemTypeBalance,
+		Value:  order.CreditAmount,
+		Status: StatusUsed,
+		UsedBy: &userID,
+		UsedAt: &now,
+		Notes:  fmt.Sprintf("在线支付订单 %s", order.OrderNo),
+	}
+	if err := s.redeemRepo.Create(ctx, record); err != nil {
+		logger.LegacyPrintf("service.payment", "failed to create balance history record: order_no=%s err=%v", order.OrderNo, err)
+	}
+}
+
+// invalidateOrderCaches 事务提交后失效鉴权与计费缓存
+// （订阅在订单事务内续期，提交前的缓存失效可能被并发读取回填旧值，这里再失效一次）
+func (s *PaymentService) invalidateOrderCaches(ctx context.Context, order *PaymentOrder) {
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, order.UserID)
+	}
+	if order.GroupID != nil && s.subscriptionService != nil {
+		s.subscriptionService.InvalidateSubCache(order.UserID, *order.GroupID)
+	}
+	if s.billingCacheService == nil {
+		return
+	}
+	userID := order.UserID
+	groupID := order.GroupID
+	go func() {
+		cacheCtx, cancel := context.WithTimeout(context.Background(), paymentCacheInvalidateTO)
+		defer cancel()
+		if groupID != nil {
+			_ = s.billingCacheService.InvalidateSubscription(cacheCtx, userID, *groupID)
+			return
+		}
+		_ = s.billingCacheService.InvalidateUserBalance(cacheCtx, userID)
+	}()
+}
+
+// resolvePlan 按分组订阅价格确定套餐（与余额购买共用 groups.subscription_price）
+func (s *PaymentService) resolvePlan(ctx context.Context, groupID int64) (*SubscriptionPlan, error) {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, ErrGroupNotFound) {
+			return nil, ErrPaymentPlanNotFound
+		}
+		return nil, fmt.Errorf("get group: %w", err)
+	}
+	plan, ok := planFromGroup(group)
+	if !ok {
+		return nil, ErrPaymentPlanNotFound
+	}
+	return plan, nil
+}
+
+func (s *PaymentService) paymentURLs(order *PaymentOrder) PaymentURLs {
+	urls := PaymentURLs{}
+	if s.cfg.NotifyBaseURL != "" {
+		urls.NotifyURL = s.cfg.NotifyBaseURL + paymentNotifyPathPrefix + order.Provider
+	}
+	if s.cfg.ReturnURL != "" {
+		urls.ReturnURL = appendURLQuery(s.cfg.ReturnURL, map[string][]string{"order_no": {order.OrderNo}})
+		urls.CancelURL = appendURLQuery(s.cfg.ReturnURL, map[string][]string{"order_no": {order.OrderNo}, "cancelled": {"1"}})
+	}
+	return urls
+}
+
+func roundPaymentAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func paymentAmountEqual(a, b float64) bool {
+	return math.Round(a*100) == math.Round(b*100)
+}
@@ -0,0 +1,327 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type paymentOrderRepoStub struct {
+	mu     sync.Mutex
+	nextID int64
+	orders map[string]*PaymentOrder
+}
+
+func newPaymentOrderRepoStub() *paymentOrderRepoStub {
+	return &paymentOrderRepoStub{nextID: 1, orders: make(map[string]*PaymentOrder)}
+}
+
+func (r *paymentOrderRepoStub) Create(_ context.Context, o *PaymentOrder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.orders[o.OrderNo]; ok {
+		return ErrPaymentOrderExists
+	}
+	o.ID = r.nextID
+	r.nextID++
+	o.CreatedAt = time.Now()
+	o.UpdatedAt = o.CreatedAt
+	cp := *o
+	r.orders[o.OrderNo] = &cp
+	return nil
+}
+
+func (r *paymentOrderRepoStub) GetByOrderNo(_ context.Context, orderNo string) (*PaymentOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[orderNo]
+	if !ok {
+		return nil, ErrPaymentOrderNotFound
+	}
+	cp := *o
+	return &cp, nil
+}
+
+func (r *paymentOrderRepoStub) SetPayInfo(_ context.Context, orderNo, payURL, providerTradeNo string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[orderNo]
+	if !ok {
+		return ErrPaymentOrderNotFound
+	}
+	o.PayURL = payURL
+	o.ProviderTradeNo = providerTradeNo
+	return nil
+}
+
+func (r *paymentOrderRepoStub) MarkCompleted(_ context.Context, orderNo, providerTradeNo string, paidAt time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[orderNo]
+	if !ok || (o.Status != PaymentOrderStatusPending && o.Status != PaymentOrderStatusCancelled) {
+		return false, nil
+	}
+	o.Status = PaymentOrderStatusCompleted
+	o.PaidAt = &paidAt
+	if providerTradeNo != "" {
+		o.ProviderTradeNo = providerTradeNo
+	}
+	return true, nil
+}
+
+func (r *paymentOrderRepoStub) UpdateStatus(_ context.Context, orderNo, fromStatus, toStatus string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	o, ok := r.orders[orderNo]
+	if !ok || o.Status != fromStatus {
+		return false, nil
+	}
+	o.Status = toStatus
+	return true, nil
+}
+
+func (r *paymentOrderRepoStub) List(context.Context, pagination.PaginationParams, PaymentOrderListFilters) ([]PaymentOrder, *pagination.PaginationResult, error) {
+	panic("unexpected List call")
+}
+
+func (r *paymentOrderRepoStub) status(orderNo string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.orders[orderNo].Status
+}
+
+const testPaymentMockSecret = "mock-secret"
+
+func newTestPaymentService(t *testing.T, idempotency *IdempotencyCoordinator, groupRepo GroupRepository) (*PaymentService, *paymentOrderRepoStub, *[]float64) {
+	t.Helper()
+	cfg := &config.Config{Payment: config.PaymentConfig{
+		Enabled:            true,
+		OrderExpireMinutes: 30,
+		MinAmount:          1,
+		MaxAmount:          500,
+		Currency:           "usd",
+		BalanceRate:        2,
+		ReturnURL:          "https://example.com/payment/result",
+		NotifyBaseURL:      "https://api.example.com",
+		Mock:               config.PaymentMockConfig{Enabled: true, Secret: testPaymentMockSecret},
+	}}
+	orderRepo := newPaymentOrderRepoStub()
+	var mu sync.Mutex
+	credits := []float64{}
+	userRepo := &mockUserRepo{updateBalanceFn: func(_ context.Context, _ int64, amount float64) error {
+		mu.Lock()
+		defer mu.Unlock()
+		credits = append(credits, amount)
+		return nil
+	}}
+	if groupRepo == nil {
+		groupRepo = groupRepoNoop{}
+	}
+	svc := NewPaymentService(cfg, orderRepo, groupRepo, userRepo, nil, nil, nil, nil, nil, idempotency, nil)
+	return svc, orderRepo, &credits
+}
+
+func mockPaymentNotify(t *testing.T, secret, orderNo string, amount float64) *PaymentNotifyRequest {
+	t.Helper()
+	body := []byte(fmt.Sprintf(`{"order_no":%q,"trade_no":"T-1","amount":%s,"currency":"usd","status":"paid"}`,
+		orderNo, strconv.FormatFloat(amount, 'f', -1, 64)))
+	header := http.Header{}
+	header.Set(MockPaymentSignatureHeader, SignMockPaymentNotification(secret, body))
+	return &PaymentNotifyRequest{Method: http.MethodPost, Header: header, Query: url.Values{}, Body: body}
+}
+
+func TestPaymentService_BalanceTopUpCreditsOnce(t *testing.T) {
+	coordinator := NewIdempotencyCoordinator(newInMemoryIdempotencyRepo(), DefaultIdempotencyConfig())
+	svc, orderRepo, credits := newTestPaymentService(t, coordinator, nil)
+	ctx := context.Background()
+
+	order, err := svc.CreateOrder(ctx, &CreatePaymentOrderInput{UserID: 42, Provider: PaymentProviderMock, Kind: PaymentOrderKindBalance, Amount: 10})
+	require.NoError(t, err)
+	require.Equal(t, 10.0, order.Amount)
+	require.Equal(t, 20.0, order.CreditAmount)
+	require.Contains(t, order.PayURL, "order_no="+order.OrderNo)
+
+	ack, err := svc.HandleNotification(ctx, PaymentProviderMock, mockPaymentNotify(t, testPaymentMockSecret, order.OrderNo, 10))
+	require.NoError(t, err)
+	require.Equal(t, "ok", ack)
+	require.Equal(t, PaymentOrderStatusCompleted, orderRepo.status(order.OrderNo))
+
+	// 重复回调：只应答不重复入账
+	ack, err = svc.HandleNotification(ctx, PaymentProviderMock, mockPaymentNotify(t, testPaymentMockSecret, order.OrderNo, 10))
+	require.NoError(t, err)
+	require.Equal(t, "ok", ack)
+	require.Equal(t, []float64{20}, *credits)
+}
+
+func TestPaymentService_FulfillReplayDoesNotCreditTwice(t *testing.T) {
+	for name, coordinator := range map[string]*IdempotencyCoordinator{
+		"with_idempotency":    NewIdempotencyCoordinator(newInMemoryIdempotencyRepo(), DefaultIdempotencyConfig()),
+		"without_idempotency": nil,
+	} {
+		t.Run(name, func(t *testing.T) {
+			svc, _, credits := newTestPaymentService(t, coordinator, nil)
+			ctx := context.Background()
+			order, err := svc.CreateOrder(ctx, &CreatePaymentOrderInput{UserID: 1, Provider: PaymentProviderMock, Kind: PaymentOrderKindBalance, Amount: 5})
+			require.NoError(t, err)
+
+			// 绕过 completed 快速判断，直接并发执行发放，验证幂等记录 + 条件更新的双重保护
+			var wg sync.WaitGroup
+			for i := 0; i < 5; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_ = svc.fulfillOrder(ctx, order, "T-1")
+				}()
+			}
+			wg.Wait()
+			require.NoError(t, svc.fulfillOrder(ctx, order, "T-1"))
+			require.Equal(t, []float64{10}, *credits)
+		})
+	}
+}
+
+func TestPaymentService_RejectsInvalidNotifications(t *testing.T) {
+	svc, orderRepo, credits := newTestPaymentService(t, nil, nil)
+	ctx := context.Background()
+	order, err := svc.CreateOrder(ctx, &CreatePaymentOrderInput{UserID: 1, Provider: PaymentProviderMock, Kind: PaymentOrderKindBalance, Amount: 10})
+	require.NoError(t, err)
+
+	_, err = svc.HandleNotification(ctx, PaymentProviderMock, mockPaymentNotify(t, "wrong-secret", order.OrderNo, 10))
+	require.ErrorIs(t, err, ErrPaymentSignatureInvalid)
+
+	_, err = svc.HandleNotification(ctx, PaymentProviderEPay, mockPaymentNotify(t, testPaymentMockSecret, order.OrderNo, 10))
+	require.ErrorIs(t, err, ErrPaymentProviderNotFound)
+
+	_, err = svc.HandleNotification(ctx, PaymentProviderMock, mockPaymentNotify(t, testPaymentMockSecret, order.OrderNo, 9.99))
+	require.ErrorIs(t, err, ErrPaymentAmountMismatch)
+	require.Equal(t, PaymentOrderStatusFailed, orderRepo.status(order.OrderNo))
+	require.Empty(t, *credits)
+}
+
+func TestPaymentService_CreateOrderValidation(t *testing.T) {
+	svc, _, _ := newTestPaymentService(t, nil, &subscriptionGroupRepoStub{group: &Group{
+		ID: 7, Name: "Pro", Status: StatusActive, SubscriptionType: SubscriptionTypeStandard, SubscriptionPrice: float64Ptr(20),
+	}})
+	ctx := context.Background()
+
+	_, err := svc.CreateOrder(ctx, &CreatePaymentOrderInput{UserID: 1, Provider: PaymentProviderMock, Kind: PaymentOrderKindBalance, Amount: 0.5})
+	require.ErrorIs(t, err, ErrPaymentAmountInvalid)
+	_, err = svc.CreateOrder(ctx, &CreatePaymentOrderInput{UserID: 1, Provider: PaymentProviderMock, Kind: PaymentOrderKindBalance, Amount: 501})
+	require.ErrorIs(t, err, ErrPaymentAmountInvalid)
+	_, err = svc.CreateOrder(ctx, &CreatePaymentOrderInput{UserID: 1, Provider: PaymentProviderStripe, Kind: PaymentOrderKindBalance, Amount: 10})
+	require.ErrorIs(t, err, ErrPaymentProviderNotFound)
+	// 非订阅分组即使设置了订阅价格也不可购买
+	_, err = svc.CreateOrder(ctx, &CreatePaymentOrderInput{UserID: 1, Provider: PaymentProviderMock, Kind: PaymentOrderKindSubscription, GroupID: 7})
+	require.ErrorIs(t, err, ErrPaymentPlanNotFound)
+
+	// 订阅分组未设置订阅价格时不可购买
+	svc, _, _ = newTestPaymentService(t, nil, &subscriptionGroupRepoStub{group: &Group{
+		ID: 7, Name: "Pro", Status: StatusActive, SubscriptionType: SubscriptionTypeSubscription,
+	}})
+	_, err = svc.CreateOrder(ctx, &CreatePaymentOrderInput{UserID: 1, Provider: PaymentProviderMock, Kind: PaymentOrderKindSubscription, GroupID: 7})
+	require.ErrorIs(t, err, ErrPaymentPlanNotFound)
+}
+
+func TestPaymentService_CreateSubscriptionOrderUsesGroupDefaultValidity(t *testing.T) {
+	svc, _, _ := newTestPaymentService(t, nil, &subscriptionGroupRepoStub{group: &Group{
+		ID: 7, Name: "Pro", Status: StatusActive, SubscriptionType: SubscriptionTypeSubscription, DefaultValidityDays: 90, SubscriptionPrice: float64Ptr(20),
+	}})
+
+	order, err := svc.CreateOrder(context.Background(), &CreatePaymentOrderInput{UserID: 1, Provider: PaymentProviderMock, Kind: PaymentOrderKindSubscription, GroupID: 7})
+	require.NoError(t, err)
+	require.Equal(t, 20.0, order.Amount)
+	require.NotNil(t, order.GroupID)
+	require.Equal(t, int64(7), *order.GroupID)
+	require.Equal(t, 90, order.ValidityDays)
+	require.Zero(t, order.CreditAmount)
+}
+
+func TestEPayPaymentProvider_SignAndVerify(t *testing.T) {
+	p := newEPayPaymentProvider(config.PaymentEPayConfig{GatewayURL: "https://pay.example.com/", PID: "1001", Key: "k3y", Types: []string{"alipay", "wxpay"}})
+	order := &PaymentOrder{OrderNo: "P1", Subject: "Balance top-up $10", Amount: 10, PayType: "wxpay"}
+
+	result, err := p.CreatePayment(context.Background(), order, PaymentURLs{NotifyURL: "https://api.example.com/api/v1/payment/notify/epay", ReturnURL: "https://example.com"})
+	require.NoError(t, err)
+	u, err := url.Parse(result.PayURL)
+	require.NoError(t, err)
+	require.Equal(t, "/submit.php", u.Path)
+	q := u.Query()
+	require.Equal(t, "wxpay", q.Get("type"))
+	require.Equal(t, "10.00", q.Get("money"))
+	require.Equal(t, epaySign(q, "k3y"), q.Get("sign"))
+
+	notify := url.Values{}
+	notify.Set("pid", "1001")
+	notify.Set("trade_no", "2024000001")
+	notify.Set("out_trade_no", "P1")
+	notify.Set("type", "wxpay")
+	notify.Set("name", "Balance top-up $10")
+	notify.Set("money", "10.00")
+	notify.Set("trade_status", "TRADE_SUCCESS")
+	notify.Set("sign", epaySign(notify, "k3y"))
+	notify.Set("sign_type", "MD5")
+
+	got, err := p.VerifyNotification(context.Background(), &PaymentNotifyRequest{Method: http.MethodGet, Header: http.Header{}, Query: notify})
+	require.NoError(t, err)
+	require.True(t, got.Paid)
+	require.Equal(t, "P1", got.OrderNo)
+	require.Equal(t, "2024000001", got.ProviderTradeNo)
+	require.Equal(t, 10.0, got.Amount)
+	require.Equal(t, "success", got.Ack)
+
+	// POST 表单回调同样可校验
+	header := http.Header{}
+	header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err = p.VerifyNotification(context.Background(), &PaymentNotifyRequest{Method: http.MethodPost, Header: header, Query: url.Values{}, Body: []byte(notify.Encode())})
+	require.NoError(t, err)
+
+	notify.Set("money", "0.01")
+	_, err = p.VerifyNotification(context.Background(), &PaymentNotifyRequest{Method: http.MethodGet, Header: http.Header{}, Query: notify})
+	require.ErrorIs(t, err, ErrPaymentSignatureInvalid)
+}
+
+func TestStripePaymentProvider_VerifyWebhook(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	p := newStripePaymentProvider(config.PaymentStripeConfig{WebhookSecret: "whsec_test"}, nil)
+	p.now = func() time.Time { return now }
+
+	body := []byte(`{"type":"checkout.session.completed","data":{"object":{"id":"cs_1","client_reference_id":"P1","amount_total":1050,"currency":"usd","payment_status":"paid"}}}`)
+	sign := func(secret string, ts time.Time) http.Header {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(strconv.FormatInt(ts.Unix(), 10) + "." + string(body)))
+		h := http.Header{}
+		h.Set(StripeSignatureHeader, fmt.Sprintf("t=%d,v1=%s", ts.Unix(), hex.EncodeToString(mac.Sum(nil))))
+		return h
+	}
+
+	got, err := p.VerifyNotification(context.Background(), &PaymentNotifyRequest{Header: sign("whsec_test", now), Body: body})
+	require.NoError(t, err)
+	require.True(t, got.Paid)
+	require.Equal(t, "P1", got.OrderNo)
+	require.Equal(t, "cs_1", got.ProviderTradeNo)
+	require.Equal(t, 10.5, got.Amount)
+	require.Equal(t, "usd", got.Currency)
+
+	_, err = p.VerifyNotification(context.Background(), &PaymentNotifyRequest{Header: sign("whsec_other", now), Body: body})
+	require.ErrorIs(t, err, ErrPaymentSignatureInvalid)
+
+	_, err = p.VerifyNotification(context.Background(), &PaymentNotifyRequest{Header: sign("whsec_test", now.Add(-10*time.Minute)), Body: body})
+	require.ErrorIs(t, err, ErrPaymentSignatureInvalid)
+
+	require.Equal(t, int64(1050), stripeMinorAmount(10.5, "usd"))
+	require.Equal(t, int64(1000), stripeMinorAmount(1000, "jpy"))
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+
+	"github.com/tidwall/gjson"
+)
+
+const (
+	StripeSignatureHeader        = "Stripe-Signature"
+	stripeWebhookTolerance       = 5 * time.Minute
+	stripeCheckoutMinExpiry      = 30 * time.Minute // Checkout Session expires_at 须至少 30 分钟后
+	stripeDefaultAPIBase         = "https://api.stripe.com"
+	stripeEventCheckoutCompleted = "checkout.session.completed"
+	stripeEventAsyncSucceeded    = "checkout.session.async_payment_succeeded"
+)
+
+// stripeZeroDecimalCurrencies 以最小单位即为元的币种（金额不乘 100）
+var stripeZeroDecimalCurrencies = map[string]struct{}{
+	"bif": {}, "clp": {}, "djf": {}, "gnf": {}, "jpy": {}, "kmf": {}, "krw": {}, "mga": {},
+	"pyg": {}, "rwf": {}, "ugx": {}, "vnd": {}, "vuv": {}, "xaf": {}, "xof": {}, "xpf": {},
+}
+
+// StripeCheckoutSessionRequest 创建 Checkout Session 请求
+type StripeCheckoutSessionRequest struct {
+	APIBase     string
+	SecretKey   string
+	OrderNo     string
+	ProductName string
+	Currency    string
+	UnitAmount  int64 // 最小货币单位
+	SuccessURL  string
+	CancelURL   string
+	ExpiresAt   *time.Time
+}
+
+// StripeCheckoutSession Checkout Session 创建结果
+type StripeCheckoutSession struct {
+	ID  string
+	URL string
+}
+
+// StripeClient 访问 Stripe API 的 HTTP 客户端
+type StripeClient interface {
+	CreateCheckoutSession(ctx context.Context, req *StripeCheckoutSessionRequest) (*StripeCheckoutSession, error)
+}
+
+// stripePaymentProvider Stripe Checkout：跳转托管支付页，通过 webhook（checkout.session.*）确认支付
+type stripePaymentProvider struct {
+	cfg    config.PaymentStripeConfig
+	client StripeClient
+	now    func() time.Time
+}
+
+func newStripePaymentProvider(cfg config.PaymentStripeConfig, client StripeClient) *stripePaymentProvider {
+	if cfg.APIBase == "" {
+		cfg.APIBase = stripeDefaultAPIBase
+	}
+	return &stripePaymentProvider{cfg: cfg, client: client, now: time.Now}
+}
+
+func (p *stripePaymentProvider) Name() string { return PaymentProviderStripe }
+
+func (p *stripePaymentProvider) PayTypes() []string { return nil }
+
+func (p *stripePaymentProvider) CreatePayment(ctx context.Context, order *PaymentOrder, urls PaymentURLs) (*PaymentCreateResult, error) {
+	req := &StripeCheckoutSessionRequest{
+		APIBase:     p.cfg.APIBase,
+		SecretKey:   p.cfg.SecretKey,
+		OrderNo:     order.OrderNo,
+		ProductName: order.Subject,
+		Currency:    order.Currency,
+		UnitAmount:  stripeMinorAmount(order.Amount, order.Currency),
+		SuccessURL:  urls.ReturnURL,
+		CancelURL:   urls.CancelURL,
+	}
+	if order.ExpiresAt.Sub(p.now()) >= stripeCheckoutMinExpiry {
+		expiresAt := order.ExpiresAt
+		req.ExpiresAt = &expiresAt
+	}
+	session, err := p.client.CreateCheckoutSession(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &PaymentCreateResult{PayURL: session.URL, ProviderTradeNo: session.ID}, nil
+}
+
+func (p *stripePaymentProvider) VerifyNotification(_ context.Context, req *PaymentNotifyRequest) (*PaymentNotification, error) {
+	if !verifyStripeSignature(req.Header.Get(StripeSignatureHeader), req.Body, p.cfg.WebhookSecret, p.now()) {
+		return nil, ErrPaymentSignatureInvalid
+	}
+	if !gjson.ValidBytes(req.Body) {
+		return nil, ErrPaymentNotificationMalformed
+	}
+	event := gjson.ParseBytes(req.Body)
+	ack := &PaymentNotification{Ack: `{"received":true}`}
+
+	eventType := event.Get("type").String()
+	if eventType != stripeEventCheckoutCompleted && eventType != stripeEventAsyncSucceeded {
+		return ack, nil
+	}
+	session := event.Get("data.object")
+	orderNo := strings.TrimSpace(session.Get("client_reference_id").String())
+	if orderNo == "" {
+		orderNo = strings.TrimSpace(session.Get("metadata.order_no").String())
+	}
+	if orderNo == "" {
+		return nil, ErrPaymentNotificationMalformed
+	}
+	currency := strings.ToLower(session.Get("currency").String())
+	ack.OrderNo = orderNo
+	ack.ProviderTradeNo = session.Get("id").String()
+	ack.Currency = currency
+	ack.Amount = stripeMajorAmount(session.Get("amount_total").Int(), currency)
+	// 异步支付方式（如银行转账）在 completed 事件中为 unpaid，等待 async_payment_succeeded
+	ack.Paid = session.Get("payment_status").String() == "paid"
+	return ack, nil
+}
+
+// verifyStripeSignature 校验 Stripe-Signature: t=<unix>,v1=<hex(HMAC-SHA256(secret, t + "." + body))>
+func verifyStripeSignature(header string, body []byte, secret string, now time.Time) bool {
+	if header == "" || secret == "" {
+		return false
+	}
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "t":
+			timestamp = v
+		case "v1":
+			signatures = append(signatures, v)
+		}
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return false
+	}
+	if diff := now.Sub(time.Unix(ts, 0)); diff > stripeWebhookTolerance || diff < -stripeWebhookTolerance {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(timestamp))
+	_, _ = mac.Write([]byte("."))
+	_, _ = mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return true
+		}
+	}
+	return false
+}
+
+func stripeMinorAmount(amount float64, currency string) int64 {
+	if _, ok := stripeZeroDecimalCurrencies[strings.ToLower(currency)]; ok {
+		return int64(math.Round(amount))
+	}
+	return int64(math.Round(amount * 100))
+}
+
+func stripeMajorAmount(minor int64, currency string) float64 {
+	if _, ok := stripeZeroDecimalCurrencies[strings.ToLower(currency)]; ok {
+		return float64(minor)
+	}
+	return float64(minor) / 100
+}
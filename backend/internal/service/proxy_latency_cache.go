@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 )
 
 type ProxyLatencyInfo struct {
@@ -27,3 +29,33 @@ type ProxyLatencyCache interface {
 	GetProxyLatencies(ctx context.Context, proxyIDs []int64) (map[int64]*ProxyLatencyInfo, error)
 	SetProxyLatency(ctx context.Context, proxyID int64, info *ProxyLatencyInfo) error
 }
+
+// saveProxyLatencyToCache 写入代理延迟缓存；info 不含质量检测字段时保留已有的质量检测结果
+func saveProxyLatencyToCache(ctx context.Context, cache ProxyLatencyCache, proxyID int64, info *ProxyLatencyInfo) {
+	if cache == nil || info == nil {
+		return
+	}
+
+	merged := *info
+	if latencies, err := cache.GetProxyLatencies(ctx, []int64{proxyID}); err == nil {
+		if existing := latencies[proxyID]; existing != nil {
+			if merged.QualityCheckedAt == nil &&
+				merged.QualityScore == nil &&
+				merged.QualityGrade == "" &&
+				merged.QualityStatus == "" &&
+				merged.QualitySummary == "" &&
+				merged.QualityCFRay == "" {
+				merged.QualityStatus = existing.QualityStatus
+				merged.QualityScore = existing.QualityScore
+				merged.QualityGrade = existing.QualityGrade
+				merged.QualitySummary = existing.QualitySummary
+				merged.QualityCheckedAt = existing.QualityCheckedAt
+				merged.QualityCFRay = existing.QualityCFRay
+			}
+		}
+	}
+
+	if err := cache.SetProxyLatency(ctx, proxyID, &merged); err != nil {
+		logger.LegacyPrintf("service.admin", "Warning: store proxy latency cache failed: %v", err)
+	}
+}
@@ -0,0 +1,718 @@
+package service
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/proxyurl"
+	"go.uber.org/zap"
+)
+
+// 代理池选择策略
+const (
+	// ProxyPoolStrategySticky 按账号固定出口（rendezvous hash），代理失效时仅迁移该代理上的账号
+	ProxyPoolStrategySticky = "sticky"
+	// ProxyPoolStrategyRoundRobin 按请求轮询
+	ProxyPoolStrategyRoundRobin = "round_robin"
+	// ProxyPoolStrategyLowestLatency 按最近一次探测延迟（proxy latency cache）升序
+	ProxyPoolStrategyLowestLatency = "lowest_latency"
+)
+
+const (
+	// proxyPoolRefreshInterval 代理池快照刷新周期（多实例部署时其他实例的配置变更在此周期内生效）
+	proxyPoolRefreshInterval = 30 * time.Second
+	proxyPoolLoadTimeout     = 3 * time.Second
+	// proxyPoolProbeConcurrency 健康探测并发上限
+	proxyPoolProbeConcurrency = 8
+	proxyPoolProbeTimeout     = 15 * time.Second
+	proxyPoolMaxMembers       = 200
+)
+
+var (
+	ErrProxyPoolNotFound = infraerrors.NotFound("PROXY_POOL_NOT_FOUND", "proxy pool not found")
+	ErrProxyPoolInUse    = infraerrors.Conflict("PROXY_POOL_IN_USE", "proxy pool is in use by accounts")
+)
+
+// ProxyPool 代理池：一组代理及其选择策略。账号通过 extra.proxy_pool_id 绑定（优先于 proxy_id）。
+type ProxyPool struct {
+	ID          int64
+	Name        string
+	Description string
+	Strategy    string // sticky / round_robin / lowest_latency
+	ProxyIDs    []int64
+	Status      string // active / disabled
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// ProxyPoolInput 创建/更新代理池参数（更新为整体替换）
+type ProxyPoolInput struct {
+	Name        string
+	Description string
+	Strategy    string
+	ProxyIDs    []int64
+	Status      string
+}
+
+type ProxyPoolRepository interface {
+	List(ctx context.Context) ([]ProxyPool, error)
+	GetByID(ctx context.Context, id int64) (*ProxyPool, error)
+	Create(ctx context.Context, pool *ProxyPool) error
+	Update(ctx context.Context, pool *ProxyPool) error
+	Delete(ctx context.Context, id int64) error
+	CountAccountsByPoolID(ctx context.Context, poolID int64) (int64, error)
+}
+
+// ProxyPoolHealth 代理池健康状态（运维监控展示）
+type ProxyPoolHealth struct {
+	PoolID         int64                   `json:"pool_id"`
+	Name           string                  `json:"name"`
+	Strategy       string                  `json:"strategy"`
+	Status         string                  `json:"status"`
+	TotalProxies   int                     `json:"total_proxies"`
+	HealthyProxies int                     `json:"healthy_proxies"`
+	Proxies        []ProxyPoolMemberHealth `json:"proxies"`
+}
+
+// ProxyPoolMemberHealth 池内单个代理的健康状态
+type ProxyPoolMemberHealth struct {
+	ProxyID             int64      `json:"proxy_id"`
+	Name                string     `json:"name"`
+	Healthy             bool       `json:"healthy"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LatencyMs           *int64     `json:"latency_ms,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastCheckedAt       *time.Time `json:"last_checked_at,omitempty"`
+	DownSince           *time.Time `json:"down_since,omitempty"`
+}
+
+type proxyPoolMember struct {
+	proxyID int64
+	name    string
+	url     string
+}
+
+type proxyPoolRuntime struct {
+	pool    ProxyPool
+	members []proxyPoolMember // 仅包含存在且 active 的代理，保持配置顺序
+	rr      atomic.Uint64
+}
+
+type proxyPoolSnapshot struct {
+	pools map[int64]*proxyPoolRuntime
+}
+
+// proxyHealthState 代理健康状态（实例内存）：请求失败被动计数 + 后台主动探测
+type proxyHealthState struct {
+	down                bool
+	consecutiveFailures int
+	lastError           string
+	lastCheckedAt       time.Time
+	downSince           time.Time
+	latencyMs           *int64
+}
+
+// ProxyPoolService 代理池管理、选择与健康探测
+type ProxyPoolService struct {
+	repo         ProxyPoolRepository
+	proxyRepo    ProxyRepository
+	prober       ProxyExitInfoProber
+	latencyCache ProxyLatencyCache
+	cfg          *config.Config
+
+	snapshot atomic.Pointer[proxyPoolSnapshot]
+	reloadMu sync.Mutex
+
+	healthMu sync.Mutex
+	health   map[int64]*proxyHealthState
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewProxyPoolService 创建代理池服务实例
+func NewProxyPoolService(
+	repo ProxyPoolRepository,
+	proxyRepo ProxyRepository,
+	prober ProxyExitInfoProber,
+	latencyCache ProxyLatencyCache,
+	cfg *config.Config,
+) *ProxyPoolService {
+	return &ProxyPoolService{
+		repo:         repo,
+		proxyRepo:    proxyRepo,
+		prober:       prober,
+		latencyCache: latencyCache,
+		cfg:          cfg,
+		health:       make(map[int64]*proxyHealthState),
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Start 注册代理池解析器并启动快照刷新与健康探测
+func (s *ProxyPoolService) Start() {
+	proxyurl.SetPoolResolver(s.resolvePrimaryProxyURL)
+	s.reload(context.Background())
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(proxyPoolRefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.reload(context.Background())
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+
+	interval := s.healthCheckInterval()
+	if interval <= 0 || s.prober == nil {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.probeAll(context.Background())
+			case <-s.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+func (s *ProxyPoolService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() { close(s.stopCh) })
+	s.wg.Wait()
+}
+
+func (s *ProxyPoolService) healthCheckInterval() time.Duration {
+	if s.cfg == nil {
+		return time.Minute
+	}
+	return time.Duration(s.cfg.ProxyPool.HealthCheckIntervalSeconds) * time.Second
+}
+
+func (s *ProxyPoolService) failureThreshold() int {
+	if s.cfg == nil || s.cfg.ProxyPool.FailureThreshold <= 0 {
+		return 3
+	}
+	return s.cfg.ProxyPool.FailureThreshold
+}
+
+// MaxFailoverAttempts 单次上游请求最多尝试的池内代理数
+func (s *ProxyPoolService) MaxFailoverAttempts() int {
+	if s.cfg == nil || s.cfg.ProxyPool.MaxFailoverAttempts <= 0 {
+		return 3
+	}
+	return s.cfg.ProxyPool.MaxFailoverAttempts
+}
+
+// ---------------------------------------------------------------------------
+// 选择与故障转移（热路径，仅读内存快照）
+// ---------------------------------------------------------------------------
+
+// ProxyPoolCandidates 返回账号本次请求应依次尝试的池内代理：
+// 健康代理按策略排序；池内没有健康代理时退而返回全部代理（仍按策略排序），
+// 让请求有机会命中已恢复但尚未探测到的代理。池不存在、已停用或为空时返回错误（不回退直连）。
+func (s *ProxyPoolService) ProxyPoolCandidates(poolID, accountID int64) ([]ProxyPoolCandidate, error) {
+	rt, err := s.runtimePool(poolID)
+	if err != nil {
+		return nil, err
+	}
+
+	healthy := make([]proxyPoolMember, 0, len(rt.members))
+	s.healthMu.Lock()
+	for _, m := range rt.members {
+		if st := s.health[m.proxyID]; st == nil || !st.down {
+			healthy = append(healthy, m)
+		}
+	}
+	s.healthMu.Unlock()
+	if len(healthy) == 0 {
+		healthy = append(healthy, rt.members...)
+	}
+
+	ordered := s.orderMembers(rt, healthy, accountID)
+	out := make([]ProxyPoolCandidate, 0, len(ordered))
+	for _, m := range ordered {
+		out = append(out, ProxyPoolCandidate{ProxyID: m.proxyID, URL: m.url})
+	}
+	return out, nil
+}
+
+// resolvePrimaryProxyURL 供 proxyurl.Resolve 使用：返回账号在池内的首选代理
+func (s *ProxyPoolService) resolvePrimaryProxyURL(poolID, accountID int64) (string, error) {
+	candidates, err := s.ProxyPoolCandidates(poolID, accountID)
+	if err != nil {
+		return "", err
+	}
+	return candidates[0].URL, nil
+}
+
+func (s *ProxyPoolService) runtimePool(poolID int64) (*proxyPoolRuntime, error) {
+	snap := s.snapshot.Load()
+	if snap == nil {
+		// 尚未加载（例如服务启动早期）时同步加载一次
+		ctx, cancel := context.WithTimeout(context.Background(), proxyPoolLoadTimeout)
+		s.reload(ctx)
+		cancel()
+		snap = s.snapshot.Load()
+	}
+	if snap == nil {
+		return nil, fmt.Errorf("proxy pool %d: pools not loaded", poolID)
+	}
+	rt := snap.pools[poolID]
+	if rt == nil {
+		return nil, fmt.Errorf("proxy pool %d not found", poolID)
+	}
+	if rt.pool.Status != StatusActive {
+		return nil, fmt.Errorf("proxy pool %d is disabled", poolID)
+	}
+	if len(rt.members) == 0 {
+		return nil, fmt.Errorf("proxy pool %d has no active proxy", poolID)
+	}
+	return rt, nil
+}
+
+func (s *ProxyPoolService) orderMembers(rt *proxyPoolRuntime, members []proxyPoolMember, accountID int64) []proxyPoolMember {
+	ordered := make([]proxyPoolMember, len(members))
+	copy(ordered, members)
+
+	switch rt.pool.Strategy {
+	case ProxyPoolStrategyRoundRobin:
+		start := int((rt.rr.Add(1) - 1) % uint64(len(ordered)))
+		ordered = append(ordered[start:], ordered[:start]...)
+	case ProxyPoolStrategyLowestLatency:
+		latencies := make(map[int64]*int64, len(ordered))
+		s.healthMu.Lock()
+		for _, m := range ordered {
+			if st := s.health[m.proxyID]; st != nil {
+				latencies[m.proxyID] = st.latencyMs
+			}
+		}
+		s.healthMu.Unlock()
+		sort.SliceStable(ordered, func(i, j int) bool {
+			li, lj := latencies[ordered[i].proxyID], latencies[ordered[j].proxyID]
+			switch {
+			case li == nil:
+				return false
+			case lj == nil:
+				return true
+			default:
+				return *li < *lj
+			}
+		})
+	default:
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return proxyPoolStickyScore(accountID, ordered[i].proxyID) > proxyPoolStickyScore(accountID, ordered[j].proxyID)
+		})
+	}
+	return ordered
+}
+
+// proxyPoolStickyScore rendezvous hash 权重：同一账号对各代理的排序稳定，
+// 某个代理下线时只有原本落在它上面的账号会迁移。
+func proxyPoolStickyScore(accountID, proxyID int64) uint64 {
+	var buf [16]byte
+	binary.LittleEndian.PutUint64(buf[:8], uint64(accountID))
+	binary.LittleEndian.PutUint64(buf[8:], uint64(proxyID))
+	h := fnv.New64a()
+	_, _ = h.Write(buf[:])
+	return h.Sum64()
+}
+
+// ReportProxyResult 上报一次经由池内代理的连接结果（err 为 nil 表示成功）。
+// 连续失败达到阈值时标记代理不可用；任意一次成功立即恢复。
+func (s *ProxyPoolService) ReportProxyResult(proxyID int64, err error) {
+	if proxyID <= 0 {
+		return
+	}
+	now := time.Now()
+	threshold := s.failureThreshold()
+
+	s.healthMu.Lock()
+	st := s.health[proxyID]
+	if st == nil {
+		st = &proxyHealthState{}
+		s.health[proxyID] = st
+	}
+	st.lastCheckedAt = now
+	if err == nil {
+		recovered := st.down
+		st.down = false
+		st.consecutiveFailures = 0
+		st.lastError = ""
+		st.downSince = time.Time{}
+		s.healthMu.Unlock()
+		if recovered {
+			logger.L().Info("proxy pool: proxy recovered", zap.Int64("proxy_id", proxyID))
+		}
+		return
+	}
+	st.consecutiveFailures++
+	st.lastError = err.Error()
+	markedDown := false
+	if !st.down && st.consecutiveFailures >= threshold {
+		st.down = true
+		st.downSince = now
+		markedDown = true
+	}
+	failures := st.consecutiveFailures
+	s.healthMu.Unlock()
+
+	if markedDown {
+		logger.L().Warn("proxy pool: proxy marked down",
+			zap.Int64("proxy_id", proxyID),
+			zap.Int("consecutive_failures", failures),
+			zap.Error(err),
+		)
+	}
+}
+
+func (s *ProxyPoolService) setProxyLatency(proxyID int64, latencyMs *int64) {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	st := s.health[proxyID]
+	if st == nil {
+		st = &proxyHealthState{}
+		s.health[proxyID] = st
+	}
+	st.latencyMs = latencyMs
+}
+
+// ---------------------------------------------------------------------------
+// 快照加载与健康探测
+// ---------------------------------------------------------------------------
+
+func (s *ProxyPoolService) reload(ctx context.Context) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	pools, err := s.repo.List(ctx)
+	if err != nil {
+		logger.L().Warn("proxy pool: load pools failed", zap.Error(err))
+		return
+	}
+
+	memberIDs := make([]int64, 0)
+	seen := make(map[int64]struct{})
+	for _, p := range pools {
+		for _, id := range p.ProxyIDs {
+			if _, ok := seen[id]; !ok {
+				seen[id] = struct{}{}
+				memberIDs = append(memberIDs, id)
+			}
+		}
+	}
+
+	proxies := make(map[int64]Proxy, len(memberIDs))
+	if len(memberIDs) > 0 {
+		list, err := s.proxyRepo.ListByIDs(ctx, memberIDs)
+		if err != nil {
+			logger.L().Warn("proxy pool: load member proxies failed", zap.Error(err))
+			return
+		}
+		for _, p := range list {
+			proxies[p.ID] = p
+		}
+		s.loadCachedLatencies(ctx, memberIDs)
+	}
+
+	snap := &proxyPoolSnapshot{pools: make(map[int64]*proxyPoolRuntime, len(pools))}
+	prev := s.snapshot.Load()
+	for _, p := range pools {
+		rt := &proxyPoolRuntime{pool: p}
+		if prev != nil {
+			if old := prev.pools[p.ID]; old != nil {
+				rt.rr.Store(old.rr.Load())
+			}
+		}
+		for _, id := range p.ProxyIDs {
+			proxy, ok := proxies[id]
+			if !ok || !proxy.IsActive() {
+				continue
+			}
+			rt.members = append(rt.members, proxyPoolMember{proxyID: proxy.ID, name: proxy.Name, url: proxy.URL()})
+		}
+		snap.pools[p.ID] = rt
+	}
+	s.snapshot.Store(snap)
+}
+
+// loadCachedLatencies 以 proxy latency cache（管理端测试 / 其他实例探测结果）初始化未探测过的代理延迟
+func (s *ProxyPoolService) loadCachedLatencies(ctx context.Context, proxyIDs []int64) {
+	if s.latencyCache == nil {
+		return
+	}
+	latencies, err := s.latencyCache.GetProxyLatencies(ctx, proxyIDs)
+	if err != nil {
+		return
+	}
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	for id, info := range latencies {
+		if info == nil || !info.Success || info.LatencyMs == nil {
+			continue
+		}
+		st := s.health[id]
+		if st == nil {
+			st = &proxyHealthState{}
+			s.health[id] = st
+		}
+		if st.latencyMs == nil {
+			st.latencyMs = info.LatencyMs
+		}
+	}
+}
+
+// probeAll 探测所有被代理池引用的代理，更新健康状态与延迟缓存
+func (s *ProxyPoolService) probeAll(ctx context.Context) {
+	snap := s.snapshot.Load()
+	if snap == nil || s.prober == nil {
+		return
+	}
+	members := make(map[int64]proxyPoolMember)
+	for _, rt := range snap.pools {
+		if rt.pool.Status != StatusActive {
+			continue
+		}
+		for _, m := range rt.members {
+			members[m.proxyID] = m
+		}
+	}
+	if len(members) == 0 {
+		return
+	}
+
+	sem := make(chan struct{}, proxyPoolProbeConcurrency)
+	var wg sync.WaitGroup
+	for _, m := range members {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(m proxyPoolMember) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.probeMember(ctx, m)
+		}(m)
+	}
+	wg.Wait()
+}
+
+func (s *ProxyPoolService) probeMember(ctx context.Context, m proxyPoolMember) {
+	probeCtx, cancel := context.WithTimeout(ctx, proxyPoolProbeTimeout)
+	defer cancel()
+
+	exitInfo, latencyMs, err := s.prober.ProbeProxy(probeCtx, m.url)
+	if err != nil {
+		s.ReportProxyResult(m.proxyID, err)
+		saveProxyLatencyToCache(ctx, s.latencyCache, m.proxyID, &ProxyLatencyInfo{
+			Success:   false,
+			Message:   err.Error(),
+			UpdatedAt: time.Now(),
+		})
+		return
+	}
+
+	latency := latencyMs
+	s.ReportProxyResult(m.proxyID, nil)
+	s.setProxyLatency(m.proxyID, &latency)
+	info := &ProxyLatencyInfo{
+		Success:   true,
+		LatencyMs: &latency,
+		Message:   "Proxy is accessible",
+		UpdatedAt: time.Now(),
+	}
+	if exitInfo != nil {
+		info.IPAddress = exitInfo.IP
+		info.Country = exitInfo.Country
+		info.CountryCode = exitInfo.CountryCode
+		info.Region = exitInfo.Region
+		info.City = exitInfo.City
+	}
+	saveProxyLatencyToCache(ctx, s.latencyCache, m.proxyID, info)
+}
+
+// Health 返回各代理池及其成员的健康状态
+func (s *ProxyPoolService) Health(ctx context.Context) ([]ProxyPoolHealth, error) {
+	if s.snapshot.Load() == nil {
+		s.reload(ctx)
+	}
+	snap := s.snapshot.Load()
+	if snap == nil {
+		return nil, fmt.Errorf("proxy pools not loaded")
+	}
+
+	ids := make([]int64, 0, len(snap.pools))
+	for id := range snap.pools {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	out := make([]ProxyPoolHealth, 0, len(ids))
+	for _, id := range ids {
+		rt := snap.pools[id]
+		item := ProxyPoolHealth{
+			PoolID:       rt.pool.ID,
+			Name:         rt.pool.Name,
+			Strategy:     rt.pool.Strategy,
+			Status:       rt.pool.Status,
+			TotalProxies: len(rt.members),
+			Proxies:      make([]ProxyPoolMemberHealth, 0, len(rt.members)),
+		}
+		for _, m := range rt.members {
+			member := ProxyPoolMemberHealth{ProxyID: m.proxyID, Name: m.name, Healthy: true}
+			if st := s.health[m.proxyID]; st != nil {
+				member.Healthy = !st.down
+				member.ConsecutiveFailures = st.consecutiveFailures
+				member.LatencyMs = st.latencyMs
+				member.LastError = st.lastError
+				if !st.lastCheckedAt.IsZero() {
+					t := st.lastCheckedAt
+					member.LastCheckedAt = &t
+				}
+				if st.down {
+					t := st.downSince
+					member.DownSince = &t
+				}
+			}
+			if member.Healthy {
+				item.HealthyProxies++
+			}
+			item.Proxies = append(item.Proxies, member)
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// ---------------------------------------------------------------------------
+// 管理端 CRUD
+// ---------------------------------------------------------------------------
+
+func (s *ProxyPoolService) List(ctx context.Context) ([]ProxyPool, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *ProxyPoolService) GetByID(ctx context.Context, id int64) (*ProxyPool, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *ProxyPoolService) Create(ctx context.Context, input *ProxyPoolInput) (*ProxyPool, error) {
+	pool, err := s.normalizeInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, pool); err != nil {
+		return nil, err
+	}
+	s.reload(ctx)
+	return pool, nil
+}
+
+func (s *ProxyPoolService) Update(ctx context.Context, id int64, input *ProxyPoolInput) (*ProxyPool, error) {
+	pool, err := s.normalizeInput(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	pool.ID = id
+	if err := s.repo.Update(ctx, pool); err != nil {
+		return nil, err
+	}
+	s.reload(ctx)
+	return pool, nil
+}
+
+func (s *ProxyPoolService) Delete(ctx context.Context, id int64) error {
+	count, err := s.repo.CountAccountsByPoolID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrProxyPoolInUse
+	}
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.reload(ctx)
+	return nil
+}
+
+func (s *ProxyPoolService) normalizeInput(ctx context.Context, input *ProxyPoolInput) (*ProxyPool, error) {
+	if input == nil {
+		return nil, infraerrors.BadRequest("PROXY_POOL_INVALID", "proxy pool is required")
+	}
+	pool := &ProxyPool{
+		Name:        strings.TrimSpace(input.Name),
+		Description: strings.TrimSpace(input.Description),
+		Strategy:    strings.TrimSpace(input.Strategy),
+		Status:      strings.TrimSpace(input.Status),
+	}
+	if pool.Name == "" {
+		return nil, infraerrors.BadRequest("PROXY_POOL_NAME_REQUIRED", "name is required")
+	}
+	switch pool.Strategy {
+	case "":
+		pool.Strategy = ProxyPoolStrategySticky
+	case ProxyPoolStrategySticky, ProxyPoolStrategyRoundRobin, ProxyPoolStrategyLowestLatency:
+	default:
+		return nil, infraerrors.BadRequest("PROXY_POOL_STRATEGY_INVALID", "strategy must be one of sticky, round_robin, lowest_latency")
+	}
+	switch pool.Status {
+	case "":
+		pool.Status = StatusActive
+	case StatusActive, StatusDisabled:
+	default:
+		return nil, infraerrors.BadRequest("PROXY_POOL_STATUS_INVALID", "status must be active or disabled")
+	}
+
+	seen := make(map[int64]struct{}, len(input.ProxyIDs))
+	for _, id := range input.ProxyIDs {
+		if id <= 0 {
+			return nil, infraerrors.BadRequest("PROXY_POOL_PROXY_INVALID", "proxy_ids must be positive")
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		pool.ProxyIDs = append(pool.ProxyIDs, id)
+	}
+	if len(pool.ProxyIDs) == 0 {
+		return nil, infraerrors.BadRequest("PROXY_POOL_PROXIES_REQUIRED", "proxy_ids must not be empty")
+	}
+	if len(pool.ProxyIDs) > proxyPoolMaxMembers {
+		return nil, infraerrors.BadRequest("PROXY_POOL_TOO_MANY_PROXIES", fmt.Sprintf("a proxy pool can contain at most %d proxies", proxyPoolMaxMembers))
+	}
+	existing, err := s.proxyRepo.ListByIDs(ctx, pool.ProxyIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) != len(pool.ProxyIDs) {
+		return nil, infraerrors.BadRequest("PROXY_POOL_PROXY_NOT_FOUND", "some proxies in proxy_ids do not exist")
+	}
+	return pool, nil
+}
@@ -0,0 +1,223 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type proxyPoolRepoStub struct {
+	pools        []ProxyPool
+	accountCount int64
+}
+
+func (s *proxyPoolRepoStub) List(ctx context.Context) ([]ProxyPool, error) {
+	return s.pools, nil
+}
+
+func (s *proxyPoolRepoStub) GetByID(ctx context.Context, id int64) (*ProxyPool, error) {
+	for i := range s.pools {
+		if s.pools[i].ID == id {
+			p := s.pools[i]
+			return &p, nil
+		}
+	}
+	return nil, ErrProxyPoolNotFound
+}
+
+func (s *proxyPoolRepoStub) Create(ctx context.Context, pool *ProxyPool) error {
+	pool.ID = int64(len(s.pools) + 1)
+	s.pools = append(s.pools, *pool)
+	return nil
+}
+
+func (s *proxyPoolRepoStub) Update(ctx context.Context, pool *ProxyPool) error {
+	for i := range s.pools {
+		if s.pools[i].ID == pool.ID {
+			s.pools[i] = *pool
+			return nil
+		}
+	}
+	return ErrProxyPoolNotFound
+}
+
+func (s *proxyPoolRepoStub) Delete(ctx context.Context, id int64) error {
+	for i := range s.pools {
+		if s.pools[i].ID == id {
+			s.pools = append(s.pools[:i], s.pools[i+1:]...)
+			return nil
+		}
+	}
+	return ErrProxyPoolNotFound
+}
+
+func (s *proxyPoolRepoStub) CountAccountsByPoolID(ctx context.Context, poolID int64) (int64, error) {
+	return s.accountCount, nil
+}
+
+type proxyPoolProxyRepoStub struct {
+	ProxyRepository
+	proxies map[int64]Proxy
+}
+
+func (s *proxyPoolProxyRepoStub) ListByIDs(ctx context.Context, ids []int64) ([]Proxy, error) {
+	out := make([]Proxy, 0, len(ids))
+	for _, id := range ids {
+		if p, ok := s.proxies[id]; ok {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func newProxyPoolTestService(strategy string, proxyIDs ...int64) (*ProxyPoolService, *proxyPoolRepoStub) {
+	proxies := make(map[int64]Proxy, len(proxyIDs))
+	for _, id := range proxyIDs {
+		proxies[id] = Proxy{ID: id, Protocol: "http", Host: "10.0.0.1", Port: 8000 + int(id), Status: StatusActive}
+	}
+	repo := &proxyPoolRepoStub{pools: []ProxyPool{{ID: 1, Name: "pool", Strategy: strategy, ProxyIDs: proxyIDs, Status: StatusActive}}}
+	cfg := &config.Config{ProxyPool: config.ProxyPoolConfig{FailureThreshold: 2, MaxFailoverAttempts: 3}}
+	return NewProxyPoolService(repo, &proxyPoolProxyRepoStub{proxies: proxies}, nil, nil, cfg), repo
+}
+
+func candidateIDs(candidates []ProxyPoolCandidate) []int64 {
+	ids := make([]int64, 0, len(candidates))
+	for _, c := range candidates {
+		ids = append(ids, c.ProxyID)
+	}
+	return ids
+}
+
+func TestProxyPoolCandidates_Sticky同账号稳定(t *testing.T) {
+	svc, _ := newProxyPoolTestService(ProxyPoolStrategySticky, 1, 2, 3, 4)
+
+	first, err := svc.ProxyPoolCandidates(1, 42)
+	require.NoError(t, err)
+	require.Len(t, first, 4)
+	for i := 0; i < 5; i++ {
+		again, err := svc.ProxyPoolCandidates(1, 42)
+		require.NoError(t, err)
+		require.Equal(t, candidateIDs(first), candidateIDs(again))
+	}
+
+	// 首选代理下线后，账号迁移到原排序中的下一个代理
+	for i := 0; i < 2; i++ {
+		svc.ReportProxyResult(first[0].ProxyID, errors.New("dial failed"))
+	}
+	after, err := svc.ProxyPoolCandidates(1, 42)
+	require.NoError(t, err)
+	require.Equal(t, candidateIDs(first)[1:], candidateIDs(after))
+}
+
+func TestProxyPoolCandidates_RoundRobin轮转(t *testing.T) {
+	svc, _ := newProxyPoolTestService(ProxyPoolStrategyRoundRobin, 1, 2, 3)
+
+	var primaries []int64
+	for i := 0; i < 4; i++ {
+		c, err := svc.ProxyPoolCandidates(1, 42)
+		require.NoError(t, err)
+		primaries = append(primaries, c[0].ProxyID)
+	}
+	require.Equal(t, []int64{1, 2, 3, 1}, primaries)
+}
+
+func TestProxyPoolCandidates_LowestLatency按延迟排序(t *testing.T) {
+	svc, _ := newProxyPoolTestService(ProxyPoolStrategyLowestLatency, 1, 2, 3)
+	fast, slow := int64(20), int64(300)
+	svc.setProxyLatency(3, &fast)
+	svc.setProxyLatency(1, &slow)
+
+	c, err := svc.ProxyPoolCandidates(1, 42)
+	require.NoError(t, err)
+	require.Equal(t, []int64{3, 1, 2}, candidateIDs(c))
+}
+
+func TestProxyPoolReportProxyResult_阈值下线与恢复(t *testing.T) {
+	svc, _ := newProxyPoolTestService(ProxyPoolStrategyRoundRobin, 1, 2)
+
+	svc.ReportProxyResult(1, errors.New("timeout"))
+	c, err := svc.ProxyPoolCandidates(1, 0)
+	require.NoError(t, err)
+	require.Len(t, c, 2, "未达阈值前不下线")
+
+	svc.ReportProxyResult(1, errors.New("timeout"))
+	c, err = svc.ProxyPoolCandidates(1, 0)
+	require.NoError(t, err)
+	require.Equal(t, []int64{2}, candidateIDs(c))
+
+	svc.ReportProxyResult(1, nil)
+	c, err = svc.ProxyPoolCandidates(1, 0)
+	require.NoError(t, err)
+	require.Len(t, c, 2)
+}
+
+func TestProxyPoolCandidates_全部下线时仍返回全部代理(t *testing.T) {
+	svc, _ := newProxyPoolTestService(ProxyPoolStrategySticky, 1, 2)
+	for _, id := range []int64{1, 2} {
+		svc.ReportProxyResult(id, errors.New("down"))
+		svc.ReportProxyResult(id, errors.New("down"))
+	}
+
+	c, err := svc.ProxyPoolCandidates(1, 7)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []int64{1, 2}, candidateIDs(c))
+}
+
+func TestProxyPoolCandidates_停用或不存在的池报错(t *testing.T) {
+	svc, repo := newProxyPoolTestService(ProxyPoolStrategySticky, 1)
+	repo.pools[0].Status = StatusDisabled
+
+	_, err := svc.ProxyPoolCandidates(1, 1)
+	require.ErrorContains(t, err, "disabled")
+	_, err = svc.ProxyPoolCandidates(99, 1)
+	require.ErrorContains(t, err, "not found")
+}
+
+func TestProxyPoolNormalizeInput(t *testing.T) {
+	svc, _ := newProxyPoolTestService(ProxyPoolStrategySticky, 1, 2)
+	ctx := context.Background()
+
+	pool, err := svc.normalizeInput(ctx, &ProxyPoolInput{Name: " p ", ProxyIDs: []int64{2, 1, 2}})
+	require.NoError(t, err)
+	require.Equal(t, "p", pool.Name)
+	require.Equal(t, ProxyPoolStrategySticky, pool.Strategy)
+	require.Equal(t, StatusActive, pool.Status)
+	require.Equal(t, []int64{2, 1}, pool.ProxyIDs)
+
+	_, err = svc.normalizeInput(ctx, &ProxyPoolInput{Name: "p", Strategy: "random", ProxyIDs: []int64{1}})
+	require.Error(t, err)
+	_, err = svc.normalizeInput(ctx, &ProxyPoolInput{Name: "p"})
+	require.Error(t, err)
+	_, err = svc.normalizeInput(ctx, &ProxyPoolInput{Name: "p", ProxyIDs: []int64{1, 3}})
+	require.Error(t, err, "不存在的代理应被拒绝")
+}
+
+func TestProxyPoolDelete_仍有账号绑定时拒绝(t *testing.T) {
+	svc, repo := newProxyPoolTestService(ProxyPoolStrategySticky, 1)
+	repo.accountCount = 1
+
+	require.ErrorIs(t, svc.Delete(context.Background(), 1), ErrProxyPoolInUse)
+	require.Len(t, repo.pools, 1)
+}
+
+func TestAccountProxyURL_代理池优先(t *testing.T) {
+	proxyID := int64(5)
+	account := &Account{
+		ID:      9,
+		ProxyID: &proxyID,
+		Proxy:   &Proxy{ID: 5, Protocol: "http", Host: "1.2.3.4", Port: 80},
+		Extra:   map[string]any{"proxy_pool_id": float64(3)},
+	}
+	require.Equal(t, "proxypool://3?account_id=9", account.ProxyURL())
+
+	account.Extra = nil
+	require.Equal(t, "http://1.2.3.4:80", account.ProxyURL())
+
+	account.ProxyID = nil
+	require.Equal(t, "", account.ProxyURL())
+}
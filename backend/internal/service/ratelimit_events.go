@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"time"
+)
+
+// AccountStateEvent 账号调度状态变更事件（限流、临时不可调度、错误禁用）
+type AccountStateEvent struct {
+	Type        string // NotificationEventAccount*
+	AccountID   int64
+	AccountName string
+	Platform    string
+	Until       *time.Time // 限流/临时不可调度的结束时间
+	Reason      string
+}
+
+// AccountEventNotifier 接收 RateLimitService 产生的账号状态变更事件。
+// 实现必须是非阻塞的：调用发生在网关请求的错误处理路径上。
+type AccountEventNotifier interface {
+	NotifyAccountEvent(ctx context.Context, event AccountStateEvent)
+}
+
+// SetAccountEventNotifier 设置账号状态事件通知器（可选依赖）
+func (s *RateLimitService) SetAccountEventNotifier(notifier AccountEventNotifier) {
+	s.accountEventNotifier = notifier
+}
+
+func (s *RateLimitService) emitAccountEvent(ctx context.Context, eventType string, account *Account, until *time.Time, reason string) {
+	if s.accountEventNotifier == nil || account == nil {
+		return
+	}
+	s.accountEventNotifier.NotifyAccountEvent(ctx, AccountStateEvent{
+		Type:        eventType,
+		AccountID:   account.ID,
+		AccountName: account.Name,
+		Platform:    account.Platform,
+		Until:       until,
+		Reason:      reason,
+	})
+}
+
+// setRateLimited 标记账号限流，成功后发出 account_rate_limited 事件
+func (s *RateLimitService) setRateLimited(ctx context.Context, account *Account, resetAt time.Time) error {
+	if err := s.accountRepo.SetRateLimited(ctx, account.ID, resetAt); err != nil {
+		return err
+	}
+	s.emitAccountEvent(ctx, NotificationEventAccountRateLimited, account, &resetAt, "")
+	return nil
+}
+
+// setTempUnschedulable 标记账号临时不可调度，成功后发出 account_temp_unschedulable 事件。
+// reason 写入账号（可能是 JSON 状态），message 是面向人的原因描述。
+func (s *RateLimitService) setTempUnschedulable(ctx context.Context, account *Account, until time.Time, reason, message string) error {
+	if err := s.accountRepo.SetTempUnschedulable(ctx, account.ID, until, reason); err != nil {
+		return err
+	}
+	s.emitAccountEvent(ctx, NotificationEventAccountTempUnschedulable, account, &until, message)
+	return nil
+}
+
+// setAccountError 将账号置为错误状态（停止调度），成功后发出 account_disabled 事件
+func (s *RateLimitService) setAccountError(ctx context.Context, account *Account, errorMsg string) error {
+	if err := s.accountRepo.SetError(ctx, account.ID, errorMsg); err != nil {
+		return err
+	}
+	s.emitAccountEvent(ctx, NotificationEventAccountDisabled, account, nil, errorMsg)
+	return nil
+}
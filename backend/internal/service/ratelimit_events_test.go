@@ -0,0 +1,80 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type accountEventRecorder struct {
+	events []AccountStateEvent
+}
+
+func (r *accountEventRecorder) NotifyAccountEvent(ctx context.Context, event AccountStateEvent) {
+	r.events = append(r.events, event)
+}
+
+type rateLimitEventRepoStub struct {
+	rateLimitAccountRepoStub
+	rateLimitErr error
+}
+
+func (r *rateLimitEventRepoStub) SetRateLimited(ctx context.Context, id int64, resetAt time.Time) error {
+	return r.rateLimitErr
+}
+
+func TestRateLimitService_EmitsAccountEvents(t *testing.T) {
+	t.Run("403 disables account", func(t *testing.T) {
+		recorder := &accountEventRecorder{}
+		svc := NewRateLimitService(&rateLimitEventRepoStub{}, nil, &config.Config{}, nil, nil)
+		svc.SetAccountEventNotifier(recorder)
+		account := &Account{ID: 1, Name: "acc", Platform: PlatformOpenAI, Type: AccountTypeAPIKey}
+
+		require.True(t, svc.HandleUpstreamError(context.Background(), account, http.StatusForbidden, http.Header{}, []byte(`{"error":{"message":"banned"}}`)))
+		require.Len(t, recorder.events, 1)
+		require.Equal(t, NotificationEventAccountDisabled, recorder.events[0].Type)
+		require.Equal(t, int64(1), recorder.events[0].AccountID)
+		require.Contains(t, recorder.events[0].Reason, "banned")
+	})
+
+	t.Run("oauth 401 temp unschedulable", func(t *testing.T) {
+		recorder := &accountEventRecorder{}
+		svc := NewRateLimitService(&rateLimitEventRepoStub{}, nil, &config.Config{}, nil, nil)
+		svc.SetAccountEventNotifier(recorder)
+		account := &Account{ID: 2, Platform: PlatformAnthropic, Type: AccountTypeOAuth}
+
+		svc.HandleUpstreamError(context.Background(), account, http.StatusUnauthorized, http.Header{}, []byte(`{"error":{"message":"expired"}}`))
+		require.Len(t, recorder.events, 1)
+		require.Equal(t, NotificationEventAccountTempUnschedulable, recorder.events[0].Type)
+		require.NotNil(t, recorder.events[0].Until)
+	})
+
+	t.Run("429 rate limited", func(t *testing.T) {
+		recorder := &accountEventRecorder{}
+		svc := NewRateLimitService(&rateLimitEventRepoStub{}, nil, &config.Config{}, nil, nil)
+		svc.SetAccountEventNotifier(recorder)
+		account := &Account{ID: 3, Platform: PlatformGemini, Type: AccountTypeAPIKey}
+
+		svc.HandleUpstreamError(context.Background(), account, http.StatusTooManyRequests, http.Header{}, nil)
+		require.Len(t, recorder.events, 1)
+		require.Equal(t, NotificationEventAccountRateLimited, recorder.events[0].Type)
+		require.NotNil(t, recorder.events[0].Until)
+	})
+
+	t.Run("no event when repo update fails", func(t *testing.T) {
+		recorder := &accountEventRecorder{}
+		svc := NewRateLimitService(&rateLimitEventRepoStub{rateLimitErr: errors.New("db down")}, nil, &config.Config{}, nil, nil)
+		svc.SetAccountEventNotifier(recorder)
+		account := &Account{ID: 4, Platform: PlatformGemini, Type: AccountTypeAPIKey}
+
+		svc.HandleUpstreamError(context.Background(), account, http.StatusTooManyRequests, http.Header{}, nil)
+		require.Empty(t, recorder.events)
+	})
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+var (
+	ErrReferralCodeInvalid  = infraerrors.BadRequest("REFERRAL_CODE_INVALID", "invalid referral code")
+	ErrReferralCodeExists   = infraerrors.Conflict("REFERRAL_CODE_EXISTS", "referral code already exists")
+	ErrReferralCodeNotFound = infraerrors.NotFound("REFERRAL_CODE_NOT_FOUND", "referral code not found")
+	ErrReferralNotFound     = infraerrors.NotFound("REFERRAL_NOT_FOUND", "referral not found")
+)
+
+// Referral 邀请关系（邀请人 → 被邀请人）
+type Referral struct {
+	ID        int64
+	InviterID int64
+	InviteeID int64
+	// SettledUntil 结算水位，该时间之前的被邀请人消费已结算
+	SettledUntil    time.Time
+	TotalCost       float64
+	TotalCommission float64
+	CreatedAt       time.Time
+
+	// 列表查询时关联填充
+	InviterEmail string
+	InviteeEmail string
+}
+
+// ReferralCommission 一次结算入账的返佣明细
+type ReferralCommission struct {
+	ID          int64
+	ReferralID  int64
+	InviterID   int64
+	InviteeID   int64
+	UsageCost   float64
+	Rate        float64
+	Amount      float64
+	PeriodStart time.Time
+	PeriodEnd   time.Time
+	CreatedAt   time.Time
+
+	// 列表查询时关联填充
+	InviteeEmail string
+}
+
+// ReferralStats 邀请人的汇总数据
+type ReferralStats struct {
+	InviteeCount    int64
+	TotalCost       float64
+	TotalCommission float64
+}
+
+// ReferralListFilters 邀请关系/返佣明细筛选条件
+type ReferralListFilters struct {
+	InviterID int64
+	InviteeID int64
+}
+
+// ReferralRepository 邀请码、邀请关系与返佣明细的持久化。
+// 写方法需支持通过 context 中的事务执行。
+type ReferralRepository interface {
+	GetCodeByUserID(ctx context.Context, userID int64) (string, error)
+	// CreateCode 保存用户邀请码；code 与其他用户冲突时返回 ErrReferralCodeExists
+	CreateCode(ctx context.Context, userID int64, code string) error
+	GetUserIDByCode(ctx context.Context, code string) (int64, error)
+
+	// CreateReferral 创建邀请关系，被邀请人已有邀请人时返回 false
+	CreateReferral(ctx context.Context, inviterID, inviteeID int64, settledFrom time.Time) (bool, error)
+	GetByInviteeID(ctx context.Context, inviteeID int64) (*Referral, error)
+	GetStats(ctx context.Context, inviterID int64) (*ReferralStats, error)
+	List(ctx context.Context, params pagination.PaginationParams, filters ReferralListFilters) ([]Referral, *pagination.PaginationResult, error)
+	ListCommissions(ctx context.Context, params pagination.PaginationParams, filters ReferralListFilters) ([]ReferralCommission, *pagination.PaginationResult, error)
+
+	// ListSettlementDue 按 id 升序列出结算水位早于 before 且未达到返佣期限/上限的邀请关系
+	ListSettlementDue(ctx context.Context, before time.Time, afterID int64, commissionDays int, maxCommission float64, limit int) ([]Referral, error)
+	// SumInviteeCost 汇总被邀请人 [from, to) 区间内的 actual_cost
+	SumInviteeCost(ctx context.Context, inviteeID int64, from, to time.Time) (float64, error)
+	// AdvanceSettlement 以水位未变化为条件推进结算水位并累加消费与返佣，返回 false 表示已被其他实例结算
+	AdvanceSettlement(ctx context.Context, referralID int64, prevSettledUntil, settledUntil time.Time, cost, commission float64) (bool, error)
+	CreateCommission(ctx context.Context, commission *ReferralCommission) error
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+const (
+	// referralCodeAlphabet 去掉易混淆字符（0/O、1/I/L）
+	referralCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+	referralCodeLength   = 8
+	// referralCodeMaxAttempts 邀请码冲突时的最大重试次数
+	referralCodeMaxAttempts = 5
+)
+
+// ReferralOverview 用户侧邀请返佣概览
+type ReferralOverview struct {
+	Enabled                 bool
+	Code                    string
+	CommissionRate          float64
+	CommissionDays          int
+	MaxCommissionPerInvitee float64
+	Stats                   ReferralStats
+	// InvitedBy 邀请当前用户的邀请人（邮箱已脱敏），无邀请人时为 nil
+	InvitedBy *Referral
+}
+
+// ReferralService 邀请码、邀请关系绑定与返佣查询
+type ReferralService struct {
+	repo ReferralRepository
+	cfg  config.ReferralConfig
+}
+
+// NewReferralService 创建邀请返佣服务
+func NewReferralService(repo ReferralRepository, cfg *config.Config) *ReferralService {
+	var referralCfg config.ReferralConfig
+	if cfg != nil {
+		referralCfg = cfg.Referral
+	}
+	return &ReferralService{repo: repo, cfg: referralCfg}
+}
+
+// Enabled 是否开启邀请返佣
+func (s *ReferralService) Enabled() bool {
+	return s != nil && s.cfg.Enabled
+}
+
+// GetOrCreateCode 返回用户的邀请码，不存在时生成
+func (s *ReferralService) GetOrCreateCode(ctx context.Context, userID int64) (string, error) {
+	code, err := s.repo.GetCodeByUserID(ctx, userID)
+	if err == nil {
+		return code, nil
+	}
+	if !errors.Is(err, ErrReferralCodeNotFound) {
+		return "", err
+	}
+
+	for attempt := 0; attempt < referralCodeMaxAttempts; attempt++ {
+		candidate, err := generateReferralCode()
+		if err != nil {
+			return "", err
+		}
+		err = s.repo.CreateCode(ctx, userID, candidate)
+		if errors.Is(err, ErrReferralCodeExists) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		// 并发生成时以先写入者为准，重新读取
+		return s.repo.GetCodeByUserID(ctx, userID)
+	}
+	return "", fmt.Errorf("generate referral code: exhausted %d attempts", referralCodeMaxAttempts)
+}
+
+// ResolveCode 根据邀请码查找邀请人，邀请码无效时返回 ErrReferralCodeInvalid
+func (s *ReferralService) ResolveCode(ctx context.Context, code string) (int64, error) {
+	code = normalizeReferralCode(code)
+	if code == "" {
+		return 0, ErrReferralCodeInvalid
+	}
+	inviterID, err := s.repo.GetUserIDByCode(ctx, code)
+	if errors.Is(err, ErrReferralCodeNotFound) {
+		return 0, ErrReferralCodeInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+	return inviterID, nil
+}
+
+// BindInvitee 建立邀请关系，被邀请人在 settledFrom 之后的消费参与返佣。
+// 被邀请人已有邀请人时保持原关系不变。
+func (s *ReferralService) BindInvitee(ctx context.Context, inviterID, inviteeID int64, settledFrom time.Time) error {
+	if inviterID <= 0 || inviteeID <= 0 || inviterID == inviteeID {
+		return ErrReferralCodeInvalid
+	}
+	if _, err := s.repo.CreateReferral(ctx, inviterID, inviteeID, settledFrom); err != nil {
+		return fmt.Errorf("create referral: %w", err)
+	}
+	return nil
+}
+
+// GetOverview 返回用户的邀请码、返佣规则、汇总数据与其邀请人
+func (s *ReferralService) GetOverview(ctx context.Context, userID int64) (*ReferralOverview, error) {
+	overview := &ReferralOverview{
+		Enabled:                 s.cfg.Enabled,
+		CommissionRate:          s.cfg.CommissionRate,
+		CommissionDays:          s.cfg.CommissionDays,
+		MaxCommissionPerInvitee: s.cfg.MaxCommissionPerInvitee,
+	}
+	if s.cfg.Enabled {
+		code, err := s.GetOrCreateCode(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		overview.Code = code
+	}
+
+	stats, err := s.repo.GetStats(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	overview.Stats = *stats
+
+	invitedBy, err := s.repo.GetByInviteeID(ctx, userID)
+	if err != nil && !errors.Is(err, ErrReferralNotFound) {
+		return nil, err
+	}
+	if invitedBy != nil {
+		invitedBy.InviterEmail = MaskEmail(invitedBy.InviterEmail)
+		overview.InvitedBy = invitedBy
+	}
+	return overview, nil
+}
+
+// ListInvitees 返回用户邀请的用户（邮箱已脱敏）
+func (s *ReferralService) ListInvitees(ctx context.Context, userID int64, params pagination.PaginationParams) ([]Referral, *pagination.PaginationResult, error) {
+	referrals, result, err := s.repo.List(ctx, params, ReferralListFilters{InviterID: userID})
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range referrals {
+		referrals[i].InviteeEmail = MaskEmail(referrals[i].InviteeEmail)
+	}
+	return referrals, result, nil
+}
+
+// ListUserCommissions 返回用户的返佣入账明细（被邀请人邮箱已脱敏）
+func (s *ReferralService) ListUserCommissions(ctx context.Context, userID int64, params pagination.PaginationParams) ([]ReferralCommission, *pagination.PaginationResult, error) {
+	commissions, result, err := s.repo.ListCommissions(ctx, params, ReferralListFilters{InviterID: userID})
+	if err != nil {
+		return nil, nil, err
+	}
+	for i := range commissions {
+		commissions[i].InviteeEmail = MaskEmail(commissions[i].InviteeEmail)
+	}
+	return commissions, result, nil
+}
+
+// List 管理端查询邀请关系
+func (s *ReferralService) List(ctx context.Context, params pagination.PaginationParams, filters ReferralListFilters) ([]Referral, *pagination.PaginationResult, error) {
+	return s.repo.List(ctx, params, filters)
+}
+
+// ListCommissions 管理端查询返佣明细
+func (s *ReferralService) ListCommissions(ctx context.Context, params pagination.PaginationParams, filters ReferralListFilters) ([]ReferralCommission, *pagination.PaginationResult, error) {
+	return s.repo.ListCommissions(ctx, params, filters)
+}
+
+func normalizeReferralCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func generateReferralCode() (string, error) {
+	max := big.NewInt(int64(len(referralCodeAlphabet)))
+	buf := make([]byte, referralCodeLength)
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("generate referral code: %w", err)
+		}
+		buf[i] = referralCodeAlphabet[n.Int64()]
+	}
+	return string(buf), nil
+}
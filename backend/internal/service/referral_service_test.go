@@ -0,0 +1,334 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type referralRepoStub struct {
+	codes       map[int64]string
+	referrals   []*Referral
+	commissions []ReferralCommission
+	// usage 被邀请人的使用记录（created_at → actual_cost）
+	usage map[int64]map[time.Time]float64
+	// createCodeConflicts 前 N 次 CreateCode 模拟邀请码冲突
+	createCodeConflicts int
+}
+
+func newReferralRepoStub() *referralRepoStub {
+	return &referralRepoStub{codes: map[int64]string{}, usage: map[int64]map[time.Time]float64{}}
+}
+
+func (r *referralRepoStub) GetCodeByUserID(_ context.Context, userID int64) (string, error) {
+	code, ok := r.codes[userID]
+	if !ok {
+		return "", ErrReferralCodeNotFound
+	}
+	return code, nil
+}
+
+func (r *referralRepoStub) CreateCode(_ context.Context, userID int64, code string) error {
+	if r.createCodeConflicts > 0 {
+		r.createCodeConflicts--
+		return ErrReferralCodeExists
+	}
+	if _, ok := r.codes[userID]; !ok {
+		r.codes[userID] = code
+	}
+	return nil
+}
+
+func (r *referralRepoStub) GetUserIDByCode(_ context.Context, code string) (int64, error) {
+	for userID, c := range r.codes {
+		if c == code {
+			return userID, nil
+		}
+	}
+	return 0, ErrReferralCodeNotFound
+}
+
+func (r *referralRepoStub) CreateReferral(_ context.Context, inviterID, inviteeID int64, settledFrom time.Time) (bool, error) {
+	for _, ref := range r.referrals {
+		if ref.InviteeID == inviteeID {
+			return false, nil
+		}
+	}
+	r.referrals = append(r.referrals, &Referral{
+		ID: int64(len(r.referrals) + 1), InviterID: inviterID, InviteeID: inviteeID,
+		SettledUntil: settledFrom, CreatedAt: settledFrom,
+	})
+	return true, nil
+}
+
+func (r *referralRepoStub) GetByInviteeID(_ context.Context, inviteeID int64) (*Referral, error) {
+	for _, ref := range r.referrals {
+		if ref.InviteeID == inviteeID {
+			cp := *ref
+			return &cp, nil
+		}
+	}
+	return nil, ErrReferralNotFound
+}
+
+func (r *referralRepoStub) GetStats(_ context.Context, inviterID int64) (*ReferralStats, error) {
+	stats := &ReferralStats{}
+	for _, ref := range r.referrals {
+		if ref.InviterID == inviterID {
+			stats.InviteeCount++
+			stats.TotalCost += ref.TotalCost
+			stats.TotalCommission += ref.TotalCommission
+		}
+	}
+	return stats, nil
+}
+
+func (r *referralRepoStub) List(context.Context, pagination.PaginationParams, ReferralListFilters) ([]Referral, *pagination.PaginationResult, error) {
+	out := make([]Referral, 0, len(r.referrals))
+	for _, ref := range r.referrals {
+		out = append(out, *ref)
+	}
+	return out, &pagination.PaginationResult{Total: int64(len(out))}, nil
+}
+
+func (r *referralRepoStub) ListCommissions(context.Context, pagination.PaginationParams, ReferralListFilters) ([]ReferralCommission, *pagination.PaginationResult, error) {
+	return r.commissions, &pagination.PaginationResult{Total: int64(len(r.commissions))}, nil
+}
+
+func (r *referralRepoStub) ListSettlementDue(_ context.Context, before time.Time, afterID int64, commissionDays int, maxCommission float64, limit int) ([]Referral, error) {
+	out := []Referral{}
+	for _, ref := range r.referrals {
+		if ref.ID <= afterID || !ref.SettledUntil.Before(before) {
+			continue
+		}
+		if commissionDays > 0 && !ref.SettledUntil.Before(ref.CreatedAt.AddDate(0, 0, commissionDays)) {
+			continue
+		}
+		if maxCommission > 0 && ref.TotalCommission >= maxCommission {
+			continue
+		}
+		out = append(out, *ref)
+		if len(out) >= limit {
+			break
+		}
+	}
+	return out, nil
+}
+
+func (r *referralRepoStub) SumInviteeCost(_ context.Context, inviteeID int64, from, to time.Time) (float64, error) {
+	var cost float64
+	for at, c := range r.usage[inviteeID] {
+		if !at.Before(from) && at.Before(to) {
+			cost += c
+		}
+	}
+	return cost, nil
+}
+
+func (r *referralRepoStub) AdvanceSettlement(_ context.Context, referralID int64, prev, next time.Time, cost, commission float64) (bool, error) {
+	for _, ref := range r.referrals {
+		if ref.ID == referralID && ref.SettledUntil.Equal(prev) {
+			ref.SettledUntil = next
+			ref.TotalCost += cost
+			ref.TotalCommission += commission
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *referralRepoStub) CreateCommission(_ context.Context, c *ReferralCommission) error {
+	c.ID = int64(len(r.commissions) + 1)
+	r.commissions = append(r.commissions, *c)
+	return nil
+}
+
+func (r *referralRepoStub) addUsage(inviteeID int64, at time.Time, cost float64) {
+	if r.usage[inviteeID] == nil {
+		r.usage[inviteeID] = map[time.Time]float64{}
+	}
+	r.usage[inviteeID][at] = cost
+}
+
+func newTestReferralSettlementService(repo ReferralRepository, cfg config.ReferralConfig) (*ReferralSettlementService, map[int64]float64) {
+	credited := map[int64]float64{}
+	userRepo := &mockUserRepo{updateBalanceFn: func(_ context.Context, id int64, amount float64) error {
+		credited[id] += amount
+		return nil
+	}}
+	svc := NewReferralSettlementService(repo, userRepo, nil, nil, nil, &config.Config{Referral: cfg})
+	return svc, credited
+}
+
+func TestReferralService_GetOrCreateCodeRetriesOnConflict(t *testing.T) {
+	repo := newReferralRepoStub()
+	repo.createCodeConflicts = 2
+	svc := NewReferralService(repo, &config.Config{Referral: config.ReferralConfig{Enabled: true}})
+	ctx := context.Background()
+
+	code, err := svc.GetOrCreateCode(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, code, referralCodeLength)
+	require.Zero(t, repo.createCodeConflicts)
+
+	again, err := svc.GetOrCreateCode(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, code, again, "已有邀请码时应直接返回")
+}
+
+func TestReferralService_ResolveAndBind(t *testing.T) {
+	repo := newReferralRepoStub()
+	repo.codes[1] = "ABCD2345"
+	svc := NewReferralService(repo, &config.Config{Referral: config.ReferralConfig{Enabled: true}})
+	ctx := context.Background()
+
+	inviterID, err := svc.ResolveCode(ctx, "  abcd2345 ")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), inviterID)
+
+	for _, code := range []string{"", "   ", "NOPE0000"} {
+		_, err := svc.ResolveCode(ctx, code)
+		require.ErrorIs(t, err, ErrReferralCodeInvalid, "code %q", code)
+	}
+
+	require.ErrorIs(t, svc.BindInvitee(ctx, 1, 1, time.Now()), ErrReferralCodeInvalid, "不能邀请自己")
+	require.NoError(t, svc.BindInvitee(ctx, 1, 2, time.Now()))
+	require.NoError(t, svc.BindInvitee(ctx, 3, 2, time.Now()))
+	require.Len(t, repo.referrals, 1, "被邀请人已有邀请人时不应改绑")
+	require.Equal(t, int64(1), repo.referrals[0].InviterID)
+}
+
+// oauthSignupUserRepoStub OAuth 首次登录：邮箱不存在，创建后分配固定 ID
+type oauthSignupUserRepoStub struct {
+	*userRepoStub
+}
+
+func (s *oauthSignupUserRepoStub) GetByEmail(ctx context.Context, email string) (*User, error) {
+	return nil, ErrUserNotFound
+}
+
+type oauthRefreshTokenCacheStub struct {
+	RefreshTokenCache
+}
+
+func (c *oauthRefreshTokenCacheStub) StoreRefreshToken(ctx context.Context, tokenHash string, data *RefreshTokenData, ttl time.Duration) error {
+	return nil
+}
+
+func (c *oauthRefreshTokenCacheStub) AddToUserTokenSet(ctx context.Context, userID int64, tokenHash string, ttl time.Duration) error {
+	return nil
+}
+
+func (c *oauthRefreshTokenCacheStub) AddToFamilyTokenSet(ctx context.Context, familyID string, tokenHash string, ttl time.Duration) error {
+	return nil
+}
+
+func TestAuthService_OAuthSignupBindsReferral(t *testing.T) {
+	for _, tc := range []struct {
+		name         string
+		referralCode string
+		wantBound    bool
+	}{
+		{name: "valid code", referralCode: "abcd2345", wantBound: true},
+		{name: "invalid code is ignored", referralCode: "NOPE0000"},
+		{name: "no code", referralCode: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			referralRepo := newReferralRepoStub()
+			referralRepo.codes[1] = "ABCD2345"
+			cfg := &config.Config{JWT: config.JWTConfig{Secret: "test-secret", ExpireHour: 1}}
+			settingService := NewSettingService(&settingRepoStub{values: map[string]string{SettingKeyRegistrationEnabled: "true"}}, cfg)
+			users := &oauthSignupUserRepoStub{userRepoStub: &userRepoStub{nextID: 7}}
+			svc := NewAuthService(nil, users, nil, &oauthRefreshTokenCacheStub{}, cfg, settingService, nil, nil, nil, nil, nil)
+			svc.SetReferralService(NewReferralService(referralRepo, &config.Config{Referral: config.ReferralConfig{Enabled: true}}))
+
+			_, user, err := svc.LoginOrRegisterOAuthWithGrants(context.Background(), "new@example.com", "new", "", tc.referralCode, nil)
+			require.NoError(t, err)
+			require.Equal(t, int64(7), user.ID)
+			if !tc.wantBound {
+				require.Empty(t, referralRepo.referrals)
+				return
+			}
+			require.Len(t, referralRepo.referrals, 1)
+			require.Equal(t, int64(1), referralRepo.referrals[0].InviterID)
+			require.Equal(t, int64(7), referralRepo.referrals[0].InviteeID)
+		})
+	}
+}
+
+func TestReferralService_OverviewDisabledSkipsCode(t *testing.T) {
+	repo := newReferralRepoStub()
+	svc := NewReferralService(repo, &config.Config{Referral: config.ReferralConfig{Enabled: false, CommissionRate: 0.1}})
+
+	overview, err := svc.GetOverview(context.Background(), 1)
+	require.NoError(t, err)
+	require.False(t, overview.Enabled)
+	require.Empty(t, overview.Code)
+	require.Empty(t, repo.codes, "未开启时不应生成邀请码")
+	require.Nil(t, overview.InvitedBy)
+}
+
+func TestReferralSettlement_CreditsCommissionOnce(t *testing.T) {
+	repo := newReferralRepoStub()
+	boundAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, _ = repo.CreateReferral(context.Background(), 1, 2, boundAt)
+	repo.addUsage(2, boundAt.Add(-time.Hour), 100) // 绑定前的消费不返佣
+	repo.addUsage(2, boundAt.Add(time.Hour), 10)
+	repo.addUsage(2, boundAt.Add(2*time.Hour), 2.5)
+
+	svc, credited := newTestReferralSettlementService(repo, config.ReferralConfig{Enabled: true, CommissionRate: 0.1, BatchSize: 10})
+	now := boundAt.Add(24 * time.Hour)
+
+	settled, total, err := svc.Settle(context.Background(), now)
+	require.NoError(t, err)
+	require.Equal(t, 1, settled)
+	require.InDelta(t, 1.25, total, 1e-9)
+	require.InDelta(t, 1.25, credited[1], 1e-9)
+	require.Len(t, repo.commissions, 1)
+	require.InDelta(t, 12.5, repo.commissions[0].UsageCost, 1e-9)
+	require.True(t, repo.referrals[0].SettledUntil.Equal(now.Add(-referralSettlementLag)))
+
+	// 同一区间重复结算不应重复入账
+	_, total, err = svc.Settle(context.Background(), now)
+	require.NoError(t, err)
+	require.Zero(t, total)
+	require.InDelta(t, 1.25, credited[1], 1e-9)
+	require.Len(t, repo.commissions, 1)
+}
+
+func TestReferralSettlement_CapAndCommissionWindow(t *testing.T) {
+	repo := newReferralRepoStub()
+	boundAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	_, _ = repo.CreateReferral(context.Background(), 1, 2, boundAt)
+	repo.addUsage(2, boundAt.Add(time.Hour), 30)
+	repo.addUsage(2, boundAt.Add(3*24*time.Hour), 1000) // 超出返佣期限
+
+	svc, credited := newTestReferralSettlementService(repo, config.ReferralConfig{
+		Enabled: true, CommissionRate: 0.5, CommissionDays: 2, MaxCommissionPerInvitee: 10, BatchSize: 10,
+	})
+
+	_, _, err := svc.Settle(context.Background(), boundAt.Add(10*24*time.Hour))
+	require.NoError(t, err)
+	require.InDelta(t, 10, credited[1], 1e-9, "返佣受单个被邀请人上限约束")
+	require.InDelta(t, 30, repo.referrals[0].TotalCost, 1e-9, "期限之后的消费不计入")
+	require.True(t, repo.referrals[0].SettledUntil.Equal(boundAt.AddDate(0, 0, 2)))
+
+	// 达到上限/期限后不再结算
+	due, err := repo.ListSettlementDue(context.Background(), boundAt.Add(20*24*time.Hour), 0, 2, 10, 10)
+	require.NoError(t, err)
+	require.Empty(t, due)
+}
+
+func TestReferralCommissionAmount(t *testing.T) {
+	require.Zero(t, referralCommissionAmount(0, 0.1, 0, 0))
+	require.Zero(t, referralCommissionAmount(10, 0, 0, 0))
+	require.InDelta(t, 0.12345679, referralCommissionAmount(1.2345678912, 0.1, 0, 0), 1e-12)
+	require.InDelta(t, 0.5, referralCommissionAmount(10, 0.1, 9.5, 10), 1e-12)
+	require.Zero(t, referralCommissionAmount(10, 0.1, 10, 10))
+}
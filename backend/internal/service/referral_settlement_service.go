@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+)
+
+// referralSettlementLag 结算截止时间相对当前时间的延迟，给异步写入的使用记录留出落库时间
+const referralSettlementLag = 5 * time.Minute
+
+// ReferralSettlementService 定期按被邀请人的 actual_cost 计算返佣并入账到邀请人余额。
+// 每条邀请关系维护结算水位 settled_until，每轮结算 [settled_until, 截止时间) 区间的消费，
+// 水位推进、返佣明细与余额入账在同一事务中完成，多实例并发时以水位条件更新避免重复入账。
+type ReferralSettlementService struct {
+	repo                 ReferralRepository
+	userRepo             UserRepository
+	billingCacheService  *BillingCacheService
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	entClient            *dbent.Client
+	cfg                  config.ReferralConfig
+	interval             time.Duration
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+}
+
+// NewReferralSettlementService 创建返佣结算服务
+func NewReferralSettlementService(
+	repo ReferralRepository,
+	userRepo UserRepository,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	entClient *dbent.Client,
+	cfg *config.Config,
+) *ReferralSettlementService {
+	var referralCfg config.ReferralConfig
+	if cfg != nil {
+		referralCfg = cfg.Referral
+	}
+	interval := time.Hour
+	if referralCfg.SettleIntervalMinutes > 0 {
+		interval = time.Duration(referralCfg.SettleIntervalMinutes) * time.Minute
+	}
+	if referralCfg.BatchSize <= 0 {
+		referralCfg.BatchSize = 500
+	}
+	return &ReferralSettlementService{
+		repo:                 repo,
+		userRepo:             userRepo,
+		billingCacheService:  billingCacheService,
+		authCacheInvalidator: authCacheInvalidator,
+		entClient:            entClient,
+		cfg:                  referralCfg,
+		interval:             interval,
+		stopCh:               make(chan struct{}),
+	}
+}
+
+func (s *ReferralSettlementService) Start() {
+	if s == nil || s.repo == nil || !s.cfg.Enabled {
+		return
+	}
+	s.startOnce.Do(func() {
+		logger.LegacyPrintf("service.referral_settlement", "[ReferralSettlement] started interval=%s rate=%.4f", s.interval, s.cfg.CommissionRate)
+		go s.runLoop()
+	})
+}
+
+func (s *ReferralSettlementService) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+		logger.LegacyPrintf("service.referral_settlement", "[ReferralSettlement] stopped")
+	})
+}
+
+func (s *ReferralSettlementService) runLoop() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.runOnce()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runOnce()
+		case <-s.stopCh:
+			return
+		}
+	}
+}
+
+func (s *ReferralSettlementService) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	settled, amount, err := s.Settle(ctx, time.Now())
+	if err != nil {
+		logger.LegacyPrintf("service.referral_settlement", "[ReferralSettlement] settle failed err=%v", err)
+		return
+	}
+	if settled > 0 {
+		logger.LegacyPrintf("service.referral_settlement", "[ReferralSettlement] settled referrals=%d commission=%.8f", settled, amount)
+	}
+}
+
+// Settle 结算截止到 now - referralSettlementLag 的被邀请人消费，返回推进了水位的邀请关系数与入账返佣总额
+func (s *ReferralSettlementService) Settle(ctx context.Context, now time.Time) (int, float64, error) {
+	end := now.Add(-referralSettlementLag).Truncate(time.Second)
+	settled := 0
+	total := 0.0
+	var afterID int64
+	for {
+		referrals, err := s.repo.ListSettlementDue(ctx, end, afterID, s.cfg.CommissionDays, s.cfg.MaxCommissionPerInvitee, s.cfg.BatchSize)
+		if err != nil {
+			return settled, total, fmt.Errorf("list referrals due for settlement: %w", err)
+		}
+		for i := range referrals {
+			if ctx.Err() != nil {
+				return settled, total, ctx.Err()
+			}
+			ref := &referrals[i]
+			afterID = ref.ID
+			advanced, commission, err := s.settleOne(ctx, ref, end)
+			if err != nil {
+				logger.LegacyPrintf("service.referral_settlement", "[ReferralSettlement] settle referral failed: referral_id=%d inviter_id=%d err=%v",
+					ref.ID, ref.InviterID, err)
+				continue
+			}
+			if advanced {
+				settled++
+				total += commission
+			}
+		}
+		if len(referrals) < s.cfg.BatchSize {
+			return settled, total, nil
+		}
+	}
+}
+
+// settleOne 结算单条邀请关系，返回水位是否推进与本次入账的返佣
+func (s *ReferralSettlementService) settleOne(ctx context.Context, ref *Referral, end time.Time) (bool, float64, error) {
+	periodStart := ref.SettledUntil
+	periodEnd := end
+	if s.cfg.CommissionDays > 0 {
+		if deadline := ref.CreatedAt.AddDate(0, 0, s.cfg.CommissionDays); deadline.Before(periodEnd) {
+			periodEnd = deadline
+		}
+	}
+	if !periodEnd.After(periodStart) {
+		return false, 0, nil
+	}
+
+	cost, err := s.repo.SumInviteeCost(ctx, ref.InviteeID, periodStart, periodEnd)
+	if err != nil {
+		return false, 0, err
+	}
+	commission := referralCommissionAmount(cost, s.cfg.CommissionRate, ref.TotalCommission, s.cfg.MaxCommissionPerInvitee)
+
+	opCtx := ctx
+	var tx *dbent.Tx
+	if s.entClient != nil {
+		tx, err = s.entClient.Tx(ctx)
+		if err != nil {
+			return false, 0, fmt.Errorf("begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+		opCtx = dbent.NewTxContext(ctx, tx)
+	}
+
+	advanced, err := s.repo.AdvanceSettlement(opCtx, ref.ID, ref.SettledUntil, periodEnd, cost, commission)
+	if err != nil {
+		return false, 0, err
+	}
+	if !advanced {
+		// 已被其他实例结算
+		return false, 0, nil
+	}
+
+	if commission > 0 {
+		if err := s.repo.CreateCommission(opCtx, &ReferralCommission{
+			ReferralID:  ref.ID,
+			InviterID:   ref.InviterID,
+			InviteeID:   ref.InviteeID,
+			UsageCost:   cost,
+			Rate:        s.cfg.CommissionRate,
+			Amount:      commission,
+			PeriodStart: periodStart,
+			PeriodEnd:   periodEnd,
+		}); err != nil {
+			return false, 0, err
+		}
+		if err := s.userRepo.UpdateBalance(opCtx, ref.InviterID, commission); err != nil {
+			return false, 0, fmt.Errorf("credit inviter balance: %w", err)
+		}
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return false, 0, fmt.Errorf("commit transaction: %w", err)
+		}
+	}
+	if commission > 0 {
+		s.invalidateBalanceCaches(ctx, ref.InviterID)
+	}
+	return true, commission, nil
+}
+
+// invalidateBalanceCaches 返佣入账后失效邀请人的鉴权与余额缓存
+func (s *ReferralSettlementService) invalidateBalanceCaches(ctx context.Context, userID int64) {
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
+	}
+	if s.billingCacheService != nil {
+		cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.billingCacheService.InvalidateUserBalance(cacheCtx, userID)
+	}
+}
+
+// referralCommissionAmount 按比例计算返佣（保留 8 位小数），并受单个被邀请人返佣上限约束
+func referralCommissionAmount(cost, rate, settled, maxPerInvitee float64) float64 {
+	if cost <= 0 || rate <= 0 {
+		return 0
+	}
+	commission := math.Round(cost*rate*1e8) / 1e8
+	if maxPerInvitee > 0 {
+		commission = math.Min(commission, math.Round((maxPerInvitee-settled)*1e8)/1e8)
+	}
+	return math.Max(commission, 0)
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// 响应缓存覆盖的入站端点，参与缓存 key 计算，避免不同协议的响应互相命中
+const (
+	ResponseCacheEndpointMessages        = "messages"
+	ResponseCacheEndpointChatCompletions = "chat_completions"
+	ResponseCacheEndpointResponses       = "responses"
+)
+
+const responseCacheWriteTimeout = 2 * time.Second
+
+// responseCacheVolatileFields 不影响模型输出、仅用于标识调用方的顶层字段，计算 key 时剔除
+var responseCacheVolatileFields = []string{
+	"metadata",
+	"user",
+	"prompt_cache_key",
+	"safety_identifier",
+}
+
+// ResponseCacheEntry 一条缓存的上游响应：原样保存写给客户端的响应体（SSE 或 JSON），
+// 以及命中时重新计费所需的用量信息。
+type ResponseCacheEntry struct {
+	StatusCode      int          `json:"status_code"`
+	ContentType     string       `json:"content_type"`
+	Body            []byte       `json:"body"`
+	Model           string       `json:"model"`
+	UpstreamModel   string       `json:"upstream_model,omitempty"`
+	BillingModel    string       `json:"billing_model,omitempty"`
+	ReasoningEffort *string      `json:"reasoning_effort,omitempty"`
+	ServiceTier     *string      `json:"service_tier,omitempty"`
+	AccountID       int64        `json:"account_id"`
+	AccountType     string       `json:"account_type"`
+	AccountPlatform string       `json:"account_platform"`
+	ClaudeUsage     *ClaudeUsage `json:"claude_usage,omitempty"`
+	OpenAIUsage     *OpenAIUsage `json:"openai_usage,omitempty"`
+	CreatedAt       time.Time    `json:"created_at"`
+}
+
+// NewClaudeResponseCacheEntry 由 Anthropic Messages 转发结果构造缓存条目
+func NewClaudeResponseCacheEntry(result *ForwardResult, account *Account, statusCode int, contentType string, body []byte) *ResponseCacheEntry {
+	usage := result.Usage
+	return &ResponseCacheEntry{
+		StatusCode:      statusCode,
+		ContentType:     contentType,
+		Body:            body,
+		Model:           result.Model,
+		UpstreamModel:   result.UpstreamModel,
+		ReasoningEffort: result.ReasoningEffort,
+		AccountID:       account.ID,
+		AccountType:     account.Type,
+		AccountPlatform: account.Platform,
+		ClaudeUsage:     &usage,
+		CreatedAt:       time.Now(),
+	}
+}
+
+// NewOpenAIResponseCacheEntry 由 OpenAI Chat Completions / Responses 转发结果构造缓存条目
+func NewOpenAIResponseCacheEntry(result *OpenAIForwardResult, account *Account, statusCode int, contentType string, body []byte) *ResponseCacheEntry {
+	usage := result.Usage
+	return &ResponseCacheEntry{
+		StatusCode:      statusCode,
+		ContentType:     contentType,
+		Body:            body,
+		Model:           result.Model,
+		UpstreamModel:   result.UpstreamModel,
+		BillingModel:    result.BillingModel,
+		ReasoningEffort: result.ReasoningEffort,
+		ServiceTier:     result.ServiceTier,
+		AccountID:       account.ID,
+		AccountType:     account.Type,
+		AccountPlatform: account.Platform,
+		OpenAIUsage:     &usage,
+		CreatedAt:       time.Now(),
+	}
+}
+
+// Account 返回生成该响应的账号快照，仅用于用量记录（账号侧成本按 0 计）
+func (e *ResponseCacheEntry) Account() *Account {
+	return &Account{ID: e.AccountID, Type: e.AccountType, Platform: e.AccountPlatform}
+}
+
+// ForwardResult 构造命中缓存时用于计费的 Anthropic 转发结果
+func (e *ResponseCacheEntry) ForwardResult(stream bool, duration time.Duration) *ForwardResult {
+	result := &ForwardResult{
+		Model:           e.Model,
+		UpstreamModel:   e.UpstreamModel,
+		ReasoningEffort: e.ReasoningEffort,
+		Stream:          stream,
+		Duration:        duration,
+	}
+	if e.ClaudeUsage != nil {
+		result.Usage = *e.ClaudeUsage
+	}
+	return result
+}
+
+// OpenAIForwardResult 构造命中缓存时用于计费的 OpenAI 转发结果
+func (e *ResponseCacheEntry) OpenAIForwardResult(stream bool, duration time.Duration) *OpenAIForwardResult {
+	result := &OpenAIForwardResult{
+		Model:           e.Model,
+		BillingModel:    e.BillingModel,
+		UpstreamModel:   e.UpstreamModel,
+		ReasoningEffort: e.ReasoningEffort,
+		ServiceTier:     e.ServiceTier,
+		Stream:          stream,
+		Duration:        duration,
+	}
+	if e.OpenAIUsage != nil {
+		result.Usage = *e.OpenAIUsage
+	}
+	return result
+}
+
+// ResponseCacheBudget 单个分组的缓存容量预算，超出时按写入时间淘汰最旧条目；0 表示不限
+type ResponseCacheBudget struct {
+	MaxEntries int
+	MaxBytes   int
+}
+
+// ResponseCacheStore 响应缓存存储（Redis）
+type ResponseCacheStore interface {
+	// Get 未命中时返回 (nil, nil)
+	Get(ctx context.Context, key string) (*ResponseCacheEntry, error)
+	// Set 写入条目并计入分组预算，写入后淘汰超出预算的最旧条目
+	Set(ctx context.Context, groupID int64, key string, entry *ResponseCacheEntry, ttl time.Duration, budget ResponseCacheBudget) error
+}
+
+// ResponseCacheService 确定性请求的精确匹配响应缓存。
+// 仅对开启 response_cache_enabled 的分组、且显式指定 temperature=0 的请求生效；
+// key 由分组、用户、端点与规范化后的请求体哈希组成。
+type ResponseCacheService struct {
+	store ResponseCacheStore
+	cfg   *config.Config
+}
+
+// NewResponseCacheService 创建响应缓存服务
+func NewResponseCacheService(store ResponseCacheStore, cfg *config.Config) *ResponseCacheService {
+	return &ResponseCacheService{store: store, cfg: cfg}
+}
+
+func (s *ResponseCacheService) enabled() bool {
+	return s != nil && s.store != nil && s.cfg != nil && s.cfg.Gateway.ResponseCache.Enabled
+}
+
+// MaxEntryBytes 单条缓存响应体的大小上限
+func (s *ResponseCacheService) MaxEntryBytes() int {
+	if !s.enabled() {
+		return 0
+	}
+	return s.cfg.Gateway.ResponseCache.MaxEntryBytes
+}
+
+// BuildKey 计算请求的缓存 key；返回空串表示该请求不参与缓存
+func (s *ResponseCacheService) BuildKey(apiKey *APIKey, endpoint string, body []byte) string {
+	if !s.enabled() || apiKey == nil || apiKey.Group == nil || !apiKey.Group.ResponseCacheEnabled {
+		return ""
+	}
+	if !isResponseCacheableRequest(body) {
+		return ""
+	}
+	hash, ok := responseCacheRequestHash(endpoint, body)
+	if !ok {
+		return ""
+	}
+	return strconv.FormatInt(apiKey.Group.ID, 10) + ":" + strconv.FormatInt(apiKey.UserID, 10) + ":" + hash
+}
+
+// Lookup 查询缓存；读取失败按未命中处理
+func (s *ResponseCacheService) Lookup(ctx context.Context, key string) *ResponseCacheEntry {
+	if key == "" || !s.enabled() {
+		return nil
+	}
+	entry, err := s.store.Get(ctx, key)
+	if err != nil {
+		logger.FromContext(ctx).Warn("response_cache.get_failed", zap.Error(err))
+		return nil
+	}
+	return entry
+}
+
+// Store 写入缓存；超出大小上限的响应不缓存，分组总量超出预算时淘汰最旧条目，写入失败仅记录日志
+func (s *ResponseCacheService) Store(ctx context.Context, groupID int64, key string, entry *ResponseCacheEntry) {
+	if key == "" || entry == nil || !s.enabled() {
+		return
+	}
+	if len(entry.Body) == 0 || len(entry.Body) > s.cfg.Gateway.ResponseCache.MaxEntryBytes {
+		return
+	}
+	// 客户端断开不影响写入
+	writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), responseCacheWriteTimeout)
+	defer cancel()
+	ttl := time.Duration(s.cfg.Gateway.ResponseCache.TTLSeconds) * time.Second
+	budget := ResponseCacheBudget{
+		MaxEntries: s.cfg.Gateway.ResponseCache.MaxEntriesPerGroup,
+		MaxBytes:   s.cfg.Gateway.ResponseCache.MaxBytesPerGroup,
+	}
+	if err := s.store.Set(writeCtx, groupID, key, entry, ttl, budget); err != nil {
+		logger.FromContext(ctx).Warn("response_cache.set_failed", zap.Error(err))
+	}
+}
+
+// isResponseCacheableRequest 仅缓存显式 temperature=0 的请求；
+// 依赖服务端会话状态（previous_response_id）或后台执行的请求结果不可复用。
+func isResponseCacheableRequest(body []byte) bool {
+	var req struct {
+		Temperature        *json.Number `json:"temperature"`
+		N                  *int         `json:"n"`
+		PreviousResponseID string       `json:"previous_response_id"`
+		Background         bool         `json:"background"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return false
+	}
+	if req.Temperature == nil {
+		return false
+	}
+	temperature, err := req.Temperature.Float64()
+	if err != nil || temperature != 0 {
+		return false
+	}
+	if req.N != nil && *req.N > 1 {
+		return false
+	}
+	return req.PreviousResponseID == "" && !req.Background
+}
+
+// responseCacheRequestHash 规范化请求体（键排序、剔除调用方标识字段、数字保持原文）后计算 sha256
+func responseCacheRequestHash(endpoint string, body []byte) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var req map[string]any
+	if err := dec.Decode(&req); err != nil {
+		return "", false
+	}
+	for _, field := range responseCacheVolatileFields {
+		delete(req, field)
+	}
+	canonical, err := json.Marshal(req)
+	if err != nil {
+		return "", false
+	}
+	h := sha256.New()
+	_, _ = h.Write([]byte(endpoint))
+	_, _ = h.Write([]byte{'\n'})
+	_, _ = h.Write(canonical)
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// responseCacheHitMultiplier 命中缓存时叠加在分组倍率之上的计费倍率
+func responseCacheHitMultiplier(cfg *config.Config) float64 {
+	if cfg == nil {
+		return 1
+	}
+	return cfg.Gateway.ResponseCache.HitPriceMultiplier
+}
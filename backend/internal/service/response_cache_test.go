@@ -0,0 +1,146 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/stretchr/testify/require"
+)
+
+type responseCacheStoreStub struct {
+	entries map[string]*ResponseCacheEntry
+	ttl     time.Duration
+	groupID int64
+	budget  ResponseCacheBudget
+}
+
+func (s *responseCacheStoreStub) Get(_ context.Context, key string) (*ResponseCacheEntry, error) {
+	return s.entries[key], nil
+}
+
+func (s *responseCacheStoreStub) Set(_ context.Context, groupID int64, key string, entry *ResponseCacheEntry, ttl time.Duration, budget ResponseCacheBudget) error {
+	s.entries[key] = entry
+	s.ttl = ttl
+	s.groupID = groupID
+	s.budget = budget
+	return nil
+}
+
+func newResponseCacheServiceForTest() (*ResponseCacheService, *responseCacheStoreStub) {
+	cfg := &config.Config{}
+	cfg.Gateway.ResponseCache = config.GatewayResponseCacheConfig{
+		Enabled:            true,
+		TTLSeconds:         60,
+		MaxEntryBytes:      16,
+		MaxEntriesPerGroup: 100,
+		MaxBytesPerGroup:   4096,
+		HitPriceMultiplier: 0.1,
+	}
+	store := &responseCacheStoreStub{entries: map[string]*ResponseCacheEntry{}}
+	return NewResponseCacheService(store, cfg), store
+}
+
+func TestIsResponseCacheableRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{"temperature zero", `{"model":"m","temperature":0}`, true},
+		{"temperature zero float", `{"model":"m","temperature":0.0}`, true},
+		{"temperature missing", `{"model":"m"}`, false},
+		{"temperature non-zero", `{"model":"m","temperature":0.2}`, false},
+		{"multiple choices", `{"model":"m","temperature":0,"n":2}`, false},
+		{"previous response", `{"model":"m","temperature":0,"previous_response_id":"resp_1"}`, false},
+		{"background", `{"model":"m","temperature":0,"background":true}`, false},
+		{"invalid json", `{`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, isResponseCacheableRequest([]byte(tt.body)))
+		})
+	}
+}
+
+func TestResponseCacheRequestHashNormalization(t *testing.T) {
+	hash := func(endpoint, body string) string {
+		h, ok := responseCacheRequestHash(endpoint, []byte(body))
+		require.True(t, ok)
+		return h
+	}
+
+	base := hash(ResponseCacheEndpointMessages, `{"model":"m","temperature":0,"messages":[{"role":"user","content":"hi"}]}`)
+	require.Equal(t, base, hash(ResponseCacheEndpointMessages, `{"messages":[{"content":"hi","role":"user"}],"temperature":0,"model":"m"}`))
+	require.Equal(t, base, hash(ResponseCacheEndpointMessages, `{"model":"m","temperature":0,"messages":[{"role":"user","content":"hi"}],"metadata":{"user_id":"session-1"}}`))
+
+	require.NotEqual(t, base, hash(ResponseCacheEndpointChatCompletions, `{"model":"m","temperature":0,"messages":[{"role":"user","content":"hi"}]}`))
+	require.NotEqual(t, base, hash(ResponseCacheEndpointMessages, `{"model":"m","temperature":0,"messages":[{"role":"user","content":"hi"}],"stream":true}`))
+	require.NotEqual(t, base, hash(ResponseCacheEndpointMessages, `{"model":"m","temperature":0,"messages":[{"role":"user","content":"hi"}],"max_tokens":10}`))
+}
+
+func TestResponseCacheServiceBuildKey(t *testing.T) {
+	svc, _ := newResponseCacheServiceForTest()
+	body := []byte(`{"model":"m","temperature":0}`)
+	apiKey := &APIKey{UserID: 7, Group: &Group{ID: 3, ResponseCacheEnabled: true}}
+
+	key := svc.BuildKey(apiKey, ResponseCacheEndpointMessages, body)
+	require.True(t, strings.HasPrefix(key, "3:7:"))
+
+	require.Empty(t, svc.BuildKey(&APIKey{UserID: 7, Group: &Group{ID: 3}}, ResponseCacheEndpointMessages, body))
+	require.Empty(t, svc.BuildKey(&APIKey{UserID: 7}, ResponseCacheEndpointMessages, body))
+	require.Empty(t, svc.BuildKey(apiKey, ResponseCacheEndpointMessages, []byte(`{"model":"m"}`)))
+
+	svc.cfg.Gateway.ResponseCache.Enabled = false
+	require.Empty(t, svc.BuildKey(apiKey, ResponseCacheEndpointMessages, body))
+
+	var nilSvc *ResponseCacheService
+	require.Empty(t, nilSvc.BuildKey(apiKey, ResponseCacheEndpointMessages, body))
+	require.Nil(t, nilSvc.Lookup(context.Background(), key))
+}
+
+func TestResponseCacheServiceStoreAndLookup(t *testing.T) {
+	svc, store := newResponseCacheServiceForTest()
+	ctx := context.Background()
+
+	svc.Store(ctx, 3, "k", &ResponseCacheEntry{StatusCode: 200, Body: []byte("0123456789abcdefg")})
+	require.Nil(t, svc.Lookup(ctx, "k"), "oversized body must not be cached")
+
+	entry := &ResponseCacheEntry{StatusCode: 200, ContentType: "application/json", Body: []byte(`{"ok":true}`)}
+	svc.Store(ctx, 3, "k", entry)
+	require.Same(t, entry, svc.Lookup(ctx, "k"))
+	require.Equal(t, time.Minute, store.ttl)
+	require.Equal(t, int64(3), store.groupID)
+	require.Equal(t, ResponseCacheBudget{MaxEntries: 100, MaxBytes: 4096}, store.budget)
+}
+
+func TestResponseCacheEntryForwardResults(t *testing.T) {
+	account := &Account{ID: 9, Type: AccountTypeAPIKey, Platform: PlatformAnthropic}
+	entry := NewClaudeResponseCacheEntry(&ForwardResult{
+		Model: "claude-sonnet-4-5",
+		Usage: ClaudeUsage{InputTokens: 10, OutputTokens: 5, CacheCreation5mTokens: 2},
+	}, account, 200, "text/event-stream", []byte("event: message_stop\n\n"))
+
+	result := entry.ForwardResult(true, time.Second)
+	require.Equal(t, "claude-sonnet-4-5", result.Model)
+	require.Equal(t, 10, result.Usage.InputTokens)
+	require.Equal(t, 2, result.Usage.CacheCreation5mTokens)
+	require.True(t, result.Stream)
+	require.Empty(t, result.RequestID)
+
+	stub := entry.Account()
+	require.Equal(t, int64(9), stub.ID)
+	require.Equal(t, PlatformAnthropic, stub.Platform)
+
+	openAIEntry := NewOpenAIResponseCacheEntry(&OpenAIForwardResult{
+		Model: "gpt-5.1",
+		Usage: OpenAIUsage{InputTokens: 4, OutputTokens: 1},
+	}, account, 200, "application/json", []byte(`{}`))
+	openAIResult := openAIEntry.OpenAIForwardResult(false, 0)
+	require.Equal(t, 4, openAIResult.Usage.InputTokens)
+	require.False(t, openAIResult.Stream)
+}
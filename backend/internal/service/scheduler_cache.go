@@ -46,10 +46,11 @@ func ParseSchedulerBucket(raw string) (SchedulerBucket, bool) {
 // SchedulerCache 负责调度快照与账号快照的缓存读写。
 type SchedulerCache interface {
 	// GetSnapshot 读取快照并返回命中与否（ready + active + 数据完整）。
+	// 账号敏感凭证保持快照中的密文形式，不在此解密。
 	GetSnapshot(ctx context.Context, bucket SchedulerBucket) ([]*Account, bool, error)
 	// SetSnapshot 写入快照并切换激活版本。
 	SetSnapshot(ctx context.Context, bucket SchedulerBucket, accounts []Account) error
-	// GetAccount 获取单账号快照。
+	// GetAccount 获取单账号快照（凭证同样不解密）。
 	GetAccount(ctx context.Context, accountID int64) (*Account, error)
 	// SetAccount 写入单账号快照（包含不可调度状态）。
 	SetAccount(ctx context.Context, account *Account) error
@@ -57,6 +58,8 @@ type SchedulerCache interface {
 	DeleteAccount(ctx context.Context, accountID int64) error
 	// UpdateLastUsed 批量更新账号的最后使用时间。
 	UpdateLastUsed(ctx context.Context, updates map[int64]time.Time) error
+	// ListCredentialKeyIDs 返回账号快照中凭证密文引用的主密钥 ID。
+	ListCredentialKeyIDs(ctx context.Context) ([]string, error)
 	// TryLockBucket 尝试获取分桶重建锁。
 	TryLockBucket(ctx context.Context, bucket SchedulerBucket, ttl time.Duration) (bool, error)
 	// ListBuckets 返回已注册的分桶集合。
@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Wei-Shaw/sub2api/internal/config"
@@ -21,17 +22,47 @@ var (
 const outboxEventTimeout = 2 * time.Minute
 
 type SchedulerSnapshotService struct {
-	cache         SchedulerCache
-	outboxRepo    SchedulerOutboxRepository
-	accountRepo   AccountRepository
-	groupRepo     GroupRepository
-	cfg           *config.Config
-	stopCh        chan struct{}
-	stopOnce      sync.Once
-	wg            sync.WaitGroup
-	fallbackLimit *fallbackLimiter
-	lagMu         sync.Mutex
-	lagFailures   int
+	cache       SchedulerCache
+	outboxRepo  SchedulerOutboxRepository
+	accountRepo AccountRepository
+	groupRepo   GroupRepository
+	// credentialCipher 快照中的敏感凭证为密文：剔除无法解密的账号，选中后再按需解密
+	credentialCipher CredentialCipher
+	cfg              *config.Config
+	stopCh           chan struct{}
+	stopOnce         sync.Once
+	wg               sync.WaitGroup
+	fallbackLimit    *fallbackLimiter
+	lagMu            sync.Mutex
+	lagFailures      int
+
+	// outbox 消费状态（供指标导出）
+	outboxLagMs      atomic.Int64
+	outboxWatermark  atomic.Int64
+	outboxLastPollAt atomic.Int64 // unix 秒
+}
+
+// SchedulerOutboxStats outbox 消费状态快照
+type SchedulerOutboxStats struct {
+	// Lag 最近一次轮询时最早未处理事件的滞后时间；无积压时为 0
+	Lag        time.Duration
+	Watermark  int64
+	LastPollAt time.Time
+}
+
+// OutboxStats 返回 outbox 消费状态快照（进程内）
+func (s *SchedulerSnapshotService) OutboxStats() SchedulerOutboxStats {
+	if s == nil {
+		return SchedulerOutboxStats{}
+	}
+	stats := SchedulerOutboxStats{
+		Lag:       time.Duration(s.outboxLagMs.Load()) * time.Millisecond,
+		Watermark: s.outboxWatermark.Load(),
+	}
+	if ts := s.outboxLastPollAt.Load(); ts > 0 {
+		stats.LastPollAt = time.Unix(ts, 0)
+	}
+	return stats
 }
 
 func NewSchedulerSnapshotService(
@@ -39,6 +70,7 @@ func NewSchedulerSnapshotService(
 	outboxRepo SchedulerOutboxRepository,
 	accountRepo AccountRepository,
 	groupRepo GroupRepository,
+	credentialCipher CredentialCipher,
 	cfg *config.Config,
 ) *SchedulerSnapshotService {
 	maxQPS := 0
@@ -46,13 +78,14 @@ func NewSchedulerSnapshotService(
 		maxQPS = cfg.Gateway.Scheduling.DbFallbackMaxQPS
 	}
 	return &SchedulerSnapshotService{
-		cache:         cache,
-		outboxRepo:    outboxRepo,
-		accountRepo:   accountRepo,
-		groupRepo:     groupRepo,
-		cfg:           cfg,
-		stopCh:        make(chan struct{}),
-		fallbackLimit: newFallbackLimiter(maxQPS),
+		cache:            cache,
+		outboxRepo:       outboxRepo,
+		accountRepo:      accountRepo,
+		groupRepo:        groupRepo,
+		credentialCipher: credentialCipher,
+		cfg:              cfg,
+		stopCh:           make(chan struct{}),
+		fallbackLimit:    newFallbackLimiter(maxQPS),
 	}
 }
 
@@ -106,7 +139,7 @@ func (s *SchedulerSnapshotService) ListSchedulableAccounts(ctx context.Context,
 		if err != nil {
 			logger.LegacyPrintf("service.scheduler_snapshot", "[Scheduler] cache read failed: bucket=%s err=%v", bucket.String(), err)
 		} else if hit {
-			return derefAccounts(cached), useMixed, nil
+			return derefAccounts(s.unsealCachedAccounts(cached)), useMixed, nil
 		}
 	}
 
@@ -140,6 +173,9 @@ func (s *SchedulerSnapshotService) GetAccount(ctx context.Context, accountID int
 		if err != nil {
 			logger.LegacyPrintf("service.scheduler_snapshot", "[Scheduler] account cache read failed: id=%d err=%v", accountID, err)
 		} else if account != nil {
+			if !s.unsealCachedAccount(account) {
+				return nil, nil
+			}
 			return account, nil
 		}
 	}
@@ -152,6 +188,32 @@ func (s *SchedulerSnapshotService) GetAccount(ctx context.Context, accountID int
 	return s.accountRepo.GetByID(fallbackCtx, accountID)
 }
 
+// unsealCachedAccounts 剔除凭证无法解密的快照账号（主密钥缺失或密文损坏），其余账号选中后按需解密
+func (s *SchedulerSnapshotService) unsealCachedAccounts(accounts []*Account) []*Account {
+	if s.credentialCipher == nil {
+		return accounts
+	}
+	out := make([]*Account, 0, len(accounts))
+	for _, account := range accounts {
+		if s.unsealCachedAccount(account) {
+			out = append(out, account)
+		}
+	}
+	return out
+}
+
+func (s *SchedulerSnapshotService) unsealCachedAccount(account *Account) bool {
+	if s.credentialCipher == nil || account == nil {
+		return true
+	}
+	if !s.credentialCipher.CanDecrypt(account.Credentials) {
+		logger.LegacyPrintf("service.scheduler_snapshot", "[Scheduler] skip account with undecryptable credentials: id=%d", account.ID)
+		return false
+	}
+	account.sealCredentials(s.credentialCipher.DecryptCredentials)
+	return true
+}
+
 // GetGroupByID 获取分组信息（供调度器使用）
 func (s *SchedulerSnapshotService) GetGroupByID(ctx context.Context, groupID int64) (*Group, error) {
 	if s.groupRepo == nil {
@@ -239,9 +301,15 @@ func (s *SchedulerSnapshotService) pollOutbox() {
 		logger.LegacyPrintf("service.scheduler_snapshot", "[Scheduler] outbox poll failed: %v", err)
 		return
 	}
+	s.outboxLastPollAt.Store(time.Now().Unix())
+	s.outboxWatermark.Store(watermark)
 	if len(events) == 0 {
+		s.outboxLagMs.Store(0)
 		return
 	}
+	if !events[0].CreatedAt.IsZero() {
+		s.outboxLagMs.Store(time.Since(events[0].CreatedAt).Milliseconds())
+	}
 
 	watermarkForCheck := watermark
 	for _, event := range events {
@@ -259,6 +327,7 @@ func (s *SchedulerSnapshotService) pollOutbox() {
 		logger.LegacyPrintf("service.scheduler_snapshot", "[Scheduler] outbox watermark write failed: %v", err)
 	} else {
 		watermarkForCheck = lastID
+		s.outboxWatermark.Store(lastID)
 	}
 
 	s.checkOutboxLag(ctx, events[0], watermarkForCheck)
@@ -174,13 +174,14 @@ const (
 	BetaPolicyScopeOAuth   = "oauth"   // 仅 OAuth 账号
 	BetaPolicyScopeAPIKey  = "apikey"  // 仅 API Key 账号
 	BetaPolicyScopeBedrock = "bedrock" // 仅 AWS Bedrock 账号
+	BetaPolicyScopeVertex  = "vertex"  // 仅 Google Vertex AI 账号
 )
 
 // BetaPolicyRule 单条 Beta 策略规则
 type BetaPolicyRule struct {
 	BetaToken            string   `json:"beta_token"`                       // beta token 值
 	Action               string   `json:"action"`                           // "pass" | "filter" | "block"
-	Scope                string   `json:"scope"`                            // "all" | "oauth" | "apikey" | "bedrock"
+	Scope                string   `json:"scope"`                            // "all" | "oauth" | "apikey" | "bedrock" | "vertex"
 	ErrorMessage         string   `json:"error_message,omitempty"`          // 自定义错误消息 (action=block 时生效)
 	ModelWhitelist       []string `json:"model_whitelist,omitempty"`        // 模型匹配模式列表（为空=对所有模型生效）
 	FallbackAction       string   `json:"fallback_action,omitempty"`        // 未匹配白名单的模型的处理方式
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	dbent "github.com/Wei-Shaw/sub2api/ent"
 	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
 	"github.com/stretchr/testify/require"
@@ -107,6 +108,18 @@ func (userSubRepoNoop) UpdateStatus(context.Context, int64, string) error {
 func (userSubRepoNoop) UpdateNotes(context.Context, int64, string) error {
 	panic("unexpected UpdateNotes call")
 }
+func (userSubRepoNoop) UpdateAutoRenew(context.Context, int64, bool) error {
+	panic("unexpected UpdateAutoRenew call")
+}
+func (userSubRepoNoop) UpdateAutoRenewFailedAt(context.Context, int64, *time.Time) error {
+	panic("unexpected UpdateAutoRenewFailedAt call")
+}
+func (userSubRepoNoop) ClaimAutoRenew(context.Context, int64, time.Time) (bool, error) {
+	panic("unexpected ClaimAutoRenew call")
+}
+func (userSubRepoNoop) ListAutoRenewDue(context.Context, time.Time, time.Time, int) ([]UserSubscription, error) {
+	panic("unexpected ListAutoRenewDue call")
+}
 func (userSubRepoNoop) ActivateWindows(context.Context, int64, time.Time) error {
 	panic("unexpected ActivateWindows call")
 }
@@ -380,6 +393,56 @@ func TestAssignSubscriptionGroupTypeValidation(t *testing.T) {
 	require.Equal(t, infraerrors.Code(ErrGroupNotSubscriptionType), infraerrors.Code(err))
 }
 
+// subscriptionTxRecordingRepoStub 记录续期写入与回读时上下文中的事务
+type subscriptionTxRecordingRepoStub struct {
+	*subscriptionUserSubRepoStub
+	writeTxs []*dbent.Tx
+	readTx   *dbent.Tx
+}
+
+func (s *subscriptionTxRecordingRepoStub) ExtendExpiry(ctx context.Context, id int64, expiresAt time.Time) error {
+	s.writeTxs = append(s.writeTxs, dbent.TxFromContext(ctx))
+	s.byID[id].ExpiresAt = expiresAt
+	return nil
+}
+
+func (s *subscriptionTxRecordingRepoStub) UpdateNotes(ctx context.Context, id int64, notes string) error {
+	s.writeTxs = append(s.writeTxs, dbent.TxFromContext(ctx))
+	s.byID[id].Notes = notes
+	return nil
+}
+
+func (s *subscriptionTxRecordingRepoStub) GetByID(ctx context.Context, id int64) (*UserSubscription, error) {
+	s.readTx = dbent.TxFromContext(ctx)
+	return s.subscriptionUserSubRepoStub.GetByID(ctx, id)
+}
+
+func TestAssignOrExtendSubscriptionUsesCallerTx(t *testing.T) {
+	expiresAt := time.Now().Add(24 * time.Hour)
+	groupRepo := &subscriptionGroupRepoStub{
+		group: &Group{ID: 1, SubscriptionType: SubscriptionTypeSubscription},
+	}
+	subRepo := &subscriptionTxRecordingRepoStub{subscriptionUserSubRepoStub: newSubscriptionUserSubRepoStub()}
+	subRepo.seed(&UserSubscription{ID: 10, UserID: 1001, GroupID: 1, Status: SubscriptionStatusActive, ExpiresAt: expiresAt})
+
+	// 调用方（如在线支付发放）已开启事务：续期写入与回读都应走该事务，不应自行开启/提交事务
+	callerTx := &dbent.Tx{}
+	ctx := dbent.NewTxContext(context.Background(), callerTx)
+	svc := NewSubscriptionService(groupRepo, subRepo, nil, nil, nil)
+
+	sub, extended, err := svc.AssignOrExtendSubscription(ctx, &AssignSubscriptionInput{
+		UserID:       1001,
+		GroupID:      1,
+		ValidityDays: 30,
+		Notes:        "order",
+	})
+	require.NoError(t, err)
+	require.True(t, extended)
+	require.Equal(t, []*dbent.Tx{callerTx, callerTx}, subRepo.writeTxs)
+	require.Same(t, callerTx, subRepo.readTx, "回读需在调用方事务内进行")
+	require.True(t, sub.ExpiresAt.Equal(expiresAt.AddDate(0, 0, 30)))
+}
+
 func strconvFormatInt(v int64) string {
 	return strconv.FormatInt(v, 10)
 }
@@ -7,18 +7,21 @@ import (
 	"time"
 )
 
-// SubscriptionExpiryService periodically updates expired subscription status.
+// SubscriptionExpiryService periodically renews auto-renew subscriptions that are
+// about to expire and updates expired subscription status.
 type SubscriptionExpiryService struct {
 	userSubRepo UserSubscriptionRepository
+	purchaseSvc *SubscriptionPurchaseService
 	interval    time.Duration
 	stopCh      chan struct{}
 	stopOnce    sync.Once
 	wg          sync.WaitGroup
 }
 
-func NewSubscriptionExpiryService(userSubRepo UserSubscriptionRepository, interval time.Duration) *SubscriptionExpiryService {
+func NewSubscriptionExpiryService(userSubRepo UserSubscriptionRepository, purchaseSvc *SubscriptionPurchaseService, interval time.Duration) *SubscriptionExpiryService {
 	return &SubscriptionExpiryService{
 		userSubRepo: userSubRepo,
+		purchaseSvc: purchaseSvc,
 		interval:    interval,
 		stopCh:      make(chan struct{}),
 	}
@@ -57,6 +60,9 @@ func (s *SubscriptionExpiryService) Stop() {
 }
 
 func (s *SubscriptionExpiryService) runOnce() {
+	// 先续订再标记过期，避免到期前未能续订的订阅在同一轮被置为过期
+	s.renewDue()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -69,3 +75,20 @@ func (s *SubscriptionExpiryService) runOnce() {
 		log.Printf("[SubscriptionExpiry] Updated %d expired subscriptions", updated)
 	}
 }
+
+func (s *SubscriptionExpiryService) renewDue() {
+	if s.purchaseSvc == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Second)
+	defer cancel()
+
+	renewed, err := s.purchaseSvc.RenewDue(ctx)
+	if err != nil {
+		log.Printf("[SubscriptionExpiry] Auto renew subscriptions failed: %v", err)
+		return
+	}
+	if renewed > 0 {
+		log.Printf("[SubscriptionExpiry] Auto renewed %d subscriptions", renewed)
+	}
+}
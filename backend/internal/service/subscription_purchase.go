@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 订阅购买记录类型
+const (
+	SubscriptionPurchaseKindPurchase = "purchase" // 用户主动购买
+	SubscriptionPurchaseKindRenewal  = "renewal"  // 到期前自动续订
+)
+
+// 订阅购买记录状态
+const (
+	SubscriptionPurchaseStatusCompleted = "completed" // 已扣费并发放订阅
+	SubscriptionPurchaseStatusFailed    = "failed"    // 自动续订失败（未扣费）
+)
+
+// ErrSubscriptionPlanNotFound 分组不存在、未启用、非订阅类型或未设置价格
+var ErrSubscriptionPlanNotFound = infraerrors.NotFound("SUBSCRIPTION_PLAN_NOT_FOUND", "subscription plan not found or not for sale")
+
+// SubscriptionPlan 可使用余额购买的订阅套餐（由设置了 subscription_price 的订阅分组生成）
+type SubscriptionPlan struct {
+	GroupID         int64
+	GroupName       string
+	Description     string
+	Platform        string
+	Price           float64
+	ValidityDays    int
+	DailyLimitUSD   *float64
+	WeeklyLimitUSD  *float64
+	MonthlyLimitUSD *float64
+}
+
+// SubscriptionPurchase 订阅购买/续订扣费记录
+type SubscriptionPurchase struct {
+	ID             int64
+	UserID         int64
+	GroupID        int64
+	SubscriptionID *int64
+	Kind           string
+	Status         string
+	Amount         float64
+	ValidityDays   int
+	// ExpiresAt 本次购买后订阅的到期时间，失败记录为空
+	ExpiresAt     *time.Time
+	FailureReason string
+	CreatedAt     time.Time
+
+	// GroupName 列表查询时关联填充
+	GroupName string
+}
+
+// SubscriptionPurchaseListFilters 购买记录筛选条件
+type SubscriptionPurchaseListFilters struct {
+	UserID  int64
+	GroupID int64
+	Kind    string
+	Status  string
+}
+
+// SubscriptionPurchaseRepository 购买记录持久化，Create 需支持通过 context 中的事务执行
+type SubscriptionPurchaseRepository interface {
+	Create(ctx context.Context, purchase *SubscriptionPurchase) error
+	List(ctx context.Context, params pagination.PaginationParams, filters SubscriptionPurchaseListFilters) ([]SubscriptionPurchase, *pagination.PaginationResult, error)
+}
@@ -0,0 +1,418 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	dbent "github.com/Wei-Shaw/sub2api/ent"
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+)
+
+// 自动续订失败原因（写入购买记录并用于通知邮件）
+const (
+	autoRenewFailureInsufficientBalance = "insufficient balance"
+	autoRenewFailurePlanUnavailable     = "subscription plan is no longer available"
+)
+
+// errAutoRenewClaimed 订阅已被其他实例续订，本轮跳过
+var errAutoRenewClaimed = errors.New("subscription already renewed")
+
+// SubscriptionPurchaseService 使用余额购买订阅、自动续订与购买记录
+type SubscriptionPurchaseService struct {
+	groupRepo            GroupRepository
+	userRepo             UserRepository
+	userSubRepo          UserSubscriptionRepository
+	purchaseRepo         SubscriptionPurchaseRepository
+	subscriptionService  *SubscriptionService
+	billingCacheService  *BillingCacheService
+	authCacheInvalidator APIKeyAuthCacheInvalidator
+	emailService         *EmailService
+	settingService       *SettingService
+	entClient            *dbent.Client
+	renewCfg             config.SubscriptionRenewalConfig
+}
+
+// NewSubscriptionPurchaseService 创建订阅购买服务
+func NewSubscriptionPurchaseService(
+	cfg *config.Config,
+	groupRepo GroupRepository,
+	userRepo UserRepository,
+	userSubRepo UserSubscriptionRepository,
+	purchaseRepo SubscriptionPurchaseRepository,
+	subscriptionService *SubscriptionService,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	emailService *EmailService,
+	settingService *SettingService,
+	entClient *dbent.Client,
+) *SubscriptionPurchaseService {
+	var renewCfg config.SubscriptionRenewalConfig
+	if cfg != nil {
+		renewCfg = cfg.SubscriptionRenewal
+	}
+	return &SubscriptionPurchaseService{
+		groupRepo:            groupRepo,
+		userRepo:             userRepo,
+		userSubRepo:          userSubRepo,
+		purchaseRepo:         purchaseRepo,
+		subscriptionService:  subscriptionService,
+		billingCacheService:  billingCacheService,
+		authCacheInvalidator: authCacheInvalidator,
+		emailService:         emailService,
+		settingService:       settingService,
+		entClient:            entClient,
+		renewCfg:             renewCfg,
+	}
+}
+
+// planFromGroup 由分组生成套餐；分组未启用、非订阅类型或未设置价格时返回 false
+func planFromGroup(group *Group) (*SubscriptionPlan, bool) {
+	if group == nil || !group.IsActive() || !group.IsSubscriptionType() || group.SubscriptionPrice == nil {
+		return nil, false
+	}
+	validityDays := group.DefaultValidityDays
+	if validityDays <= 0 {
+		validityDays = 30
+	}
+	if validityDays > MaxValidityDays {
+		validityDays = MaxValidityDays
+	}
+	return &SubscriptionPlan{
+		GroupID:         group.ID,
+		GroupName:       group.Name,
+		Description:     group.Description,
+		Platform:        group.Platform,
+		Price:           *group.SubscriptionPrice,
+		ValidityDays:    validityDays,
+		DailyLimitUSD:   group.DailyLimitUSD,
+		WeeklyLimitUSD:  group.WeeklyLimitUSD,
+		MonthlyLimitUSD: group.MonthlyLimitUSD,
+	}, true
+}
+
+// ListPlans 返回可使用余额购买的订阅套餐
+func (s *SubscriptionPurchaseService) ListPlans(ctx context.Context) ([]SubscriptionPlan, error) {
+	groups, err := s.groupRepo.ListActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list active groups: %w", err)
+	}
+	plans := make([]SubscriptionPlan, 0, len(groups))
+	for i := range groups {
+		if plan, ok := planFromGroup(&groups[i]); ok {
+			plans = append(plans, *plan)
+		}
+	}
+	return plans, nil
+}
+
+// Purchase 从余额扣费购买（或续期）订阅，autoRenew 同时设置该订阅的自动续订开关
+func (s *SubscriptionPurchaseService) Purchase(ctx context.Context, userID, groupID int64, autoRenew bool) (*SubscriptionPurchase, error) {
+	group, err := s.groupRepo.GetByID(ctx, groupID)
+	if err != nil {
+		if errors.Is(err, ErrGroupNotFound) {
+			return nil, ErrSubscriptionPlanNotFound
+		}
+		return nil, fmt.Errorf("get group: %w", err)
+	}
+	plan, ok := planFromGroup(group)
+	if !ok {
+		return nil, ErrSubscriptionPlanNotFound
+	}
+	return s.charge(ctx, userID, plan, SubscriptionPurchaseKindPurchase, autoRenew, nil)
+}
+
+// charge 在同一事务中扣除余额、发放订阅、设置自动续订并写入购买记录。
+// renewing 非空时为自动续订，先以到期时间未变化为条件锁定订阅，防止多实例重复扣费。
+func (s *SubscriptionPurchaseService) charge(ctx context.Context, userID int64, plan *SubscriptionPlan, kind string, autoRenew bool, renewing *UserSubscription) (*SubscriptionPurchase, error) {
+	opCtx := ctx
+	var tx *dbent.Tx
+	if s.entClient != nil {
+		var err error
+		tx, err = s.entClient.Tx(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("begin transaction: %w", err)
+		}
+		defer func() { _ = tx.Rollback() }()
+		opCtx = dbent.NewTxContext(ctx, tx)
+	}
+
+	if renewing != nil {
+		claimed, err := s.userSubRepo.ClaimAutoRenew(opCtx, renewing.ID, renewing.ExpiresAt)
+		if err != nil {
+			return nil, fmt.Errorf("claim subscription renewal: %w", err)
+		}
+		if !claimed {
+			return nil, errAutoRenewClaimed
+		}
+	}
+
+	// 【关键】条件扣减：余额不足时不扣费，避免并发购买把余额扣成负数
+	deducted, err := s.userRepo.DeductBalanceIfSufficient(opCtx, userID, plan.Price)
+	if err != nil {
+		return nil, fmt.Errorf("deduct balance: %w", err)
+	}
+	if !deducted {
+		return nil, ErrInsufficientBalance
+	}
+
+	notes := "余额购买订阅"
+	if kind == SubscriptionPurchaseKindRenewal {
+		notes = "余额自动续订"
+	}
+	sub, _, err := s.subscriptionService.AssignOrExtendSubscription(opCtx, &AssignSubscriptionInput{
+		UserID:       userID,
+		GroupID:      plan.GroupID,
+		ValidityDays: plan.ValidityDays,
+		AssignedBy:   0, // 系统分配
+		Notes:        notes,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("assign or extend subscription: %w", err)
+	}
+	if err := s.userSubRepo.UpdateAutoRenew(opCtx, sub.ID, autoRenew); err != nil {
+		return nil, fmt.Errorf("update auto renew: %w", err)
+	}
+
+	subscriptionID := sub.ID
+	expiresAt := sub.ExpiresAt
+	purchase := &SubscriptionPurchase{
+		UserID:         userID,
+		GroupID:        plan.GroupID,
+		SubscriptionID: &subscriptionID,
+		Kind:           kind,
+		Status:         SubscriptionPurchaseStatusCompleted,
+		Amount:         plan.Price,
+		ValidityDays:   plan.ValidityDays,
+		ExpiresAt:      &expiresAt,
+		GroupName:      plan.GroupName,
+	}
+	if err := s.purchaseRepo.Create(opCtx, purchase); err != nil {
+		return nil, fmt.Errorf("create purchase record: %w", err)
+	}
+
+	if tx != nil {
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("commit transaction: %w", err)
+		}
+	}
+	logger.LegacyPrintf("service.subscription_purchase", "subscription charged: user_id=%d group_id=%d kind=%s amount=%.8f expires_at=%s",
+		userID, plan.GroupID, kind, plan.Price, expiresAt.Format(time.RFC3339))
+
+	s.invalidateCaches(ctx, userID, plan.GroupID)
+	return purchase, nil
+}
+
+// invalidateCaches 事务提交后失效鉴权、余额与订阅缓存
+func (s *SubscriptionPurchaseService) invalidateCaches(ctx context.Context, userID, groupID int64) {
+	if s.authCacheInvalidator != nil {
+		s.authCacheInvalidator.InvalidateAuthCacheByUserID(ctx, userID)
+	}
+	if s.subscriptionService != nil {
+		s.subscriptionService.InvalidateSubCache(userID, groupID)
+	}
+	if s.billingCacheService == nil {
+		return
+	}
+	go func() {
+		cacheCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.billingCacheService.InvalidateUserBalance(cacheCtx, userID)
+		_ = s.billingCacheService.InvalidateSubscription(cacheCtx, userID, groupID)
+	}()
+}
+
+// SetAutoRenew 开关当前用户某个订阅的自动续订；开启时要求对应分组仍可购买
+func (s *SubscriptionPurchaseService) SetAutoRenew(ctx context.Context, userID, subscriptionID int64, autoRenew bool) (*UserSubscription, error) {
+	sub, err := s.userSubRepo.GetByID(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if sub.UserID != userID {
+		return nil, ErrSubscriptionNotFound
+	}
+	if autoRenew {
+		group, err := s.groupRepo.GetByID(ctx, sub.GroupID)
+		if err != nil && !errors.Is(err, ErrGroupNotFound) {
+			return nil, fmt.Errorf("get group: %w", err)
+		}
+		if _, ok := planFromGroup(group); !ok {
+			return nil, ErrSubscriptionPlanNotFound
+		}
+	}
+	if err := s.userSubRepo.UpdateAutoRenew(ctx, sub.ID, autoRenew); err != nil {
+		return nil, err
+	}
+	return s.userSubRepo.GetByID(ctx, sub.ID)
+}
+
+// ListUserPurchases 返回用户自己的购买记录
+func (s *SubscriptionPurchaseService) ListUserPurchases(ctx context.Context, userID int64, params pagination.PaginationParams) ([]SubscriptionPurchase, *pagination.PaginationResult, error) {
+	return s.purchaseRepo.List(ctx, params, SubscriptionPurchaseListFilters{UserID: userID})
+}
+
+// ListPurchases 管理端查询购买记录
+func (s *SubscriptionPurchaseService) ListPurchases(ctx context.Context, params pagination.PaginationParams, filters SubscriptionPurchaseListFilters) ([]SubscriptionPurchase, *pagination.PaginationResult, error) {
+	return s.purchaseRepo.List(ctx, params, filters)
+}
+
+// RenewDue 为即将到期且开启自动续订的订阅扣费续订，返回成功续订的数量。
+// 续订失败（余额不足、套餐下架）时记录失败时间，按 retry_interval_minutes 重试；
+// 同一到期周期内只在首次失败时写入失败记录并发送邮件通知。
+func (s *SubscriptionPurchaseService) RenewDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	dueBefore := now.Add(time.Duration(s.renewCfg.RenewBeforeHours) * time.Hour)
+	retryBefore := now.Add(-time.Duration(s.renewCfg.RetryIntervalMinutes) * time.Minute)
+	limit := s.renewCfg.BatchSize
+	if limit <= 0 {
+		limit = 100
+	}
+
+	subs, err := s.userSubRepo.ListAutoRenewDue(ctx, dueBefore, retryBefore, limit)
+	if err != nil {
+		return 0, fmt.Errorf("list auto renew due subscriptions: %w", err)
+	}
+
+	renewed := 0
+	for i := range subs {
+		if ctx.Err() != nil {
+			break
+		}
+		ok, err := s.renewOne(ctx, &subs[i], now)
+		if err != nil {
+			logger.LegacyPrintf("service.subscription_purchase", "auto renew failed: subscription_id=%d user_id=%d err=%v",
+				subs[i].ID, subs[i].UserID, err)
+			continue
+		}
+		if ok {
+			renewed++
+		}
+	}
+	return renewed, nil
+}
+
+// renewOne 续订单个订阅；业务原因导致的失败会被记录并通知用户，返回 (false, nil)
+func (s *SubscriptionPurchaseService) renewOne(ctx context.Context, sub *UserSubscription, now time.Time) (bool, error) {
+	group := sub.Group
+	if group == nil {
+		g, err := s.groupRepo.GetByID(ctx, sub.GroupID)
+		if err != nil && !errors.Is(err, ErrGroupNotFound) {
+			return false, fmt.Errorf("get group: %w", err)
+		}
+		group = g
+	}
+
+	plan, ok := planFromGroup(group)
+	if !ok {
+		return false, s.recordRenewFailure(ctx, sub, group, now, autoRenewFailurePlanUnavailable)
+	}
+
+	_, err := s.charge(ctx, sub.UserID, plan, SubscriptionPurchaseKindRenewal, true, sub)
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, errAutoRenewClaimed):
+		return false, nil
+	case errors.Is(err, ErrInsufficientBalance):
+		return false, s.recordRenewFailure(ctx, sub, group, now, autoRenewFailureInsufficientBalance)
+	default:
+		return false, err
+	}
+}
+
+// recordRenewFailure 标记续订失败；首次失败时写入失败记录并发送邮件
+func (s *SubscriptionPurchaseService) recordRenewFailure(ctx context.Context, sub *UserSubscription, group *Group, now time.Time, reason string) error {
+	if err := s.userSubRepo.UpdateAutoRenewFailedAt(ctx, sub.ID, &now); err != nil {
+		return fmt.Errorf("mark auto renew failed: %w", err)
+	}
+	if sub.AutoRenewFailedAt != nil {
+		return nil
+	}
+
+	var amount float64
+	if group != nil && group.SubscriptionPrice != nil {
+		amount = *group.SubscriptionPrice
+	}
+	subscriptionID := sub.ID
+	record := &SubscriptionPurchase{
+		UserID:         sub.UserID,
+		GroupID:        sub.GroupID,
+		SubscriptionID: &subscriptionID,
+		Kind:           SubscriptionPurchaseKindRenewal,
+		Status:         SubscriptionPurchaseStatusFailed,
+		Amount:         amount,
+		FailureReason:  reason,
+	}
+	if err := s.purchaseRepo.Create(ctx, record); err != nil {
+		logger.LegacyPrintf("service.subscription_purchase", "failed to create renewal failure record: subscription_id=%d err=%v", sub.ID, err)
+	}
+	s.sendRenewFailedEmail(ctx, sub, group, amount, reason)
+	return nil
+}
+
+// sendRenewFailedEmail 通知用户自动续订失败（尽力而为，失败只记录日志）
+func (s *SubscriptionPurchaseService) sendRenewFailedEmail(ctx context.Context, sub *UserSubscription, group *Group, amount float64, reason string) {
+	if s.emailService == nil || sub.User == nil || strings.TrimSpace(sub.User.Email) == "" {
+		return
+	}
+	siteName := "Sub2API"
+	if s.settingService != nil {
+		siteName = s.settingService.GetSiteName(ctx)
+	}
+	groupName := fmt.Sprintf("#%d", sub.GroupID)
+	if group != nil && group.Name != "" {
+		groupName = group.Name
+	}
+
+	subject := fmt.Sprintf("[%s] Subscription auto-renewal failed", siteName)
+	body := buildAutoRenewFailedEmailBody(siteName, groupName, sub.ExpiresAt, amount, reason)
+	if err := s.emailService.SendEmail(ctx, sub.User.Email, subject, body); err != nil {
+		logger.LegacyPrintf("service.subscription_purchase", "failed to send auto renew failure email: subscription_id=%d err=%v", sub.ID, err)
+	}
+}
+
+// buildAutoRenewFailedEmailBody 构建自动续订失败通知邮件HTML内容
+func buildAutoRenewFailedEmailBody(siteName, groupName string, expiresAt time.Time, amount float64, reason string) string {
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <style>
+        body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, sans-serif; background-color: #f5f5f5; margin: 0; padding: 20px; }
+        .container { max-width: 600px; margin: 0 auto; background-color: #ffffff; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 8px rgba(0,0,0,0.1); }
+        .header { background: linear-gradient(135deg, #667eea 0%%, #764ba2 100%%); color: white; padding: 30px; text-align: center; }
+        .header h1 { margin: 0; font-size: 24px; }
+        .content { padding: 40px 30px; color: #333; }
+        .info { color: #666; font-size: 14px; line-height: 1.6; margin-top: 20px; }
+        .footer { background-color: #f8f9fa; padding: 20px; text-align: center; color: #999; font-size: 12px; }
+    </style>
+</head>
+<body>
+    <div class="container">
+        <div class="header">
+            <h1>%s</h1>
+        </div>
+        <div class="content">
+            <p style="font-size: 18px;">We could not auto-renew your subscription <strong>%s</strong>.</p>
+            <p>Reason: %s</p>
+            <p>Renewal price: $%.2f</p>
+            <p>Current expiry: %s</p>
+            <div class="info">
+                <p>We will keep retrying until the subscription expires. Top up your balance or renew it manually to keep access.</p>
+                <p>You can turn off auto-renewal in your subscription settings.</p>
+            </div>
+        </div>
+        <div class="footer">
+            <p>This is an automated message, please do not reply.</p>
+        </div>
+    </div>
+</body>
+</html>
+`, html.EscapeString(siteName), html.EscapeString(groupName), html.EscapeString(reason), amount, expiresAt.UTC().Format("2006-01-02 15:04 UTC"))
+}
@@ -0,0 +1,259 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/pagination"
+	"github.com/stretchr/testify/require"
+)
+
+type purchaseGroupRepoStub struct {
+	groupRepoNoop
+	groups map[int64]*Group
+}
+
+func (r *purchaseGroupRepoStub) GetByID(_ context.Context, id int64) (*Group, error) {
+	g, ok := r.groups[id]
+	if !ok {
+		return nil, ErrGroupNotFound
+	}
+	cp := *g
+	return &cp, nil
+}
+
+func (r *purchaseGroupRepoStub) ListActive(context.Context) ([]Group, error) {
+	out := make([]Group, 0, len(r.groups))
+	for id := int64(1); id <= int64(len(r.groups)); id++ {
+		if g, ok := r.groups[id]; ok && g.IsActive() {
+			out = append(out, *g)
+		}
+	}
+	return out, nil
+}
+
+type purchaseUserRepoStub struct {
+	mockUserRepo
+	mu      sync.Mutex
+	balance float64
+}
+
+func (r *purchaseUserRepoStub) DeductBalanceIfSufficient(_ context.Context, _ int64, amount float64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.balance < amount {
+		return false, nil
+	}
+	r.balance -= amount
+	return true, nil
+}
+
+type purchaseUserSubRepoStub struct {
+	userSubRepoNoop
+	nextID int64
+	subs   map[int64]*UserSubscription
+}
+
+func newPurchaseUserSubRepoStub() *purchaseUserSubRepoStub {
+	return &purchaseUserSubRepoStub{nextID: 1, subs: map[int64]*UserSubscription{}}
+}
+
+func (r *purchaseUserSubRepoStub) Create(_ context.Context, sub *UserSubscription) error {
+	sub.ID = r.nextID
+	r.nextID++
+	cp := *sub
+	r.subs[sub.ID] = &cp
+	return nil
+}
+
+func (r *purchaseUserSubRepoStub) GetByID(_ context.Context, id int64) (*UserSubscription, error) {
+	sub, ok := r.subs[id]
+	if !ok {
+		return nil, ErrSubscriptionNotFound
+	}
+	cp := *sub
+	return &cp, nil
+}
+
+func (r *purchaseUserSubRepoStub) GetByUserIDAndGroupID(_ context.Context, userID, groupID int64) (*UserSubscription, error) {
+	for _, sub := range r.subs {
+		if sub.UserID == userID && sub.GroupID == groupID {
+			cp := *sub
+			return &cp, nil
+		}
+	}
+	return nil, ErrSubscriptionNotFound
+}
+
+func (r *purchaseUserSubRepoStub) UpdateAutoRenew(_ context.Context, id int64, autoRenew bool) error {
+	r.subs[id].AutoRenew = autoRenew
+	r.subs[id].AutoRenewFailedAt = nil
+	return nil
+}
+
+func (r *purchaseUserSubRepoStub) UpdateAutoRenewFailedAt(_ context.Context, id int64, failedAt *time.Time) error {
+	r.subs[id].AutoRenewFailedAt = failedAt
+	return nil
+}
+
+func (r *purchaseUserSubRepoStub) ClaimAutoRenew(_ context.Context, id int64, expiresAt time.Time) (bool, error) {
+	sub := r.subs[id]
+	return sub.AutoRenew && sub.ExpiresAt.Equal(expiresAt), nil
+}
+
+func (r *purchaseUserSubRepoStub) ListAutoRenewDue(context.Context, time.Time, time.Time, int) ([]UserSubscription, error) {
+	out := []UserSubscription{}
+	for _, sub := range r.subs {
+		if sub.AutoRenew {
+			out = append(out, *sub)
+		}
+	}
+	return out, nil
+}
+
+type purchaseRecordRepoStub struct {
+	records []SubscriptionPurchase
+}
+
+func (r *purchaseRecordRepoStub) Create(_ context.Context, p *SubscriptionPurchase) error {
+	p.ID = int64(len(r.records) + 1)
+	p.CreatedAt = time.Now()
+	r.records = append(r.records, *p)
+	return nil
+}
+
+func (r *purchaseRecordRepoStub) List(context.Context, pagination.PaginationParams, SubscriptionPurchaseListFilters) ([]SubscriptionPurchase, *pagination.PaginationResult, error) {
+	return r.records, &pagination.PaginationResult{Total: int64(len(r.records))}, nil
+}
+
+func newTestSubscriptionPurchaseService(balance float64) (*SubscriptionPurchaseService, *purchaseGroupRepoStub, *purchaseUserRepoStub, *purchaseUserSubRepoStub, *purchaseRecordRepoStub) {
+	groupRepo := &purchaseGroupRepoStub{groups: map[int64]*Group{
+		1: {ID: 1, Name: "Pro", Status: StatusActive, SubscriptionType: SubscriptionTypeSubscription, DefaultValidityDays: 90, SubscriptionPrice: float64Ptr(20)},
+		2: {ID: 2, Name: "Internal", Status: StatusActive, SubscriptionType: SubscriptionTypeSubscription},
+		3: {ID: 3, Name: "Standard", Status: StatusActive, SubscriptionType: SubscriptionTypeStandard, SubscriptionPrice: float64Ptr(5)},
+		4: {ID: 4, Name: "Retired", Status: StatusDisabled, SubscriptionType: SubscriptionTypeSubscription, SubscriptionPrice: float64Ptr(5)},
+	}}
+	userRepo := &purchaseUserRepoStub{balance: balance}
+	userSubRepo := newPurchaseUserSubRepoStub()
+	recordRepo := &purchaseRecordRepoStub{}
+	cfg := &config.Config{SubscriptionRenewal: config.SubscriptionRenewalConfig{RenewBeforeHours: 24, RetryIntervalMinutes: 60, BatchSize: 100}}
+	subscriptionService := NewSubscriptionService(groupRepo, userSubRepo, nil, nil, nil)
+	svc := NewSubscriptionPurchaseService(cfg, groupRepo, userRepo, userSubRepo, recordRepo, subscriptionService, nil, nil, nil, nil, nil)
+	return svc, groupRepo, userRepo, userSubRepo, recordRepo
+}
+
+func TestSubscriptionPurchaseService_ListPlansOnlyPricedSubscriptionGroups(t *testing.T) {
+	svc, _, _, _, _ := newTestSubscriptionPurchaseService(0)
+
+	plans, err := svc.ListPlans(context.Background())
+	require.NoError(t, err)
+	require.Len(t, plans, 1)
+	require.Equal(t, int64(1), plans[0].GroupID)
+	require.Equal(t, 20.0, plans[0].Price)
+	require.Equal(t, 90, plans[0].ValidityDays)
+}
+
+func TestSubscriptionPurchaseService_PurchaseDebitsBalanceAndRecords(t *testing.T) {
+	svc, _, userRepo, userSubRepo, recordRepo := newTestSubscriptionPurchaseService(50)
+
+	purchase, err := svc.Purchase(context.Background(), 7, 1, true)
+	require.NoError(t, err)
+	require.Equal(t, 30.0, userRepo.balance)
+	require.Equal(t, SubscriptionPurchaseKindPurchase, purchase.Kind)
+	require.Equal(t, SubscriptionPurchaseStatusCompleted, purchase.Status)
+	require.Equal(t, 20.0, purchase.Amount)
+	require.NotNil(t, purchase.SubscriptionID)
+	require.NotNil(t, purchase.ExpiresAt)
+	require.WithinDuration(t, time.Now().AddDate(0, 0, 90), *purchase.ExpiresAt, time.Minute)
+	require.Len(t, recordRepo.records, 1)
+
+	sub := userSubRepo.subs[*purchase.SubscriptionID]
+	require.Equal(t, int64(7), sub.UserID)
+	require.True(t, sub.AutoRenew)
+}
+
+func TestSubscriptionPurchaseService_PurchaseRejectsUnavailablePlansAndLowBalance(t *testing.T) {
+	svc, _, userRepo, userSubRepo, recordRepo := newTestSubscriptionPurchaseService(10)
+	ctx := context.Background()
+
+	for _, groupID := range []int64{2, 3, 4, 99} {
+		_, err := svc.Purchase(ctx, 7, groupID, false)
+		require.ErrorIs(t, err, ErrSubscriptionPlanNotFound, "group %d", groupID)
+	}
+
+	_, err := svc.Purchase(ctx, 7, 1, false)
+	require.ErrorIs(t, err, ErrInsufficientBalance)
+	require.Equal(t, 10.0, userRepo.balance)
+	require.Empty(t, userSubRepo.subs)
+	require.Empty(t, recordRepo.records)
+}
+
+func TestSubscriptionPurchaseService_SetAutoRenew(t *testing.T) {
+	svc, groupRepo, _, userSubRepo, _ := newTestSubscriptionPurchaseService(0)
+	ctx := context.Background()
+	require.NoError(t, userSubRepo.Create(ctx, &UserSubscription{UserID: 7, GroupID: 1, Status: SubscriptionStatusActive}))
+
+	_, err := svc.SetAutoRenew(ctx, 8, 1, true)
+	require.ErrorIs(t, err, ErrSubscriptionNotFound, "不能修改他人的订阅")
+
+	sub, err := svc.SetAutoRenew(ctx, 7, 1, true)
+	require.NoError(t, err)
+	require.True(t, sub.AutoRenew)
+
+	// 分组下架后不能再开启，但仍可关闭
+	groupRepo.groups[1].SubscriptionPrice = nil
+	_, err = svc.SetAutoRenew(ctx, 7, 1, true)
+	require.ErrorIs(t, err, ErrSubscriptionPlanNotFound)
+	sub, err = svc.SetAutoRenew(ctx, 7, 1, false)
+	require.NoError(t, err)
+	require.False(t, sub.AutoRenew)
+}
+
+func TestSubscriptionPurchaseService_RenewFailureRecordedOncePerCycle(t *testing.T) {
+	svc, _, userRepo, userSubRepo, recordRepo := newTestSubscriptionPurchaseService(5)
+	ctx := context.Background()
+	expiresAt := time.Now().Add(2 * time.Hour)
+	require.NoError(t, userSubRepo.Create(ctx, &UserSubscription{
+		UserID: 7, GroupID: 1, Status: SubscriptionStatusActive, ExpiresAt: expiresAt, AutoRenew: true,
+	}))
+
+	renewed, err := svc.RenewDue(ctx)
+	require.NoError(t, err)
+	require.Zero(t, renewed)
+	require.NotNil(t, userSubRepo.subs[1].AutoRenewFailedAt)
+	require.Len(t, recordRepo.records, 1)
+	require.Equal(t, SubscriptionPurchaseKindRenewal, recordRepo.records[0].Kind)
+	require.Equal(t, SubscriptionPurchaseStatusFailed, recordRepo.records[0].Status)
+	require.Equal(t, autoRenewFailureInsufficientBalance, recordRepo.records[0].FailureReason)
+	require.Equal(t, 20.0, recordRepo.records[0].Amount)
+
+	// 重试仍失败：更新失败时间但不重复写记录
+	renewed, err = svc.RenewDue(ctx)
+	require.NoError(t, err)
+	require.Zero(t, renewed)
+	require.Len(t, recordRepo.records, 1)
+	require.Equal(t, 5.0, userRepo.balance)
+	require.True(t, userSubRepo.subs[1].ExpiresAt.Equal(expiresAt))
+}
+
+func TestSubscriptionPurchaseService_RenewFailsWhenPlanWithdrawn(t *testing.T) {
+	svc, groupRepo, userRepo, userSubRepo, recordRepo := newTestSubscriptionPurchaseService(100)
+	ctx := context.Background()
+	groupRepo.groups[1].SubscriptionPrice = nil
+	require.NoError(t, userSubRepo.Create(ctx, &UserSubscription{
+		UserID: 7, GroupID: 1, Status: SubscriptionStatusActive, ExpiresAt: time.Now().Add(time.Hour), AutoRenew: true,
+	}))
+
+	renewed, err := svc.RenewDue(ctx)
+	require.NoError(t, err)
+	require.Zero(t, renewed)
+	require.Equal(t, 100.0, userRepo.balance)
+	require.Len(t, recordRepo.records, 1)
+	require.Equal(t, autoRenewFailurePlanUnavailable, recordRepo.records[0].FailureReason)
+	require.Zero(t, recordRepo.records[0].Amount)
+}
@@ -209,23 +209,33 @@ func (s *SubscriptionService) AssignOrExtendSubscription(ctx context.Context, in
 			newExpiresAt = MaxExpiresAt
 		}
 
-		// 开启事务：ExtendExpiry + UpdateStatus + UpdateNotes 在同一事务中完成
-		tx, err := s.entClient.Tx(ctx)
-		if err != nil {
-			return nil, false, fmt.Errorf("begin transaction: %w", err)
+		// 开启事务：ExtendExpiry + UpdateStatus + UpdateNotes 在同一事务中完成。
+		// 调用方已开启事务（兑换码核销、在线支付、余额购买）时直接复用，由调用方统一提交或回滚。
+		txCtx := ctx
+		var tx *dbent.Tx
+		if dbent.TxFromContext(ctx) == nil && s.entClient != nil {
+			tx, err = s.entClient.Tx(ctx)
+			if err != nil {
+				return nil, false, fmt.Errorf("begin transaction: %w", err)
+			}
+			txCtx = dbent.NewTxContext(ctx, tx)
+		}
+		rollback := func() {
+			if tx != nil {
+				_ = tx.Rollback()
+			}
 		}
-		txCtx := dbent.NewTxContext(ctx, tx)
 
 		// 更新过期时间
 		if err := s.userSubRepo.ExtendExpiry(txCtx, existingSub.ID, newExpiresAt); err != nil {
-			_ = tx.Rollback()
+			rollback()
 			return nil, false, fmt.Errorf("extend subscription: %w", err)
 		}
 
 		// 如果订阅已过期或被暂停，恢复为active状态
 		if existingSub.Status != SubscriptionStatusActive {
 			if err := s.userSubRepo.UpdateStatus(txCtx, existingSub.ID, SubscriptionStatusActive); err != nil {
-				_ = tx.Rollback()
+				rollback()
 				return nil, false, fmt.Errorf("update subscription status: %w", err)
 			}
 		}
@@ -238,14 +248,16 @@ func (s *SubscriptionService) AssignOrExtendSubscription(ctx context.Context, in
 			}
 			newNotes += input.Notes
 			if err := s.userSubRepo.UpdateNotes(txCtx, existingSub.ID, newNotes); err != nil {
-				_ = tx.Rollback()
+				rollback()
 				return nil, false, fmt.Errorf("update subscription notes: %w", err)
 			}
 		}
 
 		// 提交事务
-		if err := tx.Commit(); err != nil {
-			return nil, false, fmt.Errorf("commit transaction: %w", err)
+		if tx != nil {
+			if err := tx.Commit(); err != nil {
+				return nil, false, fmt.Errorf("commit transaction: %w", err)
+			}
 		}
 
 		// 失效订阅缓存
@@ -260,7 +272,8 @@ func (s *SubscriptionService) AssignOrExtendSubscription(ctx context.Context, in
 		}
 
 		// 返回更新后的订阅
-		sub, err := s.userSubRepo.GetByID(ctx, existingSub.ID)
+		// 复用调用方事务时需在同一事务内读取，才能看到尚未提交的续期结果
+		sub, err := s.userSubRepo.GetByID(txCtx, existingSub.ID)
 		return sub, true, err // true 表示是续期
 	}
 
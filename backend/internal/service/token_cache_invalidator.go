@@ -24,6 +24,13 @@ func (c *CompositeTokenCacheInvalidator) InvalidateToken(ctx context.Context, ac
 	if c == nil || c.cache == nil || account == nil {
 		return nil
 	}
+	if account.IsVertex() {
+		// Vertex 的 access token 由 Service Account 签发，凭证变更后必须丢弃旧 token
+		if err := c.cache.DeleteAccessToken(ctx, VertexTokenCacheKey(account)); err != nil {
+			slog.Warn("token_cache_delete_failed", "key", VertexTokenCacheKey(account), "account_id", account.ID, "error", err)
+		}
+		return nil
+	}
 	if account.Type != AccountTypeOAuth {
 		return nil
 	}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"github.com/Wei-Shaw/sub2api/internal/pkg/tokenestimate"
+	"github.com/tidwall/gjson"
+)
+
+// 本地 token 计数的固定开销估算（与上游 count_tokens 结果对齐的经验值）
+const (
+	localCountRequestOverhead   = 3    // 请求级固定开销（assistant 回复前缀）
+	localCountMessageOverhead   = 3    // 每条消息的角色/分隔符开销
+	localCountToolOverhead      = 8    // 每个工具定义的结构开销
+	localCountToolSystemPrompt  = 346  // Anthropic 启用 tools 时注入的系统提示词
+	localCountAnthropicImage    = 1600 // 未知尺寸图片按约 1.15MP 估算
+	localCountGeminiImage       = 258  // Gemini 每张图片固定 258 tokens
+	localCountAnthropicDocument = 1500 // 未知页数 PDF 文档
+)
+
+// EstimateAnthropicCountTokens estimates the input tokens of an Anthropic
+// Messages request body without calling upstream. The result is returned as
+// /v1/messages/count_tokens input_tokens.
+func EstimateAnthropicCountTokens(body []byte, model string) int {
+	family := tokenestimate.FamilyForModel(model)
+	total := localCountRequestOverhead
+
+	system := gjson.GetBytes(body, "system")
+	if system.Type == gjson.String {
+		total += tokenestimate.Estimate(family, system.String())
+	} else {
+		system.ForEach(func(_, block gjson.Result) bool {
+			total += countAnthropicBlock(family, block)
+			return true
+		})
+	}
+
+	gjson.GetBytes(body, "messages").ForEach(func(_, msg gjson.Result) bool {
+		total += localCountMessageOverhead
+		content := msg.Get("content")
+		if content.Type == gjson.String {
+			total += tokenestimate.Estimate(family, content.String())
+			return true
+		}
+		content.ForEach(func(_, block gjson.Result) bool {
+			total += countAnthropicBlock(family, block)
+			return true
+		})
+		return true
+	})
+
+	tools := gjson.GetBytes(body, "tools").Array()
+	if len(tools) > 0 {
+		total += localCountToolSystemPrompt
+	}
+	for _, tool := range tools {
+		total += localCountToolOverhead
+		total += tokenestimate.Estimate(family, tool.Get("name").String())
+		total += tokenestimate.Estimate(family, tool.Get("description").String())
+		if schema := tool.Get("input_schema"); schema.Exists() {
+			total += tokenestimate.Estimate(family, schema.Raw)
+		}
+	}
+	return total
+}
+
+func countAnthropicBlock(family tokenestimate.Family, block gjson.Result) int {
+	switch block.Get("type").String() {
+	case "text":
+		return tokenestimate.Estimate(family, block.Get("text").String())
+	case "tool_use":
+		return localCountToolOverhead + tokenestimate.Estimate(family, block.Get("name").String()) +
+			tokenestimate.Estimate(family, block.Get("input").Raw)
+	case "tool_result":
+		content := block.Get("content")
+		if content.Type == gjson.String {
+			return localCountToolOverhead + tokenestimate.Estimate(family, content.String())
+		}
+		n := localCountToolOverhead
+		content.ForEach(func(_, inner gjson.Result) bool {
+			n += countAnthropicBlock(family, inner)
+			return true
+		})
+		return n
+	case "image":
+		return localCountAnthropicImage
+	case "document":
+		if block.Get("source.type").String() == "text" {
+			return tokenestimate.Estimate(family, block.Get("source.data").String())
+		}
+		return localCountAnthropicDocument
+	default:
+		// thinking / redacted_thinking 等历史推理块不计入输入
+		return 0
+	}
+}
+
+// EstimateGeminiCompatCountTokens estimates the input tokens of a Gemini
+// generateContent or countTokens request body without calling upstream.
+// countTokens bodies may wrap the request in generateContentRequest.
+// It is the only local estimator for Gemini countTokens, shared by Gemini
+// groups with local counting enabled and the non-Gemini compat routes.
+func EstimateGeminiCompatCountTokens(body []byte, model string) int {
+	if inner := gjson.GetBytes(body, "generateContentRequest"); inner.IsObject() {
+		body = []byte(inner.Raw)
+	}
+	family := tokenestimate.FamilyForModel(model)
+	total := 0
+
+	countParts := func(parts gjson.Result) {
+		parts.ForEach(func(_, part gjson.Result) bool {
+			switch {
+			case part.Get("text").Exists():
+				if !part.Get("thought").Bool() {
+					total += tokenestimate.Estimate(family, part.Get("text").String())
+				}
+			case part.Get("inlineData").Exists(), part.Get("fileData").Exists():
+				total += localCountGeminiImage
+			case part.Get("functionCall").Exists():
+				total += tokenestimate.Estimate(family, part.Get("functionCall.name").String()) +
+					tokenestimate.Estimate(family, part.Get("functionCall.args").Raw)
+			case part.Get("functionResponse").Exists():
+				total += tokenestimate.Estimate(family, part.Get("functionResponse.name").String()) +
+					tokenestimate.Estimate(family, part.Get("functionResponse.response").Raw)
+			}
+			return true
+		})
+	}
+
+	countParts(gjson.GetBytes(body, "systemInstruction.parts"))
+	gjson.GetBytes(body, "contents").ForEach(func(_, content gjson.Result) bool {
+		countParts(content.Get("parts"))
+		return true
+	})
+	gjson.GetBytes(body, "tools.#.functionDeclarations|@flatten").ForEach(func(_, decl gjson.Result) bool {
+		total += tokenestimate.Estimate(family, decl.Raw)
+		return true
+	})
+	return total
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimateAnthropicCountTokens(t *testing.T) {
+	plain := []byte(`{"model":"claude-sonnet-4-5","messages":[{"role":"user","content":"Hello, how are you?"}]}`)
+	base := EstimateAnthropicCountTokens(plain, "claude-sonnet-4-5")
+	require.Greater(t, base, localCountRequestOverhead+localCountMessageOverhead)
+
+	withSystem := []byte(`{"model":"claude-sonnet-4-5","system":[{"type":"text","text":"You are a helpful assistant."}],"messages":[{"role":"user","content":"Hello, how are you?"}]}`)
+	require.Greater(t, EstimateAnthropicCountTokens(withSystem, "claude-sonnet-4-5"), base)
+
+	withImage := []byte(`{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"AAAA"}},{"type":"text","text":"Hello, how are you?"}]}]}`)
+	require.Equal(t, base+localCountAnthropicImage, EstimateAnthropicCountTokens(withImage, "claude-sonnet-4-5"))
+
+	// thinking 块不计入输入，tools 计入系统提示词开销
+	withThinking := []byte(`{"messages":[{"role":"user","content":[{"type":"thinking","thinking":"long hidden reasoning"},{"type":"text","text":"Hello, how are you?"}]}]}`)
+	require.Equal(t, base, EstimateAnthropicCountTokens(withThinking, "claude-sonnet-4-5"))
+
+	withTools := []byte(`{"messages":[{"role":"user","content":"Hello, how are you?"}],"tools":[{"name":"get_weather","description":"Get the weather","input_schema":{"type":"object","properties":{"city":{"type":"string"}}}}]}`)
+	require.Greater(t, EstimateAnthropicCountTokens(withTools, "claude-sonnet-4-5"), base+localCountToolSystemPrompt)
+}
+
+func TestEstimateGeminiCompatCountTokens(t *testing.T) {
+	body := []byte(`{"systemInstruction":{"parts":[{"text":"Be brief."}]},"contents":[{"role":"user","parts":[{"text":"Hello there"},{"inlineData":{"mimeType":"image/png","data":"AAAA"}}]},{"role":"model","parts":[{"text":"secret","thought":true},{"functionCall":{"name":"lookup","args":{"q":"x"}}}]}]}`)
+	n := EstimateGeminiCompatCountTokens(body, "gemini-2.5-pro")
+	require.Greater(t, n, localCountGeminiImage)
+
+	// countTokens 的 generateContentRequest 包装与裸请求结果一致
+	wrapped := []byte(`{"generateContentRequest":` + string(body) + `}`)
+	require.Equal(t, n, EstimateGeminiCompatCountTokens(wrapped, "gemini-2.5-pro"))
+
+	require.Equal(t, 0, EstimateGeminiCompatCountTokens([]byte(`{}`), "gemini-2.5-pro"))
+}
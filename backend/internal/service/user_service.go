@@ -42,6 +42,8 @@ type UserRepository interface {
 
 	UpdateBalance(ctx context.Context, id int64, amount float64) error
 	DeductBalance(ctx context.Context, id int64, amount float64) error
+	// DeductBalanceIfSufficient 仅当余额不低于 amount 时扣除，返回 false 表示余额不足
+	DeductBalanceIfSufficient(ctx context.Context, id int64, amount float64) (bool, error)
 	UpdateConcurrency(ctx context.Context, id int64, amount int) error
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	RemoveGroupFromAllowedGroups(ctx context.Context, groupID int64) (int64, error)
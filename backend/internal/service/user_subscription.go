@@ -23,6 +23,10 @@ type UserSubscription struct {
 	AssignedAt time.Time
 	Notes      string
 
+	// AutoRenew 到期前自动从余额扣费续订；AutoRenewFailedAt 为最近一次续订失败时间
+	AutoRenew         bool
+	AutoRenewFailedAt *time.Time
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
@@ -24,6 +24,13 @@ type UserSubscriptionRepository interface {
 	ExtendExpiry(ctx context.Context, subscriptionID int64, newExpiresAt time.Time) error
 	UpdateStatus(ctx context.Context, subscriptionID int64, status string) error
 	UpdateNotes(ctx context.Context, subscriptionID int64, notes string) error
+	// UpdateAutoRenew 开关自动续订，同时清除续订失败标记
+	UpdateAutoRenew(ctx context.Context, subscriptionID int64, autoRenew bool) error
+	UpdateAutoRenewFailedAt(ctx context.Context, subscriptionID int64, failedAt *time.Time) error
+	// ClaimAutoRenew 在续订事务中以 expires_at 未变化为条件锁定订阅，返回 false 表示已被其他实例续订
+	ClaimAutoRenew(ctx context.Context, subscriptionID int64, expiresAt time.Time) (bool, error)
+	// ListAutoRenewDue 列出 dueBefore 前到期且开启自动续订的活跃订阅（预加载 User/Group）
+	ListAutoRenewDue(ctx context.Context, dueBefore, retryBefore time.Time, limit int) ([]UserSubscription, error)
 
 	ActivateWindows(ctx context.Context, id int64, start time.Time) error
 	ResetDailyUsage(ctx context.Context, id int64, newWindowStart time.Time) error
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/Wei-Shaw/sub2api/internal/domain"
+	"github.com/Wei-Shaw/sub2api/internal/pkg/claude"
+	infraerrors "github.com/Wei-Shaw/sub2api/internal/pkg/errors"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultVertexRegion = "us-east5"
+	// vertexAnthropicVersion Vertex rawPredict 要求在请求体中携带的 anthropic_version
+	vertexAnthropicVersion = "vertex-2023-10-16"
+)
+
+// vertexDatedModelRe 匹配 Anthropic 标准日期后缀（claude-3-5-haiku-20241022），Vertex 使用 @ 分隔日期
+var vertexDatedModelRe = regexp.MustCompile(`^(claude-.+)-(\d{8})$`)
+
+// vertexUnsupportedBetaTokens 是 Vertex 不接受的 beta token（仅对 Anthropic 官方 OAuth 有意义）
+var vertexUnsupportedBetaTokens = map[string]bool{
+	claude.BetaOAuth: true,
+}
+
+// VertexServiceAccount Google Cloud Service Account JSON 中用于签发 JWT 的字段
+type VertexServiceAccount struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// ParseVertexServiceAccount 解析账号 credentials.service_account（JSON 字符串或对象均可）
+func ParseVertexServiceAccount(credentials map[string]any) (*VertexServiceAccount, error) {
+	raw, ok := credentials["service_account"]
+	if !ok || raw == nil {
+		return nil, fmt.Errorf("service_account not found in credentials")
+	}
+	var data []byte
+	switch v := raw.(type) {
+	case string:
+		data = []byte(strings.TrimSpace(v))
+	case map[string]any:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("encode service_account: %w", err)
+		}
+		data = encoded
+	default:
+		return nil, fmt.Errorf("service_account must be a JSON string or object")
+	}
+	var sa VertexServiceAccount
+	if err := json.Unmarshal(data, &sa); err != nil {
+		return nil, fmt.Errorf("parse service_account: %w", err)
+	}
+	if strings.TrimSpace(sa.ClientEmail) == "" || strings.TrimSpace(sa.PrivateKey) == "" {
+		return nil, fmt.Errorf("service_account requires client_email and private_key")
+	}
+	if strings.TrimSpace(sa.TokenURI) == "" {
+		sa.TokenURI = googleOAuthTokenURI
+	}
+	return &sa, nil
+}
+
+// vertexRegion 返回账号配置的 Vertex 区域（credentials.region），未配置时使用 us-east5
+func vertexRegion(account *Account) string {
+	if account == nil {
+		return defaultVertexRegion
+	}
+	if region := strings.TrimSpace(account.GetCredential("region")); region != "" {
+		return region
+	}
+	return defaultVertexRegion
+}
+
+// vertexProjectID 返回账号配置的 GCP 项目（credentials.project_id），未配置时回退到 Service Account 所属项目
+func vertexProjectID(account *Account) string {
+	if account == nil {
+		return ""
+	}
+	if projectID := strings.TrimSpace(account.GetCredential("project_id")); projectID != "" {
+		return projectID
+	}
+	account.openCredentials()
+	if sa, err := ParseVertexServiceAccount(account.Credentials); err == nil {
+		return strings.TrimSpace(sa.ProjectID)
+	}
+	return ""
+}
+
+// ResolveVertexModelID 将请求的 Claude 模型解析为 Vertex 模型 ID。
+// 先应用账号 model_mapping，再应用默认映射，最后将 -YYYYMMDD 日期后缀转换为 @YYYYMMDD。
+func ResolveVertexModelID(account *Account, requestedModel string) (string, bool) {
+	if account == nil {
+		return "", false
+	}
+	modelID := strings.TrimSpace(account.GetMappedModel(requestedModel))
+	if modelID == "" {
+		return "", false
+	}
+	if mapped, exists := domain.DefaultVertexModelMapping[modelID]; exists {
+		return mapped, true
+	}
+	if !strings.HasPrefix(strings.ToLower(modelID), "claude-") {
+		return "", false
+	}
+	if strings.Contains(modelID, "@") {
+		return modelID, true
+	}
+	if m := vertexDatedModelRe.FindStringSubmatch(modelID); m != nil {
+		return m[1] + "@" + m[2], true
+	}
+	return modelID, true
+}
+
+// BuildVertexURL 构建 Vertex Claude 的 rawPredict URL
+// stream=true 时使用 streamRawPredict 端点；region=global 时使用不带区域前缀的全局域名
+func BuildVertexURL(region, projectID, modelID string, stream bool) string {
+	if region == "" {
+		region = defaultVertexRegion
+	}
+	host := region + "-aiplatform.googleapis.com"
+	if region == "global" {
+		host = "aiplatform.googleapis.com"
+	}
+	method := "rawPredict"
+	if stream {
+		method = "streamRawPredict"
+	}
+	return fmt.Sprintf("https://%s/v1/projects/%s/locations/%s/publishers/anthropic/models/%s:%s",
+		host, url.PathEscape(projectID), url.PathEscape(region), url.PathEscape(modelID), method)
+}
+
+// PrepareVertexRequestBody 处理请求体以适配 Vertex rawPredict
+//  1. 注入 anthropic_version（vertex-2023-10-16）
+//  2. 移除 model 字段（Vertex 通过 URL 指定模型）
+//
+// stream 字段保留：streamRawPredict 仍依据请求体中的 stream 返回 SSE。
+func PrepareVertexRequestBody(body []byte) ([]byte, error) {
+	body, err := sjson.SetBytes(body, "anthropic_version", vertexAnthropicVersion)
+	if err != nil {
+		return nil, fmt.Errorf("inject anthropic_version: %w", err)
+	}
+	body, err = sjson.DeleteBytes(body, "model")
+	if err != nil {
+		return nil, fmt.Errorf("remove model field: %w", err)
+	}
+	return body, nil
+}
+
+// ResolveVertexBetaTokens 计算策略过滤前的 Vertex beta token 列表。
+// Vertex 通过 anthropic-beta HTTP 头传递 beta，这里仅去重并剔除 Vertex 不接受的 token。
+func ResolveVertexBetaTokens(betaHeader string) []string {
+	tokens := parseAnthropicBetaHeader(betaHeader)
+	seen := make(map[string]bool, len(tokens))
+	var result []string
+	for _, t := range tokens {
+		if vertexUnsupportedBetaTokens[t] || seen[t] {
+			continue
+		}
+		seen[t] = true
+		result = append(result, t)
+	}
+	return result
+}
+
+// ValidateVertexCredentials 校验 Vertex 账号凭证（管理后台创建/更新账号时调用）
+func ValidateVertexCredentials(platform string, credentials map[string]any) error {
+	if platform != PlatformAnthropic {
+		return infraerrors.BadRequest("VERTEX_PLATFORM_INVALID", "vertex account type is only supported on the anthropic platform")
+	}
+	sa, err := ParseVertexServiceAccount(credentials)
+	if err != nil {
+		return infraerrors.BadRequest("VERTEX_SERVICE_ACCOUNT_INVALID", err.Error())
+	}
+	if _, err := parseVertexPrivateKey(sa.PrivateKey); err != nil {
+		return infraerrors.BadRequest("VERTEX_SERVICE_ACCOUNT_INVALID", err.Error())
+	}
+	projectID, _ := credentials["project_id"].(string)
+	if strings.TrimSpace(projectID) == "" && strings.TrimSpace(sa.ProjectID) == "" {
+		return infraerrors.BadRequest("VERTEX_PROJECT_REQUIRED", "credentials.project_id is required when the service account has no project_id")
+	}
+	if raw, ok := credentials["region"]; ok && raw != nil {
+		if _, isString := raw.(string); !isString {
+			return infraerrors.BadRequest("VERTEX_REGION_INVALID", "credentials.region must be a string")
+		}
+	}
+	return nil
+}
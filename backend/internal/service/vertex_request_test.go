@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+)
+
+func TestResolveVertexModelID(t *testing.T) {
+	account := &Account{Platform: PlatformAnthropic, Type: AccountTypeVertex, Credentials: map[string]any{}}
+
+	cases := map[string]string{
+		"claude-sonnet-4-5":          "claude-sonnet-4-5@20250929",
+		"claude-opus-4-6":            "claude-opus-4-6",
+		"claude-3-5-haiku-20241022":  "claude-3-5-haiku@20241022",
+		"claude-sonnet-4-5@20250929": "claude-sonnet-4-5@20250929",
+	}
+	for requested, want := range cases {
+		got, ok := ResolveVertexModelID(account, requested)
+		require.True(t, ok, requested)
+		require.Equal(t, want, got, requested)
+	}
+
+	_, ok := ResolveVertexModelID(account, "gpt-4o")
+	require.False(t, ok)
+
+	account.Credentials["model_mapping"] = map[string]any{"my-alias": "claude-haiku-4-5"}
+	got, ok := ResolveVertexModelID(account, "my-alias")
+	require.True(t, ok)
+	require.Equal(t, "claude-haiku-4-5@20251001", got)
+}
+
+func TestBuildVertexURL(t *testing.T) {
+	require.Equal(t,
+		"https://us-east5-aiplatform.googleapis.com/v1/projects/my-proj/locations/us-east5/publishers/anthropic/models/claude-sonnet-4-5@20250929:rawPredict",
+		BuildVertexURL("us-east5", "my-proj", "claude-sonnet-4-5@20250929", false))
+	require.Equal(t,
+		"https://aiplatform.googleapis.com/v1/projects/my-proj/locations/global/publishers/anthropic/models/claude-opus-4-6:streamRawPredict",
+		BuildVertexURL("global", "my-proj", "claude-opus-4-6", true))
+}
+
+func TestPrepareVertexRequestBody(t *testing.T) {
+	body, err := PrepareVertexRequestBody([]byte(`{"model":"claude-sonnet-4-5","stream":true,"max_tokens":16,"messages":[]}`))
+	require.NoError(t, err)
+	require.Equal(t, vertexAnthropicVersion, gjson.GetBytes(body, "anthropic_version").String())
+	require.False(t, gjson.GetBytes(body, "model").Exists())
+	require.True(t, gjson.GetBytes(body, "stream").Bool())
+}
+
+func TestResolveVertexBetaTokens(t *testing.T) {
+	require.Equal(t,
+		[]string{"interleaved-thinking-2025-05-14", "context-1m-2025-08-07"},
+		ResolveVertexBetaTokens("interleaved-thinking-2025-05-14, oauth-2025-04-20,context-1m-2025-08-07,interleaved-thinking-2025-05-14"))
+	require.Nil(t, ResolveVertexBetaTokens(""))
+}
+
+func TestBetaPolicyScopeMatches_Vertex(t *testing.T) {
+	require.True(t, betaPolicyScopeMatches(BetaPolicyScopeVertex, false, false, true))
+	require.False(t, betaPolicyScopeMatches(BetaPolicyScopeAPIKey, false, false, true))
+	require.False(t, betaPolicyScopeMatches(BetaPolicyScopeBedrock, false, false, true))
+	require.True(t, betaPolicyScopeMatches(BetaPolicyScopeAll, false, false, true))
+}
+
+func TestValidateVertexCredentials(t *testing.T) {
+	creds := map[string]any{
+		"service_account": map[string]any{
+			"client_email": "sa@my-proj.iam.gserviceaccount.com",
+			"private_key":  "not-a-key",
+			"project_id":   "my-proj",
+		},
+	}
+	require.Error(t, ValidateVertexCredentials(PlatformAnthropic, creds))
+	require.Error(t, ValidateVertexCredentials(PlatformOpenAI, creds))
+	require.Error(t, ValidateVertexCredentials(PlatformAnthropic, map[string]any{}))
+}
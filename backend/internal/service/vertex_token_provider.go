@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	googleOAuthTokenURI   = "https://oauth2.googleapis.com/token"
+	vertexCloudScope      = "https://www.googleapis.com/auth/cloud-platform"
+	vertexJWTBearerGrant  = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	vertexAssertionTTL    = time.Hour
+	vertexTokenCacheSkew  = 5 * time.Minute
+	vertexLockWaitTime    = 200 * time.Millisecond
+	vertexTokenMaxBodyLen = 1 << 20
+)
+
+// VertexTokenCache token cache interface.
+type VertexTokenCache = GeminiTokenCache
+
+// VertexTokenProvider 使用 Service Account JWT 为 Vertex 账号换取并缓存 access_token。
+// Service Account 没有 refresh_token，过期后直接重新签发 JWT 换取新 token。
+type VertexTokenProvider struct {
+	tokenCache   VertexTokenCache
+	httpUpstream HTTPUpstream
+	now          func() time.Time
+}
+
+func NewVertexTokenProvider(tokenCache VertexTokenCache, httpUpstream HTTPUpstream) *VertexTokenProvider {
+	return &VertexTokenProvider{
+		tokenCache:   tokenCache,
+		httpUpstream: httpUpstream,
+		now:          time.Now,
+	}
+}
+
+// VertexTokenCacheKey 生成 Vertex 账号的缓存键
+// 格式: "vertex:account:{account_id}"
+func VertexTokenCacheKey(account *Account) string {
+	return "vertex:account:" + strconv.FormatInt(account.ID, 10)
+}
+
+// GetAccessToken returns a valid access_token.
+func (p *VertexTokenProvider) GetAccessToken(ctx context.Context, account *Account) (string, error) {
+	if account == nil {
+		return "", errors.New("account is nil")
+	}
+	if !account.IsVertex() {
+		return "", errors.New("not a vertex account")
+	}
+
+	cacheKey := VertexTokenCacheKey(account)
+
+	// 1) Try cache first.
+	if p.tokenCache != nil {
+		if token, err := p.tokenCache.GetAccessToken(ctx, cacheKey); err == nil && strings.TrimSpace(token) != "" {
+			slog.Debug("vertex_token_cache_hit", "account_id", account.ID)
+			return token, nil
+		} else if err != nil {
+			slog.Warn("vertex_token_cache_get_failed", "account_id", account.ID, "error", err)
+		}
+
+		// 2) Serialize token exchange across instances.
+		locked, lockErr := p.tokenCache.AcquireRefreshLock(ctx, cacheKey, 30*time.Second)
+		if lockErr == nil && locked {
+			defer func() { _ = p.tokenCache.ReleaseRefreshLock(ctx, cacheKey) }()
+		} else if lockErr != nil {
+			slog.Warn("vertex_token_lock_failed", "account_id", account.ID, "error", lockErr)
+		} else {
+			time.Sleep(vertexLockWaitTime)
+			if token, err := p.tokenCache.GetAccessToken(ctx, cacheKey); err == nil && strings.TrimSpace(token) != "" {
+				slog.Debug("vertex_token_cache_hit_after_wait", "account_id", account.ID)
+				return token, nil
+			}
+		}
+	}
+
+	slog.Debug("vertex_token_cache_miss", "account_id", account.ID)
+
+	accessToken, expiresIn, err := p.exchangeToken(ctx, account)
+	if err != nil {
+		return "", err
+	}
+
+	// 3) Populate cache with TTL.
+	if p.tokenCache != nil {
+		ttl := time.Minute
+		if expiresIn > vertexTokenCacheSkew {
+			ttl = expiresIn - vertexTokenCacheSkew
+		} else if expiresIn > 0 {
+			ttl = expiresIn
+		}
+		if err := p.tokenCache.SetAccessToken(ctx, cacheKey, accessToken, ttl); err != nil {
+			slog.Warn("vertex_token_cache_set_failed", "account_id", account.ID, "error", err)
+		}
+	}
+
+	return accessToken, nil
+}
+
+// InvalidateToken 删除缓存的 access_token（上游返回 401 时调用）
+func (p *VertexTokenProvider) InvalidateToken(ctx context.Context, account *Account) {
+	if p == nil || p.tokenCache == nil || account == nil {
+		return
+	}
+	if err := p.tokenCache.DeleteAccessToken(ctx, VertexTokenCacheKey(account)); err != nil {
+		slog.Warn("vertex_token_cache_delete_failed", "account_id", account.ID, "error", err)
+	}
+}
+
+// exchangeToken 签发 Service Account JWT 并通过 jwt-bearer 授权换取 access_token
+func (p *VertexTokenProvider) exchangeToken(ctx context.Context, account *Account) (string, time.Duration, error) {
+	account.openCredentials()
+	sa, err := ParseVertexServiceAccount(account.Credentials)
+	if err != nil {
+		return "", 0, err
+	}
+	assertion, err := signVertexAssertion(sa, p.now())
+	if err != nil {
+		return "", 0, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", vertexJWTBearerGrant)
+	form.Set("assertion", assertion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sa.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("build vertex token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	proxyURL := account.ProxyURL()
+	resp, err := p.httpUpstream.Do(req, proxyURL, account.ID, account.Concurrency)
+	if err != nil {
+		return "", 0, fmt.Errorf("vertex token request failed: %s", sanitizeUpstreamErrorMessage(err.Error()))
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, vertexTokenMaxBodyLen))
+	if err != nil {
+		return "", 0, fmt.Errorf("read vertex token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("vertex token exchange failed: status %d: %s", resp.StatusCode, truncateString(string(body), 500))
+	}
+
+	var parsed struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", 0, fmt.Errorf("parse vertex token response: %w", err)
+	}
+	if strings.TrimSpace(parsed.AccessToken) == "" {
+		return "", 0, errors.New("vertex token response missing access_token")
+	}
+	return parsed.AccessToken, time.Duration(parsed.ExpiresIn) * time.Second, nil
+}
+
+// signVertexAssertion 使用 Service Account 私钥签发 RS256 JWT
+func signVertexAssertion(sa *VertexServiceAccount, now time.Time) (string, error) {
+	key, err := parseVertexPrivateKey(sa.PrivateKey)
+	if err != nil {
+		return "", err
+	}
+	claims := jwt.MapClaims{
+		"iss":   sa.ClientEmail,
+		"sub":   sa.ClientEmail,
+		"aud":   sa.TokenURI,
+		"scope": vertexCloudScope,
+		"iat":   now.Unix(),
+		"exp":   now.Add(vertexAssertionTTL).Unix(),
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if sa.PrivateKeyID != "" {
+		token.Header["kid"] = sa.PrivateKeyID
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		return "", fmt.Errorf("sign vertex assertion: %w", err)
+	}
+	return signed, nil
+}
+
+func parseVertexPrivateKey(pem string) (*rsa.PrivateKey, error) {
+	// 兼容粘贴时换行被转义为字面量 \n 的情况
+	pem = strings.ReplaceAll(pem, `\n`, "\n")
+	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(pem))
+	if err != nil {
+		return nil, fmt.Errorf("parse service_account private_key: %w", err)
+	}
+	return key, nil
+}
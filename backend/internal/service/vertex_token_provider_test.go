@@ -0,0 +1,163 @@
+//go:build unit
+
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Wei-Shaw/sub2api/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func newVertexTestAccount(t *testing.T) (*Account, *rsa.PrivateKey) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	return &Account{
+		ID:          21,
+		Platform:    PlatformAnthropic,
+		Type:        AccountTypeVertex,
+		Concurrency: 1,
+		Credentials: map[string]any{
+			"region": "europe-west1",
+			"service_account": map[string]any{
+				"type":           "service_account",
+				"project_id":     "sa-proj",
+				"private_key_id": "kid-1",
+				"private_key":    string(keyPEM),
+				"client_email":   "sa@sa-proj.iam.gserviceaccount.com",
+				"token_uri":      "https://oauth2.example.com/token",
+			},
+		},
+	}, key
+}
+
+func TestVertexTokenProvider_ExchangesJWTAndCaches(t *testing.T) {
+	account, key := newVertexTestAccount(t)
+	upstream := &httpUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(`{"access_token":"ya29.vertex","expires_in":3599,"token_type":"Bearer"}`)),
+	}}
+	cache := newClaudeTokenCacheStub()
+	provider := NewVertexTokenProvider(cache, upstream)
+
+	token, err := provider.GetAccessToken(context.Background(), account)
+	require.NoError(t, err)
+	require.Equal(t, "ya29.vertex", token)
+	require.Equal(t, "ya29.vertex", cache.tokens[VertexTokenCacheKey(account)])
+
+	require.Equal(t, "https://oauth2.example.com/token", upstream.lastReq.URL.String())
+	form, err := url.ParseQuery(string(upstream.lastBody))
+	require.NoError(t, err)
+	require.Equal(t, vertexJWTBearerGrant, form.Get("grant_type"))
+
+	claims := jwt.MapClaims{}
+	parsed, err := jwt.ParseWithClaims(form.Get("assertion"), claims, func(*jwt.Token) (any, error) {
+		return &key.PublicKey, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, "kid-1", parsed.Header["kid"])
+	require.Equal(t, "sa@sa-proj.iam.gserviceaccount.com", claims["iss"])
+	require.Equal(t, vertexCloudScope, claims["scope"])
+	require.Equal(t, "https://oauth2.example.com/token", claims["aud"])
+
+	// 第二次直接命中缓存，不再请求上游
+	upstream.lastReq = nil
+	token, err = provider.GetAccessToken(context.Background(), account)
+	require.NoError(t, err)
+	require.Equal(t, "ya29.vertex", token)
+	require.Nil(t, upstream.lastReq)
+
+	provider.InvalidateToken(context.Background(), account)
+	require.Empty(t, cache.tokens[VertexTokenCacheKey(account)])
+}
+
+func TestVertexTokenProvider_ExchangeFailure(t *testing.T) {
+	account, _ := newVertexTestAccount(t)
+	upstream := &httpUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusBadRequest,
+		Body:       io.NopCloser(strings.NewReader(`{"error":"invalid_grant"}`)),
+	}}
+	provider := NewVertexTokenProvider(newClaudeTokenCacheStub(), upstream)
+
+	_, err := provider.GetAccessToken(context.Background(), account)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid_grant")
+}
+
+func TestVertexProjectAndRegionFromCredentials(t *testing.T) {
+	account, _ := newVertexTestAccount(t)
+	require.Equal(t, "sa-proj", vertexProjectID(account))
+	require.Equal(t, "europe-west1", vertexRegion(account))
+	require.NoError(t, ValidateVertexCredentials(PlatformAnthropic, account.Credentials))
+
+	account.Credentials["project_id"] = "billing-proj"
+	delete(account.Credentials, "region")
+	require.Equal(t, "billing-proj", vertexProjectID(account))
+	require.Equal(t, defaultVertexRegion, vertexRegion(account))
+}
+
+func TestVertexTokenProvider_RejectsNonVertexAccount(t *testing.T) {
+	provider := NewVertexTokenProvider(nil, &httpUpstreamRecorder{})
+	_, err := provider.GetAccessToken(context.Background(), &Account{Platform: PlatformAnthropic, Type: AccountTypeAPIKey})
+	require.Error(t, err)
+}
+
+func TestGatewayForwardVertex_NonStreamingParsesUsage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	account, _ := newVertexTestAccount(t)
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	body := []byte(`{"model":"claude-sonnet-4-5","max_tokens":16,"messages":[{"role":"user","content":"hi"}]}`)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(string(body)))
+	c.Request.Header.Set("anthropic-beta", "oauth-2025-04-20,context-1m-2025-08-07")
+
+	upstream := &httpUpstreamRecorder{resp: &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body: io.NopCloser(strings.NewReader(
+			`{"id":"msg_1","type":"message","model":"claude-sonnet-4-5-20250929","content":[{"type":"text","text":"hello"}],"usage":{"input_tokens":7,"output_tokens":3,"cache_read_input_tokens":2}}`,
+		)),
+	}}
+	cache := newClaudeTokenCacheStub()
+	cache.tokens[VertexTokenCacheKey(account)] = "ya29.cached"
+	svc := &GatewayService{
+		cfg:                 &config.Config{},
+		httpUpstream:        upstream,
+		vertexTokenProvider: NewVertexTokenProvider(cache, upstream),
+	}
+
+	result, err := svc.forwardVertex(context.Background(), c, account, &ParsedRequest{Body: body, Model: "claude-sonnet-4-5"}, time.Now())
+	require.NoError(t, err)
+	require.Equal(t,
+		"https://europe-west1-aiplatform.googleapis.com/v1/projects/sa-proj/locations/europe-west1/publishers/anthropic/models/claude-sonnet-4-5@20250929:rawPredict",
+		upstream.lastReq.URL.String())
+	require.Equal(t, "Bearer ya29.cached", upstream.lastReq.Header.Get("Authorization"))
+	require.Equal(t, "context-1m-2025-08-07", upstream.lastReq.Header.Get("anthropic-beta"))
+	require.Contains(t, string(upstream.lastBody), `"anthropic_version":"vertex-2023-10-16"`)
+	require.NotContains(t, string(upstream.lastBody), `"model"`)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "claude-sonnet-4-5", result.Model)
+	require.Equal(t, "claude-sonnet-4-5@20250929", result.UpstreamModel)
+	require.Equal(t, 7, result.Usage.InputTokens)
+	require.Equal(t, 3, result.Usage.OutputTokens)
+	require.Equal(t, 2, result.Usage.CacheReadInputTokens)
+}
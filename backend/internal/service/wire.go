@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"time"
 
+	dbent "github.com/Wei-Shaw/sub2api/ent"
 	"github.com/Wei-Shaw/sub2api/internal/config"
 	"github.com/Wei-Shaw/sub2api/internal/pkg/logger"
 	"github.com/google/wire"
@@ -142,9 +143,84 @@ func ProvideAccountExpiryService(accountRepo AccountRepository) *AccountExpirySe
 	return svc
 }
 
+// ProvideMessageBatchService creates and starts MessageBatchService.
+func ProvideMessageBatchService(
+	repo MessageBatchRepository,
+	gatewayService *GatewayService,
+	concurrencyService *ConcurrencyService,
+	billingCacheService *BillingCacheService,
+	subscriptionService *SubscriptionService,
+	apiKeyService *APIKeyService,
+	guardrailService *GuardrailService,
+	cfg *config.Config,
+) *MessageBatchService {
+	svc := NewMessageBatchService(repo, gatewayService, concurrencyService, billingCacheService, subscriptionService, apiKeyService, guardrailService, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideOpenAIBatchService creates and starts OpenAIBatchService.
+func ProvideOpenAIBatchService(
+	fileRepo OpenAIFileRepository,
+	batchRepo OpenAIBatchRepository,
+	gatewayService *OpenAIGatewayService,
+	concurrencyService *ConcurrencyService,
+	billingCacheService *BillingCacheService,
+	apiKeyService *APIKeyService,
+	guardrailService *GuardrailService,
+	backupService *BackupService,
+	cfg *config.Config,
+) *OpenAIBatchService {
+	batchCfg := cfg.Gateway.OpenAIBatches
+	storage := NewOpenAIFileStorage(batchCfg.StorageBackend, batchCfg.StorageDir, batchCfg.S3Prefix, backupService)
+	svc := NewOpenAIBatchService(fileRepo, batchRepo, storage, gatewayService, concurrencyService, billingCacheService, apiKeyService, guardrailService, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvidePayloadCaptureService creates and starts PayloadCaptureService.
+func ProvidePayloadCaptureService(repo PayloadCaptureRepository, cfg *config.Config) *PayloadCaptureService {
+	svc := NewPayloadCaptureService(repo, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideGuardrailService creates and starts GuardrailService.
+func ProvideGuardrailService(repo GuardrailRepository, groupRepo GroupRepository, cfg *config.Config) *GuardrailService {
+	svc := NewGuardrailService(repo, groupRepo, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideCredentialEncryptionService creates and starts CredentialEncryptionService
+// (background migration of plain-text / old-key credentials when enabled).
+func ProvideCredentialEncryptionService(
+	repo CredentialEncryptionRepository,
+	cipher CredentialCipher,
+	schedulerCache SchedulerCache,
+	cfg *config.Config,
+) *CredentialEncryptionService {
+	svc := NewCredentialEncryptionService(repo, cipher, schedulerCache, cfg)
+	svc.Start()
+	return svc
+}
+
+// ProvideProxyPoolService creates and starts ProxyPoolService (pool snapshot refresh + health probing).
+func ProvideProxyPoolService(
+	repo ProxyPoolRepository,
+	proxyRepo ProxyRepository,
+	prober ProxyExitInfoProber,
+	latencyCache ProxyLatencyCache,
+	cfg *config.Config,
+) *ProxyPoolService {
+	svc := NewProxyPoolService(repo, proxyRepo, prober, latencyCache, cfg)
+	svc.Start()
+	return svc
+}
+
 // ProvideSubscriptionExpiryService creates and starts SubscriptionExpiryService.
-func ProvideSubscriptionExpiryService(userSubRepo UserSubscriptionRepository) *SubscriptionExpiryService {
-	svc := NewSubscriptionExpiryService(userSubRepo, time.Minute)
+func ProvideSubscriptionExpiryService(userSubRepo UserSubscriptionRepository, purchaseSvc *SubscriptionPurchaseService) *SubscriptionExpiryService {
+	svc := NewSubscriptionExpiryService(userSubRepo, purchaseSvc, time.Minute)
 	svc.Start()
 	return svc
 }
@@ -193,9 +269,10 @@ func ProvideSchedulerSnapshotService(
 	outboxRepo SchedulerOutboxRepository,
 	accountRepo AccountRepository,
 	groupRepo GroupRepository,
+	credentialCipher CredentialCipher,
 	cfg *config.Config,
 ) *SchedulerSnapshotService {
-	svc := NewSchedulerSnapshotService(cache, outboxRepo, accountRepo, groupRepo, cfg)
+	svc := NewSchedulerSnapshotService(cache, outboxRepo, accountRepo, groupRepo, credentialCipher, cfg)
 	svc.Start()
 	return svc
 }
@@ -210,11 +287,20 @@ func ProvideRateLimitService(
 	timeoutCounterCache TimeoutCounterCache,
 	settingService *SettingService,
 	tokenCacheInvalidator TokenCacheInvalidator,
+	notificationService *NotificationService,
 ) *RateLimitService {
 	svc := NewRateLimitService(accountRepo, usageRepo, cfg, geminiQuotaService, tempUnschedCache)
 	svc.SetTimeoutCounterCache(timeoutCounterCache)
 	svc.SetSettingService(settingService)
 	svc.SetTokenCacheInvalidator(tokenCacheInvalidator)
+	svc.SetAccountEventNotifier(notificationService)
+	return svc
+}
+
+// ProvideNotificationService creates NotificationService and starts its delivery worker.
+func ProvideNotificationService(repo NotificationChannelRepository, cfg *config.Config) *NotificationService {
+	svc := NewNotificationService(repo, cfg)
+	svc.Start()
 	return svc
 }
 
@@ -251,10 +337,12 @@ func ProvideOpsAlertEvaluatorService(
 	opsService *OpsService,
 	opsRepo OpsRepository,
 	emailService *EmailService,
+	notificationService *NotificationService,
 	redisClient *redis.Client,
 	cfg *config.Config,
 ) *OpsAlertEvaluatorService {
 	svc := NewOpsAlertEvaluatorService(opsService, opsRepo, emailService, redisClient, cfg)
+	svc.SetNotificationService(notificationService)
 	svc.Start()
 	return svc
 }
@@ -343,10 +431,12 @@ func ProvideOpsScheduledReportService(
 	opsService *OpsService,
 	userService *UserService,
 	emailService *EmailService,
+	notificationService *NotificationService,
 	redisClient *redis.Client,
 	cfg *config.Config,
 ) *OpsScheduledReportService {
 	svc := NewOpsScheduledReportService(opsService, userService, emailService, redisClient, cfg)
+	svc.SetNotificationService(notificationService)
 	svc.Start()
 	return svc
 }
@@ -365,8 +455,10 @@ func ProvideBackupService(
 	encryptor SecretEncryptor,
 	storeFactory BackupObjectStoreFactory,
 	dumper DBDumper,
+	credentialEncryption *CredentialEncryptionService,
 ) *BackupService {
 	svc := NewBackupService(settingRepo, cfg, encryptor, storeFactory, dumper)
+	svc.SetCredentialEncryption(credentialEncryption)
 	svc.Start()
 	return svc
 }
@@ -378,16 +470,53 @@ func ProvideSettingService(settingRepo SettingRepository, groupRepo GroupReposit
 	return svc
 }
 
+// ProvideAuthService creates AuthService with optional referral binding on registration
+func ProvideAuthService(
+	entClient *dbent.Client,
+	userRepo UserRepository,
+	redeemRepo RedeemCodeRepository,
+	refreshTokenCache RefreshTokenCache,
+	cfg *config.Config,
+	settingService *SettingService,
+	emailService *EmailService,
+	turnstileService *TurnstileService,
+	emailQueueService *EmailQueueService,
+	promoService *PromoService,
+	defaultSubAssigner DefaultSubscriptionAssigner,
+	referralService *ReferralService,
+) *AuthService {
+	svc := NewAuthService(entClient, userRepo, redeemRepo, refreshTokenCache, cfg, settingService, emailService, turnstileService, emailQueueService, promoService, defaultSubAssigner)
+	svc.SetReferralService(referralService)
+	return svc
+}
+
+// ProvideReferralSettlementService creates and starts ReferralSettlementService.
+func ProvideReferralSettlementService(
+	repo ReferralRepository,
+	userRepo UserRepository,
+	billingCacheService *BillingCacheService,
+	authCacheInvalidator APIKeyAuthCacheInvalidator,
+	entClient *dbent.Client,
+	cfg *config.Config,
+) *ReferralSettlementService {
+	svc := NewReferralSettlementService(repo, userRepo, billingCacheService, authCacheInvalidator, entClient, cfg)
+	svc.Start()
+	return svc
+}
+
 // ProviderSet is the Wire provider set for all services
 var ProviderSet = wire.NewSet(
 	// Core services
-	NewAuthService,
+	ProvideAuthService,
 	NewUserService,
 	NewAPIKeyService,
 	ProvideAPIKeyAuthCacheInvalidator,
 	NewGroupService,
 	NewAccountService,
 	NewProxyService,
+	ProvideProxyPoolService,
+	ProvideCredentialEncryptionService,
+	wire.Bind(new(ProxyPoolResolver), new(*ProxyPoolService)),
 	NewRedeemService,
 	NewPromoService,
 	NewUsageService,
@@ -412,6 +541,7 @@ var ProviderSet = wire.NewSet(
 	ProvideAntigravityTokenProvider,
 	ProvideOpenAITokenProvider,
 	ProvideClaudeTokenProvider,
+	NewVertexTokenProvider,
 	NewAntigravityGatewayService,
 	ProvideRateLimitService,
 	NewAccountUsageService,
@@ -434,6 +564,7 @@ var ProviderSet = wire.NewSet(
 	ProvideConcurrencyService,
 	ProvideUserMessageQueueService,
 	NewUsageRecordWorkerPool,
+	NewMetricsService,
 	ProvideSchedulerSnapshotService,
 	NewIdentityService,
 	NewCRSSyncService,
@@ -459,5 +590,18 @@ var ProviderSet = wire.NewSet(
 	ProvideScheduledTestRunnerService,
 	NewGroupCapacityService,
 	NewChannelService,
+	ProvideNotificationService,
+	NewOIDCService,
+	NewPaymentService,
+	NewSubscriptionPurchaseService,
+	NewReferralService,
+	ProvideReferralSettlementService,
+	NewAdminAuditService,
+	NewAdminTokenService,
+	ProvideMessageBatchService,
+	ProvideOpenAIBatchService,
+	NewResponseCacheService,
+	ProvidePayloadCaptureService,
+	ProvideGuardrailService,
 	NewModelPricingResolver,
 )
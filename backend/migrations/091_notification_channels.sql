@@ -0,0 +1,24 @@
+-- Notification channels: outbound webhook / Slack / Feishu / DingTalk receivers
+-- for ops alerts, scheduled reports and account state change events.
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+-- 通知渠道表
+CREATE TABLE IF NOT EXISTS notification_channels (
+    id          BIGSERIAL    PRIMARY KEY,
+    name        VARCHAR(100) NOT NULL,
+    type        VARCHAR(20)  NOT NULL DEFAULT 'webhook',
+    enabled     BOOLEAN      NOT NULL DEFAULT true,
+    webhook_url TEXT         NOT NULL,
+    secret      TEXT         NOT NULL DEFAULT '',
+    headers     JSONB        NOT NULL DEFAULT '{}',
+    event_types JSONB        NOT NULL DEFAULT '[]',
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_channels_name ON notification_channels (name);
+
+-- 告警规则可投递到任意通知渠道
+ALTER TABLE ops_alert_rules ADD COLUMN IF NOT EXISTS notify_channel_ids BIGINT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,10 @@
+-- Add model restriction fields to api_keys table
+-- allowed_models: JSON array of model patterns (if set, only matching models can be requested)
+-- denied_models: JSON array of model patterns (always rejected, takes precedence over allowed_models)
+-- Patterns support the * wildcard anywhere, e.g. "claude-sonnet-*", "gpt-*-mini".
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS allowed_models JSONB DEFAULT NULL;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS denied_models JSONB DEFAULT NULL;
+
+COMMENT ON COLUMN api_keys.allowed_models IS 'JSON array of allowed model patterns, e.g. ["claude-sonnet-*", "gpt-*-mini"]';
+COMMENT ON COLUMN api_keys.denied_models IS 'JSON array of denied model patterns, e.g. ["*-opus-*"]';
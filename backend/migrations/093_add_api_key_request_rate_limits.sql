@@ -0,0 +1,10 @@
+-- Add per-key request rate limits to api_keys table
+-- rpm_limit: requests per minute (sliding 60s window, 0 = unlimited)
+-- tpm_limit: tokens per minute (sliding 60s window, 0 = unlimited)
+-- Counters live in Redis; only the limits are persisted.
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS rpm_limit INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tpm_limit INTEGER NOT NULL DEFAULT 0;
+
+COMMENT ON COLUMN api_keys.rpm_limit IS 'Requests per minute limit (0 = unlimited)';
+COMMENT ON COLUMN api_keys.tpm_limit IS 'Tokens per minute limit (0 = unlimited)';
@@ -0,0 +1,38 @@
+-- OIDC / generic OAuth2 single sign-on providers.
+-- Endpoints are resolved from the issuer discovery document unless set explicitly;
+-- group_mappings grant exclusive groups and default subscriptions by email domain or claim.
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+-- SSO 提供方表
+CREATE TABLE IF NOT EXISTS oidc_providers (
+    id                    BIGSERIAL    PRIMARY KEY,
+    slug                  VARCHAR(32)  NOT NULL,
+    name                  VARCHAR(100) NOT NULL,
+    enabled               BOOLEAN      NOT NULL DEFAULT false,
+    issuer_url            TEXT         NOT NULL DEFAULT '',
+    authorize_url         TEXT         NOT NULL DEFAULT '',
+    token_url             TEXT         NOT NULL DEFAULT '',
+    userinfo_url          TEXT         NOT NULL DEFAULT '',
+    jwks_url              TEXT         NOT NULL DEFAULT '',
+    client_id             TEXT         NOT NULL,
+    client_secret         TEXT         NOT NULL DEFAULT '',
+    scopes                TEXT         NOT NULL DEFAULT '',
+    token_auth_method     VARCHAR(32)  NOT NULL DEFAULT 'client_secret_post',
+    use_pkce              BOOLEAN      NOT NULL DEFAULT true,
+    audiences             JSONB        NOT NULL DEFAULT '[]',
+    redirect_url          TEXT         NOT NULL,
+    frontend_redirect_url TEXT         NOT NULL DEFAULT '/auth/oidc/callback',
+    allowed_email_domains JSONB        NOT NULL DEFAULT '[]',
+    trust_email           BOOLEAN      NOT NULL DEFAULT false,
+    username_claim        VARCHAR(255) NOT NULL DEFAULT '',
+    groups_claim          VARCHAR(255) NOT NULL DEFAULT '',
+    default_group_ids     JSONB        NOT NULL DEFAULT '[]',
+    group_mappings        JSONB        NOT NULL DEFAULT '[]',
+    sort_order            INT          NOT NULL DEFAULT 0,
+    created_at            TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_oidc_providers_slug ON oidc_providers (slug);
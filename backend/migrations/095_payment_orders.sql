@@ -0,0 +1,34 @@
+-- Self-service top-up orders.
+-- An order buys either balance (credit_amount in USD) or a subscription group (group_id + validity_days);
+-- the conditional status transition to completed is the crediting guard; expired is derived from expires_at.
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+-- 支付订单表
+CREATE TABLE IF NOT EXISTS payment_orders (
+    id                BIGSERIAL      PRIMARY KEY,
+    order_no          VARCHAR(64)    NOT NULL,
+    user_id           BIGINT         NOT NULL,
+    provider          VARCHAR(32)    NOT NULL,
+    pay_type          VARCHAR(32)    NOT NULL DEFAULT '',
+    kind              VARCHAR(20)    NOT NULL,
+    amount            DECIMAL(20,2)  NOT NULL,
+    currency          VARCHAR(10)    NOT NULL,
+    credit_amount     DECIMAL(20,8)  NOT NULL DEFAULT 0,
+    group_id          BIGINT,
+    validity_days     INT            NOT NULL DEFAULT 0,
+    subject           VARCHAR(255)   NOT NULL DEFAULT '',
+    status            VARCHAR(20)    NOT NULL DEFAULT 'pending',
+    provider_trade_no VARCHAR(255)   NOT NULL DEFAULT '',
+    pay_url           TEXT           NOT NULL DEFAULT '',
+    client_ip         VARCHAR(64)    NOT NULL DEFAULT '',
+    paid_at           TIMESTAMPTZ,
+    expires_at        TIMESTAMPTZ    NOT NULL,
+    created_at        TIMESTAMPTZ    NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_orders_order_no ON payment_orders (order_no);
+CREATE INDEX IF NOT EXISTS idx_payment_orders_user_created ON payment_orders (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_payment_orders_status_created ON payment_orders (status, created_at DESC);
@@ -0,0 +1,44 @@
+-- Append-only audit trail of mutating admin API calls.
+-- Rows are never updated (enforced by trigger); old rows are removed only by the ops cleanup retention job.
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+-- 管理后台审计日志表
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    id             BIGSERIAL     PRIMARY KEY,
+    actor_user_id  BIGINT        NOT NULL DEFAULT 0,
+    auth_method    VARCHAR(20)   NOT NULL DEFAULT '',
+    method         VARCHAR(10)   NOT NULL,
+    route          VARCHAR(255)  NOT NULL,
+    path           TEXT          NOT NULL,
+    resource_type  VARCHAR(64)   NOT NULL DEFAULT '',
+    resource_id    VARCHAR(128)  NOT NULL DEFAULT '',
+    target_ids     JSONB,
+    request_body   JSONB,
+    changes        JSONB,
+    status_code    INT           NOT NULL DEFAULT 0,
+    success        BOOLEAN       NOT NULL DEFAULT FALSE,
+    error_reason   VARCHAR(128)  NOT NULL DEFAULT '',
+    error_message  TEXT          NOT NULL DEFAULT '',
+    client_ip      VARCHAR(64)   NOT NULL DEFAULT '',
+    user_agent     TEXT          NOT NULL DEFAULT '',
+    duration_ms    BIGINT        NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_created_at ON admin_audit_logs (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_actor_created ON admin_audit_logs (actor_user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_logs_resource ON admin_audit_logs (resource_type, resource_id, created_at DESC);
+
+-- 审计记录只允许追加：禁止 UPDATE（DELETE 保留给数据保留策略清理）
+CREATE OR REPLACE FUNCTION admin_audit_logs_prevent_update() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'admin_audit_logs is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_admin_audit_logs_prevent_update ON admin_audit_logs;
+CREATE TRIGGER trg_admin_audit_logs_prevent_update
+    BEFORE UPDATE ON admin_audit_logs
+    FOR EACH ROW EXECUTE FUNCTION admin_audit_logs_prevent_update();
@@ -0,0 +1,27 @@
+-- Named admin tokens with scopes, replacing the single shared admin API key for integrations.
+-- Only the SHA-256 hash of a token is stored; revocation is a soft delete so audit rows keep their reference.
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+-- 管理员令牌表
+CREATE TABLE IF NOT EXISTS admin_tokens (
+    id            BIGSERIAL     PRIMARY KEY,
+    name          VARCHAR(100)  NOT NULL,
+    token_hash    VARCHAR(64)   NOT NULL,
+    token_prefix  VARCHAR(32)   NOT NULL DEFAULT '',
+    scopes        JSONB         NOT NULL DEFAULT '[]'::jsonb,
+    created_by    BIGINT        NOT NULL,
+    expires_at    TIMESTAMPTZ,
+    last_used_at  TIMESTAMPTZ,
+    last_used_ip  VARCHAR(64)   NOT NULL DEFAULT '',
+    revoked_at    TIMESTAMPTZ,
+    created_at    TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_admin_tokens_token_hash ON admin_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_admin_tokens_created_at ON admin_tokens (created_at DESC);
+
+-- 审计日志记录操作所用的令牌
+ALTER TABLE admin_audit_logs ADD COLUMN IF NOT EXISTS actor_token_id BIGINT NOT NULL DEFAULT 0;
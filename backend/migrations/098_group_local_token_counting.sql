@@ -0,0 +1,3 @@
+-- 分组级本地 token 计数开关：开启后 count_tokens / countTokens 由网关本地估算
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS local_token_counting BOOLEAN NOT NULL DEFAULT false;
+COMMENT ON COLUMN groups.local_token_counting IS 'count_tokens / countTokens 使用本地分词器估算，不请求上游';
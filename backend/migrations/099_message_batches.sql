@@ -0,0 +1,37 @@
+-- Anthropic Message Batches (/v1/messages/batches).
+-- passthrough 模式映射到上游批次；local 模式由网关本地执行器逐条转发。
+-- 请求与结果以 JSONL 文件存放在 gateway.message_batches.storage_dir 下，表内只保存元数据。
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+-- 消息批处理表
+CREATE TABLE IF NOT EXISTS message_batches (
+    id                   VARCHAR(64)   PRIMARY KEY,
+    user_id              BIGINT        NOT NULL,
+    api_key_id           BIGINT        NOT NULL,
+    group_id             BIGINT,
+    account_id           BIGINT        NOT NULL DEFAULT 0,
+    mode                 VARCHAR(16)   NOT NULL,
+    upstream_batch_id    VARCHAR(128)  NOT NULL DEFAULT '',
+    processing_status    VARCHAR(16)   NOT NULL DEFAULT 'in_progress',
+    request_count        INT           NOT NULL DEFAULT 0,
+    processing_count     INT           NOT NULL DEFAULT 0,
+    succeeded_count      INT           NOT NULL DEFAULT 0,
+    errored_count        INT           NOT NULL DEFAULT 0,
+    canceled_count       INT           NOT NULL DEFAULT 0,
+    expired_count        INT           NOT NULL DEFAULT 0,
+    results_ready        BOOLEAN       NOT NULL DEFAULT FALSE,
+    results_billed       BOOLEAN       NOT NULL DEFAULT FALSE,
+    created_at           TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at           TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    expires_at           TIMESTAMPTZ   NOT NULL,
+    ended_at             TIMESTAMPTZ,
+    cancel_initiated_at  TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_batches_api_key_created ON message_batches (api_key_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_message_batches_status ON message_batches (processing_status, mode);
+
+COMMENT ON COLUMN message_batches.mode IS 'passthrough: 上游批处理接口; local: 网关本地执行器';
+COMMENT ON COLUMN message_batches.results_billed IS 'passthrough 批次结果是否已逐条计入 usage_logs';
@@ -0,0 +1,63 @@
+-- OpenAI Files (/v1/files) 与 Batch API (/v1/batches)，供 OpenAI 分组使用。
+-- 文件内容存放在本地磁盘或数据备份的 S3 存储中（gateway.openai_batches.storage_backend），表内只保存元数据。
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+-- 分组级 Batch API 折扣倍率（NULL 表示使用配置默认值）
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS batch_discount_multiplier DECIMAL(10,4);
+COMMENT ON COLUMN groups.batch_discount_multiplier IS 'Batch API 请求在费率倍数之上额外叠加的折扣倍率，NULL 表示使用默认值';
+
+-- 文件表
+CREATE TABLE IF NOT EXISTS openai_files (
+    id            VARCHAR(64)   PRIMARY KEY,
+    user_id       BIGINT        NOT NULL,
+    api_key_id    BIGINT        NOT NULL,
+    purpose       VARCHAR(32)   NOT NULL,
+    filename      VARCHAR(255)  NOT NULL DEFAULT '',
+    bytes         BIGINT        NOT NULL DEFAULT 0,
+    storage_key   TEXT          NOT NULL,
+    created_at    TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    expires_at    TIMESTAMPTZ   NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_openai_files_api_key_created ON openai_files (api_key_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_openai_files_expires_at ON openai_files (expires_at);
+
+COMMENT ON COLUMN openai_files.purpose IS 'batch: 批处理输入文件; batch_output: 批处理输出/错误文件';
+COMMENT ON COLUMN openai_files.storage_key IS '存储后端中的对象 key';
+
+-- 批处理表
+CREATE TABLE IF NOT EXISTS openai_batches (
+    id                  VARCHAR(64)   PRIMARY KEY,
+    user_id             BIGINT        NOT NULL,
+    api_key_id          BIGINT        NOT NULL,
+    group_id            BIGINT,
+    endpoint            VARCHAR(64)   NOT NULL,
+    input_file_id       VARCHAR(64)   NOT NULL,
+    completion_window   VARCHAR(16)   NOT NULL DEFAULT '24h',
+    status              VARCHAR(20)   NOT NULL DEFAULT 'validating',
+    output_file_id      VARCHAR(64),
+    error_file_id       VARCHAR(64),
+    errors              JSONB,
+    metadata            JSONB,
+    total_count         INT           NOT NULL DEFAULT 0,
+    completed_count     INT           NOT NULL DEFAULT 0,
+    failed_count        INT           NOT NULL DEFAULT 0,
+    created_at          TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    expires_at          TIMESTAMPTZ   NOT NULL,
+    in_progress_at      TIMESTAMPTZ,
+    finalizing_at       TIMESTAMPTZ,
+    completed_at        TIMESTAMPTZ,
+    failed_at           TIMESTAMPTZ,
+    expired_at          TIMESTAMPTZ,
+    cancelling_at       TIMESTAMPTZ,
+    cancelled_at        TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_openai_batches_api_key_created ON openai_batches (api_key_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_openai_batches_status_updated ON openai_batches (status, updated_at);
+
+COMMENT ON COLUMN openai_batches.errors IS '输入文件校验失败时的错误列表';
+COMMENT ON COLUMN openai_batches.status IS 'validating / in_progress / finalizing / completed / failed / expired / cancelling / cancelled';
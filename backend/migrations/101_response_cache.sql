@@ -0,0 +1,5 @@
+-- 分组级响应缓存开关：temperature=0 的确定性请求按规范化请求哈希命中 Redis 缓存时直接回放，不请求上游
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS response_cache_enabled BOOLEAN NOT NULL DEFAULT false;
+COMMENT ON COLUMN groups.response_cache_enabled IS '是否对确定性请求（temperature=0）启用精确匹配响应缓存';
+
+COMMENT ON COLUMN usage_logs.billing_mode IS 'token / per_request / image / response_cache';
@@ -0,0 +1,61 @@
+-- Opt-in gateway request/response payload capture for debugging.
+-- Rules enable capture per group or per API key; captured rows are removed by the ops cleanup retention job.
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+-- 载荷抓取规则表（API Key 规则优先于分组规则）
+CREATE TABLE IF NOT EXISTS payload_capture_rules (
+    id              BIGSERIAL     PRIMARY KEY,
+    scope_type      VARCHAR(16)   NOT NULL,
+    scope_id        BIGINT        NOT NULL,
+    enabled         BOOLEAN       NOT NULL DEFAULT TRUE,
+    sample_rate     DOUBLE PRECISION NOT NULL DEFAULT 1,
+    max_body_bytes  INT           NOT NULL DEFAULT 0,
+    redact_fields   JSONB         NOT NULL DEFAULT '[]'::jsonb,
+    note            TEXT          NOT NULL DEFAULT '',
+    expires_at      TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    CONSTRAINT payload_capture_rules_scope_unique UNIQUE (scope_type, scope_id)
+);
+
+COMMENT ON COLUMN payload_capture_rules.scope_type IS 'group / api_key';
+COMMENT ON COLUMN payload_capture_rules.sample_rate IS '抽样比例 (0, 1]';
+COMMENT ON COLUMN payload_capture_rules.max_body_bytes IS '单个载荷记录上限（字节），0 表示使用 payload_capture.max_body_bytes';
+COMMENT ON COLUMN payload_capture_rules.redact_fields IS '在 logredact 默认敏感字段之外额外脱敏的 JSON 字段名';
+
+-- 载荷抓取记录表
+CREATE TABLE IF NOT EXISTS ops_payload_captures (
+    id                  BIGSERIAL     PRIMARY KEY,
+    rule_id             BIGINT        NOT NULL DEFAULT 0,
+    request_id          VARCHAR(128)  NOT NULL DEFAULT '',
+    client_request_id   VARCHAR(64)   NOT NULL DEFAULT '',
+    user_id             BIGINT        NOT NULL DEFAULT 0,
+    api_key_id          BIGINT        NOT NULL DEFAULT 0,
+    group_id            BIGINT,
+    account_id          BIGINT,
+    platform            VARCHAR(32)   NOT NULL DEFAULT '',
+    model               VARCHAR(128)  NOT NULL DEFAULT '',
+    method              VARCHAR(10)   NOT NULL DEFAULT '',
+    path                VARCHAR(255)  NOT NULL DEFAULT '',
+    stream              BOOLEAN       NOT NULL DEFAULT FALSE,
+    status_code         INT           NOT NULL DEFAULT 0,
+    duration_ms         BIGINT        NOT NULL DEFAULT 0,
+    request_body        TEXT          NOT NULL DEFAULT '',
+    response_body       TEXT          NOT NULL DEFAULT '',
+    request_bytes       INT           NOT NULL DEFAULT 0,
+    response_bytes      INT           NOT NULL DEFAULT 0,
+    request_truncated   BOOLEAN       NOT NULL DEFAULT FALSE,
+    response_truncated  BOOLEAN       NOT NULL DEFAULT FALSE,
+    created_at          TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ops_payload_captures_created_at ON ops_payload_captures (created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ops_payload_captures_request_id ON ops_payload_captures (request_id);
+CREATE INDEX IF NOT EXISTS idx_ops_payload_captures_client_request_id ON ops_payload_captures (client_request_id);
+CREATE INDEX IF NOT EXISTS idx_ops_payload_captures_api_key_created ON ops_payload_captures (api_key_id, created_at DESC);
+
+COMMENT ON COLUMN ops_payload_captures.response_body IS '流式响应保存重组后的最终消息，而非原始 SSE';
+COMMENT ON COLUMN ops_payload_captures.request_bytes IS '脱敏截断前的原始请求体大小';
+COMMENT ON COLUMN ops_payload_captures.response_bytes IS '脱敏截断前的原始响应体大小（流式为原始 SSE 大小）';
@@ -0,0 +1,33 @@
+-- Per-group guardrail rules inspected on gateway requests before forwarding upstream.
+-- Detectors: regex / keyword / preset (built-in PII patterns) / webhook (external inspector).
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+CREATE TABLE IF NOT EXISTS guardrail_rules (
+    id                  BIGSERIAL     PRIMARY KEY,
+    group_id            BIGINT        NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+    name                VARCHAR(100)  NOT NULL,
+    detector            VARCHAR(16)   NOT NULL,
+    patterns            JSONB         NOT NULL DEFAULT '[]'::jsonb,
+    case_sensitive      BOOLEAN       NOT NULL DEFAULT FALSE,
+    action              VARCHAR(16)   NOT NULL,
+    replacement         VARCHAR(64)   NOT NULL DEFAULT '',
+    webhook_url         TEXT          NOT NULL DEFAULT '',
+    webhook_timeout_ms  INT           NOT NULL DEFAULT 0,
+    fail_open           BOOLEAN       NOT NULL DEFAULT TRUE,
+    priority            INT           NOT NULL DEFAULT 0,
+    enabled             BOOLEAN       NOT NULL DEFAULT TRUE,
+    created_at          TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at          TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_guardrail_rules_group_id ON guardrail_rules (group_id);
+
+COMMENT ON COLUMN guardrail_rules.detector IS 'regex / keyword / preset / webhook';
+COMMENT ON COLUMN guardrail_rules.patterns IS 'regex: 正则表达式列表; keyword: 关键词列表; preset: 内置检测器名称（email / phone / cn_id_card / credit_card / api_secret）';
+COMMENT ON COLUMN guardrail_rules.action IS 'block: 拒绝请求; mask: 替换命中内容后转发; log: 仅记录';
+COMMENT ON COLUMN guardrail_rules.replacement IS 'mask 替换文本，空表示 [REDACTED]';
+COMMENT ON COLUMN guardrail_rules.webhook_timeout_ms IS '0 表示使用 guardrail.webhook_timeout_ms';
+COMMENT ON COLUMN guardrail_rules.fail_open IS 'webhook 不可用时是否放行（false 时拒绝请求）';
+COMMENT ON COLUMN guardrail_rules.priority IS '数值越小越先执行';
@@ -0,0 +1,24 @@
+-- Proxy pools: named sets of proxies with a selection strategy.
+-- Accounts bind a pool via accounts.extra.proxy_pool_id (takes precedence over proxy_id);
+-- outbound requests pick a pool member per strategy and fail over to the next member on
+-- connection errors, never falling back to a direct connection.
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+CREATE TABLE IF NOT EXISTS proxy_pools (
+    id           BIGSERIAL     PRIMARY KEY,
+    name         VARCHAR(100)  NOT NULL,
+    description  TEXT          NOT NULL DEFAULT '',
+    strategy     VARCHAR(20)   NOT NULL DEFAULT 'sticky',
+    proxy_ids    JSONB         NOT NULL DEFAULT '[]'::jsonb,
+    status       VARCHAR(20)   NOT NULL DEFAULT 'active',
+    created_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_proxy_pools_name ON proxy_pools (name);
+
+COMMENT ON COLUMN proxy_pools.strategy IS 'sticky: 按账号固定出口（失效时迁移到下一个）; round_robin: 轮询; lowest_latency: 最低延迟优先';
+COMMENT ON COLUMN proxy_pools.proxy_ids IS '池内代理 ID 列表（proxies.id），已删除或非 active 的代理在运行时忽略';
+COMMENT ON COLUMN proxy_pools.status IS 'active / disabled；disabled 的池拒绝所有绑定账号的出站请求（不回退直连）';
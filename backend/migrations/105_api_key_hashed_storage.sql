@@ -0,0 +1,18 @@
+-- API Key 改为哈希存储：
+-- key_hash   = HMAC-SHA256(api_key_hash.secret, 完整 Key) 的十六进制，用于认证查找
+-- key_prefix = Key 的前几位，仅用于列表展示与识别
+-- 旧版明文 key 列改为可空；回填需要 HMAC 密钥，无法在 SQL 中完成，
+-- 由服务启动时批量计算 key_hash/key_prefix 并将 key 置空。
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS key_hash VARCHAR(64);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS key_prefix VARCHAR(32) NOT NULL DEFAULT '';
+ALTER TABLE api_keys ALTER COLUMN key DROP NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_key_hash_key ON api_keys(key_hash);
+
+-- 明文 key 清空后不再需要模糊搜索索引，改为按前缀搜索
+DROP INDEX IF EXISTS idx_api_keys_key_trgm;
+
+COMMENT ON COLUMN api_keys.key IS 'Legacy plaintext key, cleared after hashing migration';
+COMMENT ON COLUMN api_keys.key_hash IS 'HMAC-SHA256 of the full key (hex)';
+COMMENT ON COLUMN api_keys.key_prefix IS 'Leading characters of the key shown for identification';
@@ -0,0 +1,37 @@
+-- 余额购买订阅与自动续订：
+-- groups.subscription_price 非空的订阅分组出现在用户套餐目录中，有效天数取 default_validity_days；
+-- user_subscriptions.auto_renew 开启后由订阅到期任务在到期前扣费续订；
+-- subscription_purchases 记录每一次购买/续订扣费（包括失败的自动续订）。
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS subscription_price DECIMAL(20,8);
+COMMENT ON COLUMN groups.subscription_price IS '用户使用余额购买/续订该订阅分组的价格（USD），NULL 表示不开放购买';
+
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS auto_renew BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE user_subscriptions ADD COLUMN IF NOT EXISTS auto_renew_failed_at TIMESTAMPTZ;
+COMMENT ON COLUMN user_subscriptions.auto_renew IS '到期前是否自动从余额扣费续订';
+COMMENT ON COLUMN user_subscriptions.auto_renew_failed_at IS '最近一次自动续订失败时间，续订成功后清空';
+
+CREATE INDEX IF NOT EXISTS idx_user_subscriptions_auto_renew_due
+    ON user_subscriptions (expires_at)
+    WHERE auto_renew = true AND deleted_at IS NULL;
+
+-- 订阅购买记录表
+CREATE TABLE IF NOT EXISTS subscription_purchases (
+    id               BIGSERIAL      PRIMARY KEY,
+    user_id          BIGINT         NOT NULL,
+    group_id         BIGINT         NOT NULL,
+    subscription_id  BIGINT,
+    kind             VARCHAR(20)    NOT NULL,
+    status           VARCHAR(20)    NOT NULL,
+    amount           DECIMAL(20,8)  NOT NULL DEFAULT 0,
+    validity_days    INT            NOT NULL DEFAULT 0,
+    expires_at       TIMESTAMPTZ,
+    failure_reason   TEXT           NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_purchases_user_created ON subscription_purchases (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_subscription_purchases_created ON subscription_purchases (created_at DESC);
@@ -0,0 +1,50 @@
+-- 邀请返佣：
+-- referral_codes 每个用户一个邀请码（首次访问时生成）；
+-- referrals 持久化邀请人→被邀请人关系，settled_until 为结算水位，结算任务按
+-- usage_logs.actual_cost 计算 (settled_until, 本轮截止时间] 区间内的返佣并推进水位；
+-- referral_commissions 记录每一次入账的返佣明细。
+
+SET LOCAL lock_timeout = '5s';
+SET LOCAL statement_timeout = '10min';
+
+CREATE TABLE IF NOT EXISTS referral_codes (
+    user_id     BIGINT       PRIMARY KEY,
+    code        VARCHAR(32)  NOT NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_referral_codes_code ON referral_codes (code);
+
+CREATE TABLE IF NOT EXISTS referrals (
+    id                BIGSERIAL      PRIMARY KEY,
+    inviter_id        BIGINT         NOT NULL,
+    invitee_id        BIGINT         NOT NULL,
+    settled_until     TIMESTAMPTZ    NOT NULL,
+    total_cost        DECIMAL(20,10) NOT NULL DEFAULT 0,
+    total_commission  DECIMAL(20,8)  NOT NULL DEFAULT 0,
+    created_at        TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON COLUMN referrals.settled_until IS '返佣结算水位：该时间之前的被邀请人消费已结算';
+COMMENT ON COLUMN referrals.total_cost IS '已结算的被邀请人累计消费（actual_cost）';
+COMMENT ON COLUMN referrals.total_commission IS '该被邀请人为邀请人带来的累计返佣';
+
+-- 每个用户只能被邀请一次
+CREATE UNIQUE INDEX IF NOT EXISTS idx_referrals_invitee ON referrals (invitee_id);
+CREATE INDEX IF NOT EXISTS idx_referrals_inviter_created ON referrals (inviter_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS referral_commissions (
+    id            BIGSERIAL      PRIMARY KEY,
+    referral_id   BIGINT         NOT NULL,
+    inviter_id    BIGINT         NOT NULL,
+    invitee_id    BIGINT         NOT NULL,
+    usage_cost    DECIMAL(20,10) NOT NULL,
+    rate          DECIMAL(10,6)  NOT NULL,
+    amount        DECIMAL(20,8)  NOT NULL,
+    period_start  TIMESTAMPTZ    NOT NULL,
+    period_end    TIMESTAMPTZ    NOT NULL,
+    created_at    TIMESTAMPTZ    NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_referral_commissions_inviter_created ON referral_commissions (inviter_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_referral_commissions_created ON referral_commissions (created_at DESC);
@@ -557,6 +557,7 @@ export default {
     copyToClipboard: 'Copy to clipboard',
     copied: 'Copied!',
     importToCcSwitch: 'Import to CCS',
+    fullKeyUnavailable: 'The full key is only shown once when it is created. Create a new key if you no longer have it.',
     enable: 'Enable',
     disable: 'Disable',
     nameLabel: 'Name',
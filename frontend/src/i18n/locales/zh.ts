@@ -557,6 +557,7 @@ export default {
     copyToClipboard: '复制到剪贴板',
     copied: '已复制！',
     importToCcSwitch: '导入到 CCS',
+    fullKeyUnavailable: '完整密钥仅在创建时显示一次，如已遗失请重新创建密钥。',
     enable: '启用',
     disable: '禁用',
     nameLabel: '名称',
@@ -422,7 +422,8 @@ export interface AdminGroup extends Group {
 export interface ApiKey {
   id: number
   user_id: number
-  key: string
+  key?: string // Full key, only returned once in the create response
+  key_prefix: string // Display prefix of the key (the full key is stored hashed)
   name: string
   group_id: number | null
   status: 'active' | 'inactive' | 'quota_exhausted' | 'expired'